@@ -763,11 +763,12 @@ export default function ChatWindow({
               });
             }
           }
-        } else if (data.type === "message_deleted" && data.message_id) {
+        } else if (data.type === "message_deleted" && data.payload) {
           // Handle deletion - update both state and cache
+          const { message_id } = data.payload;
           const updateDelete = (prev: Message[]) =>
             prev.map((m) =>
-              m.id === data.message_id ? { ...m, content: "[Message deleted]" } : m
+              m.id === message_id ? { ...m, content: "[Message deleted]" } : m
             );
           setMessages(updateDelete);
           setThreadReplies(updateDelete);
@@ -851,7 +852,7 @@ export default function ChatWindow({
     // 4. Fetch fresh messages (will update cache)
     try {
       const data = await api.getChannelMessages(channel.id);
-      const newMessages = data.messages || [];
+      const newMessages = data.items || [];
       setCachedMessages(channel.id, newMessages);
       // Only update if still on this channel
       if (currentChannelRef.current?.id === channel.id) {
@@ -886,7 +887,7 @@ export default function ChatWindow({
     setThreadLoading(true);
     try {
       const data = await api.getThreadReplies(msg.id);
-      setThreadReplies(data.replies || []);
+      setThreadReplies(data.items || []);
     } catch (err) {
       console.error("Failed to load thread:", err);
     } finally {
@@ -34,7 +34,7 @@ function NotificationBell() {
       if (!prev) {
         setLoading(true);
         api.getNotifications(1, 15).then((data) => {
-          setNotifications(data);
+          setNotifications(data.items);
           setLoading(false);
         }).catch(() => setLoading(false));
       }
@@ -869,7 +869,7 @@ export default function GitHubPanel({ loopName, onShareToChat, onClose }: GitHub
     setError("");
     try {
       const data = await api.getGitHubIssues(loopName, state);
-      setIssues(data.issues || []);
+      setIssues(data.items || []);
       setRepoName(data.repo_name);
       setLoaded((prev) => ({ ...prev, issues: true }));
     } catch (err) {
@@ -884,7 +884,7 @@ export default function GitHubPanel({ loopName, onShareToChat, onClose }: GitHub
     setError("");
     try {
       const data = await api.getGitHubPRs(loopName, state);
-      setPRs(data.pull_requests || []);
+      setPRs(data.items || []);
       setRepoName(data.repo_name);
       setLoaded((prev) => ({ ...prev, prs: true }));
     } catch (err) {
@@ -73,6 +73,12 @@ export function getToken(): string | null {
   return localStorage.getItem("wireloop_token");
 }
 
+// WebSocket subprotocols carrying the auth token, so it stays out of the URL
+// (and out of proxy logs); the server answers with "wireloop"
+export function wsProtocols(token: string): string[] {
+  return ["wireloop", `bearer.${token}`];
+}
+
 // Set auth token
 export function setToken(token: string): void {
   localStorage.setItem("wireloop_token", token);
@@ -114,7 +120,7 @@ async function apiRequest<T>(
     const error = await response
       .json()
       .catch(() => ({ error: "Request failed" }));
-    throw new Error(error.error || "Request failed");
+    throw new Error(error.detail || error.error || "Request failed");
   }
 
   return response.json();
@@ -187,12 +193,29 @@ export interface Message {
   sender_username: string;
   sender_avatar: string;
   created_at: string;
+  created_at_ms?: number; // Epoch millis, same instant as created_at
   channel_id?: string;    // Channel this message belongs to
   parent_id?: string;     // For thread replies
   reply_count?: number;   // Number of replies
   is_pinned?: boolean;    // Whether message is pinned
   pinned_at?: string;     // When it was pinned
   pinned_by_username?: string; // Who pinned it
+  long_post?: { chars: number; size: number }; // content is a preview; see getLongPost
+  thread_summary?: ThreadSummary; // AI summary of a long thread
+}
+
+export interface ThreadSummary {
+  summary: string;
+  replies: number; // replies the summary covers
+  updated_at: string;
+}
+
+// Envelope of paginated lists; pass next_cursor back as ?cursor= for the
+// next page
+export interface Page<T> {
+  items: T[];
+  next_cursor: string | null;
+  has_more: boolean;
 }
 
 // Notification types
@@ -206,6 +229,7 @@ export interface Notification {
   content_preview?: string;
   is_read: boolean;
   created_at: string;
+  created_at_ms?: number; // Epoch millis, same instant as created_at
 }
 
 // Member search result (for @mention autocomplete)
@@ -257,11 +281,11 @@ export function createWebSocket(projectId: string, channelId?: string): WebSocke
   const token = getToken();
   if (!token) return null;
 
-  let url = `${WS_URL}/api/ws?project_id=${projectId}&token=${token}`;
+  let url = `${WS_URL}/api/ws?project_id=${projectId}`;
   if (channelId) {
     url += `&channel_id=${channelId}`;
   }
-  const ws = new WebSocket(url);
+  const ws = new WebSocket(url, wsProtocols(token));
   return ws;
 }
 
@@ -273,6 +297,11 @@ export interface BrowseLoop {
   owner_avatar: string;
   member_count: number;
   created_at: string;
+  // Repo stats, absent until the repo has been counted
+  stars?: number;
+  forks?: number;
+  contributors?: number;
+  pushed_at?: string;
 }
 
 // Verification types
@@ -339,6 +368,27 @@ export interface GitHubSummary {
   generated_at: string;
 }
 
+// A GitHub operation the command palette can offer in a loop
+export interface GitHubAction {
+  id: string;
+  title: string;
+  description: string;
+  method: string;
+  path: string;
+  available: boolean;
+  reason?: "role" | "permission" | "scope" | "mirrored";
+  scope?: string;
+  reauth_url?: string;
+}
+
+export interface GitHubActionsCatalog {
+  repo: string;
+  role: string;
+  permission: string;
+  scopes: string[];
+  actions: GitHubAction[];
+}
+
 // PR Review Comments — unified type for all GitHub comment types
 export interface PRReviewComment {
   id: number;
@@ -350,6 +400,7 @@ export interface PRReviewComment {
   state?: string; // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED
   in_reply_to_id?: number;
   created_at: string;
+  created_at_ms?: number; // Epoch millis, same instant as created_at
   html_url: string;
   username: string;
   avatar_url: string;
@@ -513,7 +564,7 @@ export const api = {
       const error = await response
         .json()
         .catch(() => ({ error: "Upload failed" }));
-      throw new Error(error.error || "Upload failed");
+      throw new Error(error.detail || error.error || "Upload failed");
     }
 
     return response.json();
@@ -575,7 +626,7 @@ export const api = {
 
   // Messages (uses loop name, not ID)
   getMessages: (loopName: string, limit = 50, offset = 0) =>
-    apiRequest<{ messages: Message[] }>(
+    apiRequest<Page<Message>>(
       `/api/loops/${encodeURIComponent(loopName)}/messages?limit=${limit}&offset=${offset}`
     ),
 
@@ -609,16 +660,37 @@ export const api = {
     }),
 
   getChannelMessages: (channelId: string, limit = 50, offset = 0) =>
-    apiRequest<{ messages: Message[] }>(
+    apiRequest<Page<Message>>(
       `/api/channels/${channelId}/messages?limit=${limit}&offset=${offset}`
     ),
 
   // Thread / Replies
   getThreadReplies: (messageId: string, limit = 50, offset = 0) =>
-    apiRequest<{ replies: Message[]; parent_id: string }>(
+    apiRequest<Page<Message> & { parent_id: string; thread_summary?: ThreadSummary }>(
       `/api/messages/${messageId}/replies?limit=${limit}&offset=${offset}`
     ),
 
+  // Write or update the AI summary of a long thread
+  summarizeThread: (messageId: string) =>
+    apiRequest<ThreadSummary>(`/api/messages/${messageId}/summary`, {
+      method: "POST",
+    }),
+
+  // Full markdown of a long post (the message content is only a preview)
+  getLongPost: async (messageId: string): Promise<string> => {
+    const token = getToken();
+    const response = await fetch(`${API_URL}/api/messages/${messageId}/long-post`, {
+      headers: token ? { Authorization: `Bearer ${token}` } : {},
+    });
+    if (!response.ok) {
+      const error = await response
+        .json()
+        .catch(() => ({ error: "Request failed" }));
+      throw new Error(error.detail || error.error || "Request failed");
+    }
+    return response.text();
+  },
+
   // Delete message (soft delete)
   deleteMessage: (messageId: string) =>
     apiRequest<{ message: string; id: string }>(`/api/messages/${messageId}`, {
@@ -644,12 +716,12 @@ export const api = {
 
   // GitHub Context + AI Summarization
   getGitHubIssues: (loopName: string, state = "open") =>
-    apiRequest<{ issues: GitHubIssueItem[]; repo_name: string }>(
+    apiRequest<Page<GitHubIssueItem> & { repo_name: string }>(
       `/api/loops/${encodeURIComponent(loopName)}/github/issues?state=${state}`
     ),
 
   getGitHubPRs: (loopName: string, state = "open") =>
-    apiRequest<{ pull_requests: GitHubPRItem[]; repo_name: string }>(
+    apiRequest<Page<GitHubPRItem> & { repo_name: string }>(
       `/api/loops/${encodeURIComponent(loopName)}/github/pulls?state=${state}`
     ),
 
@@ -662,6 +734,11 @@ export const api = {
       }
     ),
 
+  getGitHubActionsCatalog: (loopName: string) =>
+    apiRequest<GitHubActionsCatalog>(
+      `/api/loops/${encodeURIComponent(loopName)}/github/actions-catalog`
+    ),
+
   // PR Review Sync (two-way GitHub ↔ Wireloop)
   getPRComments: (loopName: string, prNumber: number) =>
     apiRequest<{ comments: PRReviewComment[]; pr_number: number; repo_name: string }>(
@@ -701,7 +778,7 @@ export const api = {
   // NOTIFICATIONS
   // ============================================================================
   getNotifications: (page = 1, perPage = 20) =>
-    apiRequest<Notification[]>(
+    apiRequest<Page<Notification>>(
       `/api/notifications?page=${page}&per_page=${perPage}`
     ),
 
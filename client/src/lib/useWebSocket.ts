@@ -1,5 +1,5 @@
 import { useEffect, useRef, useCallback, useState } from "react";
-import { getToken } from "./api";
+import { getToken, wsProtocols } from "./api";
 
 const WS_URL = (process.env.NEXT_PUBLIC_API_URL || "http://localhost:8080").replace(
   /^http/,
@@ -103,12 +103,12 @@ export function useWebSocket({
 
     updateStatus(reconnectAttemptRef.current > 0 ? "reconnecting" : "connecting");
 
-    let url = `${WS_URL}/api/ws?project_id=${projectId}&token=${token}`;
+    let url = `${WS_URL}/api/ws?project_id=${projectId}`;
     if (channelId) {
       url += `&channel_id=${channelId}`;
     }
 
-    const ws = new WebSocket(url);
+    const ws = new WebSocket(url, wsProtocols(token));
 
     ws.onopen = () => {
       if (!mountedRef.current) {
@@ -144,8 +144,9 @@ export function useWebSocket({
 
       wsRef.current = null;
 
-      // Don't reconnect on clean close (1000) or auth failure (4001, 4003)
-      if (event.code === 1000 || event.code === 4001 || event.code === 4003) {
+      // Don't reconnect on clean close (1000), auth failure (4001, 4003) or
+      // being replaced by a newer connection over the per-user limit (4008)
+      if (event.code === 1000 || event.code === 4001 || event.code === 4003 || event.code === 4008) {
         updateStatus("disconnected");
         return;
       }
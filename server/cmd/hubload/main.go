@@ -0,0 +1,225 @@
+// Command hubload puts the chat Hub under load to compare Hub changes with
+// numbers rather than impressions.
+//
+// It starts an in-process WebSocket endpoint backed by a chat.Hub (with
+// Redis pub/sub when -redis is given), connects simulated clients spread
+// over rooms, has each send messages at a fixed rate, and reports fan-out
+// latency percentiles, how many deliveries never arrived and the Hub's own
+// metrics. No database or auth is involved; the endpoint joins a socket to
+// the room in its query string and broadcasts whatever it sends.
+//
+//	go run ./cmd/hubload -clients 2000 -rooms 50 -rate 0.5 -duration 1m
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wireloop/internal/chat"
+
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+)
+
+type loadMessage struct {
+	Type   string `json:"type"`
+	Room   string `json:"room"`
+	SentNs int64  `json:"sent_ns"`
+}
+
+type results struct {
+	mu        sync.Mutex
+	latencies []time.Duration
+	sent      atomic.Int64
+	expected  atomic.Int64 // deliveries the sends should have produced
+	received  atomic.Int64
+	dialErrs  atomic.Int64
+}
+
+func (r *results) observe(d time.Duration) {
+	r.received.Add(1)
+	r.mu.Lock()
+	r.latencies = append(r.latencies, d)
+	r.mu.Unlock()
+}
+
+func main() {
+	clients := flag.Int("clients", 500, "simulated WebSocket clients")
+	rooms := flag.Int("rooms", 10, "rooms the clients are spread over")
+	rate := flag.Float64("rate", 1, "messages per second sent by each client")
+	duration := flag.Duration("duration", 30*time.Second, "how long clients send")
+	drain := flag.Duration("drain", 3*time.Second, "wait after sending stops for deliveries to arrive")
+	redisURL := flag.String("redis", "", "Redis URL to run the Hub with pub/sub, as with REDIS_URL")
+	flag.Parse()
+	if *clients < 1 || *rooms < 1 || *rate <= 0 {
+		fmt.Fprintln(os.Stderr, "clients, rooms and rate must be positive")
+		os.Exit(2)
+	}
+
+	var rdb *redis.Client
+	if *redisURL != "" {
+		opts, err := redis.ParseURL(*redisURL)
+		if err != nil {
+			log.Fatalf("invalid -redis: %v", err)
+		}
+		rdb = redis.NewClient(opts)
+		if err := rdb.Ping(context.Background()).Err(); err != nil {
+			log.Fatalf("redis: %v", err)
+		}
+	}
+	hub := chat.NewHub(rdb)
+	addr := serve(hub)
+
+	// Members per room, for the deliveries each send should produce
+	members := make([]int, *rooms)
+	for i := range *clients {
+		members[i%*rooms]++
+	}
+
+	res := &results{}
+	var conns []*websocket.Conn
+	var joined []int // room index per connection
+	var readers, senders sync.WaitGroup
+	dialer := websocket.Dialer{HandshakeTimeout: 10 * time.Second}
+	start := time.Now()
+	for i := range *clients {
+		room := "room-" + strconv.Itoa(i%*rooms)
+		conn, _, err := dialer.Dial("ws://"+addr+"/ws?room="+room, nil)
+		if err != nil {
+			res.dialErrs.Add(1)
+			members[i%*rooms]--
+			continue
+		}
+		conns = append(conns, conn)
+		joined = append(joined, i%*rooms)
+		readers.Add(1)
+		go read(conn, res, &readers)
+	}
+	log.Printf("connected %d clients in %s (%d failed)", len(conns), time.Since(start).Round(time.Millisecond), res.dialErrs.Load())
+	// Every socket has joined before anyone sends, so each send reaches the
+	// whole room
+	for hub.Metrics().Clients < len(conns) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *duration)
+	defer cancel()
+	for i, conn := range conns {
+		r := joined[i]
+		senders.Add(1)
+		go send(ctx, conn, "room-"+strconv.Itoa(r), *rate, members[r], res, &senders)
+	}
+	senders.Wait()
+	time.Sleep(*drain)
+	for _, c := range conns {
+		c.Close()
+	}
+	readers.Wait()
+	report(res, hub.Metrics(), *duration)
+}
+
+// serve runs the test endpoint on a free local port
+func serve(hub *chat.Hub) string {
+	upgrader := websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
+		room := r.URL.Query().Get("room")
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		client := chat.NewClient(conn, pgtype.UUID{}, "", "")
+		hub.Join(room, client)
+		go client.Write()
+		defer func() {
+			hub.Leave(room, client)
+			client.Close()
+		}()
+		for {
+			var msg map[string]any
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			hub.BroadcastFrom(room, msg, time.Now())
+		}
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	go http.Serve(ln, mux)
+	return ln.Addr().String()
+}
+
+// send writes a message every 1/rate seconds until ctx ends, starting at a
+// random offset so clients don't send in lockstep
+func send(ctx context.Context, conn *websocket.Conn, room string, rate float64, roomSize int, res *results, wg *sync.WaitGroup) {
+	defer wg.Done()
+	interval := time.Duration(float64(time.Second) / rate)
+	select {
+	case <-time.After(rand.N(interval)):
+	case <-ctx.Done():
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		msg := loadMessage{Type: "load", Room: room, SentNs: time.Now().UnixNano()}
+		// Writes from one goroutine only; reads happen in read
+		if err := conn.WriteJSON(msg); err != nil {
+			return
+		}
+		res.sent.Add(1)
+		res.expected.Add(int64(roomSize))
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func read(conn *websocket.Conn, res *results, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg loadMessage
+		if json.Unmarshal(data, &msg) == nil && msg.Type == "load" {
+			res.observe(time.Since(time.Unix(0, msg.SentNs)))
+		}
+	}
+}
+
+func report(res *results, m chat.Metrics, duration time.Duration) {
+	sort.Slice(res.latencies, func(i, j int) bool { return res.latencies[i] < res.latencies[j] })
+	pct := func(p float64) time.Duration {
+		if len(res.latencies) == 0 {
+			return 0
+		}
+		return res.latencies[min(int(p*float64(len(res.latencies))), len(res.latencies)-1)].Round(time.Microsecond)
+	}
+	// With -redis a surplus means the Hub also delivered its own publishes
+	expected, received := res.expected.Load(), res.received.Load()
+	fmt.Printf("\nsent        %d messages (%.0f/s)\n", res.sent.Load(), float64(res.sent.Load())/duration.Seconds())
+	fmt.Printf("deliveries  %d of %d expected, %d missing (%.3f%%)\n",
+		received, expected, expected-received, 100*float64(expected-received)/float64(max(expected, 1)))
+	fmt.Printf("latency     p50 %s  p90 %s  p99 %s  max %s\n", pct(0.50), pct(0.90), pct(0.99), pct(1))
+	fmt.Printf("hub         %d broadcasts, avg fan-out %.1f, %d dropped sends, fan-out latency avg %.2fms\n",
+		m.Broadcasts, m.AvgFanout, m.DroppedSends, m.FanoutLatencyAvgMs)
+}
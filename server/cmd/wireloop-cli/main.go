@@ -0,0 +1,446 @@
+// Command wireloop-cli talks to a Wireloop server from the terminal.
+//
+// It authenticates with a personal access token (create one under
+// Settings → Access tokens, or POST /api/tokens) and can list your loops,
+// tail a channel live, send messages and ask for GitHub summaries.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const defaultAPIURL = "http://localhost:8080"
+
+const usage = `Usage: wireloop-cli <command> [arguments]
+
+Commands:
+  login --token <wlp_...> [--url <api url>]   save a personal access token
+  logout                                      forget the saved token
+  loops                                       list the loops you belong to
+  channels <loop>                             list a loop's channels
+  tail <loop> [channel]                       stream new messages (default channel if omitted)
+  send <loop> <channel> <message...>          post a message ("-" reads it from stdin)
+  summarize <loop> issue|pr <number>          summarize a GitHub issue or pull request
+
+The token and API URL are saved to ~/.config/wireloop/config.json.
+WIRELOOP_TOKEN and WIRELOOP_URL override the saved values.
+`
+
+type config struct {
+	APIURL string `json:"api_url"`
+	Token  string `json:"token"`
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "wireloop", "config.json"), nil
+}
+
+func loadConfig() config {
+	cfg := config{APIURL: defaultAPIURL}
+	if path, err := configPath(); err == nil {
+		if b, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(b, &cfg)
+		}
+	}
+	if v := os.Getenv("WIRELOOP_URL"); v != "" {
+		cfg.APIURL = v
+	}
+	if v := os.Getenv("WIRELOOP_TOKEN"); v != "" {
+		cfg.Token = v
+	}
+	cfg.APIURL = strings.TrimRight(cfg.APIURL, "/")
+	return cfg
+}
+
+func saveConfig(cfg config) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, _ := json.MarshalIndent(cfg, "", "  ")
+	return os.WriteFile(path, b, 0o600)
+}
+
+// client is a minimal JSON client for the Wireloop API
+type client struct {
+	cfg  config
+	http *http.Client
+}
+
+func (c *client) do(method, path string, body, out any) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, c.cfg.APIURL+path, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.cfg.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Detail    string `json:"detail"`
+			RequestID string `json:"request_id"`
+		}
+		_ = json.Unmarshal(data, &e)
+		msg := e.Detail
+		if msg == "" {
+			msg = resp.Status
+		}
+		if e.RequestID != "" {
+			msg += " (request " + e.RequestID + ")"
+		}
+		return fmt.Errorf("%s %s: %s", method, path, msg)
+	}
+	if out != nil {
+		return json.Unmarshal(data, out)
+	}
+	return nil
+}
+
+type channel struct {
+	ID        string `json:"id"`
+	ProjectID string `json:"project_id"`
+	Name      string `json:"name"`
+	IsDefault bool   `json:"is_default"`
+}
+
+type message struct {
+	ID             string  `json:"id"`
+	Content        string  `json:"content"`
+	SenderUsername string  `json:"sender_username"`
+	CreatedAt      string  `json:"created_at"`
+	ChannelID      string  `json:"channel_id"`
+	ParentID       *string `json:"parent_id"`
+}
+
+func (c *client) channels(loop string) ([]channel, error) {
+	var resp struct {
+		Channels []channel `json:"channels"`
+	}
+	err := c.do("GET", "/api/loops/"+url.PathEscape(loop)+"/channels", nil, &resp)
+	return resp.Channels, err
+}
+
+// findChannel resolves a channel by name (with or without #); an empty
+// name picks the loop's default channel
+func (c *client) findChannel(loop, name string) (channel, error) {
+	chans, err := c.channels(loop)
+	if err != nil {
+		return channel{}, err
+	}
+	name = strings.TrimPrefix(name, "#")
+	for _, ch := range chans {
+		if (name == "" && ch.IsDefault) || (name != "" && strings.EqualFold(ch.Name, name)) {
+			return ch, nil
+		}
+	}
+	if name == "" && len(chans) > 0 {
+		return chans[0], nil
+	}
+	return channel{}, fmt.Errorf("no channel %q in %s", name, loop)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cfg := loadConfig()
+	cmd, args := os.Args[1], os.Args[2:]
+
+	var err error
+	switch cmd {
+	case "login":
+		err = runLogin(cfg, args)
+	case "logout":
+		cfg.Token = ""
+		err = saveConfig(cfg)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		if cfg.Token == "" {
+			err = errors.New("not logged in; run `wireloop-cli login --token <token>`")
+			break
+		}
+		c := &client{cfg: cfg, http: &http.Client{Timeout: 60 * time.Second}}
+		err = run(c, cmd, args)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "wireloop-cli:", err)
+		os.Exit(1)
+	}
+}
+
+func run(c *client, cmd string, args []string) error {
+	switch {
+	case cmd == "loops" && len(args) == 0:
+		return runLoops(c)
+	case cmd == "channels" && len(args) == 1:
+		return runChannels(c, args[0])
+	case cmd == "tail" && (len(args) == 1 || len(args) == 2):
+		name := ""
+		if len(args) == 2 {
+			name = args[1]
+		}
+		return runTail(c, args[0], name)
+	case cmd == "send" && len(args) >= 3:
+		return runSend(c, args[0], args[1], strings.Join(args[2:], " "))
+	case cmd == "summarize" && len(args) == 3:
+		n, err := strconv.Atoi(args[2])
+		if err != nil || (args[1] != "issue" && args[1] != "pr") {
+			return errors.New("usage: summarize <loop> issue|pr <number>")
+		}
+		return runSummarize(c, args[0], args[1], n)
+	}
+	fmt.Fprint(os.Stderr, usage)
+	os.Exit(2)
+	return nil
+}
+
+func runLogin(cfg config, args []string) error {
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--token" && i+1 < len(args):
+			i++
+			cfg.Token = args[i]
+		case args[i] == "--url" && i+1 < len(args):
+			i++
+			cfg.APIURL = strings.TrimRight(args[i], "/")
+		default:
+			return fmt.Errorf("unknown argument %q", args[i])
+		}
+	}
+	if cfg.Token == "" {
+		return errors.New("--token is required")
+	}
+
+	c := &client{cfg: cfg, http: &http.Client{Timeout: 15 * time.Second}}
+	var me struct {
+		Username string `json:"username"`
+	}
+	if err := c.do("GET", "/api/profile", nil, &me); err != nil {
+		return err
+	}
+	if err := saveConfig(cfg); err != nil {
+		return err
+	}
+	fmt.Printf("Logged in as %s\n", me.Username)
+	return nil
+}
+
+func runLoops(c *client) error {
+	var resp struct {
+		Memberships []struct {
+			LoopName   string `json:"loop_name"`
+			Role       string `json:"role"`
+			IsFavorite bool   `json:"is_favorite"`
+		} `json:"memberships"`
+	}
+	if err := c.do("GET", "/api/my-memberships", nil, &resp); err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "LOOP\tROLE\t")
+	for _, m := range resp.Memberships {
+		star := ""
+		if m.IsFavorite {
+			star = "★"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", m.LoopName, m.Role, star)
+	}
+	return w.Flush()
+}
+
+func runChannels(c *client, loop string) error {
+	chans, err := c.channels(loop)
+	if err != nil {
+		return err
+	}
+	for _, ch := range chans {
+		def := ""
+		if ch.IsDefault {
+			def = " (default)"
+		}
+		fmt.Printf("#%s%s\n", ch.Name, def)
+	}
+	return nil
+}
+
+func printMessage(m message) {
+	ts := m.CreatedAt
+	if t, err := time.Parse(time.RFC3339, m.CreatedAt); err == nil {
+		ts = t.Local().Format("15:04")
+	}
+	thread := ""
+	if m.ParentID != nil {
+		thread = "↳ "
+	}
+	fmt.Printf("[%s] %s%s: %s\n", ts, thread, m.SenderUsername, m.Content)
+}
+
+// runTail follows a channel over the WebSocket until interrupted,
+// reconnecting with backoff when the connection drops
+func runTail(c *client, loop, name string) error {
+	ch, err := c.findChannel(loop, name)
+	if err != nil {
+		return err
+	}
+	u, err := url.Parse(c.cfg.APIURL + "/api/ws")
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	default:
+		u.Scheme = "ws"
+	}
+	u.RawQuery = url.Values{"project_id": {ch.ProjectID}, "channel_id": {ch.ID}}.Encode()
+	header := http.Header{"Authorization": {"Bearer " + c.cfg.Token}}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	fmt.Fprintf(os.Stderr, "Tailing %s #%s (Ctrl-C to stop)\n", loop, ch.Name)
+	backoff := time.Second
+	for {
+		conn, resp, err := websocket.DefaultDialer.Dial(u.String(), header)
+		if err != nil {
+			if resp != nil && (resp.StatusCode == 401 || resp.StatusCode == 403) {
+				return fmt.Errorf("connect: %s", resp.Status)
+			}
+			fmt.Fprintf(os.Stderr, "connect failed: %v; retrying in %s\n", err, backoff)
+		} else {
+			backoff = time.Second
+			done := make(chan error, 1)
+			go func() { done <- readMessages(conn, ch.ID) }()
+			select {
+			case <-interrupt:
+				_ = conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+				conn.Close()
+				return nil
+			case err := <-done:
+				conn.Close()
+				fmt.Fprintf(os.Stderr, "disconnected: %v; reconnecting\n", err)
+			}
+		}
+		select {
+		case <-interrupt:
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, 30*time.Second)
+	}
+}
+
+func readMessages(conn *websocket.Conn, channelID string) error {
+	for {
+		var out struct {
+			Type      string          `json:"type"`
+			Payload   json.RawMessage `json:"payload"`
+			ChannelID string          `json:"channel_id"`
+		}
+		if err := conn.ReadJSON(&out); err != nil {
+			return err
+		}
+		if out.Type != "message" {
+			continue
+		}
+		var m message
+		if err := json.Unmarshal(out.Payload, &m); err != nil {
+			continue
+		}
+		if m.ChannelID != "" && m.ChannelID != channelID {
+			continue
+		}
+		printMessage(m)
+	}
+}
+
+func runSend(c *client, loop, name, text string) error {
+	if text == "-" {
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return err
+		}
+		text = string(b)
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return errors.New("message is empty")
+	}
+	ch, err := c.findChannel(loop, name)
+	if err != nil {
+		return err
+	}
+	var m message
+	err = c.do("POST", "/api/loop/message", map[string]string{
+		"channel_id":   ch.ID,
+		"message_body": text,
+	}, &m)
+	if err != nil {
+		return err
+	}
+	if m.ID != "" {
+		fmt.Fprintf(os.Stderr, "sent %s\n", m.ID)
+	}
+	return nil
+}
+
+func runSummarize(c *client, loop, kind string, number int) error {
+	var resp struct {
+		Summary string `json:"summary"`
+		Title   string `json:"title"`
+		URL     string `json:"url"`
+	}
+	err := c.do("POST", "/api/loops/"+url.PathEscape(loop)+"/github/summarize", map[string]any{
+		"type":   kind,
+		"number": number,
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s\n%s\n\n%s\n", resp.Title, resp.URL, resp.Summary)
+	return nil
+}
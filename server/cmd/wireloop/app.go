@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+
+	"wireloop/internal/api"
+	"wireloop/internal/auth"
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/errreport"
+	"wireloop/internal/flags"
+	"wireloop/internal/github"
+	"wireloop/internal/jobs"
+	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
+	"wireloop/internal/quota"
+	"wireloop/internal/scan"
+	"wireloop/internal/secrets"
+	"wireloop/internal/storage"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// newHandler connects to the database and Redis and builds the API handler
+// that serve routes to and worker runs jobs on
+func newHandler() *api.Handler {
+	pool, slowQueries := connectDB()
+
+	// Sessions are signed with JWT_SECRET or JWT_SIGNING_KEY; never fall back to a guessable key
+	if err := auth.CheckKeys(); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+	// Avatar and attachment URLs are signed with MEDIA_SIGNING_KEY
+	if err := api.CheckMediaKey(); err != nil {
+		log.Fatalf("Refusing to start: %v", err)
+	}
+
+	// Initialize Redis for pub/sub (horizontal scaling)
+	rdb := connectRedis()
+
+	// Panics and unexpected failures go to SENTRY_DSN when set
+	errreport.Use(errreport.FromEnv())
+
+	queries := db.New(pool)
+	hub := chat.NewHub(rdb)
+	if n, err := strconv.Atoi(os.Getenv("WS_MAX_CONNECTIONS_PER_USER")); err == nil {
+		hub.SetConnLimit(n)
+	}
+	api.EnableCacheInvalidation(rdb)
+	middleware.UseRedisDeliveries(rdb)
+	middleware.UseRedisIdempotency(rdb)
+	middleware.UseRedisWSTickets(rdb)
+	store, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("Unable to initialize attachment storage: %v\n", err)
+	}
+	// Loops can read their repo as a GitHub App installation when one is set up
+	ghApp, err := github.AppFromEnv()
+	if err != nil {
+		log.Fatalf("Unable to configure the GitHub App: %v", err)
+	}
+	// Integration credentials are stored encrypted under SECRETS_MASTER_KEY
+	keyring, err := secrets.KeyringFromEnv()
+	if err != nil {
+		log.Fatalf("Unable to load the secrets master key: %v", err)
+	}
+	var secretStore *secrets.Store
+	if keyring != nil {
+		secretStore = secrets.New(queries, keyring)
+	} else {
+		log.Println("[secrets] SECRETS_MASTER_KEY not set, integration credentials are disabled")
+	}
+	maxMessageChars, _ := strconv.Atoi(os.Getenv("MAX_MESSAGE_CHARS"))
+	h, err := api.NewHandler(queries, pool, hub, api.Config{
+		Storage: store,
+		Jobs:    jobs.New(queries),
+		Scanner: scan.FromEnv(),
+		Flags:   flags.New(queries),
+		Quotas:  quota.New(queries),
+
+		GitHubApp: ghApp,
+		Secrets:   secretStore,
+
+		SlowQueries: slowQueries,
+		// 5xx counts per route for /api/admin/errors, with an optional alert webhook
+		ErrorRates: middleware.NewErrorRates(middleware.ErrorAlertFromEnv()),
+		Captures:   middleware.NewCaptureStore(),
+		Messages:   db.NewMessageWriter(queries),
+		// Longer messages are rejected unless sent as long posts; unset or
+		// invalid keeps the default
+		MaxMessageChars: maxMessageChars,
+	})
+	if err != nil {
+		log.Fatalf("Unable to set up the API: %v", err)
+	}
+	h.RegisterJobs()
+	middleware.SessionChecker = h.SessionActive
+	problem.Localize = h.LocalizeProblem
+	return h
+}
+
+// startJobs runs the job queue and periodic tasks until ctx is cancelled.
+// Jobs are claimed with SKIP LOCKED, so any number of processes can run them.
+func startJobs(ctx context.Context, h *api.Handler) {
+	h.Jobs.Start(ctx)
+	h.StartGitHubProfileRefresh(ctx)
+	h.StartRepoStatsRefresh(ctx)
+	h.StartAnalyticsRollup(ctx)
+	h.StartTopMessagesDigest(ctx)
+	h.StartWebhookDeliveryPruning(ctx)
+}
+
+// App serves the diagnostic routes
+type App struct {
+	Queries *db.Queries
+	DBPool  *pgxpool.Pool
+}
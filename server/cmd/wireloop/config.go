@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// connectDB opens the DATABASE_URL pool, exiting when the database can't be
+// reached. Queries slower than SLOW_QUERY_MS are recorded in the returned log.
+func connectDB() (*pgxpool.Pool, *db.SlowQueryLog) {
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		log.Fatal("DATABASE_URL is not set in environment")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	config, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		log.Fatalf("Unable to parse database URL: %v\n", err)
+	}
+
+	// Connection pool settings
+	maxConns := 10
+	if maxConnsStr := os.Getenv("MAX_DB_CONN"); maxConnsStr != "" {
+		if parsedMaxConns, err := strconv.Atoi(maxConnsStr); err == nil {
+			maxConns = parsedMaxConns
+		} else {
+			log.Printf("Invalid MAX_DB_CONN value: %s. Using default %d", maxConnsStr, maxConns)
+		}
+	}
+	config.MaxConns = int32(maxConns)
+	config.MinConns = 2                         // Minimal warm connections
+	config.MaxConnLifetime = 30 * time.Minute   // Refresh connections periodically
+	config.MaxConnIdleTime = 5 * time.Minute    // Close idle connections
+	config.HealthCheckPeriod = 30 * time.Second // Check connection health
+
+	// Queries slower than SLOW_QUERY_MS show up in /api/admin/slow-queries
+	slowQueryThreshold := 250 * time.Millisecond
+	if ms := os.Getenv("SLOW_QUERY_MS"); ms != "" {
+		if parsed, err := strconv.Atoi(ms); err == nil && parsed > 0 {
+			slowQueryThreshold = time.Duration(parsed) * time.Millisecond
+		} else {
+			log.Printf("Invalid SLOW_QUERY_MS value: %s. Using default %s", ms, slowQueryThreshold)
+		}
+	}
+	slowQueries := db.NewSlowQueryLog(slowQueryThreshold, 200)
+	config.ConnConfig.Tracer = slowQueries
+
+	pool, err := pgxpool.NewWithConfig(ctx, config)
+	if err != nil {
+		log.Fatalf("Unable to create connection pool: %v\n", err)
+	}
+
+	// Retry ping — Supabase pooler may need time to free slots during deploys
+	var pingErr error
+	for i := 0; i < 5; i++ {
+		retryCtx, retryCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		pingErr = pool.Ping(retryCtx)
+		retryCancel()
+		if pingErr == nil {
+			break
+		}
+		log.Printf("DB ping attempt %d/5 failed: %v — retrying in 3s...", i+1, pingErr)
+		time.Sleep(3 * time.Second)
+	}
+	if pingErr != nil {
+		log.Fatalf("Database is unreachable after 5 attempts: %v\n", pingErr)
+	}
+	log.Println("Successfully connected to PostgreSQL")
+	return pool, slowQueries
+}
+
+// connectRedis connects to REDIS_URL for pub/sub across instances, or
+// returns nil to run single-server
+func connectRedis() *redis.Client {
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		log.Println("REDIS_URL not set, running in single-server mode (no horizontal scaling)")
+		return nil
+	}
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		log.Printf("Warning: Invalid REDIS_URL, running without Redis: %v", err)
+		return nil
+	}
+	rdb := redis.NewClient(opt)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		log.Printf("Warning: Redis connection failed, running without Redis: %v", err)
+		return nil
+	}
+	log.Println("Connected to Redis for pub/sub scaling")
+	return rdb
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"wireloop/internal/api"
+	"wireloop/internal/auth"
+	"wireloop/internal/github"
+	"wireloop/internal/migrate"
+	"wireloop/internal/secrets"
+	"wireloop/internal/storage"
+	"wireloop/migrations"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/redis/go-redis/v9"
+)
+
+// Outcome of one doctor check. Warnings are optional features left off;
+// only failures stop a deployment.
+const (
+	checkOK   = "ok"
+	checkWarn = "warn"
+	checkFail = "FAIL"
+)
+
+type checkResult struct {
+	name, status, detail string
+}
+
+// runDoctor checks the configuration serve and worker start with (database,
+// migrations, session keys, GitHub, Redis, storage, secrets) and prints a
+// report. It exits 1 when a check fails, so a deploy can run it first.
+func runDoctor(args []string) int {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "fail on warnings too")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var results []checkResult
+	report := func(name, status, format string, a ...any) {
+		results = append(results, checkResult{name, status, fmt.Sprintf(format, a...)})
+	}
+
+	pool := doctorDatabase(ctx, report)
+	if pool != nil {
+		doctorMigrations(ctx, pool, report)
+		pool.Close()
+	}
+	doctorSessions(report)
+	doctorGitHub(ctx, report)
+	doctorRedis(ctx, report)
+	doctorStorage(ctx, report)
+	doctorSecrets(report)
+	doctorOptional(report)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAIL")
+	failed := 0
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.name, r.status, r.detail)
+		if r.status == checkFail || (*strict && r.status == checkWarn) {
+			failed++
+		}
+	}
+	w.Flush()
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d check(s) failed\n", failed)
+		return 1
+	}
+	return 0
+}
+
+type reportFunc func(name, status, format string, a ...any)
+
+// doctorDatabase connects once, without connectDB's retries and exits
+func doctorDatabase(ctx context.Context, report reportFunc) *pgxpool.Pool {
+	dbURL := os.Getenv("DATABASE_URL")
+	if dbURL == "" {
+		report("database", checkFail, "DATABASE_URL is not set")
+		return nil
+	}
+	pool, err := pgxpool.New(ctx, dbURL)
+	if err != nil {
+		report("database", checkFail, "invalid DATABASE_URL: %v", err)
+		return nil
+	}
+	var version string
+	if err := pool.QueryRow(ctx, "SELECT current_setting('server_version')").Scan(&version); err != nil {
+		pool.Close()
+		report("database", checkFail, "unreachable: %v", err)
+		return nil
+	}
+	report("database", checkOK, "connected, PostgreSQL %s", version)
+	return pool
+}
+
+func doctorMigrations(ctx context.Context, pool *pgxpool.Pool, report reportFunc) {
+	statuses, err := migrate.StatusOf(ctx, pool, migrations.FS)
+	if err != nil {
+		report("migrations", checkFail, "%v", err)
+		return
+	}
+	var pending []string
+	for _, s := range statuses {
+		if s.AppliedAt.IsZero() {
+			pending = append(pending, s.Name)
+		}
+	}
+	if len(pending) > 0 {
+		report("migrations", checkFail, "%d pending (%s); run `wireloop migrate`", len(pending), pending[0])
+		return
+	}
+	report("migrations", checkOK, "%d applied, none pending", len(statuses))
+}
+
+func doctorSessions(report reportFunc) {
+	if err := auth.CheckKeys(); err != nil {
+		report("session keys", checkFail, "%v", err)
+		return
+	}
+	report("session keys", checkOK, "signing with %s", strings.Join(auth.ValidMethods(), ", "))
+	if err := api.CheckMediaKey(); err != nil {
+		report("media keys", checkFail, "%v", err)
+		return
+	}
+	report("media keys", checkOK, "MEDIA_SIGNING_KEY set")
+}
+
+func doctorGitHub(ctx context.Context, report reportFunc) {
+	if os.Getenv("GITHUB_CLIENT_ID") == "" || os.Getenv("GITHUB_CLIENT_SECRET") == "" {
+		report("github oauth", checkFail, "GITHUB_CLIENT_ID and GITHUB_CLIENT_SECRET are required to sign in")
+	} else {
+		report("github oauth", checkOK, "client %s", os.Getenv("GITHUB_CLIENT_ID"))
+	}
+
+	app, err := github.AppFromEnv()
+	switch {
+	case err != nil:
+		report("github app", checkFail, "%v", err)
+	case app == nil:
+		report("github app", checkWarn, "not configured; loops read their repo with members' tokens")
+	default:
+		slug, err := app.Check(ctx)
+		if err != nil {
+			report("github app", checkFail, "GitHub rejected the app credentials: %v", err)
+		} else {
+			report("github app", checkOK, "authenticated as %s", slug)
+		}
+	}
+
+	if os.Getenv("GITHUB_WEBHOOK_SECRET") == "" {
+		report("github webhooks", checkWarn, "GITHUB_WEBHOOK_SECRET not set; webhook deliveries are refused")
+	} else {
+		report("github webhooks", checkOK, "secret set")
+	}
+}
+
+func doctorRedis(ctx context.Context, report reportFunc) {
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		report("redis", checkWarn, "REDIS_URL not set; only a single server instance is supported")
+		return
+	}
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		report("redis", checkFail, "invalid REDIS_URL: %v", err)
+		return
+	}
+	rdb := redis.NewClient(opt)
+	defer rdb.Close()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		report("redis", checkFail, "unreachable: %v", err)
+		return
+	}
+	report("redis", checkOK, "connected to %s", opt.Addr)
+}
+
+// doctorStorage writes, reads back and deletes a probe blob
+func doctorStorage(ctx context.Context, report reportFunc) {
+	store, err := storage.FromEnv()
+	if err != nil {
+		report("storage", checkFail, "%v", err)
+		return
+	}
+	b := make([]byte, 8)
+	rand.Read(b)
+	key := "doctor/" + hex.EncodeToString(b)
+	data := []byte("wireloop doctor")
+	if err := store.Put(ctx, key, data); err != nil {
+		report("storage", checkFail, "can't write: %v", err)
+		return
+	}
+	got, err := store.Get(ctx, key)
+	if err != nil || string(got) != string(data) {
+		report("storage", checkFail, "can't read back a written blob: %v", err)
+		return
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		report("storage", checkFail, "can't delete: %v", err)
+		return
+	}
+	report("storage", checkOK, "write, read and delete work")
+}
+
+func doctorSecrets(report reportFunc) {
+	keyring, err := secrets.KeyringFromEnv()
+	switch {
+	case err != nil:
+		report("secrets", checkFail, "%v", err)
+	case keyring == nil:
+		report("secrets", checkWarn, "SECRETS_MASTER_KEY not set; integration credentials are disabled")
+	default:
+		report("secrets", checkOK, "master key %s", keyring.KeyID())
+	}
+}
+
+// doctorOptional covers settings with a fallback that is rarely what a
+// deployment wants
+func doctorOptional(report reportFunc) {
+	unset := map[string]string{
+		"FRONTEND_URL": "sign-in redirects to http://localhost:3000",
+		"BACKEND_URL":  "media and feed links are relative",
+	}
+	for _, name := range []string{"FRONTEND_URL", "BACKEND_URL"} {
+		raw := os.Getenv(name)
+		u, err := url.Parse(raw)
+		switch {
+		case raw == "":
+			report(strings.ToLower(name), checkWarn, "%s not set; %s", name, unset[name])
+		case err != nil || u.Scheme == "" || u.Host == "":
+			report(strings.ToLower(name), checkFail, "%s is not an absolute URL: %q", name, raw)
+		default:
+			report(strings.ToLower(name), checkOK, "%s", raw)
+		}
+	}
+	if os.Getenv("GEMINI_API_KEY") == "" {
+		report("ai", checkWarn, "GEMINI_API_KEY not set; summaries fall back to plain excerpts")
+	} else {
+		report("ai", checkOK, "Gemini key set")
+	}
+}
@@ -0,0 +1,72 @@
+// Command wireloop is the Wireloop server. One binary serves the API, runs
+// background jobs, migrates the database and holds the maintenance tools,
+// all from the same configuration (DATABASE_URL, REDIS_URL, ...).
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+const usage = `Usage: wireloop <command> [arguments]
+
+Commands:
+  serve [-jobs=false]              serve the API (the default); -jobs=false leaves
+                                   background jobs to a separate worker
+  worker                           run background jobs without serving HTTP
+  migrate [up|down|status]         apply pending migrations (default up), roll back
+                                   the latest one, or list them
+  doctor [-strict]                 check the configuration (database, migrations, keys,
+                                   GitHub, Redis, storage) and print a report; exits 1
+                                   on a failure, or on a warning with -strict
+  seed                             load demo users, a loop and messages for local development
+  backup [file]                    write a backup archive (stdout when no file is given)
+  restore <file>                   restore an archive into an empty, migrated database
+  import-messages <file>           load a message archive from another system
+  import-slack <export.zip> <loop> [user-map.json]
+  import-discord <export.json|dir> <loop> [user-map.json]
+                                   load a Slack or Discord export into a loop
+  retention -days=N [-mode=anonymize|purge] [-only=messages,notifications,audit] [-dry-run]
+                                   anonymize or delete data older than N days;
+                                   -dry-run reports what would change
+`
+
+func main() {
+	if err := godotenv.Load("../.env"); err != nil {
+		if err := godotenv.Load(); err != nil {
+			log.Println("No .env file found, reading from system environment")
+		}
+	}
+
+	cmd, args := "serve", []string(nil)
+	if len(os.Args) > 1 {
+		cmd, args = os.Args[1], os.Args[2:]
+	}
+	switch cmd {
+	case "serve":
+		os.Exit(runServe(args))
+	case "worker":
+		os.Exit(runWorker(args))
+	case "migrate":
+		os.Exit(runMigrate(args))
+	case "doctor":
+		os.Exit(runDoctor(args))
+	case "seed":
+		os.Exit(runSeed(args))
+	case "retention":
+		os.Exit(runRetention(args))
+	case "backup", "restore", "import-messages", "import-slack", "import-discord":
+		pool, _ := connectDB()
+		code := runMaintenance(pool, os.Args[1:])
+		pool.Close()
+		os.Exit(code)
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"wireloop/internal/backup"
+	"wireloop/internal/importer"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// runMaintenance handles `backup [file]` (stdout when no file is given),
+// `restore <file>`, which needs an empty, fully migrated database, and
+// `import-messages <file>` for message archives from other systems, and
+// `import-slack`/`import-discord`, which load those services' exports into
+// a loop, matching authors by username or a JSON map of source user (ID,
+// name or email) to Wireloop username
+func runMaintenance(pool *pgxpool.Pool, args []string) int {
+	ctx := context.Background()
+	switch {
+	case args[0] == "backup" && len(args) <= 2:
+		out := os.Stdout
+		if len(args) == 2 {
+			f, err := os.Create(args[1])
+			if err != nil {
+				log.Printf("backup: %v", err)
+				return 1
+			}
+			defer f.Close()
+			out = f
+		}
+		stats, err := backup.Write(ctx, pool, out)
+		if err != nil {
+			log.Printf("backup failed: %v", err)
+			return 1
+		}
+		log.Printf("backup complete: %v", stats)
+		return 0
+	case args[0] == "restore" && len(args) == 2:
+		f, err := os.Open(args[1])
+		if err != nil {
+			log.Printf("restore: %v", err)
+			return 1
+		}
+		defer f.Close()
+		stats, err := backup.Restore(ctx, pool, f)
+		if err != nil {
+			log.Printf("restore failed: %v", err)
+			return 1
+		}
+		log.Printf("restore complete: %v", stats)
+		return 0
+	case args[0] == "import-messages" && len(args) == 2:
+		f, err := os.Open(args[1])
+		if err != nil {
+			log.Printf("import: %v", err)
+			return 1
+		}
+		defer f.Close()
+		done, err := backup.ImportMessages(ctx, pool, f, func(p backup.ImportProgress) {
+			log.Printf("imported %d messages (%.0f/s, up to id %d)", p.Messages, p.Rate(), p.LastID)
+		})
+		if err != nil {
+			log.Printf("import failed: %v", err)
+			return 1
+		}
+		log.Printf("import complete: %d messages in %s", done.Messages, done.Elapsed.Round(time.Second))
+		return 0
+	case (args[0] == "import-slack" || args[0] == "import-discord") && (len(args) == 3 || len(args) == 4):
+		opts := importer.Options{
+			Loop: args[2],
+			Progress: func(p backup.ImportProgress) {
+				log.Printf("imported %d messages (%.0f/s)", p.Messages, p.Rate())
+			},
+		}
+		if len(args) == 4 {
+			m, err := importer.LoadUserMap(args[3])
+			if err != nil {
+				log.Printf("import: %v", err)
+				return 1
+			}
+			opts.UserMap = m
+		}
+		run := importer.ImportSlack
+		if args[0] == "import-discord" {
+			run = importer.ImportDiscord
+		}
+		res, err := run(ctx, pool, args[1], opts)
+		for author, n := range res.Unmapped {
+			log.Printf("skipped %d messages from unmatched user %q", n, author)
+		}
+		if err != nil {
+			log.Printf("import failed after %d messages: %v", res.Messages, err)
+			return 1
+		}
+		log.Printf("import complete: %d messages in %s, %d system messages skipped, channels created: %v",
+			res.Messages, res.Elapsed.Round(time.Second), res.Skipped, res.ChannelsCreated)
+		return 0
+	}
+	fmt.Fprint(os.Stderr, usage)
+	return 2
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"wireloop/internal/migrate"
+	"wireloop/migrations"
+)
+
+// runMigrate applies, rolls back or lists the embedded migrations
+func runMigrate(args []string) int {
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if len(args) > 1 || (action != "up" && action != "down" && action != "status") {
+		fmt.Fprintln(os.Stderr, "usage: wireloop migrate [up|down|status]")
+		return 2
+	}
+
+	pool, _ := connectDB()
+	defer pool.Close()
+	ctx := context.Background()
+
+	switch action {
+	case "up":
+		ran, err := migrate.Up(ctx, pool, migrations.FS)
+		for _, m := range ran {
+			log.Printf("applied %s", m.Name)
+		}
+		if err != nil {
+			log.Printf("migration failed: %v", err)
+			return 1
+		}
+		if len(ran) == 0 {
+			log.Println("database is up to date")
+		}
+	case "down":
+		m, err := migrate.Down(ctx, pool, migrations.FS)
+		if err != nil {
+			log.Printf("rollback failed: %v", err)
+			return 1
+		}
+		if m == nil {
+			log.Println("no migrations to roll back")
+		} else {
+			log.Printf("rolled back %s", m.Name)
+		}
+	case "status":
+		statuses, err := migrate.StatusOf(ctx, pool, migrations.FS)
+		if err != nil {
+			log.Printf("status failed: %v", err)
+			return 1
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MIGRATION\tAPPLIED")
+		for _, s := range statuses {
+			applied := "pending"
+			if !s.AppliedAt.IsZero() {
+				applied = s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%s\t%s\n", s.Name, applied)
+		}
+		w.Flush()
+	}
+	return 0
+}
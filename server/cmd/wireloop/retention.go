@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"strings"
+	"time"
+
+	"wireloop/internal/retention"
+)
+
+// runRetention anonymizes or purges messages, notifications and audit
+// entries older than -days. -dry-run only reports how many rows would change.
+func runRetention(args []string) int {
+	fs := flag.NewFlagSet("retention", flag.ContinueOnError)
+	days := fs.Int("days", 0, "affect rows older than this many days (required)")
+	mode := fs.String("mode", string(retention.Anonymize), "anonymize (drop content and authors) or purge (delete rows)")
+	only := fs.String("only", "", "comma-separated kinds to limit to: messages, notifications, audit")
+	dryRun := fs.Bool("dry-run", false, "count the rows that would change without changing them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *days < 1 || fs.NArg() > 0 {
+		log.Println("retention: -days must be at least 1")
+		fs.Usage()
+		return 2
+	}
+
+	opts := retention.Options{
+		Cutoff: time.Now().AddDate(0, 0, -*days),
+		Mode:   retention.Mode(*mode),
+		DryRun: *dryRun,
+		Progress: func(table string, done int64) {
+			log.Printf("%s: %d rows so far", table, done)
+		},
+	}
+	if *only != "" {
+		for _, k := range strings.Split(*only, ",") {
+			opts.Kinds = append(opts.Kinds, retention.Kind(strings.TrimSpace(k)))
+		}
+	}
+
+	pool, _ := connectDB()
+	defer pool.Close()
+	report, err := retention.Run(context.Background(), pool, opts)
+	switch {
+	case err != nil:
+		log.Printf("retention failed (done so far: %v): %v", report, err)
+		return 1
+	case *dryRun:
+		log.Printf("dry run, rows older than %s that would be %sd: %v", opts.Cutoff.Format(time.DateOnly), opts.Mode, report)
+	default:
+		log.Printf("retention complete, rows older than %s %sd: %v", opts.Cutoff.Format(time.DateOnly), opts.Mode, report)
+	}
+	return 0
+}
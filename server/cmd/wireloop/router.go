@@ -0,0 +1,442 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/api"
+	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newRouter builds the HTTP API around h
+func newRouter(h *api.Handler) *gin.Engine {
+	app := &App{
+		Queries: h.Queries,
+		DBPool:  h.Pool,
+	}
+
+	r := gin.New()
+	r.Use(middleware.RequestIDMiddleware(), gin.Logger(), h.ErrorRates.Middleware(), middleware.Recovery())
+
+	// Unknown routes get the same problem+json body as handler errors
+	r.HandleMethodNotAllowed = true
+	r.NoRoute(func(c *gin.Context) { problem.Respond(c, http.StatusNotFound, "no such endpoint") })
+	r.NoMethod(func(c *gin.Context) { problem.Respond(c, http.StatusMethodNotAllowed, "method not allowed") })
+
+	// gzip/deflate compression - ~70% bandwidth savings on JSON responses
+	r.Use(middleware.CompressionMiddleware(middleware.DefaultCompressMinSize))
+
+	// Admin-enabled request capture; records bodies as handlers wrote them,
+	// so it sits inside compression
+	r.Use(h.Captures.Middleware())
+
+	// Global rate limiting - 100 req/min per IP (prevents abuse)
+	r.Use(middleware.RateLimitMiddleware())
+
+	// Body size caps - small for JSON, larger for uploads and webhook deliveries
+	bodyLimits := middleware.BodyLimitsFromEnv()
+	bodyLimits.UploadRoutes = []string{"/api/github/webhook", "/api/inbound/email"}
+	r.Use(middleware.BodyLimitMiddleware(bodyLimits))
+
+	// CORS - FRONTEND_URL plus CORS_ORIGINS (wildcard subdomains allowed), see middleware.OriginPolicy
+	r.Use(middleware.CORSMiddleware(
+		// Embeds and feeds for other sites
+		"/api/loops/:name/widget.json",
+		"/api/loops/:name/widget.svg",
+		"/api/loops/:name/shields/:metric",
+		"/api/loops/:name/channels/:id/feed.atom",
+	))
+
+	r.GET("/health", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":  "healthy",
+			"service": "wireloop-api",
+			"time":    utils.FormatTime(time.Now()),
+		})
+	})
+
+	// Public session-token keys for companion services (empty with HS256 only)
+	r.GET("/.well-known/jwks.json", api.HandleJWKS)
+
+	r.GET("/api/test-db", app.testDBHandler)
+
+	// Auth routes (public) - strict rate limiting to prevent brute force
+	authRateLimit := middleware.StrictRateLimitMiddleware()
+	r.GET("/api/auth/callback", authRateLimit, h.HandleGitHubCallback)
+	r.GET("/api/auth/github", authRateLimit, h.HandleGitHubLogin)
+	// Back to GitHub to grant a scope a feature needs (see needs_scope errors)
+	r.GET("/api/auth/github/scopes", authRateLimit, h.HandleGitHubScopes)
+
+	// Public profile route
+	r.GET("/api/users/:username", h.GetPublicProfile)
+
+	// Signed media routes (the URL signature is the authorization)
+	r.GET("/api/media/avatars/:user_id/:file", h.HandleMediaAvatar)
+	r.GET("/api/media/attachments/:id", h.HandleMediaAttachment)
+	r.GET("/api/media/emoji/:project_id/:file", h.HandleMediaEmoji)
+
+	// GitHub webhook deliveries (public, authenticated by signature)
+	r.POST("/api/github/webhook", h.GitHubWebhookAuth(), h.HandleGitHubWebhook)
+	// Calendar subscriptions (authenticated by the signed feed URL)
+	r.GET("/api/loops/:name/events.ics", h.HandleGetCalendarFeed)
+	// Atom feeds of a public loop's public channels
+	r.GET("/api/loops/:name/channels/:id/feed.atom", h.HandleGetChannelFeed)
+	// README badges and site widget for public loops
+	embedRateLimit := middleware.EmbedRateLimitMiddleware()
+	r.GET("/api/loops/:name/widget.json", embedRateLimit, h.HandleGetLoopWidget)
+	r.GET("/api/loops/:name/widget.svg", embedRateLimit, h.HandleGetLoopBadge)
+	r.GET("/api/loops/:name/shields/:metric", embedRateLimit, h.HandleGetLoopShield)
+	// Replies to notification emails, from the inbound mail relay
+	r.POST("/api/inbound/email", h.InboundEmailAuth(), h.HandleInboundEmail)
+	// Channel incoming webhooks (the token in the path is the authorization)
+	r.POST("/api/hooks/:token", h.HandleIncomingWebhook)
+
+	// Semi-public routes (work for both logged-in and anonymous users)
+	// Optional auth lets us check membership for logged-in users
+	r.GET("/api/loops/:name", h.AccessTokenAuth(), middleware.OptionalAuthMiddleware(), h.ImpersonationAudit(), h.HandleGetLoopDetails)
+	r.GET("/api/loops", h.HandleBrowseLoops)
+	// Message history; non-members may read the public channels of public loops
+	readable := r.Group("/api")
+	readable.Use(h.APIKeyAuth(), h.AccessTokenAuth(), middleware.OptionalAuthMiddleware(), h.ImpersonationAudit())
+	{
+		readable.GET("/loops/:name/channels", h.HandleGetChannels)
+		readable.GET("/loops/:name/messages", h.HandleGetMessages)
+		readable.GET("/channels/:id/messages", h.HandleGetChannelMessages)
+		readable.GET("/messages/:message_id/replies", h.HandleGetThreadReplies)
+		readable.GET("/messages/:message_id/long-post", h.HandleGetLongPost)
+	}
+
+	// Protected routes (require auth)
+	protected := r.Group("/api")
+	protected.Use(h.APIKeyAuth(), h.AccessTokenAuth(), middleware.AuthMiddleware(), h.ImpersonationAudit())
+	{
+		// OPTIMIZED: Single endpoint for all initial data (profile + projects + memberships)
+		protected.GET("/init", h.HandleInit)
+
+		// OPTIMIZED: Single endpoint for loop details + messages
+		protected.GET("/loops/:name/full", h.HandleLoopFull)
+
+		// Prefetch endpoint (for hover optimization)
+		protected.GET("/loops/:name/prefetch", h.HandlePrefetch)
+
+		// Profile
+		protected.GET("/profile", h.GetProfile)
+		protected.PUT("/profile", h.UpdateProfile)
+		protected.POST("/profile/avatar", h.UploadAvatar)
+		protected.POST("/profile/sync-github", h.HandleSyncGitHubProfile)
+		protected.PUT("/profile/username", h.HandleChangeUsername)
+		protected.GET("/profile/privacy", h.HandleGetPresenceSettings)
+		protected.PUT("/profile/privacy", h.HandleUpdatePresenceSettings)
+		protected.GET("/profile/notifications", h.HandleGetNotificationFallback)
+		protected.PUT("/profile/notifications", h.HandleUpdateNotificationFallback)
+		protected.GET("/profile/undo-send", h.HandleGetUndoSend)
+		protected.PUT("/profile/undo-send", h.HandleUpdateUndoSend)
+		protected.GET("/sessions", h.HandleGetSessions)
+		protected.DELETE("/sessions/:id", h.HandleRevokeSession)
+		protected.GET("/keys", h.HandleGetAPIKeys)
+		protected.POST("/keys", h.HandleCreateAPIKey)
+		protected.DELETE("/keys/:id", h.HandleDeleteAPIKey)
+		protected.GET("/tokens", h.HandleGetAccessTokens)
+		protected.POST("/tokens", h.HandleCreateAccessToken)
+		protected.DELETE("/tokens/:id", h.HandleDeleteAccessToken)
+		protected.GET("/tokens/:id/usage", h.HandleGetAccessTokenUsage)
+		protected.GET("/profile/impersonations", h.HandleGetImpersonations)
+		protected.GET("/flags", h.HandleGetFlags)
+		protected.GET("/profile/impersonations/:id/requests", h.HandleGetImpersonationRequests)
+
+		// Loops management
+		protected.POST("/channel", middleware.Idempotency(), h.HandleMakeChannel)
+		protected.GET("/projects", h.HandlelistProjects)
+		protected.GET("/analytics/overview", h.HandleGetAnalyticsOverview)
+		protected.GET("/loops/:name/analytics/reactions", h.HandleGetReactionAnalytics)
+
+		// Workspaces
+		protected.GET("/workspaces", h.HandleListWorkspaces)
+		protected.POST("/workspaces", h.HandleCreateWorkspace)
+		protected.GET("/workspaces/:slug", h.HandleGetWorkspace)
+		protected.PATCH("/workspaces/:slug", h.HandleUpdateWorkspace)
+		protected.PUT("/workspaces/:slug/members/:username", h.HandleSetWorkspaceMember)
+		protected.DELETE("/workspaces/:slug/members/:username", h.HandleRemoveWorkspaceMember)
+		protected.GET("/workspaces/:slug/loops/:name/full", h.HandleLoopFull)
+		protected.GET("/github/repos", h.HandleGetGitHubRepos)
+		protected.GET("/search", h.HandleSearchQuery)
+		protected.GET("/search/messages", h.HandleSearchMessages)
+		protected.GET("/quickswitch", h.HandleQuickSwitch)
+		protected.GET("/my-memberships", h.HandleGetMyMemberships)
+		protected.PUT("/loops/:name/repo", h.HandleRelinkRepo)
+		protected.POST("/loops/:name/archive", h.HandleArchiveLoop)
+
+		// Channel management (Discord-like sub-channels)
+		protected.POST("/channels", middleware.Idempotency(), h.HandleCreateChannel)
+		protected.PUT("/channels/:id", h.HandleUpdateChannel)
+		protected.DELETE("/channels/:id", h.HandleDeleteChannel)
+		protected.POST("/channels/:id/read", h.HandleMarkChannelRead)
+		protected.PUT("/channels/:id/topic", h.HandleSetChannelTopic)
+		protected.PUT("/channels/:id/banner", h.HandleSetChannelBanner)
+		protected.DELETE("/channels/:id/banner", h.HandleClearChannelBanner)
+		protected.PUT("/channels/:id/data-policy", h.HandleSetChannelDataPolicy)
+
+		// Gatekeeper - Verify & Join
+		protected.POST("/verify-access", h.HandleVerifyAccess)
+		protected.GET("/loops/:name/verification", h.HandleGetVerificationHistory)
+		protected.POST("/loops/:name/join", middleware.Idempotency(), h.HandleJoinLoop)
+		protected.GET("/loops/:name/settings", h.HandleGetLoopSettings)
+		protected.PUT("/loops/:name/settings", h.HandleUpdateLoopSettings)
+		protected.GET("/loops/:name/notifications", h.HandleGetLoopNotifyLevel)
+		protected.PUT("/loops/:name/notifications", h.HandleSetLoopNotifyLevel)
+		protected.GET("/loops/:name/emoji", h.HandleGetLoopEmoji)
+		protected.POST("/loops/:name/emoji", h.HandleCreateLoopEmoji)
+		protected.DELETE("/loops/:name/emoji/:emoji", h.HandleDeleteLoopEmoji)
+		protected.GET("/loops/:name/config", h.HandleExportLoopConfig)
+		protected.PUT("/loops/:name/config", h.HandleImportLoopConfig)
+		protected.GET("/loops/:name/onboarding", h.HandleGetOnboarding)
+		protected.PUT("/loops/:name/onboarding/steps", h.HandleReplaceOnboardingSteps)
+		protected.POST("/loops/:name/onboarding/steps/:step_id/complete", h.HandleSetOnboardingStep)
+		protected.DELETE("/loops/:name/onboarding/steps/:step_id/complete", h.HandleSetOnboardingStep)
+		protected.GET("/loops/:name/onboarding/progress", h.HandleGetOnboardingProgress)
+
+		// Invite codes (join as a guest without the gatekeeper)
+		protected.GET("/loops/:name/invites", h.HandleGetInvites)
+		protected.POST("/loops/:name/invites", h.HandleCreateInvite)
+		protected.DELETE("/loops/:name/invites/:code", h.HandleDeleteInvite)
+		protected.POST("/invites/:code/accept", h.HandleAcceptInvite)
+
+		// Sidebar layout (favorites, order, collapse)
+		protected.PATCH("/loops/:name/sidebar", h.HandleUpdateLoopSidebar)
+		protected.PUT("/sidebar/order", h.HandleSetSidebarOrder)
+
+		// Chat / Messages (use :name consistently to avoid route conflicts)
+		protected.POST("/loop/message", middleware.Idempotency(), h.HandleSendMessage)
+
+		// Thread / Replies
+		protected.DELETE("/messages/:message_id", h.HandleDeleteMessage)
+
+		// Content reports + moderation queue
+		protected.POST("/messages/:message_id/report", h.HandleReportMessage)
+		protected.GET("/loops/:name/reports", h.HandleGetLoopReports)
+		protected.POST("/reports/:id/dismiss", h.HandleDismissReport)
+		protected.POST("/reports/:id/delete-message", h.HandleReportDeleteMessage)
+		protected.POST("/reports/:id/ban-author", h.HandleReportBanAuthor)
+
+		// Integrations
+		protected.GET("/loops/:name/integrations", h.HandleGetIntegrations)
+		protected.POST("/loops/:name/integrations", h.HandleCreateIntegration)
+		protected.GET("/loops/:name/integrations/audit", h.HandleGetIntegrationAudit)
+		protected.PUT("/loops/:name/integrations/:id", h.HandleUpdateIntegration)
+		protected.DELETE("/loops/:name/integrations/:id", h.HandleDeleteIntegration)
+		protected.GET("/loops/:name/incoming-webhooks", h.HandleGetIncomingWebhooks)
+		protected.POST("/loops/:name/incoming-webhooks", h.HandleCreateIncomingWebhook)
+		protected.DELETE("/loops/:name/incoming-webhooks/:id", h.HandleDeleteIncomingWebhook)
+		protected.GET("/loops/:name/secrets", h.HandleGetSecrets)
+		protected.POST("/loops/:name/secrets", h.HandleCreateSecret)
+		protected.PUT("/loops/:name/secrets/:id", h.HandleRotateSecret)
+		protected.DELETE("/loops/:name/secrets/:id", h.HandleDeleteSecret)
+
+		// Message filters
+		protected.GET("/loops/:name/filters", h.HandleGetFilterSettings)
+		protected.PUT("/loops/:name/filters", h.HandleUpdateFilterSettings)
+		protected.GET("/loops/:name/filtered", h.HandleGetFilteredMessages)
+		protected.POST("/filtered/:id/approve", h.HandleApproveFiltered)
+		protected.POST("/filtered/:id/reject", h.HandleRejectFiltered)
+
+		// Pinned Messages
+		protected.POST("/messages/:message_id/pin", h.HandlePinMessage)
+		protected.DELETE("/messages/:message_id/pin", h.HandleUnpinMessage)
+		protected.PUT("/messages/:message_id/reactions/:emoji", h.HandleAddReaction)
+		protected.DELETE("/messages/:message_id/reactions/:emoji", h.HandleRemoveReaction)
+		protected.GET("/channels/:id/pins", h.HandleGetPinnedMessages)
+		protected.GET("/loops/:name/pins", h.HandleGetLoopPins)
+		protected.GET("/loops/:name/pins/audit", h.HandleGetPinAudit)
+
+		// Reminders
+		protected.POST("/messages/:message_id/remind", h.HandleRemindMessage)
+
+		// Attachments
+		protected.POST("/channels/:id/attachments", h.HandleUploadAttachment)
+		protected.GET("/channels/:id/attachments", h.HandleGetChannelAttachments)
+		protected.GET("/attachments/:id", h.HandleGetAttachment)
+		protected.GET("/attachments/:id/download", h.HandleDownloadAttachment)
+		protected.POST("/media/resign", h.HandleResignMedia)
+
+		// Tasks
+		protected.POST("/messages/:message_id/task", h.HandleCreateTaskFromMessage)
+		protected.POST("/messages/:message_id/export", h.HandleExportThread)
+		protected.POST("/messages/:message_id/summary", h.HandleSummarizeThread)
+		protected.GET("/channels/:id/tasks", h.HandleGetChannelTasks)
+		protected.POST("/tasks/:id/done", h.HandleCompleteTask)
+		protected.POST("/tasks/:id/escalate", h.HandleEscalateTask)
+
+		// Notifications
+		protected.GET("/notifications", h.HandleGetNotifications)
+		protected.GET("/notifications/unread-count", h.HandleGetUnreadCount)
+		protected.GET("/notifications/:id/items", h.HandleGetNotificationItems)
+		protected.POST("/notifications/:id/read", h.HandleMarkRead)
+		protected.POST("/notifications/:id/ack", h.HandleAckNotification)
+		protected.POST("/notifications/read-all", h.HandleMarkAllRead)
+
+		// Mentions inbox
+		protected.GET("/mentions", h.HandleGetMentions)
+		protected.GET("/mentions/unread-count", h.HandleGetUnreadMentionCount)
+		protected.POST("/mentions/:id/read", h.HandleMarkMentionRead)
+		protected.POST("/mentions/read-all", h.HandleMarkAllMentionsRead)
+
+		// Muted words
+		protected.GET("/muted-words", h.HandleGetMutedWords)
+		protected.PUT("/muted-words", h.HandleSetMutedWords)
+
+		// Block list
+		protected.GET("/blocks", h.HandleGetBlockedUsers)
+		protected.POST("/blocks", h.HandleBlockUser)
+		protected.DELETE("/blocks/:user_id", h.HandleUnblockUser)
+
+		// Member search and typed autocomplete (@member, #channel, :emoji:)
+		protected.GET("/loops/:name/members/search", h.HandleSearchMembers)
+		protected.GET("/loops/:name/autocomplete", h.HandleAutocomplete)
+
+		// Members idle for a while (owner only)
+		protected.GET("/loops/:name/members/inactive", h.HandleGetInactiveMembers)
+
+		// GitHub Context + AI Summarization (acts with the caller's token, so no guests)
+		gh := protected.Group("/loops/:name/github", h.DenyGuests())
+		gh.GET("/settings", h.HandleGetGitHubSettings)
+		gh.PUT("/settings", h.HandleUpdateGitHubSettings)
+		gh.GET("/token", h.HandleGetGitHubToken)
+		gh.PUT("/token", h.HandleUpdateGitHubToken)
+		gh.GET("/token/health", h.HandleCheckGitHubToken)
+		gh.GET("/digest", h.HandleGetGitHubDigest)
+		gh.PUT("/digest", h.HandleUpdateGitHubDigest)
+		gh.DELETE("/digest", h.HandleDeleteGitHubDigest)
+		gh.GET("/webhooks", h.HandleGetWebhookHealth)
+		gh.POST("/webhooks/:id/redeliver", h.HandleRedeliverWebhook)
+		gh.GET("/deployments", h.HandleGetDeployments)
+		gh.POST("/workflows/:id/dispatch", h.HandleDispatchWorkflow)
+		gh.GET("/actions-catalog", h.HandleGetGitHubActionsCatalog)
+		gh.GET("/insights", h.HandleGetRepoInsights)
+		gh.GET("/issues", h.HandleGetGitHubIssues)
+		gh.GET("/pulls", h.HandleGetGitHubPRs)
+		gh.POST("/summarize", h.HandleGitHubSummarize)
+
+		// PR Review Sync (two-way GitHub ↔ Wireloop)
+		gh.GET("/pr/:number/comments", h.HandleGetPRComments)
+		gh.POST("/pr-comment", middleware.Idempotency(), h.HandlePostPRComment)
+		gh.POST("/pr/:number/review", h.HandleSubmitPRReview)
+		gh.GET("/issue/:number/comments", h.HandleGetIssueComments)
+		gh.POST("/issue/:number/comments", middleware.Idempotency(), h.HandlePostIssueComment)
+		gh.GET("/subscriptions", h.HandleGetGitHubSubscriptions)
+		gh.PUT("/subscriptions/:number", h.HandleSubscribeGitHubItem)
+		gh.DELETE("/subscriptions/:number", h.HandleUnsubscribeGitHubItem)
+
+		// Issue Board
+		protected.GET("/loops/:name/board", h.HandleGetBoard)
+		protected.POST("/loops/:name/board/columns", h.HandleCreateBoardColumn)
+		protected.PUT("/loops/:name/board/columns/order", h.HandleReorderBoardColumns)
+		protected.POST("/loops/:name/board/cards", h.HandleCreateBoardCard)
+		protected.POST("/loops/:name/board/sync", h.DenyGuests(), h.HandleSyncBoard)
+		protected.PUT("/board/columns/:id", h.HandleRenameBoardColumn)
+		protected.DELETE("/board/columns/:id", h.HandleDeleteBoardColumn)
+		protected.PUT("/board/cards/:id", h.HandleUpdateBoardCard)
+		protected.POST("/board/cards/:id/move", h.HandleMoveBoardCard)
+		protected.DELETE("/board/cards/:id", h.HandleDeleteBoardCard)
+
+		// Events + Activity
+		protected.GET("/loops/:name/events", h.HandleGetEvents)
+		protected.GET("/loops/:name/events/feed", h.HandleGetCalendarFeedURL)
+		protected.POST("/loops/:name/events", h.HandleCreateEvent)
+		protected.GET("/loops/:name/activity", h.HandleGetActivity)
+		protected.GET("/loops/:name/top-messages", h.HandleGetTopMessages)
+		protected.GET("/events/:id", h.HandleGetEvent)
+		protected.PUT("/events/:id", h.HandleUpdateEvent)
+		protected.DELETE("/events/:id", h.HandleDeleteEvent)
+		protected.PUT("/events/:id/rsvp", h.HandleRSVPEvent)
+
+		// Direct Messages
+		protected.GET("/dms", h.HandleGetDMs)
+		protected.POST("/dms", h.HandleOpenDM)
+		protected.GET("/dms/:id/messages", h.HandleGetDMMessages)
+		protected.POST("/dms/:id/messages", middleware.Idempotency(), h.HandleSendDM)
+		protected.POST("/dms/:id/read", h.HandleMarkDMRead)
+		protected.GET("/dms/requests", h.HandleGetDMRequests)
+		protected.POST("/dms/:id/accept", h.HandleAcceptDMRequest)
+		protected.POST("/dms/:id/decline", h.HandleDeclineDMRequest)
+		protected.GET("/dms/settings", h.HandleGetDMSettings)
+		protected.PUT("/dms/settings", h.HandleUpdateDMSettings)
+		protected.POST("/dms/groups", h.HandleCreateGroupDM)
+		protected.GET("/dms/:id/members", h.HandleGetDMMembers)
+		protected.POST("/dms/:id/members", h.HandleAddGroupDMMembers)
+		protected.POST("/dms/:id/leave", h.HandleLeaveGroupDM)
+
+		// Standups
+		protected.GET("/loops/:name/standups", h.HandleGetStandups)
+		protected.POST("/loops/:name/standups", h.HandleCreateStandup)
+		protected.PUT("/standups/:id", h.HandleUpdateStandup)
+		protected.DELETE("/standups/:id", h.HandleDeleteStandup)
+		protected.POST("/standups/:id/participants", h.HandleJoinStandup)
+		protected.DELETE("/standups/:id/participants", h.HandleLeaveStandup)
+		protected.POST("/standups/:id/respond", h.HandleStandupRespond)
+
+		// One-time tickets for opening the WebSocket or SSE stream without
+		// putting the session token in the URL
+		protected.POST("/ws-ticket", middleware.WebSocketRateLimitMiddleware(), h.HandleCreateWSTicket)
+		// Long polling for clients that can use neither
+		protected.GET("/channels/:id/poll", h.HandlePoll)
+	}
+
+	// Stream routes; the only ones that take a one-time ticket in place of
+	// the session token
+	stream := r.Group("/api")
+	stream.Use(middleware.TicketAuth(), h.APIKeyAuth(), h.AccessTokenAuth(), middleware.AuthMiddleware(), h.ImpersonationAudit())
+	{
+		// WebSocket - rate limited to prevent connection spam
+		stream.GET("/ws", middleware.WebSocketRateLimitMiddleware(), h.HandleWS)
+		// Server-Sent Events fallback for networks that block WebSockets
+		stream.GET("/stream", middleware.WebSocketRateLimitMiddleware(), h.HandleSSE)
+	}
+
+	// ===== Admin / Observability routes (basic auth protected) =====
+	admin := r.Group("/api/admin")
+	admin.Use(api.AdminAuthMiddleware())
+	{
+		admin.GET("/stats", h.HandleObsStats)
+		admin.GET("/metrics", h.HandleObsHubMetrics)
+		admin.GET("/slow-queries", h.HandleObsSlowQueries)
+		admin.GET("/users", h.HandleObsUsers)
+		admin.GET("/errors", h.HandleObsErrors)
+		admin.GET("/messages-timeline", h.HandleObsTimeline)
+		admin.GET("/active-loops", h.HandleObsLoops)
+		admin.GET("/jobs", h.HandleAdminJobs)
+		admin.POST("/jobs/:id/requeue", h.HandleAdminRequeueJob)
+		admin.POST("/jobs/types/:type/requeue", h.HandleAdminRequeueJobType)
+		admin.POST("/impersonate", h.HandleAdminImpersonate)
+		admin.POST("/accounts/merge", h.HandleAdminMergeAccounts)
+		admin.GET("/flags", h.HandleAdminListFlags)
+		admin.PUT("/flags/:key", h.HandleAdminSetFlag)
+		admin.DELETE("/flags/:key", h.HandleAdminDeleteFlag)
+		admin.GET("/quotas/:user", h.HandleAdminGetQuota)
+		admin.PUT("/quotas/:user", h.HandleAdminSetQuota)
+		admin.DELETE("/quotas/:user", h.HandleAdminDeleteQuota)
+		admin.GET("/backup", h.HandleAdminBackup)
+		admin.POST("/messages/purge-deleted", h.HandleAdminPurgeDeletedMessages)
+		admin.POST("/secrets/rewrap", h.HandleAdminRewrapSecrets)
+		admin.GET("/captures", h.HandleAdminListCaptures)
+		admin.POST("/captures", h.HandleAdminStartCapture)
+		admin.DELETE("/captures", h.HandleAdminClearCaptures)
+		admin.DELETE("/captures/:id", h.HandleAdminStopCapture)
+	}
+
+	// Load signals for autoscalers, same credentials; cheap enough to poll
+	// every few seconds
+	r.GET("/obs/load", api.AdminAuthMiddleware(), h.HandleObsLoad)
+
+	return r
+}
+
+// Simple test handler to verify DB access
+func (app *App) testDBHandler(c *gin.Context) {
+	// Example call to an sqlc generated function
+	// user, err := app.Queries.GetUserByGithubID(c, 123456)
+	c.JSON(http.StatusOK, gin.H{"message": "DB connection is live and queries are ready"})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
+	utils "wireloop/internal"
+	"wireloop/internal/api"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const seedLoop = "demo"
+
+// Seed users get negative GitHub IDs, which no real account has
+var seedUsers = []struct {
+	githubID int64
+	username string
+	role     string
+}{
+	{-1, "demo-owner", "owner"},
+	{-2, "demo-alice", "moderator"},
+	{-3, "demo-bob", "contributor"},
+}
+
+var seedMessages = []struct {
+	sender  int // index into seedUsers
+	channel string
+	content string
+}{
+	{0, "general", "Welcome to the demo loop 👋"},
+	{1, "general", "Hi! I'm looking at the open issues now."},
+	{2, "general", "@demo-alice the flaky test is fixed on main"},
+	{1, "general", "Nice, thanks!"},
+	{2, "random", "Anyone going to the community call on Thursday?"},
+}
+
+// runSeed loads demo users, a loop and a few messages into a development
+// database. It does nothing when the demo loop already exists and refuses
+// to run with APP_ENV=production.
+func runSeed(args []string) int {
+	if len(args) > 0 {
+		log.Printf("seed takes no arguments")
+		return 2
+	}
+	if strings.EqualFold(os.Getenv("APP_ENV"), "production") {
+		log.Println("refusing to seed a production database")
+		return 1
+	}
+	pool, _ := connectDB()
+	defer pool.Close()
+	ctx := context.Background()
+	queries := db.New(pool)
+
+	if _, err := queries.GetProjectByName(ctx, seedLoop); err == nil {
+		log.Printf("loop %q already exists, nothing to seed", seedLoop)
+		return 0
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		log.Printf("seed failed: %v", err)
+		return 1
+	}
+
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		return seed(ctx, queries.WithTx(tx))
+	})
+	if err != nil {
+		log.Printf("seed failed: %v", err)
+		return 1
+	}
+	log.Printf("seeded loop %q with %d users and %d messages", seedLoop, len(seedUsers), len(seedMessages))
+	return 0
+}
+
+func seed(ctx context.Context, q *db.Queries) error {
+	users := make([]db.User, len(seedUsers))
+	for i, u := range seedUsers {
+		user, err := q.UpsertUser(ctx, db.UpsertUserParams{GithubID: u.githubID, Username: u.username})
+		if err != nil {
+			return err
+		}
+		users[i] = user
+	}
+	owner := users[0]
+
+	workspace, err := q.GetPersonalWorkspace(ctx, owner.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		workspace, err = q.CreateWorkspace(ctx, db.CreateWorkspaceParams{
+			Slug:           owner.Username,
+			Name:           owner.Username,
+			BillingOwnerID: owner.ID,
+			Personal:       true,
+			DefaultRole:    "contributor",
+		})
+		if err == nil {
+			err = q.UpsertWorkspaceMember(ctx, db.UpsertWorkspaceMemberParams{
+				WorkspaceID: workspace.ID,
+				UserID:      owner.ID,
+				Role:        "owner",
+			})
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	project, err := q.CreateProject(ctx, db.CreateProjectParams{
+		GithubRepoID: -1,
+		Name:         seedLoop,
+		OwnerID:      owner.ID,
+		WorkspaceID:  workspace.ID,
+	})
+	if err != nil {
+		return err
+	}
+	if err := api.CreateDefaultBoardColumns(ctx, q, project.ID); err != nil {
+		return err
+	}
+	for i, u := range seedUsers {
+		if err := q.AddMembership(ctx, db.AddMembershipParams{
+			UserID:    users[i].ID,
+			ProjectID: project.ID,
+			Role:      pgtype.Text{String: u.role, Valid: true},
+		}); err != nil {
+			return err
+		}
+	}
+
+	channels := map[string]pgtype.UUID{}
+	for i, name := range []string{"general", "random"} {
+		ch, err := q.CreateChannel(ctx, db.CreateChannelParams{
+			ProjectID: project.ID,
+			Name:      name,
+			IsDefault: pgtype.Bool{Bool: i == 0, Valid: true},
+			Position:  pgtype.Int4{Int32: int32(i), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+		channels[name] = ch.ID
+	}
+
+	for _, m := range seedMessages {
+		if err := q.AddMessage(ctx, db.AddMessageParams{
+			ID:        utils.GetMessageId(),
+			ProjectID: project.ID,
+			ChannelID: channels[m.channel],
+			SenderID:  users[m.sender].ID,
+			Content:   m.content,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"wireloop/internal/errreport"
+)
+
+// runServe serves the API until SIGINT or SIGTERM, running background jobs
+// in-process unless -jobs=false
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	withJobs := fs.Bool("jobs", true, "run background jobs in this process")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	h := newHandler()
+	defer h.Pool.Close()
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if *withJobs {
+		startJobs(jobsCtx, h)
+	} else {
+		log.Println("Background jobs disabled; run `wireloop worker` alongside")
+	}
+	// The writer outlives the jobs so messages sent while shutting down are kept
+	writerCtx, stopWriter := context.WithCancel(context.Background())
+	defer stopWriter()
+	writerDone := make(chan struct{})
+	go func() {
+		h.Messages.Run(writerCtx)
+		close(writerDone)
+	}()
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	// Graceful shutdown
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: newRouter(h).Handler(),
+	}
+
+	go func() {
+		log.Printf("Wireloop API starting on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	// Wait for interrupt signal
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down server...")
+	stopJobs()
+
+	// Shutdown doesn't wait for or close hijacked WebSockets, nor end SSE
+	// streams, so the hub closes them itself
+	h.Hub.Shutdown()
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer shutdownCancel()
+	code := 0
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server forced to shutdown: %v", err)
+		code = 1
+	}
+	// Messages still inside their undo window go out before the writer stops,
+	// even when requests were cut off
+	h.FlushHeldMessages()
+	stopWriter()
+	<-writerDone
+	errreport.Flush(5 * time.Second)
+	if code == 0 {
+		log.Println("Server exited gracefully")
+	}
+	return code
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"wireloop/internal/errreport"
+)
+
+// runWorker runs background jobs without serving HTTP, for deployments that
+// scale them apart from the API (serve -jobs=false)
+func runWorker(args []string) int {
+	if len(args) > 0 {
+		log.Printf("worker takes no arguments")
+		return 2
+	}
+	h := newHandler()
+	defer h.Pool.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	startJobs(ctx, h)
+	log.Println("Wireloop worker running background jobs")
+	<-ctx.Done()
+
+	// Jobs cut off mid-run are requeued once they go stale
+	log.Println("Shutting down worker...")
+	errreport.Flush(5 * time.Second)
+	log.Println("Worker exited")
+	return 0
+}
@@ -0,0 +1,266 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// PERSONAL ACCESS TOKENS
+// Long-lived Bearer tokens for the CLI and scripts. Unlike API keys they act
+// with the user's full access, so they always expire, can't mint or manage
+// other credentials, and every request made with one is logged by route.
+// ============================================================================
+
+const (
+	accessTokenPrefix        = "wlp_"
+	maxAccessTokensPerUser   = 20
+	accessTokenTouchInterval = 5 * time.Minute
+)
+
+// Credential management needs a browser session
+var accessTokenDeniedPrefixes = []string{"/api/tokens", "/api/keys", "/api/sessions"}
+
+var (
+	accessTokenCache        = cache.New[string, db.PersonalAccessToken](lookupTTL, 5000) // by token hash
+	accessTokenTouchedCache = cache.New[string, bool](accessTokenTouchInterval, 5000)
+)
+
+type AccessTokenResponse struct {
+	ID         string  `json:"id"`
+	Name       string  `json:"name"`
+	Prefix     string  `json:"prefix"`
+	ExpiresAt  string  `json:"expires_at"`
+	Expired    bool    `json:"expired"`
+	LastUsedAt *string `json:"last_used_at"`
+	CreatedAt  string  `json:"created_at"`
+	Token      string  `json:"token,omitempty"` // only in the create response
+}
+
+type CreateAccessTokenRequest struct {
+	Name          string `json:"name" binding:"required,notblank,max=64"`
+	ExpiresInDays int    `json:"expires_in_days" binding:"required,min=1,max=365"`
+}
+
+func accessTokenToResponse(t db.PersonalAccessToken) AccessTokenResponse {
+	resp := AccessTokenResponse{
+		ID:        utils.UUIDToStr(t.ID),
+		Name:      t.Name,
+		Prefix:    t.Prefix,
+		ExpiresAt: utils.FormatTime(t.ExpiresAt.Time),
+		Expired:   t.ExpiresAt.Time.Before(time.Now()),
+		CreatedAt: utils.FormatTime(t.CreatedAt.Time),
+	}
+	if t.LastUsedAt.Valid {
+		s := utils.FormatTime(t.LastUsedAt.Time)
+		resp.LastUsedAt = &s
+	}
+	return resp
+}
+
+// AccessTokenAuth authenticates "Authorization: Bearer wlp_..." requests and
+// logs what they hit. Attach it before the JWT middleware; any other
+// Authorization header passes through untouched.
+func (h *Handler) AccessTokenAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || !strings.HasPrefix(raw, accessTokenPrefix) {
+			c.Next()
+			return
+		}
+
+		hash := hashCredential(raw)
+		token, err := accessTokenCache.GetOrLoad(hash, func() (db.PersonalAccessToken, error) {
+			return h.Queries.GetActivePersonalAccessTokenByHash(c, hash)
+		})
+		// The cache can outlive expiry by up to its TTL, so check again here
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && token.ExpiresAt.Time.Before(time.Now())) {
+			problem.Abort(c, http.StatusUnauthorized, "Invalid or expired token")
+			return
+		}
+		if err != nil {
+			problem.Abort(c, http.StatusInternalServerError, "failed to check token")
+			return
+		}
+		for _, prefix := range accessTokenDeniedPrefixes {
+			if strings.HasPrefix(c.FullPath(), prefix) {
+				problem.Abort(c, http.StatusForbidden, "personal access tokens can't manage credentials")
+				return
+			}
+		}
+
+		tokenID := utils.UUIDToStr(token.ID)
+		if _, seen := accessTokenTouchedCache.Get(tokenID); !seen {
+			accessTokenTouchedCache.Set(tokenID, true)
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := h.Queries.TouchPersonalAccessToken(ctx, token.ID); err != nil {
+					log.Printf("[tokens] failed to update last used for %s: %v", tokenID, err)
+				}
+			}()
+		}
+
+		middleware.SetTokenUser(c, token.UserID, tokenID)
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+		method, status := c.Request.Method, int32(c.Writer.Status())
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := h.Queries.LogPersonalAccessTokenRequest(ctx, db.LogPersonalAccessTokenRequestParams{
+				TokenID: token.ID,
+				Method:  method,
+				Path:    route,
+				Status:  status,
+			}); err != nil {
+				log.Printf("[tokens] failed to log request: %v", err)
+			}
+		}()
+	}
+}
+
+// HandleGetAccessTokens lists the caller's personal access tokens,
+// including expired ones that haven't been revoked
+func (h *Handler) HandleGetAccessTokens(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	tokens, err := h.Queries.ListUserPersonalAccessTokens(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get tokens")
+		return
+	}
+	result := make([]AccessTokenResponse, len(tokens))
+	for i, t := range tokens {
+		result[i] = accessTokenToResponse(t)
+	}
+	c.JSON(200, gin.H{"tokens": result})
+}
+
+// HandleCreateAccessToken creates a token; the response is the only time it
+// is shown
+func (h *Handler) HandleCreateAccessToken(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	var req CreateAccessTokenRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+
+	existing, err := h.Queries.ListUserPersonalAccessTokens(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to create token")
+		return
+	}
+	if len(existing) >= maxAccessTokensPerUser {
+		problem.Respond(c, 400, "you already have the maximum of 20 tokens")
+		return
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		problem.Respond(c, 500, "failed to create token")
+		return
+	}
+	raw := accessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	token, err := h.Queries.CreatePersonalAccessToken(c, db.CreatePersonalAccessTokenParams{
+		UserID:    uid,
+		Name:      req.Name,
+		Prefix:    raw[:len(accessTokenPrefix)+6],
+		TokenHash: hashCredential(raw),
+		ExpiresAt: pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, req.ExpiresInDays), Valid: true},
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to create token")
+		return
+	}
+	resp := accessTokenToResponse(token)
+	resp.Token = raw
+	c.JSON(201, resp)
+}
+
+// HandleDeleteAccessToken revokes one of the caller's tokens
+func (h *Handler) HandleDeleteAccessToken(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid token id")
+		return
+	}
+	hash, err := h.Queries.RevokePersonalAccessToken(c, db.RevokePersonalAccessTokenParams{ID: id, UserID: uid})
+	if errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 404, "token not found")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to revoke token")
+		return
+	}
+	lookupInvalidator.Invalidate("access_token", hash)
+	c.JSON(200, gin.H{"message": "token revoked"})
+}
+
+// HandleGetAccessTokenUsage shows which endpoints a token has hit and when
+func (h *Handler) HandleGetAccessTokenUsage(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid token id")
+		return
+	}
+	token, err := h.Queries.GetPersonalAccessToken(c, id)
+	if err != nil || token.UserID != uid {
+		problem.Respond(c, 404, "token not found")
+		return
+	}
+
+	rows, err := h.Queries.GetPersonalAccessTokenUsage(c, id)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get token usage")
+		return
+	}
+	result := make([]gin.H, len(rows))
+	for i, r := range rows {
+		result[i] = gin.H{
+			"method":      r.Method,
+			"path":        r.Path,
+			"hits":        r.Hits,
+			"last_hit_at": utils.FormatTime(r.LastHitAt.Time),
+		}
+	}
+	c.JSON(200, gin.H{"token": accessTokenToResponse(token), "usage": result})
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+	"log"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// ACCOUNT MERGES
+// Someone who ended up with two accounts (two GitHub identities, say) asks
+// support to fold one into the other. A platform admin moves the duplicate's
+// memberships, messages, notifications and owned loops to the account the
+// user keeps, in one transaction, signs the duplicate out and revokes its
+// API keys and personal access tokens. Every merge is recorded in
+// account_merges.
+// ============================================================================
+
+type MergeAccountsRequest struct {
+	SurvivorID string `json:"survivor_id" binding:"required,uuid"` // the account the user keeps
+	MergedID   string `json:"merged_id" binding:"required,uuid"`   // the duplicate
+	Reason     string `json:"reason" binding:"required,notblank,max=500"`
+}
+
+type AccountMergeResponse struct {
+	ID             string `json:"id"`
+	SurvivorID     string `json:"survivor_id"`
+	MergedID       string `json:"merged_id"`
+	MergedUsername string `json:"merged_username"`
+	Admin          string `json:"admin"`
+	Reason         string `json:"reason"`
+	Memberships    int    `json:"memberships"`
+	Messages       int    `json:"messages"`
+	Notifications  int    `json:"notifications"`
+	Loops          int    `json:"loops"`
+	CreatedAt      string `json:"created_at"`
+}
+
+func accountMergeToResponse(m db.AccountMerge) AccountMergeResponse {
+	return AccountMergeResponse{
+		ID:             utils.UUIDToStr(m.ID),
+		SurvivorID:     utils.UUIDToStr(m.SurvivorID),
+		MergedID:       utils.UUIDToStr(m.MergedID),
+		MergedUsername: m.MergedUsername,
+		Admin:          m.Admin,
+		Reason:         m.Reason,
+		Memberships:    int(m.Memberships),
+		Messages:       int(m.Messages),
+		Notifications:  int(m.Notifications),
+		Loops:          int(m.Loops),
+		CreatedAt:      utils.FormatTime(m.CreatedAt.Time),
+	}
+}
+
+// HandleAdminMergeAccounts folds merged_id into survivor_id. In loops both
+// belong to, the survivor keeps the stronger of the two roles. The merged
+// account stays, empty and signed out, since reactions, reads and the like
+// still point at it.
+func (h *Handler) HandleAdminMergeAccounts(c *gin.Context) {
+	var req MergeAccountsRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	if req.SurvivorID == req.MergedID {
+		problem.Respond(c, 400, "an account can't be merged into itself")
+		return
+	}
+	survivorID, _ := utils.StrToUUID(req.SurvivorID)
+	mergedID, _ := utils.StrToUUID(req.MergedID)
+	survivor, err := h.Queries.GetUserByID(c, survivorID)
+	if err != nil {
+		problem.Respond(c, 404, "surviving account not found")
+		return
+	}
+	merged, err := h.Queries.GetUserByID(c, mergedID)
+	if err != nil {
+		problem.Respond(c, 404, "merged account not found")
+		return
+	}
+	if survivor.GithubID <= 0 || merged.GithubID <= 0 {
+		problem.Respond(c, 400, "bot accounts can't be merged")
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "failed to merge accounts")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	if err := qtx.MergeMembershipRoles(c, db.MergeMembershipRolesParams{SurvivorID: survivor.ID, MergedID: merged.ID}); err != nil {
+		problem.Error(c, err, "failed to merge memberships")
+		return
+	}
+	if err := qtx.DeleteOverlappingMemberships(c, db.DeleteOverlappingMembershipsParams{MergedID: merged.ID, SurvivorID: survivor.ID}); err != nil {
+		problem.Error(c, err, "failed to merge memberships")
+		return
+	}
+	memberships, err := qtx.ReassignMemberships(c, db.ReassignMembershipsParams{SurvivorID: survivor.ID, MergedID: merged.ID})
+	if err != nil {
+		problem.Error(c, err, "failed to merge memberships")
+		return
+	}
+	messages, err := qtx.ReassignMessages(c, db.ReassignMessagesParams{SurvivorID: survivor.ID, MergedID: merged.ID})
+	if err != nil {
+		problem.Error(c, err, "failed to reassign messages")
+		return
+	}
+	notifications, err := qtx.ReassignNotifications(c, db.ReassignNotificationsParams{SurvivorID: survivor.ID, MergedID: merged.ID})
+	if err != nil {
+		problem.Error(c, err, "failed to reassign notifications")
+		return
+	}
+	if err := qtx.ReassignNotificationActors(c, db.ReassignNotificationActorsParams{
+		SurvivorID:       survivor.ID,
+		SurvivorUsername: survivor.Username,
+		MergedID:         merged.ID,
+	}); err != nil {
+		problem.Error(c, err, "failed to reassign notifications")
+		return
+	}
+	loops, err := qtx.TransferOwnedLoops(c, db.TransferOwnedLoopsParams{SurvivorID: survivor.ID, MergedID: merged.ID})
+	if err != nil {
+		problem.Error(c, err, "failed to transfer loops")
+		return
+	}
+	sessions, err := qtx.RevokeUserSessions(c, merged.ID)
+	if err != nil {
+		problem.Error(c, err, "failed to sign out the merged account")
+		return
+	}
+	apiKeys, err := qtx.RevokeUserAPIKeys(c, merged.ID)
+	if err != nil {
+		problem.Error(c, err, "failed to sign out the merged account")
+		return
+	}
+	accessTokens, err := qtx.RevokeUserPersonalAccessTokens(c, merged.ID)
+	if err != nil {
+		problem.Error(c, err, "failed to sign out the merged account")
+		return
+	}
+	admin, _, _ := c.Request.BasicAuth()
+	record, err := qtx.CreateAccountMerge(c, db.CreateAccountMergeParams{
+		SurvivorID:     survivor.ID,
+		MergedID:       merged.ID,
+		MergedUsername: merged.Username,
+		Admin:          admin,
+		Reason:         req.Reason,
+		Memberships:    int32(memberships),
+		Messages:       int32(messages),
+		Notifications:  int32(notifications),
+		Loops:          int32(len(loops)),
+	})
+	if err != nil {
+		problem.Error(c, err, "failed to record the merge")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Error(c, err, "failed to merge accounts")
+		return
+	}
+
+	for _, l := range loops {
+		invalidateProject(db.Project{ID: l.ID, Name: l.Name})
+	}
+	for _, id := range sessions {
+		lookupInvalidator.Invalidate("session", utils.UUIDToStr(id))
+	}
+	for _, hash := range apiKeys {
+		lookupInvalidator.Invalidate("api_key", hash)
+	}
+	for _, hash := range accessTokens {
+		lookupInvalidator.Invalidate("access_token", hash)
+	}
+	log.Printf("[account-merge] %s merged %s into %s: %s", admin, merged.Username, survivor.Username, req.Reason)
+	c.JSON(200, accountMergeToResponse(record))
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	defaultActivityLimit = 30
+	maxActivityLimit     = 100
+	upcomingWindow       = 7 * 24 * time.Hour
+	upcomingLimit        = 5
+)
+
+type ActivityItem struct {
+	ID            string `json:"id"`
+	Kind          string `json:"kind"`
+	RefID         string `json:"ref_id,omitempty"`
+	Summary       string `json:"summary"`
+	ActorUsername string `json:"actor_username,omitempty"`
+	ActorAvatar   string `json:"actor_avatar,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// recordActivity appends to a loop's activity feed; failures are logged, not surfaced
+func (h *Handler) recordActivity(ctx context.Context, projectID, actorID pgtype.UUID, kind, refID, summary string) {
+	if err := h.Queries.CreateActivity(ctx, db.CreateActivityParams{
+		ProjectID: projectID,
+		ActorID:   actorID,
+		Kind:      kind,
+		RefID:     pgtype.Text{String: refID, Valid: refID != ""},
+		Summary:   summary,
+	}); err != nil {
+		log.Printf("[activity] failed to record %s: %v", kind, err)
+	}
+}
+
+// HandleGetActivity returns the loop's recent activity plus upcoming events
+func (h *Handler) HandleGetActivity(c *gin.Context) {
+	project, _, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultActivityLimit
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxActivityLimit)
+	}
+
+	rows, err := h.Queries.GetLoopActivity(c, db.GetLoopActivityParams{
+		ProjectID: project.ID,
+		Limit:     int32(limit),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get activity")
+		return
+	}
+
+	items := make([]ActivityItem, 0, len(rows))
+	for _, r := range rows {
+		items = append(items, ActivityItem{
+			ID:            utils.UUIDToStr(r.ID),
+			Kind:          r.Kind,
+			RefID:         r.RefID.String,
+			Summary:       r.Summary,
+			ActorUsername: r.ActorUsername.String,
+			ActorAvatar:   mediaURL(r.ActorAvatar.String),
+			CreatedAt:     utils.FormatTime(r.CreatedAt.Time),
+		})
+	}
+
+	now := time.Now()
+	upcoming, err := h.loopOccurrences(c, project.ID, now, now.Add(upcomingWindow), upcomingLimit)
+	if err != nil {
+		log.Printf("[activity] failed to load upcoming events for %s: %v", project.Name, err)
+		upcoming = []EventOccurrence{}
+	}
+
+	c.JSON(200, gin.H{"upcoming_events": upcoming, "items": items})
+}
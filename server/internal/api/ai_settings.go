@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// AI SETTINGS
+// Loops can turn the AI features off entirely, so none of their content is
+// sent to the model, or tune them: the model, a cap on output tokens, the
+// temperature, how strictly Gemini's safety filters block, and the language
+// and tone of what the model writes. Every AI call takes its options from
+// loopAI.
+// ============================================================================
+
+const (
+	defaultAIModel       = "gemini-2.5-flash"
+	defaultAITemperature = 0.3
+)
+
+// Models a loop may choose; "" uses GEMINI_MODEL or defaultAIModel
+var aiModels = map[string]bool{
+	"gemini-2.5-flash":      true,
+	"gemini-2.5-flash-lite": true,
+	"gemini-2.5-pro":        true,
+}
+
+// Safety levels a loop may choose, with the Gemini threshold each applies
+// to every harm category; "" leaves Gemini's defaults
+var aiSafetyThresholds = map[string]string{
+	"block_none": "BLOCK_NONE",
+	"block_few":  "BLOCK_ONLY_HIGH",
+	"block_some": "BLOCK_MEDIUM_AND_ABOVE",
+	"block_most": "BLOCK_LOW_AND_ABOVE",
+}
+
+// Languages a loop may have AI content written in, by code; "" is English
+var aiLanguages = map[string]string{
+	"en": "English",
+	"fr": "French",
+	"de": "German",
+	"es": "Spanish",
+	"pt": "Portuguese",
+	"it": "Italian",
+	"nl": "Dutch",
+	"pl": "Polish",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"zh": "Simplified Chinese",
+}
+
+// Tone presets a loop may choose, with what each asks of the model; ""
+// leaves each feature its own style
+var aiTones = map[string]string{
+	"formal":  "Use a formal, neutral register: complete sentences, no slang, no exclamations.",
+	"casual":  "Use a friendly, casual register, as a teammate would in chat. Stay accurate.",
+	"bullets": "Answer only with short bullet points: no paragraphs, no introduction, no closing line. Keep any headings the format asks for.",
+}
+
+var geminiHarmCategories = []string{
+	"HARM_CATEGORY_HARASSMENT",
+	"HARM_CATEGORY_HATE_SPEECH",
+	"HARM_CATEGORY_SEXUALLY_EXPLICIT",
+	"HARM_CATEGORY_DANGEROUS_CONTENT",
+}
+
+// errAIDisabled is returned for loops that turned AI off; like
+// errAINotConfigured it is expected and not reported
+var errAIDisabled = errors.New("AI features are turned off in this loop")
+
+// aiOptions configure one model call
+type aiOptions struct {
+	Model       string
+	MaxTokens   int
+	Temperature float64
+	Safety      string   // a key of aiSafetyThresholds, or "" for Gemini's defaults
+	Content     []string // the aiContentKinds that may be sent; nil for all
+	Language    string   // a key of aiLanguages, or "" for English
+	Tone        string   // a key of aiTones, or "" for the feature's own style
+}
+
+// loopAI returns the options for an AI call made for the loop, with the
+// feature's token budget capped by the loop's, or errAIDisabled
+func (h *Handler) loopAI(ctx context.Context, projectID pgtype.UUID, maxTokens int) (aiOptions, error) {
+	opts := aiOptions{
+		Model:       os.Getenv("GEMINI_MODEL"),
+		MaxTokens:   maxTokens,
+		Temperature: defaultAITemperature,
+	}
+	if opts.Model == "" {
+		opts.Model = defaultAIModel
+	}
+	s, err := h.Queries.GetLoopSettings(ctx, projectID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("[ai] failed to load loop settings, using defaults: %v", err)
+		}
+		return opts, nil
+	}
+	if s.AiDisabled {
+		return opts, errAIDisabled
+	}
+	if s.AiModel != "" {
+		opts.Model = s.AiModel
+	}
+	if s.AiMaxTokens > 0 && int(s.AiMaxTokens) < opts.MaxTokens {
+		opts.MaxTokens = int(s.AiMaxTokens)
+	}
+	if s.AiTemperature.Valid {
+		opts.Temperature = float64(s.AiTemperature.Float32)
+	}
+	opts.Safety = s.AiSafety
+	opts.Content = s.AiContent
+	opts.Language = s.AiLanguage
+	opts.Tone = s.AiTone
+	return opts, nil
+}
@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// OWNER ANALYTICS
+// Messages and memberships are rolled up per loop and UTC day by a
+// background job; the overview only reads the rollups. Today's row is
+// refreshed every analyticsRollupEvery, so it trails live activity by up to
+// that long.
+// ============================================================================
+
+const (
+	analyticsRollupEvery  = time.Hour
+	analyticsBackfillDays = 90 // days rolled up when the tables are empty
+	defaultAnalyticsDays  = 30
+	maxAnalyticsDays      = 180
+	analyticsTopPerLoop   = 5
+)
+
+type AnalyticsDay struct {
+	Day           string `json:"day"`
+	Members       int32  `json:"members"`
+	NewMembers    int32  `json:"new_members"`
+	Messages      int32  `json:"messages"`
+	ActiveMembers int32  `json:"active_members"`
+}
+
+type AnalyticsContributor struct {
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+	Messages  int32  `json:"messages"`
+}
+
+type LoopAnalytics struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Growth over the period
+	Members      int32 `json:"members"`
+	MembersStart int32 `json:"members_start"`
+	NewMembers   int32 `json:"new_members"`
+	// Retention: distinct senders, and their share of members
+	Active7d       int32   `json:"active_7d"`
+	Active30d      int32   `json:"active_30d"`
+	Retention7d    float64 `json:"retention_7d"`
+	Retention30d   float64 `json:"retention_30d"`
+	Messages       int32   `json:"messages"`
+	MessagesBefore int32   `json:"messages_previous_period"`
+	// Percent change against the previous period of the same length; nil
+	// when that period had no messages
+	MessagesChange  *float64               `json:"messages_change_pct"`
+	Daily           []AnalyticsDay         `json:"daily"`
+	TopContributors []AnalyticsContributor `json:"top_contributors"`
+
+	// Members with no message or connection for 30 and 90 days
+	Inactive30d int32 `json:"inactive_30d"`
+	Inactive90d int32 `json:"inactive_90d"`
+}
+
+// StartAnalyticsRollup keeps the loop rollups current until ctx is
+// cancelled. Rollups are idempotent, so instances running it side by side
+// only repeat work.
+func (h *Handler) StartAnalyticsRollup(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(analyticsRollupEvery)
+		defer ticker.Stop()
+		h.backfillAnalytics(ctx)
+		h.awardReactionBadges(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			// Yesterday again, for messages that landed after its last run
+			today := time.Now().UTC().Truncate(24 * time.Hour)
+			for _, day := range []time.Time{today.AddDate(0, 0, -1), today} {
+				if err := h.rollupAnalyticsDay(ctx, day); err != nil && ctx.Err() == nil {
+					log.Printf("[analytics] rollup of %s failed: %v", day.Format(time.DateOnly), err)
+				}
+			}
+			h.awardReactionBadges(ctx)
+		}
+	}()
+}
+
+// backfillAnalytics rolls up every day since the last rollup (at most
+// analyticsBackfillDays back) through today
+func (h *Handler) backfillAnalytics(ctx context.Context) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	from := today.AddDate(0, 0, -analyticsBackfillDays)
+	latest, err := h.Queries.GetLatestLoopStatsDay(ctx)
+	// Reaction rollups came later; start from whichever is further behind
+	if r, rerr := h.Queries.GetLatestLoopReactionStatsDay(ctx); rerr == nil && (!r.Valid || r.Time.Before(latest.Time)) {
+		latest = r
+	}
+	if err == nil && latest.Valid && latest.Time.After(from) {
+		from = latest.Time
+	}
+	for day := from; !day.After(today); day = day.AddDate(0, 0, 1) {
+		if err := h.rollupAnalyticsDay(ctx, day); err != nil {
+			if ctx.Err() == nil {
+				log.Printf("[analytics] backfill of %s failed: %v", day.Format(time.DateOnly), err)
+			}
+			return
+		}
+	}
+}
+
+func (h *Handler) rollupAnalyticsDay(ctx context.Context, day time.Time) error {
+	d := pgtype.Date{Time: day, Valid: true}
+	tx, err := h.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+	// Members whose messages were all deleted drop out of the day
+	if err := qtx.DeleteLoopMemberDayStats(ctx, d); err != nil {
+		return err
+	}
+	if err := qtx.RollupLoopMemberDay(ctx, d); err != nil {
+		return err
+	}
+	if err := qtx.RollupLoopDay(ctx, d); err != nil {
+		return err
+	}
+	if err := qtx.DeleteLoopReactionDayStats(ctx, d); err != nil {
+		return err
+	}
+	if err := qtx.RollupLoopReactionDay(ctx, d); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// HandleGetAnalyticsOverview compares the loops the caller owns over the
+// last ?days (default 30, at most 180)
+func (h *Handler) HandleGetAnalyticsOverview(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	days := defaultAnalyticsDays
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxAnalyticsDays {
+			problem.Respond(c, 400, "days must be between 1 and 180")
+			return
+		}
+		days = n
+	}
+
+	projects, err := h.Queries.GetProjectsByOwner(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get loops")
+		return
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	since := today.AddDate(0, 0, 1-days)
+	// The previous period is read too, for the trend
+	stats, err := h.Queries.GetOwnerLoopDailyStats(c, db.GetOwnerLoopDailyStatsParams{
+		OwnerID: uid,
+		Day:     pgtype.Date{Time: since.AddDate(0, 0, -days), Valid: true},
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get analytics")
+		return
+	}
+	active, err := h.Queries.GetOwnerLoopActiveMembers(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get analytics")
+		return
+	}
+	inactive, err := h.Queries.GetOwnerLoopInactiveMembers(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get analytics")
+		return
+	}
+	top, err := h.Queries.GetOwnerTopContributors(c, db.GetOwnerTopContributorsParams{
+		OwnerID: uid,
+		Since:   pgtype.Date{Time: since, Valid: true},
+		PerLoop: analyticsTopPerLoop,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get analytics")
+		return
+	}
+
+	byID := make(map[pgtype.UUID]*LoopAnalytics, len(projects))
+	loops := make([]*LoopAnalytics, 0, len(projects))
+	for _, p := range projects {
+		la := &LoopAnalytics{
+			ID:              utils.UUIDToStr(p.ID),
+			Name:            p.Name,
+			Daily:           []AnalyticsDay{},
+			TopContributors: []AnalyticsContributor{},
+		}
+		byID[p.ID] = la
+		loops = append(loops, la)
+	}
+	for _, s := range stats {
+		la := byID[s.ProjectID]
+		if la == nil {
+			continue
+		}
+		if s.Day.Time.Before(since) {
+			la.MessagesBefore += s.Messages
+			continue
+		}
+		if len(la.Daily) == 0 {
+			la.MembersStart = s.Members - s.NewMembers
+		}
+		la.Members = s.Members
+		la.NewMembers += s.NewMembers
+		la.Messages += s.Messages
+		la.Daily = append(la.Daily, AnalyticsDay{
+			Day:           s.Day.Time.Format(time.DateOnly),
+			Members:       s.Members,
+			NewMembers:    s.NewMembers,
+			Messages:      s.Messages,
+			ActiveMembers: s.ActiveMembers,
+		})
+	}
+	for _, a := range active {
+		if la := byID[a.ProjectID]; la != nil {
+			la.Active7d, la.Active30d = a.Active7d, a.Active30d
+		}
+	}
+	for _, in := range inactive {
+		if la := byID[in.ProjectID]; la != nil {
+			la.Inactive30d, la.Inactive90d = in.Inactive30d, in.Inactive90d
+		}
+	}
+	for _, t := range top {
+		if la := byID[t.ProjectID]; la != nil {
+			la.TopContributors = append(la.TopContributors, AnalyticsContributor{
+				Username:  t.Username,
+				AvatarURL: mediaURL(t.AvatarUrl.String),
+				Messages:  t.Messages,
+			})
+		}
+	}
+	for _, la := range loops {
+		if la.Members > 0 {
+			la.Retention7d = float64(la.Active7d) / float64(la.Members)
+			la.Retention30d = float64(la.Active30d) / float64(la.Members)
+		}
+		if la.MessagesBefore > 0 {
+			change := 100 * float64(la.Messages-la.MessagesBefore) / float64(la.MessagesBefore)
+			la.MessagesChange = &change
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"days":  days,
+		"since": since.Format(time.DateOnly),
+		"loops": loops,
+	})
+}
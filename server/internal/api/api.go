@@ -1,8 +1,23 @@
 package api
 
 import (
+	"errors"
 	"wireloop/internal/chat"
 	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/flags"
+	"wireloop/internal/github"
+	"wireloop/internal/integrations"
+	"wireloop/internal/jobs"
+	"wireloop/internal/loops"
+	"wireloop/internal/members"
+	"wireloop/internal/messages"
+	"wireloop/internal/middleware"
+	"wireloop/internal/notify"
+	"wireloop/internal/quota"
+	"wireloop/internal/scan"
+	"wireloop/internal/secrets"
+	"wireloop/internal/storage"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -12,4 +27,134 @@ type Handler struct {
 	Queries *db.Queries
 	Pool    *pgxpool.Pool
 	Hub     *chat.Hub
+	// Business rules over Queries: loop lookups, membership and roles, and
+	// message deletion. Handlers go through these rather than repeating them.
+	Loops        *loops.Service
+	Members      *members.Service
+	MessageRules *messages.Service
+	Events       *events.Bus // publishes to Hub
+	Jobs         *jobs.Queue
+	Storage      storage.Store
+	Scanner      scan.Scanner
+	Flags        *flags.Store
+	// Plan limits; nil enforces none
+	Quotas *quota.Store
+	// Every notification goes out through here
+	Notifier *notify.Service
+	// Loop integrations and the events dispatched to them
+	Integrations *integrations.Service
+	// Encrypted integration credentials; nil when no master key is set
+	Secrets *secrets.Store
+	// Mints installation tokens for loops reading GitHub as the app; nil
+	// when no app is configured
+	GitHubApp *github.App
+
+	// Queries over the slow-query threshold, for /api/admin/slow-queries
+	SlowQueries *db.SlowQueryLog
+	// 5xx responses per route, for /api/admin/errors
+	ErrorRates *middleware.ErrorRates
+	// Admin-enabled request/response recording
+	Captures *middleware.CaptureStore
+	// Batches WebSocket message inserts; nil writes each message directly
+	Messages *db.MessageWriter
+	// Longest message, in characters, a send accepts; longer markdown has to
+	// go as a long post
+	MaxMessageChars int
+}
+
+// Config holds a Handler's optional dependencies. Storage must be set;
+// NewHandler fills the rest with working defaults when left nil.
+type Config struct {
+	Storage storage.Store
+	Jobs    *jobs.Queue  // default jobs.New(queries)
+	Scanner scan.Scanner // default scan.Noop
+	Flags   *flags.Store // default flags.New(queries)
+	Quotas  *quota.Store // nil enforces no limits
+	// default notify.New(queries, hub, jobs); transports are added by the caller
+	Notifier *notify.Service
+	// default integrations.New(queries, jobs) with the built-in kinds
+	Integrations *integrations.Service
+	Secrets      *secrets.Store // nil turns secrets off
+	GitHubApp    *github.App
+
+	SlowQueries *db.SlowQueryLog
+	ErrorRates  *middleware.ErrorRates   // default without an alert webhook
+	Captures    *middleware.CaptureStore // default new, capturing nothing until enabled
+	Messages    *db.MessageWriter
+
+	MaxMessageChars int // default defaultMaxMessageChars
+}
+
+// NewHandler wires a Handler. Queries, Pool and Hub are used throughout and
+// are required; a missing one is an error here rather than a nil-pointer
+// panic in whichever route reaches it first.
+func NewHandler(queries *db.Queries, pool *pgxpool.Pool, hub *chat.Hub, cfg Config) (*Handler, error) {
+	switch {
+	case queries == nil:
+		return nil, errors.New("api: queries are required")
+	case pool == nil:
+		return nil, errors.New("api: pool is required")
+	case hub == nil:
+		return nil, errors.New("api: hub is required")
+	case cfg.Storage == nil:
+		return nil, errors.New("api: storage is required")
+	}
+	h := &Handler{
+		Queries:      queries,
+		Pool:         pool,
+		Hub:          hub,
+		Loops:        loops.New(queries),
+		Members:      members.New(queries),
+		MessageRules: messages.New(queries),
+		Events:       events.NewBus(hub),
+		Jobs:         cfg.Jobs,
+		Storage:      cfg.Storage,
+		Scanner:      cfg.Scanner,
+		Flags:        cfg.Flags,
+		Quotas:       cfg.Quotas,
+		Notifier:     cfg.Notifier,
+		Integrations: cfg.Integrations,
+		Secrets:      cfg.Secrets,
+		GitHubApp:    cfg.GitHubApp,
+		SlowQueries:  cfg.SlowQueries,
+		ErrorRates:   cfg.ErrorRates,
+		Captures:     cfg.Captures,
+		Messages:     cfg.Messages,
+
+		MaxMessageChars: cfg.MaxMessageChars,
+	}
+	lookupInvalidator.Register("project_name", h.Loops.ForgetName)
+	lookupInvalidator.Register("project_id", h.Loops.ForgetID)
+	if h.Jobs == nil {
+		h.Jobs = jobs.New(queries)
+	}
+	if h.MaxMessageChars <= 0 {
+		h.MaxMessageChars = defaultMaxMessageChars
+	}
+	if h.Scanner == nil {
+		h.Scanner = scan.Noop{}
+	}
+	if h.Flags == nil {
+		h.Flags = flags.New(queries)
+	}
+	if h.Notifier == nil {
+		h.Notifier = notify.New(queries, hub, h.Jobs)
+	}
+	h.Notifier.AddFilter(h.notBlocked)
+	h.Notifier.AddFilter(h.notifyLevelAllows)
+	gate.UseStarSource(h.cachedStarCount)
+	if h.Integrations == nil {
+		h.Integrations = integrations.New(queries, h.Jobs)
+	}
+	lookupInvalidator.Register("loop_integrations", h.Integrations.Forget)
+	if h.Secrets != nil {
+		h.Integrations.UseCredentials(h.resolveCredential)
+	}
+	if h.ErrorRates == nil {
+		h.ErrorRates = middleware.NewErrorRates(middleware.ErrorAlert{})
+	}
+	if h.Captures == nil {
+		h.Captures = middleware.NewCaptureStore()
+	}
+	return h, nil
 }
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/ulule/limiter/v3"
+	"github.com/ulule/limiter/v3/drivers/store/memory"
+)
+
+// ============================================================================
+// SCOPED API KEYS
+// Programmatic access for scripts and bots, sent as X-API-Key. A key only
+// reaches the routes its scopes cover (apiKeyRoutes) and has its own rate
+// limit; everything else, including key management, needs a browser session.
+// ============================================================================
+
+const (
+	scopeMessagesRead  = "messages:read"
+	scopeMessagesWrite = "messages:write"
+	scopeGitHubRead    = "github:read"
+
+	apiKeyPrefix        = "wlk_"
+	maxAPIKeysPerUser   = 20
+	defaultAPIKeyLimit  = 60 // requests per minute
+	apiKeyTouchInterval = 5 * time.Minute
+)
+
+// apiKeyRoutes maps "METHOD route pattern" to the scope a key needs for it
+var apiKeyRoutes = map[string]string{
+	"GET /api/loops/:name/channels":                      scopeMessagesRead,
+	"GET /api/loops/:name/messages":                      scopeMessagesRead,
+	"GET /api/channels/:id/messages":                     scopeMessagesRead,
+	"GET /api/messages/:message_id/replies":              scopeMessagesRead,
+	"POST /api/loop/message":                             scopeMessagesWrite,
+	"GET /api/loops/:name/github/deployments":            scopeGitHubRead,
+	"GET /api/loops/:name/github/insights":               scopeGitHubRead,
+	"GET /api/loops/:name/github/issues":                 scopeGitHubRead,
+	"GET /api/loops/:name/github/pulls":                  scopeGitHubRead,
+	"GET /api/loops/:name/github/pr/:number/comments":    scopeGitHubRead,
+	"GET /api/loops/:name/github/issue/:number/comments": scopeGitHubRead,
+}
+
+var (
+	apiKeyCache        = cache.New[string, db.ApiKey](lookupTTL, 5000) // by key hash
+	apiKeyTouchedCache = cache.New[string, bool](apiKeyTouchInterval, 5000)
+	apiKeyLimiters     sync.Map // rate per minute -> *limiter.Limiter
+	apiKeyLimiterStore = memory.NewStore()
+)
+
+type APIKeyResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	RateLimit  int32    `json:"rate_limit"`
+	LastUsedAt *string  `json:"last_used_at"`
+	CreatedAt  string   `json:"created_at"`
+	Key        string   `json:"key,omitempty"` // only in the create response
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string   `json:"name" binding:"required,notblank,max=64"`
+	Scopes    []string `json:"scopes" binding:"required,min=1,dive,oneof=messages:read messages:write github:read"`
+	RateLimit *int     `json:"rate_limit" binding:"omitempty,min=1,max=600"`
+}
+
+func apiKeyToResponse(k db.ApiKey) APIKeyResponse {
+	resp := APIKeyResponse{
+		ID:        utils.UUIDToStr(k.ID),
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Scopes:    k.Scopes,
+		RateLimit: k.RateLimit,
+		CreatedAt: utils.FormatTime(k.CreatedAt.Time),
+	}
+	if k.LastUsedAt.Valid {
+		t := utils.FormatTime(k.LastUsedAt.Time)
+		resp.LastUsedAt = &t
+	}
+	return resp
+}
+
+// hashCredential is how API keys and personal access tokens are stored
+func hashCredential(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func apiKeyLimiter(perMinute int32) *limiter.Limiter {
+	if l, ok := apiKeyLimiters.Load(perMinute); ok {
+		return l.(*limiter.Limiter)
+	}
+	l, _ := apiKeyLimiters.LoadOrStore(perMinute, limiter.New(apiKeyLimiterStore, limiter.Rate{
+		Period: time.Minute,
+		Limit:  int64(perMinute),
+	}))
+	return l.(*limiter.Limiter)
+}
+
+// APIKeyAuth authenticates requests carrying X-API-Key. Attach it before
+// the JWT middleware; requests without the header pass through untouched.
+func (h *Handler) APIKeyAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := c.GetHeader("X-API-Key")
+		if raw == "" {
+			c.Next()
+			return
+		}
+
+		hash := hashCredential(raw)
+		key, err := apiKeyCache.GetOrLoad(hash, func() (db.ApiKey, error) {
+			return h.Queries.GetActiveAPIKeyByHash(c, hash)
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			problem.Abort(c, http.StatusUnauthorized, "Invalid API key")
+			return
+		}
+		if err != nil {
+			problem.Abort(c, http.StatusInternalServerError, "failed to check API key")
+			return
+		}
+
+		scope, allowed := apiKeyRoutes[c.Request.Method+" "+c.FullPath()]
+		if !allowed {
+			problem.Abort(c, http.StatusForbidden, "API keys can't access this endpoint")
+			return
+		}
+		hasScope := false
+		for _, s := range key.Scopes {
+			hasScope = hasScope || s == scope
+		}
+		if !hasScope {
+			problem.Abort(c, http.StatusForbidden, "API key is missing the "+scope+" scope")
+			return
+		}
+
+		keyID := utils.UUIDToStr(key.ID)
+		if lc, err := apiKeyLimiter(key.RateLimit).Get(c, keyID); err == nil && lc.Reached {
+			problem.AbortCode(c, http.StatusTooManyRequests, "rate_limit_exceeded", "This API key is over its rate limit", gin.H{
+				"retry_after": time.Until(time.Unix(lc.Reset, 0)).Round(time.Second).String(),
+			})
+			return
+		}
+
+		if _, seen := apiKeyTouchedCache.Get(keyID); !seen {
+			apiKeyTouchedCache.Set(keyID, true)
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := h.Queries.TouchAPIKey(ctx, key.ID); err != nil {
+					log.Printf("[api-keys] failed to update last used for %s: %v", keyID, err)
+				}
+			}()
+		}
+
+		middleware.SetAPIKeyUser(c, key.UserID, keyID)
+		c.Next()
+	}
+}
+
+// HandleGetAPIKeys lists the caller's active API keys
+func (h *Handler) HandleGetAPIKeys(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	keys, err := h.Queries.ListUserAPIKeys(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get API keys")
+		return
+	}
+	result := make([]APIKeyResponse, len(keys))
+	for i, k := range keys {
+		result[i] = apiKeyToResponse(k)
+	}
+	c.JSON(200, gin.H{"keys": result})
+}
+
+// HandleCreateAPIKey creates a key; the response is the only time it is shown
+func (h *Handler) HandleCreateAPIKey(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	var req CreateAPIKeyRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	rate := defaultAPIKeyLimit
+	if req.RateLimit != nil {
+		rate = *req.RateLimit
+	}
+
+	existing, err := h.Queries.ListUserAPIKeys(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to create API key")
+		return
+	}
+	if len(existing) >= maxAPIKeysPerUser {
+		problem.Respond(c, 400, "you already have the maximum of 20 API keys")
+		return
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		problem.Respond(c, 500, "failed to create API key")
+		return
+	}
+	raw := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	key, err := h.Queries.CreateAPIKey(c, db.CreateAPIKeyParams{
+		UserID:    uid,
+		Name:      req.Name,
+		Prefix:    raw[:len(apiKeyPrefix)+6],
+		KeyHash:   hashCredential(raw),
+		Scopes:    req.Scopes,
+		RateLimit: int32(rate),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to create API key")
+		return
+	}
+	resp := apiKeyToResponse(key)
+	resp.Key = raw
+	c.JSON(201, resp)
+}
+
+// HandleDeleteAPIKey revokes one of the caller's API keys
+func (h *Handler) HandleDeleteAPIKey(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid key id")
+		return
+	}
+	hash, err := h.Queries.RevokeAPIKey(c, db.RevokeAPIKeyParams{ID: id, UserID: uid})
+	if errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 404, "API key not found")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to revoke API key")
+		return
+	}
+	lookupInvalidator.Invalidate("api_key", hash)
+	c.JSON(200, gin.H{"message": "API key revoked"})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"testing"
+
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/scan"
+	"wireloop/internal/storage"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func testDeps(t *testing.T) (*db.Queries, *pgxpool.Pool, *chat.Hub, storage.Store) {
+	t.Helper()
+	// The pool connects lazily, so no database is needed
+	pool, err := pgxpool.New(context.Background(), "postgres://wireloop@127.0.0.1:1/wireloop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Close)
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db.New(pool), pool, chat.NewHub(nil), store
+}
+
+func TestNewHandlerRequiresCoreDependencies(t *testing.T) {
+	queries, pool, hub, store := testDeps(t)
+	cases := []struct {
+		name    string
+		queries *db.Queries
+		pool    *pgxpool.Pool
+		hub     *chat.Hub
+		store   storage.Store
+	}{
+		{"queries", nil, pool, hub, store},
+		{"pool", queries, nil, hub, store},
+		{"hub", queries, pool, nil, store},
+		{"storage", queries, pool, hub, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := NewHandler(tc.queries, tc.pool, tc.hub, Config{Storage: tc.store})
+			if err == nil || h != nil {
+				t.Fatalf("NewHandler without %s = %v, %v; want an error", tc.name, h, err)
+			}
+		})
+	}
+}
+
+func TestNewHandlerFillsDefaults(t *testing.T) {
+	queries, pool, hub, store := testDeps(t)
+	h, err := NewHandler(queries, pool, hub, Config{Storage: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Queries != queries || h.Pool != pool || h.Hub != hub || h.Storage != store {
+		t.Error("required dependencies not wired through")
+	}
+	if h.Jobs == nil || h.Flags == nil || h.ErrorRates == nil || h.Captures == nil || h.Notifier == nil {
+		t.Errorf("defaults missing: jobs=%v flags=%v error rates=%v captures=%v notifier=%v", h.Jobs, h.Flags, h.ErrorRates, h.Captures, h.Notifier)
+	}
+	if h.Loops == nil || h.Members == nil || h.MessageRules == nil {
+		t.Errorf("services missing: loops=%v members=%v messages=%v", h.Loops, h.Members, h.MessageRules)
+	}
+	if _, ok := h.Scanner.(scan.Noop); !ok {
+		t.Errorf("Scanner = %T, want scan.Noop", h.Scanner)
+	}
+	if h.Quotas != nil || h.Messages != nil {
+		t.Error("optional dependencies should stay nil when not given")
+	}
+}
+
+func TestNewHandlerKeepsGivenDependencies(t *testing.T) {
+	queries, pool, hub, store := testDeps(t)
+	scanner := &scan.HTTP{URL: "http://scanner.invalid"}
+	h, err := NewHandler(queries, pool, hub, Config{Storage: store, Scanner: scanner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Scanner != scanner {
+		t.Errorf("Scanner = %v, want the configured one", h.Scanner)
+	}
+}
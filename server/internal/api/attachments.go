@@ -0,0 +1,311 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	jobAttachmentScan     = "attachment_scan"
+	maxAttachmentSize     = 25 << 20
+	maxAttachmentListSize = 100
+
+	scanStatusPending  = "pending"
+	scanStatusClean    = "clean"
+	scanStatusInfected = "infected"
+)
+
+type AttachmentResponse struct {
+	ID          string `json:"id"`
+	LoopID      string `json:"loop_id"`
+	ChannelID   string `json:"channel_id,omitempty"`
+	UploaderID  string `json:"uploader_id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	ScanStatus  string `json:"scan_status"`
+	Quarantined bool   `json:"quarantined"` // true until the scan passes; show a placeholder
+	DownloadURL string `json:"download_url,omitempty"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type attachmentScanPayload struct {
+	AttachmentID string `json:"attachment_id"`
+}
+
+func attachmentToResponse(a db.Attachment) AttachmentResponse {
+	resp := AttachmentResponse{
+		ID:          utils.UUIDToStr(a.ID),
+		LoopID:      utils.UUIDToStr(a.ProjectID),
+		UploaderID:  utils.UUIDToStr(a.UploaderID),
+		Filename:    a.Filename,
+		ContentType: a.ContentType,
+		Size:        a.SizeBytes,
+		ScanStatus:  a.ScanStatus,
+		Quarantined: a.ScanStatus != scanStatusClean,
+		CreatedAt:   utils.FormatTime(a.CreatedAt.Time),
+	}
+	if a.ChannelID.Valid {
+		resp.ChannelID = utils.UUIDToStr(a.ChannelID)
+	}
+	if !resp.Quarantined {
+		resp.DownloadURL = attachmentURL(a)
+	}
+	return resp
+}
+
+// attachmentAccess loads the attachment from :id and checks the caller may
+// use its channel
+func (h *Handler) attachmentAccess(c *gin.Context) (db.Attachment, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Attachment{}, false
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid attachment id")
+		return db.Attachment{}, false
+	}
+	a, err := h.Queries.GetAttachmentByID(c, id)
+	if err != nil {
+		problem.Respond(c, 404, "attachment not found")
+		return db.Attachment{}, false
+	}
+	if !h.Members.CanUseChannel(c, h.Members.Role(c, uid, a.ProjectID), a.ProjectID, a.ChannelID) {
+		problem.Respond(c, 403, "not a member of this channel")
+		return db.Attachment{}, false
+	}
+	return a, true
+}
+
+// HandleUploadAttachment stores a file in a channel and queues it for scanning.
+// Multipart form field: "file".
+func (h *Handler) HandleUploadAttachment(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	channelID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid channel id")
+		return
+	}
+	channel, err := h.Queries.GetChannelByID(c, channelID)
+	if err != nil {
+		problem.Respond(c, 404, "channel not found")
+		return
+	}
+	if !h.Members.CanUseChannel(c, h.Members.Role(c, uid, channel.ProjectID), channel.ProjectID, channel.ID) {
+		problem.Respond(c, 403, "not a member of this channel")
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		problem.Respond(c, 400, "no file uploaded")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxAttachmentSize+1))
+	if err != nil {
+		problem.Respond(c, 500, "failed to read file")
+		return
+	}
+	if len(data) > maxAttachmentSize {
+		problem.Respond(c, 413, "file must be at most 25MB")
+		return
+	}
+	if err := h.Quotas.CheckStorage(c, channel.ProjectID, int64(len(data))); err != nil {
+		respondQuota(c, err)
+		return
+	}
+
+	filename := filepath.Base(header.Filename)
+	if filename == "." || filename == "/" {
+		filename = "file"
+	}
+	contentType := header.Header.Get("Content-Type")
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		contentType = http.DetectContentType(data)
+	}
+
+	key := utils.UUIDToStr(channel.ProjectID) + "/" + strconv.FormatInt(utils.GetMessageId(), 10)
+	if err := h.Storage.Put(c, key, data); err != nil {
+		log.Printf("[attachments] store failed: %v", err)
+		problem.Respond(c, 500, "failed to store file")
+		return
+	}
+
+	a, err := h.Queries.CreateAttachment(c, db.CreateAttachmentParams{
+		ProjectID:   channel.ProjectID,
+		ChannelID:   channel.ID,
+		UploaderID:  uid,
+		Filename:    filename,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		StorageKey:  key,
+	})
+	if err != nil {
+		h.Storage.Delete(context.Background(), key)
+		problem.Respond(c, 500, "failed to save attachment")
+		return
+	}
+
+	if _, err := h.Jobs.Enqueue(c, jobAttachmentScan, attachmentScanPayload{
+		AttachmentID: utils.UUIDToStr(a.ID),
+	}, time.Now()); err != nil {
+		// Stays quarantined; better unavailable than unscanned
+		log.Printf("[attachments] failed to queue scan for %s: %v", utils.UUIDToStr(a.ID), err)
+	}
+
+	c.JSON(201, attachmentToResponse(a))
+}
+
+// HandleGetChannelAttachments lists recent attachments in a channel
+func (h *Handler) HandleGetChannelAttachments(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	channelID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid channel id")
+		return
+	}
+	channel, err := h.Queries.GetChannelByID(c, channelID)
+	if err != nil {
+		problem.Respond(c, 404, "channel not found")
+		return
+	}
+	if !h.Members.CanUseChannel(c, h.Members.Role(c, uid, channel.ProjectID), channel.ProjectID, channel.ID) {
+		problem.Respond(c, 403, "not a member of this channel")
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxAttachmentListSize)
+	}
+
+	rows, err := h.Queries.GetChannelAttachments(c, db.GetChannelAttachmentsParams{ChannelID: channel.ID, Limit: int32(limit)})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get attachments")
+		return
+	}
+
+	result := make([]AttachmentResponse, 0, len(rows))
+	for _, a := range rows {
+		result = append(result, attachmentToResponse(a))
+	}
+	c.JSON(200, result)
+}
+
+// HandleGetAttachment returns attachment metadata, including scan state
+func (h *Handler) HandleGetAttachment(c *gin.Context) {
+	a, ok := h.attachmentAccess(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, attachmentToResponse(a))
+}
+
+// HandleDownloadAttachment redirects to a signed URL once the file has passed scanning
+func (h *Handler) HandleDownloadAttachment(c *gin.Context) {
+	a, ok := h.attachmentAccess(c)
+	if !ok {
+		return
+	}
+	if a.ScanStatus != scanStatusClean {
+		problem.RespondCode(c, 423, "quarantined", "attachment is quarantined", gin.H{
+			"scan_status": a.ScanStatus,
+			"placeholder": true,
+		})
+		return
+	}
+	c.Redirect(http.StatusFound, attachmentURL(a))
+}
+
+// mimeDisposition is the Content-Disposition for downloading an attachment
+func mimeDisposition(a db.Attachment) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})
+}
+
+// runAttachmentScan scans a pending attachment and records the verdict.
+// Scanner errors are returned so the queue retries; the file stays quarantined meanwhile.
+func (h *Handler) runAttachmentScan(ctx context.Context, raw json.RawMessage) error {
+	var p attachmentScanPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	id, err := utils.StrToUUID(p.AttachmentID)
+	if err != nil {
+		return fmt.Errorf("bad attachment id: %w", err)
+	}
+
+	a, err := h.Queries.GetAttachmentByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if a.ScanStatus != scanStatusPending {
+		return nil
+	}
+
+	data, err := h.Storage.Get(ctx, a.StorageKey)
+	if err != nil {
+		return fmt.Errorf("read blob: %w", err)
+	}
+	result, err := h.Scanner.Scan(ctx, a.Filename, data)
+	if err != nil {
+		return err
+	}
+
+	status := scanStatusClean
+	if !result.Clean {
+		status = scanStatusInfected
+		log.Printf("[attachments] %s flagged by %s: %s", p.AttachmentID, result.Engine, result.Signature)
+		if err := h.Storage.Delete(ctx, a.StorageKey); err != nil {
+			log.Printf("[attachments] failed to delete infected blob: %v", err)
+		}
+	}
+	if err := h.Queries.SetAttachmentScanResult(ctx, db.SetAttachmentScanResultParams{
+		ID:            a.ID,
+		ScanStatus:    status,
+		ScanEngine:    pgtype.Text{String: result.Engine, Valid: result.Engine != ""},
+		ScanSignature: pgtype.Text{String: result.Signature, Valid: result.Signature != ""},
+	}); err != nil {
+		return err
+	}
+
+	a.ScanStatus = status
+	if a.ChannelID.Valid {
+		channelID := utils.UUIDToStr(a.ChannelID)
+		h.Events.PublishChannel(channelID, events.Of(events.AttachmentScanned, attachmentToResponse(a)))
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"fmt"
 	"log"
 	"net/http"
 	"net/url"
@@ -8,12 +9,104 @@ import (
 	"strings"
 	"wireloop/internal/auth"
 	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// githubLoginScope is what signing in asks GitHub for
+const githubLoginScope = "repo"
+
+// githubExtraScopes can be granted later through HandleGitHubScopes, when a
+// feature runs into a needs_scope error
+var githubExtraScopes = map[string]bool{"repo": true, "read:org": true, "workflow": true}
+
+// HandleGitHubLogin starts the OAuth flow (GET /api/auth/github)
+func (h *Handler) HandleGitHubLogin(c *gin.Context) {
+	redirectToGitHub(c, githubLoginScope)
+}
+
+// HandleGitHubScopes sends a signed-in user back to GitHub to grant one more
+// scope (GET /api/auth/github/scopes?scope=read:org). GitHub only asks about
+// what is new; the callback then stores the upgraded token.
+func (h *Handler) HandleGitHubScopes(c *gin.Context) {
+	scope := c.Query("scope")
+	if !githubExtraScopes[scope] {
+		problem.Respond(c, 400, "scope must be one of repo, read:org, workflow")
+		return
+	}
+	scopes := githubLoginScope
+	if scope != githubLoginScope {
+		scopes += " " + scope
+	}
+	redirectToGitHub(c, scopes)
+}
+
+// redirectToGitHub redirects to GitHub's authorize page for scopes
+// (space-separated)
+func redirectToGitHub(c *gin.Context, scopes string) {
+	clientID := os.Getenv("GITHUB_CLIENT_ID")
+	if clientID == "" {
+		log.Println("WARNING: GITHUB_CLIENT_ID is empty!")
+		problem.Respond(c, 503, "OAuth not configured")
+		return
+	}
+
+	// Build callback URL — auto-detect from request if BACKEND_URL not set
+	backendURL := os.Getenv("BACKEND_URL")
+	if backendURL == "" {
+		// Auto-detect from the incoming request
+		scheme := "https"
+		if c.Request.TLS == nil && c.GetHeader("X-Forwarded-Proto") == "" {
+			scheme = "http"
+		} else if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+			scheme = proto
+		}
+		host := c.GetHeader("X-Forwarded-Host")
+		if host == "" {
+			host = c.Request.Host
+		}
+		backendURL = scheme + "://" + host
+		log.Printf("[auth] BACKEND_URL not set, auto-detected: %s", backendURL)
+	}
+	callbackURL := backendURL + "/api/auth/callback"
+
+	// Generate CSRF state token
+	state := auth.GenerateState()
+	log.Printf("[auth] OAuth flow started, clientID=%s..., callback=%s, state=%s, scope=%s", clientID[:min(10, len(clientID))], callbackURL, state[:8], scopes)
+
+	redirectURL := fmt.Sprintf(
+		"https://github.com/login/oauth/authorize?client_id=%s&redirect_uri=%s&state=%s&scope=%s",
+		clientID,
+		url.QueryEscape(callbackURL),
+		state,
+		url.QueryEscape(scopes),
+	)
+	c.Redirect(http.StatusTemporaryRedirect, redirectURL)
+}
+
+// respondGitHubError writes a failed GitHub call: needs_scope when the
+// user's token lacks a scope, with the URL that grants it, else GitHub's
+// status and message
+func respondGitHubError(c *gin.Context, err error) {
+	scope := github.MissingScope(err)
+	if scope == "" || !githubExtraScopes[scope] {
+		problem.Respond(c, github.StatusCode(err), err.Error())
+		return
+	}
+	problem.RespondCode(c, 403, "needs_scope", fmt.Sprintf("your GitHub sign-in doesn't grant the %s scope this needs", scope), gin.H{
+		"scope":      scope,
+		"reauth_url": githubScopeURL(scope),
+	})
+}
+
+// githubScopeURL is where a user goes to grant scope
+func githubScopeURL(scope string) string {
+	return strings.TrimRight(os.Getenv("BACKEND_URL"), "/") + "/api/auth/github/scopes?scope=" + url.QueryEscape(scope)
+}
+
 func (h *Handler) HandleGitHubCallback(c *gin.Context) {
 	frontendURL := os.Getenv("FRONTEND_URL")
 	if frontendURL == "" {
@@ -46,13 +139,14 @@ func (h *Handler) HandleGitHubCallback(c *gin.Context) {
 
 	log.Printf("[auth] OAuth callback received, code=%s..., state=%s, ip=%s", code[:min(8, len(code))], state, c.ClientIP())
 
-	token, err := auth.ExchangeCodeForToken(code)
+	token, scope, err := github.Default.ExchangeCode(c, os.Getenv("GITHUB_CLIENT_ID"), os.Getenv("GITHUB_CLIENT_SECRET"), code)
 	if err != nil {
 		redirectError("Failed to exchange token: " + err.Error())
 		return
 	}
+	log.Printf("[auth] Token exchange successful, scope: %s", scope)
 
-	ghUser, err := auth.GetGitHubProfile(token)
+	ghUser, err := github.Default.GetAuthenticatedUser(c, token)
 	if err != nil {
 		redirectError("Failed to fetch GitHub profile: " + err.Error())
 		return
@@ -70,8 +164,10 @@ func (h *Handler) HandleGitHubCallback(c *gin.Context) {
 		redirectError("Failed to save user")
 		return
 	}
+	// Fresh access token and profile data — drop any cached copy
+	invalidateUser(user.ID)
 
-	jwtToken, err := auth.GenerateJWT(user.ID)
+	jwtToken, err := h.startSession(c, user.ID)
 	if err != nil {
 		redirectError("Failed to generate session token")
 		return
@@ -81,26 +177,8 @@ func (h *Handler) HandleGitHubCallback(c *gin.Context) {
 	c.Redirect(http.StatusTemporaryRedirect, frontendURL+"/auth/success?token="+jwtToken)
 }
 
-func AuthMiddleware(secret string) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		h := c.GetHeader("Authorization")
-		if !strings.HasPrefix(h, "Bearer ") {
-			c.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
-
-		tokenStr := strings.TrimPrefix(h, "Bearer ")
-
-		t, err := jwt.Parse(tokenStr, func(t *jwt.Token) (any, error) {
-			return []byte(secret), nil
-		})
-		if err != nil || !t.Valid {
-			c.AbortWithStatus(http.StatusUnauthorized)
-			return
-		}
-
-		claims := t.Claims.(jwt.MapClaims)
-		c.Set("user_id", claims["user_id"])
-		c.Next()
-	}
+// HandleJWKS serves the keys that verify Wireloop session tokens
+func HandleJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.Data(http.StatusOK, "application/json", auth.JWKS())
 }
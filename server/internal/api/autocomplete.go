@@ -0,0 +1,208 @@
+package api
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/emoji"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// AUTOCOMPLETE
+// One endpoint backs the composer's @member, #channel and :emoji: pickers.
+// Matches are by prefix and ranked by the caller's own recent interaction:
+// members they last mentioned or replied to, channels they last posted in
+// and emoji they react with most.
+// ============================================================================
+
+const (
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 25
+)
+
+// AutocompleteItem is one suggestion; which fields are set depends on Type
+type AutocompleteItem struct {
+	Type string `json:"type"` // member, channel, emoji or custom_emoji
+	ID   string `json:"id,omitempty"`
+	// member
+	Username    string `json:"username,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+	DisplayName string `json:"display_name,omitempty"`
+	// channel
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	// emoji
+	Shortcode string `json:"shortcode,omitempty"`
+	Unicode   string `json:"unicode,omitempty"`
+	URL       string `json:"url,omitempty"`
+	// The text the composer inserts: @username, #channel or :shortcode:
+	Insert string `json:"insert"`
+}
+
+// HandleAutocomplete suggests ?type=member|channel|emoji completions of ?q
+// in the loop, at most ?limit (default 10, at most 25)
+func (h *Handler) HandleAutocomplete(c *gin.Context) {
+	kind := c.Query("type")
+	if kind != "member" && kind != "channel" && kind != "emoji" {
+		problem.Respond(c, 400, "type must be member, channel or emoji")
+		return
+	}
+	limit := defaultAutocompleteLimit
+	if l := c.Query("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v < 1 || v > maxAutocompleteLimit {
+			problem.Respond(c, 400, "limit must be between 1 and 25")
+			return
+		}
+		limit = v
+	}
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	prefix := strings.TrimLeft(strings.TrimSpace(c.Query("q")), "@#:")
+
+	var items []AutocompleteItem
+	var err error
+	switch kind {
+	case "member":
+		items, err = h.autocompleteMembers(c, project.ID, uid, prefix, limit)
+	case "channel":
+		items, err = h.autocompleteChannels(c, project.ID, uid, prefix, limit)
+	case "emoji":
+		items, err = h.autocompleteEmoji(c, project.ID, uid, strings.ToLower(prefix), limit)
+	}
+	if err != nil {
+		problem.Respond(c, 500, "autocomplete failed")
+		return
+	}
+	c.JSON(200, items)
+}
+
+func (h *Handler) autocompleteMembers(ctx context.Context, projectID, viewer pgtype.UUID, prefix string, limit int) ([]AutocompleteItem, error) {
+	rows, err := h.Queries.AutocompleteMembers(ctx, db.AutocompleteMembersParams{
+		ViewerID:  viewer,
+		ProjectID: projectID,
+		Prefix:    pgtype.Text{String: likeEscaper.Replace(prefix), Valid: true},
+		RowLimit:  int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+	items := make([]AutocompleteItem, 0, len(rows))
+	for _, m := range rows {
+		items = append(items, AutocompleteItem{
+			Type:        "member",
+			ID:          utils.UUIDToStr(m.ID),
+			Username:    m.Username,
+			AvatarURL:   mediaURL(m.AvatarUrl.String),
+			DisplayName: m.DisplayName.String,
+			Insert:      "@" + m.Username,
+		})
+	}
+	return items, nil
+}
+
+// autocompleteChannels only suggests channels the viewer can read
+func (h *Handler) autocompleteChannels(ctx context.Context, projectID, viewer pgtype.UUID, prefix string, limit int) ([]AutocompleteItem, error) {
+	params := db.AutocompleteChannelsParams{
+		ViewerID:   viewer,
+		ProjectID:  projectID,
+		Prefix:     pgtype.Text{String: likeEscaper.Replace(prefix), Valid: true},
+		AllowedIds: []pgtype.UUID{},
+		RowLimit:   int32(limit),
+	}
+	if h.Members.Role(ctx, viewer, projectID) == members.Guest {
+		params.Restricted = true
+		for id := range h.Members.GuestChannels(ctx, projectID) {
+			params.AllowedIds = append(params.AllowedIds, id)
+		}
+	}
+	rows, err := h.Queries.AutocompleteChannels(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]AutocompleteItem, 0, len(rows))
+	for _, ch := range rows {
+		items = append(items, AutocompleteItem{
+			Type:        "channel",
+			ID:          utils.UUIDToStr(ch.ID),
+			Name:        ch.Name,
+			Description: ch.Description.String,
+			Insert:      "#" + ch.Name,
+		})
+	}
+	return items, nil
+}
+
+// autocompleteEmoji matches the loop's custom emoji and the standard set.
+// Emoji the viewer reacted with lately come first, then custom ones, then
+// by name.
+func (h *Handler) autocompleteEmoji(ctx context.Context, projectID, viewer pgtype.UUID, prefix string, limit int) ([]AutocompleteItem, error) {
+	recent, err := h.Queries.GetRecentReactionEmoji(ctx, db.GetRecentReactionEmojiParams{
+		UserID:    viewer,
+		ProjectID: projectID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Reactions are stored as the Unicode emoji or as :name: for custom ones
+	uses := make(map[string]int32, len(recent))
+	for _, r := range recent {
+		uses[r.Emoji] = r.Uses
+	}
+
+	var items []AutocompleteItem
+	for name, e := range h.loopEmojiSet(ctx, projectID) {
+		if strings.HasPrefix(name, prefix) {
+			items = append(items, AutocompleteItem{
+				Type:      "custom_emoji",
+				ID:        utils.UUIDToStr(e.ID),
+				Shortcode: name,
+				URL:       mediaURL(e.ImagePath),
+				Insert:    ":" + name + ":",
+			})
+		}
+	}
+	for _, e := range emoji.Search(prefix) {
+		items = append(items, AutocompleteItem{
+			Type:      "emoji",
+			Shortcode: e.Shortcode,
+			Unicode:   e.Unicode,
+			Insert:    ":" + e.Shortcode + ":",
+		})
+	}
+
+	key := func(it AutocompleteItem) string {
+		if it.Type == "custom_emoji" {
+			return it.Insert
+		}
+		return it.Unicode
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if ua, ub := uses[key(a)], uses[key(b)]; ua != ub {
+			return ua > ub
+		}
+		if a.Type != b.Type {
+			return a.Type == "custom_emoji"
+		}
+		return a.Shortcode < b.Shortcode
+	})
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	if items == nil {
+		items = []AutocompleteItem{}
+	}
+	return items, nil
+}
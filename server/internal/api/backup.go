@@ -0,0 +1,30 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"wireloop/internal/backup"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HandleAdminBackup streams a backup archive of users, loops, channels and
+// messages. Restoring is only available from the command line.
+func (h *Handler) HandleAdminBackup(c *gin.Context) {
+	name := fmt.Sprintf("wireloop-%s.jsonl.gz", time.Now().UTC().Format("20060102-150405"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", `attachment; filename="`+name+`"`)
+
+	stats, err := backup.Write(c, h.Pool, c.Writer)
+	if err != nil {
+		log.Printf("[backup] admin backup failed: %v", err)
+		if !c.Writer.Written() {
+			problem.Respond(c, 500, "backup failed")
+		}
+		return
+	}
+	log.Printf("[backup] admin backup %s written: %v", name, stats)
+}
@@ -0,0 +1,214 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+
+	"wireloop/internal/gatekeeper"
+	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is one entry of a structured validation response
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Report fields by their JSON names rather than Go struct names
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// Request-specific formats, usable in binding tags like the built-ins
+	_ = v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	_ = v.RegisterValidation("rfc3339", func(fl validator.FieldLevel) bool {
+		_, err := time.Parse(time.RFC3339, fl.Field().String())
+		return err == nil
+	})
+	_ = v.RegisterValidation("usertime", func(fl validator.FieldLevel) bool {
+		_, err := parseUserTime(fl.Field().String(), time.UTC)
+		return err == nil
+	})
+	_ = v.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+		d, err := time.ParseDuration(fl.Field().String())
+		return err == nil && d > 0
+	})
+	_ = v.RegisterValidation("criteria", func(fl validator.FieldLevel) bool {
+		return gatekeeper.CriteriaType(fl.Field().String()).Valid()
+	})
+}
+
+// bindJSON decodes the body into dst and runs its binding tags, writing a
+// validation_failed problem listing every bad field on failure. Unknown
+// fields are ignored; see bindStrictJSON for endpoints where they matter.
+func bindJSON(c *gin.Context, dst any) bool {
+	return decodeAndValidate(c, dst, false)
+}
+
+// bindStrictJSON is bindJSON that also rejects unknown fields and trailing
+// data. Use it for endpoints where a silently ignored typo would change
+// access or configuration.
+func bindStrictJSON(c *gin.Context, dst any) bool {
+	return decodeAndValidate(c, dst, true)
+}
+
+func decodeAndValidate(c *gin.Context, dst any, strict bool) bool {
+	dec := json.NewDecoder(c.Request.Body)
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(dst)
+	if err == nil && strict && dec.More() {
+		err = errors.New("unexpected data after the JSON body")
+	}
+	if err == nil {
+		err = binding.Validator.ValidateStruct(dst)
+	}
+	if err == nil {
+		return true
+	}
+
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		middleware.BodyTooLarge(c, tooLarge.Limit)
+		return false
+	}
+	fields := bindErrorFields(err)
+	problem.RespondCode(c, 400, "validation_failed", fields[0].Message, fields)
+	return false
+}
+
+func bindErrorFields(err error) []FieldError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var invalid validator.ValidationErrors
+	switch {
+	case errors.Is(err, io.EOF):
+		return []FieldError{{Message: "request body is required"}}
+	case errors.As(err, &syntaxErr):
+		return []FieldError{{Message: fmt.Sprintf("malformed JSON at byte %d", syntaxErr.Offset)}}
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return []FieldError{{Message: "malformed JSON: unexpected end of body"}}
+	case errors.As(err, &typeErr):
+		return []FieldError{{Field: typeErr.Field, Message: typeErr.Field + " must be a " + typeErr.Type.String()}}
+	case errors.As(err, &invalid):
+		fields := make([]FieldError, len(invalid))
+		for i, fe := range invalid {
+			// Namespace is "Request.field.nested"; drop the request type
+			_, field, ok := strings.Cut(fe.Namespace(), ".")
+			if !ok {
+				field = fe.Field()
+			}
+			fields[i] = FieldError{Field: field, Message: field + " " + validationMessage(fe)}
+		}
+		return fields
+	}
+	// encoding/json has no typed error for unknown fields
+	if name, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		name = strings.Trim(name, `"`)
+		return []FieldError{{Field: name, Message: "unknown field " + name}}
+	}
+	return []FieldError{{Message: err.Error()}}
+}
+
+func validationMessage(fe validator.FieldError) string {
+	kind := fe.Kind()
+	countable := kind == reflect.String || kind == reflect.Slice || kind == reflect.Map
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required when " + toSnake(fe.Param()) + " is not set"
+	case "required_if":
+		field, value, _ := strings.Cut(fe.Param(), " ")
+		return "is required when " + toSnake(field) + " is " + value
+	case "excluded_with":
+		return "can't be combined with " + toSnake(fe.Param())
+	case "notblank":
+		return "must not be blank"
+	case "min", "gte":
+		if countable {
+			return "must have at least " + fe.Param() + unit(kind)
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if countable {
+			return "must have at most " + fe.Param() + unit(kind)
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "len":
+		return "must have exactly " + fe.Param() + unit(kind)
+	case "oneof":
+		return "must be one of " + fe.Param()
+	case "uuid":
+		return "must be a UUID"
+	case "numeric":
+		return "must be a number"
+	case "rfc3339":
+		return "must be an RFC 3339 timestamp"
+	case "usertime":
+		return "must be an RFC 3339 timestamp or a local time like 2006-01-02T15:04"
+	case "duration":
+		return "must be a positive duration like 30m or 2h"
+	case "datetime":
+		return "must match the format " + fe.Param()
+	case "timezone":
+		return "must be an IANA time zone"
+	case "criteria":
+		names := make([]string, len(gatekeeper.CriteriaTypes))
+		for i, t := range gatekeeper.CriteriaTypes {
+			names[i] = string(t)
+		}
+		return "must be one of " + strings.Join(names, " ")
+	case "unique":
+		return "must not contain duplicates"
+	}
+	return "failed " + fe.Tag() + " validation"
+}
+
+func unit(kind reflect.Kind) string {
+	if kind == reflect.String {
+		return " characters"
+	}
+	return " items"
+}
+
+// toSnake turns the Go field names in cross-field tag params (FullName)
+// into the JSON names clients send (full_name)
+func toSnake(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 && !unicode.IsUpper(rune(name[i-1])) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// bindRequest runs body through bindJSON (or bindStrictJSON) into a fresh
+// value of the request type and returns the recorded response
+func bindRequest[T any](body []byte, strict bool) (T, bool, *httptest.ResponseRecorder) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/test", bytes.NewReader(body))
+	var dst T
+	var ok bool
+	if strict {
+		ok = bindStrictJSON(c, &dst)
+	} else {
+		ok = bindJSON(c, &dst)
+	}
+	return dst, ok, w
+}
+
+func checkBindResponse(t *testing.T, ok bool, w *httptest.ResponseRecorder) {
+	t.Helper()
+	if ok {
+		if w.Body.Len() != 0 {
+			t.Fatalf("successful bind wrote a response: %s", w.Body)
+		}
+		return
+	}
+	if w.Code != http.StatusBadRequest && w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("failed bind answered %d", w.Code)
+	}
+	var p problem.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("error body is not a problem: %v (%s)", err, w.Body)
+	}
+	if p.Status != w.Code || p.Detail == "" || p.Code == "" {
+		t.Fatalf("incomplete problem: %+v", p)
+	}
+}
+
+func FuzzBindJSON(f *testing.F) {
+	for _, seed := range []string{
+		``,
+		`{}`,
+		`null`,
+		`[]`,
+		`{"channel_id": "not-a-uuid", "message_body": "hi"}`,
+		`{"channel_id": "6f1c2a9e-4b7d-4e0a-9c3f-2d8e5b1a7c40", "message_body": "   "}`,
+		`{"name": "ci", "scopes": ["messages:read", "admin"]}`,
+		`{"in": "30m", "at": "2026-01-02T15:04:05Z"}`,
+		`{"title": "x", "starts_at": "yesterday", "ends_at": 5}`,
+		`{"steps": [{"title": "a", "kind": "post_in_channel"}]}`,
+		`{"repo_id": 1, "name": "loop", "rules": [{"criteria_type": "karma", "threshold": -1}]}`,
+		`{"privacy": "anyone"} {"privacy": "nobody"}`,
+		`{"unknown": true}`,
+		`{"message_body": "\u0000"`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, body []byte) {
+		_, ok, w := bindRequest[MessagePayload](body, false)
+		checkBindResponse(t, ok, w)
+		_, ok, w = bindRequest[CreateAPIKeyRequest](body, true)
+		checkBindResponse(t, ok, w)
+		_, ok, w = bindRequest[RemindRequest](body, false)
+		checkBindResponse(t, ok, w)
+		_, ok, w = bindRequest[ReplaceOnboardingStepsRequest](body, false)
+		checkBindResponse(t, ok, w)
+		_, ok, w = bindRequest[MakeChannelRequest](body, false)
+		checkBindResponse(t, ok, w)
+		_, ok, w = bindRequest[DMSettingsRequest](body, true)
+		checkBindResponse(t, ok, w)
+		_, ok, w = bindRequest[LoopConfig](body, true)
+		checkBindResponse(t, ok, w)
+	})
+}
+
+func FuzzEventRequest(f *testing.F) {
+	f.Add("standup", "2026-03-01T09:00:00Z", "2026-03-01T09:15:00Z", "weekly", 15)
+	f.Add(" ", "2026-03-01T09:00:00+02:00", "2026-03-01T08:00:00Z", "none", 0)
+	f.Add("release", "2026-03-01", "2026-03-02", "yearly", -5)
+
+	f.Fuzz(func(t *testing.T, title, startsAt, endsAt, recurrence string, remind int) {
+		body, _ := json.Marshal(map[string]any{
+			"title":          title,
+			"starts_at":      startsAt,
+			"ends_at":        endsAt,
+			"recurrence":     recurrence,
+			"remind_minutes": remind,
+		})
+		req, ok, w := bindRequest[EventRequest](body, false)
+		checkBindResponse(t, ok, w)
+		if !ok {
+			return
+		}
+		p, err := parseEventRequest(req)
+		if err != nil {
+			return
+		}
+		if strings.TrimSpace(p.Title) == "" {
+			t.Fatalf("accepted a blank title %q", title)
+		}
+		if p.EndsAt.Time.Before(p.StartsAt.Time) {
+			t.Fatalf("accepted an event ending before it starts: %s - %s", startsAt, endsAt)
+		}
+		if p.RemindMinutes < 0 || p.RemindMinutes > int32(7*24*time.Hour/time.Minute) {
+			t.Fatalf("accepted remind_minutes %d", p.RemindMinutes)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package api
+
+import (
+	"context"
+	"log"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type BlockUserRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+}
+
+// blockedSetFor returns the set of user IDs the viewer has blocked, keyed by UUID string
+func (h *Handler) blockedSetFor(ctx context.Context, viewer pgtype.UUID) map[string]bool {
+	ids, err := h.Queries.GetBlockedUserIDs(ctx, viewer)
+	if err != nil {
+		log.Printf("[blocks] failed to load block list: %v", err)
+		return nil
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[utils.UUIDToStr(id)] = true
+	}
+	return set
+}
+
+// annotateForViewer sets the per-viewer hints (muted, blocked_author) and
+// the reactions on a message list
+func (h *Handler) annotateForViewer(ctx context.Context, viewer pgtype.UUID, msgs []MessageResponse) {
+	h.attachReactions(ctx, viewer, msgs)
+	if len(msgs) == 0 || !viewer.Valid {
+		return
+	}
+	markMuted(msgs, h.mutedWordsFor(ctx, viewer))
+	blocked := h.blockedSetFor(ctx, viewer)
+	if len(blocked) == 0 {
+		return
+	}
+	for i := range msgs {
+		msgs[i].BlockedAuthor = blocked[msgs[i].SenderID]
+	}
+}
+
+// HandleGetBlockedUsers lists the users the caller has blocked
+func (h *Handler) HandleGetBlockedUsers(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	rows, err := h.Queries.GetBlockedUsers(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get blocked users")
+		return
+	}
+
+	result := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, gin.H{
+			"user_id":    utils.UUIDToStr(r.BlockedID),
+			"username":   r.Username,
+			"avatar_url": mediaURL(r.AvatarUrl.String),
+			"blocked_at": utils.FormatTime(r.CreatedAt.Time),
+		})
+	}
+
+	c.JSON(200, result)
+}
+
+// HandleBlockUser adds a user to the caller's block list
+func (h *Handler) HandleBlockUser(c *gin.Context) {
+	var req BlockUserRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	targetID, err := utils.StrToUUID(req.UserID)
+	if err != nil {
+		problem.Respond(c, 400, "invalid user id")
+		return
+	}
+	if targetID == uid {
+		problem.Respond(c, 400, "cannot block yourself")
+		return
+	}
+	if _, err := h.getUserByID(c, targetID); err != nil {
+		problem.Respond(c, 404, "user not found")
+		return
+	}
+
+	if err := h.Queries.BlockUser(c, db.BlockUserParams{BlockerID: uid, BlockedID: targetID}); err != nil {
+		problem.Respond(c, 500, "failed to block user")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleUnblockUser removes a user from the caller's block list
+func (h *Handler) HandleUnblockUser(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	targetID, err := utils.StrToUUID(c.Param("user_id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid user id")
+		return
+	}
+
+	if err := h.Queries.UnblockUser(c, db.UnblockUserParams{BlockerID: uid, BlockedID: targetID}); err != nil {
+		problem.Respond(c, 500, "failed to unblock user")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true})
+}
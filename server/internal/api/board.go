@@ -0,0 +1,637 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// ISSUE BOARD — lightweight kanban per loop
+// Columns are owner-managed; any member can add, edit and move cards.
+// Cards are local tasks or references to GitHub issues (title/state synced).
+// ============================================================================
+
+var defaultBoardColumns = []string{"Backlog", "In Progress", "Done"}
+
+// CreateDefaultBoardColumns gives a new loop its starting columns. Call it in
+// the transaction that creates the loop, so the board never has to be set
+// up by a read.
+func CreateDefaultBoardColumns(ctx context.Context, q *db.Queries, projectID pgtype.UUID) error {
+	for i, name := range defaultBoardColumns {
+		if _, err := q.CreateBoardColumn(ctx, db.CreateBoardColumnParams{
+			ProjectID: projectID,
+			Name:      name,
+			Position:  int32(i),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxBoardSync caps how many linked cards one sync refreshes from GitHub
+const maxBoardSync = 100
+
+type BoardCardResponse struct {
+	ID          string `json:"id"`
+	ColumnID    string `json:"column_id"`
+	Title       string `json:"title"`
+	Body        string `json:"body,omitempty"`
+	IssueNumber *int   `json:"issue_number,omitempty"`
+	GithubState string `json:"github_state,omitempty"`
+	Position    int    `json:"position"`
+	CreatedBy   string `json:"created_by,omitempty"`
+	UpdatedAt   string `json:"updated_at"`
+}
+
+type BoardColumnResponse struct {
+	ID       string              `json:"id"`
+	Name     string              `json:"name"`
+	Position int                 `json:"position"`
+	Cards    []BoardCardResponse `json:"cards"`
+}
+
+type CreateBoardColumnRequest struct {
+	Name string `json:"name" binding:"required,notblank,max=100"`
+}
+
+type ReorderBoardColumnsRequest struct {
+	ColumnIDs []string `json:"column_ids" binding:"required,dive,uuid"`
+}
+
+type CreateBoardCardRequest struct {
+	ColumnID    string `json:"column_id" binding:"required,uuid"`
+	Title       string `json:"title" binding:"max=200"`
+	Body        string `json:"body" binding:"max=10000"`
+	IssueNumber int    `json:"issue_number" binding:"min=0"` // link a GitHub issue instead of a local title
+}
+
+type UpdateBoardCardRequest struct {
+	Title *string `json:"title" binding:"omitempty,max=200"`
+	Body  *string `json:"body" binding:"omitempty,max=10000"`
+}
+
+type MoveBoardCardRequest struct {
+	ColumnID string `json:"column_id" binding:"required,uuid"`
+	Position int    `json:"position" binding:"min=0"`
+}
+
+func boardCardToResponse(card db.BoardCard) BoardCardResponse {
+	resp := BoardCardResponse{
+		ID:          utils.UUIDToStr(card.ID),
+		ColumnID:    utils.UUIDToStr(card.ColumnID),
+		Title:       card.Title,
+		Body:        card.Body.String,
+		GithubState: card.GithubState.String,
+		Position:    int(card.Position),
+		UpdatedAt:   utils.FormatTime(card.UpdatedAt.Time),
+	}
+	if card.GithubIssueNumber.Valid {
+		n := int(card.GithubIssueNumber.Int32)
+		resp.IssueNumber = &n
+	}
+	if card.CreatedBy.Valid {
+		resp.CreatedBy = utils.UUIDToStr(card.CreatedBy)
+	}
+	return resp
+}
+
+// broadcastBoard tells every connected loop member the board changed
+func (h *Handler) broadcastBoard(projectID pgtype.UUID, action string, change events.BoardChange) {
+	change.Action = action
+	h.Events.Publish(loopRoom(utils.UUIDToStr(projectID)), change)
+}
+
+// loopMemberAccess resolves the loop by :name and checks membership
+func (h *Handler) loopMemberAccess(c *gin.Context) (db.Project, pgtype.UUID, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Project{}, uid, false
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return db.Project{}, uid, false
+	}
+	if !h.Members.IsMember(c, uid, project.ID) {
+		problem.Respond(c, 403, "not a member")
+		return db.Project{}, uid, false
+	}
+	return project, uid, true
+}
+
+// boardCardAccess resolves a card by :id and checks membership of its loop
+func (h *Handler) boardCardAccess(c *gin.Context) (db.BoardCard, pgtype.UUID, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.BoardCard{}, uid, false
+	}
+	cardID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid card id")
+		return db.BoardCard{}, uid, false
+	}
+	card, err := h.Queries.GetBoardCardByID(c, cardID)
+	if err != nil {
+		problem.Respond(c, 404, "card not found")
+		return db.BoardCard{}, uid, false
+	}
+	if !h.Members.IsMember(c, uid, card.ProjectID) {
+		problem.Respond(c, 403, "not a member")
+		return db.BoardCard{}, uid, false
+	}
+	return card, uid, true
+}
+
+// boardColumnOwnerAccess resolves a column by :id and requires loop ownership
+func (h *Handler) boardColumnOwnerAccess(c *gin.Context) (db.BoardColumn, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.BoardColumn{}, false
+	}
+	columnID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid column id")
+		return db.BoardColumn{}, false
+	}
+	column, err := h.Queries.GetBoardColumnByID(c, columnID)
+	if err != nil {
+		problem.Respond(c, 404, "column not found")
+		return db.BoardColumn{}, false
+	}
+	project, err := h.Loops.ByID(c, column.ProjectID)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return db.BoardColumn{}, false
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can manage board columns")
+		return db.BoardColumn{}, false
+	}
+	return column, true
+}
+
+// HandleGetBoard returns all columns with their cards. Loops get their
+// default columns when they are created.
+func (h *Handler) HandleGetBoard(c *gin.Context) {
+	project, _, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	columns, err := h.Queries.GetBoardColumns(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load board")
+		return
+	}
+
+	cards, err := h.Queries.GetBoardCards(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load board")
+		return
+	}
+
+	byColumn := make(map[string][]BoardCardResponse, len(columns))
+	for _, card := range cards {
+		key := utils.UUIDToStr(card.ColumnID)
+		byColumn[key] = append(byColumn[key], boardCardToResponse(card))
+	}
+
+	resp := make([]BoardColumnResponse, 0, len(columns))
+	for _, col := range columns {
+		id := utils.UUIDToStr(col.ID)
+		colCards := byColumn[id]
+		if colCards == nil {
+			colCards = []BoardCardResponse{}
+		}
+		resp = append(resp, BoardColumnResponse{
+			ID:       id,
+			Name:     col.Name,
+			Position: int(col.Position),
+			Cards:    colCards,
+		})
+	}
+
+	c.JSON(200, gin.H{"columns": resp})
+}
+
+// HandleCreateBoardColumn appends a column (owner only)
+func (h *Handler) HandleCreateBoardColumn(c *gin.Context) {
+	var req CreateBoardColumnRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can manage board columns")
+		return
+	}
+
+	columns, err := h.Queries.GetBoardColumns(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load board")
+		return
+	}
+
+	col, err := h.Queries.CreateBoardColumn(c, db.CreateBoardColumnParams{
+		ProjectID: project.ID,
+		Name:      strings.TrimSpace(req.Name),
+		Position:  int32(len(columns)),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to create column")
+		return
+	}
+
+	out := BoardColumnResponse{
+		ID:       utils.UUIDToStr(col.ID),
+		Name:     col.Name,
+		Position: int(col.Position),
+		Cards:    []BoardCardResponse{},
+	}
+	h.broadcastBoard(project.ID, "column_created", events.BoardChange{Column: out})
+	c.JSON(201, out)
+}
+
+// HandleRenameBoardColumn renames a column (owner only)
+func (h *Handler) HandleRenameBoardColumn(c *gin.Context) {
+	var req CreateBoardColumnRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	column, ok := h.boardColumnOwnerAccess(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.Queries.RenameBoardColumn(c, db.RenameBoardColumnParams{
+		ID:   column.ID,
+		Name: strings.TrimSpace(req.Name),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to rename column")
+		return
+	}
+
+	h.broadcastBoard(column.ProjectID, "column_updated", events.BoardChange{
+		ColumnID: utils.UUIDToStr(updated.ID),
+		Name:     updated.Name,
+	})
+	c.JSON(200, gin.H{"id": utils.UUIDToStr(updated.ID), "name": updated.Name})
+}
+
+// HandleDeleteBoardColumn removes a column and its cards (owner only)
+func (h *Handler) HandleDeleteBoardColumn(c *gin.Context) {
+	column, ok := h.boardColumnOwnerAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Queries.DeleteBoardColumn(c, column.ID); err != nil {
+		problem.Respond(c, 500, "failed to delete column")
+		return
+	}
+
+	h.broadcastBoard(column.ProjectID, "column_deleted", events.BoardChange{ColumnID: utils.UUIDToStr(column.ID)})
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleReorderBoardColumns sets column order from the given ID list (owner only)
+func (h *Handler) HandleReorderBoardColumns(c *gin.Context) {
+	var req ReorderBoardColumnsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can manage board columns")
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	for i, idStr := range req.ColumnIDs {
+		id, err := utils.StrToUUID(idStr)
+		if err != nil {
+			problem.Respond(c, 400, "invalid column id")
+			return
+		}
+		// project_id in the WHERE clause ignores columns from other loops
+		if err := qtx.SetBoardColumnPosition(c, db.SetBoardColumnPositionParams{
+			ID:        id,
+			ProjectID: project.ID,
+			Position:  int32(i),
+		}); err != nil {
+			problem.Respond(c, 500, "failed to reorder columns")
+			return
+		}
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to save changes")
+		return
+	}
+
+	h.broadcastBoard(project.ID, "columns_reordered", events.BoardChange{ColumnIDs: req.ColumnIDs})
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleCreateBoardCard adds a local card or links a GitHub issue
+func (h *Handler) HandleCreateBoardCard(c *gin.Context) {
+	var req CreateBoardCardRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.IssueNumber <= 0 && strings.TrimSpace(req.Title) == "" {
+		problem.Respond(c, 400, "title or issue_number required")
+		return
+	}
+
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	columnID, err := utils.StrToUUID(req.ColumnID)
+	if err != nil {
+		problem.Respond(c, 400, "invalid column id")
+		return
+	}
+	column, err := h.Queries.GetBoardColumnByID(c, columnID)
+	if err != nil || column.ProjectID != project.ID {
+		problem.Respond(c, 404, "column not found")
+		return
+	}
+
+	params := db.CreateBoardCardParams{
+		ProjectID: project.ID,
+		ColumnID:  column.ID,
+		Title:     strings.TrimSpace(req.Title),
+		CreatedBy: uid,
+	}
+	if req.Body != "" {
+		params.Body = pgtype.Text{String: req.Body, Valid: true}
+	}
+
+	if req.IssueNumber > 0 {
+		if project.GithubRepoID == 0 {
+			problem.Respond(c, 400, "no GitHub repository linked to this loop")
+			return
+		}
+		if h.rejectGuest(c, uid, project.ID) {
+			return
+		}
+		user, err := h.getUserByID(c, uid)
+		if err != nil {
+			problem.Respond(c, 500, "failed to get user")
+			return
+		}
+		token := h.loopReadToken(c, project, user)
+		repoFullName, ok := h.repoFullNameFor(c, project, token)
+		if !ok {
+			return
+		}
+		issue, err := github.Default.GetIssue(c, token, repoFullName, req.IssueNumber)
+		if err != nil {
+			forgetRepoOn404(err, project.GithubRepoID)
+			respondGitHubError(c, err)
+			return
+		}
+		params.Title = issue.Title
+		params.GithubIssueNumber = pgtype.Int4{Int32: int32(issue.Number), Valid: true}
+		params.GithubState = pgtype.Text{String: issue.State, Valid: true}
+	}
+
+	count, err := h.Queries.GetBoardCardCount(c, column.ID)
+	if err != nil {
+		count = 0
+	}
+	params.Position = int32(count)
+
+	card, err := h.Queries.CreateBoardCard(c, params)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "unique constraint") {
+			problem.Respond(c, 409, "this issue is already on the board")
+			return
+		}
+		log.Printf("[board] CreateBoardCard failed: %v", err)
+		problem.Respond(c, 500, "failed to create card")
+		return
+	}
+
+	out := boardCardToResponse(card)
+	h.broadcastBoard(project.ID, "card_created", events.BoardChange{Card: out})
+	c.JSON(201, out)
+}
+
+// HandleUpdateBoardCard edits a card's title/body.
+// Titles of GitHub-linked cards are owned by GitHub and refreshed on sync.
+func (h *Handler) HandleUpdateBoardCard(c *gin.Context) {
+	var req UpdateBoardCardRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	card, _, ok := h.boardCardAccess(c)
+	if !ok {
+		return
+	}
+
+	title := card.Title
+	if req.Title != nil && !card.GithubIssueNumber.Valid {
+		title = strings.TrimSpace(*req.Title)
+		if title == "" {
+			problem.Respond(c, 400, "title cannot be empty")
+			return
+		}
+	}
+	body := card.Body
+	if req.Body != nil {
+		body = pgtype.Text{String: *req.Body, Valid: *req.Body != ""}
+	}
+
+	updated, err := h.Queries.UpdateBoardCard(c, db.UpdateBoardCardParams{
+		ID:    card.ID,
+		Title: title,
+		Body:  body,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to update card")
+		return
+	}
+
+	out := boardCardToResponse(updated)
+	h.broadcastBoard(card.ProjectID, "card_updated", events.BoardChange{Card: out})
+	c.JSON(200, out)
+}
+
+// HandleMoveBoardCard moves a card to a column at the given index and renumbers that column
+func (h *Handler) HandleMoveBoardCard(c *gin.Context) {
+	var req MoveBoardCardRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	card, _, ok := h.boardCardAccess(c)
+	if !ok {
+		return
+	}
+
+	columnID, err := utils.StrToUUID(req.ColumnID)
+	if err != nil {
+		problem.Respond(c, 400, "invalid column id")
+		return
+	}
+	column, err := h.Queries.GetBoardColumnByID(c, columnID)
+	if err != nil || column.ProjectID != card.ProjectID {
+		problem.Respond(c, 404, "column not found")
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	siblings, err := qtx.GetBoardCardsByColumn(c, column.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to move card")
+		return
+	}
+
+	order := make([]pgtype.UUID, 0, len(siblings)+1)
+	for _, s := range siblings {
+		if s.ID != card.ID {
+			order = append(order, s.ID)
+		}
+	}
+	pos := min(max(req.Position, 0), len(order))
+	order = append(order[:pos], append([]pgtype.UUID{card.ID}, order[pos:]...)...)
+
+	for i, id := range order {
+		if err := qtx.SetBoardCardPosition(c, db.SetBoardCardPositionParams{
+			ID:       id,
+			ColumnID: column.ID,
+			Position: int32(i),
+		}); err != nil {
+			problem.Respond(c, 500, "failed to move card")
+			return
+		}
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to save changes")
+		return
+	}
+
+	h.broadcastBoard(card.ProjectID, "card_moved", events.BoardChange{
+		CardID:       utils.UUIDToStr(card.ID),
+		FromColumnID: utils.UUIDToStr(card.ColumnID),
+		ColumnID:     utils.UUIDToStr(column.ID),
+		Position:     &pos,
+	})
+	c.JSON(200, gin.H{"success": true, "position": pos})
+}
+
+// HandleDeleteBoardCard removes a card (GitHub issues are untouched)
+func (h *Handler) HandleDeleteBoardCard(c *gin.Context) {
+	card, _, ok := h.boardCardAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Queries.DeleteBoardCard(c, card.ID); err != nil {
+		problem.Respond(c, 500, "failed to delete card")
+		return
+	}
+
+	h.broadcastBoard(card.ProjectID, "card_deleted", events.BoardChange{CardID: utils.UUIDToStr(card.ID)})
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleSyncBoard refreshes titles/states of GitHub-linked cards
+func (h *Handler) HandleSyncBoard(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+
+	user, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	token := h.loopReadToken(c, project, user)
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
+		return
+	}
+
+	cards, err := h.Queries.GetLinkedBoardCards(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load board")
+		return
+	}
+	if len(cards) > maxBoardSync {
+		cards = cards[:maxBoardSync]
+	}
+
+	synced := 0
+	for _, card := range cards {
+		issue, err := github.Default.GetIssue(c, token, repoFullName, int(card.GithubIssueNumber.Int32))
+		if err != nil {
+			log.Printf("[board] sync #%d for %s failed: %v", card.GithubIssueNumber.Int32, project.Name, err)
+			continue
+		}
+		if issue.Title == card.Title && issue.State == card.GithubState.String {
+			continue
+		}
+		if err := h.Queries.SyncBoardCardIssue(c, db.SyncBoardCardIssueParams{
+			ProjectID:         project.ID,
+			GithubIssueNumber: card.GithubIssueNumber,
+			Title:             issue.Title,
+			GithubState:       pgtype.Text{String: issue.State, Valid: true},
+		}); err != nil {
+			log.Printf("[board] saving sync for #%d failed: %v", card.GithubIssueNumber.Int32, err)
+			continue
+		}
+		synced++
+	}
+
+	if synced > 0 {
+		h.broadcastBoard(project.ID, "synced", events.BoardChange{Updated: &synced})
+	}
+	c.JSON(200, gin.H{"checked": len(cards), "updated": synced})
+}
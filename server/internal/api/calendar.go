@@ -0,0 +1,164 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// CALENDAR FEED
+// Calendar apps can't send a session, so each member gets their own signed
+// feed URL. The signature covers the user and the loop; membership is checked
+// on every fetch, so leaving the loop stops the feed.
+// ============================================================================
+
+const (
+	calendarPast   = 90 * 24 * time.Hour  // ended one-off events kept in the feed
+	calendarFuture = 366 * 24 * time.Hour // one-off events listed ahead
+)
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func calendarSignature(uid, projectID pgtype.UUID) string {
+	mac := hmac.New(sha256.New, mediaKey())
+	mac.Write([]byte("calendar\n" + utils.UUIDToStr(uid) + "\n" + utils.UUIDToStr(projectID)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HandleGetCalendarFeedURL returns the caller's subscription URL for the
+// loop's events
+func (h *Handler) HandleGetCalendarFeedURL(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	q := url.Values{}
+	q.Set("user", utils.UUIDToStr(uid))
+	q.Set("sig", calendarSignature(uid, project.ID))
+	feed := strings.TrimRight(os.Getenv("BACKEND_URL"), "/") + "/api/loops/" + url.PathEscape(project.Name) + "/events.ics?" + q.Encode()
+	c.JSON(200, gin.H{
+		"url":        feed,
+		"webcal_url": "webcal://" + strings.TrimPrefix(strings.TrimPrefix(feed, "https://"), "http://"),
+	})
+}
+
+// HandleGetCalendarFeed serves the loop's events, releases included, as an
+// iCalendar feed (GET /api/loops/:name/events.ics?user=&sig=)
+func (h *Handler) HandleGetCalendarFeed(c *gin.Context) {
+	uid, err := utils.StrToUUID(c.Query("user"))
+	if err != nil {
+		problem.Respond(c, 401, "invalid feed link")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !hmac.Equal([]byte(c.Query("sig")), []byte(calendarSignature(uid, project.ID))) {
+		problem.Respond(c, 401, "invalid feed link")
+		return
+	}
+	if !h.Members.IsMember(c, uid, project.ID) {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+
+	now := time.Now()
+	events, err := h.Queries.GetEventsInRange(c, db.GetEventsInRangeParams{
+		ProjectID:  project.ID,
+		RangeEnd:   pgtype.Timestamptz{Time: now.Add(calendarFuture), Valid: true},
+		RangeStart: pgtype.Timestamptz{Time: now.Add(-calendarPast), Valid: true},
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get events")
+		return
+	}
+
+	var b strings.Builder
+	icalLine(&b, "BEGIN:VCALENDAR")
+	icalLine(&b, "VERSION:2.0")
+	icalLine(&b, "PRODID:-//Wireloop//Loop Events//EN")
+	icalLine(&b, "CALSCALE:GREGORIAN")
+	icalLine(&b, "METHOD:PUBLISH")
+	icalLine(&b, "X-WR-CALNAME:"+icalEscaper.Replace(project.Name))
+	icalLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	for _, ev := range events {
+		if ev.RecurrenceUntil.Valid && ev.RecurrenceUntil.Time.Before(now.Add(-calendarPast)) {
+			continue
+		}
+		writeICalEvent(&b, ev)
+	}
+	icalLine(&b, "END:VCALENDAR")
+
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Header("Content-Disposition", `inline; filename="`+project.Name+`.ics"`)
+	c.Data(200, "text/calendar; charset=utf-8", []byte(b.String()))
+}
+
+func writeICalEvent(b *strings.Builder, ev db.Event) {
+	stamp := ev.UpdatedAt.Time
+	if !ev.UpdatedAt.Valid {
+		stamp = ev.CreatedAt.Time
+	}
+	icalLine(b, "BEGIN:VEVENT")
+	icalLine(b, "UID:"+utils.UUIDToStr(ev.ID)+"@wireloop")
+	icalLine(b, "DTSTAMP:"+icalTime(stamp))
+	icalLine(b, "DTSTART:"+icalTime(ev.StartsAt.Time))
+	icalLine(b, "DTEND:"+icalTime(ev.EndsAt.Time))
+	icalLine(b, "SUMMARY:"+icalEscaper.Replace(ev.Title))
+	if ev.Description.Valid && ev.Description.String != "" {
+		icalLine(b, "DESCRIPTION:"+icalEscaper.Replace(ev.Description.String))
+	}
+	icalLine(b, "CATEGORIES:"+strings.ToUpper(ev.Kind))
+	if freq := map[string]string{"daily": "DAILY", "weekly": "WEEKLY", "monthly": "MONTHLY"}[ev.Recurrence]; freq != "" {
+		rule := "RRULE:FREQ=" + freq
+		if ev.RecurrenceUntil.Valid {
+			rule += ";UNTIL=" + icalTime(ev.RecurrenceUntil.Time)
+		}
+		icalLine(b, rule)
+	}
+	if ev.RemindMinutes > 0 {
+		icalLine(b, "BEGIN:VALARM")
+		icalLine(b, "ACTION:DISPLAY")
+		icalLine(b, "DESCRIPTION:"+icalEscaper.Replace(ev.Title))
+		icalLine(b, "TRIGGER:-PT"+strconv.Itoa(int(ev.RemindMinutes))+"M")
+		icalLine(b, "END:VALARM")
+	}
+	icalLine(b, "END:VEVENT")
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icalLine writes a content line folded at 75 octets, as RFC 5545 requires,
+// without splitting a UTF-8 sequence
+func icalLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // after the leading space
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
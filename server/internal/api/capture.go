@@ -0,0 +1,120 @@
+package api
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// REQUEST CAPTURE
+// To debug client issues that won't reproduce, an admin turns on capture for
+// one user or one route for a while. Matching requests are recorded with
+// their responses, secrets redacted, in a bounded in-memory buffer on each
+// instance; with several instances, look at each or pin the client to one.
+// ============================================================================
+
+const (
+	defaultCaptureTTL = 30 * time.Minute
+	maxCaptureTTL     = 4 * time.Hour
+)
+
+type StartCaptureRequest struct {
+	UserID     string `json:"user_id" binding:"omitempty,uuid"`
+	Username   string `json:"username" binding:"max=39"`
+	Route      string `json:"route" binding:"max=200"`
+	Note       string `json:"note" binding:"max=500"`
+	TTLMinutes int    `json:"ttl_minutes" binding:"min=0"`
+}
+
+// HandleAdminStartCapture starts recording the requests of a user, of a
+// route pattern, or of a user on a route
+func (h *Handler) HandleAdminStartCapture(c *gin.Context) {
+	var req StartCaptureRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	route := strings.TrimSpace(req.Route)
+	if req.UserID == "" && req.Username == "" && route == "" {
+		problem.Respond(c, 400, "give a user_id, a username or a route to capture")
+		return
+	}
+	if route != "" && !strings.HasPrefix(route, "/") {
+		problem.Respond(c, 400, "route is a route pattern such as /api/channels/:id/messages")
+		return
+	}
+
+	rule := middleware.CaptureRule{Route: route, Note: req.Note}
+	if req.UserID != "" || req.Username != "" {
+		var user db.User
+		var err error
+		if req.UserID != "" {
+			id, _ := utils.StrToUUID(req.UserID)
+			user, err = h.Queries.GetUserByID(c, id)
+		} else {
+			user, err = h.Queries.GetUserByUsername(c, req.Username)
+		}
+		if err != nil {
+			problem.Respond(c, 404, "user not found")
+			return
+		}
+		rule.UserID = utils.UUIDToStr(user.ID)
+	}
+
+	ttl := defaultCaptureTTL
+	if req.TTLMinutes > 0 {
+		ttl = min(time.Duration(req.TTLMinutes)*time.Minute, maxCaptureTTL)
+	}
+	rule.ExpiresAt = time.Now().Add(ttl)
+	rule.CreatedBy, _, _ = c.Request.BasicAuth()
+
+	rule = h.Captures.AddRule(rule)
+	log.Printf("[capture] %s started capture %d (user %q, route %q) until %s",
+		rule.CreatedBy, rule.ID, rule.UserID, rule.Route, utils.FormatTime(rule.ExpiresAt))
+	c.JSON(201, rule)
+}
+
+// HandleAdminListCaptures returns the active rules and what was captured on
+// this instance, newest first; ?rule=<id> narrows to one rule
+func (h *Handler) HandleAdminListCaptures(c *gin.Context) {
+	var ruleID int64
+	if r := c.Query("rule"); r != "" {
+		id, err := strconv.ParseInt(r, 10, 64)
+		if err != nil {
+			problem.Respond(c, 400, "invalid rule id")
+			return
+		}
+		ruleID = id
+	}
+	c.JSON(200, gin.H{
+		"rules":     h.Captures.Rules(),
+		"exchanges": h.Captures.Exchanges(ruleID),
+	})
+}
+
+// HandleAdminStopCapture ends a rule; its captures stay until cleared
+func (h *Handler) HandleAdminStopCapture(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid rule id")
+		return
+	}
+	if !h.Captures.RemoveRule(id) {
+		problem.Respond(c, 404, "no such capture rule")
+		return
+	}
+	c.JSON(200, gin.H{"message": "capture stopped"})
+}
+
+// HandleAdminClearCaptures drops everything captured on this instance
+func (h *Handler) HandleAdminClearCaptures(c *gin.Context) {
+	h.Captures.Clear()
+	c.JSON(200, gin.H{"message": "captures cleared"})
+}
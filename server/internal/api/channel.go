@@ -1,10 +1,12 @@
 package api
 
 import (
-	"strconv"
-	"time"
+	"context"
 	utils "wireloop/internal"
 	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -19,20 +21,34 @@ type ChannelResponse struct {
 	IsDefault   bool   `json:"is_default"`
 	Position    int    `json:"position"`
 	CreatedAt   string `json:"created_at"`
+	Topic       string `json:"topic,omitempty"`
+	// The pinned announcement, while it hasn't expired
+	Banner *ChannelBanner `json:"banner,omitempty"`
+	// Set when the channel is bound to a GitHub issue or PR
+	GitHub *ChannelGitHubLink `json:"github,omitempty"`
+	// Set when the channel keeps its messages from AI or exports
+	DataPolicy *ChannelDataPolicy `json:"data_policy,omitempty"`
+	// Readable by non-members because the loop is public
+	Public bool `json:"public,omitempty"`
+	// The caller's unread state, in LoopFull
+	Activity *ChannelActivity `json:"activity,omitempty"`
 }
 
 // CreateChannelRequest represents a request to create a new channel
 type CreateChannelRequest struct {
-	ProjectID   string `json:"project_id" binding:"required"`
-	Name        string `json:"name" binding:"required"`
-	Description string `json:"description"`
+	ProjectID   string `json:"project_id" binding:"required,uuid"`
+	Name        string `json:"name" binding:"required,notblank,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	// Optional: bind the channel to issue/PR #GitHubNumber of the loop's repo
+	GitHubNumber *int `json:"github_number" binding:"omitempty,min=1"`
+	CrossPost    bool `json:"cross_post"`
 }
 
 // UpdateChannelRequest represents a request to update a channel
 type UpdateChannelRequest struct {
-	Name        *string `json:"name"`
-	Description *string `json:"description"`
-	Position    *int    `json:"position"`
+	Name        *string `json:"name" binding:"omitempty,notblank,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+	Position    *int    `json:"position" binding:"omitempty,min=0"`
 }
 
 // Helper to convert db.Channel to ChannelResponse
@@ -44,72 +60,124 @@ func channelToResponse(c db.Channel) ChannelResponse {
 		Description: c.Description.String,
 		IsDefault:   c.IsDefault.Bool,
 		Position:    int(c.Position.Int32),
-		CreatedAt:   c.CreatedAt.Time.Format(time.RFC3339),
+		CreatedAt:   utils.FormatTime(c.CreatedAt.Time),
 	}
 }
 
-// HandleGetChannels returns all channels for a loop
+// HandleGetChannels returns all channels for a loop. Guests only see the
+// guest channels, and visitors who are not members only the public channels
+// of a public loop.
 func (h *Handler) HandleGetChannels(c *gin.Context) {
 	loopName := c.Param("name")
 	if loopName == "" {
-		c.JSON(400, gin.H{"error": "loop name required"})
+		problem.Respond(c, 400, "loop name required")
 		return
 	}
 
+	uid, signedIn := utils.GetUserIdFromContext(c)
+
 	// Get project by name
-	project, err := h.Queries.GetProjectByName(c, loopName)
+	project, err := h.Loops.ByName(c, loopName)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+
+	public := h.publicChannelSet(c, project.ID)
+	role := h.Members.Role(c, uid, project.ID)
+	member := role != ""
+	if !member && !signedIn && public == nil {
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	// Get channels
 	channels, err := h.Queries.GetChannelsByProject(c, project.ID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get channels"})
+		problem.Respond(c, 500, "failed to get channels")
+		return
+	}
+
+	links, err := h.Queries.GetProjectChannelGithubLinks(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get channels")
+		return
+	}
+	linkByChannel := make(map[pgtype.UUID]db.ChannelGithubLink, len(links))
+	for _, l := range links {
+		linkByChannel[l.ChannelID] = l
+	}
+	topics, err := h.Queries.GetProjectChannelTopics(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get channels")
+		return
+	}
+	topicByChannel := topicsByChannel(topics)
+	policies, err := h.Queries.GetProjectChannelDataPolicies(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get channels")
 		return
 	}
+	policyByChannel := policiesByChannel(policies)
+
+	var guestChannels map[pgtype.UUID]bool
+	if role == members.Guest {
+		guestChannels = h.Members.GuestChannels(c, project.ID)
+	}
 
-	result := make([]ChannelResponse, len(channels))
-	for i, ch := range channels {
-		result[i] = ChannelResponse{
+	result := make([]ChannelResponse, 0, len(channels))
+	for _, ch := range channels {
+		if public != nil && !member && !public[ch.ID] {
+			continue
+		}
+		if role == members.Guest && !guestChannels[ch.ID] && !public[ch.ID] {
+			continue
+		}
+		resp := ChannelResponse{
 			ID:          utils.UUIDToStr(ch.ID),
 			ProjectID:   utils.UUIDToStr(ch.ProjectID),
 			Name:        ch.Name,
 			Description: ch.Description.String,
 			IsDefault:   ch.IsDefault.Bool,
 			Position:    int(ch.Position.Int32),
-			CreatedAt:   ch.CreatedAt.Time.Format(time.RFC3339),
+			CreatedAt:   utils.FormatTime(ch.CreatedAt.Time),
+			Public:      public[ch.ID],
+		}
+		if l, ok := linkByChannel[ch.ID]; ok {
+			resp.GitHub = channelLinkToResponse(l)
 		}
+		t, ok := topicByChannel[ch.ID]
+		p, hasPolicy := policyByChannel[ch.ID]
+		result = append(result, resp.withTopic(t, ok).withPolicy(p, hasPolicy))
 	}
 
-	c.JSON(200, gin.H{"channels": result})
+	body := gin.H{"channels": result}
+	respondJSONWithETag(c, body, body)
 }
 
 // HandleCreateChannel creates a new channel in a loop
 func (h *Handler) HandleCreateChannel(c *gin.Context) {
 	var req CreateChannelRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	// Get project
 	projectID, err := utils.StrToUUID(req.ProjectID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid project id"})
+		problem.Respond(c, 400, "invalid project id")
 		return
 	}
 
-	project, err := h.Queries.GetProjectByID(c, projectID)
+	project, err := h.Loops.ByID(c, projectID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
@@ -117,18 +185,33 @@ func (h *Handler) HandleCreateChannel(c *gin.Context) {
 	if project.OwnerID != uid {
 		// Check if user is a member with admin role
 		// For now, only owners can create channels
-		c.JSON(403, gin.H{"error": "only loop owner can create channels"})
+		problem.Respond(c, 403, "only loop owner can create channels")
 		return
 	}
 
+	var item *github.Issue
+	if req.GitHubNumber != nil {
+		if item, ok = h.resolveChannelGitHubItem(c, project, uid, *req.GitHubNumber); !ok {
+			return
+		}
+	}
+
 	// Get current channel count for position
 	count, err := h.Queries.GetChannelCount(c, projectID)
 	if err != nil {
 		count = 0
 	}
 
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "failed to create channel")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
 	// Create channel
-	channel, err := h.Queries.CreateChannel(c, db.CreateChannelParams{
+	channel, err := qtx.CreateChannel(c, db.CreateChannelParams{
 		ProjectID:   projectID,
 		Name:        req.Name,
 		Description: pgtype.Text{String: req.Description, Valid: req.Description != ""},
@@ -136,55 +219,80 @@ func (h *Handler) HandleCreateChannel(c *gin.Context) {
 		Position:    pgtype.Int4{Int32: int32(count), Valid: true},
 	})
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to create channel"})
+		problem.Respond(c, 500, "failed to create channel")
+		return
+	}
+	resp := channelToResponse(channel)
+
+	if item != nil {
+		kind := "issue"
+		if item.PullRequest != nil {
+			kind = "pr"
+		}
+		link, err := qtx.CreateChannelGithubLink(c, db.CreateChannelGithubLinkParams{
+			ChannelID:    channel.ID,
+			ProjectID:    projectID,
+			GithubNumber: int32(item.Number),
+			Kind:         kind,
+			CrossPost:    req.CrossPost,
+			LinkedBy:     uid,
+		})
+		if err != nil {
+			problem.Respond(c, 500, "failed to bind channel")
+			return
+		}
+		resp.GitHub = channelLinkToResponse(link)
+	}
+
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to create channel")
 		return
 	}
 
-	c.JSON(201, channelToResponse(channel))
+	c.JSON(201, resp)
 }
 
 // HandleUpdateChannel updates a channel
 func (h *Handler) HandleUpdateChannel(c *gin.Context) {
 	channelID := c.Param("id")
 	if channelID == "" {
-		c.JSON(400, gin.H{"error": "channel id required"})
+		problem.Respond(c, 400, "channel id required")
 		return
 	}
 
 	var req UpdateChannelRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	// Get channel
 	channelUUID, err := utils.StrToUUID(channelID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid channel id"})
+		problem.Respond(c, 400, "invalid channel id")
 		return
 	}
 
 	channel, err := h.Queries.GetChannelByID(c, channelUUID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "channel not found"})
+		problem.Respond(c, 404, "channel not found")
 		return
 	}
 
 	// Get project to verify ownership
-	project, err := h.Queries.GetProjectByID(c, channel.ProjectID)
+	project, err := h.Loops.ByID(c, channel.ProjectID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
 	if project.OwnerID != uid {
-		c.JSON(403, gin.H{"error": "only loop owner can update channels"})
+		problem.Respond(c, 403, "only loop owner can update channels")
 		return
 	}
 
@@ -202,55 +310,56 @@ func (h *Handler) HandleUpdateChannel(c *gin.Context) {
 
 	updated, err := h.Queries.UpdateChannel(c, params)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to update channel"})
+		problem.Respond(c, 500, "failed to update channel")
 		return
 	}
 
-	c.JSON(200, channelToResponse(updated))
+	t, err := h.Queries.GetChannelTopic(c, updated.ID)
+	c.JSON(200, channelToResponse(updated).withTopic(t, err == nil))
 }
 
 // HandleDeleteChannel deletes a channel
 func (h *Handler) HandleDeleteChannel(c *gin.Context) {
 	channelID := c.Param("id")
 	if channelID == "" {
-		c.JSON(400, gin.H{"error": "channel id required"})
+		problem.Respond(c, 400, "channel id required")
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	channelUUID, err := utils.StrToUUID(channelID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid channel id"})
+		problem.Respond(c, 400, "invalid channel id")
 		return
 	}
 
 	channel, err := h.Queries.GetChannelByID(c, channelUUID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "channel not found"})
+		problem.Respond(c, 404, "channel not found")
 		return
 	}
 
 	// Get project to verify ownership
-	project, err := h.Queries.GetProjectByID(c, channel.ProjectID)
+	project, err := h.Loops.ByID(c, channel.ProjectID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
 	if project.OwnerID != uid {
-		c.JSON(403, gin.H{"error": "only loop owner can delete channels"})
+		problem.Respond(c, 403, "only loop owner can delete channels")
 		return
 	}
 
 	// Don't allow deleting the last channel
 	count, err := h.Queries.GetChannelCount(c, channel.ProjectID)
 	if err == nil && count <= 1 {
-		c.JSON(400, gin.H{"error": "cannot delete the last channel"})
+		problem.Respond(c, 400, "cannot delete the last channel")
 		return
 	}
 
@@ -271,7 +380,7 @@ func (h *Handler) HandleDeleteChannel(c *gin.Context) {
 	}
 
 	if err := h.Queries.DeleteChannel(c, channelUUID); err != nil {
-		c.JSON(500, gin.H{"error": "failed to delete channel"})
+		problem.Respond(c, 500, "failed to delete channel")
 		return
 	}
 
@@ -282,87 +391,30 @@ func (h *Handler) HandleDeleteChannel(c *gin.Context) {
 func (h *Handler) HandleGetChannelMessages(c *gin.Context) {
 	channelID := c.Param("id")
 	if channelID == "" {
-		c.JSON(400, gin.H{"error": "channel id required"})
+		problem.Respond(c, 400, "channel id required")
 		return
 	}
 
-	uid, ok := utils.GetUserIdFromContext(c)
-	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
-		return
-	}
+	uid, _ := utils.GetUserIdFromContext(c)
 
 	channelUUID, err := utils.StrToUUID(channelID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid channel id"})
+		problem.Respond(c, 400, "invalid channel id")
 		return
 	}
 
 	// Get channel to get project ID
 	channel, err := h.Queries.GetChannelByID(c, channelUUID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "channel not found"})
-		return
-	}
-
-	// Verify membership
-	if _, err := h.Queries.IsMember(c, db.IsMemberParams{
-		UserID: uid, ProjectID: channel.ProjectID,
-	}); err != nil {
-		c.JSON(403, gin.H{"error": "not a member"})
+		problem.Respond(c, 404, "channel not found")
 		return
 	}
 
-	// Parse pagination
-	limit := int32(50)
-	offset := int32(0)
-	if l := c.Query("limit"); l != "" {
-		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
-			limit = int32(v)
-		}
-	}
-	if o := c.Query("offset"); o != "" {
-		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
-			offset = int32(v)
-		}
-	}
-
-	messages, err := h.Queries.GetMessages(c, db.GetMessagesParams{
-		ChannelID: channelUUID,
-		Limit:     limit,
-		Offset:    offset,
-	})
-	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get messages"})
+	if !h.channelReadAccess(c, uid, channel.ProjectID, channel.ID) {
 		return
 	}
 
-	// Transform to response format
-	result := make([]MessageResponse, len(messages))
-	for i, m := range messages {
-		var parentID *string
-		if m.ParentID.Valid {
-			pid := strconv.FormatInt(m.ParentID.Int64, 10)
-			parentID = &pid
-		}
-		result[i] = MessageResponse{
-			ID:             strconv.FormatInt(m.ID, 10),
-			Content:        m.Content,
-			SenderID:       utils.UUIDToStr(m.SenderID),
-			SenderUsername: m.SenderUsername,
-			SenderAvatar:   m.SenderAvatar.String,
-			CreatedAt:      m.CreatedAt.Time.Format(time.RFC3339),
-			ParentID:       parentID,
-			ReplyCount:     int(m.ReplyCount.Int32),
-		}
-	}
-
-	// Reverse to get chronological order (oldest first)
-	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
-		result[i], result[j] = result[j], result[i]
-	}
-
-	c.JSON(200, gin.H{"messages": result})
+	h.respondChannelHistory(c, uid, channel.ProjectID, channelUUID)
 }
 
 // EnsureDefaultChannel creates a default #general channel for a project if none exists
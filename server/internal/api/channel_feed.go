@@ -0,0 +1,180 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// ATOM FEEDS
+// The public channels of a public loop (announcements, releases) syndicate
+// as Atom so feed readers and other sites can follow them. Feeds hold the
+// newest top-level messages and are rendered at most once per feedTTL per
+// channel and size.
+// ============================================================================
+
+const (
+	feedTTL          = 2 * time.Minute
+	defaultFeedItems = 20
+	maxFeedItems     = 50
+	feedTitleRunes   = 80
+)
+
+var channelFeedCache = cache.New[string, []byte](feedTTL, 500)
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Sub     string      `xml:"subtitle,omitempty"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID      string     `xml:"id"`
+	Title   string     `xml:"title"`
+	Updated string     `xml:"updated"`
+	Author  atomAuthor `xml:"author"`
+	Link    atomLink   `xml:"link"`
+	Content atomText   `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// feedTitle is the first line of a message, shortened for feed readers
+func feedTitle(content string) string {
+	title, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	if utf8.RuneCountInString(title) > feedTitleRunes {
+		title = string([]rune(title)[:feedTitleRunes-1]) + "…"
+	}
+	return title
+}
+
+// HandleGetChannelFeed serves a public channel as an Atom feed
+// (GET /api/loops/:name/channels/:id/feed.atom?limit=)
+func (h *Handler) HandleGetChannelFeed(c *gin.Context) {
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	channelID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid channel id")
+		return
+	}
+	// Members-only channels 404 rather than 403 so their IDs can't be probed
+	if !h.publicChannelSet(c, project.ID)[channelID] {
+		problem.Respond(c, 404, "no public channel with that id")
+		return
+	}
+	limit := defaultFeedItems
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxFeedItems)
+	}
+
+	key := utils.UUIDToStr(channelID) + ":" + strconv.Itoa(limit)
+	body, err := channelFeedCache.GetOrLoad(key, func() ([]byte, error) {
+		return h.renderChannelFeed(c, project, channelID, limit)
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to build feed")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(feedTTL.Seconds())))
+	c.Header("ETag", etag)
+	if etagMatches(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(200, "application/atom+xml; charset=utf-8", body)
+}
+
+func (h *Handler) renderChannelFeed(c *gin.Context, project db.Project, channelID pgtype.UUID, limit int) ([]byte, error) {
+	channel, err := h.Queries.GetChannelByID(c, channelID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := h.Queries.GetMessages(c, db.GetMessagesParams{
+		ChannelID: channelID,
+		Limit:     int32(limit),
+		Offset:    0,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	frontend := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/")
+	channelStr := utils.UUIDToStr(channelID)
+	page := frontend + "/loops/" + url.PathEscape(project.Name) + "?channel=" + channelStr
+	self := strings.TrimRight(os.Getenv("BACKEND_URL"), "/") + c.Request.URL.Path
+	feed := atomFeed{
+		ID:    "urn:wireloop:channel:" + channelStr,
+		Title: project.Name + " #" + channel.Name,
+		Sub:   channel.Description.String,
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: self},
+			{Rel: "alternate", Type: "text/html", Href: page},
+		},
+		Entries: make([]atomEntry, 0, len(messages)),
+	}
+	// Atom requires an updated time even for an empty feed
+	updated := channel.CreatedAt.Time
+	for _, m := range messages {
+		if m.IsDeleted {
+			continue
+		}
+		if m.CreatedAt.Time.After(updated) {
+			updated = m.CreatedAt.Time
+		}
+		id := strconv.FormatInt(m.ID, 10)
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      "urn:wireloop:message:" + id,
+			Title:   feedTitle(m.Content),
+			Updated: utils.FormatTime(m.CreatedAt.Time),
+			Author:  atomAuthor{Name: m.SenderUsername},
+			Link:    atomLink{Rel: "alternate", Type: "text/html", Href: page + "#message-" + id},
+			Content: atomText{Type: "text", Body: m.Content},
+		})
+	}
+	feed.Updated = utils.FormatTime(updated)
+
+	out, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
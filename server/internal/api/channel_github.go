@@ -0,0 +1,291 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/emoji"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// CHANNELS BOUND TO A GITHUB ISSUE / PR
+// New comments on the item stream into the channel, the channel's messages
+// can be cross-posted back as comments, and closing or merging the item
+// archives the channel.
+// ============================================================================
+
+const jobGitHubCrossPost = "github_crosspost"
+
+type githubCrossPostPayload struct {
+	MessageID int64  `json:"message_id"`
+	ChannelID string `json:"channel_id"`
+	SenderID  string `json:"sender_id"`
+}
+
+// ChannelGitHubLink describes a channel's bound issue or PR
+type ChannelGitHubLink struct {
+	Number    int    `json:"number"`
+	Kind      string `json:"kind"` // issue or pr
+	CrossPost bool   `json:"cross_post"`
+	Archived  bool   `json:"archived"`
+}
+
+func channelLinkToResponse(l db.ChannelGithubLink) *ChannelGitHubLink {
+	return &ChannelGitHubLink{
+		Number:    int(l.GithubNumber),
+		Kind:      l.Kind,
+		CrossPost: l.CrossPost,
+		Archived:  l.ArchivedAt.Valid,
+	}
+}
+
+// channelLinkCache holds each channel's binding; unbound channels cache the zero row
+var channelLinkCache = cache.New[string, db.ChannelGithubLink](lookupTTL, 5000)
+
+// channelGitHubLink returns the channel's binding, checked on every message send
+func (h *Handler) channelGitHubLink(ctx context.Context, channelID pgtype.UUID) (db.ChannelGithubLink, bool) {
+	link, err := channelLinkCache.GetOrLoad(utils.UUIDToStr(channelID), func() (db.ChannelGithubLink, error) {
+		l, err := h.Queries.GetChannelGithubLink(ctx, channelID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.ChannelGithubLink{}, nil
+		}
+		return l, err
+	})
+	if err != nil {
+		log.Printf("[channel-github] failed to load link for %s: %v", utils.UUIDToStr(channelID), err)
+		return db.ChannelGithubLink{}, false
+	}
+	return link, link.ChannelID.Valid
+}
+
+// resolveChannelGitHubItem checks that issue/PR #number exists and is still
+// open, writing the error response on failure
+func (h *Handler) resolveChannelGitHubItem(c *gin.Context, project db.Project, uid pgtype.UUID, number int) (*github.Issue, bool) {
+	item, ok := h.fetchGitHubItem(c, project, uid, number)
+	if !ok {
+		return nil, false
+	}
+	if item.State != "open" {
+		problem.Respond(c, 400, "issue or PR is already closed")
+		return nil, false
+	}
+	return item, true
+}
+
+// fetchGitHubItem loads issue/PR #number of the loop's repo, writing the
+// error response on failure
+func (h *Handler) fetchGitHubItem(c *gin.Context, project db.Project, uid pgtype.UUID, number int) (*github.Issue, bool) {
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return nil, false
+	}
+	if h.rejectGuest(c, uid, project.ID) {
+		return nil, false
+	}
+	user, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return nil, false
+	}
+	token := h.loopReadToken(c, project, user)
+	if token == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
+		return nil, false
+	}
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
+		return nil, false
+	}
+
+	// The issues endpoint serves PRs too; PullRequest tells them apart
+	item, err := github.Default.GetIssue(c, token, repoFullName, number)
+	if err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
+		return nil, false
+	}
+	return item, true
+}
+
+// queueCrossPost schedules a channel message to be posted as a GitHub comment
+func (h *Handler) queueCrossPost(ctx context.Context, msgID int64, channelID, senderID pgtype.UUID) {
+	if _, err := h.Jobs.Enqueue(ctx, jobGitHubCrossPost, githubCrossPostPayload{
+		MessageID: msgID,
+		ChannelID: utils.UUIDToStr(channelID),
+		SenderID:  utils.UUIDToStr(senderID),
+	}, time.Now()); err != nil {
+		log.Printf("[channel-github] failed to queue cross-post of %d: %v", msgID, err)
+	}
+}
+
+// runGitHubCrossPost posts a channel message as a comment on the bound item,
+// using the sender's own token so GitHub attributes it to them.
+func (h *Handler) runGitHubCrossPost(ctx context.Context, raw json.RawMessage) error {
+	var p githubCrossPostPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	channelID, err := utils.StrToUUID(p.ChannelID)
+	if err != nil {
+		return fmt.Errorf("bad channel id: %w", err)
+	}
+	senderID, err := utils.StrToUUID(p.SenderID)
+	if err != nil {
+		return fmt.Errorf("bad sender id: %w", err)
+	}
+
+	// A retry after a successful post must not comment twice
+	if done, err := h.Queries.IsMessageCrossPosted(ctx, p.MessageID); err != nil || done {
+		return err
+	}
+	link, err := h.Queries.GetChannelGithubLink(ctx, channelID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !link.CrossPost || link.ArchivedAt.Valid || !h.channelPolicy(ctx, channelID).Exports {
+		return nil
+	}
+	msg, err := h.Queries.GetMessageByID(ctx, p.MessageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if msg.IsDeleted.Bool {
+		return nil
+	}
+
+	sender, err := h.getUserByID(ctx, senderID)
+	if err != nil {
+		return err
+	}
+	if sender.AccessToken == "" {
+		log.Printf("[channel-github] %s has no GitHub token, not cross-posting %d", sender.Username, p.MessageID)
+		return nil
+	}
+	project, err := h.Loops.ByID(ctx, link.ProjectID)
+	if err != nil {
+		return err
+	}
+	if h.Members.Role(ctx, senderID, project.ID) == members.Guest {
+		return nil // guests never act on GitHub through the loop
+	}
+	repoFullName, err := github.Default.RepoFullName(ctx, sender.AccessToken, project.GithubRepoID)
+	if err != nil {
+		return err
+	}
+
+	number := int(link.GithubNumber)
+	created, err := github.Default.CreateIssueComment(ctx, sender.AccessToken, repoFullName, number, emoji.ReplaceStandard(msg.Content))
+	if err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		return err
+	}
+	if _, err := h.Queries.LinkMessageToGithubComment(ctx, db.LinkMessageToGithubCommentParams{
+		MessageID: p.MessageID,
+		CommentID: created.ID,
+	}); err != nil {
+		// Not retried: the comment exists, and another attempt would duplicate it
+		log.Printf("[channel-github] posted %d as comment %d but failed to record it: %v", p.MessageID, created.ID, err)
+	}
+	if _, err := h.Queries.UpsertPRComment(ctx, issueCommentRow(project.GithubRepoID, number, *created)); err != nil {
+		log.Printf("[channel-github] failed to store comment %d: %v", created.ID, err)
+	}
+	return nil
+}
+
+// mirrorCommentToChannels posts a new GitHub comment into every open channel
+// bound to its item. Authors with a Wireloop account post as themselves;
+// anyone else is relayed through the user who bound the channel.
+func (h *Handler) mirrorCommentToChannels(ctx context.Context, project db.Project, number int, comment github.Comment) error {
+	links, err := h.Queries.GetActiveChannelGithubLinks(ctx, db.GetActiveChannelGithubLinksParams{
+		ProjectID:    project.ID,
+		GithubNumber: int32(number),
+	})
+	if err != nil || len(links) == 0 {
+		return err
+	}
+
+	author, err := h.Queries.GetUserByGithubID(ctx, comment.User.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	isUser := err == nil
+
+	for _, link := range links {
+		// Our own cross-posts (and redelivered webhooks) are already in the channel
+		seen, err := h.Queries.IsGithubCommentInChannel(ctx, db.IsGithubCommentInChannelParams{
+			CommentID: comment.ID,
+			ChannelID: link.ChannelID,
+		})
+		if err != nil {
+			return err
+		}
+		if seen {
+			continue
+		}
+
+		sender, content := author, comment.Body
+		if !isUser {
+			if !link.LinkedBy.Valid {
+				continue
+			}
+			if sender, err = h.getUserByID(ctx, link.LinkedBy); err != nil {
+				continue
+			}
+			content = fmt.Sprintf("**@%s** on GitHub:\n\n%s", comment.User.Login, comment.Body)
+		}
+
+		msgID, err := h.postChannelMessage(ctx, project.ID, link.ChannelID, sender, content)
+		if err != nil {
+			return err
+		}
+		if _, err := h.Queries.LinkMessageToGithubComment(ctx, db.LinkMessageToGithubCommentParams{
+			MessageID: msgID,
+			CommentID: comment.ID,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveBoundChannels archives the channels bound to a closed or merged item
+func (h *Handler) archiveBoundChannels(ctx context.Context, project db.Project, number int, reason string) error {
+	archived, err := h.Queries.ArchiveChannelGithubLinks(ctx, db.ArchiveChannelGithubLinksParams{
+		ProjectID:    project.ID,
+		GithubNumber: int32(number),
+	})
+	if err != nil {
+		return err
+	}
+	for _, link := range archived {
+		channelID := utils.UUIDToStr(link.ChannelID)
+		lookupInvalidator.Invalidate("channel_link", channelID)
+		h.Events.Publish(loopRoom(utils.UUIDToStr(project.ID)), events.ArchivedChannel{
+			ChannelID: channelID,
+			Number:    number,
+			Reason:    reason,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// CHANNEL DATA POLICIES
+// A channel with sensitive discussions can be kept out of AI thread
+// summaries and out of exports (thread export and cross-posting to GitHub),
+// on top of whatever the loop allows. Moderators set the policy; it is
+// checked where the data leaves, so queued jobs respect a later change.
+// ============================================================================
+
+// channelPolicyCache holds each channel's policy; channels without one cache
+// the zero row
+var channelPolicyCache = cache.New[string, db.ChannelDataPolicy](lookupTTL, 5000)
+
+// ChannelDataPolicy is what a channel lets leave Wireloop
+type ChannelDataPolicy struct {
+	AISummaries bool `json:"ai_summaries"`
+	Exports     bool `json:"exports"`
+}
+
+type SetChannelDataPolicyRequest struct {
+	AISummaries *bool `json:"ai_summaries"`
+	Exports     *bool `json:"exports"`
+}
+
+// restricted reports whether the policy keeps anything in
+func (p ChannelDataPolicy) restricted() bool {
+	return !p.AISummaries || !p.Exports
+}
+
+func channelPolicyToResponse(p db.ChannelDataPolicy) ChannelDataPolicy {
+	return ChannelDataPolicy{AISummaries: p.AiSummaries, Exports: p.Exports}
+}
+
+// channelPolicy returns channelID's policy; everything is allowed without one
+func (h *Handler) channelPolicy(ctx context.Context, channelID pgtype.UUID) ChannelDataPolicy {
+	p, err := channelPolicyCache.GetOrLoad(utils.UUIDToStr(channelID), func() (db.ChannelDataPolicy, error) {
+		p, err := h.Queries.GetChannelDataPolicy(ctx, channelID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.ChannelDataPolicy{}, nil
+		}
+		return p, err
+	})
+	if err != nil {
+		// Fail closed: a policy exists to keep data in
+		log.Printf("[channel-policy] failed to load policy for %s: %v", utils.UUIDToStr(channelID), err)
+		return ChannelDataPolicy{}
+	}
+	if !p.ChannelID.Valid {
+		return ChannelDataPolicy{AISummaries: true, Exports: true}
+	}
+	return channelPolicyToResponse(p)
+}
+
+// withPolicy fills in the channel's data policy when it restricts anything
+func (resp ChannelResponse) withPolicy(p db.ChannelDataPolicy, ok bool) ChannelResponse {
+	if ok {
+		if policy := channelPolicyToResponse(p); policy.restricted() {
+			resp.DataPolicy = &policy
+		}
+	}
+	return resp
+}
+
+// policiesByChannel indexes a loop's policy rows by channel
+func policiesByChannel(rows []db.ChannelDataPolicy) map[pgtype.UUID]db.ChannelDataPolicy {
+	out := make(map[pgtype.UUID]db.ChannelDataPolicy, len(rows))
+	for _, p := range rows {
+		out[p.ChannelID] = p
+	}
+	return out
+}
+
+// HandleSetChannelDataPolicy changes what channel :id lets leave Wireloop
+// (moderators). Turning AI summaries off also deletes the channel's stored
+// thread summaries.
+func (h *Handler) HandleSetChannelDataPolicy(c *gin.Context) {
+	var req SetChannelDataPolicyRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	channel, user, ok := h.moderatedChannel(c, "change a channel's data policy")
+	if !ok {
+		return
+	}
+	policy := h.channelPolicy(c, channel.ID)
+	if req.AISummaries != nil {
+		policy.AISummaries = *req.AISummaries
+	}
+	if req.Exports != nil {
+		policy.Exports = *req.Exports
+	}
+
+	p, err := h.Queries.SetChannelDataPolicy(c, db.SetChannelDataPolicyParams{
+		ChannelID:   channel.ID,
+		ProjectID:   channel.ProjectID,
+		AiSummaries: policy.AISummaries,
+		Exports:     policy.Exports,
+		UpdatedBy:   user.ID,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to update data policy")
+		return
+	}
+	lookupInvalidator.Invalidate("channel_policy", utils.UUIDToStr(channel.ID))
+	if !p.AiSummaries {
+		if err := h.Queries.DeleteChannelThreadSummaries(c, channel.ID); err != nil {
+			log.Printf("[channel-policy] failed to delete thread summaries of %s: %v", utils.UUIDToStr(channel.ID), err)
+		}
+	}
+	log.Printf("[channel-policy] #%s: ai_summaries=%t exports=%t, set by %s", channel.Name, p.AiSummaries, p.Exports, user.Username)
+	c.JSON(200, channelPolicyToResponse(p))
+}
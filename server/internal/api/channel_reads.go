@@ -0,0 +1,126 @@
+package api
+
+import (
+	"strconv"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// CHANNEL READ STATE
+// Members mark how far they've read each channel, and their other devices
+// hear about it over the socket. LoopFull and Init carry every visible
+// channel's latest message and unread/mention counts, so the sidebar can put
+// unread channels first without a request per channel.
+// ============================================================================
+
+type MarkChannelReadRequest struct {
+	// Read up to this message; the channel's latest when omitted
+	MessageID string `json:"message_id" binding:"omitempty,numeric"`
+}
+
+// ReadStateEvent is the read_state WebSocket payload sent to the reader's
+// own connections, and the response to marking a channel read
+type ReadStateEvent struct {
+	LoopID     string `json:"loop_id"`
+	ChannelID  string `json:"channel_id"`
+	LastReadID string `json:"last_read_id"`
+}
+
+// ChannelActivity is a channel's latest message and what the caller hasn't read
+type ChannelActivity struct {
+	ChannelID          string  `json:"channel_id"`
+	LastMessageID      string  `json:"last_message_id,omitempty"`
+	LastMessageAt      *string `json:"last_message_at"`
+	LastMessagePreview string  `json:"last_message_preview,omitempty"`
+	LastMessageSender  string  `json:"last_message_sender,omitempty"`
+	// Top-level messages from others since the read mark, at most 100
+	UnreadCount  int `json:"unread_count"`
+	MentionCount int `json:"mention_count"`
+}
+
+func channelActivityToData(a db.GetChannelActivityRow) ChannelActivity {
+	data := ChannelActivity{
+		ChannelID:     utils.UUIDToStr(a.ChannelID),
+		LastMessageAt: optionalTime(a.LastMessageAt),
+		UnreadCount:   int(a.UnreadCount),
+		MentionCount:  int(a.MentionCount),
+	}
+	if a.LastMessageID.Valid {
+		data.LastMessageID = utils.FormatMessageID(a.LastMessageID.Int64)
+		data.LastMessagePreview = feedTitle(a.LastMessageContent.String)
+		data.LastMessageSender = a.LastMessageSender.String
+	}
+	return data
+}
+
+// channelActivityByID indexes activity rows by channel for the channel list
+func channelActivityByID(rows []db.GetChannelActivityRow) map[pgtype.UUID]*ChannelActivity {
+	out := make(map[pgtype.UUID]*ChannelActivity, len(rows))
+	for _, a := range rows {
+		data := channelActivityToData(a)
+		out[a.ChannelID] = &data
+	}
+	return out
+}
+
+// HandleMarkChannelRead moves the caller's read mark in channel :id forward
+func (h *Handler) HandleMarkChannelRead(c *gin.Context) {
+	var req MarkChannelReadRequest
+	if c.Request.ContentLength != 0 && !bindStrictJSON(c, &req) { // body is optional
+		return
+	}
+	channelID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid channel id")
+		return
+	}
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	channel, err := h.Queries.GetChannelByID(c, channelID)
+	if err != nil {
+		problem.Respond(c, 404, "channel not found")
+		return
+	}
+	if !h.Members.CanUseChannel(c, h.Members.Role(c, uid, channel.ProjectID), channel.ProjectID, channelID) {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+
+	var upTo pgtype.Int8
+	if req.MessageID != "" {
+		id, err := strconv.ParseInt(req.MessageID, 10, 64)
+		if err != nil {
+			problem.Respond(c, 400, "invalid message id")
+			return
+		}
+		upTo = pgtype.Int8{Int64: id, Valid: true}
+	}
+	lastRead, err := h.Queries.MarkChannelRead(c, db.MarkChannelReadParams{
+		UserID:    uid,
+		ChannelID: channelID,
+		MessageID: upTo,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to mark read")
+		return
+	}
+
+	// The caller's other devices clear their badges; nobody else needs to know
+	state := ReadStateEvent{
+		LoopID:     utils.UUIDToStr(channel.ProjectID),
+		ChannelID:  utils.UUIDToStr(channelID),
+		LastReadID: utils.FormatMessageID(lastRead),
+	}
+	h.Events.PublishUser(utils.UUIDToStr(uid), events.Of(events.ReadState, state))
+	c.JSON(200, state)
+}
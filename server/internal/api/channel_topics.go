@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// CHANNEL TOPICS AND BANNERS
+// Moderators set a channel's topic and an announcement banner, which can
+// expire. Both come back in ChannelResponse, and every change is pushed to
+// the loop as channel_topic_changed.
+// ============================================================================
+
+// ChannelBanner is a channel's announcement banner
+type ChannelBanner struct {
+	Text      string  `json:"text"`
+	SetBy     string  `json:"set_by,omitempty"`
+	SetAt     string  `json:"set_at"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+}
+
+type SetChannelTopicRequest struct {
+	Topic string `json:"topic" binding:"max=250"` // empty clears it
+}
+
+type SetChannelBannerRequest struct {
+	Text string `json:"text" binding:"required,notblank,max=1000"`
+	// When the banner comes down by itself; it stays until cleared if omitted
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// ChannelTopicEvent is the channel_topic_changed WebSocket payload
+type ChannelTopicEvent struct {
+	ChannelID string         `json:"channel_id"`
+	Topic     string         `json:"topic"`
+	Banner    *ChannelBanner `json:"banner"`
+	ChangedBy string         `json:"changed_by"`
+}
+
+// channelBanner is t's banner, or nil when there is none or it has expired
+func channelBanner(t db.ChannelTopic, now time.Time) *ChannelBanner {
+	if t.Banner == "" || (t.BannerExpiresAt.Valid && !t.BannerExpiresAt.Time.After(now)) {
+		return nil
+	}
+	b := &ChannelBanner{
+		Text:      t.Banner,
+		SetAt:     utils.FormatTime(t.BannerSetAt.Time),
+		ExpiresAt: optionalTime(t.BannerExpiresAt),
+	}
+	if t.BannerSetBy.Valid {
+		b.SetBy = utils.UUIDToStr(t.BannerSetBy)
+	}
+	return b
+}
+
+// withTopic fills in the channel's topic and live banner
+func (resp ChannelResponse) withTopic(t db.ChannelTopic, ok bool) ChannelResponse {
+	if ok {
+		resp.Topic = t.Topic
+		resp.Banner = channelBanner(t, time.Now())
+	}
+	return resp
+}
+
+// topicsByChannel indexes a loop's topic rows by channel
+func topicsByChannel(rows []db.ChannelTopic) map[pgtype.UUID]db.ChannelTopic {
+	out := make(map[pgtype.UUID]db.ChannelTopic, len(rows))
+	for _, t := range rows {
+		out[t.ChannelID] = t
+	}
+	return out
+}
+
+// moderatedChannel loads channel :id for a moderator of its loop. On
+// failure it writes the error.
+func (h *Handler) moderatedChannel(c *gin.Context, action string) (db.Channel, db.User, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Channel{}, db.User{}, false
+	}
+	channelID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid channel id")
+		return db.Channel{}, db.User{}, false
+	}
+	channel, err := h.Queries.GetChannelByID(c, channelID)
+	if err != nil {
+		problem.Respond(c, 404, "channel not found")
+		return db.Channel{}, db.User{}, false
+	}
+	project, err := h.Loops.ByID(c, channel.ProjectID)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return db.Channel{}, db.User{}, false
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only the loop owner and moderators can "+action)
+		return db.Channel{}, db.User{}, false
+	}
+	user, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return db.Channel{}, db.User{}, false
+	}
+	return channel, user, true
+}
+
+// announceChannelTopic tells the loop about a topic or banner change
+func (h *Handler) announceChannelTopic(ctx context.Context, t db.ChannelTopic, by db.User) ChannelTopicEvent {
+	ev := ChannelTopicEvent{
+		ChannelID: utils.UUIDToStr(t.ChannelID),
+		Topic:     t.Topic,
+		Banner:    channelBanner(t, time.Now()),
+		ChangedBy: by.Username,
+	}
+	h.Events.Publish(loopRoom(utils.UUIDToStr(t.ProjectID)), events.Of(events.ChannelTopicChanged, ev))
+	return ev
+}
+
+// HandleSetChannelTopic sets or clears channel :id's topic (moderators)
+func (h *Handler) HandleSetChannelTopic(c *gin.Context) {
+	var req SetChannelTopicRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	channel, user, ok := h.moderatedChannel(c, "change the topic")
+	if !ok {
+		return
+	}
+
+	t, err := h.Queries.SetChannelTopic(c, db.SetChannelTopicParams{
+		ChannelID:  channel.ID,
+		ProjectID:  channel.ProjectID,
+		Topic:      strings.TrimSpace(req.Topic),
+		TopicSetBy: user.ID,
+	})
+	if err != nil {
+		log.Printf("[channels] failed to set topic of %s: %v", utils.UUIDToStr(channel.ID), err)
+		problem.Respond(c, 500, "failed to set topic")
+		return
+	}
+	c.JSON(200, h.announceChannelTopic(c, t, user))
+}
+
+// HandleSetChannelBanner puts up an announcement banner in channel :id,
+// replacing any other (moderators)
+func (h *Handler) HandleSetChannelBanner(c *gin.Context) {
+	var req SetChannelBannerRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	var expires pgtype.Timestamptz
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			problem.Respond(c, 400, "expires_at must be in the future")
+			return
+		}
+		expires = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
+	}
+	channel, user, ok := h.moderatedChannel(c, "set the banner")
+	if !ok {
+		return
+	}
+
+	t, err := h.Queries.SetChannelBanner(c, db.SetChannelBannerParams{
+		ChannelID:       channel.ID,
+		ProjectID:       channel.ProjectID,
+		Banner:          strings.TrimSpace(req.Text),
+		BannerSetBy:     user.ID,
+		BannerExpiresAt: expires,
+	})
+	if err != nil {
+		log.Printf("[channels] failed to set banner of %s: %v", utils.UUIDToStr(channel.ID), err)
+		problem.Respond(c, 500, "failed to set banner")
+		return
+	}
+	c.JSON(200, h.announceChannelTopic(c, t, user))
+}
+
+// HandleClearChannelBanner takes down channel :id's banner (moderators)
+func (h *Handler) HandleClearChannelBanner(c *gin.Context) {
+	channel, user, ok := h.moderatedChannel(c, "clear the banner")
+	if !ok {
+		return
+	}
+
+	t, err := h.Queries.SetChannelBanner(c, db.SetChannelBannerParams{
+		ChannelID:   channel.ID,
+		ProjectID:   channel.ProjectID,
+		BannerSetBy: user.ID,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to clear banner")
+		return
+	}
+	c.JSON(200, h.announceChannelTopic(c, t, user))
+}
@@ -2,26 +2,36 @@ package api
 
 import (
 	"context"
-	"fmt"
+	"log"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 	utils "wireloop/internal"
 	"wireloop/internal/db"
+	"wireloop/internal/emoji"
+	"wireloop/internal/events"
+	"wireloop/internal/members"
+	"wireloop/internal/messages"
 	"wireloop/internal/middleware"
+	"wireloop/internal/msgfilter"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type MessagePayload struct {
-	MessageBody string  `json:"message_body"`
-	ChannelID   string  `json:"channel_id"`
-	ParentID    *string `json:"parent_id,omitempty"` // For thread replies
+	MessageBody string  `json:"message_body" binding:"required,notblank"` // at most Handler.MaxMessageChars unless LongPost
+	ChannelID   string  `json:"channel_id" binding:"required,uuid"`
+	ParentID    *string `json:"parent_id,omitempty" binding:"omitempty,numeric"` // For thread replies
+	// Send text over the limit as a long post instead of rejecting it
+	LongPost bool `json:"long_post,omitempty"`
 }
 
 // DeleteMessageRequest represents a request to delete a message
 type DeleteMessageRequest struct {
-	MessageID string `json:"message_id" binding:"required"`
+	MessageID string `json:"message_id" binding:"required,numeric"`
 }
 
 type MessageResponse struct {
@@ -31,77 +41,200 @@ type MessageResponse struct {
 	SenderUsername string  `json:"sender_username"`
 	SenderAvatar   string  `json:"sender_avatar"`
 	CreatedAt      string  `json:"created_at"`
+	CreatedAtMs    int64   `json:"created_at_ms"`
 	ChannelID      string  `json:"channel_id,omitempty"`
 	ParentID       *string `json:"parent_id,omitempty"`
 	ReplyCount     int     `json:"reply_count"`
+	Seq            int64   `json:"seq,omitempty"`            // per-channel order; top-level messages only
+	Muted          bool    `json:"muted,omitempty"`          // contains one of the caller's muted words
+	BlockedAuthor  bool    `json:"blocked_author,omitempty"` // sender is on the caller's block list
+	Deleted        bool    `json:"deleted,omitempty"`        // tombstone; Content is the placeholder
+
+	Reactions []ReactionSummary `json:"reactions,omitempty"`
+	Entities  []emoji.Entity    `json:"entities,omitempty"` // resolved :shortcode: emoji
+	// Issues, PRs and commits the text references; filled in after sending
+	GitHubEntities []GitHubEntity `json:"github_entities,omitempty"`
+	// Set when Content is the preview of a long post
+	LongPost *LongPostRef `json:"long_post,omitempty"`
+	// AI summary of the thread, once it is long enough to have one
+	ThreadSummary *ThreadSummaryResponse `json:"thread_summary,omitempty"`
 }
 
+// HandleSendMessage posts a message over REST, for API keys and for clients
+// that stream over SSE because their network blocks the socket. It follows
+// the same rules as a socket send and broadcasts the same frame.
 func (h *Handler) HandleSendMessage(c *gin.Context) {
 	var req MessagePayload
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
-	channelID, err := utils.StrToUUID(req.ChannelID)
+	channelUUID, err := utils.StrToUUID(req.ChannelID)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid channel id"})
+		problem.Respond(c, 400, "invalid channel id")
 		return
 	}
+	var projectUUID pgtype.UUID
+	if channel, err := h.Queries.GetChannelByID(c, channelUUID); err == nil {
+		projectUUID = channel.ProjectID
+	} else {
+		// Older clients send the loop's ID and post to its default channel
+		projectUUID = channelUUID
+		channel, err := h.Queries.GetDefaultChannel(c, projectUUID)
+		if err != nil {
+			problem.Respond(c, 404, "channel not found")
+			return
+		}
+		channelUUID = channel.ID
+	}
+	channelID := utils.UUIDToStr(channelUUID)
 
-	if _, err := h.Queries.IsMember(c, db.IsMemberParams{
-		UserID: uid, ProjectID: channelID,
-	}); err != nil {
-		c.JSON(403, gin.H{"error": "not a member"})
+	role := h.Members.Role(c, uid, projectUUID)
+	if role == "" {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+	if role == members.Guest && !h.Members.GuestChannels(c, projectUUID)[channelUUID] {
+		problem.Respond(c, 403, "guests can only post in guest channels")
+		return
+	}
+	if reason := members.GuestPostAllowed(role, req.MessageBody); reason != "" {
+		problem.Respond(c, 403, reason)
+		return
+	}
+
+	// content is what the message stores: the text, or a long post's preview
+	content := req.MessageBody
+	longPost := false
+	if h.messageTooLong(content) != "" {
+		if !req.LongPost {
+			h.respondMessageTooLong(c, content)
+			return
+		}
+		if len(content) > maxLongPostBytes {
+			problem.RespondCode(c, 413, "long_post_too_long", "long posts must be at most 512KB", gin.H{"limit": maxLongPostBytes})
+			return
+		}
+		content, longPost = longPostPreview(content), true
+	}
+
+	var parentID pgtype.Int8
+	if req.ParentID != nil && *req.ParentID != "" {
+		pid, _ := strconv.ParseInt(*req.ParentID, 10, 64)
+		parentID = pgtype.Int8{Int64: pid, Valid: true}
+	} else {
+		req.ParentID = nil
+	}
+
+	link, linked := h.channelGitHubLink(c, channelUUID)
+	if linked && link.ArchivedAt.Valid {
+		problem.Respond(c, 403, "this channel was archived when its GitHub "+link.Kind+" closed")
+		return
+	}
+	if h.loopArchived(c, projectUUID) {
+		problem.Respond(c, 403, loopArchivedReason)
 		return
 	}
 
 	// Get sender info for broadcast
-	user, err := h.Queries.GetUserByID(c, uid)
+	user, err := h.getUserByID(c, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
 
 	msgID := utils.GetMessageId()
 	now := time.Now()
 
+	msg := MessageResponse{
+		ID:             strconv.FormatInt(msgID, 10),
+		Content:        content,
+		SenderID:       utils.UUIDToStr(uid),
+		SenderUsername: user.Username,
+		SenderAvatar:   mediaURL(user.AvatarUrl.String),
+		CreatedAt:      utils.FormatTime(now),
+		CreatedAtMs:    utils.EpochMillis(now),
+		ChannelID:      channelID,
+		ParentID:       req.ParentID,
+		Entities:       h.messageEntities(c, projectUUID, content),
+	}
+
+	verdict := h.screenMessage(c, msgID, projectUUID, channelUUID, uid, parentID, req.MessageBody)
+	switch verdict.Action {
+	case msgfilter.ActionBlock:
+		problem.RespondCode(c, 422, "message_blocked", blockedReason(verdict), gin.H{"rule": verdict.Rule})
+		return
+	case msgfilter.ActionHold:
+		// Shadow hold: the sender gets the normal response, nobody else sees it yet
+		c.JSON(200, msg)
+		return
+	}
+	if err := h.Quotas.UseMessage(c, projectUUID); err != nil {
+		respondQuota(c, err)
+		return
+	}
+
+	var doc db.Attachment
+	if longPost {
+		if doc, err = h.storeLongPost(c, projectUUID, channelUUID, uid, msgID, req.MessageBody); err != nil {
+			log.Printf("[long-posts] store failed: %v", err)
+			problem.Respond(c, 500, "failed to store long post")
+			return
+		}
+	}
+	seq := h.nextSeq(c, channelUUID, parentID)
+	msg.Seq = seq.Int64
 	if err := h.Queries.AddMessage(c, db.AddMessageParams{
 		ID:        msgID,
 		SenderID:  uid,
-		Content:   req.MessageBody,
-		ProjectID: channelID,
+		Content:   content,
+		ProjectID: projectUUID,
+		ChannelID: channelUUID,
+		ParentID:  parentID,
+		Seq:       seq,
 	}); err != nil {
-		c.JSON(500, gin.H{"error": "db tx failed"})
+		problem.Respond(c, 500, "db tx failed")
 		return
 	}
-
-	// Broadcast with full message info
-	msg := MessageResponse{
-		ID:             strconv.FormatInt(msgID, 10),
-		Content:        req.MessageBody,
-		SenderID:       utils.UUIDToStr(uid),
-		SenderUsername: user.Username,
-		SenderAvatar:   user.AvatarUrl.String,
-		CreatedAt:      now.Format(time.RFC3339),
+	if longPost {
+		chars := utf8.RuneCountInString(req.MessageBody)
+		if err := h.Queries.CreateLongPost(c, db.CreateLongPostParams{
+			MessageID:    msgID,
+			AttachmentID: doc.ID,
+			Chars:        int32(chars),
+		}); err != nil {
+			// The preview still posts; the full text is only in the attachment
+			log.Printf("[long-posts] failed to link %d: %v", msgID, err)
+		} else {
+			msg.LongPost = &LongPostRef{Chars: chars, Size: doc.SizeBytes}
+		}
 	}
+	h.touchMember(uid, projectUUID)
 
-	h.PushToWS(req.ChannelID, gin.H{
-		"type":    "message",
-		"payload": msg,
-	})
+	// Same frame a socket send broadcasts, so WS and SSE clients render it alike
+	h.Events.PublishChannelFrom(channelID, events.Of(events.Message, msg), now)
 
-	// Process @mentions asynchronously
 	go func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
-		h.ProcessMentions(ctx, req.MessageBody, uid, user.Username, msgID, channelID, channelID)
+		if parentID.Valid {
+			h.Queries.IncrementReplyCount(ctx, parentID.Int64)
+			h.queueThreadSummary(ctx, parentID.Int64)
+		} else {
+			if linked && link.CrossPost {
+				h.queueCrossPost(ctx, msgID, channelUUID, uid)
+			}
+			h.completeOnboarding(ctx, projectUUID, uid, onboardingPostInChannel, channelUUID)
+			h.dispatchIntegrations(ctx, projectUUID, messageEvent(msg))
+		}
+		h.ProcessMentions(ctx, req.MessageBody, uid, user.Username, msgID, projectUUID, channelUUID)
+		h.queueGitHubRefs(ctx, msgID, content, utils.UUIDToStr(uid))
 	}()
 
 	c.JSON(200, msg)
@@ -112,28 +245,17 @@ func (h *Handler) HandleGetMessages(c *gin.Context) {
 	loopName := c.Param("name")
 	channelID := c.Query("channel_id")
 	if loopName == "" {
-		c.JSON(400, gin.H{"error": "loop name required"})
+		problem.Respond(c, 400, "loop name required")
 		return
 	}
 
-	uid, ok := utils.GetUserIdFromContext(c)
-	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
-		return
-	}
+	// Anonymous visitors may read the public channels of a public loop
+	uid, _ := utils.GetUserIdFromContext(c)
 
 	// Get project by name
-	project, err := h.Queries.GetProjectByName(c, loopName)
+	project, err := h.Loops.ByName(c, loopName)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
-		return
-	}
-
-	// Verify membership
-	if _, err := h.Queries.IsMember(c, db.IsMemberParams{
-		UserID: uid, ProjectID: project.ID,
-	}); err != nil {
-		c.JSON(403, gin.H{"error": "not a member"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
@@ -141,46 +263,118 @@ func (h *Handler) HandleGetMessages(c *gin.Context) {
 	var channelUUID pgtype.UUID
 	if channelID != "" {
 		if err := channelUUID.Scan(channelID); err != nil {
-			c.JSON(400, gin.H{"error": "invalid channel id"})
+			problem.Respond(c, 400, "invalid channel id")
+			return
+		}
+		// Only the loop in the path's channels; access is checked against it
+		channel, err := h.Queries.GetChannelByID(c, channelUUID)
+		if err != nil || channel.ProjectID != project.ID {
+			problem.Respond(c, 404, "channel not found")
 			return
 		}
 	} else {
 		// Get default channel for the loop
 		defaultChannel, err := h.EnsureDefaultChannel(c, project.ID)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "failed to get default channel"})
+			problem.Respond(c, 500, "failed to get default channel")
 			return
 		}
 		channelUUID = defaultChannel.ID
 	}
+	if !h.channelReadAccess(c, uid, project.ID, channelUUID) {
+		return
+	}
+
+	h.respondChannelHistory(c, uid, project.ID, channelUUID)
+}
 
+// respondChannelHistory writes a page of the channel's top-level messages,
+// oldest first. Pages are picked by offset from the newest message, or by
+// snowflake cursor: before=<id> pages upward from a message, after=<id>
+// downward, and both together load the window between two messages.
+// has_more says whether the page was cut short in its direction, and
+// next_cursor continues in that direction.
+func (h *Handler) respondChannelHistory(c *gin.Context, uid, projectID, channelUUID pgtype.UUID) {
 	// Parse pagination
-	limit := int32(50)
+	limit := int32(pageLimit(c, 50, 100))
 	offset := int32(0)
-	if l := c.Query("limit"); l != "" {
-		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
-			limit = int32(v)
-		}
-	}
 	if o := c.Query("offset"); o != "" {
 		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
 			offset = int32(v)
 		}
 	}
+	var before, after pgtype.Int8
+	if cursor := c.Query("cursor"); cursor != "" {
+		var ok bool
+		if before, after, ok = parseHistoryCursor(cursor); !ok {
+			problem.Respond(c, 400, "invalid cursor")
+			return
+		}
+	}
+	for _, cursor := range []struct {
+		name string
+		dst  *pgtype.Int8
+	}{{"before", &before}, {"after", &after}} {
+		if q := c.Query(cursor.name); q != "" {
+			id, err := strconv.ParseInt(q, 10, 64)
+			if err != nil || id < 0 {
+				problem.Respond(c, 400, "invalid "+cursor.name+" message id")
+				return
+			}
+			*cursor.dst = pgtype.Int8{Int64: id, Valid: true}
+		}
+	}
+	if before.Valid && after.Valid && after.Int64 >= before.Int64 {
+		problem.Respond(c, 400, "after must be older than before")
+		return
+	}
 
-	messages, err := h.Queries.GetMessages(c, db.GetMessagesParams{
-		ChannelID: channelUUID,
-		Limit:     limit,
-		Offset:    offset,
-	})
+	// One extra row tells whether there is more past the page
+	var page []db.GetMessagesRow
+	var err error
+	switch {
+	case after.Valid:
+		var rows []db.GetMessagesAfterRow
+		rows, err = h.Queries.GetMessagesAfter(c, db.GetMessagesAfterParams{
+			ChannelID: channelUUID,
+			After:     after.Int64,
+			Before:    before,
+			RowLimit:  limit + 1,
+		})
+		for _, r := range rows {
+			page = append(page, db.GetMessagesRow(r))
+		}
+	case before.Valid:
+		var rows []db.GetMessagesBeforeRow
+		rows, err = h.Queries.GetMessagesBefore(c, db.GetMessagesBeforeParams{
+			ChannelID: channelUUID,
+			Before:    before.Int64,
+			RowLimit:  limit + 1,
+		})
+		for _, r := range rows {
+			page = append(page, db.GetMessagesRow(r))
+		}
+	default:
+		page, err = h.Queries.GetMessages(c, db.GetMessagesParams{
+			ChannelID: channelUUID,
+			Limit:     limit + 1,
+			Offset:    offset,
+		})
+	}
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get messages"})
+		problem.Respond(c, 500, "failed to get messages")
 		return
 	}
+	page, hasMore := trimPage(page, int(limit))
+	next := ""
+	if hasMore {
+		// The last row is the furthest from where the page started
+		next = historyCursor(page[len(page)-1].ID, after.Valid)
+	}
 
 	// Transform to response format
-	result := make([]MessageResponse, len(messages))
-	for i, m := range messages {
+	result := make([]MessageResponse, len(page))
+	for i, m := range page {
 		var parentID *string
 		if m.ParentID.Valid {
 			pid := strconv.FormatInt(m.ParentID.Int64, 10)
@@ -188,82 +382,107 @@ func (h *Handler) HandleGetMessages(c *gin.Context) {
 		}
 		result[i] = MessageResponse{
 			ID:             strconv.FormatInt(m.ID, 10),
-			Content:        m.Content,
+			Content:        messages.Content(m.Content, m.IsDeleted),
 			SenderID:       utils.UUIDToStr(m.SenderID),
 			SenderUsername: m.SenderUsername,
-			SenderAvatar:   m.SenderAvatar.String,
-			CreatedAt:      m.CreatedAt.Time.Format(time.RFC3339),
+			SenderAvatar:   mediaURL(m.SenderAvatar.String),
+			CreatedAt:      utils.FormatTime(m.CreatedAt.Time),
+			CreatedAtMs:    utils.EpochMillis(m.CreatedAt.Time),
 			ParentID:       parentID,
+			Deleted:        m.IsDeleted,
 			ReplyCount:     int(m.ReplyCount.Int32),
+			Seq:            m.Seq.Int64,
+		}
+	}
+
+	// Reverse to get chronological order (oldest first); after= pages
+	// already are
+	if !after.Valid {
+		for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+			result[i], result[j] = result[j], result[i]
 		}
 	}
+	h.attachEntities(c, projectID, result)
+	h.annotateForViewer(c, uid, result)
+
+	c.JSON(200, newPage(result, hasMore, next))
+}
 
-	// Reverse to get chronological order (oldest first)
-	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
-		result[i], result[j] = result[j], result[i]
+// historyCursor continues channel history past message id: upward, or
+// downward for after= pages
+func historyCursor(id int64, downward bool) string {
+	if downward {
+		return "after:" + strconv.FormatInt(id, 10)
 	}
+	return "before:" + strconv.FormatInt(id, 10)
+}
 
-	c.JSON(200, gin.H{"messages": result})
+// parseHistoryCursor reads a historyCursor back as before= or after=
+func parseHistoryCursor(cursor string) (before, after pgtype.Int8, ok bool) {
+	dir, raw, found := strings.Cut(cursor, ":")
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if !found || err != nil || id < 0 {
+		return before, after, false
+	}
+	switch dir {
+	case "before":
+		before = pgtype.Int8{Int64: id, Valid: true}
+	case "after":
+		after = pgtype.Int8{Int64: id, Valid: true}
+	default:
+		return before, after, false
+	}
+	return before, after, true
 }
 
-// HandleGetThreadReplies returns all replies to a specific message
+// ThreadRepliesResponse is a page of a thread's replies, oldest first
+type ThreadRepliesResponse struct {
+	Page[MessageResponse]
+	ParentID      string                 `json:"parent_id"`
+	ThreadSummary *ThreadSummaryResponse `json:"thread_summary,omitempty"`
+}
+
+// HandleGetThreadReplies returns a page of the replies to a message
 func (h *Handler) HandleGetThreadReplies(c *gin.Context) {
 	messageIDStr := c.Param("message_id")
 	if messageIDStr == "" {
-		c.JSON(400, gin.H{"error": "message id required"})
+		problem.Respond(c, 400, "message id required")
 		return
 	}
 
-	uid, ok := utils.GetUserIdFromContext(c)
-	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
-		return
-	}
+	uid, _ := utils.GetUserIdFromContext(c)
 
 	messageID, err := strconv.ParseInt(messageIDStr, 10, 64)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid message id"})
+		problem.Respond(c, 400, "invalid message id")
 		return
 	}
 
 	// Get the parent message to verify channel access
 	parentMsg, err := h.Queries.GetMessageByID(c, messageID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "message not found"})
+		problem.Respond(c, 404, "message not found")
 		return
 	}
 
-	// Verify membership via project_id
-	if _, err := h.Queries.IsMember(c, db.IsMemberParams{
-		UserID: uid, ProjectID: parentMsg.ProjectID,
-	}); err != nil {
-		c.JSON(403, gin.H{"error": "not a member"})
+	if !h.channelReadAccess(c, uid, parentMsg.ProjectID, parentMsg.ChannelID) {
 		return
 	}
 
-	// Parse pagination
-	limit := int32(50)
-	offset := int32(0)
-	if l := c.Query("limit"); l != "" {
-		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
-			limit = int32(v)
-		}
-	}
-	if o := c.Query("offset"); o != "" {
-		if v, err := strconv.Atoi(o); err == nil && v >= 0 {
-			offset = int32(v)
-		}
+	limit, offset, ok := offsetPage(c, 50, 100)
+	if !ok {
+		return
 	}
-
 	replies, err := h.Queries.GetThreadReplies(c, db.GetThreadRepliesParams{
 		ParentID: pgtype.Int8{Int64: parentMsg.ID, Valid: true},
-		Limit:    limit,
-		Offset:   offset,
+		Limit:    int32(limit + 1),
+		Offset:   int32(offset),
 	})
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get replies"})
+		problem.Respond(c, 500, "failed to get replies")
 		return
 	}
+	replies, hasMore := trimPage(replies, limit)
 
 	result := make([]MessageResponse, len(replies))
 	for i, m := range replies {
@@ -274,97 +493,120 @@ func (h *Handler) HandleGetThreadReplies(c *gin.Context) {
 		}
 		result[i] = MessageResponse{
 			ID:             strconv.FormatInt(m.ID, 10),
-			Content:        m.Content,
+			Content:        messages.Content(m.Content, m.IsDeleted),
 			SenderID:       utils.UUIDToStr(m.SenderID),
 			SenderUsername: m.SenderUsername,
-			SenderAvatar:   m.SenderAvatar.String,
-			CreatedAt:      m.CreatedAt.Time.Format(time.RFC3339),
+			SenderAvatar:   mediaURL(m.SenderAvatar.String),
+			CreatedAt:      utils.FormatTime(m.CreatedAt.Time),
+			CreatedAtMs:    utils.EpochMillis(m.CreatedAt.Time),
 			ParentID:       parentID,
+			Deleted:        m.IsDeleted,
 		}
 	}
 
-	c.JSON(200, gin.H{"replies": result, "parent_id": messageIDStr})
+	h.attachEntities(c, parentMsg.ProjectID, result)
+	h.annotateForViewer(c, uid, result)
+
+	resp := ThreadRepliesResponse{
+		Page:     newPage(result, hasMore, offsetCursor(offset+limit)),
+		ParentID: messageIDStr,
+	}
+	if s, err := h.Queries.GetThreadSummary(c, parentMsg.ID); err == nil {
+		resp.ThreadSummary = threadSummaryToResponse(s)
+	}
+	c.JSON(200, resp)
 }
 
 // HandleDeleteMessage soft-deletes a message (only by sender or loop owner)
 func (h *Handler) HandleDeleteMessage(c *gin.Context) {
 	messageIDStr := c.Param("message_id")
 	if messageIDStr == "" {
-		c.JSON(400, gin.H{"error": "message id required"})
+		problem.Respond(c, 400, "message id required")
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	messageID, err := strconv.ParseInt(messageIDStr, 10, 64)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid message id"})
+		problem.Respond(c, 400, "invalid message id")
 		return
 	}
 
-	// Get the message
-	msg, err := h.Queries.GetMessageByID(c, messageID)
+	msg, err := h.MessageRules.Get(c, messageID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "message not found"})
+		problem.Respond(c, 404, "message not found")
 		return
 	}
 
 	// Get project to check ownership
-	project, err := h.Queries.GetProjectByID(c, msg.ProjectID)
+	project, err := h.Loops.ByID(c, msg.ProjectID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get project"})
+		problem.Respond(c, 500, "failed to get project")
 		return
 	}
 
-	// Only sender or project owner can delete
-	isSender := msg.SenderID == uid
-	isOwner := project.OwnerID == uid
-	if !isSender && !isOwner {
-		c.JSON(403, gin.H{"error": "only message sender or loop owner can delete"})
+	if !h.MessageRules.CanDelete(uid, msg, project) {
+		problem.Respond(c, 403, "only message sender or loop owner can delete")
 		return
 	}
 
-	// Soft delete the message
-	if err := h.Queries.SoftDeleteMessage(c, messageID); err != nil {
-		c.JSON(500, gin.H{"error": "failed to delete message"})
+	if err := h.MessageRules.Delete(c, msg); err != nil {
+		problem.Respond(c, 500, "failed to delete message")
 		return
 	}
 
-	// If it was a reply, decrement parent's reply count
-	if msg.ParentID.Valid {
-		h.Queries.DecrementReplyCount(c, msg.ParentID.Int64)
-	}
-
 	// Broadcast deletion to WebSocket
-	h.PushToWS(utils.UUIDToStr(msg.ChannelID), gin.H{
-		"type":       "message_deleted",
-		"message_id": messageIDStr,
-	})
+	h.Events.PublishChannel(utils.UUIDToStr(msg.ChannelID), events.MessageRef{MessageID: messageIDStr})
 
 	c.JSON(200, gin.H{"message": "deleted", "id": messageIDStr})
 }
 
+// HandleAdminPurgeDeletedMessages hard-deletes messages soft-deleted more than
+// older_than_days ago (default 30). Deleted messages that still have replies
+// are kept as their thread's tombstone.
+func (h *Handler) HandleAdminPurgeDeletedMessages(c *gin.Context) {
+	days := 30
+	if d := c.Query("older_than_days"); d != "" {
+		v, err := strconv.Atoi(d)
+		if err != nil || v < 0 {
+			problem.Respond(c, 400, "older_than_days must be a non-negative integer")
+			return
+		}
+		days = v
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	purged, err := h.Queries.PurgeDeletedMessages(c, pgtype.Timestamptz{Time: cutoff, Valid: true})
+	if err != nil {
+		problem.Respond(c, 500, "failed to purge deleted messages")
+		return
+	}
+	log.Printf("[admin] purged %d messages deleted before %s", purged, utils.FormatTime(cutoff))
+	c.JSON(200, gin.H{"purged": purged, "deleted_before": utils.FormatTime(cutoff)})
+}
+
 // HandleGetLoopDetails returns loop info including members
 func (h *Handler) HandleGetLoopDetails(c *gin.Context) {
 	name := c.Param("name")
 	if name == "" {
-		c.JSON(400, gin.H{"error": "loop name required"})
+		problem.Respond(c, 400, "loop name required")
 		return
 	}
 
-	project, err := h.Queries.GetProjectByName(c, name)
+	project, err := h.Loops.ByName(c, name)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
 	members, err := h.Queries.GetLoopMembers(c, project.ID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get members"})
+		problem.Respond(c, 500, "failed to get members")
 		return
 	}
 
@@ -383,18 +625,16 @@ func (h *Handler) HandleGetLoopDetails(c *gin.Context) {
 	}
 
 	if ok {
-		_, err := h.Queries.IsMember(c, db.IsMemberParams{
-			UserID: uid, ProjectID: project.ID,
-		})
-		isMember = err == nil
+		isMember = h.Members.IsMember(c, uid, project.ID)
 	}
 
 	c.JSON(200, gin.H{
 		"id":         utils.UUIDToStr(project.ID),
 		"name":       project.Name,
 		"owner_id":   utils.UUIDToStr(project.OwnerID),
-		"created_at": project.CreatedAt.Time.Format(time.RFC3339),
+		"created_at": utils.FormatTime(project.CreatedAt.Time),
 		"is_member":  isMember,
+		"is_public":  h.publicChannelSet(c, project.ID) != nil,
 		"members":    formatMembers(members),
 	})
 }
@@ -405,18 +645,65 @@ func formatMembers(members []db.GetLoopMembersRow) []gin.H {
 		result[i] = gin.H{
 			"id":           utils.UUIDToStr(m.ID),
 			"username":     m.Username,
-			"avatar_url":   m.AvatarUrl.String,
+			"avatar_url":   mediaURL(m.AvatarUrl.String),
 			"display_name": m.DisplayName.String,
 			"role":         m.Role.String,
-			"joined_at":    m.JoinedAt.Time.Format(time.RFC3339),
+			"joined_at":    utils.FormatTime(m.JoinedAt.Time),
+			"badges":       m.Badges,
 		}
 	}
 	return result
 }
 
-func (h *Handler) PushToWS(projectID string, msg any) {
-	fmt.Printf("[WS] Broadcasting to room %s\n", projectID)
-	h.Hub.Broadcast(projectID, msg)
+// postChannelMessage stores a top-level message from sender and pushes it to
+// the channel's room. Used for messages the server writes on someone's behalf.
+func (h *Handler) postChannelMessage(ctx context.Context, projectID, channelID pgtype.UUID, sender db.User, content string) (int64, error) {
+	msgID := utils.GetMessageId()
+	seq := h.nextSeq(ctx, channelID, pgtype.Int8{})
+	if err := h.Queries.AddMessage(ctx, db.AddMessageParams{
+		ID:        msgID,
+		ProjectID: projectID,
+		ChannelID: channelID,
+		SenderID:  sender.ID,
+		Content:   content,
+		Seq:       seq,
+	}); err != nil {
+		return 0, err
+	}
+
+	room := utils.UUIDToStr(channelID)
+	now := time.Now()
+	h.Events.PublishChannel(room, events.Of(events.Message, MessageResponse{
+		ID:             strconv.FormatInt(msgID, 10),
+		Content:        content,
+		SenderID:       utils.UUIDToStr(sender.ID),
+		SenderUsername: sender.Username,
+		SenderAvatar:   mediaURL(sender.AvatarUrl.String),
+		CreatedAt:      utils.FormatTime(now),
+		CreatedAtMs:    utils.EpochMillis(now),
+		ChannelID:      room,
+		Seq:            seq.Int64,
+	}))
+	return msgID, nil
+}
+
+// nextSeq takes the next number of a top-level message's channel. It is taken
+// before the broadcast, so messages over the socket and history share one
+// order. Numbers only ever increase but may skip: a message that then fails
+// to store, or is deleted, leaves its number unused, so a gap alone doesn't
+// mean a client missed something. Replies are ordered within their thread
+// and get no number, nor do legacy loop-level messages or a message whose
+// number couldn't be taken; clients place those by ID.
+func (h *Handler) nextSeq(ctx context.Context, channelID pgtype.UUID, parentID pgtype.Int8) pgtype.Int8 {
+	if parentID.Valid || !channelID.Valid {
+		return pgtype.Int8{}
+	}
+	seq, err := h.Queries.NextChannelSeq(ctx, channelID)
+	if err != nil {
+		log.Printf("[chat] failed to number message in %s: %v", utils.UUIDToStr(channelID), err)
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: seq, Valid: true}
 }
 
 // HandleBrowseLoops returns a paginated list of all loops
@@ -440,7 +727,7 @@ func (h *Handler) HandleBrowseLoops(c *gin.Context) {
 		Offset: offset,
 	})
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get loops"})
+		problem.Respond(c, 500, "failed to get loops")
 		return
 	}
 
@@ -450,9 +737,18 @@ func (h *Handler) HandleBrowseLoops(c *gin.Context) {
 			"id":             utils.UUIDToStr(l.ID),
 			"name":           l.Name,
 			"owner_username": l.OwnerUsername,
-			"owner_avatar":   l.OwnerAvatar.String,
+			"owner_avatar":   mediaURL(l.OwnerAvatar.String),
 			"member_count":   l.MemberCount,
-			"created_at":     l.CreatedAt.Time.Format(time.RFC3339),
+			"created_at":     utils.FormatTime(l.CreatedAt.Time),
+		}
+		// Absent until the refresher has counted the repo
+		if l.Stars.Valid {
+			result[i]["stars"] = l.Stars.Int32
+			result[i]["forks"] = l.Forks.Int32
+			result[i]["contributors"] = l.Contributors.Int32
+		}
+		if l.PushedAt.Valid {
+			result[i]["pushed_at"] = utils.FormatTime(l.PushedAt.Time)
 		}
 	}
 
@@ -463,24 +759,19 @@ func (h *Handler) HandleBrowseLoops(c *gin.Context) {
 func (h *Handler) HandleGetMyMemberships(c *gin.Context) {
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	memberships, err := h.Queries.GetUserMemberships(c, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get memberships"})
+		problem.Respond(c, 500, "failed to get memberships")
 		return
 	}
 
-	result := make([]gin.H, len(memberships))
+	result := make([]MembershipData, len(memberships))
 	for i, m := range memberships {
-		result[i] = gin.H{
-			"loop_id":   utils.UUIDToStr(m.ProjectID),
-			"loop_name": m.ProjectName,
-			"role":      m.Role.String,
-			"joined_at": m.JoinedAt.Time.Format(time.RFC3339),
-		}
+		result[i] = membershipToData(m)
 	}
 
 	c.JSON(200, gin.H{"memberships": result})
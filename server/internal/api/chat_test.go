@@ -0,0 +1,49 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+)
+
+func TestGetMessagesRejectsChannelOfAnotherLoop(t *testing.T) {
+	it := newIntegration(t)
+	ctx := context.Background()
+	alice := it.user("alice", 1)
+	bob := it.user("bob", 2)
+	mine := it.loop("app", it.gh.AddRepo("alice", "app", false), alice, nil)
+	theirs := it.loop("secret", it.gh.AddRepo("bob", "secret", false), bob, nil)
+
+	channel := func(project db.Project) db.Channel {
+		ch, err := it.h.Queries.CreateChannel(ctx, db.CreateChannelParams{ProjectID: project.ID, Name: "general"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ch
+	}
+	own, foreign := channel(mine), channel(theirs)
+
+	cases := []struct {
+		name    string
+		channel db.Channel
+		status  int
+	}{
+		{"own channel", own, http.StatusOK},
+		{"channel of another loop", foreign, http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := it.do(it.h.HandleGetMessages, http.MethodGet, "/api/loops/:name/messages",
+				"/api/loops/"+mine.Name+"/messages?channel_id="+utils.UUIDToStr(tc.channel.ID), alice.ID, nil, nil)
+			if got != tc.status {
+				t.Errorf("status = %d, want %d", got, tc.status)
+			}
+		})
+	}
+	if it.h.Members.CanUseChannel(ctx, it.h.Members.Role(ctx, alice.ID, mine.ID), mine.ID, foreign.ID) {
+		t.Error("CanUseChannel allowed a channel of another loop")
+	}
+}
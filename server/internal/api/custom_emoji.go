@@ -0,0 +1,269 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/emoji"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// CUSTOM EMOJI AND SHORTCODES
+// Messages are scanned for :shortcode: sequences naming a standard emoji or
+// one of the loop's custom emoji, and carry the matches as entities so
+// clients, reactions and the GitHub bridge work with the emoji rather than
+// the raw text. Owners and moderators manage a loop's custom emoji; the
+// images are served through signed media URLs like avatars.
+// ============================================================================
+
+const (
+	emojiStoragePath = "emoji/"
+	maxEmojiImage    = 256 << 10
+	maxLoopEmoji     = 200
+)
+
+var (
+	loopEmojiCache = cache.New[string, map[string]db.LoopEmoji](lookupTTL, 2000) // by project ID
+	emojiImageExt  = map[string]string{
+		"image/png":  ".png",
+		"image/gif":  ".gif",
+		"image/webp": ".webp",
+		"image/jpeg": ".jpg",
+	}
+)
+
+type LoopEmojiResponse struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	URL       string `json:"url"`
+	CreatedAt string `json:"created_at"`
+}
+
+// loopEmojiSet returns the loop's custom emoji by name
+func (h *Handler) loopEmojiSet(ctx context.Context, projectID pgtype.UUID) map[string]db.LoopEmoji {
+	set, err := loopEmojiCache.GetOrLoad(utils.UUIDToStr(projectID), func() (map[string]db.LoopEmoji, error) {
+		rows, err := h.Queries.ListLoopEmoji(ctx, projectID)
+		if err != nil {
+			return nil, err
+		}
+		set := make(map[string]db.LoopEmoji, len(rows))
+		for _, e := range rows {
+			set[e.Name] = e
+		}
+		return set, nil
+	})
+	if err != nil {
+		log.Printf("[emoji] failed to load custom emoji: %v", err)
+	}
+	return set
+}
+
+// messageEntities resolves the shortcodes in a message posted to the loop
+func (h *Handler) messageEntities(ctx context.Context, projectID pgtype.UUID, content string) []emoji.Entity {
+	if !strings.Contains(content, ":") {
+		return nil
+	}
+	set := h.loopEmojiSet(ctx, projectID)
+	return emoji.Parse(content, func(name string) (emoji.Entity, bool) {
+		e, ok := set[name]
+		if !ok {
+			return emoji.Entity{}, false
+		}
+		return emoji.Entity{CustomID: utils.UUIDToStr(e.ID), URL: mediaURL(e.ImagePath)}, true
+	})
+}
+
+// attachEntities fills in Entities and GitHubEntities for a message list
+// from one loop
+func (h *Handler) attachEntities(ctx context.Context, projectID pgtype.UUID, msgs []MessageResponse) {
+	for i := range msgs {
+		if msgs[i].Deleted {
+			continue
+		}
+		msgs[i].Entities = h.messageEntities(ctx, projectID, msgs[i].Content)
+	}
+	h.attachGitHubEntities(ctx, msgs)
+	h.attachLongPosts(ctx, msgs)
+	h.attachThreadSummaries(ctx, msgs)
+}
+
+// canonicalReaction maps a reaction to the form it is stored under: the
+// Unicode emoji for a standard shortcode, :name: for a custom emoji of the
+// loop, and emoji characters as sent. ok is false for unknown shortcodes.
+func (h *Handler) canonicalReaction(ctx context.Context, projectID pgtype.UUID, reaction string) (string, bool) {
+	if !strings.HasPrefix(reaction, ":") {
+		return reaction, true
+	}
+	name := strings.Trim(reaction, ":")
+	if _, custom := h.loopEmojiSet(ctx, projectID)[name]; custom {
+		return reaction, true
+	}
+	if u, ok := emoji.Lookup(name); ok {
+		return u, true
+	}
+	return "", false
+}
+
+func loopEmojiToResponse(e db.LoopEmoji) LoopEmojiResponse {
+	return LoopEmojiResponse{
+		ID:        utils.UUIDToStr(e.ID),
+		Name:      e.Name,
+		URL:       mediaURL(e.ImagePath),
+		CreatedAt: utils.FormatTime(e.CreatedAt.Time),
+	}
+}
+
+// HandleGetLoopEmoji lists the loop's custom emoji
+func (h *Handler) HandleGetLoopEmoji(c *gin.Context) {
+	project, _, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	rows, err := h.Queries.ListLoopEmoji(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get emoji")
+		return
+	}
+	result := make([]LoopEmojiResponse, len(rows))
+	for i, e := range rows {
+		result[i] = loopEmojiToResponse(e)
+	}
+	c.JSON(200, gin.H{"emoji": result})
+}
+
+// HandleCreateLoopEmoji uploads a custom emoji (owner or moderator). The
+// multipart form carries the name and the image.
+func (h *Handler) HandleCreateLoopEmoji(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only the loop owner or a moderator can add emoji")
+		return
+	}
+	name := strings.ToLower(strings.Trim(c.PostForm("name"), ": "))
+	if !emoji.NamePattern.MatchString(name) {
+		problem.Respond(c, 400, "name must be 1-32 lowercase letters, digits, _, + or -")
+		return
+	}
+	if _, taken := emoji.Lookup(name); taken {
+		problem.Respond(c, 409, "that name is a standard emoji")
+		return
+	}
+	count, err := h.Queries.CountLoopEmoji(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to add emoji")
+		return
+	}
+	if count >= maxLoopEmoji {
+		problem.Respond(c, 400, "a loop can have at most 200 custom emoji")
+		return
+	}
+
+	file, _, err := c.Request.FormFile("image")
+	if err != nil {
+		problem.Respond(c, 400, "No file uploaded")
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxEmojiImage+1))
+	if err != nil {
+		problem.Respond(c, 500, "Failed to read file")
+		return
+	}
+	if len(data) > maxEmojiImage {
+		problem.Respond(c, 413, "emoji images can be at most 256KB")
+		return
+	}
+	ext, ok := emojiImageExt[http.DetectContentType(data)]
+	if !ok {
+		problem.Respond(c, 400, "emoji must be a PNG, GIF, WebP or JPEG image")
+		return
+	}
+
+	// A fresh file name per upload keeps the served image immutable
+	key := emojiStoragePath + utils.UUIDToStr(project.ID) + "/" + strconv.FormatInt(utils.GetMessageId(), 10) + ext
+	if err := h.Storage.Put(c, key, data); err != nil {
+		problem.Respond(c, 500, "failed to store emoji")
+		return
+	}
+	e, err := h.Queries.CreateLoopEmoji(c, db.CreateLoopEmojiParams{
+		ProjectID: project.ID,
+		Name:      name,
+		ImagePath: mediaPathPrefix + key,
+		CreatedBy: uid,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.Storage.Delete(context.Background(), key)
+		problem.Respond(c, 409, "the loop already has an emoji with that name")
+		return
+	}
+	if err != nil {
+		h.Storage.Delete(context.Background(), key)
+		problem.Respond(c, 500, "failed to add emoji")
+		return
+	}
+	lookupInvalidator.Invalidate("loop_emoji", utils.UUIDToStr(project.ID))
+	c.JSON(201, loopEmojiToResponse(e))
+}
+
+// HandleDeleteLoopEmoji removes a custom emoji (owner or moderator).
+// Reactions already using it keep their :name:.
+func (h *Handler) HandleDeleteLoopEmoji(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only the loop owner or a moderator can remove emoji")
+		return
+	}
+	path, err := h.Queries.DeleteLoopEmoji(c, db.DeleteLoopEmojiParams{
+		ProjectID: project.ID,
+		Name:      c.Param("emoji"),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 404, "emoji not found")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to remove emoji")
+		return
+	}
+	if err := h.Storage.Delete(c, strings.TrimPrefix(path, mediaPathPrefix)); err != nil {
+		log.Printf("[emoji] failed to delete %s: %v", path, err)
+	}
+	lookupInvalidator.Invalidate("loop_emoji", utils.UUIDToStr(project.ID))
+	c.JSON(200, gin.H{"message": "emoji removed"})
+}
+
+// HandleMediaEmoji serves a custom emoji image. Each upload has its own
+// file name, so it can be cached forever.
+func (h *Handler) HandleMediaEmoji(c *gin.Context) {
+	if !verifyMediaRequest(c) {
+		problem.Respond(c, 403, "invalid or expired link")
+		return
+	}
+	key := emojiStoragePath + c.Param("project_id") + "/" + c.Param("file")
+	data, err := h.Storage.Get(c, key)
+	if err != nil {
+		problem.Respond(c, 404, "not found")
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", `"`+c.Param("file")+`"`)
+	c.Data(200, http.DetectContentType(data), data)
+}
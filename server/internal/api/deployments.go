@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// ============================================================================
+// DEPLOYMENTS — GitHub Deployments/Environments for the linked repo
+// ============================================================================
+
+const maxDeploymentStatusFetches = 8
+
+type DeploymentResponse struct {
+	ID             int64  `json:"id"`
+	SHA            string `json:"sha"`
+	Ref            string `json:"ref"`
+	Environment    string `json:"environment"`
+	Description    string `json:"description,omitempty"`
+	Creator        string `json:"creator"`
+	CreatedAt      string `json:"created_at"`
+	State          string `json:"state"` // latest status; "unknown" if it has none
+	StatusAt       string `json:"status_at,omitempty"`
+	LogURL         string `json:"log_url,omitempty"`
+	EnvironmentURL string `json:"environment_url,omitempty"`
+}
+
+type EnvironmentDeployments struct {
+	Name        string               `json:"name"`
+	Deployments []DeploymentResponse `json:"deployments"`
+}
+
+// ============================================================================
+// GET /api/loops/:name/github/deployments
+// Recent deployments grouped by environment, each with its latest status
+// ============================================================================
+
+func (h *Handler) HandleGetDeployments(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	ctx := c.Request.Context()
+	project, err := h.Loops.ByName(ctx, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	token := h.loopReadToken(ctx, project, user)
+	if token == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
+		return
+	}
+
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
+		return
+	}
+
+	filter := url.Values{}
+	if env := c.Query("environment"); env != "" {
+		filter.Set("environment", env)
+	}
+	gh := github.Default
+	deployments, err := gh.ListDeployments(ctx, token, repoFullName, github.ListOptions{PerPage: "30"}, filter)
+	if err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
+		return
+	}
+
+	// One status call per deployment; bounded so a busy repo doesn't burst the rate limit
+	result := make([]DeploymentResponse, len(deployments))
+	sem := make(chan struct{}, maxDeploymentStatusFetches)
+	var wg sync.WaitGroup
+	for i, d := range deployments {
+		result[i] = DeploymentResponse{
+			ID:          d.ID,
+			SHA:         d.SHA,
+			Ref:         d.Ref,
+			Environment: d.Environment,
+			Description: d.Description,
+			Creator:     d.Creator.Login,
+			CreatedAt:   d.CreatedAt,
+			State:       "unknown",
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			statuses, err := gh.ListDeploymentStatuses(ctx, token, repoFullName, d.ID, github.ListOptions{PerPage: "1"})
+			if err != nil {
+				log.Printf("[deployments] status fetch for %d failed: %v", d.ID, err)
+				return
+			}
+			if len(statuses) > 0 {
+				s := statuses[0]
+				result[i].State = s.State
+				result[i].StatusAt = s.CreatedAt
+				result[i].LogURL = s.LogURL
+				result[i].EnvironmentURL = s.EnvironmentURL
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Group by environment, keeping the newest-first order within and across groups
+	var envs []EnvironmentDeployments
+	index := make(map[string]int)
+	for _, d := range result {
+		i, ok := index[d.Environment]
+		if !ok {
+			i = len(envs)
+			index[d.Environment] = i
+			envs = append(envs, EnvironmentDeployments{Name: d.Environment})
+		}
+		envs[i].Deployments = append(envs[i].Deployments, d)
+	}
+
+	c.JSON(200, gin.H{
+		"environments": envs,
+		"repo_name":    repoFullName,
+	})
+}
+
+// deployNotice maps a deployment status to the announcement wording; statuses
+// without one (queued, pending, inactive) aren't announced
+var deployNotice = map[string]string{
+	"in_progress": "🟡 Deploy to **%s** started",
+	"success":     "🟢 Deploy to **%s** succeeded",
+	"failure":     "🔴 Deploy to **%s** failed",
+	"error":       "🔴 Deploy to **%s** errored",
+}
+
+// handleDeploymentStatusWebhook announces production deploys in each linked
+// loop's deploy channel
+func (h *Handler) handleDeploymentStatusWebhook(ctx context.Context, body []byte) error {
+	var ev github.DeploymentStatusEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	format, ok := deployNotice[ev.DeploymentStatus.State]
+	if !ok {
+		return nil
+	}
+
+	projects, err := h.Queries.GetProjectsByGithubRepoID(ctx, ev.Repository.ID)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		settings, err := h.Queries.GetLoopGithubSettings(ctx, p.ID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !settings.DeployChannelID.Valid || !strings.EqualFold(ev.Deployment.Environment, settings.ProductionEnvironment) {
+			continue
+		}
+		if err := h.postDeployNotice(ctx, p, settings, ev, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *Handler) postDeployNotice(ctx context.Context, project db.Project, settings db.LoopGithubSetting, ev github.DeploymentStatusEvent, format string) error {
+	sender, err := h.Queries.GetUserByGithubID(ctx, ev.Deployment.Creator.ID)
+	if err != nil {
+		if sender, err = h.getUserByID(ctx, project.OwnerID); err != nil {
+			return err
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, format, ev.Deployment.Environment)
+	sha := ev.Deployment.SHA
+	if len(sha) > 7 {
+		sha = sha[:7]
+	}
+	fmt.Fprintf(&sb, " · `%s`", sha)
+	if ev.Deployment.Ref != "" && ev.Deployment.Ref != ev.Deployment.SHA {
+		fmt.Fprintf(&sb, " (%s)", ev.Deployment.Ref)
+	}
+	if ev.Deployment.Creator.Login != "" {
+		fmt.Fprintf(&sb, " by @%s", ev.Deployment.Creator.Login)
+	}
+	if ev.DeploymentStatus.Description != "" {
+		sb.WriteString("\n" + ev.DeploymentStatus.Description)
+	}
+	if ev.DeploymentStatus.LogURL != "" {
+		sb.WriteString("\n[Logs](" + ev.DeploymentStatus.LogURL + ")")
+	}
+	if ev.DeploymentStatus.State == "success" && ev.DeploymentStatus.EnvironmentURL != "" {
+		sb.WriteString(" · [Open](" + ev.DeploymentStatus.EnvironmentURL + ")")
+	}
+
+	_, err = h.postChannelMessage(ctx, project.ID, settings.DeployChannelID, sender, sb.String())
+	return err
+}
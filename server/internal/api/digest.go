@@ -0,0 +1,249 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// TOP MESSAGES DIGEST
+// Once a week (from Monday 00:00 UTC) loops with a digest channel get a post
+// of the past week's most-reacted and most-replied messages. The same lists
+// are available on demand for any period.
+// ============================================================================
+
+const (
+	jobTopMessagesDigest = "top_messages_digest"
+	digestCheckEvery     = time.Hour
+	digestPeriod         = 7 * 24 * time.Hour
+	digestTopN           = 5
+	defaultTopDays       = 7
+	maxTopDays           = 90
+)
+
+type TopMessage struct {
+	ID             string `json:"id"`
+	ChannelID      string `json:"channel_id"`
+	ChannelName    string `json:"channel_name"`
+	Preview        string `json:"preview"` // first line of the message
+	SenderUsername string `json:"sender_username"`
+	CreatedAt      string `json:"created_at"`
+	// Reactions for most_reacted, thread replies for most_replied
+	Count int32 `json:"count"`
+}
+
+type TopMessagesResponse struct {
+	Since       string       `json:"since"`
+	MostReacted []TopMessage `json:"most_reacted"`
+	MostReplied []TopMessage `json:"most_replied"`
+}
+
+type topMessagesDigestPayload struct {
+	ProjectID string `json:"project_id"`
+	ChannelID string `json:"channel_id"`
+}
+
+// digestWeekStart is the Monday 00:00 UTC starting t's week
+func digestWeekStart(t time.Time) time.Time {
+	day := t.UTC().Truncate(24 * time.Hour)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// topMessages collects the loop's top messages since a time from the
+// channels visible reports true for
+func (h *Handler) topMessages(ctx context.Context, projectID pgtype.UUID, since time.Time, visible func(pgtype.UUID) bool) (TopMessagesResponse, error) {
+	resp := TopMessagesResponse{
+		Since:       utils.FormatTime(since),
+		MostReacted: []TopMessage{},
+		MostReplied: []TopMessage{},
+	}
+	from := pgtype.Timestamptz{Time: since, Valid: true}
+	// Fetch extra so hidden channels don't leave the lists short
+	const fetch = digestTopN * 4
+
+	reacted, err := h.Queries.GetTopReactedMessages(ctx, db.GetTopReactedMessagesParams{
+		ProjectID: projectID,
+		Since:     from,
+		RowLimit:  fetch,
+	})
+	if err != nil {
+		return resp, err
+	}
+	for _, m := range reacted {
+		if len(resp.MostReacted) < digestTopN && visible(m.ChannelID) {
+			resp.MostReacted = append(resp.MostReacted, topMessage(m.ID, m.ChannelID, m.ChannelName, m.Content, m.SenderUsername, m.CreatedAt, m.Reactions))
+		}
+	}
+	replied, err := h.Queries.GetTopRepliedMessages(ctx, db.GetTopRepliedMessagesParams{
+		ProjectID: projectID,
+		Since:     from,
+		RowLimit:  fetch,
+	})
+	if err != nil {
+		return resp, err
+	}
+	for _, m := range replied {
+		if len(resp.MostReplied) < digestTopN && visible(m.ChannelID) {
+			resp.MostReplied = append(resp.MostReplied, topMessage(m.ID, m.ChannelID, m.ChannelName, m.Content, m.SenderUsername, m.CreatedAt, m.ReplyCount))
+		}
+	}
+	return resp, nil
+}
+
+func topMessage(id int64, channelID pgtype.UUID, channelName, content, sender string, createdAt pgtype.Timestamptz, count int32) TopMessage {
+	return TopMessage{
+		ID:             strconv.FormatInt(id, 10),
+		ChannelID:      utils.UUIDToStr(channelID),
+		ChannelName:    channelName,
+		Preview:        feedTitle(content),
+		SenderUsername: sender,
+		CreatedAt:      utils.FormatTime(createdAt.Time),
+		Count:          count,
+	}
+}
+
+// HandleGetTopMessages returns the loop's most-reacted and most-replied
+// messages of the last ?days (default 7, at most 90) from the channels the
+// caller can read
+func (h *Handler) HandleGetTopMessages(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	days := defaultTopDays
+	if d := c.Query("days"); d != "" {
+		v, err := strconv.Atoi(d)
+		if err != nil || v < 1 || v > maxTopDays {
+			problem.Respond(c, 400, fmt.Sprintf("days must be between 1 and %d", maxTopDays))
+			return
+		}
+		days = v
+	}
+
+	role := h.Members.Role(c, uid, project.ID)
+	resp, err := h.topMessages(c, project.ID, time.Now().AddDate(0, 0, -days), func(channelID pgtype.UUID) bool {
+		return h.Members.CanUseChannel(c, role, project.ID, channelID)
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get top messages")
+		return
+	}
+	c.JSON(200, resp)
+}
+
+// StartTopMessagesDigest queues each loop's weekly digest until ctx is
+// cancelled. Loops are claimed in the database, so instances running it
+// side by side never post the same week twice.
+func (h *Handler) StartTopMessagesDigest(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(digestCheckEvery)
+		defer ticker.Stop()
+		for {
+			h.queueDueDigests(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (h *Handler) queueDueDigests(ctx context.Context) {
+	weekStart := digestWeekStart(time.Now())
+	due, err := h.Queries.ClaimDueLoopDigests(ctx, pgtype.Timestamptz{Time: weekStart, Valid: true})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[digest] failed to claim due digests: %v", err)
+		}
+		return
+	}
+	for _, d := range due {
+		if _, err := h.Jobs.Enqueue(ctx, jobTopMessagesDigest, topMessagesDigestPayload{
+			ProjectID: utils.UUIDToStr(d.ProjectID),
+			ChannelID: utils.UUIDToStr(d.DigestChannelID),
+		}, time.Now()); err != nil {
+			log.Printf("[digest] failed to queue digest for loop %s: %v", utils.UUIDToStr(d.ProjectID), err)
+		}
+	}
+}
+
+// runTopMessagesDigest posts the past week's top messages to the loop's
+// digest channel, as the loop owner. Quiet weeks post nothing.
+func (h *Handler) runTopMessagesDigest(ctx context.Context, raw json.RawMessage) error {
+	var p topMessagesDigestPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	projectID, err := utils.StrToUUID(p.ProjectID)
+	if err != nil {
+		return fmt.Errorf("bad project id: %w", err)
+	}
+	channelID, err := utils.StrToUUID(p.ChannelID)
+	if err != nil {
+		return fmt.Errorf("bad channel id: %w", err)
+	}
+
+	project, err := h.Loops.ByID(ctx, projectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // loop deleted
+	}
+	if err != nil {
+		return err
+	}
+	owner, err := h.getUserByID(ctx, project.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	// A digest posted where guests read only covers what guests can read
+	visible := func(pgtype.UUID) bool { return true }
+	if guests := h.Members.GuestChannels(ctx, projectID); guests[channelID] {
+		visible = func(id pgtype.UUID) bool { return guests[id] }
+	}
+	top, err := h.topMessages(ctx, projectID, time.Now().Add(-digestPeriod), visible)
+	if err != nil {
+		return err
+	}
+	if len(top.MostReacted) == 0 && len(top.MostReplied) == 0 {
+		return nil
+	}
+
+	_, err = h.postChannelMessage(ctx, projectID, channelID, owner, formatTopMessagesDigest(project.Name, top))
+	return err
+}
+
+func formatTopMessagesDigest(loopName string, top TopMessagesResponse) string {
+	page := strings.TrimRight(os.Getenv("FRONTEND_URL"), "/") + "/loops/" + url.PathEscape(loopName)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🏆 **This week's top messages in %s**\n", loopName)
+	section := func(title, unit string, msgs []TopMessage) {
+		if len(msgs) == 0 {
+			return
+		}
+		fmt.Fprintf(&sb, "\n**%s**\n", title)
+		for i, m := range msgs {
+			fmt.Fprintf(&sb, "%d. [%s](%s?channel=%s#message-%s) — @%s in #%s (%d %s)\n",
+				i+1, m.Preview, page, m.ChannelID, m.ID, m.SenderUsername, m.ChannelName, m.Count, unit)
+		}
+	}
+	section("Most reactions", "reactions", top.MostReacted)
+	section("Most replies", "replies", top.MostReplied)
+	return strings.TrimRight(sb.String(), "\n")
+}
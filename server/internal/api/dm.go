@@ -0,0 +1,417 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// DIRECT MESSAGES
+// 1:1 conversations are keyed by the sorted user pair so each pair has one.
+// Every user also has a bot conversation where Wireloop (sender NULL) posts
+// prompts such as standups; replies there are routed to handleBotReply.
+// ============================================================================
+
+const (
+	botUsername       = "wireloop"
+	defaultDMPageSize = 50
+	maxDMPageSize     = 100
+	botDMKeyPrefix    = "bot:"
+)
+
+type DMConversationResponse struct {
+	ID            string `json:"id"`
+	IsBot         bool   `json:"is_bot"`
+	Status        string `json:"status"` // "active" or "pending" (a request the other side hasn't accepted)
+	IsGroup       bool   `json:"is_group"`
+	Name          string `json:"name,omitempty"`
+	MemberCount   int64  `json:"member_count,omitempty"`
+	OtherUserID   string `json:"other_user_id,omitempty"`
+	OtherUsername string `json:"other_username"`
+	OtherAvatar   string `json:"other_avatar,omitempty"`
+	UnreadCount   int64  `json:"unread_count"`
+	LastMessageAt string `json:"last_message_at"`
+}
+
+type DMMessageResponse struct {
+	ID             string `json:"id"`
+	ConversationID string `json:"conversation_id"`
+	Content        string `json:"content"`
+	SenderID       string `json:"sender_id,omitempty"`
+	SenderUsername string `json:"sender_username"`
+	SenderAvatar   string `json:"sender_avatar,omitempty"`
+	IsBot          bool   `json:"is_bot"`
+	CreatedAt      string `json:"created_at"`
+	CreatedAtMs    int64  `json:"created_at_ms"`
+}
+
+type OpenDMRequest struct {
+	UserID string `json:"user_id" binding:"required,uuid"`
+}
+
+type SendDMRequest struct {
+	Content string `json:"content" binding:"required,notblank,max=4000"`
+}
+
+// dmKey is the canonical key for a 1:1 conversation
+func dmKey(a, b pgtype.UUID) string {
+	sa, sb := utils.UUIDToStr(a), utils.UUIDToStr(b)
+	if sa > sb {
+		sa, sb = sb, sa
+	}
+	return sa + ":" + sb
+}
+
+// openDM gets or creates the conversation for key and ensures all participants are in it.
+// A valid requestedBy creates it as a pending message request; existing conversations keep their status.
+func (h *Handler) openDM(ctx context.Context, key string, isBot bool, requestedBy pgtype.UUID, participants ...pgtype.UUID) (db.DmConversation, error) {
+	status := dmStatusActive
+	if requestedBy.Valid {
+		status = dmStatusPending
+	}
+	conv, err := h.Queries.CreateDMConversation(ctx, db.CreateDMConversationParams{
+		DmKey:       pgtype.Text{String: key, Valid: true},
+		IsBot:       isBot,
+		Status:      status,
+		RequestedBy: requestedBy,
+	})
+	if err != nil {
+		return conv, err
+	}
+	for _, uid := range participants {
+		if err := h.Queries.AddDMParticipant(ctx, db.AddDMParticipantParams{
+			ConversationID: conv.ID,
+			UserID:         uid,
+		}); err != nil {
+			return conv, err
+		}
+	}
+	return conv, nil
+}
+
+// dmAccess loads the conversation from :id and checks the caller is in it
+func (h *Handler) dmAccess(c *gin.Context) (db.DmConversation, pgtype.UUID, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.DmConversation{}, uid, false
+	}
+	convID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid conversation id")
+		return db.DmConversation{}, uid, false
+	}
+	if _, err := h.Queries.IsDMParticipant(c, db.IsDMParticipantParams{ConversationID: convID, UserID: uid}); err != nil {
+		problem.Respond(c, 404, "conversation not found")
+		return db.DmConversation{}, uid, false
+	}
+	conv, err := h.Queries.GetDMConversationByID(c, convID)
+	if err != nil {
+		problem.Respond(c, 404, "conversation not found")
+		return db.DmConversation{}, uid, false
+	}
+	return conv, uid, true
+}
+
+// storeAndDeliverDM persists a DM and pushes it to every participant's sockets.
+// sender is NULL for bot messages.
+func (h *Handler) storeAndDeliverDM(ctx context.Context, conv db.DmConversation, sender pgtype.UUID, content string) (DMMessageResponse, error) {
+	msgID := utils.GetMessageId()
+	if err := h.Queries.AddDMMessage(ctx, db.AddDMMessageParams{
+		ID:             msgID,
+		ConversationID: conv.ID,
+		SenderID:       sender,
+		Content:        content,
+	}); err != nil {
+		return DMMessageResponse{}, err
+	}
+	if err := h.Queries.TouchDMConversation(ctx, conv.ID); err != nil {
+		log.Printf("[dm] failed to touch conversation: %v", err)
+	}
+
+	now := time.Now()
+	msg := DMMessageResponse{
+		ID:             strconv.FormatInt(msgID, 10),
+		ConversationID: utils.UUIDToStr(conv.ID),
+		Content:        content,
+		SenderUsername: botUsername,
+		IsBot:          !sender.Valid,
+		CreatedAt:      utils.FormatTime(now),
+		CreatedAtMs:    utils.EpochMillis(now),
+	}
+	if sender.Valid {
+		user, err := h.getUserByID(ctx, sender)
+		if err != nil {
+			return msg, err
+		}
+		msg.SenderID = utils.UUIDToStr(sender)
+		msg.SenderUsername = user.Username
+		msg.SenderAvatar = mediaURL(user.AvatarUrl.String)
+	}
+
+	participants, err := h.Queries.GetDMParticipantIDs(ctx, conv.ID)
+	if err != nil {
+		log.Printf("[dm] failed to load participants: %v", err)
+	}
+	if conv.IsGroup {
+		h.deliverGroupDM(conv, msg, participants)
+		return msg, nil
+	}
+	for _, p := range participants {
+		// Pending requests reach the recipient's requests inbox, not their conversation list
+		eventType := events.DMMessage
+		if conv.Status == dmStatusPending && p != conv.RequestedBy {
+			eventType = events.DMRequest
+		}
+		h.Events.PublishUser(utils.UUIDToStr(p), events.Of(eventType, msg))
+	}
+	return msg, nil
+}
+
+// dmBlocked reports whether a block exists between sender and any other participant
+func (h *Handler) dmBlocked(ctx context.Context, conv db.DmConversation, sender pgtype.UUID) bool {
+	participants, err := h.Queries.GetDMParticipantIDs(ctx, conv.ID)
+	if err != nil {
+		log.Printf("[dm] failed to load participants: %v", err)
+		return true
+	}
+	for _, p := range participants {
+		if p == sender {
+			continue
+		}
+		blocked, err := h.Queries.IsBlockedBetween(ctx, db.IsBlockedBetweenParams{BlockerID: sender, BlockedID: p})
+		if err != nil || blocked {
+			return true
+		}
+	}
+	return false
+}
+
+// sendBotDM posts a Wireloop bot message into the user's bot conversation
+func (h *Handler) sendBotDM(ctx context.Context, userID pgtype.UUID, content string) error {
+	conv, err := h.openDM(ctx, botDMKeyPrefix+utils.UUIDToStr(userID), true, pgtype.UUID{}, userID)
+	if err != nil {
+		return err
+	}
+	_, err = h.storeAndDeliverDM(ctx, conv, pgtype.UUID{}, content)
+	return err
+}
+
+// HandleGetDMs lists the caller's conversations, most recent first
+func (h *Handler) HandleGetDMs(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	rows, err := h.Queries.GetDMConversations(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get conversations")
+		return
+	}
+
+	result := make([]DMConversationResponse, 0, len(rows))
+	for _, r := range rows {
+		conv := DMConversationResponse{
+			ID:            utils.UUIDToStr(r.ID),
+			IsBot:         r.IsBot,
+			Status:        r.Status,
+			IsGroup:       r.IsGroup,
+			Name:          r.Name.String,
+			MemberCount:   r.MemberCount,
+			OtherUsername: r.OtherUsername.String,
+			OtherAvatar:   mediaURL(r.OtherAvatar.String),
+			UnreadCount:   r.UnreadCount,
+			LastMessageAt: utils.FormatTime(r.LastMessageAt.Time),
+		}
+		if r.IsBot {
+			conv.OtherUsername = botUsername
+		} else if r.OtherUserID.Valid {
+			conv.OtherUserID = utils.UUIDToStr(r.OtherUserID)
+		}
+		result = append(result, conv)
+	}
+
+	c.JSON(200, result)
+}
+
+// HandleOpenDM gets or creates a 1:1 conversation with another user
+func (h *Handler) HandleOpenDM(c *gin.Context) {
+	var req OpenDMRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	otherID, err := utils.StrToUUID(req.UserID)
+	if err != nil {
+		problem.Respond(c, 400, "invalid user id")
+		return
+	}
+	if otherID == uid {
+		problem.Respond(c, 400, "cannot message yourself")
+		return
+	}
+	other, err := h.getUserByID(c, otherID)
+	if err != nil {
+		problem.Respond(c, 404, "user not found")
+		return
+	}
+	if blocked, err := h.Queries.IsBlockedBetween(c, db.IsBlockedBetweenParams{BlockerID: uid, BlockedID: otherID}); err != nil || blocked {
+		problem.Respond(c, 403, "cannot message this user")
+		return
+	}
+
+	key := dmKey(uid, otherID)
+	var requestedBy pgtype.UUID
+	if _, err := h.Queries.GetDMConversationByKey(c, pgtype.Text{String: key, Valid: true}); err != nil {
+		// New conversation: the recipient's privacy setting decides if and how it opens
+		pending, allowed := h.dmOpenPolicy(c, uid, otherID)
+		if !allowed {
+			problem.Respond(c, 403, "this user isn't accepting direct messages")
+			return
+		}
+		if pending {
+			requestedBy = uid
+		}
+	}
+
+	conv, err := h.openDM(c, key, false, requestedBy, uid, otherID)
+	if err != nil {
+		log.Printf("[dm] open failed: %v", err)
+		problem.Respond(c, 500, "failed to open conversation")
+		return
+	}
+
+	c.JSON(200, DMConversationResponse{
+		ID:            utils.UUIDToStr(conv.ID),
+		Status:        conv.Status,
+		OtherUserID:   utils.UUIDToStr(other.ID),
+		OtherUsername: other.Username,
+		OtherAvatar:   mediaURL(other.AvatarUrl.String),
+		LastMessageAt: utils.FormatTime(conv.LastMessageAt.Time),
+	})
+}
+
+// HandleGetDMMessages returns a page of messages, newest first (?before=<id>&limit=)
+func (h *Handler) HandleGetDMMessages(c *gin.Context) {
+	conv, _, ok := h.dmAccess(c)
+	if !ok {
+		return
+	}
+
+	var before int64
+	if s := c.Query("before"); s != "" {
+		b, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			problem.Respond(c, 400, "invalid before id")
+			return
+		}
+		before = b
+	}
+	limit := defaultDMPageSize
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxDMPageSize)
+	}
+
+	rows, err := h.Queries.GetDMMessages(c, db.GetDMMessagesParams{
+		ConversationID: conv.ID,
+		Before:         before,
+		N:              int32(limit),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get messages")
+		return
+	}
+
+	convID := utils.UUIDToStr(conv.ID)
+	result := make([]DMMessageResponse, 0, len(rows))
+	for _, m := range rows {
+		msg := DMMessageResponse{
+			ID:             strconv.FormatInt(m.ID, 10),
+			ConversationID: convID,
+			Content:        m.Content,
+			SenderUsername: botUsername,
+			IsBot:          !m.SenderID.Valid,
+			CreatedAt:      utils.FormatTime(m.CreatedAt.Time),
+			CreatedAtMs:    utils.EpochMillis(m.CreatedAt.Time),
+		}
+		if m.SenderID.Valid {
+			msg.SenderID = utils.UUIDToStr(m.SenderID)
+			msg.SenderUsername = m.SenderUsername.String
+			msg.SenderAvatar = mediaURL(m.SenderAvatar.String)
+		}
+		result = append(result, msg)
+	}
+
+	c.JSON(200, result)
+}
+
+// HandleSendDM posts a message into a conversation
+func (h *Handler) HandleSendDM(c *gin.Context) {
+	var req SendDMRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	content := strings.TrimSpace(req.Content)
+
+	conv, uid, ok := h.dmAccess(c)
+	if !ok {
+		return
+	}
+	if !conv.IsBot && !conv.IsGroup && h.dmBlocked(c, conv, uid) {
+		problem.Respond(c, 403, "cannot message this user")
+		return
+	}
+	if conv.Status == dmStatusPending && conv.RequestedBy != uid {
+		problem.Respond(c, 403, "accept the message request first")
+		return
+	}
+
+	msg, err := h.storeAndDeliverDM(c, conv, uid, content)
+	if err != nil {
+		log.Printf("[dm] send failed: %v", err)
+		problem.Respond(c, 500, "failed to send message")
+		return
+	}
+
+	if conv.IsBot {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			h.handleBotReply(ctx, uid, content)
+		}()
+	}
+
+	c.JSON(200, msg)
+}
+
+// HandleMarkDMRead clears the caller's unread count for a conversation
+func (h *Handler) HandleMarkDMRead(c *gin.Context) {
+	conv, uid, ok := h.dmAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Queries.MarkDMRead(c, db.MarkDMReadParams{ConversationID: conv.ID, UserID: uid}); err != nil {
+		problem.Respond(c, 500, "failed to mark read")
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const maxGroupDMMembers = 10 // including the creator
+
+type CreateGroupDMRequest struct {
+	Name    string   `json:"name" binding:"max=80"`
+	UserIDs []string `json:"user_ids" binding:"required,dive,uuid"`
+}
+
+type AddGroupDMMembersRequest struct {
+	UserIDs []string `json:"user_ids" binding:"required,dive,uuid"`
+}
+
+// dmRoom is the Hub room clients join while a group conversation is open
+func dmRoom(conversationID string) string {
+	return "dm:" + conversationID
+}
+
+// deliverGroupDM streams the message to everyone viewing the group and pings
+// each participant so unread badges update without the full payload
+func (h *Handler) deliverGroupDM(conv db.DmConversation, msg DMMessageResponse, participants []pgtype.UUID) {
+	convID := utils.UUIDToStr(conv.ID)
+	h.Events.Publish(dmRoom(convID), events.Of(events.DMMessage, msg))
+	for _, p := range participants {
+		h.Events.PublishUser(utils.UUIDToStr(p), events.ConversationActivity{
+			ConversationID: convID,
+			MessageID:      msg.ID,
+			SenderID:       msg.SenderID,
+		})
+	}
+}
+
+// groupInvitees validates user IDs for a group and loads them. Invitees must
+// share a loop with the inviter and not have blocked them (or been blocked).
+func (h *Handler) groupInvitees(c *gin.Context, inviter pgtype.UUID, ids []string) ([]db.User, bool) {
+	seen := make(map[string]bool)
+	users := make([]db.User, 0, len(ids))
+	for _, s := range ids {
+		id, err := utils.StrToUUID(s)
+		if err != nil {
+			problem.Respond(c, 400, "invalid user id")
+			return nil, false
+		}
+		if id == inviter || seen[s] {
+			continue
+		}
+		seen[s] = true
+
+		user, err := h.getUserByID(c, id)
+		if err != nil {
+			problem.Respond(c, 404, "user not found")
+			return nil, false
+		}
+		if blocked, err := h.Queries.IsBlockedBetween(c, db.IsBlockedBetweenParams{BlockerID: inviter, BlockedID: id}); err != nil || blocked {
+			problem.Respond(c, 403, "cannot add "+user.Username)
+			return nil, false
+		}
+		if pending, allowed := h.dmOpenPolicy(c, inviter, id); !allowed || pending {
+			problem.Respond(c, 403, user.Username+" isn't accepting group messages from you")
+			return nil, false
+		}
+		users = append(users, user)
+	}
+	return users, true
+}
+
+// HandleCreateGroupDM starts a group conversation with the caller and the given users
+func (h *Handler) HandleCreateGroupDM(c *gin.Context) {
+	var req CreateGroupDMRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	invitees, ok := h.groupInvitees(c, uid, req.UserIDs)
+	if !ok {
+		return
+	}
+	if len(invitees) < 2 {
+		problem.Respond(c, 400, "a group needs at least two other people")
+		return
+	}
+	if len(invitees)+1 > maxGroupDMMembers {
+		problem.Respond(c, 400, "groups are limited to 10 members")
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	conv, err := qtx.CreateGroupDM(c, db.CreateGroupDMParams{
+		Name:      pgtype.Text{String: name, Valid: name != ""},
+		CreatedBy: uid,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to create group")
+		return
+	}
+	members := make([]pgtype.UUID, 0, len(invitees)+1)
+	members = append(members, uid)
+	for _, u := range invitees {
+		members = append(members, u.ID)
+	}
+	for _, m := range members {
+		if err := qtx.AddDMParticipant(c, db.AddDMParticipantParams{ConversationID: conv.ID, UserID: m}); err != nil {
+			problem.Respond(c, 500, "failed to add members")
+			return
+		}
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to save changes")
+		return
+	}
+
+	resp := DMConversationResponse{
+		ID:            utils.UUIDToStr(conv.ID),
+		Status:        conv.Status,
+		IsGroup:       true,
+		Name:          name,
+		MemberCount:   int64(len(members)),
+		LastMessageAt: utils.FormatTime(conv.LastMessageAt.Time),
+	}
+	for _, m := range members {
+		h.Events.PublishUser(utils.UUIDToStr(m), events.Of(events.DMGroupCreated, resp))
+	}
+
+	c.JSON(201, resp)
+}
+
+// HandleAddGroupDMMembers adds people to a group conversation the caller is in
+func (h *Handler) HandleAddGroupDMMembers(c *gin.Context) {
+	var req AddGroupDMMembersRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	conv, uid, ok := h.dmAccess(c)
+	if !ok {
+		return
+	}
+	if !conv.IsGroup {
+		problem.Respond(c, 400, "not a group conversation")
+		return
+	}
+
+	invitees, ok := h.groupInvitees(c, uid, req.UserIDs)
+	if !ok {
+		return
+	}
+	count, err := h.Queries.CountDMParticipants(c, conv.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to count members")
+		return
+	}
+	if int(count)+len(invitees) > maxGroupDMMembers {
+		problem.Respond(c, 400, "groups are limited to 10 members")
+		return
+	}
+
+	actor, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	added := make([]string, 0, len(invitees))
+	for _, u := range invitees {
+		if err := h.Queries.AddDMParticipant(c, db.AddDMParticipantParams{ConversationID: conv.ID, UserID: u.ID}); err != nil {
+			problem.Respond(c, 500, "failed to add members")
+			return
+		}
+		added = append(added, u.Username)
+	}
+
+	if len(added) > 0 {
+		if _, err := h.storeAndDeliverDM(c, conv, pgtype.UUID{}, actor.Username+" added "+strings.Join(added, ", ")); err != nil {
+			log.Printf("[dm] failed to post member notice: %v", err)
+		}
+	}
+
+	c.JSON(200, gin.H{"added": added})
+}
+
+// HandleLeaveGroupDM removes the caller from a group; the last one out deletes it
+func (h *Handler) HandleLeaveGroupDM(c *gin.Context) {
+	conv, uid, ok := h.dmAccess(c)
+	if !ok {
+		return
+	}
+	if !conv.IsGroup {
+		problem.Respond(c, 400, "not a group conversation")
+		return
+	}
+
+	if err := h.Queries.RemoveDMParticipant(c, db.RemoveDMParticipantParams{ConversationID: conv.ID, UserID: uid}); err != nil {
+		problem.Respond(c, 500, "failed to leave group")
+		return
+	}
+
+	remaining, err := h.Queries.CountDMParticipants(c, conv.ID)
+	if err == nil && remaining == 0 {
+		if err := h.Queries.DeleteDMConversation(c, conv.ID); err != nil {
+			log.Printf("[dm] failed to delete empty group: %v", err)
+		}
+	} else if user, err := h.getUserByID(c, uid); err == nil {
+		if _, err := h.storeAndDeliverDM(c, conv, pgtype.UUID{}, user.Username+" left the group"); err != nil {
+			log.Printf("[dm] failed to post member notice: %v", err)
+		}
+	}
+
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleGetDMMembers lists a conversation's participants
+func (h *Handler) HandleGetDMMembers(c *gin.Context) {
+	conv, _, ok := h.dmAccess(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.Queries.GetDMParticipants(c, conv.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get members")
+		return
+	}
+
+	result := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, gin.H{
+			"user_id":    utils.UUIDToStr(r.UserID),
+			"username":   r.Username,
+			"avatar_url": mediaURL(r.AvatarUrl.String),
+			"joined_at":  utils.FormatTime(r.JoinedAt.Time),
+		})
+	}
+
+	c.JSON(200, result)
+}
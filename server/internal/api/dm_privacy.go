@@ -0,0 +1,180 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Who may start a new DM with a user
+const (
+	dmPrivacyAnyone      = "anyone"       // strangers land in the requests inbox
+	dmPrivacySharedLoops = "shared_loops" // only people sharing a loop
+	dmPrivacyNobody      = "nobody"
+)
+
+const (
+	dmStatusActive  = "active"
+	dmStatusPending = "pending"
+)
+
+type DMSettingsRequest struct {
+	Privacy string `json:"privacy" binding:"required,oneof=anyone shared_loops nobody"`
+}
+
+type DMRequestResponse struct {
+	ID                string `json:"id"`
+	RequesterID       string `json:"requester_id"`
+	RequesterUsername string `json:"requester_username"`
+	RequesterAvatar   string `json:"requester_avatar,omitempty"`
+	LastMessage       string `json:"last_message,omitempty"`
+	CreatedAt         string `json:"created_at"`
+	LastMessageAt     string `json:"last_message_at"`
+}
+
+// dmPrivacyFor returns the user's DM privacy setting, defaulting to anyone
+func (h *Handler) dmPrivacyFor(ctx context.Context, userID pgtype.UUID) (string, error) {
+	privacy, err := h.Queries.GetDMPrivacy(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return dmPrivacyAnyone, nil
+	}
+	return privacy, err
+}
+
+// dmOpenPolicy decides whether sender may start a conversation with recipient,
+// and whether it has to go through the recipient's requests inbox first
+func (h *Handler) dmOpenPolicy(ctx context.Context, sender, recipient pgtype.UUID) (pending, allowed bool) {
+	privacy, err := h.dmPrivacyFor(ctx, recipient)
+	if err != nil {
+		log.Printf("[dm] failed to load privacy setting: %v", err)
+		return false, false
+	}
+	if privacy == dmPrivacyNobody {
+		return false, false
+	}
+	shared, err := h.Queries.SharesLoop(ctx, db.SharesLoopParams{UserA: sender, UserB: recipient})
+	if err != nil {
+		log.Printf("[dm] shared loop check failed: %v", err)
+		return false, false
+	}
+	if shared {
+		return false, true
+	}
+	return true, privacy == dmPrivacyAnyone
+}
+
+// HandleGetDMSettings returns the caller's DM privacy setting
+func (h *Handler) HandleGetDMSettings(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	privacy, err := h.dmPrivacyFor(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get settings")
+		return
+	}
+
+	c.JSON(200, gin.H{"privacy": privacy})
+}
+
+// HandleUpdateDMSettings changes who may start new DMs with the caller
+func (h *Handler) HandleUpdateDMSettings(c *gin.Context) {
+	var req DMSettingsRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	if err := h.Queries.SetDMPrivacy(c, db.SetDMPrivacyParams{UserID: uid, DmPrivacy: req.Privacy}); err != nil {
+		problem.Respond(c, 500, "failed to update settings")
+		return
+	}
+
+	c.JSON(200, gin.H{"privacy": req.Privacy})
+}
+
+// HandleGetDMRequests lists pending conversations other people started with the caller
+func (h *Handler) HandleGetDMRequests(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	rows, err := h.Queries.GetDMRequests(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get requests")
+		return
+	}
+
+	result := make([]DMRequestResponse, 0, len(rows))
+	for _, r := range rows {
+		result = append(result, DMRequestResponse{
+			ID:                utils.UUIDToStr(r.ID),
+			RequesterID:       utils.UUIDToStr(r.RequesterID),
+			RequesterUsername: r.RequesterUsername,
+			RequesterAvatar:   mediaURL(r.RequesterAvatar.String),
+			LastMessage:       r.LastMessage.String,
+			CreatedAt:         utils.FormatTime(r.CreatedAt.Time),
+			LastMessageAt:     utils.FormatTime(r.LastMessageAt.Time),
+		})
+	}
+
+	c.JSON(200, result)
+}
+
+// HandleAcceptDMRequest moves a pending conversation into the caller's DM list
+func (h *Handler) HandleAcceptDMRequest(c *gin.Context) {
+	conv, uid, ok := h.dmAccess(c)
+	if !ok {
+		return
+	}
+
+	n, err := h.Queries.AcceptDMRequest(c, db.AcceptDMRequestParams{ID: conv.ID, RequestedBy: uid})
+	if err != nil {
+		problem.Respond(c, 500, "failed to accept request")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "no pending request")
+		return
+	}
+
+	h.Events.PublishUser(utils.UUIDToStr(conv.RequestedBy), events.ConversationRef{ConversationID: utils.UUIDToStr(conv.ID)})
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleDeclineDMRequest deletes a pending conversation and its messages
+func (h *Handler) HandleDeclineDMRequest(c *gin.Context) {
+	conv, uid, ok := h.dmAccess(c)
+	if !ok {
+		return
+	}
+
+	n, err := h.Queries.DeclineDMRequest(c, db.DeclineDMRequestParams{ID: conv.ID, RequestedBy: uid})
+	if err != nil {
+		problem.Respond(c, 500, "failed to decline request")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "no pending request")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true})
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/members"
+	"wireloop/internal/middleware"
+	"wireloop/internal/msgfilter"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// EMAIL REPLIES
+// Notification emails about a message carry a reply address,
+// reply+<token>@EMAIL_REPLY_DOMAIN, whose token names the recipient and the
+// message and is signed with EMAIL_REPLY_KEY so it can't be forged or
+// pointed elsewhere. The domain's inbound relay posts each received mail to
+// /api/inbound/email, signed with INBOUND_EMAIL_SECRET, and the reply (quoted
+// text stripped) lands in the message's thread as if the recipient had typed
+// it.
+// ============================================================================
+
+const (
+	emailReplyTTL = 30 * 24 * time.Hour
+	// user ID, message ID, expiry day, truncated MAC: 55 base32 characters,
+	// which keeps reply+<token> inside the 64-character local-part limit
+	emailTokenMACLen = 8
+	emailTokenLen    = 16 + 8 + 2 + emailTokenMACLen
+)
+
+var (
+	emailTokenEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+	emailReplyAddrRe   = regexp.MustCompile(`(?i)reply\+([a-z2-7]+)@`)
+	// "On Tue, 4 Mar 2025 at 10:02, Someone <a@b.c> wrote:", possibly wrapped
+	emailAttributionRe = regexp.MustCompile(`(?i)^on\b.*\bwrote:$`)
+)
+
+// emailReplyKey signs reply tokens. A forged token would post as anyone, so
+// without EMAIL_REPLY_KEY no reply addresses are handed out or accepted.
+var emailReplyKey = sync.OnceValue(func() []byte {
+	key := os.Getenv("EMAIL_REPLY_KEY")
+	if key == "" {
+		log.Println("[email] EMAIL_REPLY_KEY not set, email replies are disabled")
+	}
+	return []byte(key)
+})
+
+var inboundEmailSecret = sync.OnceValue(func() []byte {
+	secret := os.Getenv("INBOUND_EMAIL_SECRET")
+	if secret == "" {
+		log.Println("[email] INBOUND_EMAIL_SECRET not set, email replies are disabled")
+	}
+	return []byte(secret)
+})
+
+func emailTokenMAC(payload []byte) []byte {
+	mac := hmac.New(sha256.New, emailReplyKey())
+	mac.Write(payload)
+	return mac.Sum(nil)[:emailTokenMACLen]
+}
+
+// emailReplyAddress is the Reply-To for an email telling uid about msgID,
+// or "" when EMAIL_REPLY_DOMAIN or EMAIL_REPLY_KEY isn't set. Digests use
+// their newest message.
+func emailReplyAddress(uid pgtype.UUID, msgID int64) string {
+	domain := os.Getenv("EMAIL_REPLY_DOMAIN")
+	if domain == "" || len(emailReplyKey()) == 0 {
+		return ""
+	}
+	buf := make([]byte, 0, emailTokenLen)
+	buf = append(buf, uid.Bytes[:]...)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(msgID))
+	buf = binary.BigEndian.AppendUint16(buf, uint16(time.Now().Add(emailReplyTTL).Unix()/86400))
+	buf = append(buf, emailTokenMAC(buf)...)
+	return "reply+" + strings.ToLower(emailTokenEncoding.EncodeToString(buf)) + "@" + domain
+}
+
+// parseEmailReplyToken returns the user and message a reply token names
+func parseEmailReplyToken(token string) (pgtype.UUID, int64, bool) {
+	if len(emailReplyKey()) == 0 {
+		return pgtype.UUID{}, 0, false
+	}
+	buf, err := emailTokenEncoding.DecodeString(strings.ToUpper(token))
+	if err != nil || len(buf) != emailTokenLen {
+		return pgtype.UUID{}, 0, false
+	}
+	payload, sig := buf[:emailTokenLen-emailTokenMACLen], buf[emailTokenLen-emailTokenMACLen:]
+	if !hmac.Equal(sig, emailTokenMAC(payload)) {
+		return pgtype.UUID{}, 0, false
+	}
+	if expDay := int64(binary.BigEndian.Uint16(payload[24:26])); time.Now().Unix()/86400 > expDay {
+		return pgtype.UUID{}, 0, false
+	}
+	var uid pgtype.UUID
+	copy(uid.Bytes[:], payload[:16])
+	uid.Valid = true
+	return uid, int64(binary.BigEndian.Uint64(payload[16:24])), true
+}
+
+// stripQuotedReply keeps what the sender wrote above the quoted original,
+// the client's attribution line and their signature
+func stripQuotedReply(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	end := len(lines)
+	for i, line := range lines {
+		t := strings.TrimSpace(line)
+		next := ""
+		if i+1 < len(lines) {
+			next = strings.TrimSpace(lines[i+1])
+		}
+		if strings.HasPrefix(t, ">") ||
+			emailAttributionRe.MatchString(t) || emailAttributionRe.MatchString(t+" "+next) ||
+			line == "-- " || t == "--" ||
+			strings.HasPrefix(t, "-----Original Message-----") ||
+			strings.HasPrefix(t, "________________________________") ||
+			strings.HasPrefix(t, "Sent from my ") ||
+			(strings.HasPrefix(t, "From:") && i > 0 && strings.TrimSpace(lines[i-1]) == "") {
+			end = i
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines[:end], "\n"))
+}
+
+// InboundEmail is what the relay posts for each received mail
+type InboundEmail struct {
+	To        []string `json:"to"`
+	From      string   `json:"from"`
+	Subject   string   `json:"subject"`
+	Text      string   `json:"text"`
+	MessageID string   `json:"message_id"`
+}
+
+// InboundEmailAuth checks the relay's X-Wireloop-Signature over
+// "<timestamp>.<body>" and drops redelivered mails by their Message-ID
+func (h *Handler) InboundEmailAuth() gin.HandlerFunc {
+	return middleware.VerifyWebhook(middleware.WebhookSignature{
+		Provider:        "email",
+		Secret:          inboundEmailSecret,
+		Header:          "X-Wireloop-Signature",
+		Prefix:          "sha256=",
+		TimestampHeader: "X-Wireloop-Timestamp",
+		DeliveryHeader:  "X-Email-Message-Id",
+		MaxBody:         1 << 20,
+	})
+}
+
+// HandleInboundEmail posts an emailed reply into the thread of the message
+// its reply address names (POST /api/inbound/email)
+func (h *Handler) HandleInboundEmail(c *gin.Context) {
+	var mail InboundEmail
+	if err := json.Unmarshal(middleware.WebhookBody(c), &mail); err != nil {
+		problem.Respond(c, 400, "invalid email payload")
+		return
+	}
+	var uid pgtype.UUID
+	var msgID int64
+	found := false
+	for _, to := range mail.To {
+		for _, m := range emailReplyAddrRe.FindAllStringSubmatch(to, -1) {
+			if uid, msgID, found = parseEmailReplyToken(m[1]); found {
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		problem.Respond(c, 404, "no valid reply address")
+		return
+	}
+
+	content := stripQuotedReply(mail.Text)
+	if content == "" {
+		c.JSON(200, gin.H{"status": "ignored", "reason": "empty reply"})
+		return
+	}
+	if h.messageTooLong(content) != "" {
+		problem.RespondCode(c, 413, "message_too_long", "reply is too long", gin.H{"limit": h.MaxMessageChars})
+		return
+	}
+
+	original, err := h.Queries.GetMessageByID(c, msgID)
+	if err != nil || original.IsDeleted.Bool {
+		problem.Respond(c, 410, "the message was deleted")
+		return
+	}
+	role := h.Members.Role(c, uid, original.ProjectID)
+	if role == "" {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+	if role == members.Guest && !h.Members.GuestChannels(c, original.ProjectID)[original.ChannelID] {
+		problem.Respond(c, 403, "guests can only post in guest channels")
+		return
+	}
+	if reason := members.GuestPostAllowed(role, content); reason != "" {
+		problem.Respond(c, 403, reason)
+		return
+	}
+	if link, linked := h.channelGitHubLink(c, original.ChannelID); linked && link.ArchivedAt.Valid {
+		problem.Respond(c, 403, "this channel was archived when its GitHub "+link.Kind+" closed")
+		return
+	}
+	if h.loopArchived(c, original.ProjectID) {
+		problem.Respond(c, 403, loopArchivedReason)
+		return
+	}
+	user, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 404, "user not found")
+		return
+	}
+
+	// Replies to a reply join the same thread
+	parentID := pgtype.Int8{Int64: original.ID, Valid: true}
+	if original.ParentID.Valid {
+		parentID = original.ParentID
+	}
+	newID := utils.GetMessageId()
+	now := time.Now()
+	channelID := utils.UUIDToStr(original.ChannelID)
+	parentStr := strconv.FormatInt(parentID.Int64, 10)
+	msg := MessageResponse{
+		ID:             strconv.FormatInt(newID, 10),
+		Content:        content,
+		SenderID:       utils.UUIDToStr(uid),
+		SenderUsername: user.Username,
+		SenderAvatar:   mediaURL(user.AvatarUrl.String),
+		CreatedAt:      utils.FormatTime(now),
+		CreatedAtMs:    utils.EpochMillis(now),
+		ChannelID:      channelID,
+		ParentID:       &parentStr,
+		Entities:       h.messageEntities(c, original.ProjectID, content),
+	}
+
+	verdict := h.screenMessage(c, newID, original.ProjectID, original.ChannelID, uid, parentID, content)
+	switch verdict.Action {
+	case msgfilter.ActionBlock:
+		problem.RespondCode(c, 422, "message_blocked", blockedReason(verdict), gin.H{"rule": verdict.Rule})
+		return
+	case msgfilter.ActionHold:
+		c.JSON(202, gin.H{"status": "held", "id": msg.ID})
+		return
+	}
+	if err := h.Quotas.UseMessage(c, original.ProjectID); err != nil {
+		respondQuota(c, err)
+		return
+	}
+
+	if err := h.Queries.AddMessage(c, db.AddMessageParams{
+		ID:        newID,
+		SenderID:  uid,
+		Content:   content,
+		ProjectID: original.ProjectID,
+		ChannelID: original.ChannelID,
+		ParentID:  parentID,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to post reply")
+		return
+	}
+	h.touchMember(uid, original.ProjectID)
+	h.Events.PublishChannelFrom(channelID, events.Of(events.Message, msg), now)
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		h.Queries.IncrementReplyCount(ctx, parentID.Int64)
+		h.queueThreadSummary(ctx, parentID.Int64)
+		h.ProcessMentions(ctx, content, uid, user.Username, newID, original.ProjectID, original.ChannelID)
+	}()
+
+	log.Printf("[email] %s replied by email to message %d", user.Username, msgID)
+	c.JSON(200, gin.H{"status": "posted", "id": msg.ID})
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// CONDITIONAL GETS
+// The aggregate endpoints the SPA refetches constantly (init, loop full,
+// channel lists) tag their JSON with a weak ETag of its content. A client
+// sending the tag back in If-None-Match gets an empty 304 instead of the
+// payload. Signed media URLs are windowed, so the tag stays stable until
+// something the viewer can see actually changes.
+// ============================================================================
+
+// contentETag is a weak ETag over body's JSON encoding
+func contentETag(body any) string {
+	raw, err := json.Marshal(body)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(raw)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether If-None-Match already names etag. Weak
+// comparison, as RFC 9110 requires for If-None-Match.
+func etagMatches(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// respondJSONWithETag writes body with its ETag, or a bare 304 when the
+// client already has it. tagged is what the tag covers; pass body itself
+// unless the response carries volatile fields (timing) that shouldn't
+// defeat the cache.
+func respondJSONWithETag(c *gin.Context, tagged, body any) {
+	etag := contentETag(tagged)
+	// private: the payload depends on the viewer; no-cache: always revalidate
+	c.Header("Cache-Control", "private, no-cache")
+	if etag != "" {
+		c.Header("ETag", etag)
+	}
+	if etagMatches(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
@@ -0,0 +1,550 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/i18n"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOOP EVENTS — standups, release cutoffs, community calls
+// Recurring events are stored once and expanded into occurrences on read.
+// ============================================================================
+
+const (
+	jobEventReminder = "event_reminder"
+
+	defaultEventWindow = 30 * 24 * time.Hour
+	maxEventWindow     = 366 * 24 * time.Hour
+	maxOccurrences     = 500
+)
+
+type EventRequest struct {
+	Title           string `json:"title" binding:"required,notblank,max=200"`
+	Description     string `json:"description" binding:"max=5000"`
+	Kind            string `json:"kind" binding:"omitempty,oneof=standup release call other"`
+	StartsAt        string `json:"starts_at" binding:"required,rfc3339"`
+	EndsAt          string `json:"ends_at" binding:"required,rfc3339"`
+	Recurrence      string `json:"recurrence" binding:"omitempty,oneof=none daily weekly monthly"`
+	RecurrenceUntil string `json:"recurrence_until" binding:"omitempty,rfc3339"`
+	RemindMinutes   *int   `json:"remind_minutes" binding:"omitempty,min=0,max=10080"` // 0 disables reminders
+}
+
+type RSVPRequest struct {
+	Status string `json:"status" binding:"required,oneof=going maybe declined"`
+}
+
+type EventResponse struct {
+	ID              string  `json:"id"`
+	Title           string  `json:"title"`
+	Description     string  `json:"description,omitempty"`
+	Kind            string  `json:"kind"`
+	StartsAt        string  `json:"starts_at"`
+	EndsAt          string  `json:"ends_at"`
+	Recurrence      string  `json:"recurrence"`
+	RecurrenceUntil *string `json:"recurrence_until,omitempty"`
+	RemindMinutes   int     `json:"remind_minutes"`
+	CreatedBy       string  `json:"created_by,omitempty"`
+}
+
+// EventOccurrence is one concrete instance of a (possibly recurring) event
+type EventOccurrence struct {
+	EventResponse
+	OccurrenceStart string `json:"occurrence_start"`
+	OccurrenceEnd   string `json:"occurrence_end"`
+}
+
+type eventReminderPayload struct {
+	EventID    string `json:"event_id"`
+	Version    int64  `json:"version"`    // event updated_at; stale reminders are skipped
+	Occurrence int64  `json:"occurrence"` // unix seconds of the occurrence start
+}
+
+func eventToResponse(ev db.Event) EventResponse {
+	resp := EventResponse{
+		ID:            utils.UUIDToStr(ev.ID),
+		Title:         ev.Title,
+		Description:   ev.Description.String,
+		Kind:          ev.Kind,
+		StartsAt:      utils.FormatTime(ev.StartsAt.Time),
+		EndsAt:        utils.FormatTime(ev.EndsAt.Time),
+		Recurrence:    ev.Recurrence,
+		RemindMinutes: int(ev.RemindMinutes),
+	}
+	if ev.RecurrenceUntil.Valid {
+		s := utils.FormatTime(ev.RecurrenceUntil.Time)
+		resp.RecurrenceUntil = &s
+	}
+	if ev.CreatedBy.Valid {
+		resp.CreatedBy = utils.UUIDToStr(ev.CreatedBy)
+	}
+	return resp
+}
+
+// nthOccurrence returns the start of the n-th repetition of a series
+func nthOccurrence(start time.Time, recurrence string, n int) time.Time {
+	switch recurrence {
+	case "daily":
+		return start.AddDate(0, 0, n)
+	case "weekly":
+		return start.AddDate(0, 0, 7*n)
+	case "monthly":
+		return start.AddDate(0, n, 0)
+	}
+	return start
+}
+
+// eventOccurrences lists occurrence start times overlapping [from, to)
+func eventOccurrences(ev db.Event, from, to time.Time, limit int) []time.Time {
+	start := ev.StartsAt.Time
+	duration := ev.EndsAt.Time.Sub(start)
+	var out []time.Time
+	for n := 0; len(out) < limit; n++ {
+		occ := nthOccurrence(start, ev.Recurrence, n)
+		if !occ.Before(to) || (ev.RecurrenceUntil.Valid && occ.After(ev.RecurrenceUntil.Time)) {
+			break
+		}
+		if !occ.Add(duration).Before(from) {
+			out = append(out, occ)
+		}
+		if ev.Recurrence == "none" || n > 100000 {
+			break
+		}
+	}
+	return out
+}
+
+// nextOccurrenceAfter finds the first occurrence starting strictly after t
+func nextOccurrenceAfter(ev db.Event, t time.Time) (time.Time, bool) {
+	for n := 0; n <= 100000; n++ {
+		occ := nthOccurrence(ev.StartsAt.Time, ev.Recurrence, n)
+		if ev.RecurrenceUntil.Valid && occ.After(ev.RecurrenceUntil.Time) {
+			return time.Time{}, false
+		}
+		if occ.After(t) {
+			return occ, true
+		}
+		if ev.Recurrence == "none" {
+			break
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseEventRequest validates the body and fills defaults
+func parseEventRequest(req EventRequest) (db.UpdateEventParams, error) {
+	var p db.UpdateEventParams
+	p.Title = strings.TrimSpace(req.Title)
+	if req.Description != "" {
+		p.Description = pgtype.Text{String: req.Description, Valid: true}
+	}
+
+	p.Kind = req.Kind
+	if p.Kind == "" {
+		p.Kind = "other"
+	}
+	p.Recurrence = req.Recurrence
+	if p.Recurrence == "" {
+		p.Recurrence = "none"
+	}
+
+	startsAt, err := time.Parse(time.RFC3339, req.StartsAt)
+	if err != nil {
+		return p, errors.New("starts_at must be RFC3339")
+	}
+	endsAt, err := time.Parse(time.RFC3339, req.EndsAt)
+	if err != nil {
+		return p, errors.New("ends_at must be RFC3339")
+	}
+	if endsAt.Before(startsAt) {
+		return p, errors.New("ends_at must not be before starts_at")
+	}
+	p.StartsAt = pgtype.Timestamptz{Time: startsAt, Valid: true}
+	p.EndsAt = pgtype.Timestamptz{Time: endsAt, Valid: true}
+
+	if req.RecurrenceUntil != "" && p.Recurrence != "none" {
+		until, err := time.Parse(time.RFC3339, req.RecurrenceUntil)
+		if err != nil {
+			return p, errors.New("recurrence_until must be RFC3339")
+		}
+		p.RecurrenceUntil = pgtype.Timestamptz{Time: until, Valid: true}
+	}
+
+	p.RemindMinutes = 15
+	if req.RemindMinutes != nil {
+		p.RemindMinutes = int32(*req.RemindMinutes)
+	}
+	return p, nil
+}
+
+// eventAccess loads the event from :id and checks membership of its loop
+func (h *Handler) eventAccess(c *gin.Context) (db.Event, pgtype.UUID, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Event{}, uid, false
+	}
+	eventID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid event id")
+		return db.Event{}, uid, false
+	}
+	ev, err := h.Queries.GetEventByID(c, eventID)
+	if err != nil {
+		problem.Respond(c, 404, "event not found")
+		return db.Event{}, uid, false
+	}
+	if !h.Members.IsMember(c, uid, ev.ProjectID) {
+		problem.Respond(c, 403, "not a member")
+		return db.Event{}, uid, false
+	}
+	return ev, uid, true
+}
+
+// canManageEvent: the creator or the loop owner
+func (h *Handler) canManageEvent(ctx context.Context, ev db.Event, uid pgtype.UUID) bool {
+	if ev.CreatedBy == uid {
+		return true
+	}
+	project, err := h.Loops.ByID(ctx, ev.ProjectID)
+	return err == nil && project.OwnerID == uid
+}
+
+// HandleGetEvents lists event occurrences in ?from..?to (default: next 30 days)
+func (h *Handler) HandleGetEvents(c *gin.Context) {
+	project, _, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	from := time.Now()
+	if s := c.Query("from"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			problem.Respond(c, 400, "from must be RFC3339")
+			return
+		}
+		from = t
+	}
+	to := from.Add(defaultEventWindow)
+	if s := c.Query("to"); s != "" {
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			problem.Respond(c, 400, "to must be RFC3339")
+			return
+		}
+		to = t
+	}
+	if !to.After(from) || to.Sub(from) > maxEventWindow {
+		problem.Respond(c, 400, "range must be positive and at most 366 days")
+		return
+	}
+
+	occurrences, err := h.loopOccurrences(c, project.ID, from, to, maxOccurrences)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get events")
+		return
+	}
+	c.JSON(200, gin.H{"events": occurrences})
+}
+
+// loopOccurrences expands all events of a loop within [from, to), sorted by start
+func (h *Handler) loopOccurrences(ctx context.Context, projectID pgtype.UUID, from, to time.Time, limit int) ([]EventOccurrence, error) {
+	events, err := h.Queries.GetEventsInRange(ctx, db.GetEventsInRangeParams{
+		ProjectID:  projectID,
+		RangeEnd:   pgtype.Timestamptz{Time: to, Valid: true},
+		RangeStart: pgtype.Timestamptz{Time: from, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type occurrence struct {
+		start time.Time
+		EventOccurrence
+	}
+	var all []occurrence
+	for _, ev := range events {
+		base := eventToResponse(ev)
+		duration := ev.EndsAt.Time.Sub(ev.StartsAt.Time)
+		for _, occ := range eventOccurrences(ev, from, to, limit) {
+			all = append(all, occurrence{occ, EventOccurrence{
+				EventResponse:   base,
+				OccurrenceStart: utils.FormatTime(occ),
+				OccurrenceEnd:   utils.FormatTime(occ.Add(duration)),
+			}})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].start.Before(all[j].start) })
+	if len(all) > limit {
+		all = all[:limit]
+	}
+
+	result := make([]EventOccurrence, 0, len(all))
+	for _, o := range all {
+		result = append(result, o.EventOccurrence)
+	}
+	return result, nil
+}
+
+// HandleCreateEvent schedules an event in a loop (any member)
+func (h *Handler) HandleCreateEvent(c *gin.Context) {
+	var req EventRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	p, err := parseEventRequest(req)
+	if err != nil {
+		problem.Respond(c, 400, err.Error())
+		return
+	}
+
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	ev, err := h.Queries.CreateEvent(c, db.CreateEventParams{
+		ProjectID:       project.ID,
+		Title:           p.Title,
+		Description:     p.Description,
+		Kind:            p.Kind,
+		StartsAt:        p.StartsAt,
+		EndsAt:          p.EndsAt,
+		Recurrence:      p.Recurrence,
+		RecurrenceUntil: p.RecurrenceUntil,
+		RemindMinutes:   p.RemindMinutes,
+		CreatedBy:       uid,
+	})
+	if err != nil {
+		log.Printf("[events] CreateEvent failed: %v", err)
+		problem.Respond(c, 500, "failed to create event")
+		return
+	}
+
+	// The creator is going by default
+	if err := h.Queries.UpsertEventRSVP(c, db.UpsertEventRSVPParams{EventID: ev.ID, UserID: uid, Status: "going"}); err != nil {
+		log.Printf("[events] auto-RSVP failed: %v", err)
+	}
+	h.scheduleEventReminder(c, ev, time.Now())
+
+	resp := eventToResponse(ev)
+	h.recordActivity(c, project.ID, uid, "event_created", resp.ID,
+		fmt.Sprintf("scheduled %s for %s", ev.Title, ev.StartsAt.Time.UTC().Format("Mon Jan 2 15:04 MST")))
+	h.Events.Publish(loopRoom(utils.UUIDToStr(project.ID)), events.Of(events.EventCreated, resp))
+
+	c.JSON(201, resp)
+}
+
+// HandleGetEvent returns an event with its RSVPs
+func (h *Handler) HandleGetEvent(c *gin.Context) {
+	ev, _, ok := h.eventAccess(c)
+	if !ok {
+		return
+	}
+
+	rsvps, err := h.Queries.GetEventRSVPs(c, ev.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get RSVPs")
+		return
+	}
+
+	attendees := make([]gin.H, 0, len(rsvps))
+	for _, r := range rsvps {
+		attendees = append(attendees, gin.H{
+			"user_id":    utils.UUIDToStr(r.UserID),
+			"username":   r.Username,
+			"avatar_url": mediaURL(r.AvatarUrl.String),
+			"status":     r.Status,
+		})
+	}
+
+	c.JSON(200, gin.H{"event": eventToResponse(ev), "rsvps": attendees})
+}
+
+// HandleUpdateEvent replaces an event's details (creator or loop owner)
+func (h *Handler) HandleUpdateEvent(c *gin.Context) {
+	var req EventRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	p, err := parseEventRequest(req)
+	if err != nil {
+		problem.Respond(c, 400, err.Error())
+		return
+	}
+
+	ev, uid, ok := h.eventAccess(c)
+	if !ok {
+		return
+	}
+	if !h.canManageEvent(c, ev, uid) {
+		problem.Respond(c, 403, "only the creator or loop owner can edit this event")
+		return
+	}
+
+	p.ID = ev.ID
+	updated, err := h.Queries.UpdateEvent(c, p)
+	if err != nil {
+		problem.Respond(c, 500, "failed to update event")
+		return
+	}
+
+	// Reminders queued for the old version see a different updated_at and skip
+	h.scheduleEventReminder(c, updated, time.Now())
+
+	resp := eventToResponse(updated)
+	h.Events.Publish(loopRoom(utils.UUIDToStr(ev.ProjectID)), events.Of(events.EventUpdated, resp))
+	c.JSON(200, resp)
+}
+
+// HandleDeleteEvent removes an event (creator or loop owner)
+func (h *Handler) HandleDeleteEvent(c *gin.Context) {
+	ev, uid, ok := h.eventAccess(c)
+	if !ok {
+		return
+	}
+	if !h.canManageEvent(c, ev, uid) {
+		problem.Respond(c, 403, "only the creator or loop owner can delete this event")
+		return
+	}
+
+	if err := h.Queries.DeleteEvent(c, ev.ID); err != nil {
+		problem.Respond(c, 500, "failed to delete event")
+		return
+	}
+
+	h.Events.Publish(loopRoom(utils.UUIDToStr(ev.ProjectID)), events.EventRef{EventID: utils.UUIDToStr(ev.ID)})
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleRSVPEvent sets the caller's RSVP (going / maybe / declined)
+func (h *Handler) HandleRSVPEvent(c *gin.Context) {
+	var req RSVPRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ev, uid, ok := h.eventAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Queries.UpsertEventRSVP(c, db.UpsertEventRSVPParams{
+		EventID: ev.ID,
+		UserID:  uid,
+		Status:  req.Status,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to save RSVP")
+		return
+	}
+
+	h.Events.Publish(loopRoom(utils.UUIDToStr(ev.ProjectID)), events.RSVP{
+		EventID: utils.UUIDToStr(ev.ID),
+		UserID:  utils.UUIDToStr(uid),
+		Status:  req.Status,
+	})
+	c.JSON(200, gin.H{"success": true, "status": req.Status})
+}
+
+// ============================================================================
+// Reminders (job queue)
+// ============================================================================
+
+// scheduleEventReminder queues a reminder for the first occurrence starting after `after`
+func (h *Handler) scheduleEventReminder(ctx context.Context, ev db.Event, after time.Time) {
+	if h.Jobs == nil || ev.RemindMinutes <= 0 {
+		return
+	}
+	occ, ok := nextOccurrenceAfter(ev, after)
+	if !ok {
+		return
+	}
+
+	runAt := occ.Add(-time.Duration(ev.RemindMinutes) * time.Minute)
+	if _, err := h.Jobs.Enqueue(ctx, jobEventReminder, eventReminderPayload{
+		EventID:    utils.UUIDToStr(ev.ID),
+		Version:    ev.UpdatedAt.Time.UnixNano(),
+		Occurrence: occ.Unix(),
+	}, runAt); err != nil {
+		log.Printf("[events] failed to schedule reminder for %s: %v", utils.UUIDToStr(ev.ID), err)
+	}
+}
+
+// runEventReminder notifies everyone going/maybe, then queues the next occurrence
+func (h *Handler) runEventReminder(ctx context.Context, raw json.RawMessage) error {
+	var p eventReminderPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	eventID, err := utils.StrToUUID(p.EventID)
+	if err != nil {
+		return fmt.Errorf("bad event id: %w", err)
+	}
+
+	ev, err := h.Queries.GetEventByID(ctx, eventID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // deleted since scheduling
+	}
+	if err != nil {
+		return err
+	}
+	if ev.UpdatedAt.Time.UnixNano() != p.Version {
+		return nil // edited since scheduling; the edit queued its own reminder
+	}
+
+	occ := time.Unix(p.Occurrence, 0)
+	remindees, err := h.Queries.GetEventRemindees(ctx, ev.ID)
+	if err != nil {
+		return err
+	}
+
+	actorID := ev.CreatedBy
+	if !actorID.Valid {
+		project, err := h.Loops.ByID(ctx, ev.ProjectID)
+		if err != nil {
+			return err
+		}
+		actorID = project.OwnerID
+	}
+	actor, err := h.getUserByID(ctx, actorID)
+	if err != nil {
+		return err
+	}
+
+	minutes := int(time.Until(occ).Round(time.Minute).Minutes())
+	for _, userID := range remindees {
+		preview := i18n.T(h.recipientLocale(ctx, userID), "%s starts in %d min", ev.Title, minutes)
+		if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+			Type:          "event_reminder",
+			UserID:        userID,
+			ActorID:       actor.ID,
+			ActorUsername: actor.Username,
+			ProjectID:     ev.ProjectID,
+			Preview:       preview,
+			Extra:         map[string]any{"event_id": p.EventID, "occurrence_start": utils.FormatTime(occ)},
+		}); err != nil {
+			log.Printf("[events] failed to create reminder notification: %v", err)
+		}
+	}
+
+	if ev.Recurrence != "none" {
+		h.scheduleEventReminder(ctx, ev, occ)
+	}
+	return nil
+}
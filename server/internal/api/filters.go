@@ -0,0 +1,408 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/flags"
+	"wireloop/internal/msgfilter"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// MESSAGE FILTERS
+// Every channel message goes through messageFilter before it is stored or
+// broadcast. Blocked messages never leave the sender; held messages are
+// echoed back to the sender only and wait in the moderation queue; flagged
+// messages are posted normally and also queued.
+// ============================================================================
+
+const (
+	maxFilterWordLength = 50
+
+	filteredPending  = "pending"
+	filteredApproved = "approved"
+	filteredRejected = "rejected"
+)
+
+var (
+	messageFilter     = msgfilter.New()
+	filterConfigCache = cache.New[string, msgfilter.Config](lookupTTL, 2000)
+)
+
+func filterConfigFromRow(s db.LoopFilterSetting) msgfilter.Config {
+	return msgfilter.Config{
+		BannedWords:      s.BannedWords,
+		BannedWordAction: msgfilter.Action(s.BannedWordAction),
+		MaxLinks:         int(s.MaxLinks),
+		LinkSpamAction:   msgfilter.Action(s.LinkSpamAction),
+		RepeatLimit:      int(s.RepeatLimit),
+		RepeatAction:     msgfilter.Action(s.RepeatAction),
+	}
+}
+
+// filterConfig returns the loop's filter settings, or the defaults if it has none
+func (h *Handler) filterConfig(ctx context.Context, projectID pgtype.UUID) msgfilter.Config {
+	cfg, err := filterConfigCache.GetOrLoad(utils.UUIDToStr(projectID), func() (msgfilter.Config, error) {
+		s, err := h.Queries.GetLoopFilterSettings(ctx, projectID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return msgfilter.DefaultConfig(), nil
+		}
+		if err != nil {
+			return msgfilter.Config{}, err
+		}
+		return filterConfigFromRow(s), nil
+	})
+	if err != nil {
+		log.Printf("[filter] failed to load settings, using defaults: %v", err)
+		return msgfilter.DefaultConfig()
+	}
+	return cfg
+}
+
+// screenMessage runs the filter over a channel message about to be sent and
+// queues it for review when the verdict is hold or flag. A hold that can't be
+// queued is treated as a block so the message isn't silently lost.
+func (h *Handler) screenMessage(ctx context.Context, msgID int64, projectID, channelID, senderID pgtype.UUID, parentID pgtype.Int8, content string) msgfilter.Verdict {
+	if !h.Flags.Enabled(ctx, flags.MessageFilters, flags.Subject{UserID: senderID, LoopID: projectID}) {
+		return msgfilter.Verdict{}
+	}
+	v := messageFilter.Check(h.filterConfig(ctx, projectID), utils.UUIDToStr(senderID), content)
+	if v.Action != msgfilter.ActionHold && v.Action != msgfilter.ActionFlag {
+		return v
+	}
+
+	if err := h.Queries.CreateFilteredMessage(ctx, db.CreateFilteredMessageParams{
+		ID:        msgID,
+		ProjectID: projectID,
+		ChannelID: channelID,
+		SenderID:  senderID,
+		ParentID:  parentID,
+		Content:   content,
+		Rule:      string(v.Rule),
+		Action:    string(v.Action),
+		Detail:    pgtype.Text{String: v.Detail, Valid: v.Detail != ""},
+	}); err != nil {
+		log.Printf("[filter] failed to queue filtered message: %v", err)
+		if v.Action == msgfilter.ActionHold {
+			v.Action = msgfilter.ActionBlock
+		}
+	}
+	return v
+}
+
+// blockedReason is the message shown to a sender whose message was blocked
+func blockedReason(v msgfilter.Verdict) string {
+	switch v.Rule {
+	case msgfilter.RuleBannedWord:
+		return "your message contains a word that isn't allowed in this loop"
+	case msgfilter.RuleLinkSpam:
+		return "your message contains too many links"
+	case msgfilter.RuleRepeat:
+		return "you're sending the same message too often"
+	}
+	return "your message was blocked"
+}
+
+type FilterSettingsRequest struct {
+	BannedWords      []string `json:"banned_words" binding:"max=200"`
+	BannedWordAction string   `json:"banned_word_action" binding:"required,oneof=block hold flag off"`
+	MaxLinks         int      `json:"max_links" binding:"min=0,max=50"`
+	LinkSpamAction   string   `json:"link_spam_action" binding:"required,oneof=block hold flag off"`
+	RepeatLimit      int      `json:"repeat_limit" binding:"min=0,max=20"`
+	RepeatAction     string   `json:"repeat_action" binding:"required,oneof=block hold flag off"`
+}
+
+func filterSettingsResponse(cfg msgfilter.Config) FilterSettingsRequest {
+	words := cfg.BannedWords
+	if words == nil {
+		words = []string{}
+	}
+	return FilterSettingsRequest{
+		BannedWords:      words,
+		BannedWordAction: string(cfg.BannedWordAction),
+		MaxLinks:         cfg.MaxLinks,
+		LinkSpamAction:   string(cfg.LinkSpamAction),
+		RepeatLimit:      cfg.RepeatLimit,
+		RepeatAction:     string(cfg.RepeatAction),
+	}
+}
+
+// moderatedLoop loads the loop named in the URL and checks the caller can
+// moderate it. It writes the error response itself when ok is false.
+func (h *Handler) moderatedLoop(c *gin.Context) (uid pgtype.UUID, project db.Project, ok bool) {
+	uid, authed := utils.GetUserIdFromContext(c)
+	if !authed {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only loop owners and moderators can manage filters")
+		return
+	}
+	return uid, project, true
+}
+
+// HandleGetFilterSettings returns the loop's message filter configuration
+func (h *Handler) HandleGetFilterSettings(c *gin.Context) {
+	_, project, ok := h.moderatedLoop(c)
+	if !ok {
+		return
+	}
+	c.JSON(200, filterSettingsResponse(h.filterConfig(c, project.ID)))
+}
+
+// validateFilterSettings checks a filter configuration and returns its
+// normalized banned word list, or the problem to report
+func validateFilterSettings(req FilterSettingsRequest) ([]string, string) {
+	seen := make(map[string]bool, len(req.BannedWords))
+	words := make([]string, 0, len(req.BannedWords))
+	for _, w := range req.BannedWords {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || seen[w] {
+			continue
+		}
+		if len(w) > maxFilterWordLength {
+			return nil, "banned word too long"
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	return words, ""
+}
+
+// HandleUpdateFilterSettings replaces the loop's message filter configuration
+func (h *Handler) HandleUpdateFilterSettings(c *gin.Context) {
+	_, project, ok := h.moderatedLoop(c)
+	if !ok {
+		return
+	}
+
+	var req FilterSettingsRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	words, invalid := validateFilterSettings(req)
+	if invalid != "" {
+		problem.Respond(c, 400, invalid)
+		return
+	}
+
+	s, err := h.Queries.UpsertLoopFilterSettings(c, db.UpsertLoopFilterSettingsParams{
+		ProjectID:        project.ID,
+		BannedWords:      words,
+		BannedWordAction: req.BannedWordAction,
+		MaxLinks:         int32(req.MaxLinks),
+		LinkSpamAction:   req.LinkSpamAction,
+		RepeatLimit:      int32(req.RepeatLimit),
+		RepeatAction:     req.RepeatAction,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to save filter settings")
+		return
+	}
+	lookupInvalidator.Invalidate("filter_config", utils.UUIDToStr(project.ID))
+
+	c.JSON(200, filterSettingsResponse(filterConfigFromRow(s)))
+}
+
+type FilteredMessageResponse struct {
+	ID             string  `json:"id"`
+	ChannelID      string  `json:"channel_id"`
+	ParentID       *string `json:"parent_id,omitempty"`
+	SenderID       string  `json:"sender_id"`
+	SenderUsername string  `json:"sender_username"`
+	Content        string  `json:"content"`
+	Rule           string  `json:"rule"`
+	Action         string  `json:"action"`
+	Detail         string  `json:"detail,omitempty"`
+	Status         string  `json:"status"`
+	CreatedAt      string  `json:"created_at"`
+}
+
+// HandleGetFilteredMessages lists the loop's filter queue (?status=pending|approved|rejected)
+func (h *Handler) HandleGetFilteredMessages(c *gin.Context) {
+	_, project, ok := h.moderatedLoop(c)
+	if !ok {
+		return
+	}
+
+	status := c.DefaultQuery("status", filteredPending)
+	if status != filteredPending && status != filteredApproved && status != filteredRejected {
+		problem.Respond(c, 400, "invalid status")
+		return
+	}
+
+	rows, err := h.Queries.GetLoopFilteredMessages(c, db.GetLoopFilteredMessagesParams{
+		ProjectID: project.ID,
+		Status:    status,
+		Limit:     200,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get filtered messages")
+		return
+	}
+
+	result := make([]FilteredMessageResponse, len(rows))
+	for i, r := range rows {
+		var parentID *string
+		if r.ParentID.Valid {
+			s := strconv.FormatInt(r.ParentID.Int64, 10)
+			parentID = &s
+		}
+		result[i] = FilteredMessageResponse{
+			ID:             strconv.FormatInt(r.ID, 10),
+			ChannelID:      utils.UUIDToStr(r.ChannelID),
+			ParentID:       parentID,
+			SenderID:       utils.UUIDToStr(r.SenderID),
+			SenderUsername: r.SenderUsername,
+			Content:        r.Content,
+			Rule:           r.Rule,
+			Action:         r.Action,
+			Detail:         r.Detail.String,
+			Status:         r.Status,
+			CreatedAt:      utils.FormatTime(r.CreatedAt.Time),
+		}
+	}
+
+	c.JSON(200, result)
+}
+
+// reviewableFiltered loads the filtered message named in the URL and marks it
+// reviewed with status, if the caller moderates its loop. It writes the error
+// response itself when ok is false.
+func (h *Handler) reviewableFiltered(c *gin.Context, status string) (f db.FilteredMessage, ok bool) {
+	uid, authed := utils.GetUserIdFromContext(c)
+	if !authed {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid id")
+		return
+	}
+	f, err = h.Queries.GetFilteredMessageByID(c, id)
+	if err != nil {
+		problem.Respond(c, 404, "filtered message not found")
+		return
+	}
+	project, err := h.Loops.ByID(c, f.ProjectID)
+	if err != nil || !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only loop owners and moderators can review filtered messages")
+		return
+	}
+
+	n, err := h.Queries.ReviewFilteredMessage(c, db.ReviewFilteredMessageParams{ID: id, Status: status, ReviewedBy: uid})
+	if err != nil {
+		problem.Respond(c, 500, "failed to review message")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 409, "already reviewed")
+		return
+	}
+	return f, true
+}
+
+// HandleApproveFiltered releases a held message to its channel, or clears a flag
+func (h *Handler) HandleApproveFiltered(c *gin.Context) {
+	f, ok := h.reviewableFiltered(c, filteredApproved)
+	if !ok {
+		return
+	}
+	if f.Action != string(msgfilter.ActionHold) {
+		c.JSON(200, gin.H{"status": filteredApproved})
+		return
+	}
+
+	sender, err := h.getUserByID(c, f.SenderID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get sender")
+		return
+	}
+	// Numbered on release, so it takes its place after what was posted meanwhile
+	seq := h.nextSeq(c, f.ChannelID, f.ParentID)
+	if err := h.Queries.AddMessage(c, db.AddMessageParams{
+		ID:        f.ID,
+		ProjectID: f.ProjectID,
+		ChannelID: f.ChannelID,
+		SenderID:  f.SenderID,
+		Content:   f.Content,
+		ParentID:  f.ParentID,
+		Seq:       seq,
+	}); err != nil {
+		log.Printf("[filter] failed to release held message %d: %v", f.ID, err)
+		problem.Respond(c, 500, "failed to post message")
+		return
+	}
+	var parentID *string
+	if f.ParentID.Valid {
+		h.Queries.IncrementReplyCount(c, f.ParentID.Int64)
+		h.queueThreadSummary(c, f.ParentID.Int64)
+		s := strconv.FormatInt(f.ParentID.Int64, 10)
+		parentID = &s
+	}
+
+	roomID := utils.UUIDToStr(f.ChannelID)
+	if !f.ChannelID.Valid {
+		roomID = utils.UUIDToStr(f.ProjectID) // sent through the legacy loop-level endpoint
+	}
+	h.Events.PublishChannel(roomID, events.Of(events.Message, MessageResponse{
+		ID:             strconv.FormatInt(f.ID, 10),
+		Content:        f.Content,
+		SenderID:       utils.UUIDToStr(f.SenderID),
+		SenderUsername: sender.Username,
+		SenderAvatar:   mediaURL(sender.AvatarUrl.String),
+		CreatedAt:      utils.FormatTime(f.CreatedAt.Time),
+		CreatedAtMs:    utils.EpochMillis(f.CreatedAt.Time),
+		ChannelID:      roomID,
+		ParentID:       parentID,
+		Seq:            seq.Int64,
+	}))
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		h.ProcessMentions(ctx, f.Content, f.SenderID, sender.Username, f.ID, f.ProjectID, f.ChannelID)
+	}()
+
+	c.JSON(200, gin.H{"status": filteredApproved})
+}
+
+// HandleRejectFiltered discards a held message, or deletes a flagged one
+func (h *Handler) HandleRejectFiltered(c *gin.Context) {
+	f, ok := h.reviewableFiltered(c, filteredRejected)
+	if !ok {
+		return
+	}
+	if f.Action == string(msgfilter.ActionFlag) {
+		if err := h.deleteMessageAsModerator(c, f.ID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("[filter] failed to delete flagged message %d: %v", f.ID, err)
+			problem.Respond(c, 500, "failed to delete message")
+			return
+		}
+	}
+	h.Events.PublishUser(utils.UUIDToStr(f.SenderID), events.Rejection{
+		MessageID: strconv.FormatInt(f.ID, 10),
+		ChannelID: utils.UUIDToStr(f.ChannelID),
+		Reason:    "a moderator removed your message",
+	})
+	c.JSON(200, gin.H{"status": filteredRejected})
+}
@@ -0,0 +1,176 @@
+package api
+
+import (
+	"regexp"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/flags"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// FEATURE FLAGS
+// Admins override flag defaults through /api/admin/flags; clients read the
+// flags evaluated for them from /api/flags.
+// ============================================================================
+
+var flagKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+type FlagResponse struct {
+	Key            string   `json:"key"`
+	Description    string   `json:"description,omitempty"`
+	Default        bool     `json:"default"`
+	Overridden     bool     `json:"overridden"`
+	Enabled        bool     `json:"enabled"`
+	RolloutPercent int32    `json:"rollout_percent"`
+	AllowUsers     []string `json:"allow_users"`
+	AllowLoops     []string `json:"allow_loops"`
+	UpdatedAt      string   `json:"updated_at,omitempty"`
+}
+
+func uuidStrings(ids []pgtype.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = utils.UUIDToStr(id)
+	}
+	return out
+}
+
+// HandleAdminListFlags lists every flag declared in code or stored in the DB
+func (h *Handler) HandleAdminListFlags(c *gin.Context) {
+	rows, err := h.Queries.ListFeatureFlags(c)
+	if err != nil {
+		problem.Respond(c, 500, "failed to list flags")
+		return
+	}
+
+	byKey := make(map[string]*FlagResponse)
+	var result []*FlagResponse
+	for _, d := range flags.Definitions() {
+		f := &FlagResponse{
+			Key:         string(d.Key),
+			Description: d.Description,
+			Default:     d.Default,
+			Enabled:     d.Default,
+			AllowUsers:  []string{},
+			AllowLoops:  []string{},
+		}
+		if d.Default {
+			f.RolloutPercent = 100
+		}
+		byKey[f.Key] = f
+		result = append(result, f)
+	}
+	for _, r := range rows {
+		f, ok := byKey[r.Key]
+		if !ok {
+			f = &FlagResponse{Key: r.Key}
+			result = append(result, f)
+		}
+		if r.Description.Valid {
+			f.Description = r.Description.String
+		}
+		f.Overridden = true
+		f.Enabled = r.Enabled
+		f.RolloutPercent = r.RolloutPercent
+		f.AllowUsers = uuidStrings(r.AllowUsers)
+		f.AllowLoops = uuidStrings(r.AllowLoops)
+		f.UpdatedAt = utils.FormatTime(r.UpdatedAt.Time)
+	}
+
+	c.JSON(200, result)
+}
+
+type SetFlagRequest struct {
+	Description    string   `json:"description" binding:"max=500"`
+	Enabled        bool     `json:"enabled"`
+	RolloutPercent int32    `json:"rollout_percent" binding:"min=0,max=100"`
+	AllowUsers     []string `json:"allow_users" binding:"dive,uuid"`
+	AllowLoops     []string `json:"allow_loops" binding:"dive,uuid"`
+}
+
+// HandleAdminSetFlag creates or replaces a flag override
+func (h *Handler) HandleAdminSetFlag(c *gin.Context) {
+	key := c.Param("key")
+	if !flagKeyPattern.MatchString(key) {
+		problem.Respond(c, 400, "flag keys are lowercase letters, digits and underscores")
+		return
+	}
+
+	var req SetFlagRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+
+	parseIDs := func(raw []string) []pgtype.UUID {
+		ids := make([]pgtype.UUID, 0, len(raw))
+		for _, s := range raw {
+			id, _ := utils.StrToUUID(s)
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	users, loops := parseIDs(req.AllowUsers), parseIDs(req.AllowLoops)
+
+	f, err := h.Queries.UpsertFeatureFlag(c, db.UpsertFeatureFlagParams{
+		Key:            key,
+		Description:    pgtype.Text{String: req.Description, Valid: req.Description != ""},
+		Enabled:        req.Enabled,
+		RolloutPercent: req.RolloutPercent,
+		AllowUsers:     users,
+		AllowLoops:     loops,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to save flag")
+		return
+	}
+	h.Flags.Invalidate()
+
+	c.JSON(200, FlagResponse{
+		Key:            f.Key,
+		Description:    f.Description.String,
+		Overridden:     true,
+		Enabled:        f.Enabled,
+		RolloutPercent: f.RolloutPercent,
+		AllowUsers:     uuidStrings(f.AllowUsers),
+		AllowLoops:     uuidStrings(f.AllowLoops),
+		UpdatedAt:      utils.FormatTime(f.UpdatedAt.Time),
+	})
+}
+
+// HandleAdminDeleteFlag removes an override, returning the flag to its default
+func (h *Handler) HandleAdminDeleteFlag(c *gin.Context) {
+	n, err := h.Queries.DeleteFeatureFlag(c, c.Param("key"))
+	if err != nil {
+		problem.Respond(c, 500, "failed to delete flag")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "flag has no override")
+		return
+	}
+	h.Flags.Invalidate()
+	c.JSON(200, gin.H{"deleted": c.Param("key")})
+}
+
+// HandleGetFlags returns every flag evaluated for the caller, optionally
+// within a loop (?loop=<name>)
+func (h *Handler) HandleGetFlags(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	subj := flags.Subject{UserID: uid}
+	if name := c.Query("loop"); name != "" {
+		if project, err := h.Loops.ByName(c, name); err == nil {
+			subj.LoopID = project.ID
+		}
+	}
+
+	c.JSON(200, h.Flags.Evaluate(c, subj))
+}
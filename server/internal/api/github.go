@@ -3,6 +3,7 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -14,89 +15,37 @@ import (
 	"time"
 
 	utils "wireloop/internal"
+	"wireloop/internal/errreport"
+	"wireloop/internal/flags"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // ============================================================================
 // GitHub Context Types
 // ============================================================================
 
-type GitHubLabel struct {
-	Name  string `json:"name"`
-	Color string `json:"color"`
-}
-
-type GitHubUser struct {
-	Login     string `json:"login"`
-	AvatarURL string `json:"avatar_url"`
-}
-
-type GitHubIssue struct {
-	Number      int           `json:"number"`
-	Title       string        `json:"title"`
-	Body        string        `json:"body"`
-	State       string        `json:"state"`
-	Labels      []GitHubLabel `json:"labels"`
-	User        GitHubUser    `json:"user"`
-	Comments    int           `json:"comments"`
-	CreatedAt   string        `json:"created_at"`
-	UpdatedAt   string        `json:"updated_at"`
-	HTMLURL     string        `json:"html_url"`
-	PullRequest *struct {
-		URL string `json:"url"`
-	} `json:"pull_request,omitempty"`
-}
-
-type GitHubPR struct {
-	Number    int           `json:"number"`
-	Title     string        `json:"title"`
-	Body      string        `json:"body"`
-	State     string        `json:"state"`
-	Draft     bool          `json:"draft"`
-	Labels    []GitHubLabel `json:"labels"`
-	User      GitHubUser    `json:"user"`
-	Comments  int           `json:"comments"`
-	Additions int           `json:"additions"`
-	Deletions int           `json:"deletions"`
-	CreatedAt string        `json:"created_at"`
-	UpdatedAt string        `json:"updated_at"`
-	MergedAt  *string       `json:"merged_at"`
-	HTMLURL   string        `json:"html_url"`
-	Head      struct {
-		Ref string `json:"ref"`
-	} `json:"head"`
-	Base struct {
-		Ref string `json:"ref"`
-	} `json:"base"`
-}
-
-type GitHubComment struct {
-	Body      string     `json:"body"`
-	User      GitHubUser `json:"user"`
-	CreatedAt string     `json:"created_at"`
-}
-
-type GitHubReview struct {
-	Body      string     `json:"body"`
-	State     string     `json:"state"`
-	User      GitHubUser `json:"user"`
-	CreatedAt string     `json:"submitted_at"`
-}
-
+// IssuesResponse is a page of the repo's issues. GitHub doesn't say how many
+// there are, so a full page reports has_more and the next may be empty.
 type IssuesResponse struct {
-	Issues   []GitHubIssue `json:"issues"`
-	RepoName string        `json:"repo_name"`
+	Page[github.Issue]
+	RepoName string `json:"repo_name"`
 }
 
+// PRsResponse is IssuesResponse for pull requests
 type PRsResponse struct {
-	PullRequests []GitHubPR `json:"pull_requests"`
-	RepoName     string     `json:"repo_name"`
+	Page[github.PullRequest]
+	RepoName string `json:"repo_name"`
 }
 
 type SummarizeRequest struct {
-	Type   string `json:"type"`
-	Number int    `json:"number"`
+	Type   string `json:"type" binding:"required,oneof=issue pr"`
+	Number int    `json:"number" binding:"required,min=1"`
+	// Also post the summary to this channel of the loop
+	PostToChannelID string `json:"post_to_channel_id" binding:"omitempty,uuid"`
 }
 
 type SummaryResponse struct {
@@ -107,85 +56,61 @@ type SummaryResponse struct {
 	RepoName  string `json:"repo_name"`
 	URL       string `json:"url"`
 	Generated string `json:"generated_at"`
+	// The channel message, when post_to_channel_id was given
+	MessageID string `json:"message_id,omitempty"`
 }
 
 // ============================================================================
 // Helpers
 // ============================================================================
 
-// Shared HTTP client with connection pooling for GitHub API calls
-var githubHTTPClient = &http.Client{
-	Timeout: 15 * time.Second,
-	Transport: &http.Transport{
-		MaxIdleConns:        20,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
-	},
-}
-
-// Cache repo full names to avoid repeated GitHub API calls
-var repoNameCache sync.Map // map[int64]string
-
-func getRepoFullName(repoID int64, accessToken string) (string, error) {
-	// Check cache first
-	if cached, ok := repoNameCache.Load(repoID); ok {
-		return cached.(string), nil
+// forgetRepoOn404 drops the cached full name once GitHub says the repo is gone
+func forgetRepoOn404(err error, repoID int64) {
+	if errors.Is(err, github.ErrNotFound) {
+		github.Default.ForgetRepo(repoID)
 	}
+}
 
-	if repoID == 0 {
-		return "", fmt.Errorf("no GitHub repository linked to this loop (repo ID is 0)")
+// reportGitHubError sends GitHub failures on our side or theirs (5xx,
+// transport errors) to the error reporter; 4xx and rate limits are the
+// caller's and are only logged
+func reportGitHubError(req *http.Request, err error, op string) {
+	if github.StatusCode(err) >= 500 && !errors.Is(err, github.ErrRateLimited) {
+		errreport.Capture(req, err, map[string]string{"component": "github", "op": op})
 	}
+}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("https://api.github.com/repositories/%d", repoID), nil)
-	if err != nil {
-		return "", err
-	}
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github+json")
-
-	resp, err := githubHTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to connect to GitHub API: %v", err)
-	}
-	defer resp.Body.Close()
+// errAINotConfigured is expected without GEMINI_API_KEY and not reported
+var errAINotConfigured = errors.New("GEMINI_API_KEY not set")
 
-	if resp.StatusCode != 200 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		log.Printf("[github] getRepoFullName failed: repoID=%d status=%d body=%s", repoID, resp.StatusCode, string(bodyBytes))
-
-		switch resp.StatusCode {
-		case 401:
-			return "", fmt.Errorf("GitHub token expired or invalid — try signing out and back in")
-		case 403:
-			return "", fmt.Errorf("GitHub token lacks permission to access this repository")
-		case 404:
-			return "", fmt.Errorf("repository not found (ID: %d) — it may have been deleted or made private", repoID)
-		default:
-			return "", fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(bodyBytes))
-		}
+// reportAIError sends a failed AI call to the error reporter
+func reportAIError(req *http.Request, err error, feature string) {
+	if !errors.Is(err, errAINotConfigured) && !errors.Is(err, errAIDisabled) && !errors.Is(err, errAIContentBlocked) {
+		errreport.Capture(req, err, map[string]string{"component": "ai", "feature": feature})
 	}
-
-	var repo struct {
-		FullName string `json:"full_name"`
-	}
-	if err := json.NewDecoder(resp.Body).Decode(&repo); err != nil {
-		return "", err
-	}
-
-	// Cache the result
-	repoNameCache.Store(repoID, repo.FullName)
-
-	return repo.FullName, nil
 }
 
-func githubAPIGet(url, accessToken string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	return githubHTTPClient.Do(req)
+// githubListPage reads the page of a GitHub list: ?state=, ?limit= (or
+// ?per_page=) and ?cursor= (or ?page=), which is a GitHub page number. It
+// writes the 400 for a bad cursor.
+func githubListPage(c *gin.Context) (opts github.ListOptions, page, perPage int, ok bool) {
+	perPage = pageLimit(c, 20, 100)
+	raw := c.Query("cursor")
+	if raw == "" {
+		raw = c.DefaultQuery("page", "1")
+	}
+	page, err := strconv.Atoi(raw)
+	if err != nil || page < 1 {
+		problem.Respond(c, 400, "invalid cursor")
+		return opts, 0, 0, false
+	}
+	return github.ListOptions{
+		State:   c.DefaultQuery("state", "open"),
+		Page:    strconv.Itoa(page),
+		PerPage: strconv.Itoa(perPage),
+		Sort:    "updated",
+		Dir:     "desc",
+	}, page, perPage, true
 }
 
 // ============================================================================
@@ -196,72 +121,60 @@ func (h *Handler) HandleGetGitHubIssues(c *gin.Context) {
 	name := c.Param("name")
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	ctx := c.Request.Context()
-	project, err := h.Queries.GetProjectByName(ctx, name)
+	project, err := h.Loops.ByName(ctx, name)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 	if project.GithubRepoID == 0 {
-		c.JSON(400, gin.H{"error": "no GitHub repository linked to this loop"})
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
 		return
 	}
 
-	user, err := h.Queries.GetUserByID(ctx, uid)
+	user, err := h.getUserByID(ctx, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
-	if user.AccessToken == "" {
-		c.JSON(401, gin.H{"error": "No GitHub access token. Please re-login."})
+	token := h.loopReadToken(ctx, project, user)
+	if token == "" {
+		problem.Respond(c, 401, "No GitHub access token. Please re-login.")
 		return
 	}
 
-	repoFullName, err := getRepoFullName(project.GithubRepoID, user.AccessToken)
-	if err != nil {
-		log.Printf("[GitHub] Failed to get repo name for ID %d: %v", project.GithubRepoID, err)
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
-	state := c.DefaultQuery("state", "open")
-	page := c.DefaultQuery("page", "1")
-	perPage := c.DefaultQuery("per_page", "20")
-
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/issues?state=%s&page=%s&per_page=%s&sort=updated&direction=desc",
-		repoFullName, state, page, perPage)
-
-	resp, err := githubAPIGet(apiURL, user.AccessToken)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to fetch issues from GitHub"})
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		c.JSON(resp.StatusCode, gin.H{"error": fmt.Sprintf("GitHub API error: %d", resp.StatusCode)})
+	opts, page, perPage, ok := githubListPage(c)
+	if !ok {
 		return
 	}
-
-	var allItems []GitHubIssue
-	if err := json.NewDecoder(resp.Body).Decode(&allItems); err != nil {
-		c.JSON(500, gin.H{"error": "failed to parse GitHub response"})
+	allItems, err := cachedIssues(ctx, utils.UUIDToStr(project.ID), project.GithubRepoID, token, repoFullName, opts)
+	if err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
 		return
 	}
 
 	// Filter out PRs (GitHub issues API includes them)
-	issues := make([]GitHubIssue, 0, len(allItems))
+	issues := make([]github.Issue, 0, len(allItems))
 	for _, item := range allItems {
 		if item.PullRequest == nil {
 			issues = append(issues, item)
 		}
 	}
 
-	c.JSON(200, IssuesResponse{Issues: issues, RepoName: repoFullName})
+	c.JSON(200, IssuesResponse{
+		Page:     newPage(issues, len(allItems) == perPage, strconv.Itoa(page+1)),
+		RepoName: repoFullName,
+	})
 }
 
 // ============================================================================
@@ -272,64 +185,52 @@ func (h *Handler) HandleGetGitHubPRs(c *gin.Context) {
 	name := c.Param("name")
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	ctx := c.Request.Context()
-	project, err := h.Queries.GetProjectByName(ctx, name)
+	project, err := h.Loops.ByName(ctx, name)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 	if project.GithubRepoID == 0 {
-		c.JSON(400, gin.H{"error": "no GitHub repository linked to this loop"})
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
 		return
 	}
 
-	user, err := h.Queries.GetUserByID(ctx, uid)
+	user, err := h.getUserByID(ctx, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
-	if user.AccessToken == "" {
-		c.JSON(401, gin.H{"error": "No GitHub access token. Please re-login."})
+	token := h.loopReadToken(ctx, project, user)
+	if token == "" {
+		problem.Respond(c, 401, "No GitHub access token. Please re-login.")
 		return
 	}
 
-	repoFullName, err := getRepoFullName(project.GithubRepoID, user.AccessToken)
-	if err != nil {
-		log.Printf("[GitHub] Failed to get repo name for ID %d: %v", project.GithubRepoID, err)
-		c.JSON(500, gin.H{"error": err.Error()})
-		return
-	}
-
-	state := c.DefaultQuery("state", "open")
-	page := c.DefaultQuery("page", "1")
-	perPage := c.DefaultQuery("per_page", "20")
-
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/pulls?state=%s&page=%s&per_page=%s&sort=updated&direction=desc",
-		repoFullName, state, page, perPage)
-
-	resp, err := githubAPIGet(apiURL, user.AccessToken)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to fetch PRs from GitHub"})
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		c.JSON(resp.StatusCode, gin.H{"error": fmt.Sprintf("GitHub API error: %d", resp.StatusCode)})
+	opts, page, perPage, ok := githubListPage(c)
+	if !ok {
 		return
 	}
-
-	var prs []GitHubPR
-	if err := json.NewDecoder(resp.Body).Decode(&prs); err != nil {
-		c.JSON(500, gin.H{"error": "failed to parse GitHub response"})
+	prs, err := cachedPulls(ctx, utils.UUIDToStr(project.ID), project.GithubRepoID, token, repoFullName, opts)
+	if err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
 		return
 	}
 
-	c.JSON(200, PRsResponse{PullRequests: prs, RepoName: repoFullName})
+	c.JSON(200, PRsResponse{
+		Page:     newPage(prs, len(prs) == perPage, strconv.Itoa(page+1)),
+		RepoName: repoFullName,
+	})
 }
 
 // ============================================================================
@@ -340,46 +241,60 @@ func (h *Handler) HandleGitHubSummarize(c *gin.Context) {
 	name := c.Param("name")
 
 	var req SummarizeRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "invalid request: type and number required"})
-		return
-	}
-	if req.Type != "issue" && req.Type != "pr" {
-		c.JSON(400, gin.H{"error": "type must be 'issue' or 'pr'"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	ctx := c.Request.Context()
-	project, err := h.Queries.GetProjectByName(ctx, name)
+	project, err := h.Loops.ByName(ctx, name)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 	if project.GithubRepoID == 0 {
-		c.JSON(400, gin.H{"error": "no GitHub repository linked"})
+		problem.Respond(c, 400, "no GitHub repository linked")
 		return
 	}
+	if !h.Flags.Enabled(ctx, flags.AISummaries, flags.Subject{UserID: uid, LoopID: project.ID}) {
+		problem.Respond(c, 404, "AI summaries are not available for this loop yet")
+		return
+	}
+
+	// Check the target channel before spending an AI call on the summary
+	var postTo pgtype.UUID
+	if req.PostToChannelID != "" {
+		if postTo, ok = h.loopChannelParam(c, project, req.PostToChannelID); !ok {
+			return
+		}
+		if !h.Members.CanUseChannel(ctx, h.Members.Role(ctx, uid, project.ID), project.ID, postTo) {
+			problem.Respond(c, 403, "you can't post in that channel")
+			return
+		}
+		if link, linked := h.channelGitHubLink(ctx, postTo); linked && link.ArchivedAt.Valid {
+			problem.Respond(c, 403, "this channel was archived when its GitHub "+link.Kind+" closed")
+			return
+		}
+	}
 
-	user, err := h.Queries.GetUserByID(ctx, uid)
+	user, err := h.getUserByID(ctx, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
-	if user.AccessToken == "" {
-		c.JSON(401, gin.H{"error": "No GitHub access token"})
+	token := h.loopReadToken(ctx, project, user)
+	if token == "" {
+		problem.Respond(c, 401, "No GitHub access token")
 		return
 	}
 
-	repoFullName, err := getRepoFullName(project.GithubRepoID, user.AccessToken)
-	if err != nil {
-		log.Printf("[GitHub] Failed to get repo name for ID %d: %v", project.GithubRepoID, err)
-		c.JSON(500, gin.H{"error": err.Error()})
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
 		return
 	}
 
@@ -390,32 +305,25 @@ func (h *Handler) HandleGitHubSummarize(c *gin.Context) {
 		itemBody  string
 		itemURL   string
 		itemState string
-		comments  []GitHubComment
-		reviews   []GitHubReview
-		prDetails *GitHubPR
+		comments  []github.Comment
+		reviews   []github.Review
+		prDetails *github.PullRequest
 		itemErr   error
 	)
 
-	numStr := strconv.Itoa(req.Number)
+	gh := github.Default
+	recent := github.ListOptions{PerPage: "50"}
 
 	if req.Type == "issue" {
 		wg.Add(2)
 		go func() {
 			defer wg.Done()
-			resp, err := githubAPIGet(
-				fmt.Sprintf("https://api.github.com/repos/%s/issues/%s", repoFullName, numStr),
-				user.AccessToken)
+			issue, err := gh.GetIssue(ctx, token, repoFullName, req.Number)
 			if err != nil {
+				forgetRepoOn404(err, project.GithubRepoID)
 				itemErr = err
 				return
 			}
-			defer resp.Body.Close()
-			if resp.StatusCode != 200 {
-				itemErr = fmt.Errorf("GitHub error: %d", resp.StatusCode)
-				return
-			}
-			var issue GitHubIssue
-			json.NewDecoder(resp.Body).Decode(&issue)
 			itemTitle = issue.Title
 			itemBody = issue.Body
 			itemURL = issue.HTMLURL
@@ -423,36 +331,19 @@ func (h *Handler) HandleGitHubSummarize(c *gin.Context) {
 		}()
 		go func() {
 			defer wg.Done()
-			resp, err := githubAPIGet(
-				fmt.Sprintf("https://api.github.com/repos/%s/issues/%s/comments?per_page=50", repoFullName, numStr),
-				user.AccessToken)
-			if err != nil {
-				return
-			}
-			defer resp.Body.Close()
-			if resp.StatusCode == 200 {
-				json.NewDecoder(resp.Body).Decode(&comments)
-			}
+			comments, _ = gh.ListIssueComments(ctx, token, repoFullName, req.Number, recent)
 		}()
 	} else {
 		wg.Add(3)
 		go func() {
 			defer wg.Done()
-			resp, err := githubAPIGet(
-				fmt.Sprintf("https://api.github.com/repos/%s/pulls/%s", repoFullName, numStr),
-				user.AccessToken)
+			pr, err := gh.GetPull(ctx, token, repoFullName, req.Number)
 			if err != nil {
+				forgetRepoOn404(err, project.GithubRepoID)
 				itemErr = err
 				return
 			}
-			defer resp.Body.Close()
-			if resp.StatusCode != 200 {
-				itemErr = fmt.Errorf("GitHub error: %d", resp.StatusCode)
-				return
-			}
-			var pr GitHubPR
-			json.NewDecoder(resp.Body).Decode(&pr)
-			prDetails = &pr
+			prDetails = pr
 			itemTitle = pr.Title
 			itemBody = pr.Body
 			itemURL = pr.HTMLURL
@@ -460,29 +351,11 @@ func (h *Handler) HandleGitHubSummarize(c *gin.Context) {
 		}()
 		go func() {
 			defer wg.Done()
-			resp, err := githubAPIGet(
-				fmt.Sprintf("https://api.github.com/repos/%s/issues/%s/comments?per_page=50", repoFullName, numStr),
-				user.AccessToken)
-			if err != nil {
-				return
-			}
-			defer resp.Body.Close()
-			if resp.StatusCode == 200 {
-				json.NewDecoder(resp.Body).Decode(&comments)
-			}
+			comments, _ = gh.ListIssueComments(ctx, token, repoFullName, req.Number, recent)
 		}()
 		go func() {
 			defer wg.Done()
-			resp, err := githubAPIGet(
-				fmt.Sprintf("https://api.github.com/repos/%s/pulls/%s/reviews?per_page=50", repoFullName, numStr),
-				user.AccessToken)
-			if err != nil {
-				return
-			}
-			defer resp.Body.Close()
-			if resp.StatusCode == 200 {
-				json.NewDecoder(resp.Body).Decode(&reviews)
-			}
+			reviews, _ = gh.ListReviews(ctx, token, repoFullName, req.Number, recent)
 		}()
 	}
 
@@ -490,26 +363,48 @@ func (h *Handler) HandleGitHubSummarize(c *gin.Context) {
 
 	if itemErr != nil {
 		log.Printf("[GitHub Summarize] Failed to fetch %s #%d: %v", req.Type, req.Number, itemErr)
-		c.JSON(500, gin.H{"error": "failed to fetch item from GitHub"})
+		reportGitHubError(c.Request, itemErr, "summarize_fetch")
+		problem.Respond(c, 500, "failed to fetch item from GitHub")
 		return
 	}
 
 	// Generate AI summary with fallback
-	summary, err := generateAISummary(req.Type, itemTitle, itemBody, itemState, repoFullName, req.Number, comments, reviews, prDetails)
+	summary := ""
+	opts, err := h.loopAI(ctx, project.ID, 500)
+	if err == nil {
+		summary, err = generateAISummary(req.Type, itemTitle, itemBody, itemState, repoFullName, req.Number, comments, reviews, prDetails, opts)
+	}
 	if err != nil {
 		log.Printf("[AI Summarize] AI unavailable, using fallback: %v", err)
+		reportAIError(c.Request, err, "github_summary")
 		summary = generateFallbackSummary(itemType(req.Type), itemTitle, itemBody, itemState, comments, reviews, prDetails)
 	}
 
-	c.JSON(200, SummaryResponse{
+	resp := SummaryResponse{
 		Summary:   summary,
 		Type:      req.Type,
 		Number:    req.Number,
 		Title:     itemTitle,
 		RepoName:  repoFullName,
 		URL:       itemURL,
-		Generated: time.Now().Format(time.RFC3339),
-	})
+		Generated: utils.FormatTime(time.Now()),
+	}
+
+	if postTo.Valid {
+		if err := h.Quotas.UseMessage(ctx, project.ID); err != nil {
+			respondQuota(c, err)
+			return
+		}
+		content := fmt.Sprintf("🤖 **Summary of %s [#%d %s](%s)**\n\n%s", itemType(req.Type), req.Number, itemTitle, itemURL, summary)
+		msgID, err := h.postChannelMessage(ctx, project.ID, postTo, user, content)
+		if err != nil {
+			problem.Respond(c, 500, "failed to post summary")
+			return
+		}
+		resp.MessageID = utils.FormatMessageID(msgID)
+	}
+
+	c.JSON(200, resp)
 }
 
 // itemType helper to capitalize
@@ -538,10 +433,16 @@ type geminiGenerationConfig struct {
 	MaxOutputTokens int     `json:"maxOutputTokens"`
 }
 
+type geminiSafetySetting struct {
+	Category  string `json:"category"`
+	Threshold string `json:"threshold"`
+}
+
 type geminiRequest struct {
 	Contents          []geminiContent        `json:"contents"`
 	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
 	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+	SafetySettings    []geminiSafetySetting  `json:"safetySettings,omitempty"`
 }
 
 type geminiResponse struct {
@@ -554,16 +455,16 @@ type geminiResponse struct {
 	} `json:"candidates"`
 }
 
-func generateAISummary(typ, title, body, state, repoName string, number int, comments []GitHubComment, reviews []GitHubReview, pr *GitHubPR) (string, error) {
+func generateAISummary(typ, title, body, state, repoName string, number int, comments []github.Comment, reviews []github.Review, pr *github.PullRequest, opts aiOptions) (string, error) {
 	apiKey := os.Getenv("GEMINI_API_KEY")
 	if apiKey == "" {
-		return "", fmt.Errorf("GEMINI_API_KEY not set")
+		return "", errAINotConfigured
 	}
 
 	var prompt strings.Builder
 	prompt.WriteString(fmt.Sprintf("Repository: %s\n", repoName))
 	prompt.WriteString(fmt.Sprintf("Type: %s #%d\n", typ, number))
-	prompt.WriteString(fmt.Sprintf("Title: %s\n", title))
+	prompt.WriteString(fmt.Sprintf("Title:\n%s\n", untrusted("title", title)))
 	prompt.WriteString(fmt.Sprintf("State: %s\n", state))
 
 	if pr != nil {
@@ -577,12 +478,15 @@ func generateAISummary(typ, title, body, state, repoName string, number int, com
 		}
 	}
 
-	if body != "" {
+	if body != "" && opts.allows(aiContentBodies) {
 		trimmed := body
 		if len(trimmed) > 3000 {
 			trimmed = trimmed[:3000] + "...[truncated]"
 		}
-		prompt.WriteString(fmt.Sprintf("\nDescription:\n%s\n", trimmed))
+		prompt.WriteString(fmt.Sprintf("\nDescription:\n%s\n", untrusted("description", trimmed)))
+	}
+	if !opts.allows(aiContentComments) {
+		comments, reviews = nil, nil
 	}
 
 	if len(comments) > 0 {
@@ -596,7 +500,7 @@ func generateAISummary(typ, title, body, state, repoName string, number int, com
 			if len(t) > 500 {
 				t = t[:500] + "..."
 			}
-			prompt.WriteString(fmt.Sprintf("@%s: %s\n\n", c.User.Login, t))
+			prompt.WriteString(untrusted("comment by @"+c.User.Login, t) + "\n\n")
 		}
 	}
 
@@ -604,7 +508,7 @@ func generateAISummary(typ, title, body, state, repoName string, number int, com
 		prompt.WriteString("\nCode Reviews:\n")
 		for _, r := range reviews {
 			if r.Body != "" {
-				prompt.WriteString(fmt.Sprintf("@%s [%s]: %s\n\n", r.User.Login, r.State, r.Body))
+				prompt.WriteString(untrusted(fmt.Sprintf("review by @%s, %s", r.User.Login, r.State), r.Body) + "\n\n")
 			}
 		}
 	}
@@ -622,23 +526,38 @@ Format:
 
 Be concise. No unnecessary jargon.`
 
-	model := os.Getenv("GEMINI_MODEL")
+	return generateGemini(apiKey, system, prompt.String(), opts)
+}
+
+// generateGemini runs one prompt against Gemini with the loop's options,
+// including the language and tone it wants answers in
+func generateGemini(apiKey, system, prompt string, opts aiOptions) (string, error) {
+	model := opts.Model
 	if model == "" {
-		model = "gemini-2.5-flash"
+		model = defaultAIModel
+	}
+
+	if style := aiStyleRules(opts); style != "" {
+		system += "\n\n" + style
 	}
 
 	reqBody := geminiRequest{
 		Contents: []geminiContent{
-			{Role: "user", Parts: []geminiPart{{Text: prompt.String()}}},
+			{Role: "user", Parts: []geminiPart{{Text: prompt}}},
 		},
 		SystemInstruction: &geminiContent{
-			Parts: []geminiPart{{Text: system}},
+			Parts: []geminiPart{{Text: system + "\n\n" + aiUntrustedRules}},
 		},
 		GenerationConfig: geminiGenerationConfig{
-			Temperature:     0.3,
-			MaxOutputTokens: 500,
+			Temperature:     opts.Temperature,
+			MaxOutputTokens: opts.MaxTokens,
 		},
 	}
+	if threshold, ok := aiSafetyThresholds[opts.Safety]; ok {
+		for _, category := range geminiHarmCategories {
+			reqBody.SafetySettings = append(reqBody.SafetySettings, geminiSafetySetting{Category: category, Threshold: threshold})
+		}
+	}
 
 	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
@@ -672,12 +591,15 @@ Be concise. No unnecessary jargon.`
 	if len(aiResp.Candidates) == 0 || len(aiResp.Candidates[0].Content.Parts) == 0 {
 		return "", fmt.Errorf("no response from Gemini")
 	}
-
-	return aiResp.Candidates[0].Content.Parts[0].Text, nil
+	text := cleanAIOutput(aiResp.Candidates[0].Content.Parts[0].Text)
+	if text == "" {
+		return "", fmt.Errorf("empty response from Gemini")
+	}
+	return text, nil
 }
 
 // Fallback summary when AI is unavailable
-func generateFallbackSummary(typeName, title, body, state string, comments []GitHubComment, reviews []GitHubReview, pr *GitHubPR) string {
+func generateFallbackSummary(typeName, title, body, state string, comments []github.Comment, reviews []github.Review, pr *github.PullRequest) string {
 	var sb strings.Builder
 
 	sb.WriteString(fmt.Sprintf("**Status**: %s\n", state))
@@ -0,0 +1,231 @@
+package api
+
+import (
+	"net/url"
+	"strings"
+
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// GITHUB ACTIONS CATALOG
+// The GitHub operations a member can run from a loop depend on their role,
+// on what their GitHub account may do in the repo and on the OAuth scopes
+// they granted. The catalog works all three out for the caller with one
+// GitHub call, so the command palette offers what will work and says why the
+// rest won't, instead of hardcoding it.
+// ============================================================================
+
+// githubActionSpec is an operation the catalog lists. Permission is the
+// least repo permission it takes (pull, triage or push).
+type githubActionSpec struct {
+	ID          string
+	Title       string
+	Description string
+	Method      string
+	Path        string // :name is filled in with the loop's name
+	Permission  string
+	Scope       string // the OAuth scope it needs, or "" when reading did
+	Moderators  bool   // only the owner and moderators may run it
+	Writes      bool   // refused while the loop mirrors a gone repo
+}
+
+var githubActions = []githubActionSpec{
+	{
+		ID: "create_issue", Title: "Create issue", Description: "Open a GitHub issue from a task",
+		Method: "POST", Path: "/api/tasks/:id/escalate",
+		Permission: "pull", Scope: "repo", Writes: true,
+	},
+	{
+		ID: "export_thread", Title: "Export thread", Description: "Post a thread as a new issue or as a comment on one",
+		Method: "POST", Path: "/api/messages/:message_id/export",
+		Permission: "pull", Scope: "repo", Writes: true,
+	},
+	{
+		ID: "comment_issue", Title: "Comment on issue", Description: "Reply to an issue",
+		Method: "POST", Path: "/api/loops/:name/github/issue/:number/comments",
+		Permission: "pull", Scope: "repo", Writes: true,
+	},
+	{
+		ID: "comment_pr", Title: "Comment on pull request", Description: "Reply to a pull request or one of its review comments",
+		Method: "POST", Path: "/api/loops/:name/github/pr-comment",
+		Permission: "pull", Scope: "repo", Writes: true,
+	},
+	{
+		ID: "review_pr", Title: "Review pull request", Description: "Comment on or request changes to a pull request",
+		Method: "POST", Path: "/api/loops/:name/github/pr/:number/review",
+		Permission: "pull", Scope: "repo", Writes: true,
+	},
+	{
+		// GitHub takes anyone's approval but only counts collaborators'
+		ID: "approve_pr", Title: "Approve pull request", Description: "Approve a pull request",
+		Method: "POST", Path: "/api/loops/:name/github/pr/:number/review",
+		Permission: "push", Scope: "repo", Writes: true,
+	},
+	{
+		ID: "dispatch_workflow", Title: "Run workflow", Description: "Start a GitHub Actions workflow and announce it in a channel",
+		Method: "POST", Path: "/api/loops/:name/github/workflows/:id/dispatch",
+		Permission: "push", Scope: "repo", Moderators: true, Writes: true,
+	},
+	{
+		ID: "summarize", Title: "Summarize", Description: "Summarize an issue or pull request",
+		Method: "POST", Path: "/api/loops/:name/github/summarize",
+		Permission: "pull",
+	},
+}
+
+// Why an action is unavailable
+const (
+	actionNeedsRole       = "role"       // the caller isn't a moderator
+	actionNeedsPermission = "permission" // their GitHub account lacks the repo permission
+	actionNeedsScope      = "scope"      // their sign-in lacks the OAuth scope
+	actionRepoMirrored    = "mirrored"   // the repo is archived or gone
+)
+
+type GitHubAction struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Available   bool   `json:"available"`
+	// Set when unavailable: role, permission, scope or mirrored
+	Reason string `json:"reason,omitempty"`
+	// The missing scope and where to grant it, for reason scope
+	Scope     string `json:"scope,omitempty"`
+	ReauthURL string `json:"reauth_url,omitempty"`
+}
+
+type GitHubActionsCatalog struct {
+	Repo string `json:"repo"`
+	Role string `json:"role"`
+	// The caller's permission in the repo: admin, maintain, push, triage or
+	// pull
+	Permission string `json:"permission"`
+	// Classic OAuth scopes of the caller's sign-in; empty when GitHub
+	// reports none, in which case scopes aren't checked
+	Scopes  []string       `json:"scopes"`
+	Actions []GitHubAction `json:"actions"`
+}
+
+// actionCaller is what decides which actions a caller may run
+type actionCaller struct {
+	Moderator   bool
+	Permissions *github.RepoPermissions
+	Scopes      []string
+	Mirrored    bool
+}
+
+// repoPermission names the strongest permission in p
+func repoPermission(p *github.RepoPermissions) string {
+	switch {
+	case p == nil:
+		return "pull" // the token read the repo
+	case p.Admin:
+		return "admin"
+	case p.Maintain:
+		return "maintain"
+	case p.Push:
+		return "push"
+	case p.Triage:
+		return "triage"
+	}
+	return "pull"
+}
+
+// hasRepoPermission reports whether p grants want or more
+func hasRepoPermission(p *github.RepoPermissions, want string) bool {
+	levels := []string{"pull", "triage", "push", "maintain", "admin"}
+	have := repoPermission(p)
+	for _, l := range levels {
+		if l == want {
+			return true
+		}
+		if l == have {
+			return false
+		}
+	}
+	return false
+}
+
+// unavailable returns why the caller can't run a, or "" when they can
+func (a githubActionSpec) unavailable(caller actionCaller) string {
+	switch {
+	case a.Moderators && !caller.Moderator:
+		return actionNeedsRole
+	case a.Writes && caller.Mirrored:
+		return actionRepoMirrored
+	case !hasRepoPermission(caller.Permissions, a.Permission):
+		return actionNeedsPermission
+	case a.Scope != "" && len(caller.Scopes) > 0 && !github.HasScope(caller.Scopes, a.Scope):
+		return actionNeedsScope
+	}
+	return ""
+}
+
+// ============================================================================
+// GET /api/loops/:name/github/actions-catalog
+// ============================================================================
+
+func (h *Handler) HandleGetGitHubActionsCatalog(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+	ctx := c.Request.Context()
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	if user.AccessToken == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
+		return
+	}
+
+	health, err := github.Default.CheckRepoToken(ctx, user.AccessToken, project.GithubRepoID)
+	if err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
+		return
+	}
+	_, mirrored := h.loopMirror(ctx, project.ID)
+	caller := actionCaller{
+		Moderator:   h.Members.CanModerate(ctx, uid, project),
+		Permissions: health.Permissions,
+		Scopes:      health.Scopes,
+		Mirrored:    mirrored,
+	}
+
+	actions := make([]GitHubAction, 0, len(githubActions))
+	for _, a := range githubActions {
+		action := GitHubAction{
+			ID:          a.ID,
+			Title:       a.Title,
+			Description: a.Description,
+			Method:      a.Method,
+			Path:        strings.Replace(a.Path, ":name", url.PathEscape(project.Name), 1),
+			Reason:      a.unavailable(caller),
+		}
+		action.Available = action.Reason == ""
+		if action.Reason == actionNeedsScope {
+			action.Scope = a.Scope
+			action.ReauthURL = githubScopeURL(a.Scope)
+		}
+		actions = append(actions, action)
+	}
+	c.JSON(200, GitHubActionsCatalog{
+		Repo:       health.Repo,
+		Role:       h.Members.Role(ctx, uid, project.ID),
+		Permission: repoPermission(health.Permissions),
+		Scopes:     health.Scopes,
+		Actions:    actions,
+	})
+}
@@ -0,0 +1,481 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/flags"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// GITHUB ACTIVITY DIGEST
+// Daily or weekly, at the loop's chosen time, the linked repo's activity
+// since the last digest (new issues, merged PRs, releases, busiest threads)
+// is written up and posted to a channel. The write-up is AI-generated when
+// AI summaries are on for the loop and falls back to plain lists.
+// ============================================================================
+
+const (
+	jobGitHubDigest = "github_digest"
+
+	digestDaily  = "daily"
+	digestWeekly = "weekly"
+
+	digestSectionIssues      = "issues"      // newly opened issues
+	digestSectionPulls       = "pulls"       // merged pull requests
+	digestSectionReleases    = "releases"    // published releases
+	digestSectionDiscussions = "discussions" // most-commented issues and PRs
+
+	// Items listed per section
+	githubDigestItems = 8
+)
+
+var githubDigestSections = []string{digestSectionIssues, digestSectionPulls, digestSectionReleases, digestSectionDiscussions}
+
+type GitHubDigestSettings struct {
+	ChannelID  string   `json:"channel_id"`
+	Frequency  string   `json:"frequency"`
+	SendTime   string   `json:"send_time"`
+	Timezone   string   `json:"timezone"`
+	Weekday    int      `json:"weekday"` // weekly only, 0 = Sunday
+	Sections   []string `json:"sections"`
+	Enabled    bool     `json:"enabled"`
+	NextPostAt *string  `json:"next_post_at,omitempty"`
+	LastPosted *string  `json:"last_posted_at,omitempty"`
+}
+
+type GitHubDigestRequest struct {
+	ChannelID string   `json:"channel_id" binding:"required,uuid"`
+	Frequency string   `json:"frequency" binding:"omitempty,oneof=daily weekly"` // default weekly
+	SendTime  string   `json:"send_time"`                                        // "HH:MM", default 09:00
+	Timezone  string   `json:"timezone" binding:"omitempty,timezone"`            // IANA, default the owner's
+	Weekday   *int     `json:"weekday" binding:"omitnil,min=0,max=6"`            // default Monday
+	Sections  []string `json:"sections" binding:"omitempty,max=4,dive,oneof=issues pulls releases discussions"`
+	Enabled   *bool    `json:"enabled"`
+}
+
+type githubDigestPayload struct {
+	ProjectID string `json:"project_id"`
+	Version   int64  `json:"version"` // digest updated_at; stale runs are skipped
+}
+
+// githubActivity is what a digest covers
+type githubActivity struct {
+	Repo        string
+	Since       time.Time
+	Issues      []github.Issue
+	Pulls       []github.PullRequest
+	Releases    []github.Release
+	Discussions []github.Issue
+}
+
+func (a githubActivity) empty() bool {
+	return len(a.Issues) == 0 && len(a.Pulls) == 0 && len(a.Releases) == 0 && len(a.Discussions) == 0
+}
+
+// nextGitHubDigestTime finds the first scheduled digest strictly after t
+func nextGitHubDigestTime(d db.LoopGithubDigest, t time.Time) (time.Time, bool) {
+	weekdays := int32(127)
+	if d.Frequency == digestWeekly {
+		weekdays = 1 << d.Weekday
+	}
+	return nextScheduledTime(d.SendTime, d.Timezone, weekdays, t)
+}
+
+func githubDigestToResponse(d db.LoopGithubDigest) GitHubDigestSettings {
+	resp := GitHubDigestSettings{
+		ChannelID: utils.UUIDToStr(d.ChannelID),
+		Frequency: d.Frequency,
+		SendTime:  d.SendTime,
+		Timezone:  d.Timezone,
+		Weekday:   int(d.Weekday),
+		Sections:  d.Sections,
+		Enabled:   d.Enabled,
+	}
+	if d.Enabled {
+		if next, ok := nextGitHubDigestTime(d, time.Now()); ok {
+			n := utils.FormatTime(next)
+			resp.NextPostAt = &n
+		}
+	}
+	if d.LastPostedAt.Valid {
+		l := utils.FormatTime(d.LastPostedAt.Time)
+		resp.LastPosted = &l
+	}
+	return resp
+}
+
+// HandleGetGitHubDigest returns the loop's digest settings (owner only);
+// null when the loop has none
+func (h *Handler) HandleGetGitHubDigest(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "view the GitHub digest")
+	if !ok {
+		return
+	}
+
+	d, err := h.Queries.GetLoopGithubDigest(c, project.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		c.JSON(200, nil)
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to get digest settings")
+		return
+	}
+	c.JSON(200, githubDigestToResponse(d))
+}
+
+// HandleUpdateGitHubDigest sets up or replaces the loop's digest (owner only)
+func (h *Handler) HandleUpdateGitHubDigest(c *gin.Context) {
+	var req GitHubDigestRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+
+	project, ok := h.ownedLoop(c, "configure the GitHub digest")
+	if !ok {
+		return
+	}
+	uid, _ := utils.GetUserIdFromContext(c)
+
+	channelID, ok := h.loopChannelParam(c, project, req.ChannelID)
+	if !ok {
+		return
+	}
+	p := db.UpsertLoopGithubDigestParams{
+		ProjectID: project.ID,
+		ChannelID: channelID,
+		Frequency: req.Frequency,
+		SendTime:  req.SendTime,
+		Timezone:  req.Timezone,
+		Weekday:   1,
+		Sections:  githubDigestSections,
+		Enabled:   true,
+		UpdatedBy: uid,
+	}
+	if p.Frequency == "" {
+		p.Frequency = digestWeekly
+	}
+	if p.SendTime == "" {
+		p.SendTime = "09:00"
+	}
+	if !promptTimeRegex.MatchString(p.SendTime) {
+		problem.Respond(c, 400, "send_time must be HH:MM")
+		return
+	}
+	if p.Timezone == "" {
+		p.Timezone = h.userTimezone(c, uid).String()
+	}
+	if req.Weekday != nil {
+		p.Weekday = int32(*req.Weekday)
+	}
+	if len(req.Sections) > 0 {
+		// Keep the canonical order whatever order they were sent in
+		p.Sections = slices.DeleteFunc(slices.Clone(githubDigestSections), func(s string) bool {
+			return !slices.Contains(req.Sections, s)
+		})
+	}
+	if req.Enabled != nil {
+		p.Enabled = *req.Enabled
+	}
+
+	d, err := h.Queries.UpsertLoopGithubDigest(c, p)
+	if err != nil {
+		log.Printf("[github digest] failed to save settings for %s: %v", project.Name, err)
+		problem.Respond(c, 500, "failed to save digest settings")
+		return
+	}
+	// Runs queued for the previous version see a different updated_at and skip
+	h.scheduleGitHubDigest(c, d, time.Now())
+
+	c.JSON(200, githubDigestToResponse(d))
+}
+
+// HandleDeleteGitHubDigest stops the loop's digest (owner only)
+func (h *Handler) HandleDeleteGitHubDigest(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "configure the GitHub digest")
+	if !ok {
+		return
+	}
+
+	if _, err := h.Queries.DeleteLoopGithubDigest(c, project.ID); err != nil {
+		problem.Respond(c, 500, "failed to delete digest settings")
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// ============================================================================
+// Jobs
+// ============================================================================
+
+// scheduleGitHubDigest queues the next digest after `after`, if enabled
+func (h *Handler) scheduleGitHubDigest(ctx context.Context, d db.LoopGithubDigest, after time.Time) {
+	if h.Jobs == nil || !d.Enabled {
+		return
+	}
+	next, ok := nextGitHubDigestTime(d, after)
+	if !ok {
+		return
+	}
+	if _, err := h.Jobs.Enqueue(ctx, jobGitHubDigest, githubDigestPayload{
+		ProjectID: utils.UUIDToStr(d.ProjectID),
+		Version:   d.UpdatedAt.Time.UnixNano(),
+	}, next); err != nil {
+		log.Printf("[github digest] failed to schedule digest for %s: %v", utils.UUIDToStr(d.ProjectID), err)
+	}
+}
+
+// runGitHubDigest posts the repo's activity since the last digest, as the
+// loop owner, and queues the next one. Quiet periods post nothing.
+func (h *Handler) runGitHubDigest(ctx context.Context, raw json.RawMessage) error {
+	var p githubDigestPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	projectID, err := utils.StrToUUID(p.ProjectID)
+	if err != nil {
+		return fmt.Errorf("bad project id: %w", err)
+	}
+
+	d, err := h.Queries.GetLoopGithubDigest(ctx, projectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // deleted
+	}
+	if err != nil {
+		return err
+	}
+	if d.UpdatedAt.Time.UnixNano() != p.Version || !d.Enabled {
+		return nil // reconfigured or disabled since scheduling
+	}
+
+	// Queue the next digest up front; failures below are logged rather than
+	// retried so a retry can't queue it twice
+	h.scheduleGitHubDigest(ctx, d, time.Now())
+
+	project, err := h.Loops.ByID(ctx, projectID)
+	if err != nil {
+		log.Printf("[github digest] failed to load loop %s: %v", p.ProjectID, err)
+		return nil
+	}
+	owner, err := h.getUserByID(ctx, project.OwnerID)
+	if err != nil {
+		log.Printf("[github digest] failed to load the owner of %s: %v", project.Name, err)
+		return nil
+	}
+
+	period := 24 * time.Hour
+	if d.Frequency == digestWeekly {
+		period = 7 * period
+	}
+	now := time.Now()
+	since := now.Add(-period)
+	if d.LastPostedAt.Valid && d.LastPostedAt.Time.After(since) {
+		since = d.LastPostedAt.Time
+	}
+
+	token := h.loopReadToken(ctx, project, owner)
+	if token == "" {
+		log.Printf("[github digest] no GitHub token can read the repo of %s", project.Name)
+		return nil
+	}
+	activity, err := collectGitHubActivity(ctx, token, project.GithubRepoID, since, d.Sections)
+	if err != nil {
+		log.Printf("[github digest] failed to collect activity for %s: %v", project.Name, err)
+		reportGitHubError(nil, err, "github_digest")
+		return nil
+	}
+	if activity.empty() {
+		return nil
+	}
+
+	content := formatGitHubDigest(activity, d.Frequency)
+	if h.Flags.Enabled(ctx, flags.AISummaries, flags.Subject{LoopID: project.ID}) {
+		summary := ""
+		opts, err := h.loopAI(ctx, project.ID, 700)
+		if err == nil {
+			summary, err = summarizeGitHubActivity(activity, d.Frequency, opts)
+		}
+		if err != nil {
+			log.Printf("[github digest] AI summary unavailable, posting lists: %v", err)
+			reportAIError(nil, err, "github_digest")
+		} else {
+			content = githubDigestTitle(activity, d.Frequency) + "\n\n" + strings.TrimSpace(summary)
+		}
+	}
+
+	if _, err := h.postChannelMessage(ctx, project.ID, d.ChannelID, owner, content); err != nil {
+		log.Printf("[github digest] failed to post digest for %s: %v", project.Name, err)
+		return nil
+	}
+	if err := h.Queries.MarkLoopGithubDigestPosted(ctx, db.MarkLoopGithubDigestPostedParams{
+		ProjectID:    project.ID,
+		LastPostedAt: pgtype.Timestamptz{Time: now, Valid: true},
+	}); err != nil {
+		log.Printf("[github digest] failed to record digest for %s: %v", project.Name, err)
+	}
+	return nil
+}
+
+// collectGitHubActivity fetches the repo's activity since a time for the
+// given sections
+func collectGitHubActivity(ctx context.Context, token string, repoID int64, since time.Time, sections []string) (githubActivity, error) {
+	repo, err := github.Default.RepoFullName(ctx, token, repoID)
+	if err != nil {
+		return githubActivity{}, err
+	}
+	a := githubActivity{Repo: repo, Since: since}
+	after := func(ts string) bool {
+		t, err := time.Parse(time.RFC3339, ts)
+		return err == nil && !t.Before(since)
+	}
+	updatedSince := url.Values{"since": {utils.FormatTime(since)}}
+
+	if slices.Contains(sections, digestSectionIssues) {
+		issues, err := github.Default.ListIssues(ctx, token, repo, github.ListOptions{State: "all", Sort: "created", Dir: "desc", PerPage: "50"}, updatedSince)
+		if err != nil {
+			return a, err
+		}
+		for _, i := range issues {
+			if i.PullRequest == nil && after(i.CreatedAt) && len(a.Issues) < githubDigestItems {
+				a.Issues = append(a.Issues, i)
+			}
+		}
+	}
+	if slices.Contains(sections, digestSectionPulls) {
+		pulls, err := github.Default.ListPulls(ctx, token, repo, github.ListOptions{State: "closed", Sort: "updated", Dir: "desc", PerPage: "50"})
+		if err != nil {
+			return a, err
+		}
+		for _, pr := range pulls {
+			if pr.MergedAt != nil && after(*pr.MergedAt) && len(a.Pulls) < githubDigestItems {
+				a.Pulls = append(a.Pulls, pr)
+			}
+		}
+	}
+	if slices.Contains(sections, digestSectionReleases) {
+		releases, err := github.Default.ListReleases(ctx, token, repo, github.ListOptions{PerPage: "20"})
+		if err != nil {
+			return a, err
+		}
+		for _, r := range releases {
+			if !r.Draft && after(r.PublishedAt) && len(a.Releases) < githubDigestItems {
+				a.Releases = append(a.Releases, r)
+			}
+		}
+	}
+	if slices.Contains(sections, digestSectionDiscussions) {
+		// Threads active in the period, busiest first
+		busy, err := github.Default.ListIssues(ctx, token, repo, github.ListOptions{State: "all", Sort: "comments", Dir: "desc", PerPage: "20"}, updatedSince)
+		if err != nil {
+			return a, err
+		}
+		for _, i := range busy {
+			if i.Comments > 0 && len(a.Discussions) < githubDigestItems/2 {
+				a.Discussions = append(a.Discussions, i)
+			}
+		}
+	}
+	return a, nil
+}
+
+func githubDigestTitle(a githubActivity, frequency string) string {
+	period := "This week"
+	if frequency == digestDaily {
+		period = "Today"
+	}
+	return fmt.Sprintf("📰 **%s in %s**", period, a.Repo)
+}
+
+func formatGitHubDigest(a githubActivity, frequency string) string {
+	var sb strings.Builder
+	sb.WriteString(githubDigestTitle(a, frequency) + "\n")
+	if len(a.Releases) > 0 {
+		sb.WriteString("\n**Releases**\n")
+		for _, r := range a.Releases {
+			name := r.TagName
+			if r.Name != "" && r.Name != r.TagName {
+				name += " — " + r.Name
+			}
+			fmt.Fprintf(&sb, "- [%s](%s)\n", name, r.HTMLURL)
+		}
+	}
+	if len(a.Pulls) > 0 {
+		sb.WriteString("\n**Merged pull requests**\n")
+		for _, pr := range a.Pulls {
+			fmt.Fprintf(&sb, "- [#%d %s](https://github.com/%s/pull/%d) by @%s\n", pr.Number, pr.Title, a.Repo, pr.Number, pr.User.Login)
+		}
+	}
+	if len(a.Issues) > 0 {
+		sb.WriteString("\n**New issues**\n")
+		for _, i := range a.Issues {
+			fmt.Fprintf(&sb, "- [#%d %s](%s) by @%s\n", i.Number, i.Title, i.HTMLURL, i.User.Login)
+		}
+	}
+	if len(a.Discussions) > 0 {
+		sb.WriteString("\n**Busiest threads**\n")
+		for _, i := range a.Discussions {
+			fmt.Fprintf(&sb, "- [#%d %s](%s) — %d comments\n", i.Number, i.Title, i.HTMLURL, i.Comments)
+		}
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// summarizeGitHubActivity writes the digest body from the collected activity
+func summarizeGitHubActivity(a githubActivity, frequency string, opts aiOptions) (string, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return "", errAINotConfigured
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Repository: %s\nPeriod: %s digest, since %s\n", a.Repo, frequency, a.Since.UTC().Format(time.RFC1123))
+	// The title, and the body when the loop allows it, as one block
+	item := func(title, body, kind string) string {
+		text := title
+		body = strings.TrimSpace(body)
+		if body != "" && opts.allows(kind) {
+			if len(body) > 300 {
+				body = body[:300] + "..."
+			}
+			text += "\n\n" + body
+		}
+		return untrusted("title and description", text)
+	}
+	for _, r := range a.Releases {
+		fmt.Fprintf(&prompt, "\nRelease %s (%s)\n%s\n", r.TagName, r.HTMLURL, item(r.Name, r.Body, aiContentReleases))
+	}
+	for _, pr := range a.Pulls {
+		fmt.Fprintf(&prompt, "\nMerged PR #%d by @%s (https://github.com/%s/pull/%d)\n%s\n", pr.Number, pr.User.Login, a.Repo, pr.Number, item(pr.Title, pr.Body, aiContentBodies))
+	}
+	for _, i := range a.Issues {
+		fmt.Fprintf(&prompt, "\nNew issue #%d by @%s (%s)\n%s\n", i.Number, i.User.Login, i.HTMLURL, item(i.Title, i.Body, aiContentBodies))
+	}
+	for _, i := range a.Discussions {
+		fmt.Fprintf(&prompt, "\nActive thread #%d, %d comments (%s)\n%s\n", i.Number, i.Comments, i.HTMLURL, untrusted("title", i.Title))
+	}
+
+	system := `You write a short digest of a GitHub repository's recent activity for a
+team chat. Open with one or two sentences on the overall picture, then short
+markdown sections for the kinds of activity given (releases, merged pull
+requests, new issues, active threads), linking items as [#123 title](url).
+Mention only items from the input. No top-level heading.`
+
+	return generateGemini(apiKey, system, prompt.String(), opts)
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"wireloop/internal/cache"
+	"wireloop/internal/github"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// ============================================================================
+// GITHUB LIST CACHE
+// Everyone in a loop browses the same issue and pull request lists, so a
+// page is cached per (loop, list, state, page, per_page) for a short while
+// instead of being fetched from GitHub once per member, and concurrent
+// misses share one call. Issue, pull request and comment webhooks drop the
+// repo's pages at once; the TTL covers repos without webhooks. The repo is
+// still resolved with each caller's token first, so access is checked per
+// member as before.
+// ============================================================================
+
+const githubListTTL = 30 * time.Second
+
+var (
+	githubIssueListCache = cache.New[string, []github.Issue](githubListTTL, 2000)
+	githubPullListCache  = cache.New[string, []github.PullRequest](githubListTTL, 2000)
+	githubListFlight     singleflight.Group
+
+	// githubListGens is bumped per repo ID to drop every cached page of the
+	// repo without knowing the keys
+	githubListGens sync.Map // string -> *atomic.Int64
+)
+
+// githubListKey is the cache key of one page of a loop's list
+func githubListKey(projectID string, repoID int64, opts github.ListOptions) string {
+	gen := int64(0)
+	if g, ok := githubListGens.Load(strconv.FormatInt(repoID, 10)); ok {
+		gen = g.(*atomic.Int64).Load()
+	}
+	return fmt.Sprintf("%s:%d:%s:%s:%s", projectID, gen, opts.State, opts.Page, opts.PerPage)
+}
+
+// dropGitHubLists is the invalidator for "github_lists", keyed by repo ID
+func dropGitHubLists(repoID string) {
+	g, _ := githubListGens.LoadOrStore(repoID, new(atomic.Int64))
+	g.(*atomic.Int64).Add(1)
+}
+
+// invalidateGitHubLists drops the cached lists of every loop on repoID, on
+// this instance and the others
+func invalidateGitHubLists(repoID int64) {
+	lookupInvalidator.Invalidate("github_lists", strconv.FormatInt(repoID, 10))
+}
+
+// cachedIssues lists a page of the repo's issues (pull requests included,
+// as GitHub returns them) through the loop's cache
+func cachedIssues(ctx context.Context, projectID string, repoID int64, token, repoFullName string, opts github.ListOptions) ([]github.Issue, error) {
+	key := githubListKey(projectID, repoID, opts)
+	if items, ok := githubIssueListCache.Get(key); ok {
+		return items, nil
+	}
+	v, err, _ := githubListFlight.Do("issues:"+key, func() (any, error) {
+		// Shared by every waiting caller, so one leaving doesn't fail the rest
+		items, err := github.Default.ListIssues(context.WithoutCancel(ctx), token, repoFullName, opts, nil)
+		if err != nil {
+			return nil, err
+		}
+		githubIssueListCache.Set(key, items)
+		return items, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]github.Issue), nil
+}
+
+// cachedPulls is cachedIssues for pull requests
+func cachedPulls(ctx context.Context, projectID string, repoID int64, token, repoFullName string, opts github.ListOptions) ([]github.PullRequest, error) {
+	key := githubListKey(projectID, repoID, opts)
+	if prs, ok := githubPullListCache.Get(key); ok {
+		return prs, nil
+	}
+	v, err, _ := githubListFlight.Do("pulls:"+key, func() (any, error) {
+		prs, err := github.Default.ListPulls(context.WithoutCancel(ctx), token, repoFullName, opts)
+		if err != nil {
+			return nil, err
+		}
+		githubPullListCache.Set(key, prs)
+		return prs, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]github.PullRequest), nil
+}
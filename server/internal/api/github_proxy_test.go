@@ -0,0 +1,147 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestGitHubProxy(t *testing.T) {
+	it := newIntegration(t)
+	owner := it.user("maintainer", 1)
+	ana := it.user("ana", 2)
+	repo := it.gh.AddRepo("maintainer", "app", false)
+	repo.AddIssue("ana", "Crash on start", "open")
+	repo.AddIssue("ana", "Old bug", "closed")
+	repo.AddPull("ana", "Fix crash", false)
+	repo.AddPull("bob", "Docs", true)
+	it.loop("app", repo, owner, nil)
+
+	const (
+		issuesRoute = "/api/loops/:name/github/issues"
+		pullsRoute  = "/api/loops/:name/github/pulls"
+	)
+	cases := []struct {
+		name   string
+		route  string
+		target string
+		uid    pgtype.UUID
+		status int
+		count  int
+	}{
+		// Open pull requests come back from GitHub's issue list too and are dropped
+		{"open issues", issuesRoute, "/api/loops/app/github/issues", ana.ID, http.StatusOK, 1},
+		{"all issues", issuesRoute, "/api/loops/app/github/issues?state=all", ana.ID, http.StatusOK, 2},
+		{"open pulls", pullsRoute, "/api/loops/app/github/pulls", ana.ID, http.StatusOK, 1},
+		{"all pulls", pullsRoute, "/api/loops/app/github/pulls?state=all", owner.ID, http.StatusOK, 2},
+		{"unknown loop", issuesRoute, "/api/loops/nope/github/issues", ana.ID, http.StatusNotFound, 0},
+		{"anonymous", issuesRoute, "/api/loops/app/github/issues", pgtype.UUID{}, http.StatusUnauthorized, 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := it.h.HandleGetGitHubIssues
+			if tc.route == pullsRoute {
+				handler = it.h.HandleGetGitHubPRs
+			}
+			var out struct {
+				Items    []any  `json:"items"`
+				HasMore  bool   `json:"has_more"`
+				RepoName string `json:"repo_name"`
+			}
+			status := it.do(handler, http.MethodGet, tc.route, tc.target, tc.uid, nil, &out)
+			if status != tc.status {
+				t.Fatalf("status = %d, want %d", status, tc.status)
+			}
+			if status != http.StatusOK {
+				return
+			}
+			if got := len(out.Items); got != tc.count {
+				t.Errorf("got %d items, want %d", got, tc.count)
+			}
+			if out.HasMore {
+				t.Error("has_more on a partial page")
+			}
+			if out.RepoName != "maintainer/app" {
+				t.Errorf("repo_name = %q", out.RepoName)
+			}
+		})
+	}
+}
+
+func TestGitHubProxyRateLimited(t *testing.T) {
+	it := newIntegration(t)
+	owner := it.user("maintainer", 1)
+	repo := it.gh.AddRepo("maintainer", "app", false)
+	it.loop("app", repo, owner, nil)
+
+	// Resolving the repo spends the last request; the listing is refused
+	it.gh.SetRateLimit(1, time.Now().Add(time.Hour))
+	status := it.do(it.h.HandleGetGitHubIssues, http.MethodGet, "/api/loops/:name/github/issues", "/api/loops/app/github/issues", owner.ID, nil, nil)
+	if status != http.StatusTooManyRequests {
+		t.Errorf("status = %d, want 429", status)
+	}
+}
+
+func TestIssueCommentsRoundTrip(t *testing.T) {
+	it := newIntegration(t)
+	owner := it.user("maintainer", 1)
+	ana := it.user("ana", 2)
+	repo := it.gh.AddRepo("maintainer", "app", false)
+	number := repo.AddIssue("ana", "Crash on start", "open")
+	it.loop("app", repo, owner, nil)
+
+	const route = "/api/loops/:name/github/issue/:number/comments"
+	target := fmt.Sprintf("/api/loops/app/github/issue/%d/comments", number)
+	body := PostIssueCommentRequest{Body: "Reproduced on main"}
+	if status := it.do(it.h.HandlePostIssueComment, http.MethodPost, route, target, ana.ID, body, nil); status != http.StatusCreated {
+		t.Fatalf("post: status = %d", status)
+	}
+	if got := it.gh.Comments(repo, number); len(got) != 1 || got[0].User.Login != "ana" {
+		t.Fatalf("GitHub has comments %+v", got)
+	}
+
+	var out struct {
+		Comments []UnifiedComment `json:"comments"`
+	}
+	if status := it.do(it.h.HandleGetIssueComments, http.MethodGet, route, target, owner.ID, nil, &out); status != http.StatusOK {
+		t.Fatalf("list: status = %d", status)
+	}
+	if len(out.Comments) != 1 || out.Comments[0].Body != body.Body {
+		t.Errorf("comments = %+v", out.Comments)
+	}
+}
+
+func TestGitHubProxyCachesLists(t *testing.T) {
+	it := newIntegration(t)
+	owner := it.user("maintainer", 1)
+	ana := it.user("ana", 2)
+	repo := it.gh.AddRepo("maintainer", "app", false)
+	repo.AddIssue("ana", "Crash on start", "open")
+	it.loop("app", repo, owner, nil)
+
+	count := func(uid pgtype.UUID) int {
+		t.Helper()
+		var out IssuesResponse
+		status := it.do(it.h.HandleGetGitHubIssues, http.MethodGet, "/api/loops/:name/github/issues", "/api/loops/app/github/issues", uid, nil, &out)
+		if status != http.StatusOK {
+			t.Fatalf("status = %d", status)
+		}
+		return len(out.Items)
+	}
+	if got := count(owner.ID); got != 1 {
+		t.Fatalf("got %d issues, want 1", got)
+	}
+
+	// Another member gets the cached page until a webhook says it changed
+	repo.AddIssue("ana", "Slow search", "open")
+	if got := count(ana.ID); got != 1 {
+		t.Errorf("after a new issue: got %d issues, want the cached 1", got)
+	}
+	invalidateGitHubLists(repo.ID)
+	if got := count(ana.ID); got != 2 {
+		t.Errorf("after invalidation: got %d issues, want 2", got)
+	}
+}
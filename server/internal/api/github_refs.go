@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ============================================================================
+// GITHUB REFERENCES IN CHAT
+// #123 and pasted commit SHAs are looked up in the loop's repo once the
+// message is stored. What is found is kept with the message, returned in
+// history as github_entities and pushed to the channel as entities_resolved.
+// ============================================================================
+
+const jobResolveGitHubRefs = "resolve_github_refs"
+
+// Lookups by repo ID and ref, shared by every message that mentions them
+var githubRefCache = cache.New[string, GitHubEntity](5*time.Minute, 5000)
+
+// GitHubEntity is a resolved reference in a message
+type GitHubEntity struct {
+	Type   string `json:"type"` // "issue", "pull" or "commit"
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	Number int    `json:"number,omitempty"`
+	SHA    string `json:"sha,omitempty"`
+	Title  string `json:"title"`
+	State  string `json:"state,omitempty"` // open, closed, merged or draft
+	URL    string `json:"url"`
+}
+
+type resolveGitHubRefsPayload struct {
+	MessageID int64  `json:"message_id"`
+	SenderID  string `json:"sender_id"`
+}
+
+// queueGitHubRefs schedules the lookup of a stored message's references, if
+// it has any
+func (h *Handler) queueGitHubRefs(ctx context.Context, msgID int64, content string, senderID string) {
+	if len(github.FindRefs(content)) == 0 {
+		return
+	}
+	if _, err := h.Jobs.Enqueue(ctx, jobResolveGitHubRefs, resolveGitHubRefsPayload{
+		MessageID: msgID,
+		SenderID:  senderID,
+	}, time.Now()); err != nil {
+		log.Printf("[github refs] failed to queue lookup for %d: %v", msgID, err)
+	}
+}
+
+// runResolveGitHubRefs looks up a message's references, stores the ones
+// that exist and tells the channel. Lookups that fail for reasons other
+// than the item not existing are retried with the job.
+func (h *Handler) runResolveGitHubRefs(ctx context.Context, raw json.RawMessage) error {
+	var p resolveGitHubRefsPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	msg, err := h.Queries.GetMessageByID(ctx, p.MessageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // deleted
+	}
+	if err != nil {
+		return err
+	}
+	if msg.IsDeleted.Bool {
+		return nil
+	}
+	refs := github.FindRefs(msg.Content)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	project, err := h.Loops.ByID(ctx, msg.ProjectID)
+	if err != nil {
+		return err
+	}
+	var sender db.User
+	if id, err := utils.StrToUUID(p.SenderID); err == nil {
+		sender, _ = h.getUserByID(ctx, id)
+	}
+	token := h.loopReadToken(ctx, project, sender)
+	if token == "" {
+		return nil
+	}
+	repo, err := github.Default.RepoFullName(ctx, token, project.GithubRepoID)
+	if err != nil {
+		return err
+	}
+
+	var entities []GitHubEntity
+	var lookupErr error
+	for _, ref := range refs {
+		ent, err := githubRefCache.GetOrLoad(fmt.Sprintf("%d:%s", project.GithubRepoID, ref.Key()), func() (GitHubEntity, error) {
+			return lookupGitHubRef(ctx, token, repo, ref)
+		})
+		if errors.Is(err, github.ErrNotFound) {
+			continue // not an issue, PR or commit of this repo
+		}
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		ent.Offset, ent.Length = ref.Offset, ref.Length
+		if err := h.Queries.UpsertMessageGithubEntity(ctx, db.UpsertMessageGithubEntityParams{
+			MessageID:  msg.ID,
+			TextOffset: int32(ent.Offset),
+			TextLength: int32(ent.Length),
+			Kind:       ent.Type,
+			Number:     int32(ent.Number),
+			Sha:        ent.SHA,
+			Title:      ent.Title,
+			State:      ent.State,
+			Url:        ent.URL,
+		}); err != nil {
+			return err
+		}
+		entities = append(entities, ent)
+	}
+
+	if len(entities) > 0 {
+		room := utils.UUIDToStr(msg.ChannelID)
+		h.Events.PublishChannel(room, events.ResolvedEntities{
+			MessageID:      strconv.FormatInt(msg.ID, 10),
+			GitHubEntities: entities,
+		})
+	}
+	if lookupErr != nil {
+		reportGitHubError(nil, lookupErr, "resolve_refs")
+	}
+	return lookupErr
+}
+
+// lookupGitHubRef fetches what ref points at in repo; a #number is an issue
+// or a PR, which GitHub tells apart
+func lookupGitHubRef(ctx context.Context, token, repo string, ref github.Ref) (GitHubEntity, error) {
+	if ref.SHA != "" {
+		commit, err := github.Default.GetCommit(ctx, token, repo, ref.SHA)
+		if err != nil {
+			return GitHubEntity{}, err
+		}
+		title, _, _ := strings.Cut(commit.Commit.Message, "\n")
+		return GitHubEntity{Type: "commit", SHA: commit.SHA, Title: title, URL: commit.HTMLURL}, nil
+	}
+
+	issue, err := github.Default.GetIssue(ctx, token, repo, ref.Number)
+	if err != nil {
+		return GitHubEntity{}, err
+	}
+	ent := GitHubEntity{Type: "issue", Number: issue.Number, Title: issue.Title, State: issue.State, URL: issue.HTMLURL}
+	if issue.PullRequest != nil {
+		ent.Type = "pull"
+		pr, err := github.Default.GetPull(ctx, token, repo, ref.Number)
+		if err != nil {
+			return GitHubEntity{}, err
+		}
+		switch {
+		case pr.MergedAt != nil:
+			ent.State = "merged"
+		case pr.Draft && pr.State == "open":
+			ent.State = "draft"
+		}
+	}
+	return ent, nil
+}
+
+// attachGitHubEntities fills in GitHubEntities for a message list
+func (h *Handler) attachGitHubEntities(ctx context.Context, msgs []MessageResponse) {
+	ids := make([]int64, 0, len(msgs))
+	index := make(map[int64]int, len(msgs))
+	for i, m := range msgs {
+		if m.Deleted {
+			continue
+		}
+		if id, err := strconv.ParseInt(m.ID, 10, 64); err == nil {
+			ids = append(ids, id)
+			index[id] = i
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	rows, err := h.Queries.GetMessageGithubEntities(ctx, ids)
+	if err != nil {
+		log.Printf("[github refs] failed to load entities: %v", err)
+		return
+	}
+	for _, r := range rows {
+		i := index[r.MessageID]
+		msgs[i].GitHubEntities = append(msgs[i].GitHubEntities, GitHubEntity{
+			Type:   r.Kind,
+			Offset: int(r.TextOffset),
+			Length: int(r.TextLength),
+			Number: int(r.Number),
+			SHA:    r.Sha,
+			Title:  r.Title,
+			State:  r.State,
+			URL:    r.Url,
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"errors"
+	"strings"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOOP GITHUB SETTINGS — where automated GitHub posts land
+// ============================================================================
+
+const defaultProductionEnvironment = "production"
+
+type GitHubSettingsResponse struct {
+	AnnouncementsChannelID string `json:"announcements_channel_id"`
+	DeployChannelID        string `json:"deploy_channel_id"`
+	ProductionEnvironment  string `json:"production_environment"`
+	FirstPRChannelID       string `json:"first_pr_channel_id"`
+	FirstPRTemplate        string `json:"first_pr_template"`
+}
+
+// UpdateGitHubSettingsRequest; an empty channel id turns that feed off
+type UpdateGitHubSettingsRequest struct {
+	AnnouncementsChannelID *string `json:"announcements_channel_id"`
+	DeployChannelID        *string `json:"deploy_channel_id"`
+	ProductionEnvironment  *string `json:"production_environment" binding:"omitnil,notblank,max=255"`
+	FirstPRChannelID       *string `json:"first_pr_channel_id"`
+	// Takes {username}, {loop}, {pr_number}, {pr_title} and {pr_url}; empty restores the default
+	FirstPRTemplate *string `json:"first_pr_template" binding:"omitnil,max=2000"`
+}
+
+func githubSettingsToResponse(s db.LoopGithubSetting) GitHubSettingsResponse {
+	return GitHubSettingsResponse{
+		AnnouncementsChannelID: utils.UUIDToStr(s.AnnouncementsChannelID),
+		DeployChannelID:        utils.UUIDToStr(s.DeployChannelID),
+		ProductionEnvironment:  s.ProductionEnvironment,
+		FirstPRChannelID:       utils.UUIDToStr(s.FirstPrChannelID),
+		FirstPRTemplate:        s.FirstPrTemplate,
+	}
+}
+
+// loopGitHubSettings returns the loop's settings, or empty ones if it has none
+func (h *Handler) loopGitHubSettings(c *gin.Context, projectID pgtype.UUID) (db.LoopGithubSetting, error) {
+	s, err := h.Queries.GetLoopGithubSettings(c, projectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return db.LoopGithubSetting{ProjectID: projectID, ProductionEnvironment: defaultProductionEnvironment}, nil
+	}
+	return s, err
+}
+
+// loopChannelParam parses an optional channel id that must belong to the loop.
+// Empty means "none".
+func (h *Handler) loopChannelParam(c *gin.Context, project db.Project, raw string) (pgtype.UUID, bool) {
+	if raw == "" {
+		return pgtype.UUID{}, true
+	}
+	id, err := utils.StrToUUID(raw)
+	if err != nil {
+		problem.Respond(c, 400, "invalid channel id")
+		return pgtype.UUID{}, false
+	}
+	ch, err := h.Queries.GetChannelByID(c, id)
+	if err != nil || ch.ProjectID != project.ID {
+		problem.Respond(c, 400, "channel not found in this loop")
+		return pgtype.UUID{}, false
+	}
+	return id, true
+}
+
+// HandleGetGitHubSettings returns the loop's GitHub integration settings
+func (h *Handler) HandleGetGitHubSettings(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !h.Members.IsMember(c, uid, project.ID) {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+
+	s, err := h.loopGitHubSettings(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load settings")
+		return
+	}
+	c.JSON(200, githubSettingsToResponse(s))
+}
+
+// HandleUpdateGitHubSettings changes the loop's GitHub integration settings (moderators only)
+func (h *Handler) HandleUpdateGitHubSettings(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only moderators can change GitHub settings")
+		return
+	}
+
+	var req UpdateGitHubSettingsRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+
+	s, err := h.loopGitHubSettings(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load settings")
+		return
+	}
+	if req.AnnouncementsChannelID != nil {
+		if s.AnnouncementsChannelID, ok = h.loopChannelParam(c, project, *req.AnnouncementsChannelID); !ok {
+			return
+		}
+	}
+
+	if req.DeployChannelID != nil {
+		if s.DeployChannelID, ok = h.loopChannelParam(c, project, *req.DeployChannelID); !ok {
+			return
+		}
+	}
+	if req.ProductionEnvironment != nil {
+		s.ProductionEnvironment = strings.TrimSpace(*req.ProductionEnvironment)
+	}
+
+	if req.FirstPRChannelID != nil {
+		if s.FirstPrChannelID, ok = h.loopChannelParam(c, project, *req.FirstPRChannelID); !ok {
+			return
+		}
+	}
+	if req.FirstPRTemplate != nil {
+		s.FirstPrTemplate = strings.TrimSpace(*req.FirstPRTemplate)
+	}
+
+	s, err = h.Queries.UpsertLoopGithubSettings(c, db.UpsertLoopGithubSettingsParams{
+		ProjectID:              project.ID,
+		AnnouncementsChannelID: s.AnnouncementsChannelID,
+		DeployChannelID:        s.DeployChannelID,
+		ProductionEnvironment:  s.ProductionEnvironment,
+		FirstPrChannelID:       s.FirstPrChannelID,
+		FirstPrTemplate:        s.FirstPrTemplate,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to save settings")
+		return
+	}
+	c.JSON(200, githubSettingsToResponse(s))
+}
@@ -0,0 +1,252 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/i18n"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// GITHUB ITEM SUBSCRIPTIONS
+// Members follow issues and PRs of the loop's repo. When a webhook reports
+// one closed, merged, reopened or labeled, each subscriber is notified and
+// the change is posted once to every channel a subscription names.
+// ============================================================================
+
+type SubscribeGitHubItemRequest struct {
+	// Also post changes to this channel of the loop
+	ChannelID string `json:"channel_id" binding:"omitempty,uuid"`
+}
+
+type GitHubItemSubscriptionResponse struct {
+	Number    int    `json:"number"`
+	Kind      string `json:"kind"` // issue or pr
+	ChannelID string `json:"channel_id,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+func githubSubscriptionToResponse(s db.GithubItemSubscription) GitHubItemSubscriptionResponse {
+	return GitHubItemSubscriptionResponse{
+		Number:    int(s.GithubNumber),
+		Kind:      s.Kind,
+		ChannelID: utils.UUIDToStr(s.ChannelID),
+		CreatedAt: utils.FormatTime(s.CreatedAt.Time),
+	}
+}
+
+// itemNumberParam parses :number, writing the 400 itself
+func itemNumberParam(c *gin.Context) (int, bool) {
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil || number < 1 {
+		problem.Respond(c, 400, "invalid issue or PR number")
+		return 0, false
+	}
+	return number, true
+}
+
+// HandleGetGitHubSubscriptions lists the issues and PRs the caller follows in the loop
+func (h *Handler) HandleGetGitHubSubscriptions(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	subs, err := h.Queries.GetUserGithubItemSubscriptions(c, db.GetUserGithubItemSubscriptionsParams{
+		ProjectID: project.ID,
+		UserID:    uid,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get subscriptions")
+		return
+	}
+	result := make([]GitHubItemSubscriptionResponse, 0, len(subs))
+	for _, s := range subs {
+		result = append(result, githubSubscriptionToResponse(s))
+	}
+	c.JSON(200, result)
+}
+
+// HandleSubscribeGitHubItem follows issue/PR :number; subscribing again
+// replaces the channel
+func (h *Handler) HandleSubscribeGitHubItem(c *gin.Context) {
+	var req SubscribeGitHubItemRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	number, ok := itemNumberParam(c)
+	if !ok {
+		return
+	}
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	channelID, ok := h.loopChannelParam(c, project, req.ChannelID)
+	if !ok {
+		return
+	}
+
+	item, ok := h.fetchGitHubItem(c, project, uid, number)
+	if !ok {
+		return
+	}
+	kind := "issue"
+	if item.PullRequest != nil {
+		kind = "pr"
+	}
+
+	sub, err := h.Queries.SubscribeGithubItem(c, db.SubscribeGithubItemParams{
+		ProjectID:    project.ID,
+		UserID:       uid,
+		GithubNumber: int32(number),
+		Kind:         kind,
+		ChannelID:    channelID,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to subscribe")
+		return
+	}
+	c.JSON(200, githubSubscriptionToResponse(sub))
+}
+
+// HandleUnsubscribeGitHubItem stops following issue/PR :number
+func (h *Handler) HandleUnsubscribeGitHubItem(c *gin.Context) {
+	number, ok := itemNumberParam(c)
+	if !ok {
+		return
+	}
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	n, err := h.Queries.UnsubscribeGithubItem(c, db.UnsubscribeGithubItemParams{
+		ProjectID:    project.ID,
+		GithubNumber: int32(number),
+		UserID:       uid,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to unsubscribe")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "not subscribed")
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// itemChange describes an issues or pull_request event subscribers hear
+// about; ok is false for the actions they don't
+func itemChange(ev github.ItemEvent) (number int, title, url, change string, ok bool) {
+	switch {
+	case ev.PullRequest != nil:
+		number, title, url = ev.PullRequest.Number, ev.PullRequest.Title, ev.PullRequest.HTMLURL
+		if ev.Action == "closed" && ev.PullRequest.MergedAt != nil {
+			return number, title, url, "merged", true
+		}
+	case ev.Issue != nil:
+		number, title, url = ev.Issue.Number, ev.Issue.Title, ev.Issue.HTMLURL
+	default:
+		return 0, "", "", "", false
+	}
+	switch ev.Action {
+	case "closed", "reopened":
+		return number, title, url, ev.Action, true
+	case "labeled":
+		return number, title, url, ev.Action, ev.Label != nil
+	}
+	return 0, "", "", "", false
+}
+
+// notifyItemSubscribers tells the loop's subscribers of an issue or PR about
+// a change and posts it to their channels. The event's sender is the actor
+// if they're on Wireloop, the loop's owner otherwise; a subscriber who made
+// the change isn't told about it.
+func (h *Handler) notifyItemSubscribers(ctx context.Context, project db.Project, ev github.ItemEvent) {
+	number, title, url, change, ok := itemChange(ev)
+	if !ok {
+		return
+	}
+	subs, err := h.Queries.GetGithubItemSubscribers(ctx, db.GetGithubItemSubscribersParams{
+		ProjectID:    project.ID,
+		GithubNumber: int32(number),
+	})
+	if err != nil {
+		log.Printf("[github-subs] failed to load subscribers of #%d: %v", number, err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	actor, err := h.Queries.GetUserByGithubID(ctx, ev.Sender.ID)
+	if err != nil {
+		if actor, err = h.getUserByID(ctx, project.OwnerID); err != nil {
+			log.Printf("[github-subs] failed to load loop owner: %v", err)
+			return
+		}
+	}
+	login := ev.Sender.Login
+	if login == "" {
+		login = actor.Username
+	}
+	label := ""
+	if ev.Label != nil {
+		label = ev.Label.Name
+	}
+
+	posted := make(map[pgtype.UUID]bool)
+	for _, s := range subs {
+		if s.ChannelID.Valid && !posted[s.ChannelID] {
+			posted[s.ChannelID] = true
+			if _, err := h.postChannelMessage(ctx, project.ID, s.ChannelID, actor, formatItemChange(number, title, url, change, login, label)); err != nil {
+				log.Printf("[github-subs] failed to post #%d change to %s: %v", number, utils.UUIDToStr(s.ChannelID), err)
+			}
+		}
+		if s.GithubID == ev.Sender.ID {
+			continue
+		}
+
+		locale := h.recipientLocale(ctx, s.UserID)
+		var preview string
+		switch change {
+		case "closed":
+			preview = i18n.T(locale, "%s closed #%d %s", login, number, title)
+		case "merged":
+			preview = i18n.T(locale, "%s merged #%d %s", login, number, title)
+		case "reopened":
+			preview = i18n.T(locale, "%s reopened #%d %s", login, number, title)
+		case "labeled":
+			preview = i18n.T(locale, "%s labeled #%d %s as %s", login, number, title, label)
+		}
+		if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+			Type:          "github_item_update",
+			UserID:        s.UserID,
+			ActorID:       actor.ID,
+			ActorUsername: actor.Username,
+			ProjectID:     project.ID,
+			Preview:       notify.Preview(preview),
+			Extra:         map[string]any{"number": number, "url": url, "change": change, "label": label},
+		}); err != nil {
+			log.Printf("[github-subs] failed to notify %s of #%d: %v", s.Username, number, err)
+		}
+	}
+}
+
+func formatItemChange(number int, title, url, change, login, label string) string {
+	what := change
+	if change == "labeled" {
+		what = fmt.Sprintf("labeled `%s`", label)
+	}
+	return fmt.Sprintf("🔔 [#%d %s](%s) was %s by @%s", number, title, url, what, login)
+}
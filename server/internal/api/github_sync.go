@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	githubSyncInterval = 24 * time.Hour   // how stale a profile may get
+	githubSyncPoll     = 10 * time.Minute // how often the refresher looks for stale rows
+	githubSyncBatch    = 50
+)
+
+// errGitHubIdentityMismatch means the stored token now belongs to another GitHub account
+var errGitHubIdentityMismatch = errors.New("GitHub token belongs to a different account")
+
+// isUniqueViolation reports whether err is a Postgres unique constraint error
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// syncGitHubProfile refetches the user's GitHub identity and stores it. Users
+// are matched by github_id, so a GitHub rename just updates the username.
+// replaceCustom also overwrites an uploaded avatar and custom display name.
+func (h *Handler) syncGitHubProfile(ctx context.Context, user db.User, replaceCustom bool) (db.User, error) {
+	updated, err := h.applyGitHubProfile(ctx, user, replaceCustom)
+	if !isUniqueViolation(err) {
+		return updated, err
+	}
+
+	// The new login is still held by another account, which must itself have
+	// been renamed on GitHub since its last sync. Refresh it and try again once.
+	holder, lookupErr := h.Queries.GetUserByUsername(ctx, updated.Username)
+	if lookupErr != nil || holder.ID == user.ID {
+		return updated, err
+	}
+	if _, holderErr := h.applyGitHubProfile(ctx, holder, false); holderErr != nil {
+		log.Printf("[github-sync] could not free username %s held by %s: %v", updated.Username, utils.UUIDToStr(holder.ID), holderErr)
+		return updated, err
+	}
+	return h.applyGitHubProfile(ctx, user, replaceCustom)
+}
+
+// applyGitHubProfile does a single fetch-and-store. On a username conflict it
+// returns the wanted username in the otherwise empty user.
+func (h *Handler) applyGitHubProfile(ctx context.Context, user db.User, replaceCustom bool) (db.User, error) {
+	gh, err := github.Default.GetAuthenticatedUser(ctx, user.AccessToken)
+	if err != nil {
+		return db.User{}, err
+	}
+	if gh.ID != user.GithubID {
+		return db.User{}, errGitHubIdentityMismatch
+	}
+
+	updated, err := h.Queries.SyncUserFromGitHub(ctx, db.SyncUserFromGitHubParams{
+		Username:      gh.Login,
+		ReplaceCustom: replaceCustom,
+		AvatarUrl:     gh.AvatarURL,
+		DisplayName:   pgtype.Text{String: gh.Name, Valid: gh.Name != ""},
+		ID:            user.ID,
+	})
+	if err != nil {
+		return db.User{Username: gh.Login}, err
+	}
+	invalidateUser(user.ID)
+	if updated.Username != user.Username {
+		log.Printf("[github-sync] %s renamed to %s on GitHub", user.Username, updated.Username)
+		h.recordGitHubRename(ctx, user.ID, user.Username)
+	}
+	return updated, nil
+}
+
+// HandleSyncGitHubProfile refreshes the caller's avatar, display name and
+// username from GitHub, replacing any custom avatar or display name
+func (h *Handler) HandleSyncGitHubProfile(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	user, err := h.Queries.GetUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 404, "user not found")
+		return
+	}
+
+	updated, err := h.syncGitHubProfile(c, user, true)
+	switch {
+	case err == nil:
+	case errors.Is(err, github.ErrUnauthorized), errors.Is(err, errGitHubIdentityMismatch):
+		problem.Respond(c, 401, err.Error())
+		return
+	case isUniqueViolation(err):
+		problem.Respond(c, 409, fmt.Sprintf("username %s is still held by another account", updated.Username))
+		return
+	default:
+		log.Printf("[github-sync] sync failed for %s: %v", user.Username, err)
+		problem.Respond(c, 502, "failed to fetch GitHub profile")
+		return
+	}
+
+	c.JSON(200, ProfileResponse{
+		ID:               formatUUID(updated.ID.Bytes),
+		Username:         updated.Username,
+		AvatarURL:        avatarURL(updated.AvatarUrl),
+		DisplayName:      nullableString(updated.DisplayName),
+		ProfileCompleted: updated.ProfileCompleted.Bool,
+		CreatedAt:        utils.FormatTime(updated.CreatedAt.Time),
+	})
+}
+
+// StartGitHubProfileRefresh periodically re-syncs profiles that haven't been
+// refreshed within githubSyncInterval, until ctx is cancelled
+func (h *Handler) StartGitHubProfileRefresh(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(githubSyncPoll)
+		defer ticker.Stop()
+		for {
+			h.refreshStaleGitHubProfiles(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (h *Handler) refreshStaleGitHubProfiles(ctx context.Context) {
+	users, err := h.Queries.ClaimStaleGitHubProfiles(ctx, db.ClaimStaleGitHubProfilesParams{
+		GithubSyncedAt: pgtype.Timestamptz{Time: time.Now().Add(-githubSyncInterval), Valid: true},
+		Limit:          githubSyncBatch,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[github-sync] claim failed: %v", err)
+		}
+		return
+	}
+	for _, u := range users {
+		if ctx.Err() != nil {
+			return
+		}
+		// Expired tokens are expected for users who haven't signed in for a
+		// while; they get picked up again on the next interval
+		if _, err := h.syncGitHubProfile(ctx, u, false); err != nil && !errors.Is(err, github.ErrUnauthorized) {
+			log.Printf("[github-sync] refresh failed for %s: %v", u.Username, err)
+		}
+	}
+}
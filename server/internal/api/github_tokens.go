@@ -0,0 +1,228 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOOP GITHUB TOKENS
+// Reads of a loop's repo (issues, PRs, insights, deployments, comment sync)
+// use the loop's service token when it has one, so every member sees the
+// same thing whatever their own token can reach. Writes attributed to a
+// member — comments, reviews, issues, workflow dispatches — always use that
+// member's token.
+// ============================================================================
+
+const (
+	tokenSourceRequester = "requester"
+	tokenSourceOwner     = "owner"
+	tokenSourceApp       = "app"
+)
+
+var loopGitHubTokenCache = cache.New[string, db.LoopGithubToken](lookupTTL, 2000) // by project ID
+
+type GitHubTokenSettings struct {
+	Source         string `json:"source"`
+	InstallationID int64  `json:"installation_id,omitempty"`
+	// Whether the server can mint GitHub App tokens at all
+	AppAvailable bool `json:"app_available"`
+}
+
+type UpdateGitHubTokenRequest struct {
+	Source         string `json:"source" binding:"required,oneof=requester owner app"`
+	InstallationID int64  `json:"installation_id" binding:"required_if=Source app,omitempty,min=1"`
+}
+
+type GitHubTokenHealth struct {
+	Source string `json:"source"`
+	OK     bool   `json:"ok"`
+	// Why the token can't read the repo; reads then fall back to each
+	// member's own token
+	Error  string              `json:"error,omitempty"`
+	Health *github.TokenHealth `json:"health,omitempty"`
+}
+
+// loopGitHubToken returns the loop's token setting; loops that never set one
+// read with the requester's token
+func (h *Handler) loopGitHubToken(ctx context.Context, projectID pgtype.UUID) (db.LoopGithubToken, error) {
+	return loopGitHubTokenCache.GetOrLoad(utils.UUIDToStr(projectID), func() (db.LoopGithubToken, error) {
+		t, err := h.Queries.GetLoopGithubToken(ctx, projectID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.LoopGithubToken{ProjectID: projectID, Source: tokenSourceRequester}, nil
+		}
+		return t, err
+	})
+}
+
+// invalidateLoopGitHubToken must be called after the loop's token setting changes
+func invalidateLoopGitHubToken(projectID pgtype.UUID) {
+	lookupInvalidator.Invalidate("loop_github_token", utils.UUIDToStr(projectID))
+}
+
+// serviceToken returns the token the loop's setting names, or "" when reads
+// use the requester's
+func (h *Handler) serviceToken(ctx context.Context, project db.Project) (string, string, error) {
+	setting, err := h.loopGitHubToken(ctx, project.ID)
+	if err != nil {
+		return "", tokenSourceRequester, err
+	}
+	switch setting.Source {
+	case tokenSourceOwner:
+		owner, err := h.getUserByID(ctx, project.OwnerID)
+		if err != nil {
+			return "", setting.Source, err
+		}
+		if owner.AccessToken == "" {
+			return "", setting.Source, errors.New("the loop owner has no GitHub token — they need to sign in again")
+		}
+		return owner.AccessToken, setting.Source, nil
+	case tokenSourceApp:
+		token, err := h.GitHubApp.InstallationToken(ctx, setting.InstallationID.Int64)
+		return token, setting.Source, err
+	}
+	return "", tokenSourceRequester, nil
+}
+
+// loopReadToken picks the token for reading the loop's repo: its service
+// token, or the requester's when it has none or the service token can't be
+// had. Empty when neither exists.
+func (h *Handler) loopReadToken(ctx context.Context, project db.Project, requester db.User) string {
+	token, source, err := h.serviceToken(ctx, project)
+	if err != nil {
+		log.Printf("[GitHub] %s token for loop %s unavailable, using the requester's: %v", source, project.Name, err)
+	}
+	if token == "" {
+		return requester.AccessToken
+	}
+	return token
+}
+
+// HandleGetGitHubToken returns which token reads the loop's repo (moderators only)
+func (h *Handler) HandleGetGitHubToken(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only moderators can view the GitHub token setting")
+		return
+	}
+
+	setting, err := h.loopGitHubToken(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load settings")
+		return
+	}
+	c.JSON(200, GitHubTokenSettings{
+		Source:         setting.Source,
+		InstallationID: setting.InstallationID.Int64,
+		AppAvailable:   h.GitHubApp != nil,
+	})
+}
+
+// HandleUpdateGitHubToken chooses which token reads the loop's repo. Only the
+// owner may, since "owner" lends their token to every member's reads.
+func (h *Handler) HandleUpdateGitHubToken(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "change the GitHub token")
+	if !ok {
+		return
+	}
+	uid, _ := utils.GetUserIdFromContext(c)
+
+	var req UpdateGitHubTokenRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	var installationID pgtype.Int8
+	if req.Source == tokenSourceApp {
+		if h.GitHubApp == nil {
+			problem.Respond(c, 400, "no GitHub App is configured on this server")
+			return
+		}
+		installationID = pgtype.Int8{Int64: req.InstallationID, Valid: true}
+	}
+
+	setting, err := h.Queries.SetLoopGithubToken(c, db.SetLoopGithubTokenParams{
+		ProjectID:      project.ID,
+		Source:         req.Source,
+		InstallationID: installationID,
+		UpdatedBy:      uid,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to save settings")
+		return
+	}
+	invalidateLoopGitHubToken(project.ID)
+
+	c.JSON(200, GitHubTokenSettings{
+		Source:         setting.Source,
+		InstallationID: setting.InstallationID.Int64,
+		AppAvailable:   h.GitHubApp != nil,
+	})
+}
+
+// HandleCheckGitHubToken tries the loop's read token against its repo and
+// reports what it can do and how much rate limit it has left (moderators
+// only). With the requester setting, the caller's own token is checked.
+func (h *Handler) HandleCheckGitHubToken(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	ctx := c.Request.Context()
+	project, err := h.Loops.ByName(ctx, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only moderators can check the GitHub token")
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+
+	token, source, err := h.serviceToken(ctx, project)
+	if err == nil && source == tokenSourceRequester {
+		user, uErr := h.getUserByID(ctx, uid)
+		if uErr != nil {
+			problem.Respond(c, 500, "failed to get user")
+			return
+		}
+		token = user.AccessToken
+		if token == "" {
+			err = errors.New("you have no GitHub token — sign in again")
+		}
+	}
+	result := GitHubTokenHealth{Source: source}
+	if err == nil {
+		result.Health, err = github.Default.CheckRepoToken(ctx, token, project.GithubRepoID)
+	}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.OK = true
+	}
+	c.JSON(200, result)
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/gatekeeper"
+	"wireloop/internal/github"
+	"wireloop/internal/storage"
+	"wireloop/internal/testkit"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// integration is a Handler on its own migrated database, talking to a fake
+// GitHub. Tests using it skip without testkit.DatabaseEnv.
+type integration struct {
+	t  *testing.T
+	h  *Handler
+	gh *testkit.GitHub
+}
+
+func newIntegration(t *testing.T) *integration {
+	t.Helper()
+	queries, pool := testkit.DB(t)
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := NewHandler(queries, pool, chat.NewHub(nil), Config{Storage: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gh := testkit.NewGitHub(t)
+	prevClient, prevGate := github.Default, gate
+	github.Default = gh.Client()
+	gate = gatekeeper.NewWith(github.Default)
+	gate.UseStarSource(h.cachedStarCount)
+	t.Cleanup(func() {
+		github.Default, gate = prevClient, prevGate
+	})
+	return &integration{t: t, h: h, gh: gh}
+}
+
+// user signs login up, with a GitHub token the fake accepts
+func (it *integration) user(login string, githubID int64) db.User {
+	it.t.Helper()
+	token := login + "-token"
+	it.gh.AddUser(login, token)
+	u, err := it.h.Queries.UpsertUser(context.Background(), db.UpsertUserParams{
+		GithubID:    githubID,
+		Username:    login,
+		AccessToken: token,
+	})
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	return u
+}
+
+// loop creates a loop on repo, owned by owner, with rules as
+// criteria type -> threshold
+func (it *integration) loop(name string, repo *testkit.Repo, owner db.User, rules map[gatekeeper.CriteriaType]string) db.Project {
+	it.t.Helper()
+	ctx := context.Background()
+	p, err := it.h.Queries.CreateProject(ctx, db.CreateProjectParams{
+		GithubRepoID: repo.ID,
+		Name:         name,
+		OwnerID:      owner.ID,
+	})
+	if err != nil {
+		it.t.Fatal(err)
+	}
+	if err := it.h.Queries.AddMembership(ctx, db.AddMembershipParams{
+		UserID:    owner.ID,
+		ProjectID: p.ID,
+		Role:      pgtype.Text{String: "owner", Valid: true},
+	}); err != nil {
+		it.t.Fatal(err)
+	}
+	for criteria, threshold := range rules {
+		if _, err := it.h.Queries.CreateRule(ctx, db.CreateRuleParams{
+			ProjectID:    p.ID,
+			CriteriaType: string(criteria),
+			Threshold:    threshold,
+		}); err != nil {
+			it.t.Fatal(err)
+		}
+	}
+	return p
+}
+
+// do runs one request through handler, mounted at route, as uid (anonymous
+// when invalid), and decodes the JSON response into out when given
+func (it *integration) do(handler gin.HandlerFunc, method, route, target string, uid pgtype.UUID, body any, out any) int {
+	it.t.Helper()
+	r := gin.New()
+	r.Handle(method, route, func(c *gin.Context) {
+		if uid.Valid {
+			c.Set("user_id", uid)
+		}
+	}, handler)
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			it.t.Fatal(err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if out != nil && w.Code < 300 {
+		if err := json.Unmarshal(w.Body.Bytes(), out); err != nil {
+			it.t.Fatalf("%s %s: decode %s: %v", method, target, w.Body, err)
+		}
+	}
+	if w.Code >= 300 {
+		it.t.Logf("%s %s -> %d %s", method, target, w.Code, w.Body)
+	}
+	return w.Code
+}
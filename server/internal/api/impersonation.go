@@ -0,0 +1,191 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/auth"
+	"wireloop/internal/db"
+	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// SUPPORT-MODE IMPERSONATION
+// Platform admins (observability basic auth) can mint a short-lived token
+// that sees Wireloop as a given user. The auth middleware rejects writes made
+// with it; ImpersonationAudit records every request, and the user can review
+// sessions on their own account afterwards.
+// ============================================================================
+
+const (
+	defaultImpersonationTTL = 15 * time.Minute
+	maxImpersonationTTL     = time.Hour
+)
+
+type ImpersonateRequest struct {
+	UserID     string `json:"user_id" binding:"required_without=Username,omitempty,uuid"`
+	Username   string `json:"username" binding:"required_without=UserID,max=39"`
+	Reason     string `json:"reason" binding:"required,notblank,max=500"`
+	TTLMinutes int    `json:"ttl_minutes" binding:"min=0"`
+}
+
+// HandleAdminImpersonate starts a read-only support session for a user
+func (h *Handler) HandleAdminImpersonate(c *gin.Context) {
+	var req ImpersonateRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+
+	var user db.User
+	var err error
+	if req.UserID != "" {
+		id, _ := utils.StrToUUID(req.UserID)
+		user, err = h.Queries.GetUserByID(c, id)
+	} else {
+		user, err = h.Queries.GetUserByUsername(c, req.Username)
+	}
+	if err != nil {
+		problem.Respond(c, 404, "user not found")
+		return
+	}
+
+	ttl := defaultImpersonationTTL
+	if req.TTLMinutes > 0 {
+		ttl = min(time.Duration(req.TTLMinutes)*time.Minute, maxImpersonationTTL)
+	}
+	expiresAt := time.Now().Add(ttl)
+
+	admin, _, _ := c.Request.BasicAuth()
+	session, err := h.Queries.CreateImpersonationSession(c, db.CreateImpersonationSessionParams{
+		UserID:    user.ID,
+		Admin:     admin,
+		Reason:    req.Reason,
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to start session")
+		return
+	}
+
+	token, err := auth.GenerateImpersonationJWT(user.ID, utils.UUIDToStr(session.ID), expiresAt)
+	if err != nil {
+		problem.Respond(c, 500, "failed to generate token")
+		return
+	}
+
+	log.Printf("[impersonation] %s started a session as %s: %s", admin, user.Username, req.Reason)
+	c.JSON(201, gin.H{
+		"session_id": utils.UUIDToStr(session.ID),
+		"user_id":    utils.UUIDToStr(user.ID),
+		"username":   user.Username,
+		"token":      token,
+		"read_only":  true,
+		"expires_at": utils.FormatTime(expiresAt),
+	})
+}
+
+// ImpersonationAudit records every request made with an impersonation token.
+// Attach it after the auth middleware.
+func (h *Handler) ImpersonationAudit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sessionID, ok := middleware.ImpersonationSession(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		c.Header("X-Wireloop-Impersonation", sessionID)
+		c.Next()
+
+		id, err := utils.StrToUUID(sessionID)
+		if err != nil {
+			return
+		}
+		method, path, status := c.Request.Method, c.Request.URL.Path, int32(c.Writer.Status())
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := h.Queries.LogImpersonatedRequest(ctx, db.LogImpersonatedRequestParams{
+				SessionID: id,
+				Method:    method,
+				Path:      path,
+				Status:    status,
+			}); err != nil {
+				log.Printf("[impersonation] failed to log request: %v", err)
+			}
+		}()
+	}
+}
+
+// HandleGetImpersonations lists support sessions that acted as the caller
+func (h *Handler) HandleGetImpersonations(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	rows, err := h.Queries.GetUserImpersonationSessions(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get sessions")
+		return
+	}
+
+	result := make([]gin.H, len(rows))
+	for i, r := range rows {
+		result[i] = gin.H{
+			"id":            utils.UUIDToStr(r.ID),
+			"admin":         r.Admin,
+			"reason":        r.Reason,
+			"request_count": r.RequestCount,
+			"expires_at":    utils.FormatTime(r.ExpiresAt.Time),
+			"created_at":    utils.FormatTime(r.CreatedAt.Time),
+		}
+	}
+
+	c.JSON(200, result)
+}
+
+// HandleGetImpersonationRequests lists what a support session looked at
+func (h *Handler) HandleGetImpersonationRequests(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	sessionID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid session id")
+		return
+	}
+	session, err := h.Queries.GetImpersonationSession(c, sessionID)
+	if err != nil || session.UserID != uid {
+		problem.Respond(c, 404, "session not found")
+		return
+	}
+
+	rows, err := h.Queries.GetImpersonationRequests(c, sessionID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get requests")
+		return
+	}
+
+	result := make([]gin.H, len(rows))
+	for i, r := range rows {
+		result[i] = gin.H{
+			"id":         strconv.FormatInt(r.ID, 10),
+			"method":     r.Method,
+			"path":       r.Path,
+			"status":     r.Status,
+			"created_at": utils.FormatTime(r.CreatedAt.Time),
+		}
+	}
+
+	c.JSON(200, result)
+}
@@ -0,0 +1,331 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// INCOMING WEBHOOKS
+// A channel-scoped URL with the token in the path, for CI jobs and cron
+// scripts that only need to post a notice. Each hook posts as its own bot
+// user (<name>[bot], a name no GitHub login can take), so messages render
+// and load like any other. Owners and moderators create and revoke them.
+// ============================================================================
+
+const (
+	incomingWebhookPrefix  = "wlh_"
+	incomingWebhookLimit   = 30 // posts per minute per hook
+	maxIncomingWebhookName = maxUsernameLength - len("[bot]")
+)
+
+// Status markers a payload can lead its message with
+var incomingWebhookStatus = map[string]string{
+	"success": "✅",
+	"failure": "❌",
+	"warning": "⚠️",
+	"info":    "ℹ️",
+}
+
+type IncomingWebhookResponse struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	ChannelID   string  `json:"channel_id"`
+	ChannelName string  `json:"channel_name"`
+	BotUsername string  `json:"bot_username"`
+	BotAvatar   string  `json:"bot_avatar"`
+	Prefix      string  `json:"prefix"`
+	LastUsedAt  *string `json:"last_used_at"`
+	CreatedAt   string  `json:"created_at"`
+	RevokedAt   *string `json:"revoked_at"`
+	URL         string  `json:"url,omitempty"` // only in the create response
+}
+
+type CreateIncomingWebhookRequest struct {
+	ChannelID string `json:"channel_id" binding:"required,uuid"`
+	Name      string `json:"name" binding:"required,notblank"`
+	AvatarURL string `json:"avatar_url" binding:"omitempty,url,max=500"`
+}
+
+type IncomingWebhookPayload struct {
+	Text   string `json:"text" binding:"max=8000"`
+	Title  string `json:"title" binding:"max=200"`
+	URL    string `json:"url" binding:"omitempty,url,startswith=https://"`
+	Status string `json:"status" binding:"omitempty,oneof=success failure warning info"`
+}
+
+func incomingWebhookToResponse(w db.ListIncomingWebhooksRow) IncomingWebhookResponse {
+	resp := IncomingWebhookResponse{
+		ID:          utils.UUIDToStr(w.ID),
+		Name:        w.Name,
+		ChannelID:   utils.UUIDToStr(w.ChannelID),
+		ChannelName: w.ChannelName,
+		BotUsername: w.BotUsername,
+		BotAvatar:   mediaURL(w.BotAvatar.String),
+		Prefix:      w.Prefix,
+		CreatedAt:   utils.FormatTime(w.CreatedAt.Time),
+	}
+	if w.LastUsedAt.Valid {
+		t := utils.FormatTime(w.LastUsedAt.Time)
+		resp.LastUsedAt = &t
+	}
+	if w.RevokedAt.Valid {
+		t := utils.FormatTime(w.RevokedAt.Time)
+		resp.RevokedAt = &t
+	}
+	return resp
+}
+
+// formatIncomingWebhook renders a payload as message markdown
+func formatIncomingWebhook(p IncomingWebhookPayload) string {
+	var parts []string
+	head := strings.TrimSpace(p.Title)
+	if head != "" {
+		if p.URL != "" {
+			head = "[" + head + "](" + p.URL + ")"
+		}
+		head = "**" + head + "**"
+	}
+	if marker := incomingWebhookStatus[p.Status]; marker != "" {
+		head = strings.TrimSpace(marker + " " + head)
+	}
+	if head != "" {
+		parts = append(parts, head)
+	}
+	if text := strings.TrimSpace(p.Text); text != "" {
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n")
+}
+
+// incomingWebhookModerator resolves the loop and checks the caller may
+// manage its hooks
+func (h *Handler) incomingWebhookModerator(c *gin.Context) (db.Project, pgtype.UUID, bool) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return project, uid, false
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only the owner and moderators can manage incoming webhooks")
+		return project, uid, false
+	}
+	return project, uid, true
+}
+
+// HandleGetIncomingWebhooks lists the loop's hooks, revoked ones last
+func (h *Handler) HandleGetIncomingWebhooks(c *gin.Context) {
+	project, _, ok := h.incomingWebhookModerator(c)
+	if !ok {
+		return
+	}
+	hooks, err := h.Queries.ListIncomingWebhooks(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get incoming webhooks")
+		return
+	}
+	result := make([]IncomingWebhookResponse, len(hooks))
+	for i, w := range hooks {
+		result[i] = incomingWebhookToResponse(w)
+	}
+	c.JSON(200, gin.H{"webhooks": result})
+}
+
+// HandleCreateIncomingWebhook creates a hook and its bot user; the response
+// is the only time the URL is shown
+func (h *Handler) HandleCreateIncomingWebhook(c *gin.Context) {
+	project, uid, ok := h.incomingWebhookModerator(c)
+	if !ok {
+		return
+	}
+	var req CreateIncomingWebhookRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if reason := validateUsername(req.Name); reason != "" {
+		problem.Respond(c, 400, strings.Replace(reason, "username", "name", 1))
+		return
+	}
+	if len(req.Name) > maxIncomingWebhookName {
+		problem.Respond(c, 400, "name must be at most 34 characters")
+		return
+	}
+
+	channelID, _ := utils.StrToUUID(req.ChannelID)
+	channel, err := h.Queries.GetChannelByID(c, channelID)
+	if err != nil || channel.ProjectID != project.ID {
+		problem.Respond(c, 404, "channel not found")
+		return
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		problem.Respond(c, 500, "failed to create incoming webhook")
+		return
+	}
+	raw := incomingWebhookPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	// The bot only exists for its webhook, so they are created together
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "failed to create incoming webhook")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	bot, err := qtx.CreateBotUser(c, db.CreateBotUserParams{
+		Username:  req.Name + "[bot]",
+		AvatarUrl: pgtype.Text{String: req.AvatarURL, Valid: req.AvatarURL != ""},
+	})
+	if isUniqueViolation(err) {
+		problem.Respond(c, 409, "a bot named "+req.Name+"[bot] already exists")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to create incoming webhook")
+		return
+	}
+	hook, err := qtx.CreateIncomingWebhook(c, db.CreateIncomingWebhookParams{
+		ProjectID: project.ID,
+		ChannelID: channel.ID,
+		BotID:     bot.ID,
+		Name:      req.Name,
+		Prefix:    raw[:len(incomingWebhookPrefix)+6],
+		TokenHash: hashCredential(raw),
+		CreatedBy: uid,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to create incoming webhook")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to create incoming webhook")
+		return
+	}
+
+	resp := incomingWebhookToResponse(db.ListIncomingWebhooksRow{
+		ID:          hook.ID,
+		ProjectID:   hook.ProjectID,
+		ChannelID:   hook.ChannelID,
+		BotID:       hook.BotID,
+		Name:        hook.Name,
+		Prefix:      hook.Prefix,
+		CreatedAt:   hook.CreatedAt,
+		ChannelName: channel.Name,
+		BotUsername: bot.Username,
+		BotAvatar:   bot.AvatarUrl,
+	})
+	resp.URL = strings.TrimRight(os.Getenv("BACKEND_URL"), "/") + "/api/hooks/" + raw
+	c.JSON(201, resp)
+}
+
+// HandleDeleteIncomingWebhook revokes a hook. The bot user stays so its
+// past messages still load.
+func (h *Handler) HandleDeleteIncomingWebhook(c *gin.Context) {
+	project, _, ok := h.incomingWebhookModerator(c)
+	if !ok {
+		return
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid webhook id")
+		return
+	}
+	n, err := h.Queries.RevokeIncomingWebhook(c, db.RevokeIncomingWebhookParams{ID: id, ProjectID: project.ID})
+	if err != nil {
+		problem.Respond(c, 500, "failed to revoke incoming webhook")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "incoming webhook not found")
+		return
+	}
+	c.JSON(200, gin.H{"message": "Incoming webhook revoked"})
+}
+
+// HandleIncomingWebhook posts a payload to the hook's channel. The token is
+// the only credential. Message filters are skipped: a moderator set the
+// hook up, and a held or blocked notice would just be lost.
+func (h *Handler) HandleIncomingWebhook(c *gin.Context) {
+	hook, err := h.Queries.GetActiveIncomingWebhookByHash(c, hashCredential(c.Param("token")))
+	if errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 404, "unknown or revoked webhook")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to check webhook")
+		return
+	}
+
+	hookID := utils.UUIDToStr(hook.ID)
+	if lc, err := apiKeyLimiter(incomingWebhookLimit).Get(c, "hook:"+hookID); err == nil && lc.Reached {
+		problem.RespondCode(c, http.StatusTooManyRequests, "rate_limit_exceeded", "This webhook is over its rate limit", gin.H{
+			"retry_after": time.Until(time.Unix(lc.Reset, 0)).Round(time.Second).String(),
+		})
+		return
+	}
+
+	var payload IncomingWebhookPayload
+	if !bindStrictJSON(c, &payload) {
+		return
+	}
+	if strings.TrimSpace(payload.Text) == "" && strings.TrimSpace(payload.Title) == "" {
+		problem.Respond(c, 400, "text or title is required")
+		return
+	}
+	if payload.URL != "" {
+		if u, err := url.Parse(payload.URL); err != nil || u.Host == "" {
+			problem.Respond(c, 400, "url must be an absolute https URL")
+			return
+		}
+	}
+	content := formatIncomingWebhook(payload)
+	if h.messageTooLong(content) != "" {
+		h.respondMessageTooLong(c, content)
+		return
+	}
+	if link, linked := h.channelGitHubLink(c, hook.ChannelID); linked && link.ArchivedAt.Valid {
+		problem.Respond(c, 403, "this channel was archived when its GitHub "+link.Kind+" closed")
+		return
+	}
+	if h.loopArchived(c, hook.ProjectID) {
+		problem.Respond(c, 403, loopArchivedReason)
+		return
+	}
+	if err := h.Quotas.UseMessage(c, hook.ProjectID); err != nil {
+		respondQuota(c, err)
+		return
+	}
+
+	bot, err := h.getUserByID(c, hook.BotID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to post message")
+		return
+	}
+	msgID, err := h.postChannelMessage(c, hook.ProjectID, hook.ChannelID, bot, content)
+	if err != nil {
+		problem.Respond(c, 500, "failed to post message")
+		return
+	}
+	if err := h.Queries.TouchIncomingWebhook(c, hook.ID); err != nil {
+		log.Printf("[incoming-webhooks] failed to update last used for %s: %v", hookID, err)
+	}
+	c.JSON(200, gin.H{"message_id": strconv.FormatInt(msgID, 10)})
+}
@@ -3,17 +3,23 @@ package api
 import (
 	"context"
 	"log"
+	"strings"
 	"sync"
 	"time"
 	utils "wireloop/internal"
 	"wireloop/internal/db"
+	"wireloop/internal/members"
+	"wireloop/internal/messages"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // InitResponse aggregates all data needed for app initialization in ONE request
 type InitResponse struct {
 	Profile     *ProfileData     `json:"profile"`
+	Workspaces  []WorkspaceData  `json:"workspaces"`
 	Projects    []ProjectData    `json:"projects"`
 	Memberships []MembershipData `json:"memberships"`
 	Timing      map[string]int64 `json:"_timing,omitempty"` // Debug timing info
@@ -32,14 +38,54 @@ type ProjectData struct {
 	ID           string `json:"id"`
 	Name         string `json:"name"`
 	GithubRepoID int64  `json:"github_repo_id"`
+	WorkspaceID  string `json:"workspace_id,omitempty"`
 	CreatedAt    string `json:"created_at"`
 }
 
+func projectToData(p db.Project) ProjectData {
+	data := ProjectData{
+		ID:           utils.UUIDToStr(p.ID),
+		Name:         p.Name,
+		GithubRepoID: p.GithubRepoID,
+		CreatedAt:    utils.FormatTime(p.CreatedAt.Time),
+	}
+	if p.WorkspaceID.Valid {
+		data.WorkspaceID = utils.UUIDToStr(p.WorkspaceID)
+	}
+	return data
+}
+
 type MembershipData struct {
-	LoopID   string `json:"loop_id"`
-	LoopName string `json:"loop_name"`
-	Role     string `json:"role"`
-	JoinedAt string `json:"joined_at"`
+	LoopID      string `json:"loop_id"`
+	LoopName    string `json:"loop_name"`
+	WorkspaceID string `json:"workspace_id,omitempty"`
+	Role        string `json:"role"`
+	JoinedAt    string `json:"joined_at"`
+	IsFavorite  bool   `json:"is_favorite"`
+	SortOrder   *int   `json:"sort_order"` // null = not manually placed
+	Collapsed   bool   `json:"collapsed"`
+	// Latest message and unread counts per channel the member can see
+	Channels []ChannelActivity `json:"channels"`
+}
+
+func membershipToData(m db.GetUserMembershipsRow) MembershipData {
+	data := MembershipData{
+		LoopID:     utils.UUIDToStr(m.ProjectID),
+		LoopName:   m.ProjectName,
+		Role:       m.Role.String,
+		JoinedAt:   utils.FormatTime(m.JoinedAt.Time),
+		IsFavorite: m.IsFavorite,
+		Collapsed:  m.SidebarCollapsed,
+		Channels:   []ChannelActivity{},
+	}
+	if m.WorkspaceID.Valid {
+		data.WorkspaceID = utils.UUIDToStr(m.WorkspaceID)
+	}
+	if m.SortOrder.Valid {
+		n := int(m.SortOrder.Int32)
+		data.SortOrder = &n
+	}
+	return data
 }
 
 // HandleInit returns ALL data needed for app initialization in a single request
@@ -50,7 +96,7 @@ func (h *Handler) HandleInit(c *gin.Context) {
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
@@ -59,15 +105,19 @@ func (h *Handler) HandleInit(c *gin.Context) {
 		profile     db.GetUserProfileRow
 		projects    []db.Project
 		memberships []db.GetUserMembershipsRow
+		workspaces  []db.GetUserWorkspacesRow
+		activity    []db.GetChannelActivityRow
 		profileErr  error
 		projectsErr error
 		membersErr  error
+		spacesErr   error
+		activityErr error
 	)
 
 	ctx := c.Request.Context()
 
 	// Launch all queries in parallel using goroutines
-	wg.Add(3)
+	wg.Add(5)
 
 	go func() {
 		defer wg.Done()
@@ -90,12 +140,29 @@ func (h *Handler) HandleInit(c *gin.Context) {
 		timing["memberships_ms"] = time.Since(t).Milliseconds()
 	}()
 
+	go func() {
+		defer wg.Done()
+		t := time.Now()
+		workspaces, spacesErr = h.Queries.GetUserWorkspaces(ctx, uid)
+		timing["workspaces_ms"] = time.Since(t).Milliseconds()
+	}()
+
+	go func() {
+		defer wg.Done()
+		t := time.Now()
+		activity, activityErr = h.Queries.GetChannelActivity(ctx, db.GetChannelActivityParams{
+			UnreadCap: db.ChannelUnreadCap,
+			UserID:    uid,
+		})
+		timing["activity_ms"] = time.Since(t).Milliseconds()
+	}()
+
 	wg.Wait()
 
 	// Check for errors
 	if profileErr != nil {
 		log.Printf("[Init] Profile error: %v", profileErr)
-		c.JSON(500, gin.H{"error": "failed to get profile"})
+		problem.Respond(c, 500, "failed to get profile")
 		return
 	}
 
@@ -104,44 +171,62 @@ func (h *Handler) HandleInit(c *gin.Context) {
 		Profile: &ProfileData{
 			ID:               utils.UUIDToStr(profile.ID),
 			Username:         profile.Username,
-			AvatarURL:        profile.AvatarUrl.String,
+			AvatarURL:        mediaURL(profile.AvatarUrl.String),
 			DisplayName:      profile.DisplayName.String,
 			ProfileCompleted: profile.ProfileCompleted.Bool,
-			CreatedAt:        profile.CreatedAt.Time.Format(time.RFC3339),
+			CreatedAt:        utils.FormatTime(profile.CreatedAt.Time),
 		},
+		Workspaces:  make([]WorkspaceData, 0),
 		Projects:    make([]ProjectData, 0),
 		Memberships: make([]MembershipData, 0),
 	}
 
+	if spacesErr == nil {
+		for _, w := range workspaces {
+			resp.Workspaces = append(resp.Workspaces, workspaceToData(db.Workspace{
+				ID:             w.ID,
+				Slug:           w.Slug,
+				Name:           w.Name,
+				BillingOwnerID: w.BillingOwnerID,
+				Personal:       w.Personal,
+				DefaultRole:    w.DefaultRole,
+				CreatedAt:      w.CreatedAt,
+			}, w.Role))
+		}
+	}
+
 	if projectsErr == nil && projects != nil {
 		for _, p := range projects {
-			resp.Projects = append(resp.Projects, ProjectData{
-				ID:           utils.UUIDToStr(p.ID),
-				Name:         p.Name,
-				GithubRepoID: p.GithubRepoID,
-				CreatedAt:    p.CreatedAt.Time.Format(time.RFC3339),
-			})
+			resp.Projects = append(resp.Projects, projectToData(p))
 		}
 	}
 
 	if membersErr == nil && memberships != nil {
+		byLoop := make(map[pgtype.UUID][]ChannelActivity)
+		if activityErr == nil {
+			for _, a := range activity {
+				byLoop[a.ProjectID] = append(byLoop[a.ProjectID], channelActivityToData(a))
+			}
+		} else {
+			log.Printf("[Init] Channel activity error: %v", activityErr)
+		}
 		for _, m := range memberships {
-			resp.Memberships = append(resp.Memberships, MembershipData{
-				LoopID:   utils.UUIDToStr(m.ProjectID),
-				LoopName: m.ProjectName,
-				Role:     m.Role.String,
-				JoinedAt: m.JoinedAt.Time.Format(time.RFC3339),
-			})
+			data := membershipToData(m)
+			if chans, ok := byLoop[m.ProjectID]; ok {
+				data.Channels = chans
+			}
+			resp.Memberships = append(resp.Memberships, data)
 		}
 	}
 
+	tagged := resp // the ETag leaves out timing
 	timing["total_ms"] = time.Since(start).Milliseconds()
 	resp.Timing = timing
 
 	log.Printf("[Init] Completed in %dms (profile: %dms, projects: %dms, memberships: %dms)",
 		timing["total_ms"], timing["profile_ms"], timing["projects_ms"], timing["memberships_ms"])
 
-	c.JSON(200, resp)
+	respondJSONWithETag(c, tagged, resp)
 }
 
 // LoopFullResponse aggregates loop details + messages in ONE request
@@ -150,23 +235,28 @@ type LoopFullResponse struct {
 	Name          string            `json:"name"`
 	OwnerID       string            `json:"owner_id"`
 	CreatedAt     string            `json:"created_at"`
+	Workspace     *WorkspaceData    `json:"workspace,omitempty"`
 	IsMember      bool              `json:"is_member"`
+	Role          string            `json:"role,omitempty"`
 	Members       []gin.H           `json:"members"`
 	Channels      []ChannelResponse `json:"channels"`
 	ActiveChannel *ChannelResponse  `json:"active_channel,omitempty"`
-	Messages      []MessageResponse `json:"messages"`
-	Timing        map[string]int64  `json:"_timing,omitempty"`
+	// Set while the linked repo is archived or deleted on GitHub
+	Mirror   *LoopMirrorResponse `json:"mirror,omitempty"`
+	Messages []MessageResponse   `json:"messages"`
+	Timing   map[string]int64    `json:"_timing,omitempty"`
 }
 
 // HandleLoopFull returns loop details + members + channels + messages in a single request
-// This eliminates the sequential loading of loop details then messages
+// After the project lookup, everything else is fetched in one pgx batch round trip
+// Also served under /workspaces/:slug, where the loop must be in that workspace
 func (h *Handler) HandleLoopFull(c *gin.Context) {
 	start := time.Now()
 	timing := make(map[string]int64)
 
 	name := c.Param("name")
 	if name == "" {
-		c.JSON(400, gin.H{"error": "loop name required"})
+		problem.Respond(c, 400, "loop name required")
 		return
 	}
 
@@ -175,7 +265,7 @@ func (h *Handler) HandleLoopFull(c *gin.Context) {
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
@@ -183,74 +273,84 @@ func (h *Handler) HandleLoopFull(c *gin.Context) {
 
 	// First get the project (needed for other queries)
 	t := time.Now()
-	project, err := h.Queries.GetProjectByName(ctx, name)
+	project, err := h.Loops.ByName(ctx, name)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 	timing["project_ms"] = time.Since(t).Milliseconds()
 
-	var (
-		wg            sync.WaitGroup
-		members       []db.GetLoopMembersRow
-		channels      []db.GetChannelsByProjectRow
-		messages      []db.GetMessagesRow
-		isMember      bool
-		membersErr    error
-		channelsErr   error
-		messagesErr   error
-		activeChannel *db.GetChannelsByProjectRow
-	)
-
-	// Launch parallel queries (except messages which depends on channel)
-	wg.Add(3)
+	var workspace *WorkspaceData
+	if project.WorkspaceID.Valid {
+		if w, err := h.getWorkspaceByID(ctx, project.WorkspaceID); err == nil {
+			data := workspaceToData(w, "")
+			workspace = &data
+		}
+	}
+	if slug := c.Param("slug"); slug != "" && (workspace == nil || workspace.Slug != strings.ToLower(slug)) {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
 
-	go func() {
-		defer wg.Done()
-		t := time.Now()
-		members, membersErr = h.Queries.GetLoopMembers(ctx, project.ID)
-		timing["members_ms"] = time.Since(t).Milliseconds()
-	}()
+	// Requested channel (if any) is fetched optimistically in the same batch
+	var requestedChannel pgtype.UUID
+	if channelIDParam != "" {
+		if parsed, err := utils.StrToUUID(channelIDParam); err == nil {
+			requestedChannel = parsed
+		}
+	}
 
-	go func() {
-		defer wg.Done()
-		t := time.Now()
-		_, err := h.Queries.IsMember(ctx, db.IsMemberParams{
-			UserID:    uid,
-			ProjectID: project.ID,
-		})
-		isMember = err == nil
-		timing["membership_check_ms"] = time.Since(t).Milliseconds()
-	}()
+	// Members, membership, channels and first message page in ONE round trip
+	t = time.Now()
+	overview, err := h.Queries.GetLoopOverview(ctx, project.ID, uid, requestedChannel, 50)
+	if err != nil {
+		log.Printf("[LoopFull] batch failed for %s: %v", name, err)
+		problem.Respond(c, 500, "failed to load loop")
+		return
+	}
+	timing["batch_ms"] = time.Since(t).Milliseconds()
+
+	loopMembers := overview.Members
+	channels := overview.Channels
+	isMember := overview.IsMember
+	history := overview.Messages
+	var messagesErr error
+	var activeChannel *db.GetChannelsByProjectRow
+
+	// Auto-create default channel for legacy loops without channels
+	if len(channels) == 0 {
+		defaultCh, err := h.EnsureDefaultChannel(c, project.ID)
+		if err == nil && defaultCh != nil {
+			channels, _ = h.Queries.GetChannelsByProject(ctx, project.ID)
+		}
+	}
 
-	go func() {
-		defer wg.Done()
-		t := time.Now()
-		channels, channelsErr = h.Queries.GetChannelsByProject(ctx, project.ID)
-		// Auto-create default channel for legacy loops without channels
-		if channelsErr == nil && len(channels) == 0 {
-			defaultCh, err := h.EnsureDefaultChannel(c, project.ID)
-			if err == nil && defaultCh != nil {
-				// Re-fetch channels after creating default
-				channels, channelsErr = h.Queries.GetChannelsByProject(ctx, project.ID)
+	// Guests only get their channels; the batch may have guessed another one
+	var role string
+	guessDropped := false
+	if isMember {
+		role = h.Members.Role(ctx, uid, project.ID)
+	}
+	if role == members.Guest {
+		allowed := h.Members.GuestChannels(ctx, project.ID)
+		visible := channels[:0:0]
+		for _, ch := range channels {
+			if allowed[ch.ID] {
+				visible = append(visible, ch)
 			}
 		}
-		timing["channels_ms"] = time.Since(t).Milliseconds()
-	}()
-
-	wg.Wait()
-
-	if membersErr != nil {
-		c.JSON(500, gin.H{"error": "failed to get members"})
-		return
+		channels = visible
+		if len(history) > 0 && !allowed[history[0].ChannelID] {
+			history, guessDropped = nil, true
+		}
 	}
 
 	// Determine active channel
-	if channelsErr == nil && len(channels) > 0 {
-		if channelIDParam != "" {
+	if len(channels) > 0 {
+		if requestedChannel.Valid {
 			// Use specified channel
 			for i := range channels {
-				if utils.UUIDToStr(channels[i].ID) == channelIDParam {
+				if channels[i].ID == requestedChannel {
 					activeChannel = &channels[i]
 					break
 				}
@@ -270,10 +370,14 @@ func (h *Handler) HandleLoopFull(c *gin.Context) {
 		}
 	}
 
-	// Fetch messages for active channel if member
-	if isMember && activeChannel != nil {
+	// The batch guessed the channel; refetch only if the guess was wrong
+	// (requested channel not in this loop, or entry channel just created)
+	batchHit := activeChannel != nil &&
+		((requestedChannel.Valid && activeChannel.ID == requestedChannel) ||
+			(!requestedChannel.Valid && (len(history) == 0 || history[0].ChannelID == activeChannel.ID)))
+	if isMember && activeChannel != nil && (!batchHit || guessDropped) {
 		t := time.Now()
-		messages, messagesErr = h.Queries.GetMessages(ctx, db.GetMessagesParams{
+		history, messagesErr = h.Queries.GetMessages(ctx, db.GetMessagesParams{
 			ChannelID: activeChannel.ID,
 			Limit:     50,
 			Offset:    0,
@@ -286,16 +390,26 @@ func (h *Handler) HandleLoopFull(c *gin.Context) {
 		ID:        utils.UUIDToStr(project.ID),
 		Name:      project.Name,
 		OwnerID:   utils.UUIDToStr(project.OwnerID),
-		CreatedAt: project.CreatedAt.Time.Format(time.RFC3339),
+		CreatedAt: utils.FormatTime(project.CreatedAt.Time),
+		Workspace: workspace,
 		IsMember:  isMember,
-		Members:   formatMembers(members),
+		Role:      role,
+		Members:   formatMembers(loopMembers),
 		Channels:  make([]ChannelResponse, 0),
 		Messages:  make([]MessageResponse, 0),
 	}
+	if m, ok := h.loopMirror(ctx, project.ID); ok {
+		resp.Mirror = loopMirrorToResponse(m)
+	}
 
 	// Add channels to response
-	if channelsErr == nil && channels != nil {
+	if channels != nil {
+		activity := channelActivityByID(overview.Activity)
+		topics := topicsByChannel(overview.Topics)
+		policies := policiesByChannel(overview.Policies)
 		for _, ch := range channels {
+			t, ok := topics[ch.ID]
+			p, hasPolicy := policies[ch.ID]
 			resp.Channels = append(resp.Channels, ChannelResponse{
 				ID:          utils.UUIDToStr(ch.ID),
 				ProjectID:   utils.UUIDToStr(ch.ProjectID),
@@ -303,8 +417,9 @@ func (h *Handler) HandleLoopFull(c *gin.Context) {
 				Description: ch.Description.String,
 				IsDefault:   ch.IsDefault.Bool,
 				Position:    int(ch.Position.Int32),
-				CreatedAt:   ch.CreatedAt.Time.Format(time.RFC3339),
-			})
+				CreatedAt:   utils.FormatTime(ch.CreatedAt.Time),
+				Activity:    activity[ch.ID],
+			}.withTopic(t, ok).withPolicy(p, hasPolicy))
 		}
 	}
 
@@ -317,15 +432,18 @@ func (h *Handler) HandleLoopFull(c *gin.Context) {
 			Description: activeChannel.Description.String,
 			IsDefault:   activeChannel.IsDefault.Bool,
 			Position:    int(activeChannel.Position.Int32),
-			CreatedAt:   activeChannel.CreatedAt.Time.Format(time.RFC3339),
+			CreatedAt:   utils.FormatTime(activeChannel.CreatedAt.Time),
 		}
+		t, ok := topicsByChannel(overview.Topics)[activeChannel.ID]
+		p, hasPolicy := policiesByChannel(overview.Policies)[activeChannel.ID]
+		active = active.withTopic(t, ok).withPolicy(p, hasPolicy)
 		resp.ActiveChannel = &active
 	}
 
 	// Only include messages if user is a member
-	if isMember && messagesErr == nil && messages != nil {
-		msgList := make([]MessageResponse, len(messages))
-		for i, m := range messages {
+	if isMember && messagesErr == nil && history != nil {
+		msgList := make([]MessageResponse, len(history))
+		for i, m := range history {
 			var parentID *string
 			if m.ParentID.Valid {
 				pid := utils.FormatMessageID(m.ParentID.Int64)
@@ -333,45 +451,51 @@ func (h *Handler) HandleLoopFull(c *gin.Context) {
 			}
 			msgList[i] = MessageResponse{
 				ID:             utils.FormatMessageID(m.ID),
-				Content:        m.Content,
+				Content:        messages.Content(m.Content, m.IsDeleted),
 				SenderID:       utils.UUIDToStr(m.SenderID),
 				SenderUsername: m.SenderUsername,
-				SenderAvatar:   m.SenderAvatar.String,
-				CreatedAt:      m.CreatedAt.Time.Format(time.RFC3339),
+				SenderAvatar:   mediaURL(m.SenderAvatar.String),
+				CreatedAt:      utils.FormatTime(m.CreatedAt.Time),
+				CreatedAtMs:    utils.EpochMillis(m.CreatedAt.Time),
 				ParentID:       parentID,
 				ReplyCount:     int(m.ReplyCount.Int32),
+				Seq:            m.Seq.Int64,
+				Deleted:        m.IsDeleted,
 			}
 		}
 		// Reverse to chronological order
 		for i, j := 0, len(msgList)-1; i < j; i, j = i+1, j-1 {
 			msgList[i], msgList[j] = msgList[j], msgList[i]
 		}
+		h.attachEntities(ctx, project.ID, msgList)
+		h.annotateForViewer(ctx, uid, msgList)
 		resp.Messages = msgList
 	}
 
+	tagged := resp
 	timing["total_ms"] = time.Since(start).Milliseconds()
 	resp.Timing = timing
 
-	log.Printf("[LoopFull] %s completed in %dms (project: %dms, members: %dms, channels: %dms, messages: %dms)",
-		name, timing["total_ms"], timing["project_ms"], timing["members_ms"], timing["channels_ms"], timing["messages_ms"])
+	log.Printf("[LoopFull] %s completed in %dms (project: %dms, batch: %dms, messages: %dms)",
+		name, timing["total_ms"], timing["project_ms"], timing["batch_ms"], timing["messages_ms"])
 
-	c.JSON(200, resp)
+	respondJSONWithETag(c, tagged, resp)
 }
 
 // HandlePrefetch returns minimal data for prefetching (hover optimization)
 func (h *Handler) HandlePrefetch(c *gin.Context) {
 	name := c.Param("name")
 	if name == "" {
-		c.JSON(400, gin.H{"error": "loop name required"})
+		problem.Respond(c, 400, "loop name required")
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 100*time.Millisecond)
 	defer cancel()
 
-	project, err := h.Queries.GetProjectByName(ctx, name)
+	project, err := h.Loops.ByName(ctx, name)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// REPOSITORY INSIGHTS — dashboard numbers for a loop's linked repo
+// ============================================================================
+
+const (
+	insightsTTL         = 15 * time.Minute
+	insightsTopAuthors  = 10
+	insightsRecentWeeks = 12
+	insightsPRSample    = "100" // most recently closed PRs used for merge times
+)
+
+// Keyed by repo ID: every member of the loop sees the same numbers
+var insightsCache = cache.New[int64, RepoInsights](insightsTTL, 1000)
+
+type RepoInsights struct {
+	RepoName       string              `json:"repo_name"`
+	Contributors   ContributorInsights `json:"contributors"`
+	CommitActivity CommitInsights      `json:"commit_activity"`
+	Issues         IssueInsights       `json:"issues"`
+	PullRequests   PRInsights          `json:"pull_requests"`
+	Languages      []LanguageShare     `json:"languages"`
+	GeneratedAt    string              `json:"generated_at"`
+	// Sections GitHub was still computing; retry later to fill them in
+	Pending []string `json:"pending,omitempty"`
+}
+
+type ContributorInsights struct {
+	Total int                `json:"total"`
+	Top   []ContributorShare `json:"top"`
+}
+
+type ContributorShare struct {
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+	Commits   int    `json:"commits"`
+	Additions int    `json:"additions"`
+	Deletions int    `json:"deletions"`
+}
+
+type CommitInsights struct {
+	Weeks         []WeekCommits `json:"weeks"` // oldest first
+	LastYearTotal int           `json:"last_year_total"`
+	WeeklyAverage float64       `json:"weekly_average"`
+}
+
+type WeekCommits struct {
+	Week    string `json:"week"` // YYYY-MM-DD, start of the week
+	Commits int    `json:"commits"`
+}
+
+type IssueInsights struct {
+	Open        int     `json:"open"`
+	Closed      int     `json:"closed"`
+	ClosedRatio float64 `json:"closed_ratio"`
+}
+
+type PRInsights struct {
+	Open   int `json:"open"`
+	Merged int `json:"merged"`
+	// Hours from open to merge over the sampled PRs
+	MergeHours MergeTimePercentiles `json:"merge_hours"`
+	SampledPRs int                  `json:"sampled_prs"`
+}
+
+type MergeTimePercentiles struct {
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+	P90 float64 `json:"p90"`
+}
+
+type LanguageShare struct {
+	Name    string  `json:"name"`
+	Bytes   int64   `json:"bytes"`
+	Percent float64 `json:"percent"`
+}
+
+// ============================================================================
+// GET /api/loops/:name/github/insights
+// ============================================================================
+
+func (h *Handler) HandleGetRepoInsights(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	ctx := c.Request.Context()
+	project, err := h.Loops.ByName(ctx, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+
+	if cached, ok := insightsCache.Get(project.GithubRepoID); ok {
+		c.JSON(200, cached)
+		return
+	}
+
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	token := h.loopReadToken(ctx, project, user)
+	if token == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
+		return
+	}
+
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
+		return
+	}
+
+	insights, err := collectRepoInsights(ctx, token, repoFullName)
+	if err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
+		return
+	}
+	// Partial results aren't cached so the next view picks up the pending sections
+	if len(insights.Pending) == 0 {
+		insightsCache.Set(project.GithubRepoID, insights)
+	}
+	c.JSON(200, insights)
+}
+
+// collectRepoInsights runs the GitHub calls in parallel. A /stats endpoint that
+// is still computing marks its section pending; any other failure fails the lot.
+func collectRepoInsights(ctx context.Context, token, repo string) (RepoInsights, error) {
+	gh := github.Default
+	insights := RepoInsights{RepoName: repo}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	run := func(section string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := fn()
+			if err == nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if errors.Is(err, github.ErrStatsPending) {
+				insights.Pending = append(insights.Pending, section)
+			} else if firstErr == nil {
+				firstErr = err
+			}
+		}()
+	}
+	count := func(dst *int, query string) func() error {
+		return func() error {
+			n, err := gh.SearchIssueCount(ctx, token, "repo:"+repo+" "+query)
+			*dst = n
+			return err
+		}
+	}
+
+	run("contributors", func() error {
+		stats, err := gh.ContributorStats(ctx, token, repo)
+		insights.Contributors = contributorInsights(stats)
+		return err
+	})
+	run("commit_activity", func() error {
+		weeks, err := gh.CommitActivity(ctx, token, repo)
+		insights.CommitActivity = commitInsights(weeks)
+		return err
+	})
+	run("languages", func() error {
+		langs, err := gh.ListLanguages(ctx, token, repo)
+		insights.Languages = languageShares(langs)
+		return err
+	})
+	run("issues", count(&insights.Issues.Open, "is:issue is:open"))
+	run("issues", count(&insights.Issues.Closed, "is:issue is:closed"))
+	run("pull_requests", count(&insights.PullRequests.Open, "is:pr is:open"))
+	run("pull_requests", count(&insights.PullRequests.Merged, "is:pr is:merged"))
+	run("pull_requests", func() error {
+		pulls, err := gh.ListPulls(ctx, token, repo, github.ListOptions{
+			State: "closed", PerPage: insightsPRSample, Sort: "updated", Dir: "desc",
+		})
+		insights.PullRequests.MergeHours, insights.PullRequests.SampledPRs = mergeTimes(pulls)
+		return err
+	})
+	wg.Wait()
+
+	if firstErr != nil {
+		return RepoInsights{}, firstErr
+	}
+	if total := insights.Issues.Open + insights.Issues.Closed; total > 0 {
+		insights.Issues.ClosedRatio = round2(float64(insights.Issues.Closed) / float64(total))
+	}
+	sort.Strings(insights.Pending)
+	insights.GeneratedAt = utils.FormatTime(time.Now())
+	return insights, nil
+}
+
+func contributorInsights(stats []github.ContributorStats) ContributorInsights {
+	out := ContributorInsights{Total: len(stats), Top: []ContributorShare{}}
+	for _, s := range stats {
+		share := ContributorShare{Login: s.Author.Login, AvatarURL: s.Author.AvatarURL, Commits: s.Total}
+		for _, w := range s.Weeks {
+			share.Additions += w.Additions
+			share.Deletions += w.Deletions
+		}
+		out.Top = append(out.Top, share)
+	}
+	sort.Slice(out.Top, func(i, j int) bool { return out.Top[i].Commits > out.Top[j].Commits })
+	if len(out.Top) > insightsTopAuthors {
+		out.Top = out.Top[:insightsTopAuthors]
+	}
+	return out
+}
+
+func commitInsights(weeks []github.WeeklyCommits) CommitInsights {
+	out := CommitInsights{Weeks: []WeekCommits{}}
+	for _, w := range weeks {
+		out.LastYearTotal += w.Total
+	}
+	if len(weeks) > 0 {
+		out.WeeklyAverage = round2(float64(out.LastYearTotal) / float64(len(weeks)))
+	}
+	recent := weeks[max(0, len(weeks)-insightsRecentWeeks):]
+	for _, w := range recent {
+		out.Weeks = append(out.Weeks, WeekCommits{
+			Week:    time.Unix(w.Week, 0).UTC().Format("2006-01-02"),
+			Commits: w.Total,
+		})
+	}
+	return out
+}
+
+func languageShares(langs map[string]int64) []LanguageShare {
+	var total int64
+	for _, n := range langs {
+		total += n
+	}
+	out := make([]LanguageShare, 0, len(langs))
+	for name, n := range langs {
+		out = append(out, LanguageShare{Name: name, Bytes: n, Percent: round2(100 * float64(n) / float64(total))})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Bytes > out[j].Bytes })
+	return out
+}
+
+// mergeTimes computes open-to-merge percentiles (nearest rank) over the
+// merged PRs in pulls; closed-unmerged ones are skipped
+func mergeTimes(pulls []github.PullRequest) (MergeTimePercentiles, int) {
+	var hours []float64
+	for _, pr := range pulls {
+		if pr.MergedAt == nil {
+			continue
+		}
+		created, err1 := time.Parse(time.RFC3339, pr.CreatedAt)
+		merged, err2 := time.Parse(time.RFC3339, *pr.MergedAt)
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		hours = append(hours, merged.Sub(created).Hours())
+	}
+	if len(hours) == 0 {
+		return MergeTimePercentiles{}, 0
+	}
+	sort.Float64s(hours)
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(hours)))) - 1
+		return round2(hours[max(0, i)])
+	}
+	return MergeTimePercentiles{P50: rank(0.50), P75: rank(0.75), P90: rank(0.90)}, len(hours)
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
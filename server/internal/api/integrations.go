@@ -0,0 +1,413 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/integrations"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOOP INTEGRATIONS
+// The owner connects the loop to outside services through integrations of
+// the kinds the server registers. Messages, standup summaries and GitHub
+// events are dispatched to every enabled integration listening to their
+// topic. Credentials are referenced, never stored in config or returned.
+// ============================================================================
+
+const (
+	defaultIntegrationAudit = 50
+	maxIntegrationAudit     = 200
+)
+
+type IntegrationResponse struct {
+	ID      string          `json:"id"`
+	Type    string          `json:"type"`
+	Name    string          `json:"name"`
+	Config  json.RawMessage `json:"config"`
+	Topics  []string        `json:"topics"` // empty takes every topic of the type
+	Enabled bool            `json:"enabled"`
+	// Whether a credential is attached; the reference itself stays server-side
+	HasCredential bool `json:"has_credential"`
+	// Set while the credential is read from the server environment (an env:
+	// ref from before loop secrets), asking the owner to move it to a secret
+	CredentialWarning string `json:"credential_warning,omitempty"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
+}
+
+type IntegrationsResponse struct {
+	Integrations []IntegrationResponse `json:"integrations"`
+	// Kinds the server can set up
+	Types []string `json:"types"`
+}
+
+type CreateIntegrationRequest struct {
+	Type           string          `json:"type" binding:"required"`
+	Name           string          `json:"name" binding:"required,max=64"`
+	Config         json.RawMessage `json:"config"`
+	Topics         []string        `json:"topics"`
+	Enabled        *bool           `json:"enabled"`
+	CredentialsRef string          `json:"credentials_ref"`
+}
+
+// UpdateIntegrationRequest changes the fields that are set; a credentials_ref
+// of "" detaches the credential
+type UpdateIntegrationRequest struct {
+	Name           *string         `json:"name" binding:"omitempty,min=1,max=64"`
+	Config         json.RawMessage `json:"config"`
+	Topics         []string        `json:"topics"`
+	Enabled        *bool           `json:"enabled"`
+	CredentialsRef *string         `json:"credentials_ref"`
+}
+
+type IntegrationAuditEntry struct {
+	ID              string `json:"id"`
+	IntegrationID   string `json:"integration_id,omitempty"` // empty once deleted
+	IntegrationName string `json:"integration_name"`
+	Action          string `json:"action"`
+	Detail          string `json:"detail,omitempty"`
+	// Empty for deliveries
+	ActorUsername string `json:"actor_username,omitempty"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func integrationToResponse(in db.LoopIntegration) IntegrationResponse {
+	topics := in.Topics
+	if topics == nil {
+		topics = []string{}
+	}
+	var warning string
+	if strings.HasPrefix(in.CredentialsRef.String, integrations.EnvRefPrefix) {
+		warning = "this credential comes from the server environment; store it as a loop secret and point credentials_ref at secret:<name>"
+	}
+	return IntegrationResponse{
+		ID:                utils.UUIDToStr(in.ID),
+		Type:              in.Type,
+		Name:              in.Name,
+		Config:            in.Config,
+		Topics:            topics,
+		Enabled:           in.Enabled,
+		HasCredential:     in.CredentialsRef.Valid,
+		CredentialWarning: warning,
+		CreatedAt:         utils.FormatTime(in.CreatedAt.Time),
+		UpdatedAt:         utils.FormatTime(in.UpdatedAt.Time),
+	}
+}
+
+// dispatchIntegrations hands ev to the loop's integrations; failures are
+// logged, since the event already happened either way
+func (h *Handler) dispatchIntegrations(ctx context.Context, projectID pgtype.UUID, ev integrations.Event) {
+	ev.ProjectID = utils.UUIDToStr(projectID)
+	if err := h.Integrations.Dispatch(ctx, ev); err != nil {
+		log.Printf("[integrations] failed to dispatch %s for loop %s: %v", ev.Topic, ev.ProjectID, err)
+	}
+}
+
+// messageEvent is the integration event for a new top-level message
+func messageEvent(msg MessageResponse) integrations.Event {
+	return integrations.Event{
+		Topic:     integrations.TopicMessage,
+		ChannelID: msg.ChannelID,
+		Text:      "@" + msg.SenderUsername + ": " + msg.Content,
+		Data: map[string]any{
+			"message_id":      msg.ID,
+			"sender_id":       msg.SenderID,
+			"sender_username": msg.SenderUsername,
+			"content":         msg.Content,
+		},
+	}
+}
+
+// dispatchGitHubIntegrations hands a handled webhook delivery to the
+// integrations of every loop linked to its repo
+func (h *Handler) dispatchGitHubIntegrations(ctx context.Context, event string, body []byte) {
+	var env struct {
+		Action     string `json:"action"`
+		Repository struct {
+			ID       int64  `json:"id"`
+			FullName string `json:"full_name"`
+		} `json:"repository"`
+		Sender struct {
+			Login string `json:"login"`
+		} `json:"sender"`
+	}
+	if event == "ping" {
+		return
+	}
+	if err := json.Unmarshal(body, &env); err != nil || env.Repository.ID == 0 {
+		return
+	}
+	projects, err := h.Queries.GetProjectsByGithubRepoID(ctx, env.Repository.ID)
+	if err != nil {
+		log.Printf("[integrations] failed to find loops for repo %d: %v", env.Repository.ID, err)
+		return
+	}
+
+	text := env.Repository.FullName + ": " + event
+	if env.Action != "" {
+		text += " " + env.Action
+	}
+	if env.Sender.Login != "" {
+		text += " by " + env.Sender.Login
+	}
+	for _, p := range projects {
+		h.dispatchIntegrations(ctx, p.ID, integrations.Event{
+			Topic: integrations.TopicGitHub + "." + event,
+			Text:  text,
+			Data: map[string]any{
+				"event":      event,
+				"action":     env.Action,
+				"repository": env.Repository.FullName,
+				"sender":     env.Sender.Login,
+			},
+		})
+	}
+}
+
+// invalidateLoopIntegrations must be called after a loop's integrations change
+func invalidateLoopIntegrations(projectID pgtype.UUID) {
+	lookupInvalidator.Invalidate("loop_integrations", utils.UUIDToStr(projectID))
+}
+
+// loopIntegration resolves :id within an owned loop
+func (h *Handler) loopIntegration(c *gin.Context, action string) (db.LoopIntegration, bool) {
+	project, ok := h.ownedLoop(c, action)
+	if !ok {
+		return db.LoopIntegration{}, false
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid integration id")
+		return db.LoopIntegration{}, false
+	}
+	in, err := h.Queries.GetLoopIntegration(c, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && in.ProjectID != project.ID) {
+		problem.Respond(c, 404, "integration not found")
+		return db.LoopIntegration{}, false
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to get integration")
+		return db.LoopIntegration{}, false
+	}
+	return in, true
+}
+
+// HandleGetIntegrations lists the loop's integrations and the types that can
+// be added (owner only)
+func (h *Handler) HandleGetIntegrations(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "view integrations")
+	if !ok {
+		return
+	}
+
+	rows, err := h.Queries.GetLoopIntegrations(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get integrations")
+		return
+	}
+	out := IntegrationsResponse{
+		Integrations: make([]IntegrationResponse, 0, len(rows)),
+		Types:        h.Integrations.Types(),
+	}
+	for _, in := range rows {
+		out.Integrations = append(out.Integrations, integrationToResponse(in))
+	}
+	c.JSON(200, out)
+}
+
+// HandleCreateIntegration adds an integration to the loop (owner only)
+func (h *Handler) HandleCreateIntegration(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "add integrations")
+	if !ok {
+		return
+	}
+	uid, _ := utils.GetUserIdFromContext(c)
+
+	var req CreateIntegrationRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	in := db.LoopIntegration{
+		ProjectID:      project.ID,
+		Type:           req.Type,
+		Name:           strings.TrimSpace(req.Name),
+		Config:         req.Config,
+		Topics:         req.Topics,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		CredentialsRef: pgtype.Text{String: req.CredentialsRef, Valid: req.CredentialsRef != ""},
+	}
+	if len(in.Config) == 0 {
+		in.Config = json.RawMessage("{}")
+	}
+	if in.Topics == nil {
+		in.Topics = []string{}
+	}
+	if err := h.Integrations.Validate(c, in); err != nil {
+		problem.Respond(c, 400, err.Error())
+		return
+	}
+
+	created, err := h.Queries.CreateLoopIntegration(c, db.CreateLoopIntegrationParams{
+		ProjectID:      in.ProjectID,
+		Type:           in.Type,
+		Name:           in.Name,
+		Config:         in.Config,
+		Topics:         in.Topics,
+		Enabled:        in.Enabled,
+		CredentialsRef: in.CredentialsRef,
+		CreatedBy:      uid,
+	})
+	if isUniqueViolation(err) {
+		problem.Respond(c, 409, "the loop already has an integration with this name")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to create integration")
+		return
+	}
+	invalidateLoopIntegrations(project.ID)
+	h.Integrations.Audit(c, created, uid, integrations.ActionCreated, created.Type)
+
+	c.JSON(201, integrationToResponse(created))
+}
+
+// HandleUpdateIntegration changes an integration (owner only)
+func (h *Handler) HandleUpdateIntegration(c *gin.Context) {
+	in, ok := h.loopIntegration(c, "change integrations")
+	if !ok {
+		return
+	}
+	uid, _ := utils.GetUserIdFromContext(c)
+
+	var req UpdateIntegrationRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	var changed []string
+	if req.Name != nil {
+		in.Name = strings.TrimSpace(*req.Name)
+		changed = append(changed, "name")
+	}
+	if len(req.Config) > 0 {
+		in.Config = req.Config
+		changed = append(changed, "config")
+	}
+	if req.Topics != nil {
+		in.Topics = req.Topics
+		changed = append(changed, "topics")
+	}
+	if req.Enabled != nil {
+		in.Enabled = *req.Enabled
+		changed = append(changed, "enabled="+strconv.FormatBool(in.Enabled))
+	}
+	if req.CredentialsRef != nil {
+		in.CredentialsRef = pgtype.Text{String: *req.CredentialsRef, Valid: *req.CredentialsRef != ""}
+		changed = append(changed, "credential")
+	}
+	if err := h.Integrations.Validate(c, in); err != nil {
+		problem.Respond(c, 400, err.Error())
+		return
+	}
+
+	updated, err := h.Queries.UpdateLoopIntegration(c, db.UpdateLoopIntegrationParams{
+		ID:             in.ID,
+		Name:           in.Name,
+		Config:         in.Config,
+		Topics:         in.Topics,
+		Enabled:        in.Enabled,
+		CredentialsRef: in.CredentialsRef,
+	})
+	if isUniqueViolation(err) {
+		problem.Respond(c, 409, "the loop already has an integration with this name")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to update integration")
+		return
+	}
+	invalidateLoopIntegrations(updated.ProjectID)
+	h.Integrations.Audit(c, updated, uid, integrations.ActionUpdated, strings.Join(changed, ", "))
+
+	c.JSON(200, integrationToResponse(updated))
+}
+
+// HandleDeleteIntegration removes an integration (owner only); its audit
+// trail stays
+func (h *Handler) HandleDeleteIntegration(c *gin.Context) {
+	in, ok := h.loopIntegration(c, "remove integrations")
+	if !ok {
+		return
+	}
+	uid, _ := utils.GetUserIdFromContext(c)
+
+	// Recorded first, while the row it points at still exists
+	h.Integrations.Audit(c, in, uid, integrations.ActionDeleted, in.Type)
+	if _, err := h.Queries.DeleteLoopIntegration(c, in.ID); err != nil {
+		problem.Respond(c, 500, "failed to delete integration")
+		return
+	}
+	invalidateLoopIntegrations(in.ProjectID)
+
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleGetIntegrationAudit lists the loop's integration changes and failed
+// deliveries, newest first, optionally for ?integration_id (owner only)
+func (h *Handler) HandleGetIntegrationAudit(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "view the integration audit trail")
+	if !ok {
+		return
+	}
+	var integrationID pgtype.UUID
+	if raw := c.Query("integration_id"); raw != "" {
+		id, err := utils.StrToUUID(raw)
+		if err != nil {
+			problem.Respond(c, 400, "invalid integration_id")
+			return
+		}
+		integrationID = id
+	}
+	limit := int32(defaultIntegrationAudit)
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			problem.Respond(c, 400, "invalid limit")
+			return
+		}
+		limit = int32(min(n, maxIntegrationAudit))
+	}
+
+	rows, err := h.Queries.GetIntegrationAudit(c, db.GetIntegrationAuditParams{
+		ProjectID:     project.ID,
+		IntegrationID: integrationID,
+		MaxRows:       limit,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get audit trail")
+		return
+	}
+	out := make([]IntegrationAuditEntry, 0, len(rows))
+	for _, r := range rows {
+		out = append(out, IntegrationAuditEntry{
+			ID:              strconv.FormatInt(r.ID, 10),
+			IntegrationID:   utils.UUIDToStr(r.IntegrationID),
+			IntegrationName: r.IntegrationName,
+			Action:          r.Action,
+			Detail:          r.Detail,
+			ActorUsername:   r.ActorUsername.String,
+			CreatedAt:       utils.FormatTime(r.CreatedAt.Time),
+		})
+	}
+	c.JSON(200, out)
+}
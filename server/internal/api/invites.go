@@ -0,0 +1,229 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOOP INVITES — codes that let someone in without the gatekeeper, as a guest
+// ============================================================================
+
+const defaultInviteLifetime = 7 * 24 * time.Hour
+
+type InviteResponse struct {
+	Code      string  `json:"code"`
+	Role      string  `json:"role"`
+	MaxUses   *int32  `json:"max_uses,omitempty"`
+	Uses      int32   `json:"uses"`
+	ExpiresAt *string `json:"expires_at,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// CreateInviteRequest; max_uses defaults to unlimited and expires_in_hours
+// to a week (0 means the code never expires)
+type CreateInviteRequest struct {
+	MaxUses        *int `json:"max_uses" binding:"omitempty,min=1,max=1000"`
+	ExpiresInHours *int `json:"expires_in_hours" binding:"omitempty,min=0,max=720"` // 0 never expires
+}
+
+func inviteToResponse(inv db.LoopInvite) InviteResponse {
+	resp := InviteResponse{
+		Code:      inv.Code,
+		Role:      inv.Role,
+		Uses:      inv.Uses,
+		CreatedAt: utils.FormatTime(inv.CreatedAt.Time),
+	}
+	if inv.MaxUses.Valid {
+		resp.MaxUses = &inv.MaxUses.Int32
+	}
+	if inv.ExpiresAt.Valid {
+		t := utils.FormatTime(inv.ExpiresAt.Time)
+		resp.ExpiresAt = &t
+	}
+	return resp
+}
+
+func newInviteCode() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// inviteManagerAccess resolves :name and checks the caller may manage its
+// invites (owner or moderator)
+func (h *Handler) inviteManagerAccess(c *gin.Context) (db.Project, pgtype.UUID, bool) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return project, uid, false
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only the loop owner and moderators can manage invites")
+		return project, uid, false
+	}
+	return project, uid, true
+}
+
+// HandleCreateInvite creates a guest invite code for the loop
+func (h *Handler) HandleCreateInvite(c *gin.Context) {
+	var req CreateInviteRequest
+	if c.Request.ContentLength != 0 && !bindStrictJSON(c, &req) { // body is optional
+		return
+	}
+	var maxUses pgtype.Int4
+	if req.MaxUses != nil {
+		maxUses = pgtype.Int4{Int32: int32(*req.MaxUses), Valid: true}
+	}
+	lifetime := defaultInviteLifetime
+	if req.ExpiresInHours != nil {
+		lifetime = time.Duration(*req.ExpiresInHours) * time.Hour
+	}
+	var expiresAt pgtype.Timestamptz
+	if lifetime > 0 {
+		expiresAt = pgtype.Timestamptz{Time: time.Now().Add(lifetime), Valid: true}
+	}
+
+	project, uid, ok := h.inviteManagerAccess(c)
+	if !ok {
+		return
+	}
+
+	code, err := newInviteCode()
+	if err != nil {
+		problem.Respond(c, 500, "failed to create invite")
+		return
+	}
+	inv, err := h.Queries.CreateLoopInvite(c, db.CreateLoopInviteParams{
+		Code:      code,
+		ProjectID: project.ID,
+		Role:      members.Guest,
+		CreatedBy: uid,
+		MaxUses:   maxUses,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to create invite")
+		return
+	}
+	c.JSON(201, inviteToResponse(inv))
+}
+
+// HandleGetInvites lists the loop's invite codes, including spent ones
+func (h *Handler) HandleGetInvites(c *gin.Context) {
+	project, _, ok := h.inviteManagerAccess(c)
+	if !ok {
+		return
+	}
+	invites, err := h.Queries.ListLoopInvites(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get invites")
+		return
+	}
+	result := make([]InviteResponse, len(invites))
+	for i, inv := range invites {
+		result[i] = inviteToResponse(inv)
+	}
+	c.JSON(200, gin.H{"invites": result})
+}
+
+// HandleDeleteInvite revokes an invite code; members it already let in stay
+func (h *Handler) HandleDeleteInvite(c *gin.Context) {
+	project, _, ok := h.inviteManagerAccess(c)
+	if !ok {
+		return
+	}
+	n, err := h.Queries.DeleteLoopInvite(c, db.DeleteLoopInviteParams{Code: c.Param("code"), ProjectID: project.ID})
+	if err != nil {
+		problem.Respond(c, 500, "failed to revoke invite")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "invite not found")
+		return
+	}
+	c.JSON(200, gin.H{"message": "invite revoked"})
+}
+
+// HandleAcceptInvite joins the caller to the invite's loop with the invite's
+// role. Existing members keep their role and don't use up the code.
+func (h *Handler) HandleAcceptInvite(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	code := c.Param("code")
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "failed to accept invite")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	inv, err := qtx.RedeemLoopInvite(c, code)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 404, "invite is invalid, expired or used up")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to accept invite")
+		return
+	}
+	project, err := h.Loops.ByID(c, inv.ProjectID)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+
+	if _, err := qtx.IsMember(c, db.IsMemberParams{UserID: uid, ProjectID: project.ID}); err == nil {
+		c.JSON(200, gin.H{"message": "You are already a member!", "loop": project.Name})
+		return // rolls back the redemption
+	}
+	if banned, err := qtx.IsBannedFromLoop(c, db.IsBannedFromLoopParams{ProjectID: project.ID, UserID: uid}); err != nil || banned {
+		problem.Respond(c, 403, "you have been banned from this loop")
+		return
+	}
+
+	if err := qtx.AddMembership(c, db.AddMembershipParams{
+		UserID:    uid,
+		ProjectID: project.ID,
+		Role:      pgtype.Text{String: inv.Role, Valid: true},
+	}); err != nil {
+		problem.Respond(c, 500, "failed to join loop")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to join loop")
+		return
+	}
+
+	if user, err := h.getUserByID(c, uid); err == nil {
+		go h.welcomeNewMember(project, user)
+	} else {
+		log.Printf("[invites] failed to load %s for welcome: %v", utils.UUIDToStr(uid), err)
+	}
+	h.scheduleOnboardingNudges(c, project.ID, uid)
+
+	c.JSON(200, gin.H{
+		"message": "Successfully joined the loop!",
+		"loop":    project.Name,
+		"role":    inv.Role,
+	})
+}
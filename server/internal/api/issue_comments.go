@@ -0,0 +1,209 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// ============================================================================
+// ISSUE COMMENT SYNC — the issue counterpart of the PR review sync.
+// Issue comments live in the same store as PR comments: GitHub numbers
+// issues and PRs from one sequence, so (repo, number) never collides.
+// ============================================================================
+
+// PostIssueCommentRequest for posting a comment on an issue
+type PostIssueCommentRequest struct {
+	Body string `json:"body" binding:"required,notblank,max=65536"`
+}
+
+// ============================================================================
+// GET /api/loops/:name/github/issue/:number/comments
+// ============================================================================
+
+func (h *Handler) HandleGetIssueComments(c *gin.Context) {
+	name := c.Param("name")
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid issue number")
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	ctx := c.Request.Context()
+	project, err := h.Loops.ByName(ctx, name)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	token := h.loopReadToken(ctx, project, user)
+	if token == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
+		return
+	}
+
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
+		return
+	}
+
+	state, err := h.Queries.GetPRCommentSync(ctx, db.GetPRCommentSyncParams{
+		RepoID:   project.GithubRepoID,
+		PrNumber: int32(number),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 500, "failed to load comments")
+		return
+	}
+
+	refreshing := false
+	switch {
+	case !state.SyncedAt.Valid:
+		if _, err := h.syncIssueComments(ctx, token, repoFullName, project.GithubRepoID, number); err != nil {
+			log.Printf("[issue-comments] initial sync of %s#%d failed: %v", repoFullName, number, err)
+		}
+	case time.Since(state.SyncedAt.Time) > prCommentsStaleAfter:
+		refreshing = h.queuePRCommentsRefresh(ctx, project, uid, number, true)
+	}
+
+	rows, err := h.Queries.ListPRComments(ctx, db.ListPRCommentsParams{
+		RepoID:   project.GithubRepoID,
+		PrNumber: int32(number),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to load comments")
+		return
+	}
+
+	all := make([]UnifiedComment, 0, len(rows))
+	for _, r := range rows {
+		all = append(all, prCommentToUnified(r))
+	}
+
+	c.JSON(200, gin.H{
+		"comments":     all,
+		"issue_number": number,
+		"repo_name":    repoFullName,
+		"refreshing":   refreshing,
+	})
+}
+
+// syncIssueComments refetches an issue's comment thread and reconciles the store
+func (h *Handler) syncIssueComments(ctx context.Context, token, repoFullName string, repoID int64, number int) (bool, error) {
+	comments, err := github.Default.ListIssueComments(ctx, token, repoFullName, number,
+		github.ListOptions{PerPage: "100", Sort: "created", Dir: "asc"})
+	if err != nil {
+		forgetRepoOn404(err, repoID)
+		return false, err
+	}
+
+	rows := make([]db.UpsertPRCommentParams, 0, len(comments))
+	for _, c := range comments {
+		rows = append(rows, issueCommentRow(repoID, number, c))
+	}
+	changed, err := h.storeFetchedComments(ctx, repoID, number, "issue_comment", rows)
+	if err != nil {
+		return changed, err
+	}
+	err = h.Queries.MarkPRCommentsSynced(ctx, db.MarkPRCommentsSyncedParams{RepoID: repoID, PrNumber: int32(number)})
+	return changed, err
+}
+
+// ============================================================================
+// POST /api/loops/:name/github/issue/:number/comments
+// Posts a comment on an issue (Wireloop → GitHub)
+// ============================================================================
+
+func (h *Handler) HandlePostIssueComment(c *gin.Context) {
+	name := c.Param("name")
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid issue number")
+		return
+	}
+
+	var req PostIssueCommentRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	ctx := c.Request.Context()
+	project, err := h.Loops.ByName(ctx, name)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	if user.AccessToken == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
+		return
+	}
+
+	repoFullName, ok := h.repoForWrite(c, project, user.AccessToken)
+	if !ok {
+		return
+	}
+
+	created, err := github.Default.CreateIssueComment(ctx, user.AccessToken, repoFullName, number, req.Body)
+	if err != nil {
+		log.Printf("[issue-comments] post comment failed: %v", err)
+		reportGitHubError(c.Request, err, "create_issue_comment")
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
+		return
+	}
+
+	stored := issueCommentRow(project.GithubRepoID, number, *created)
+	if _, err := h.Queries.UpsertPRComment(ctx, stored); err != nil {
+		log.Printf("[issue-comments] failed to store posted comment: %v", err)
+	}
+
+	comment := prCommentToUnified(storedPRComment(stored))
+	comment.Username = user.Username
+	comment.AvatarURL = mediaURL(user.AvatarUrl.String)
+	comment.Source = "wireloop"
+	h.Events.Publish(loopRoom(utils.UUIDToStr(project.ID)), events.IssueCommentChange{
+		IssueNumber: number,
+		Action:      "created",
+		Comment:     comment,
+	})
+
+	c.JSON(201, gin.H{
+		"success":  true,
+		"id":       created.ID,
+		"html_url": created.HTMLURL,
+	})
+}
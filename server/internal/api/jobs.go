@@ -0,0 +1,164 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+	"sort"
+	"strconv"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// RegisterJobs attaches the API's background job handlers to h.Jobs.
+// Call once at startup, before the queue is started.
+func (h *Handler) RegisterJobs() {
+	h.Jobs.Register(jobEventReminder, h.runEventReminder)
+	h.Jobs.Register(jobStandupPrompt, h.runStandupPrompt)
+	h.Jobs.Register(jobStandupSummary, h.runStandupSummary)
+	h.Jobs.Register(jobMessageReminder, h.runMessageReminder)
+	h.Jobs.Register(jobAttachmentScan, h.runAttachmentScan)
+	h.Jobs.Register(jobPRCommentsSync, h.runPRCommentsSync)
+	h.Jobs.Register(jobGitHubCrossPost, h.runGitHubCrossPost)
+	h.Jobs.Register(jobReleaseAnnouncement, h.runReleaseAnnouncement)
+	h.Jobs.Register(jobOnboardingNudge, h.runOnboardingNudge)
+	h.Jobs.Register(jobTopMessagesDigest, h.runTopMessagesDigest)
+	h.Jobs.Register(jobGitHubDigest, h.runGitHubDigest)
+	h.Jobs.Register(jobResolveGitHubRefs, h.runResolveGitHubRefs)
+	h.Jobs.Register(jobJoinRecheck, h.runJoinRecheck)
+	h.Jobs.Register(jobThreadSummary, h.runThreadSummary)
+}
+
+// ============================================================================
+// Admin view of the job queue: what is waiting, running and failing per job
+// type, with the failures' errors, and a way to run failed jobs again once
+// their cause is fixed
+// ============================================================================
+
+const (
+	defaultJobFailuresLimit = 50
+	maxJobFailuresLimit     = 200
+)
+
+type JobTypeStats struct {
+	Type     string `json:"type"`
+	Queued   int    `json:"queued"`
+	Retrying int    `json:"retrying"` // queued again after a failed attempt
+	Running  int    `json:"running"`
+	Failed   int    `json:"failed"`
+	// When the longest-waiting due job was due; empty when none is
+	OldestDue string `json:"oldest_due,omitempty"`
+	// False for types no handler is registered for on this instance
+	Registered bool `json:"registered"`
+}
+
+type JobFailure struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Status    string          `json:"status"` // failed, or queued to retry
+	Attempts  int             `json:"attempts"`
+	Error     string          `json:"error"`
+	Payload   json.RawMessage `json:"payload"`
+	RunAt     string          `json:"run_at"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+// HandleAdminJobs returns unfinished job counts per type and the most recent
+// failures. Query: ?type= narrows the failures, ?limit= caps them.
+func (h *Handler) HandleAdminJobs(c *gin.Context) {
+	ctx := c.Request.Context()
+	limit := defaultJobFailuresLimit
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxJobFailuresLimit)
+	}
+	jobType := pgtype.Text{String: c.Query("type"), Valid: c.Query("type") != ""}
+
+	rows, err := h.Queries.GetJobStats(ctx)
+	if err != nil {
+		problem.Error(c, err, "failed to load job stats")
+		return
+	}
+	failed, err := h.Queries.ListJobFailures(ctx, db.ListJobFailuresParams{Type: jobType, N: int32(limit)})
+	if err != nil {
+		problem.Error(c, err, "failed to load job failures")
+		return
+	}
+
+	registered := h.Jobs.Types()
+	seen := make(map[string]bool, len(rows))
+	types := make([]JobTypeStats, 0, len(registered)+len(rows))
+	for _, r := range rows {
+		s := JobTypeStats{
+			Type:       r.Type,
+			Queued:     int(r.Queued),
+			Retrying:   int(r.Retrying),
+			Running:    int(r.Running),
+			Failed:     int(r.Failed),
+			Registered: slices.Contains(registered, r.Type),
+		}
+		if r.OldestDue.Valid {
+			s.OldestDue = utils.FormatTime(r.OldestDue.Time)
+		}
+		types = append(types, s)
+		seen[r.Type] = true
+	}
+	// Idle types too, so a quiet queue still lists what it runs
+	for _, t := range registered {
+		if !seen[t] {
+			types = append(types, JobTypeStats{Type: t, Registered: true})
+		}
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Type < types[j].Type })
+
+	failures := make([]JobFailure, 0, len(failed))
+	for _, j := range failed {
+		failures = append(failures, JobFailure{
+			ID:        strconv.FormatInt(j.ID, 10),
+			Type:      j.Type,
+			Status:    j.Status,
+			Attempts:  int(j.Attempts),
+			Error:     j.LastError.String,
+			Payload:   j.Payload,
+			RunAt:     utils.FormatTime(j.RunAt.Time),
+			UpdatedAt: utils.FormatTime(j.UpdatedAt.Time),
+		})
+	}
+	c.JSON(200, gin.H{"types": types, "failures": failures})
+}
+
+// HandleAdminRequeueJob runs a failed job again, from its first attempt
+func (h *Handler) HandleAdminRequeueJob(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid job id")
+		return
+	}
+	n, err := h.Queries.RequeueFailedJob(c, id)
+	if err != nil {
+		problem.Error(c, err, "failed to requeue job")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "no failed job with that id")
+		return
+	}
+	log.Printf("[jobs] admin requeued job %d", id)
+	c.JSON(200, gin.H{"requeued": n})
+}
+
+// HandleAdminRequeueJobType runs every failed job of a type again
+func (h *Handler) HandleAdminRequeueJobType(c *gin.Context) {
+	jobType := c.Param("type")
+	n, err := h.Queries.RequeueFailedJobsByType(c, jobType)
+	if err != nil {
+		problem.Error(c, err, "failed to requeue jobs")
+		return
+	}
+	log.Printf("[jobs] admin requeued %d failed %s jobs", n, jobType)
+	c.JSON(200, gin.H{"requeued": n})
+}
@@ -1,11 +1,18 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"log"
 	"strings"
+	"time"
 	utils "wireloop/internal"
 	"wireloop/internal/db"
 	"wireloop/internal/gatekeeper"
+	"wireloop/internal/i18n"
+	"wireloop/internal/members"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -14,133 +21,116 @@ import (
 var gate = gatekeeper.New()
 
 type VerifyAccessRequest struct {
-	LoopName string `json:"loop_name" binding:"required"`
+	LoopName string `json:"loop_name" binding:"required,max=100"`
 }
 
 // HandleVerifyAccess checks if a user meets the contribution requirements for a loop
 func (h *Handler) HandleVerifyAccess(c *gin.Context) {
 	var req VerifyAccessRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "loop_name required"})
+	if !bindJSON(c, &req) {
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	// Get the user's GitHub token and username
-	user, err := h.Queries.GetUserByID(c, uid)
+	user, err := h.getUserByID(c, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
 
 	// Get the loop/project
-	project, err := h.Queries.GetProjectByName(c, req.LoopName)
+	project, err := h.Loops.ByName(c, req.LoopName)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
 	// Check if already a member
-	if _, err := h.Queries.IsMember(c, db.IsMemberParams{
-		UserID:    uid,
-		ProjectID: project.ID,
-	}); err == nil {
+	if h.Members.IsMember(c, uid, project.ID) {
 		c.JSON(200, gin.H{
 			"is_member": true,
 			"can_join":  true,
-			"message":   "You are already a member of this loop",
+			"message":   h.tr(c, "You are already a member of this loop"),
 			"results":   []gatekeeper.VerificationResult{},
 		})
 		return
 	}
 
-	// Resolve the REAL GitHub repo owner/name from the stored github_repo_id
-	repoInfo, err := gate.ResolveRepoByID(c, user.AccessToken, project.GithubRepoID)
-	if err != nil {
-		log.Printf("[verify-access] Failed to resolve repo ID %d: %v", project.GithubRepoID, err)
+	v, err := h.checkJoinRequirements(c, user, project)
+	switch {
+	case errors.Is(err, errRepoUnresolved):
 		// Can't resolve the repo — return graceful failure
 		c.JSON(200, gin.H{
 			"is_member": false,
 			"can_join":  false,
-			"message":   "Could not resolve the GitHub repository. It may be private or deleted.",
+			"message":   h.tr(c, "Could not resolve the GitHub repository. It may be private or deleted."),
+			"results":   []gatekeeper.VerificationResult{},
+		})
+		return
+	case errors.Is(err, errUnverifiable):
+		c.JSON(200, gin.H{
+			"is_member": false,
+			"can_join":  false,
+			"message":   h.tr(c, "Could not verify your contributions. The repo may be private or inaccessible."),
 			"results":   []gatekeeper.VerificationResult{},
 		})
 		return
+	case err != nil:
+		problem.Respond(c, 500, "failed to get rules")
+		return
 	}
 
-	// Check if user is a GitHub collaborator — bypass all rules
-	isCollab, err := gate.CheckCollaborator(c, user.AccessToken, repoInfo.Owner, repoInfo.Name, user.Username)
+	attempt, err := h.recordVerification(c, uid, project.ID, v, verificationManual)
 	if err != nil {
-		log.Printf("[verify-access] Collaborator check failed for %s on %s/%s: %v", user.Username, repoInfo.Owner, repoInfo.Name, err)
-		isCollab = false
+		log.Printf("[verify-access] failed to record attempt of %s on %s: %v", user.Username, project.Name, err)
+	} else if !v.Passed {
+		h.scheduleJoinRecheck(c, attempt, attempt.CheckedAt.Time)
 	}
 
-	if isCollab {
+	if v.Collaborator {
 		c.JSON(200, gin.H{
 			"is_member":       false,
 			"can_join":        true,
 			"is_collaborator": true,
-			"message":         "You're a collaborator on this repo — welcome in!",
+			"message":         h.tr(c, "You're a collaborator on this repo — welcome in!"),
 			"results":         []gatekeeper.VerificationResult{},
 		})
 		return
 	}
 
-	// Get the rules for this loop
-	rules, err := h.Queries.GetRulesByProject(c, project.ID)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get rules"})
-		return
-	}
-
 	// If no rules, anyone can join
-	if len(rules) == 0 {
+	if v.Open {
 		c.JSON(200, gin.H{
 			"is_member": false,
 			"can_join":  true,
-			"message":   "This loop is open to everyone",
-			"results":   []gatekeeper.VerificationResult{},
-		})
-		return
-	}
-
-	// Convert rules to gatekeeper format
-	gkRules := make([]gatekeeper.Rule, len(rules))
-	for i, r := range rules {
-		threshold, _ := gatekeeper.ParseThreshold(r.Threshold)
-		gkRules[i] = gatekeeper.Rule{
-			CriteriaType: gatekeeper.CriteriaType(r.CriteriaType),
-			Threshold:    threshold,
-		}
-	}
-
-	// Verify access against rules using the REAL repo coordinates
-	results, passed, err := gate.VerifyAccess(c, user.AccessToken, repoInfo.Owner, repoInfo.Name, user.Username, gkRules)
-	if err != nil {
-		c.JSON(200, gin.H{
-			"is_member": false,
-			"can_join":  false,
-			"message":   "Could not verify your contributions. The repo may be private or inaccessible.",
+			"message":   h.tr(c, "This loop is open to everyone"),
 			"results":   []gatekeeper.VerificationResult{},
 		})
 		return
 	}
 
 	message := "You meet all requirements! Click 'Join' to enter."
-	if !passed {
+	if !v.Passed {
 		message = "You don't meet all requirements yet. Keep contributing!"
 	}
+	locale := h.requestLocale(c)
+	for i := range v.Results {
+		v.Results[i].Localize(locale)
+	}
 
 	c.JSON(200, gin.H{
 		"is_member": false,
-		"can_join":  passed,
-		"message":   message,
-		"results":   results,
+		"can_join":  v.Passed,
+		"message":   i18n.T(locale, message),
+		"results":   v.Results,
+		// How each requirement moved since an earlier attempt
+		"progress": h.verificationProgress(c, uid, project.ID, v.Results, time.Now()),
 	})
 }
 
@@ -148,42 +138,47 @@ func (h *Handler) HandleVerifyAccess(c *gin.Context) {
 func (h *Handler) HandleJoinLoop(c *gin.Context) {
 	loopName := c.Param("name")
 	if loopName == "" {
-		c.JSON(400, gin.H{"error": "loop name required"})
+		problem.Respond(c, 400, "loop name required")
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	// Get the user
-	user, err := h.Queries.GetUserByID(c, uid)
+	user, err := h.getUserByID(c, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
 
 	// Get the loop
-	project, err := h.Queries.GetProjectByName(c, loopName)
+	project, err := h.Loops.ByName(c, loopName)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
-	// Check if already a member - if so, just return success
-	if _, err := h.Queries.IsMember(c, db.IsMemberParams{
-		UserID:    uid,
-		ProjectID: project.ID,
-	}); err == nil {
+	// Check if already a member - if so, just return success.
+	// Guests go through the gatekeeper to become contributors.
+	role := h.Members.Role(c, uid, project.ID)
+	if role != "" && role != members.Guest {
 		c.JSON(200, gin.H{
-			"message": "You are already a member!",
+			"message": h.tr(c, "You are already a member!"),
 			"loop":    loopName,
 		})
 		return
 	}
 
+	// Banned users can't rejoin
+	if banned, err := h.Queries.IsBannedFromLoop(c, db.IsBannedFromLoopParams{ProjectID: project.ID, UserID: uid}); err != nil || banned {
+		problem.Respond(c, 403, "you have been banned from this loop")
+		return
+	}
+
 	// Resolve the REAL GitHub repo owner/name
 	repoInfo, err := gate.ResolveRepoByID(c, user.AccessToken, project.GithubRepoID)
 	if err != nil {
@@ -201,7 +196,7 @@ func (h *Handler) HandleJoinLoop(c *gin.Context) {
 		// Not a collaborator — enforce rules
 		rules, err := h.Queries.GetRulesByProject(c, project.ID)
 		if err != nil {
-			c.JSON(500, gin.H{"error": "failed to get rules"})
+			problem.Respond(c, 500, "failed to get rules")
 			return
 		}
 
@@ -217,36 +212,73 @@ func (h *Handler) HandleJoinLoop(c *gin.Context) {
 
 			_, passed, err := gate.VerifyAccess(c, user.AccessToken, repoInfo.Owner, repoInfo.Name, user.Username, gkRules)
 			if err != nil {
-				c.JSON(500, gin.H{"error": "verification failed"})
+				problem.Respond(c, 500, "verification failed")
 				return
 			}
 
 			if !passed {
-				c.JSON(403, gin.H{"error": "contribution requirements not met"})
+				problem.Respond(c, 403, "contribution requirements not met")
 				return
 			}
 		}
 	}
 
+	if role == members.Guest {
+		if _, err := h.Queries.SetMembershipRole(c, db.SetMembershipRoleParams{
+			UserID:    uid,
+			ProjectID: project.ID,
+			Role:      pgtype.Text{String: members.Contributor, Valid: true},
+		}); err != nil {
+			problem.Respond(c, 500, "failed to join loop")
+			return
+		}
+		c.JSON(200, gin.H{
+			"message": h.tr(c, "You are now a full member of the loop!"),
+			"loop":    loopName,
+		})
+		return
+	}
+
 	// Add membership
 	if err := h.Queries.AddMembership(c, db.AddMembershipParams{
 		UserID:    uid,
 		ProjectID: project.ID,
-		Role:      pgtype.Text{String: "contributor", Valid: true},
+		Role:      pgtype.Text{String: members.Contributor, Valid: true},
 	}); err != nil {
 		if strings.Contains(err.Error(), "duplicate") {
 			c.JSON(200, gin.H{
-				"message": "You are already a member!",
+				"message": h.tr(c, "You are already a member!"),
 				"loop":    loopName,
 			})
 			return
 		}
-		c.JSON(500, gin.H{"error": "failed to join loop"})
+		problem.Respond(c, 500, "failed to join loop")
 		return
 	}
 
+	go h.welcomeNewMember(project, user)
+	go h.notifyMemberJoined(project, user)
+	h.scheduleOnboardingNudges(c, project.ID, uid)
+
 	c.JSON(200, gin.H{
-		"message": "Successfully joined the loop!",
+		"message": h.tr(c, "Successfully joined the loop!"),
 		"loop":    loopName,
 	})
 }
+
+// notifyMemberJoined tells the loop's owner a newcomer joined
+func (h *Handler) notifyMemberJoined(project db.Project, user db.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+		Type:          "member_joined",
+		UserID:        project.OwnerID,
+		ActorID:       user.ID,
+		ActorUsername: user.Username,
+		ProjectID:     project.ID,
+		Preview:       i18n.T(h.recipientLocale(ctx, project.OwnerID), "%s joined %s", user.Username, project.Name),
+	}); err != nil {
+		log.Printf("[join] failed to notify the owner of %s: %v", project.Name, err)
+	}
+}
@@ -0,0 +1,333 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/gatekeeper"
+	"wireloop/internal/i18n"
+	"wireloop/internal/members"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// JOIN VERIFICATION HISTORY
+// Each check against a loop's requirements is recorded, so the join page can
+// show how far the user has come since an earlier attempt. A failed attempt
+// is re-checked daily in the background for joinRecheckWindow, and the user
+// is notified once they qualify.
+// ============================================================================
+
+const (
+	jobJoinRecheck = "join_recheck"
+
+	verificationManual  = "manual"
+	verificationRecheck = "recheck"
+
+	joinRecheckInterval = 24 * time.Hour
+	// How long after the user's own attempt re-checks continue
+	joinRecheckWindow = 30 * 24 * time.Hour
+	// Progress is measured against an attempt at least this old
+	progressBaselineAge    = 24 * time.Hour
+	maxVerificationHistory = 30
+)
+
+var (
+	errRepoUnresolved = errors.New("repository could not be resolved")
+	errUnverifiable   = errors.New("contributions could not be verified")
+)
+
+// joinVerdict is the outcome of checking a user against a loop's requirements
+type joinVerdict struct {
+	Results      []gatekeeper.VerificationResult
+	Passed       bool
+	Collaborator bool // collaborators skip the rules
+	Open         bool // the loop has no rules
+}
+
+// attemptResult is one requirement of a recorded attempt
+type attemptResult struct {
+	Criteria string `json:"criteria"`
+	Required int    `json:"required"`
+	Actual   int    `json:"actual"`
+	Passed   bool   `json:"passed"`
+}
+
+type VerificationAttemptResponse struct {
+	ID        string          `json:"id"`
+	Passed    bool            `json:"passed"`
+	Source    string          `json:"source"` // manual or recheck
+	CheckedAt string          `json:"checked_at"`
+	Results   []attemptResult `json:"results"`
+}
+
+// VerificationProgress compares a requirement with an earlier attempt;
+// Previous is unset when there is none to compare with
+type VerificationProgress struct {
+	Criteria          string  `json:"criteria"`
+	Required          int     `json:"required"`
+	Actual            int     `json:"actual"`
+	Previous          *int    `json:"previous,omitempty"`
+	PreviousCheckedAt *string `json:"previous_checked_at,omitempty"`
+}
+
+// checkJoinRequirements checks user against project's contribution rules
+// with the user's token. It fails with errRepoUnresolved or errUnverifiable
+// when GitHub can't answer, and otherwise only if the rules can't be read.
+func (h *Handler) checkJoinRequirements(ctx context.Context, user db.User, project db.Project) (joinVerdict, error) {
+	// Resolve the REAL GitHub repo owner/name from the stored github_repo_id
+	repoInfo, err := gate.ResolveRepoByID(ctx, user.AccessToken, project.GithubRepoID)
+	if err != nil {
+		log.Printf("[verify-access] Failed to resolve repo ID %d: %v", project.GithubRepoID, err)
+		return joinVerdict{}, errRepoUnresolved
+	}
+
+	// Check if user is a GitHub collaborator — bypass all rules
+	isCollab, err := gate.CheckCollaborator(ctx, user.AccessToken, repoInfo.Owner, repoInfo.Name, user.Username)
+	if err != nil {
+		log.Printf("[verify-access] Collaborator check failed for %s on %s/%s: %v", user.Username, repoInfo.Owner, repoInfo.Name, err)
+		isCollab = false
+	}
+	if isCollab {
+		return joinVerdict{Passed: true, Collaborator: true}, nil
+	}
+
+	rules, err := h.Queries.GetRulesByProject(ctx, project.ID)
+	if err != nil {
+		return joinVerdict{}, err
+	}
+	if len(rules) == 0 {
+		return joinVerdict{Passed: true, Open: true}, nil
+	}
+
+	gkRules := make([]gatekeeper.Rule, len(rules))
+	for i, r := range rules {
+		threshold, _ := gatekeeper.ParseThreshold(r.Threshold)
+		gkRules[i] = gatekeeper.Rule{
+			CriteriaType: gatekeeper.CriteriaType(r.CriteriaType),
+			Threshold:    threshold,
+		}
+	}
+	results, passed, err := gate.VerifyAccess(ctx, user.AccessToken, repoInfo.Owner, repoInfo.Name, user.Username, gkRules)
+	if err != nil {
+		return joinVerdict{}, errUnverifiable
+	}
+	return joinVerdict{Results: results, Passed: passed}, nil
+}
+
+// recordVerification stores v as an attempt by uid on projectID
+func (h *Handler) recordVerification(ctx context.Context, uid, projectID pgtype.UUID, v joinVerdict, source string) (db.LoopVerificationAttempt, error) {
+	results := make([]attemptResult, len(v.Results))
+	for i, r := range v.Results {
+		results[i] = attemptResult{Criteria: r.Criteria, Required: r.Required, Actual: r.Actual, Passed: r.Passed}
+	}
+	raw, err := json.Marshal(results)
+	if err != nil {
+		return db.LoopVerificationAttempt{}, err
+	}
+	return h.Queries.CreateVerificationAttempt(ctx, db.CreateVerificationAttemptParams{
+		UserID:    uid,
+		ProjectID: projectID,
+		Passed:    v.Passed,
+		Results:   raw,
+		Source:    source,
+	})
+}
+
+func attemptResults(a db.LoopVerificationAttempt) []attemptResult {
+	var out []attemptResult
+	if err := json.Unmarshal(a.Results, &out); err != nil || out == nil {
+		return []attemptResult{}
+	}
+	return out
+}
+
+func attemptToResponse(a db.LoopVerificationAttempt) VerificationAttemptResponse {
+	return VerificationAttemptResponse{
+		ID:        utils.UUIDToStr(a.ID),
+		Passed:    a.Passed,
+		Source:    a.Source,
+		CheckedAt: utils.FormatTime(a.CheckedAt.Time),
+		Results:   attemptResults(a),
+	}
+}
+
+// verificationProgress pairs each result with the same requirement in the
+// latest attempt at least progressBaselineAge older than now
+func (h *Handler) verificationProgress(ctx context.Context, uid, projectID pgtype.UUID, results []gatekeeper.VerificationResult, now time.Time) []VerificationProgress {
+	out := make([]VerificationProgress, len(results))
+	for i, r := range results {
+		out[i] = VerificationProgress{Criteria: r.Criteria, Required: r.Required, Actual: r.Actual}
+	}
+	baseline, err := h.Queries.GetVerificationBaseline(ctx, db.GetVerificationBaselineParams{
+		UserID:    uid,
+		ProjectID: projectID,
+		CheckedAt: pgtype.Timestamptz{Time: now.Add(-progressBaselineAge), Valid: true},
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("[verify-access] failed to load earlier attempt: %v", err)
+		}
+		return out
+	}
+	before := make(map[string]int)
+	for _, r := range attemptResults(baseline) {
+		before[r.Criteria] = r.Actual
+	}
+	checkedAt := utils.FormatTime(baseline.CheckedAt.Time)
+	for i := range out {
+		if prev, ok := before[out[i].Criteria]; ok {
+			out[i].Previous = &prev
+			out[i].PreviousCheckedAt = &checkedAt
+		}
+	}
+	return out
+}
+
+// HandleGetVerificationHistory lists the caller's recent attempts to meet
+// loop :name's requirements, newest first
+func (h *Handler) HandleGetVerificationHistory(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	rows, err := h.Queries.ListVerificationAttempts(c, db.ListVerificationAttemptsParams{
+		UserID:    uid,
+		ProjectID: project.ID,
+		Limit:     maxVerificationHistory,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get verification history")
+		return
+	}
+	out := make([]VerificationAttemptResponse, len(rows))
+	for i, a := range rows {
+		out[i] = attemptToResponse(a)
+	}
+	c.JSON(200, gin.H{"attempts": out})
+}
+
+// ============================================================================
+// Jobs
+// ============================================================================
+
+type joinRecheckPayload struct {
+	UserID    string `json:"user_id"`
+	ProjectID string `json:"project_id"`
+	// The attempt being followed up; a newer attempt schedules its own re-check
+	AttemptID string `json:"attempt_id"`
+	// When the user last tried themselves, as Unix seconds
+	Since int64 `json:"since"`
+}
+
+// scheduleJoinRecheck queues a re-check after the failed attempt a, unless
+// joinRecheckWindow has passed since the user's own attempt at since
+func (h *Handler) scheduleJoinRecheck(ctx context.Context, a db.LoopVerificationAttempt, since time.Time) {
+	next := a.CheckedAt.Time.Add(joinRecheckInterval)
+	if h.Jobs == nil || next.Sub(since) > joinRecheckWindow {
+		return
+	}
+	if _, err := h.Jobs.Enqueue(ctx, jobJoinRecheck, joinRecheckPayload{
+		UserID:    utils.UUIDToStr(a.UserID),
+		ProjectID: utils.UUIDToStr(a.ProjectID),
+		AttemptID: utils.UUIDToStr(a.ID),
+		Since:     since.Unix(),
+	}, next); err != nil {
+		log.Printf("[verify-access] failed to schedule re-check for %s: %v", utils.UUIDToStr(a.UserID), err)
+	}
+}
+
+// runJoinRecheck checks a user who failed a loop's requirements again,
+// notifying them if they now qualify and queueing the next re-check if not
+func (h *Handler) runJoinRecheck(ctx context.Context, raw json.RawMessage) error {
+	var p joinRecheckPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	uid, err := utils.StrToUUID(p.UserID)
+	if err != nil {
+		return fmt.Errorf("bad user id: %w", err)
+	}
+	projectID, err := utils.StrToUUID(p.ProjectID)
+	if err != nil {
+		return fmt.Errorf("bad project id: %w", err)
+	}
+	since := time.Unix(p.Since, 0)
+
+	latest, err := h.Queries.ListVerificationAttempts(ctx, db.ListVerificationAttemptsParams{UserID: uid, ProjectID: projectID, Limit: 1})
+	if err != nil {
+		return err
+	}
+	if len(latest) == 0 || utils.UUIDToStr(latest[0].ID) != p.AttemptID || latest[0].Passed {
+		return nil // superseded by a newer attempt
+	}
+	if role := h.Members.Role(ctx, uid, projectID); role != "" && role != members.Guest {
+		return nil // joined since
+	}
+	if banned, err := h.Queries.IsBannedFromLoop(ctx, db.IsBannedFromLoopParams{ProjectID: projectID, UserID: uid}); err != nil || banned {
+		return err
+	}
+	project, err := h.Loops.ByID(ctx, projectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil || user.AccessToken == "" {
+		return nil // deleted, or can't call GitHub as them
+	}
+
+	v, err := h.checkJoinRequirements(ctx, user, project)
+	if errors.Is(err, errRepoUnresolved) || errors.Is(err, errUnverifiable) {
+		// GitHub didn't answer this time; try again tomorrow
+		h.scheduleJoinRecheck(ctx, db.LoopVerificationAttempt{
+			ID:        latest[0].ID,
+			UserID:    uid,
+			ProjectID: projectID,
+			CheckedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+		}, since)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	attempt, err := h.recordVerification(ctx, uid, projectID, v, verificationRecheck)
+	if err != nil {
+		return err
+	}
+	if !v.Passed {
+		h.scheduleJoinRecheck(ctx, attempt, since)
+		return nil
+	}
+
+	if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+		Type:      "join_eligible",
+		UserID:    uid,
+		ProjectID: projectID,
+		Preview:   i18n.T(h.recipientLocale(ctx, uid), "You now meet the requirements to join %s", project.Name),
+		Extra:     map[string]any{"loop_name": project.Name},
+	}); err != nil {
+		log.Printf("[verify-access] failed to notify %s about %s: %v", user.Username, project.Name, err)
+	}
+	return nil
+}
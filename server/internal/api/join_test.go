@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"wireloop/internal/gatekeeper"
+	"wireloop/internal/members"
+	"wireloop/internal/testkit"
+)
+
+func TestJoinLoop(t *testing.T) {
+	cases := []struct {
+		name   string
+		rules  map[gatekeeper.CriteriaType]string
+		setup  func(repo *testkit.Repo)
+		status int
+		member bool
+	}{
+		{"no rules", nil, nil, http.StatusOK, true},
+		{
+			"meets the rules",
+			map[gatekeeper.CriteriaType]string{gatekeeper.PRMerged: "1", gatekeeper.CommitCount: "2"},
+			func(repo *testkit.Repo) {
+				repo.AddPull("ana", "Fix", true)
+				repo.Commits["ana"] = 2
+			},
+			http.StatusOK, true,
+		},
+		{
+			"falls short",
+			map[gatekeeper.CriteriaType]string{gatekeeper.PRMerged: "2"},
+			func(repo *testkit.Repo) { repo.AddPull("ana", "Fix", true) },
+			http.StatusForbidden, false,
+		},
+		{
+			"collaborators skip the rules",
+			map[gatekeeper.CriteriaType]string{gatekeeper.PRMerged: "5"},
+			func(repo *testkit.Repo) { repo.Collaborators["ana"] = "push" },
+			http.StatusOK, true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			it := newIntegration(t)
+			owner := it.user("maintainer", 1)
+			ana := it.user("ana", 2)
+			repo := it.gh.AddRepo("maintainer", "app", false)
+			if tc.setup != nil {
+				tc.setup(repo)
+			}
+			project := it.loop("app", repo, owner, tc.rules)
+
+			status := it.do(it.h.HandleJoinLoop, http.MethodPost, "/api/loops/:name/join", "/api/loops/app/join", ana.ID, nil, nil)
+			if status != tc.status {
+				t.Fatalf("status = %d, want %d", status, tc.status)
+			}
+			if got := it.h.Members.IsMember(context.Background(), ana.ID, project.ID); got != tc.member {
+				t.Errorf("member = %v, want %v", got, tc.member)
+			}
+		})
+	}
+}
+
+func TestVerifyAccessReportsRules(t *testing.T) {
+	it := newIntegration(t)
+	owner := it.user("maintainer", 1)
+	ana := it.user("ana", 2)
+	repo := it.gh.AddRepo("maintainer", "app", false)
+	repo.AddIssue("ana", "Bug", "open")
+	project := it.loop("app", repo, owner, map[gatekeeper.CriteriaType]string{gatekeeper.IssueCount: "1", gatekeeper.PRCount: "1"})
+
+	var out struct {
+		CanJoin bool                            `json:"can_join"`
+		Results []gatekeeper.VerificationResult `json:"results"`
+	}
+	status := it.do(it.h.HandleVerifyAccess, http.MethodPost, "/api/verify-access", "/api/verify-access", ana.ID, VerifyAccessRequest{LoopName: "app"}, &out)
+	if status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if out.CanJoin || len(out.Results) != 2 {
+		t.Fatalf("verify = %+v, want two rules and no entry", out)
+	}
+
+	// Owners are members already and skip verification
+	status = it.do(it.h.HandleVerifyAccess, http.MethodPost, "/api/verify-access", "/api/verify-access", owner.ID, VerifyAccessRequest{LoopName: "app"}, &out)
+	if status != http.StatusOK || !out.CanJoin {
+		t.Errorf("owner verify = %d %+v", status, out)
+	}
+	if role := it.h.Members.Role(context.Background(), owner.ID, project.ID); role != members.Owner {
+		t.Errorf("owner role = %q", role)
+	}
+}
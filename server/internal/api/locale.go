@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOCALIZATION
+// Server-generated text is written in English and translated with
+// i18n.T. A request is answered in the caller's saved locale, else the best
+// match for its Accept-Language header; text sent to someone else
+// (notifications, welcome messages) uses the recipient's saved locale.
+// Local times a user types are read in their saved time zone, UTC by default.
+// ============================================================================
+
+// localDateTime is a wall-clock time without an offset, as typed into a
+// datetime-local input
+const localDateTime = "2006-01-02T15:04"
+
+var (
+	userLocaleCache   = cache.New[string, string](lookupTTL, 5000)         // by user ID; "" when unset
+	userTimezoneCache = cache.New[string, *time.Location](lookupTTL, 5000) // by user ID
+)
+
+// userLocale returns the user's saved locale, or "" when they haven't chosen one
+func (h *Handler) userLocale(ctx context.Context, uid pgtype.UUID) string {
+	locale, err := userLocaleCache.GetOrLoad(utils.UUIDToStr(uid), func() (string, error) {
+		l, err := h.Queries.GetUserLocale(ctx, uid)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", nil
+		}
+		return l.String, err
+	})
+	if err != nil {
+		log.Printf("[i18n] failed to load locale: %v", err)
+		return ""
+	}
+	return locale
+}
+
+// recipientLocale is the locale to address uid in when they aren't the one
+// making the request
+func (h *Handler) recipientLocale(ctx context.Context, uid pgtype.UUID) string {
+	if l := h.userLocale(ctx, uid); l != "" {
+		return l
+	}
+	return i18n.Default
+}
+
+// requestLocale is the locale to answer c in
+func (h *Handler) requestLocale(c *gin.Context) string {
+	if uid, ok := utils.GetUserIdFromContext(c); ok {
+		if l := h.userLocale(c, uid); l != "" {
+			return l
+		}
+	}
+	if l := i18n.FromAcceptLanguage(c.GetHeader("Accept-Language")); l != "" {
+		return l
+	}
+	return i18n.Default
+}
+
+// tr translates msg into the request's locale
+func (h *Handler) tr(c *gin.Context, msg string, args ...any) string {
+	return i18n.T(h.requestLocale(c), msg, args...)
+}
+
+// LocalizeProblem translates error details; install it as problem.Localize
+func (h *Handler) LocalizeProblem(c *gin.Context, detail string) string {
+	return h.tr(c, detail)
+}
+
+// userTimezone returns the user's saved time zone, or UTC
+func (h *Handler) userTimezone(ctx context.Context, uid pgtype.UUID) *time.Location {
+	loc, err := userTimezoneCache.GetOrLoad(utils.UUIDToStr(uid), func() (*time.Location, error) {
+		tz, err := h.Queries.GetUserTimezone(ctx, uid)
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && !tz.Valid) {
+			return time.UTC, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return time.LoadLocation(tz.String)
+	})
+	if err != nil {
+		log.Printf("[i18n] failed to load time zone: %v", err)
+		return time.UTC
+	}
+	return loc
+}
+
+// parseUserTime reads an RFC 3339 timestamp, or a localDateTime in loc
+func parseUserTime(s string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation(localDateTime, s, loc)
+}
+
+func invalidateUserLocale(id pgtype.UUID) {
+	lookupInvalidator.Invalidate("user_locale", utils.UUIDToStr(id))
+}
+
+func invalidateUserTimezone(id pgtype.UUID) {
+	lookupInvalidator.Invalidate("user_timezone", utils.UUIDToStr(id))
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LONG POSTS
+// Messages are capped at Handler.MaxMessageChars. Longer markdown can be sent
+// over REST as a long post: the full text is stored like an attachment and
+// the message carries a preview, with the rest fetched on demand.
+// ============================================================================
+
+const (
+	defaultMaxMessageChars = 8000
+	maxLongPostBytes       = 512 << 10
+	longPostPreviewChars   = 600
+	longPostContentType    = "text/markdown; charset=utf-8"
+)
+
+// LongPostRef marks a message whose content is a preview of a long post
+type LongPostRef struct {
+	Chars int   `json:"chars"` // length of the full post
+	Size  int64 `json:"size"`  // bytes
+}
+
+// messageTooLong is the rejection for content over the limit, or "" when it fits
+func (h *Handler) messageTooLong(content string) string {
+	if utf8.RuneCountInString(content) <= h.MaxMessageChars {
+		return ""
+	}
+	return fmt.Sprintf("message must be at most %d characters; send it as a long post instead", h.MaxMessageChars)
+}
+
+// respondMessageTooLong writes the 413 for a send over the limit
+func (h *Handler) respondMessageTooLong(c *gin.Context, content string) {
+	problem.RespondCode(c, 413, "message_too_long", h.messageTooLong(content), gin.H{
+		"limit":     h.MaxMessageChars,
+		"length":    utf8.RuneCountInString(content),
+		"long_post": true,
+	})
+}
+
+// longPostPreview cuts content to longPostPreviewChars, at a line or word
+// break when there is one in the last quarter
+func longPostPreview(content string) string {
+	runes := []rune(content)
+	if len(runes) <= longPostPreviewChars {
+		return content
+	}
+	cut := string(runes[:longPostPreviewChars])
+	floor := len(cut) * 3 / 4
+	if i := strings.LastIndex(cut, "\n"); i > floor {
+		cut = cut[:i]
+	} else if i := strings.LastIndexAny(cut, " \t"); i > floor {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " \t\n") + "…"
+}
+
+// storeLongPost stores the full markdown as an attachment of the channel.
+// It's text the message filters already screened, so it skips the scan.
+func (h *Handler) storeLongPost(ctx context.Context, projectID, channelID, uploader pgtype.UUID, msgID int64, content string) (db.Attachment, error) {
+	key := utils.UUIDToStr(projectID) + "/" + strconv.FormatInt(utils.GetMessageId(), 10)
+	if err := h.Storage.Put(ctx, key, []byte(content)); err != nil {
+		return db.Attachment{}, err
+	}
+	a, err := h.Queries.CreateAttachment(ctx, db.CreateAttachmentParams{
+		ProjectID:   projectID,
+		ChannelID:   channelID,
+		UploaderID:  uploader,
+		Filename:    "post-" + strconv.FormatInt(msgID, 10) + ".md",
+		ContentType: longPostContentType,
+		SizeBytes:   int64(len(content)),
+		StorageKey:  key,
+	})
+	if err != nil {
+		h.Storage.Delete(context.Background(), key)
+		return db.Attachment{}, err
+	}
+	if err := h.Queries.SetAttachmentScanResult(ctx, db.SetAttachmentScanResultParams{
+		ID:         a.ID,
+		ScanStatus: scanStatusClean,
+	}); err != nil {
+		return a, err
+	}
+	a.ScanStatus = scanStatusClean
+	return a, nil
+}
+
+// attachLongPosts marks the messages that are long post previews
+func (h *Handler) attachLongPosts(ctx context.Context, msgs []MessageResponse) {
+	ids := make([]int64, 0, len(msgs))
+	index := make(map[int64]int, len(msgs))
+	for i, m := range msgs {
+		if m.Deleted {
+			continue
+		}
+		if id, err := strconv.ParseInt(m.ID, 10, 64); err == nil {
+			ids = append(ids, id)
+			index[id] = i
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	rows, err := h.Queries.ListLongPosts(ctx, ids)
+	if err != nil {
+		log.Printf("[long-posts] lookup failed: %v", err)
+		return
+	}
+	for _, r := range rows {
+		msgs[index[r.MessageID]].LongPost = &LongPostRef{Chars: int(r.Chars), Size: r.SizeBytes}
+	}
+}
+
+// HandleGetLongPost returns the full markdown of a long post, to anyone who
+// can read its channel
+func (h *Handler) HandleGetLongPost(c *gin.Context) {
+	uid, _ := utils.GetUserIdFromContext(c)
+	messageID, err := strconv.ParseInt(c.Param("message_id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid message id")
+		return
+	}
+	post, err := h.Queries.GetLongPost(c, messageID)
+	if err != nil {
+		problem.Respond(c, 404, "long post not found")
+		return
+	}
+	if !h.channelReadAccess(c, uid, post.ProjectID, post.ChannelID) {
+		return
+	}
+	data, err := h.Storage.Get(c, post.StorageKey)
+	if err != nil {
+		log.Printf("[long-posts] read %d failed: %v", messageID, err)
+		problem.Respond(c, 500, "failed to read long post")
+		return
+	}
+	c.Data(200, longPostContentType, data)
+}
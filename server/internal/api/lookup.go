@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// Read-through cache for hot lookups (users and workspaces by ID; loops are
+// cached by the loops service and invalidated through here too)
+// These run on nearly every request, often several times per request.
+// ============================================================================
+
+const lookupTTL = 30 * time.Second
+
+var (
+	userByIDCache      = cache.New[string, db.User](lookupTTL, 5000)
+	workspaceByIDCache = cache.New[string, db.Workspace](lookupTTL, 2000)
+
+	lookupInvalidator = newLookupInvalidator(nil)
+)
+
+func newLookupInvalidator(rdb *redis.Client) *cache.Invalidator {
+	inv := cache.NewInvalidator(rdb)
+	inv.Register("user_id", userByIDCache.Delete)
+	inv.Register("workspace_id", workspaceByIDCache.Delete)
+	inv.Register("filter_config", filterConfigCache.Delete)
+	inv.Register("channel_link", channelLinkCache.Delete)
+	inv.Register("session", sessionActiveCache.Delete)
+	inv.Register("api_key", apiKeyCache.Delete)
+	inv.Register("access_token", accessTokenCache.Delete)
+	inv.Register("loop_emoji", loopEmojiCache.Delete)
+	inv.Register("user_locale", userLocaleCache.Delete)
+	inv.Register("user_timezone", userTimezoneCache.Delete)
+	inv.Register("loop_github_token", loopGitHubTokenCache.Delete)
+	inv.Register("loop_mirror", loopMirrorCache.Delete)
+	inv.Register("channel_policy", channelPolicyCache.Delete)
+	inv.Register("github_lists", dropGitHubLists)
+	inv.Register("undo_send", undoSendCache.Delete)
+	return inv
+}
+
+// EnableCacheInvalidation propagates lookup cache invalidations to other
+// server instances via Redis. Call once at startup, before serving.
+func EnableCacheInvalidation(rdb *redis.Client) {
+	if rdb != nil {
+		lookupInvalidator = newLookupInvalidator(rdb)
+	}
+}
+
+func (h *Handler) getUserByID(ctx context.Context, id pgtype.UUID) (db.User, error) {
+	return userByIDCache.GetOrLoad(utils.UUIDToStr(id), func() (db.User, error) {
+		return h.Queries.GetUserByID(ctx, id)
+	})
+}
+
+func (h *Handler) getWorkspaceByID(ctx context.Context, id pgtype.UUID) (db.Workspace, error) {
+	return workspaceByIDCache.GetOrLoad(utils.UUIDToStr(id), func() (db.Workspace, error) {
+		return h.Queries.GetWorkspaceByID(ctx, id)
+	})
+}
+
+// invalidateProject must be called after any write to a project row
+func invalidateProject(p db.Project) {
+	lookupInvalidator.Invalidate("project_name", p.Name)
+	lookupInvalidator.Invalidate("project_id", utils.UUIDToStr(p.ID))
+}
+
+// invalidateUser must be called after any write to a user row
+func invalidateUser(id pgtype.UUID) {
+	lookupInvalidator.Invalidate("user_id", utils.UUIDToStr(id))
+}
+
+// invalidateWorkspace must be called after any write to a workspace row
+func invalidateWorkspace(id pgtype.UUID) {
+	lookupInvalidator.Invalidate("workspace_id", utils.UUIDToStr(id))
+}
@@ -0,0 +1,477 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/gatekeeper"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
+	"wireloop/internal/types"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOOP CONFIG — export a loop's setup as JSON and apply it to another loop
+// Channels are referred to by name so a file works across instances; no
+// messages, members or invites are included.
+// ============================================================================
+
+const loopConfigVersion = 1
+
+type LoopConfig struct {
+	Version      int                    `json:"version"`
+	ExportedAt   string                 `json:"exported_at,omitempty"`
+	Loop         string                 `json:"loop,omitempty"` // source loop, informational
+	Channels     []LoopConfigChannel    `json:"channels" binding:"max=100,dive"`
+	Rules        []types.Rule           `json:"rules" binding:"max=20,dive"`
+	Roles        LoopConfigRoles        `json:"roles"`
+	Settings     LoopConfigSettings     `json:"settings"`
+	Filters      *FilterSettingsRequest `json:"filters,omitempty"`
+	Integrations LoopConfigIntegrations `json:"integrations"`
+}
+
+type LoopConfigChannel struct {
+	Name        string `json:"name" binding:"required,notblank,max=100"`
+	Description string `json:"description,omitempty" binding:"max=500"`
+	IsDefault   bool   `json:"is_default,omitempty"`
+	Position    int    `json:"position"`
+}
+
+// LoopConfigRoles; moderators are GitHub usernames and are only promoted if
+// they are already members of the importing loop
+type LoopConfigRoles struct {
+	Moderators    []string `json:"moderators" binding:"max=100"`
+	GuestChannels []string `json:"guest_channels" binding:"max=50"`
+}
+
+type LoopConfigSettings struct {
+	WelcomeChannel  string   `json:"welcome_channel,omitempty"`
+	WelcomeTemplate string   `json:"welcome_template,omitempty" binding:"max=2000"`
+	WelcomeDM       bool     `json:"welcome_dm,omitempty"`
+	Visibility      string   `json:"visibility,omitempty" binding:"omitempty,oneof=members public"`
+	PublicChannels  []string `json:"public_channels" binding:"max=50"`
+	PinRole         string   `json:"pin_role,omitempty" binding:"omitempty,oneof=members moderators"`
+	PinLimit        int      `json:"pin_limit,omitempty" binding:"omitempty,min=1,max=250"`
+	// Notification level new members start at
+	DefaultNotifyLevel string `json:"default_notify_level,omitempty" binding:"omitempty,oneof=all mentions none"`
+	ThreadSummaries    string `json:"thread_summaries,omitempty" binding:"omitempty,oneof=off offer auto"`
+	AIDisabled         bool   `json:"ai_disabled,omitempty"`
+	AIModel            string `json:"ai_model,omitempty"`
+	AIMaxTokens        int    `json:"ai_max_tokens,omitempty" binding:"min=0,max=2048"`
+	// Omitted for the default
+	AITemperature *float64 `json:"ai_temperature,omitempty" binding:"omitnil,min=0,max=2"`
+	AISafety      string   `json:"ai_safety,omitempty" binding:"omitempty,oneof=block_none block_few block_some block_most"`
+	// null sends every kind of content to the model
+	AIContent []string `json:"ai_content" binding:"max=10,dive,oneof=bodies comments chat releases"`
+	// Language code and tone preset of AI-written content; omitted for the
+	// defaults
+	AILanguage string `json:"ai_language,omitempty"`
+	AITone     string `json:"ai_tone,omitempty" binding:"omitempty,oneof=formal casual bullets"`
+	// What a member losing access to the repo leads to: notify or demote
+	AccessRevoked string `json:"access_revoked,omitempty" binding:"omitempty,oneof=notify demote"`
+}
+
+type LoopConfigIntegrations struct {
+	GitHub LoopConfigGitHub `json:"github"`
+}
+
+type LoopConfigGitHub struct {
+	AnnouncementsChannel  string `json:"announcements_channel,omitempty"`
+	DeployChannel         string `json:"deploy_channel,omitempty"`
+	ProductionEnvironment string `json:"production_environment,omitempty" binding:"max=255"`
+	FirstPRChannel        string `json:"first_pr_channel,omitempty"`
+	FirstPRTemplate       string `json:"first_pr_template,omitempty" binding:"max=2000"`
+}
+
+// ownedLoop resolves :name and checks the caller owns it
+func (h *Handler) ownedLoop(c *gin.Context, action string) (db.Project, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Project{}, false
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return db.Project{}, false
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can "+action)
+		return db.Project{}, false
+	}
+	return project, true
+}
+
+// HandleExportLoopConfig returns the loop's configuration as a downloadable
+// JSON file (owner only)
+func (h *Handler) HandleExportLoopConfig(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "export the loop configuration")
+	if !ok {
+		return
+	}
+	cfg, err := h.exportLoopConfig(c, project)
+	if err != nil {
+		log.Printf("[loop-config] export of %s failed: %v", project.Name, err)
+		problem.Respond(c, 500, "failed to export configuration")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-config.json"`, project.Name))
+	c.JSON(200, cfg)
+}
+
+func (h *Handler) exportLoopConfig(ctx context.Context, project db.Project) (LoopConfig, error) {
+	cfg := LoopConfig{
+		Version:    loopConfigVersion,
+		ExportedAt: utils.FormatTime(time.Now()),
+		Loop:       project.Name,
+		Rules:      []types.Rule{},
+		Roles:      LoopConfigRoles{Moderators: []string{}, GuestChannels: []string{}},
+		Settings:   LoopConfigSettings{PublicChannels: []string{}},
+	}
+
+	channels, err := h.Queries.GetChannelsByProject(ctx, project.ID)
+	if err != nil {
+		return cfg, err
+	}
+	names := make(map[pgtype.UUID]string, len(channels))
+	cfg.Channels = make([]LoopConfigChannel, len(channels))
+	for i, ch := range channels {
+		names[ch.ID] = ch.Name
+		cfg.Channels[i] = LoopConfigChannel{
+			Name:        ch.Name,
+			Description: ch.Description.String,
+			IsDefault:   ch.IsDefault.Bool,
+			Position:    int(ch.Position.Int32),
+		}
+	}
+	channelNames := func(ids []pgtype.UUID) []string {
+		out := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if n, ok := names[id]; ok {
+				out = append(out, n)
+			}
+		}
+		return out
+	}
+
+	rules, err := h.Queries.GetRulesByProject(ctx, project.ID)
+	if err != nil {
+		return cfg, err
+	}
+	for _, r := range rules {
+		threshold, _ := gatekeeper.ParseThreshold(r.Threshold)
+		cfg.Rules = append(cfg.Rules, types.Rule{CriteriaType: r.CriteriaType, Threshold: threshold})
+	}
+
+	loopMembers, err := h.Queries.GetLoopMembers(ctx, project.ID)
+	if err != nil {
+		return cfg, err
+	}
+	for _, m := range loopMembers {
+		if m.Role.String == members.Moderator {
+			cfg.Roles.Moderators = append(cfg.Roles.Moderators, m.Username)
+		}
+	}
+
+	s, err := h.Queries.GetLoopSettings(ctx, project.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return cfg, err
+	}
+	cfg.Roles.GuestChannels = channelNames(s.GuestChannelIds)
+	pinRole, pinLimit := pinPolicy(s)
+	var temperature *float64
+	if s.AiTemperature.Valid {
+		t := float64(s.AiTemperature.Float32)
+		temperature = &t
+	}
+	cfg.Settings = LoopConfigSettings{
+		WelcomeChannel:     names[s.WelcomeChannelID],
+		WelcomeTemplate:    s.WelcomeTemplate,
+		WelcomeDM:          s.WelcomeDm,
+		Visibility:         s.Visibility,
+		PublicChannels:     channelNames(s.PublicChannelIds),
+		PinRole:            pinRole,
+		PinLimit:           int(pinLimit),
+		DefaultNotifyLevel: loopDefaultNotifyLevel(s),
+		ThreadSummaries:    loopThreadSummaries(s),
+		AIDisabled:         s.AiDisabled,
+		AIModel:            s.AiModel,
+		AIMaxTokens:        int(s.AiMaxTokens),
+		AITemperature:      temperature,
+		AISafety:           s.AiSafety,
+		AIContent:          s.AiContent,
+		AILanguage:         s.AiLanguage,
+		AITone:             s.AiTone,
+		AccessRevoked:      loopAccessRevoked(s),
+	}
+
+	gh, err := h.Queries.GetLoopGithubSettings(ctx, project.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return cfg, err
+	}
+	cfg.Integrations.GitHub = LoopConfigGitHub{
+		AnnouncementsChannel:  names[gh.AnnouncementsChannelID],
+		DeployChannel:         names[gh.DeployChannelID],
+		ProductionEnvironment: gh.ProductionEnvironment,
+		FirstPRChannel:        names[gh.FirstPrChannelID],
+		FirstPRTemplate:       gh.FirstPrTemplate,
+	}
+
+	filters := filterSettingsResponse(h.filterConfig(ctx, project.ID))
+	cfg.Filters = &filters
+	return cfg, nil
+}
+
+// validateLoopConfig checks an uploaded config before anything is written
+// and returns the problem to report, or ""
+func validateLoopConfig(cfg *LoopConfig) string {
+	if cfg.Version != loopConfigVersion {
+		return fmt.Sprintf("unsupported config version %d", cfg.Version)
+	}
+	names := make(map[string]bool, len(cfg.Channels))
+	for i := range cfg.Channels {
+		ch := &cfg.Channels[i]
+		ch.Name = strings.TrimSpace(ch.Name)
+		if names[ch.Name] {
+			return "duplicate channel " + ch.Name
+		}
+		names[ch.Name] = true
+	}
+	if m := cfg.Settings.AIModel; m != "" && !aiModels[m] {
+		return "unknown AI model " + m
+	}
+	if l := cfg.Settings.AILanguage; l != "" && aiLanguages[l] == "" {
+		return "unknown AI language " + l
+	}
+	return ""
+}
+
+// HandleImportLoopConfig applies an exported configuration to the loop
+// (owner only). Channels are matched by name: missing ones are created and
+// existing ones updated, none are deleted. Rules are replaced, settings
+// overwritten, and everything happens in one transaction.
+func (h *Handler) HandleImportLoopConfig(c *gin.Context) {
+	var cfg LoopConfig
+	if !bindStrictJSON(c, &cfg) {
+		return
+	}
+	if invalid := validateLoopConfig(&cfg); invalid != "" {
+		problem.Respond(c, 400, invalid)
+		return
+	}
+	var filterWords []string
+	if cfg.Filters != nil {
+		var invalid string
+		if filterWords, invalid = validateFilterSettings(*cfg.Filters); invalid != "" {
+			problem.Respond(c, 400, invalid)
+			return
+		}
+	}
+
+	project, ok := h.ownedLoop(c, "import a loop configuration")
+	if !ok {
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "failed to import configuration")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	// Channels, by name
+	existing, err := qtx.GetChannelsByProject(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to import configuration")
+		return
+	}
+	ids := make(map[string]pgtype.UUID, len(existing)+len(cfg.Channels))
+	for _, ch := range existing {
+		ids[ch.Name] = ch.ID
+	}
+	created := 0
+	var defaultID pgtype.UUID
+	for _, ch := range cfg.Channels {
+		desc := pgtype.Text{String: ch.Description, Valid: ch.Description != ""}
+		pos := pgtype.Int4{Int32: int32(ch.Position), Valid: true}
+		if id, ok := ids[ch.Name]; ok {
+			if _, err := qtx.UpdateChannel(c, db.UpdateChannelParams{ID: id, Name: ch.Name, Description: desc, Position: pos}); err != nil {
+				problem.Respond(c, 500, "failed to update channel "+ch.Name)
+				return
+			}
+		} else {
+			row, err := qtx.CreateChannel(c, db.CreateChannelParams{
+				ProjectID:   project.ID,
+				Name:        ch.Name,
+				Description: desc,
+				IsDefault:   pgtype.Bool{Bool: false, Valid: true},
+				Position:    pos,
+			})
+			if err != nil {
+				problem.Respond(c, 500, "failed to create channel "+ch.Name)
+				return
+			}
+			ids[ch.Name] = row.ID
+			created++
+		}
+		if ch.IsDefault {
+			defaultID = ids[ch.Name]
+		}
+	}
+	if defaultID.Valid {
+		if err := qtx.SetDefaultChannel(c, db.SetDefaultChannelParams{ProjectID: project.ID, ID: defaultID}); err != nil {
+			problem.Respond(c, 500, "failed to set default channel")
+			return
+		}
+	}
+
+	// Unknown names leave the reference empty rather than failing the import
+	channelID := func(name string) pgtype.UUID { return ids[name] }
+	channelIDs := func(names []string) []pgtype.UUID {
+		out := make([]pgtype.UUID, 0, len(names))
+		for _, n := range names {
+			if id, ok := ids[n]; ok {
+				out = append(out, id)
+			}
+		}
+		return out
+	}
+
+	// Gatekeeper rules
+	if err := qtx.DeleteRulesByProject(c, project.ID); err != nil {
+		problem.Respond(c, 500, "failed to replace rules")
+		return
+	}
+	for _, r := range cfg.Rules {
+		if _, err := qtx.CreateRule(c, db.CreateRuleParams{
+			ProjectID:    project.ID,
+			CriteriaType: r.CriteriaType,
+			Threshold:    strconv.Itoa(r.Threshold),
+		}); err != nil {
+			problem.Respond(c, 500, "failed to replace rules")
+			return
+		}
+	}
+
+	// Settings and integrations
+	visibility := cfg.Settings.Visibility
+	if visibility == "" {
+		visibility = loopVisibilityMembers
+	}
+	pinRole, pinLimit := pinPolicy(db.LoopSetting{PinRole: cfg.Settings.PinRole, PinLimit: int32(cfg.Settings.PinLimit)})
+	var temperature pgtype.Float4
+	if t := cfg.Settings.AITemperature; t != nil {
+		temperature = pgtype.Float4{Float32: float32(*t), Valid: true}
+	}
+	var aiContent []string
+	if cfg.Settings.AIContent != nil {
+		aiContent = aiContentList(cfg.Settings.AIContent)
+	}
+	if _, err := qtx.UpsertLoopSettings(c, db.UpsertLoopSettingsParams{
+		ProjectID:          project.ID,
+		WelcomeChannelID:   channelID(cfg.Settings.WelcomeChannel),
+		WelcomeTemplate:    strings.TrimSpace(cfg.Settings.WelcomeTemplate),
+		WelcomeDm:          cfg.Settings.WelcomeDM,
+		Visibility:         visibility,
+		PublicChannelIds:   channelIDs(cfg.Settings.PublicChannels),
+		GuestChannelIds:    channelIDs(cfg.Roles.GuestChannels),
+		PinRole:            pinRole,
+		PinLimit:           pinLimit,
+		DefaultNotifyLevel: loopDefaultNotifyLevel(db.LoopSetting{DefaultNotifyLevel: cfg.Settings.DefaultNotifyLevel}),
+		ThreadSummaries:    loopThreadSummaries(db.LoopSetting{ThreadSummaries: cfg.Settings.ThreadSummaries}),
+		AiDisabled:         cfg.Settings.AIDisabled,
+		AiModel:            cfg.Settings.AIModel,
+		AiMaxTokens:        int32(cfg.Settings.AIMaxTokens),
+		AiTemperature:      temperature,
+		AiSafety:           cfg.Settings.AISafety,
+		AiContent:          aiContent,
+		AiLanguage:         cfg.Settings.AILanguage,
+		AiTone:             cfg.Settings.AITone,
+		AccessRevoked:      loopAccessRevoked(db.LoopSetting{AccessRevoked: cfg.Settings.AccessRevoked}),
+	}); err != nil {
+		problem.Respond(c, 500, "failed to save settings")
+		return
+	}
+	gh := cfg.Integrations.GitHub
+	env := strings.TrimSpace(gh.ProductionEnvironment)
+	if env == "" {
+		env = defaultProductionEnvironment
+	}
+	if _, err := qtx.UpsertLoopGithubSettings(c, db.UpsertLoopGithubSettingsParams{
+		ProjectID:              project.ID,
+		AnnouncementsChannelID: channelID(gh.AnnouncementsChannel),
+		DeployChannelID:        channelID(gh.DeployChannel),
+		ProductionEnvironment:  env,
+		FirstPrChannelID:       channelID(gh.FirstPRChannel),
+		FirstPrTemplate:        strings.TrimSpace(gh.FirstPRTemplate),
+	}); err != nil {
+		problem.Respond(c, 500, "failed to save GitHub settings")
+		return
+	}
+	if cfg.Filters != nil {
+		f := cfg.Filters
+		if _, err := qtx.UpsertLoopFilterSettings(c, db.UpsertLoopFilterSettingsParams{
+			ProjectID:        project.ID,
+			BannedWords:      filterWords,
+			BannedWordAction: f.BannedWordAction,
+			MaxLinks:         int32(f.MaxLinks),
+			LinkSpamAction:   f.LinkSpamAction,
+			RepeatLimit:      int32(f.RepeatLimit),
+			RepeatAction:     f.RepeatAction,
+		}); err != nil {
+			problem.Respond(c, 500, "failed to save filter settings")
+			return
+		}
+	}
+
+	// Moderators can only be promoted once they have joined
+	promoted := []string{}
+	skipped := []string{}
+	for _, name := range cfg.Roles.Moderators {
+		user, err := h.userByHandle(c, name)
+		if err != nil || user.ID == project.OwnerID {
+			skipped = append(skipped, name)
+			continue
+		}
+		n, err := qtx.SetMembershipRole(c, db.SetMembershipRoleParams{
+			UserID:    user.ID,
+			ProjectID: project.ID,
+			Role:      pgtype.Text{String: members.Moderator, Valid: true},
+		})
+		if err != nil {
+			problem.Respond(c, 500, "failed to apply roles")
+			return
+		}
+		if n == 0 {
+			skipped = append(skipped, name)
+		} else {
+			promoted = append(promoted, user.Username)
+		}
+	}
+
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to import configuration")
+		return
+	}
+	lookupInvalidator.Invalidate("filter_config", utils.UUIDToStr(project.ID))
+
+	c.JSON(200, gin.H{
+		"message":              "configuration imported",
+		"channels_created":     created,
+		"moderators_promoted":  promoted,
+		"moderators_not_found": skipped,
+	})
+}
@@ -0,0 +1,397 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOOP SETTINGS — general per-loop preferences (welcome message, visibility)
+// ============================================================================
+
+// Loop visibility. Public loops expose their public_channel_ids read-only to
+// everyone, including anonymous visitors; joining and posting are unchanged.
+const (
+	loopVisibilityMembers = "members"
+	loopVisibilityPublic  = "public"
+)
+
+type LoopSettingsResponse struct {
+	WelcomeChannelID string   `json:"welcome_channel_id"`
+	WelcomeTemplate  string   `json:"welcome_template"`
+	WelcomeDM        bool     `json:"welcome_dm"`
+	Visibility       string   `json:"visibility"`
+	PublicChannelIDs []string `json:"public_channel_ids"`
+	GuestChannelIDs  []string `json:"guest_channel_ids"`
+	PinRole          string   `json:"pin_role"`
+	PinLimit         int      `json:"pin_limit"`
+	DigestChannelID  string   `json:"digest_channel_id"`
+	// The notification level new members start at: all, mentions or none
+	DefaultNotifyLevel string `json:"default_notify_level"`
+	// AI summaries of long threads: off, offer or auto
+	ThreadSummaries string `json:"thread_summaries"`
+	// False when the loop turned AI off: nothing of it is sent to the model
+	AIEnabled bool `json:"ai_enabled"`
+	// Empty for the server's default model
+	AIModel string `json:"ai_model"`
+	// Cap on each AI answer's length; 0 leaves each feature its own
+	AIMaxTokens   int      `json:"ai_max_tokens"`
+	AITemperature *float64 `json:"ai_temperature"` // null for the default
+	// How strictly answers are filtered: default, block_none, block_few,
+	// block_some or block_most
+	AISafety string `json:"ai_safety"`
+	// What the AI features may send to the model, of bodies, comments, chat
+	// and releases
+	AIContent []string `json:"ai_content"`
+	// The language code AI-written content is in; empty for English
+	AILanguage string `json:"ai_language"`
+	// The tone of AI-written content: default, formal, casual or bullets
+	AITone string `json:"ai_tone"`
+	// What GitHub reporting that a member lost access to the repo leads to:
+	// notify tells the owner, demote also drops moderators to contributor
+	AccessRevoked string `json:"access_revoked"`
+	// What new members currently receive, with the default filled in
+	WelcomePreview string `json:"welcome_preview"`
+}
+
+// UpdateLoopSettingsRequest; omitted fields are left unchanged.
+// welcome_template takes {username} and {loop}; empty restores the default.
+type UpdateLoopSettingsRequest struct {
+	WelcomeChannelID *string `json:"welcome_channel_id"` // empty stops channel welcomes
+	WelcomeTemplate  *string `json:"welcome_template" binding:"omitnil,max=2000"`
+	WelcomeDM        *bool   `json:"welcome_dm"`
+	Visibility       *string `json:"visibility" binding:"omitnil,oneof=members public"`
+	// Channels readable by non-members when the loop is public
+	PublicChannelIDs *[]string `json:"public_channel_ids" binding:"omitnil,max=50"`
+	// The only channels guests can see and post in
+	GuestChannelIDs *[]string `json:"guest_channel_ids" binding:"omitnil,max=50"`
+	// Who may pin: any member but guests, or only the owner and moderators
+	PinRole *string `json:"pin_role" binding:"omitnil,oneof=members moderators"`
+	// Pins per channel
+	PinLimit *int `json:"pin_limit" binding:"omitnil,min=1,max=250"`
+	// Where the weekly top messages digest is posted; empty stops it
+	DigestChannelID *string `json:"digest_channel_id"`
+	// Applies to members who join from now on; current members keep theirs
+	DefaultNotifyLevel *string `json:"default_notify_level" binding:"omitnil,oneof=all mentions none"`
+	// Whether long threads get a summary on request (offer) or as replies
+	// arrive (auto)
+	ThreadSummaries *string `json:"thread_summaries" binding:"omitnil,oneof=off offer auto"`
+	AIEnabled       *bool   `json:"ai_enabled"`
+	// One of aiModels; empty restores the server's default
+	AIModel     *string `json:"ai_model" binding:"omitnil,max=100"`
+	AIMaxTokens *int    `json:"ai_max_tokens" binding:"omitnil,min=0,max=2048"`
+	// Negative restores the default
+	AITemperature *float64 `json:"ai_temperature" binding:"omitnil,max=2"`
+	AISafety      *string  `json:"ai_safety" binding:"omitnil,oneof=default block_none block_few block_some block_most"`
+	// The kinds of content the AI features may send; empty sends none
+	AIContent *[]string `json:"ai_content" binding:"omitnil,max=10,dive,oneof=bodies comments chat releases"`
+	// One of aiLanguages, for summaries, digests and release notes; empty
+	// restores English
+	AILanguage    *string `json:"ai_language" binding:"omitnil,max=10"`
+	AITone        *string `json:"ai_tone" binding:"omitnil,oneof=default formal casual bullets"`
+	AccessRevoked *string `json:"access_revoked" binding:"omitnil,oneof=notify demote"`
+}
+
+func loopSettingsToResponse(s db.LoopSetting, project db.Project, username string) LoopSettingsResponse {
+	tmpl := s.WelcomeTemplate
+	if tmpl == "" {
+		tmpl = defaultWelcomeTemplate
+	}
+	visibility := s.Visibility
+	if visibility == "" {
+		visibility = loopVisibilityMembers
+	}
+	pinRole, pinLimit := pinPolicy(s)
+	var temperature *float64
+	if s.AiTemperature.Valid {
+		t := float64(s.AiTemperature.Float32)
+		temperature = &t
+	}
+	safety := s.AiSafety
+	if safety == "" {
+		safety = "default"
+	}
+	content := aiContentKinds
+	if s.AiContent != nil {
+		content = aiContentList(s.AiContent)
+	}
+	tone := s.AiTone
+	if tone == "" {
+		tone = "default"
+	}
+	return LoopSettingsResponse{
+		WelcomeChannelID:   utils.UUIDToStr(s.WelcomeChannelID),
+		WelcomeTemplate:    s.WelcomeTemplate,
+		WelcomeDM:          s.WelcomeDm,
+		Visibility:         visibility,
+		PublicChannelIDs:   uuidsToStrs(s.PublicChannelIds),
+		GuestChannelIDs:    uuidsToStrs(s.GuestChannelIds),
+		PinRole:            pinRole,
+		PinLimit:           int(pinLimit),
+		DigestChannelID:    utils.UUIDToStr(s.DigestChannelID),
+		DefaultNotifyLevel: loopDefaultNotifyLevel(s),
+		ThreadSummaries:    loopThreadSummaries(s),
+		AIEnabled:          !s.AiDisabled,
+		AIModel:            s.AiModel,
+		AIMaxTokens:        int(s.AiMaxTokens),
+		AITemperature:      temperature,
+		AISafety:           safety,
+		AIContent:          content,
+		AILanguage:         s.AiLanguage,
+		AITone:             tone,
+		AccessRevoked:      loopAccessRevoked(s),
+		WelcomePreview:     renderTemplate(tmpl, map[string]string{"username": username, "loop": project.Name}),
+	}
+}
+
+// HandleGetLoopSettings returns the loop's settings (members only)
+func (h *Handler) HandleGetLoopSettings(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	s, err := h.Queries.GetLoopSettings(c, project.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		s, err = db.LoopSetting{ProjectID: project.ID}, nil
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to load settings")
+		return
+	}
+	user, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	c.JSON(200, loopSettingsToResponse(s, project, user.Username))
+}
+
+// HandleUpdateLoopSettings changes the loop's settings (owner only)
+func (h *Handler) HandleUpdateLoopSettings(c *gin.Context) {
+	var req UpdateLoopSettingsRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can change loop settings")
+		return
+	}
+
+	s, err := h.Queries.GetLoopSettings(c, project.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		s, err = db.LoopSetting{ProjectID: project.ID}, nil
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to load settings")
+		return
+	}
+	if req.WelcomeChannelID != nil {
+		if s.WelcomeChannelID, ok = h.loopChannelParam(c, project, *req.WelcomeChannelID); !ok {
+			return
+		}
+	}
+	if req.WelcomeTemplate != nil {
+		s.WelcomeTemplate = strings.TrimSpace(*req.WelcomeTemplate)
+	}
+	if req.WelcomeDM != nil {
+		s.WelcomeDm = *req.WelcomeDM
+	}
+	if req.Visibility != nil {
+		s.Visibility = *req.Visibility
+	}
+	if s.Visibility == "" {
+		s.Visibility = loopVisibilityMembers
+	}
+	if req.PublicChannelIDs != nil {
+		if s.PublicChannelIds, ok = h.loopChannelList(c, project, *req.PublicChannelIDs); !ok {
+			return
+		}
+	}
+	if req.GuestChannelIDs != nil {
+		if s.GuestChannelIds, ok = h.loopChannelList(c, project, *req.GuestChannelIDs); !ok {
+			return
+		}
+	}
+	if req.PinRole != nil {
+		s.PinRole = *req.PinRole
+	}
+	if req.PinLimit != nil {
+		s.PinLimit = int32(*req.PinLimit)
+	}
+	s.PinRole, s.PinLimit = pinPolicy(s)
+	if req.DigestChannelID != nil {
+		if s.DigestChannelID, ok = h.loopChannelParam(c, project, *req.DigestChannelID); !ok {
+			return
+		}
+	}
+	if req.DefaultNotifyLevel != nil {
+		s.DefaultNotifyLevel = *req.DefaultNotifyLevel
+	}
+	s.DefaultNotifyLevel = loopDefaultNotifyLevel(s)
+	if req.ThreadSummaries != nil {
+		s.ThreadSummaries = *req.ThreadSummaries
+	}
+	s.ThreadSummaries = loopThreadSummaries(s)
+	if req.AIEnabled != nil {
+		s.AiDisabled = !*req.AIEnabled
+	}
+	if req.AIModel != nil {
+		model := strings.TrimSpace(*req.AIModel)
+		if model != "" && !aiModels[model] {
+			problem.Respond(c, 400, "unknown AI model")
+			return
+		}
+		s.AiModel = model
+	}
+	if req.AIMaxTokens != nil {
+		s.AiMaxTokens = int32(*req.AIMaxTokens)
+	}
+	if req.AITemperature != nil {
+		s.AiTemperature = pgtype.Float4{Float32: float32(*req.AITemperature), Valid: *req.AITemperature >= 0}
+	}
+	if req.AISafety != nil {
+		s.AiSafety = *req.AISafety
+		if s.AiSafety == "default" {
+			s.AiSafety = ""
+		}
+	}
+	if req.AIContent != nil {
+		s.AiContent = aiContentList(*req.AIContent)
+	}
+	if req.AILanguage != nil {
+		language := strings.TrimSpace(*req.AILanguage)
+		if language != "" && aiLanguages[language] == "" {
+			problem.Respond(c, 400, "unknown AI language")
+			return
+		}
+		s.AiLanguage = language
+	}
+	if req.AITone != nil {
+		s.AiTone = *req.AITone
+		if s.AiTone == "default" {
+			s.AiTone = ""
+		}
+	}
+	if req.AccessRevoked != nil {
+		s.AccessRevoked = *req.AccessRevoked
+	}
+	s.AccessRevoked = loopAccessRevoked(s)
+	// The columns are NOT NULL
+	if s.PublicChannelIds == nil {
+		s.PublicChannelIds = []pgtype.UUID{}
+	}
+	if s.GuestChannelIds == nil {
+		s.GuestChannelIds = []pgtype.UUID{}
+	}
+
+	s, err = h.Queries.UpsertLoopSettings(c, db.UpsertLoopSettingsParams{
+		ProjectID:          project.ID,
+		WelcomeChannelID:   s.WelcomeChannelID,
+		WelcomeTemplate:    s.WelcomeTemplate,
+		WelcomeDm:          s.WelcomeDm,
+		Visibility:         s.Visibility,
+		PublicChannelIds:   s.PublicChannelIds,
+		GuestChannelIds:    s.GuestChannelIds,
+		PinRole:            s.PinRole,
+		PinLimit:           s.PinLimit,
+		DigestChannelID:    s.DigestChannelID,
+		DefaultNotifyLevel: s.DefaultNotifyLevel,
+		ThreadSummaries:    s.ThreadSummaries,
+		AiDisabled:         s.AiDisabled,
+		AiModel:            s.AiModel,
+		AiMaxTokens:        s.AiMaxTokens,
+		AiTemperature:      s.AiTemperature,
+		AiSafety:           s.AiSafety,
+		AiContent:          s.AiContent,
+		AiLanguage:         s.AiLanguage,
+		AiTone:             s.AiTone,
+		AccessRevoked:      s.AccessRevoked,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to save settings")
+		return
+	}
+	user, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	c.JSON(200, loopSettingsToResponse(s, project, user.Username))
+}
+
+// loopChannelList validates a list of channel IDs of the loop, dropping
+// blanks and duplicates; like loopChannelParam it writes the 400 itself
+func (h *Handler) loopChannelList(c *gin.Context, project db.Project, raw []string) ([]pgtype.UUID, bool) {
+	ids := make([]pgtype.UUID, 0, len(raw))
+	seen := make(map[pgtype.UUID]bool)
+	for _, r := range raw {
+		id, ok := h.loopChannelParam(c, project, r)
+		if !ok {
+			return nil, false
+		}
+		if id.Valid && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids, true
+}
+
+func uuidsToStrs(ids []pgtype.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = utils.UUIDToStr(id)
+	}
+	return out
+}
+
+// publicChannelSet returns the channels non-members may read, or nil when the
+// loop is members-only
+func (h *Handler) publicChannelSet(ctx context.Context, projectID pgtype.UUID) map[pgtype.UUID]bool {
+	s, err := h.Queries.GetLoopSettings(ctx, projectID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("[visibility] failed to load loop settings: %v", err)
+		}
+		return nil
+	}
+	if s.Visibility != loopVisibilityPublic {
+		return nil
+	}
+	set := make(map[pgtype.UUID]bool, len(s.PublicChannelIds))
+	for _, id := range s.PublicChannelIds {
+		set[id] = true
+	}
+	return set
+}
+
+// channelReadAccess checks that uid may read the channel's history: members
+// read every channel their role allows, anyone else only the public channels
+// of a public loop. On refusal it writes the 401/403 and returns false.
+func (h *Handler) channelReadAccess(c *gin.Context, uid, projectID, channelID pgtype.UUID) bool {
+	if h.Members.CanUseChannel(c, h.Members.Role(c, uid, projectID), projectID, channelID) ||
+		h.publicChannelSet(c, projectID)[channelID] {
+		return true
+	}
+	if !uid.Valid {
+		problem.Respond(c, 401, "unauthorized")
+	} else {
+		problem.Respond(c, 403, "not a member")
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// SIGNED MEDIA
+// Avatars and attachments are served from /api/media/... only with a valid
+// ?exp=&sig= pair. Expiries are rounded to a window so the same file gets the
+// same URL for a while and browser/CDN caches stay warm.
+// ============================================================================
+
+const (
+	mediaPathPrefix   = "/api/media/"
+	avatarURLWindow   = 24 * time.Hour
+	attachmentURLTTL  = time.Hour
+	avatarStoragePath = "avatars/"
+)
+
+// errNoMediaKey is returned by CheckMediaKey when MEDIA_SIGNING_KEY is unset
+var errNoMediaKey = errors.New("MEDIA_SIGNING_KEY is not set")
+
+// mediaKey signs media URLs. It has its own variable rather than reusing the
+// session secret, which ES256 setups don't even have.
+var mediaKey = sync.OnceValue(func() []byte {
+	return []byte(os.Getenv("MEDIA_SIGNING_KEY"))
+})
+
+// CheckMediaKey reports whether media URLs can be signed; without a key
+// anyone could sign a URL for any file, so the server refuses to start
+func CheckMediaKey() error {
+	if len(mediaKey()) == 0 {
+		return errNoMediaKey
+	}
+	return nil
+}
+
+func mediaSignature(path string, exp int64) string {
+	mac := hmac.New(sha256.New, mediaKey())
+	mac.Write([]byte(path + "\n" + strconv.FormatInt(exp, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signMediaPath returns an absolute signed URL for path, valid for at least window
+func signMediaPath(path string, window time.Duration) string {
+	exp := time.Now().Truncate(window).Add(2 * window).Unix()
+	q := url.Values{}
+	q.Set("exp", strconv.FormatInt(exp, 10))
+	q.Set("sig", mediaSignature(path, exp))
+	return strings.TrimRight(os.Getenv("BACKEND_URL"), "/") + path + "?" + q.Encode()
+}
+
+// verifyMediaRequest checks the signature on the current media request
+func verifyMediaRequest(c *gin.Context) bool {
+	exp, err := strconv.ParseInt(c.Query("exp"), 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	want := mediaSignature(c.Request.URL.Path, exp)
+	return hmac.Equal([]byte(want), []byte(c.Query("sig")))
+}
+
+// mediaURL signs stored media paths (uploaded avatars); anything else,
+// such as GitHub avatar URLs, is returned as is
+func mediaURL(raw string) string {
+	if !strings.HasPrefix(raw, mediaPathPrefix) {
+		return raw
+	}
+	return signMediaPath(raw, avatarURLWindow)
+}
+
+// attachmentURL is the signed download URL for a clean attachment
+func attachmentURL(a db.Attachment) string {
+	return signMediaPath(mediaPathPrefix+"attachments/"+utils.UUIDToStr(a.ID), attachmentURLTTL)
+}
+
+// HandleMediaAvatar serves an uploaded avatar. Avatar files are never
+// overwritten (each upload gets a new name), so they can be cached forever.
+func (h *Handler) HandleMediaAvatar(c *gin.Context) {
+	if !verifyMediaRequest(c) {
+		problem.Respond(c, 403, "invalid or expired link")
+		return
+	}
+	key := avatarStoragePath + c.Param("user_id") + "/" + c.Param("file")
+	data, err := h.Storage.Get(c, key)
+	if err != nil {
+		problem.Respond(c, 404, "not found")
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", `"`+c.Param("file")+`"`)
+	c.Data(200, "image/jpeg", data)
+}
+
+// HandleMediaAttachment serves a clean attachment to holders of a signed link
+func (h *Handler) HandleMediaAttachment(c *gin.Context) {
+	if !verifyMediaRequest(c) {
+		problem.Respond(c, 403, "invalid or expired link")
+		return
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 404, "not found")
+		return
+	}
+	a, err := h.Queries.GetAttachmentByID(c, id)
+	if err != nil {
+		problem.Respond(c, 404, "not found")
+		return
+	}
+	if a.ScanStatus != scanStatusClean {
+		problem.RespondCode(c, 423, "quarantined", "attachment is quarantined", gin.H{"scan_status": a.ScanStatus, "placeholder": true})
+		return
+	}
+	data, err := h.Storage.Get(c, a.StorageKey)
+	if err != nil {
+		problem.Respond(c, 404, "not found")
+		return
+	}
+
+	exp, _ := strconv.ParseInt(c.Query("exp"), 10, 64)
+	maxAge := max(exp-time.Now().Unix(), 0)
+	c.Header("Cache-Control", "private, max-age="+strconv.FormatInt(maxAge, 10)+", immutable")
+	c.Header("ETag", `"`+utils.UUIDToStr(a.ID)+`"`)
+	c.Header("Content-Disposition", mimeDisposition(a))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Data(200, a.ContentType, data)
+}
+
+type ResignMediaRequest struct {
+	URLs []string `json:"urls" binding:"required,max=100"`
+}
+
+// HandleResignMedia issues fresh signed URLs for media links the caller may still see.
+// Attachment links require membership in the attachment's loop; unknown links map to "".
+func (h *Handler) HandleResignMedia(c *gin.Context) {
+	var req ResignMediaRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	result := make(map[string]string, len(req.URLs))
+	for _, raw := range req.URLs {
+		result[raw] = ""
+		u, err := url.Parse(raw)
+		if err != nil || !strings.HasPrefix(u.Path, mediaPathPrefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(u.Path, mediaPathPrefix)
+		switch {
+		case strings.HasPrefix(rest, avatarStoragePath), strings.HasPrefix(rest, emojiStoragePath):
+			result[raw] = mediaURL(u.Path)
+		case strings.HasPrefix(rest, "attachments/"):
+			id, err := utils.StrToUUID(strings.TrimPrefix(rest, "attachments/"))
+			if err != nil {
+				continue
+			}
+			a, err := h.Queries.GetAttachmentByID(c, id)
+			if err != nil || a.ScanStatus != scanStatusClean {
+				continue
+			}
+			if !h.Members.IsMember(c, uid, a.ProjectID) {
+				continue
+			}
+			result[raw] = attachmentURL(a)
+		}
+	}
+
+	c.JSON(200, gin.H{"urls": result})
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// MEMBER ACTIVITY
+// Each membership remembers when the member last sent a message or opened a
+// connection to the loop. Writes are throttled per member, so the timestamp
+// trails real activity by up to memberTouchInterval.
+// ============================================================================
+
+const (
+	memberTouchInterval = 5 * time.Minute
+	defaultInactiveDays = 30
+	maxInactiveDays     = 365
+	maxInactivePerPage  = 100
+)
+
+var memberTouchedCache = cache.New[string, bool](memberTouchInterval, 50000)
+
+type InactiveMember struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+	Role      string `json:"role"`
+	JoinedAt  string `json:"joined_at"`
+	// Empty for members who were never active
+	LastActiveAt string `json:"last_active_at,omitempty"`
+}
+
+// InactiveMembersResponse is a page of the members idle for Days, with the
+// loop's inactivity counts
+type InactiveMembersResponse struct {
+	Page[InactiveMember]
+	Members     int32 `json:"members"`
+	Inactive30d int32 `json:"inactive_30d"`
+	Inactive90d int32 `json:"inactive_90d"`
+	Days        int   `json:"days"`
+}
+
+// touchMember records that the user was active in the loop
+func (h *Handler) touchMember(userID, projectID pgtype.UUID) {
+	key := utils.UUIDToStr(userID) + ":" + utils.UUIDToStr(projectID)
+	if _, seen := memberTouchedCache.Get(key); seen {
+		return
+	}
+	memberTouchedCache.Set(key, true)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := h.Queries.TouchMemberActivity(ctx, db.TouchMemberActivityParams{UserID: userID, ProjectID: projectID}); err != nil {
+			log.Printf("[activity] failed to update last active for %s: %v", key, err)
+		}
+	}()
+}
+
+// HandleGetInactiveMembers breaks the loop's members down by inactivity and
+// lists those idle for ?days (default 30), longest idle first (owner only)
+func (h *Handler) HandleGetInactiveMembers(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "view inactive members")
+	if !ok {
+		return
+	}
+	days := defaultInactiveDays
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxInactiveDays {
+			problem.Respond(c, 400, "days must be between 1 and 365")
+			return
+		}
+		days = n
+	}
+	limit, offset, ok := offsetPage(c, 50, maxInactivePerPage)
+	if !ok {
+		return
+	}
+
+	counts, err := h.Queries.GetLoopInactivity(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get member activity")
+		return
+	}
+	rows, err := h.Queries.GetInactiveMembers(c, db.GetInactiveMembersParams{
+		ProjectID: project.ID,
+		Before:    pgtype.Timestamptz{Time: time.Now().AddDate(0, 0, -days), Valid: true},
+		RowLimit:  int32(limit + 1),
+		RowOffset: int32(offset),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get member activity")
+		return
+	}
+	rows, hasMore := trimPage(rows, limit)
+
+	inactive := make([]InactiveMember, 0, len(rows))
+	for _, r := range rows {
+		m := InactiveMember{
+			ID:        utils.UUIDToStr(r.ID),
+			Username:  r.Username,
+			AvatarURL: mediaURL(r.AvatarUrl.String),
+			Role:      r.Role.String,
+			JoinedAt:  utils.FormatTime(r.JoinedAt.Time),
+		}
+		if r.LastActiveAt.Valid {
+			m.LastActiveAt = utils.FormatTime(r.LastActiveAt.Time)
+		}
+		inactive = append(inactive, m)
+	}
+
+	c.JSON(200, InactiveMembersResponse{
+		Page:        newPage(inactive, hasMore, offsetCursor(offset+limit)),
+		Members:     counts.Members,
+		Inactive30d: counts.Inactive30d,
+		Inactive90d: counts.Inactive90d,
+		Days:        days,
+	})
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"strconv"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMentionPageSize = 30
+	maxMentionPageSize     = 100
+)
+
+// MentionResponse is one entry in the user's mentions inbox
+type MentionResponse struct {
+	ID            string  `json:"id"`
+	MessageID     string  `json:"message_id"`
+	ParentID      *string `json:"parent_id,omitempty"`
+	LoopID        string  `json:"loop_id,omitempty"`
+	LoopName      string  `json:"loop_name,omitempty"`
+	ChannelID     string  `json:"channel_id,omitempty"`
+	ChannelName   string  `json:"channel_name,omitempty"`
+	ActorUsername string  `json:"actor_username"`
+	ActorAvatar   string  `json:"actor_avatar,omitempty"`
+	Content       string  `json:"content"`
+	IsRead        bool    `json:"is_read"`
+	CreatedAt     string  `json:"created_at"`
+}
+
+// HandleGetMentions returns the caller's mentions, newest first.
+// Query: ?unread=true to hide read entries, ?before=<id>&limit= for paging.
+func (h *Handler) HandleGetMentions(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	var before int64
+	if s := c.Query("before"); s != "" {
+		b, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			problem.Respond(c, 400, "invalid before id")
+			return
+		}
+		before = b
+	}
+	limit := defaultMentionPageSize
+	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 {
+		limit = min(l, maxMentionPageSize)
+	}
+	unreadOnly := c.Query("unread") == "true"
+
+	rows, err := h.Queries.GetMentions(c, db.GetMentionsParams{
+		UserID:     uid,
+		UnreadOnly: unreadOnly,
+		Before:     before,
+		N:          int32(limit),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get mentions")
+		return
+	}
+
+	result := make([]MentionResponse, 0, len(rows))
+	for _, m := range rows {
+		mention := MentionResponse{
+			ID:            strconv.FormatInt(m.ID, 10),
+			MessageID:     strconv.FormatInt(m.MessageID, 10),
+			LoopName:      m.ProjectName.String,
+			ChannelName:   m.ChannelName.String,
+			ActorUsername: m.ActorUsername,
+			ActorAvatar:   mediaURL(m.ActorAvatar.String),
+			Content:       m.Content,
+			IsRead:        m.IsRead,
+			CreatedAt:     utils.FormatTime(m.CreatedAt.Time),
+		}
+		if m.ProjectID.Valid {
+			mention.LoopID = utils.UUIDToStr(m.ProjectID)
+		}
+		if m.ChannelID.Valid {
+			mention.ChannelID = utils.UUIDToStr(m.ChannelID)
+		}
+		if m.ParentID.Valid {
+			pid := strconv.FormatInt(m.ParentID.Int64, 10)
+			mention.ParentID = &pid
+		}
+		result = append(result, mention)
+	}
+
+	c.JSON(200, result)
+}
+
+// HandleGetUnreadMentionCount returns the number of unread mentions
+func (h *Handler) HandleGetUnreadMentionCount(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	count, err := h.Queries.GetUnreadMentionCount(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get unread count")
+		return
+	}
+
+	c.JSON(200, gin.H{"count": count})
+}
+
+// HandleMarkMentionRead marks a single mention as read
+func (h *Handler) HandleMarkMentionRead(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	mid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid mention id")
+		return
+	}
+
+	if err := h.Queries.MarkMentionRead(c, db.MarkMentionReadParams{ID: mid, UserID: uid}); err != nil {
+		problem.Respond(c, 500, "failed to mark as read")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleMarkAllMentionsRead marks every mention as read
+func (h *Handler) HandleMarkAllMentionsRead(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	if err := h.Queries.MarkAllMentionsRead(c, uid); err != nil {
+		problem.Respond(c, 500, "failed to mark all as read")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true})
+}
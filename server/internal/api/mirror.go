@@ -0,0 +1,225 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// MIRROR MODE
+// A loop whose repo was archived or deleted on GitHub keeps its chat but
+// becomes a read-only mirror of the repo: GitHub writes are refused up front
+// instead of failing at GitHub, and for a deleted repo reads are too. The
+// repo stats refresher and repository webhooks switch loops in and out; the
+// owner can relink the loop, or archive it, which also stops new messages.
+// ============================================================================
+
+// Why a loop is a mirror
+const (
+	mirrorArchived = "archived"
+	mirrorDeleted  = "deleted"
+)
+
+const loopArchivedReason = "this loop was archived after its GitHub repository went away"
+
+var (
+	// loopMirrorCache holds each loop's mirror row; other loops cache the zero row
+	loopMirrorCache = cache.New[string, db.LoopMirror](lookupTTL, 5000)
+	// repoRecheckCache spaces out rechecks of a repo that 404'd for someone
+	repoRecheckCache = cache.New[string, bool](10*time.Minute, 1000)
+)
+
+// LoopMirrorResponse is the mirror state in LoopFull
+type LoopMirrorResponse struct {
+	Reason     string  `json:"reason"` // archived or deleted
+	Since      string  `json:"since"`
+	ArchivedAt *string `json:"archived_at"` // set once the owner archived the loop
+}
+
+func loopMirrorToResponse(m db.LoopMirror) *LoopMirrorResponse {
+	resp := &LoopMirrorResponse{
+		Reason: m.Reason,
+		Since:  utils.FormatTime(m.Since.Time),
+	}
+	if m.ArchivedAt.Valid {
+		t := utils.FormatTime(m.ArchivedAt.Time)
+		resp.ArchivedAt = &t
+	}
+	return resp
+}
+
+// loopMirror returns the loop's mirror row, if it is a mirror
+func (h *Handler) loopMirror(ctx context.Context, projectID pgtype.UUID) (db.LoopMirror, bool) {
+	m, err := loopMirrorCache.GetOrLoad(utils.UUIDToStr(projectID), func() (db.LoopMirror, error) {
+		m, err := h.Queries.GetLoopMirror(ctx, projectID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.LoopMirror{}, nil
+		}
+		return m, err
+	})
+	if err != nil {
+		log.Printf("[mirror] failed to load state for %s: %v", utils.UUIDToStr(projectID), err)
+		return db.LoopMirror{}, false
+	}
+	return m, m.ProjectID.Valid
+}
+
+// loopArchived reports whether the owner archived the loop
+func (h *Handler) loopArchived(ctx context.Context, projectID pgtype.UUID) bool {
+	m, ok := h.loopMirror(ctx, projectID)
+	return ok && m.ArchivedAt.Valid
+}
+
+// setRepoMirror records what GitHub said about repoID for every loop linked
+// to it; reason "" means the repo is readable and writable again
+func (h *Handler) setRepoMirror(ctx context.Context, repoID int64, reason string) error {
+	var changed []pgtype.UUID
+	var err error
+	if reason == "" {
+		changed, err = h.Queries.ClearRepoMirrors(ctx, repoID)
+	} else {
+		changed, err = h.Queries.MarkRepoMirrored(ctx, db.MarkRepoMirroredParams{GithubRepoID: repoID, Reason: reason})
+	}
+	if err != nil {
+		return err
+	}
+	for _, id := range changed {
+		lookupInvalidator.Invalidate("loop_mirror", utils.UUIDToStr(id))
+		if reason == "" {
+			log.Printf("[mirror] loop %s: repo %d is back, leaving mirror mode", utils.UUIDToStr(id), repoID)
+		} else {
+			log.Printf("[mirror] loop %s: repo %d was %s, now a mirror", utils.UUIDToStr(id), repoID, reason)
+		}
+	}
+	return nil
+}
+
+// recheckRepo has the repo stats refresher look at repoID now, with the
+// loop's own token, after a member got a 404 for it. A 404 for one token
+// may only mean that token can't see the repo.
+func (h *Handler) recheckRepo(repoID int64) {
+	key := strconv.FormatInt(repoID, 10)
+	if _, seen := repoRecheckCache.Get(key); seen {
+		return
+	}
+	repoRecheckCache.Set(key, true)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := h.refreshRepoStats(ctx, repoID); err != nil && !errors.Is(err, github.ErrUnauthorized) {
+			log.Printf("[mirror] recheck of repo %d failed: %v", repoID, err)
+		}
+	}()
+}
+
+// respondRepoMirrored writes the refusal for a GitHub call a mirror can't make
+func respondRepoMirrored(c *gin.Context, m db.LoopMirror) {
+	details := gin.H{"mirror": loopMirrorToResponse(m)}
+	if m.Reason == mirrorDeleted {
+		problem.RespondCode(c, 410, "repo_unavailable", "the linked GitHub repository was deleted or can no longer be read; the loop owner can relink it", details)
+		return
+	}
+	problem.RespondCode(c, 409, "repo_archived", "the linked GitHub repository is archived, so it is read-only", details)
+}
+
+// repoFullNameFor resolves a loop's linked repo for reading, writing the
+// error response on failure
+func (h *Handler) repoFullNameFor(c *gin.Context, project db.Project, accessToken string) (string, bool) {
+	if m, ok := h.loopMirror(c, project.ID); ok && m.Reason == mirrorDeleted {
+		respondRepoMirrored(c, m)
+		return "", false
+	}
+	repoFullName, err := github.Default.RepoFullName(c.Request.Context(), accessToken, project.GithubRepoID)
+	if github.MissingScope(err) != "" {
+		respondGitHubError(c, err)
+		return "", false
+	}
+	if errors.Is(err, github.ErrNotFound) {
+		h.recheckRepo(project.GithubRepoID)
+		problem.Respond(c, 404, err.Error())
+		return "", false
+	}
+	if err != nil {
+		log.Printf("[GitHub] Failed to get repo name for ID %d: %v", project.GithubRepoID, err)
+		reportGitHubError(c.Request, err, "repo_name")
+		problem.Respond(c, 500, "failed to resolve repository")
+		return "", false
+	}
+	return repoFullName, true
+}
+
+// repoForWrite is repoFullNameFor for changes, which no mirror can make
+func (h *Handler) repoForWrite(c *gin.Context, project db.Project, accessToken string) (string, bool) {
+	if m, ok := h.loopMirror(c, project.ID); ok {
+		respondRepoMirrored(c, m)
+		return "", false
+	}
+	return h.repoFullNameFor(c, project, accessToken)
+}
+
+// handleRepositoryWebhook switches the repo's loops in or out of mirror mode
+// as soon as GitHub says, rather than at the next stats refresh
+func (h *Handler) handleRepositoryWebhook(ctx context.Context, body []byte) error {
+	var ev github.RepositoryEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return err
+	}
+	switch ev.Action {
+	case "archived":
+		return h.setRepoMirror(ctx, ev.Repository.ID, mirrorArchived)
+	case "unarchived":
+		return h.setRepoMirror(ctx, ev.Repository.ID, "")
+	case "deleted":
+		github.Default.ForgetRepo(ev.Repository.ID)
+		return h.setRepoMirror(ctx, ev.Repository.ID, mirrorDeleted)
+	}
+	return nil
+}
+
+// HandleArchiveLoop archives a mirror loop: its history stays readable but
+// nobody can post (owner only). Relinking the loop undoes it.
+func (h *Handler) HandleArchiveLoop(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only the loop owner can archive the loop")
+		return
+	}
+	if _, ok := h.loopMirror(c, project.ID); !ok {
+		problem.Respond(c, 409, "only loops whose GitHub repository was archived or deleted can be archived")
+		return
+	}
+	m, err := h.Queries.ArchiveMirroredLoop(c, db.ArchiveMirroredLoopParams{ProjectID: project.ID, ArchivedBy: uid})
+	if errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 409, "this loop is already archived")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to archive loop")
+		return
+	}
+	lookupInvalidator.Invalidate("loop_mirror", utils.UUIDToStr(project.ID))
+	log.Printf("[mirror] %s archived by its owner", project.Name)
+	c.JSON(200, gin.H{"mirror": loopMirrorToResponse(m)})
+}
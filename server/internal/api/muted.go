@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	maxMutedWords      = 100
+	maxMutedWordLength = 50
+)
+
+type SetMutedWordsRequest struct {
+	Words []string `json:"words"`
+}
+
+// mutedWordsFor loads a user's muted words; errors are logged and treated as none
+func (h *Handler) mutedWordsFor(ctx context.Context, userID pgtype.UUID) []string {
+	words, err := h.Queries.GetMutedWords(ctx, userID)
+	if err != nil {
+		log.Printf("[muted] failed to load muted words: %v", err)
+		return nil
+	}
+	return words
+}
+
+// containsMutedWord reports whether content has any of words as a whole word or phrase.
+// words must already be lowercased.
+func containsMutedWord(content string, words []string) bool {
+	if len(words) == 0 {
+		return false
+	}
+	lower := strings.ToLower(content)
+	for _, w := range words {
+		for from := 0; from < len(lower); {
+			i := strings.Index(lower[from:], w)
+			if i < 0 {
+				break
+			}
+			start, end := from+i, from+i+len(w)
+			if isWordBoundary(lower, start, end) {
+				return true
+			}
+			from = start + 1
+		}
+	}
+	return false
+}
+
+// isWordBoundary checks that s[start:end] isn't part of a longer word ("art" in "start")
+func isWordBoundary(s string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(s[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end < len(s) {
+		r, _ := utf8.DecodeRuneInString(s[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// markMuted sets the Muted hint on messages that contain one of words
+func markMuted(msgs []MessageResponse, words []string) {
+	if len(words) == 0 {
+		return
+	}
+	for i := range msgs {
+		msgs[i].Muted = containsMutedWord(msgs[i].Content, words)
+	}
+}
+
+// HandleGetMutedWords returns the caller's muted words
+func (h *Handler) HandleGetMutedWords(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	words, err := h.Queries.GetMutedWords(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get muted words")
+		return
+	}
+	if words == nil {
+		words = []string{}
+	}
+
+	c.JSON(200, gin.H{"words": words})
+}
+
+// HandleSetMutedWords replaces the caller's muted words with the given list
+func (h *Handler) HandleSetMutedWords(c *gin.Context) {
+	var req SetMutedWordsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	words := make([]string, 0, len(req.Words))
+	seen := make(map[string]bool)
+	for _, w := range req.Words {
+		w = strings.ToLower(strings.TrimSpace(w))
+		if w == "" || seen[w] {
+			continue
+		}
+		if len(w) > maxMutedWordLength {
+			problem.Respond(c, 400, "muted words must be at most 50 characters")
+			return
+		}
+		seen[w] = true
+		words = append(words, w)
+	}
+	if len(words) > maxMutedWords {
+		problem.Respond(c, 400, "too many muted words (max 100)")
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	if err := qtx.ClearMutedWords(c, uid); err != nil {
+		problem.Respond(c, 500, "failed to save muted words")
+		return
+	}
+	for _, w := range words {
+		if err := qtx.AddMutedWord(c, db.AddMutedWordParams{UserID: uid, Word: w}); err != nil {
+			problem.Respond(c, 500, "failed to save muted words")
+			return
+		}
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to save changes")
+		return
+	}
+
+	c.JSON(200, gin.H{"words": words})
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	maxUsernameLength = 39  // same limit as GitHub logins
+	maxLoopNameLength = 100 // same limit as GitHub repo names
+)
+
+var (
+	// Same charset as GitHub logins, which is also what @mention parsing accepts
+	usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?$`)
+	// Same charset as GitHub repo names
+	loopNamePattern = regexp.MustCompile(`^[a-zA-Z0-9._-]+$`)
+)
+
+// reservedNames can't be chosen as a username or loop name. They cover
+// top-level routes on the API and frontend, broadcast mentions like @here,
+// and words that would read as the product speaking.
+var reservedNames = map[string]bool{
+	"about": true, "admin": true, "administrator": true, "all": true,
+	"api": true, "assets": true, "auth": true, "browse": true,
+	"channel": true, "dm": true, "dms": true, "everyone": true,
+	"explore": true, "health": true, "help": true, "here": true,
+	"login": true, "logout": true, "loop": true, "loops": true,
+	"me": true, "media": true, "mentions": true, "new": true,
+	"notifications": true, "null": true, "profile": true, "root": true,
+	"search": true, "settings": true, "setup": true, "static": true,
+	"support": true, "system": true, "undefined": true, "user": true,
+	"users": true, "wireloop": true,
+}
+
+func isReservedName(name string) bool {
+	return reservedNames[strings.ToLower(name)]
+}
+
+// validateUsername returns a user-facing reason the name can't be used, or ""
+func validateUsername(name string) string {
+	if name == "" || len(name) > maxUsernameLength {
+		return fmt.Sprintf("username must be 1-%d characters", maxUsernameLength)
+	}
+	if !usernamePattern.MatchString(name) {
+		return "username may only contain letters, numbers and single hyphens, and cannot start or end with a hyphen"
+	}
+	if strings.Contains(name, "--") {
+		return "username cannot contain consecutive hyphens"
+	}
+	if isReservedName(name) {
+		return fmt.Sprintf("%q is reserved", name)
+	}
+	return ""
+}
+
+// validateLoopName returns a user-facing reason the loop name can't be used, or ""
+func validateLoopName(name string) string {
+	if name == "" || len(name) > maxLoopNameLength {
+		return fmt.Sprintf("loop name must be 1-%d characters", maxLoopNameLength)
+	}
+	if !loopNamePattern.MatchString(name) {
+		return "loop name may only contain letters, numbers, '.', '-' and '_'"
+	}
+	if strings.Trim(name, ".") == "" {
+		return "loop name cannot consist only of dots"
+	}
+	if isReservedName(name) {
+		return fmt.Sprintf("%q is reserved", name)
+	}
+	return ""
+}
@@ -3,10 +3,16 @@ package api
 import (
 	"context"
 	"log"
+	"net/url"
 	"regexp"
+	"slices"
 	"strconv"
+	"time"
 	utils "wireloop/internal"
 	"wireloop/internal/db"
+	"wireloop/internal/i18n"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -15,6 +21,9 @@ import (
 // mentionRegex matches @username patterns in message content
 var mentionRegex = regexp.MustCompile(`@([a-zA-Z0-9_-]+)`)
 
+// mentionBatchWindow is how long repeat mentions from one sender fold into one notification
+const mentionBatchWindow = time.Minute
+
 // NotificationResponse is what the frontend receives
 type NotificationResponse struct {
 	ID             string `json:"id"`
@@ -26,37 +35,85 @@ type NotificationResponse struct {
 	ContentPreview string `json:"content_preview,omitempty"`
 	IsRead         bool   `json:"is_read"`
 	CreatedAt      string `json:"created_at"`
+	CreatedAtMs    int64  `json:"created_at_ms"`
+	BatchCount     int32  `json:"batch_count"`
+	// How it reached the user: pending, delivered, fallback, retrying or
+	// persisted (see notify); DeliveredVia names the fallback transport
+	DeliveryState string `json:"delivery_state"`
+	DeliveredVia  string `json:"delivered_via,omitempty"`
+
+	Link *NotificationLink `json:"link,omitempty"`
+}
+
+// NotificationFallbackRequest sets the transports tried, in order, for
+// notifications no open client acked; an empty list keeps them in-app
+type NotificationFallbackRequest struct {
+	Fallback []string `json:"fallback" binding:"required,dive,oneof=push email"`
+}
+
+// NotificationLink tells the client where a notification points.
+// Path is a ready-made client route: /loops/<name>?channel=<id>&thread=<parent>#message-<id>
+type NotificationLink struct {
+	LoopName       string `json:"loop_name"`
+	ChannelName    string `json:"channel_name,omitempty"`
+	MessageAnchor  string `json:"message_anchor,omitempty"`
+	ThreadParentID string `json:"thread_parent_id,omitempty"`
+	Path           string `json:"path"`
+}
+
+// notificationLink resolves the deep link for a notification row; nil when the loop is gone
+func notificationLink(n db.GetNotificationsRow) *NotificationLink {
+	if !n.ProjectName.Valid {
+		return nil
+	}
+	link := &NotificationLink{
+		LoopName:    n.ProjectName.String,
+		ChannelName: n.ChannelName.String,
+	}
+	query := url.Values{}
+	if n.ChannelName.Valid {
+		query.Set("channel", utils.UUIDToStr(n.ChannelID))
+	}
+	if n.ThreadParentID.Valid {
+		link.ThreadParentID = strconv.FormatInt(n.ThreadParentID.Int64, 10)
+		query.Set("thread", link.ThreadParentID)
+	}
+	link.Path = "/loops/" + url.PathEscape(link.LoopName)
+	if len(query) > 0 {
+		link.Path += "?" + query.Encode()
+	}
+	if n.MessageID.Valid {
+		link.MessageAnchor = "message-" + strconv.FormatInt(n.MessageID.Int64, 10)
+		link.Path += "#" + link.MessageAnchor
+	}
+	return link
 }
 
 // HandleGetNotifications returns paginated notifications for the user
 func (h *Handler) HandleGetNotifications(c *gin.Context) {
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "20"))
-	if page < 1 {
-		page = 1
-	}
-	if perPage > 50 {
-		perPage = 50
+	limit, offset, ok := offsetPage(c, 20, 50)
+	if !ok {
+		return
 	}
-	offset := (page - 1) * perPage
 
 	ctx := c.Request.Context()
 
 	notifications, err := h.Queries.GetNotifications(ctx, db.GetNotificationsParams{
 		UserID: uid,
-		Limit:  int32(perPage),
+		Limit:  int32(limit + 1),
 		Offset: int32(offset),
 	})
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get notifications"})
+		problem.Respond(c, 500, "failed to get notifications")
 		return
 	}
+	notifications, hasMore := trimPage(notifications, limit)
 
 	result := make([]NotificationResponse, 0, len(notifications))
 	for _, n := range notifications {
@@ -73,24 +130,29 @@ func (h *Handler) HandleGetNotifications(c *gin.Context) {
 			ActorUsername:  n.ActorUsername,
 			ContentPreview: n.ContentPreview.String,
 			IsRead:         n.IsRead.Bool,
-			CreatedAt:      n.CreatedAt.Time.Format("2006-01-02T15:04:05Z07:00"),
+			CreatedAt:      utils.FormatTime(n.CreatedAt.Time),
+			CreatedAtMs:    utils.EpochMillis(n.CreatedAt.Time),
+			BatchCount:     n.BatchCount,
+			DeliveryState:  n.DeliveryState,
+			DeliveredVia:   n.DeliveryTransport.String,
+			Link:           notificationLink(n),
 		})
 	}
 
-	c.JSON(200, result)
+	c.JSON(200, newPage(result, hasMore, offsetCursor(offset+limit)))
 }
 
 // HandleGetUnreadCount returns the count of unread notifications
 func (h *Handler) HandleGetUnreadCount(c *gin.Context) {
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	count, err := h.Queries.GetUnreadNotificationCount(c.Request.Context(), uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get unread count"})
+		problem.Respond(c, 500, "failed to get unread count")
 		return
 	}
 
@@ -101,14 +163,14 @@ func (h *Handler) HandleGetUnreadCount(c *gin.Context) {
 func (h *Handler) HandleMarkRead(c *gin.Context) {
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	nidStr := c.Param("id")
 	nid, err := strconv.ParseInt(nidStr, 10, 64)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid notification id"})
+		problem.Respond(c, 400, "invalid notification id")
 		return
 	}
 
@@ -116,7 +178,7 @@ func (h *Handler) HandleMarkRead(c *gin.Context) {
 		ID:     nid,
 		UserID: uid,
 	}); err != nil {
-		c.JSON(500, gin.H{"error": "failed to mark as read"})
+		problem.Respond(c, 500, "failed to mark as read")
 		return
 	}
 
@@ -127,71 +189,60 @@ func (h *Handler) HandleMarkRead(c *gin.Context) {
 func (h *Handler) HandleMarkAllRead(c *gin.Context) {
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	if err := h.Queries.MarkAllNotificationsRead(c.Request.Context(), uid); err != nil {
-		c.JSON(500, gin.H{"error": "failed to mark all as read"})
+		problem.Respond(c, 500, "failed to mark all as read")
 		return
 	}
 
 	c.JSON(200, gin.H{"success": true})
 }
 
-// HandleSearchMembers returns members matching a username prefix (for @mention autocomplete)
+// HandleSearchMembers returns members matching a username prefix (for @mention
+// autocomplete). Kept for older clients; HandleAutocomplete supersedes it.
 func (h *Handler) HandleSearchMembers(c *gin.Context) {
-	loopName := c.Param("name")
 	query := c.Query("q")
 	if query == "" {
 		c.JSON(200, []any{})
 		return
 	}
-
-	ctx := c.Request.Context()
-
-	project, err := h.Queries.GetProjectByName(ctx, loopName)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
 		return
 	}
 
-	members, err := h.Queries.SearchMembersByUsername(ctx, db.SearchMembersByUsernameParams{
-		ProjectID: project.ID,
-		Column2:   pgtype.Text{String: query, Valid: true},
-	})
+	members, err := h.autocompleteMembers(c, project.ID, uid, query, maxAutocompleteLimit)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "search failed"})
+		problem.Respond(c, 500, "search failed")
 		return
 	}
 
 	result := make([]gin.H, 0, len(members))
 	for _, m := range members {
 		result = append(result, gin.H{
-			"id":           utils.UUIDToStr(m.ID),
+			"id":           m.ID,
 			"username":     m.Username,
-			"avatar_url":   m.AvatarUrl.String,
-			"display_name": m.DisplayName.String,
+			"avatar_url":   m.AvatarURL,
+			"display_name": m.DisplayName,
 		})
 	}
 
 	c.JSON(200, result)
 }
 
-// ProcessMentions extracts @mentions from content and creates notifications
+// ProcessMentions extracts @mentions from content and creates notifications,
+// then notifies the members following every message in the loop.
 // Called asynchronously after a message is sent
 func (h *Handler) ProcessMentions(ctx context.Context, content string, senderID pgtype.UUID, senderUsername string, messageID int64, projectID, channelID pgtype.UUID) {
 	matches := mentionRegex.FindAllStringSubmatch(content, -1)
-	if len(matches) == 0 {
-		return
-	}
 
 	// Deduplicate mentioned usernames
 	seen := make(map[string]bool)
-	preview := content
-	if len(preview) > 100 {
-		preview = preview[:100] + "..."
-	}
+	mentioned := make(map[pgtype.UUID]bool)
+	preview := notify.Preview(content)
 
 	for _, match := range matches {
 		username := match[1]
@@ -205,43 +256,201 @@ func (h *Handler) ProcessMentions(ctx context.Context, content string, senderID
 			continue
 		}
 
-		// Look up the mentioned user (must be a member of the project)
-		user, err := h.Queries.GetUserByUsername(ctx, username)
-		if err != nil {
+		// Look up the mentioned user (must be a member of the project).
+		// Old handles of renamed users still resolve.
+		user, err := h.userByHandle(ctx, username)
+		if err != nil || user.ID == senderID {
 			continue // User doesn't exist, skip
 		}
+		if seen[user.Username] && user.Username != username {
+			continue // Same user mentioned by old and new handle
+		}
+		seen[user.Username] = true
+		mentioned[user.ID] = true
 
-		// Check membership
-		if _, err := h.Queries.IsMember(ctx, db.IsMemberParams{
-			UserID: user.ID, ProjectID: projectID,
-		}); err != nil {
-			continue // Not a member, skip
+		// Only members who can see the channel hear about it
+		if !h.Members.CanUseChannel(ctx, h.Members.Role(ctx, user.ID, projectID), projectID, channelID) {
+			continue
 		}
 
-		notifID := utils.GetMessageId()
-		if err := h.Queries.CreateNotification(ctx, db.CreateNotificationParams{
-			ID:             notifID,
+		// Blocked senders don't reach the user at all, not even the mentions inbox
+		if h.hasBlocked(ctx, user.ID, senderID) {
+			continue
+		}
+
+		// Muted content still lands in the mentions inbox, it just doesn't notify
+		var notifRef pgtype.Int8
+		if !containsMutedWord(content, h.mutedWordsFor(ctx, user.ID)) {
+			n, sent, err := h.Notifier.Notify(ctx, notify.Event{
+				Type:          "mention",
+				UserID:        user.ID,
+				ActorID:       senderID,
+				ActorUsername: senderUsername,
+				ProjectID:     projectID,
+				ChannelID:     channelID,
+				MessageID:     pgtype.Int8{Int64: messageID, Valid: true},
+				Preview:       preview,
+				Batch:         h.mentionBatch(ctx, user.ID, senderUsername, channelID),
+			})
+			if err != nil {
+				log.Printf("[notifications] failed to create mention notification: %v", err)
+			} else if sent {
+				notifRef = pgtype.Int8{Int64: n.ID, Valid: true}
+			}
+		}
+
+		// The mention row outlives the notification, so the inbox survives clears
+		if err := h.Queries.CreateMention(ctx, db.CreateMentionParams{
+			ID:             utils.GetMessageId(),
 			UserID:         user.ID,
-			Type:           "mention",
-			MessageID:      pgtype.Int8{Int64: messageID, Valid: true},
+			MessageID:      messageID,
 			ProjectID:      projectID,
 			ChannelID:      channelID,
 			ActorID:        senderID,
-			ActorUsername:  senderUsername,
-			ContentPreview: pgtype.Text{String: preview, Valid: true},
+			NotificationID: notifRef,
 		}); err != nil {
-			log.Printf("[notifications] failed to create mention notification: %v", err)
+			log.Printf("[notifications] failed to record mention: %v", err)
 		}
+	}
 
-		// Send real-time notification via WebSocket
-		h.Hub.NotifyUser(utils.UUIDToStr(user.ID), WSOutMessage{
-			Type: "notification",
-			Payload: gin.H{
-				"id":              strconv.FormatInt(notifID, 10),
-				"type":            "mention",
-				"actor_username":  senderUsername,
-				"content_preview": preview,
-			},
-		})
+	h.notifyMessageFollowers(ctx, content, senderID, senderUsername, messageID, projectID, channelID, mentioned)
+}
+
+// mentionBatch folds repeat mentions from one sender in one channel within
+// mentionBatchWindow into a single notification, summarized in the
+// recipient's language
+func (h *Handler) mentionBatch(ctx context.Context, userID pgtype.UUID, senderUsername string, channelID pgtype.UUID) *notify.Batch {
+	return &notify.Batch{
+		Window: mentionBatchWindow,
+		Summary: func(count int32) string {
+			locale := h.recipientLocale(ctx, userID)
+			if ch, err := h.Queries.GetChannelByID(ctx, channelID); err == nil {
+				return i18n.T(locale, "%s mentioned you %d times in #%s", senderUsername, count, ch.Name)
+			}
+			return i18n.T(locale, "%s mentioned you %d times", senderUsername, count)
+		},
+	}
+}
+
+// hasBlocked reports whether userID blocked actorID; an unreadable block
+// list counts as blocked
+func (h *Handler) hasBlocked(ctx context.Context, userID, actorID pgtype.UUID) bool {
+	blocked, err := h.Queries.HasBlocked(ctx, db.HasBlockedParams{BlockerID: userID, BlockedID: actorID})
+	return err != nil || blocked
+}
+
+// notBlocked is the notifier filter dropping notifications caused by someone
+// the recipient blocked
+func (h *Handler) notBlocked(ctx context.Context, ev notify.Event) bool {
+	return !ev.ActorID.Valid || !h.hasBlocked(ctx, ev.UserID, ev.ActorID)
+}
+
+// HandleGetNotificationItems expands a batched notification into its mentions
+func (h *Handler) HandleGetNotificationItems(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	nid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid notification id")
+		return
+	}
+
+	rows, err := h.Queries.GetNotificationMentions(c, db.GetNotificationMentionsParams{
+		NotificationID: pgtype.Int8{Int64: nid, Valid: true},
+		UserID:         uid,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get notification items")
+		return
+	}
+
+	result := make([]gin.H, 0, len(rows))
+	for _, r := range rows {
+		item := gin.H{
+			"mention_id":      strconv.FormatInt(r.ID, 10),
+			"message_id":      strconv.FormatInt(r.MessageID, 10),
+			"content_preview": notify.Preview(r.Content),
+			"created_at":      utils.FormatTime(r.CreatedAt.Time),
+		}
+		if r.ParentID.Valid {
+			item["thread_parent_id"] = strconv.FormatInt(r.ParentID.Int64, 10)
+		}
+		result = append(result, item)
+	}
+
+	c.JSON(200, result)
+}
+
+// HandleAckNotification records that a client received notification :id,
+// for clients without a WebSocket to send notification_ack on
+func (h *Handler) HandleAckNotification(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	nid, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid notification id")
+		return
+	}
+
+	acked, err := h.Notifier.Ack(c, uid, nid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to ack notification")
+		return
+	}
+	if !acked {
+		problem.Respond(c, 404, "notification not found")
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleGetNotificationFallback returns the caller's fallback transports
+func (h *Handler) HandleGetNotificationFallback(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	order, err := h.Notifier.FallbackOrder(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get settings")
+		return
+	}
+	c.JSON(200, gin.H{"fallback": order})
+}
+
+// HandleUpdateNotificationFallback sets the caller's fallback transports
+func (h *Handler) HandleUpdateNotificationFallback(c *gin.Context) {
+	var req NotificationFallbackRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	order := make([]string, 0, len(req.Fallback))
+	for _, t := range req.Fallback {
+		if !slices.Contains(order, t) {
+			order = append(order, t)
+		}
+	}
+	if err := h.Queries.SetNotificationFallback(c, db.SetNotificationFallbackParams{
+		UserID:         uid,
+		NotifyFallback: order,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to update settings")
+		return
 	}
+	c.JSON(200, gin.H{"fallback": order})
 }
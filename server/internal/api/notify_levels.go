@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"log"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/i18n"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// NOTIFICATION LEVELS
+// What a member hears about from a loop's chat: every message, mentions of
+// them, or nothing. New members start at the loop's default
+// (loop_settings.default_notify_level) and can change their own level.
+// Notifications that aren't about chat, such as task assignments and
+// reminders, are sent whatever the level.
+// ============================================================================
+
+const (
+	notifyLevelAll      = "all"
+	notifyLevelMentions = "mentions"
+	notifyLevelNone     = "none"
+)
+
+// messageBatchWindow is how long a sender's messages in one channel fold
+// into one notification for members following every message
+const messageBatchWindow = 5 * time.Minute
+
+type LoopNotifyLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=all mentions none"`
+}
+
+type LoopNotifyLevelResponse struct {
+	Level string `json:"level"`
+	// The level new members start at
+	LoopDefault string `json:"loop_default"`
+}
+
+// loopDefaultNotifyLevel is s's default level, mentions when unset
+func loopDefaultNotifyLevel(s db.LoopSetting) string {
+	if s.DefaultNotifyLevel == "" {
+		return notifyLevelMentions
+	}
+	return s.DefaultNotifyLevel
+}
+
+// loopNotifyLevelResponse reports level next to the loop's default
+func (h *Handler) loopNotifyLevelResponse(ctx context.Context, projectID pgtype.UUID, level string) LoopNotifyLevelResponse {
+	s, _ := h.Queries.GetLoopSettings(ctx, projectID)
+	return LoopNotifyLevelResponse{Level: level, LoopDefault: loopDefaultNotifyLevel(s)}
+}
+
+// HandleGetLoopNotifyLevel returns the caller's notification level in a loop
+func (h *Handler) HandleGetLoopNotifyLevel(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	m, err := h.Queries.GetMembership(c, db.GetMembershipParams{UserID: uid, ProjectID: project.ID})
+	if err != nil {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+	c.JSON(200, h.loopNotifyLevelResponse(c, project.ID, m.NotifyLevel))
+}
+
+// HandleSetLoopNotifyLevel changes the caller's notification level in a loop
+func (h *Handler) HandleSetLoopNotifyLevel(c *gin.Context) {
+	var req LoopNotifyLevelRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	n, err := h.Queries.SetMemberNotifyLevel(c, db.SetMemberNotifyLevelParams{
+		UserID:      uid,
+		ProjectID:   project.ID,
+		NotifyLevel: req.Level,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to update notification level")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+	c.JSON(200, h.loopNotifyLevelResponse(c, project.ID, req.Level))
+}
+
+// notifyLevelAllows is the notifier filter applying the recipient's level in
+// the loop to chat notifications: mentions need mentions or all, messages
+// need all
+func (h *Handler) notifyLevelAllows(ctx context.Context, ev notify.Event) bool {
+	if !ev.ProjectID.Valid || (ev.Type != "mention" && ev.Type != "message") {
+		return true
+	}
+	m, err := h.Queries.GetMembership(ctx, db.GetMembershipParams{UserID: ev.UserID, ProjectID: ev.ProjectID})
+	if err != nil {
+		return ev.Type == "mention"
+	}
+	switch m.NotifyLevel {
+	case notifyLevelNone:
+		return false
+	case notifyLevelMentions:
+		return ev.Type == "mention"
+	}
+	return true
+}
+
+// notifyMessageFollowers notifies the loop's members at level all of a new
+// message, skipping the sender and anyone already notified of it as a
+// mention
+func (h *Handler) notifyMessageFollowers(ctx context.Context, content string, senderID pgtype.UUID, senderUsername string, messageID int64, projectID, channelID pgtype.UUID, skip map[pgtype.UUID]bool) {
+	followers, err := h.Queries.GetLoopMessageFollowers(ctx, db.GetLoopMessageFollowersParams{
+		ProjectID: projectID,
+		UserID:    senderID,
+	})
+	if err != nil {
+		log.Printf("[notifications] failed to list message followers of %s: %v", utils.UUIDToStr(projectID), err)
+		return
+	}
+	preview := notify.Preview(content)
+	for _, uid := range followers {
+		if skip[uid] || !h.Members.CanUseChannel(ctx, h.Members.Role(ctx, uid, projectID), projectID, channelID) {
+			continue
+		}
+		if containsMutedWord(content, h.mutedWordsFor(ctx, uid)) {
+			continue
+		}
+		if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+			Type:          "message",
+			UserID:        uid,
+			ActorID:       senderID,
+			ActorUsername: senderUsername,
+			ProjectID:     projectID,
+			ChannelID:     channelID,
+			MessageID:     pgtype.Int8{Int64: messageID, Valid: true},
+			Preview:       preview,
+			Batch:         h.messageBatch(ctx, uid, senderUsername, channelID),
+		}); err != nil {
+			log.Printf("[notifications] failed to create message notification: %v", err)
+		}
+	}
+}
+
+// messageBatch folds a sender's messages in one channel within
+// messageBatchWindow into a single notification
+func (h *Handler) messageBatch(ctx context.Context, userID pgtype.UUID, senderUsername string, channelID pgtype.UUID) *notify.Batch {
+	return &notify.Batch{
+		Window: messageBatchWindow,
+		Summary: func(count int32) string {
+			locale := h.recipientLocale(ctx, userID)
+			if ch, err := h.Queries.GetChannelByID(ctx, channelID); err == nil {
+				return i18n.T(locale, "%s sent %d messages in #%s", senderUsername, count, ch.Name)
+			}
+			return i18n.T(locale, "%s sent %d messages", senderUsername, count)
+		},
+	}
+}
@@ -8,6 +8,10 @@ import (
 	"os"
 	"strconv"
 	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
 )
@@ -24,14 +28,14 @@ func AdminAuthMiddleware() gin.HandlerFunc {
 
 	return func(c *gin.Context) {
 		if user == "" || pass == "" {
-			c.AbortWithStatusJSON(503, gin.H{"error": "observability not configured"})
+			problem.Abort(c, 503, "observability not configured")
 			return
 		}
 
 		reqUser, reqPass, ok := c.Request.BasicAuth()
 		if !ok {
 			c.Header("WWW-Authenticate", `Basic realm="Wireloop Observability"`)
-			c.AbortWithStatus(http.StatusUnauthorized)
+			problem.Abort(c, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 
@@ -44,7 +48,7 @@ func AdminAuthMiddleware() gin.HandlerFunc {
 		if subtle.ConstantTimeCompare(uHash[:], euHash[:]) != 1 ||
 			subtle.ConstantTimeCompare(pHash[:], epHash[:]) != 1 {
 			c.Header("WWW-Authenticate", `Basic realm="Wireloop Observability"`)
-			c.AbortWithStatus(http.StatusUnauthorized)
+			problem.Abort(c, http.StatusUnauthorized, "unauthorized")
 			return
 		}
 
@@ -92,6 +96,9 @@ func (h *Handler) HandleObsStats(c *gin.Context) {
 	stats["unread_notifications"] = unreadNotifs
 	stats["pinned_messages"] = pinned
 
+	// Message filter volume since this instance started
+	stats["message_filter"] = messageFilter.Stats()
+
 	// DB pool stats
 	ps := h.Pool.Stat()
 	stats["db_pool"] = gin.H{
@@ -104,6 +111,54 @@ func (h *Handler) HandleObsStats(c *gin.Context) {
 	c.JSON(200, stats)
 }
 
+// HandleObsHubMetrics returns WebSocket hub throughput, fan-out latency and
+// per-room backlog for this instance, for capacity planning
+func (h *Handler) HandleObsHubMetrics(c *gin.Context) {
+	c.JSON(200, h.Hub.Metrics())
+}
+
+// LoadResponse is what /obs/load reports, flat so a scaler can pick a field
+type LoadResponse struct {
+	chat.Load
+	WriteQueue       int     `json:"write_queue"` // messages waiting to be stored
+	DBAcquiredConns  int32   `json:"db_acquired_conns"`
+	DBMaxConns       int32   `json:"db_max_conns"`
+	DBPoolSaturation float64 `json:"db_pool_saturation"` // acquired / max
+}
+
+// HandleObsLoad returns this instance's load for autoscalers and dashboards
+// that poll often. Every figure is an in-memory counter; nothing here
+// queries the database.
+func (h *Handler) HandleObsLoad(c *gin.Context) {
+	ps := h.Pool.Stat()
+	resp := LoadResponse{
+		Load:            h.Hub.Load(),
+		DBAcquiredConns: ps.AcquiredConns(),
+		DBMaxConns:      ps.MaxConns(),
+	}
+	if resp.DBMaxConns > 0 {
+		resp.DBPoolSaturation = float64(resp.DBAcquiredConns) / float64(resp.DBMaxConns)
+	}
+	if h.Messages != nil {
+		resp.WriteQueue = h.Messages.Pending()
+	}
+	c.JSON(200, resp)
+}
+
+// HandleObsSlowQueries returns the most recent queries over the slow-query
+// threshold on this instance, newest first, to spot missing indexes
+func (h *Handler) HandleObsSlowQueries(c *gin.Context) {
+	if h.SlowQueries == nil {
+		c.JSON(200, gin.H{"threshold_ms": 0, "total": 0, "queries": []db.SlowQuery{}})
+		return
+	}
+	c.JSON(200, gin.H{
+		"threshold_ms": h.SlowQueries.Threshold().Milliseconds(),
+		"total":        h.SlowQueries.Total(),
+		"queries":      h.SlowQueries.Recent(),
+	})
+}
+
 // HandleObsUsers returns recent users with engagement stats
 func (h *Handler) HandleObsUsers(c *gin.Context) {
 	ctx := c.Request.Context()
@@ -114,20 +169,11 @@ func (h *Handler) HandleObsUsers(c *gin.Context) {
 		}
 	}
 
-	rows, err := h.Pool.Query(ctx, `
-		SELECT u.id, u.username, u.avatar_url, u.github_id, u.profile_completed, u.created_at,
-		       (SELECT COUNT(*) FROM memberships m WHERE m.user_id = u.id) AS loop_count,
-		       (SELECT COUNT(*) FROM messages msg WHERE msg.sender_id = u.id) AS message_count
-		FROM users u
-		ORDER BY u.created_at DESC
-		LIMIT $1
-	`, limit)
+	rows, err := h.Queries.GetUserEngagementStats(ctx, int32(limit))
 	if err != nil {
-		log.Printf("[obs] users query failed: %v", err)
-		c.JSON(500, gin.H{"error": err.Error()})
+		problem.Error(c, err, "failed to load user stats")
 		return
 	}
-	defer rows.Close()
 
 	type UserInfo struct {
 		ID               string    `json:"id"`
@@ -140,22 +186,26 @@ func (h *Handler) HandleObsUsers(c *gin.Context) {
 		MessageCount     int       `json:"message_count"`
 	}
 
-	var users []UserInfo
-	for rows.Next() {
-		var u UserInfo
-		if err := rows.Scan(&u.ID, &u.Username, &u.AvatarURL, &u.GitHubID, &u.ProfileCompleted, &u.CreatedAt, &u.LoopCount, &u.MessageCount); err != nil {
-			continue
+	users := make([]UserInfo, 0, len(rows))
+	for _, r := range rows {
+		u := UserInfo{
+			ID:               utils.UUIDToStr(r.ID),
+			Username:         r.Username,
+			GitHubID:         r.GithubID,
+			ProfileCompleted: r.ProfileCompleted.Bool,
+			CreatedAt:        r.CreatedAt.Time,
+			LoopCount:        int(r.LoopCount),
+			MessageCount:     int(r.MessageCount),
 		}
+		u.AvatarURL = avatarURL(r.AvatarUrl)
 		users = append(users, u)
 	}
-	if users == nil {
-		users = []UserInfo{}
-	}
 
 	c.JSON(200, users)
 }
 
-// HandleObsErrors returns potential issues/anomalies
+// HandleObsErrors returns potential issues/anomalies, along with this
+// instance's 5xx responses per route over the last few minutes
 func (h *Handler) HandleObsErrors(c *gin.Context) {
 	ctx := c.Request.Context()
 
@@ -172,12 +222,16 @@ func (h *Handler) HandleObsErrors(c *gin.Context) {
 	orphanedMessages := scanCount("orphaned", "SELECT COUNT(*) FROM messages WHERE sender_id NOT IN (SELECT id FROM users)")
 	deletedMessages := scanCount("deleted", "SELECT COUNT(*) FROM messages WHERE is_deleted = TRUE")
 
-	c.JSON(200, gin.H{
+	resp := gin.H{
 		"incomplete_profiles": incompleteProfiles,
 		"users_no_loops":      noLoops,
 		"orphaned_messages":   orphanedMessages,
 		"deleted_messages":    deletedMessages,
-	})
+	}
+	if h.ErrorRates != nil {
+		resp["server_errors"] = h.ErrorRates.Snapshot()
+	}
+	c.JSON(200, resp)
 }
 
 // HandleObsTimeline returns messages per day for last 30 days
@@ -192,8 +246,7 @@ func (h *Handler) HandleObsTimeline(c *gin.Context) {
 		ORDER BY day ASC
 	`)
 	if err != nil {
-		log.Printf("[obs] timeline query failed: %v", err)
-		c.JSON(500, gin.H{"error": err.Error()})
+		problem.Error(c, err, "failed to load timeline")
 		return
 	}
 	defer rows.Close()
@@ -224,27 +277,11 @@ func (h *Handler) HandleObsTimeline(c *gin.Context) {
 func (h *Handler) HandleObsLoops(c *gin.Context) {
 	ctx := c.Request.Context()
 
-	rows, err := h.Pool.Query(ctx, `
-		SELECT p.name,
-		       (SELECT COUNT(*) FROM memberships m WHERE m.project_id = p.id) AS member_count,
-		       (SELECT COUNT(*) FROM channels ch WHERE ch.project_id = p.id) AS channel_count,
-		       (SELECT COUNT(*) FROM messages msg 
-		        JOIN channels ch ON msg.channel_id = ch.id 
-		        WHERE ch.project_id = p.id) AS total_messages,
-		       (SELECT COUNT(*) FROM messages msg 
-		        JOIN channels ch ON msg.channel_id = ch.id 
-		        WHERE ch.project_id = p.id AND msg.created_at > NOW() - INTERVAL '24 hours') AS messages_today,
-		       p.created_at
-		FROM projects p
-		ORDER BY messages_today DESC, total_messages DESC
-		LIMIT 20
-	`)
+	rows, err := h.Queries.GetActiveLoopStats(ctx, 20)
 	if err != nil {
-		log.Printf("[obs] loops query failed: %v", err)
-		c.JSON(500, gin.H{"error": err.Error()})
+		problem.Error(c, err, "failed to load loop stats")
 		return
 	}
-	defer rows.Close()
 
 	type LoopInfo struct {
 		Name          string    `json:"name"`
@@ -255,16 +292,16 @@ func (h *Handler) HandleObsLoops(c *gin.Context) {
 		CreatedAt     time.Time `json:"created_at"`
 	}
 
-	var loops []LoopInfo
-	for rows.Next() {
-		var l LoopInfo
-		if err := rows.Scan(&l.Name, &l.MemberCount, &l.ChannelCount, &l.TotalMessages, &l.MessagesToday, &l.CreatedAt); err != nil {
-			continue
-		}
-		loops = append(loops, l)
-	}
-	if loops == nil {
-		loops = []LoopInfo{}
+	loops := make([]LoopInfo, 0, len(rows))
+	for _, r := range rows {
+		loops = append(loops, LoopInfo{
+			Name:          r.Name,
+			MemberCount:   int(r.MemberCount),
+			ChannelCount:  int(r.ChannelCount),
+			TotalMessages: int(r.TotalMessages),
+			MessagesToday: int(r.MessagesToday),
+			CreatedAt:     r.CreatedAt.Time,
+		})
 	}
 
 	c.JSON(200, loops)
@@ -0,0 +1,374 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// ONBOARDING CHECKLIST — owner-defined first steps for new members
+// Manual steps are ticked off by the member; the others complete themselves
+// when the member posts in the step's channel or gets a PR merged.
+// ============================================================================
+
+const (
+	onboardingManual        = "manual"
+	onboardingPostInChannel = "post_in_channel"
+	onboardingFirstPR       = "first_pr"
+
+	jobOnboardingNudge = "onboarding_nudge"
+)
+
+// onboardingNudgeAfter is when new members with unfinished steps get reminded
+var onboardingNudgeAfter = []time.Duration{24 * time.Hour, 72 * time.Hour}
+
+type OnboardingStepResponse struct {
+	ID          string  `json:"id"`
+	Title       string  `json:"title"`
+	Description string  `json:"description,omitempty"`
+	Kind        string  `json:"kind"`
+	ChannelID   string  `json:"channel_id,omitempty"`
+	Position    int     `json:"position"`
+	CompletedAt *string `json:"completed_at,omitempty"`
+}
+
+type OnboardingStepInput struct {
+	ID          string `json:"id" binding:"omitempty,uuid"` // empty for a new step
+	Title       string `json:"title" binding:"required,notblank,max=200"`
+	Description string `json:"description" binding:"max=1000"`
+	Kind        string `json:"kind" binding:"omitempty,oneof=manual post_in_channel first_pr"`
+	ChannelID   string `json:"channel_id" binding:"required_if=Kind post_in_channel,omitempty,uuid"`
+}
+
+// ReplaceOnboardingStepsRequest is the whole checklist in order; steps left
+// out are deleted along with members' progress on them
+type ReplaceOnboardingStepsRequest struct {
+	Steps []OnboardingStepInput `json:"steps" binding:"max=20,dive"`
+}
+
+type onboardingNudgePayload struct {
+	ProjectID string `json:"project_id"`
+	UserID    string `json:"user_id"`
+}
+
+func memberOnboardingToResponse(rows []db.GetMemberOnboardingRow) ([]OnboardingStepResponse, int) {
+	steps := make([]OnboardingStepResponse, len(rows))
+	done := 0
+	for i, r := range rows {
+		steps[i] = OnboardingStepResponse{
+			ID:          utils.UUIDToStr(r.ID),
+			Title:       r.Title,
+			Description: r.Description,
+			Kind:        r.Kind,
+			Position:    int(r.Position),
+		}
+		if r.ChannelID.Valid {
+			steps[i].ChannelID = utils.UUIDToStr(r.ChannelID)
+		}
+		if r.CompletedAt.Valid {
+			at := utils.FormatTime(r.CompletedAt.Time)
+			steps[i].CompletedAt = &at
+			done++
+		}
+	}
+	return steps, done
+}
+
+// HandleGetOnboarding returns the caller's checklist and progress
+func (h *Handler) HandleGetOnboarding(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	rows, err := h.Queries.GetMemberOnboarding(c, db.GetMemberOnboardingParams{ProjectID: project.ID, UserID: uid})
+	if err != nil {
+		problem.Respond(c, 500, "failed to load onboarding")
+		return
+	}
+	steps, done := memberOnboardingToResponse(rows)
+	c.JSON(200, gin.H{
+		"steps":     steps,
+		"completed": done,
+		"total":     len(steps),
+	})
+}
+
+// HandleReplaceOnboardingSteps saves the loop's checklist (owner only)
+func (h *Handler) HandleReplaceOnboardingSteps(c *gin.Context) {
+	var req ReplaceOnboardingStepsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can manage onboarding")
+		return
+	}
+
+	// Validate everything before touching the database
+	channels := make([]pgtype.UUID, len(req.Steps))
+	for i, s := range req.Steps {
+		if s.Kind == "" {
+			req.Steps[i].Kind = onboardingManual
+		}
+		if channels[i], ok = h.loopChannelParam(c, project, s.ChannelID); !ok {
+			return
+		}
+		req.Steps[i].Title = strings.TrimSpace(s.Title)
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	keep := make([]pgtype.UUID, 0, len(req.Steps))
+	for i, s := range req.Steps {
+		if s.ID == "" {
+			step, err := qtx.CreateOnboardingStep(c, db.CreateOnboardingStepParams{
+				ProjectID:   project.ID,
+				Title:       s.Title,
+				Description: s.Description,
+				Kind:        s.Kind,
+				ChannelID:   channels[i],
+				Position:    int32(i),
+			})
+			if err != nil {
+				problem.Respond(c, 500, "failed to save onboarding")
+				return
+			}
+			keep = append(keep, step.ID)
+			continue
+		}
+
+		id, err := utils.StrToUUID(s.ID)
+		if err != nil {
+			problem.Respond(c, 400, "invalid step id")
+			return
+		}
+		n, err := qtx.UpdateOnboardingStep(c, db.UpdateOnboardingStepParams{
+			ID:          id,
+			ProjectID:   project.ID,
+			Title:       s.Title,
+			Description: s.Description,
+			Kind:        s.Kind,
+			ChannelID:   channels[i],
+			Position:    int32(i),
+		})
+		if err != nil {
+			problem.Respond(c, 500, "failed to save onboarding")
+			return
+		}
+		if n == 0 {
+			problem.Respond(c, 400, "step "+s.ID+" not found in this loop")
+			return
+		}
+		keep = append(keep, id)
+	}
+	if err := qtx.DeleteOnboardingStepsExcept(c, db.DeleteOnboardingStepsExceptParams{ProjectID: project.ID, Keep: keep}); err != nil {
+		problem.Respond(c, 500, "failed to save onboarding")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to save changes")
+		return
+	}
+
+	rows, err := h.Queries.GetMemberOnboarding(c, db.GetMemberOnboardingParams{ProjectID: project.ID, UserID: uid})
+	if err != nil {
+		problem.Respond(c, 500, "failed to load onboarding")
+		return
+	}
+	steps, _ := memberOnboardingToResponse(rows)
+	h.Events.Publish(loopRoom(utils.UUIDToStr(project.ID)), events.OnboardingUpdated)
+	c.JSON(200, gin.H{"steps": steps})
+}
+
+// HandleSetOnboardingStep ticks off (POST) or un-ticks (DELETE) a manual step
+func (h *Handler) HandleSetOnboardingStep(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	stepID, err := utils.StrToUUID(c.Param("step_id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid step id")
+		return
+	}
+	step, err := h.Queries.GetOnboardingStep(c, stepID)
+	if err != nil || step.ProjectID != project.ID {
+		problem.Respond(c, 404, "step not found")
+		return
+	}
+	if step.Kind != onboardingManual {
+		problem.Respond(c, 400, "this step completes automatically")
+		return
+	}
+
+	params := db.CompleteOnboardingStepParams{StepID: stepID, UserID: uid}
+	if c.Request.Method == "DELETE" {
+		err = h.Queries.UncompleteOnboardingStep(c, db.UncompleteOnboardingStepParams(params))
+	} else {
+		_, err = h.Queries.CompleteOnboardingStep(c, params)
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to update progress")
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleGetOnboardingProgress lists every member's progress (moderators only),
+// newest members first so the ones who need a nudge are on top
+func (h *Handler) HandleGetOnboardingProgress(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only moderators can view onboarding progress")
+		return
+	}
+
+	steps, err := h.Queries.ListOnboardingSteps(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load onboarding")
+		return
+	}
+	rows, err := h.Queries.GetLoopOnboardingProgress(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load onboarding")
+		return
+	}
+
+	members := make([]gin.H, len(rows))
+	for i, r := range rows {
+		members[i] = gin.H{
+			"user_id":    utils.UUIDToStr(r.ID),
+			"username":   r.Username,
+			"avatar_url": mediaURL(r.AvatarUrl.String),
+			"joined_at":  utils.FormatTime(r.JoinedAt.Time),
+			"completed":  r.Completed,
+			"done":       int(r.Completed) >= len(steps),
+		}
+	}
+	c.JSON(200, gin.H{"total_steps": len(steps), "members": members})
+}
+
+// completeOnboarding ticks off a member's automatic steps of the given kind
+// and lets their clients refresh the checklist
+func (h *Handler) completeOnboarding(ctx context.Context, projectID, userID pgtype.UUID, kind string, channelID pgtype.UUID) {
+	n, err := h.Queries.CompleteOnboardingStepsByKind(ctx, db.CompleteOnboardingStepsByKindParams{
+		UserID:    userID,
+		ProjectID: projectID,
+		Kind:      kind,
+		ChannelID: channelID,
+	})
+	if err != nil {
+		log.Printf("[onboarding] failed to complete %s steps: %v", kind, err)
+		return
+	}
+	if n > 0 {
+		h.Events.PublishUser(utils.UUIDToStr(userID), events.LoopRef{ProjectID: utils.UUIDToStr(projectID)})
+	}
+}
+
+// completeOnboardingForPR credits a merged PR to its author's checklist
+func (h *Handler) completeOnboardingForPR(ctx context.Context, project db.Project, pr *github.PullRequest) {
+	author, err := h.Queries.GetUserByGithubID(ctx, pr.User.ID)
+	if err != nil {
+		return
+	}
+	h.completeOnboarding(ctx, project.ID, author.ID, onboardingFirstPR, pgtype.UUID{})
+}
+
+// scheduleOnboardingNudges queues the reminders for a member who just joined
+func (h *Handler) scheduleOnboardingNudges(ctx context.Context, projectID, userID pgtype.UUID) {
+	payload := onboardingNudgePayload{ProjectID: utils.UUIDToStr(projectID), UserID: utils.UUIDToStr(userID)}
+	for _, after := range onboardingNudgeAfter {
+		if _, err := h.Jobs.Enqueue(ctx, jobOnboardingNudge, payload, time.Now().Add(after)); err != nil {
+			log.Printf("[onboarding] failed to schedule nudge: %v", err)
+		}
+	}
+}
+
+// runOnboardingNudge notifies a member who still has steps left, pointing at
+// the next one. Nothing is sent once they're done or have left the loop.
+func (h *Handler) runOnboardingNudge(ctx context.Context, raw json.RawMessage) error {
+	var p onboardingNudgePayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	projectID, err := utils.StrToUUID(p.ProjectID)
+	if err != nil {
+		return fmt.Errorf("bad project id: %w", err)
+	}
+	userID, err := utils.StrToUUID(p.UserID)
+	if err != nil {
+		return fmt.Errorf("bad user id: %w", err)
+	}
+	if !h.Members.IsMember(ctx, userID, projectID) {
+		return nil
+	}
+
+	rows, err := h.Queries.GetMemberOnboarding(ctx, db.GetMemberOnboardingParams{ProjectID: projectID, UserID: userID})
+	if err != nil {
+		return err
+	}
+	var next *db.GetMemberOnboardingRow
+	left := 0
+	for i := range rows {
+		if !rows[i].CompletedAt.Valid {
+			if next == nil {
+				next = &rows[i]
+			}
+			left++
+		}
+	}
+	if next == nil {
+		return nil
+	}
+
+	project, err := h.Loops.ByID(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	owner, err := h.getUserByID(ctx, project.OwnerID)
+	if err != nil {
+		return err
+	}
+	preview := fmt.Sprintf("%d of %d onboarding steps left in %s — next: %s", left, len(rows), project.Name, next.Title)
+
+	_, _, err = h.Notifier.Notify(ctx, notify.Event{
+		Type:          "onboarding",
+		UserID:        userID,
+		ActorID:       owner.ID,
+		ActorUsername: owner.Username,
+		ProjectID:     projectID,
+		ChannelID:     next.ChannelID,
+		Preview:       preview,
+	})
+	return err
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"strconv"
+
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// PAGINATION
+// Paginated lists answer with one envelope: the page's items, whether more
+// follow, and the cursor to send back as ?cursor= for them. Cursors are
+// opaque to clients; each list decides what it puts in them (an offset, a
+// message ID, a GitHub page number). ?limit= sets the page size. The query
+// parameters lists took before (offset, page, per_page) are still read.
+// ============================================================================
+
+// Page is the envelope of a paginated list. next_cursor is null on the last
+// page.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+	HasMore    bool    `json:"has_more"`
+}
+
+// newPage wraps a page of items; next is only kept when more follow
+func newPage[T any](items []T, hasMore bool, next string) Page[T] {
+	if items == nil {
+		items = []T{}
+	}
+	p := Page[T]{Items: items, HasMore: hasMore}
+	if hasMore {
+		p.NextCursor = &next
+	}
+	return p
+}
+
+// trimPage cuts rows fetched with one row over limit down to the page and
+// reports whether there is more past it
+func trimPage[R any](rows []R, limit int) ([]R, bool) {
+	if len(rows) > limit {
+		return rows[:limit], true
+	}
+	return rows, false
+}
+
+// pageLimit reads the page size from ?limit= (or ?per_page=); a missing or
+// invalid one is def, and it is capped at most
+func pageLimit(c *gin.Context, def, most int) int {
+	raw := c.Query("limit")
+	if raw == "" {
+		raw = c.Query("per_page")
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return def
+	}
+	return min(n, most)
+}
+
+// offsetPage reads the page of a list paged by offset, whose cursor is the
+// offset of the next page. Without a cursor it falls back to ?offset= and
+// then ?page=. It writes the 400 for a bad cursor.
+func offsetPage(c *gin.Context, def, most int) (limit, offset int, ok bool) {
+	limit = pageLimit(c, def, most)
+	if cursor := c.Query("cursor"); cursor != "" {
+		o, err := strconv.Atoi(cursor)
+		if err != nil || o < 0 {
+			problem.Respond(c, 400, "invalid cursor")
+			return 0, 0, false
+		}
+		return limit, o, true
+	}
+	if o, err := strconv.Atoi(c.Query("offset")); err == nil && o > 0 {
+		offset = o
+	} else if p, err := strconv.Atoi(c.Query("page")); err == nil && p > 1 {
+		offset = (p - 1) * limit
+	}
+	return limit, offset, true
+}
+
+// offsetCursor is the cursor of the page starting at offset
+func offsetCursor(offset int) string {
+	return strconv.Itoa(offset)
+}
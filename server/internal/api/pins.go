@@ -1,12 +1,22 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"log"
 	"strconv"
+	"strings"
 	"time"
 	utils "wireloop/internal"
 	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/members"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // PinnedMessageResponse extends MessageResponse with pin info
@@ -16,105 +26,194 @@ type PinnedMessageResponse struct {
 	PinnedByUsername string `json:"pinned_by_username"`
 }
 
-// HandlePinMessage pins a message in a channel
-func (h *Handler) HandlePinMessage(c *gin.Context) {
-	messageIDStr := c.Param("message_id")
-	messageID, err := strconv.ParseInt(messageIDStr, 10, 64)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid message id"})
-		return
+// Who may pin in a loop (loop_settings.pin_role)
+const (
+	pinRoleMembers    = "members" // any member but guests
+	pinRoleModerators = "moderators"
+
+	defaultPinLimit = 50
+	maxPinLimit     = 250
+)
+
+// Pin audit actions. evict is an unpin made to stay under the pin limit.
+const (
+	pinActionPin   = "pin"
+	pinActionUnpin = "unpin"
+	pinActionEvict = "evict"
+)
+
+// pinPolicy returns who may pin and the per-channel limit, with the
+// defaults filled in for loops that never saved settings
+func pinPolicy(s db.LoopSetting) (string, int32) {
+	role, limit := s.PinRole, s.PinLimit
+	if role == "" {
+		role = pinRoleMembers
 	}
+	if limit <= 0 {
+		limit = defaultPinLimit
+	}
+	return role, limit
+}
 
+// pinTarget loads the message behind :message_id and checks the caller may
+// pin in its loop. On refusal it writes the error and returns false.
+func (h *Handler) pinTarget(c *gin.Context) (db.Message, pgtype.UUID, db.LoopSetting, bool) {
+	messageID, err := strconv.ParseInt(c.Param("message_id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid message id")
+		return db.Message{}, pgtype.UUID{}, db.LoopSetting{}, false
+	}
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
-		return
+		problem.Respond(c, 401, "unauthorized")
+		return db.Message{}, uid, db.LoopSetting{}, false
 	}
 
-	ctx := c.Request.Context()
-
 	// Get the message to verify it exists
-	msg, err := h.Queries.GetMessageByID(ctx, messageID)
+	msg, err := h.Queries.GetMessageByID(c, messageID)
+	if err != nil || msg.IsDeleted.Bool {
+		problem.Respond(c, 404, "message not found")
+		return db.Message{}, uid, db.LoopSetting{}, false
+	}
+
+	settings, err := h.Queries.GetLoopSettings(c, msg.ProjectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		settings, err = db.LoopSetting{ProjectID: msg.ProjectID}, nil
+	}
 	if err != nil {
-		c.JSON(404, gin.H{"error": "message not found"})
-		return
+		problem.Respond(c, 500, "failed to load settings")
+		return db.Message{}, uid, db.LoopSetting{}, false
 	}
+	role := h.Members.Role(c, uid, msg.ProjectID)
+	pinRole, _ := pinPolicy(settings)
+	switch {
+	case role == "":
+		problem.Respond(c, 403, "not a member")
+		return db.Message{}, uid, settings, false
+	case role == members.Guest:
+		problem.Respond(c, 403, "guests can't pin messages")
+		return db.Message{}, uid, settings, false
+	case pinRole == pinRoleModerators && role != members.Owner && role != members.Moderator:
+		problem.Respond(c, 403, "only moderators can pin messages in this loop")
+		return db.Message{}, uid, settings, false
+	}
+	return msg, uid, settings, true
+}
 
-	// Verify user is member of the project
-	if _, err := h.Queries.IsMember(ctx, db.IsMemberParams{
-		UserID: uid, ProjectID: msg.ProjectID,
+// logPinEvent records a pin action; the action already happened, so a
+// failure is only logged
+func (h *Handler) logPinEvent(ctx context.Context, msg db.Message, actor pgtype.UUID, action string) {
+	if err := h.Queries.LogPinEvent(ctx, db.LogPinEventParams{
+		ProjectID: msg.ProjectID,
+		ChannelID: msg.ChannelID,
+		MessageID: msg.ID,
+		ActorID:   actor,
+		Action:    action,
 	}); err != nil {
-		c.JSON(403, gin.H{"error": "not a member"})
+		log.Printf("[pins] failed to record %s of %d: %v", action, msg.ID, err)
+	}
+}
+
+// HandlePinMessage pins a message in a channel. A channel at its pin limit
+// answers 409 pin_limit_reached naming the oldest pin; repeating the request
+// with ?replace_oldest=true unpins that one to make room.
+func (h *Handler) HandlePinMessage(c *gin.Context) {
+	msg, uid, settings, ok := h.pinTarget(c)
+	if !ok {
+		return
+	}
+	if msg.IsPinned.Bool {
+		c.JSON(200, gin.H{"success": true})
+		return
+	}
+
+	ctx := c.Request.Context()
+	channelID := utils.UUIDToStr(msg.ChannelID)
+	_, limit := pinPolicy(settings)
+	count, err := h.Queries.CountChannelPins(ctx, msg.ChannelID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to pin message")
 		return
 	}
+	if count >= limit {
+		oldestID, err := h.Queries.GetOldestChannelPin(ctx, msg.ChannelID)
+		if err != nil {
+			problem.Respond(c, 500, "failed to pin message")
+			return
+		}
+		oldest := strconv.FormatInt(oldestID, 10)
+		if c.Query("replace_oldest") != "true" {
+			problem.RespondCode(c, 409, "pin_limit_reached",
+				"this channel already has "+strconv.Itoa(int(limit))+" pins; unpin one or retry with replace_oldest=true",
+				gin.H{"limit": limit, "oldest_message_id": oldest})
+			return
+		}
+		if err := h.Queries.UnpinMessage(ctx, oldestID); err != nil {
+			problem.Respond(c, 500, "failed to unpin the oldest message")
+			return
+		}
+		h.logPinEvent(ctx, db.Message{ID: oldestID, ProjectID: msg.ProjectID, ChannelID: msg.ChannelID}, uid, pinActionEvict)
+		h.Events.PublishChannel(channelID, events.MessageRef{MessageID: oldest, Unpinned: true})
+	}
 
 	// Pin the message
 	if err := h.Queries.PinMessage(ctx, db.PinMessageParams{
-		ID:       messageID,
+		ID:       msg.ID,
 		PinnedBy: uid,
 	}); err != nil {
-		c.JSON(500, gin.H{"error": "failed to pin message"})
+		problem.Respond(c, 500, "failed to pin message")
 		return
 	}
+	h.logPinEvent(ctx, msg, uid, pinActionPin)
 
 	// Broadcast pin event via WebSocket
-	channelID := utils.UUIDToStr(msg.ChannelID)
-	user, _ := h.Queries.GetUserByID(ctx, uid)
-
-	h.Hub.Broadcast(channelID, WSOutMessage{
-		Type:      "message_pinned",
-		ChannelID: channelID,
-		Payload: gin.H{
-			"message_id": messageIDStr,
-			"pinned_by":  user.Username,
-			"pinned_at":  time.Now().Format(time.RFC3339),
-		},
+	user, _ := h.getUserByID(ctx, uid)
+
+	h.Events.PublishChannel(channelID, events.Pin{
+		MessageID: strconv.FormatInt(msg.ID, 10),
+		PinnedBy:  user.Username,
+		PinnedAt:  utils.FormatTime(time.Now()),
 	})
 
+	// Let the author know someone found their message worth pinning
+	if msg.SenderID != uid {
+		if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+			Type:          "pin",
+			UserID:        msg.SenderID,
+			ActorID:       uid,
+			ActorUsername: user.Username,
+			ProjectID:     msg.ProjectID,
+			ChannelID:     msg.ChannelID,
+			MessageID:     pgtype.Int8{Int64: msg.ID, Valid: true},
+			Preview:       notify.Preview(msg.Content),
+		}); err != nil {
+			log.Printf("[pins] failed to notify message author: %v", err)
+		}
+	}
+
 	c.JSON(200, gin.H{"success": true})
 }
 
 // HandleUnpinMessage unpins a message
 func (h *Handler) HandleUnpinMessage(c *gin.Context) {
-	messageIDStr := c.Param("message_id")
-	messageID, err := strconv.ParseInt(messageIDStr, 10, 64)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid message id"})
-		return
-	}
-
-	uid, ok := utils.GetUserIdFromContext(c)
+	msg, uid, _, ok := h.pinTarget(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	ctx := c.Request.Context()
-
-	msg, err := h.Queries.GetMessageByID(ctx, messageID)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "message not found"})
 		return
 	}
-
-	if _, err := h.Queries.IsMember(ctx, db.IsMemberParams{
-		UserID: uid, ProjectID: msg.ProjectID,
-	}); err != nil {
-		c.JSON(403, gin.H{"error": "not a member"})
+	if !msg.IsPinned.Bool {
+		c.JSON(200, gin.H{"success": true})
 		return
 	}
 
-	if err := h.Queries.UnpinMessage(ctx, messageID); err != nil {
-		c.JSON(500, gin.H{"error": "failed to unpin message"})
+	ctx := c.Request.Context()
+	if err := h.Queries.UnpinMessage(ctx, msg.ID); err != nil {
+		problem.Respond(c, 500, "failed to unpin message")
 		return
 	}
+	h.logPinEvent(ctx, msg, uid, pinActionUnpin)
 
 	channelID := utils.UUIDToStr(msg.ChannelID)
-	h.Hub.Broadcast(channelID, WSOutMessage{
-		Type:      "message_unpinned",
-		ChannelID: channelID,
-		Payload:   gin.H{"message_id": messageIDStr},
-	})
+	h.Events.PublishChannel(channelID, events.MessageRef{MessageID: strconv.FormatInt(msg.ID, 10), Unpinned: true})
 
 	c.JSON(200, gin.H{"success": true})
 }
@@ -124,13 +223,13 @@ func (h *Handler) HandleGetPinnedMessages(c *gin.Context) {
 	channelIDStr := c.Param("id")
 	channelID, err := utils.StrToUUID(channelIDStr)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid channel id"})
+		problem.Respond(c, 400, "invalid channel id")
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
@@ -139,20 +238,18 @@ func (h *Handler) HandleGetPinnedMessages(c *gin.Context) {
 	// Verify channel belongs to a project user is member of
 	channel, err := h.Queries.GetChannelByID(ctx, channelID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "channel not found"})
+		problem.Respond(c, 404, "channel not found")
 		return
 	}
 
-	if _, err := h.Queries.IsMember(ctx, db.IsMemberParams{
-		UserID: uid, ProjectID: channel.ProjectID,
-	}); err != nil {
-		c.JSON(403, gin.H{"error": "not a member"})
+	if !h.Members.CanUseChannel(ctx, h.Members.Role(ctx, uid, channel.ProjectID), channel.ProjectID, channelID) {
+		problem.Respond(c, 403, "not a member of this channel")
 		return
 	}
 
 	pinned, err := h.Queries.GetPinnedMessages(ctx, channelID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get pinned messages"})
+		problem.Respond(c, 500, "failed to get pinned messages")
 		return
 	}
 
@@ -165,7 +262,7 @@ func (h *Handler) HandleGetPinnedMessages(c *gin.Context) {
 		}
 		pinnedAt := ""
 		if m.PinnedAt.Valid {
-			pinnedAt = m.PinnedAt.Time.Format(time.RFC3339)
+			pinnedAt = utils.FormatTime(m.PinnedAt.Time)
 		}
 		result = append(result, PinnedMessageResponse{
 			MessageResponse: MessageResponse{
@@ -173,8 +270,9 @@ func (h *Handler) HandleGetPinnedMessages(c *gin.Context) {
 				Content:        m.Content,
 				SenderID:       utils.UUIDToStr(m.SenderID),
 				SenderUsername: m.SenderUsername,
-				SenderAvatar:   m.SenderAvatar.String,
-				CreatedAt:      m.CreatedAt.Time.Format(time.RFC3339),
+				SenderAvatar:   mediaURL(m.SenderAvatar.String),
+				CreatedAt:      utils.FormatTime(m.CreatedAt.Time),
+				CreatedAtMs:    utils.EpochMillis(m.CreatedAt.Time),
 				ChannelID:      channelIDStr,
 				ParentID:       parentID,
 				ReplyCount:     int(m.ReplyCount.Int32),
@@ -186,3 +284,165 @@ func (h *Handler) HandleGetPinnedMessages(c *gin.Context) {
 
 	c.JSON(200, result)
 }
+
+// LoopPinResponse is a pinned message on the loop-wide pin board
+type LoopPinResponse struct {
+	PinnedMessageResponse
+	ChannelName string `json:"channel_name"`
+}
+
+// HandleGetLoopPins lists the pinned messages of every channel in the loop
+// the caller can see, newest pin first. Pages continue from next_cursor.
+func (h *Handler) HandleGetLoopPins(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	limit := pageLimit(c, 50, 100)
+	params := db.GetLoopPinnedMessagesParams{ProjectID: project.ID, RowLimit: int32(limit + 1)}
+	if cursor := c.Query("cursor"); cursor != "" {
+		pinnedAt, id, ok := parsePinCursor(cursor)
+		if !ok {
+			problem.Respond(c, 400, "invalid cursor")
+			return
+		}
+		params.BeforePinnedAt = pgtype.Timestamptz{Time: pinnedAt, Valid: true}
+		params.BeforeID = pgtype.Int8{Int64: id, Valid: true}
+	}
+	if h.Members.Role(c, uid, project.ID) == members.Guest {
+		params.ChannelIds = make([]pgtype.UUID, 0)
+		for id := range h.Members.GuestChannels(c, project.ID) {
+			params.ChannelIds = append(params.ChannelIds, id)
+		}
+	}
+
+	pinned, err := h.Queries.GetLoopPinnedMessages(c, params)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get pinned messages")
+		return
+	}
+	pinned, hasMore := trimPage(pinned, limit)
+	next := ""
+	if hasMore {
+		last := pinned[len(pinned)-1]
+		next = strconv.FormatInt(last.PinnedAt.Time.UnixMicro(), 10) + "_" + strconv.FormatInt(last.ID, 10)
+	}
+
+	messages := make([]MessageResponse, len(pinned))
+	for i, m := range pinned {
+		var parentID *string
+		if m.ParentID.Valid {
+			s := strconv.FormatInt(m.ParentID.Int64, 10)
+			parentID = &s
+		}
+		messages[i] = MessageResponse{
+			ID:             strconv.FormatInt(m.ID, 10),
+			Content:        m.Content,
+			SenderID:       utils.UUIDToStr(m.SenderID),
+			SenderUsername: m.SenderUsername,
+			SenderAvatar:   mediaURL(m.SenderAvatar.String),
+			CreatedAt:      utils.FormatTime(m.CreatedAt.Time),
+			CreatedAtMs:    utils.EpochMillis(m.CreatedAt.Time),
+			ChannelID:      utils.UUIDToStr(m.ChannelID),
+			ParentID:       parentID,
+			ReplyCount:     int(m.ReplyCount.Int32),
+		}
+	}
+	h.attachEntities(c, project.ID, messages)
+	h.annotateForViewer(c, uid, messages)
+
+	result := make([]LoopPinResponse, len(pinned))
+	for i, m := range pinned {
+		result[i] = LoopPinResponse{
+			PinnedMessageResponse: PinnedMessageResponse{
+				MessageResponse:  messages[i],
+				PinnedAt:         utils.FormatTime(m.PinnedAt.Time),
+				PinnedByUsername: m.PinnedByUsername.String,
+			},
+			ChannelName: m.ChannelName,
+		}
+	}
+	c.JSON(200, newPage(result, hasMore, next))
+}
+
+type PinEventResponse struct {
+	ID            string `json:"id"`
+	Action        string `json:"action"` // pin, unpin or evict
+	MessageID     string `json:"message_id"`
+	ChannelID     string `json:"channel_id"`
+	ChannelName   string `json:"channel_name"`
+	ActorUsername string `json:"actor_username"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// HandleGetPinAudit lists the loop's pin and unpin actions, newest first
+// (owner or moderator). Pages continue from ?before=<next_before>.
+func (h *Handler) HandleGetPinAudit(c *gin.Context) {
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only the loop owner or a moderator can view the pin log")
+		return
+	}
+
+	limit := int32(50)
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 100 {
+			limit = int32(v)
+		}
+	}
+	params := db.ListPinEventsParams{ProjectID: project.ID, RowLimit: limit + 1}
+	if b := c.Query("before"); b != "" {
+		id, err := strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			problem.Respond(c, 400, "invalid before cursor")
+			return
+		}
+		params.BeforeID = pgtype.Int8{Int64: id, Valid: true}
+	}
+	rows, err := h.Queries.ListPinEvents(c, params)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get pin log")
+		return
+	}
+	var nextBefore *string
+	if len(rows) > int(limit) {
+		rows = rows[:limit]
+		next := strconv.FormatInt(rows[len(rows)-1].ID, 10)
+		nextBefore = &next
+	}
+
+	events := make([]PinEventResponse, len(rows))
+	for i, e := range rows {
+		events[i] = PinEventResponse{
+			ID:            strconv.FormatInt(e.ID, 10),
+			Action:        e.Action,
+			MessageID:     strconv.FormatInt(e.MessageID, 10),
+			ChannelID:     utils.UUIDToStr(e.ChannelID),
+			ChannelName:   e.ChannelName.String,
+			ActorUsername: e.ActorUsername.String,
+			CreatedAt:     utils.FormatTime(e.CreatedAt.Time),
+		}
+	}
+	c.JSON(200, gin.H{"events": events, "next_before": nextBefore})
+}
+
+// parsePinCursor reads a "<pinned_at unix micros>_<message id>" cursor
+func parsePinCursor(cursor string) (time.Time, int64, bool) {
+	at, id, found := strings.Cut(cursor, "_")
+	if !found {
+		return time.Time{}, 0, false
+	}
+	micros, err := strconv.ParseInt(at, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	msgID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return time.UnixMicro(micros), msgID, true
+}
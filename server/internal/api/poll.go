@@ -0,0 +1,147 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/chat"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// ============================================================================
+// LONG POLLING
+// The last resort for clients that can use neither the socket nor SSE.
+// GET /api/channels/:id/poll?after=<cursor> answers as soon as the channel
+// (or its loop) has events after the cursor, or empty once the timeout
+// passes; the client polls again with the returned cursor. Events are the
+// same frames the socket sends, read from the Hub's room logs.
+// ============================================================================
+
+const (
+	defaultPollTimeout = 25 * time.Second
+	// Stays under the 60s idle timeout most proxies and load balancers use
+	maxPollTimeout = 55 * time.Second
+)
+
+type PollResponse struct {
+	Events []any  `json:"events"`
+	Cursor string `json:"cursor"`
+	// The log no longer reaches back to after; refetch history, then keep
+	// polling from Cursor
+	Resync bool `json:"resync,omitempty"`
+}
+
+// HandlePoll holds the request until the channel has new events
+func (h *Handler) HandlePoll(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	channelUUID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid channel id")
+		return
+	}
+	channel, err := h.Queries.GetChannelByID(c, channelUUID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 404, "channel not found")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to get channel")
+		return
+	}
+	role := h.Members.Role(c, uid, channel.ProjectID)
+	if role == "" {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+	if role == members.Guest && !h.Members.GuestChannels(c, channel.ProjectID)[channelUUID] {
+		problem.Respond(c, 403, "guests can only join guest channels")
+		return
+	}
+
+	var after int64
+	if q := c.Query("after"); q != "" {
+		after, err = strconv.ParseInt(q, 10, 64)
+		if err != nil || after < 0 {
+			problem.Respond(c, 400, "invalid after cursor")
+			return
+		}
+	}
+	timeout := defaultPollTimeout
+	if q := c.Query("timeout"); q != "" {
+		timeout, err = time.ParseDuration(q)
+		if err != nil || timeout < 0 || timeout > maxPollTimeout {
+			problem.Respond(c, 400, "timeout must be a duration of at most 55s")
+			return
+		}
+	}
+
+	channelID := utils.UUIDToStr(channelUUID)
+	rooms := []string{channelID, loopRoom(utils.UUIDToStr(channel.ProjectID))}
+	for _, room := range rooms {
+		h.Hub.Watch(room)
+	}
+	collect := func() (events []chat.Event, cursor int64, complete bool) {
+		cursor, complete = after, true
+		for _, room := range rooms {
+			logged, last, ok := h.Hub.Since(room, after)
+			events = append(events, logged...)
+			cursor = max(cursor, last)
+			complete = complete && ok
+		}
+		sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+		return events, cursor, complete
+	}
+
+	events, cursor, complete := collect()
+	if after == 0 {
+		// No cursor yet: start from now rather than replaying the log
+		after = cursor
+	}
+	if len(events) == 0 && complete && timeout > 0 {
+		client := chat.NewStreamClient(uid, "", "")
+		for _, room := range rooms {
+			h.Hub.Join(room, client)
+		}
+		defer func() {
+			for _, room := range rooms {
+				h.Hub.Leave(room, client)
+			}
+			client.Close()
+		}()
+		// Anything logged before the Join wouldn't wake the client
+		events, cursor, complete = collect()
+		if len(events) == 0 && complete {
+			wait, cancel := context.WithTimeout(c.Request.Context(), timeout)
+			_, woke := client.Receive(wait)
+			cancel()
+			if c.Request.Context().Err() != nil {
+				return
+			}
+			if woke {
+				events, cursor, complete = collect()
+			}
+		}
+	}
+
+	resp := PollResponse{
+		Events: make([]any, len(events)),
+		Cursor: strconv.FormatInt(cursor, 10),
+		Resync: !complete,
+	}
+	for i, ev := range events {
+		resp.Events[i] = ev.Data
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(200, resp)
+}
@@ -1,68 +1,29 @@
 package api
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"strconv"
+	"time"
 
 	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 // ============================================================================
 // PR REVIEW SYNC — Two-way sync between GitHub PR comments and Wireloop
 // ============================================================================
 
-// PRReviewComment represents a review comment from GitHub
-type PRReviewComment struct {
-	ID        int64  `json:"id"`
-	Body      string `json:"body"`
-	Path      string `json:"path,omitempty"`
-	Line      *int   `json:"line,omitempty"`
-	Side      string `json:"side,omitempty"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	HTMLURL   string `json:"html_url"`
-	User      struct {
-		Login     string `json:"login"`
-		AvatarURL string `json:"avatar_url"`
-	} `json:"user"`
-	// For review comments on specific lines
-	DiffHunk    string `json:"diff_hunk,omitempty"`
-	InReplyToID *int64 `json:"in_reply_to_id,omitempty"`
-}
-
-// PRIssueComment represents a top-level PR comment (not on a specific line)
-type PRIssueComment struct {
-	ID        int64  `json:"id"`
-	Body      string `json:"body"`
-	CreatedAt string `json:"created_at"`
-	UpdatedAt string `json:"updated_at"`
-	HTMLURL   string `json:"html_url"`
-	User      struct {
-		Login     string `json:"login"`
-		AvatarURL string `json:"avatar_url"`
-	} `json:"user"`
-}
-
-// PRReview represents a review (approved, changes requested, etc.)
-type PRReview struct {
-	ID        int64  `json:"id"`
-	Body      string `json:"body"`
-	State     string `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED
-	CreatedAt string `json:"submitted_at"`
-	HTMLURL   string `json:"html_url"`
-	User      struct {
-		Login     string `json:"login"`
-		AvatarURL string `json:"avatar_url"`
-	} `json:"user"`
-}
-
 // UnifiedComment is what we return to the frontend — all comment types merged
 type UnifiedComment struct {
 	ID          int64  `json:"id"`
@@ -74,23 +35,119 @@ type UnifiedComment struct {
 	State       string `json:"state,omitempty"` // For reviews: APPROVED, CHANGES_REQUESTED
 	InReplyToID *int64 `json:"in_reply_to_id,omitempty"`
 	CreatedAt   string `json:"created_at"`
+	CreatedAtMs int64  `json:"created_at_ms"`
 	HTMLURL     string `json:"html_url"`
 	Username    string `json:"username"`
 	AvatarURL   string `json:"avatar_url"`
 	Source      string `json:"source"` // "github"
 }
 
-// PostCommentRequest for posting a comment back to GitHub
+const (
+	jobPRCommentsSync    = "pr_comments_sync"
+	prCommentsStaleAfter = 2 * time.Minute
+)
+
+type prCommentsSyncPayload struct {
+	ProjectID string `json:"project_id"`
+	UserID    string `json:"user_id"`
+	PRNumber  int    `json:"pr_number"`
+	// Issue refreshes a plain issue's comments instead; issues and PRs share numbers
+	Issue bool `json:"issue,omitempty"`
+}
+
+// PostCommentRequest for posting a comment back to GitHub.
+// Without InReplyTo, Path or Review it becomes a top-level PR comment.
 type PostCommentRequest struct {
-	PRNumber int    `json:"pr_number" binding:"required"`
-	Body     string `json:"body" binding:"required"`
+	PRNumber int    `json:"pr_number" binding:"required,min=1"`
+	Body     string `json:"body" binding:"max=65536"`
 	// Optional: reply to a specific review comment
 	InReplyTo *int64 `json:"in_reply_to,omitempty"`
+	// Optional: new inline comment on the diff. CommitID defaults to the PR head.
+	Path      string `json:"path,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Side      string `json:"side,omitempty" binding:"omitempty,oneof=LEFT RIGHT"`
+	StartLine int    `json:"start_line,omitempty"`
+	CommitID  string `json:"commit_id,omitempty"`
+	// Optional review flow: "start" opens a pending review holding Comments
+	// (plus the inline comment above, if any); "submit" publishes ReviewID as Event
+	Review   string                      `json:"review,omitempty" binding:"omitempty,oneof=start submit"`
+	ReviewID int64                       `json:"review_id,omitempty"`
+	Event    string                      `json:"event,omitempty" binding:"omitempty,oneof=COMMENT APPROVE REQUEST_CHANGES"`
+	Comments []github.DraftReviewComment `json:"comments,omitempty" binding:"max=50"`
+}
+
+// SubmitReviewRequest submits a complete review in one step
+type SubmitReviewRequest struct {
+	Event    string                      `json:"event" binding:"required,oneof=COMMENT APPROVE REQUEST_CHANGES"`
+	Body     string                      `json:"body" binding:"max=65536"`
+	CommitID string                      `json:"commit_id,omitempty"`
+	Comments []github.DraftReviewComment `json:"comments,omitempty" binding:"max=50"`
+}
+
+// PRReviewState is the PR's review outcome, from each reviewer's latest verdict
+type PRReviewState struct {
+	Decision           string   `json:"decision"` // approved, changes_requested, review_required
+	ApprovedBy         []string `json:"approved_by"`
+	ChangesRequestedBy []string `json:"changes_requested_by"`
+}
+
+const maxDraftReviewComments = 50
+
+// validDiffSide accepts GitHub's diff sides; empty means RIGHT
+func validDiffSide(side string) bool {
+	return side == "" || side == "LEFT" || side == "RIGHT"
+}
+
+func validDraftComments(drafts []github.DraftReviewComment) bool {
+	for _, d := range drafts {
+		if d.Path == "" || d.Body == "" || d.Line <= 0 || !validDiffSide(d.Side) || d.StartLine >= d.Line {
+			return false
+		}
+	}
+	return true
+}
+
+// validate checks the request shape for the selected mode; binding tags
+// have already checked the enums
+func (req *PostCommentRequest) validate() string {
+	switch req.Review {
+	case "":
+		if req.Body == "" {
+			return "body is required"
+		}
+	case "start":
+		if req.Path != "" {
+			req.Comments = append(req.Comments, github.DraftReviewComment{
+				Path: req.Path, Body: req.Body, Line: req.Line, Side: req.Side, StartLine: req.StartLine,
+			})
+			req.Body = ""
+		}
+		if len(req.Comments) > maxDraftReviewComments {
+			return "too many review comments"
+		}
+		if !validDraftComments(req.Comments) {
+			return "invalid review comment"
+		}
+		return ""
+	case "submit":
+		if req.ReviewID == 0 {
+			return "review_id is required"
+		}
+		if req.Event == "" {
+			req.Event = "COMMENT"
+		}
+		return ""
+	}
+	if req.Path != "" && (req.Line <= 0 || !validDiffSide(req.Side) || req.StartLine >= req.Line) {
+		return "invalid line range"
+	}
+	return ""
 }
 
 // ============================================================================
 // GET /api/loops/:name/github/pr/:number/comments
-// Fetches all comments on a PR — review comments, issue comments, and reviews
+// Serves a PR's review comments, issue comments and reviews from the local
+// store. The first view fetches inline; stale PRs refresh in the background.
 // ============================================================================
 
 func (h *Handler) HandleGetPRComments(c *gin.Context) {
@@ -98,305 +155,699 @@ func (h *Handler) HandleGetPRComments(c *gin.Context) {
 	prNumberStr := c.Param("number")
 	prNumber, err := strconv.Atoi(prNumberStr)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "invalid PR number"})
+		problem.Respond(c, 400, "invalid PR number")
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	ctx := c.Request.Context()
-	project, err := h.Queries.GetProjectByName(ctx, name)
+	project, err := h.Loops.ByName(ctx, name)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
-	user, err := h.Queries.GetUserByID(ctx, uid)
+	user, err := h.getUserByID(ctx, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
-	if user.AccessToken == "" {
-		c.JSON(401, gin.H{"error": "no GitHub access token — please re-login"})
+	token := h.loopReadToken(ctx, project, user)
+	if token == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
 		return
 	}
 
-	repoFullName, err := getRepoFullName(project.GithubRepoID, user.AccessToken)
+	repoFullName, ok := h.repoFullNameFor(c, project, token)
+	if !ok {
+		return
+	}
+
+	state, err := h.Queries.GetPRCommentSync(ctx, db.GetPRCommentSyncParams{
+		RepoID:   project.GithubRepoID,
+		PrNumber: int32(prNumber),
+	})
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 500, "failed to load comments")
+		return
+	}
+
+	refreshing := false
+	switch {
+	case !state.SyncedAt.Valid:
+		// Nothing stored yet — fetch now so the first view isn't empty
+		if _, err := h.syncPRComments(ctx, token, repoFullName, project.GithubRepoID, prNumber); err != nil {
+			log.Printf("[pr-review] initial sync of %s#%d incomplete: %v", repoFullName, prNumber, err)
+		}
+	case time.Since(state.SyncedAt.Time) > prCommentsStaleAfter:
+		refreshing = h.queuePRCommentsRefresh(ctx, project, uid, prNumber, false)
+	}
+
+	rows, err := h.Queries.ListPRComments(ctx, db.ListPRCommentsParams{
+		RepoID:   project.GithubRepoID,
+		PrNumber: int32(prNumber),
+	})
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		problem.Respond(c, 500, "failed to load comments")
 		return
 	}
 
-	// Fetch all 3 types of comments in parallel
-	type result struct {
-		comments []UnifiedComment
-		err      error
+	all := make([]UnifiedComment, 0, len(rows))
+	for _, r := range rows {
+		all = append(all, prCommentToUnified(r))
 	}
 
-	reviewCommentsCh := make(chan result, 1)
-	issueCommentsCh := make(chan result, 1)
-	reviewsCh := make(chan result, 1)
+	c.JSON(200, gin.H{
+		"comments":     all,
+		"pr_number":    prNumber,
+		"repo_name":    repoFullName,
+		"refreshing":   refreshing,
+		"review_state": prReviewState(rows),
+	})
+}
 
-	// 1. Review comments (inline on code)
-	go func() {
-		url := fmt.Sprintf("https://api.github.com/repos/%s/pulls/%d/comments?per_page=100&sort=created&direction=asc", repoFullName, prNumber)
-		resp, err := githubAPIGet(url, user.AccessToken)
-		if err != nil {
-			reviewCommentsCh <- result{err: err}
-			return
+// prReviewState folds stored reviews (oldest first) into the current outcome.
+// Plain COMMENTED reviews don't change a reviewer's verdict; DISMISSED clears it.
+func prReviewState(rows []db.PrComment) PRReviewState {
+	verdicts := make(map[string]string)
+	var order []string
+	for _, r := range rows {
+		if r.CommentType != "review" {
+			continue
 		}
-		defer resp.Body.Close()
+		switch r.State.String {
+		case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			if _, seen := verdicts[r.Username]; !seen {
+				order = append(order, r.Username)
+			}
+			verdicts[r.Username] = r.State.String
+		}
+	}
 
-		if resp.StatusCode != 200 {
-			body, _ := io.ReadAll(resp.Body)
-			reviewCommentsCh <- result{err: fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))}
-			return
+	state := PRReviewState{Decision: "review_required", ApprovedBy: []string{}, ChangesRequestedBy: []string{}}
+	for _, username := range order {
+		switch verdicts[username] {
+		case "APPROVED":
+			state.ApprovedBy = append(state.ApprovedBy, username)
+		case "CHANGES_REQUESTED":
+			state.ChangesRequestedBy = append(state.ChangesRequestedBy, username)
 		}
+	}
+	switch {
+	case len(state.ChangesRequestedBy) > 0:
+		state.Decision = "changes_requested"
+	case len(state.ApprovedBy) > 0:
+		state.Decision = "approved"
+	}
+	return state
+}
 
-		var comments []PRReviewComment
-		if err := json.NewDecoder(resp.Body).Decode(&comments); err != nil {
-			reviewCommentsCh <- result{err: err}
+// prCommentToUnified converts a stored comment to the API shape
+func prCommentToUnified(r db.PrComment) UnifiedComment {
+	u := UnifiedComment{
+		ID:          r.CommentID,
+		Type:        r.CommentType,
+		Body:        r.Body,
+		Path:        r.Path.String,
+		DiffHunk:    r.DiffHunk.String,
+		State:       r.State.String,
+		CreatedAt:   utils.FormatTime(r.GithubCreatedAt.Time),
+		CreatedAtMs: utils.EpochMillis(r.GithubCreatedAt.Time),
+		HTMLURL:     r.HtmlUrl,
+		Username:    r.Username,
+		AvatarURL:   r.AvatarUrl.String,
+		Source:      "github",
+	}
+	if r.Line.Valid {
+		line := int(r.Line.Int32)
+		u.Line = &line
+	}
+	if r.InReplyToID.Valid {
+		u.InReplyToID = &r.InReplyToID.Int64
+	}
+	return u
+}
+
+// storedPRComment is the row an upsert writes, for building responses without a re-read
+func storedPRComment(p db.UpsertPRCommentParams) db.PrComment {
+	return db.PrComment{
+		RepoID:          p.RepoID,
+		PrNumber:        p.PrNumber,
+		CommentType:     p.CommentType,
+		CommentID:       p.CommentID,
+		Body:            p.Body,
+		Path:            p.Path,
+		Line:            p.Line,
+		DiffHunk:        p.DiffHunk,
+		InReplyToID:     p.InReplyToID,
+		State:           p.State,
+		Username:        p.Username,
+		AvatarUrl:       p.AvatarUrl,
+		HtmlUrl:         p.HtmlUrl,
+		GithubCreatedAt: p.GithubCreatedAt,
+		GithubUpdatedAt: p.GithubUpdatedAt,
+	}
+}
+
+// githubTime parses a GitHub timestamp, falling back to now for missing values
+func githubTime(s string) pgtype.Timestamptz {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		t = time.Now()
+	}
+	return pgtype.Timestamptz{Time: t, Valid: true}
+}
+
+func optionalText(s string) pgtype.Text {
+	return pgtype.Text{String: s, Valid: s != ""}
+}
+
+// issueCommentRow maps a top-level PR comment to its stored form
+func issueCommentRow(repoID int64, prNumber int, c github.Comment) db.UpsertPRCommentParams {
+	return db.UpsertPRCommentParams{
+		RepoID:          repoID,
+		PrNumber:        int32(prNumber),
+		CommentType:     "issue_comment",
+		CommentID:       c.ID,
+		Body:            c.Body,
+		Username:        c.User.Login,
+		AvatarUrl:       optionalText(c.User.AvatarURL),
+		HtmlUrl:         c.HTMLURL,
+		GithubCreatedAt: githubTime(c.CreatedAt),
+		GithubUpdatedAt: githubTime(c.UpdatedAt),
+	}
+}
+
+// reviewCommentRow maps an inline diff comment to its stored form
+func reviewCommentRow(repoID int64, prNumber int, c github.ReviewComment) db.UpsertPRCommentParams {
+	row := issueCommentRow(repoID, prNumber, c.Comment)
+	row.CommentType = "review_comment"
+	row.Path = optionalText(c.Path)
+	row.DiffHunk = optionalText(c.DiffHunk)
+	if c.Line != nil {
+		row.Line = pgtype.Int4{Int32: int32(*c.Line), Valid: true}
+	}
+	if c.InReplyToID != nil {
+		row.InReplyToID = pgtype.Int8{Int64: *c.InReplyToID, Valid: true}
+	}
+	return row
+}
+
+// reviewRow maps a submitted review to its stored form. Reviews carry no
+// updated_at; state changes are caught by the upsert's body/state check.
+func reviewRow(repoID int64, prNumber int, r github.Review) db.UpsertPRCommentParams {
+	submitted := githubTime(r.SubmittedAt)
+	return db.UpsertPRCommentParams{
+		RepoID:          repoID,
+		PrNumber:        int32(prNumber),
+		CommentType:     "review",
+		CommentID:       r.ID,
+		Body:            r.Body,
+		State:           optionalText(r.State),
+		Username:        r.User.Login,
+		AvatarUrl:       optionalText(r.User.AvatarURL),
+		HtmlUrl:         r.HTMLURL,
+		GithubCreatedAt: submitted,
+		GithubUpdatedAt: submitted,
+	}
+}
+
+// queuePRCommentsRefresh schedules a background refresh of a PR's (or, with
+// issue set, an issue's) comments unless one was claimed within the stale
+// window. Reports whether a refresh was queued.
+func (h *Handler) queuePRCommentsRefresh(ctx context.Context, project db.Project, uid pgtype.UUID, prNumber int, issue bool) bool {
+	claimed, err := h.Queries.ClaimPRCommentRefresh(ctx, db.ClaimPRCommentRefreshParams{
+		RepoID:        project.GithubRepoID,
+		PrNumber:      int32(prNumber),
+		ClaimedBefore: pgtype.Timestamptz{Time: time.Now().Add(-prCommentsStaleAfter), Valid: true},
+	})
+	if err != nil || claimed == 0 {
+		return false
+	}
+	if _, err := h.Jobs.Enqueue(ctx, jobPRCommentsSync, prCommentsSyncPayload{
+		ProjectID: utils.UUIDToStr(project.ID),
+		UserID:    utils.UUIDToStr(uid),
+		PRNumber:  prNumber,
+		Issue:     issue,
+	}, time.Now()); err != nil {
+		log.Printf("[pr-review] failed to queue refresh for #%d: %v", prNumber, err)
+		return false
+	}
+	return true
+}
+
+// syncPRComments fetches all three comment sources from GitHub and reconciles
+// them with the local store. Sources that fail to fetch are left untouched;
+// the PR is only marked synced when every source succeeded.
+// Reports whether any stored comment changed.
+func (h *Handler) syncPRComments(ctx context.Context, token, repoFullName string, repoID int64, prNumber int) (bool, error) {
+	type result struct {
+		kind string
+		rows []db.UpsertPRCommentParams
+		err  error
+	}
+
+	gh := github.Default
+	chronological := github.ListOptions{PerPage: "100", Sort: "created", Dir: "asc"}
+	results := make(chan result, 3)
+
+	// 1. Review comments (inline on code)
+	go func() {
+		comments, err := gh.ListReviewComments(ctx, token, repoFullName, prNumber, chronological)
+		if err != nil {
+			forgetRepoOn404(err, repoID)
+			results <- result{kind: "review_comment", err: err}
 			return
 		}
 
-		unified := make([]UnifiedComment, 0, len(comments))
+		rows := make([]db.UpsertPRCommentParams, 0, len(comments))
 		for _, c := range comments {
-			unified = append(unified, UnifiedComment{
-				ID:          c.ID,
-				Type:        "review_comment",
-				Body:        c.Body,
-				Path:        c.Path,
-				Line:        c.Line,
-				DiffHunk:    c.DiffHunk,
-				InReplyToID: c.InReplyToID,
-				CreatedAt:   c.CreatedAt,
-				HTMLURL:     c.HTMLURL,
-				Username:    c.User.Login,
-				AvatarURL:   c.User.AvatarURL,
-				Source:      "github",
-			})
+			rows = append(rows, reviewCommentRow(repoID, prNumber, c))
 		}
-		reviewCommentsCh <- result{comments: unified}
+		results <- result{kind: "review_comment", rows: rows}
 	}()
 
 	// 2. Issue comments (top-level PR comments)
 	go func() {
-		url := fmt.Sprintf("https://api.github.com/repos/%s/issues/%d/comments?per_page=100&sort=created&direction=asc", repoFullName, prNumber)
-		resp, err := githubAPIGet(url, user.AccessToken)
+		comments, err := gh.ListIssueComments(ctx, token, repoFullName, prNumber, chronological)
 		if err != nil {
-			issueCommentsCh <- result{err: err}
+			forgetRepoOn404(err, repoID)
+			results <- result{kind: "issue_comment", err: err}
 			return
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != 200 {
-			body, _ := io.ReadAll(resp.Body)
-			issueCommentsCh <- result{err: fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))}
-			return
-		}
-
-		var comments []PRIssueComment
-		if err := json.NewDecoder(resp.Body).Decode(&comments); err != nil {
-			issueCommentsCh <- result{err: err}
-			return
-		}
-
-		unified := make([]UnifiedComment, 0, len(comments))
+		rows := make([]db.UpsertPRCommentParams, 0, len(comments))
 		for _, c := range comments {
-			unified = append(unified, UnifiedComment{
-				ID:        c.ID,
-				Type:      "issue_comment",
-				Body:      c.Body,
-				CreatedAt: c.CreatedAt,
-				HTMLURL:   c.HTMLURL,
-				Username:  c.User.Login,
-				AvatarURL: c.User.AvatarURL,
-				Source:    "github",
-			})
+			rows = append(rows, issueCommentRow(repoID, prNumber, c))
 		}
-		issueCommentsCh <- result{comments: unified}
+		results <- result{kind: "issue_comment", rows: rows}
 	}()
 
 	// 3. Reviews (approved, changes requested, etc.)
 	go func() {
-		url := fmt.Sprintf("https://api.github.com/repos/%s/pulls/%d/reviews?per_page=100", repoFullName, prNumber)
-		resp, err := githubAPIGet(url, user.AccessToken)
+		reviews, err := gh.ListReviews(ctx, token, repoFullName, prNumber, github.ListOptions{PerPage: "100"})
 		if err != nil {
-			reviewsCh <- result{err: err}
+			forgetRepoOn404(err, repoID)
+			results <- result{kind: "review", err: err}
 			return
 		}
-		defer resp.Body.Close()
 
-		if resp.StatusCode != 200 {
-			body, _ := io.ReadAll(resp.Body)
-			reviewsCh <- result{err: fmt.Errorf("GitHub API error %d: %s", resp.StatusCode, string(body))}
-			return
-		}
-
-		var reviews []PRReview
-		if err := json.NewDecoder(resp.Body).Decode(&reviews); err != nil {
-			reviewsCh <- result{err: err}
-			return
-		}
-
-		unified := make([]UnifiedComment, 0, len(reviews))
+		rows := make([]db.UpsertPRCommentParams, 0, len(reviews))
 		for _, r := range reviews {
 			// Skip empty COMMENTED reviews (they're just containers for inline comments)
-			if r.State == "COMMENTED" && r.Body == "" {
+			// and pending ones, which only their author can see
+			if (r.State == "COMMENTED" && r.Body == "") || r.State == "PENDING" {
 				continue
 			}
-			unified = append(unified, UnifiedComment{
-				ID:        r.ID,
-				Type:      "review",
-				Body:      r.Body,
-				State:     r.State,
-				CreatedAt: r.CreatedAt,
-				HTMLURL:   r.HTMLURL,
-				Username:  r.User.Login,
-				AvatarURL: r.User.AvatarURL,
-				Source:    "github",
-			})
+			rows = append(rows, reviewRow(repoID, prNumber, r))
 		}
-		reviewsCh <- result{comments: unified}
+		results <- result{kind: "review", rows: rows}
 	}()
 
-	// Collect results
-	r1 := <-reviewCommentsCh
-	r2 := <-issueCommentsCh
-	r3 := <-reviewsCh
+	var firstErr error
+	changed := false
+	for range 3 {
+		r := <-results
+		if r.err != nil {
+			log.Printf("[pr-review] %s fetch error: %v", r.kind, r.err)
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
 
-	// Log errors but don't fail — return whatever we got
-	if r1.err != nil {
-		log.Printf("[pr-review] review comments fetch error: %v", r1.err)
-	}
-	if r2.err != nil {
-		log.Printf("[pr-review] issue comments fetch error: %v", r2.err)
+		n, err := h.storeFetchedComments(ctx, repoID, prNumber, r.kind, r.rows)
+		changed = changed || n
+		if err != nil {
+			return changed, err
+		}
 	}
-	if r3.err != nil {
-		log.Printf("[pr-review] reviews fetch error: %v", r3.err)
+	if firstErr != nil {
+		return changed, firstErr
 	}
 
-	// Merge all comments
-	all := make([]UnifiedComment, 0, len(r1.comments)+len(r2.comments)+len(r3.comments))
-	all = append(all, r1.comments...)
-	all = append(all, r2.comments...)
-	all = append(all, r3.comments...)
+	err := h.Queries.MarkPRCommentsSynced(ctx, db.MarkPRCommentsSyncedParams{RepoID: repoID, PrNumber: int32(prNumber)})
+	return changed, err
+}
 
-	c.JSON(200, gin.H{
-		"comments":  all,
-		"pr_number": prNumber,
-		"repo_name": repoFullName,
+// storeFetchedComments reconciles one complete comment listing with the
+// store: rows are upserted, and stored comments of that kind missing from the
+// listing are pruned. Reports whether anything changed.
+func (h *Handler) storeFetchedComments(ctx context.Context, repoID int64, number int, kind string, rows []db.UpsertPRCommentParams) (bool, error) {
+	changed := false
+	keep := make([]int64, 0, len(rows))
+	for _, row := range rows {
+		n, err := h.Queries.UpsertPRComment(ctx, row)
+		if err != nil {
+			return changed, err
+		}
+		changed = changed || n > 0
+		keep = append(keep, row.CommentID)
+	}
+	n, err := h.Queries.PrunePRComments(ctx, db.PrunePRCommentsParams{
+		RepoID:      repoID,
+		PrNumber:    int32(number),
+		CommentType: kind,
+		Keep:        keep,
 	})
+	return changed || n > 0, err
+}
+
+// runPRCommentsSync refreshes a PR's or issue's stored comments with the
+// loop's read token (the viewer's when it has none) and tells the loop when
+// something changed.
+func (h *Handler) runPRCommentsSync(ctx context.Context, raw json.RawMessage) error {
+	var p prCommentsSyncPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	projectID, err := utils.StrToUUID(p.ProjectID)
+	if err != nil {
+		return fmt.Errorf("bad project id: %w", err)
+	}
+	uid, err := utils.StrToUUID(p.UserID)
+	if err != nil {
+		return fmt.Errorf("bad user id: %w", err)
+	}
+
+	project, err := h.Loops.ByID(ctx, projectID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	user, err := h.getUserByID(ctx, uid)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	token := h.loopReadToken(ctx, project, user)
+	if token == "" {
+		return nil
+	}
+
+	repoFullName, err := github.Default.RepoFullName(ctx, token, project.GithubRepoID)
+	if errors.Is(err, github.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if p.Issue {
+		changed, err := h.syncIssueComments(ctx, token, repoFullName, project.GithubRepoID, p.PRNumber)
+		if changed {
+			h.Events.Publish(loopRoom(p.ProjectID), events.IssueCommentsSync{IssueNumber: p.PRNumber})
+		}
+		if errors.Is(err, github.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	changed, err := h.syncPRComments(ctx, token, repoFullName, project.GithubRepoID, p.PRNumber)
+	if changed {
+		h.Events.Publish(loopRoom(p.ProjectID), events.PRCommentsSync{PRNumber: p.PRNumber})
+	}
+	if errors.Is(err, github.ErrNotFound) {
+		return nil
+	}
+	return err
 }
 
 // ============================================================================
 // POST /api/loops/:name/github/pr-comment
-// Posts a comment on a PR (two-way sync: Wireloop → GitHub)
+// Posts a comment on a PR (two-way sync: Wireloop → GitHub): top-level
+// comments, replies, new inline comments, and pending reviews
 // ============================================================================
 
 func (h *Handler) HandlePostPRComment(c *gin.Context) {
 	name := c.Param("name")
 
 	var req PostCommentRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		problem.Respond(c, 400, msg)
 		return
 	}
 
 	uid, ok := utils.GetUserIdFromContext(c)
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 
 	ctx := c.Request.Context()
-	project, err := h.Queries.GetProjectByName(ctx, name)
+	project, err := h.Loops.ByName(ctx, name)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "loop not found"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
 
-	user, err := h.Queries.GetUserByID(ctx, uid)
+	user, err := h.getUserByID(ctx, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
 	if user.AccessToken == "" {
-		c.JSON(401, gin.H{"error": "no GitHub access token — please re-login"})
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
 		return
 	}
 
-	repoFullName, err := getRepoFullName(project.GithubRepoID, user.AccessToken)
-	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+	repoFullName, ok := h.repoForWrite(c, project, user.AccessToken)
+	if !ok {
 		return
 	}
 
-	var apiURL string
-	if req.InReplyTo != nil {
+	gh := github.Default
+	token := user.AccessToken
+
+	// New inline comments and reviews anchor to a commit; default to the PR head
+	if req.CommitID == "" && (req.Path != "" || req.Review == "start") {
+		pr, err := gh.GetPull(ctx, token, repoFullName, req.PRNumber)
+		if err != nil {
+			forgetRepoOn404(err, project.GithubRepoID)
+			respondGitHubError(c, err)
+			return
+		}
+		req.CommitID = pr.Head.SHA
+	}
+
+	var stored db.UpsertPRCommentParams
+	switch {
+	case req.Review == "start":
+		review, err := gh.CreateReview(ctx, token, repoFullName, req.PRNumber, github.NewReview{
+			CommitID: req.CommitID,
+			Body:     req.Body,
+			Comments: req.Comments,
+		})
+		if err != nil {
+			log.Printf("[pr-review] start review failed: %v", err)
+			forgetRepoOn404(err, project.GithubRepoID)
+			respondGitHubError(c, err)
+			return
+		}
+		// Pending reviews are private to their author, so nothing to store or broadcast yet
+		c.JSON(201, gin.H{
+			"success":   true,
+			"review_id": review.ID,
+			"state":     review.State,
+		})
+		return
+
+	case req.Review == "submit":
+		review, err := gh.SubmitReview(ctx, token, repoFullName, req.PRNumber, req.ReviewID, req.Event, req.Body)
+		if err != nil {
+			log.Printf("[pr-review] submit review failed: %v", err)
+			forgetRepoOn404(err, project.GithubRepoID)
+			respondGitHubError(c, err)
+			return
+		}
+		stored = reviewRow(project.GithubRepoID, req.PRNumber, *review)
+		// The review's inline comments only become visible now; pull them in
+		if _, err := h.Jobs.Enqueue(ctx, jobPRCommentsSync, prCommentsSyncPayload{
+			ProjectID: utils.UUIDToStr(project.ID),
+			UserID:    utils.UUIDToStr(uid),
+			PRNumber:  req.PRNumber,
+		}, time.Now()); err != nil {
+			log.Printf("[pr-review] failed to queue refresh for PR #%d: %v", req.PRNumber, err)
+		}
+
+	case req.InReplyTo != nil:
 		// Reply to a specific review comment
-		apiURL = fmt.Sprintf("https://api.github.com/repos/%s/pulls/%d/comments/%d/replies", repoFullName, req.PRNumber, *req.InReplyTo)
-	} else {
+		created, err := gh.ReplyToReviewComment(ctx, token, repoFullName, req.PRNumber, *req.InReplyTo, req.Body)
+		if err != nil {
+			log.Printf("[pr-review] post comment failed: %v", err)
+			forgetRepoOn404(err, project.GithubRepoID)
+			respondGitHubError(c, err)
+			return
+		}
+		stored = reviewCommentRow(project.GithubRepoID, req.PRNumber, *created)
+
+	case req.Path != "":
+		// New inline comment on the diff
+		created, err := gh.CreateReviewComment(ctx, token, repoFullName, req.PRNumber, github.NewReviewComment{
+			Body:      req.Body,
+			CommitID:  req.CommitID,
+			Path:      req.Path,
+			Line:      req.Line,
+			Side:      req.Side,
+			StartLine: req.StartLine,
+		})
+		if err != nil {
+			log.Printf("[pr-review] post inline comment failed: %v", err)
+			forgetRepoOn404(err, project.GithubRepoID)
+			respondGitHubError(c, err)
+			return
+		}
+		stored = reviewCommentRow(project.GithubRepoID, req.PRNumber, *created)
+
+	default:
 		// Top-level issue comment on the PR
-		apiURL = fmt.Sprintf("https://api.github.com/repos/%s/issues/%d/comments", repoFullName, req.PRNumber)
+		created, err := gh.CreateIssueComment(ctx, token, repoFullName, req.PRNumber, req.Body)
+		if err != nil {
+			log.Printf("[pr-review] post comment failed: %v", err)
+			forgetRepoOn404(err, project.GithubRepoID)
+			respondGitHubError(c, err)
+			return
+		}
+		stored = issueCommentRow(project.GithubRepoID, req.PRNumber, *created)
+	}
+
+	// Store it now so the next view has it without waiting for a refresh
+	if _, err := h.Queries.UpsertPRComment(ctx, stored); err != nil {
+		log.Printf("[pr-review] failed to store posted comment: %v", err)
 	}
 
-	payload, _ := json.Marshal(map[string]string{"body": req.Body})
-	httpReq, err := http.NewRequest("POST", apiURL, bytes.NewReader(payload))
+	// Broadcast the new comment to the loop's WebSocket channel so other users see it
+	comment := prCommentToUnified(storedPRComment(stored))
+	comment.Username = user.Username
+	comment.AvatarURL = mediaURL(user.AvatarUrl.String)
+	comment.Source = "wireloop"
+	h.Events.Publish(loopRoom(utils.UUIDToStr(project.ID)), events.PRCommentChange{
+		PRNumber: req.PRNumber,
+		Comment:  comment,
+	})
+
+	c.JSON(201, gin.H{
+		"success":  true,
+		"id":       stored.CommentID,
+		"html_url": stored.HtmlUrl,
+	})
+}
+
+// ============================================================================
+// POST /api/loops/:name/github/pr/:number/review
+// Submits an approval, change request or comment review on GitHub
+// ============================================================================
+
+func (h *Handler) HandleSubmitPRReview(c *gin.Context) {
+	name := c.Param("name")
+	prNumber, err := strconv.Atoi(c.Param("number"))
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to create request"})
+		problem.Respond(c, 400, "invalid PR number")
 		return
 	}
-	httpReq.Header.Set("Authorization", "Bearer "+user.AccessToken)
-	httpReq.Header.Set("Accept", "application/vnd.github+json")
-	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := githubHTTPClient.Do(httpReq)
+	var req SubmitReviewRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	// GitHub rejects COMMENT and REQUEST_CHANGES reviews with nothing to say
+	if req.Event != "APPROVE" && req.Body == "" && len(req.Comments) == 0 {
+		problem.Respond(c, 400, "body is required for this review")
+		return
+	}
+	if !validDraftComments(req.Comments) {
+		problem.Respond(c, 400, "invalid review comment")
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	ctx := c.Request.Context()
+	project, err := h.Loops.ByName(ctx, name)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to post comment to GitHub"})
+		problem.Respond(c, 404, "loop not found")
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 201 {
-		body, _ := io.ReadAll(resp.Body)
-		log.Printf("[pr-review] post comment failed: status=%d body=%s", resp.StatusCode, string(body))
-		c.JSON(resp.StatusCode, gin.H{"error": fmt.Sprintf("GitHub API error: %s", string(body))})
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	if user.AccessToken == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
 		return
 	}
 
-	var createdComment struct {
-		ID      int64  `json:"id"`
-		Body    string `json:"body"`
-		HTMLURL string `json:"html_url"`
+	repoFullName, ok := h.repoForWrite(c, project, user.AccessToken)
+	if !ok {
+		return
 	}
-	json.NewDecoder(resp.Body).Decode(&createdComment)
 
-	// Broadcast the new comment to the loop's WebSocket channel so other users see it
-	h.Hub.Broadcast(utils.UUIDToStr(project.ID), WSOutMessage{
-		Type: "pr_comment",
-		Payload: gin.H{
-			"pr_number": req.PRNumber,
-			"comment": UnifiedComment{
-				ID:        createdComment.ID,
-				Type:      "issue_comment",
-				Body:      createdComment.Body,
-				HTMLURL:   createdComment.HTMLURL,
-				Username:  user.Username,
-				AvatarURL: user.AvatarUrl.String,
-				Source:    "wireloop",
-				CreatedAt: "just now",
-			},
-		},
+	review, err := github.Default.CreateReview(ctx, user.AccessToken, repoFullName, prNumber, github.NewReview{
+		CommitID: req.CommitID,
+		Body:     req.Body,
+		Event:    req.Event,
+		Comments: req.Comments,
+	})
+	if err != nil {
+		log.Printf("[pr-review] submit review on %s#%d failed: %v", repoFullName, prNumber, err)
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
+		return
+	}
+
+	stored := reviewRow(project.GithubRepoID, prNumber, *review)
+	if _, err := h.Queries.UpsertPRComment(ctx, stored); err != nil {
+		log.Printf("[pr-review] failed to store review: %v", err)
+	}
+	if len(req.Comments) > 0 {
+		if _, err := h.Jobs.Enqueue(ctx, jobPRCommentsSync, prCommentsSyncPayload{
+			ProjectID: utils.UUIDToStr(project.ID),
+			UserID:    utils.UUIDToStr(uid),
+			PRNumber:  prNumber,
+		}, time.Now()); err != nil {
+			log.Printf("[pr-review] failed to queue refresh for PR #%d: %v", prNumber, err)
+		}
+	}
+
+	rows, err := h.Queries.ListPRComments(ctx, db.ListPRCommentsParams{
+		RepoID:   project.GithubRepoID,
+		PrNumber: int32(prNumber),
+	})
+	if err != nil {
+		log.Printf("[pr-review] failed to load reviews for PR #%d: %v", prNumber, err)
+	}
+	state := prReviewState(rows)
+
+	h.Events.Publish(loopRoom(utils.UUIDToStr(project.ID)), events.Review{
+		PRNumber:    prNumber,
+		Review:      prCommentToUnified(storedPRComment(stored)),
+		ReviewState: state,
 	})
 
 	c.JSON(201, gin.H{
-		"success":  true,
-		"id":       createdComment.ID,
-		"html_url": createdComment.HTMLURL,
+		"success":      true,
+		"id":           review.ID,
+		"state":        review.State,
+		"html_url":     review.HTMLURL,
+		"review_state": state,
 	})
 }
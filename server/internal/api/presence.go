@@ -0,0 +1,116 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	utils "wireloop/internal"
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// TYPING & PRESENCE
+// Members see who is online in a loop and who is typing in a channel. Each
+// user can stop sending typing indicators or hide their presence; the hub
+// enforces both, so a client ignoring the setting can't leak either.
+// ============================================================================
+
+// PresenceSettingsRequest changes the fields that are set
+type PresenceSettingsRequest struct {
+	SendTyping   *bool `json:"send_typing"`
+	ShowPresence *bool `json:"show_presence"`
+}
+
+// presencePrivacyFor returns the user's typing and presence settings. A
+// failed lookup hides both rather than share what the user may have hidden.
+func (h *Handler) presencePrivacyFor(ctx context.Context, userID pgtype.UUID) (chat.Privacy, error) {
+	row, err := h.Queries.GetPresencePrivacy(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return chat.DefaultPrivacy, nil
+	}
+	if err != nil {
+		return chat.Privacy{}, err
+	}
+	return chat.Privacy{SendTyping: row.SendTyping, ShowPresence: row.ShowPresence}, nil
+}
+
+// presenceMessage is the event announcing uid coming online or going offline
+func presenceMessage(projectID, userID string, online bool) events.Envelope {
+	status := "offline"
+	if online {
+		status = "online"
+	}
+	return events.Wrap(events.PresenceChange{ProjectID: projectID, UserID: userID, Status: status}, "")
+}
+
+// HandleGetPresenceSettings returns the caller's typing and presence settings
+func (h *Handler) HandleGetPresenceSettings(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	p, err := h.presencePrivacyFor(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get settings")
+		return
+	}
+
+	c.JSON(200, p)
+}
+
+// HandleUpdatePresenceSettings changes whether the caller sends typing
+// indicators and shows as online. Open connections follow at once: hiding
+// presence announces the caller offline in their loops, showing it online.
+func (h *Handler) HandleUpdatePresenceSettings(c *gin.Context) {
+	var req PresenceSettingsRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	p, err := h.presencePrivacyFor(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to update settings")
+		return
+	}
+	was := p
+	if req.SendTyping != nil {
+		p.SendTyping = *req.SendTyping
+	}
+	if req.ShowPresence != nil {
+		p.ShowPresence = *req.ShowPresence
+	}
+	if err := h.Queries.SetPresencePrivacy(c, db.SetPresencePrivacyParams{
+		UserID:       uid,
+		SendTyping:   p.SendTyping,
+		ShowPresence: p.ShowPresence,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to update settings")
+		return
+	}
+
+	userID := utils.UUIDToStr(uid)
+	rooms := h.Hub.UpdatePrivacy(userID, p)
+	if was.ShowPresence != p.ShowPresence {
+		for _, room := range rooms {
+			if projectID, isLoop := strings.CutPrefix(room, loopRoom("")); isLoop {
+				h.Hub.Broadcast(room, presenceMessage(projectID, userID, p.ShowPresence))
+			}
+		}
+	}
+
+	c.JSON(200, p)
+}
@@ -3,7 +3,6 @@ package api
 import (
 	"bytes"
 	"context"
-	"encoding/base64"
 	"fmt"
 	"image"
 	"image/jpeg"
@@ -11,9 +10,14 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	utils "wireloop/internal"
 	"wireloop/internal/db"
+	"wireloop/internal/i18n"
 	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -34,34 +38,44 @@ type ProfileResponse struct {
 	DisplayName      *string `json:"display_name"`
 	ProfileCompleted bool    `json:"profile_completed"`
 	CreatedAt        string  `json:"created_at"`
+	// Language for server-generated text; "" follows Accept-Language
+	Locale string `json:"locale"`
+	// IANA time zone local times are read in
+	Timezone string `json:"timezone"`
 }
 
 // UpdateProfileRequest represents the profile update payload
 type UpdateProfileRequest struct {
-	DisplayName *string `json:"display_name"`
+	DisplayName *string `json:"display_name" binding:"omitempty,max=50"` // MaxNameLength
+	// One of i18n.Supported, or "" to follow Accept-Language again
+	Locale *string `json:"locale"`
+	// IANA time zone, or "" for UTC
+	Timezone *string `json:"timezone" binding:"omitempty,timezone"`
 }
 
 // GetProfile returns the authenticated user's profile
 func (h *Handler) GetProfile(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		problem.Respond(c, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
 	profile, err := h.Queries.GetUserProfile(c, userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Profile not found"})
+		problem.Respond(c, http.StatusNotFound, "Profile not found")
 		return
 	}
 
 	c.JSON(http.StatusOK, ProfileResponse{
 		ID:               formatUUID(profile.ID.Bytes),
 		Username:         profile.Username,
-		AvatarURL:        nullableString(profile.AvatarUrl),
+		AvatarURL:        avatarURL(profile.AvatarUrl),
 		DisplayName:      nullableString(profile.DisplayName),
 		ProfileCompleted: profile.ProfileCompleted.Bool,
-		CreatedAt:        profile.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
+		CreatedAt:        utils.FormatTime(profile.CreatedAt.Time),
+		Locale:           h.userLocale(c, userID),
+		Timezone:         h.userTimezone(c, userID).String(),
 	})
 }
 
@@ -69,19 +83,16 @@ func (h *Handler) GetProfile(c *gin.Context) {
 func (h *Handler) UpdateProfile(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		problem.Respond(c, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
 	var req UpdateProfileRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+	if !bindStrictJSON(c, &req) {
 		return
 	}
-
-	// Validate display name
-	if req.DisplayName != nil && len(*req.DisplayName) > MaxNameLength {
-		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Display name must be %d characters or less", MaxNameLength)})
+	if req.Locale != nil && *req.Locale != "" && i18n.Normalize(*req.Locale) != *req.Locale {
+		problem.Respond(c, http.StatusBadRequest, "locale must be one of "+strings.Join(i18n.Supported(), ", "))
 		return
 	}
 
@@ -90,17 +101,40 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 		DisplayName: toPgText(req.DisplayName),
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		problem.Respond(c, http.StatusInternalServerError, "Failed to update profile")
 		return
 	}
+	invalidateUser(userID)
+	if req.Locale != nil {
+		if err := h.Queries.SetUserLocale(c, db.SetUserLocaleParams{
+			UserID: userID,
+			Locale: pgtype.Text{String: *req.Locale, Valid: *req.Locale != ""},
+		}); err != nil {
+			problem.Respond(c, http.StatusInternalServerError, "Failed to update profile")
+			return
+		}
+		invalidateUserLocale(userID)
+	}
+	if req.Timezone != nil {
+		if err := h.Queries.SetUserTimezone(c, db.SetUserTimezoneParams{
+			UserID:   userID,
+			Timezone: pgtype.Text{String: *req.Timezone, Valid: *req.Timezone != ""},
+		}); err != nil {
+			problem.Respond(c, http.StatusInternalServerError, "Failed to update profile")
+			return
+		}
+		invalidateUserTimezone(userID)
+	}
 
 	c.JSON(http.StatusOK, ProfileResponse{
 		ID:               formatUUID(user.ID.Bytes),
 		Username:         user.Username,
-		AvatarURL:        nullableString(user.AvatarUrl),
+		AvatarURL:        avatarURL(user.AvatarUrl),
 		DisplayName:      nullableString(user.DisplayName),
 		ProfileCompleted: user.ProfileCompleted.Bool,
-		CreatedAt:        user.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
+		CreatedAt:        utils.FormatTime(user.CreatedAt.Time),
+		Locale:           h.userLocale(c, userID),
+		Timezone:         h.userTimezone(c, userID).String(),
 	})
 }
 
@@ -108,13 +142,13 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 func (h *Handler) UploadAvatar(c *gin.Context) {
 	userID, ok := middleware.GetUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		problem.Respond(c, http.StatusUnauthorized, "User not authenticated")
 		return
 	}
 
 	file, header, err := c.Request.FormFile("avatar")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		problem.Respond(c, http.StatusBadRequest, "No file uploaded")
 		return
 	}
 	defer file.Close()
@@ -122,14 +156,14 @@ func (h *Handler) UploadAvatar(c *gin.Context) {
 	// Check content type
 	contentType := header.Header.Get("Content-Type")
 	if !strings.HasPrefix(contentType, "image/") {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "File must be an image"})
+		problem.Respond(c, http.StatusBadRequest, "File must be an image")
 		return
 	}
 
 	// Read file into memory
 	data, err := io.ReadAll(file)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		problem.Respond(c, http.StatusInternalServerError, "Failed to read file")
 		return
 	}
 
@@ -147,37 +181,51 @@ func (h *Handler) processAndUpdateAvatar(userID pgtype.UUID, data []byte, conten
 		return
 	}
 
-	dataURL := fmt.Sprintf("data:image/jpeg;base64,%s", base64.StdEncoding.EncodeToString(processedData))
+	// Each upload gets a fresh file name so the served file can be cached as immutable
+	name := formatUUID(userID.Bytes) + "/" + strconv.FormatInt(utils.GetMessageId(), 10) + ".jpg"
+	if err := h.Storage.Put(context.Background(), avatarStoragePath+name, processedData); err != nil {
+		log.Printf("Error storing avatar for user %v: %v", userID, err)
+		return
+	}
 
 	_, err = h.Queries.UpdateUserAvatar(context.Background(), db.UpdateUserAvatarParams{
 		ID:        userID,
-		AvatarUrl: pgtype.Text{String: dataURL, Valid: true},
+		AvatarUrl: pgtype.Text{String: mediaPathPrefix + avatarStoragePath + name, Valid: true},
 	})
 	if err != nil {
 		log.Printf("Error updating avatar for user %v: %v", userID, err)
+		return
 	}
+	invalidateUser(userID)
 }
 
 // GetPublicProfile returns a user's public profile by username
 func (h *Handler) GetPublicProfile(c *gin.Context) {
 	username := c.Param("username")
 	if username == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username required"})
+		problem.Respond(c, http.StatusBadRequest, "Username required")
 		return
 	}
 
 	profile, err := h.Queries.GetPublicProfile(c, username)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		// Old handles of renamed users point at the current profile
+		if ownerID, histErr := h.Queries.GetUsernameRedirect(c, username); histErr == nil {
+			if owner, err := h.getUserByID(c, ownerID); err == nil {
+				c.Redirect(http.StatusFound, "/api/users/"+url.PathEscape(owner.Username))
+				return
+			}
+		}
+		problem.Respond(c, http.StatusNotFound, "User not found")
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"id":           formatUUID(profile.ID.Bytes),
 		"username":     profile.Username,
-		"avatar_url":   nullableString(profile.AvatarUrl),
+		"avatar_url":   avatarURL(profile.AvatarUrl),
 		"display_name": nullableString(profile.DisplayName),
-		"created_at":   profile.CreatedAt.Time.Format("2006-01-02T15:04:05Z"),
+		"created_at":   utils.FormatTime(profile.CreatedAt.Time),
 	})
 }
 
@@ -248,6 +296,15 @@ func nullableString(t pgtype.Text) *string {
 	return nil
 }
 
+// avatarURL is nullableString for avatar columns, signing uploaded avatars
+func avatarURL(t pgtype.Text) *string {
+	if !t.Valid {
+		return nil
+	}
+	u := mediaURL(t.String)
+	return &u
+}
+
 func toPgText(s *string) pgtype.Text {
 	if s == nil {
 		return pgtype.Text{Valid: false}
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// QUICK SWITCHER
+// Backs the client's Cmd-K: the caller's loops, channels, DMs and recent
+// threads matching ?q, in one ranked list. Ranking is frecency, how often
+// and how recently the caller posted or read somewhere, scaled by how well
+// the name matches.
+// ============================================================================
+
+const (
+	defaultQuickSwitchLimit = 20
+	maxQuickSwitchLimit     = 50
+	// Candidates fetched of each kind before ranking
+	quickSwitchCandidates = 50
+	quickSwitchSnippetLen = 80
+)
+
+// QuickSwitchItem is one switcher entry; Loop, ChannelID and AvatarURL are
+// set where the client needs them to navigate or draw it
+type QuickSwitchItem struct {
+	Type     string `json:"type"` // loop, channel, dm or thread
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle,omitempty"`
+	// The loop's name, for channels and threads
+	Loop      string  `json:"loop,omitempty"`
+	ChannelID string  `json:"channel_id,omitempty"`
+	AvatarURL string  `json:"avatar_url,omitempty"`
+	Score     float64 `json:"score"`
+}
+
+// recencyWeight buckets how long ago something was last used, so an hour
+// ago and yesterday are far apart but last week and the week before aren't
+func recencyWeight(last pgtype.Timestamptz, now time.Time) float64 {
+	if !last.Valid {
+		return 1
+	}
+	switch age := now.Sub(last.Time); {
+	case age < 4*time.Hour:
+		return 100
+	case age < 24*time.Hour:
+		return 70
+	case age < 3*24*time.Hour:
+		return 50
+	case age < 7*24*time.Hour:
+		return 30
+	case age < 30*24*time.Hour:
+		return 10
+	}
+	return 5
+}
+
+// frecency combines how often (uses in the last 30 days) and how recently
+func frecency(uses int32, last pgtype.Timestamptz, now time.Time) float64 {
+	return float64(1+uses) * recencyWeight(last, now)
+}
+
+// matchQuality rates how well name matches q: exactly, as a prefix, at the
+// start of a word, anywhere, or (0) not at all. Everything matches an empty q.
+func matchQuality(name, q string) float64 {
+	if q == "" {
+		return 1
+	}
+	name = strings.ToLower(name)
+	i := strings.Index(name, q)
+	switch {
+	case name == q:
+		return 4
+	case i == 0:
+		return 3
+	case i < 0:
+		return 0
+	case strings.ContainsAny(name[i-1:i], " -_/."):
+		return 2
+	}
+	return 1
+}
+
+// snippet is the first line of content, cut to n runes
+func snippet(content string, n int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(content), "\n")
+	if utf8.RuneCountInString(line) <= n {
+		return line
+	}
+	return string([]rune(line)[:n]) + "…"
+}
+
+// HandleQuickSwitch returns the caller's best matches for ?q across loops,
+// channels, DMs and threads, at most ?limit (default 20, at most 50)
+func (h *Handler) HandleQuickSwitch(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	limit := defaultQuickSwitchLimit
+	if l := c.Query("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v < 1 || v > maxQuickSwitchLimit {
+			problem.Respond(c, 400, "limit must be between 1 and 50")
+			return
+		}
+		limit = v
+	}
+	q := strings.ToLower(strings.TrimLeft(strings.TrimSpace(c.Query("q")), "#@"))
+	if len(q) > 100 {
+		problem.Respond(c, 400, "q is too long")
+		return
+	}
+	pattern := "%" + likeEscaper.Replace(q) + "%"
+
+	var (
+		loops    []db.QuickSwitchLoopsRow
+		channels []db.QuickSwitchChannelsRow
+		dms      []db.QuickSwitchDMsRow
+		threads  []db.QuickSwitchThreadsRow
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if firstErr == nil {
+					firstErr = err
+				}
+			}
+		}()
+	}
+	ctx := c.Request.Context()
+	run(func() (err error) {
+		loops, err = h.Queries.QuickSwitchLoops(ctx, db.QuickSwitchLoopsParams{UserID: uid, Pattern: pattern, RowLimit: quickSwitchCandidates})
+		return err
+	})
+	run(func() (err error) {
+		channels, err = h.Queries.QuickSwitchChannels(ctx, db.QuickSwitchChannelsParams{UserID: uid, Pattern: pattern, RowLimit: quickSwitchCandidates})
+		return err
+	})
+	run(func() (err error) {
+		dms, err = h.Queries.QuickSwitchDMs(ctx, db.QuickSwitchDMsParams{UserID: uid, Pattern: pattern, RowLimit: quickSwitchCandidates})
+		return err
+	})
+	run(func() (err error) {
+		threads, err = h.Queries.QuickSwitchThreads(ctx, db.QuickSwitchThreadsParams{UserID: uid, Pattern: pattern, RowLimit: quickSwitchCandidates})
+		return err
+	})
+	wg.Wait()
+	if firstErr != nil {
+		problem.Error(c, firstErr, "quick switch failed")
+		return
+	}
+
+	now := time.Now()
+	items := make([]QuickSwitchItem, 0, len(loops)+len(channels)+len(dms)+len(threads))
+	add := func(item QuickSwitchItem, match float64, uses int32, last pgtype.Timestamptz) {
+		if match == 0 {
+			return
+		}
+		item.Score = match * frecency(uses, last, now)
+		items = append(items, item)
+	}
+	for _, l := range loops {
+		add(QuickSwitchItem{
+			Type:  "loop",
+			ID:    utils.UUIDToStr(l.ID),
+			Title: l.Name,
+			Loop:  l.Name,
+		}, matchQuality(l.Name, q), l.Uses, l.LastUsedAt)
+	}
+	for _, ch := range channels {
+		add(QuickSwitchItem{
+			Type:     "channel",
+			ID:       utils.UUIDToStr(ch.ID),
+			Title:    "#" + ch.Name,
+			Subtitle: ch.ProjectName,
+			Loop:     ch.ProjectName,
+		}, matchQuality(ch.Name, q), ch.Uses, ch.LastUsedAt)
+	}
+	for _, d := range dms {
+		title := d.Name.String
+		avatar := ""
+		switch {
+		case d.IsBot:
+			title = botUsername
+		case !d.IsGroup:
+			title = d.OtherUsername.String
+			avatar = mediaURL(d.OtherAvatar.String)
+		}
+		add(QuickSwitchItem{
+			Type:      "dm",
+			ID:        utils.UUIDToStr(d.ID),
+			Title:     title,
+			AvatarURL: avatar,
+		}, matchQuality(title, q), d.Uses, d.LastUsedAt)
+	}
+	for _, t := range threads {
+		// Matched anywhere in the text, so a word start is as good as it gets
+		match := min(matchQuality(t.Content, q), 2)
+		add(QuickSwitchItem{
+			Type:      "thread",
+			ID:        strconv.FormatInt(t.ID, 10),
+			Title:     snippet(t.Content, quickSwitchSnippetLen),
+			Subtitle:  "#" + t.ChannelName + " · " + strconv.Itoa(int(t.ReplyCount)) + " replies",
+			Loop:      t.ProjectName,
+			ChannelID: utils.UUIDToStr(t.ChannelID),
+		}, match, t.Uses, t.LastUsedAt)
+	}
+
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Score > items[j].Score })
+	if len(items) > limit {
+		items = items[:limit]
+	}
+	c.JSON(200, gin.H{"items": items})
+}
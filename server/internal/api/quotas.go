@@ -0,0 +1,208 @@
+package api
+
+import (
+	"errors"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+	"wireloop/internal/quota"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// USAGE QUOTAS
+// Handlers check quotas before doing the work; going over answers 402 for
+// plan limits (loops, storage) and 429 with Retry-After for the daily message
+// quota, both with code quota_exceeded. Admins set per-account overrides
+// through /api/admin/quotas.
+// ============================================================================
+
+// respondQuota writes err if it is a quota error and reports whether it did
+func respondQuota(c *gin.Context, err error) bool {
+	var ex *quota.Exceeded
+	if !errors.As(err, &ex) {
+		return false
+	}
+	status := 402
+	details := gin.H{"quota": ex.Kind, "limit": ex.Limit, "used": ex.Used}
+	if !ex.ResetAt.IsZero() {
+		status = 429
+		c.Header("Retry-After", strconv.Itoa(int(time.Until(ex.ResetAt).Seconds())+1))
+		details["resets_at"] = utils.FormatTime(ex.ResetAt)
+	}
+	problem.RespondCode(c, status, "quota_exceeded", ex.Error(), details)
+	return true
+}
+
+type QuotaOverrides struct {
+	MaxLoops       *int64 `json:"max_loops" binding:"omitempty,min=0"`
+	MessagesPerDay *int64 `json:"messages_per_day" binding:"omitempty,min=0"`
+	StorageBytes   *int64 `json:"storage_bytes" binding:"omitempty,min=0"`
+	Note           string `json:"note" binding:"max=500"`
+}
+
+type LoopUsage struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	MessagesToday int64  `json:"messages_today"`
+	StorageBytes  int64  `json:"storage_bytes"`
+}
+
+type AccountQuotaResponse struct {
+	UserID    string         `json:"user_id"`
+	Username  string         `json:"username"`
+	Limits    quota.Limits   `json:"limits"`
+	Defaults  quota.Limits   `json:"defaults"`
+	Overrides QuotaOverrides `json:"overrides"`
+	UpdatedAt string         `json:"updated_at,omitempty"`
+	Loops     []LoopUsage    `json:"loops"`
+}
+
+func int8Ptr(v pgtype.Int8) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+func ptrInt8(p *int64) pgtype.Int8 {
+	if p == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *p, Valid: true}
+}
+
+// quotaAccount resolves :user, a user ID or username
+func (h *Handler) quotaAccount(c *gin.Context) (db.User, bool) {
+	var user db.User
+	var err error
+	if id, perr := utils.StrToUUID(c.Param("user")); perr == nil {
+		user, err = h.Queries.GetUserByID(c, id)
+	} else {
+		user, err = h.Queries.GetUserByUsername(c, c.Param("user"))
+	}
+	if err != nil {
+		problem.Respond(c, 404, "user not found")
+		return db.User{}, false
+	}
+	return user, true
+}
+
+func (h *Handler) accountQuotaResponse(c *gin.Context, user db.User, row db.AccountQuota) (AccountQuotaResponse, error) {
+	resp := AccountQuotaResponse{
+		UserID:   utils.UUIDToStr(user.ID),
+		Username: user.Username,
+		Limits:   h.Quotas.Defaults().Apply(row),
+		Defaults: h.Quotas.Defaults(),
+		Overrides: QuotaOverrides{
+			MaxLoops:       int8Ptr(row.MaxLoops),
+			MessagesPerDay: int8Ptr(row.MessagesPerDay),
+			StorageBytes:   int8Ptr(row.StorageBytes),
+			Note:           row.Note.String,
+		},
+		Loops: []LoopUsage{},
+	}
+	if row.UpdatedAt.Valid {
+		resp.UpdatedAt = utils.FormatTime(row.UpdatedAt.Time)
+	}
+
+	projects, err := h.Queries.GetProjectsByOwner(c, user.ID)
+	if err != nil {
+		return resp, err
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, p := range projects {
+		messages, err := h.Queries.GetLoopMessageUsage(c, db.GetLoopMessageUsageParams{
+			Since:     pgtype.Timestamptz{Time: today, Valid: true},
+			ProjectID: p.ID,
+		})
+		if err != nil {
+			return resp, err
+		}
+		storage, err := h.Queries.GetLoopStorageUsage(c, p.ID)
+		if err != nil {
+			return resp, err
+		}
+		resp.Loops = append(resp.Loops, LoopUsage{
+			ID:            utils.UUIDToStr(p.ID),
+			Name:          p.Name,
+			MessagesToday: messages.Messages,
+			StorageBytes:  storage.Bytes,
+		})
+	}
+	return resp, nil
+}
+
+// HandleAdminGetQuota returns an account's limits and current usage
+func (h *Handler) HandleAdminGetQuota(c *gin.Context) {
+	user, ok := h.quotaAccount(c)
+	if !ok {
+		return
+	}
+	row, err := h.Queries.GetAccountQuota(c, user.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 500, "failed to get quota")
+		return
+	}
+	resp, err := h.accountQuotaResponse(c, user, row)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get usage")
+		return
+	}
+	c.JSON(200, resp)
+}
+
+// HandleAdminSetQuota replaces an account's overrides; omitted limits fall
+// back to the defaults
+func (h *Handler) HandleAdminSetQuota(c *gin.Context) {
+	user, ok := h.quotaAccount(c)
+	if !ok {
+		return
+	}
+	var req QuotaOverrides
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	row, err := h.Queries.UpsertAccountQuota(c, db.UpsertAccountQuotaParams{
+		UserID:         user.ID,
+		MaxLoops:       ptrInt8(req.MaxLoops),
+		MessagesPerDay: ptrInt8(req.MessagesPerDay),
+		StorageBytes:   ptrInt8(req.StorageBytes),
+		Note:           pgtype.Text{String: req.Note, Valid: req.Note != ""},
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to save quota")
+		return
+	}
+	h.Quotas.Invalidate(user.ID)
+	resp, err := h.accountQuotaResponse(c, user, row)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get usage")
+		return
+	}
+	c.JSON(200, resp)
+}
+
+// HandleAdminDeleteQuota returns an account to the default limits
+func (h *Handler) HandleAdminDeleteQuota(c *gin.Context) {
+	user, ok := h.quotaAccount(c)
+	if !ok {
+		return
+	}
+	n, err := h.Queries.DeleteAccountQuota(c, user.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to delete quota")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "account has no quota overrides")
+		return
+	}
+	h.Quotas.Invalidate(user.ID)
+	c.JSON(200, gin.H{"deleted": utils.UUIDToStr(user.ID)})
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// REACTION ANALYTICS
+// Reactions are rolled up with the other analytics (see rollupAnalyticsDay),
+// by loop, day, emoji and the author of the message reacted to. Owners read
+// the top emoji, the most reacted-to members and the daily trend; members
+// whose messages draw enough reactions earn a badge.
+// ============================================================================
+
+const (
+	reactionTopEmoji   = 10
+	reactionTopAuthors = 10
+
+	badgeCrowdFavorite = "crowd_favorite"
+	// Reactions from others a member's messages need for the badge
+	crowdFavoriteReactions = 100
+)
+
+type EmojiUsage struct {
+	Emoji     string `json:"emoji"`
+	Reactions int32  `json:"reactions"`
+}
+
+type ReactedAuthor struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+	Reactions int32  `json:"reactions"`
+}
+
+type ReactionDay struct {
+	Day       string `json:"day"`
+	Reactions int32  `json:"reactions"`
+}
+
+type ReactionAnalytics struct {
+	Days       int             `json:"days"`
+	Since      string          `json:"since"`
+	Reactions  int32           `json:"reactions"`
+	TopEmoji   []EmojiUsage    `json:"top_emoji"`
+	TopAuthors []ReactedAuthor `json:"top_authors"`
+	Daily      []ReactionDay   `json:"daily"`
+}
+
+// HandleGetReactionAnalytics summarizes reactions in a loop over the last
+// ?days (default 30, at most 180). Owner only, like the analytics overview.
+func (h *Handler) HandleGetReactionAnalytics(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only the loop owner can view analytics")
+		return
+	}
+	days := defaultAnalyticsDays
+	if s := c.Query("days"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxAnalyticsDays {
+			problem.Respond(c, 400, "days must be between 1 and 180")
+			return
+		}
+		days = n
+	}
+
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1-days)
+	day := pgtype.Date{Time: since, Valid: true}
+	emoji, err := h.Queries.GetLoopTopEmoji(c, db.GetLoopTopEmojiParams{ProjectID: project.ID, Day: day, Limit: reactionTopEmoji})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get analytics")
+		return
+	}
+	authors, err := h.Queries.GetLoopMostReactedAuthors(c, db.GetLoopMostReactedAuthorsParams{ProjectID: project.ID, Day: day, Limit: reactionTopAuthors})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get analytics")
+		return
+	}
+	trend, err := h.Queries.GetLoopReactionTrend(c, db.GetLoopReactionTrendParams{ProjectID: project.ID, Day: day})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get analytics")
+		return
+	}
+
+	out := ReactionAnalytics{
+		Days:       days,
+		Since:      since.Format(time.DateOnly),
+		TopEmoji:   make([]EmojiUsage, len(emoji)),
+		TopAuthors: make([]ReactedAuthor, len(authors)),
+		Daily:      make([]ReactionDay, len(trend)),
+	}
+	for i, e := range emoji {
+		out.TopEmoji[i] = EmojiUsage{Emoji: e.Emoji, Reactions: e.Reactions}
+	}
+	for i, a := range authors {
+		out.TopAuthors[i] = ReactedAuthor{
+			UserID:    utils.UUIDToStr(a.AuthorID),
+			Username:  a.Username,
+			AvatarURL: mediaURL(a.AvatarUrl.String),
+			Reactions: a.Reactions,
+		}
+	}
+	for i, d := range trend {
+		out.Daily[i] = ReactionDay{Day: d.Day.Time.Format(time.DateOnly), Reactions: d.Reactions}
+		out.Reactions += d.Reactions
+	}
+	c.JSON(200, out)
+}
+
+// awardReactionBadges gives the crowd favorite badge to members who have
+// crossed crowdFavoriteReactions since the last rollup
+func (h *Handler) awardReactionBadges(ctx context.Context) {
+	earners, err := h.Queries.ListReactionBadgeEarners(ctx, db.ListReactionBadgeEarnersParams{
+		Badge:     badgeCrowdFavorite,
+		Threshold: crowdFavoriteReactions,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[analytics] reaction badge lookup failed: %v", err)
+		}
+		return
+	}
+	for _, e := range earners {
+		awarded, err := h.Queries.AwardMemberBadge(ctx, db.AwardMemberBadgeParams{
+			ProjectID: e.ProjectID,
+			UserID:    e.AuthorID,
+			Badge:     badgeCrowdFavorite,
+		})
+		if err != nil {
+			log.Printf("[analytics] failed to award %s: %v", badgeCrowdFavorite, err)
+			continue
+		}
+		if awarded == 0 {
+			continue
+		}
+		h.Events.Publish(loopRoom(utils.UUIDToStr(e.ProjectID)), events.Badge{
+			UserID: utils.UUIDToStr(e.AuthorID),
+			Badge:  badgeCrowdFavorite,
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// MESSAGE REACTIONS
+// Anyone who can post in a channel can react to its messages, once per
+// emoji. Message lists come back with per-emoji counts and whether the
+// viewer reacted, loaded for the whole page in one query.
+// ============================================================================
+
+const (
+	maxReactionLength = 64 // bytes; long enough for ZWJ sequences with modifiers
+	// Distinct emoji per message; adding to an existing one is always allowed
+	maxReactionEmoji = 20
+)
+
+var shortcodeReaction = regexp.MustCompile(`^:[a-z0-9_+\-]{1,32}:$`)
+
+// ReactionSummary is one emoji's reactions on a message
+type ReactionSummary struct {
+	Emoji   string `json:"emoji"`
+	Count   int    `json:"count"`
+	Reacted bool   `json:"reacted,omitempty"` // the viewer is among the reactors
+}
+
+// validReaction accepts a :shortcode: or a run of emoji characters; letters,
+// digits, spaces and control characters are rejected
+func validReaction(emoji string) bool {
+	if emoji == "" || len(emoji) > maxReactionLength || !utf8.ValidString(emoji) {
+		return false
+	}
+	if shortcodeReaction.MatchString(emoji) {
+		return true
+	}
+	symbol := false
+	for _, r := range emoji {
+		symbol = symbol || r >= 0x80
+		if r < 0x80 && r != '#' && r != '*' && !('0' <= r && r <= '9') {
+			return false
+		}
+		if unicode.IsSpace(r) || unicode.IsControl(r) || unicode.IsLetter(r) {
+			return false
+		}
+	}
+	return symbol
+}
+
+// attachReactions fills in Reactions for a message list. viewer may be
+// zero for anonymous readers.
+func (h *Handler) attachReactions(ctx context.Context, viewer pgtype.UUID, msgs []MessageResponse) {
+	if len(msgs) == 0 {
+		return
+	}
+	ids := make([]int64, 0, len(msgs))
+	index := make(map[int64]int, len(msgs))
+	for i, m := range msgs {
+		if m.Deleted {
+			continue
+		}
+		if id, err := strconv.ParseInt(m.ID, 10, 64); err == nil {
+			ids = append(ids, id)
+			index[id] = i
+		}
+	}
+	rows, err := h.Queries.GetReactionSummaries(ctx, db.GetReactionSummariesParams{
+		ViewerID:   viewer,
+		MessageIds: ids,
+	})
+	if err != nil {
+		log.Printf("[reactions] failed to load reactions: %v", err)
+		return
+	}
+	for _, r := range rows {
+		i := index[r.MessageID]
+		msgs[i].Reactions = append(msgs[i].Reactions, ReactionSummary{
+			Emoji:   r.Emoji,
+			Count:   int(r.Count),
+			Reacted: r.Reacted.Bool,
+		})
+	}
+}
+
+// reactionTarget loads the message a reaction request is about and checks
+// the caller may use its channel. On failure it writes the error.
+func (h *Handler) reactionTarget(c *gin.Context) (db.Message, pgtype.UUID, string, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Message{}, uid, "", false
+	}
+	messageID, err := strconv.ParseInt(c.Param("message_id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid message id")
+		return db.Message{}, uid, "", false
+	}
+	emoji := c.Param("emoji")
+	if !validReaction(emoji) {
+		problem.Respond(c, 400, "reaction must be an emoji or a :shortcode:")
+		return db.Message{}, uid, "", false
+	}
+	msg, err := h.Queries.GetMessageByID(c, messageID)
+	if err != nil || msg.IsDeleted.Bool {
+		problem.Respond(c, 404, "message not found")
+		return db.Message{}, uid, "", false
+	}
+	if !h.Members.CanUseChannel(c, h.Members.Role(c, uid, msg.ProjectID), msg.ProjectID, msg.ChannelID) {
+		problem.Respond(c, 403, "not a member")
+		return db.Message{}, uid, "", false
+	}
+	// :thumbsup: and 👍 are the same reaction
+	if emoji, ok = h.canonicalReaction(c, msg.ProjectID, emoji); !ok {
+		problem.Respond(c, 400, "unknown emoji")
+		return db.Message{}, uid, "", false
+	}
+	return msg, uid, emoji, true
+}
+
+// HandleAddReaction reacts to a message; reacting twice is a no-op
+func (h *Handler) HandleAddReaction(c *gin.Context) {
+	msg, uid, emoji, ok := h.reactionTarget(c)
+	if !ok {
+		return
+	}
+	others, err := h.Queries.CountOtherReactionEmoji(c, db.CountOtherReactionEmojiParams{
+		MessageID: msg.ID,
+		Emoji:     emoji,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to add reaction")
+		return
+	}
+	if others >= maxReactionEmoji {
+		problem.Respond(c, 400, "a message can have at most 20 different reactions")
+		return
+	}
+	added, err := h.Queries.AddMessageReaction(c, db.AddMessageReactionParams{
+		MessageID: msg.ID,
+		UserID:    uid,
+		Emoji:     emoji,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to add reaction")
+		return
+	}
+	if added > 0 {
+		h.broadcastReaction(msg, uid, emoji, false)
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleRemoveReaction takes the caller's reaction off a message
+func (h *Handler) HandleRemoveReaction(c *gin.Context) {
+	msg, uid, emoji, ok := h.reactionTarget(c)
+	if !ok {
+		return
+	}
+	removed, err := h.Queries.RemoveMessageReaction(c, db.RemoveMessageReactionParams{
+		MessageID: msg.ID,
+		UserID:    uid,
+		Emoji:     emoji,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to remove reaction")
+		return
+	}
+	if removed > 0 {
+		h.broadcastReaction(msg, uid, emoji, true)
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+func (h *Handler) broadcastReaction(msg db.Message, uid pgtype.UUID, emoji string, removed bool) {
+	h.Events.PublishChannel(utils.UUIDToStr(msg.ChannelID), events.Reaction{
+		MessageID: strconv.FormatInt(msg.ID, 10),
+		Emoji:     emoji,
+		UserID:    utils.UUIDToStr(uid),
+		Removed:   removed,
+	})
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/flags"
+	"wireloop/internal/github"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ============================================================================
+// RELEASE ANNOUNCEMENTS — published GitHub releases posted to the loop's
+// announcements channel
+// ============================================================================
+
+const (
+	jobReleaseAnnouncement = "release_announcement"
+	maxReleaseNotesExcerpt = 800
+)
+
+type releaseAnnouncementPayload struct {
+	ProjectID string         `json:"project_id"`
+	RepoName  string         `json:"repo_name"`
+	Release   github.Release `json:"release"`
+}
+
+// queueReleaseAnnouncements schedules an announcement in each loop linked to the repo
+func (h *Handler) queueReleaseAnnouncements(ctx context.Context, body []byte) error {
+	var ev github.ReleaseEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	if ev.Action != "published" || ev.Release.Draft {
+		return nil
+	}
+
+	projects, err := h.Queries.GetProjectsByGithubRepoID(ctx, ev.Repository.ID)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		if _, err := h.Jobs.Enqueue(ctx, jobReleaseAnnouncement, releaseAnnouncementPayload{
+			ProjectID: utils.UUIDToStr(p.ID),
+			RepoName:  ev.Repository.FullName,
+			Release:   ev.Release,
+		}, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runReleaseAnnouncement posts one release to the loop's announcements channel.
+// The release author posts it if they have an account, otherwise the loop owner.
+func (h *Handler) runReleaseAnnouncement(ctx context.Context, raw json.RawMessage) error {
+	var p releaseAnnouncementPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	projectID, err := utils.StrToUUID(p.ProjectID)
+	if err != nil {
+		return fmt.Errorf("bad project id: %w", err)
+	}
+
+	settings, err := h.Queries.GetLoopGithubSettings(ctx, projectID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !settings.AnnouncementsChannelID.Valid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	project, err := h.Loops.ByID(ctx, projectID)
+	if err != nil {
+		return err
+	}
+	owner, err := h.getUserByID(ctx, project.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	sender := owner
+	if u, err := h.Queries.GetUserByGithubID(ctx, p.Release.Author.ID); err == nil {
+		sender = u
+	}
+
+	// The compare link needs the release before this one
+	var previousTag string
+	if owner.AccessToken != "" {
+		releases, err := github.Default.ListReleases(ctx, owner.AccessToken, p.RepoName, github.ListOptions{PerPage: "20"})
+		if err != nil {
+			log.Printf("[releases] failed to list releases for %s: %v", p.RepoName, err)
+			reportGitHubError(nil, err, "list_releases")
+		}
+		previousTag = previousReleaseTag(releases, p.Release)
+	}
+
+	notes := strings.TrimSpace(p.Release.Body)
+	if notes != "" && h.Flags.Enabled(ctx, flags.AISummaries, flags.Subject{LoopID: project.ID}) {
+		summary := ""
+		opts, err := h.loopAI(ctx, project.ID, 300)
+		if err == nil {
+			summary, err = summarizeReleaseNotes(p.RepoName, p.Release, opts)
+		}
+		if err != nil {
+			log.Printf("[releases] AI summary unavailable, using notes excerpt: %v", err)
+			reportAIError(nil, err, "release_notes")
+		} else {
+			notes = summary
+		}
+	}
+
+	content := formatReleaseAnnouncement(p.RepoName, p.Release, previousTag, notes)
+	_, err = h.postChannelMessage(ctx, project.ID, settings.AnnouncementsChannelID, sender, content)
+	return err
+}
+
+// previousReleaseTag finds the newest published release older than rel
+func previousReleaseTag(releases []github.Release, rel github.Release) string {
+	seen := false
+	for _, r := range releases {
+		if r.ID == rel.ID {
+			seen = true
+			continue
+		}
+		if seen && !r.Draft {
+			return r.TagName
+		}
+	}
+	return ""
+}
+
+func formatReleaseAnnouncement(repoName string, rel github.Release, previousTag, notes string) string {
+	var sb strings.Builder
+	title := rel.TagName
+	if rel.Name != "" && rel.Name != rel.TagName {
+		title += " — " + rel.Name
+	}
+	kind := "Release"
+	if rel.Prerelease {
+		kind = "Pre-release"
+	}
+	fmt.Fprintf(&sb, "🚀 **%s %s** of %s\n", kind, title, repoName)
+
+	if notes != "" {
+		if len(notes) > maxReleaseNotesExcerpt {
+			notes = notes[:maxReleaseNotesExcerpt] + "…"
+		}
+		sb.WriteString("\n" + notes + "\n")
+	}
+
+	sb.WriteString("\n[Release notes](" + rel.HTMLURL + ")")
+	if previousTag != "" {
+		fmt.Fprintf(&sb, " · [Compare %s...%s](https://github.com/%s/compare/%s...%s)",
+			previousTag, rel.TagName, repoName, previousTag, rel.TagName)
+	}
+	return sb.String()
+}
+
+// summarizeReleaseNotes condenses release notes into a few bullets for chat
+func summarizeReleaseNotes(repoName string, rel github.Release, opts aiOptions) (string, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return "", errAINotConfigured
+	}
+	if !opts.allows(aiContentReleases) {
+		return "", errAIContentBlocked
+	}
+
+	notes := rel.Body
+	if len(notes) > 6000 {
+		notes = notes[:6000] + "...[truncated]"
+	}
+	prompt := fmt.Sprintf("Repository: %s\nRelease: %s\n\nRelease notes:\n%s\n", repoName, rel.TagName, untrusted("release notes", rel.Name+"\n\n"+notes))
+	system := `You summarize software release notes for a team chat announcement.
+Write 3-5 short markdown bullet points covering the most important changes,
+breaking changes first. No heading, no preamble.`
+
+	return generateGemini(apiKey, system, prompt, opts)
+}
@@ -0,0 +1,142 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	jobMessageReminder = "message_reminder"
+	maxReminderDelay   = 365 * 24 * time.Hour
+)
+
+// RemindRequest takes either a relative duration ("30m", "2h", "24h") or an
+// absolute time; one without an offset is in the caller's time zone
+type RemindRequest struct {
+	In string `json:"in" binding:"required_without=At,excluded_with=At,omitempty,duration"`
+	At string `json:"at" binding:"required_without=In,omitempty,usertime"`
+}
+
+type messageReminderPayload struct {
+	UserID    string `json:"user_id"`
+	MessageID int64  `json:"message_id"`
+}
+
+// HandleRemindMessage schedules a personal reminder about a message
+func (h *Handler) HandleRemindMessage(c *gin.Context) {
+	messageID, err := strconv.ParseInt(c.Param("message_id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid message id")
+		return
+	}
+
+	var req RemindRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	var remindAt time.Time
+	if req.In != "" {
+		d, _ := time.ParseDuration(req.In)
+		remindAt = time.Now().Add(d)
+	} else {
+		remindAt, _ = parseUserTime(req.At, h.userTimezone(c, uid))
+		if !remindAt.After(time.Now()) {
+			problem.Respond(c, 400, "at must be in the future")
+			return
+		}
+	}
+	if time.Until(remindAt) > maxReminderDelay {
+		problem.Respond(c, 400, "reminders can be at most one year out")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	msg, err := h.Queries.GetMessageByID(ctx, messageID)
+	if err != nil || msg.IsDeleted.Bool {
+		problem.Respond(c, 404, "message not found")
+		return
+	}
+
+	if !h.Members.IsMember(ctx, uid, msg.ProjectID) {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+
+	jobID, err := h.Jobs.Enqueue(ctx, jobMessageReminder, messageReminderPayload{
+		UserID:    utils.UUIDToStr(uid),
+		MessageID: messageID,
+	}, remindAt)
+	if err != nil {
+		problem.Respond(c, 500, "failed to schedule reminder")
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"reminder_id": strconv.FormatInt(jobID, 10),
+		"message_id":  strconv.FormatInt(messageID, 10),
+		"remind_at":   utils.FormatTime(remindAt),
+	})
+}
+
+// runMessageReminder notifies the user about the message, unless it was deleted
+// or they've since lost access to the loop
+func (h *Handler) runMessageReminder(ctx context.Context, raw json.RawMessage) error {
+	var p messageReminderPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	userID, err := utils.StrToUUID(p.UserID)
+	if err != nil {
+		return fmt.Errorf("bad user id: %w", err)
+	}
+
+	msg, err := h.Queries.GetMessageByID(ctx, p.MessageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if msg.IsDeleted.Bool {
+		return nil
+	}
+	if !h.Members.IsMember(ctx, userID, msg.ProjectID) {
+		return nil
+	}
+
+	user, err := h.getUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = h.Notifier.Notify(ctx, notify.Event{
+		Type:          "reminder",
+		UserID:        userID,
+		ActorID:       userID,
+		ActorUsername: user.Username,
+		ProjectID:     msg.ProjectID,
+		ChannelID:     msg.ChannelID,
+		MessageID:     pgtype.Int8{Int64: msg.ID, Valid: true},
+		Preview:       notify.Preview(msg.Content),
+	})
+	return err
+}
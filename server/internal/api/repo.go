@@ -2,13 +2,16 @@ package api
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
-	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	utils "wireloop/internal"
 	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
 	"wireloop/internal/types"
 
 	"github.com/gin-gonic/gin"
@@ -16,22 +19,55 @@ import (
 )
 
 type MakeChannelRequest struct {
-	GithubRepoId int64        `json:"repo_id"`
-	ChannelName  string       `json:"name"`
-	Rules        []types.Rule `json:"rules"`
+	GithubRepoId int64        `json:"repo_id" binding:"required,min=1"`
+	ChannelName  string       `json:"name" binding:"required"`
+	Rules        []types.Rule `json:"rules" binding:"max=20,dive"`
+	// AllowDuplicate creates the loop even if another loop already uses the repo
+	AllowDuplicate bool `json:"allow_duplicate"`
+	// Workspace slug to create the loop in; the caller's personal workspace
+	// when empty
+	Workspace string `json:"workspace"`
+}
+
+// repoTaken answers a second loop for the same repository, pointing
+// at the existing one unless the caller explicitly asked for a duplicate
+func (h *Handler) repoTaken(c *gin.Context, q *db.Queries, repoID int64, except pgtype.UUID) bool {
+	existing, err := q.GetProjectsByGithubRepoID(c, repoID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to check existing loops")
+		return true
+	}
+	for _, p := range existing {
+		if p.ID == except {
+			continue
+		}
+		problem.RespondCode(c, 409, "loop_exists", "a loop for this repository already exists", gin.H{
+			"existing_loop": gin.H{
+				"name": p.Name,
+				"path": "/loops/" + url.PathEscape(p.Name),
+			},
+			"hint": "set allow_duplicate to create another loop for it anyway",
+		})
+		return true
+	}
+	return false
 }
 
 // HandleMakeChannel creates a new project/loop for a GitHub repository
 func (h *Handler) HandleMakeChannel(c *gin.Context) {
 	var req MakeChannelRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	if reason := validateLoopName(req.ChannelName); reason != "" {
+		problem.Respond(c, 400, reason)
 		return
 	}
 
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 	uid := userID.(pgtype.UUID)
@@ -41,7 +77,15 @@ func (h *Handler) HandleMakeChannel(c *gin.Context) {
 		OwnerID: uid,
 		Name:    req.ChannelName,
 	}); err == nil {
-		c.JSON(409, gin.H{"error": "A loop with this name already exists"})
+		problem.Respond(c, 409, "A loop with this name already exists")
+		return
+	}
+	workspace, ok := h.loopWorkspace(c, uid, req.Workspace)
+	if !ok {
+		return
+	}
+	if err := h.Quotas.CheckLoops(c, workspace.BillingOwnerID); err != nil {
+		respondQuota(c, err)
 		return
 	}
 
@@ -49,25 +93,31 @@ func (h *Handler) HandleMakeChannel(c *gin.Context) {
 	tx, err := h.Pool.Begin(c)
 	if err != nil {
 		log.Printf("Failed to begin transaction: %v", err)
-		c.JSON(500, gin.H{"error": "internal server error"})
+		problem.Respond(c, 500, "internal server error")
 		return
 	}
 	defer tx.Rollback(context.Background())
 
 	qtx := h.Queries.WithTx(tx)
 
+	// Held until commit, so two loops for one repo can't slip in side by side
+	if err := qtx.LockGithubRepo(c, req.GithubRepoId); err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	if !req.AllowDuplicate && h.repoTaken(c, qtx, req.GithubRepoId, pgtype.UUID{}) {
+		return
+	}
+
 	project, err := qtx.CreateProject(c, db.CreateProjectParams{
 		GithubRepoID: req.GithubRepoId,
 		Name:         req.ChannelName,
 		OwnerID:      uid,
+		WorkspaceID:  workspace.ID,
 	})
 	if err != nil {
 		log.Printf("CreateProject error: %v", err)
-		if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "unique constraint") {
-			c.JSON(409, gin.H{"error": "A loop for this repository already exists"})
-			return
-		}
-		c.JSON(500, gin.H{"error": "failed to create project: " + err.Error()})
+		problem.Respond(c, 500, "failed to create project: "+err.Error())
 		return
 	}
 
@@ -79,7 +129,7 @@ func (h *Handler) HandleMakeChannel(c *gin.Context) {
 		})
 		if err != nil {
 			log.Printf("CreateRule error: %v", err)
-			c.JSON(500, gin.H{"error": "failed to create rules: " + err.Error()})
+			problem.Respond(c, 500, "failed to create rules: "+err.Error())
 			return
 		}
 	}
@@ -87,11 +137,16 @@ func (h *Handler) HandleMakeChannel(c *gin.Context) {
 	err = qtx.AddMembership(c, db.AddMembershipParams{
 		UserID:    uid,
 		ProjectID: project.ID,
-		Role:      pgtype.Text{String: "owner", Valid: true},
+		Role:      pgtype.Text{String: members.Owner, Valid: true},
 	})
 	if err != nil {
 		log.Printf("AddMembership error: %v", err)
-		c.JSON(500, gin.H{"error": "failed to add membership: " + err.Error()})
+		problem.Respond(c, 500, "failed to add membership: "+err.Error())
+		return
+	}
+	if err := qtx.AddWorkspaceMembersToLoop(c, project.ID); err != nil {
+		log.Printf("AddWorkspaceMembersToLoop error: %v", err)
+		problem.Respond(c, 500, "failed to add workspace members")
 		return
 	}
 
@@ -105,20 +160,26 @@ func (h *Handler) HandleMakeChannel(c *gin.Context) {
 	})
 	if err != nil {
 		log.Printf("CreateChannel error: %v", err)
-		c.JSON(500, gin.H{"error": "failed to create default channel: " + err.Error()})
+		problem.Respond(c, 500, "failed to create default channel: "+err.Error())
+		return
+	}
+	if err := CreateDefaultBoardColumns(c, qtx, project.ID); err != nil {
+		log.Printf("CreateDefaultBoardColumns error: %v", err)
+		problem.Respond(c, 500, "failed to create the board")
 		return
 	}
 
 	// Commit the transaction
 	if err := tx.Commit(c); err != nil {
 		log.Printf("Failed to commit transaction: %v", err)
-		c.JSON(500, gin.H{"error": "failed to save changes"})
+		problem.Respond(c, 500, "failed to save changes")
 		return
 	}
 
 	c.JSON(201, gin.H{
 		"id":              project.ID,
 		"name":            project.Name,
+		"workspace":       workspace.Slug,
 		"default_channel": channel.ID,
 	})
 }
@@ -126,14 +187,14 @@ func (h *Handler) HandleMakeChannel(c *gin.Context) {
 func (h *Handler) HandlelistProjects(c *gin.Context) {
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 	uid := userID.(pgtype.UUID)
 
 	projects, err := h.Queries.GetProjectsByOwner(c, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to fetch projects"})
+		problem.Respond(c, 500, "failed to fetch projects")
 		return
 	}
 
@@ -142,94 +203,222 @@ func (h *Handler) HandlelistProjects(c *gin.Context) {
 	})
 }
 
-// GitHubRepo represents a GitHub repository
-type GitHubRepo struct {
-	ID          int64  `json:"id"`
-	Name        string `json:"name"`
-	FullName    string `json:"full_name"`
-	Description string `json:"description"`
-	Private     bool   `json:"private"`
-	HTMLURL     string `json:"html_url"`
-	Language    string `json:"language"`
-	StarCount   int    `json:"stargazers_count"`
-	ForksCount  int    `json:"forks_count"`
-	Owner       struct {
-		Login     string `json:"login"`
-		AvatarURL string `json:"avatar_url"`
-	} `json:"owner"`
-}
+// maxRepoPages caps how far HandleGetGitHubRepos follows pagination (100 repos per page)
+const maxRepoPages = 10
+
+var validRepoAffiliations = map[string]bool{"owner": true, "collaborator": true, "organization_member": true}
 
-// HandleGetGitHubRepos fetches the user's GitHub repositories
+// HandleGetGitHubRepos fetches the user's GitHub repositories.
+// Without ?page it walks every page (up to maxRepoPages); with ?page/?per_page
+// it returns that single page plus next_page. ?affiliation is passed to GitHub;
+// ?org and ?language filter the result.
 func (h *Handler) HandleGetGitHubRepos(c *gin.Context) {
 	userID, ok := c.Get("user_id")
 	if !ok {
-		c.JSON(401, gin.H{"error": "unauthorized"})
+		problem.Respond(c, 401, "unauthorized")
 		return
 	}
 	uid := userID.(pgtype.UUID)
 
 	// Get user's access token from DB
-	user, err := h.Queries.GetUserByID(c, uid)
+	user, err := h.getUserByID(c, uid)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to get user"})
+		problem.Respond(c, 500, "failed to get user")
 		return
 	}
 
 	// Debug: Check if token exists
 	if user.AccessToken == "" {
 		log.Printf("[GitHub API] No access token stored for user %s", user.Username)
-		c.JSON(401, gin.H{
-			"error":   "No GitHub access token",
-			"message": "Please log out and log in again to connect your GitHub account",
-		})
+		problem.RespondCode(c, 401, "github_not_connected",
+			"No GitHub access token. Please log out and log in again to connect your GitHub account", nil)
 		return
 	}
 
-	// Debug: Log token length (not the actual token for security)
-	log.Printf("[GitHub API] Fetching repos for user %s (token length: %d)", user.Username, len(user.AccessToken))
+	filter := url.Values{}
+	if aff := c.Query("affiliation"); aff != "" {
+		for _, a := range strings.Split(aff, ",") {
+			if !validRepoAffiliations[a] {
+				problem.Respond(c, 400, "affiliation must be a comma-separated list of owner, collaborator, organization_member")
+				return
+			}
+		}
+		filter.Set("affiliation", aff)
+	}
+
+	opts := github.ListOptions{Sort: "updated", PerPage: "100"}
+	page := c.Query("page")
+	if page != "" {
+		if n, err := strconv.Atoi(page); err != nil || n < 1 {
+			problem.Respond(c, 400, "invalid page")
+			return
+		}
+		opts.Page = page
+		if pp := c.Query("per_page"); pp != "" {
+			if n, err := strconv.Atoi(pp); err != nil || n < 1 || n > 100 {
+				problem.Respond(c, 400, "per_page must be between 1 and 100")
+				return
+			}
+			opts.PerPage = pp
+		}
+	}
 
 	// Fetch repos from GitHub API
-	req, err := http.NewRequest("GET", "https://api.github.com/user/repos?sort=updated&per_page=100", nil)
+	var (
+		repos     []github.Repo
+		truncated bool
+		nextPage  *int
+	)
+	if page != "" {
+		var resp *github.Response
+		repos, resp, err = github.Default.ListUserRepos(c, user.AccessToken, opts, filter)
+		if err == nil && resp.NextURL != "" {
+			n, _ := strconv.Atoi(page)
+			n++
+			nextPage = &n
+		}
+	} else {
+		repos, truncated, err = github.Default.ListAllUserRepos(c, user.AccessToken, opts, filter, maxRepoPages)
+	}
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to create request"})
+		if errors.Is(err, github.ErrUnauthorized) {
+			problem.RespondCode(c, 401, "github_token_invalid",
+				"GitHub token expired or invalid. Please log out and log in again to refresh your GitHub access", nil)
+			return
+		}
+		respondGitHubError(c, err)
 		return
 	}
 
-	req.Header.Set("Authorization", "Bearer "+user.AccessToken)
-	req.Header.Set("Accept", "application/vnd.github+json")
+	// Client-side filters (GitHub's /user/repos has no org or language filter)
+	org := c.Query("org")
+	language := c.Query("language")
+	if org != "" || language != "" {
+		filtered := make([]github.Repo, 0, len(repos))
+		for _, r := range repos {
+			if org != "" && !strings.EqualFold(r.Owner.Login, org) {
+				continue
+			}
+			if language != "" && !strings.EqualFold(r.Language, language) {
+				continue
+			}
+			filtered = append(filtered, r)
+		}
+		repos = filtered
+	}
+	if repos == nil {
+		repos = []github.Repo{}
+	}
+
+	c.JSON(200, gin.H{
+		"repos":     repos,
+		"next_page": nextPage,
+		"truncated": truncated,
+	})
+}
+
+type RelinkRepoRequest struct {
+	RepoID         int64  `json:"repo_id" binding:"required_without=FullName,min=0"`
+	FullName       string `json:"full_name" binding:"required_without=RepoID,max=200"` // "owner/name", alternative to repo_id
+	AllowDuplicate bool   `json:"allow_duplicate"`
+}
+
+// HandleRelinkRepo changes the GitHub repository a loop is linked to (owner only).
+// Also used after a rename/transfer with the same repo_id to refresh the cached name.
+func (h *Handler) HandleRelinkRepo(c *gin.Context) {
+	var req RelinkRepoRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	ctx := c.Request.Context()
+	project, err := h.Loops.ByName(ctx, c.Param("name"))
 	if err != nil {
-		c.JSON(500, gin.H{"error": "failed to fetch repos from GitHub"})
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can change the linked repository")
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		// Debug: Log the error response from GitHub
-		var errorBody map[string]interface{}
-		json.NewDecoder(resp.Body).Decode(&errorBody)
-		log.Printf("[GitHub API] Error %d for user %s: %v", resp.StatusCode, user.Username, errorBody)
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	if user.AccessToken == "" {
+		problem.Respond(c, 401, "No GitHub access token. Please re-login.")
+		return
+	}
 
-		if resp.StatusCode == 401 {
-			c.JSON(401, gin.H{
-				"error":   "GitHub token expired or invalid",
-				"message": "Please log out and log in again to refresh your GitHub access",
-			})
+	repoID := req.RepoID
+	if repoID == 0 {
+		owner, name, ok := github.SplitFullName(req.FullName)
+		if !ok {
+			problem.Respond(c, 400, "full_name must look like owner/name")
 			return
 		}
-		c.JSON(resp.StatusCode, gin.H{"error": fmt.Sprintf("GitHub API error: %d", resp.StatusCode)})
-		return
+		repo, err := github.Default.GetRepo(ctx, user.AccessToken, owner, name)
+		if err != nil {
+			respondGitHubError(c, err)
+			return
+		}
+		repoID = repo.ID
 	}
 
-	var repos []GitHubRepo
-	if err := json.NewDecoder(resp.Body).Decode(&repos); err != nil {
-		c.JSON(500, gin.H{"error": "failed to parse GitHub response"})
+	// Same sanity checks as verification: the repo must resolve with the
+	// owner's token, and the owner must have write access to it
+	github.Default.ForgetRepo(repoID)
+	repoInfo, err := gate.ResolveRepoByID(ctx, user.AccessToken, repoID)
+	if err != nil {
+		respondGitHubError(c, err)
+		return
+	}
+	isCollab, err := gate.CheckCollaborator(ctx, user.AccessToken, repoInfo.Owner, repoInfo.Name, user.Username)
+	if err != nil || !isCollab {
+		problem.Respond(c, 403, "you need write access to "+repoInfo.Owner+"/"+repoInfo.Name+" to link it")
 		return
 	}
 
+	oldRepoID := project.GithubRepoID
+	if repoID != oldRepoID {
+		if !req.AllowDuplicate && h.repoTaken(c, h.Queries, repoID, project.ID) {
+			return
+		}
+		updated, err := h.Queries.UpdateProjectRepo(ctx, db.UpdateProjectRepoParams{
+			ID:           project.ID,
+			GithubRepoID: repoID,
+		})
+		if err != nil {
+			log.Printf("[relink] UpdateProjectRepo failed for %s: %v", project.Name, err)
+			problem.Respond(c, 500, "failed to update repository")
+			return
+		}
+		project = updated
+		invalidateProject(project)
+	}
+	github.Default.ForgetRepo(oldRepoID)
+	// Relinking, even to the same repo once it is back, ends mirror mode; the
+	// recheck puts the loop back if the new repo is archived
+	if err := h.Queries.DeleteLoopMirror(ctx, project.ID); err != nil {
+		log.Printf("[relink] failed to clear mirror state of %s: %v", project.Name, err)
+	}
+	lookupInvalidator.Invalidate("loop_mirror", utils.UUIDToStr(project.ID))
+	repoRecheckCache.Delete(strconv.FormatInt(repoID, 10))
+	h.recheckRepo(repoID)
+
+	log.Printf("[relink] %s: repo %d -> %d (%s/%s)", project.Name, oldRepoID, repoID, repoInfo.Owner, repoInfo.Name)
+
 	c.JSON(200, gin.H{
-		"repos": repos,
+		"name":           project.Name,
+		"repo_id":        project.GithubRepoID,
+		"repo_full_name": repoInfo.Owner + "/" + repoInfo.Name,
 	})
 }
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/i18n"
+	"wireloop/internal/members"
+	"wireloop/internal/notify"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOST REPO ACCESS
+// When GitHub reports that a collaborator was removed from a repo, or lost
+// write access to it, each loop linked to the repo reacts as its owner chose:
+// the owner is told (notify), or a moderator is also dropped back to
+// contributor (demote). Membership itself stays: contributors may have
+// joined through the gatekeeper rules rather than as collaborators, and
+// their GitHub features already run with their own token.
+// ============================================================================
+
+// What a loop does when a member loses access to its repo
+const (
+	accessRevokedNotify = "notify"
+	accessRevokedDemote = "demote"
+)
+
+// repoWritePermissions are the permissions that made someone a collaborator
+var repoWritePermissions = map[string]bool{"write": true, "maintain": true, "admin": true}
+
+func loopAccessRevoked(s db.LoopSetting) string {
+	if s.AccessRevoked == "" {
+		return accessRevokedNotify
+	}
+	return s.AccessRevoked
+}
+
+// accessRevoked reports whether a member event takes away the member's
+// access to the repo
+func accessRevoked(ev github.MemberEvent) bool {
+	switch ev.Action {
+	case "removed":
+		return true
+	case "edited":
+		p := ev.Changes.Permission
+		return p != nil && repoWritePermissions[p.From] && !repoWritePermissions[p.To]
+	}
+	return false
+}
+
+// handleMemberWebhook applies each linked loop's policy to a member who
+// lost access to the repo
+func (h *Handler) handleMemberWebhook(ctx context.Context, body []byte) error {
+	var ev github.MemberEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return err
+	}
+	if !accessRevoked(ev) || ev.Member.ID == 0 {
+		return nil
+	}
+	rows, err := h.Queries.GetRepoMembershipsByGithubID(ctx, db.GetRepoMembershipsByGithubIDParams{
+		GithubRepoID: ev.Repository.ID,
+		GithubID:     ev.Member.ID,
+	})
+	if err != nil {
+		return err
+	}
+	for _, m := range rows {
+		// The owner answers for the loop; guests never relied on the repo
+		if m.UserID == m.OwnerID || m.Role.String == members.Guest {
+			continue
+		}
+		demoted := false
+		if m.AccessRevoked == accessRevokedDemote && m.Role.String == members.Moderator {
+			if _, err := h.Queries.SetMembershipRole(ctx, db.SetMembershipRoleParams{
+				UserID:    m.UserID,
+				ProjectID: m.ProjectID,
+				Role:      pgtype.Text{String: members.Contributor, Valid: true},
+			}); err != nil {
+				return err
+			}
+			demoted = true
+		}
+		log.Printf("[repo-access] %s lost access to %s (loop %s, demoted=%t)", m.Username, ev.Repository.FullName, m.ProjectName, demoted)
+		h.notifyAccessRevoked(ctx, m, ev.Repository.FullName, demoted)
+	}
+	return nil
+}
+
+// notifyAccessRevoked tells the loop's owner a member lost access to the repo
+func (h *Handler) notifyAccessRevoked(ctx context.Context, m db.GetRepoMembershipsByGithubIDRow, repoFullName string, demoted bool) {
+	locale := h.recipientLocale(ctx, m.OwnerID)
+	preview := i18n.T(locale, "%s no longer has access to %s", m.Username, repoFullName)
+	if demoted {
+		preview = i18n.T(locale, "%s no longer has access to %s and is no longer a moderator of %s", m.Username, repoFullName, m.ProjectName)
+	}
+	if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+		Type:          "member_access_revoked",
+		UserID:        m.OwnerID,
+		ActorID:       m.UserID,
+		ActorUsername: m.Username,
+		ProjectID:     m.ProjectID,
+		Preview:       preview,
+		Extra: map[string]any{
+			"loop_name": m.ProjectName,
+			"repo":      repoFullName,
+			"demoted":   demoted,
+		},
+	}); err != nil {
+		log.Printf("[repo-access] failed to notify the owner of %s: %v", m.ProjectName, err)
+	}
+}
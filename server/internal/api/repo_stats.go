@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"wireloop/internal/db"
+	"wireloop/internal/github"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// REPO STATS
+// Stars, forks, contributors and last push of every linked repo, kept in
+// repo_stats by a background refresher. The gatekeeper's STAR_COUNT rule and
+// the explore page read them from there instead of asking GitHub each time.
+// ============================================================================
+
+const (
+	repoStatsInterval = 6 * time.Hour    // how often a repo's numbers are fetched
+	repoStatsPoll     = 10 * time.Minute // how often the refresher looks for stale rows
+	repoStatsBatch    = 25
+	// Numbers older than this are not trusted for STAR_COUNT checks, which
+	// then ask GitHub directly
+	repoStatsMaxAge = 48 * time.Hour
+)
+
+// StartRepoStatsRefresh periodically refreshes repos whose stats are older
+// than repoStatsInterval, until ctx is cancelled
+func (h *Handler) StartRepoStatsRefresh(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(repoStatsPoll)
+		defer ticker.Stop()
+		for {
+			h.refreshStaleRepoStats(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (h *Handler) refreshStaleRepoStats(ctx context.Context) {
+	if err := h.Queries.SeedRepoStats(ctx); err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[repo-stats] seed failed: %v", err)
+		}
+		return
+	}
+	stale, err := h.Queries.ClaimStaleRepoStats(ctx, db.ClaimStaleRepoStatsParams{
+		CheckedAt: pgtype.Timestamptz{Time: time.Now().Add(-repoStatsInterval), Valid: true},
+		Limit:     repoStatsBatch,
+	})
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[repo-stats] claim failed: %v", err)
+		}
+		return
+	}
+	for _, s := range stale {
+		if ctx.Err() != nil {
+			return
+		}
+		// Rows that fail keep their old numbers and are retried next interval
+		if err := h.refreshRepoStats(ctx, s.RepoID); err != nil && !errors.Is(err, github.ErrUnauthorized) {
+			log.Printf("[repo-stats] refresh failed for repo %d: %v", s.RepoID, err)
+		}
+	}
+}
+
+// refreshRepoStats fetches repoID's numbers with the read token of a loop
+// linked to it
+func (h *Handler) refreshRepoStats(ctx context.Context, repoID int64) error {
+	projects, err := h.Queries.GetProjectsByGithubRepoID(ctx, repoID)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		return nil
+	}
+	project := projects[0]
+	owner, err := h.getUserByID(ctx, project.OwnerID)
+	if err != nil {
+		return fmt.Errorf("loop owner: %w", err)
+	}
+	token := h.loopReadToken(ctx, project, owner)
+
+	repo, err := github.Default.GetRepoByID(ctx, token, repoID)
+	if errors.Is(err, github.ErrNotFound) {
+		return h.setRepoMirror(ctx, repoID, mirrorDeleted)
+	}
+	if err != nil {
+		return err
+	}
+	mirror := ""
+	if repo.Archived {
+		mirror = mirrorArchived
+	}
+	if err := h.setRepoMirror(ctx, repoID, mirror); err != nil {
+		log.Printf("[repo-stats] failed to update mirror state of repo %d: %v", repoID, err)
+	}
+	contributors, err := github.Default.ContributorCount(ctx, token, repo.FullName)
+	if err != nil {
+		return err
+	}
+	var pushedAt pgtype.Timestamptz
+	if t, err := time.Parse(time.RFC3339, repo.PushedAt); err == nil {
+		pushedAt = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+	return h.Queries.UpdateRepoStats(ctx, db.UpdateRepoStatsParams{
+		RepoID:       repoID,
+		FullName:     repo.FullName,
+		Stars:        int32(repo.StarCount),
+		Forks:        int32(repo.ForksCount),
+		Contributors: int32(contributors),
+		PushedAt:     pushedAt,
+	})
+}
+
+// cachedStarCount is the gatekeeper's star source: the repo's stars from
+// repo_stats when they were refreshed within repoStatsMaxAge
+func (h *Handler) cachedStarCount(ctx context.Context, owner, repo string) (int, bool) {
+	s, err := h.Queries.GetRepoStatsByName(ctx, owner+"/"+repo)
+	if err != nil || time.Since(s.RefreshedAt.Time) > repoStatsMaxAge {
+		return 0, false
+	}
+	return int(s.Stars), true
+}
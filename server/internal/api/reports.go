@@ -0,0 +1,363 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// CONTENT REPORTS
+// Members flag messages; the loop owner and moderators work through the
+// queue. Resolving a report resolves every open report on the same message
+// and tells each reporter the outcome.
+// ============================================================================
+
+const (
+	reportStatusOpen      = "open"
+	reportStatusDismissed = "dismissed"
+	reportStatusActioned  = "actioned"
+)
+
+type ReportResponse struct {
+	ID               string  `json:"id"`
+	MessageID        string  `json:"message_id"`
+	ChannelID        string  `json:"channel_id,omitempty"`
+	Reason           string  `json:"reason"`
+	Details          string  `json:"details,omitempty"`
+	Status           string  `json:"status"`
+	Resolution       string  `json:"resolution,omitempty"`
+	ReporterID       string  `json:"reporter_id"`
+	ReporterUsername string  `json:"reporter_username,omitempty"`
+	AuthorID         string  `json:"author_id,omitempty"`
+	AuthorUsername   string  `json:"author_username,omitempty"`
+	MessageContent   string  `json:"message_content,omitempty"`
+	MessageDeleted   bool    `json:"message_deleted"`
+	CreatedAt        string  `json:"created_at"`
+	ResolvedAt       *string `json:"resolved_at,omitempty"`
+}
+
+type ReportMessageRequest struct {
+	Reason  string `json:"reason" binding:"required,oneof=spam harassment inappropriate other"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+// HandleReportMessage files (or updates) the caller's report on a message
+func (h *Handler) HandleReportMessage(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	var req ReportMessageRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	messageID, err := strconv.ParseInt(c.Param("message_id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid message id")
+		return
+	}
+	msg, err := h.Queries.GetMessageByID(c, messageID)
+	if err != nil || msg.IsDeleted.Bool {
+		problem.Respond(c, 404, "message not found")
+		return
+	}
+	if msg.SenderID == uid {
+		problem.Respond(c, 400, "you cannot report your own message")
+		return
+	}
+	if !h.Members.IsMember(c, uid, msg.ProjectID) {
+		problem.Respond(c, 403, "not a member of this loop")
+		return
+	}
+
+	report, err := h.Queries.CreateMessageReport(c, db.CreateMessageReportParams{
+		MessageID:  messageID,
+		ProjectID:  msg.ProjectID,
+		ReporterID: uid,
+		Reason:     req.Reason,
+		Details:    pgtype.Text{String: req.Details, Valid: req.Details != ""},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		problem.Respond(c, 409, "your report on this message has already been resolved")
+		return
+	}
+	if err != nil {
+		log.Printf("[reports] failed to create report: %v", err)
+		problem.Respond(c, 500, "failed to report message")
+		return
+	}
+
+	c.JSON(201, reportResponse(report))
+}
+
+func reportResponse(r db.MessageReport) ReportResponse {
+	resp := ReportResponse{
+		ID:         utils.UUIDToStr(r.ID),
+		MessageID:  strconv.FormatInt(r.MessageID, 10),
+		Reason:     r.Reason,
+		Details:    r.Details.String,
+		Status:     r.Status,
+		Resolution: r.Resolution.String,
+		ReporterID: utils.UUIDToStr(r.ReporterID),
+		CreatedAt:  utils.FormatTime(r.CreatedAt.Time),
+	}
+	if r.ResolvedAt.Valid {
+		s := utils.FormatTime(r.ResolvedAt.Time)
+		resp.ResolvedAt = &s
+	}
+	return resp
+}
+
+// HandleGetLoopReports lists the loop's report queue (?status=open|dismissed|actioned)
+func (h *Handler) HandleGetLoopReports(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only loop owners and moderators can review reports")
+		return
+	}
+
+	status := c.DefaultQuery("status", reportStatusOpen)
+	if status != reportStatusOpen && status != reportStatusDismissed && status != reportStatusActioned {
+		problem.Respond(c, 400, "invalid status")
+		return
+	}
+
+	rows, err := h.Queries.GetLoopReports(c, db.GetLoopReportsParams{
+		ProjectID: project.ID,
+		Status:    status,
+		Limit:     200,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get reports")
+		return
+	}
+
+	result := make([]ReportResponse, len(rows))
+	for i, r := range rows {
+		resp := ReportResponse{
+			ID:               utils.UUIDToStr(r.ID),
+			MessageID:        strconv.FormatInt(r.MessageID, 10),
+			ChannelID:        utils.UUIDToStr(r.ChannelID),
+			Reason:           r.Reason,
+			Details:          r.Details.String,
+			Status:           r.Status,
+			Resolution:       r.Resolution.String,
+			ReporterID:       utils.UUIDToStr(r.ReporterID),
+			ReporterUsername: r.ReporterUsername,
+			AuthorID:         utils.UUIDToStr(r.AuthorID),
+			AuthorUsername:   r.AuthorUsername.String,
+			MessageContent:   r.MessageContent,
+			MessageDeleted:   r.MessageDeleted.Bool,
+			CreatedAt:        utils.FormatTime(r.CreatedAt.Time),
+		}
+		if r.ResolvedAt.Valid {
+			s := utils.FormatTime(r.ResolvedAt.Time)
+			resp.ResolvedAt = &s
+		}
+		result[i] = resp
+	}
+
+	c.JSON(200, result)
+}
+
+// reviewableReport loads the report named in the URL and checks the caller may
+// resolve it. It writes the error response itself when ok is false.
+func (h *Handler) reviewableReport(c *gin.Context) (report db.MessageReport, mod db.User, project db.Project, ok bool) {
+	uid, authed := utils.GetUserIdFromContext(c)
+	if !authed {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	reportID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid report id")
+		return
+	}
+	report, err = h.Queries.GetMessageReportByID(c, reportID)
+	if err != nil {
+		problem.Respond(c, 404, "report not found")
+		return
+	}
+	project, err = h.Loops.ByID(c, report.ProjectID)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only loop owners and moderators can review reports")
+		return
+	}
+	if report.Status != reportStatusOpen {
+		problem.Respond(c, 409, "report already resolved")
+		return
+	}
+	mod, err = h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	return report, mod, project, true
+}
+
+// HandleDismissReport closes the message's open reports without action
+func (h *Handler) HandleDismissReport(c *gin.Context) {
+	report, mod, _, ok := h.reviewableReport(c)
+	if !ok {
+		return
+	}
+	h.resolveReports(c, report, mod, reportStatusDismissed, "dismissed")
+}
+
+// HandleReportDeleteMessage deletes the reported message and closes its reports
+func (h *Handler) HandleReportDeleteMessage(c *gin.Context) {
+	report, mod, _, ok := h.reviewableReport(c)
+	if !ok {
+		return
+	}
+	if err := h.deleteMessageAsModerator(c, report.MessageID); err != nil {
+		problem.Respond(c, 500, "failed to delete message")
+		return
+	}
+	h.resolveReports(c, report, mod, reportStatusActioned, "message_deleted")
+}
+
+type BanAuthorRequest struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// HandleReportBanAuthor bans the reported message's author from the loop,
+// removes their membership and deletes the message
+func (h *Handler) HandleReportBanAuthor(c *gin.Context) {
+	report, mod, project, ok := h.reviewableReport(c)
+	if !ok {
+		return
+	}
+	var req BanAuthorRequest
+	if c.Request.ContentLength != 0 && !bindJSON(c, &req) { // body is optional
+		return
+	}
+
+	msg, err := h.Queries.GetMessageByID(c, report.MessageID)
+	if err != nil {
+		problem.Respond(c, 404, "message not found")
+		return
+	}
+	if msg.SenderID == project.OwnerID {
+		problem.Respond(c, 400, "the loop owner cannot be banned")
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "failed to ban user")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	if err := qtx.BanFromLoop(c, db.BanFromLoopParams{
+		ProjectID: project.ID,
+		UserID:    msg.SenderID,
+		BannedBy:  mod.ID,
+		Reason:    pgtype.Text{String: req.Reason, Valid: req.Reason != ""},
+	}); err != nil {
+		problem.Respond(c, 500, "failed to ban user")
+		return
+	}
+	if err := qtx.RemoveMembership(c, db.RemoveMembershipParams{UserID: msg.SenderID, ProjectID: project.ID}); err != nil {
+		problem.Respond(c, 500, "failed to ban user")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to ban user")
+		return
+	}
+
+	if err := h.deleteMessageAsModerator(c, report.MessageID); err != nil {
+		log.Printf("[reports] banned author but failed to delete message %d: %v", report.MessageID, err)
+	}
+	h.Events.PublishUser(utils.UUIDToStr(msg.SenderID), events.Ban{LoopID: utils.UUIDToStr(project.ID), LoopName: project.Name})
+	h.resolveReports(c, report, mod, reportStatusActioned, "author_banned")
+}
+
+// deleteMessageAsModerator soft-deletes a message the same way its sender would
+func (h *Handler) deleteMessageAsModerator(ctx context.Context, messageID int64) error {
+	msg, err := h.Queries.GetMessageByID(ctx, messageID)
+	if err != nil {
+		return err
+	}
+	if msg.IsDeleted.Bool {
+		return nil
+	}
+	if err := h.Queries.SoftDeleteMessage(ctx, messageID); err != nil {
+		return err
+	}
+	if msg.ParentID.Valid {
+		h.Queries.DecrementReplyCount(ctx, msg.ParentID.Int64)
+	}
+	h.Events.PublishChannel(utils.UUIDToStr(msg.ChannelID), events.MessageRef{MessageID: strconv.FormatInt(messageID, 10)})
+	return nil
+}
+
+var reportOutcomes = map[string]string{
+	"dismissed":       "A moderator reviewed your report and took no action",
+	"message_deleted": "A moderator reviewed your report and removed the message",
+	"author_banned":   "A moderator reviewed your report and removed the author from the loop",
+}
+
+// resolveReports closes every open report on the message, notifies the
+// reporters and writes the response
+func (h *Handler) resolveReports(c *gin.Context, report db.MessageReport, mod db.User, status, resolution string) {
+	resolved, err := h.Queries.ResolveMessageReports(c, db.ResolveMessageReportsParams{
+		MessageID:  report.MessageID,
+		Status:     status,
+		Resolution: pgtype.Text{String: resolution, Valid: true},
+		ResolvedBy: mod.ID,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to resolve report")
+		return
+	}
+
+	preview := reportOutcomes[resolution]
+	for _, r := range resolved {
+		if _, _, err := h.Notifier.Notify(c, notify.Event{
+			Type:          "report_resolved",
+			UserID:        r.ReporterID,
+			ActorID:       mod.ID,
+			ActorUsername: mod.Username,
+			ProjectID:     r.ProjectID,
+			Preview:       preview,
+			Extra:         map[string]any{"report_id": utils.UUIDToStr(r.ID), "resolution": resolution},
+		}); err != nil {
+			log.Printf("[reports] failed to notify reporter: %v", err)
+		}
+	}
+
+	c.JSON(200, gin.H{"resolution": resolution, "resolved": len(resolved)})
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	utils "wireloop/internal"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// ROLES — route guards over the members service (internal/members)
+// ============================================================================
+
+// DenyGuests keeps guests away from a loop route (":name"), used for
+// everything that acts on GitHub with the caller's token. Unknown loops and
+// non-members fall through to the handler's own checks.
+func (h *Handler) DenyGuests() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		uid, ok := utils.GetUserIdFromContext(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		project, err := h.Loops.ByName(c, c.Param("name"))
+		if err != nil {
+			c.Next()
+			return
+		}
+		if h.Members.Role(c, uid, project.ID) == members.Guest {
+			problem.Abort(c, 403, "guests can't use GitHub features in this loop")
+			return
+		}
+		c.Next()
+	}
+}
+
+// rejectGuest is DenyGuests for handlers that find their loop another way
+// (task or channel IDs). It writes the 403 and returns true for guests.
+func (h *Handler) rejectGuest(c *gin.Context, uid, projectID pgtype.UUID) bool {
+	if h.Members.Role(c, uid, projectID) != members.Guest {
+		return false
+	}
+	problem.Respond(c, 403, "guests can't use GitHub features in this loop")
+	return true
+}
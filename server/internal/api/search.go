@@ -1,9 +1,13 @@
 package api
 
 import (
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+	utils "wireloop/internal"
 	"wireloop/internal/db"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -60,7 +64,7 @@ func (h *Handler) HandleSearchQuery(c *gin.Context) {
 		N: 10,
 	})
 	if err != nil {
-		c.JSON(500, gin.H{"error": err.Error()})
+		problem.Error(c, err, "search failed")
 		return
 	}
 
@@ -70,3 +74,95 @@ func (h *Handler) HandleSearchQuery(c *gin.Context) {
 
 	c.JSON(200, repos)
 }
+
+const (
+	defaultMessageSearchLimit = 20
+	maxMessageSearchLimit     = 50
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+type MessageSearchResult struct {
+	ID             string `json:"id"`
+	Content        string `json:"content"`
+	LoopName       string `json:"loop_name"`
+	ChannelID      string `json:"channel_id"`
+	ChannelName    string `json:"channel_name"`
+	SenderID       string `json:"sender_id"`
+	SenderUsername string `json:"sender_username"`
+	SenderAvatar   string `json:"sender_avatar"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type MessageSearchResponse struct {
+	Results []MessageSearchResult `json:"results"`
+	// Pass as ?before= for the next page; empty on the last
+	NextBefore string `json:"next_before,omitempty"`
+}
+
+// HandleSearchMessages searches message content across the caller's loops,
+// or one with ?loop=, newest first. Pages with ?before=<message id>.
+// Which channels the caller may read is enforced by the query itself.
+func (h *Handler) HandleSearchMessages(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	raw := strings.TrimSpace(c.Query("q"))
+	if len([]rune(raw)) < 2 {
+		problem.Respond(c, 400, "search needs at least 2 characters")
+		return
+	}
+
+	params := db.SearchMessagesParams{
+		UserID:   uid,
+		Pattern:  pgtype.Text{String: likeEscaper.Replace(raw), Valid: true},
+		RowLimit: defaultMessageSearchLimit,
+	}
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= maxMessageSearchLimit {
+			params.RowLimit = int32(v)
+		}
+	}
+	if b := c.Query("before"); b != "" {
+		before, err := strconv.ParseInt(b, 10, 64)
+		if err != nil {
+			problem.Respond(c, 400, "invalid before")
+			return
+		}
+		params.BeforeID = pgtype.Int8{Int64: before, Valid: true}
+	}
+	if name := c.Query("loop"); name != "" {
+		project, err := h.Loops.ByName(c, name)
+		if err != nil {
+			problem.Respond(c, 404, "loop not found")
+			return
+		}
+		params.ProjectID = project.ID
+	}
+
+	rows, err := h.Queries.SearchMessages(c, params)
+	if err != nil {
+		problem.Error(c, err, "search failed")
+		return
+	}
+	resp := MessageSearchResponse{Results: make([]MessageSearchResult, 0, len(rows))}
+	for _, m := range rows {
+		resp.Results = append(resp.Results, MessageSearchResult{
+			ID:             strconv.FormatInt(m.ID, 10),
+			Content:        m.Content,
+			LoopName:       m.LoopName,
+			ChannelID:      utils.UUIDToStr(m.ChannelID),
+			ChannelName:    m.ChannelName,
+			SenderID:       utils.UUIDToStr(m.SenderID),
+			SenderUsername: m.SenderUsername,
+			SenderAvatar:   mediaURL(m.SenderAvatar.String),
+			CreatedAt:      utils.FormatTime(m.CreatedAt.Time),
+		})
+	}
+	if len(rows) == int(params.RowLimit) {
+		resp.NextBefore = resp.Results[len(resp.Results)-1].ID
+	}
+	c.JSON(200, resp)
+}
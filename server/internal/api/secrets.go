@@ -0,0 +1,232 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+	"wireloop/internal/secrets"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOOP SECRETS
+// Credentials for the loop's integrations, which refer to them as
+// secret:<name>. Values are encrypted at rest and write-only: after they are
+// sent they are never returned, only a short hint of the last characters.
+// ============================================================================
+
+// secretRefPrefix marks a credentials_ref that names a loop secret
+const secretRefPrefix = "secret:"
+
+var secretNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+type SecretResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// What integrations put in credentials_ref to use it
+	Ref       string `json:"ref"`
+	Hint      string `json:"hint,omitempty"`
+	CreatedAt string `json:"created_at"`
+	RotatedAt string `json:"rotated_at,omitempty"`
+}
+
+type CreateSecretRequest struct {
+	Name  string `json:"name" binding:"required"`
+	Value string `json:"value" binding:"required,max=4096"`
+}
+
+type RotateSecretRequest struct {
+	Value string `json:"value" binding:"required,max=4096"`
+}
+
+func secretToResponse(s db.LoopSecret) SecretResponse {
+	out := SecretResponse{
+		ID:        utils.UUIDToStr(s.ID),
+		Name:      s.Name,
+		Ref:       secretRefPrefix + s.Name,
+		Hint:      s.Hint,
+		CreatedAt: utils.FormatTime(s.CreatedAt.Time),
+	}
+	if s.RotatedAt.Valid {
+		out.RotatedAt = utils.FormatTime(s.RotatedAt.Time)
+	}
+	return out
+}
+
+// resolveCredential is the integrations' credential resolver: it reveals the
+// loop secret a secret:<name> ref names
+func (h *Handler) resolveCredential(ctx context.Context, projectID pgtype.UUID, ref string) (string, error) {
+	name, ok := strings.CutPrefix(ref, secretRefPrefix)
+	if !ok {
+		return "", fmt.Errorf("credentials_ref must look like %s<name>", secretRefPrefix)
+	}
+	value, err := h.Secrets.Reveal(ctx, projectID, name)
+	if errors.Is(err, secrets.ErrNotFound) {
+		return "", fmt.Errorf("the loop has no secret called %q", name)
+	}
+	return value, err
+}
+
+// secretsLoop resolves an owned loop, refusing when the server has no
+// secrets store
+func (h *Handler) secretsLoop(c *gin.Context, action string) (db.Project, bool) {
+	if h.Secrets == nil {
+		problem.Respond(c, 503, "secrets are not configured on this server")
+		return db.Project{}, false
+	}
+	return h.ownedLoop(c, action)
+}
+
+// loopSecret resolves :id within an owned loop
+func (h *Handler) loopSecret(c *gin.Context, action string) (db.LoopSecret, bool) {
+	project, ok := h.secretsLoop(c, action)
+	if !ok {
+		return db.LoopSecret{}, false
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid secret id")
+		return db.LoopSecret{}, false
+	}
+	s, err := h.Queries.GetLoopSecret(c, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && s.ProjectID != project.ID) {
+		problem.Respond(c, 404, "secret not found")
+		return db.LoopSecret{}, false
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to get secret")
+		return db.LoopSecret{}, false
+	}
+	return s, true
+}
+
+// HandleGetSecrets lists the loop's secrets without their values (owner only)
+func (h *Handler) HandleGetSecrets(c *gin.Context) {
+	project, ok := h.secretsLoop(c, "view secrets")
+	if !ok {
+		return
+	}
+
+	rows, err := h.Queries.GetLoopSecrets(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get secrets")
+		return
+	}
+	out := make([]SecretResponse, 0, len(rows))
+	for _, s := range rows {
+		out = append(out, secretToResponse(s))
+	}
+	c.JSON(200, out)
+}
+
+// HandleCreateSecret stores a new secret (owner only). The value is not
+// returned, here or anywhere else.
+func (h *Handler) HandleCreateSecret(c *gin.Context) {
+	project, ok := h.secretsLoop(c, "add secrets")
+	if !ok {
+		return
+	}
+	uid, _ := utils.GetUserIdFromContext(c)
+
+	var req CreateSecretRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	if !secretNamePattern.MatchString(req.Name) {
+		problem.Respond(c, 400, "secret names are 1-64 lowercase letters, digits, - and _")
+		return
+	}
+
+	s, err := h.Secrets.Create(c, project.ID, req.Name, req.Value, uid)
+	if isUniqueViolation(err) {
+		problem.Respond(c, 409, "the loop already has a secret with this name")
+		return
+	}
+	if err != nil {
+		log.Printf("[secrets] failed to create %s for loop %s: %v", req.Name, project.Name, err)
+		problem.Respond(c, 500, "failed to store secret")
+		return
+	}
+
+	c.JSON(201, secretToResponse(s))
+}
+
+// HandleRotateSecret replaces a secret's value (owner only); integrations
+// using it pick the new value up with their next delivery
+func (h *Handler) HandleRotateSecret(c *gin.Context) {
+	s, ok := h.loopSecret(c, "rotate secrets")
+	if !ok {
+		return
+	}
+
+	var req RotateSecretRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+
+	rotated, err := h.Secrets.Rotate(c, s, req.Value)
+	if errors.Is(err, secrets.ErrNotFound) {
+		problem.Respond(c, 404, "secret not found")
+		return
+	}
+	if err != nil {
+		log.Printf("[secrets] failed to rotate %s: %v", s.Name, err)
+		problem.Respond(c, 500, "failed to rotate secret")
+		return
+	}
+
+	c.JSON(200, secretToResponse(rotated))
+}
+
+// HandleDeleteSecret removes a secret no integration uses (owner only)
+func (h *Handler) HandleDeleteSecret(c *gin.Context) {
+	s, ok := h.loopSecret(c, "remove secrets")
+	if !ok {
+		return
+	}
+
+	inUse, err := h.Queries.CountIntegrationsUsingCredential(c, db.CountIntegrationsUsingCredentialParams{
+		ProjectID:      s.ProjectID,
+		CredentialsRef: pgtype.Text{String: secretRefPrefix + s.Name, Valid: true},
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to delete secret")
+		return
+	}
+	if inUse > 0 {
+		problem.Respond(c, 409, fmt.Sprintf("%d integration(s) still use this secret", inUse))
+		return
+	}
+	if _, err := h.Queries.DeleteLoopSecret(c, s.ID); err != nil {
+		problem.Respond(c, 500, "failed to delete secret")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleAdminRewrapSecrets re-wraps every data key under the current master
+// key. Run it after rotating SECRETS_MASTER_KEY, before dropping the old key
+// from SECRETS_PREVIOUS_KEYS.
+func (h *Handler) HandleAdminRewrapSecrets(c *gin.Context) {
+	if h.Secrets == nil {
+		problem.Respond(c, 503, "secrets are not configured on this server")
+		return
+	}
+	n, err := h.Secrets.Rewrap(c)
+	if err != nil {
+		problem.Respond(c, 500, "failed to rewrap secrets")
+		return
+	}
+	log.Printf("[admin] rewrapped %d secrets", n)
+	c.JSON(200, gin.H{"rewrapped": n})
+}
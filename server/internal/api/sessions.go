@@ -0,0 +1,177 @@
+package api
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/auth"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/middleware"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// LOGIN SESSIONS
+// Every GitHub login creates a sessions row and the token carries its id.
+// The auth middleware asks SessionActive on each request, so revoking a
+// session kills its token within a cache TTL on every instance.
+// ============================================================================
+
+const (
+	// Last-seen is only written this often per session, not on every request
+	sessionTouchInterval = 5 * time.Minute
+	maxUserAgentLen      = 512
+)
+
+var (
+	sessionActiveCache  = cache.New[string, bool](lookupTTL, 20000)
+	sessionTouchedCache = cache.New[string, bool](sessionTouchInterval, 20000)
+)
+
+type SessionResponse struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	UserAgent  string `json:"user_agent"`
+	IP         string `json:"ip"`
+	Current    bool   `json:"current"`
+	CreatedAt  string `json:"created_at"`
+	LastSeenAt string `json:"last_seen_at"`
+	ExpiresAt  string `json:"expires_at"`
+}
+
+// startSession records a login from this request and returns its token
+func (h *Handler) startSession(c *gin.Context, uid pgtype.UUID) (string, error) {
+	expiresAt := time.Now().Add(auth.SessionTTL)
+	ua := c.GetHeader("User-Agent")
+	if len(ua) > maxUserAgentLen {
+		ua = ua[:maxUserAgentLen]
+	}
+	session, err := h.Queries.CreateSession(c, db.CreateSessionParams{
+		UserID:    uid,
+		UserAgent: ua,
+		Ip:        c.ClientIP(),
+		ExpiresAt: pgtype.Timestamptz{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		return "", err
+	}
+	return auth.GenerateJWT(uid, utils.UUIDToStr(session.ID), expiresAt)
+}
+
+// SessionActive is the auth middleware's revocation check. A lookup error
+// lets the request through: the token itself is still signed and unexpired.
+func (h *Handler) SessionActive(ctx context.Context, sessionID string) bool {
+	id, err := utils.StrToUUID(sessionID)
+	if err != nil {
+		return false
+	}
+	active, err := sessionActiveCache.GetOrLoad(sessionID, func() (bool, error) {
+		return h.Queries.IsSessionActive(ctx, id)
+	})
+	if err != nil {
+		log.Printf("[sessions] revocation check failed for %s: %v", sessionID, err)
+		return true
+	}
+	if active {
+		if _, seen := sessionTouchedCache.Get(sessionID); !seen {
+			sessionTouchedCache.Set(sessionID, true)
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				if err := h.Queries.TouchSession(ctx, id); err != nil {
+					log.Printf("[sessions] failed to update last seen for %s: %v", sessionID, err)
+				}
+			}()
+		}
+	}
+	return active
+}
+
+// describeUserAgent turns a User-Agent into a short "Browser on OS" label
+func describeUserAgent(ua string) string {
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"}, {"Safari/", "Safari"}, {"curl/", "curl"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+	platform := "unknown OS"
+	for _, o := range []struct{ token, name string }{
+		{"iPhone", "iOS"}, {"iPad", "iPadOS"}, {"Android", "Android"},
+		{"Windows", "Windows"}, {"Mac OS X", "macOS"}, {"CrOS", "ChromeOS"}, {"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			platform = o.name
+			break
+		}
+	}
+	return browser + " on " + platform
+}
+
+// HandleGetSessions lists the caller's live login sessions
+func (h *Handler) HandleGetSessions(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	current, _ := middleware.SessionID(c)
+
+	sessions, err := h.Queries.ListUserSessions(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get sessions")
+		return
+	}
+	result := make([]SessionResponse, len(sessions))
+	for i, s := range sessions {
+		id := utils.UUIDToStr(s.ID)
+		result[i] = SessionResponse{
+			ID:         id,
+			Device:     describeUserAgent(s.UserAgent),
+			UserAgent:  s.UserAgent,
+			IP:         s.Ip,
+			Current:    id == current,
+			CreatedAt:  utils.FormatTime(s.CreatedAt.Time),
+			LastSeenAt: utils.FormatTime(s.LastSeenAt.Time),
+			ExpiresAt:  utils.FormatTime(s.ExpiresAt.Time),
+		}
+	}
+	c.JSON(200, gin.H{"sessions": result})
+}
+
+// HandleRevokeSession signs one of the caller's sessions out everywhere.
+// Revoking the current session is allowed and works as a logout.
+func (h *Handler) HandleRevokeSession(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	id, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid session id")
+		return
+	}
+
+	n, err := h.Queries.RevokeSession(c, db.RevokeSessionParams{ID: id, UserID: uid})
+	if err != nil {
+		problem.Respond(c, 500, "failed to revoke session")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "session not found")
+		return
+	}
+	lookupInvalidator.Invalidate("session", utils.UUIDToStr(id))
+	c.JSON(200, gin.H{"message": "session revoked"})
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// UpdateSidebarRequest changes per-loop sidebar state; omitted fields are kept
+type UpdateSidebarRequest struct {
+	IsFavorite *bool `json:"is_favorite"`
+	Collapsed  *bool `json:"collapsed"`
+}
+
+type SidebarOrderRequest struct {
+	LoopIDs []string `json:"loop_ids" binding:"required,dive,uuid"`
+}
+
+// HandleUpdateLoopSidebar sets favorite / collapsed state for one of the caller's loops
+func (h *Handler) HandleUpdateLoopSidebar(c *gin.Context) {
+	var req UpdateSidebarRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+
+	membership, err := h.Queries.GetMembership(c, db.GetMembershipParams{UserID: uid, ProjectID: project.ID})
+	if err != nil {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+
+	if req.IsFavorite != nil {
+		membership.IsFavorite = *req.IsFavorite
+	}
+	if req.Collapsed != nil {
+		membership.SidebarCollapsed = *req.Collapsed
+	}
+
+	if _, err := h.Queries.UpdateMembershipPreferences(c, db.UpdateMembershipPreferencesParams{
+		UserID:           uid,
+		ProjectID:        project.ID,
+		IsFavorite:       membership.IsFavorite,
+		SidebarCollapsed: membership.SidebarCollapsed,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to update sidebar")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"loop_id":     utils.UUIDToStr(project.ID),
+		"is_favorite": membership.IsFavorite,
+		"collapsed":   membership.SidebarCollapsed,
+	})
+}
+
+// HandleSetSidebarOrder stores the caller's manual loop order.
+// Loops missing from the list keep their previous position.
+func (h *Handler) HandleSetSidebarOrder(c *gin.Context) {
+	var req SidebarOrderRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	ids := make([]pgtype.UUID, 0, len(req.LoopIDs))
+	for _, s := range req.LoopIDs {
+		id, err := utils.StrToUUID(s)
+		if err != nil {
+			problem.Respond(c, 400, "invalid loop id")
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	// user_id in the WHERE clause makes IDs of loops the caller isn't in a no-op
+	for i, id := range ids {
+		if err := qtx.SetMembershipSortOrder(c, db.SetMembershipSortOrderParams{
+			UserID:    uid,
+			ProjectID: id,
+			SortOrder: pgtype.Int4{Int32: int32(i), Valid: true},
+		}); err != nil {
+			problem.Respond(c, 500, "failed to save order")
+			return
+		}
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to save changes")
+		return
+	}
+
+	c.JSON(200, gin.H{"success": true})
+}
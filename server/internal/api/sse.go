@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+	"wireloop/internal/chat"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// SERVER-SENT EVENTS
+// For networks whose proxies block WebSockets. GET /api/stream follows the
+// same loop and channel rooms a socket would and writes every broadcast as
+// an SSE event whose data is the socket frame. Sending goes through
+// POST /api/loop/message. A reconnecting EventSource sends Last-Event-ID
+// and gets whatever it missed replayed, or a resync event when the room's
+// log no longer reaches back that far.
+// ============================================================================
+
+const (
+	// Comment lines keep proxies from timing the stream out while it's quiet
+	sseHeartbeat = 25 * time.Second
+	sseRetry     = 3 * time.Second
+)
+
+// HandleSSE streams a loop channel's events as text/event-stream
+func (h *Handler) HandleSSE(c *gin.Context) {
+	target, ok := h.resolveChatTarget(c)
+	if !ok {
+		return
+	}
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		// EventSource can't set headers on the first connection
+		lastEventID = c.Query("last_event_id")
+	}
+	var resumeFrom int64
+	if lastEventID != "" {
+		id, err := strconv.ParseInt(lastEventID, 10, 64)
+		if err != nil || id < 0 {
+			problem.Abort(c, 400, "invalid Last-Event-ID")
+			return
+		}
+		resumeFrom = id
+	}
+
+	rooms := []string{target.channelID, loopRoom(target.projectID)}
+	client := chat.NewStreamClient(target.userID, target.user.Username, mediaURL(target.user.AvatarUrl.String))
+	h.Hub.Connect(client)
+	for _, room := range rooms {
+		h.Hub.Watch(room)
+		h.Hub.Join(room, client)
+	}
+	defer func() {
+		for _, room := range rooms {
+			h.Hub.Leave(room, client)
+		}
+		h.Hub.Disconnect(client)
+		client.Close()
+	}()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // nginx would otherwise hold events back
+	c.Status(200)
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry.Milliseconds())
+
+	writeSSE(c.Writer, chat.Event{Data: events.Wrap(events.ConnectedInfo{
+		ChannelID: target.channelID,
+		ProjectID: target.projectID,
+	}, target.channelID)})
+
+	// Anything broadcast since Joining is queued as well as logged; the
+	// replayed IDs let the loop below drop those duplicates
+	replayed := make(map[int64]bool)
+	if resumeFrom > 0 {
+		var missed []chat.Event
+		complete := true
+		for _, room := range rooms {
+			events, _, ok := h.Hub.Since(room, resumeFrom)
+			missed = append(missed, events...)
+			complete = complete && ok
+		}
+		if !complete {
+			writeSSE(c.Writer, chat.Event{Data: events.Wrap(events.Resync, target.channelID)})
+		}
+		sort.Slice(missed, func(i, j int) bool { return missed[i].ID < missed[j].ID })
+		for _, ev := range missed {
+			replayed[ev.ID] = true
+			writeSSE(c.Writer, ev)
+		}
+	}
+	c.Writer.Flush()
+
+	// The hub ends streams when the server shuts down
+	ctx, stop := context.WithCancel(c.Request.Context())
+	defer stop()
+	go func() {
+		select {
+		case <-h.Hub.Closing():
+			stop()
+		case <-ctx.Done():
+		}
+	}()
+	for {
+		wait, cancel := context.WithTimeout(ctx, sseHeartbeat)
+		ev, ok := client.Receive(wait)
+		cancel()
+		if !ok {
+			if ctx.Err() != nil || !errors.Is(wait.Err(), context.DeadlineExceeded) {
+				return
+			}
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+			continue
+		}
+		if replayed[ev.ID] {
+			continue
+		}
+		if err := writeSSE(c.Writer, ev); err != nil {
+			return
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeSSE writes ev as one SSE event. Events without a log position carry
+// no id, so the client's Last-Event-ID stays on the last resumable one.
+func writeSSE(w io.Writer, ev chat.Event) error {
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return nil
+	}
+	if ev.ID != 0 {
+		_, err = fmt.Fprintf(w, "id: %d\ndata: %s\n\n", ev.ID, data)
+	} else {
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+	return err
+}
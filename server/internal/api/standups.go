@@ -0,0 +1,747 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/integrations"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// STANDUP BOT
+// At prompt_time on enabled weekdays the bot DMs each participant the
+// questions, collects answers (DM reply or /respond) for collect_minutes,
+// then posts a compiled summary to the standup's channel.
+// ============================================================================
+
+const (
+	jobStandupPrompt  = "standup_prompt"
+	jobStandupSummary = "standup_summary"
+
+	defaultWeekdays = 62 // Mon-Fri
+)
+
+var (
+	promptTimeRegex = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+	answerNumbering = regexp.MustCompile(`^\s*\d+[.)]\s*`)
+)
+
+type StandupRequest struct {
+	Name           string   `json:"name" binding:"required,notblank,max=100"`
+	ChannelID      string   `json:"channel_id" binding:"required,uuid"`
+	Questions      []string `json:"questions" binding:"required,min=1,max=10,dive,max=500"`
+	PromptTime     string   `json:"prompt_time" binding:"required"`                     // "HH:MM"
+	Timezone       string   `json:"timezone" binding:"omitempty,timezone"`              // IANA, default the creator's
+	Weekdays       []int    `json:"weekdays" binding:"max=7,dive,min=0,max=6"`          // 0 = Sunday; default Mon-Fri
+	CollectMinutes int      `json:"collect_minutes" binding:"omitempty,min=5,max=1440"` // default 120
+	Enabled        *bool    `json:"enabled"`
+	ParticipantIDs []string `json:"participant_ids" binding:"dive,uuid"`
+}
+
+type StandupAnswersRequest struct {
+	Answers []string `json:"answers" binding:"required,min=1,max=10,dive,max=4000"`
+}
+
+type StandupParticipantResponse struct {
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+type StandupResponse struct {
+	ID             string                       `json:"id"`
+	Name           string                       `json:"name"`
+	ChannelID      string                       `json:"channel_id"`
+	Questions      []string                     `json:"questions"`
+	PromptTime     string                       `json:"prompt_time"`
+	Timezone       string                       `json:"timezone"`
+	Weekdays       []int                        `json:"weekdays"`
+	CollectMinutes int                          `json:"collect_minutes"`
+	Enabled        bool                         `json:"enabled"`
+	NextPromptAt   *string                      `json:"next_prompt_at,omitempty"`
+	Participants   []StandupParticipantResponse `json:"participants"`
+}
+
+type standupPromptPayload struct {
+	StandupID string `json:"standup_id"`
+	Version   int64  `json:"version"` // standup updated_at; stale prompts are skipped
+}
+
+type standupSummaryPayload struct {
+	RunID string `json:"run_id"`
+}
+
+func weekdayMask(days []int) (int32, error) {
+	if len(days) == 0 {
+		return defaultWeekdays, nil
+	}
+	var mask int32
+	for _, d := range days {
+		if d < 0 || d > 6 {
+			return 0, errors.New("weekdays must be 0 (Sunday) to 6 (Saturday)")
+		}
+		mask |= 1 << d
+	}
+	return mask, nil
+}
+
+func weekdaysFromMask(mask int32) []int {
+	days := make([]int, 0, 7)
+	for d := 0; d < 7; d++ {
+		if mask&(1<<d) != 0 {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// nextStandupTime finds the first scheduled prompt strictly after t
+func nextStandupTime(s db.Standup, t time.Time) (time.Time, bool) {
+	return nextScheduledTime(s.PromptTime, s.Timezone, s.Weekdays, t)
+}
+
+// nextScheduledTime finds the first "HH:MM" in timezone strictly after t on
+// one of the weekdays in the mask
+func nextScheduledTime(hhmm, timezone string, weekdays int32, t time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	hh, _ := strconv.Atoi(hhmm[:2])
+	mm, _ := strconv.Atoi(hhmm[3:])
+	local := t.In(loc)
+	for d := 0; d <= 7; d++ {
+		day := local.AddDate(0, 0, d)
+		at := time.Date(day.Year(), day.Month(), day.Day(), hh, mm, 0, 0, loc)
+		if at.After(t) && weekdays&(1<<int(at.Weekday())) != 0 {
+			return at, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// splitAnswers maps a free-text DM reply onto the standup's questions.
+// One line per answer (leading "1." / "2)" stripped); extra lines join the last answer.
+func splitAnswers(reply string, questions int) []string {
+	var lines []string
+	for _, l := range strings.Split(reply, "\n") {
+		if l = strings.TrimSpace(answerNumbering.ReplaceAllString(l, "")); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	if questions <= 1 || len(lines) <= questions {
+		if len(lines) == 0 {
+			return []string{strings.TrimSpace(reply)}
+		}
+		if questions <= 1 {
+			return []string{strings.Join(lines, "\n")}
+		}
+		return lines
+	}
+	out := append([]string{}, lines[:questions-1]...)
+	return append(out, strings.Join(lines[questions-1:], "\n"))
+}
+
+func (h *Handler) standupToResponse(ctx context.Context, s db.Standup) StandupResponse {
+	resp := StandupResponse{
+		ID:             utils.UUIDToStr(s.ID),
+		Name:           s.Name,
+		ChannelID:      utils.UUIDToStr(s.ChannelID),
+		Questions:      s.Questions,
+		PromptTime:     s.PromptTime,
+		Timezone:       s.Timezone,
+		Weekdays:       weekdaysFromMask(s.Weekdays),
+		CollectMinutes: int(s.CollectMinutes),
+		Enabled:        s.Enabled,
+		Participants:   []StandupParticipantResponse{},
+	}
+	if s.Enabled {
+		if next, ok := nextStandupTime(s, time.Now()); ok {
+			n := utils.FormatTime(next)
+			resp.NextPromptAt = &n
+		}
+	}
+	participants, err := h.Queries.GetStandupParticipants(ctx, s.ID)
+	if err != nil {
+		log.Printf("[standup] failed to load participants: %v", err)
+	}
+	for _, p := range participants {
+		resp.Participants = append(resp.Participants, StandupParticipantResponse{
+			UserID:    utils.UUIDToStr(p.UserID),
+			Username:  p.Username,
+			AvatarURL: mediaURL(p.AvatarUrl.String),
+		})
+	}
+	return resp
+}
+
+// validateStandupRequest checks the body against the loop and returns update
+// params, using defaultTimezone when the body names none
+func (h *Handler) validateStandupRequest(ctx context.Context, req StandupRequest, projectID pgtype.UUID, defaultTimezone string) (db.UpdateStandupParams, error) {
+	var p db.UpdateStandupParams
+	p.Name = strings.TrimSpace(req.Name)
+
+	for _, q := range req.Questions {
+		if q = strings.TrimSpace(q); q != "" {
+			p.Questions = append(p.Questions, q)
+		}
+	}
+	if len(p.Questions) == 0 {
+		return p, errors.New("provide 1-10 questions")
+	}
+
+	if !promptTimeRegex.MatchString(req.PromptTime) {
+		return p, errors.New("prompt_time must be HH:MM")
+	}
+	p.PromptTime = req.PromptTime
+
+	p.Timezone = req.Timezone
+	if p.Timezone == "" {
+		p.Timezone = defaultTimezone
+	}
+
+	mask, err := weekdayMask(req.Weekdays)
+	if err != nil {
+		return p, err
+	}
+	p.Weekdays = mask
+
+	p.CollectMinutes = 120
+	if req.CollectMinutes != 0 {
+		p.CollectMinutes = int32(req.CollectMinutes)
+	}
+
+	p.Enabled = true
+	if req.Enabled != nil {
+		p.Enabled = *req.Enabled
+	}
+
+	channelID, _ := utils.StrToUUID(req.ChannelID)
+	channel, err := h.Queries.GetChannelByID(ctx, channelID)
+	if err != nil || channel.ProjectID != projectID {
+		return p, errors.New("channel not found in this loop")
+	}
+	p.ChannelID = channelID
+	return p, nil
+}
+
+// setStandupParticipants replaces the participant list with loop members from ids
+func (h *Handler) setStandupParticipants(ctx context.Context, s db.Standup, ids []string) error {
+	current, err := h.Queries.GetStandupParticipants(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+	want := make(map[pgtype.UUID]bool, len(ids))
+	for _, idStr := range ids {
+		id, err := utils.StrToUUID(idStr)
+		if err != nil {
+			return fmt.Errorf("invalid participant id %q", idStr)
+		}
+		if !h.Members.IsMember(ctx, id, s.ProjectID) {
+			return fmt.Errorf("participant %s is not a member of this loop", idStr)
+		}
+		want[id] = true
+	}
+	for _, p := range current {
+		if !want[p.UserID] {
+			if err := h.Queries.RemoveStandupParticipant(ctx, db.RemoveStandupParticipantParams{StandupID: s.ID, UserID: p.UserID}); err != nil {
+				return err
+			}
+		}
+	}
+	for id := range want {
+		if err := h.Queries.AddStandupParticipant(ctx, db.AddStandupParticipantParams{StandupID: s.ID, UserID: id}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// standupOwnerAccess loads the standup from :id and requires loop ownership
+func (h *Handler) standupOwnerAccess(c *gin.Context) (db.Standup, bool) {
+	s, uid, ok := h.standupMemberAccess(c)
+	if !ok {
+		return s, false
+	}
+	project, err := h.Loops.ByID(c, s.ProjectID)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return s, false
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can manage standups")
+		return s, false
+	}
+	return s, true
+}
+
+// standupMemberAccess loads the standup from :id and checks loop membership
+func (h *Handler) standupMemberAccess(c *gin.Context) (db.Standup, pgtype.UUID, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Standup{}, uid, false
+	}
+	standupID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid standup id")
+		return db.Standup{}, uid, false
+	}
+	s, err := h.Queries.GetStandupByID(c, standupID)
+	if err != nil {
+		problem.Respond(c, 404, "standup not found")
+		return db.Standup{}, uid, false
+	}
+	if !h.Members.IsMember(c, uid, s.ProjectID) {
+		problem.Respond(c, 403, "not a member")
+		return db.Standup{}, uid, false
+	}
+	return s, uid, true
+}
+
+// HandleGetStandups lists a loop's standups
+func (h *Handler) HandleGetStandups(c *gin.Context) {
+	project, _, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	standups, err := h.Queries.GetStandupsByProject(c, project.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get standups")
+		return
+	}
+
+	result := make([]StandupResponse, 0, len(standups))
+	for _, s := range standups {
+		result = append(result, h.standupToResponse(c, s))
+	}
+	c.JSON(200, result)
+}
+
+// HandleCreateStandup configures a new standup (owner only)
+func (h *Handler) HandleCreateStandup(c *gin.Context) {
+	var req StandupRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	project, uid, ok := h.loopMemberAccess(c)
+	if !ok {
+		return
+	}
+	if project.OwnerID != uid {
+		problem.Respond(c, 403, "only loop owner can manage standups")
+		return
+	}
+
+	p, err := h.validateStandupRequest(c, req, project.ID, h.userTimezone(c, uid).String())
+	if err != nil {
+		problem.Respond(c, 400, err.Error())
+		return
+	}
+
+	s, err := h.Queries.CreateStandup(c, db.CreateStandupParams{
+		ProjectID:      project.ID,
+		ChannelID:      p.ChannelID,
+		Name:           p.Name,
+		Questions:      p.Questions,
+		PromptTime:     p.PromptTime,
+		Timezone:       p.Timezone,
+		Weekdays:       p.Weekdays,
+		CollectMinutes: p.CollectMinutes,
+		CreatedBy:      uid,
+	})
+	if err != nil {
+		log.Printf("[standup] CreateStandup failed: %v", err)
+		problem.Respond(c, 500, "failed to create standup")
+		return
+	}
+
+	if len(req.ParticipantIDs) > 0 {
+		if err := h.setStandupParticipants(c, s, req.ParticipantIDs); err != nil {
+			problem.Respond(c, 400, err.Error())
+			return
+		}
+	}
+	h.scheduleStandupPrompt(c, s, time.Now())
+
+	c.JSON(201, h.standupToResponse(c, s))
+}
+
+// HandleUpdateStandup replaces a standup's configuration (owner only)
+func (h *Handler) HandleUpdateStandup(c *gin.Context) {
+	var req StandupRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	s, ok := h.standupOwnerAccess(c)
+	if !ok {
+		return
+	}
+
+	p, err := h.validateStandupRequest(c, req, s.ProjectID, s.Timezone)
+	if err != nil {
+		problem.Respond(c, 400, err.Error())
+		return
+	}
+	p.ID = s.ID
+
+	updated, err := h.Queries.UpdateStandup(c, p)
+	if err != nil {
+		problem.Respond(c, 500, "failed to update standup")
+		return
+	}
+	if req.ParticipantIDs != nil {
+		if err := h.setStandupParticipants(c, updated, req.ParticipantIDs); err != nil {
+			problem.Respond(c, 400, err.Error())
+			return
+		}
+	}
+	// Prompts queued for the previous version see a different updated_at and skip
+	h.scheduleStandupPrompt(c, updated, time.Now())
+
+	c.JSON(200, h.standupToResponse(c, updated))
+}
+
+// HandleDeleteStandup removes a standup and its history (owner only)
+func (h *Handler) HandleDeleteStandup(c *gin.Context) {
+	s, ok := h.standupOwnerAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Queries.DeleteStandup(c, s.ID); err != nil {
+		problem.Respond(c, 500, "failed to delete standup")
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleJoinStandup opts the caller into a standup
+func (h *Handler) HandleJoinStandup(c *gin.Context) {
+	s, uid, ok := h.standupMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Queries.AddStandupParticipant(c, db.AddStandupParticipantParams{StandupID: s.ID, UserID: uid}); err != nil {
+		problem.Respond(c, 500, "failed to join standup")
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleLeaveStandup opts the caller out of a standup
+func (h *Handler) HandleLeaveStandup(c *gin.Context) {
+	s, uid, ok := h.standupMemberAccess(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Queries.RemoveStandupParticipant(c, db.RemoveStandupParticipantParams{StandupID: s.ID, UserID: uid}); err != nil {
+		problem.Respond(c, 500, "failed to leave standup")
+		return
+	}
+	c.JSON(200, gin.H{"success": true})
+}
+
+// HandleStandupRespond records the caller's answers for the standup's open run
+func (h *Handler) HandleStandupRespond(c *gin.Context) {
+	var req StandupAnswersRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	s, uid, ok := h.standupMemberAccess(c)
+	if !ok {
+		return
+	}
+	if len(req.Answers) > len(s.Questions) {
+		problem.Respond(c, 400, "more answers than questions")
+		return
+	}
+
+	participants, err := h.Queries.GetStandupParticipants(c, s.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load participants")
+		return
+	}
+	isParticipant := false
+	for _, p := range participants {
+		if p.UserID == uid {
+			isParticipant = true
+			break
+		}
+	}
+	if !isParticipant {
+		problem.Respond(c, 403, "you are not a participant of this standup")
+		return
+	}
+
+	run, err := h.Queries.GetOpenStandupRun(c, s.ID)
+	if err != nil {
+		problem.Respond(c, 409, "this standup is not collecting answers right now")
+		return
+	}
+
+	if err := h.Queries.UpsertStandupResponse(c, db.UpsertStandupResponseParams{
+		RunID:   run.ID,
+		UserID:  uid,
+		Answers: req.Answers,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to save answers")
+		return
+	}
+	c.JSON(200, gin.H{"success": true, "closes_at": utils.FormatTime(run.ClosesAt.Time)})
+}
+
+// handleBotReply treats a message in the bot DM as an answer to the newest open standup
+func (h *Handler) handleBotReply(ctx context.Context, uid pgtype.UUID, content string) {
+	runs, err := h.Queries.GetOpenStandupRunsForUser(ctx, uid)
+	if err != nil {
+		log.Printf("[standup] failed to load open runs: %v", err)
+		return
+	}
+
+	reply := "There's no standup waiting for your answers right now."
+	if len(runs) > 0 {
+		run := runs[0]
+		if err := h.Queries.UpsertStandupResponse(ctx, db.UpsertStandupResponseParams{
+			RunID:   run.ID,
+			UserID:  uid,
+			Answers: splitAnswers(content, len(run.Questions)),
+		}); err != nil {
+			log.Printf("[standup] failed to save DM answers: %v", err)
+			reply = "Sorry, I couldn't save that. Please try again."
+		} else {
+			reply = fmt.Sprintf("Thanks! Your answers for **%s** are in. Send another message before %s to replace them.",
+				run.Name, run.ClosesAt.Time.UTC().Format("15:04 MST"))
+		}
+	}
+
+	if err := h.sendBotDM(ctx, uid, reply); err != nil {
+		log.Printf("[standup] failed to reply in bot DM: %v", err)
+	}
+}
+
+// ============================================================================
+// Jobs
+// ============================================================================
+
+// scheduleStandupPrompt queues the next prompt after `after`, if enabled
+func (h *Handler) scheduleStandupPrompt(ctx context.Context, s db.Standup, after time.Time) {
+	if h.Jobs == nil || !s.Enabled {
+		return
+	}
+	next, ok := nextStandupTime(s, after)
+	if !ok {
+		return
+	}
+	if _, err := h.Jobs.Enqueue(ctx, jobStandupPrompt, standupPromptPayload{
+		StandupID: utils.UUIDToStr(s.ID),
+		Version:   s.UpdatedAt.Time.UnixNano(),
+	}, next); err != nil {
+		log.Printf("[standup] failed to schedule prompt for %s: %v", utils.UUIDToStr(s.ID), err)
+	}
+}
+
+// runStandupPrompt opens a run, DMs every participant, and queues the summary and next prompt
+func (h *Handler) runStandupPrompt(ctx context.Context, raw json.RawMessage) error {
+	var p standupPromptPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	standupID, err := utils.StrToUUID(p.StandupID)
+	if err != nil {
+		return fmt.Errorf("bad standup id: %w", err)
+	}
+
+	s, err := h.Queries.GetStandupByID(ctx, standupID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // deleted
+	}
+	if err != nil {
+		return err
+	}
+	if s.UpdatedAt.Time.UnixNano() != p.Version || !s.Enabled {
+		return nil // reconfigured or disabled since scheduling
+	}
+
+	// Queue the next prompt up front; failures below are logged rather than
+	// retried so a retry can't queue it twice
+	h.scheduleStandupPrompt(ctx, s, time.Now())
+
+	participants, err := h.Queries.GetStandupParticipants(ctx, s.ID)
+	if err != nil {
+		log.Printf("[standup] failed to load participants for %s: %v", s.Name, err)
+		return nil
+	}
+	if len(participants) == 0 {
+		return nil
+	}
+
+	closesAt := time.Now().Add(time.Duration(s.CollectMinutes) * time.Minute)
+	run, err := h.Queries.CreateStandupRun(ctx, db.CreateStandupRunParams{
+		StandupID: s.ID,
+		ClosesAt:  pgtype.Timestamptz{Time: closesAt, Valid: true},
+	})
+	if err != nil {
+		log.Printf("[standup] failed to open run for %s: %v", s.Name, err)
+		return nil
+	}
+
+	loopName := ""
+	if project, err := h.Loops.ByID(ctx, s.ProjectID); err == nil {
+		loopName = project.Name
+	}
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Time for **%s** in %s! Reply here with one answer per line:\n", s.Name, loopName)
+	for i, q := range s.Questions {
+		fmt.Fprintf(&prompt, "%d. %s\n", i+1, q)
+	}
+	fmt.Fprintf(&prompt, "Answers close at %s.", closesAt.UTC().Format("15:04 MST"))
+
+	for _, participant := range participants {
+		if err := h.sendBotDM(ctx, participant.UserID, prompt.String()); err != nil {
+			log.Printf("[standup] failed to prompt %s: %v", participant.Username, err)
+		}
+	}
+
+	if _, err := h.Jobs.Enqueue(ctx, jobStandupSummary, standupSummaryPayload{
+		RunID: utils.UUIDToStr(run.ID),
+	}, closesAt); err != nil {
+		log.Printf("[standup] failed to schedule summary for run %s: %v", utils.UUIDToStr(run.ID), err)
+	}
+	return nil
+}
+
+// runStandupSummary compiles a run's answers and posts them to the standup channel
+func (h *Handler) runStandupSummary(ctx context.Context, raw json.RawMessage) error {
+	var p standupSummaryPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	runID, err := utils.StrToUUID(p.RunID)
+	if err != nil {
+		return fmt.Errorf("bad run id: %w", err)
+	}
+
+	run, err := h.Queries.GetStandupRunByID(ctx, runID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // standup deleted
+	}
+	if err != nil {
+		return err
+	}
+	if run.Status != "collecting" {
+		return nil
+	}
+	s, err := h.Queries.GetStandupByID(ctx, run.StandupID)
+	if err != nil {
+		return err
+	}
+
+	responses, err := h.Queries.GetStandupResponses(ctx, run.ID)
+	if err != nil {
+		return err
+	}
+	participants, err := h.Queries.GetStandupParticipants(ctx, s.ID)
+	if err != nil {
+		return err
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "**%s** — %s\n", s.Name, run.StartedAt.Time.UTC().Format("Mon Jan 2"))
+	answered := make(map[pgtype.UUID]bool, len(responses))
+	for _, r := range responses {
+		answered[r.UserID] = true
+		fmt.Fprintf(&summary, "\n**@%s**\n", r.Username)
+		for i, a := range r.Answers {
+			q := ""
+			if i < len(s.Questions) {
+				q = s.Questions[i] + ": "
+			}
+			fmt.Fprintf(&summary, "• %s%s\n", q, a)
+		}
+	}
+	var missing []string
+	for _, participant := range participants {
+		if !answered[participant.UserID] {
+			missing = append(missing, "@"+participant.Username)
+		}
+	}
+	if len(responses) == 0 {
+		summary.WriteString("\nNo answers this time.\n")
+	}
+	if len(missing) > 0 {
+		fmt.Fprintf(&summary, "\n_No update from %s_", strings.Join(missing, ", "))
+	}
+
+	// Summaries are posted on behalf of the standup's creator (or the loop owner)
+	posterID := s.CreatedBy
+	if !posterID.Valid {
+		project, err := h.Loops.ByID(ctx, s.ProjectID)
+		if err != nil {
+			return err
+		}
+		posterID = project.OwnerID
+	}
+	poster, err := h.getUserByID(ctx, posterID)
+	if err != nil {
+		return err
+	}
+
+	content := strings.TrimRight(summary.String(), "\n")
+	msgID := utils.GetMessageId()
+	seq := h.nextSeq(ctx, s.ChannelID, pgtype.Int8{})
+	if err := h.Queries.AddMessage(ctx, db.AddMessageParams{
+		ID:        msgID,
+		SenderID:  poster.ID,
+		Content:   content,
+		ProjectID: s.ProjectID,
+		ChannelID: s.ChannelID,
+		Seq:       seq,
+	}); err != nil {
+		return err
+	}
+	if err := h.Queries.MarkStandupRunPosted(ctx, run.ID); err != nil {
+		log.Printf("[standup] failed to mark run %s posted: %v", p.RunID, err)
+	}
+
+	channelID := utils.UUIDToStr(s.ChannelID)
+	now := time.Now()
+	h.Events.PublishChannel(channelID, events.Of(events.Message, MessageResponse{
+		ID:             strconv.FormatInt(msgID, 10),
+		Content:        content,
+		SenderID:       utils.UUIDToStr(poster.ID),
+		SenderUsername: poster.Username,
+		SenderAvatar:   mediaURL(poster.AvatarUrl.String),
+		CreatedAt:      utils.FormatTime(now),
+		CreatedAtMs:    utils.EpochMillis(now),
+		ChannelID:      channelID,
+		Seq:            seq.Int64,
+	}))
+	h.dispatchIntegrations(ctx, s.ProjectID, integrations.Event{
+		Topic:     integrations.TopicStandupSummary,
+		ChannelID: channelID,
+		Text:      content,
+		Data:      map[string]any{"standup": s.Name, "responses": len(responses), "missing": missing},
+	})
+	return nil
+}
@@ -0,0 +1,451 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type TaskResponse struct {
+	ID               string  `json:"id"`
+	ChannelID        string  `json:"channel_id"`
+	MessageID        *string `json:"message_id,omitempty"`
+	Title            string  `json:"title"`
+	Status           string  `json:"status"`
+	AssigneeID       *string `json:"assignee_id,omitempty"`
+	AssigneeUsername string  `json:"assignee_username,omitempty"`
+	AssigneeAvatar   string  `json:"assignee_avatar,omitempty"`
+	DueAt            *string `json:"due_at,omitempty"`
+	IssueNumber      *int    `json:"issue_number,omitempty"`
+	CreatedAt        string  `json:"created_at"`
+}
+
+type CreateTaskRequest struct {
+	Title      string `json:"title" binding:"max=200"`              // defaults to the message content
+	AssigneeID string `json:"assignee_id" binding:"omitempty,uuid"` // must be a loop member
+	DueAt      string `json:"due_at" binding:"omitempty,rfc3339"`
+}
+
+func taskToResponse(t db.Task) TaskResponse {
+	resp := TaskResponse{
+		ID:        utils.UUIDToStr(t.ID),
+		ChannelID: utils.UUIDToStr(t.ChannelID),
+		Title:     t.Title,
+		Status:    t.Status,
+		CreatedAt: utils.FormatTime(t.CreatedAt.Time),
+	}
+	if t.MessageID.Valid {
+		s := strconv.FormatInt(t.MessageID.Int64, 10)
+		resp.MessageID = &s
+	}
+	if t.AssigneeID.Valid {
+		s := utils.UUIDToStr(t.AssigneeID)
+		resp.AssigneeID = &s
+	}
+	if t.DueAt.Valid {
+		s := utils.FormatTime(t.DueAt.Time)
+		resp.DueAt = &s
+	}
+	if t.GithubIssueNumber.Valid {
+		n := int(t.GithubIssueNumber.Int32)
+		resp.IssueNumber = &n
+	}
+	return resp
+}
+
+// taskAccess loads the task from :id and checks the caller is a loop member
+func (h *Handler) taskAccess(c *gin.Context) (db.Task, pgtype.UUID, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Task{}, uid, false
+	}
+	taskID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid task id")
+		return db.Task{}, uid, false
+	}
+	task, err := h.Queries.GetTaskByID(c, taskID)
+	if err != nil {
+		problem.Respond(c, 404, "task not found")
+		return db.Task{}, uid, false
+	}
+	if !h.Members.IsMember(c, uid, task.ProjectID) {
+		problem.Respond(c, 403, "not a member")
+		return db.Task{}, uid, false
+	}
+	return task, uid, true
+}
+
+// HandleCreateTaskFromMessage turns a chat message into a task
+func (h *Handler) HandleCreateTaskFromMessage(c *gin.Context) {
+	messageID, err := strconv.ParseInt(c.Param("message_id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid message id")
+		return
+	}
+
+	var req CreateTaskRequest
+	if c.Request.ContentLength > 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	msg, err := h.Queries.GetMessageByID(ctx, messageID)
+	if err != nil || msg.IsDeleted.Bool {
+		problem.Respond(c, 404, "message not found")
+		return
+	}
+
+	// Messages from before channels existed have none to put the task in
+	if !msg.ChannelID.Valid {
+		problem.Respond(c, 400, "message is not in a channel")
+		return
+	}
+	if !h.Members.CanUseChannel(ctx, h.Members.Role(ctx, uid, msg.ProjectID), msg.ProjectID, msg.ChannelID) {
+		problem.Respond(c, 403, "not a member of this channel")
+		return
+	}
+
+	title := strings.TrimSpace(req.Title)
+	if title == "" {
+		title = strings.TrimSpace(msg.Content)
+	}
+	if utf8.RuneCountInString(title) > 200 {
+		title = string([]rune(title)[:200]) + "..."
+	}
+
+	var assignee pgtype.UUID
+	if req.AssigneeID != "" {
+		assignee, _ = utils.StrToUUID(req.AssigneeID)
+		if !h.Members.IsMember(ctx, assignee, msg.ProjectID) {
+			problem.Respond(c, 400, "assignee is not a member of this loop")
+			return
+		}
+	}
+
+	var dueAt pgtype.Timestamptz
+	if req.DueAt != "" {
+		t, _ := time.Parse(time.RFC3339, req.DueAt)
+		dueAt = pgtype.Timestamptz{Time: t, Valid: true}
+	}
+
+	task, err := h.Queries.CreateTask(ctx, db.CreateTaskParams{
+		ProjectID:  msg.ProjectID,
+		ChannelID:  msg.ChannelID,
+		MessageID:  pgtype.Int8{Int64: messageID, Valid: true},
+		Title:      title,
+		AssigneeID: assignee,
+		DueAt:      dueAt,
+		CreatedBy:  uid,
+	})
+	if err != nil {
+		log.Printf("[tasks] CreateTask failed: %v", err)
+		problem.Respond(c, 500, "failed to create task")
+		return
+	}
+
+	resp := taskToResponse(task)
+	user, _ := h.getUserByID(ctx, uid)
+	if assignee.Valid {
+		if a, err := h.getUserByID(ctx, assignee); err == nil {
+			resp.AssigneeUsername = a.Username
+			resp.AssigneeAvatar = mediaURL(a.AvatarUrl.String)
+		}
+		if assignee != uid {
+			h.notifyTaskAssigned(c, task, user)
+		}
+	}
+
+	channelID := utils.UUIDToStr(msg.ChannelID)
+	h.Events.PublishChannel(channelID, events.Of(events.TaskCreated, resp))
+
+	c.JSON(201, resp)
+}
+
+// notifyTaskAssigned records and pushes a notification to the task's assignee
+func (h *Handler) notifyTaskAssigned(ctx context.Context, task db.Task, actor db.User) {
+	if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+		Type:          "task_assigned",
+		UserID:        task.AssigneeID,
+		ActorID:       actor.ID,
+		ActorUsername: actor.Username,
+		ProjectID:     task.ProjectID,
+		ChannelID:     task.ChannelID,
+		MessageID:     task.MessageID,
+		Preview:       task.Title,
+	}); err != nil {
+		log.Printf("[tasks] failed to create assignment notification: %v", err)
+	}
+}
+
+// HandleGetChannelTasks lists open tasks in a channel, soonest due first
+func (h *Handler) HandleGetChannelTasks(c *gin.Context) {
+	channelID, err := utils.StrToUUID(c.Param("id"))
+	if err != nil {
+		problem.Respond(c, 400, "invalid channel id")
+		return
+	}
+
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	channel, err := h.Queries.GetChannelByID(ctx, channelID)
+	if err != nil {
+		problem.Respond(c, 404, "channel not found")
+		return
+	}
+
+	if !h.Members.CanUseChannel(ctx, h.Members.Role(ctx, uid, channel.ProjectID), channel.ProjectID, channelID) {
+		problem.Respond(c, 403, "not a member of this channel")
+		return
+	}
+
+	tasks, err := h.Queries.GetOpenTasksByChannel(ctx, channelID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get tasks")
+		return
+	}
+
+	result := make([]TaskResponse, 0, len(tasks))
+	for _, t := range tasks {
+		resp := taskToResponse(db.Task{
+			ID:                t.ID,
+			ChannelID:         channelID,
+			MessageID:         t.MessageID,
+			Title:             t.Title,
+			AssigneeID:        t.AssigneeID,
+			DueAt:             t.DueAt,
+			Status:            "open",
+			GithubIssueNumber: t.GithubIssueNumber,
+			CreatedAt:         t.CreatedAt,
+		})
+		resp.AssigneeUsername = t.AssigneeUsername.String
+		resp.AssigneeAvatar = mediaURL(t.AssigneeAvatar.String)
+		result = append(result, resp)
+	}
+
+	c.JSON(200, result)
+}
+
+// HandleCompleteTask marks a task done
+func (h *Handler) HandleCompleteTask(c *gin.Context) {
+	task, _, ok := h.taskAccess(c)
+	if !ok {
+		return
+	}
+	if task.Status == "done" {
+		c.JSON(200, taskToResponse(task))
+		return
+	}
+
+	done, err := h.Queries.CompleteTask(c, task.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to update task")
+		return
+	}
+
+	channelID := utils.UUIDToStr(task.ChannelID)
+	h.Events.PublishChannel(channelID, events.TaskRef{TaskID: utils.UUIDToStr(task.ID)})
+
+	c.JSON(200, taskToResponse(done))
+}
+
+// HandleEscalateTask opens a GitHub issue for a task in the loop's linked repo
+func (h *Handler) HandleEscalateTask(c *gin.Context) {
+	task, uid, ok := h.taskAccess(c)
+	if !ok {
+		return
+	}
+	if task.GithubIssueNumber.Valid {
+		problem.RespondCode(c, 409, "conflict", "task already has a GitHub issue", gin.H{"issue_number": task.GithubIssueNumber.Int32})
+		return
+	}
+
+	project, err := h.Loops.ByID(c, task.ProjectID)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+	if h.rejectGuest(c, uid, project.ID) {
+		return
+	}
+
+	user, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	repoFullName, ok := h.repoForWrite(c, project, user.AccessToken)
+	if !ok {
+		return
+	}
+
+	body := fmt.Sprintf("Created from a Wireloop task in **%s** by @%s.", project.Name, user.Username)
+	if task.MessageID.Valid {
+		if msg, err := h.Queries.GetMessageByID(c, task.MessageID.Int64); err == nil && !msg.IsDeleted.Bool {
+			body = "> " + strings.ReplaceAll(msg.Content, "\n", "\n> ") + "\n\n" + body
+		}
+	}
+
+	issue, err := github.Default.CreateIssue(c, user.AccessToken, repoFullName, task.Title, body)
+	if err != nil {
+		log.Printf("[tasks] escalate failed: %v", err)
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
+		return
+	}
+
+	if err := h.Queries.SetTaskGithubIssue(c, db.SetTaskGithubIssueParams{
+		ID:                task.ID,
+		GithubIssueNumber: pgtype.Int4{Int32: int32(issue.Number), Valid: true},
+	}); err != nil {
+		// The issue exists on GitHub; report it even though the link wasn't saved
+		log.Printf("[tasks] failed to save issue #%d for task %s: %v", issue.Number, utils.UUIDToStr(task.ID), err)
+	}
+
+	channelID := utils.UUIDToStr(task.ChannelID)
+	h.Events.PublishChannel(channelID, events.TaskEscalation{
+		TaskID:      utils.UUIDToStr(task.ID),
+		IssueNumber: issue.Number,
+		HTMLURL:     issue.HTMLURL,
+	})
+
+	c.JSON(200, gin.H{"issue_number": issue.Number, "html_url": issue.HTMLURL})
+}
+
+// ============================================================================
+// ASSIGNING FROM GITHUB
+// A comment on the linked repo with "@wireloop assign @ana @ben" creates a
+// task for each named member, in the channel bound to the issue or PR (or
+// the loop's default channel), and notifies them. Only loop members can
+// assign, so strangers on a public repo can't notify anyone.
+// ============================================================================
+
+// assignCommandRegex matches the command at the start of a line
+var assignCommandRegex = regexp.MustCompile(`(?im)^[ \t]*@wireloop[ \t]+assign((?:[ \t,]+@[a-zA-Z0-9_-]+)+)`)
+
+// parseAssignCommand returns the usernames the comment assigns, in order and
+// without duplicates. Quoted lines are skipped, so replies don't repeat it.
+func parseAssignCommand(body string) []string {
+	var names []string
+	for _, m := range assignCommandRegex.FindAllStringSubmatch(body, -1) {
+		for _, mention := range mentionRegex.FindAllStringSubmatch(m[1], -1) {
+			if !slices.Contains(names, mention[1]) {
+				names = append(names, mention[1])
+			}
+		}
+	}
+	return names
+}
+
+// assignFromGitHubComment runs the assign command in a new comment on item
+// number of project's repo
+func (h *Handler) assignFromGitHubComment(ctx context.Context, project db.Project, number int, title string, comment github.Comment) error {
+	names := parseAssignCommand(comment.Body)
+	if len(names) == 0 {
+		return nil
+	}
+	actor, err := h.Queries.GetUserByGithubID(ctx, comment.User.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !h.Members.IsMember(ctx, actor.ID, project.ID) {
+		log.Printf("[tasks] ignoring assign by @%s on %s#%d: not a member", comment.User.Login, project.Name, number)
+		return nil
+	}
+
+	channelID, err := h.githubItemChannel(ctx, project, number)
+	if err != nil || !channelID.Valid {
+		return err
+	}
+	for _, name := range names {
+		assignee, err := h.Queries.GetUserByUsername(ctx, name)
+		if err != nil || !h.Members.IsMember(ctx, assignee.ID, project.ID) {
+			log.Printf("[tasks] can't assign %s#%d to @%s: not a member", project.Name, number, name)
+			continue
+		}
+		task, err := h.Queries.CreateTask(ctx, db.CreateTaskParams{
+			ProjectID:  project.ID,
+			ChannelID:  channelID,
+			Title:      fmt.Sprintf("#%d %s", number, title),
+			AssigneeID: assignee.ID,
+			CreatedBy:  actor.ID,
+		})
+		if err != nil {
+			return err
+		}
+		task.GithubIssueNumber = pgtype.Int4{Int32: int32(number), Valid: true}
+		if err := h.Queries.SetTaskGithubIssue(ctx, db.SetTaskGithubIssueParams{
+			ID:                task.ID,
+			GithubIssueNumber: task.GithubIssueNumber,
+		}); err != nil {
+			return err
+		}
+
+		resp := taskToResponse(task)
+		resp.AssigneeUsername = assignee.Username
+		resp.AssigneeAvatar = mediaURL(assignee.AvatarUrl.String)
+		h.Events.PublishChannel(utils.UUIDToStr(channelID), events.Of(events.TaskCreated, resp))
+		if assignee.ID != actor.ID {
+			h.notifyTaskAssigned(ctx, task, actor)
+		}
+	}
+	return nil
+}
+
+// githubItemChannel is where tasks about item number go: its first open
+// bound channel, else the loop's default channel. Invalid when there is none.
+func (h *Handler) githubItemChannel(ctx context.Context, project db.Project, number int) (pgtype.UUID, error) {
+	links, err := h.Queries.GetActiveChannelGithubLinks(ctx, db.GetActiveChannelGithubLinksParams{
+		ProjectID:    project.ID,
+		GithubNumber: int32(number),
+	})
+	if err != nil {
+		return pgtype.UUID{}, err
+	}
+	if len(links) > 0 {
+		return links[0].ChannelID, nil
+	}
+	channel, err := h.Queries.GetDefaultChannel(ctx, project.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return pgtype.UUID{}, nil
+	}
+	return channel.ID, err
+}
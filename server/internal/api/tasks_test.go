@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/members"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestParseAssignCommand(t *testing.T) {
+	cases := []struct {
+		body string
+		want []string
+	}{
+		{"@wireloop assign @ana", []string{"ana"}},
+		{"Looks good.\n  @Wireloop assign @ana, @ben-c @ana\nthanks", []string{"ana", "ben-c"}},
+		{"> @wireloop assign @ana\nquoted only", nil},
+		{"please @wireloop assign @ana", nil},
+		{"@wireloop assign", nil},
+	}
+	for _, tc := range cases {
+		if got := parseAssignCommand(tc.body); !slices.Equal(got, tc.want) {
+			t.Errorf("parseAssignCommand(%q) = %v, want %v", tc.body, got, tc.want)
+		}
+	}
+}
+
+func TestGuestTasksConfinedToGuestChannels(t *testing.T) {
+	it := newIntegration(t)
+	ctx := context.Background()
+	owner := it.user("maintainer", 1)
+	guest := it.user("visitor", 2)
+	repo := it.gh.AddRepo("maintainer", "app", false)
+	project := it.loop("app", repo, owner, nil)
+	if err := it.h.Queries.AddMembership(ctx, db.AddMembershipParams{
+		UserID:    guest.ID,
+		ProjectID: project.ID,
+		Role:      pgtype.Text{String: members.Guest, Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	channel := func(name string) db.Channel {
+		ch, err := it.h.Queries.CreateChannel(ctx, db.CreateChannelParams{ProjectID: project.ID, Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ch
+	}
+	open, private := channel("guests"), channel("core")
+	if _, err := it.h.Queries.UpsertLoopSettings(ctx, db.UpsertLoopSettingsParams{
+		ProjectID:          project.ID,
+		Visibility:         loopVisibilityMembers,
+		PublicChannelIds:   []pgtype.UUID{},
+		GuestChannelIds:    []pgtype.UUID{open.ID},
+		PinRole:            "members",
+		PinLimit:           50,
+		DefaultNotifyLevel: "mentions",
+		ThreadSummaries:    "offer",
+		AccessRevoked:      accessRevokedNotify,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i, ch := range []db.Channel{open, private} {
+		if err := it.h.Queries.AddMessage(ctx, db.AddMessageParams{
+			ID:        int64(1000 + i),
+			ProjectID: project.ID,
+			ChannelID: ch.ID,
+			SenderID:  owner.ID,
+			Content:   "Ship the " + ch.Name + " release",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name    string
+		channel db.Channel
+		msgID   int64
+		status  int
+	}{
+		{"guest channel", open, 1000, http.StatusOK},
+		{"other channel", private, 1001, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			create := it.do(it.h.HandleCreateTaskFromMessage, http.MethodPost, "/api/messages/:message_id/task",
+				"/api/messages/"+strconv.FormatInt(tc.msgID, 10)+"/task", guest.ID, nil, nil)
+			want := tc.status
+			if want == http.StatusOK {
+				want = http.StatusCreated
+			}
+			if create != want {
+				t.Errorf("create task: status = %d, want %d", create, want)
+			}
+			list := it.do(it.h.HandleGetChannelTasks, http.MethodGet, "/api/channels/:id/tasks",
+				"/api/channels/"+utils.UUIDToStr(tc.channel.ID)+"/tasks", guest.ID, nil, nil)
+			if list != tc.status {
+				t.Errorf("list tasks: status = %d, want %d", list, tc.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// THREAD EXPORT
+// Copies a thread (the parent message and its replies) to the loop's repo as
+// an issue or discussion comment, posted with the caller's token, so a
+// decision made in chat ends up in the repo's record.
+// ============================================================================
+
+const (
+	// GitHub rejects comment bodies longer than this
+	maxGitHubCommentLen = 65536
+	// Replies taken from one thread
+	maxExportedReplies = 500
+)
+
+type ExportThreadRequest struct {
+	Target string `json:"target" binding:"required,oneof=issue discussion"`
+	Number int    `json:"number" binding:"required,min=1"`
+}
+
+// exportedMessage is one message of a thread as it is written out
+type exportedMessage struct {
+	Username  string
+	Content   string
+	CreatedAt time.Time
+}
+
+// formatThreadExport renders a thread as markdown, one attributed section
+// per message. Replies that don't fit in a comment are cut off with a note.
+func formatThreadExport(loop, channel, exporter string, msgs []exportedMessage) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Thread from **#%s** in **%s** on Wireloop, exported by @%s.\n\n---\n", channel, loop, exporter)
+
+	const omittedNote = "\n_%d more replies not included._\n"
+	for i, m := range msgs {
+		section := fmt.Sprintf("\n**@%s** · %s\n\n%s\n", m.Username, m.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"), m.Content)
+		if b.Len()+len(section)+len(omittedNote)+10 > maxGitHubCommentLen {
+			if i == 0 {
+				// The parent alone is too long; keep as much of it as fits
+				section = strings.ToValidUTF8(section[:maxGitHubCommentLen-b.Len()-len(omittedNote)-20], "") + "…\n"
+			} else {
+				fmt.Fprintf(&b, omittedNote, len(msgs)-i)
+				break
+			}
+		}
+		b.WriteString(section)
+	}
+	return b.String()
+}
+
+// HandleExportThread posts the thread :message_id belongs to as a comment
+// on issue or discussion #number of the loop's repo
+func (h *Handler) HandleExportThread(c *gin.Context) {
+	messageID, err := strconv.ParseInt(c.Param("message_id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid message id")
+		return
+	}
+	var req ExportThreadRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	parent, err := h.Queries.GetMessageByID(c, messageID)
+	if err != nil {
+		problem.Respond(c, 404, "message not found")
+		return
+	}
+	// Exporting a reply exports its whole thread
+	if parent.ParentID.Valid {
+		if parent, err = h.Queries.GetMessageByID(c, parent.ParentID.Int64); err != nil {
+			problem.Respond(c, 404, "message not found")
+			return
+		}
+	}
+	if parent.IsDeleted.Bool {
+		problem.Respond(c, 404, "message not found")
+		return
+	}
+	if !h.Members.CanUseChannel(c, h.Members.Role(c, uid, parent.ProjectID), parent.ProjectID, parent.ChannelID) {
+		problem.Respond(c, 403, "not a member of this channel")
+		return
+	}
+	if h.rejectGuest(c, uid, parent.ProjectID) {
+		return
+	}
+	if !h.channelPolicy(c, parent.ChannelID).Exports {
+		problem.RespondCode(c, 403, "channel_export_disabled", "this channel's messages can't be exported", nil)
+		return
+	}
+
+	project, err := h.Loops.ByID(c, parent.ProjectID)
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+	channel, err := h.Queries.GetChannelByID(c, parent.ChannelID)
+	if err != nil {
+		problem.Respond(c, 404, "channel not found")
+		return
+	}
+	user, err := h.getUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	if user.AccessToken == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
+		return
+	}
+	repoFullName, ok := h.repoForWrite(c, project, user.AccessToken)
+	if !ok {
+		return
+	}
+
+	replies, err := h.Queries.GetThreadReplies(c, db.GetThreadRepliesParams{
+		ParentID: pgtype.Int8{Int64: parent.ID, Valid: true},
+		Limit:    maxExportedReplies,
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to get replies")
+		return
+	}
+	msgs := make([]exportedMessage, 0, len(replies)+1)
+	if sender, err := h.getUserByID(c, parent.SenderID); err == nil {
+		msgs = append(msgs, exportedMessage{sender.Username, parent.Content, parent.CreatedAt.Time})
+	}
+	for _, r := range replies {
+		if !r.IsDeleted {
+			msgs = append(msgs, exportedMessage{r.SenderUsername, r.Content, r.CreatedAt.Time})
+		}
+	}
+	body := formatThreadExport(project.Name, channel.Name, user.Username, msgs)
+
+	var htmlURL string
+	switch req.Target {
+	case "issue":
+		var created *github.Comment
+		created, err = github.Default.CreateIssueComment(c, user.AccessToken, repoFullName, req.Number, body)
+		if err == nil {
+			htmlURL = created.HTMLURL
+		}
+	case "discussion":
+		var created *github.DiscussionComment
+		created, err = github.Default.CommentOnDiscussion(c, user.AccessToken, repoFullName, req.Number, body)
+		if err == nil {
+			htmlURL = created.URL
+		}
+	}
+	if err != nil {
+		log.Printf("[thread export] posting thread %d to %s %s#%d failed: %v", parent.ID, req.Target, repoFullName, req.Number, err)
+		reportGitHubError(c.Request, err, "export_thread")
+		respondGitHubError(c, err)
+		return
+	}
+
+	room := utils.UUIDToStr(parent.ChannelID)
+	h.Events.PublishChannel(room, events.ThreadExport{
+		MessageID:  strconv.FormatInt(parent.ID, 10),
+		Target:     req.Target,
+		Number:     req.Number,
+		HTMLURL:    htmlURL,
+		ExportedBy: user.Username,
+	})
+
+	c.JSON(201, gin.H{
+		"target":   req.Target,
+		"number":   req.Number,
+		"html_url": htmlURL,
+		"messages": len(msgs),
+	})
+}
@@ -0,0 +1,309 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/flags"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// ============================================================================
+// THREAD SUMMARIES
+// Threads of threadSummaryMinReplies or more replies can carry an AI summary,
+// stored on the parent message. Each run feeds the previous summary and the
+// replies since, so a long thread costs one small prompt per update. Loops
+// choose (loop_settings.thread_summaries) whether members ask for one
+// (offer), it follows the thread on its own (auto), or neither (off).
+// ============================================================================
+
+const (
+	threadSummariesOff   = "off"
+	threadSummariesOffer = "offer"
+	threadSummariesAuto  = "auto"
+
+	jobThreadSummary = "thread_summary"
+
+	threadSummaryMinReplies = 20
+	// New replies before an auto summary is brought up to date
+	threadSummaryStep = 10
+	// Replies read per run; the rest wait for the next update
+	threadSummaryBatch = 200
+)
+
+type ThreadSummaryResponse struct {
+	Summary   string `json:"summary"`
+	Replies   int    `json:"replies"` // how many replies it covers
+	UpdatedAt string `json:"updated_at"`
+}
+
+type threadSummaryPayload struct {
+	MessageID int64 `json:"message_id"`
+}
+
+// loopThreadSummaries is s's thread summary mode, offer when unset
+func loopThreadSummaries(s db.LoopSetting) string {
+	if s.ThreadSummaries == "" {
+		return threadSummariesOffer
+	}
+	return s.ThreadSummaries
+}
+
+func threadSummaryToResponse(s db.ThreadSummary) *ThreadSummaryResponse {
+	return &ThreadSummaryResponse{
+		Summary:   s.Summary,
+		Replies:   int(s.Replies),
+		UpdatedAt: utils.FormatTime(s.UpdatedAt.Time),
+	}
+}
+
+// attachThreadSummaries fills in the summaries of long threads
+func (h *Handler) attachThreadSummaries(ctx context.Context, msgs []MessageResponse) {
+	var ids []int64
+	index := make(map[int64]int)
+	for i, m := range msgs {
+		if m.Deleted || m.ReplyCount < threadSummaryMinReplies {
+			continue
+		}
+		if id, err := strconv.ParseInt(m.ID, 10, 64); err == nil {
+			ids = append(ids, id)
+			index[id] = i
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	rows, err := h.Queries.ListThreadSummaries(ctx, ids)
+	if err != nil {
+		log.Printf("[thread-summary] lookup failed: %v", err)
+		return
+	}
+	for _, r := range rows {
+		msgs[index[r.MessageID]].ThreadSummary = threadSummaryToResponse(r)
+	}
+}
+
+// queueThreadSummary is called after a reply to parentID; in loops that
+// summarize automatically it queues an update once enough replies are new
+func (h *Handler) queueThreadSummary(ctx context.Context, parentID int64) {
+	parent, err := h.Queries.GetMessageByID(ctx, parentID)
+	if err != nil || int(parent.ReplyCount.Int32) < threadSummaryMinReplies {
+		return
+	}
+	s, err := h.Queries.GetLoopSettings(ctx, parent.ProjectID)
+	if err != nil || s.AiDisabled || loopThreadSummaries(s) != threadSummariesAuto ||
+		!(aiOptions{Content: s.AiContent}).allows(aiContentChat) {
+		return
+	}
+	if !h.Flags.Enabled(ctx, flags.AISummaries, flags.Subject{LoopID: parent.ProjectID}) {
+		return
+	}
+	if !h.channelPolicy(ctx, parent.ChannelID).AISummaries {
+		return
+	}
+	if prev, err := h.Queries.GetThreadSummary(ctx, parentID); err == nil &&
+		parent.ReplyCount.Int32-prev.Replies < threadSummaryStep {
+		return
+	}
+	if _, err := h.Jobs.Enqueue(ctx, jobThreadSummary, threadSummaryPayload{MessageID: parentID}, time.Now()); err != nil {
+		log.Printf("[thread-summary] failed to queue %d: %v", parentID, err)
+	}
+}
+
+// runThreadSummary brings an auto summary up to date. Jobs queued by
+// replies that arrived together find the work done and return.
+func (h *Handler) runThreadSummary(ctx context.Context, raw json.RawMessage) error {
+	var p threadSummaryPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	parent, err := h.Queries.GetMessageByID(ctx, p.MessageID)
+	if errors.Is(err, pgx.ErrNoRows) || parent.IsDeleted.Bool {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if prev, err := h.Queries.GetThreadSummary(ctx, p.MessageID); err == nil &&
+		parent.ReplyCount.Int32-prev.Replies < threadSummaryStep {
+		return nil
+	}
+	_, err = h.summarizeThread(ctx, parent)
+	if errors.Is(err, errAINotConfigured) || errors.Is(err, errAIDisabled) ||
+		errors.Is(err, errAIContentBlocked) || errors.Is(err, errChannelAIBlocked) {
+		return nil
+	}
+	return err
+}
+
+// summarizeThread extends the thread's summary with the replies it doesn't
+// cover yet, stores it and tells the channel
+func (h *Handler) summarizeThread(ctx context.Context, parent db.Message) (db.ThreadSummary, error) {
+	prev, err := h.Queries.GetThreadSummary(ctx, parent.ID)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return prev, err
+	}
+	replies, err := h.Queries.ListThreadRepliesSince(ctx, db.ListThreadRepliesSinceParams{
+		ParentID: parent.ID,
+		After:    prev.LastReplyID,
+		RowLimit: threadSummaryBatch,
+	})
+	if err != nil {
+		return prev, err
+	}
+	if len(replies) == 0 {
+		return prev, nil
+	}
+
+	starter := ""
+	if parent.SenderID.Valid {
+		if u, err := h.getUserByID(ctx, parent.SenderID); err == nil {
+			starter = u.Username
+		}
+	}
+	opts, err := h.loopAI(ctx, parent.ProjectID, 400)
+	if err != nil {
+		return prev, err
+	}
+	if !opts.allows(aiContentChat) {
+		return prev, errAIContentBlocked
+	}
+	if !h.channelPolicy(ctx, parent.ChannelID).AISummaries {
+		return prev, errChannelAIBlocked
+	}
+	summary, err := generateThreadSummary(starter, parent.Content, prev.Summary, replies, opts)
+	if err != nil {
+		return prev, err
+	}
+	s, err := h.Queries.UpsertThreadSummary(ctx, db.UpsertThreadSummaryParams{
+		MessageID:   parent.ID,
+		Summary:     summary,
+		Replies:     prev.Replies + int32(len(replies)),
+		LastReplyID: replies[len(replies)-1].ID,
+	})
+	if err != nil {
+		return prev, err
+	}
+	h.Events.PublishChannel(utils.UUIDToStr(parent.ChannelID), events.ThreadSummary{
+		MessageID: strconv.FormatInt(parent.ID, 10),
+		Summary:   s.Summary,
+		Replies:   int(s.Replies),
+		UpdatedAt: utils.FormatTime(s.UpdatedAt.Time),
+	})
+	return s, nil
+}
+
+// generateThreadSummary asks the model to write, or extend, a summary
+func generateThreadSummary(starter, opening, previous string, replies []db.ListThreadRepliesSinceRow, opts aiOptions) (string, error) {
+	apiKey := os.Getenv("GEMINI_API_KEY")
+	if apiKey == "" {
+		return "", errAINotConfigured
+	}
+	excerpt := func(s string, n int) string {
+		s = strings.TrimSpace(s)
+		if len(s) > n {
+			s = s[:n] + "..."
+		}
+		return s
+	}
+
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Thread started by @%s:\n%s\n", starter, untrusted("message by @"+starter, excerpt(opening, 2000)))
+	if previous != "" {
+		// Written by the model, but from the same untrusted replies
+		fmt.Fprintf(&prompt, "\nSummary of the earlier replies:\n%s\n\nNew replies:\n", untrusted("earlier summary", previous))
+	} else {
+		prompt.WriteString("\nReplies:\n")
+	}
+	for _, r := range replies {
+		prompt.WriteString(untrusted("message by @"+r.Username, excerpt(r.Content, 500)) + "\n\n")
+	}
+
+	system := `You summarize chat threads for a development team.
+When given an earlier summary, return one updated summary that covers it and the new replies.
+
+Format:
+**Summary**: 2-3 sentences on what the thread is about and where it stands.
+**Decisions**: bullet points, if any were made.
+**Open questions**: bullet points, if any remain.
+
+Be concise. Mention people by @username only when it matters who said what.`
+
+	return generateGemini(apiKey, system, prompt.String(), opts)
+}
+
+// HandleSummarizeThread writes or updates the summary of a long thread on
+// request. Any member who can read the channel may ask, unless the loop
+// turned summaries off.
+func (h *Handler) HandleSummarizeThread(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	messageID, err := strconv.ParseInt(c.Param("message_id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid message id")
+		return
+	}
+	parent, err := h.Queries.GetMessageByID(c, messageID)
+	if err != nil || parent.IsDeleted.Bool {
+		problem.Respond(c, 404, "message not found")
+		return
+	}
+	if !h.Members.CanUseChannel(c, h.Members.Role(c, uid, parent.ProjectID), parent.ProjectID, parent.ChannelID) {
+		problem.Respond(c, 403, "not a member")
+		return
+	}
+	if !h.Flags.Enabled(c, flags.AISummaries, flags.Subject{UserID: uid, LoopID: parent.ProjectID}) {
+		problem.Respond(c, 404, "AI summaries are not available for this loop yet")
+		return
+	}
+	s, _ := h.Queries.GetLoopSettings(c, parent.ProjectID)
+	if s.AiDisabled {
+		problem.Respond(c, 403, errAIDisabled.Error())
+		return
+	}
+	if loopThreadSummaries(s) == threadSummariesOff {
+		problem.Respond(c, 403, "thread summaries are turned off in this loop")
+		return
+	}
+	if int(parent.ReplyCount.Int32) < threadSummaryMinReplies {
+		problem.Respond(c, 400, fmt.Sprintf("threads can be summarized from %d replies", threadSummaryMinReplies))
+		return
+	}
+
+	summary, err := h.summarizeThread(c, parent)
+	if errors.Is(err, errAINotConfigured) {
+		problem.Respond(c, 503, "AI summaries are not configured")
+		return
+	}
+	if errors.Is(err, errAIContentBlocked) {
+		problem.Respond(c, 403, "this loop doesn't send chat messages to AI")
+		return
+	}
+	if errors.Is(err, errChannelAIBlocked) {
+		problem.RespondCode(c, 403, "channel_ai_disabled", errChannelAIBlocked.Error(), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("[thread-summary] %d failed: %v", messageID, err)
+		reportAIError(c.Request, err, "thread_summary")
+		problem.Respond(c, 502, "failed to summarize the thread")
+		return
+	}
+	c.JSON(200, threadSummaryToResponse(summary))
+}
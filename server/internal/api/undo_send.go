@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// UNDO SEND
+// A user can have their chat messages held for a few seconds before they go
+// out. A held message has passed the filters but isn't counted, numbered,
+// broadcast or stored yet; a cancel_send frame on the same connection
+// withdraws it. The instance that took the message holds it, and sends
+// whatever it still holds when shutting down.
+// ============================================================================
+
+var (
+	// undoSendCache holds each user's window in seconds; 0 when unset
+	undoSendCache = cache.New[string, int32](lookupTTL, 5000)
+
+	heldMu       sync.Mutex
+	heldMessages = make(map[int64]*heldMessage) // by message ID
+)
+
+// heldMessage is a message waiting out its sender's undo window
+type heldMessage struct {
+	senderID pgtype.UUID
+	timer    *time.Timer
+	send     func()
+}
+
+type UndoSendRequest struct {
+	Seconds *int `json:"seconds" binding:"required,min=0,max=30"`
+}
+
+// undoSendWindow returns how long uid's messages are held; 0 sends at once
+func (h *Handler) undoSendWindow(ctx context.Context, uid pgtype.UUID) time.Duration {
+	seconds, err := undoSendCache.GetOrLoad(utils.UUIDToStr(uid), func() (int32, error) {
+		n, err := h.Queries.GetUndoSendSeconds(ctx, uid)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+		return n, err
+	})
+	if err != nil {
+		// Sending at once is what the user gets without the setting
+		log.Printf("[undo-send] failed to load window: %v", err)
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// holdMessage runs send once window passes unless the sender cancels first,
+// and tells the sender when it goes out
+func holdMessage(client *chat.Client, roomID string, msgID int64, window time.Duration, send func()) {
+	m := &heldMessage{senderID: client.UserID, send: send}
+	heldMu.Lock()
+	heldMessages[msgID] = m
+	m.timer = time.AfterFunc(window, func() {
+		if takeHeldMessage(msgID, m.senderID) != nil {
+			send()
+		}
+	})
+	heldMu.Unlock()
+	client.Send(events.Wrap(events.HeldMessage{
+		MessageID: strconv.FormatInt(msgID, 10),
+		SendAt:    utils.FormatTime(time.Now().Add(window)),
+	}, roomID))
+}
+
+// takeHeldMessage removes msgID from the held messages if senderID sent it.
+// Whoever takes it decides its fate, so a message is sent or cancelled once.
+func takeHeldMessage(msgID int64, senderID pgtype.UUID) *heldMessage {
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	m := heldMessages[msgID]
+	if m == nil || m.senderID != senderID {
+		return nil
+	}
+	delete(heldMessages, msgID)
+	return m
+}
+
+// cancelHeldMessage handles a cancel_send frame
+func cancelHeldMessage(client *chat.Client, roomID, rawID string) {
+	msgID, err := strconv.ParseInt(rawID, 10, 64)
+	var m *heldMessage
+	if err == nil {
+		m = takeHeldMessage(msgID, client.UserID)
+	}
+	if m == nil {
+		client.Send(events.Wrap(events.CommandFailure{Command: "cancel_send", Error: "the message was already sent"}, roomID))
+		return
+	}
+	m.timer.Stop()
+	client.Send(events.Wrap(events.CancelledSend{MessageID: rawID}, roomID))
+}
+
+// FlushHeldMessages sends every held message now, so none is lost when the
+// server shuts down
+func (h *Handler) FlushHeldMessages() {
+	heldMu.Lock()
+	held := heldMessages
+	heldMessages = make(map[int64]*heldMessage)
+	heldMu.Unlock()
+	for _, m := range held {
+		m.timer.Stop()
+		m.send()
+	}
+	if len(held) > 0 {
+		log.Printf("[undo-send] sent %d held messages on shutdown", len(held))
+	}
+}
+
+// HandleGetUndoSend returns the caller's undo send window
+func (h *Handler) HandleGetUndoSend(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	c.JSON(200, gin.H{"seconds": int(h.undoSendWindow(c, uid) / time.Second)})
+}
+
+// HandleUpdateUndoSend sets how many seconds (0-30) the caller's messages
+// are held before they are sent; 0 turns undo send off
+func (h *Handler) HandleUpdateUndoSend(c *gin.Context) {
+	var req UndoSendRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	if err := h.Queries.SetUndoSendSeconds(c, db.SetUndoSendSecondsParams{
+		UserID:          uid,
+		UndoSendSeconds: int32(*req.Seconds),
+	}); err != nil {
+		problem.Respond(c, 500, "failed to update settings")
+		return
+	}
+	lookupInvalidator.Invalidate("undo_send", utils.UUIDToStr(uid))
+	c.JSON(200, gin.H{"seconds": *req.Seconds})
+}
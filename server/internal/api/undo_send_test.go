@@ -0,0 +1,26 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"wireloop/internal/chat"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestFlushHeldMessagesAfterClientClosed(t *testing.T) {
+	client := chat.NewStreamClient(pgtype.UUID{Bytes: [16]byte{1}, Valid: true}, "ada", "")
+	sent := false
+	holdMessage(client, "room", 4242, time.Hour, func() {
+		sent = true
+		// A quota rejection goes back to the sender, who is gone by now
+		client.Send("rejected")
+	})
+	client.Close()
+
+	(&Handler{}).FlushHeldMessages()
+	if !sent {
+		t.Fatal("held message was not sent on flush")
+	}
+}
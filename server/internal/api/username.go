@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Minimum time between two username changes
+const usernameChangeCooldown = 30 * 24 * time.Hour
+
+// userByHandle resolves a username, following the history of renamed accounts
+func (h *Handler) userByHandle(ctx context.Context, username string) (db.User, error) {
+	user, err := h.Queries.GetUserByUsername(ctx, username)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return user, err
+	}
+	ownerID, histErr := h.Queries.GetUsernameRedirect(ctx, username)
+	if histErr != nil {
+		return user, err
+	}
+	return h.getUserByID(ctx, ownerID)
+}
+
+// renameUser moves a user to a new handle and keeps the old one redirecting
+func (h *Handler) renameUser(ctx context.Context, user db.User, username string) (db.User, error) {
+	tx, err := h.Pool.Begin(ctx)
+	if err != nil {
+		return db.User{}, err
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	if err := qtx.ReleaseUsernameHistory(ctx, username); err != nil {
+		return db.User{}, err
+	}
+	updated, err := qtx.ChangeUsername(ctx, db.ChangeUsernameParams{ID: user.ID, Username: username})
+	if err != nil {
+		return db.User{}, err
+	}
+	if err := qtx.RecordUsernameHistory(ctx, db.RecordUsernameHistoryParams{OldUsername: user.Username, UserID: user.ID}); err != nil {
+		return db.User{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return db.User{}, err
+	}
+
+	invalidateUser(user.ID)
+	return updated, nil
+}
+
+type ChangeUsernameRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// HandleChangeUsername renames the caller, at most once per usernameChangeCooldown
+func (h *Handler) HandleChangeUsername(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	var req ChangeUsernameRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	if reason := validateUsername(req.Username); reason != "" {
+		problem.Respond(c, 400, reason)
+		return
+	}
+
+	user, err := h.Queries.GetUserByID(c, uid)
+	if err != nil {
+		problem.Respond(c, 404, "user not found")
+		return
+	}
+	if user.Username == req.Username {
+		problem.Respond(c, 400, "that is already your username")
+		return
+	}
+	if user.UsernameChangedAt.Valid {
+		if next := user.UsernameChangedAt.Time.Add(usernameChangeCooldown); time.Now().Before(next) {
+			problem.RespondCode(c, 429, "username_cooldown", "username was changed recently", gin.H{
+				"next_change_at": utils.FormatTime(next),
+			})
+			return
+		}
+	}
+
+	updated, err := h.renameUser(c, user, req.Username)
+	if isUniqueViolation(err) {
+		problem.Respond(c, 409, "username is taken")
+		return
+	}
+	if err != nil {
+		log.Printf("[profile] username change failed for %s: %v", user.Username, err)
+		problem.Respond(c, 500, "failed to change username")
+		return
+	}
+
+	c.JSON(200, ProfileResponse{
+		ID:               formatUUID(updated.ID.Bytes),
+		Username:         updated.Username,
+		AvatarURL:        avatarURL(updated.AvatarUrl),
+		DisplayName:      nullableString(updated.DisplayName),
+		ProfileCompleted: updated.ProfileCompleted.Bool,
+		CreatedAt:        utils.FormatTime(updated.CreatedAt.Time),
+	})
+}
+
+// recordGitHubRename keeps a handle dropped by a GitHub rename redirecting
+func (h *Handler) recordGitHubRename(ctx context.Context, userID pgtype.UUID, oldUsername string) {
+	if err := h.Queries.RecordUsernameHistory(ctx, db.RecordUsernameHistoryParams{OldUsername: oldUsername, UserID: userID}); err != nil {
+		log.Printf("[github-sync] failed to record old username %s: %v", oldUsername, err)
+	}
+}
@@ -0,0 +1,287 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// WEBHOOK DELIVERY LOG
+// Every GitHub delivery is logged with its outcome. Moderators of a loop see
+// the deliveries for its repo; the owner can replay one through the same
+// handlers, which acts for every loop linked to the repo, as the original
+// delivery did.
+// ============================================================================
+
+const (
+	webhookStatsWindow      = 24 * time.Hour
+	webhookDeliveryRetained = 14 * 24 * time.Hour
+	webhookPruneEvery       = 6 * time.Hour
+	webhookSnippetLen       = 600
+	defaultDeliveriesLimit  = 50
+)
+
+type WebhookDeliveryResponse struct {
+	ID         string `json:"id"`
+	DeliveryID string `json:"delivery_id"`
+	Event      string `json:"event"`
+	Action     string `json:"action,omitempty"`
+	Status     string `json:"status"` // ok, failed or ignored
+	Error      string `json:"error,omitempty"`
+	// Retries include GitHub's own and in-app redeliveries
+	Attempts      int32  `json:"attempts"`
+	ReceivedAt    string `json:"received_at"`
+	LastAttemptAt string `json:"last_attempt_at"`
+	// The start of the payload with credentials and emails masked
+	PayloadSnippet string `json:"payload_snippet"`
+}
+
+type WebhookHealthResponse struct {
+	// Over the last 24 hours
+	Deliveries     int32                     `json:"deliveries_24h"`
+	Failed         int32                     `json:"failed_24h"`
+	Retries        int32                     `json:"retries_24h"`
+	LastDeliveryAt *string                   `json:"last_delivery_at"`
+	LastFailureAt  *string                   `json:"last_failure_at"`
+	Recent         []WebhookDeliveryResponse `json:"recent"`
+}
+
+// webhookEnvelope is what every delivery shares
+type webhookEnvelope struct {
+	Action     string `json:"action"`
+	Repository struct {
+		ID int64 `json:"id"`
+	} `json:"repository"`
+}
+
+// recordWebhookDelivery logs a delivery's outcome. Deliveries without an id
+// can't be told apart from their retries and aren't logged.
+func (h *Handler) recordWebhookDelivery(ctx context.Context, deliveryID, event string, body []byte, handled bool, procErr error) {
+	if deliveryID == "" {
+		return
+	}
+	var env webhookEnvelope
+	_ = json.Unmarshal(body, &env)
+	if !json.Valid(body) {
+		body = []byte("null")
+	}
+
+	status := "ok"
+	var errText pgtype.Text
+	switch {
+	case !handled:
+		status = "ignored"
+	case procErr != nil:
+		status = "failed"
+		errText = pgtype.Text{String: procErr.Error(), Valid: true}
+	}
+	if err := h.Queries.RecordWebhookDelivery(context.WithoutCancel(ctx), db.RecordWebhookDeliveryParams{
+		DeliveryID: deliveryID,
+		Event:      event,
+		Action:     env.Action,
+		RepoID:     env.Repository.ID,
+		Payload:    body,
+		Status:     status,
+		Error:      errText,
+	}); err != nil {
+		log.Printf("[webhook] failed to log delivery %s: %v", deliveryID, err)
+	}
+}
+
+// StartWebhookDeliveryPruning drops logged deliveries past retention until
+// ctx is cancelled
+func (h *Handler) StartWebhookDeliveryPruning(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(webhookPruneEvery)
+		defer ticker.Stop()
+		for {
+			before := pgtype.Timestamptz{Time: time.Now().Add(-webhookDeliveryRetained), Valid: true}
+			if n, err := h.Queries.PruneWebhookDeliveries(ctx, before); err != nil {
+				if ctx.Err() == nil {
+					log.Printf("[webhook] failed to prune delivery log: %v", err)
+				}
+			} else if n > 0 {
+				log.Printf("[webhook] pruned %d logged deliveries", n)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sensitivePayloadKey reports whether a payload field is masked in snippets
+func sensitivePayloadKey(key string) bool {
+	k := strings.ToLower(key)
+	switch k {
+	case "token", "secret", "password", "email", "private_key", "authorization":
+		return true
+	}
+	return strings.HasSuffix(k, "_token") || strings.HasSuffix(k, "_secret") || strings.HasSuffix(k, "_email")
+}
+
+func maskPayload(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, child := range t {
+			if sensitivePayloadKey(k) {
+				if child != nil {
+					t[k] = "[redacted]"
+				}
+				continue
+			}
+			t[k] = maskPayload(child)
+		}
+	case []any:
+		for i := range t {
+			t[i] = maskPayload(t[i])
+		}
+	}
+	return v
+}
+
+// payloadSnippet is the start of a payload with sensitive fields masked
+func payloadSnippet(payload []byte) string {
+	var v any
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return ""
+	}
+	out, err := json.Marshal(maskPayload(v))
+	if err != nil {
+		return ""
+	}
+	if len(out) > webhookSnippetLen {
+		return string(out[:webhookSnippetLen]) + "…"
+	}
+	return string(out)
+}
+
+func webhookDeliveryToResponse(d db.WebhookDelivery) WebhookDeliveryResponse {
+	return WebhookDeliveryResponse{
+		ID:             strconv.FormatInt(d.ID, 10),
+		DeliveryID:     d.DeliveryID,
+		Event:          d.Event,
+		Action:         d.Action,
+		Status:         d.Status,
+		Error:          d.Error.String,
+		Attempts:       d.Attempts,
+		ReceivedAt:     utils.FormatTime(d.ReceivedAt.Time),
+		LastAttemptAt:  utils.FormatTime(d.LastAttemptAt.Time),
+		PayloadSnippet: payloadSnippet(d.Payload),
+	}
+}
+
+func optionalTime(t pgtype.Timestamptz) *string {
+	if !t.Valid {
+		return nil
+	}
+	s := utils.FormatTime(t.Time)
+	return &s
+}
+
+// HandleGetWebhookHealth summarizes the last day of webhook deliveries for
+// the loop's repo and lists recent ones, ?failed=true for failures only
+// (moderators only)
+func (h *Handler) HandleGetWebhookHealth(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if !h.Members.CanModerate(c, uid, project) {
+		problem.Respond(c, 403, "only moderators can view webhook deliveries")
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+	limit := defaultDeliveriesLimit
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil && v > 0 && v <= 200 {
+			limit = v
+		}
+	}
+
+	stats, err := h.Queries.GetRepoWebhookStats(c, db.GetRepoWebhookStatsParams{
+		RepoID: project.GithubRepoID,
+		Since:  pgtype.Timestamptz{Time: time.Now().Add(-webhookStatsWindow), Valid: true},
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to load webhook stats")
+		return
+	}
+	deliveries, err := h.Queries.GetRepoWebhookDeliveries(c, db.GetRepoWebhookDeliveriesParams{
+		RepoID:     project.GithubRepoID,
+		FailedOnly: c.Query("failed") == "true",
+		RowLimit:   int32(limit),
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to load webhook deliveries")
+		return
+	}
+
+	resp := WebhookHealthResponse{
+		Deliveries:     stats.Deliveries,
+		Failed:         stats.Failed,
+		Retries:        stats.Retries,
+		LastDeliveryAt: optionalTime(stats.LastDeliveryAt),
+		LastFailureAt:  optionalTime(stats.LastFailureAt),
+		Recent:         make([]WebhookDeliveryResponse, 0, len(deliveries)),
+	}
+	for _, d := range deliveries {
+		resp.Recent = append(resp.Recent, webhookDeliveryToResponse(d))
+	}
+	c.JSON(200, resp)
+}
+
+// HandleRedeliverWebhook runs a logged delivery of the loop's repo through
+// the webhook handlers again (owner only)
+func (h *Handler) HandleRedeliverWebhook(c *gin.Context) {
+	project, ok := h.ownedLoop(c, "redeliver webhooks")
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Respond(c, 400, "invalid delivery id")
+		return
+	}
+	d, err := h.Queries.GetWebhookDelivery(c, id)
+	if err != nil || project.GithubRepoID == 0 || d.RepoID != project.GithubRepoID {
+		problem.Respond(c, 404, "delivery not found")
+		return
+	}
+
+	ctx := c.Request.Context()
+	handled, procErr := h.dispatchGitHubEvent(ctx, d.Event, d.Payload)
+	h.recordWebhookDelivery(ctx, d.DeliveryID, d.Event, d.Payload, handled, procErr)
+	if procErr != nil {
+		log.Printf("[webhook] redelivery of %s failed: %v", d.DeliveryID, procErr)
+	}
+
+	d, err = h.Queries.GetWebhookDelivery(c, id)
+	if err != nil {
+		problem.Respond(c, 500, "failed to load delivery")
+		return
+	}
+	c.JSON(200, webhookDeliveryToResponse(d))
+}
@@ -0,0 +1,258 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/errreport"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/i18n"
+	"wireloop/internal/middleware"
+	"wireloop/internal/notify"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// GITHUB WEBHOOKS — repository events pushed by GitHub
+// ============================================================================
+
+// webhookSecret is the secret configured on the repository's webhook.
+// Without it every delivery is refused, since none could be authenticated.
+var webhookSecret = sync.OnceValue(func() []byte {
+	secret := os.Getenv("GITHUB_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Println("[webhook] GITHUB_WEBHOOK_SECRET not set, GitHub webhooks are disabled")
+	}
+	return []byte(secret)
+})
+
+// GitHubWebhookAuth checks X-Hub-Signature-256 and drops redelivered ids
+// before HandleGitHubWebhook sees the body
+func (h *Handler) GitHubWebhookAuth() gin.HandlerFunc {
+	return middleware.VerifyWebhook(middleware.GitHubWebhook(webhookSecret))
+}
+
+// HandleGitHubWebhook receives webhook deliveries (POST /api/github/webhook)
+func (h *Handler) HandleGitHubWebhook(c *gin.Context) {
+	body := middleware.WebhookBody(c)
+	event := c.GetHeader("X-GitHub-Event")
+	delivery := c.GetHeader("X-GitHub-Delivery")
+
+	handled, err := h.dispatchGitHubEvent(c.Request.Context(), event, body)
+	h.recordWebhookDelivery(c.Request.Context(), delivery, event, body, handled, err)
+	if !handled {
+		c.JSON(202, gin.H{"ignored": event})
+		return
+	}
+	if err != nil {
+		log.Printf("[webhook] %s delivery %s failed: %v", event, delivery, err)
+		errreport.Capture(c.Request, err, map[string]string{"component": "github_webhook", "event": event})
+		problem.Respond(c, 500, "failed to process event")
+		return
+	}
+	h.dispatchGitHubIntegrations(c.Request.Context(), event, body)
+	c.JSON(200, gin.H{"ok": true})
+}
+
+// dispatchGitHubEvent runs the handler for one event; handled is false for
+// events Wireloop doesn't act on
+func (h *Handler) dispatchGitHubEvent(ctx context.Context, event string, body []byte) (handled bool, err error) {
+	switch event {
+	case "ping":
+	case "issue_comment", "pull_request_review_comment":
+		err = h.handleCommentWebhook(ctx, event, body)
+	case "issues", "pull_request":
+		err = h.handleItemWebhook(ctx, body)
+	case "release":
+		err = h.queueReleaseAnnouncements(ctx, body)
+	case "deployment_status":
+		err = h.handleDeploymentStatusWebhook(ctx, body)
+	case "workflow_run":
+		err = h.handleWorkflowRunWebhook(ctx, body)
+	case "repository":
+		err = h.handleRepositoryWebhook(ctx, body)
+	case "member":
+		err = h.handleMemberWebhook(ctx, body)
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// handleCommentWebhook mirrors a PR or issue comment change into the local
+// store and pushes it to every loop linked to the repo
+func (h *Handler) handleCommentWebhook(ctx context.Context, event string, body []byte) error {
+	var ev github.CommentEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+
+	// Plain issues get their own event types; issue and PR numbers share one sequence
+	var number int
+	var title string
+	var row db.UpsertPRCommentParams
+	var isIssue bool
+	switch {
+	case event == "pull_request_review_comment" && ev.PullRequest != nil:
+		number, title = ev.PullRequest.Number, ev.PullRequest.Title
+		row = reviewCommentRow(ev.Repository.ID, number, ev.Comment)
+	case event == "issue_comment" && ev.Issue != nil:
+		number, title = ev.Issue.Number, ev.Issue.Title
+		row = issueCommentRow(ev.Repository.ID, number, ev.Comment.Comment)
+		isIssue = ev.Issue.PullRequest == nil
+	default:
+		return nil
+	}
+	// Lists show comment counts
+	invalidateGitHubLists(ev.Repository.ID)
+
+	projects, err := h.Queries.GetProjectsByGithubRepoID(ctx, ev.Repository.ID)
+	if err != nil {
+		return err
+	}
+	if len(projects) == 0 {
+		return nil
+	}
+
+	var out events.Event
+	switch ev.Action {
+	case "created", "edited":
+		if _, err := h.Queries.UpsertPRComment(ctx, row); err != nil {
+			return err
+		}
+		comment := prCommentToUnified(storedPRComment(row))
+		out = events.PRCommentChange{PRNumber: number, Action: ev.Action, Comment: comment}
+		if isIssue {
+			out = events.IssueCommentChange{IssueNumber: number, Action: ev.Action, Comment: comment}
+		}
+	case "deleted":
+		if err := h.Queries.DeletePRComment(ctx, db.DeletePRCommentParams{
+			RepoID:      row.RepoID,
+			PrNumber:    row.PrNumber,
+			CommentType: row.CommentType,
+			CommentID:   row.CommentID,
+		}); err != nil {
+			return err
+		}
+		out = events.PRCommentRemoval{PRNumber: number, ID: row.CommentID, Type: row.CommentType}
+		if isIssue {
+			out = events.IssueCommentRemoval{IssueNumber: number, ID: row.CommentID, Type: row.CommentType}
+		}
+	default:
+		return nil
+	}
+
+	for _, p := range projects {
+		h.Events.Publish(loopRoom(utils.UUIDToStr(p.ID)), out)
+		if ev.Action != "created" {
+			continue
+		}
+		if event == "issue_comment" {
+			if err := h.mirrorCommentToChannels(ctx, p, number, ev.Comment.Comment); err != nil {
+				return err
+			}
+		}
+		if err := h.assignFromGitHubComment(ctx, p, number, title, ev.Comment.Comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleItemWebhook tells subscribers about state changes, archives channels
+// bound to an issue or PR once it closes, and tells the author and celebrates
+// first-time contributors when a PR merges
+func (h *Handler) handleItemWebhook(ctx context.Context, body []byte) error {
+	var ev github.ItemEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	if ev.PullRequest == nil && ev.Issue == nil {
+		return nil
+	}
+	invalidateGitHubLists(ev.Repository.ID)
+
+	projects, err := h.Queries.GetProjectsByGithubRepoID(ctx, ev.Repository.ID)
+	if err != nil {
+		return err
+	}
+	for _, p := range projects {
+		h.notifyItemSubscribers(ctx, p, ev)
+		if ev.Action != "closed" {
+			continue
+		}
+
+		var number int
+		reason := "closed"
+		if ev.PullRequest != nil {
+			number = ev.PullRequest.Number
+			if ev.PullRequest.MergedAt != nil {
+				reason = "merged"
+			}
+		} else {
+			number = ev.Issue.Number
+		}
+		if err := h.archiveBoundChannels(ctx, p, number, reason); err != nil {
+			return err
+		}
+		if reason == "merged" {
+			h.completeOnboardingForPR(ctx, p, ev.PullRequest)
+			h.notifyPRMerged(ctx, p, ev.PullRequest)
+			if err := h.celebrateFirstMerge(ctx, p, ev.Repository.FullName, ev.PullRequest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// notifyPRMerged tells a PR's author, when they are in the loop, that
+// someone else merged it. The merger is the actor if they're on Wireloop,
+// the loop's owner otherwise.
+func (h *Handler) notifyPRMerged(ctx context.Context, project db.Project, pr *github.PullRequest) {
+	if pr.MergedBy != nil && pr.MergedBy.ID == pr.User.ID {
+		return
+	}
+	author, err := h.Queries.GetUserByGithubID(ctx, pr.User.ID)
+	if err != nil {
+		return
+	}
+	if !h.Members.IsMember(ctx, author.ID, project.ID) {
+		return
+	}
+
+	actor, err := h.getUserByID(ctx, project.OwnerID)
+	if pr.MergedBy != nil {
+		if merger, mErr := h.Queries.GetUserByGithubID(ctx, pr.MergedBy.ID); mErr == nil {
+			actor, err = merger, nil
+		}
+	}
+	if err != nil || actor.ID == author.ID {
+		return
+	}
+	login := actor.Username
+	if pr.MergedBy != nil {
+		login = pr.MergedBy.Login
+	}
+
+	if _, _, err := h.Notifier.Notify(ctx, notify.Event{
+		Type:          "pr_merged",
+		UserID:        author.ID,
+		ActorID:       actor.ID,
+		ActorUsername: actor.Username,
+		ProjectID:     project.ID,
+		Preview:       i18n.T(h.recipientLocale(ctx, author.ID), "%s merged your pull request #%d", login, pr.Number),
+		Extra:         map[string]any{"pr_number": pr.Number, "pr_url": pr.HTMLURL},
+	}); err != nil {
+		log.Printf("[webhooks] failed to notify %s of merged PR #%d: %v", author.Username, pr.Number, err)
+	}
+}
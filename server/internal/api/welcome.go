@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/i18n"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ============================================================================
+// CONTRIBUTOR WELCOME — greet new members and first merged PRs
+// ============================================================================
+
+const (
+	badgeFirstContribution = "first_contribution"
+
+	defaultWelcomeTemplate = "👋 Welcome to **{loop}**, @{username}!"
+	defaultFirstPRTemplate = "🎉 Congrats @{username} on your first merged PR to **{loop}**: [#{pr_number} {pr_title}]({pr_url})"
+)
+
+// renderTemplate fills {name} placeholders; unknown ones are left as typed
+func renderTemplate(tmpl string, vars map[string]string) string {
+	pairs := make([]string, 0, 2*len(vars))
+	for k, v := range vars {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// welcomeNewMember greets user the first time they join the loop, in the
+// welcome channel and/or by DM. Runs after the join response, so failures are
+// only logged.
+func (h *Handler) welcomeNewMember(project db.Project, user db.User) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	first, err := h.Queries.RecordLoopWelcome(ctx, db.RecordLoopWelcomeParams{ProjectID: project.ID, UserID: user.ID})
+	if err != nil || first == 0 {
+		return
+	}
+	settings, err := h.Queries.GetLoopSettings(ctx, project.ID)
+	if err != nil || (!settings.WelcomeChannelID.Valid && !settings.WelcomeDm) {
+		return
+	}
+
+	// The default greeting speaks the newcomer's language; custom ones are
+	// the owner's words
+	tmpl := settings.WelcomeTemplate
+	if tmpl == "" {
+		tmpl = i18n.T(h.recipientLocale(ctx, user.ID), defaultWelcomeTemplate)
+	}
+	content := renderTemplate(tmpl, map[string]string{"username": user.Username, "loop": project.Name})
+
+	if settings.WelcomeChannelID.Valid {
+		owner, err := h.getUserByID(ctx, project.OwnerID)
+		if err == nil {
+			_, err = h.postChannelMessage(ctx, project.ID, settings.WelcomeChannelID, owner, content)
+		}
+		if err != nil {
+			log.Printf("[welcome] failed to welcome %s to %s: %v", user.Username, project.Name, err)
+		}
+	}
+	if settings.WelcomeDm {
+		if err := h.sendBotDM(ctx, user.ID, content); err != nil {
+			log.Printf("[welcome] failed to DM %s: %v", user.Username, err)
+		}
+	}
+}
+
+// celebrateFirstMerge awards the first-contribution badge when a member's
+// first PR to the repo merges, and congratulates them if the loop opted in
+func (h *Handler) celebrateFirstMerge(ctx context.Context, project db.Project, repo string, pr *github.PullRequest) error {
+	author, err := h.Queries.GetUserByGithubID(ctx, pr.User.ID)
+	if err != nil {
+		return nil // not on Wireloop
+	}
+	if !h.Members.IsMember(ctx, author.ID, project.ID) {
+		return nil
+	}
+
+	// Search may not have indexed this merge yet, so only more than one means earlier PRs
+	if author.AccessToken != "" {
+		merged, err := github.Default.SearchIssueCount(ctx, author.AccessToken, "repo:"+repo+" is:pr is:merged author:"+pr.User.Login)
+		if err != nil {
+			log.Printf("[welcome] merged PR count for %s failed: %v", pr.User.Login, err)
+		} else if merged > 1 {
+			return nil
+		}
+	}
+
+	awarded, err := h.Queries.AwardMemberBadge(ctx, db.AwardMemberBadgeParams{
+		ProjectID: project.ID,
+		UserID:    author.ID,
+		Badge:     badgeFirstContribution,
+	})
+	if err != nil || awarded == 0 {
+		return err
+	}
+	h.Events.Publish(loopRoom(utils.UUIDToStr(project.ID)), events.Badge{
+		UserID: utils.UUIDToStr(author.ID),
+		Badge:  badgeFirstContribution,
+	})
+
+	settings, err := h.Queries.GetLoopGithubSettings(ctx, project.ID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !settings.FirstPrChannelID.Valid) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	owner, err := h.getUserByID(ctx, project.OwnerID)
+	if err != nil {
+		return err
+	}
+
+	tmpl := settings.FirstPrTemplate
+	if tmpl == "" {
+		tmpl = i18n.T(h.recipientLocale(ctx, author.ID), defaultFirstPRTemplate)
+	}
+	content := renderTemplate(tmpl, map[string]string{
+		"username":  author.Username,
+		"loop":      project.Name,
+		"pr_number": strconv.Itoa(pr.Number),
+		"pr_title":  pr.Title,
+		"pr_url":    pr.HTMLURL,
+	})
+	_, err = h.postChannelMessage(ctx, project.ID, settings.FirstPrChannelID, owner, content)
+	return err
+}
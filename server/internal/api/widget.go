@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ============================================================================
+// EMBEDDABLE WIDGET
+// A public loop's headline numbers as JSON or an SVG badge, for READMEs and
+// project sites. Both are readable from any origin (see CORSMiddleware),
+// rebuilt at most every widgetTTL per loop and cacheable by CDNs for as long.
+// shields.io can read the same numbers one per badge. These routes have their
+// own strict per-IP rate limit, since anyone can hit them.
+// ============================================================================
+
+const widgetTTL = 15 * time.Minute
+
+var widgetCache = cache.New[string, LoopWidget](widgetTTL, 2000)
+
+type LoopWidget struct {
+	Name             string         `json:"name"`
+	URL              string         `json:"url"`
+	MemberCount      int64          `json:"member_count"`
+	MessagesThisWeek int64          `json:"messages_this_week"`
+	LatestRelease    *WidgetRelease `json:"latest_release,omitempty"`
+	GeneratedAt      string         `json:"generated_at"`
+}
+
+type WidgetRelease struct {
+	Tag         string `json:"tag"`
+	Name        string `json:"name,omitempty"`
+	URL         string `json:"url"`
+	PublishedAt string `json:"published_at,omitempty"`
+}
+
+// loopWidget loads (or reuses) the widget for a public loop; ok is false when
+// the loop doesn't exist or isn't public, which both answer 404
+func (h *Handler) loopWidget(c *gin.Context) (LoopWidget, bool) {
+	project, err := h.Loops.ByName(c, c.Param("name"))
+	if err != nil || h.publicChannelSet(c, project.ID) == nil {
+		problem.Respond(c, 404, "no public loop with that name")
+		return LoopWidget{}, false
+	}
+	w, err := widgetCache.GetOrLoad(project.Name, func() (LoopWidget, error) {
+		return h.buildLoopWidget(c.Request.Context(), project)
+	})
+	if err != nil {
+		problem.Respond(c, 500, "failed to build widget")
+		return LoopWidget{}, false
+	}
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d, stale-while-revalidate=3600", int(widgetTTL.Seconds())))
+	return w, true
+}
+
+func (h *Handler) buildLoopWidget(ctx context.Context, project db.Project) (LoopWidget, error) {
+	stats, err := h.Queries.GetLoopWidgetStats(ctx, project.ID)
+	if err != nil {
+		return LoopWidget{}, err
+	}
+	w := LoopWidget{
+		Name:             project.Name,
+		URL:              strings.TrimRight(os.Getenv("FRONTEND_URL"), "/") + "/loops/" + url.PathEscape(project.Name),
+		MemberCount:      stats.MemberCount,
+		MessagesThisWeek: stats.MessagesThisWeek,
+		LatestRelease:    h.latestRelease(ctx, project),
+		GeneratedAt:      utils.FormatTime(time.Now()),
+	}
+	return w, nil
+}
+
+// latestRelease is the newest published release of the loop's repository,
+// preferring full releases over prereleases; nil if there is none or GitHub
+// can't be reached
+func (h *Handler) latestRelease(ctx context.Context, project db.Project) *WidgetRelease {
+	owner, err := h.getUserByID(ctx, project.OwnerID)
+	if err != nil || owner.AccessToken == "" {
+		return nil
+	}
+	repo, err := github.Default.RepoFullName(ctx, owner.AccessToken, project.GithubRepoID)
+	if err != nil {
+		reportGitHubError(nil, err, "repo_name")
+		return nil
+	}
+	releases, err := github.Default.ListReleases(ctx, owner.AccessToken, repo, github.ListOptions{PerPage: "10"})
+	if err != nil {
+		log.Printf("[widget] failed to list releases for %s: %v", repo, err)
+		reportGitHubError(nil, err, "list_releases")
+		return nil
+	}
+	var pick *github.Release
+	for i, r := range releases {
+		if r.Draft {
+			continue
+		}
+		if !r.Prerelease {
+			pick = &releases[i]
+			break
+		}
+		if pick == nil {
+			pick = &releases[i]
+		}
+	}
+	if pick == nil {
+		return nil
+	}
+	return &WidgetRelease{Tag: pick.TagName, Name: pick.Name, URL: pick.HTMLURL, PublishedAt: pick.PublishedAt}
+}
+
+// HandleGetLoopWidget returns a public loop's widget data
+// (GET /api/loops/:name/widget.json)
+func (h *Handler) HandleGetLoopWidget(c *gin.Context) {
+	if w, ok := h.loopWidget(c); ok {
+		c.JSON(200, w)
+	}
+}
+
+// HandleGetLoopBadge renders the widget as a shields-style SVG badge
+// (GET /api/loops/:name/widget.svg)
+func (h *Handler) HandleGetLoopBadge(c *gin.Context) {
+	w, ok := h.loopWidget(c)
+	if !ok {
+		return
+	}
+	value := fmt.Sprintf("%s members · %s msgs/wk", compactCount(w.MemberCount), compactCount(w.MessagesThisWeek))
+	if w.LatestRelease != nil {
+		value += " · " + w.LatestRelease.Tag
+	}
+	c.Data(http.StatusOK, "image/svg+xml; charset=utf-8", []byte(badgeSVG("wireloop", value)))
+}
+
+// ShieldsBadge is the JSON shields.io renders as a badge
+// (https://shields.io/badges/endpoint-badge)
+type ShieldsBadge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+	CacheSeconds  int    `json:"cacheSeconds"`
+}
+
+// onlineBadgeTTL is how long the online count may be cached; shields.io
+// won't go below five minutes anyway
+const onlineBadgeTTL = 5 * time.Minute
+
+// HandleGetLoopShield returns one of a public loop's numbers for a shields.io
+// endpoint badge: members, online (connected to this server now) or
+// messages (this week)
+// (GET /api/loops/:name/shields/:metric)
+func (h *Handler) HandleGetLoopShield(c *gin.Context) {
+	metric := c.Param("metric")
+	if metric != "members" && metric != "online" && metric != "messages" {
+		problem.Respond(c, 404, "unknown badge; use members, online or messages")
+		return
+	}
+	w, ok := h.loopWidget(c)
+	if !ok {
+		return
+	}
+	badge := ShieldsBadge{SchemaVersion: 1, Color: "6d4aff", CacheSeconds: int(widgetTTL.Seconds())}
+	switch metric {
+	case "members":
+		badge.Label, badge.Message = "members", compactCount(w.MemberCount)
+	case "messages":
+		badge.Label, badge.Message = "messages", compactCount(w.MessagesThisWeek)+"/week"
+	case "online":
+		project, err := h.Loops.ByName(c, c.Param("name"))
+		if err != nil {
+			problem.Respond(c, 404, "no public loop with that name")
+			return
+		}
+		online := int64(len(h.Hub.Online(loopRoom(utils.UUIDToStr(project.ID)))))
+		badge.Label, badge.Message = "online", compactCount(online)
+		badge.CacheSeconds = int(onlineBadgeTTL.Seconds())
+		if online == 0 {
+			badge.Color = "lightgrey"
+		}
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", badge.CacheSeconds))
+	}
+	c.JSON(200, badge)
+}
+
+// compactCount writes 1234 as 1.2k
+func compactCount(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return strconv.FormatFloat(float64(n)/1_000_000, 'f', 1, 64) + "M"
+	case n >= 1_000:
+		return strconv.FormatFloat(float64(n)/1_000, 'f', 1, 64) + "k"
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// badgeSVG draws a two-part badge; widths are estimated from the text, which
+// is close enough for the 11px sans-serif badges use
+func badgeSVG(label, value string) string {
+	width := func(s string) int { return len([]rune(s))*7 + 10 }
+	lw, vw := width(label), width(value)
+	label, value = html.EscapeString(label), html.EscapeString(value)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">`+
+		`<title>%[4]s: %[5]s</title>`+
+		`<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`+
+		`<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>`+
+		`<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="#6d4aff"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>`+
+		`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`+
+		`<text x="%[6]d" y="14">%[4]s</text><text x="%[7]d" y="14">%[5]s</text></g></svg>`,
+		lw+vw, lw, vw, label, value, lw/2, lw+vw/2)
+}
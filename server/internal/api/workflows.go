@@ -0,0 +1,276 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/github"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// WORKFLOW DISPATCH — trigger GitHub Actions from a channel
+// ============================================================================
+
+const deployCommandUsage = "usage: /deploy <workflow> [ref] [input=value ...]"
+
+type DispatchWorkflowRequest struct {
+	ChannelID string            `json:"channel_id" binding:"required"`
+	Ref       string            `json:"ref" binding:"max=255"` // defaults to the repo's default branch
+	Inputs    map[string]string `json:"inputs"`
+}
+
+// ============================================================================
+// POST /api/loops/:name/github/workflows/:id/dispatch
+// :id is the workflow's numeric ID or file name (deploy.yml)
+// ============================================================================
+
+func (h *Handler) HandleDispatchWorkflow(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+
+	var req DispatchWorkflowRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	ctx := c.Request.Context()
+	project, err := h.Loops.ByName(ctx, c.Param("name"))
+	if err != nil {
+		problem.Respond(c, 404, "loop not found")
+		return
+	}
+	if project.GithubRepoID == 0 {
+		problem.Respond(c, 400, "no GitHub repository linked to this loop")
+		return
+	}
+	if !h.Members.CanModerate(ctx, uid, project) {
+		problem.Respond(c, 403, "only the owner and moderators can run workflows")
+		return
+	}
+	channelID, ok := h.loopChannelParam(c, project, req.ChannelID)
+	if !ok {
+		return
+	}
+
+	user, err := h.getUserByID(ctx, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get user")
+		return
+	}
+	if user.AccessToken == "" {
+		problem.Respond(c, 401, "no GitHub access token — please re-login")
+		return
+	}
+
+	repoFullName, ok := h.repoForWrite(c, project, user.AccessToken)
+	if !ok {
+		return
+	}
+
+	run, ref, err := h.dispatchWorkflow(ctx, project, user, repoFullName, channelID, c.Param("id"), req.Ref, req.Inputs)
+	if err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		respondGitHubError(c, err)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"run_id":   run.RunID,
+		"html_url": run.HTMLURL,
+		"ref":      ref,
+	})
+}
+
+// dispatchWorkflow triggers the run with the caller's token, announces it in
+// the channel and records it so the workflow_run webhook can report back
+func (h *Handler) dispatchWorkflow(ctx context.Context, project db.Project, user db.User, repo string, channelID pgtype.UUID, workflow, ref string, inputs map[string]string) (*github.WorkflowDispatch, string, error) {
+	gh := github.Default
+	if ref == "" {
+		owner, name, _ := github.SplitFullName(repo)
+		r, err := gh.GetRepo(ctx, user.AccessToken, owner, name)
+		if err != nil {
+			return nil, "", err
+		}
+		ref = r.DefaultBranch
+	}
+
+	run, err := gh.DispatchWorkflow(ctx, user.AccessToken, repo, workflow, ref, inputs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	link := run.HTMLURL
+	if link == "" {
+		link = "https://github.com/" + repo + "/actions"
+	}
+	content := fmt.Sprintf("▶️ Started **%s** on `%s` · [Run](%s)", workflow, ref, link)
+	if _, err := h.postChannelMessage(ctx, project.ID, channelID, user, content); err != nil {
+		log.Printf("[workflows] failed to announce dispatch of %s: %v", workflow, err)
+	}
+
+	// Without a run ID there's nothing to match the completion webhook against
+	if run.RunID != 0 {
+		if err := h.Queries.CreateWorkflowDispatch(ctx, db.CreateWorkflowDispatchParams{
+			RunID:     run.RunID,
+			ProjectID: project.ID,
+			ChannelID: channelID,
+			UserID:    user.ID,
+			Workflow:  workflow,
+			Ref:       ref,
+			HtmlUrl:   link,
+		}); err != nil {
+			log.Printf("[workflows] failed to record run %d: %v", run.RunID, err)
+		}
+	}
+	return run, ref, nil
+}
+
+// ============================================================================
+// /deploy slash command
+// ============================================================================
+
+type deployCommand struct {
+	Workflow string
+	Ref      string
+	Inputs   map[string]string
+}
+
+// parseDeployCommand recognises "/deploy <workflow> [ref] [input=value ...]".
+// ok is false for ordinary messages; err is set for a malformed command.
+func parseDeployCommand(content string) (cmd deployCommand, ok bool, err error) {
+	fields := strings.Fields(content)
+	if len(fields) == 0 || fields[0] != "/deploy" {
+		return cmd, false, nil
+	}
+	if len(fields) < 2 || strings.Contains(fields[1], "=") {
+		return cmd, true, errors.New(deployCommandUsage)
+	}
+	cmd.Workflow = fields[1]
+	for i, f := range fields[2:] {
+		key, value, isInput := strings.Cut(f, "=")
+		if !isInput {
+			if i != 0 {
+				return cmd, true, errors.New(deployCommandUsage)
+			}
+			cmd.Ref = f
+			continue
+		}
+		if key == "" {
+			return cmd, true, errors.New(deployCommandUsage)
+		}
+		if cmd.Inputs == nil {
+			cmd.Inputs = make(map[string]string)
+		}
+		cmd.Inputs[key] = value
+	}
+	return cmd, true, nil
+}
+
+// runDeployCommand executes a /deploy typed into a channel. Failures go back
+// to the sender only; the command text itself is never stored. It runs after
+// the frame was handled, so the sender may have disconnected by the time it
+// answers; Send drops what a closed client can't take.
+func (h *Handler) runDeployCommand(client *chat.Client, roomID string, projectID, channelID pgtype.UUID, cmd deployCommand) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	fail := func(msg string) {
+		client.Send(events.Wrap(events.CommandFailure{Command: "deploy", Error: msg}, roomID))
+	}
+
+	project, err := h.Loops.ByID(ctx, projectID)
+	if err != nil {
+		fail("loop not found")
+		return
+	}
+	if project.GithubRepoID == 0 {
+		fail("no GitHub repository linked to this loop")
+		return
+	}
+	if !h.Members.CanModerate(ctx, client.UserID, project) {
+		fail("only the owner and moderators can run workflows")
+		return
+	}
+	user, err := h.getUserByID(ctx, client.UserID)
+	if err != nil || user.AccessToken == "" {
+		fail("no GitHub access token — please re-login")
+		return
+	}
+	repoFullName, err := github.Default.RepoFullName(ctx, user.AccessToken, project.GithubRepoID)
+	if err != nil {
+		fail(err.Error())
+		return
+	}
+
+	if _, _, err := h.dispatchWorkflow(ctx, project, user, repoFullName, channelID, cmd.Workflow, cmd.Ref, cmd.Inputs); err != nil {
+		forgetRepoOn404(err, project.GithubRepoID)
+		fail(err.Error())
+	}
+}
+
+// ============================================================================
+// workflow_run webhook — report completion of runs started from chat
+// ============================================================================
+
+var runOutcome = map[string]string{
+	"success":   "✅ **%s** on `%s` succeeded",
+	"failure":   "❌ **%s** on `%s` failed",
+	"timed_out": "❌ **%s** on `%s` timed out",
+	"cancelled": "⚪ **%s** on `%s` was cancelled",
+}
+
+func (h *Handler) handleWorkflowRunWebhook(ctx context.Context, body []byte) error {
+	var ev github.WorkflowRunEvent
+	if err := json.Unmarshal(body, &ev); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	if ev.Action != "completed" {
+		return nil
+	}
+
+	run := ev.WorkflowRun
+	d, err := h.Queries.CompleteWorkflowDispatch(ctx, db.CompleteWorkflowDispatchParams{
+		RunID:      run.ID,
+		Conclusion: pgtype.Text{String: run.Conclusion, Valid: run.Conclusion != ""},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // not started from chat, or already reported
+	}
+	if err != nil {
+		return err
+	}
+
+	sender, err := h.getUserByID(ctx, d.UserID)
+	if err != nil {
+		return err
+	}
+	name := run.Name
+	if name == "" {
+		name = d.Workflow
+	}
+	format, ok := runOutcome[run.Conclusion]
+	if !ok {
+		format = "🔘 **%s** on `%s` finished: " + run.Conclusion
+	}
+	content := fmt.Sprintf(format, name, d.Ref) + " · [Run](" + d.HtmlUrl + ")"
+
+	_, err = h.postChannelMessage(ctx, d.ProjectID, d.ChannelID, sender, content)
+	return err
+}
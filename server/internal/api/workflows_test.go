@@ -0,0 +1,23 @@
+package api
+
+import (
+	"testing"
+
+	"wireloop/internal/chat"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestDeployCommandAfterClientClosed(t *testing.T) {
+	queries, pool, hub, store := testDeps(t)
+	h, err := NewHandler(queries, pool, hub, Config{Storage: store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := chat.NewStreamClient(pgtype.UUID{Bytes: [16]byte{1}, Valid: true}, "ada", "")
+	client.Close()
+
+	// Without a database the loop lookup fails, and the failure goes to the
+	// sender who has already left
+	h.runDeployCommand(client, "room", pgtype.UUID{Bytes: [16]byte{2}, Valid: true}, pgtype.UUID{Bytes: [16]byte{3}, Valid: true}, deployCommand{Workflow: "deploy.yml"})
+}
@@ -0,0 +1,476 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+	"wireloop/internal/members"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// WORKSPACES
+// A workspace groups loops under one billing owner, whose quotas they count
+// against. Workspace members are members of every loop in it: owners and
+// admins as moderators, everyone else with the workspace's default role.
+// Each user has a personal workspace, created with their first loop, that
+// holds the loops they make outside any organization.
+// ============================================================================
+
+const (
+	wsRoleOwner  = "owner"
+	wsRoleAdmin  = "admin"
+	wsRoleMember = "member"
+)
+
+var (
+	workspaceRoles = map[string]int{wsRoleMember: 1, wsRoleAdmin: 2, wsRoleOwner: 3}
+	// Loop roles a workspace can hand its members by default
+	workspaceDefaultRoles = map[string]bool{members.Contributor: true, members.Moderator: true, members.Guest: true}
+)
+
+type WorkspaceData struct {
+	ID           string `json:"id"`
+	Slug         string `json:"slug"`
+	Name         string `json:"name"`
+	Personal     bool   `json:"personal"`
+	DefaultRole  string `json:"default_role"`
+	BillingOwner string `json:"billing_owner_id"`
+	Role         string `json:"role,omitempty"` // the caller's
+	CreatedAt    string `json:"created_at"`
+}
+
+func workspaceToData(w db.Workspace, role string) WorkspaceData {
+	return WorkspaceData{
+		ID:           utils.UUIDToStr(w.ID),
+		Slug:         w.Slug,
+		Name:         w.Name,
+		Personal:     w.Personal,
+		DefaultRole:  w.DefaultRole,
+		BillingOwner: utils.UUIDToStr(w.BillingOwnerID),
+		Role:         role,
+		CreatedAt:    utils.FormatTime(w.CreatedAt.Time),
+	}
+}
+
+// validateWorkspaceSlug returns a user-facing reason the slug can't be used,
+// or "". Slugs share the username charset so personal workspaces can use
+// their owner's name.
+func validateWorkspaceSlug(slug string) string {
+	if reason := validateUsername(slug); reason != "" {
+		return strings.Replace(reason, "username", "workspace slug", 1)
+	}
+	return ""
+}
+
+// ensurePersonalWorkspace returns the user's personal workspace, creating it
+// the first time. Its slug is the username, or the username with a suffix
+// when an organization already took it.
+func (h *Handler) ensurePersonalWorkspace(ctx context.Context, user db.User) (db.Workspace, error) {
+	w, err := h.Queries.GetPersonalWorkspace(ctx, user.ID)
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return w, err
+	}
+	slug := strings.ToLower(user.Username)
+	for attempt := 0; ; attempt++ {
+		w, err = h.Queries.CreateWorkspace(ctx, db.CreateWorkspaceParams{
+			Slug:           slug,
+			Name:           user.Username,
+			BillingOwnerID: user.ID,
+			Personal:       true,
+			DefaultRole:    members.Contributor,
+		})
+		if err == nil || !isUniqueViolation(err) || attempt == 3 {
+			break
+		}
+		// Lost a race for the personal workspace itself, or the slug is taken
+		if existing, gerr := h.Queries.GetPersonalWorkspace(ctx, user.ID); gerr == nil {
+			return existing, nil
+		}
+		suffix := make([]byte, 2)
+		rand.Read(suffix)
+		slug = strings.ToLower(user.Username) + "-" + hex.EncodeToString(suffix)
+	}
+	if err != nil {
+		return db.Workspace{}, err
+	}
+	err = h.Queries.UpsertWorkspaceMember(ctx, db.UpsertWorkspaceMemberParams{
+		WorkspaceID: w.ID,
+		UserID:      user.ID,
+		Role:        wsRoleOwner,
+	})
+	return w, err
+}
+
+// loopWorkspace resolves the workspace a new loop goes into: slug, which the
+// caller must administer, or their personal workspace
+func (h *Handler) loopWorkspace(c *gin.Context, uid pgtype.UUID, slug string) (db.Workspace, bool) {
+	if slug == "" {
+		user, err := h.getUserByID(c, uid)
+		if err != nil {
+			problem.Respond(c, 404, "user not found")
+			return db.Workspace{}, false
+		}
+		w, err := h.ensurePersonalWorkspace(c, user)
+		if err != nil {
+			problem.Respond(c, 500, "failed to set up your workspace")
+			return db.Workspace{}, false
+		}
+		return w, true
+	}
+	w, err := h.Queries.GetWorkspaceBySlug(c, strings.ToLower(slug))
+	if err != nil {
+		problem.Respond(c, 404, "workspace not found")
+		return db.Workspace{}, false
+	}
+	m, err := h.Queries.GetWorkspaceMember(c, db.GetWorkspaceMemberParams{WorkspaceID: w.ID, UserID: uid})
+	if err != nil {
+		problem.Respond(c, 404, "workspace not found")
+		return db.Workspace{}, false
+	}
+	if workspaceRoles[m.Role] < workspaceRoles[wsRoleAdmin] {
+		problem.Respond(c, 403, "only workspace admins can create loops in it")
+		return db.Workspace{}, false
+	}
+	return w, true
+}
+
+// workspaceAccess loads :slug for a member holding at least minRole
+func (h *Handler) workspaceAccess(c *gin.Context, minRole string) (db.Workspace, pgtype.UUID, string, bool) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return db.Workspace{}, uid, "", false
+	}
+	w, err := h.Queries.GetWorkspaceBySlug(c, strings.ToLower(c.Param("slug")))
+	if err != nil {
+		problem.Respond(c, 404, "workspace not found")
+		return db.Workspace{}, uid, "", false
+	}
+	m, err := h.Queries.GetWorkspaceMember(c, db.GetWorkspaceMemberParams{WorkspaceID: w.ID, UserID: uid})
+	if err != nil {
+		// Outsiders can't tell a private workspace from a missing one
+		problem.Respond(c, 404, "workspace not found")
+		return db.Workspace{}, uid, "", false
+	}
+	if workspaceRoles[m.Role] < workspaceRoles[minRole] {
+		problem.Respond(c, 403, "requires the workspace "+minRole+" role")
+		return db.Workspace{}, uid, "", false
+	}
+	return w, uid, m.Role, true
+}
+
+// HandleListWorkspaces lists the caller's workspaces, personal first
+func (h *Handler) HandleListWorkspaces(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	rows, err := h.Queries.GetUserWorkspaces(c, uid)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get workspaces")
+		return
+	}
+	result := make([]WorkspaceData, len(rows))
+	for i, r := range rows {
+		result[i] = workspaceToData(db.Workspace{
+			ID:             r.ID,
+			Slug:           r.Slug,
+			Name:           r.Name,
+			BillingOwnerID: r.BillingOwnerID,
+			Personal:       r.Personal,
+			DefaultRole:    r.DefaultRole,
+			CreatedAt:      r.CreatedAt,
+		}, r.Role)
+	}
+	c.JSON(200, result)
+}
+
+type CreateWorkspaceRequest struct {
+	Slug        string `json:"slug" binding:"required"`
+	Name        string `json:"name" binding:"required,max=100"`
+	DefaultRole string `json:"default_role"`
+}
+
+// HandleCreateWorkspace creates an organization workspace billed to and
+// owned by the caller
+func (h *Handler) HandleCreateWorkspace(c *gin.Context) {
+	uid, ok := utils.GetUserIdFromContext(c)
+	if !ok {
+		problem.Respond(c, 401, "unauthorized")
+		return
+	}
+	var req CreateWorkspaceRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	slug := strings.ToLower(req.Slug)
+	if reason := validateWorkspaceSlug(slug); reason != "" {
+		problem.Respond(c, 400, reason)
+		return
+	}
+	if req.DefaultRole == "" {
+		req.DefaultRole = members.Contributor
+	}
+	if !workspaceDefaultRoles[req.DefaultRole] {
+		problem.Respond(c, 400, "default_role must be contributor, moderator or guest")
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+
+	w, err := qtx.CreateWorkspace(c, db.CreateWorkspaceParams{
+		Slug:           slug,
+		Name:           strings.TrimSpace(req.Name),
+		BillingOwnerID: uid,
+		DefaultRole:    req.DefaultRole,
+	})
+	if isUniqueViolation(err) {
+		problem.Respond(c, 409, "that workspace slug is taken")
+		return
+	}
+	if err != nil {
+		problem.Respond(c, 500, "failed to create workspace")
+		return
+	}
+	if err := qtx.UpsertWorkspaceMember(c, db.UpsertWorkspaceMemberParams{
+		WorkspaceID: w.ID,
+		UserID:      uid,
+		Role:        wsRoleOwner,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to create workspace")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to save changes")
+		return
+	}
+	c.JSON(201, workspaceToData(w, wsRoleOwner))
+}
+
+// HandleGetWorkspace returns a workspace with its members and loops
+func (h *Handler) HandleGetWorkspace(c *gin.Context) {
+	w, _, role, ok := h.workspaceAccess(c, wsRoleMember)
+	if !ok {
+		return
+	}
+	members, err := h.Queries.GetWorkspaceMembers(c, w.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get members")
+		return
+	}
+	projects, err := h.Queries.GetWorkspaceProjects(c, w.ID)
+	if err != nil {
+		problem.Respond(c, 500, "failed to get loops")
+		return
+	}
+
+	memberList := make([]gin.H, len(members))
+	for i, m := range members {
+		memberList[i] = gin.H{
+			"id":         utils.UUIDToStr(m.ID),
+			"username":   m.Username,
+			"avatar_url": mediaURL(m.AvatarUrl.String),
+			"role":       m.Role,
+			"joined_at":  utils.FormatTime(m.JoinedAt.Time),
+		}
+	}
+	loops := make([]ProjectData, len(projects))
+	for i, p := range projects {
+		loops[i] = projectToData(p)
+	}
+	c.JSON(200, gin.H{
+		"workspace": workspaceToData(w, role),
+		"members":   memberList,
+		"loops":     loops,
+	})
+}
+
+type UpdateWorkspaceRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	DefaultRole *string `json:"default_role"`
+	// Username of the member to bill; only the workspace owner may change it
+	BillingOwner *string `json:"billing_owner"`
+}
+
+// HandleUpdateWorkspace renames a workspace, changes its default role or
+// moves its billing to another member. A new default role applies to
+// members who join its loops from now on.
+func (h *Handler) HandleUpdateWorkspace(c *gin.Context) {
+	w, _, role, ok := h.workspaceAccess(c, wsRoleAdmin)
+	if !ok {
+		return
+	}
+	var req UpdateWorkspaceRequest
+	if !bindStrictJSON(c, &req) {
+		return
+	}
+	params := db.UpdateWorkspaceParams{
+		ID:             w.ID,
+		Name:           w.Name,
+		DefaultRole:    w.DefaultRole,
+		BillingOwnerID: w.BillingOwnerID,
+	}
+	if req.Name != nil {
+		params.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.DefaultRole != nil {
+		if !workspaceDefaultRoles[*req.DefaultRole] {
+			problem.Respond(c, 400, "default_role must be contributor, moderator or guest")
+			return
+		}
+		params.DefaultRole = *req.DefaultRole
+	}
+	if req.BillingOwner != nil {
+		if role != wsRoleOwner || w.Personal {
+			problem.Respond(c, 403, "only the owner of an organization workspace can move its billing")
+			return
+		}
+		user, err := h.Queries.GetUserByUsername(c, *req.BillingOwner)
+		if err != nil {
+			problem.Respond(c, 404, "user not found")
+			return
+		}
+		if _, err := h.Queries.GetWorkspaceMember(c, db.GetWorkspaceMemberParams{WorkspaceID: w.ID, UserID: user.ID}); err != nil {
+			problem.Respond(c, 400, "the billing owner must be a member of the workspace")
+			return
+		}
+		params.BillingOwnerID = user.ID
+	}
+
+	updated, err := h.Queries.UpdateWorkspace(c, params)
+	if err != nil {
+		problem.Respond(c, 500, "failed to update workspace")
+		return
+	}
+	invalidateWorkspace(w.ID)
+	c.JSON(200, workspaceToData(updated, role))
+}
+
+type SetWorkspaceMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member"`
+}
+
+// HandleSetWorkspaceMember adds a user to the workspace, and so to all of
+// its loops, or changes their workspace role
+func (h *Handler) HandleSetWorkspaceMember(c *gin.Context) {
+	w, _, role, ok := h.workspaceAccess(c, wsRoleAdmin)
+	if !ok {
+		return
+	}
+	if w.Personal {
+		problem.Respond(c, 400, "personal workspaces have no other members; create an organization workspace instead")
+		return
+	}
+	var req SetWorkspaceMemberRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if workspaceRoles[req.Role] > workspaceRoles[role] {
+		problem.Respond(c, 403, "you can't grant a role above your own")
+		return
+	}
+	user, err := h.Queries.GetUserByUsername(c, c.Param("username"))
+	if err != nil {
+		problem.Respond(c, 404, "user not found")
+		return
+	}
+	if user.ID == w.BillingOwnerID && req.Role != wsRoleOwner {
+		problem.Respond(c, 400, "the billing owner must stay a workspace owner")
+		return
+	}
+	if existing, err := h.Queries.GetWorkspaceMember(c, db.GetWorkspaceMemberParams{WorkspaceID: w.ID, UserID: user.ID}); err == nil &&
+		workspaceRoles[existing.Role] > workspaceRoles[role] {
+		problem.Respond(c, 403, "you can't change the role of someone above you")
+		return
+	}
+
+	if err := h.Queries.UpsertWorkspaceMember(c, db.UpsertWorkspaceMemberParams{
+		WorkspaceID: w.ID,
+		UserID:      user.ID,
+		Role:        req.Role,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to save member")
+		return
+	}
+	// Loops they're already in keep the role they have there
+	if err := h.Queries.AddWorkspaceMemberToLoops(c, db.AddWorkspaceMemberToLoopsParams{
+		WorkspaceID: w.ID,
+		UserID:      user.ID,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to add member to the workspace's loops")
+		return
+	}
+	c.JSON(200, gin.H{"username": user.Username, "role": req.Role})
+}
+
+// HandleRemoveWorkspaceMember removes a member, or lets a member leave, and
+// takes them out of the workspace's loops except those they own
+func (h *Handler) HandleRemoveWorkspaceMember(c *gin.Context) {
+	w, uid, role, ok := h.workspaceAccess(c, wsRoleMember)
+	if !ok {
+		return
+	}
+	user, err := h.Queries.GetUserByUsername(c, c.Param("username"))
+	if err != nil {
+		problem.Respond(c, 404, "user not found")
+		return
+	}
+	if user.ID != uid && workspaceRoles[role] < workspaceRoles[wsRoleAdmin] {
+		problem.Respond(c, 403, "requires the workspace admin role")
+		return
+	}
+	if user.ID == w.BillingOwnerID {
+		problem.Respond(c, 400, "move the workspace's billing to another member first")
+		return
+	}
+	if existing, err := h.Queries.GetWorkspaceMember(c, db.GetWorkspaceMemberParams{WorkspaceID: w.ID, UserID: user.ID}); err == nil &&
+		user.ID != uid && workspaceRoles[existing.Role] > workspaceRoles[role] {
+		problem.Respond(c, 403, "you can't remove someone above you")
+		return
+	}
+
+	tx, err := h.Pool.Begin(c)
+	if err != nil {
+		problem.Respond(c, 500, "internal server error")
+		return
+	}
+	defer tx.Rollback(context.Background())
+	qtx := h.Queries.WithTx(tx)
+	n, err := qtx.RemoveWorkspaceMember(c, db.RemoveWorkspaceMemberParams{WorkspaceID: w.ID, UserID: user.ID})
+	if err != nil {
+		problem.Respond(c, 500, "failed to remove member")
+		return
+	}
+	if n == 0 {
+		problem.Respond(c, 404, "not a member of this workspace")
+		return
+	}
+	if err := qtx.RemoveWorkspaceMemberFromLoops(c, db.RemoveWorkspaceMemberFromLoopsParams{
+		WorkspaceID: w.ID,
+		UserID:      user.ID,
+	}); err != nil {
+		problem.Respond(c, 500, "failed to remove member from the workspace's loops")
+		return
+	}
+	if err := tx.Commit(c); err != nil {
+		problem.Respond(c, 500, "failed to save changes")
+		return
+	}
+	c.JSON(200, gin.H{"removed": user.Username})
+}
@@ -3,15 +3,21 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 	utils "wireloop/internal"
 	"wireloop/internal/chat"
 	"wireloop/internal/db"
+	"wireloop/internal/errreport"
+	"wireloop/internal/events"
+	"wireloop/internal/members"
+	"wireloop/internal/middleware"
+	"wireloop/internal/msgfilter"
+	"wireloop/internal/problem"
+	"wireloop/internal/quota"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
@@ -19,27 +25,21 @@ import (
 )
 
 var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		origin := r.Header.Get("Origin")
-		if origin == "" {
-			return true // Allow no-origin (e.g., native clients)
-		}
-		allowed := []string{"http://localhost:3000", "https://localhost:3000"}
-		if frontendURL := os.Getenv("FRONTEND_URL"); frontendURL != "" {
-			allowed = append(allowed, frontendURL)
-		}
-		for _, a := range allowed {
-			if origin == a {
-				return true
-			}
-		}
-		log.Printf("[WS] rejected origin: %s", origin)
-		return false
-	},
+	CheckOrigin: wsOriginAllowed,
+	// Clients sending their token in Sec-WebSocket-Protocol offer this too
+	Subprotocols:    []string{middleware.WSProtocol},
 	ReadBufferSize:  4096,
 	WriteBufferSize: 4096,
 }
 
+// wsOriginAllowed accepts upgrades from the CORS allowlist (see
+// middleware.OriginPolicy) and from clients that send no Origin, which
+// browsers always do
+func wsOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	return origin == "" || middleware.Origins().Allowed(origin)
+}
+
 const (
 	// SOTA WebSocket connection settings
 	writeWait      = 10 * time.Second    // Time allowed to write a message
@@ -54,88 +54,164 @@ type WSMessage struct {
 	Content   string  `json:"content,omitempty"`
 	ChannelID string  `json:"channel_id,omitempty"`
 	ParentID  *string `json:"parent_id,omitempty"` // For thread replies
-}
 
-// WSOutMessage represents an outgoing WebSocket message
-type WSOutMessage struct {
-	Type      string      `json:"type"`
-	Payload   interface{} `json:"payload,omitempty"`
-	ChannelID string      `json:"channel_id,omitempty"`
+	ConversationID string `json:"conversation_id,omitempty"` // For open_dm / close_dm
+	NotificationID string `json:"notification_id,omitempty"` // For notification_ack
+	MessageID      string `json:"message_id,omitempty"`      // For cancel_send
 }
 
-func (h *Handler) HandleWS(c *gin.Context) {
-	projectID := c.Query("project_id")
-	channelID := c.Query("channel_id")
+type WSTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresAt string `json:"expires_at"`
+}
 
-	if projectID == "" {
-		c.AbortWithStatus(400)
+// HandleCreateWSTicket issues a one-time ticket for opening a WebSocket or
+// SSE connection with ?ticket= instead of the session token
+func (h *Handler) HandleCreateWSTicket(c *gin.Context) {
+	ticket, expires, err := middleware.IssueWSTicket(c)
+	if errors.Is(err, middleware.ErrTicketNotAllowed) {
+		problem.Respond(c, 400, "send the key in the Authorization header when connecting instead")
+		return
+	}
+	if err != nil {
+		log.Printf("[WS] failed to issue ticket: %v", err)
+		problem.Respond(c, 500, "failed to issue ticket")
 		return
 	}
+	c.JSON(201, WSTicketResponse{Ticket: ticket, ExpiresAt: utils.FormatTime(expires)})
+}
+
+// loopRoom is the loop-wide room every connected member joins alongside
+// their current channel, for events that aren't tied to one channel.
+func loopRoom(projectID string) string {
+	return "loop:" + projectID
+}
+
+// chatTarget is the loop and channel a realtime connection follows
+type chatTarget struct {
+	userID      pgtype.UUID
+	user        db.User
+	role        string
+	projectID   string
+	projectUUID pgtype.UUID
+	channelID   string
+	channelUUID pgtype.UUID
+	// Guests are confined to the guest channels for the whole connection
+	guestChannels map[pgtype.UUID]bool
+}
+
+func (t chatTarget) channelAllowed(id pgtype.UUID) bool {
+	return t.role != members.Guest || t.guestChannels[id]
+}
+
+// resolveChatTarget checks the caller's membership in ?project_id and picks
+// the channel to follow: ?channel_id, or the loop's default channel. It
+// aborts with a problem and returns false when the connection can't open.
+func (h *Handler) resolveChatTarget(c *gin.Context) (chatTarget, bool) {
+	t := chatTarget{
+		projectID: c.Query("project_id"),
+		channelID: c.Query("channel_id"),
+	}
+	if t.projectID == "" {
+		problem.Abort(c, 400, "project_id required")
+		return t, false
+	}
 
 	// User ID should be set by auth middleware
 	userIDVal, exists := c.Get("user_id")
 	if !exists {
-		c.AbortWithStatus(401)
-		return
+		problem.Abort(c, 401, "unauthorized")
+		return t, false
 	}
-	userID := userIDVal.(pgtype.UUID)
+	t.userID = userIDVal.(pgtype.UUID)
 
 	// Fetch user info ONCE on connect (cache in client)
-	user, err := h.Queries.GetUserByID(c, userID)
+	user, err := h.getUserByID(c, t.userID)
 	if err != nil {
-		c.AbortWithStatus(500)
-		return
+		problem.Abort(c, 500, "failed to get user")
+		return t, false
 	}
+	t.user = user
 
 	// Verify membership ONCE on connect
-	projectUUID, err := utils.StrToUUID(projectID)
+	t.projectUUID, err = utils.StrToUUID(t.projectID)
 	if err != nil {
-		c.AbortWithStatus(400)
-		return
+		problem.Abort(c, 400, "invalid project_id")
+		return t, false
 	}
 
-	if _, err := h.Queries.IsMember(c, db.IsMemberParams{
-		UserID: userID, ProjectID: projectUUID,
-	}); err != nil {
-		c.AbortWithStatus(403)
-		return
+	t.role = h.Members.Role(c, t.userID, t.projectUUID)
+	if t.role == "" {
+		problem.Abort(c, 403, "not a member")
+		return t, false
+	}
+	if t.role == members.Guest {
+		t.guestChannels = h.Members.GuestChannels(c, t.projectUUID)
 	}
 
 	// Determine the channel to join
-	var channelUUID pgtype.UUID
-	if channelID != "" {
-		channelUUID, err = utils.StrToUUID(channelID)
+	if t.channelID != "" {
+		t.channelUUID, err = utils.StrToUUID(t.channelID)
 		if err != nil {
-			c.AbortWithStatus(400)
-			return
+			problem.Abort(c, 400, "invalid channel_id")
+			return t, false
 		}
 		// Verify channel belongs to project
-		channel, err := h.Queries.GetChannelByID(c, channelUUID)
-		if err != nil || utils.UUIDToStr(channel.ProjectID) != projectID {
-			c.AbortWithStatus(400)
-			return
+		channel, err := h.Queries.GetChannelByID(c, t.channelUUID)
+		if err != nil || utils.UUIDToStr(channel.ProjectID) != t.projectID {
+			problem.Abort(c, 400, "channel not in this loop")
+			return t, false
 		}
 	} else {
 		// Get default channel
-		channel, err := h.Queries.GetDefaultChannel(c, projectUUID)
+		channel, err := h.Queries.GetDefaultChannel(c, t.projectUUID)
 		if err != nil {
 			// Try to get any channel
-			channels, err := h.Queries.GetChannelsByProject(c, projectUUID)
+			channels, err := h.Queries.GetChannelsByProject(c, t.projectUUID)
 			if err != nil || len(channels) == 0 {
-				c.AbortWithStatus(500)
-				return
+				problem.Abort(c, 500, "loop has no channels")
+				return t, false
 			}
-			channelUUID = channels[0].ID
-			channelID = utils.UUIDToStr(channelUUID)
+			t.channelUUID = channels[0].ID
 		} else {
-			channelUUID = channel.ID
-			channelID = utils.UUIDToStr(channelUUID)
+			t.channelUUID = channel.ID
 		}
+		t.channelID = utils.UUIDToStr(t.channelUUID)
+	}
+
+	if !t.channelAllowed(t.channelUUID) {
+		problem.Abort(c, 403, "guests can only join guest channels")
+		return t, false
+	}
+	return t, true
+}
+
+func (h *Handler) HandleWS(c *gin.Context) {
+	// Checked here too so refusals are counted before the upgrader sees them
+	if !wsOriginAllowed(c.Request) {
+		log.Printf("[WS] rejected origin: %s", c.GetHeader("Origin"))
+		h.Hub.RejectOrigin()
+		problem.Abort(c, 403, "origin not allowed")
+		return
+	}
+	// A socket can send messages, which a read-only support session must not do
+	if _, impersonating := middleware.ImpersonationSession(c); impersonating {
+		problem.Abort(c, 403, "impersonation sessions are read-only")
+		return
 	}
 
+	target, ok := h.resolveChatTarget(c)
+	if !ok {
+		return
+	}
+	userID, user, role := target.userID, target.user, target.role
+	projectID, projectUUID := target.projectID, target.projectUUID
+	channelID, channelUUID := target.channelID, target.channelUUID
+	channelAllowed := target.channelAllowed
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
-		fmt.Printf("[WS] Upgrade error: %v\n", err)
+		log.Printf("[WS] upgrade failed: %v", err)
 		return
 	}
 
@@ -148,26 +224,34 @@ func (h *Handler) HandleWS(c *gin.Context) {
 	})
 
 	// Create client with cached user info - no more DB lookups per message!
-	client := chat.NewClient(conn, userID, user.Username, user.AvatarUrl.String)
+	client := chat.NewClient(conn, userID, user.Username, mediaURL(user.AvatarUrl.String))
+	privacy, err := h.presencePrivacyFor(c, userID)
+	if err != nil {
+		log.Printf("[WS] failed to load presence settings: %v", err)
+	}
+	client.SetPrivacy(privacy)
+	// Closes the user's oldest connection if this one puts them over the limit
+	h.Hub.Connect(client)
 
 	// Room is now channel-specific for more granular messaging
 	roomID := channelID
 	h.Hub.Join(roomID, client)
-
-	fmt.Printf("[WS] %s joined channel %s in project %s\n", user.Username, channelID, projectID)
+	h.Hub.Join(loopRoom(projectID), client)
+	h.Hub.Presence(loopRoom(projectID), client, true, presenceMessage(projectID, utils.UUIDToStr(userID), true))
+	h.touchMember(userID, projectUUID)
 
 	// Send channel info on connect
-	client.Send(WSOutMessage{
-		Type:      "connected",
+	client.Send(events.Wrap(events.ConnectedInfo{
 		ChannelID: channelID,
-		Payload: gin.H{
-			"channel_id": channelID,
-			"project_id": projectID,
-		},
-	})
+		ProjectID: projectID,
+		Online:    h.Hub.Online(loopRoom(projectID)),
+	}, channelID))
 
 	go client.Write()
 
+	// Group DM rooms this connection has open, left on disconnect
+	dmRooms := make(map[string]bool)
+
 	// Start ping ticker for connection health monitoring
 	ticker := time.NewTicker(pingPeriod)
 	done := make(chan struct{})
@@ -213,12 +297,21 @@ func (h *Handler) HandleWS(c *gin.Context) {
 				parsedUUID, err := utils.StrToUUID(msg.ChannelID)
 				if err == nil {
 					ch, err := h.Queries.GetChannelByID(c, parsedUUID)
-					if err == nil && utils.UUIDToStr(ch.ProjectID) == projectID {
+					if err == nil && utils.UUIDToStr(ch.ProjectID) == projectID && channelAllowed(parsedUUID) {
 						msgChannelID = msg.ChannelID
 						msgChannelUUID = parsedUUID
 					}
 				}
 			}
+			if reason := members.GuestPostAllowed(role, msg.Content); reason != "" {
+				client.Send(events.Wrap(events.Rejection{Reason: reason}, msgChannelID))
+				continue
+			}
+			// Long posts go over REST, where the body limit allows them
+			if reason := h.messageTooLong(msg.Content); reason != "" {
+				client.Send(events.Wrap(events.Rejection{Reason: reason, Limit: h.MaxMessageChars}, msgChannelID))
+				continue
+			}
 			h.handleWSMessage(client, msgChannelID, projectUUID, msgChannelUUID, msg.Content, msg.ParentID)
 		case "switch_channel":
 			// Switch to a different channel
@@ -226,29 +319,67 @@ func (h *Handler) HandleWS(c *gin.Context) {
 				newChannelUUID, err := utils.StrToUUID(msg.ChannelID)
 				if err == nil {
 					ch, err := h.Queries.GetChannelByID(c, newChannelUUID)
-					if err == nil && utils.UUIDToStr(ch.ProjectID) == projectID {
+					if err == nil && utils.UUIDToStr(ch.ProjectID) == projectID && channelAllowed(newChannelUUID) {
 						// Leave old room, join new room
 						h.Hub.Leave(roomID, client)
 						roomID = msg.ChannelID
 						channelID = msg.ChannelID
 						channelUUID = newChannelUUID
 						h.Hub.Join(roomID, client)
-						client.Send(WSOutMessage{
-							Type:      "channel_switched",
-							ChannelID: channelID,
-						})
-						fmt.Printf("[WS] %s switched to channel %s\n", user.Username, channelID)
+						client.Send(events.Wrap(events.ChannelSwitched, channelID))
 					}
 				}
 			}
+		case "typing":
+			// Only the channel the connection follows; the hub drops it if the
+			// user doesn't send typing indicators
+			h.Hub.Typing(roomID, client, events.Wrap(events.TypingUser{
+				UserID:   utils.UUIDToStr(userID),
+				Username: user.Username,
+			}, channelID))
+		case "open_dm":
+			convUUID, err := utils.StrToUUID(msg.ConversationID)
+			if err != nil {
+				continue
+			}
+			if _, err := h.Queries.IsDMParticipant(c, db.IsDMParticipantParams{ConversationID: convUUID, UserID: userID}); err != nil {
+				continue
+			}
+			room := dmRoom(msg.ConversationID)
+			if !dmRooms[room] {
+				dmRooms[room] = true
+				h.Hub.Join(room, client)
+			}
+		case "close_dm":
+			room := dmRoom(msg.ConversationID)
+			if dmRooms[room] {
+				delete(dmRooms, room)
+				h.Hub.Leave(room, client)
+			}
+		case "notification_ack":
+			// The client got a notification push; without this the fallback
+			// transports take over once notify.AckWindow passes
+			if nid, err := strconv.ParseInt(msg.NotificationID, 10, 64); err == nil {
+				if _, err := h.Notifier.Ack(c, userID, nid); err != nil {
+					log.Printf("[WS] failed to ack notification %d: %v", nid, err)
+				}
+			}
+		case "cancel_send":
+			// Within the sender's undo window a message can still be withdrawn
+			cancelHeldMessage(client, channelID, msg.MessageID)
 		case "ping":
-			client.Send(WSOutMessage{Type: "pong"})
+			client.Send(events.Wrap(events.Pong, ""))
 		}
 	}
 
+	for room := range dmRooms {
+		h.Hub.Leave(room, client)
+	}
 	h.Hub.Leave(roomID, client)
+	h.Hub.Leave(loopRoom(projectID), client)
+	h.Hub.Presence(loopRoom(projectID), client, false, presenceMessage(projectID, utils.UUIDToStr(userID), false))
+	h.Hub.Disconnect(client)
 	client.Close()
-	fmt.Printf("[WS] %s left channel %s\n", user.Username, channelID)
 }
 
 func (h *Handler) handleWSMessage(client *chat.Client, roomID string, projectUUID pgtype.UUID, channelUUID pgtype.UUID, content string, parentIDStr *string) {
@@ -276,38 +407,120 @@ func (h *Handler) handleWSMessage(client *chat.Client, roomID string, projectUUI
 		SenderID:       utils.UUIDToStr(client.UserID),
 		SenderUsername: client.Username,
 		SenderAvatar:   client.AvatarURL,
-		CreatedAt:      now.Format(time.RFC3339),
+		CreatedAt:      utils.FormatTime(now),
+		CreatedAtMs:    utils.EpochMillis(now),
 		ChannelID:      roomID,
 		ParentID:       parentIDResponse,
 		ReplyCount:     0,
+		Entities:       h.messageEntities(context.Background(), projectUUID, content),
 	}
 
-	// Broadcast IMMEDIATELY to all clients in this channel (including sender for confirmation)
-	h.Hub.Broadcast(roomID, WSOutMessage{
-		Type:      "message",
-		Payload:   msgResponse,
-		ChannelID: roomID,
-	})
+	link, linked := h.channelGitHubLink(context.Background(), channelUUID)
+	if linked && link.ArchivedAt.Valid {
+		client.Send(events.Wrap(events.Rejection{Reason: "this channel was archived when its GitHub " + link.Kind + " closed"}, roomID))
+		return
+	}
+	if h.loopArchived(context.Background(), projectUUID) {
+		client.Send(events.Wrap(events.Rejection{Reason: loopArchivedReason}, roomID))
+		return
+	}
 
-	// Async DB write - don't block the response!
-	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
-		if err := h.Queries.AddMessage(ctx, db.AddMessageParams{
+	if cmd, isCommand, err := parseDeployCommand(content); isCommand {
+		if err != nil {
+			client.Send(events.Wrap(events.CommandFailure{Command: "deploy", Error: err.Error()}, roomID))
+			return
+		}
+		go h.runDeployCommand(client, roomID, projectUUID, channelUUID, cmd)
+		return
+	}
+
+	// Filters run before anything is broadcast or stored
+	verdict := h.screenMessage(context.Background(), msgID, projectUUID, channelUUID, client.UserID, parentID, content)
+	switch verdict.Action {
+	case msgfilter.ActionBlock:
+		client.Send(events.Wrap(events.Rejection{Reason: blockedReason(verdict), Rule: string(verdict.Rule)}, roomID))
+		return
+	case msgfilter.ActionHold:
+		// Shadow hold: only the sender sees the message until it is approved
+		client.Send(events.Wrap(events.Of(events.Message, msgResponse), roomID))
+		return
+	}
+	// send counts, numbers, broadcasts and stores the message
+	send := func() {
+		// Membership is checked on connect; a ban or removal since then
+		// must stop the open connection from posting too
+		if h.Members.Role(context.Background(), client.UserID, projectUUID) == "" {
+			client.Send(events.Wrap(events.Rejection{Reason: "you are no longer a member of this loop"}, roomID))
+			return
+		}
+		if err := h.Quotas.UseMessage(context.Background(), projectUUID); err != nil {
+			client.Send(events.Wrap(events.Rejection{Reason: err.Error(), Quota: string(quota.MessagesPerDay)}, roomID))
+			return
+		}
+
+		// Numbered before the broadcast so every client sees the same order
+		seq := h.nextSeq(context.Background(), channelUUID, parentID)
+		msgResponse.Seq = seq.Int64
+
+		// Broadcast IMMEDIATELY to all clients in this channel (including sender for confirmation)
+		h.Events.PublishChannelFrom(roomID, events.Of(events.Message, msgResponse), now)
+
+		// Async DB write - don't block the response!
+		arg := db.AddMessageParams{
 			ID:        msgID,
 			SenderID:  client.UserID,
 			Content:   content,
 			ProjectID: projectUUID,
 			ChannelID: channelUUID,
 			ParentID:  parentID,
-		}); err != nil {
-			fmt.Printf("[WS] Failed to persist message: %v\n", err)
+			Seq:       seq,
 		}
-		// If this is a reply, increment the parent's reply count
-		if parentID.Valid {
-			h.Queries.IncrementReplyCount(ctx, parentID.Int64)
+		persisted := func(err error) {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err != nil {
+				log.Printf("[WS] failed to persist message: %v", err)
+				// The message was already broadcast, so it is lost on reload;
+				// the sender hears so, and nothing counts or notifies it
+				errreport.Capture(nil, err, map[string]string{"component": "ws", "op": "persist_message"})
+				client.Send(events.Wrap(events.Rejection{
+					MessageID: msgResponse.ID,
+					ChannelID: roomID,
+					Reason:    "the message could not be saved",
+				}, roomID))
+				return
+			}
+			if !parentID.Valid {
+				if linked && link.CrossPost {
+					h.queueCrossPost(ctx, msgID, channelUUID, client.UserID)
+				}
+				h.completeOnboarding(ctx, projectUUID, client.UserID, onboardingPostInChannel, channelUUID)
+				h.dispatchIntegrations(ctx, projectUUID, messageEvent(msgResponse))
+			}
+			h.touchMember(client.UserID, projectUUID)
+			h.queueGitHubRefs(ctx, msgID, content, utils.UUIDToStr(client.UserID))
+			// If this is a reply, increment the parent's reply count
+			if parentID.Valid {
+				h.Queries.IncrementReplyCount(ctx, parentID.Int64)
+				h.queueThreadSummary(ctx, parentID.Int64)
+			}
+			// Process @mentions and create notifications
+			h.ProcessMentions(ctx, content, client.UserID, client.Username, msgID, projectUUID, channelUUID)
 		}
-		// Process @mentions and create notifications
-		h.ProcessMentions(ctx, content, client.UserID, client.Username, msgID, projectUUID, channelUUID)
-	}()
+		if h.Messages != nil {
+			h.Messages.Add(arg, persisted)
+			return
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			persisted(h.Queries.AddMessage(ctx, arg))
+		}()
+	}
+	// A held message is only sent once the sender's undo window passes
+	if window := h.undoSendWindow(context.Background(), client.UserID); window > 0 {
+		holdMessage(client, roomID, msgID, window, send)
+		return
+	}
+	send()
 }
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+)
+
+func TestBannedMemberCannotPostFromOpenConnection(t *testing.T) {
+	it := newIntegration(t)
+	ctx := context.Background()
+	owner := it.user("maintainer", 1)
+	member := it.user("troll", 2)
+	repo := it.gh.AddRepo("maintainer", "app", false)
+	project := it.loop("app", repo, owner, nil)
+	if err := it.h.Queries.AddMembership(ctx, db.AddMembershipParams{UserID: member.ID, ProjectID: project.ID}); err != nil {
+		t.Fatal(err)
+	}
+	channel, err := it.h.Queries.CreateChannel(ctx, db.CreateChannelParams{ProjectID: project.ID, Name: "general"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	room := utils.UUIDToStr(channel.ID)
+
+	// Connected while still a member, then banned
+	client := chat.NewStreamClient(member.ID, member.Username, "")
+	it.h.Hub.Join(room, client)
+	defer func() {
+		it.h.Hub.Leave(room, client)
+		client.Close()
+	}()
+	if err := it.h.Queries.BanFromLoop(ctx, db.BanFromLoopParams{ProjectID: project.ID, UserID: member.ID, BannedBy: owner.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := it.h.Queries.RemoveMembership(ctx, db.RemoveMembershipParams{UserID: member.ID, ProjectID: project.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	it.h.handleWSMessage(client, room, project.ID, channel.ID, "still here", nil)
+
+	wait, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	ev, ok := client.Receive(wait)
+	if !ok {
+		t.Fatal("no answer to the banned member's message")
+	}
+	env, _ := ev.Data.(events.Envelope)
+	if _, rejected := env.Payload.(events.Rejection); !rejected {
+		t.Fatalf("got %+v, want a rejection", ev.Data)
+	}
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Every token names who issued it and who it is for, so a token minted by
+// another service sharing a key isn't accepted as a session
+const (
+	Issuer   = "wireloop"
+	Audience = "wireloop-api"
+)
+
+// ErrNoSecret is returned by CheckKeys when neither JWT_SECRET nor a
+// signing key is set
+var ErrNoSecret = errors.New("neither JWT_SECRET nor JWT_SIGNING_KEY is set")
+
+// GenerateState creates a random state token for CSRF protection
+func GenerateState() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// Fallback — should never happen
+		return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// SessionTTL is how long a login lasts
+const SessionTTL = 60 * 24 * time.Hour
+
+// GenerateJWT creates a JWT token for the authenticated user. The "sid" claim
+// ties it to a sessions row so it can be revoked before it expires.
+func GenerateJWT(userID pgtype.UUID, sessionID string, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID.Bytes,
+		"sid":     sessionID,
+		"exp":     expiresAt.Unix(),
+		"iat":     time.Now().Unix(),
+		"iss":     Issuer,
+		"aud":     Audience,
+	}
+
+	return sign(claims)
+}
+
+// GenerateImpersonationJWT creates a short-lived token that acts as userID for
+// a support session. The "imp" claim carries the session ID; the auth
+// middleware makes such tokens read-only.
+func GenerateImpersonationJWT(userID pgtype.UUID, sessionID string, expiresAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID.Bytes,
+		"imp":     sessionID,
+		"exp":     expiresAt.Unix(),
+		"iat":     time.Now().Unix(),
+		"iss":     Issuer,
+		"aud":     Audience,
+	}
+
+	return sign(claims)
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Asymmetric signing is opt-in. With JWT_SIGNING_KEY (or _FILE) set to a
+// PEM P-256 private key, sessions are signed ES256 and carry a kid, and the
+// public half is published at /.well-known/jwks.json so other services can
+// verify tokens without the secret. JWT_PREVIOUS_PUBLIC_KEYS (or _FILE) holds
+// PEM public keys still accepted after a rotation. While JWT_SECRET is set,
+// HS256 tokens issued before the switch keep working until they expire.
+
+type publicKey struct {
+	kid string
+	key *ecdsa.PublicKey
+}
+
+type keySet struct {
+	secret  []byte
+	signer  *ecdsa.PrivateKey
+	kid     string
+	publics []publicKey // current first
+}
+
+var loadedKeys = sync.OnceValues(loadKeys)
+
+func keys() *keySet {
+	ks, _ := loadedKeys()
+	return ks
+}
+
+func envPEM(name string) (string, error) {
+	if v := os.Getenv(name); v != "" {
+		// Single-line env values carry literal \n
+		return strings.ReplaceAll(v, `\n`, "\n"), nil
+	}
+	if path := os.Getenv(name + "_FILE"); path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("%s_FILE: %w", name, err)
+		}
+		return string(b), nil
+	}
+	return "", nil
+}
+
+func loadKeys() (*keySet, error) {
+	ks := &keySet{secret: []byte(os.Getenv("JWT_SECRET"))}
+
+	signing, err := envPEM("JWT_SIGNING_KEY")
+	if err != nil {
+		return ks, err
+	}
+	if signing != "" {
+		block, _ := pem.Decode([]byte(signing))
+		if block == nil {
+			return ks, errors.New("JWT_SIGNING_KEY is not PEM")
+		}
+		priv, err := parseECPrivateKey(block.Bytes)
+		if err != nil {
+			return ks, fmt.Errorf("JWT_SIGNING_KEY: %w", err)
+		}
+		ks.signer = priv
+		ks.kid = thumbprint(&priv.PublicKey)
+		ks.publics = append(ks.publics, publicKey{kid: ks.kid, key: &priv.PublicKey})
+	}
+
+	previous, err := envPEM("JWT_PREVIOUS_PUBLIC_KEYS")
+	if err != nil {
+		return ks, err
+	}
+	rest := []byte(previous)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		ec, ok := pub.(*ecdsa.PublicKey)
+		if err != nil || !ok || ec.Curve != elliptic.P256() {
+			return ks, errors.New("JWT_PREVIOUS_PUBLIC_KEYS must hold P-256 public keys")
+		}
+		ks.publics = append(ks.publics, publicKey{kid: thumbprint(ec), key: ec})
+	}
+
+	if len(ks.secret) == 0 && ks.signer == nil {
+		return ks, ErrNoSecret
+	}
+	return ks, nil
+}
+
+func parseECPrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+	key, err := x509.ParseECPrivateKey(der)
+	if err != nil {
+		pk, err8 := x509.ParsePKCS8PrivateKey(der)
+		if err8 != nil {
+			return nil, err
+		}
+		var ok bool
+		if key, ok = pk.(*ecdsa.PrivateKey); !ok {
+			return nil, errors.New("not an EC private key")
+		}
+	}
+	if key.Curve != elliptic.P256() {
+		return nil, errors.New("only P-256 keys are supported")
+	}
+	return key, nil
+}
+
+// coords returns the fixed-width x and y of a P-256 key
+func coords(k *ecdsa.PublicKey) (x, y string) {
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc(k.X.FillBytes(make([]byte, 32))), enc(k.Y.FillBytes(make([]byte, 32)))
+}
+
+// thumbprint is the RFC 7638 JWK thumbprint, used as the kid
+func thumbprint(k *ecdsa.PublicKey) string {
+	x, y := coords(k)
+	sum := sha256.Sum256([]byte(`{"crv":"P-256","kty":"EC","x":"` + x + `","y":"` + y + `"}`))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// CheckKeys loads the signing configuration; the server refuses to start
+// when it is missing or unreadable
+func CheckKeys() error {
+	_, err := loadedKeys()
+	return err
+}
+
+func sign(claims jwt.MapClaims) (string, error) {
+	ks := keys()
+	if ks.signer != nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = ks.kid
+		return token.SignedString(ks.signer)
+	}
+	if len(ks.secret) == 0 {
+		return "", ErrNoSecret
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(ks.secret)
+}
+
+// ValidMethods are the algorithms a session token may use: ES256 when a
+// signing key is configured, HS256 while JWT_SECRET is set
+func ValidMethods() []string {
+	ks := keys()
+	var methods []string
+	if len(ks.publics) > 0 {
+		methods = append(methods, jwt.SigningMethodES256.Alg())
+	}
+	if len(ks.secret) > 0 {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	return methods
+}
+
+// Keyfunc returns the verification key for a token. The key type always
+// follows the token's algorithm, so an HS256 token can't be checked against
+// a public key.
+func Keyfunc(t *jwt.Token) (any, error) {
+	ks := keys()
+	switch t.Method.Alg() {
+	case jwt.SigningMethodHS256.Alg():
+		if len(ks.secret) == 0 {
+			return nil, jwt.ErrTokenUnverifiable
+		}
+		return ks.secret, nil
+	case jwt.SigningMethodES256.Alg():
+		kid, _ := t.Header["kid"].(string)
+		for _, pk := range ks.publics {
+			if pk.kid == kid {
+				return pk.key, nil
+			}
+		}
+	}
+	return nil, jwt.ErrTokenUnverifiable
+}
+
+// JWK is one public key in a JWKS document
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+}
+
+// JWKS returns the current and previous verification keys. It is empty
+// when tokens are only signed with the shared secret.
+func JWKS() json.RawMessage {
+	ks := keys()
+	set := struct {
+		Keys []JWK `json:"keys"`
+	}{Keys: make([]JWK, 0, len(ks.publics))}
+	for _, pk := range ks.publics {
+		x, y := coords(pk.key)
+		set.Keys = append(set.Keys, JWK{Kty: "EC", Crv: "P-256", X: x, Y: y, Kid: pk.kid, Use: "sig", Alg: "ES256"})
+	}
+	b, _ := json.Marshal(set)
+	return b
+}
@@ -0,0 +1,204 @@
+// Package backup snapshots the core of a Wireloop instance (users, loops,
+// channels, messages and the rows that tie them together) into a portable
+// archive and replays one into an empty database.
+//
+// An archive is a gzipped stream of JSON lines: a Header, then one record per
+// row in restore order. Rows are written with row_to_json and read back with
+// json_populate_record, so the archive doesn't depend on pg_dump versions but
+// does expect the target to be migrated to the same schema version. Archives
+// include users' GitHub tokens; store them as carefully as a database dump.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	Format  = "wireloop-backup"
+	Version = 1
+
+	restoreBatchSize = 500
+)
+
+// Tables in restore order, parents before children. Messages are written
+// oldest first so thread parents exist before their replies.
+var Tables = []string{"users", "workspaces", "workspace_members", "projects", "rules", "memberships", "channels", "messages"}
+
+var orderBy = map[string]string{
+	"messages": " ORDER BY id",
+}
+
+// ErrNotEmpty is returned by Restore when the target already holds data
+var ErrNotEmpty = errors.New("backup: target database is not empty")
+
+// Header is the first line of an archive
+type Header struct {
+	Format        string    `json:"format"`
+	Version       int       `json:"version"`
+	CreatedAt     time.Time `json:"created_at"`
+	SchemaVersion int64     `json:"schema_version,omitempty"` // goose version, 0 if unknown
+	Tables        []string  `json:"tables"`
+}
+
+type record struct {
+	Table string          `json:"table"`
+	Row   json.RawMessage `json:"row"`
+}
+
+// Stats counts rows per table
+type Stats map[string]int64
+
+// schemaVersion returns the latest applied goose migration, or 0 when the
+// database isn't managed by goose
+func schemaVersion(ctx context.Context, q interface {
+	QueryRow(context.Context, string, ...any) pgx.Row
+}) int64 {
+	var v int64
+	if err := q.QueryRow(ctx, `SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied`).Scan(&v); err != nil {
+		return 0
+	}
+	return v
+}
+
+// Write streams a consistent snapshot of Tables to w
+func Write(ctx context.Context, pool *pgxpool.Pool, w io.Writer) (Stats, error) {
+	tx, err := pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	zw := gzip.NewWriter(w)
+	enc := json.NewEncoder(zw)
+	if err := enc.Encode(Header{
+		Format:        Format,
+		Version:       Version,
+		CreatedAt:     time.Now().UTC(),
+		SchemaVersion: schemaVersion(ctx, tx),
+		Tables:        Tables,
+	}); err != nil {
+		return nil, err
+	}
+
+	stats := make(Stats, len(Tables))
+	for _, table := range Tables {
+		rows, err := tx.Query(ctx, "SELECT row_to_json(t)::text FROM "+table+" t"+orderBy[table])
+		if err != nil {
+			return nil, fmt.Errorf("backup: reading %s: %w", table, err)
+		}
+		for rows.Next() {
+			var row string
+			if err := rows.Scan(&row); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			if err := enc.Encode(record{Table: table, Row: json.RawMessage(row)}); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			stats[table]++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("backup: reading %s: %w", table, err)
+		}
+	}
+	return stats, zw.Close()
+}
+
+// Restore replays an archive into pool in a single transaction. The target
+// must be migrated to the archive's schema version and hold no rows in any
+// of the archived tables.
+func Restore(ctx context.Context, pool *pgxpool.Pool, r io.Reader) (Stats, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("backup: not an archive: %w", err)
+	}
+	defer zr.Close()
+	dec := json.NewDecoder(bufio.NewReader(zr))
+
+	var hdr Header
+	if err := dec.Decode(&hdr); err != nil || hdr.Format != Format {
+		return nil, errors.New("backup: not a wireloop archive")
+	}
+	if hdr.Version != Version {
+		return nil, fmt.Errorf("backup: unsupported archive version %d", hdr.Version)
+	}
+
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(context.Background())
+
+	if v := schemaVersion(ctx, tx); hdr.SchemaVersion != 0 && v != 0 && v != hdr.SchemaVersion {
+		return nil, fmt.Errorf("backup: archive is from schema version %d but the database is at %d", hdr.SchemaVersion, v)
+	}
+
+	// Table names are only ever taken from this map, never from the archive
+	inserts := make(map[string]string, len(Tables))
+	for _, table := range Tables {
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM "+table+")").Scan(&exists); err != nil {
+			return nil, err
+		}
+		if exists {
+			return nil, fmt.Errorf("%w (%s has rows)", ErrNotEmpty, table)
+		}
+		inserts[table] = "INSERT INTO " + table + " SELECT * FROM json_populate_record(NULL::" + table + ", $1::json)"
+	}
+
+	stats := make(Stats, len(Tables))
+	batch := &pgx.Batch{}
+	flush := func() error {
+		if batch.Len() == 0 {
+			return nil
+		}
+		err := tx.SendBatch(ctx, batch).Close()
+		batch = &pgx.Batch{}
+		return err
+	}
+	current := ""
+	for {
+		var rec record
+		err := dec.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("backup: corrupt archive: %w", err)
+		}
+		stmt, ok := inserts[rec.Table]
+		if !ok {
+			return nil, fmt.Errorf("backup: unknown table %q in archive", rec.Table)
+		}
+		// Children may reference any parent row, so finish each table first
+		if rec.Table != current {
+			if err := flush(); err != nil {
+				return nil, fmt.Errorf("backup: restoring %s: %w", current, err)
+			}
+			current = rec.Table
+		}
+		batch.Queue(stmt, string(rec.Row))
+		stats[rec.Table]++
+		if batch.Len() >= restoreBatchSize {
+			if err := flush(); err != nil {
+				return nil, fmt.Errorf("backup: restoring %s: %w", current, err)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return nil, fmt.Errorf("backup: restoring %s: %w", current, err)
+	}
+	return stats, tx.Commit(ctx)
+}
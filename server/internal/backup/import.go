@@ -0,0 +1,239 @@
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ImportChunkSize is how many messages ImportMessages copies per transaction
+const ImportChunkSize = 10000
+
+// sonyflakeEpoch is the start time of the IDs utils.GetMessageId hands out.
+// Generated IDs use the same layout (10ms ticks above 24 bits) so imported
+// history sorts among live messages by time.
+var sonyflakeEpoch = time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+
+// ArchiveMessage is one line of a message archive. Without an id, one is
+// derived from created_at; a reply's parent_id must name a message in the
+// database or earlier in the archive.
+type ArchiveMessage struct {
+	ID        int64     `json:"id,omitempty"`
+	ChannelID string    `json:"channel_id"`
+	SenderID  string    `json:"sender_id"`
+	Content   string    `json:"content"`
+	ParentID  int64     `json:"parent_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ImportProgress is reported after each committed chunk
+type ImportProgress struct {
+	Messages int64         `json:"messages"`
+	LastID   int64         `json:"last_id"` // highest ID committed so far
+	Elapsed  time.Duration `json:"elapsed"`
+}
+
+// Rate returns messages imported per second so far
+func (p ImportProgress) Rate() float64 {
+	if p.Elapsed <= 0 {
+		return 0
+	}
+	return float64(p.Messages) / p.Elapsed.Seconds()
+}
+
+var messageImportColumns = []string{"id", "project_id", "channel_id", "sender_id", "content", "parent_id", "created_at"}
+
+// MessageSource yields archive messages in order and io.EOF after the last
+type MessageSource func() (ArchiveMessage, error)
+
+// ImportMessages loads a message archive (JSON lines of ArchiveMessage,
+// optionally gzipped, oldest first) with ImportMessageSource
+func ImportMessages(ctx context.Context, pool *pgxpool.Pool, r io.Reader, progress func(ImportProgress)) (ImportProgress, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return ImportProgress{}, fmt.Errorf("backup: reading archive: %w", err)
+		}
+		defer zr.Close()
+		br = bufio.NewReaderSize(zr, 1<<16)
+	}
+	dec := json.NewDecoder(br)
+	line := 0
+	return ImportMessageSource(ctx, pool, func() (ArchiveMessage, error) {
+		var m ArchiveMessage
+		line++
+		err := dec.Decode(&m)
+		if err != nil && !errors.Is(err, io.EOF) {
+			err = fmt.Errorf("archive line %d: %w", line, err)
+		}
+		return m, err
+	}, progress)
+}
+
+// ImportMessageSource writes messages straight into the messages table with
+// COPY, bypassing the chat Hub, notifications and mention processing. Each
+// chunk commits on its own; on failure the error says how far the import got
+// so the rest can be loaded after fixing it.
+func ImportMessageSource(ctx context.Context, pool *pgxpool.Pool, next MessageSource, progress func(ImportProgress)) (ImportProgress, error) {
+	imp := &importer{
+		pool:     pool,
+		projects: make(map[[16]byte]pgtype.UUID),
+		senders:  make(map[[16]byte]bool),
+		channels: make(map[[16]byte]bool),
+		start:    time.Now(),
+	}
+	chunk := make([][]any, 0, ImportChunkSize)
+	for n := 1; ; n++ {
+		m, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return imp.done, fmt.Errorf("backup: %w", err)
+		}
+		row, err := imp.row(ctx, m)
+		if err != nil {
+			return imp.done, fmt.Errorf("backup: message %d: %w", n, err)
+		}
+		chunk = append(chunk, row)
+		if len(chunk) == ImportChunkSize {
+			if err := imp.copy(ctx, chunk, progress); err != nil {
+				return imp.done, err
+			}
+			chunk = chunk[:0]
+		}
+	}
+	if len(chunk) > 0 {
+		if err := imp.copy(ctx, chunk, progress); err != nil {
+			return imp.done, err
+		}
+	}
+	if err := imp.fixReplyCounts(ctx); err != nil {
+		return imp.done, fmt.Errorf("backup: updating reply counts: %w", err)
+	}
+	return imp.done, nil
+}
+
+type importer struct {
+	pool     *pgxpool.Pool
+	projects map[[16]byte]pgtype.UUID // channel -> project
+	senders  map[[16]byte]bool
+	channels map[[16]byte]bool // channels that received replies
+	ids      IDGenerator
+	start    time.Time
+	done     ImportProgress
+}
+
+// row validates m and turns it into a COPY row
+func (imp *importer) row(ctx context.Context, m ArchiveMessage) ([]any, error) {
+	var channel, sender pgtype.UUID
+	if err := channel.Scan(m.ChannelID); err != nil || !channel.Valid {
+		return nil, fmt.Errorf("invalid channel_id %q", m.ChannelID)
+	}
+	if err := sender.Scan(m.SenderID); err != nil || !sender.Valid {
+		return nil, fmt.Errorf("invalid sender_id %q", m.SenderID)
+	}
+	if strings.TrimSpace(m.Content) == "" {
+		return nil, errors.New("empty content")
+	}
+	if m.CreatedAt.IsZero() {
+		return nil, errors.New("missing created_at")
+	}
+
+	project, ok := imp.projects[channel.Bytes]
+	if !ok {
+		if err := imp.pool.QueryRow(ctx, `SELECT project_id FROM channels WHERE id = $1`, channel).Scan(&project); err != nil {
+			return nil, fmt.Errorf("channel %s: %w", m.ChannelID, err)
+		}
+		imp.projects[channel.Bytes] = project
+	}
+	if !imp.senders[sender.Bytes] {
+		var exists bool
+		if err := imp.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, sender).Scan(&exists); err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, fmt.Errorf("no user %s", m.SenderID)
+		}
+		imp.senders[sender.Bytes] = true
+	}
+
+	id := m.ID
+	if id == 0 {
+		id = imp.ids.Next(m.CreatedAt)
+	}
+	var parent pgtype.Int8
+	if m.ParentID != 0 {
+		parent = pgtype.Int8{Int64: m.ParentID, Valid: true}
+		imp.channels[channel.Bytes] = true
+	}
+	return []any{id, project, channel, sender, m.Content, parent, m.CreatedAt}, nil
+}
+
+// IDGenerator builds sonyflake-layout message IDs for past times, counting
+// through the sequence and machine bits for messages in the same 10ms tick.
+// The zero value is ready to use.
+type IDGenerator struct {
+	used map[int64]int64 // IDs handed out per tick
+}
+
+func (g *IDGenerator) Next(t time.Time) int64 {
+	if g.used == nil {
+		g.used = make(map[int64]int64)
+	}
+	tick := t.Sub(sonyflakeEpoch).Milliseconds() / 10
+	seq := g.used[tick]
+	g.used[tick] = seq + 1
+	return tick<<24 | seq&(1<<24-1)
+}
+
+// copy writes one chunk in ID order, so a reply in the chunk follows its
+// parent, and reports progress once it commits
+func (imp *importer) copy(ctx context.Context, rows [][]any, progress func(ImportProgress)) error {
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0].(int64) < rows[j][0].(int64) })
+	n, err := imp.pool.CopyFrom(ctx, pgx.Identifier{"messages"}, messageImportColumns, pgx.CopyFromRows(rows))
+	if err != nil {
+		return fmt.Errorf("backup: importing messages %d to %d (%d already imported, up to id %d): %w",
+			rows[0][0], rows[len(rows)-1][0], imp.done.Messages, imp.done.LastID, err)
+	}
+	imp.done.Messages += n
+	imp.done.LastID = max(imp.done.LastID, rows[len(rows)-1][0].(int64))
+	imp.done.Elapsed = time.Since(imp.start)
+	if progress != nil {
+		progress(imp.done)
+	}
+	return nil
+}
+
+// fixReplyCounts recounts replies in the channels that received any, since
+// the import skips the per-message IncrementReplyCount
+func (imp *importer) fixReplyCounts(ctx context.Context) error {
+	if len(imp.channels) == 0 {
+		return nil
+	}
+	ids := make([]pgtype.UUID, 0, len(imp.channels))
+	for b := range imp.channels {
+		ids = append(ids, pgtype.UUID{Bytes: b, Valid: true})
+	}
+	_, err := imp.pool.Exec(ctx, `
+		UPDATE messages p SET reply_count = r.n
+		FROM (
+			SELECT parent_id, COUNT(*) AS n FROM messages
+			WHERE channel_id = ANY($1) AND parent_id IS NOT NULL AND NOT COALESCE(is_deleted, FALSE)
+			GROUP BY parent_id
+		) r
+		WHERE p.id = r.parent_id`, ids)
+	return err
+}
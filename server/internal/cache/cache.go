@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// TTL is an in-process key/value cache with per-entry expiry and a size cap.
+// Safe for concurrent use. A zero maxEntries means unbounded.
+type TTL[K comparable, V any] struct {
+	mu         sync.RWMutex
+	items      map[K]item[V]
+	ttl        time.Duration
+	maxEntries int
+}
+
+type item[V any] struct {
+	v   V
+	exp time.Time
+}
+
+// New creates a cache whose entries live for ttl
+func New[K comparable, V any](ttl time.Duration, maxEntries int) *TTL[K, V] {
+	c := &TTL[K, V]{
+		items:      make(map[K]item[V]),
+		ttl:        ttl,
+		maxEntries: maxEntries,
+	}
+	go c.janitor()
+	return c
+}
+
+// Get returns the cached value if present and not expired
+func (c *TTL[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	it, ok := c.items[key]
+	c.mu.RUnlock()
+	if !ok || time.Now().After(it.exp) {
+		var zero V
+		return zero, false
+	}
+	return it.v, true
+}
+
+// Set stores a value, evicting expired (then arbitrary) entries when full
+func (c *TTL[K, V]) Set(key K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.maxEntries > 0 && len(c.items) >= c.maxEntries {
+		if _, exists := c.items[key]; !exists {
+			c.evictLocked()
+		}
+	}
+	c.items[key] = item[V]{v: v, exp: time.Now().Add(c.ttl)}
+}
+
+// Delete removes a key (no-op if absent)
+func (c *TTL[K, V]) Delete(key K) {
+	c.mu.Lock()
+	delete(c.items, key)
+	c.mu.Unlock()
+}
+
+// Purge drops every entry
+func (c *TTL[K, V]) Purge() {
+	c.mu.Lock()
+	c.items = make(map[K]item[V])
+	c.mu.Unlock()
+}
+
+// Len reports the number of stored entries (including not-yet-swept expired ones)
+func (c *TTL[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
+// GetOrLoad returns the cached value or calls load and caches its result.
+// Errors are not cached.
+func (c *TTL[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	if v, ok := c.Get(key); ok {
+		return v, nil
+	}
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+	c.Set(key, v)
+	return v, nil
+}
+
+// evictLocked frees at least one slot. Caller must hold mu.
+func (c *TTL[K, V]) evictLocked() {
+	now := time.Now()
+	for k, it := range c.items {
+		if now.After(it.exp) {
+			delete(c.items, k)
+		}
+	}
+	if len(c.items) < c.maxEntries {
+		return
+	}
+	// Map iteration order is random, so this approximates random eviction
+	for k := range c.items {
+		delete(c.items, k)
+		if len(c.items) < c.maxEntries {
+			return
+		}
+	}
+}
+
+// janitor periodically sweeps expired entries to bound memory
+func (c *TTL[K, V]) janitor() {
+	interval := c.ttl
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	for {
+		time.Sleep(interval)
+		now := time.Now()
+		c.mu.Lock()
+		for k, it := range c.items {
+			if now.After(it.exp) {
+				delete(c.items, k)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const invalidateChannel = "cache:invalidate"
+
+// Invalidator fans out key invalidations to every server instance.
+// Without Redis it only invalidates locally (single-server mode).
+type Invalidator struct {
+	mu       sync.RWMutex
+	handlers map[string]func(key string)
+	redis    *redis.Client
+}
+
+// NewInvalidator creates an invalidator; rdb may be nil
+func NewInvalidator(rdb *redis.Client) *Invalidator {
+	inv := &Invalidator{
+		handlers: make(map[string]func(string)),
+		redis:    rdb,
+	}
+	if rdb != nil {
+		go inv.subscribe()
+	}
+	return inv
+}
+
+// Register attaches a named cache's delete function
+func (inv *Invalidator) Register(name string, del func(key string)) {
+	inv.mu.Lock()
+	inv.handlers[name] = del
+	inv.mu.Unlock()
+}
+
+// Invalidate drops key from the named cache here and on other instances
+func (inv *Invalidator) Invalidate(name, key string) {
+	inv.apply(name, key)
+	if inv.redis != nil {
+		inv.redis.Publish(context.Background(), invalidateChannel, name+"\x00"+key)
+	}
+}
+
+func (inv *Invalidator) apply(name, key string) {
+	inv.mu.RLock()
+	del := inv.handlers[name]
+	inv.mu.RUnlock()
+	if del != nil {
+		del(key)
+	}
+}
+
+// subscribe applies invalidations published by other instances
+func (inv *Invalidator) subscribe() {
+	pubsub := inv.redis.Subscribe(context.Background(), invalidateChannel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		name, key, ok := strings.Cut(msg.Payload, "\x00")
+		if !ok {
+			log.Printf("[cache] malformed invalidation: %q", msg.Payload)
+			continue
+		}
+		inv.apply(name, key)
+	}
+}
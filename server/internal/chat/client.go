@@ -2,6 +2,7 @@ package chat
 
 import (
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -12,11 +13,18 @@ const (
 	// SOTA message batching settings
 	batchInterval = 50 * time.Millisecond // Batch messages every 50ms
 	maxBatchSize  = 10                    // Max messages per batch
+
+	sendBufferSize = 256 // Increased buffer for batching
 )
 
 type Client struct {
-	conn   *websocket.Conn
-	send   chan any
+	conn *websocket.Conn
+	send chan any
+	// sendMu guards closed: Send and trySend hold it for reading so Close
+	// can't close send under them. Held messages and command results may
+	// be sent long after the connection went away.
+	sendMu sync.RWMutex
+	closed bool
 	UserID pgtype.UUID
 	// Cached user info - no DB lookup per message!
 	Username  string
@@ -26,12 +34,18 @@ type Client struct {
 	batchMu    sync.Mutex
 	batch      []any
 	batchTimer *time.Timer
+
+	stream bool // no socket; drained with Receive
+
+	// Privacy settings, flipped while connected when the user changes them
+	hideTyping   atomic.Bool
+	hidePresence atomic.Bool
 }
 
 func NewClient(conn *websocket.Conn, userID pgtype.UUID, username, avatarURL string) *Client {
 	c := &Client{
 		conn:      conn,
-		send:      make(chan any, 256), // Increased buffer for batching
+		send:      make(chan any, sendBufferSize),
 		UserID:    userID,
 		Username:  username,
 		AvatarURL: avatarURL,
@@ -43,7 +57,15 @@ func NewClient(conn *websocket.Conn, userID pgtype.UUID, username, avatarURL str
 func (c *Client) Write() {
 	defer c.conn.Close()
 	for msg := range c.send {
-		if err := c.conn.WriteJSON(msg); err != nil {
+		d, broadcast := msg.(delivery)
+		if broadcast {
+			msg = d.msg
+		}
+		err := c.conn.WriteJSON(msg)
+		if broadcast {
+			d.settle()
+		}
+		if err != nil {
 			return
 		}
 	}
@@ -55,6 +77,11 @@ func (c *Client) Conn() *websocket.Conn {
 
 // Send queues a message for sending (with optional batching for high throughput)
 func (c *Client) Send(msg any) {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.closed {
+		return
+	}
 	select {
 	case c.send <- msg:
 	default:
@@ -62,6 +89,21 @@ func (c *Client) Send(msg any) {
 	}
 }
 
+// trySend is Send for hub broadcasts, reporting whether the message was queued
+func (c *Client) trySend(msg any) bool {
+	c.sendMu.RLock()
+	defer c.sendMu.RUnlock()
+	if c.closed {
+		return false
+	}
+	select {
+	case c.send <- msg:
+		return true
+	default:
+		return false
+	}
+}
+
 // SendBatched adds message to batch and flushes when batch is full or timer expires
 // Use this for high-frequency messages (typing indicators, presence updates)
 func (c *Client) SendBatched(msg any) {
@@ -113,5 +155,18 @@ func (c *Client) Close() {
 	c.batchMu.Lock()
 	c.flushBatchLocked() // Flush remaining messages
 	c.batchMu.Unlock()
+	c.sendMu.Lock()
+	if c.closed {
+		c.sendMu.Unlock()
+		return
+	}
+	c.closed = true
 	close(c.send)
+	c.sendMu.Unlock()
+	// Settle what no one will write now, alongside Write if it still runs
+	for msg := range c.send {
+		if d, ok := msg.(delivery); ok {
+			d.settle()
+		}
+	}
 }
@@ -0,0 +1,147 @@
+package chat
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DefaultConnLimit is how many WebSocket connections one user may hold on an
+// instance before the oldest is closed to make room for a new one
+const DefaultConnLimit = 10
+
+// closeEvicted is the close code a connection gets when a newer one of the
+// same user pushes it over the limit; clients shouldn't reconnect on it
+const closeEvicted = 4008
+
+// connSet tracks each user's WebSocket connections, oldest first, and their
+// stream clients, which don't count towards the limit
+type connSet struct {
+	mu      sync.Mutex
+	limit   int
+	byUser  map[pgtype.UUID][]*Client
+	streams map[pgtype.UUID][]*Client
+}
+
+// SetConnLimit changes how many connections a user may hold on this
+// instance; n <= 0 removes the limit
+func (h *Hub) SetConnLimit(n int) {
+	h.conns.mu.Lock()
+	h.conns.limit = n
+	h.conns.mu.Unlock()
+}
+
+// Connect registers a new WebSocket client. When the user is now over the
+// limit their oldest connections are closed, and their read loops clean up
+// as for any other disconnect. Stream clients are only tracked.
+func (h *Hub) Connect(c *Client) {
+	h.conns.mu.Lock()
+	if c.stream {
+		if h.conns.streams == nil {
+			h.conns.streams = make(map[pgtype.UUID][]*Client)
+		}
+		h.conns.streams[c.UserID] = append(h.conns.streams[c.UserID], c)
+		h.conns.mu.Unlock()
+		return
+	}
+	if h.conns.byUser == nil {
+		h.conns.byUser = make(map[pgtype.UUID][]*Client)
+	}
+	list := append(h.conns.byUser[c.UserID], c)
+	var evicted []*Client
+	if h.conns.limit > 0 && len(list) > h.conns.limit {
+		n := len(list) - h.conns.limit
+		evicted = append(evicted, list[:n]...)
+		list = append([]*Client(nil), list[n:]...)
+	}
+	h.conns.byUser[c.UserID] = list
+	h.conns.mu.Unlock()
+	// Evicted connections are no longer tracked, so Disconnect won't count them
+	h.metrics.connections.Add(1 - int64(len(evicted)))
+
+	for _, old := range evicted {
+		h.metrics.evicted.Add(1)
+		log.Printf("[WS] closing oldest connection of %s: over %d connections", old.Username, h.conns.limit)
+		old.evict()
+	}
+}
+
+// Disconnect forgets a client registered with Connect
+func (h *Hub) Disconnect(c *Client) {
+	h.conns.mu.Lock()
+	defer h.conns.mu.Unlock()
+	set := h.conns.byUser
+	if c.stream {
+		set = h.conns.streams
+	}
+	list := set[c.UserID]
+	for i, other := range list {
+		if other == c {
+			list = append(list[:i:i], list[i+1:]...)
+			if !c.stream {
+				h.metrics.connections.Add(-1)
+			}
+			break
+		}
+	}
+	if len(list) == 0 {
+		delete(set, c.UserID)
+	} else {
+		set[c.UserID] = list
+	}
+}
+
+// userClients returns the user's connections and stream clients on this
+// instance
+func (h *Hub) userClients(uid pgtype.UUID) []*Client {
+	h.conns.mu.Lock()
+	defer h.conns.mu.Unlock()
+	sockets, streams := h.conns.byUser[uid], h.conns.streams[uid]
+	out := make([]*Client, 0, len(sockets)+len(streams))
+	return append(append(out, sockets...), streams...)
+}
+
+// RejectOrigin counts a WebSocket upgrade refused for its Origin
+func (h *Hub) RejectOrigin() {
+	h.metrics.originRejected.Add(1)
+}
+
+// Shutdown ends every connection on this instance as the server stops.
+// WebSockets are hijacked and streams never finish on their own, so both
+// would outlive http.Server.Shutdown: sockets get a going-away close, which
+// clients reconnect on, and stream readers watching Closing return.
+func (h *Hub) Shutdown() {
+	h.closeOnce.Do(func() { close(h.closing) })
+	h.conns.mu.Lock()
+	var all []*Client
+	for _, list := range h.conns.byUser {
+		all = append(all, list...)
+	}
+	h.conns.mu.Unlock()
+	for _, c := range all {
+		c.closeWith(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// Closing is closed once Shutdown has been called
+func (h *Hub) Closing() <-chan struct{} {
+	return h.closing
+}
+
+// evict tells the client why and closes its socket, which ends its read loop
+func (c *Client) evict() {
+	c.closeWith(closeEvicted, "too many connections")
+}
+
+// closeWith sends a close frame with code and reason, then closes the socket
+func (c *Client) closeWith(code int, reason string) {
+	if c.conn == nil {
+		return
+	}
+	msg := websocket.FormatCloseMessage(code, reason)
+	_ = c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+	c.conn.Close()
+}
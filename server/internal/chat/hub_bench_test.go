@@ -0,0 +1,155 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Benchmarks for the local fan-out path. Run them before and after Hub
+// changes (sharding, another pub/sub backend) and compare with benchstat:
+//
+//	go test ./internal/chat -run '^$' -bench . -benchmem -count 10
+//
+// cmd/hubload drives the same paths with many sockets for minutes at a time.
+
+var fanoutSizes = []int{1, 10, 100, 1000}
+
+// drainStreams joins n stream clients to room and empties their queues
+// until the returned stop is called
+func drainStreams(h *Hub, room string, n int) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	for range n {
+		c := NewStreamClient(pgtype.UUID{}, "", "")
+		h.Join(room, c)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, ok := c.Receive(ctx); !ok {
+					return
+				}
+			}
+		}()
+	}
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+func BenchmarkBroadcast(b *testing.B) {
+	msg := map[string]any{"type": "message", "payload": map[string]any{"content": "hello"}}
+	for _, n := range fanoutSizes {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			h := NewHub(nil)
+			stop := drainStreams(h, "room", n)
+			defer stop()
+			b.ResetTimer()
+			for range b.N {
+				h.BroadcastFrom("room", msg, time.Now())
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(h.metrics.dropped.Load())/float64(b.N), "drops/op")
+		})
+	}
+}
+
+// BenchmarkBroadcastRooms broadcasts to many rooms at once, the shape of a
+// busy instance, to show contention in the room map
+func BenchmarkBroadcastRooms(b *testing.B) {
+	const rooms, perRoom = 100, 10
+	msg := map[string]any{"type": "message"}
+	h := NewHub(nil)
+	for i := range rooms {
+		stop := drainStreams(h, fmt.Sprintf("room-%d", i), perRoom)
+		defer stop()
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			h.Broadcast(fmt.Sprintf("room-%d", i%rooms), msg)
+			i++
+		}
+	})
+}
+
+func BenchmarkJoinLeave(b *testing.B) {
+	h := NewHub(nil)
+	stop := drainStreams(h, "room", 100)
+	defer stop()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		c := NewStreamClient(pgtype.UUID{}, "", "")
+		for pb.Next() {
+			h.Join("room", c)
+			h.Leave("room", c)
+		}
+	})
+}
+
+// BenchmarkBroadcastWebSocket includes JSON encoding and the socket writes,
+// timing each broadcast until every client has read it
+func BenchmarkBroadcastWebSocket(b *testing.B) {
+	for _, n := range []int{1, 10, 100} {
+		b.Run(fmt.Sprintf("clients=%d", n), func(b *testing.B) {
+			h := NewHub(nil)
+			upgrader := websocket.Upgrader{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					return
+				}
+				c := NewClient(conn, pgtype.UUID{}, "", "")
+				h.Join("room", c)
+				go c.Write()
+			}))
+			defer srv.Close()
+
+			received := make(chan struct{}, n*64)
+			var conns []*websocket.Conn
+			for range n {
+				conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+				if err != nil {
+					b.Fatal(err)
+				}
+				conns = append(conns, conn)
+				go func() {
+					for {
+						if _, _, err := conn.ReadMessage(); err != nil {
+							return
+						}
+						received <- struct{}{}
+					}
+				}()
+			}
+			defer func() {
+				for _, c := range conns {
+					c.Close()
+				}
+			}()
+			for h.Metrics().Clients < n {
+				time.Sleep(time.Millisecond)
+			}
+
+			msg := map[string]any{"type": "message", "payload": map[string]any{"content": "hello"}}
+			b.ResetTimer()
+			for range b.N {
+				h.BroadcastFrom("room", msg, time.Now())
+				for range n {
+					<-received
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,237 @@
+package chat
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	rateWindow      = 60 // seconds of history behind the per-second rates
+	maxBacklogRooms = 20 // busiest rooms reported in a snapshot
+)
+
+// Upper bounds (ms) of the fan-out latency buckets; the last bucket is open
+var latencyBuckets = []float64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500}
+
+// hubMetrics counts broadcasts and how long they take to reach every client.
+// Everything is updated from hot paths, so it sticks to atomics and a small
+// mutex around the per-second ring.
+type hubMetrics struct {
+	started    time.Time
+	broadcasts atomic.Int64
+	fanoutSum  atomic.Int64 // clients targeted, summed over all broadcasts
+	dropped    atomic.Int64 // broadcast sends skipped because a client's buffer was full
+
+	originRejected atomic.Int64 // upgrades refused for their Origin
+	evicted        atomic.Int64 // connections closed for a newer one over the per-user limit
+
+	connections atomic.Int64 // open WebSocket connections
+	queued      atomic.Int64 // broadcasts sitting in client queues, not yet written
+
+	latencyCount atomic.Int64
+	latencySumUs atomic.Int64
+	latencyHist  [11]atomic.Int64 // len(latencyBuckets)+1
+
+	broadcastRate rateCounter
+	messageRate   rateCounter // chat messages published from this instance
+}
+
+func newHubMetrics() *hubMetrics {
+	now := time.Now()
+	return &hubMetrics{
+		started:       now,
+		broadcastRate: rateCounter{started: now},
+		messageRate:   rateCounter{started: now},
+	}
+}
+
+func (m *hubMetrics) recordBroadcast(fanout int) {
+	m.broadcasts.Add(1)
+	m.fanoutSum.Add(int64(fanout))
+	m.broadcastRate.add()
+}
+
+func (m *hubMetrics) recordLatency(d time.Duration) {
+	m.latencyCount.Add(1)
+	m.latencySumUs.Add(d.Microseconds())
+	ms := float64(d.Microseconds()) / 1000
+	i := sort.SearchFloat64s(latencyBuckets, ms)
+	m.latencyHist[i].Add(1)
+}
+
+// rateCounter counts events per second in a ring covering the last
+// rateWindow seconds
+type rateCounter struct {
+	started time.Time
+	mu      sync.Mutex
+	slots   [rateWindow]struct{ sec, n int64 }
+}
+
+func (r *rateCounter) add() {
+	sec := time.Now().Unix()
+	r.mu.Lock()
+	slot := &r.slots[sec%rateWindow]
+	if slot.sec != sec {
+		slot.sec, slot.n = sec, 0
+	}
+	slot.n++
+	r.mu.Unlock()
+}
+
+// perSecond averages events per second over the last full window
+func (r *rateCounter) perSecond() float64 {
+	now := time.Now().Unix()
+	window := int64(rateWindow)
+	if up := now - r.started.Unix(); up < window {
+		window = max(up, 1)
+	}
+	var n int64
+	r.mu.Lock()
+	for _, slot := range r.slots {
+		if slot.sec > now-window && slot.sec <= now {
+			n += slot.n
+		}
+	}
+	r.mu.Unlock()
+	return float64(n) / float64(window)
+}
+
+// fanout tracks one timed broadcast until the last targeted client has
+// written it (or dropped it)
+type fanout struct {
+	start   time.Time
+	pending atomic.Int32
+	metrics *hubMetrics
+}
+
+func (f *fanout) done() {
+	if f.pending.Add(-1) == 0 {
+		f.metrics.recordLatency(time.Since(f.start))
+	}
+}
+
+// delivery is what a broadcast puts on a client's send queue; fan is set
+// for timed broadcasts
+type delivery struct {
+	msg    any
+	fan    *fanout
+	queued *atomic.Int64
+}
+
+// settle marks the delivery written (or dropped) by its client
+func (d delivery) settle() {
+	d.queued.Add(-1)
+	if d.fan != nil {
+		d.fan.done()
+	}
+}
+
+// LatencyBucket is one histogram bucket; LeMs is nil for the open bucket
+type LatencyBucket struct {
+	LeMs  *float64 `json:"le_ms"`
+	Count int64    `json:"count"`
+}
+
+// RoomBacklog is how many messages are queued for a room's local clients
+type RoomBacklog struct {
+	Room    string `json:"room"`
+	Clients int    `json:"clients"`
+	Queued  int    `json:"queued"`
+	Max     int    `json:"max"` // fullest single client queue
+}
+
+// Metrics is a point-in-time view of the hub on this instance
+type Metrics struct {
+	UptimeSeconds       int64           `json:"uptime_seconds"`
+	Rooms               int             `json:"rooms"`
+	Clients             int             `json:"clients"`
+	Broadcasts          int64           `json:"broadcasts"`
+	BroadcastsPerSecond float64         `json:"broadcasts_per_second"`
+	AvgFanout           float64         `json:"avg_fanout"`
+	DroppedSends        int64           `json:"dropped_sends"`
+	OriginRejections    int64           `json:"origin_rejections"`
+	EvictedConnections  int64           `json:"evicted_connections"`
+	FanoutLatencyCount  int64           `json:"fanout_latency_count"`
+	FanoutLatencyAvgMs  float64         `json:"fanout_latency_avg_ms"`
+	FanoutLatency       []LatencyBucket `json:"fanout_latency"`
+	QueueCapacity       int             `json:"queue_capacity"`
+	Backlog             []RoomBacklog   `json:"backlog"` // busiest rooms first
+}
+
+// Metrics snapshots throughput, fan-out latency and per-room backlog. Only
+// broadcasts sent with BroadcastFrom contribute to the latency figures.
+func (h *Hub) Metrics() Metrics {
+	m := h.metrics
+	out := Metrics{
+		UptimeSeconds:       int64(time.Since(m.started).Seconds()),
+		Broadcasts:          m.broadcasts.Load(),
+		BroadcastsPerSecond: m.broadcastRate.perSecond(),
+		DroppedSends:        m.dropped.Load(),
+		OriginRejections:    m.originRejected.Load(),
+		EvictedConnections:  m.evicted.Load(),
+		FanoutLatencyCount:  m.latencyCount.Load(),
+		QueueCapacity:       sendBufferSize,
+	}
+	if out.Broadcasts > 0 {
+		out.AvgFanout = float64(m.fanoutSum.Load()) / float64(out.Broadcasts)
+	}
+	if out.FanoutLatencyCount > 0 {
+		out.FanoutLatencyAvgMs = float64(m.latencySumUs.Load()) / 1000 / float64(out.FanoutLatencyCount)
+	}
+	out.FanoutLatency = make([]LatencyBucket, len(m.latencyHist))
+	for i := range m.latencyHist {
+		out.FanoutLatency[i].Count = m.latencyHist[i].Load()
+		if i < len(latencyBuckets) {
+			out.FanoutLatency[i].LeMs = &latencyBuckets[i]
+		}
+	}
+
+	clients := make(map[*Client]bool)
+	var backlog []RoomBacklog
+	h.rooms.each(func(room string, members []*Client) {
+		rb := RoomBacklog{Room: room, Clients: len(members)}
+		for _, c := range members {
+			clients[c] = true
+			q := len(c.send)
+			rb.Queued += q
+			rb.Max = max(rb.Max, q)
+		}
+		out.Rooms++
+		if rb.Queued > 0 {
+			backlog = append(backlog, rb)
+		}
+	})
+	out.Clients = len(clients)
+	sort.Slice(backlog, func(i, j int) bool { return backlog[i].Queued > backlog[j].Queued })
+	if len(backlog) > maxBacklogRooms {
+		backlog = backlog[:maxBacklogRooms]
+	}
+	out.Backlog = append([]RoomBacklog{}, backlog...)
+	return out
+}
+
+// Load is the handful of figures an autoscaler polls. Every one is a counter
+// kept up to date as things happen, so reading them costs nothing.
+type Load struct {
+	Connections       int64   `json:"ws_connections"`
+	MessagesPerSecond float64 `json:"messages_per_second"`
+	QueuedEvents      int64   `json:"event_queue"` // broadcasts not yet written to their client
+}
+
+// Load snapshots this instance's connection count, message rate and
+// outbound queue depth
+func (h *Hub) Load() Load {
+	m := h.metrics
+	return Load{
+		Connections:       m.connections.Load(),
+		MessagesPerSecond: m.messageRate.perSecond(),
+		QueuedEvents:      m.queued.Load(),
+	}
+}
+
+// CountMessage records a chat message published from this instance
+func (h *Hub) CountMessage() {
+	h.metrics.messageRate.add()
+}
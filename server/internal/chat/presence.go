@@ -0,0 +1,128 @@
+package chat
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// ============================================================================
+// TYPING & PRESENCE
+// Typing indicators and online presence go through the Hub so a user's
+// privacy settings hold no matter what their client does: a client that
+// doesn't send typing indicators has them dropped here, and one that hides
+// its presence is never announced or listed as online. Settings changes reach
+// connections on other instances over Redis.
+// ============================================================================
+
+// privacyChannel carries privacy changes between server instances
+const privacyChannel = "privacy:user"
+
+// Privacy is what a user lets other members see of their activity
+type Privacy struct {
+	SendTyping   bool `json:"send_typing"`
+	ShowPresence bool `json:"show_presence"`
+}
+
+// DefaultPrivacy shares everything, for users who haven't changed a setting
+var DefaultPrivacy = Privacy{SendTyping: true, ShowPresence: true}
+
+type privacyUpdate struct {
+	UserID  string  `json:"user_id"`
+	Privacy Privacy `json:"privacy"`
+}
+
+// SetPrivacy applies p to the client's later typing and presence events
+func (c *Client) SetPrivacy(p Privacy) {
+	c.hideTyping.Store(!p.SendTyping)
+	c.hidePresence.Store(!p.ShowPresence)
+}
+
+// Privacy returns the settings the client was last given
+func (c *Client) Privacy() Privacy {
+	return Privacy{SendTyping: !c.hideTyping.Load(), ShowPresence: !c.hidePresence.Load()}
+}
+
+// Typing relays c's typing indicator to the rest of room. It reports false
+// when c doesn't send typing indicators and nothing was relayed.
+func (h *Hub) Typing(room string, c *Client, msg any) bool {
+	if c.hideTyping.Load() {
+		return false
+	}
+	h.BroadcastExcept(room, msg, c)
+	return true
+}
+
+// Presence announces c coming online (after it joined room) or going
+// offline (after it left). Nothing is announced for a client that hides its
+// presence, nor an offline event while the user has another connection in
+// the room on this instance.
+func (h *Hub) Presence(room string, c *Client, online bool, msg any) bool {
+	if c.hidePresence.Load() {
+		return false
+	}
+	if !online && h.visibleIn(room, UUIDToString(c.UserID)) {
+		return false
+	}
+	h.BroadcastExcept(room, msg, c)
+	return true
+}
+
+// Online lists the users connected to room on this instance who show their
+// presence, each once
+func (h *Hub) Online(room string) []string {
+	seen := make(map[string]bool)
+	users := []string{}
+	for _, c := range h.rooms.clients(room) {
+		if c.stream || c.hidePresence.Load() {
+			continue
+		}
+		id := UUIDToString(c.UserID)
+		if !seen[id] {
+			seen[id] = true
+			users = append(users, id)
+		}
+	}
+	return users
+}
+
+func (h *Hub) visibleIn(room, userID string) bool {
+	for _, c := range h.rooms.clients(room) {
+		if !c.stream && !c.hidePresence.Load() && UUIDToString(c.UserID) == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdatePrivacy applies p to every connection of the user, here and on other
+// instances, and returns the rooms this instance's connections are in so the
+// caller can announce a presence change
+func (h *Hub) UpdatePrivacy(userID string, p Privacy) []string {
+	rooms := h.applyPrivacy(userID, p)
+	if h.redis != nil {
+		payload, err := json.Marshal(privacyUpdate{UserID: userID, Privacy: p})
+		if err != nil {
+			log.Printf("Redis publish marshal error: %v", err)
+			return rooms
+		}
+		h.redis.Publish(h.ctx, privacyChannel, payload)
+	}
+	return rooms
+}
+
+func (h *Hub) applyPrivacy(userID string, p Privacy) []string {
+	var rooms []string
+	h.rooms.each(func(name string, clients []*Client) {
+		in := false
+		for _, c := range clients {
+			if UUIDToString(c.UserID) == userID {
+				c.SetPrivacy(p)
+				in = true
+			}
+		}
+		if in {
+			rooms = append(rooms, name)
+		}
+	})
+	return rooms
+}
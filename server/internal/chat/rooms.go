@@ -0,0 +1,132 @@
+package chat
+
+import "sync"
+
+// roomShards spreads rooms over this many independently locked maps
+const roomShards = 64
+
+// roomSet holds the Hub's rooms. Rooms are sharded by name so joins and
+// leaves in unrelated rooms don't contend. A room keeps its clients in a set
+// plus a slice built from it on the first read after a change: broadcasts
+// take the slice under a read lock and iterate it with no lock held, and
+// joins and leaves stay O(1).
+type roomSet struct {
+	shards [roomShards]roomShard
+}
+
+type roomShard struct {
+	mu    sync.RWMutex
+	rooms map[string]*room
+}
+
+type room struct {
+	members map[*Client]struct{}
+	list    []*Client // nil after a join or leave; never modified once built
+}
+
+func (s *roomSet) shard(name string) *roomShard {
+	// FNV-1a
+	h := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		h ^= uint32(name[i])
+		h *= 16777619
+	}
+	return &s.shards[h%roomShards]
+}
+
+// clients returns the room's clients. The slice is shared; don't modify it.
+func (s *roomSet) clients(name string) []*Client {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	r := sh.rooms[name]
+	var list []*Client
+	if r != nil {
+		list = r.list
+	}
+	sh.mu.RUnlock()
+	if r == nil || list != nil {
+		return list
+	}
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	r = sh.rooms[name]
+	if r == nil {
+		return nil
+	}
+	return r.snapshot()
+}
+
+// snapshot builds r.list if a change cleared it; the shard lock must be held
+// for writing
+func (r *room) snapshot() []*Client {
+	if r.list == nil {
+		r.list = make([]*Client, 0, len(r.members))
+		for c := range r.members {
+			r.list = append(r.list, c)
+		}
+	}
+	return r.list
+}
+
+func (s *roomSet) join(name string, c *Client) {
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if sh.rooms == nil {
+		sh.rooms = make(map[string]*room)
+	}
+	r := sh.rooms[name]
+	if r == nil {
+		r = &room{members: make(map[*Client]struct{})}
+		sh.rooms[name] = r
+	}
+	if _, ok := r.members[c]; !ok {
+		r.members[c] = struct{}{}
+		r.list = nil
+	}
+}
+
+// leave removes c from the room, dropping the room once it's empty
+func (s *roomSet) leave(name string, c *Client) {
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	r := sh.rooms[name]
+	if r == nil {
+		return
+	}
+	if _, ok := r.members[c]; !ok {
+		return
+	}
+	delete(r.members, c)
+	r.list = nil
+	if len(r.members) == 0 {
+		delete(sh.rooms, name)
+	}
+}
+
+func (s *roomSet) contains(name string, c *Client) bool {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	r := sh.rooms[name]
+	if r == nil {
+		return false
+	}
+	_, ok := r.members[c]
+	return ok
+}
+
+// each calls fn for every room, one shard at a time under its write lock
+// (slices may need building); fn must not join or leave rooms
+func (s *roomSet) each(fn func(name string, clients []*Client)) {
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		for name, r := range sh.rooms {
+			fn(name, r.snapshot())
+		}
+		sh.mu.Unlock()
+	}
+}
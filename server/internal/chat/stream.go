@@ -0,0 +1,139 @@
+package chat
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ============================================================================
+// STREAM TRANSPORTS
+// Server-Sent Events and long polling read the same room broadcasts as the
+// socket through stream clients: Clients with no connection whose queue is
+// drained with Receive. Rooms someone streams from keep a short log of
+// recent events so a reconnecting stream can resume after the last event
+// it saw instead of losing whatever arrived in between.
+// ============================================================================
+
+const (
+	roomLogSize = 256
+	// A room stays logged this long after its last stream reader, which
+	// covers reconnects and the gap between two polls
+	roomLogIdle = 5 * time.Minute
+)
+
+// Event is a room broadcast with its position in the room's log. ID is 0
+// for messages that weren't broadcast to a room (direct notifications).
+type Event struct {
+	ID   int64
+	Data any
+}
+
+type roomLog struct {
+	mu       sync.Mutex
+	events   []Event // oldest first, at most roomLogSize
+	lastID   int64
+	floor    int64 // events at or before this ID may be missing
+	lastRead time.Time
+}
+
+// Watch starts (or keeps) logging room's broadcasts for stream readers
+func (h *Hub) Watch(room string) {
+	now := time.Now()
+	l, _ := h.logs.LoadOrStore(room, &roomLog{floor: now.UnixMicro(), lastID: now.UnixMicro()})
+	rl := l.(*roomLog)
+	rl.mu.Lock()
+	rl.lastRead = time.Now()
+	rl.mu.Unlock()
+}
+
+// record appends msg to room's log when somebody watches the room
+func (h *Hub) record(room string, msg any) Event {
+	l, ok := h.logs.Load(room)
+	if !ok {
+		return Event{Data: msg}
+	}
+	rl := l.(*roomLog)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	if now.Sub(rl.lastRead) > roomLogIdle && !h.hasStreamClient(room) {
+		h.logs.Delete(room)
+		return Event{Data: msg}
+	}
+	// Microsecond timestamps keep IDs roughly comparable across instances
+	id := max(now.UnixMicro(), rl.lastID+1)
+	rl.lastID = id
+	ev := Event{ID: id, Data: msg}
+	if len(rl.events) == roomLogSize {
+		rl.floor = rl.events[0].ID
+		copy(rl.events, rl.events[1:])
+		rl.events = rl.events[:roomLogSize-1]
+	}
+	rl.events = append(rl.events, ev)
+	return ev
+}
+
+// Since returns room's logged events after id. complete is false when the
+// log no longer reaches back to id, so the reader missed events and should
+// refetch history. An id of 0 asks for nothing but the current position.
+func (h *Hub) Since(room string, id int64) (events []Event, last int64, complete bool) {
+	l, ok := h.logs.Load(room)
+	if !ok {
+		return nil, id, id == 0
+	}
+	rl := l.(*roomLog)
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.lastRead = time.Now()
+	if id == 0 {
+		return nil, rl.lastID, true
+	}
+	complete = id >= rl.floor
+	for _, ev := range rl.events {
+		if ev.ID > id {
+			events = append(events, ev)
+		}
+	}
+	return events, max(id, rl.lastID), complete
+}
+
+func (h *Hub) hasStreamClient(room string) bool {
+	for _, c := range h.rooms.clients(room) {
+		if c.stream {
+			return true
+		}
+	}
+	return false
+}
+
+// NewStreamClient is a Client for transports that drain its queue with
+// Receive instead of writing to a socket
+func NewStreamClient(userID pgtype.UUID, username, avatarURL string) *Client {
+	c := NewClient(nil, userID, username, avatarURL)
+	c.stream = true
+	return c
+}
+
+// Receive waits for the stream client's next event. ok is false once the
+// client is closed or ctx ends.
+func (c *Client) Receive(ctx context.Context) (ev Event, ok bool) {
+	select {
+	case msg, open := <-c.send:
+		if !open {
+			return Event{}, false
+		}
+		if d, ok := msg.(delivery); ok {
+			d.settle()
+			msg = d.msg
+		}
+		if ev, isEvent := msg.(Event); isEvent {
+			return ev, true
+		}
+		return Event{Data: msg}, true
+	case <-ctx.Done():
+		return Event{}, false
+	}
+}
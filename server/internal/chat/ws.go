@@ -2,10 +2,13 @@ package chat
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/redis/go-redis/v9"
@@ -14,15 +17,26 @@ import (
 // Hub manages WebSocket connections and room subscriptions
 // Supports Redis pub/sub for horizontal scaling across multiple server instances
 type Hub struct {
-	rooms sync.Map // room -> *sync.Map[*Client]struct{}
-	redis *redis.Client
-	ctx   context.Context
+	rooms   roomSet
+	conns   connSet
+	logs    sync.Map // room -> *roomLog, for rooms with stream readers
+	redis   *redis.Client
+	id      string // tells this instance's notices apart on Redis
+	ctx     context.Context
+	metrics *hubMetrics
+
+	closing   chan struct{} // closed by Shutdown
+	closeOnce sync.Once
 }
 
 func NewHub(rdb *redis.Client) *Hub {
 	h := &Hub{
-		redis: rdb,
-		ctx:   context.Background(),
+		redis:   rdb,
+		id:      newHubID(),
+		ctx:     context.Background(),
+		conns:   connSet{limit: DefaultConnLimit},
+		metrics: newHubMetrics(),
+		closing: make(chan struct{}),
 	}
 
 	// If Redis is available, subscribe to messages from other server instances
@@ -33,13 +47,50 @@ func NewHub(rdb *redis.Client) *Hub {
 	return h
 }
 
+func newHubID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// notifyChannel carries NotifyUser messages between server instances
+const notifyChannel = "notify:user"
+
+type userNotice struct {
+	Origin     string `json:"origin"` // the hub that published it
+	TargetUser string `json:"target_user"`
+	Message    any    `json:"message"`
+}
+
 // subscribeToRedis listens for messages published by other server instances
 func (h *Hub) subscribeToRedis() {
-	pubsub := h.redis.PSubscribe(h.ctx, "room:*")
+	pubsub := h.redis.PSubscribe(h.ctx, "room:*", privacyChannel, notifyChannel)
 	defer pubsub.Close()
 
 	ch := pubsub.Channel()
 	for msg := range ch {
+		if msg.Channel == notifyChannel {
+			var n userNotice
+			if err := json.Unmarshal([]byte(msg.Payload), &n); err != nil {
+				log.Printf("Redis notice parse error: %v", err)
+				continue
+			}
+			// This instance delivered its own notices when it sent them
+			if n.Origin != h.id {
+				h.notifyLocal(n.TargetUser, n.Message)
+			}
+			continue
+		}
+		if msg.Channel == privacyChannel {
+			var u privacyUpdate
+			if err := json.Unmarshal([]byte(msg.Payload), &u); err != nil {
+				log.Printf("Redis privacy parse error: %v", err)
+				continue
+			}
+			h.applyPrivacy(u.UserID, u.Privacy)
+			continue
+		}
+
 		// msg.Channel format: "room:{roomName}"
 		room := msg.Channel[5:] // Strip "room:" prefix
 
@@ -56,40 +107,66 @@ func (h *Hub) subscribeToRedis() {
 
 // broadcastLocal sends to clients on THIS server instance only
 func (h *Hub) broadcastLocal(room string, msg any) {
-	if clients, ok := h.rooms.Load(room); ok {
-		clients.(*sync.Map).Range(func(key, value any) bool {
-			key.(*Client).Send(msg)
-			return true
-		})
+	h.fanOut(room, msg, nil, time.Time{})
+}
+
+// fanOut queues msg for every local client in room except one. A non-zero
+// received time makes the broadcast count towards fan-out latency.
+func (h *Hub) fanOut(room string, msg any, except *Client, received time.Time) {
+	ev := h.record(room, msg)
+	targets := h.rooms.clients(room)
+	if except != nil {
+		filtered := make([]*Client, 0, len(targets))
+		for _, c := range targets {
+			if c != except {
+				filtered = append(filtered, c)
+			}
+		}
+		targets = filtered
+	}
+	h.metrics.recordBroadcast(len(targets))
+	if len(targets) == 0 {
+		return
+	}
+
+	var fan *fanout
+	if !received.IsZero() {
+		fan = &fanout{start: received, metrics: h.metrics}
+		fan.pending.Store(int32(len(targets)))
+	}
+	for _, c := range targets {
+		var out any = msg
+		if c.stream {
+			out = ev
+		}
+		d := delivery{msg: out, fan: fan, queued: &h.metrics.queued}
+		h.metrics.queued.Add(1)
+		if !c.trySend(d) {
+			h.metrics.dropped.Add(1)
+			d.settle()
+		}
 	}
 }
 
 func (h *Hub) Join(room string, c *Client) {
-	clients, _ := h.rooms.LoadOrStore(room, &sync.Map{})
-	clients.(*sync.Map).Store(c, struct{}{})
+	h.rooms.join(room, c)
 }
 
+// Leave takes c out of room; empty rooms are deleted
 func (h *Hub) Leave(room string, c *Client) {
-	if clients, ok := h.rooms.Load(room); ok {
-		clients.(*sync.Map).Delete(c)
-
-		// Check if room is empty and delete it
-		empty := true
-		clients.(*sync.Map).Range(func(key, value any) bool {
-			empty = false
-			return false // break
-		})
-
-		if empty {
-			h.rooms.Delete(room)
-		}
-	}
+	h.rooms.leave(room, c)
 }
 
 // Broadcast sends to all clients in a room (local + other servers via Redis)
 func (h *Hub) Broadcast(room string, msg any) {
+	h.BroadcastFrom(room, msg, time.Time{})
+}
+
+// BroadcastFrom is Broadcast for a message that arrived at received; the time
+// until the last local client has written it feeds the hub's latency metrics
+func (h *Hub) BroadcastFrom(room string, msg any, received time.Time) {
 	// Always broadcast to local clients
-	h.broadcastLocal(room, msg)
+	h.fanOut(room, msg, nil, received)
 
 	// If Redis is available, publish to other server instances
 	if h.redis != nil {
@@ -104,14 +181,7 @@ func (h *Hub) Broadcast(room string, msg any) {
 
 // BroadcastExcept sends to all clients except the sender (for optimistic UI)
 func (h *Hub) BroadcastExcept(room string, msg any, except *Client) {
-	if clients, ok := h.rooms.Load(room); ok {
-		clients.(*sync.Map).Range(func(key, value any) bool {
-			if key.(*Client) != except {
-				key.(*Client).Send(msg)
-			}
-			return true
-		})
-	}
+	h.fanOut(room, msg, except, time.Time{})
 
 	// If Redis is available, publish to other server instances
 	// (other servers don't have the "except" client, so they broadcast to all)
@@ -127,36 +197,34 @@ func (h *Hub) BroadcastExcept(room string, msg any, except *Client) {
 
 // GetClient returns a client if they're in the room
 func (h *Hub) GetClient(room string, client *Client) bool {
-	if clients, ok := h.rooms.Load(room); ok {
-		_, exists := clients.(*sync.Map).Load(client)
-		return exists
-	}
-	return false
+	return h.rooms.contains(room, client)
 }
 
 // NotifyUser sends a message to a specific user across all rooms they're in.
 // Used for targeted notifications (e.g., @mentions, pin alerts).
 func (h *Hub) NotifyUser(userID string, msg any) {
-	h.rooms.Range(func(key, value any) bool {
-		value.(*sync.Map).Range(func(clientKey, _ any) bool {
-			client := clientKey.(*Client)
-			if UUIDToString(client.UserID) == userID {
-				client.Send(msg)
-			}
-			return true
-		})
-		return true
-	})
+	h.notifyLocal(userID, msg)
 
 	// Also publish via Redis so other server instances can deliver
 	if h.redis != nil {
-		payload, err := json.Marshal(map[string]any{
-			"target_user": userID,
-			"message":     msg,
-		})
-		if err == nil {
-			h.redis.Publish(h.ctx, "notify:user", payload)
+		payload, err := json.Marshal(userNotice{Origin: h.id, TargetUser: userID, Message: msg})
+		if err != nil {
+			log.Printf("Redis publish marshal error: %v", err)
+			return
 		}
+		h.redis.Publish(h.ctx, notifyChannel, payload)
+	}
+}
+
+// notifyLocal sends msg to the user's clients on THIS server instance only,
+// found through the per-user connection tracking rather than every room
+func (h *Hub) notifyLocal(userID string, msg any) {
+	var uid pgtype.UUID
+	if err := uid.Scan(userID); err != nil {
+		return
+	}
+	for _, client := range h.userClients(uid) {
+		client.Send(msg)
 	}
 }
 
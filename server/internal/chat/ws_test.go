@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"wireloop/internal/testkit"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestNotifyUserAcrossInstances(t *testing.T) {
+	sender := NewHub(testkit.Redis(t))
+	receiver := NewHub(testkit.Redis(t))
+
+	uid := pgtype.UUID{Bytes: [16]byte{7}, Valid: true}
+	client := NewStreamClient(uid, "ada", "")
+	receiver.Join("loop:app", client)
+	defer func() {
+		receiver.Leave("loop:app", client)
+		client.Close()
+	}()
+
+	// The receiver subscribes in the background, so keep notifying until
+	// a notice gets through
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for {
+		sender.NotifyUser(UUIDToString(uid), map[string]any{"type": "read_state"})
+		wait, stop := context.WithTimeout(ctx, 100*time.Millisecond)
+		ev, ok := client.Receive(wait)
+		stop()
+		if ok {
+			if m, _ := ev.Data.(map[string]any); m["type"] != "read_state" {
+				t.Fatalf("got %+v, want the read_state notice", ev.Data)
+			}
+			return
+		}
+		if ctx.Err() != nil {
+			t.Fatal("notice from another instance never arrived")
+		}
+	}
+}
+
+func TestNotifyUserReachesOnlyTheUsersClients(t *testing.T) {
+	h := NewHub(nil)
+	ada := pgtype.UUID{Bytes: [16]byte{7}, Valid: true}
+	bob := pgtype.UUID{Bytes: [16]byte{8}, Valid: true}
+	mine, theirs := NewStreamClient(ada, "ada", ""), NewStreamClient(bob, "bob", "")
+	for _, c := range []*Client{mine, theirs} {
+		h.Connect(c)
+		// In a channel and its loop, like every connection
+		h.Join("channel", c)
+		h.Join("loop:app", c)
+	}
+
+	h.NotifyUser(UUIDToString(ada), "ping")
+
+	receive := func(c *Client) (Event, bool) {
+		wait, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		return c.Receive(wait)
+	}
+	if ev, ok := receive(mine); !ok || ev.Data != "ping" {
+		t.Fatalf("ada got %+v, %v; want the notice", ev, ok)
+	}
+	if _, ok := receive(mine); ok {
+		t.Error("ada got the notice twice")
+	}
+	if _, ok := receive(theirs); ok {
+		t.Error("bob got ada's notice")
+	}
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// batchSender is implemented by *pgxpool.Pool, *pgx.Conn and pgx.Tx
+type batchSender interface {
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// ChannelUnreadCap is where per-channel unread counts stop; the sidebar shows
+// anything at the cap as "99+"
+const ChannelUnreadCap = 100
+
+// LoopOverview is everything HandleLoopFull needs once the project is known
+type LoopOverview struct {
+	Members  []GetLoopMembersRow
+	IsMember bool
+	Channels []GetChannelsByProjectRow
+	// Messages for the requested channel, or for the entry channel when none was requested
+	Messages []GetMessagesRow
+	// Activity and unread counts for the channels the user can see
+	Activity []GetChannelActivityRow
+	// Topics and banners of the channels that have one
+	Topics []ChannelTopic
+	// Data policies of the channels that have one
+	Policies []ChannelDataPolicy
+}
+
+// GetLoopOverview loads members, membership, channels, channel activity,
+// topics and data policies, and the first page of messages in a single round trip using a pgx batch. When channelID is not
+// valid, messages come from the loop's entry channel (default, else first).
+func (q *Queries) GetLoopOverview(ctx context.Context, projectID, userID, channelID pgtype.UUID, limit int32) (LoopOverview, error) {
+	sender, ok := q.db.(batchSender)
+	if !ok {
+		return q.getLoopOverviewSerial(ctx, projectID, userID, channelID, limit)
+	}
+
+	var out LoopOverview
+	batch := &pgx.Batch{}
+
+	batch.Queue(getLoopMembers, projectID).Query(func(rows pgx.Rows) error {
+		defer rows.Close()
+		for rows.Next() {
+			var i GetLoopMembersRow
+			if err := rows.Scan(
+				&i.ID,
+				&i.Username,
+				&i.AvatarUrl,
+				&i.DisplayName,
+				&i.Role,
+				&i.JoinedAt,
+			); err != nil {
+				return err
+			}
+			out.Members = append(out.Members, i)
+		}
+		return rows.Err()
+	})
+
+	batch.Queue(isMember, userID, projectID).QueryRow(func(row pgx.Row) error {
+		var n int32
+		err := row.Scan(&n)
+		if err == pgx.ErrNoRows {
+			return nil
+		}
+		out.IsMember = err == nil
+		return err
+	})
+
+	batch.Queue(getChannelsByProject, projectID).Query(func(rows pgx.Rows) error {
+		defer rows.Close()
+		for rows.Next() {
+			var i GetChannelsByProjectRow
+			if err := rows.Scan(
+				&i.ID,
+				&i.ProjectID,
+				&i.Name,
+				&i.Description,
+				&i.IsDefault,
+				&i.Position,
+				&i.CreatedAt,
+			); err != nil {
+				return err
+			}
+			out.Channels = append(out.Channels, i)
+		}
+		return rows.Err()
+	})
+
+	activity := GetChannelActivityParams{UnreadCap: ChannelUnreadCap, UserID: userID, ProjectID: projectID}
+	batch.Queue(getChannelActivity, activity.UnreadCap, activity.UserID, activity.ProjectID).Query(func(rows pgx.Rows) error {
+		defer rows.Close()
+		for rows.Next() {
+			var i GetChannelActivityRow
+			if err := rows.Scan(
+				&i.ProjectID,
+				&i.ChannelID,
+				&i.LastMessageID,
+				&i.LastMessageAt,
+				&i.LastMessageContent,
+				&i.LastMessageSender,
+				&i.UnreadCount,
+				&i.MentionCount,
+			); err != nil {
+				return err
+			}
+			out.Activity = append(out.Activity, i)
+		}
+		return rows.Err()
+	})
+
+	batch.Queue(getProjectChannelTopics, projectID).Query(func(rows pgx.Rows) error {
+		defer rows.Close()
+		for rows.Next() {
+			var i ChannelTopic
+			if err := rows.Scan(
+				&i.ChannelID,
+				&i.ProjectID,
+				&i.Topic,
+				&i.TopicSetBy,
+				&i.TopicSetAt,
+				&i.Banner,
+				&i.BannerSetBy,
+				&i.BannerSetAt,
+				&i.BannerExpiresAt,
+			); err != nil {
+				return err
+			}
+			out.Topics = append(out.Topics, i)
+		}
+		return rows.Err()
+	})
+
+	batch.Queue(getProjectChannelDataPolicies, projectID).Query(func(rows pgx.Rows) error {
+		defer rows.Close()
+		for rows.Next() {
+			var i ChannelDataPolicy
+			if err := rows.Scan(
+				&i.ChannelID,
+				&i.ProjectID,
+				&i.AiSummaries,
+				&i.Exports,
+				&i.UpdatedBy,
+				&i.UpdatedAt,
+			); err != nil {
+				return err
+			}
+			out.Policies = append(out.Policies, i)
+		}
+		return rows.Err()
+	})
+
+	scanMessages := func(rows pgx.Rows) error {
+		defer rows.Close()
+		for rows.Next() {
+			var i GetMessagesRow
+			if err := rows.Scan(
+				&i.ID,
+				&i.Content,
+				&i.CreatedAt,
+				&i.SenderID,
+				&i.ChannelID,
+				&i.ParentID,
+				&i.ReplyCount,
+				&i.Seq,
+				&i.SenderUsername,
+				&i.SenderAvatar,
+				&i.IsDeleted,
+			); err != nil {
+				return err
+			}
+			out.Messages = append(out.Messages, i)
+		}
+		return rows.Err()
+	}
+	if channelID.Valid {
+		batch.Queue(getMessages, channelID, limit, 0).Query(scanMessages)
+	} else {
+		batch.Queue(getEntryChannelMessages, projectID, limit).Query(scanMessages)
+	}
+
+	if err := sender.SendBatch(ctx, batch).Close(); err != nil {
+		return LoopOverview{}, err
+	}
+	return out, nil
+}
+
+// getLoopOverviewSerial is the fallback for DBTX implementations without batch support
+func (q *Queries) getLoopOverviewSerial(ctx context.Context, projectID, userID, channelID pgtype.UUID, limit int32) (LoopOverview, error) {
+	var out LoopOverview
+	var err error
+
+	if out.Members, err = q.GetLoopMembers(ctx, projectID); err != nil {
+		return LoopOverview{}, err
+	}
+	_, memberErr := q.IsMember(ctx, IsMemberParams{UserID: userID, ProjectID: projectID})
+	out.IsMember = memberErr == nil
+	if out.Channels, err = q.GetChannelsByProject(ctx, projectID); err != nil {
+		return LoopOverview{}, err
+	}
+	if out.Activity, err = q.GetChannelActivity(ctx, GetChannelActivityParams{
+		UnreadCap: ChannelUnreadCap,
+		UserID:    userID,
+		ProjectID: projectID,
+	}); err != nil {
+		return LoopOverview{}, err
+	}
+	if out.Topics, err = q.GetProjectChannelTopics(ctx, projectID); err != nil {
+		return LoopOverview{}, err
+	}
+	if out.Policies, err = q.GetProjectChannelDataPolicies(ctx, projectID); err != nil {
+		return LoopOverview{}, err
+	}
+
+	if channelID.Valid {
+		out.Messages, err = q.GetMessages(ctx, GetMessagesParams{ChannelID: channelID, Limit: limit})
+		return out, err
+	}
+	entry, err := q.GetEntryChannelMessages(ctx, GetEntryChannelMessagesParams{ProjectID: projectID, Limit: limit})
+	for _, m := range entry {
+		out.Messages = append(out.Messages, GetMessagesRow(m))
+	}
+	return out, err
+}
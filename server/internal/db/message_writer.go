@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	messageFlushInterval = 5 * time.Millisecond // longest a queued message waits
+	messageBatchMax      = 500
+	messageQueueSize     = 4096
+	messageFlushTimeout  = 10 * time.Second
+)
+
+// copier is implemented by *pgxpool.Pool, *pgx.Conn and pgx.Tx
+type copier interface {
+	CopyFrom(ctx context.Context, table pgx.Identifier, columns []string, rows pgx.CopyFromSource) (int64, error)
+}
+
+var messageColumns = []string{"id", "project_id", "channel_id", "sender_id", "content", "parent_id", "seq"}
+
+// MessageWriter coalesces message inserts. Messages queued within a few
+// milliseconds of each other are written together with COPY, in snowflake
+// ID order, instead of one INSERT (and one pooled connection) each.
+type MessageWriter struct {
+	q     *Queries
+	queue chan pendingMessage
+}
+
+type pendingMessage struct {
+	arg  AddMessageParams
+	done func(error)
+}
+
+func NewMessageWriter(q *Queries) *MessageWriter {
+	return &MessageWriter{q: q, queue: make(chan pendingMessage, messageQueueSize)}
+}
+
+// Add queues a message. done, if not nil, runs in its own goroutine once the
+// message is stored or has failed to be.
+func (w *MessageWriter) Add(arg AddMessageParams, done func(error)) {
+	select {
+	case w.queue <- pendingMessage{arg, done}:
+	default:
+		// Queue full: write this one on its own rather than block the sender
+		go w.flush([]pendingMessage{{arg, done}})
+	}
+}
+
+// Pending reports how many messages are queued and not yet being written
+func (w *MessageWriter) Pending() int {
+	return len(w.queue)
+}
+
+// Run writes queued messages until ctx ends, then drains the queue
+func (w *MessageWriter) Run(ctx context.Context) {
+	batch := make([]pendingMessage, 0, messageBatchMax)
+	for {
+		select {
+		case p := <-w.queue:
+			batch = append(batch[:0], p)
+		case <-ctx.Done():
+			w.drain(batch[:0])
+			return
+		}
+		timer := time.NewTimer(messageFlushInterval)
+	collect:
+		for len(batch) < messageBatchMax {
+			select {
+			case p := <-w.queue:
+				batch = append(batch, p)
+			case <-timer.C:
+				break collect
+			case <-ctx.Done():
+				break collect
+			}
+		}
+		timer.Stop()
+		w.flush(batch)
+	}
+}
+
+func (w *MessageWriter) drain(batch []pendingMessage) {
+	for {
+		select {
+		case p := <-w.queue:
+			batch = append(batch, p)
+			if len(batch) == messageBatchMax {
+				w.flush(batch)
+				batch = batch[:0]
+			}
+		default:
+			if len(batch) > 0 {
+				w.flush(batch)
+			}
+			return
+		}
+	}
+}
+
+// flush writes a batch with COPY, falling back to one INSERT per message if
+// that fails so a single bad row (a channel deleted meanwhile) doesn't lose
+// the others
+func (w *MessageWriter) flush(batch []pendingMessage) {
+	ctx, cancel := context.WithTimeout(context.Background(), messageFlushTimeout)
+	defer cancel()
+	sort.Slice(batch, func(i, j int) bool { return batch[i].arg.ID < batch[j].arg.ID })
+
+	errs := make([]error, len(batch))
+	cp, ok := w.q.db.(copier)
+	copied := false
+	if ok && len(batch) > 1 {
+		_, err := cp.CopyFrom(ctx, pgx.Identifier{"messages"}, messageColumns, pgx.CopyFromSlice(len(batch), func(i int) ([]any, error) {
+			a := batch[i].arg
+			return []any{a.ID, a.ProjectID, a.ChannelID, a.SenderID, a.Content, a.ParentID, a.Seq}, nil
+		}))
+		if err != nil {
+			log.Printf("[messages] batch of %d failed, inserting one by one: %v", len(batch), err)
+		}
+		copied = err == nil
+	}
+	if !copied {
+		for i, p := range batch {
+			errs[i] = w.q.AddMessage(ctx, p.arg)
+		}
+	}
+	for i, p := range batch {
+		if p.done != nil {
+			go p.done(errs[i])
+		}
+	}
+}
@@ -8,6 +8,80 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type AccountMerge struct {
+	ID             pgtype.UUID
+	SurvivorID     pgtype.UUID
+	MergedID       pgtype.UUID
+	MergedUsername string
+	Admin          string
+	Reason         string
+	Memberships    int32
+	Messages       int32
+	Notifications  int32
+	Loops          int32
+	CreatedAt      pgtype.Timestamptz
+}
+
+type AccountQuota struct {
+	UserID         pgtype.UUID
+	MaxLoops       pgtype.Int8
+	MessagesPerDay pgtype.Int8
+	StorageBytes   pgtype.Int8
+	Note           pgtype.Text
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type ApiKey struct {
+	ID         pgtype.UUID
+	UserID     pgtype.UUID
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	RateLimit  int32
+	LastUsedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	RevokedAt  pgtype.Timestamptz
+}
+
+type Attachment struct {
+	ID            pgtype.UUID
+	ProjectID     pgtype.UUID
+	ChannelID     pgtype.UUID
+	UploaderID    pgtype.UUID
+	Filename      string
+	ContentType   string
+	SizeBytes     int64
+	StorageKey    string
+	ScanStatus    string
+	ScanEngine    pgtype.Text
+	ScanSignature pgtype.Text
+	ScannedAt     pgtype.Timestamptz
+	CreatedAt     pgtype.Timestamptz
+}
+
+type BoardCard struct {
+	ID                pgtype.UUID
+	ProjectID         pgtype.UUID
+	ColumnID          pgtype.UUID
+	Title             string
+	Body              pgtype.Text
+	GithubIssueNumber pgtype.Int4
+	GithubState       pgtype.Text
+	Position          int32
+	CreatedBy         pgtype.UUID
+	CreatedAt         pgtype.Timestamptz
+	UpdatedAt         pgtype.Timestamptz
+}
+
+type BoardColumn struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+	Name      string
+	Position  int32
+	CreatedAt pgtype.Timestamptz
+}
+
 type Channel struct {
 	ID          pgtype.UUID
 	ProjectID   pgtype.UUID
@@ -19,11 +93,410 @@ type Channel struct {
 	UpdatedAt   pgtype.Timestamptz
 }
 
-type Membership struct {
+type ChannelDataPolicy struct {
+	ChannelID   pgtype.UUID
+	ProjectID   pgtype.UUID
+	AiSummaries bool
+	Exports     bool
+	UpdatedBy   pgtype.UUID
+	UpdatedAt   pgtype.Timestamptz
+}
+
+type ChannelGithubLink struct {
+	ChannelID    pgtype.UUID
+	ProjectID    pgtype.UUID
+	GithubNumber int32
+	Kind         string
+	CrossPost    bool
+	LinkedBy     pgtype.UUID
+	ArchivedAt   pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+}
+
+type ChannelRead struct {
+	UserID     pgtype.UUID
+	ChannelID  pgtype.UUID
+	LastReadID int64
+	UpdatedAt  pgtype.Timestamptz
+}
+
+type ChannelSeq struct {
+	ChannelID pgtype.UUID
+	LastSeq   int64
+}
+
+type ChannelTopic struct {
+	ChannelID       pgtype.UUID
+	ProjectID       pgtype.UUID
+	Topic           string
+	TopicSetBy      pgtype.UUID
+	TopicSetAt      pgtype.Timestamptz
+	Banner          string
+	BannerSetBy     pgtype.UUID
+	BannerSetAt     pgtype.Timestamptz
+	BannerExpiresAt pgtype.Timestamptz
+}
+
+type DmConversation struct {
+	ID            pgtype.UUID
+	DmKey         pgtype.Text
+	IsBot         bool
+	CreatedAt     pgtype.Timestamptz
+	LastMessageAt pgtype.Timestamptz
+	Status        string
+	RequestedBy   pgtype.UUID
+	IsGroup       bool
+	Name          pgtype.Text
+	CreatedBy     pgtype.UUID
+}
+
+type DmMessage struct {
+	ID             int64
+	ConversationID pgtype.UUID
+	SenderID       pgtype.UUID
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+}
+
+type DmParticipant struct {
+	ConversationID pgtype.UUID
+	UserID         pgtype.UUID
+	LastReadAt     pgtype.Timestamptz
+	JoinedAt       pgtype.Timestamptz
+}
+
+type Event struct {
+	ID              pgtype.UUID
+	ProjectID       pgtype.UUID
+	Title           string
+	Description     pgtype.Text
+	Kind            string
+	StartsAt        pgtype.Timestamptz
+	EndsAt          pgtype.Timestamptz
+	Recurrence      string
+	RecurrenceUntil pgtype.Timestamptz
+	RemindMinutes   int32
+	CreatedBy       pgtype.UUID
+	CreatedAt       pgtype.Timestamptz
+	UpdatedAt       pgtype.Timestamptz
+}
+
+type EventRsvp struct {
+	EventID   pgtype.UUID
+	UserID    pgtype.UUID
+	Status    string
+	UpdatedAt pgtype.Timestamptz
+}
+
+type FeatureFlag struct {
+	Key            string
+	Description    pgtype.Text
+	Enabled        bool
+	RolloutPercent int32
+	AllowUsers     []pgtype.UUID
+	AllowLoops     []pgtype.UUID
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type FilteredMessage struct {
+	ID         int64
+	ProjectID  pgtype.UUID
+	ChannelID  pgtype.UUID
+	SenderID   pgtype.UUID
+	ParentID   pgtype.Int8
+	Content    string
+	Rule       string
+	Action     string
+	Detail     pgtype.Text
+	Status     string
+	ReviewedBy pgtype.UUID
+	ReviewedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+}
+
+type GithubItemSubscription struct {
+	ProjectID    pgtype.UUID
+	UserID       pgtype.UUID
+	GithubNumber int32
+	Kind         string
+	ChannelID    pgtype.UUID
+	CreatedAt    pgtype.Timestamptz
+}
+
+type ImpersonationRequest struct {
+	ID        int64
+	SessionID pgtype.UUID
+	Method    string
+	Path      string
+	Status    int32
+	CreatedAt pgtype.Timestamptz
+}
+
+type ImpersonationSession struct {
+	ID        pgtype.UUID
+	UserID    pgtype.UUID
+	Admin     string
+	Reason    string
+	ExpiresAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type IncomingWebhook struct {
+	ID         pgtype.UUID
+	ProjectID  pgtype.UUID
+	ChannelID  pgtype.UUID
+	BotID      pgtype.UUID
+	Name       string
+	Prefix     string
+	TokenHash  string
+	CreatedBy  pgtype.UUID
+	LastUsedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	RevokedAt  pgtype.Timestamptz
+}
+
+type IntegrationAudit struct {
+	ID              int64
+	ProjectID       pgtype.UUID
+	IntegrationID   pgtype.UUID
+	IntegrationName string
+	ActorID         pgtype.UUID
+	Action          string
+	Detail          string
+	CreatedAt       pgtype.Timestamptz
+}
+
+type Job struct {
+	ID        int64
+	Type      string
+	Payload   []byte
+	RunAt     pgtype.Timestamptz
+	Status    string
+	Attempts  int32
+	LastError pgtype.Text
+	CreatedAt pgtype.Timestamptz
+	UpdatedAt pgtype.Timestamptz
+}
+
+type LoopActivity struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+	ActorID   pgtype.UUID
+	Kind      string
+	RefID     pgtype.Text
+	Summary   string
+	CreatedAt pgtype.Timestamptz
+}
+
+type LoopBan struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+	BannedBy  pgtype.UUID
+	Reason    pgtype.Text
+	CreatedAt pgtype.Timestamptz
+}
+
+type LoopDailyStat struct {
+	ProjectID     pgtype.UUID
+	Day           pgtype.Date
+	Members       int32
+	NewMembers    int32
+	Messages      int32
+	ActiveMembers int32
+}
+
+type LoopEmoji struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+	Name      string
+	ImagePath string
+	CreatedBy pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
+type LoopFilterSetting struct {
+	ProjectID        pgtype.UUID
+	BannedWords      []string
+	BannedWordAction string
+	MaxLinks         int32
+	LinkSpamAction   string
+	RepeatLimit      int32
+	RepeatAction     string
+	UpdatedAt        pgtype.Timestamptz
+}
+
+type LoopGithubDigest struct {
+	ProjectID    pgtype.UUID
+	ChannelID    pgtype.UUID
+	Frequency    string
+	SendTime     string
+	Timezone     string
+	Weekday      int32
+	Sections     []string
+	Enabled      bool
+	LastPostedAt pgtype.Timestamptz
+	UpdatedBy    pgtype.UUID
+	UpdatedAt    pgtype.Timestamptz
+}
+
+type LoopGithubSetting struct {
+	ProjectID              pgtype.UUID
+	AnnouncementsChannelID pgtype.UUID
+	UpdatedAt              pgtype.Timestamptz
+	DeployChannelID        pgtype.UUID
+	ProductionEnvironment  string
+	FirstPrChannelID       pgtype.UUID
+	FirstPrTemplate        string
+}
+
+type LoopGithubToken struct {
+	ProjectID      pgtype.UUID
+	Source         string
+	InstallationID pgtype.Int8
+	UpdatedBy      pgtype.UUID
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type LoopIntegration struct {
+	ID             pgtype.UUID
+	ProjectID      pgtype.UUID
+	Type           string
+	Name           string
+	Config         []byte
+	Topics         []string
+	Enabled        bool
+	CredentialsRef pgtype.Text
+	CreatedBy      pgtype.UUID
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type LoopInvite struct {
+	Code      string
+	ProjectID pgtype.UUID
+	Role      string
+	CreatedBy pgtype.UUID
+	MaxUses   pgtype.Int4
+	Uses      int32
+	ExpiresAt pgtype.Timestamptz
+	CreatedAt pgtype.Timestamptz
+}
+
+type LoopMemberDailyStat struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+	Day       pgtype.Date
+	Messages  int32
+}
+
+type LoopMirror struct {
+	ProjectID  pgtype.UUID
+	Reason     string
+	Since      pgtype.Timestamptz
+	ArchivedAt pgtype.Timestamptz
+	ArchivedBy pgtype.UUID
+}
+
+type LoopReactionDailyStat struct {
+	ProjectID pgtype.UUID
+	Day       pgtype.Date
+	Emoji     string
+	AuthorID  pgtype.UUID
+	Reactions int32
+}
+
+type LoopSecret struct {
+	ID         pgtype.UUID
+	ProjectID  pgtype.UUID
+	Name       string
+	Ciphertext []byte
+	Nonce      []byte
+	WrappedKey []byte
+	KeyID      string
+	Hint       string
+	CreatedBy  pgtype.UUID
+	CreatedAt  pgtype.Timestamptz
+	RotatedAt  pgtype.Timestamptz
+}
+
+type LoopSetting struct {
+	ProjectID          pgtype.UUID
+	WelcomeChannelID   pgtype.UUID
+	WelcomeTemplate    string
+	WelcomeDm          bool
+	UpdatedAt          pgtype.Timestamptz
+	Visibility         string
+	PublicChannelIds   []pgtype.UUID
+	GuestChannelIds    []pgtype.UUID
+	PinRole            string
+	PinLimit           int32
+	DigestChannelID    pgtype.UUID
+	DigestPostedAt     pgtype.Timestamptz
+	DefaultNotifyLevel string
+	ThreadSummaries    string
+	AiDisabled         bool
+	AiModel            string
+	AiMaxTokens        int32
+	AiTemperature      pgtype.Float4
+	AiSafety           string
+	AiContent          []string
+	AccessRevoked      string
+	AiLanguage         string
+	AiTone             string
+}
+
+type LoopVerificationAttempt struct {
+	ID        pgtype.UUID
 	UserID    pgtype.UUID
 	ProjectID pgtype.UUID
-	Role      pgtype.Text
-	JoinedAt  pgtype.Timestamptz
+	Passed    bool
+	Results   []byte
+	Source    string
+	CheckedAt pgtype.Timestamptz
+}
+
+type LoopWelcome struct {
+	ProjectID  pgtype.UUID
+	UserID     pgtype.UUID
+	WelcomedAt pgtype.Timestamptz
+}
+
+type LongPost struct {
+	MessageID    int64
+	AttachmentID pgtype.UUID
+	Chars        int32
+	CreatedAt    pgtype.Timestamptz
+}
+
+type MemberBadge struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+	Badge     string
+	AwardedAt pgtype.Timestamptz
+}
+
+type Membership struct {
+	UserID           pgtype.UUID
+	ProjectID        pgtype.UUID
+	Role             pgtype.Text
+	JoinedAt         pgtype.Timestamptz
+	IsFavorite       bool
+	SortOrder        pgtype.Int4
+	SidebarCollapsed bool
+	LastActiveAt     pgtype.Timestamptz
+	NotifyLevel      string
+}
+
+type Mention struct {
+	ID             int64
+	UserID         pgtype.UUID
+	MessageID      int64
+	ProjectID      pgtype.UUID
+	ChannelID      pgtype.UUID
+	ActorID        pgtype.UUID
+	IsRead         bool
+	CreatedAt      pgtype.Timestamptz
+	NotificationID pgtype.Int8
 }
 
 type Message struct {
@@ -40,6 +513,46 @@ type Message struct {
 	IsPinned   pgtype.Bool
 	PinnedBy   pgtype.UUID
 	PinnedAt   pgtype.Timestamptz
+	Seq        pgtype.Int8
+}
+
+type MessageGithubComment struct {
+	MessageID int64
+	CommentID int64
+}
+
+type MessageGithubEntity struct {
+	MessageID  int64
+	TextOffset int32
+	TextLength int32
+	Kind       string
+	Number     int32
+	Sha        string
+	Title      string
+	State      string
+	Url        string
+	ResolvedAt pgtype.Timestamptz
+}
+
+type MessageReaction struct {
+	MessageID int64
+	UserID    pgtype.UUID
+	Emoji     string
+	CreatedAt pgtype.Timestamptz
+}
+
+type MessageReport struct {
+	ID         pgtype.UUID
+	MessageID  int64
+	ProjectID  pgtype.UUID
+	ReporterID pgtype.UUID
+	Reason     string
+	Details    pgtype.Text
+	Status     string
+	Resolution pgtype.Text
+	ResolvedBy pgtype.UUID
+	ResolvedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
 }
 
 type Notification struct {
@@ -54,6 +567,93 @@ type Notification struct {
 	ContentPreview pgtype.Text
 	IsRead         pgtype.Bool
 	CreatedAt      pgtype.Timestamptz
+	BatchCount     int32
+}
+
+type NotificationDelivery struct {
+	NotificationID int64
+	UserID         pgtype.UUID
+	State          string
+	PushedAt       pgtype.Timestamptz
+	AckedAt        pgtype.Timestamptz
+	Transport      pgtype.Text
+	Attempts       int32
+	LastError      pgtype.Text
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type OnboardingProgress struct {
+	StepID      pgtype.UUID
+	UserID      pgtype.UUID
+	CompletedAt pgtype.Timestamptz
+}
+
+type OnboardingStep struct {
+	ID          pgtype.UUID
+	ProjectID   pgtype.UUID
+	Title       string
+	Description string
+	Kind        string
+	ChannelID   pgtype.UUID
+	Position    int32
+	CreatedAt   pgtype.Timestamptz
+}
+
+type PersonalAccessToken struct {
+	ID         pgtype.UUID
+	UserID     pgtype.UUID
+	Name       string
+	Prefix     string
+	TokenHash  string
+	ExpiresAt  pgtype.Timestamptz
+	LastUsedAt pgtype.Timestamptz
+	CreatedAt  pgtype.Timestamptz
+	RevokedAt  pgtype.Timestamptz
+}
+
+type PersonalAccessTokenRequest struct {
+	ID        int64
+	TokenID   pgtype.UUID
+	Method    string
+	Path      string
+	Status    int32
+	CreatedAt pgtype.Timestamptz
+}
+
+type PinEvent struct {
+	ID        int64
+	ProjectID pgtype.UUID
+	ChannelID pgtype.UUID
+	MessageID int64
+	ActorID   pgtype.UUID
+	Action    string
+	CreatedAt pgtype.Timestamptz
+}
+
+type PrComment struct {
+	RepoID          int64
+	PrNumber        int32
+	CommentType     string
+	CommentID       int64
+	Body            string
+	Path            pgtype.Text
+	Line            pgtype.Int4
+	DiffHunk        pgtype.Text
+	InReplyToID     pgtype.Int8
+	State           pgtype.Text
+	Username        string
+	AvatarUrl       pgtype.Text
+	HtmlUrl         string
+	GithubCreatedAt pgtype.Timestamptz
+	GithubUpdatedAt pgtype.Timestamptz
+	SyncedAt        pgtype.Timestamptz
+}
+
+type PrCommentSync struct {
+	RepoID    int64
+	PrNumber  int32
+	SyncedAt  pgtype.Timestamptz
+	ClaimedAt pgtype.Timestamptz
 }
 
 type Project struct {
@@ -62,6 +662,18 @@ type Project struct {
 	Name         string
 	OwnerID      pgtype.UUID
 	CreatedAt    pgtype.Timestamptz
+	WorkspaceID  pgtype.UUID
+}
+
+type RepoStat struct {
+	RepoID       int64
+	FullName     string
+	Stars        int32
+	Forks        int32
+	Contributors int32
+	PushedAt     pgtype.Timestamptz
+	RefreshedAt  pgtype.Timestamptz
+	CheckedAt    pgtype.Timestamptz
 }
 
 type Rule struct {
@@ -72,14 +684,161 @@ type Rule struct {
 	CreatedAt    pgtype.Timestamptz
 }
 
+type Session struct {
+	ID         pgtype.UUID
+	UserID     pgtype.UUID
+	UserAgent  string
+	Ip         string
+	CreatedAt  pgtype.Timestamptz
+	LastSeenAt pgtype.Timestamptz
+	ExpiresAt  pgtype.Timestamptz
+	RevokedAt  pgtype.Timestamptz
+}
+
+type Standup struct {
+	ID             pgtype.UUID
+	ProjectID      pgtype.UUID
+	ChannelID      pgtype.UUID
+	Name           string
+	Questions      []string
+	PromptTime     string
+	Timezone       string
+	Weekdays       int32
+	CollectMinutes int32
+	Enabled        bool
+	CreatedBy      pgtype.UUID
+	CreatedAt      pgtype.Timestamptz
+	UpdatedAt      pgtype.Timestamptz
+}
+
+type StandupParticipant struct {
+	StandupID pgtype.UUID
+	UserID    pgtype.UUID
+}
+
+type StandupResponse struct {
+	RunID     pgtype.UUID
+	UserID    pgtype.UUID
+	Answers   []string
+	CreatedAt pgtype.Timestamptz
+}
+
+type StandupRun struct {
+	ID        pgtype.UUID
+	StandupID pgtype.UUID
+	Status    string
+	StartedAt pgtype.Timestamptz
+	ClosesAt  pgtype.Timestamptz
+	PostedAt  pgtype.Timestamptz
+}
+
+type Task struct {
+	ID                pgtype.UUID
+	ProjectID         pgtype.UUID
+	ChannelID         pgtype.UUID
+	MessageID         pgtype.Int8
+	Title             string
+	AssigneeID        pgtype.UUID
+	DueAt             pgtype.Timestamptz
+	Status            string
+	GithubIssueNumber pgtype.Int4
+	CreatedBy         pgtype.UUID
+	CompletedAt       pgtype.Timestamptz
+	CreatedAt         pgtype.Timestamptz
+}
+
+type ThreadSummary struct {
+	MessageID   int64
+	Summary     string
+	Replies     int32
+	LastReplyID int64
+	UpdatedAt   pgtype.Timestamptz
+}
+
 type User struct {
-	ID               pgtype.UUID
-	GithubID         int64
-	Username         string
-	AvatarUrl        pgtype.Text
-	DisplayName      pgtype.Text
-	AccessToken      string
-	ProfileCompleted pgtype.Bool
-	CreatedAt        pgtype.Timestamptz
-	UpdatedAt        pgtype.Timestamptz
+	ID                pgtype.UUID
+	GithubID          int64
+	Username          string
+	AvatarUrl         pgtype.Text
+	DisplayName       pgtype.Text
+	AccessToken       string
+	ProfileCompleted  pgtype.Bool
+	CreatedAt         pgtype.Timestamptz
+	UpdatedAt         pgtype.Timestamptz
+	GithubSyncedAt    pgtype.Timestamptz
+	UsernameChangedAt pgtype.Timestamptz
+}
+
+type UserBlock struct {
+	BlockerID pgtype.UUID
+	BlockedID pgtype.UUID
+	CreatedAt pgtype.Timestamptz
+}
+
+type UserMutedWord struct {
+	UserID    pgtype.UUID
+	Word      string
+	CreatedAt pgtype.Timestamptz
+}
+
+type UserSetting struct {
+	UserID          pgtype.UUID
+	DmPrivacy       string
+	UpdatedAt       pgtype.Timestamptz
+	Locale          pgtype.Text
+	Timezone        pgtype.Text
+	SendTyping      bool
+	ShowPresence    bool
+	NotifyFallback  []string
+	UndoSendSeconds int32
+}
+
+type UsernameHistory struct {
+	OldUsername string
+	UserID      pgtype.UUID
+	ChangedAt   pgtype.Timestamptz
+}
+
+type WebhookDelivery struct {
+	ID            int64
+	DeliveryID    string
+	Event         string
+	Action        string
+	RepoID        int64
+	Payload       []byte
+	Status        string
+	Error         pgtype.Text
+	Attempts      int32
+	ReceivedAt    pgtype.Timestamptz
+	LastAttemptAt pgtype.Timestamptz
+}
+
+type WorkflowDispatch struct {
+	RunID       int64
+	ProjectID   pgtype.UUID
+	ChannelID   pgtype.UUID
+	UserID      pgtype.UUID
+	Workflow    string
+	Ref         string
+	HtmlUrl     string
+	Conclusion  pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+	CompletedAt pgtype.Timestamptz
+}
+
+type Workspace struct {
+	ID             pgtype.UUID
+	Slug           string
+	Name           string
+	BillingOwnerID pgtype.UUID
+	Personal       bool
+	DefaultRole    string
+	CreatedAt      pgtype.Timestamptz
+}
+
+type WorkspaceMember struct {
+	WorkspaceID pgtype.UUID
+	UserID      pgtype.UUID
+	Role        string
+	JoinedAt    pgtype.Timestamptz
 }
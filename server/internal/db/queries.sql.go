@@ -11,9 +11,113 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const acceptDMRequest = `-- name: AcceptDMRequest :execrows
+UPDATE dm_conversations SET status = 'active'
+WHERE id = $1 AND status = 'pending' AND requested_by <> $2
+`
+
+type AcceptDMRequestParams struct {
+	ID          pgtype.UUID
+	RequestedBy pgtype.UUID
+}
+
+func (q *Queries) AcceptDMRequest(ctx context.Context, arg AcceptDMRequestParams) (int64, error) {
+	result, err := q.db.Exec(ctx, acceptDMRequest, arg.ID, arg.RequestedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const ackNotificationDelivery = `-- name: AckNotificationDelivery :execrows
+UPDATE notification_deliveries
+SET acked_at = COALESCE(acked_at, NOW()),
+    state = CASE WHEN state IN ('pending', 'retrying') THEN 'delivered' ELSE state END,
+    updated_at = NOW()
+WHERE notification_id = $1 AND user_id = $2
+`
+
+type AckNotificationDeliveryParams struct {
+	NotificationID int64
+	UserID         pgtype.UUID
+}
+
+// A late ack is recorded but doesn't undo a fallback already sent
+func (q *Queries) AckNotificationDelivery(ctx context.Context, arg AckNotificationDeliveryParams) (int64, error) {
+	result, err := q.db.Exec(ctx, ackNotificationDelivery, arg.NotificationID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const addDMMessage = `-- name: AddDMMessage :exec
+INSERT INTO dm_messages (id, conversation_id, sender_id, content)
+VALUES ($1, $2, $3, $4)
+`
+
+type AddDMMessageParams struct {
+	ID             int64
+	ConversationID pgtype.UUID
+	SenderID       pgtype.UUID
+	Content        string
+}
+
+func (q *Queries) AddDMMessage(ctx context.Context, arg AddDMMessageParams) error {
+	_, err := q.db.Exec(ctx, addDMMessage,
+		arg.ID,
+		arg.ConversationID,
+		arg.SenderID,
+		arg.Content,
+	)
+	return err
+}
+
+const addDMParticipant = `-- name: AddDMParticipant :exec
+INSERT INTO dm_participants (conversation_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddDMParticipantParams struct {
+	ConversationID pgtype.UUID
+	UserID         pgtype.UUID
+}
+
+func (q *Queries) AddDMParticipant(ctx context.Context, arg AddDMParticipantParams) error {
+	_, err := q.db.Exec(ctx, addDMParticipant, arg.ConversationID, arg.UserID)
+	return err
+}
+
+const addIntegrationAudit = `-- name: AddIntegrationAudit :exec
+INSERT INTO integration_audit (project_id, integration_id, integration_name, actor_id, action, detail)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type AddIntegrationAuditParams struct {
+	ProjectID       pgtype.UUID
+	IntegrationID   pgtype.UUID
+	IntegrationName string
+	ActorID         pgtype.UUID
+	Action          string
+	Detail          string
+}
+
+func (q *Queries) AddIntegrationAudit(ctx context.Context, arg AddIntegrationAuditParams) error {
+	_, err := q.db.Exec(ctx, addIntegrationAudit,
+		arg.ProjectID,
+		arg.IntegrationID,
+		arg.IntegrationName,
+		arg.ActorID,
+		arg.Action,
+		arg.Detail,
+	)
+	return err
+}
+
 const addMembership = `-- name: AddMembership :exec
-INSERT INTO memberships (user_id, project_id, role)
-VALUES ($1, $2, $3)
+INSERT INTO memberships (user_id, project_id, role, notify_level)
+VALUES ($1, $2, $3, COALESCE((SELECT default_notify_level FROM loop_settings WHERE project_id = $2), 'mentions'))
 `
 
 type AddMembershipParams struct {
@@ -22,14 +126,15 @@ type AddMembershipParams struct {
 	Role      pgtype.Text
 }
 
+// New members start at the loop's default notification level
 func (q *Queries) AddMembership(ctx context.Context, arg AddMembershipParams) error {
 	_, err := q.db.Exec(ctx, addMembership, arg.UserID, arg.ProjectID, arg.Role)
 	return err
 }
 
 const addMessage = `-- name: AddMessage :exec
-INSERT INTO messages (id, project_id, channel_id, sender_id, content, parent_id)
-VALUES ($1, $2, $3, $4, $5, $6)
+INSERT INTO messages (id, project_id, channel_id, sender_id, content, parent_id, seq)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
 `
 
 type AddMessageParams struct {
@@ -39,6 +144,7 @@ type AddMessageParams struct {
 	SenderID  pgtype.UUID
 	Content   string
 	ParentID  pgtype.Int8
+	Seq       pgtype.Int8
 }
 
 func (q *Queries) AddMessage(ctx context.Context, arg AddMessageParams) error {
@@ -49,10 +155,51 @@ func (q *Queries) AddMessage(ctx context.Context, arg AddMessageParams) error {
 		arg.SenderID,
 		arg.Content,
 		arg.ParentID,
+		arg.Seq,
 	)
 	return err
 }
 
+const addMessageReaction = `-- name: AddMessageReaction :execrows
+
+INSERT INTO message_reactions (message_id, user_id, emoji)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING
+`
+
+type AddMessageReactionParams struct {
+	MessageID int64
+	UserID    pgtype.UUID
+	Emoji     string
+}
+
+// ============================================================================
+// MESSAGE REACTIONS
+// ============================================================================
+func (q *Queries) AddMessageReaction(ctx context.Context, arg AddMessageReactionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, addMessageReaction, arg.MessageID, arg.UserID, arg.Emoji)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const addMutedWord = `-- name: AddMutedWord :exec
+INSERT INTO user_muted_words (user_id, word)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type AddMutedWordParams struct {
+	UserID pgtype.UUID
+	Word   string
+}
+
+func (q *Queries) AddMutedWord(ctx context.Context, arg AddMutedWordParams) error {
+	_, err := q.db.Exec(ctx, addMutedWord, arg.UserID, arg.Word)
+	return err
+}
+
 const addReply = `-- name: AddReply :exec
 INSERT INTO messages (id, project_id, channel_id, sender_id, content, parent_id)
 VALUES ($1, $2, $3, $4, $5, $6)
@@ -79,197 +226,179 @@ func (q *Queries) AddReply(ctx context.Context, arg AddReplyParams) error {
 	return err
 }
 
-const createChannel = `-- name: CreateChannel :one
-
-INSERT INTO channels (project_id, name, description, is_default, position)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, project_id, name, description, is_default, position, created_at, updated_at
+const addStandupParticipant = `-- name: AddStandupParticipant :exec
+INSERT INTO standup_participants (standup_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
 `
 
-type CreateChannelParams struct {
-	ProjectID   pgtype.UUID
-	Name        string
-	Description pgtype.Text
-	IsDefault   pgtype.Bool
-	Position    pgtype.Int4
+type AddStandupParticipantParams struct {
+	StandupID pgtype.UUID
+	UserID    pgtype.UUID
 }
 
-// ============================================================================
-// CHANNEL QUERIES
-// ============================================================================
-func (q *Queries) CreateChannel(ctx context.Context, arg CreateChannelParams) (Channel, error) {
-	row := q.db.QueryRow(ctx, createChannel,
-		arg.ProjectID,
-		arg.Name,
-		arg.Description,
-		arg.IsDefault,
-		arg.Position,
-	)
-	var i Channel
-	err := row.Scan(
-		&i.ID,
-		&i.ProjectID,
-		&i.Name,
-		&i.Description,
-		&i.IsDefault,
-		&i.Position,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) AddStandupParticipant(ctx context.Context, arg AddStandupParticipantParams) error {
+	_, err := q.db.Exec(ctx, addStandupParticipant, arg.StandupID, arg.UserID)
+	return err
 }
 
-const createNotification = `-- name: CreateNotification :exec
-
-INSERT INTO notifications (id, user_id, type, message_id, project_id, channel_id, actor_id, actor_username, content_preview)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+const addWorkspaceMemberToLoops = `-- name: AddWorkspaceMemberToLoops :exec
+INSERT INTO memberships (user_id, project_id, role, notify_level)
+SELECT wm.user_id, p.id, CASE WHEN wm.role IN ('owner', 'admin') THEN 'moderator' ELSE w.default_role END,
+    COALESCE(ls.default_notify_level, 'mentions')
+FROM workspace_members wm
+JOIN workspaces w ON w.id = wm.workspace_id
+JOIN projects p ON p.workspace_id = w.id
+LEFT JOIN loop_settings ls ON ls.project_id = p.id
+WHERE wm.workspace_id = $1 AND wm.user_id = $2
+ON CONFLICT (user_id, project_id) DO NOTHING
 `
 
-type CreateNotificationParams struct {
-	ID             int64
-	UserID         pgtype.UUID
-	Type           string
-	MessageID      pgtype.Int8
-	ProjectID      pgtype.UUID
-	ChannelID      pgtype.UUID
-	ActorID        pgtype.UUID
-	ActorUsername  string
-	ContentPreview pgtype.Text
+type AddWorkspaceMemberToLoopsParams struct {
+	WorkspaceID pgtype.UUID
+	UserID      pgtype.UUID
 }
 
-// ============================================================================
-// NOTIFICATIONS
-// ============================================================================
-func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
-	_, err := q.db.Exec(ctx, createNotification,
-		arg.ID,
-		arg.UserID,
-		arg.Type,
-		arg.MessageID,
-		arg.ProjectID,
-		arg.ChannelID,
-		arg.ActorID,
-		arg.ActorUsername,
-		arg.ContentPreview,
-	)
+// Makes a workspace member a member of each of its loops they aren't in yet
+func (q *Queries) AddWorkspaceMemberToLoops(ctx context.Context, arg AddWorkspaceMemberToLoopsParams) error {
+	_, err := q.db.Exec(ctx, addWorkspaceMemberToLoops, arg.WorkspaceID, arg.UserID)
 	return err
 }
 
-const createProject = `-- name: CreateProject :one
-INSERT INTO projects (github_repo_id, name, owner_id)
-VALUES ($1, $2, $3)
-RETURNING id, github_repo_id, name, owner_id, created_at
+const addWorkspaceMembersToLoop = `-- name: AddWorkspaceMembersToLoop :exec
+INSERT INTO memberships (user_id, project_id, role, notify_level)
+SELECT wm.user_id, p.id, CASE WHEN wm.role IN ('owner', 'admin') THEN 'moderator' ELSE w.default_role END,
+    COALESCE(ls.default_notify_level, 'mentions')
+FROM projects p
+JOIN workspaces w ON w.id = p.workspace_id
+JOIN workspace_members wm ON wm.workspace_id = w.id
+LEFT JOIN loop_settings ls ON ls.project_id = p.id
+WHERE p.id = $1
+ON CONFLICT (user_id, project_id) DO NOTHING
 `
 
-type CreateProjectParams struct {
-	GithubRepoID int64
-	Name         string
-	OwnerID      pgtype.UUID
-}
-
-func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error) {
-	row := q.db.QueryRow(ctx, createProject, arg.GithubRepoID, arg.Name, arg.OwnerID)
-	var i Project
-	err := row.Scan(
-		&i.ID,
-		&i.GithubRepoID,
-		&i.Name,
-		&i.OwnerID,
-		&i.CreatedAt,
-	)
-	return i, err
+// Makes every member of the loop's workspace a member of the loop
+func (q *Queries) AddWorkspaceMembersToLoop(ctx context.Context, projectID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, addWorkspaceMembersToLoop, projectID)
+	return err
 }
 
-const createRule = `-- name: CreateRule :one
-INSERT INTO rules (project_id, criteria_type, threshold)
-VALUES ($1, $2, $3)
-RETURNING id, project_id, criteria_type, threshold, created_at
+const archiveChannelGithubLinks = `-- name: ArchiveChannelGithubLinks :many
+UPDATE channel_github_links SET archived_at = NOW()
+WHERE project_id = $1 AND github_number = $2 AND archived_at IS NULL
+RETURNING channel_id, project_id, github_number, kind, cross_post, linked_by, archived_at, created_at
 `
 
-type CreateRuleParams struct {
+type ArchiveChannelGithubLinksParams struct {
 	ProjectID    pgtype.UUID
-	CriteriaType string
-	Threshold    string
+	GithubNumber int32
 }
 
-func (q *Queries) CreateRule(ctx context.Context, arg CreateRuleParams) (Rule, error) {
-	row := q.db.QueryRow(ctx, createRule, arg.ProjectID, arg.CriteriaType, arg.Threshold)
-	var i Rule
-	err := row.Scan(
-		&i.ID,
-		&i.ProjectID,
-		&i.CriteriaType,
-		&i.Threshold,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) ArchiveChannelGithubLinks(ctx context.Context, arg ArchiveChannelGithubLinksParams) ([]ChannelGithubLink, error) {
+	rows, err := q.db.Query(ctx, archiveChannelGithubLinks, arg.ProjectID, arg.GithubNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChannelGithubLink
+	for rows.Next() {
+		var i ChannelGithubLink
+		if err := rows.Scan(
+			&i.ChannelID,
+			&i.ProjectID,
+			&i.GithubNumber,
+			&i.Kind,
+			&i.CrossPost,
+			&i.LinkedBy,
+			&i.ArchivedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const decrementReplyCount = `-- name: DecrementReplyCount :exec
-UPDATE messages SET reply_count = GREATEST(0, reply_count - 1) WHERE id = $1
+const archiveMirroredLoop = `-- name: ArchiveMirroredLoop :one
+UPDATE loop_mirrors SET archived_at = NOW(), archived_by = $2
+WHERE project_id = $1 AND archived_at IS NULL
+RETURNING project_id, reason, since, archived_at, archived_by
 `
 
-func (q *Queries) DecrementReplyCount(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, decrementReplyCount, id)
-	return err
+type ArchiveMirroredLoopParams struct {
+	ProjectID  pgtype.UUID
+	ArchivedBy pgtype.UUID
 }
 
-const deleteChannel = `-- name: DeleteChannel :exec
-DELETE FROM channels WHERE id = $1
-`
-
-func (q *Queries) DeleteChannel(ctx context.Context, id pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, deleteChannel, id)
-	return err
+func (q *Queries) ArchiveMirroredLoop(ctx context.Context, arg ArchiveMirroredLoopParams) (LoopMirror, error) {
+	row := q.db.QueryRow(ctx, archiveMirroredLoop, arg.ProjectID, arg.ArchivedBy)
+	var i LoopMirror
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Reason,
+		&i.Since,
+		&i.ArchivedAt,
+		&i.ArchivedBy,
+	)
+	return i, err
 }
 
-const getAllLoops = `-- name: GetAllLoops :many
-SELECT 
-    p.id,
-    p.name,
-    p.github_repo_id,
-    p.created_at,
-    u.username AS owner_username,
-    u.avatar_url AS owner_avatar,
-    (SELECT COUNT(*) FROM memberships m WHERE m.project_id = p.id) AS member_count
-FROM projects p
-JOIN users u ON p.owner_id = u.id
-ORDER BY p.created_at DESC
-LIMIT $1 OFFSET $2
+const autocompleteChannels = `-- name: AutocompleteChannels :many
+SELECT c.id, c.name, c.description
+FROM channels c
+LEFT JOIN LATERAL (
+    SELECT MAX(m.created_at) AS at
+    FROM messages m
+    WHERE m.channel_id = c.id AND m.sender_id = $1
+) posted ON TRUE
+WHERE c.project_id = $2
+  AND c.name ILIKE $3 || '%'
+  AND (NOT $4::bool OR c.id = ANY($5::uuid[]))
+ORDER BY posted.at DESC NULLS LAST, c.position, c.name
+LIMIT $6
 `
 
-type GetAllLoopsParams struct {
-	Limit  int32
-	Offset int32
+type AutocompleteChannelsParams struct {
+	ViewerID   pgtype.UUID
+	ProjectID  pgtype.UUID
+	Prefix     pgtype.Text
+	Restricted bool
+	AllowedIds []pgtype.UUID
+	RowLimit   int32
 }
 
-type GetAllLoopsRow struct {
-	ID            pgtype.UUID
-	Name          string
-	GithubRepoID  int64
-	CreatedAt     pgtype.Timestamptz
-	OwnerUsername string
-	OwnerAvatar   pgtype.Text
-	MemberCount   int64
+type AutocompleteChannelsRow struct {
+	ID          pgtype.UUID
+	Name        string
+	Description pgtype.Text
 }
 
-func (q *Queries) GetAllLoops(ctx context.Context, arg GetAllLoopsParams) ([]GetAllLoopsRow, error) {
-	rows, err := q.db.Query(ctx, getAllLoops, arg.Limit, arg.Offset)
+// Channels whose name starts with prefix, those the viewer posted in most
+// recently first. With restricted, only allowed_ids (a guest's channels).
+func (q *Queries) AutocompleteChannels(ctx context.Context, arg AutocompleteChannelsParams) ([]AutocompleteChannelsRow, error) {
+	rows, err := q.db.Query(ctx, autocompleteChannels,
+		arg.ViewerID,
+		arg.ProjectID,
+		arg.Prefix,
+		arg.Restricted,
+		arg.AllowedIds,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetAllLoopsRow
+	var items []AutocompleteChannelsRow
 	for rows.Next() {
-		var i GetAllLoopsRow
+		var i AutocompleteChannelsRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.Name,
-			&i.GithubRepoID,
-			&i.CreatedAt,
-			&i.OwnerUsername,
-			&i.OwnerAvatar,
-			&i.MemberCount,
+			&i.Description,
 		); err != nil {
 			return nil, err
 		}
@@ -281,105 +410,255 @@ func (q *Queries) GetAllLoops(ctx context.Context, arg GetAllLoopsParams) ([]Get
 	return items, nil
 }
 
-const getChannelByID = `-- name: GetChannelByID :one
-SELECT id, project_id, name, description, is_default, position, created_at, updated_at FROM channels WHERE id = $1 LIMIT 1
+const autocompleteMembers = `-- name: AutocompleteMembers :many
+
+SELECT u.id, u.username, u.avatar_url, u.display_name
+FROM memberships mem
+JOIN users u ON u.id = mem.user_id
+LEFT JOIN LATERAL (
+    SELECT MAX(n.created_at) AS at
+    FROM notifications n
+    WHERE n.user_id = u.id AND n.actor_id = $1 AND n.project_id = mem.project_id
+) mentioned ON TRUE
+WHERE mem.project_id = $2
+  AND (u.username ILIKE $3 || '%' OR u.display_name ILIKE $3 || '%')
+ORDER BY mentioned.at DESC NULLS LAST, COALESCE(mem.last_active_at, mem.joined_at) DESC, u.username
+LIMIT $4
 `
 
-func (q *Queries) GetChannelByID(ctx context.Context, id pgtype.UUID) (Channel, error) {
-	row := q.db.QueryRow(ctx, getChannelByID, id)
-	var i Channel
-	err := row.Scan(
-		&i.ID,
-		&i.ProjectID,
-		&i.Name,
-		&i.Description,
-		&i.IsDefault,
-		&i.Position,
-		&i.CreatedAt,
-		&i.UpdatedAt,
+type AutocompleteMembersParams struct {
+	ViewerID  pgtype.UUID
+	ProjectID pgtype.UUID
+	Prefix    pgtype.Text
+	RowLimit  int32
+}
+
+type AutocompleteMembersRow struct {
+	ID          pgtype.UUID
+	Username    string
+	AvatarUrl   pgtype.Text
+	DisplayName pgtype.Text
+}
+
+// AUTOCOMPLETE
+// Members whose username or display name starts with prefix: those the
+// viewer last notified (mentions, replies) first, then the recently active
+func (q *Queries) AutocompleteMembers(ctx context.Context, arg AutocompleteMembersParams) ([]AutocompleteMembersRow, error) {
+	rows, err := q.db.Query(ctx, autocompleteMembers,
+		arg.ViewerID,
+		arg.ProjectID,
+		arg.Prefix,
+		arg.RowLimit,
 	)
-	return i, err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AutocompleteMembersRow
+	for rows.Next() {
+		var i AutocompleteMembersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getChannelByProjectAndName = `-- name: GetChannelByProjectAndName :one
-SELECT id, project_id, name, description, is_default, position, created_at, updated_at FROM channels 
-WHERE project_id = $1 AND name = $2 
-LIMIT 1
+const awardMemberBadge = `-- name: AwardMemberBadge :execrows
+INSERT INTO member_badges (project_id, user_id, badge)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, user_id, badge) DO NOTHING
 `
 
-type GetChannelByProjectAndNameParams struct {
+type AwardMemberBadgeParams struct {
 	ProjectID pgtype.UUID
-	Name      string
+	UserID    pgtype.UUID
+	Badge     string
 }
 
-func (q *Queries) GetChannelByProjectAndName(ctx context.Context, arg GetChannelByProjectAndNameParams) (Channel, error) {
-	row := q.db.QueryRow(ctx, getChannelByProjectAndName, arg.ProjectID, arg.Name)
-	var i Channel
+// 0 rows means the badge was already held
+func (q *Queries) AwardMemberBadge(ctx context.Context, arg AwardMemberBadgeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, awardMemberBadge, arg.ProjectID, arg.UserID, arg.Badge)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const banFromLoop = `-- name: BanFromLoop :exec
+INSERT INTO loop_bans (project_id, user_id, banned_by, reason)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (project_id, user_id) DO NOTHING
+`
+
+type BanFromLoopParams struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+	BannedBy  pgtype.UUID
+	Reason    pgtype.Text
+}
+
+func (q *Queries) BanFromLoop(ctx context.Context, arg BanFromLoopParams) error {
+	_, err := q.db.Exec(ctx, banFromLoop,
+		arg.ProjectID,
+		arg.UserID,
+		arg.BannedBy,
+		arg.Reason,
+	)
+	return err
+}
+
+const blockUser = `-- name: BlockUser :exec
+
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type BlockUserParams struct {
+	BlockerID pgtype.UUID
+	BlockedID pgtype.UUID
+}
+
+// ============================================================================
+// USER BLOCKS
+// ============================================================================
+func (q *Queries) BlockUser(ctx context.Context, arg BlockUserParams) error {
+	_, err := q.db.Exec(ctx, blockUser, arg.BlockerID, arg.BlockedID)
+	return err
+}
+
+const bumpNotificationBatch = `-- name: BumpNotificationBatch :exec
+UPDATE notifications
+SET batch_count = batch_count + 1, message_id = $2, content_preview = $3
+WHERE id = $1
+`
+
+type BumpNotificationBatchParams struct {
+	ID             int64
+	MessageID      pgtype.Int8
+	ContentPreview pgtype.Text
+}
+
+func (q *Queries) BumpNotificationBatch(ctx context.Context, arg BumpNotificationBatchParams) error {
+	_, err := q.db.Exec(ctx, bumpNotificationBatch, arg.ID, arg.MessageID, arg.ContentPreview)
+	return err
+}
+
+const changeUsername = `-- name: ChangeUsername :one
+
+UPDATE users
+SET username = $2, username_changed_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at
+`
+
+type ChangeUsernameParams struct {
+	ID       pgtype.UUID
+	Username string
+}
+
+// ============================================================================
+// USERNAME CHANGES
+// ============================================================================
+func (q *Queries) ChangeUsername(ctx context.Context, arg ChangeUsernameParams) (User, error) {
+	row := q.db.QueryRow(ctx, changeUsername, arg.ID, arg.Username)
+	var i User
 	err := row.Scan(
 		&i.ID,
-		&i.ProjectID,
-		&i.Name,
-		&i.Description,
-		&i.IsDefault,
-		&i.Position,
+		&i.GithubID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.DisplayName,
+		&i.AccessToken,
+		&i.ProfileCompleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
 	)
 	return i, err
 }
 
-const getChannelCount = `-- name: GetChannelCount :one
-SELECT COUNT(*) FROM channels WHERE project_id = $1
+const claimDueJobs = `-- name: ClaimDueJobs :many
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM jobs
+    WHERE status = 'queued' AND run_at <= NOW()
+    ORDER BY run_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, type, payload, run_at, status, attempts, last_error, created_at, updated_at
 `
 
-func (q *Queries) GetChannelCount(ctx context.Context, projectID pgtype.UUID) (int64, error) {
-	row := q.db.QueryRow(ctx, getChannelCount, projectID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+// SKIP LOCKED lets several server instances poll without double-running jobs
+func (q *Queries) ClaimDueJobs(ctx context.Context, limit int32) ([]Job, error) {
+	rows, err := q.db.Query(ctx, claimDueJobs, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Payload,
+			&i.RunAt,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-const getChannelsByProject = `-- name: GetChannelsByProject :many
-SELECT 
-    id,
-    project_id,
-    name,
-    description,
-    is_default,
-    position,
-    created_at
-FROM channels
-WHERE project_id = $1
-ORDER BY position ASC, created_at ASC
+const claimDueLoopDigests = `-- name: ClaimDueLoopDigests :many
+UPDATE loop_settings SET digest_posted_at = NOW()
+WHERE digest_channel_id IS NOT NULL
+  AND (digest_posted_at IS NULL OR digest_posted_at < $1)
+RETURNING project_id, digest_channel_id
 `
 
-type GetChannelsByProjectRow struct {
-	ID          pgtype.UUID
-	ProjectID   pgtype.UUID
-	Name        string
-	Description pgtype.Text
-	IsDefault   pgtype.Bool
-	Position    pgtype.Int4
-	CreatedAt   pgtype.Timestamptz
+type ClaimDueLoopDigestsRow struct {
+	ProjectID       pgtype.UUID
+	DigestChannelID pgtype.UUID
 }
 
-func (q *Queries) GetChannelsByProject(ctx context.Context, projectID pgtype.UUID) ([]GetChannelsByProjectRow, error) {
-	rows, err := q.db.Query(ctx, getChannelsByProject, projectID)
+// Marks every loop whose digest hasn't been posted since week_start as
+// posted and returns them, so concurrent instances each get different loops
+func (q *Queries) ClaimDueLoopDigests(ctx context.Context, weekStart pgtype.Timestamptz) ([]ClaimDueLoopDigestsRow, error) {
+	rows, err := q.db.Query(ctx, claimDueLoopDigests, weekStart)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetChannelsByProjectRow
+	var items []ClaimDueLoopDigestsRow
 	for rows.Next() {
-		var i GetChannelsByProjectRow
+		var i ClaimDueLoopDigestsRow
 		if err := rows.Scan(
-			&i.ID,
 			&i.ProjectID,
-			&i.Name,
-			&i.Description,
-			&i.IsDefault,
-			&i.Position,
-			&i.CreatedAt,
+			&i.DigestChannelID,
 		); err != nil {
 			return nil, err
 		}
@@ -391,67 +670,71 @@ func (q *Queries) GetChannelsByProject(ctx context.Context, projectID pgtype.UUI
 	return items, nil
 }
 
-const getDefaultChannel = `-- name: GetDefaultChannel :one
-SELECT id, project_id, name, description, is_default, position, created_at, updated_at FROM channels 
-WHERE project_id = $1 AND is_default = TRUE 
-LIMIT 1
+const claimPRCommentRefresh = `-- name: ClaimPRCommentRefresh :execrows
+INSERT INTO pr_comment_syncs (repo_id, pr_number) VALUES ($1, $2)
+ON CONFLICT (repo_id, pr_number) DO UPDATE SET claimed_at = NOW()
+WHERE pr_comment_syncs.claimed_at < $3
 `
 
-func (q *Queries) GetDefaultChannel(ctx context.Context, projectID pgtype.UUID) (Channel, error) {
-	row := q.db.QueryRow(ctx, getDefaultChannel, projectID)
-	var i Channel
-	err := row.Scan(
-		&i.ID,
-		&i.ProjectID,
-		&i.Name,
-		&i.Description,
-		&i.IsDefault,
-		&i.Position,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+type ClaimPRCommentRefreshParams struct {
+	RepoID        int64
+	PrNumber      int32
+	ClaimedBefore pgtype.Timestamptz
 }
 
-const getLoopMembers = `-- name: GetLoopMembers :many
-SELECT 
-    u.id,
-    u.username,
-    u.avatar_url,
-    u.display_name,
-    mem.role,
-    mem.joined_at
-FROM memberships mem
-JOIN users u ON mem.user_id = u.id
-WHERE mem.project_id = $1
-ORDER BY mem.joined_at ASC
+// Returns 1 when the caller won the right to refresh this PR
+func (q *Queries) ClaimPRCommentRefresh(ctx context.Context, arg ClaimPRCommentRefreshParams) (int64, error) {
+	result, err := q.db.Exec(ctx, claimPRCommentRefresh, arg.RepoID, arg.PrNumber, arg.ClaimedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const claimStaleGitHubProfiles = `-- name: ClaimStaleGitHubProfiles :many
+
+UPDATE users
+SET github_synced_at = NOW()
+WHERE id IN (
+    SELECT id FROM users
+    WHERE github_id > 0 AND (github_synced_at IS NULL OR github_synced_at < $1)
+    ORDER BY github_synced_at NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at
 `
 
-type GetLoopMembersRow struct {
-	ID          pgtype.UUID
-	Username    string
-	AvatarUrl   pgtype.Text
-	DisplayName pgtype.Text
-	Role        pgtype.Text
-	JoinedAt    pgtype.Timestamptz
+type ClaimStaleGitHubProfilesParams struct {
+	GithubSyncedAt pgtype.Timestamptz
+	Limit          int32
 }
 
-func (q *Queries) GetLoopMembers(ctx context.Context, projectID pgtype.UUID) ([]GetLoopMembersRow, error) {
-	rows, err := q.db.Query(ctx, getLoopMembers, projectID)
+// ============================================================================
+// GITHUB PROFILE SYNC
+// ============================================================================
+// Stamps the rows up front so concurrent refreshers pick different users
+func (q *Queries) ClaimStaleGitHubProfiles(ctx context.Context, arg ClaimStaleGitHubProfilesParams) ([]User, error) {
+	rows, err := q.db.Query(ctx, claimStaleGitHubProfiles, arg.GithubSyncedAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetLoopMembersRow
+	var items []User
 	for rows.Next() {
-		var i GetLoopMembersRow
+		var i User
 		if err := rows.Scan(
 			&i.ID,
+			&i.GithubID,
 			&i.Username,
 			&i.AvatarUrl,
 			&i.DisplayName,
-			&i.Role,
-			&i.JoinedAt,
+			&i.AccessToken,
+			&i.ProfileCompleted,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.GithubSyncedAt,
+			&i.UsernameChangedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -463,88 +746,45 @@ func (q *Queries) GetLoopMembers(ctx context.Context, projectID pgtype.UUID) ([]
 	return items, nil
 }
 
-const getMessageByID = `-- name: GetMessageByID :one
-SELECT id, project_id, channel_id, sender_id, content, parent_id, reply_count, is_deleted, deleted_at, created_at, is_pinned, pinned_by, pinned_at FROM messages WHERE id = $1 LIMIT 1
+const claimStaleRepoStats = `-- name: ClaimStaleRepoStats :many
+UPDATE repo_stats
+SET checked_at = NOW()
+WHERE repo_id IN (
+    SELECT s.repo_id FROM repo_stats s
+    WHERE (s.checked_at IS NULL OR s.checked_at < $1)
+      AND EXISTS (SELECT 1 FROM projects p WHERE p.github_repo_id = s.repo_id)
+    ORDER BY s.checked_at NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING repo_id, full_name, stars, forks, contributors, pushed_at, refreshed_at, checked_at
 `
 
-func (q *Queries) GetMessageByID(ctx context.Context, id int64) (Message, error) {
-	row := q.db.QueryRow(ctx, getMessageByID, id)
-	var i Message
-	err := row.Scan(
-		&i.ID,
-		&i.ProjectID,
-		&i.ChannelID,
-		&i.SenderID,
-		&i.Content,
-		&i.ParentID,
-		&i.ReplyCount,
-		&i.IsDeleted,
-		&i.DeletedAt,
-		&i.CreatedAt,
-		&i.IsPinned,
-		&i.PinnedBy,
-		&i.PinnedAt,
-	)
-	return i, err
+type ClaimStaleRepoStatsParams struct {
+	CheckedAt pgtype.Timestamptz
+	Limit     int32
 }
 
-const getMessages = `-- name: GetMessages :many
-SELECT 
-    m.id,
-    m.content,
-    m.created_at,
-    m.sender_id,
-    m.channel_id,
-    m.parent_id,
-    m.reply_count,
-    u.username AS sender_username,
-    u.avatar_url AS sender_avatar
-FROM messages m
-JOIN users u ON m.sender_id = u.id
-WHERE m.channel_id = $1 
-  AND m.parent_id IS NULL 
-  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
-ORDER BY m.created_at DESC
-LIMIT $2 OFFSET $3
-`
-
-type GetMessagesParams struct {
-	ChannelID pgtype.UUID
-	Limit     int32
-	Offset    int32
-}
-
-type GetMessagesRow struct {
-	ID             int64
-	Content        string
-	CreatedAt      pgtype.Timestamptz
-	SenderID       pgtype.UUID
-	ChannelID      pgtype.UUID
-	ParentID       pgtype.Int8
-	ReplyCount     pgtype.Int4
-	SenderUsername string
-	SenderAvatar   pgtype.Text
-}
-
-func (q *Queries) GetMessages(ctx context.Context, arg GetMessagesParams) ([]GetMessagesRow, error) {
-	rows, err := q.db.Query(ctx, getMessages, arg.ChannelID, arg.Limit, arg.Offset)
+// Stamps the rows up front so concurrent refreshers pick different repos;
+// repos no loop links to any more are left alone
+func (q *Queries) ClaimStaleRepoStats(ctx context.Context, arg ClaimStaleRepoStatsParams) ([]RepoStat, error) {
+	rows, err := q.db.Query(ctx, claimStaleRepoStats, arg.CheckedAt, arg.Limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetMessagesRow
+	var items []RepoStat
 	for rows.Next() {
-		var i GetMessagesRow
+		var i RepoStat
 		if err := rows.Scan(
-			&i.ID,
-			&i.Content,
-			&i.CreatedAt,
-			&i.SenderID,
-			&i.ChannelID,
-			&i.ParentID,
-			&i.ReplyCount,
-			&i.SenderUsername,
-			&i.SenderAvatar,
+			&i.RepoID,
+			&i.FullName,
+			&i.Stars,
+			&i.Forks,
+			&i.Contributors,
+			&i.PushedAt,
+			&i.RefreshedAt,
+			&i.CheckedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -556,67 +796,36 @@ func (q *Queries) GetMessages(ctx context.Context, arg GetMessagesParams) ([]Get
 	return items, nil
 }
 
-const getMessagesByProject = `-- name: GetMessagesByProject :many
-SELECT 
-    m.id,
-    m.content,
-    m.created_at,
-    m.sender_id,
-    m.channel_id,
-    m.parent_id,
-    m.reply_count,
-    u.username AS sender_username,
-    u.avatar_url AS sender_avatar
-FROM messages m
-JOIN users u ON m.sender_id = u.id
-WHERE m.project_id = $1 
-  AND m.parent_id IS NULL
-  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
-ORDER BY m.created_at DESC
-LIMIT $2 OFFSET $3
+const clearMutedWords = `-- name: ClearMutedWords :exec
+DELETE FROM user_muted_words WHERE user_id = $1
 `
 
-type GetMessagesByProjectParams struct {
-	ProjectID pgtype.UUID
-	Limit     int32
-	Offset    int32
+func (q *Queries) ClearMutedWords(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, clearMutedWords, userID)
+	return err
 }
 
-type GetMessagesByProjectRow struct {
-	ID             int64
-	Content        string
-	CreatedAt      pgtype.Timestamptz
-	SenderID       pgtype.UUID
-	ChannelID      pgtype.UUID
-	ParentID       pgtype.Int8
-	ReplyCount     pgtype.Int4
-	SenderUsername string
-	SenderAvatar   pgtype.Text
-}
+const clearRepoMirrors = `-- name: ClearRepoMirrors :many
+DELETE FROM loop_mirrors m
+USING projects p
+WHERE p.id = m.project_id AND p.github_repo_id = $1 AND m.archived_at IS NULL
+RETURNING m.project_id
+`
 
-func (q *Queries) GetMessagesByProject(ctx context.Context, arg GetMessagesByProjectParams) ([]GetMessagesByProjectRow, error) {
-	rows, err := q.db.Query(ctx, getMessagesByProject, arg.ProjectID, arg.Limit, arg.Offset)
+// Loops the owner archived stay as they are until relinked
+func (q *Queries) ClearRepoMirrors(ctx context.Context, githubRepoID int64) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, clearRepoMirrors, githubRepoID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetMessagesByProjectRow
+	var items []pgtype.UUID
 	for rows.Next() {
-		var i GetMessagesByProjectRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Content,
-			&i.CreatedAt,
-			&i.SenderID,
-			&i.ChannelID,
-			&i.ParentID,
-			&i.ReplyCount,
-			&i.SenderUsername,
-			&i.SenderAvatar,
-		); err != nil {
+		var project_id pgtype.UUID
+		if err := rows.Scan(&project_id); err != nil {
 			return nil, err
 		}
-		items = append(items, i)
+		items = append(items, project_id)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -624,397 +833,458 @@ func (q *Queries) GetMessagesByProject(ctx context.Context, arg GetMessagesByPro
 	return items, nil
 }
 
-const getNotifications = `-- name: GetNotifications :many
-SELECT id, user_id, type, message_id, project_id, channel_id, actor_id, actor_username, content_preview, is_read, created_at FROM notifications
-WHERE user_id = $1
-ORDER BY created_at DESC
-LIMIT $2 OFFSET $3
+const completeJob = `-- name: CompleteJob :exec
+UPDATE jobs SET status = 'done', updated_at = NOW() WHERE id = $1
 `
 
-type GetNotificationsParams struct {
+func (q *Queries) CompleteJob(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, completeJob, id)
+	return err
+}
+
+const completeOnboardingStep = `-- name: CompleteOnboardingStep :execrows
+INSERT INTO onboarding_progress (step_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (step_id, user_id) DO NOTHING
+`
+
+type CompleteOnboardingStepParams struct {
+	StepID pgtype.UUID
 	UserID pgtype.UUID
-	Limit  int32
-	Offset int32
 }
 
-func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]Notification, error) {
-	rows, err := q.db.Query(ctx, getNotifications, arg.UserID, arg.Limit, arg.Offset)
+func (q *Queries) CompleteOnboardingStep(ctx context.Context, arg CompleteOnboardingStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeOnboardingStep, arg.StepID, arg.UserID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Notification
-	for rows.Next() {
-		var i Notification
-		if err := rows.Scan(
-			&i.ID,
-			&i.UserID,
-			&i.Type,
-			&i.MessageID,
-			&i.ProjectID,
-			&i.ChannelID,
-			&i.ActorID,
-			&i.ActorUsername,
-			&i.ContentPreview,
-			&i.IsRead,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getPinnedMessages = `-- name: GetPinnedMessages :many
-SELECT 
-    m.id,
-    m.content,
-    m.created_at,
-    m.sender_id,
-    m.channel_id,
-    m.parent_id,
-    m.reply_count,
-    m.pinned_at,
-    u.username AS sender_username,
-    u.avatar_url AS sender_avatar,
-    pinner.username AS pinned_by_username
-FROM messages m
-JOIN users u ON m.sender_id = u.id
-LEFT JOIN users pinner ON m.pinned_by = pinner.id
-WHERE m.channel_id = $1 
-  AND m.is_pinned = TRUE
-  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
-ORDER BY m.pinned_at DESC
+const completeOnboardingStepsByKind = `-- name: CompleteOnboardingStepsByKind :execrows
+INSERT INTO onboarding_progress (step_id, user_id)
+SELECT s.id, $1::uuid FROM onboarding_steps s
+WHERE s.project_id = $2 AND s.kind = $3
+  AND ($4::uuid IS NULL OR s.channel_id = $4)
+ON CONFLICT (step_id, user_id) DO NOTHING
 `
 
-type GetPinnedMessagesRow struct {
-	ID               int64
-	Content          string
-	CreatedAt        pgtype.Timestamptz
-	SenderID         pgtype.UUID
-	ChannelID        pgtype.UUID
-	ParentID         pgtype.Int8
-	ReplyCount       pgtype.Int4
-	PinnedAt         pgtype.Timestamptz
-	SenderUsername   string
-	SenderAvatar     pgtype.Text
-	PinnedByUsername pgtype.Text
+type CompleteOnboardingStepsByKindParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+	Kind      string
+	ChannelID pgtype.UUID
 }
 
-func (q *Queries) GetPinnedMessages(ctx context.Context, channelID pgtype.UUID) ([]GetPinnedMessagesRow, error) {
-	rows, err := q.db.Query(ctx, getPinnedMessages, channelID)
+// Ticks off automatic steps; channel_id narrows post_in_channel steps to the channel posted in
+func (q *Queries) CompleteOnboardingStepsByKind(ctx context.Context, arg CompleteOnboardingStepsByKindParams) (int64, error) {
+	result, err := q.db.Exec(ctx, completeOnboardingStepsByKind,
+		arg.UserID,
+		arg.ProjectID,
+		arg.Kind,
+		arg.ChannelID,
+	)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetPinnedMessagesRow
-	for rows.Next() {
-		var i GetPinnedMessagesRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Content,
-			&i.CreatedAt,
-			&i.SenderID,
-			&i.ChannelID,
-			&i.ParentID,
-			&i.ReplyCount,
-			&i.PinnedAt,
-			&i.SenderUsername,
-			&i.SenderAvatar,
-			&i.PinnedByUsername,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getProjectByID = `-- name: GetProjectByID :one
-SELECT id, github_repo_id, name, owner_id, created_at FROM projects WHERE id = $1 LIMIT 1
+const completeTask = `-- name: CompleteTask :one
+UPDATE tasks
+SET status = 'done', completed_at = NOW()
+WHERE id = $1
+RETURNING id, project_id, channel_id, message_id, title, assignee_id, due_at, status, github_issue_number, created_by, completed_at, created_at
 `
 
-func (q *Queries) GetProjectByID(ctx context.Context, id pgtype.UUID) (Project, error) {
-	row := q.db.QueryRow(ctx, getProjectByID, id)
-	var i Project
+func (q *Queries) CompleteTask(ctx context.Context, id pgtype.UUID) (Task, error) {
+	row := q.db.QueryRow(ctx, completeTask, id)
+	var i Task
 	err := row.Scan(
 		&i.ID,
-		&i.GithubRepoID,
-		&i.Name,
-		&i.OwnerID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.MessageID,
+		&i.Title,
+		&i.AssigneeID,
+		&i.DueAt,
+		&i.Status,
+		&i.GithubIssueNumber,
+		&i.CreatedBy,
+		&i.CompletedAt,
 		&i.CreatedAt,
 	)
 	return i, err
 }
 
-const getProjectByName = `-- name: GetProjectByName :one
-SELECT id, github_repo_id, name, owner_id, created_at FROM projects WHERE name = $1 LIMIT 1
+const completeWorkflowDispatch = `-- name: CompleteWorkflowDispatch :one
+UPDATE workflow_dispatches
+SET conclusion = $2, completed_at = NOW()
+WHERE run_id = $1 AND completed_at IS NULL
+RETURNING run_id, project_id, channel_id, user_id, workflow, ref, html_url, conclusion, created_at, completed_at
 `
 
-func (q *Queries) GetProjectByName(ctx context.Context, name string) (Project, error) {
-	row := q.db.QueryRow(ctx, getProjectByName, name)
-	var i Project
+type CompleteWorkflowDispatchParams struct {
+	RunID      int64
+	Conclusion pgtype.Text
+}
+
+// Only the first completion wins, so redelivered webhooks don't post twice
+func (q *Queries) CompleteWorkflowDispatch(ctx context.Context, arg CompleteWorkflowDispatchParams) (WorkflowDispatch, error) {
+	row := q.db.QueryRow(ctx, completeWorkflowDispatch, arg.RunID, arg.Conclusion)
+	var i WorkflowDispatch
 	err := row.Scan(
-		&i.ID,
-		&i.GithubRepoID,
-		&i.Name,
-		&i.OwnerID,
+		&i.RunID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.UserID,
+		&i.Workflow,
+		&i.Ref,
+		&i.HtmlUrl,
+		&i.Conclusion,
 		&i.CreatedAt,
+		&i.CompletedAt,
 	)
 	return i, err
 }
 
-const getProjectByOwnerAndName = `-- name: GetProjectByOwnerAndName :one
-SELECT id, github_repo_id, name, owner_id, created_at FROM projects
-WHERE owner_id = $1 AND name = $2
-LIMIT 1
+const countChannelPins = `-- name: CountChannelPins :one
+SELECT COUNT(*)::int FROM messages
+WHERE channel_id = $1 AND is_pinned = TRUE AND (is_deleted = FALSE OR is_deleted IS NULL)
 `
 
-type GetProjectByOwnerAndNameParams struct {
-	OwnerID pgtype.UUID
-	Name    string
+func (q *Queries) CountChannelPins(ctx context.Context, channelID pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, countChannelPins, channelID)
+	var count int32
+	err := row.Scan(&count)
+	return count, err
 }
 
-func (q *Queries) GetProjectByOwnerAndName(ctx context.Context, arg GetProjectByOwnerAndNameParams) (Project, error) {
-	row := q.db.QueryRow(ctx, getProjectByOwnerAndName, arg.OwnerID, arg.Name)
-	var i Project
-	err := row.Scan(
-		&i.ID,
-		&i.GithubRepoID,
-		&i.Name,
-		&i.OwnerID,
-		&i.CreatedAt,
-	)
-	return i, err
+const countDMParticipants = `-- name: CountDMParticipants :one
+SELECT COUNT(*) FROM dm_participants WHERE conversation_id = $1
+`
+
+func (q *Queries) CountDMParticipants(ctx context.Context, conversationID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countDMParticipants, conversationID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
-const getProjectsByOwner = `-- name: GetProjectsByOwner :many
-SELECT id, github_repo_id, name, owner_id, created_at
-FROM projects
-WHERE owner_id = $1
-ORDER BY created_at DESC
+const countIntegrationsUsingCredential = `-- name: CountIntegrationsUsingCredential :one
+SELECT COUNT(*) FROM loop_integrations WHERE project_id = $1 AND credentials_ref = $2
 `
 
-func (q *Queries) GetProjectsByOwner(ctx context.Context, ownerID pgtype.UUID) ([]Project, error) {
-	rows, err := q.db.Query(ctx, getProjectsByOwner, ownerID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Project
-	for rows.Next() {
-		var i Project
-		if err := rows.Scan(
-			&i.ID,
-			&i.GithubRepoID,
-			&i.Name,
-			&i.OwnerID,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CountIntegrationsUsingCredentialParams struct {
+	ProjectID      pgtype.UUID
+	CredentialsRef pgtype.Text
 }
 
-const getPublicProfile = `-- name: GetPublicProfile :one
-SELECT
-id,
-username,
-avatar_url,
-display_name,
-created_at
-FROM users WHERE username = $1 LIMIT 1
+func (q *Queries) CountIntegrationsUsingCredential(ctx context.Context, arg CountIntegrationsUsingCredentialParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countIntegrationsUsingCredential, arg.ProjectID, arg.CredentialsRef)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countLoopEmoji = `-- name: CountLoopEmoji :one
+SELECT COUNT(*)::int FROM loop_emoji WHERE project_id = $1
 `
 
-type GetPublicProfileRow struct {
-	ID          pgtype.UUID
-	Username    string
-	AvatarUrl   pgtype.Text
-	DisplayName pgtype.Text
-	CreatedAt   pgtype.Timestamptz
+func (q *Queries) CountLoopEmoji(ctx context.Context, projectID pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, countLoopEmoji, projectID)
+	var count int32
+	err := row.Scan(&count)
+	return count, err
 }
 
-func (q *Queries) GetPublicProfile(ctx context.Context, username string) (GetPublicProfileRow, error) {
-	row := q.db.QueryRow(ctx, getPublicProfile, username)
-	var i GetPublicProfileRow
+const countLoopsBilledTo = `-- name: CountLoopsBilledTo :one
+SELECT COUNT(*) FROM projects p
+LEFT JOIN workspaces w ON w.id = p.workspace_id
+WHERE COALESCE(w.billing_owner_id, p.owner_id) = $1
+`
+
+func (q *Queries) CountLoopsBilledTo(ctx context.Context, ownerID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countLoopsBilledTo, ownerID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const countOtherReactionEmoji = `-- name: CountOtherReactionEmoji :one
+SELECT COUNT(DISTINCT emoji)::int FROM message_reactions
+WHERE message_id = $1 AND emoji <> $2
+`
+
+type CountOtherReactionEmojiParams struct {
+	MessageID int64
+	Emoji     string
+}
+
+// Distinct emoji on the message besides the given one
+func (q *Queries) CountOtherReactionEmoji(ctx context.Context, arg CountOtherReactionEmojiParams) (int32, error) {
+	row := q.db.QueryRow(ctx, countOtherReactionEmoji, arg.MessageID, arg.Emoji)
+	var count int32
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAPIKey = `-- name: CreateAPIKey :one
+
+INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, rate_limit)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, name, prefix, key_hash, scopes, rate_limit, last_used_at, created_at, revoked_at
+`
+
+type CreateAPIKeyParams struct {
+	UserID    pgtype.UUID
+	Name      string
+	Prefix    string
+	KeyHash   string
+	Scopes    []string
+	RateLimit int32
+}
+
+// ============================================================================
+// API KEYS
+// ============================================================================
+func (q *Queries) CreateAPIKey(ctx context.Context, arg CreateAPIKeyParams) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, createAPIKey,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.KeyHash,
+		arg.Scopes,
+		arg.RateLimit,
+	)
+	var i ApiKey
 	err := row.Scan(
 		&i.ID,
-		&i.Username,
-		&i.AvatarUrl,
-		&i.DisplayName,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.RateLimit,
+		&i.LastUsedAt,
 		&i.CreatedAt,
+		&i.RevokedAt,
 	)
 	return i, err
 }
 
-const getRulesByProject = `-- name: GetRulesByProject :many
-SELECT id, project_id, criteria_type, threshold, created_at FROM rules
-WHERE project_id = $1
-ORDER BY created_at ASC
+const createAccountMerge = `-- name: CreateAccountMerge :one
+INSERT INTO account_merges (survivor_id, merged_id, merged_username, admin, reason, memberships, messages, notifications, loops)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, survivor_id, merged_id, merged_username, admin, reason, memberships, messages, notifications, loops, created_at
 `
 
-func (q *Queries) GetRulesByProject(ctx context.Context, projectID pgtype.UUID) ([]Rule, error) {
-	rows, err := q.db.Query(ctx, getRulesByProject, projectID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []Rule
-	for rows.Next() {
-		var i Rule
-		if err := rows.Scan(
-			&i.ID,
-			&i.ProjectID,
-			&i.CriteriaType,
-			&i.Threshold,
-			&i.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+type CreateAccountMergeParams struct {
+	SurvivorID     pgtype.UUID
+	MergedID       pgtype.UUID
+	MergedUsername string
+	Admin          string
+	Reason         string
+	Memberships    int32
+	Messages       int32
+	Notifications  int32
+	Loops          int32
 }
 
-const getThreadReplies = `-- name: GetThreadReplies :many
-SELECT 
-    m.id,
-    m.content,
-    m.created_at,
-    m.sender_id,
-    m.channel_id,
-    m.parent_id,
-    u.username AS sender_username,
-    u.avatar_url AS sender_avatar
-FROM messages m
-JOIN users u ON m.sender_id = u.id
-WHERE m.parent_id = $1 
-  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
-ORDER BY m.created_at ASC
-LIMIT $2 OFFSET $3
+func (q *Queries) CreateAccountMerge(ctx context.Context, arg CreateAccountMergeParams) (AccountMerge, error) {
+	row := q.db.QueryRow(ctx, createAccountMerge,
+		arg.SurvivorID,
+		arg.MergedID,
+		arg.MergedUsername,
+		arg.Admin,
+		arg.Reason,
+		arg.Memberships,
+		arg.Messages,
+		arg.Notifications,
+		arg.Loops,
+	)
+	var i AccountMerge
+	err := row.Scan(
+		&i.ID,
+		&i.SurvivorID,
+		&i.MergedID,
+		&i.MergedUsername,
+		&i.Admin,
+		&i.Reason,
+		&i.Memberships,
+		&i.Messages,
+		&i.Notifications,
+		&i.Loops,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createActivity = `-- name: CreateActivity :exec
+INSERT INTO loop_activity (project_id, actor_id, kind, ref_id, summary)
+VALUES ($1, $2, $3, $4, $5)
 `
 
-type GetThreadRepliesParams struct {
-	ParentID pgtype.Int8
-	Limit    int32
-	Offset   int32
+type CreateActivityParams struct {
+	ProjectID pgtype.UUID
+	ActorID   pgtype.UUID
+	Kind      string
+	RefID     pgtype.Text
+	Summary   string
 }
 
-type GetThreadRepliesRow struct {
-	ID             int64
-	Content        string
-	CreatedAt      pgtype.Timestamptz
-	SenderID       pgtype.UUID
-	ChannelID      pgtype.UUID
-	ParentID       pgtype.Int8
-	SenderUsername string
-	SenderAvatar   pgtype.Text
+func (q *Queries) CreateActivity(ctx context.Context, arg CreateActivityParams) error {
+	_, err := q.db.Exec(ctx, createActivity,
+		arg.ProjectID,
+		arg.ActorID,
+		arg.Kind,
+		arg.RefID,
+		arg.Summary,
+	)
+	return err
 }
 
-func (q *Queries) GetThreadReplies(ctx context.Context, arg GetThreadRepliesParams) ([]GetThreadRepliesRow, error) {
-	rows, err := q.db.Query(ctx, getThreadReplies, arg.ParentID, arg.Limit, arg.Offset)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []GetThreadRepliesRow
-	for rows.Next() {
-		var i GetThreadRepliesRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Content,
-			&i.CreatedAt,
-			&i.SenderID,
-			&i.ChannelID,
-			&i.ParentID,
-			&i.SenderUsername,
-			&i.SenderAvatar,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
-}
+const createAttachment = `-- name: CreateAttachment :one
 
-const getUnreadNotificationCount = `-- name: GetUnreadNotificationCount :one
-SELECT COUNT(*) FROM notifications
-WHERE user_id = $1 AND is_read = FALSE
+INSERT INTO attachments (project_id, channel_id, uploader_id, filename, content_type, size_bytes, storage_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, project_id, channel_id, uploader_id, filename, content_type, size_bytes, storage_key, scan_status, scan_engine, scan_signature, scanned_at, created_at
 `
 
-func (q *Queries) GetUnreadNotificationCount(ctx context.Context, userID pgtype.UUID) (int64, error) {
-	row := q.db.QueryRow(ctx, getUnreadNotificationCount, userID)
-	var count int64
-	err := row.Scan(&count)
-	return count, err
+type CreateAttachmentParams struct {
+	ProjectID   pgtype.UUID
+	ChannelID   pgtype.UUID
+	UploaderID  pgtype.UUID
+	Filename    string
+	ContentType string
+	SizeBytes   int64
+	StorageKey  string
 }
 
-const getUserByGithubID = `-- name: GetUserByGithubID :one
-SELECT id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at FROM users WHERE github_id = $1 LIMIT 1
+// ============================================================================
+// ATTACHMENTS
+// ============================================================================
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRow(ctx, createAttachment,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.UploaderID,
+		arg.Filename,
+		arg.ContentType,
+		arg.SizeBytes,
+		arg.StorageKey,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.UploaderID,
+		&i.Filename,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.StorageKey,
+		&i.ScanStatus,
+		&i.ScanEngine,
+		&i.ScanSignature,
+		&i.ScannedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createBoardCard = `-- name: CreateBoardCard :one
+INSERT INTO board_cards (project_id, column_id, title, body, github_issue_number, github_state, position, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, project_id, column_id, title, body, github_issue_number, github_state, position, created_by, created_at, updated_at
 `
 
-func (q *Queries) GetUserByGithubID(ctx context.Context, githubID int64) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByGithubID, githubID)
-	var i User
+type CreateBoardCardParams struct {
+	ProjectID         pgtype.UUID
+	ColumnID          pgtype.UUID
+	Title             string
+	Body              pgtype.Text
+	GithubIssueNumber pgtype.Int4
+	GithubState       pgtype.Text
+	Position          int32
+	CreatedBy         pgtype.UUID
+}
+
+func (q *Queries) CreateBoardCard(ctx context.Context, arg CreateBoardCardParams) (BoardCard, error) {
+	row := q.db.QueryRow(ctx, createBoardCard,
+		arg.ProjectID,
+		arg.ColumnID,
+		arg.Title,
+		arg.Body,
+		arg.GithubIssueNumber,
+		arg.GithubState,
+		arg.Position,
+		arg.CreatedBy,
+	)
+	var i BoardCard
 	err := row.Scan(
 		&i.ID,
-		&i.GithubID,
-		&i.Username,
-		&i.AvatarUrl,
-		&i.DisplayName,
-		&i.AccessToken,
-		&i.ProfileCompleted,
+		&i.ProjectID,
+		&i.ColumnID,
+		&i.Title,
+		&i.Body,
+		&i.GithubIssueNumber,
+		&i.GithubState,
+		&i.Position,
+		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getUserByID = `-- name: GetUserByID :one
-SELECT id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at FROM users WHERE id = $1 LIMIT 1
+const createBoardColumn = `-- name: CreateBoardColumn :one
+
+INSERT INTO board_columns (project_id, name, position)
+VALUES ($1, $2, $3)
+RETURNING id, project_id, name, position, created_at
 `
 
-func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByID, id)
-	var i User
+type CreateBoardColumnParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+	Position  int32
+}
+
+// ============================================================================
+// ISSUE BOARD
+// ============================================================================
+func (q *Queries) CreateBoardColumn(ctx context.Context, arg CreateBoardColumnParams) (BoardColumn, error) {
+	row := q.db.QueryRow(ctx, createBoardColumn, arg.ProjectID, arg.Name, arg.Position)
+	var i BoardColumn
 	err := row.Scan(
 		&i.ID,
-		&i.GithubID,
-		&i.Username,
-		&i.AvatarUrl,
-		&i.DisplayName,
-		&i.AccessToken,
-		&i.ProfileCompleted,
+		&i.ProjectID,
+		&i.Name,
+		&i.Position,
 		&i.CreatedAt,
-		&i.UpdatedAt,
 	)
 	return i, err
 }
 
-const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at FROM users WHERE username = $1 LIMIT 1
+const createBotUser = `-- name: CreateBotUser :one
+
+INSERT INTO users (github_id, username, avatar_url, access_token, profile_completed)
+VALUES (-nextval('bot_user_seq'), $1, $2, '', TRUE)
+RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at
 `
 
-func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
-	row := q.db.QueryRow(ctx, getUserByUsername, username)
+type CreateBotUserParams struct {
+	Username  string
+	AvatarUrl pgtype.Text
+}
+
+// ============================================================================
+// INCOMING WEBHOOKS
+// ============================================================================
+// Bots have no GitHub account or token; negative github_ids keep them apart
+func (q *Queries) CreateBotUser(ctx context.Context, arg CreateBotUserParams) (User, error) {
+	row := q.db.QueryRow(ctx, createBotUser, arg.Username, arg.AvatarUrl)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -1026,225 +1296,10206 @@ func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User,
 		&i.ProfileCompleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
 	)
 	return i, err
 }
 
-const getUserByUsername2 = `-- name: GetUserByUsername2 :one
-SELECT id FROM users WHERE username = $1 LIMIT 1
+const createChannel = `-- name: CreateChannel :one
+
+INSERT INTO channels (project_id, name, description, is_default, position)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, project_id, name, description, is_default, position, created_at, updated_at
 `
 
-func (q *Queries) GetUserByUsername2(ctx context.Context, username string) (pgtype.UUID, error) {
-	row := q.db.QueryRow(ctx, getUserByUsername2, username)
-	var id pgtype.UUID
-	err := row.Scan(&id)
-	return id, err
+type CreateChannelParams struct {
+	ProjectID   pgtype.UUID
+	Name        string
+	Description pgtype.Text
+	IsDefault   pgtype.Bool
+	Position    pgtype.Int4
 }
 
-const getUserMemberships = `-- name: GetUserMemberships :many
-SELECT 
-    p.id AS project_id,
-    p.name AS project_name,
+// ============================================================================
+// CHANNEL QUERIES
+// ============================================================================
+func (q *Queries) CreateChannel(ctx context.Context, arg CreateChannelParams) (Channel, error) {
+	row := q.db.QueryRow(ctx, createChannel,
+		arg.ProjectID,
+		arg.Name,
+		arg.Description,
+		arg.IsDefault,
+		arg.Position,
+	)
+	var i Channel
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.IsDefault,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createChannelGithubLink = `-- name: CreateChannelGithubLink :one
+
+INSERT INTO channel_github_links (channel_id, project_id, github_number, kind, cross_post, linked_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING channel_id, project_id, github_number, kind, cross_post, linked_by, archived_at, created_at
+`
+
+type CreateChannelGithubLinkParams struct {
+	ChannelID    pgtype.UUID
+	ProjectID    pgtype.UUID
+	GithubNumber int32
+	Kind         string
+	CrossPost    bool
+	LinkedBy     pgtype.UUID
+}
+
+// ============================================================================
+// CHANNEL GITHUB LINKS
+// ============================================================================
+func (q *Queries) CreateChannelGithubLink(ctx context.Context, arg CreateChannelGithubLinkParams) (ChannelGithubLink, error) {
+	row := q.db.QueryRow(ctx, createChannelGithubLink,
+		arg.ChannelID,
+		arg.ProjectID,
+		arg.GithubNumber,
+		arg.Kind,
+		arg.CrossPost,
+		arg.LinkedBy,
+	)
+	var i ChannelGithubLink
+	err := row.Scan(
+		&i.ChannelID,
+		&i.ProjectID,
+		&i.GithubNumber,
+		&i.Kind,
+		&i.CrossPost,
+		&i.LinkedBy,
+		&i.ArchivedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createDMConversation = `-- name: CreateDMConversation :one
+INSERT INTO dm_conversations (dm_key, is_bot, status, requested_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (dm_key) DO UPDATE SET dm_key = EXCLUDED.dm_key
+RETURNING id, dm_key, is_bot, created_at, last_message_at, status, requested_by, is_group, name, created_by
+`
+
+type CreateDMConversationParams struct {
+	DmKey       pgtype.Text
+	IsBot       bool
+	Status      string
+	RequestedBy pgtype.UUID
+}
+
+// Upsert so two concurrent opens of the same pair share one conversation
+func (q *Queries) CreateDMConversation(ctx context.Context, arg CreateDMConversationParams) (DmConversation, error) {
+	row := q.db.QueryRow(ctx, createDMConversation,
+		arg.DmKey,
+		arg.IsBot,
+		arg.Status,
+		arg.RequestedBy,
+	)
+	var i DmConversation
+	err := row.Scan(
+		&i.ID,
+		&i.DmKey,
+		&i.IsBot,
+		&i.CreatedAt,
+		&i.LastMessageAt,
+		&i.Status,
+		&i.RequestedBy,
+		&i.IsGroup,
+		&i.Name,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const createEvent = `-- name: CreateEvent :one
+
+INSERT INTO events (project_id, title, description, kind, starts_at, ends_at, recurrence, recurrence_until, remind_minutes, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, project_id, title, description, kind, starts_at, ends_at, recurrence, recurrence_until, remind_minutes, created_by, created_at, updated_at
+`
+
+type CreateEventParams struct {
+	ProjectID       pgtype.UUID
+	Title           string
+	Description     pgtype.Text
+	Kind            string
+	StartsAt        pgtype.Timestamptz
+	EndsAt          pgtype.Timestamptz
+	Recurrence      string
+	RecurrenceUntil pgtype.Timestamptz
+	RemindMinutes   int32
+	CreatedBy       pgtype.UUID
+}
+
+// ============================================================================
+// EVENTS + ACTIVITY
+// ============================================================================
+func (q *Queries) CreateEvent(ctx context.Context, arg CreateEventParams) (Event, error) {
+	row := q.db.QueryRow(ctx, createEvent,
+		arg.ProjectID,
+		arg.Title,
+		arg.Description,
+		arg.Kind,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Recurrence,
+		arg.RecurrenceUntil,
+		arg.RemindMinutes,
+		arg.CreatedBy,
+	)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Title,
+		&i.Description,
+		&i.Kind,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Recurrence,
+		&i.RecurrenceUntil,
+		&i.RemindMinutes,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createFilteredMessage = `-- name: CreateFilteredMessage :exec
+INSERT INTO filtered_messages (id, project_id, channel_id, sender_id, parent_id, content, rule, action, detail)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type CreateFilteredMessageParams struct {
+	ID        int64
+	ProjectID pgtype.UUID
+	ChannelID pgtype.UUID
+	SenderID  pgtype.UUID
+	ParentID  pgtype.Int8
+	Content   string
+	Rule      string
+	Action    string
+	Detail    pgtype.Text
+}
+
+func (q *Queries) CreateFilteredMessage(ctx context.Context, arg CreateFilteredMessageParams) error {
+	_, err := q.db.Exec(ctx, createFilteredMessage,
+		arg.ID,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.SenderID,
+		arg.ParentID,
+		arg.Content,
+		arg.Rule,
+		arg.Action,
+		arg.Detail,
+	)
+	return err
+}
+
+const createGroupDM = `-- name: CreateGroupDM :one
+
+INSERT INTO dm_conversations (is_group, name, created_by)
+VALUES (TRUE, $1, $2)
+RETURNING id, dm_key, is_bot, created_at, last_message_at, status, requested_by, is_group, name, created_by
+`
+
+type CreateGroupDMParams struct {
+	Name      pgtype.Text
+	CreatedBy pgtype.UUID
+}
+
+// ============================================================================
+// GROUP DMS
+// ============================================================================
+func (q *Queries) CreateGroupDM(ctx context.Context, arg CreateGroupDMParams) (DmConversation, error) {
+	row := q.db.QueryRow(ctx, createGroupDM, arg.Name, arg.CreatedBy)
+	var i DmConversation
+	err := row.Scan(
+		&i.ID,
+		&i.DmKey,
+		&i.IsBot,
+		&i.CreatedAt,
+		&i.LastMessageAt,
+		&i.Status,
+		&i.RequestedBy,
+		&i.IsGroup,
+		&i.Name,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const createImpersonationSession = `-- name: CreateImpersonationSession :one
+
+INSERT INTO impersonation_sessions (user_id, admin, reason, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, admin, reason, expires_at, created_at
+`
+
+type CreateImpersonationSessionParams struct {
+	UserID    pgtype.UUID
+	Admin     string
+	Reason    string
+	ExpiresAt pgtype.Timestamptz
+}
+
+// ============================================================================
+// ADMIN IMPERSONATION
+// ============================================================================
+func (q *Queries) CreateImpersonationSession(ctx context.Context, arg CreateImpersonationSessionParams) (ImpersonationSession, error) {
+	row := q.db.QueryRow(ctx, createImpersonationSession,
+		arg.UserID,
+		arg.Admin,
+		arg.Reason,
+		arg.ExpiresAt,
+	)
+	var i ImpersonationSession
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Admin,
+		&i.Reason,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createIncomingWebhook = `-- name: CreateIncomingWebhook :one
+INSERT INTO incoming_webhooks (project_id, channel_id, bot_id, name, prefix, token_hash, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, project_id, channel_id, bot_id, name, prefix, token_hash, created_by, last_used_at, created_at, revoked_at
+`
+
+type CreateIncomingWebhookParams struct {
+	ProjectID pgtype.UUID
+	ChannelID pgtype.UUID
+	BotID     pgtype.UUID
+	Name      string
+	Prefix    string
+	TokenHash string
+	CreatedBy pgtype.UUID
+}
+
+func (q *Queries) CreateIncomingWebhook(ctx context.Context, arg CreateIncomingWebhookParams) (IncomingWebhook, error) {
+	row := q.db.QueryRow(ctx, createIncomingWebhook,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.BotID,
+		arg.Name,
+		arg.Prefix,
+		arg.TokenHash,
+		arg.CreatedBy,
+	)
+	var i IncomingWebhook
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.BotID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createLongPost = `-- name: CreateLongPost :exec
+
+INSERT INTO long_posts (message_id, attachment_id, chars)
+VALUES ($1, $2, $3)
+`
+
+type CreateLongPostParams struct {
+	MessageID    int64
+	AttachmentID pgtype.UUID
+	Chars        int32
+}
+
+// ============================================================================
+// LONG POSTS
+// ============================================================================
+func (q *Queries) CreateLongPost(ctx context.Context, arg CreateLongPostParams) error {
+	_, err := q.db.Exec(ctx, createLongPost, arg.MessageID, arg.AttachmentID, arg.Chars)
+	return err
+}
+
+const createLoopEmoji = `-- name: CreateLoopEmoji :one
+
+INSERT INTO loop_emoji (project_id, name, image_path, created_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (project_id, name) DO NOTHING
+RETURNING id, project_id, name, image_path, created_by, created_at
+`
+
+type CreateLoopEmojiParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+	ImagePath string
+	CreatedBy pgtype.UUID
+}
+
+// ============================================================================
+// CUSTOM EMOJI
+// ============================================================================
+func (q *Queries) CreateLoopEmoji(ctx context.Context, arg CreateLoopEmojiParams) (LoopEmoji, error) {
+	row := q.db.QueryRow(ctx, createLoopEmoji,
+		arg.ProjectID,
+		arg.Name,
+		arg.ImagePath,
+		arg.CreatedBy,
+	)
+	var i LoopEmoji
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.ImagePath,
+		&i.CreatedBy,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createLoopIntegration = `-- name: CreateLoopIntegration :one
+
+INSERT INTO loop_integrations (project_id, type, name, config, topics, enabled, credentials_ref, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, project_id, type, name, config, topics, enabled, credentials_ref, created_by, created_at, updated_at
+`
+
+type CreateLoopIntegrationParams struct {
+	ProjectID      pgtype.UUID
+	Type           string
+	Name           string
+	Config         []byte
+	Topics         []string
+	Enabled        bool
+	CredentialsRef pgtype.Text
+	CreatedBy      pgtype.UUID
+}
+
+// LOOP INTEGRATIONS
+func (q *Queries) CreateLoopIntegration(ctx context.Context, arg CreateLoopIntegrationParams) (LoopIntegration, error) {
+	row := q.db.QueryRow(ctx, createLoopIntegration,
+		arg.ProjectID,
+		arg.Type,
+		arg.Name,
+		arg.Config,
+		arg.Topics,
+		arg.Enabled,
+		arg.CredentialsRef,
+		arg.CreatedBy,
+	)
+	var i LoopIntegration
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Type,
+		&i.Name,
+		&i.Config,
+		&i.Topics,
+		&i.Enabled,
+		&i.CredentialsRef,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createLoopInvite = `-- name: CreateLoopInvite :one
+
+INSERT INTO loop_invites (code, project_id, role, created_by, max_uses, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING code, project_id, role, created_by, max_uses, uses, expires_at, created_at
+`
+
+type CreateLoopInviteParams struct {
+	Code      string
+	ProjectID pgtype.UUID
+	Role      string
+	CreatedBy pgtype.UUID
+	MaxUses   pgtype.Int4
+	ExpiresAt pgtype.Timestamptz
+}
+
+// ============================================================================
+// LOOP INVITES
+// ============================================================================
+func (q *Queries) CreateLoopInvite(ctx context.Context, arg CreateLoopInviteParams) (LoopInvite, error) {
+	row := q.db.QueryRow(ctx, createLoopInvite,
+		arg.Code,
+		arg.ProjectID,
+		arg.Role,
+		arg.CreatedBy,
+		arg.MaxUses,
+		arg.ExpiresAt,
+	)
+	var i LoopInvite
+	err := row.Scan(
+		&i.Code,
+		&i.ProjectID,
+		&i.Role,
+		&i.CreatedBy,
+		&i.MaxUses,
+		&i.Uses,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createLoopSecret = `-- name: CreateLoopSecret :one
+
+INSERT INTO loop_secrets (project_id, name, ciphertext, nonce, wrapped_key, key_id, hint, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING id, project_id, name, ciphertext, nonce, wrapped_key, key_id, hint, created_by, created_at, rotated_at
+`
+
+type CreateLoopSecretParams struct {
+	ProjectID  pgtype.UUID
+	Name       string
+	Ciphertext []byte
+	Nonce      []byte
+	WrappedKey []byte
+	KeyID      string
+	Hint       string
+	CreatedBy  pgtype.UUID
+}
+
+// LOOP SECRETS
+func (q *Queries) CreateLoopSecret(ctx context.Context, arg CreateLoopSecretParams) (LoopSecret, error) {
+	row := q.db.QueryRow(ctx, createLoopSecret,
+		arg.ProjectID,
+		arg.Name,
+		arg.Ciphertext,
+		arg.Nonce,
+		arg.WrappedKey,
+		arg.KeyID,
+		arg.Hint,
+		arg.CreatedBy,
+	)
+	var i LoopSecret
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Ciphertext,
+		&i.Nonce,
+		&i.WrappedKey,
+		&i.KeyID,
+		&i.Hint,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const createMention = `-- name: CreateMention :exec
+
+INSERT INTO mentions (id, user_id, message_id, project_id, channel_id, actor_id, notification_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (message_id, user_id) DO NOTHING
+`
+
+type CreateMentionParams struct {
+	ID             int64
+	UserID         pgtype.UUID
+	MessageID      int64
+	ProjectID      pgtype.UUID
+	ChannelID      pgtype.UUID
+	ActorID        pgtype.UUID
+	NotificationID pgtype.Int8
+}
+
+// ============================================================================
+// MENTIONS
+// ============================================================================
+func (q *Queries) CreateMention(ctx context.Context, arg CreateMentionParams) error {
+	_, err := q.db.Exec(ctx, createMention,
+		arg.ID,
+		arg.UserID,
+		arg.MessageID,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.ActorID,
+		arg.NotificationID,
+	)
+	return err
+}
+
+const createMessageReport = `-- name: CreateMessageReport :one
+
+INSERT INTO message_reports (message_id, project_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (message_id, reporter_id) DO UPDATE
+SET reason = EXCLUDED.reason, details = EXCLUDED.details
+WHERE message_reports.status = 'open'
+RETURNING id, message_id, project_id, reporter_id, reason, details, status, resolution, resolved_by, resolved_at, created_at
+`
+
+type CreateMessageReportParams struct {
+	MessageID  int64
+	ProjectID  pgtype.UUID
+	ReporterID pgtype.UUID
+	Reason     string
+	Details    pgtype.Text
+}
+
+// ============================================================================
+// CONTENT REPORTS
+// ============================================================================
+// Re-reporting an open report updates its reason; resolved reports are left alone
+func (q *Queries) CreateMessageReport(ctx context.Context, arg CreateMessageReportParams) (MessageReport, error) {
+	row := q.db.QueryRow(ctx, createMessageReport,
+		arg.MessageID,
+		arg.ProjectID,
+		arg.ReporterID,
+		arg.Reason,
+		arg.Details,
+	)
+	var i MessageReport
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.ProjectID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Details,
+		&i.Status,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createNotification = `-- name: CreateNotification :exec
+
+INSERT INTO notifications (id, user_id, type, message_id, project_id, channel_id, actor_id, actor_username, content_preview)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+`
+
+type CreateNotificationParams struct {
+	ID             int64
+	UserID         pgtype.UUID
+	Type           string
+	MessageID      pgtype.Int8
+	ProjectID      pgtype.UUID
+	ChannelID      pgtype.UUID
+	ActorID        pgtype.UUID
+	ActorUsername  string
+	ContentPreview pgtype.Text
+}
+
+// ============================================================================
+// NOTIFICATIONS
+// ============================================================================
+func (q *Queries) CreateNotification(ctx context.Context, arg CreateNotificationParams) error {
+	_, err := q.db.Exec(ctx, createNotification,
+		arg.ID,
+		arg.UserID,
+		arg.Type,
+		arg.MessageID,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.ActorID,
+		arg.ActorUsername,
+		arg.ContentPreview,
+	)
+	return err
+}
+
+const createOnboardingStep = `-- name: CreateOnboardingStep :one
+INSERT INTO onboarding_steps (project_id, title, description, kind, channel_id, position)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, project_id, title, description, kind, channel_id, position, created_at
+`
+
+type CreateOnboardingStepParams struct {
+	ProjectID   pgtype.UUID
+	Title       string
+	Description string
+	Kind        string
+	ChannelID   pgtype.UUID
+	Position    int32
+}
+
+func (q *Queries) CreateOnboardingStep(ctx context.Context, arg CreateOnboardingStepParams) (OnboardingStep, error) {
+	row := q.db.QueryRow(ctx, createOnboardingStep,
+		arg.ProjectID,
+		arg.Title,
+		arg.Description,
+		arg.Kind,
+		arg.ChannelID,
+		arg.Position,
+	)
+	var i OnboardingStep
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Title,
+		&i.Description,
+		&i.Kind,
+		&i.ChannelID,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createPersonalAccessToken = `-- name: CreatePersonalAccessToken :one
+
+INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, name, prefix, token_hash, expires_at, last_used_at, created_at, revoked_at
+`
+
+type CreatePersonalAccessTokenParams struct {
+	UserID    pgtype.UUID
+	Name      string
+	Prefix    string
+	TokenHash string
+	ExpiresAt pgtype.Timestamptz
+}
+
+// ============================================================================
+// PERSONAL ACCESS TOKENS
+// ============================================================================
+func (q *Queries) CreatePersonalAccessToken(ctx context.Context, arg CreatePersonalAccessTokenParams) (PersonalAccessToken, error) {
+	row := q.db.QueryRow(ctx, createPersonalAccessToken,
+		arg.UserID,
+		arg.Name,
+		arg.Prefix,
+		arg.TokenHash,
+		arg.ExpiresAt,
+	)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createProject = `-- name: CreateProject :one
+INSERT INTO projects (github_repo_id, name, owner_id, workspace_id)
+VALUES ($1, $2, $3, $4)
+RETURNING id, github_repo_id, name, owner_id, created_at, workspace_id
+`
+
+type CreateProjectParams struct {
+	GithubRepoID int64
+	Name         string
+	OwnerID      pgtype.UUID
+	WorkspaceID  pgtype.UUID
+}
+
+func (q *Queries) CreateProject(ctx context.Context, arg CreateProjectParams) (Project, error) {
+	row := q.db.QueryRow(ctx, createProject,
+		arg.GithubRepoID,
+		arg.Name,
+		arg.OwnerID,
+		arg.WorkspaceID,
+	)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.GithubRepoID,
+		&i.Name,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const createRule = `-- name: CreateRule :one
+INSERT INTO rules (project_id, criteria_type, threshold)
+VALUES ($1, $2, $3)
+RETURNING id, project_id, criteria_type, threshold, created_at
+`
+
+type CreateRuleParams struct {
+	ProjectID    pgtype.UUID
+	CriteriaType string
+	Threshold    string
+}
+
+func (q *Queries) CreateRule(ctx context.Context, arg CreateRuleParams) (Rule, error) {
+	row := q.db.QueryRow(ctx, createRule, arg.ProjectID, arg.CriteriaType, arg.Threshold)
+	var i Rule
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.CriteriaType,
+		&i.Threshold,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createSession = `-- name: CreateSession :one
+
+INSERT INTO sessions (user_id, user_agent, ip, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+`
+
+type CreateSessionParams struct {
+	UserID    pgtype.UUID
+	UserAgent string
+	Ip        string
+	ExpiresAt pgtype.Timestamptz
+}
+
+// ============================================================================
+// SESSIONS
+// ============================================================================
+func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
+	row := q.db.QueryRow(ctx, createSession,
+		arg.UserID,
+		arg.UserAgent,
+		arg.Ip,
+		arg.ExpiresAt,
+	)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.UserAgent,
+		&i.Ip,
+		&i.CreatedAt,
+		&i.LastSeenAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const createStandup = `-- name: CreateStandup :one
+
+INSERT INTO standups (project_id, channel_id, name, questions, prompt_time, timezone, weekdays, collect_minutes, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, project_id, channel_id, name, questions, prompt_time, timezone, weekdays, collect_minutes, enabled, created_by, created_at, updated_at
+`
+
+type CreateStandupParams struct {
+	ProjectID      pgtype.UUID
+	ChannelID      pgtype.UUID
+	Name           string
+	Questions      []string
+	PromptTime     string
+	Timezone       string
+	Weekdays       int32
+	CollectMinutes int32
+	CreatedBy      pgtype.UUID
+}
+
+// ============================================================================
+// STANDUPS
+// ============================================================================
+func (q *Queries) CreateStandup(ctx context.Context, arg CreateStandupParams) (Standup, error) {
+	row := q.db.QueryRow(ctx, createStandup,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.Name,
+		arg.Questions,
+		arg.PromptTime,
+		arg.Timezone,
+		arg.Weekdays,
+		arg.CollectMinutes,
+		arg.CreatedBy,
+	)
+	var i Standup
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.Name,
+		&i.Questions,
+		&i.PromptTime,
+		&i.Timezone,
+		&i.Weekdays,
+		&i.CollectMinutes,
+		&i.Enabled,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createStandupRun = `-- name: CreateStandupRun :one
+INSERT INTO standup_runs (standup_id, closes_at)
+VALUES ($1, $2)
+RETURNING id, standup_id, status, started_at, closes_at, posted_at
+`
+
+type CreateStandupRunParams struct {
+	StandupID pgtype.UUID
+	ClosesAt  pgtype.Timestamptz
+}
+
+func (q *Queries) CreateStandupRun(ctx context.Context, arg CreateStandupRunParams) (StandupRun, error) {
+	row := q.db.QueryRow(ctx, createStandupRun, arg.StandupID, arg.ClosesAt)
+	var i StandupRun
+	err := row.Scan(
+		&i.ID,
+		&i.StandupID,
+		&i.Status,
+		&i.StartedAt,
+		&i.ClosesAt,
+		&i.PostedAt,
+	)
+	return i, err
+}
+
+const createTask = `-- name: CreateTask :one
+
+INSERT INTO tasks (project_id, channel_id, message_id, title, assignee_id, due_at, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING id, project_id, channel_id, message_id, title, assignee_id, due_at, status, github_issue_number, created_by, completed_at, created_at
+`
+
+type CreateTaskParams struct {
+	ProjectID  pgtype.UUID
+	ChannelID  pgtype.UUID
+	MessageID  pgtype.Int8
+	Title      string
+	AssigneeID pgtype.UUID
+	DueAt      pgtype.Timestamptz
+	CreatedBy  pgtype.UUID
+}
+
+// ============================================================================
+// TASKS
+// ============================================================================
+func (q *Queries) CreateTask(ctx context.Context, arg CreateTaskParams) (Task, error) {
+	row := q.db.QueryRow(ctx, createTask,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.MessageID,
+		arg.Title,
+		arg.AssigneeID,
+		arg.DueAt,
+		arg.CreatedBy,
+	)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.MessageID,
+		&i.Title,
+		&i.AssigneeID,
+		&i.DueAt,
+		&i.Status,
+		&i.GithubIssueNumber,
+		&i.CreatedBy,
+		&i.CompletedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createVerificationAttempt = `-- name: CreateVerificationAttempt :one
+
+INSERT INTO loop_verification_attempts (user_id, project_id, passed, results, source)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, user_id, project_id, passed, results, source, checked_at
+`
+
+type CreateVerificationAttemptParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+	Passed    bool
+	Results   []byte
+	Source    string
+}
+
+// ============================================================================
+// JOIN VERIFICATION HISTORY
+// ============================================================================
+func (q *Queries) CreateVerificationAttempt(ctx context.Context, arg CreateVerificationAttemptParams) (LoopVerificationAttempt, error) {
+	row := q.db.QueryRow(ctx, createVerificationAttempt,
+		arg.UserID,
+		arg.ProjectID,
+		arg.Passed,
+		arg.Results,
+		arg.Source,
+	)
+	var i LoopVerificationAttempt
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ProjectID,
+		&i.Passed,
+		&i.Results,
+		&i.Source,
+		&i.CheckedAt,
+	)
+	return i, err
+}
+
+const createWorkflowDispatch = `-- name: CreateWorkflowDispatch :exec
+
+INSERT INTO workflow_dispatches (run_id, project_id, channel_id, user_id, workflow, ref, html_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (run_id) DO NOTHING
+`
+
+type CreateWorkflowDispatchParams struct {
+	RunID     int64
+	ProjectID pgtype.UUID
+	ChannelID pgtype.UUID
+	UserID    pgtype.UUID
+	Workflow  string
+	Ref       string
+	HtmlUrl   string
+}
+
+// ============================================================================
+// WORKFLOW DISPATCHES
+// ============================================================================
+func (q *Queries) CreateWorkflowDispatch(ctx context.Context, arg CreateWorkflowDispatchParams) error {
+	_, err := q.db.Exec(ctx, createWorkflowDispatch,
+		arg.RunID,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.UserID,
+		arg.Workflow,
+		arg.Ref,
+		arg.HtmlUrl,
+	)
+	return err
+}
+
+const createWorkspace = `-- name: CreateWorkspace :one
+
+INSERT INTO workspaces (slug, name, billing_owner_id, personal, default_role)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, slug, name, billing_owner_id, personal, default_role, created_at
+`
+
+type CreateWorkspaceParams struct {
+	Slug           string
+	Name           string
+	BillingOwnerID pgtype.UUID
+	Personal       bool
+	DefaultRole    string
+}
+
+// WORKSPACES
+func (q *Queries) CreateWorkspace(ctx context.Context, arg CreateWorkspaceParams) (Workspace, error) {
+	row := q.db.QueryRow(ctx, createWorkspace,
+		arg.Slug,
+		arg.Name,
+		arg.BillingOwnerID,
+		arg.Personal,
+		arg.DefaultRole,
+	)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.BillingOwnerID,
+		&i.Personal,
+		&i.DefaultRole,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const declineDMRequest = `-- name: DeclineDMRequest :execrows
+DELETE FROM dm_conversations
+WHERE id = $1 AND status = 'pending' AND requested_by <> $2
+`
+
+type DeclineDMRequestParams struct {
+	ID          pgtype.UUID
+	RequestedBy pgtype.UUID
+}
+
+func (q *Queries) DeclineDMRequest(ctx context.Context, arg DeclineDMRequestParams) (int64, error) {
+	result, err := q.db.Exec(ctx, declineDMRequest, arg.ID, arg.RequestedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const decrementReplyCount = `-- name: DecrementReplyCount :exec
+UPDATE messages SET reply_count = GREATEST(0, reply_count - 1) WHERE id = $1
+`
+
+func (q *Queries) DecrementReplyCount(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, decrementReplyCount, id)
+	return err
+}
+
+const deleteAccountQuota = `-- name: DeleteAccountQuota :execrows
+DELETE FROM account_quotas WHERE user_id = $1
+`
+
+func (q *Queries) DeleteAccountQuota(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAccountQuota, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteBoardCard = `-- name: DeleteBoardCard :exec
+DELETE FROM board_cards WHERE id = $1
+`
+
+func (q *Queries) DeleteBoardCard(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBoardCard, id)
+	return err
+}
+
+const deleteBoardColumn = `-- name: DeleteBoardColumn :exec
+DELETE FROM board_columns WHERE id = $1
+`
+
+func (q *Queries) DeleteBoardColumn(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteBoardColumn, id)
+	return err
+}
+
+const deleteChannel = `-- name: DeleteChannel :exec
+DELETE FROM channels WHERE id = $1
+`
+
+func (q *Queries) DeleteChannel(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteChannel, id)
+	return err
+}
+
+const deleteChannelThreadSummaries = `-- name: DeleteChannelThreadSummaries :exec
+DELETE FROM thread_summaries
+WHERE message_id IN (SELECT id FROM messages WHERE channel_id = $1)
+`
+
+func (q *Queries) DeleteChannelThreadSummaries(ctx context.Context, channelID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteChannelThreadSummaries, channelID)
+	return err
+}
+
+const deleteDMConversation = `-- name: DeleteDMConversation :exec
+DELETE FROM dm_conversations WHERE id = $1
+`
+
+func (q *Queries) DeleteDMConversation(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteDMConversation, id)
+	return err
+}
+
+const deleteEvent = `-- name: DeleteEvent :exec
+DELETE FROM events WHERE id = $1
+`
+
+func (q *Queries) DeleteEvent(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteEvent, id)
+	return err
+}
+
+const deleteFeatureFlag = `-- name: DeleteFeatureFlag :execrows
+DELETE FROM feature_flags WHERE key = $1
+`
+
+func (q *Queries) DeleteFeatureFlag(ctx context.Context, key string) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteFeatureFlag, key)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLoopEmoji = `-- name: DeleteLoopEmoji :one
+DELETE FROM loop_emoji WHERE project_id = $1 AND name = $2
+RETURNING image_path
+`
+
+type DeleteLoopEmojiParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+}
+
+func (q *Queries) DeleteLoopEmoji(ctx context.Context, arg DeleteLoopEmojiParams) (string, error) {
+	row := q.db.QueryRow(ctx, deleteLoopEmoji, arg.ProjectID, arg.Name)
+	var image_path string
+	err := row.Scan(&image_path)
+	return image_path, err
+}
+
+const deleteLoopGithubDigest = `-- name: DeleteLoopGithubDigest :execrows
+DELETE FROM loop_github_digests WHERE project_id = $1
+`
+
+func (q *Queries) DeleteLoopGithubDigest(ctx context.Context, projectID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteLoopGithubDigest, projectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLoopIntegration = `-- name: DeleteLoopIntegration :execrows
+DELETE FROM loop_integrations WHERE id = $1
+`
+
+func (q *Queries) DeleteLoopIntegration(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteLoopIntegration, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLoopInvite = `-- name: DeleteLoopInvite :execrows
+DELETE FROM loop_invites WHERE code = $1 AND project_id = $2
+`
+
+type DeleteLoopInviteParams struct {
+	Code      string
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) DeleteLoopInvite(ctx context.Context, arg DeleteLoopInviteParams) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteLoopInvite, arg.Code, arg.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteLoopMemberDayStats = `-- name: DeleteLoopMemberDayStats :exec
+
+DELETE FROM loop_member_daily_stats WHERE day = $1
+`
+
+// LOOP ANALYTICS ROLLUPS
+func (q *Queries) DeleteLoopMemberDayStats(ctx context.Context, day pgtype.Date) error {
+	_, err := q.db.Exec(ctx, deleteLoopMemberDayStats, day)
+	return err
+}
+
+const deleteLoopMirror = `-- name: DeleteLoopMirror :exec
+DELETE FROM loop_mirrors WHERE project_id = $1
+`
+
+func (q *Queries) DeleteLoopMirror(ctx context.Context, projectID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteLoopMirror, projectID)
+	return err
+}
+
+const deleteLoopReactionDayStats = `-- name: DeleteLoopReactionDayStats :exec
+
+DELETE FROM loop_reaction_daily_stats WHERE day = $1
+`
+
+// ============================================================================
+// REACTION ANALYTICS
+// ============================================================================
+func (q *Queries) DeleteLoopReactionDayStats(ctx context.Context, day pgtype.Date) error {
+	_, err := q.db.Exec(ctx, deleteLoopReactionDayStats, day)
+	return err
+}
+
+const deleteLoopSecret = `-- name: DeleteLoopSecret :execrows
+DELETE FROM loop_secrets WHERE id = $1
+`
+
+func (q *Queries) DeleteLoopSecret(ctx context.Context, id pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteLoopSecret, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const deleteOnboardingStepsExcept = `-- name: DeleteOnboardingStepsExcept :exec
+DELETE FROM onboarding_steps
+WHERE project_id = $1 AND NOT (id = ANY($2::uuid[]))
+`
+
+type DeleteOnboardingStepsExceptParams struct {
+	ProjectID pgtype.UUID
+	Keep      []pgtype.UUID
+}
+
+func (q *Queries) DeleteOnboardingStepsExcept(ctx context.Context, arg DeleteOnboardingStepsExceptParams) error {
+	_, err := q.db.Exec(ctx, deleteOnboardingStepsExcept, arg.ProjectID, arg.Keep)
+	return err
+}
+
+const deleteOverlappingMemberships = `-- name: DeleteOverlappingMemberships :exec
+DELETE FROM memberships m
+WHERE m.user_id = $1
+  AND EXISTS (
+    SELECT 1 FROM memberships s
+    WHERE s.user_id = $2 AND s.project_id = m.project_id
+  )
+`
+
+type DeleteOverlappingMembershipsParams struct {
+	MergedID   pgtype.UUID
+	SurvivorID pgtype.UUID
+}
+
+func (q *Queries) DeleteOverlappingMemberships(ctx context.Context, arg DeleteOverlappingMembershipsParams) error {
+	_, err := q.db.Exec(ctx, deleteOverlappingMemberships, arg.MergedID, arg.SurvivorID)
+	return err
+}
+
+const deletePRComment = `-- name: DeletePRComment :exec
+DELETE FROM pr_comments
+WHERE repo_id = $1 AND pr_number = $2 AND comment_type = $3 AND comment_id = $4
+`
+
+type DeletePRCommentParams struct {
+	RepoID      int64
+	PrNumber    int32
+	CommentType string
+	CommentID   int64
+}
+
+func (q *Queries) DeletePRComment(ctx context.Context, arg DeletePRCommentParams) error {
+	_, err := q.db.Exec(ctx, deletePRComment,
+		arg.RepoID,
+		arg.PrNumber,
+		arg.CommentType,
+		arg.CommentID,
+	)
+	return err
+}
+
+const deleteRulesByProject = `-- name: DeleteRulesByProject :exec
+DELETE FROM rules WHERE project_id = $1
+`
+
+func (q *Queries) DeleteRulesByProject(ctx context.Context, projectID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteRulesByProject, projectID)
+	return err
+}
+
+const deleteStandup = `-- name: DeleteStandup :exec
+DELETE FROM standups WHERE id = $1
+`
+
+func (q *Queries) DeleteStandup(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteStandup, id)
+	return err
+}
+
+const enqueueJob = `-- name: EnqueueJob :one
+
+INSERT INTO jobs (type, payload, run_at)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+type EnqueueJobParams struct {
+	Type    string
+	Payload []byte
+	RunAt   pgtype.Timestamptz
+}
+
+// ============================================================================
+// JOB QUEUE
+// ============================================================================
+func (q *Queries) EnqueueJob(ctx context.Context, arg EnqueueJobParams) (int64, error) {
+	row := q.db.QueryRow(ctx, enqueueJob, arg.Type, arg.Payload, arg.RunAt)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const failJob = `-- name: FailJob :exec
+UPDATE jobs
+SET status = 'failed', last_error = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type FailJobParams struct {
+	ID        int64
+	LastError pgtype.Text
+}
+
+func (q *Queries) FailJob(ctx context.Context, arg FailJobParams) error {
+	_, err := q.db.Exec(ctx, failJob, arg.ID, arg.LastError)
+	return err
+}
+
+const getAccountQuota = `-- name: GetAccountQuota :one
+
+SELECT user_id, max_loops, messages_per_day, storage_bytes, note, updated_at FROM account_quotas WHERE user_id = $1
+`
+
+// USAGE QUOTAS
+func (q *Queries) GetAccountQuota(ctx context.Context, userID pgtype.UUID) (AccountQuota, error) {
+	row := q.db.QueryRow(ctx, getAccountQuota, userID)
+	var i AccountQuota
+	err := row.Scan(
+		&i.UserID,
+		&i.MaxLoops,
+		&i.MessagesPerDay,
+		&i.StorageBytes,
+		&i.Note,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getActiveAPIKeyByHash = `-- name: GetActiveAPIKeyByHash :one
+SELECT id, user_id, name, prefix, key_hash, scopes, rate_limit, last_used_at, created_at, revoked_at FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (ApiKey, error) {
+	row := q.db.QueryRow(ctx, getActiveAPIKeyByHash, keyHash)
+	var i ApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.KeyHash,
+		&i.Scopes,
+		&i.RateLimit,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getActiveChannelGithubLinks = `-- name: GetActiveChannelGithubLinks :many
+SELECT channel_id, project_id, github_number, kind, cross_post, linked_by, archived_at, created_at FROM channel_github_links
+WHERE project_id = $1 AND github_number = $2 AND archived_at IS NULL
+`
+
+type GetActiveChannelGithubLinksParams struct {
+	ProjectID    pgtype.UUID
+	GithubNumber int32
+}
+
+func (q *Queries) GetActiveChannelGithubLinks(ctx context.Context, arg GetActiveChannelGithubLinksParams) ([]ChannelGithubLink, error) {
+	rows, err := q.db.Query(ctx, getActiveChannelGithubLinks, arg.ProjectID, arg.GithubNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChannelGithubLink
+	for rows.Next() {
+		var i ChannelGithubLink
+		if err := rows.Scan(
+			&i.ChannelID,
+			&i.ProjectID,
+			&i.GithubNumber,
+			&i.Kind,
+			&i.CrossPost,
+			&i.LinkedBy,
+			&i.ArchivedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActiveIncomingWebhookByHash = `-- name: GetActiveIncomingWebhookByHash :one
+SELECT id, project_id, channel_id, bot_id, name, prefix, token_hash, created_by, last_used_at, created_at, revoked_at FROM incoming_webhooks WHERE token_hash = $1 AND revoked_at IS NULL
+`
+
+func (q *Queries) GetActiveIncomingWebhookByHash(ctx context.Context, tokenHash string) (IncomingWebhook, error) {
+	row := q.db.QueryRow(ctx, getActiveIncomingWebhookByHash, tokenHash)
+	var i IncomingWebhook
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.BotID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.CreatedBy,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getActiveLoopStats = `-- name: GetActiveLoopStats :many
+SELECT
+    p.name,
+    COALESCE(mem.member_count, 0)::bigint AS member_count,
+    COALESCE(ch.channel_count, 0)::bigint AS channel_count,
+    COALESCE(msg.total_messages, 0)::bigint AS total_messages,
+    COALESCE(msg.messages_today, 0)::bigint AS messages_today,
+    p.created_at
+FROM projects p
+LEFT JOIN (
+    SELECT project_id, COUNT(*) AS member_count
+    FROM memberships
+    GROUP BY project_id
+) mem ON mem.project_id = p.id
+LEFT JOIN (
+    SELECT project_id, COUNT(*) AS channel_count
+    FROM channels
+    GROUP BY project_id
+) ch ON ch.project_id = p.id
+LEFT JOIN (
+    SELECT c.project_id,
+           COUNT(*) AS total_messages,
+           COUNT(*) FILTER (WHERE m.created_at > NOW() - INTERVAL '24 hours') AS messages_today
+    FROM messages m
+    JOIN channels c ON m.channel_id = c.id
+    GROUP BY c.project_id
+) msg ON msg.project_id = p.id
+ORDER BY messages_today DESC, total_messages DESC
+LIMIT $1
+`
+
+type GetActiveLoopStatsRow struct {
+	Name          string
+	MemberCount   int64
+	ChannelCount  int64
+	TotalMessages int64
+	MessagesToday int64
+	CreatedAt     pgtype.Timestamptz
+}
+
+func (q *Queries) GetActiveLoopStats(ctx context.Context, limit int32) ([]GetActiveLoopStatsRow, error) {
+	rows, err := q.db.Query(ctx, getActiveLoopStats, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetActiveLoopStatsRow
+	for rows.Next() {
+		var i GetActiveLoopStatsRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.MemberCount,
+			&i.ChannelCount,
+			&i.TotalMessages,
+			&i.MessagesToday,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActivePersonalAccessTokenByHash = `-- name: GetActivePersonalAccessTokenByHash :one
+SELECT id, user_id, name, prefix, token_hash, expires_at, last_used_at, created_at, revoked_at FROM personal_access_tokens
+WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW()
+`
+
+func (q *Queries) GetActivePersonalAccessTokenByHash(ctx context.Context, tokenHash string) (PersonalAccessToken, error) {
+	row := q.db.QueryRow(ctx, getActivePersonalAccessTokenByHash, tokenHash)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getAllLoops = `-- name: GetAllLoops :many
+SELECT 
+    p.id,
+    p.name,
+    p.github_repo_id,
+    p.created_at,
+    u.username AS owner_username,
+    u.avatar_url AS owner_avatar,
+    COUNT(m.user_id) AS member_count,
+    rs.stars,
+    rs.forks,
+    rs.contributors,
+    rs.pushed_at
+FROM projects p
+JOIN users u ON p.owner_id = u.id
+LEFT JOIN memberships m ON m.project_id = p.id
+LEFT JOIN repo_stats rs ON rs.repo_id = p.github_repo_id AND rs.refreshed_at IS NOT NULL
+GROUP BY p.id, u.id, rs.repo_id
+ORDER BY p.created_at DESC
+LIMIT $1 OFFSET $2
+`
+
+type GetAllLoopsParams struct {
+	Limit  int32
+	Offset int32
+}
+
+type GetAllLoopsRow struct {
+	ID            pgtype.UUID
+	Name          string
+	GithubRepoID  int64
+	CreatedAt     pgtype.Timestamptz
+	OwnerUsername string
+	OwnerAvatar   pgtype.Text
+	MemberCount   int64
+	Stars         pgtype.Int4
+	Forks         pgtype.Int4
+	Contributors  pgtype.Int4
+	PushedAt      pgtype.Timestamptz
+}
+
+func (q *Queries) GetAllLoops(ctx context.Context, arg GetAllLoopsParams) ([]GetAllLoopsRow, error) {
+	rows, err := q.db.Query(ctx, getAllLoops, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetAllLoopsRow
+	for rows.Next() {
+		var i GetAllLoopsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.GithubRepoID,
+			&i.CreatedAt,
+			&i.OwnerUsername,
+			&i.OwnerAvatar,
+			&i.MemberCount,
+			&i.Stars,
+			&i.Forks,
+			&i.Contributors,
+			&i.PushedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAttachmentByID = `-- name: GetAttachmentByID :one
+SELECT id, project_id, channel_id, uploader_id, filename, content_type, size_bytes, storage_key, scan_status, scan_engine, scan_signature, scanned_at, created_at FROM attachments WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetAttachmentByID(ctx context.Context, id pgtype.UUID) (Attachment, error) {
+	row := q.db.QueryRow(ctx, getAttachmentByID, id)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.UploaderID,
+		&i.Filename,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.StorageKey,
+		&i.ScanStatus,
+		&i.ScanEngine,
+		&i.ScanSignature,
+		&i.ScannedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getBlockedUserIDs = `-- name: GetBlockedUserIDs :many
+SELECT blocked_id FROM user_blocks WHERE blocker_id = $1
+`
+
+func (q *Queries) GetBlockedUserIDs(ctx context.Context, blockerID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getBlockedUserIDs, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var blocked_id pgtype.UUID
+		if err := rows.Scan(&blocked_id); err != nil {
+			return nil, err
+		}
+		items = append(items, blocked_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBlockedUsers = `-- name: GetBlockedUsers :many
+SELECT b.blocked_id, u.username, u.avatar_url, b.created_at
+FROM user_blocks b
+JOIN users u ON b.blocked_id = u.id
+WHERE b.blocker_id = $1
+ORDER BY b.created_at DESC
+`
+
+type GetBlockedUsersRow struct {
+	BlockedID pgtype.UUID
+	Username  string
+	AvatarUrl pgtype.Text
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetBlockedUsers(ctx context.Context, blockerID pgtype.UUID) ([]GetBlockedUsersRow, error) {
+	rows, err := q.db.Query(ctx, getBlockedUsers, blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBlockedUsersRow
+	for rows.Next() {
+		var i GetBlockedUsersRow
+		if err := rows.Scan(
+			&i.BlockedID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBoardCardByID = `-- name: GetBoardCardByID :one
+SELECT id, project_id, column_id, title, body, github_issue_number, github_state, position, created_by, created_at, updated_at FROM board_cards WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetBoardCardByID(ctx context.Context, id pgtype.UUID) (BoardCard, error) {
+	row := q.db.QueryRow(ctx, getBoardCardByID, id)
+	var i BoardCard
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ColumnID,
+		&i.Title,
+		&i.Body,
+		&i.GithubIssueNumber,
+		&i.GithubState,
+		&i.Position,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getBoardCardCount = `-- name: GetBoardCardCount :one
+SELECT COUNT(*) FROM board_cards WHERE column_id = $1
+`
+
+func (q *Queries) GetBoardCardCount(ctx context.Context, columnID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getBoardCardCount, columnID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getBoardCards = `-- name: GetBoardCards :many
+SELECT id, project_id, column_id, title, body, github_issue_number, github_state, position, created_by, created_at, updated_at FROM board_cards
+WHERE project_id = $1
+ORDER BY position ASC, created_at ASC
+`
+
+func (q *Queries) GetBoardCards(ctx context.Context, projectID pgtype.UUID) ([]BoardCard, error) {
+	rows, err := q.db.Query(ctx, getBoardCards, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BoardCard
+	for rows.Next() {
+		var i BoardCard
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.ColumnID,
+			&i.Title,
+			&i.Body,
+			&i.GithubIssueNumber,
+			&i.GithubState,
+			&i.Position,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBoardCardsByColumn = `-- name: GetBoardCardsByColumn :many
+SELECT id, project_id, column_id, title, body, github_issue_number, github_state, position, created_by, created_at, updated_at FROM board_cards
+WHERE column_id = $1
+ORDER BY position ASC, created_at ASC
+`
+
+func (q *Queries) GetBoardCardsByColumn(ctx context.Context, columnID pgtype.UUID) ([]BoardCard, error) {
+	rows, err := q.db.Query(ctx, getBoardCardsByColumn, columnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BoardCard
+	for rows.Next() {
+		var i BoardCard
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.ColumnID,
+			&i.Title,
+			&i.Body,
+			&i.GithubIssueNumber,
+			&i.GithubState,
+			&i.Position,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBoardColumnByID = `-- name: GetBoardColumnByID :one
+SELECT id, project_id, name, position, created_at FROM board_columns WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetBoardColumnByID(ctx context.Context, id pgtype.UUID) (BoardColumn, error) {
+	row := q.db.QueryRow(ctx, getBoardColumnByID, id)
+	var i BoardColumn
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getBoardColumns = `-- name: GetBoardColumns :many
+SELECT id, project_id, name, position, created_at FROM board_columns
+WHERE project_id = $1
+ORDER BY position ASC, created_at ASC
+`
+
+func (q *Queries) GetBoardColumns(ctx context.Context, projectID pgtype.UUID) ([]BoardColumn, error) {
+	rows, err := q.db.Query(ctx, getBoardColumns, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BoardColumn
+	for rows.Next() {
+		var i BoardColumn
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Position,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChannelActivity = `-- name: GetChannelActivity :many
+
+SELECT c.project_id, c.id AS channel_id,
+    last.id AS last_message_id, last.created_at AS last_message_at,
+    last.content AS last_message_content, last.sender_username AS last_message_sender,
+    COALESCE(unread.n, 0)::int AS unread_count, COALESCE(mentioned.n, 0)::int AS mention_count
+FROM memberships mem
+JOIN channels c ON c.project_id = mem.project_id
+LEFT JOIN loop_settings ls ON ls.project_id = mem.project_id
+LEFT JOIN channel_reads r ON r.user_id = mem.user_id AND r.channel_id = c.id
+LEFT JOIN LATERAL (
+    SELECT m.id, m.created_at, m.content, u.username AS sender_username
+    FROM messages m
+    LEFT JOIN users u ON u.id = m.sender_id
+    WHERE m.channel_id = c.id AND m.parent_id IS NULL AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+    ORDER BY m.id DESC
+    LIMIT 1
+) last ON TRUE
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n FROM (
+        SELECT 1 FROM messages m
+        WHERE m.channel_id = c.id AND m.parent_id IS NULL AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+          AND m.sender_id IS DISTINCT FROM mem.user_id
+          AND CASE WHEN r.last_read_id IS NULL THEN m.created_at > mem.joined_at ELSE m.id > r.last_read_id END
+        LIMIT $1
+    ) capped
+) unread ON TRUE
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n FROM mentions mn
+    WHERE mn.user_id = mem.user_id AND mn.channel_id = c.id AND mn.is_read = FALSE
+      AND CASE WHEN r.last_read_id IS NULL THEN mn.created_at > mem.joined_at ELSE mn.message_id > r.last_read_id END
+) mentioned ON TRUE
+WHERE mem.user_id = $2
+  AND ($3::uuid IS NULL OR mem.project_id = $3)
+  AND (mem.role IS DISTINCT FROM 'guest' OR c.id = ANY(COALESCE(ls.guest_channel_ids, '{}')))
+ORDER BY c.project_id, c.position, c.name
+`
+
+type GetChannelActivityParams struct {
+	UnreadCap int32
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+type GetChannelActivityRow struct {
+	ProjectID          pgtype.UUID
+	ChannelID          pgtype.UUID
+	LastMessageID      pgtype.Int8
+	LastMessageAt      pgtype.Timestamptz
+	LastMessageContent pgtype.Text
+	LastMessageSender  pgtype.Text
+	UnreadCount        int32
+	MentionCount       int32
+}
+
+// CHANNEL READ STATE
+// Per-channel activity for a member's sidebar in one pass: the latest
+// top-level message and how many top-level messages from others and unread
+// mentions came after the member's read mark (or their join, before they
+// first read the channel). Unread counts stop at unread_cap. Covers every
+// loop of the user unless project_id is set; guests only get their channels.
+func (q *Queries) GetChannelActivity(ctx context.Context, arg GetChannelActivityParams) ([]GetChannelActivityRow, error) {
+	rows, err := q.db.Query(ctx, getChannelActivity, arg.UnreadCap, arg.UserID, arg.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChannelActivityRow
+	for rows.Next() {
+		var i GetChannelActivityRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.ChannelID,
+			&i.LastMessageID,
+			&i.LastMessageAt,
+			&i.LastMessageContent,
+			&i.LastMessageSender,
+			&i.UnreadCount,
+			&i.MentionCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChannelAttachments = `-- name: GetChannelAttachments :many
+SELECT id, project_id, channel_id, uploader_id, filename, content_type, size_bytes, storage_key, scan_status, scan_engine, scan_signature, scanned_at, created_at FROM attachments
+WHERE channel_id = $1
+ORDER BY created_at DESC
+LIMIT $2
+`
+
+type GetChannelAttachmentsParams struct {
+	ChannelID pgtype.UUID
+	Limit     int32
+}
+
+func (q *Queries) GetChannelAttachments(ctx context.Context, arg GetChannelAttachmentsParams) ([]Attachment, error) {
+	rows, err := q.db.Query(ctx, getChannelAttachments, arg.ChannelID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.ChannelID,
+			&i.UploaderID,
+			&i.Filename,
+			&i.ContentType,
+			&i.SizeBytes,
+			&i.StorageKey,
+			&i.ScanStatus,
+			&i.ScanEngine,
+			&i.ScanSignature,
+			&i.ScannedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChannelByID = `-- name: GetChannelByID :one
+SELECT id, project_id, name, description, is_default, position, created_at, updated_at FROM channels WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetChannelByID(ctx context.Context, id pgtype.UUID) (Channel, error) {
+	row := q.db.QueryRow(ctx, getChannelByID, id)
+	var i Channel
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.IsDefault,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getChannelByProjectAndName = `-- name: GetChannelByProjectAndName :one
+SELECT id, project_id, name, description, is_default, position, created_at, updated_at FROM channels 
+WHERE project_id = $1 AND name = $2 
+LIMIT 1
+`
+
+type GetChannelByProjectAndNameParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+}
+
+func (q *Queries) GetChannelByProjectAndName(ctx context.Context, arg GetChannelByProjectAndNameParams) (Channel, error) {
+	row := q.db.QueryRow(ctx, getChannelByProjectAndName, arg.ProjectID, arg.Name)
+	var i Channel
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.IsDefault,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getChannelCount = `-- name: GetChannelCount :one
+SELECT COUNT(*) FROM channels WHERE project_id = $1
+`
+
+func (q *Queries) GetChannelCount(ctx context.Context, projectID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getChannelCount, projectID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getChannelDataPolicy = `-- name: GetChannelDataPolicy :one
+
+SELECT channel_id, project_id, ai_summaries, exports, updated_by, updated_at FROM channel_data_policies WHERE channel_id = $1
+`
+
+// ============================================================================
+// CHANNEL DATA POLICIES
+// ============================================================================
+func (q *Queries) GetChannelDataPolicy(ctx context.Context, channelID pgtype.UUID) (ChannelDataPolicy, error) {
+	row := q.db.QueryRow(ctx, getChannelDataPolicy, channelID)
+	var i ChannelDataPolicy
+	err := row.Scan(
+		&i.ChannelID,
+		&i.ProjectID,
+		&i.AiSummaries,
+		&i.Exports,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getChannelGithubLink = `-- name: GetChannelGithubLink :one
+SELECT channel_id, project_id, github_number, kind, cross_post, linked_by, archived_at, created_at FROM channel_github_links WHERE channel_id = $1
+`
+
+func (q *Queries) GetChannelGithubLink(ctx context.Context, channelID pgtype.UUID) (ChannelGithubLink, error) {
+	row := q.db.QueryRow(ctx, getChannelGithubLink, channelID)
+	var i ChannelGithubLink
+	err := row.Scan(
+		&i.ChannelID,
+		&i.ProjectID,
+		&i.GithubNumber,
+		&i.Kind,
+		&i.CrossPost,
+		&i.LinkedBy,
+		&i.ArchivedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getChannelTopic = `-- name: GetChannelTopic :one
+
+SELECT channel_id, project_id, topic, topic_set_by, topic_set_at, banner, banner_set_by, banner_set_at, banner_expires_at FROM channel_topics WHERE channel_id = $1
+`
+
+// CHANNEL TOPICS AND BANNERS
+func (q *Queries) GetChannelTopic(ctx context.Context, channelID pgtype.UUID) (ChannelTopic, error) {
+	row := q.db.QueryRow(ctx, getChannelTopic, channelID)
+	var i ChannelTopic
+	err := row.Scan(
+		&i.ChannelID,
+		&i.ProjectID,
+		&i.Topic,
+		&i.TopicSetBy,
+		&i.TopicSetAt,
+		&i.Banner,
+		&i.BannerSetBy,
+		&i.BannerSetAt,
+		&i.BannerExpiresAt,
+	)
+	return i, err
+}
+
+const getChannelsByProject = `-- name: GetChannelsByProject :many
+SELECT 
+    id,
+    project_id,
+    name,
+    description,
+    is_default,
+    position,
+    created_at
+FROM channels
+WHERE project_id = $1
+ORDER BY position ASC, created_at ASC
+`
+
+type GetChannelsByProjectRow struct {
+	ID          pgtype.UUID
+	ProjectID   pgtype.UUID
+	Name        string
+	Description pgtype.Text
+	IsDefault   pgtype.Bool
+	Position    pgtype.Int4
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) GetChannelsByProject(ctx context.Context, projectID pgtype.UUID) ([]GetChannelsByProjectRow, error) {
+	rows, err := q.db.Query(ctx, getChannelsByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChannelsByProjectRow
+	for rows.Next() {
+		var i GetChannelsByProjectRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Description,
+			&i.IsDefault,
+			&i.Position,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDMConversationByID = `-- name: GetDMConversationByID :one
+SELECT id, dm_key, is_bot, created_at, last_message_at, status, requested_by, is_group, name, created_by FROM dm_conversations WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetDMConversationByID(ctx context.Context, id pgtype.UUID) (DmConversation, error) {
+	row := q.db.QueryRow(ctx, getDMConversationByID, id)
+	var i DmConversation
+	err := row.Scan(
+		&i.ID,
+		&i.DmKey,
+		&i.IsBot,
+		&i.CreatedAt,
+		&i.LastMessageAt,
+		&i.Status,
+		&i.RequestedBy,
+		&i.IsGroup,
+		&i.Name,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const getDMConversationByKey = `-- name: GetDMConversationByKey :one
+
+SELECT id, dm_key, is_bot, created_at, last_message_at, status, requested_by, is_group, name, created_by FROM dm_conversations WHERE dm_key = $1 LIMIT 1
+`
+
+// ============================================================================
+// DIRECT MESSAGES
+// ============================================================================
+func (q *Queries) GetDMConversationByKey(ctx context.Context, dmKey pgtype.Text) (DmConversation, error) {
+	row := q.db.QueryRow(ctx, getDMConversationByKey, dmKey)
+	var i DmConversation
+	err := row.Scan(
+		&i.ID,
+		&i.DmKey,
+		&i.IsBot,
+		&i.CreatedAt,
+		&i.LastMessageAt,
+		&i.Status,
+		&i.RequestedBy,
+		&i.IsGroup,
+		&i.Name,
+		&i.CreatedBy,
+	)
+	return i, err
+}
+
+const getDMConversations = `-- name: GetDMConversations :many
+SELECT
+    c.id,
+    c.is_bot,
+    c.is_group,
+    c.name,
+    c.status,
+    c.last_message_at,
+    u.id AS other_user_id,
+    u.username AS other_username,
+    u.avatar_url AS other_avatar,
+    (SELECT COUNT(*) FROM dm_messages m
+     WHERE m.conversation_id = c.id
+       AND m.created_at > COALESCE(p.last_read_at, 'epoch')
+       AND m.sender_id IS DISTINCT FROM p.user_id) AS unread_count,
+    (SELECT COUNT(*) FROM dm_participants mp WHERE mp.conversation_id = c.id) AS member_count
+FROM dm_participants p
+JOIN dm_conversations c ON c.id = p.conversation_id
+LEFT JOIN dm_participants op ON op.conversation_id = c.id AND op.user_id <> p.user_id AND NOT c.is_group
+LEFT JOIN users u ON u.id = op.user_id
+WHERE p.user_id = $1
+  AND (c.status = 'active' OR c.requested_by = p.user_id)
+ORDER BY c.last_message_at DESC
+`
+
+type GetDMConversationsRow struct {
+	ID            pgtype.UUID
+	IsBot         bool
+	IsGroup       bool
+	Name          pgtype.Text
+	Status        string
+	LastMessageAt pgtype.Timestamptz
+	OtherUserID   pgtype.UUID
+	OtherUsername pgtype.Text
+	OtherAvatar   pgtype.Text
+	UnreadCount   int64
+	MemberCount   int64
+}
+
+func (q *Queries) GetDMConversations(ctx context.Context, userID pgtype.UUID) ([]GetDMConversationsRow, error) {
+	rows, err := q.db.Query(ctx, getDMConversations, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDMConversationsRow
+	for rows.Next() {
+		var i GetDMConversationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IsBot,
+			&i.IsGroup,
+			&i.Name,
+			&i.Status,
+			&i.LastMessageAt,
+			&i.OtherUserID,
+			&i.OtherUsername,
+			&i.OtherAvatar,
+			&i.UnreadCount,
+			&i.MemberCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDMMessages = `-- name: GetDMMessages :many
+SELECT m.id, m.sender_id, m.content, m.created_at, u.username AS sender_username, u.avatar_url AS sender_avatar
+FROM dm_messages m
+LEFT JOIN users u ON m.sender_id = u.id
+WHERE m.conversation_id = $1
+  AND ($2::bigint = 0 OR m.id < $2::bigint)
+ORDER BY m.id DESC
+LIMIT $3
+`
+
+type GetDMMessagesParams struct {
+	ConversationID pgtype.UUID
+	Before         int64
+	N              int32
+}
+
+type GetDMMessagesRow struct {
+	ID             int64
+	SenderID       pgtype.UUID
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	SenderUsername pgtype.Text
+	SenderAvatar   pgtype.Text
+}
+
+func (q *Queries) GetDMMessages(ctx context.Context, arg GetDMMessagesParams) ([]GetDMMessagesRow, error) {
+	rows, err := q.db.Query(ctx, getDMMessages, arg.ConversationID, arg.Before, arg.N)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDMMessagesRow
+	for rows.Next() {
+		var i GetDMMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.SenderID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDMParticipantIDs = `-- name: GetDMParticipantIDs :many
+SELECT user_id FROM dm_participants WHERE conversation_id = $1
+`
+
+func (q *Queries) GetDMParticipantIDs(ctx context.Context, conversationID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getDMParticipantIDs, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var user_id pgtype.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDMParticipants = `-- name: GetDMParticipants :many
+SELECT p.user_id, u.username, u.avatar_url, p.joined_at
+FROM dm_participants p
+JOIN users u ON p.user_id = u.id
+WHERE p.conversation_id = $1
+ORDER BY p.joined_at ASC
+`
+
+type GetDMParticipantsRow struct {
+	UserID    pgtype.UUID
+	Username  string
+	AvatarUrl pgtype.Text
+	JoinedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) GetDMParticipants(ctx context.Context, conversationID pgtype.UUID) ([]GetDMParticipantsRow, error) {
+	rows, err := q.db.Query(ctx, getDMParticipants, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDMParticipantsRow
+	for rows.Next() {
+		var i GetDMParticipantsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDMPrivacy = `-- name: GetDMPrivacy :one
+SELECT dm_privacy FROM user_settings WHERE user_id = $1
+`
+
+func (q *Queries) GetDMPrivacy(ctx context.Context, userID pgtype.UUID) (string, error) {
+	row := q.db.QueryRow(ctx, getDMPrivacy, userID)
+	var dm_privacy string
+	err := row.Scan(&dm_privacy)
+	return dm_privacy, err
+}
+
+const getDMRequests = `-- name: GetDMRequests :many
+
+SELECT
+    c.id,
+    c.created_at,
+    c.last_message_at,
+    u.id AS requester_id,
+    u.username AS requester_username,
+    u.avatar_url AS requester_avatar,
+    (SELECT m.content FROM dm_messages m
+     WHERE m.conversation_id = c.id
+     ORDER BY m.id DESC
+     LIMIT 1) AS last_message
+FROM dm_participants p
+JOIN dm_conversations c ON c.id = p.conversation_id
+JOIN users u ON u.id = c.requested_by
+WHERE p.user_id = $1 AND c.status = 'pending' AND c.requested_by <> $1
+ORDER BY c.last_message_at DESC
+`
+
+type GetDMRequestsRow struct {
+	ID                pgtype.UUID
+	CreatedAt         pgtype.Timestamptz
+	LastMessageAt     pgtype.Timestamptz
+	RequesterID       pgtype.UUID
+	RequesterUsername string
+	RequesterAvatar   pgtype.Text
+	LastMessage       pgtype.Text
+}
+
+// ============================================================================
+// DM PRIVACY + REQUESTS
+// ============================================================================
+func (q *Queries) GetDMRequests(ctx context.Context, userID pgtype.UUID) ([]GetDMRequestsRow, error) {
+	rows, err := q.db.Query(ctx, getDMRequests, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetDMRequestsRow
+	for rows.Next() {
+		var i GetDMRequestsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.LastMessageAt,
+			&i.RequesterID,
+			&i.RequesterUsername,
+			&i.RequesterAvatar,
+			&i.LastMessage,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDefaultChannel = `-- name: GetDefaultChannel :one
+SELECT id, project_id, name, description, is_default, position, created_at, updated_at FROM channels 
+WHERE project_id = $1 AND is_default = TRUE 
+LIMIT 1
+`
+
+func (q *Queries) GetDefaultChannel(ctx context.Context, projectID pgtype.UUID) (Channel, error) {
+	row := q.db.QueryRow(ctx, getDefaultChannel, projectID)
+	var i Channel
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.IsDefault,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getEnabledLoopIntegrations = `-- name: GetEnabledLoopIntegrations :many
+SELECT id, project_id, type, name, config, topics, enabled, credentials_ref, created_by, created_at, updated_at FROM loop_integrations WHERE project_id = $1 AND enabled = TRUE
+`
+
+func (q *Queries) GetEnabledLoopIntegrations(ctx context.Context, projectID pgtype.UUID) ([]LoopIntegration, error) {
+	rows, err := q.db.Query(ctx, getEnabledLoopIntegrations, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoopIntegration
+	for rows.Next() {
+		var i LoopIntegration
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Type,
+			&i.Name,
+			&i.Config,
+			&i.Topics,
+			&i.Enabled,
+			&i.CredentialsRef,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEntryChannelMessages = `-- name: GetEntryChannelMessages :many
+SELECT 
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.seq,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.channel_id = (
+    SELECT ch.id FROM channels ch
+    WHERE ch.project_id = $1
+    ORDER BY ch.is_default DESC NULLS LAST, ch.position ASC, ch.created_at ASC
+    LIMIT 1
+)
+  AND m.parent_id IS NULL 
+ORDER BY m.created_at DESC
+LIMIT $2
+`
+
+type GetEntryChannelMessagesParams struct {
+	ProjectID pgtype.UUID
+	Limit     int32
+}
+
+type GetEntryChannelMessagesRow struct {
+	ID             int64
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	SenderID       pgtype.UUID
+	ChannelID      pgtype.UUID
+	ParentID       pgtype.Int8
+	ReplyCount     pgtype.Int4
+	Seq            pgtype.Int8
+	SenderUsername string
+	SenderAvatar   pgtype.Text
+	IsDeleted      bool
+}
+
+// Messages for the channel a loop opens on (default channel, else first by position)
+func (q *Queries) GetEntryChannelMessages(ctx context.Context, arg GetEntryChannelMessagesParams) ([]GetEntryChannelMessagesRow, error) {
+	rows, err := q.db.Query(ctx, getEntryChannelMessages, arg.ProjectID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEntryChannelMessagesRow
+	for rows.Next() {
+		var i GetEntryChannelMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderID,
+			&i.ChannelID,
+			&i.ParentID,
+			&i.ReplyCount,
+			&i.Seq,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+			&i.IsDeleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventByID = `-- name: GetEventByID :one
+SELECT id, project_id, title, description, kind, starts_at, ends_at, recurrence, recurrence_until, remind_minutes, created_by, created_at, updated_at FROM events WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetEventByID(ctx context.Context, id pgtype.UUID) (Event, error) {
+	row := q.db.QueryRow(ctx, getEventByID, id)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Title,
+		&i.Description,
+		&i.Kind,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Recurrence,
+		&i.RecurrenceUntil,
+		&i.RemindMinutes,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getEventRSVPs = `-- name: GetEventRSVPs :many
+SELECT r.user_id, r.status, u.username, u.avatar_url
+FROM event_rsvps r
+JOIN users u ON r.user_id = u.id
+WHERE r.event_id = $1
+ORDER BY r.updated_at ASC
+`
+
+type GetEventRSVPsRow struct {
+	UserID    pgtype.UUID
+	Status    string
+	Username  string
+	AvatarUrl pgtype.Text
+}
+
+func (q *Queries) GetEventRSVPs(ctx context.Context, eventID pgtype.UUID) ([]GetEventRSVPsRow, error) {
+	rows, err := q.db.Query(ctx, getEventRSVPs, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEventRSVPsRow
+	for rows.Next() {
+		var i GetEventRSVPsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Status,
+			&i.Username,
+			&i.AvatarUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventRemindees = `-- name: GetEventRemindees :many
+SELECT user_id FROM event_rsvps
+WHERE event_id = $1 AND status IN ('going', 'maybe')
+`
+
+func (q *Queries) GetEventRemindees(ctx context.Context, eventID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getEventRemindees, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var user_id pgtype.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsInRange = `-- name: GetEventsInRange :many
+SELECT id, project_id, title, description, kind, starts_at, ends_at, recurrence, recurrence_until, remind_minutes, created_by, created_at, updated_at FROM events
+WHERE project_id = $1
+  AND starts_at < $2
+  AND (ends_at >= $3 OR recurrence <> 'none')
+ORDER BY starts_at ASC
+`
+
+type GetEventsInRangeParams struct {
+	ProjectID  pgtype.UUID
+	RangeEnd   pgtype.Timestamptz
+	RangeStart pgtype.Timestamptz
+}
+
+// Recurring series are returned whole; occurrences are expanded in Go
+func (q *Queries) GetEventsInRange(ctx context.Context, arg GetEventsInRangeParams) ([]Event, error) {
+	rows, err := q.db.Query(ctx, getEventsInRange, arg.ProjectID, arg.RangeEnd, arg.RangeStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Event
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Title,
+			&i.Description,
+			&i.Kind,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Recurrence,
+			&i.RecurrenceUntil,
+			&i.RemindMinutes,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFilteredMessageByID = `-- name: GetFilteredMessageByID :one
+SELECT id, project_id, channel_id, sender_id, parent_id, content, rule, action, detail, status, reviewed_by, reviewed_at, created_at FROM filtered_messages WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetFilteredMessageByID(ctx context.Context, id int64) (FilteredMessage, error) {
+	row := q.db.QueryRow(ctx, getFilteredMessageByID, id)
+	var i FilteredMessage
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.SenderID,
+		&i.ParentID,
+		&i.Content,
+		&i.Rule,
+		&i.Action,
+		&i.Detail,
+		&i.Status,
+		&i.ReviewedBy,
+		&i.ReviewedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getGithubItemSubscribers = `-- name: GetGithubItemSubscribers :many
+SELECT s.user_id, s.channel_id, u.username, u.github_id
+FROM github_item_subscriptions s
+JOIN users u ON u.id = s.user_id
+WHERE s.project_id = $1 AND s.github_number = $2
+`
+
+type GetGithubItemSubscribersParams struct {
+	ProjectID    pgtype.UUID
+	GithubNumber int32
+}
+
+type GetGithubItemSubscribersRow struct {
+	UserID    pgtype.UUID
+	ChannelID pgtype.UUID
+	Username  string
+	GithubID  int64
+}
+
+func (q *Queries) GetGithubItemSubscribers(ctx context.Context, arg GetGithubItemSubscribersParams) ([]GetGithubItemSubscribersRow, error) {
+	rows, err := q.db.Query(ctx, getGithubItemSubscribers, arg.ProjectID, arg.GithubNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetGithubItemSubscribersRow
+	for rows.Next() {
+		var i GetGithubItemSubscribersRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.ChannelID,
+			&i.Username,
+			&i.GithubID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImpersonationRequests = `-- name: GetImpersonationRequests :many
+SELECT id, session_id, method, path, status, created_at FROM impersonation_requests
+WHERE session_id = $1
+ORDER BY created_at ASC
+LIMIT 1000
+`
+
+func (q *Queries) GetImpersonationRequests(ctx context.Context, sessionID pgtype.UUID) ([]ImpersonationRequest, error) {
+	rows, err := q.db.Query(ctx, getImpersonationRequests, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ImpersonationRequest
+	for rows.Next() {
+		var i ImpersonationRequest
+		if err := rows.Scan(
+			&i.ID,
+			&i.SessionID,
+			&i.Method,
+			&i.Path,
+			&i.Status,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getImpersonationSession = `-- name: GetImpersonationSession :one
+SELECT id, user_id, admin, reason, expires_at, created_at FROM impersonation_sessions WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetImpersonationSession(ctx context.Context, id pgtype.UUID) (ImpersonationSession, error) {
+	row := q.db.QueryRow(ctx, getImpersonationSession, id)
+	var i ImpersonationSession
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Admin,
+		&i.Reason,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getInactiveMembers = `-- name: GetInactiveMembers :many
+SELECT u.id, u.username, u.avatar_url, m.role, m.joined_at, m.last_active_at
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+WHERE m.project_id = $1 AND COALESCE(m.last_active_at, m.joined_at) < $2
+ORDER BY COALESCE(m.last_active_at, m.joined_at), u.username
+LIMIT $3 OFFSET $4
+`
+
+type GetInactiveMembersParams struct {
+	ProjectID pgtype.UUID
+	Before    pgtype.Timestamptz
+	RowLimit  int32
+	RowOffset int32
+}
+
+type GetInactiveMembersRow struct {
+	ID           pgtype.UUID
+	Username     string
+	AvatarUrl    pgtype.Text
+	Role         pgtype.Text
+	JoinedAt     pgtype.Timestamptz
+	LastActiveAt pgtype.Timestamptz
+}
+
+// Members last active before a time, longest idle first
+func (q *Queries) GetInactiveMembers(ctx context.Context, arg GetInactiveMembersParams) ([]GetInactiveMembersRow, error) {
+	rows, err := q.db.Query(ctx, getInactiveMembers,
+		arg.ProjectID,
+		arg.Before,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetInactiveMembersRow
+	for rows.Next() {
+		var i GetInactiveMembersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.Role,
+			&i.JoinedAt,
+			&i.LastActiveAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getIntegrationAudit = `-- name: GetIntegrationAudit :many
+SELECT a.id, a.integration_id, a.integration_name, a.action, a.detail, a.created_at,
+       u.username AS actor_username
+FROM integration_audit a
+LEFT JOIN users u ON u.id = a.actor_id
+WHERE a.project_id = $1
+  AND ($2::uuid IS NULL OR a.integration_id = $2)
+ORDER BY a.created_at DESC, a.id DESC
+LIMIT $3
+`
+
+type GetIntegrationAuditParams struct {
+	ProjectID     pgtype.UUID
+	IntegrationID pgtype.UUID
+	MaxRows       int32
+}
+
+type GetIntegrationAuditRow struct {
+	ID              int64
+	IntegrationID   pgtype.UUID
+	IntegrationName string
+	Action          string
+	Detail          string
+	CreatedAt       pgtype.Timestamptz
+	ActorUsername   pgtype.Text
+}
+
+// A loop's integration audit trail, newest first, optionally for one integration
+func (q *Queries) GetIntegrationAudit(ctx context.Context, arg GetIntegrationAuditParams) ([]GetIntegrationAuditRow, error) {
+	rows, err := q.db.Query(ctx, getIntegrationAudit, arg.ProjectID, arg.IntegrationID, arg.MaxRows)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetIntegrationAuditRow
+	for rows.Next() {
+		var i GetIntegrationAuditRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IntegrationID,
+			&i.IntegrationName,
+			&i.Action,
+			&i.Detail,
+			&i.CreatedAt,
+			&i.ActorUsername,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getJobStats = `-- name: GetJobStats :many
+SELECT type,
+    COUNT(*) FILTER (WHERE status = 'queued')::int AS queued,
+    COUNT(*) FILTER (WHERE status = 'queued' AND attempts > 0)::int AS retrying,
+    COUNT(*) FILTER (WHERE status = 'running')::int AS running,
+    COUNT(*) FILTER (WHERE status = 'failed')::int AS failed,
+    MIN(run_at) FILTER (WHERE status = 'queued' AND run_at <= NOW())::timestamptz AS oldest_due
+FROM jobs
+WHERE status <> 'done'
+GROUP BY type
+ORDER BY type
+`
+
+type GetJobStatsRow struct {
+	Type      string
+	Queued    int32
+	Retrying  int32
+	Running   int32
+	Failed    int32
+	OldestDue pgtype.Timestamptz
+}
+
+// Unfinished jobs per type; retrying ones are queued after a failed attempt
+func (q *Queries) GetJobStats(ctx context.Context) ([]GetJobStatsRow, error) {
+	rows, err := q.db.Query(ctx, getJobStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetJobStatsRow
+	for rows.Next() {
+		var i GetJobStatsRow
+		if err := rows.Scan(
+			&i.Type,
+			&i.Queued,
+			&i.Retrying,
+			&i.Running,
+			&i.Failed,
+			&i.OldestDue,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLatestLoopReactionStatsDay = `-- name: GetLatestLoopReactionStatsDay :one
+SELECT MAX(day)::date FROM loop_reaction_daily_stats
+`
+
+func (q *Queries) GetLatestLoopReactionStatsDay(ctx context.Context) (pgtype.Date, error) {
+	row := q.db.QueryRow(ctx, getLatestLoopReactionStatsDay)
+	var max pgtype.Date
+	err := row.Scan(&max)
+	return max, err
+}
+
+const getLatestLoopStatsDay = `-- name: GetLatestLoopStatsDay :one
+SELECT MAX(day)::date FROM loop_daily_stats
+`
+
+func (q *Queries) GetLatestLoopStatsDay(ctx context.Context) (pgtype.Date, error) {
+	row := q.db.QueryRow(ctx, getLatestLoopStatsDay)
+	var max pgtype.Date
+	err := row.Scan(&max)
+	return max, err
+}
+
+const getLinkedBoardCards = `-- name: GetLinkedBoardCards :many
+SELECT id, project_id, column_id, title, body, github_issue_number, github_state, position, created_by, created_at, updated_at FROM board_cards
+WHERE project_id = $1 AND github_issue_number IS NOT NULL
+`
+
+func (q *Queries) GetLinkedBoardCards(ctx context.Context, projectID pgtype.UUID) ([]BoardCard, error) {
+	rows, err := q.db.Query(ctx, getLinkedBoardCards, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []BoardCard
+	for rows.Next() {
+		var i BoardCard
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.ColumnID,
+			&i.Title,
+			&i.Body,
+			&i.GithubIssueNumber,
+			&i.GithubState,
+			&i.Position,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLongPost = `-- name: GetLongPost :one
+SELECT lp.message_id, lp.chars, m.project_id, m.channel_id, a.storage_key
+FROM long_posts lp
+JOIN messages m ON m.id = lp.message_id
+JOIN attachments a ON a.id = lp.attachment_id
+WHERE lp.message_id = $1 AND m.is_deleted IS NOT TRUE
+`
+
+type GetLongPostRow struct {
+	MessageID  int64
+	Chars      int32
+	ProjectID  pgtype.UUID
+	ChannelID  pgtype.UUID
+	StorageKey string
+}
+
+// The stored markdown of a long post, unless its message was deleted
+func (q *Queries) GetLongPost(ctx context.Context, messageID int64) (GetLongPostRow, error) {
+	row := q.db.QueryRow(ctx, getLongPost, messageID)
+	var i GetLongPostRow
+	err := row.Scan(
+		&i.MessageID,
+		&i.Chars,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.StorageKey,
+	)
+	return i, err
+}
+
+const getLoopActivity = `-- name: GetLoopActivity :many
+SELECT a.id, a.kind, a.ref_id, a.summary, a.created_at, u.username AS actor_username, u.avatar_url AS actor_avatar
+FROM loop_activity a
+LEFT JOIN users u ON a.actor_id = u.id
+WHERE a.project_id = $1
+ORDER BY a.created_at DESC
+LIMIT $2
+`
+
+type GetLoopActivityParams struct {
+	ProjectID pgtype.UUID
+	Limit     int32
+}
+
+type GetLoopActivityRow struct {
+	ID            pgtype.UUID
+	Kind          string
+	RefID         pgtype.Text
+	Summary       string
+	CreatedAt     pgtype.Timestamptz
+	ActorUsername pgtype.Text
+	ActorAvatar   pgtype.Text
+}
+
+func (q *Queries) GetLoopActivity(ctx context.Context, arg GetLoopActivityParams) ([]GetLoopActivityRow, error) {
+	rows, err := q.db.Query(ctx, getLoopActivity, arg.ProjectID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopActivityRow
+	for rows.Next() {
+		var i GetLoopActivityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Kind,
+			&i.RefID,
+			&i.Summary,
+			&i.CreatedAt,
+			&i.ActorUsername,
+			&i.ActorAvatar,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopFilterSettings = `-- name: GetLoopFilterSettings :one
+
+SELECT project_id, banned_words, banned_word_action, max_links, link_spam_action, repeat_limit, repeat_action, updated_at FROM loop_filter_settings WHERE project_id = $1 LIMIT 1
+`
+
+// ============================================================================
+// MESSAGE FILTERS
+// ============================================================================
+func (q *Queries) GetLoopFilterSettings(ctx context.Context, projectID pgtype.UUID) (LoopFilterSetting, error) {
+	row := q.db.QueryRow(ctx, getLoopFilterSettings, projectID)
+	var i LoopFilterSetting
+	err := row.Scan(
+		&i.ProjectID,
+		&i.BannedWords,
+		&i.BannedWordAction,
+		&i.MaxLinks,
+		&i.LinkSpamAction,
+		&i.RepeatLimit,
+		&i.RepeatAction,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getLoopFilteredMessages = `-- name: GetLoopFilteredMessages :many
+SELECT f.id, f.channel_id, f.sender_id, f.parent_id, f.content, f.rule, f.action, f.detail, f.status, f.created_at,
+    u.username AS sender_username
+FROM filtered_messages f
+JOIN users u ON f.sender_id = u.id
+WHERE f.project_id = $1 AND f.status = $2
+ORDER BY f.created_at ASC
+LIMIT $3
+`
+
+type GetLoopFilteredMessagesParams struct {
+	ProjectID pgtype.UUID
+	Status    string
+	Limit     int32
+}
+
+type GetLoopFilteredMessagesRow struct {
+	ID             int64
+	ChannelID      pgtype.UUID
+	SenderID       pgtype.UUID
+	ParentID       pgtype.Int8
+	Content        string
+	Rule           string
+	Action         string
+	Detail         pgtype.Text
+	Status         string
+	CreatedAt      pgtype.Timestamptz
+	SenderUsername string
+}
+
+func (q *Queries) GetLoopFilteredMessages(ctx context.Context, arg GetLoopFilteredMessagesParams) ([]GetLoopFilteredMessagesRow, error) {
+	rows, err := q.db.Query(ctx, getLoopFilteredMessages, arg.ProjectID, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopFilteredMessagesRow
+	for rows.Next() {
+		var i GetLoopFilteredMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChannelID,
+			&i.SenderID,
+			&i.ParentID,
+			&i.Content,
+			&i.Rule,
+			&i.Action,
+			&i.Detail,
+			&i.Status,
+			&i.CreatedAt,
+			&i.SenderUsername,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopGithubDigest = `-- name: GetLoopGithubDigest :one
+
+SELECT project_id, channel_id, frequency, send_time, timezone, weekday, sections, enabled, last_posted_at, updated_by, updated_at FROM loop_github_digests WHERE project_id = $1
+`
+
+// GITHUB ACTIVITY DIGESTS
+func (q *Queries) GetLoopGithubDigest(ctx context.Context, projectID pgtype.UUID) (LoopGithubDigest, error) {
+	row := q.db.QueryRow(ctx, getLoopGithubDigest, projectID)
+	var i LoopGithubDigest
+	err := row.Scan(
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.Frequency,
+		&i.SendTime,
+		&i.Timezone,
+		&i.Weekday,
+		&i.Sections,
+		&i.Enabled,
+		&i.LastPostedAt,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getLoopGithubSettings = `-- name: GetLoopGithubSettings :one
+
+SELECT project_id, announcements_channel_id, updated_at, deploy_channel_id, production_environment, first_pr_channel_id, first_pr_template FROM loop_github_settings WHERE project_id = $1
+`
+
+// ============================================================================
+// LOOP GITHUB SETTINGS
+// ============================================================================
+func (q *Queries) GetLoopGithubSettings(ctx context.Context, projectID pgtype.UUID) (LoopGithubSetting, error) {
+	row := q.db.QueryRow(ctx, getLoopGithubSettings, projectID)
+	var i LoopGithubSetting
+	err := row.Scan(
+		&i.ProjectID,
+		&i.AnnouncementsChannelID,
+		&i.UpdatedAt,
+		&i.DeployChannelID,
+		&i.ProductionEnvironment,
+		&i.FirstPrChannelID,
+		&i.FirstPrTemplate,
+	)
+	return i, err
+}
+
+const getLoopGithubToken = `-- name: GetLoopGithubToken :one
+
+SELECT project_id, source, installation_id, updated_by, updated_at FROM loop_github_tokens WHERE project_id = $1
+`
+
+// LOOP GITHUB TOKENS
+func (q *Queries) GetLoopGithubToken(ctx context.Context, projectID pgtype.UUID) (LoopGithubToken, error) {
+	row := q.db.QueryRow(ctx, getLoopGithubToken, projectID)
+	var i LoopGithubToken
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Source,
+		&i.InstallationID,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getLoopInactivity = `-- name: GetLoopInactivity :one
+SELECT
+    COUNT(*)::int AS members,
+    COUNT(*) FILTER (WHERE COALESCE(last_active_at, joined_at) < NOW() - INTERVAL '30 days')::int AS inactive_30d,
+    COUNT(*) FILTER (WHERE COALESCE(last_active_at, joined_at) < NOW() - INTERVAL '90 days')::int AS inactive_90d
+FROM memberships
+WHERE project_id = $1
+`
+
+type GetLoopInactivityRow struct {
+	Members     int32
+	Inactive30d int32
+	Inactive90d int32
+}
+
+// Members not active for 30 and 90 days; members who never were count from joining
+func (q *Queries) GetLoopInactivity(ctx context.Context, projectID pgtype.UUID) (GetLoopInactivityRow, error) {
+	row := q.db.QueryRow(ctx, getLoopInactivity, projectID)
+	var i GetLoopInactivityRow
+	err := row.Scan(
+		&i.Members,
+		&i.Inactive30d,
+		&i.Inactive90d,
+	)
+	return i, err
+}
+
+const getLoopIntegration = `-- name: GetLoopIntegration :one
+SELECT id, project_id, type, name, config, topics, enabled, credentials_ref, created_by, created_at, updated_at FROM loop_integrations WHERE id = $1
+`
+
+func (q *Queries) GetLoopIntegration(ctx context.Context, id pgtype.UUID) (LoopIntegration, error) {
+	row := q.db.QueryRow(ctx, getLoopIntegration, id)
+	var i LoopIntegration
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Type,
+		&i.Name,
+		&i.Config,
+		&i.Topics,
+		&i.Enabled,
+		&i.CredentialsRef,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getLoopIntegrations = `-- name: GetLoopIntegrations :many
+SELECT id, project_id, type, name, config, topics, enabled, credentials_ref, created_by, created_at, updated_at FROM loop_integrations WHERE project_id = $1 ORDER BY created_at
+`
+
+func (q *Queries) GetLoopIntegrations(ctx context.Context, projectID pgtype.UUID) ([]LoopIntegration, error) {
+	rows, err := q.db.Query(ctx, getLoopIntegrations, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoopIntegration
+	for rows.Next() {
+		var i LoopIntegration
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Type,
+			&i.Name,
+			&i.Config,
+			&i.Topics,
+			&i.Enabled,
+			&i.CredentialsRef,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopMembers = `-- name: GetLoopMembers :many
+SELECT 
+    u.id,
+    u.username,
+    u.avatar_url,
+    u.display_name,
+    mem.role,
+    mem.joined_at,
+    ARRAY(
+        SELECT b.badge FROM member_badges b
+        WHERE b.project_id = mem.project_id AND b.user_id = u.id
+        ORDER BY b.awarded_at
+    )::TEXT[] AS badges
+FROM memberships mem
+JOIN users u ON mem.user_id = u.id
+WHERE mem.project_id = $1
+ORDER BY mem.joined_at ASC
+`
+
+type GetLoopMembersRow struct {
+	ID          pgtype.UUID
+	Username    string
+	AvatarUrl   pgtype.Text
+	DisplayName pgtype.Text
+	Role        pgtype.Text
+	JoinedAt    pgtype.Timestamptz
+	Badges      []string
+}
+
+func (q *Queries) GetLoopMembers(ctx context.Context, projectID pgtype.UUID) ([]GetLoopMembersRow, error) {
+	rows, err := q.db.Query(ctx, getLoopMembers, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopMembersRow
+	for rows.Next() {
+		var i GetLoopMembersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.DisplayName,
+			&i.Role,
+			&i.JoinedAt,
+			&i.Badges,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopMessageFollowers = `-- name: GetLoopMessageFollowers :many
+SELECT user_id FROM memberships
+WHERE project_id = $1 AND notify_level = 'all' AND user_id <> $2
+`
+
+type GetLoopMessageFollowersParams struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+}
+
+// Members notified of every message in the loop, but the sender
+func (q *Queries) GetLoopMessageFollowers(ctx context.Context, arg GetLoopMessageFollowersParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, getLoopMessageFollowers, arg.ProjectID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var user_id pgtype.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopMessageUsage = `-- name: GetLoopMessageUsage :one
+SELECT
+    COALESCE(w.billing_owner_id, p.owner_id)::uuid AS owner_id,
+    (SELECT COUNT(*) FROM messages m
+     WHERE m.project_id = p.id AND m.created_at >= $1)::bigint AS messages
+FROM projects p
+LEFT JOIN workspaces w ON w.id = p.workspace_id
+WHERE p.id = $2
+`
+
+type GetLoopMessageUsageParams struct {
+	Since     pgtype.Timestamptz
+	ProjectID pgtype.UUID
+}
+
+type GetLoopMessageUsageRow struct {
+	OwnerID  pgtype.UUID
+	Messages int64
+}
+
+// The account the loop is billed to and how many messages it has received since a time
+func (q *Queries) GetLoopMessageUsage(ctx context.Context, arg GetLoopMessageUsageParams) (GetLoopMessageUsageRow, error) {
+	row := q.db.QueryRow(ctx, getLoopMessageUsage, arg.Since, arg.ProjectID)
+	var i GetLoopMessageUsageRow
+	err := row.Scan(
+		&i.OwnerID,
+		&i.Messages,
+	)
+	return i, err
+}
+
+const getLoopMirror = `-- name: GetLoopMirror :one
+SELECT project_id, reason, since, archived_at, archived_by FROM loop_mirrors WHERE project_id = $1
+`
+
+func (q *Queries) GetLoopMirror(ctx context.Context, projectID pgtype.UUID) (LoopMirror, error) {
+	row := q.db.QueryRow(ctx, getLoopMirror, projectID)
+	var i LoopMirror
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Reason,
+		&i.Since,
+		&i.ArchivedAt,
+		&i.ArchivedBy,
+	)
+	return i, err
+}
+
+const getLoopMostReactedAuthors = `-- name: GetLoopMostReactedAuthors :many
+SELECT s.author_id, u.username, u.avatar_url, SUM(s.reactions)::int AS reactions
+FROM loop_reaction_daily_stats s
+JOIN users u ON u.id = s.author_id
+WHERE s.project_id = $1 AND s.day >= $2
+GROUP BY s.author_id, u.username, u.avatar_url
+ORDER BY reactions DESC, u.username
+LIMIT $3
+`
+
+type GetLoopMostReactedAuthorsParams struct {
+	ProjectID pgtype.UUID
+	Day       pgtype.Date
+	Limit     int32
+}
+
+type GetLoopMostReactedAuthorsRow struct {
+	AuthorID  pgtype.UUID
+	Username  string
+	AvatarUrl pgtype.Text
+	Reactions int32
+}
+
+func (q *Queries) GetLoopMostReactedAuthors(ctx context.Context, arg GetLoopMostReactedAuthorsParams) ([]GetLoopMostReactedAuthorsRow, error) {
+	rows, err := q.db.Query(ctx, getLoopMostReactedAuthors, arg.ProjectID, arg.Day, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopMostReactedAuthorsRow
+	for rows.Next() {
+		var i GetLoopMostReactedAuthorsRow
+		if err := rows.Scan(
+			&i.AuthorID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.Reactions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopOnboardingProgress = `-- name: GetLoopOnboardingProgress :many
+SELECT u.id, u.username, u.avatar_url, mem.joined_at,
+    (SELECT COUNT(*) FROM onboarding_progress p
+     JOIN onboarding_steps s ON s.id = p.step_id
+     WHERE s.project_id = mem.project_id AND p.user_id = u.id) AS completed
+FROM memberships mem
+JOIN users u ON u.id = mem.user_id
+WHERE mem.project_id = $1
+ORDER BY mem.joined_at DESC
+`
+
+type GetLoopOnboardingProgressRow struct {
+	ID        pgtype.UUID
+	Username  string
+	AvatarUrl pgtype.Text
+	JoinedAt  pgtype.Timestamptz
+	Completed int64
+}
+
+func (q *Queries) GetLoopOnboardingProgress(ctx context.Context, projectID pgtype.UUID) ([]GetLoopOnboardingProgressRow, error) {
+	rows, err := q.db.Query(ctx, getLoopOnboardingProgress, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopOnboardingProgressRow
+	for rows.Next() {
+		var i GetLoopOnboardingProgressRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.JoinedAt,
+			&i.Completed,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopPinnedMessages = `-- name: GetLoopPinnedMessages :many
+SELECT
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.pinned_at,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    pinner.username AS pinned_by_username,
+    ch.name AS channel_name
+FROM messages m
+JOIN channels ch ON m.channel_id = ch.id
+JOIN users u ON m.sender_id = u.id
+LEFT JOIN users pinner ON m.pinned_by = pinner.id
+WHERE ch.project_id = $1
+  AND m.is_pinned = TRUE
+  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+  AND ($2::uuid[] IS NULL OR m.channel_id = ANY($2::uuid[]))
+  AND ($3::timestamptz IS NULL
+       OR (m.pinned_at, m.id) < ($3::timestamptz, $4::bigint))
+ORDER BY m.pinned_at DESC, m.id DESC
+LIMIT $5
+`
+
+type GetLoopPinnedMessagesParams struct {
+	ProjectID      pgtype.UUID
+	ChannelIds     []pgtype.UUID
+	BeforePinnedAt pgtype.Timestamptz
+	BeforeID       pgtype.Int8
+	RowLimit       int32
+}
+
+type GetLoopPinnedMessagesRow struct {
+	ID               int64
+	Content          string
+	CreatedAt        pgtype.Timestamptz
+	SenderID         pgtype.UUID
+	ChannelID        pgtype.UUID
+	ParentID         pgtype.Int8
+	ReplyCount       pgtype.Int4
+	PinnedAt         pgtype.Timestamptz
+	SenderUsername   string
+	SenderAvatar     pgtype.Text
+	PinnedByUsername pgtype.Text
+	ChannelName      string
+}
+
+// Pins across a loop's channels (all of them when channel_ids is NULL),
+// newest pin first, paged by the (pinned_at, id) of the last one seen
+func (q *Queries) GetLoopPinnedMessages(ctx context.Context, arg GetLoopPinnedMessagesParams) ([]GetLoopPinnedMessagesRow, error) {
+	rows, err := q.db.Query(ctx, getLoopPinnedMessages,
+		arg.ProjectID,
+		arg.ChannelIds,
+		arg.BeforePinnedAt,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopPinnedMessagesRow
+	for rows.Next() {
+		var i GetLoopPinnedMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderID,
+			&i.ChannelID,
+			&i.ParentID,
+			&i.ReplyCount,
+			&i.PinnedAt,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+			&i.PinnedByUsername,
+			&i.ChannelName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopReactionTrend = `-- name: GetLoopReactionTrend :many
+SELECT day, SUM(reactions)::int AS reactions
+FROM loop_reaction_daily_stats
+WHERE project_id = $1 AND day >= $2
+GROUP BY day
+ORDER BY day
+`
+
+type GetLoopReactionTrendParams struct {
+	ProjectID pgtype.UUID
+	Day       pgtype.Date
+}
+
+type GetLoopReactionTrendRow struct {
+	Day       pgtype.Date
+	Reactions int32
+}
+
+func (q *Queries) GetLoopReactionTrend(ctx context.Context, arg GetLoopReactionTrendParams) ([]GetLoopReactionTrendRow, error) {
+	rows, err := q.db.Query(ctx, getLoopReactionTrend, arg.ProjectID, arg.Day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopReactionTrendRow
+	for rows.Next() {
+		var i GetLoopReactionTrendRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.Reactions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopReports = `-- name: GetLoopReports :many
+SELECT
+    r.id, r.message_id, r.reporter_id, r.reason, r.details, r.status, r.resolution, r.resolved_at, r.created_at,
+    ru.username AS reporter_username,
+    m.content AS message_content,
+    m.sender_id AS author_id,
+    m.channel_id,
+    m.is_deleted AS message_deleted,
+    au.username AS author_username
+FROM message_reports r
+JOIN users ru ON r.reporter_id = ru.id
+JOIN messages m ON r.message_id = m.id
+LEFT JOIN users au ON m.sender_id = au.id
+WHERE r.project_id = $1 AND r.status = $2
+ORDER BY r.created_at ASC
+LIMIT $3
+`
+
+type GetLoopReportsParams struct {
+	ProjectID pgtype.UUID
+	Status    string
+	Limit     int32
+}
+
+type GetLoopReportsRow struct {
+	ID               pgtype.UUID
+	MessageID        int64
+	ReporterID       pgtype.UUID
+	Reason           string
+	Details          pgtype.Text
+	Status           string
+	Resolution       pgtype.Text
+	ResolvedAt       pgtype.Timestamptz
+	CreatedAt        pgtype.Timestamptz
+	ReporterUsername string
+	MessageContent   string
+	AuthorID         pgtype.UUID
+	ChannelID        pgtype.UUID
+	MessageDeleted   pgtype.Bool
+	AuthorUsername   pgtype.Text
+}
+
+func (q *Queries) GetLoopReports(ctx context.Context, arg GetLoopReportsParams) ([]GetLoopReportsRow, error) {
+	rows, err := q.db.Query(ctx, getLoopReports, arg.ProjectID, arg.Status, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopReportsRow
+	for rows.Next() {
+		var i GetLoopReportsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.ReporterID,
+			&i.Reason,
+			&i.Details,
+			&i.Status,
+			&i.Resolution,
+			&i.ResolvedAt,
+			&i.CreatedAt,
+			&i.ReporterUsername,
+			&i.MessageContent,
+			&i.AuthorID,
+			&i.ChannelID,
+			&i.MessageDeleted,
+			&i.AuthorUsername,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopSecret = `-- name: GetLoopSecret :one
+SELECT id, project_id, name, ciphertext, nonce, wrapped_key, key_id, hint, created_by, created_at, rotated_at FROM loop_secrets WHERE id = $1
+`
+
+func (q *Queries) GetLoopSecret(ctx context.Context, id pgtype.UUID) (LoopSecret, error) {
+	row := q.db.QueryRow(ctx, getLoopSecret, id)
+	var i LoopSecret
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Ciphertext,
+		&i.Nonce,
+		&i.WrappedKey,
+		&i.KeyID,
+		&i.Hint,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const getLoopSecretByName = `-- name: GetLoopSecretByName :one
+SELECT id, project_id, name, ciphertext, nonce, wrapped_key, key_id, hint, created_by, created_at, rotated_at FROM loop_secrets WHERE project_id = $1 AND name = $2
+`
+
+type GetLoopSecretByNameParams struct {
+	ProjectID pgtype.UUID
+	Name      string
+}
+
+func (q *Queries) GetLoopSecretByName(ctx context.Context, arg GetLoopSecretByNameParams) (LoopSecret, error) {
+	row := q.db.QueryRow(ctx, getLoopSecretByName, arg.ProjectID, arg.Name)
+	var i LoopSecret
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Ciphertext,
+		&i.Nonce,
+		&i.WrappedKey,
+		&i.KeyID,
+		&i.Hint,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const getLoopSecrets = `-- name: GetLoopSecrets :many
+SELECT id, project_id, name, ciphertext, nonce, wrapped_key, key_id, hint, created_by, created_at, rotated_at FROM loop_secrets WHERE project_id = $1 ORDER BY name
+`
+
+func (q *Queries) GetLoopSecrets(ctx context.Context, projectID pgtype.UUID) ([]LoopSecret, error) {
+	rows, err := q.db.Query(ctx, getLoopSecrets, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoopSecret
+	for rows.Next() {
+		var i LoopSecret
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Ciphertext,
+			&i.Nonce,
+			&i.WrappedKey,
+			&i.KeyID,
+			&i.Hint,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.RotatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopSecretsNotUnderKey = `-- name: GetLoopSecretsNotUnderKey :many
+SELECT id, project_id, name, ciphertext, nonce, wrapped_key, key_id, hint, created_by, created_at, rotated_at FROM loop_secrets WHERE key_id <> $1
+`
+
+func (q *Queries) GetLoopSecretsNotUnderKey(ctx context.Context, keyID string) ([]LoopSecret, error) {
+	rows, err := q.db.Query(ctx, getLoopSecretsNotUnderKey, keyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoopSecret
+	for rows.Next() {
+		var i LoopSecret
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.Ciphertext,
+			&i.Nonce,
+			&i.WrappedKey,
+			&i.KeyID,
+			&i.Hint,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.RotatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopSettings = `-- name: GetLoopSettings :one
+
+SELECT project_id, welcome_channel_id, welcome_template, welcome_dm, updated_at, visibility, public_channel_ids, guest_channel_ids, pin_role, pin_limit, digest_channel_id, digest_posted_at, default_notify_level, thread_summaries, ai_disabled, ai_model, ai_max_tokens, ai_temperature, ai_safety, ai_content, access_revoked, ai_language, ai_tone FROM loop_settings WHERE project_id = $1
+`
+
+// ============================================================================
+// LOOP SETTINGS
+// ============================================================================
+func (q *Queries) GetLoopSettings(ctx context.Context, projectID pgtype.UUID) (LoopSetting, error) {
+	row := q.db.QueryRow(ctx, getLoopSettings, projectID)
+	var i LoopSetting
+	err := row.Scan(
+		&i.ProjectID,
+		&i.WelcomeChannelID,
+		&i.WelcomeTemplate,
+		&i.WelcomeDm,
+		&i.UpdatedAt,
+		&i.Visibility,
+		&i.PublicChannelIds,
+		&i.GuestChannelIds,
+		&i.PinRole,
+		&i.PinLimit,
+		&i.DigestChannelID,
+		&i.DigestPostedAt,
+		&i.DefaultNotifyLevel,
+		&i.ThreadSummaries,
+		&i.AiDisabled,
+		&i.AiModel,
+		&i.AiMaxTokens,
+		&i.AiTemperature,
+		&i.AiSafety,
+		&i.AiContent,
+		&i.AccessRevoked,
+		&i.AiLanguage,
+		&i.AiTone,
+	)
+	return i, err
+}
+
+const getLoopStorageUsage = `-- name: GetLoopStorageUsage :one
+SELECT
+    COALESCE(w.billing_owner_id, p.owner_id)::uuid AS owner_id,
+    COALESCE((SELECT SUM(a.size_bytes) FROM attachments a WHERE a.project_id = p.id), 0)::bigint AS bytes
+FROM projects p
+LEFT JOIN workspaces w ON w.id = p.workspace_id
+WHERE p.id = $1
+`
+
+type GetLoopStorageUsageRow struct {
+	OwnerID pgtype.UUID
+	Bytes   int64
+}
+
+// The account the loop is billed to and the bytes its attachments take up
+func (q *Queries) GetLoopStorageUsage(ctx context.Context, projectID pgtype.UUID) (GetLoopStorageUsageRow, error) {
+	row := q.db.QueryRow(ctx, getLoopStorageUsage, projectID)
+	var i GetLoopStorageUsageRow
+	err := row.Scan(
+		&i.OwnerID,
+		&i.Bytes,
+	)
+	return i, err
+}
+
+const getLoopTopEmoji = `-- name: GetLoopTopEmoji :many
+SELECT emoji, SUM(reactions)::int AS reactions
+FROM loop_reaction_daily_stats
+WHERE project_id = $1 AND day >= $2
+GROUP BY emoji
+ORDER BY reactions DESC, emoji
+LIMIT $3
+`
+
+type GetLoopTopEmojiParams struct {
+	ProjectID pgtype.UUID
+	Day       pgtype.Date
+	Limit     int32
+}
+
+type GetLoopTopEmojiRow struct {
+	Emoji     string
+	Reactions int32
+}
+
+func (q *Queries) GetLoopTopEmoji(ctx context.Context, arg GetLoopTopEmojiParams) ([]GetLoopTopEmojiRow, error) {
+	rows, err := q.db.Query(ctx, getLoopTopEmoji, arg.ProjectID, arg.Day, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetLoopTopEmojiRow
+	for rows.Next() {
+		var i GetLoopTopEmojiRow
+		if err := rows.Scan(
+			&i.Emoji,
+			&i.Reactions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getLoopWidgetStats = `-- name: GetLoopWidgetStats :one
+SELECT
+    (SELECT COUNT(*) FROM memberships WHERE project_id = $1)::bigint AS member_count,
+    (SELECT COUNT(*) FROM messages m
+     JOIN channels c ON m.channel_id = c.id
+     WHERE c.project_id = $1
+       AND m.created_at > NOW() - INTERVAL '7 days'
+       AND (m.is_deleted = FALSE OR m.is_deleted IS NULL))::bigint AS messages_this_week
+`
+
+type GetLoopWidgetStatsRow struct {
+	MemberCount      int64
+	MessagesThisWeek int64
+}
+
+// Member count and last-7-days message volume for the embeddable widget
+func (q *Queries) GetLoopWidgetStats(ctx context.Context, projectID pgtype.UUID) (GetLoopWidgetStatsRow, error) {
+	row := q.db.QueryRow(ctx, getLoopWidgetStats, projectID)
+	var i GetLoopWidgetStatsRow
+	err := row.Scan(
+		&i.MemberCount,
+		&i.MessagesThisWeek,
+	)
+	return i, err
+}
+
+const getMemberOnboarding = `-- name: GetMemberOnboarding :many
+SELECT s.id, s.title, s.description, s.kind, s.channel_id, s.position, p.completed_at
+FROM onboarding_steps s
+LEFT JOIN onboarding_progress p ON p.step_id = s.id AND p.user_id = $2
+WHERE s.project_id = $1
+ORDER BY s.position, s.created_at
+`
+
+type GetMemberOnboardingParams struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+}
+
+type GetMemberOnboardingRow struct {
+	ID          pgtype.UUID
+	Title       string
+	Description string
+	Kind        string
+	ChannelID   pgtype.UUID
+	Position    int32
+	CompletedAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetMemberOnboarding(ctx context.Context, arg GetMemberOnboardingParams) ([]GetMemberOnboardingRow, error) {
+	rows, err := q.db.Query(ctx, getMemberOnboarding, arg.ProjectID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMemberOnboardingRow
+	for rows.Next() {
+		var i GetMemberOnboardingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.Kind,
+			&i.ChannelID,
+			&i.Position,
+			&i.CompletedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMembership = `-- name: GetMembership :one
+
+SELECT user_id, project_id, role, joined_at, is_favorite, sort_order, sidebar_collapsed, last_active_at, notify_level FROM memberships
+WHERE user_id = $1 AND project_id = $2 LIMIT 1
+`
+
+type GetMembershipParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+// ============================================================================
+// SIDEBAR PREFERENCES
+// ============================================================================
+func (q *Queries) GetMembership(ctx context.Context, arg GetMembershipParams) (Membership, error) {
+	row := q.db.QueryRow(ctx, getMembership, arg.UserID, arg.ProjectID)
+	var i Membership
+	err := row.Scan(
+		&i.UserID,
+		&i.ProjectID,
+		&i.Role,
+		&i.JoinedAt,
+		&i.IsFavorite,
+		&i.SortOrder,
+		&i.SidebarCollapsed,
+		&i.LastActiveAt,
+		&i.NotifyLevel,
+	)
+	return i, err
+}
+
+const getMentions = `-- name: GetMentions :many
+SELECT
+    mn.id,
+    mn.message_id,
+    mn.project_id,
+    mn.channel_id,
+    mn.is_read,
+    mn.created_at,
+    m.content,
+    m.parent_id,
+    u.username AS actor_username,
+    u.avatar_url AS actor_avatar,
+    p.name AS project_name,
+    ch.name AS channel_name
+FROM mentions mn
+JOIN messages m ON mn.message_id = m.id
+JOIN users u ON mn.actor_id = u.id
+LEFT JOIN projects p ON mn.project_id = p.id
+LEFT JOIN channels ch ON mn.channel_id = ch.id
+WHERE mn.user_id = $1
+  AND (m.is_deleted IS NULL OR m.is_deleted = FALSE)
+  AND (NOT $2::bool OR mn.is_read = FALSE)
+  AND ($3::bigint = 0 OR mn.id < $3::bigint)
+ORDER BY mn.id DESC
+LIMIT $4
+`
+
+type GetMentionsParams struct {
+	UserID     pgtype.UUID
+	UnreadOnly bool
+	Before     int64
+	N          int32
+}
+
+type GetMentionsRow struct {
+	ID            int64
+	MessageID     int64
+	ProjectID     pgtype.UUID
+	ChannelID     pgtype.UUID
+	IsRead        bool
+	CreatedAt     pgtype.Timestamptz
+	Content       string
+	ParentID      pgtype.Int8
+	ActorUsername string
+	ActorAvatar   pgtype.Text
+	ProjectName   pgtype.Text
+	ChannelName   pgtype.Text
+}
+
+func (q *Queries) GetMentions(ctx context.Context, arg GetMentionsParams) ([]GetMentionsRow, error) {
+	rows, err := q.db.Query(ctx, getMentions,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.Before,
+		arg.N,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMentionsRow
+	for rows.Next() {
+		var i GetMentionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.ProjectID,
+			&i.ChannelID,
+			&i.IsRead,
+			&i.CreatedAt,
+			&i.Content,
+			&i.ParentID,
+			&i.ActorUsername,
+			&i.ActorAvatar,
+			&i.ProjectName,
+			&i.ChannelName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessageByID = `-- name: GetMessageByID :one
+SELECT id, project_id, channel_id, sender_id, content, parent_id, reply_count, is_deleted, deleted_at, created_at, is_pinned, pinned_by, pinned_at, seq FROM messages WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetMessageByID(ctx context.Context, id int64) (Message, error) {
+	row := q.db.QueryRow(ctx, getMessageByID, id)
+	var i Message
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.SenderID,
+		&i.Content,
+		&i.ParentID,
+		&i.ReplyCount,
+		&i.IsDeleted,
+		&i.DeletedAt,
+		&i.CreatedAt,
+		&i.IsPinned,
+		&i.PinnedBy,
+		&i.PinnedAt,
+		&i.Seq,
+	)
+	return i, err
+}
+
+const getMessageGithubEntities = `-- name: GetMessageGithubEntities :many
+SELECT message_id, text_offset, text_length, kind, number, sha, title, state, url, resolved_at FROM message_github_entities
+WHERE message_id = ANY($1::bigint[])
+ORDER BY message_id, text_offset
+`
+
+func (q *Queries) GetMessageGithubEntities(ctx context.Context, messageIds []int64) ([]MessageGithubEntity, error) {
+	rows, err := q.db.Query(ctx, getMessageGithubEntities, messageIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MessageGithubEntity
+	for rows.Next() {
+		var i MessageGithubEntity
+		if err := rows.Scan(
+			&i.MessageID,
+			&i.TextOffset,
+			&i.TextLength,
+			&i.Kind,
+			&i.Number,
+			&i.Sha,
+			&i.Title,
+			&i.State,
+			&i.Url,
+			&i.ResolvedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessageReportByID = `-- name: GetMessageReportByID :one
+SELECT id, message_id, project_id, reporter_id, reason, details, status, resolution, resolved_by, resolved_at, created_at FROM message_reports WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetMessageReportByID(ctx context.Context, id pgtype.UUID) (MessageReport, error) {
+	row := q.db.QueryRow(ctx, getMessageReportByID, id)
+	var i MessageReport
+	err := row.Scan(
+		&i.ID,
+		&i.MessageID,
+		&i.ProjectID,
+		&i.ReporterID,
+		&i.Reason,
+		&i.Details,
+		&i.Status,
+		&i.Resolution,
+		&i.ResolvedBy,
+		&i.ResolvedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getMessages = `-- name: GetMessages :many
+SELECT 
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.seq,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.channel_id = $1 
+  AND m.parent_id IS NULL 
+ORDER BY m.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type GetMessagesParams struct {
+	ChannelID pgtype.UUID
+	Limit     int32
+	Offset    int32
+}
+
+type GetMessagesRow struct {
+	ID             int64
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	SenderID       pgtype.UUID
+	ChannelID      pgtype.UUID
+	ParentID       pgtype.Int8
+	ReplyCount     pgtype.Int4
+	Seq            pgtype.Int8
+	SenderUsername string
+	SenderAvatar   pgtype.Text
+	IsDeleted      bool
+}
+
+func (q *Queries) GetMessages(ctx context.Context, arg GetMessagesParams) ([]GetMessagesRow, error) {
+	rows, err := q.db.Query(ctx, getMessages, arg.ChannelID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMessagesRow
+	for rows.Next() {
+		var i GetMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderID,
+			&i.ChannelID,
+			&i.ParentID,
+			&i.ReplyCount,
+			&i.Seq,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+			&i.IsDeleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessagesAfter = `-- name: GetMessagesAfter :many
+SELECT
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.seq,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.channel_id = $1
+  AND m.parent_id IS NULL
+  AND m.id > $2
+  AND ($3::bigint IS NULL OR m.id < $3)
+ORDER BY m.id ASC
+LIMIT $4
+`
+
+type GetMessagesAfterParams struct {
+	ChannelID pgtype.UUID
+	After     int64
+	Before    pgtype.Int8
+	RowLimit  int32
+}
+
+type GetMessagesAfterRow struct {
+	ID             int64
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	SenderID       pgtype.UUID
+	ChannelID      pgtype.UUID
+	ParentID       pgtype.Int8
+	ReplyCount     pgtype.Int4
+	Seq            pgtype.Int8
+	SenderUsername string
+	SenderAvatar   pgtype.Text
+	IsDeleted      bool
+}
+
+// Oldest top-level messages newer than after (and older than before, when set)
+func (q *Queries) GetMessagesAfter(ctx context.Context, arg GetMessagesAfterParams) ([]GetMessagesAfterRow, error) {
+	rows, err := q.db.Query(ctx, getMessagesAfter,
+		arg.ChannelID,
+		arg.After,
+		arg.Before,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMessagesAfterRow
+	for rows.Next() {
+		var i GetMessagesAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderID,
+			&i.ChannelID,
+			&i.ParentID,
+			&i.ReplyCount,
+			&i.Seq,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+			&i.IsDeleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessagesBefore = `-- name: GetMessagesBefore :many
+SELECT
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.seq,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.channel_id = $1
+  AND m.parent_id IS NULL
+  AND m.id < $2
+  AND ($3::bigint IS NULL OR m.id > $3)
+ORDER BY m.id DESC
+LIMIT $4
+`
+
+type GetMessagesBeforeParams struct {
+	ChannelID pgtype.UUID
+	Before    int64
+	After     pgtype.Int8
+	RowLimit  int32
+}
+
+type GetMessagesBeforeRow struct {
+	ID             int64
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	SenderID       pgtype.UUID
+	ChannelID      pgtype.UUID
+	ParentID       pgtype.Int8
+	ReplyCount     pgtype.Int4
+	Seq            pgtype.Int8
+	SenderUsername string
+	SenderAvatar   pgtype.Text
+	IsDeleted      bool
+}
+
+// Newest top-level messages older than before (and newer than after, when set)
+func (q *Queries) GetMessagesBefore(ctx context.Context, arg GetMessagesBeforeParams) ([]GetMessagesBeforeRow, error) {
+	rows, err := q.db.Query(ctx, getMessagesBefore,
+		arg.ChannelID,
+		arg.Before,
+		arg.After,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMessagesBeforeRow
+	for rows.Next() {
+		var i GetMessagesBeforeRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderID,
+			&i.ChannelID,
+			&i.ParentID,
+			&i.ReplyCount,
+			&i.Seq,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+			&i.IsDeleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMessagesByProject = `-- name: GetMessagesByProject :many
+SELECT 
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.project_id = $1 
+  AND m.parent_id IS NULL
+  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+ORDER BY m.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type GetMessagesByProjectParams struct {
+	ProjectID pgtype.UUID
+	Limit     int32
+	Offset    int32
+}
+
+type GetMessagesByProjectRow struct {
+	ID             int64
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	SenderID       pgtype.UUID
+	ChannelID      pgtype.UUID
+	ParentID       pgtype.Int8
+	ReplyCount     pgtype.Int4
+	SenderUsername string
+	SenderAvatar   pgtype.Text
+}
+
+func (q *Queries) GetMessagesByProject(ctx context.Context, arg GetMessagesByProjectParams) ([]GetMessagesByProjectRow, error) {
+	rows, err := q.db.Query(ctx, getMessagesByProject, arg.ProjectID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMessagesByProjectRow
+	for rows.Next() {
+		var i GetMessagesByProjectRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderID,
+			&i.ChannelID,
+			&i.ParentID,
+			&i.ReplyCount,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMutedWords = `-- name: GetMutedWords :many
+
+SELECT word FROM user_muted_words
+WHERE user_id = $1
+ORDER BY word ASC
+`
+
+// ============================================================================
+// MUTED WORDS
+// ============================================================================
+func (q *Queries) GetMutedWords(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, getMutedWords, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, err
+		}
+		items = append(items, word)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationByID = `-- name: GetNotificationByID :one
+SELECT id, user_id, type, message_id, project_id, channel_id, actor_id, actor_username, content_preview, is_read, created_at, batch_count FROM notifications WHERE id = $1
+`
+
+func (q *Queries) GetNotificationByID(ctx context.Context, id int64) (Notification, error) {
+	row := q.db.QueryRow(ctx, getNotificationByID, id)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Type,
+		&i.MessageID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.ActorID,
+		&i.ActorUsername,
+		&i.ContentPreview,
+		&i.IsRead,
+		&i.CreatedAt,
+		&i.BatchCount,
+	)
+	return i, err
+}
+
+const getNotificationDelivery = `-- name: GetNotificationDelivery :one
+SELECT notification_id, user_id, state, pushed_at, acked_at, transport, attempts, last_error, updated_at FROM notification_deliveries WHERE notification_id = $1
+`
+
+func (q *Queries) GetNotificationDelivery(ctx context.Context, notificationID int64) (NotificationDelivery, error) {
+	row := q.db.QueryRow(ctx, getNotificationDelivery, notificationID)
+	var i NotificationDelivery
+	err := row.Scan(
+		&i.NotificationID,
+		&i.UserID,
+		&i.State,
+		&i.PushedAt,
+		&i.AckedAt,
+		&i.Transport,
+		&i.Attempts,
+		&i.LastError,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getNotificationFallback = `-- name: GetNotificationFallback :one
+SELECT notify_fallback FROM user_settings WHERE user_id = $1
+`
+
+func (q *Queries) GetNotificationFallback(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	row := q.db.QueryRow(ctx, getNotificationFallback, userID)
+	var notify_fallback []string
+	err := row.Scan(&notify_fallback)
+	return notify_fallback, err
+}
+
+const getNotificationMentions = `-- name: GetNotificationMentions :many
+SELECT mn.id, mn.message_id, m.content, m.parent_id, mn.created_at
+FROM mentions mn
+JOIN messages m ON mn.message_id = m.id
+WHERE mn.notification_id = $1 AND mn.user_id = $2
+ORDER BY mn.id ASC
+`
+
+type GetNotificationMentionsParams struct {
+	NotificationID pgtype.Int8
+	UserID         pgtype.UUID
+}
+
+type GetNotificationMentionsRow struct {
+	ID        int64
+	MessageID int64
+	Content   string
+	ParentID  pgtype.Int8
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetNotificationMentions(ctx context.Context, arg GetNotificationMentionsParams) ([]GetNotificationMentionsRow, error) {
+	rows, err := q.db.Query(ctx, getNotificationMentions, arg.NotificationID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationMentionsRow
+	for rows.Next() {
+		var i GetNotificationMentionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.Content,
+			&i.ParentID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotifications = `-- name: GetNotifications :many
+SELECT
+    n.id,
+    n.user_id,
+    n.type,
+    n.message_id,
+    n.project_id,
+    n.channel_id,
+    n.actor_id,
+    n.actor_username,
+    n.content_preview,
+    n.is_read,
+    n.created_at,
+    n.batch_count,
+    p.name AS project_name,
+    ch.name AS channel_name,
+    m.parent_id AS thread_parent_id,
+    COALESCE(d.state, 'persisted')::text AS delivery_state,
+    d.transport AS delivery_transport
+FROM notifications n
+LEFT JOIN projects p ON n.project_id = p.id
+LEFT JOIN channels ch ON n.channel_id = ch.id
+LEFT JOIN messages m ON n.message_id = m.id
+LEFT JOIN notification_deliveries d ON d.notification_id = n.id
+WHERE n.user_id = $1
+ORDER BY n.created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type GetNotificationsParams struct {
+	UserID pgtype.UUID
+	Limit  int32
+	Offset int32
+}
+
+type GetNotificationsRow struct {
+	ID                int64
+	UserID            pgtype.UUID
+	Type              string
+	MessageID         pgtype.Int8
+	ProjectID         pgtype.UUID
+	ChannelID         pgtype.UUID
+	ActorID           pgtype.UUID
+	ActorUsername     string
+	ContentPreview    pgtype.Text
+	IsRead            pgtype.Bool
+	CreatedAt         pgtype.Timestamptz
+	BatchCount        int32
+	ProjectName       pgtype.Text
+	ChannelName       pgtype.Text
+	ThreadParentID    pgtype.Int8
+	DeliveryState     string
+	DeliveryTransport pgtype.Text
+}
+
+func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]GetNotificationsRow, error) {
+	rows, err := q.db.Query(ctx, getNotifications, arg.UserID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationsRow
+	for rows.Next() {
+		var i GetNotificationsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Type,
+			&i.MessageID,
+			&i.ProjectID,
+			&i.ChannelID,
+			&i.ActorID,
+			&i.ActorUsername,
+			&i.ContentPreview,
+			&i.IsRead,
+			&i.CreatedAt,
+			&i.BatchCount,
+			&i.ProjectName,
+			&i.ChannelName,
+			&i.ThreadParentID,
+			&i.DeliveryState,
+			&i.DeliveryTransport,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOldestChannelPin = `-- name: GetOldestChannelPin :one
+SELECT id FROM messages
+WHERE channel_id = $1 AND is_pinned = TRUE AND (is_deleted = FALSE OR is_deleted IS NULL)
+ORDER BY pinned_at ASC, id ASC
+LIMIT 1
+`
+
+func (q *Queries) GetOldestChannelPin(ctx context.Context, channelID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getOldestChannelPin, channelID)
+	var id int64
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getOnboardingStep = `-- name: GetOnboardingStep :one
+SELECT id, project_id, title, description, kind, channel_id, position, created_at FROM onboarding_steps WHERE id = $1
+`
+
+func (q *Queries) GetOnboardingStep(ctx context.Context, id pgtype.UUID) (OnboardingStep, error) {
+	row := q.db.QueryRow(ctx, getOnboardingStep, id)
+	var i OnboardingStep
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Title,
+		&i.Description,
+		&i.Kind,
+		&i.ChannelID,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOpenStandupRun = `-- name: GetOpenStandupRun :one
+SELECT id, standup_id, status, started_at, closes_at, posted_at FROM standup_runs
+WHERE standup_id = $1 AND status = 'collecting'
+ORDER BY started_at DESC
+LIMIT 1
+`
+
+func (q *Queries) GetOpenStandupRun(ctx context.Context, standupID pgtype.UUID) (StandupRun, error) {
+	row := q.db.QueryRow(ctx, getOpenStandupRun, standupID)
+	var i StandupRun
+	err := row.Scan(
+		&i.ID,
+		&i.StandupID,
+		&i.Status,
+		&i.StartedAt,
+		&i.ClosesAt,
+		&i.PostedAt,
+	)
+	return i, err
+}
+
+const getOpenStandupRunsForUser = `-- name: GetOpenStandupRunsForUser :many
+SELECT r.id, r.standup_id, s.name, s.questions, r.closes_at
+FROM standup_runs r
+JOIN standups s ON r.standup_id = s.id
+JOIN standup_participants p ON p.standup_id = s.id AND p.user_id = $1
+WHERE r.status = 'collecting'
+ORDER BY r.started_at DESC
+`
+
+type GetOpenStandupRunsForUserRow struct {
+	ID        pgtype.UUID
+	StandupID pgtype.UUID
+	Name      string
+	Questions []string
+	ClosesAt  pgtype.Timestamptz
+}
+
+func (q *Queries) GetOpenStandupRunsForUser(ctx context.Context, userID pgtype.UUID) ([]GetOpenStandupRunsForUserRow, error) {
+	rows, err := q.db.Query(ctx, getOpenStandupRunsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOpenStandupRunsForUserRow
+	for rows.Next() {
+		var i GetOpenStandupRunsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.StandupID,
+			&i.Name,
+			&i.Questions,
+			&i.ClosesAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOpenTasksByChannel = `-- name: GetOpenTasksByChannel :many
+SELECT
+    t.id,
+    t.message_id,
+    t.title,
+    t.assignee_id,
+    t.due_at,
+    t.github_issue_number,
+    t.created_at,
+    a.username AS assignee_username,
+    a.avatar_url AS assignee_avatar
+FROM tasks t
+LEFT JOIN users a ON t.assignee_id = a.id
+WHERE t.channel_id = $1 AND t.status = 'open'
+ORDER BY t.due_at ASC NULLS LAST, t.created_at ASC
+`
+
+type GetOpenTasksByChannelRow struct {
+	ID                pgtype.UUID
+	MessageID         pgtype.Int8
+	Title             string
+	AssigneeID        pgtype.UUID
+	DueAt             pgtype.Timestamptz
+	GithubIssueNumber pgtype.Int4
+	CreatedAt         pgtype.Timestamptz
+	AssigneeUsername  pgtype.Text
+	AssigneeAvatar    pgtype.Text
+}
+
+func (q *Queries) GetOpenTasksByChannel(ctx context.Context, channelID pgtype.UUID) ([]GetOpenTasksByChannelRow, error) {
+	rows, err := q.db.Query(ctx, getOpenTasksByChannel, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOpenTasksByChannelRow
+	for rows.Next() {
+		var i GetOpenTasksByChannelRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.Title,
+			&i.AssigneeID,
+			&i.DueAt,
+			&i.GithubIssueNumber,
+			&i.CreatedAt,
+			&i.AssigneeUsername,
+			&i.AssigneeAvatar,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOwnerLoopActiveMembers = `-- name: GetOwnerLoopActiveMembers :many
+SELECT
+    s.project_id,
+    COUNT(DISTINCT s.user_id) FILTER (WHERE s.day > CURRENT_DATE - 7)::int AS active_7d,
+    COUNT(DISTINCT s.user_id)::int AS active_30d
+FROM loop_member_daily_stats s
+JOIN projects p ON p.id = s.project_id
+WHERE p.owner_id = $1 AND s.day > CURRENT_DATE - 30
+GROUP BY s.project_id
+`
+
+type GetOwnerLoopActiveMembersRow struct {
+	ProjectID pgtype.UUID
+	Active7d  int32
+	Active30d int32
+}
+
+// Distinct senders per owned loop over the last 7 and 30 days
+func (q *Queries) GetOwnerLoopActiveMembers(ctx context.Context, ownerID pgtype.UUID) ([]GetOwnerLoopActiveMembersRow, error) {
+	rows, err := q.db.Query(ctx, getOwnerLoopActiveMembers, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOwnerLoopActiveMembersRow
+	for rows.Next() {
+		var i GetOwnerLoopActiveMembersRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.Active7d,
+			&i.Active30d,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOwnerLoopDailyStats = `-- name: GetOwnerLoopDailyStats :many
+SELECT s.project_id, s.day, s.members, s.new_members, s.messages, s.active_members
+FROM loop_daily_stats s
+JOIN projects p ON p.id = s.project_id
+WHERE p.owner_id = $1 AND s.day >= $2
+ORDER BY s.project_id, s.day
+`
+
+type GetOwnerLoopDailyStatsParams struct {
+	OwnerID pgtype.UUID
+	Day     pgtype.Date
+}
+
+type GetOwnerLoopDailyStatsRow struct {
+	ProjectID     pgtype.UUID
+	Day           pgtype.Date
+	Members       int32
+	NewMembers    int32
+	Messages      int32
+	ActiveMembers int32
+}
+
+func (q *Queries) GetOwnerLoopDailyStats(ctx context.Context, arg GetOwnerLoopDailyStatsParams) ([]GetOwnerLoopDailyStatsRow, error) {
+	rows, err := q.db.Query(ctx, getOwnerLoopDailyStats, arg.OwnerID, arg.Day)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOwnerLoopDailyStatsRow
+	for rows.Next() {
+		var i GetOwnerLoopDailyStatsRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.Day,
+			&i.Members,
+			&i.NewMembers,
+			&i.Messages,
+			&i.ActiveMembers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOwnerLoopInactiveMembers = `-- name: GetOwnerLoopInactiveMembers :many
+SELECT
+    m.project_id,
+    COUNT(*) FILTER (WHERE COALESCE(m.last_active_at, m.joined_at) < NOW() - INTERVAL '30 days')::int AS inactive_30d,
+    COUNT(*) FILTER (WHERE COALESCE(m.last_active_at, m.joined_at) < NOW() - INTERVAL '90 days')::int AS inactive_90d
+FROM memberships m
+JOIN projects p ON p.id = m.project_id
+WHERE p.owner_id = $1
+GROUP BY m.project_id
+`
+
+type GetOwnerLoopInactiveMembersRow struct {
+	ProjectID   pgtype.UUID
+	Inactive30d int32
+	Inactive90d int32
+}
+
+// Inactive member counts per owned loop
+func (q *Queries) GetOwnerLoopInactiveMembers(ctx context.Context, ownerID pgtype.UUID) ([]GetOwnerLoopInactiveMembersRow, error) {
+	rows, err := q.db.Query(ctx, getOwnerLoopInactiveMembers, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOwnerLoopInactiveMembersRow
+	for rows.Next() {
+		var i GetOwnerLoopInactiveMembersRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.Inactive30d,
+			&i.Inactive90d,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOwnerTopContributors = `-- name: GetOwnerTopContributors :many
+SELECT t.project_id, t.user_id, u.username, u.avatar_url, t.messages
+FROM (
+    SELECT
+        s.project_id,
+        s.user_id,
+        SUM(s.messages)::int AS messages,
+        ROW_NUMBER() OVER (PARTITION BY s.project_id ORDER BY SUM(s.messages) DESC, s.user_id) AS rank
+    FROM loop_member_daily_stats s
+    JOIN projects p ON p.id = s.project_id
+    WHERE p.owner_id = $1 AND s.day >= $2
+    GROUP BY s.project_id, s.user_id
+) t
+JOIN users u ON u.id = t.user_id
+WHERE t.rank <= $3
+ORDER BY t.project_id, t.messages DESC
+`
+
+type GetOwnerTopContributorsParams struct {
+	OwnerID pgtype.UUID
+	Since   pgtype.Date
+	PerLoop int64
+}
+
+type GetOwnerTopContributorsRow struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+	Username  string
+	AvatarUrl pgtype.Text
+	Messages  int32
+}
+
+// The most active senders of each owned loop since a day
+func (q *Queries) GetOwnerTopContributors(ctx context.Context, arg GetOwnerTopContributorsParams) ([]GetOwnerTopContributorsRow, error) {
+	rows, err := q.db.Query(ctx, getOwnerTopContributors, arg.OwnerID, arg.Since, arg.PerLoop)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOwnerTopContributorsRow
+	for rows.Next() {
+		var i GetOwnerTopContributorsRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.UserID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.Messages,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPRCommentSync = `-- name: GetPRCommentSync :one
+SELECT repo_id, pr_number, synced_at, claimed_at FROM pr_comment_syncs WHERE repo_id = $1 AND pr_number = $2
+`
+
+type GetPRCommentSyncParams struct {
+	RepoID   int64
+	PrNumber int32
+}
+
+func (q *Queries) GetPRCommentSync(ctx context.Context, arg GetPRCommentSyncParams) (PrCommentSync, error) {
+	row := q.db.QueryRow(ctx, getPRCommentSync, arg.RepoID, arg.PrNumber)
+	var i PrCommentSync
+	err := row.Scan(
+		&i.RepoID,
+		&i.PrNumber,
+		&i.SyncedAt,
+		&i.ClaimedAt,
+	)
+	return i, err
+}
+
+const getPersonalAccessToken = `-- name: GetPersonalAccessToken :one
+SELECT id, user_id, name, prefix, token_hash, expires_at, last_used_at, created_at, revoked_at FROM personal_access_tokens WHERE id = $1
+`
+
+func (q *Queries) GetPersonalAccessToken(ctx context.Context, id pgtype.UUID) (PersonalAccessToken, error) {
+	row := q.db.QueryRow(ctx, getPersonalAccessToken, id)
+	var i PersonalAccessToken
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Prefix,
+		&i.TokenHash,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.CreatedAt,
+		&i.RevokedAt,
+	)
+	return i, err
+}
+
+const getPersonalAccessTokenUsage = `-- name: GetPersonalAccessTokenUsage :many
+SELECT method, path, COUNT(*)::bigint AS hits, MAX(created_at)::timestamptz AS last_hit_at
+FROM personal_access_token_requests
+WHERE token_id = $1
+GROUP BY method, path
+ORDER BY hits DESC
+`
+
+type GetPersonalAccessTokenUsageRow struct {
+	Method    string
+	Path      string
+	Hits      int64
+	LastHitAt pgtype.Timestamptz
+}
+
+// Requests per route, for the token's audit view
+func (q *Queries) GetPersonalAccessTokenUsage(ctx context.Context, tokenID pgtype.UUID) ([]GetPersonalAccessTokenUsageRow, error) {
+	rows, err := q.db.Query(ctx, getPersonalAccessTokenUsage, tokenID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPersonalAccessTokenUsageRow
+	for rows.Next() {
+		var i GetPersonalAccessTokenUsageRow
+		if err := rows.Scan(
+			&i.Method,
+			&i.Path,
+			&i.Hits,
+			&i.LastHitAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPersonalWorkspace = `-- name: GetPersonalWorkspace :one
+SELECT id, slug, name, billing_owner_id, personal, default_role, created_at FROM workspaces WHERE billing_owner_id = $1 AND personal
+`
+
+func (q *Queries) GetPersonalWorkspace(ctx context.Context, billingOwnerID pgtype.UUID) (Workspace, error) {
+	row := q.db.QueryRow(ctx, getPersonalWorkspace, billingOwnerID)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.BillingOwnerID,
+		&i.Personal,
+		&i.DefaultRole,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getPinnedMessages = `-- name: GetPinnedMessages :many
+SELECT 
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.pinned_at,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    pinner.username AS pinned_by_username
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+LEFT JOIN users pinner ON m.pinned_by = pinner.id
+WHERE m.channel_id = $1 
+  AND m.is_pinned = TRUE
+  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+ORDER BY m.pinned_at DESC
+`
+
+type GetPinnedMessagesRow struct {
+	ID               int64
+	Content          string
+	CreatedAt        pgtype.Timestamptz
+	SenderID         pgtype.UUID
+	ChannelID        pgtype.UUID
+	ParentID         pgtype.Int8
+	ReplyCount       pgtype.Int4
+	PinnedAt         pgtype.Timestamptz
+	SenderUsername   string
+	SenderAvatar     pgtype.Text
+	PinnedByUsername pgtype.Text
+}
+
+func (q *Queries) GetPinnedMessages(ctx context.Context, channelID pgtype.UUID) ([]GetPinnedMessagesRow, error) {
+	rows, err := q.db.Query(ctx, getPinnedMessages, channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPinnedMessagesRow
+	for rows.Next() {
+		var i GetPinnedMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderID,
+			&i.ChannelID,
+			&i.ParentID,
+			&i.ReplyCount,
+			&i.PinnedAt,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+			&i.PinnedByUsername,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPresencePrivacy = `-- name: GetPresencePrivacy :one
+
+SELECT send_typing, show_presence FROM user_settings WHERE user_id = $1
+`
+
+type GetPresencePrivacyRow struct {
+	SendTyping   bool
+	ShowPresence bool
+}
+
+// TYPING & PRESENCE PRIVACY
+func (q *Queries) GetPresencePrivacy(ctx context.Context, userID pgtype.UUID) (GetPresencePrivacyRow, error) {
+	row := q.db.QueryRow(ctx, getPresencePrivacy, userID)
+	var i GetPresencePrivacyRow
+	err := row.Scan(&i.SendTyping, &i.ShowPresence)
+	return i, err
+}
+
+const getProjectByID = `-- name: GetProjectByID :one
+SELECT id, github_repo_id, name, owner_id, created_at, workspace_id FROM projects WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetProjectByID(ctx context.Context, id pgtype.UUID) (Project, error) {
+	row := q.db.QueryRow(ctx, getProjectByID, id)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.GithubRepoID,
+		&i.Name,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const getProjectByName = `-- name: GetProjectByName :one
+SELECT id, github_repo_id, name, owner_id, created_at, workspace_id FROM projects WHERE name = $1 LIMIT 1
+`
+
+func (q *Queries) GetProjectByName(ctx context.Context, name string) (Project, error) {
+	row := q.db.QueryRow(ctx, getProjectByName, name)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.GithubRepoID,
+		&i.Name,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const getProjectByOwnerAndName = `-- name: GetProjectByOwnerAndName :one
+SELECT id, github_repo_id, name, owner_id, created_at, workspace_id FROM projects
+WHERE owner_id = $1 AND name = $2
+LIMIT 1
+`
+
+type GetProjectByOwnerAndNameParams struct {
+	OwnerID pgtype.UUID
+	Name    string
+}
+
+func (q *Queries) GetProjectByOwnerAndName(ctx context.Context, arg GetProjectByOwnerAndNameParams) (Project, error) {
+	row := q.db.QueryRow(ctx, getProjectByOwnerAndName, arg.OwnerID, arg.Name)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.GithubRepoID,
+		&i.Name,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const getProjectChannelDataPolicies = `-- name: GetProjectChannelDataPolicies :many
+SELECT channel_id, project_id, ai_summaries, exports, updated_by, updated_at FROM channel_data_policies WHERE project_id = $1
+`
+
+func (q *Queries) GetProjectChannelDataPolicies(ctx context.Context, projectID pgtype.UUID) ([]ChannelDataPolicy, error) {
+	rows, err := q.db.Query(ctx, getProjectChannelDataPolicies, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChannelDataPolicy
+	for rows.Next() {
+		var i ChannelDataPolicy
+		if err := rows.Scan(
+			&i.ChannelID,
+			&i.ProjectID,
+			&i.AiSummaries,
+			&i.Exports,
+			&i.UpdatedBy,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProjectChannelGithubLinks = `-- name: GetProjectChannelGithubLinks :many
+SELECT channel_id, project_id, github_number, kind, cross_post, linked_by, archived_at, created_at FROM channel_github_links WHERE project_id = $1
+`
+
+func (q *Queries) GetProjectChannelGithubLinks(ctx context.Context, projectID pgtype.UUID) ([]ChannelGithubLink, error) {
+	rows, err := q.db.Query(ctx, getProjectChannelGithubLinks, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChannelGithubLink
+	for rows.Next() {
+		var i ChannelGithubLink
+		if err := rows.Scan(
+			&i.ChannelID,
+			&i.ProjectID,
+			&i.GithubNumber,
+			&i.Kind,
+			&i.CrossPost,
+			&i.LinkedBy,
+			&i.ArchivedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProjectChannelTopics = `-- name: GetProjectChannelTopics :many
+SELECT channel_id, project_id, topic, topic_set_by, topic_set_at, banner, banner_set_by, banner_set_at, banner_expires_at FROM channel_topics WHERE project_id = $1
+`
+
+func (q *Queries) GetProjectChannelTopics(ctx context.Context, projectID pgtype.UUID) ([]ChannelTopic, error) {
+	rows, err := q.db.Query(ctx, getProjectChannelTopics, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChannelTopic
+	for rows.Next() {
+		var i ChannelTopic
+		if err := rows.Scan(
+			&i.ChannelID,
+			&i.ProjectID,
+			&i.Topic,
+			&i.TopicSetBy,
+			&i.TopicSetAt,
+			&i.Banner,
+			&i.BannerSetBy,
+			&i.BannerSetAt,
+			&i.BannerExpiresAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProjectsByGithubRepoID = `-- name: GetProjectsByGithubRepoID :many
+
+SELECT id, github_repo_id, name, owner_id, created_at, workspace_id FROM projects WHERE github_repo_id = $1
+`
+
+// ============================================================================
+// GITHUB WEBHOOKS
+// ============================================================================
+func (q *Queries) GetProjectsByGithubRepoID(ctx context.Context, githubRepoID int64) ([]Project, error) {
+	rows, err := q.db.Query(ctx, getProjectsByGithubRepoID, githubRepoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.GithubRepoID,
+			&i.Name,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProjectsByOwner = `-- name: GetProjectsByOwner :many
+SELECT id, github_repo_id, name, owner_id, created_at, workspace_id
+FROM projects
+WHERE owner_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetProjectsByOwner(ctx context.Context, ownerID pgtype.UUID) ([]Project, error) {
+	rows, err := q.db.Query(ctx, getProjectsByOwner, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.GithubRepoID,
+			&i.Name,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPublicProfile = `-- name: GetPublicProfile :one
+SELECT
+id,
+username,
+avatar_url,
+display_name,
+created_at
+FROM users WHERE username = $1 LIMIT 1
+`
+
+type GetPublicProfileRow struct {
+	ID          pgtype.UUID
+	Username    string
+	AvatarUrl   pgtype.Text
+	DisplayName pgtype.Text
+	CreatedAt   pgtype.Timestamptz
+}
+
+func (q *Queries) GetPublicProfile(ctx context.Context, username string) (GetPublicProfileRow, error) {
+	row := q.db.QueryRow(ctx, getPublicProfile, username)
+	var i GetPublicProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.DisplayName,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReactionSummaries = `-- name: GetReactionSummaries :many
+SELECT
+    message_id,
+    emoji,
+    COUNT(*)::int AS count,
+    BOOL_OR(user_id = $1) AS reacted
+FROM message_reactions
+WHERE message_id = ANY($2::bigint[])
+GROUP BY message_id, emoji
+ORDER BY message_id, MIN(created_at)
+`
+
+type GetReactionSummariesParams struct {
+	ViewerID   pgtype.UUID
+	MessageIds []int64
+}
+
+type GetReactionSummariesRow struct {
+	MessageID int64
+	Emoji     string
+	Count     int32
+	Reacted   pgtype.Bool
+}
+
+// Per-emoji counts for a page of messages, in the order each emoji was
+// first used, and whether the viewer is among the reactors
+func (q *Queries) GetReactionSummaries(ctx context.Context, arg GetReactionSummariesParams) ([]GetReactionSummariesRow, error) {
+	rows, err := q.db.Query(ctx, getReactionSummaries, arg.ViewerID, arg.MessageIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReactionSummariesRow
+	for rows.Next() {
+		var i GetReactionSummariesRow
+		if err := rows.Scan(
+			&i.MessageID,
+			&i.Emoji,
+			&i.Count,
+			&i.Reacted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentReactionEmoji = `-- name: GetRecentReactionEmoji :many
+SELECT r.emoji, COUNT(*)::int AS uses
+FROM message_reactions r
+JOIN messages m ON m.id = r.message_id
+WHERE r.user_id = $1 AND m.project_id = $2 AND r.created_at > NOW() - INTERVAL '30 days'
+GROUP BY r.emoji
+ORDER BY uses DESC, MAX(r.created_at) DESC
+LIMIT 50
+`
+
+type GetRecentReactionEmojiParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+type GetRecentReactionEmojiRow struct {
+	Emoji string
+	Uses  int32
+}
+
+// The emoji a user reacted with in a loop over the last 30 days, most used first
+func (q *Queries) GetRecentReactionEmoji(ctx context.Context, arg GetRecentReactionEmojiParams) ([]GetRecentReactionEmojiRow, error) {
+	rows, err := q.db.Query(ctx, getRecentReactionEmoji, arg.UserID, arg.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRecentReactionEmojiRow
+	for rows.Next() {
+		var i GetRecentReactionEmojiRow
+		if err := rows.Scan(
+			&i.Emoji,
+			&i.Uses,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentUnreadNotification = `-- name: GetRecentUnreadNotification :one
+SELECT id, batch_count FROM notifications
+WHERE user_id = $1 AND actor_id = $2 AND channel_id = $3 AND type = $4
+  AND is_read = FALSE AND created_at > $5
+ORDER BY created_at DESC
+LIMIT 1
+`
+
+type GetRecentUnreadNotificationParams struct {
+	UserID    pgtype.UUID
+	ActorID   pgtype.UUID
+	ChannelID pgtype.UUID
+	Type      string
+	CreatedAt pgtype.Timestamptz
+}
+
+type GetRecentUnreadNotificationRow struct {
+	ID         int64
+	BatchCount int32
+}
+
+// Finds an open batch to fold a new notification into
+func (q *Queries) GetRecentUnreadNotification(ctx context.Context, arg GetRecentUnreadNotificationParams) (GetRecentUnreadNotificationRow, error) {
+	row := q.db.QueryRow(ctx, getRecentUnreadNotification,
+		arg.UserID,
+		arg.ActorID,
+		arg.ChannelID,
+		arg.Type,
+		arg.CreatedAt,
+	)
+	var i GetRecentUnreadNotificationRow
+	err := row.Scan(
+		&i.ID,
+		&i.BatchCount,
+	)
+	return i, err
+}
+
+const getRepoMembershipsByGithubID = `-- name: GetRepoMembershipsByGithubID :many
+
+SELECT m.user_id, m.project_id, m.role, u.username, p.name AS project_name, p.owner_id,
+       COALESCE(ls.access_revoked, 'notify')::text AS access_revoked
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+JOIN projects p ON p.id = m.project_id
+LEFT JOIN loop_settings ls ON ls.project_id = p.id
+WHERE p.github_repo_id = $1 AND u.github_id = $2
+`
+
+type GetRepoMembershipsByGithubIDParams struct {
+	GithubRepoID int64
+	GithubID     int64
+}
+
+type GetRepoMembershipsByGithubIDRow struct {
+	UserID        pgtype.UUID
+	ProjectID     pgtype.UUID
+	Role          pgtype.Text
+	Username      string
+	ProjectName   string
+	OwnerID       pgtype.UUID
+	AccessRevoked string
+}
+
+// ============================================================================
+// LOST REPO ACCESS
+// ============================================================================
+// The memberships of a GitHub user in the loops linked to a repo
+func (q *Queries) GetRepoMembershipsByGithubID(ctx context.Context, arg GetRepoMembershipsByGithubIDParams) ([]GetRepoMembershipsByGithubIDRow, error) {
+	rows, err := q.db.Query(ctx, getRepoMembershipsByGithubID, arg.GithubRepoID, arg.GithubID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRepoMembershipsByGithubIDRow
+	for rows.Next() {
+		var i GetRepoMembershipsByGithubIDRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.ProjectID,
+			&i.Role,
+			&i.Username,
+			&i.ProjectName,
+			&i.OwnerID,
+			&i.AccessRevoked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRepoStatsByName = `-- name: GetRepoStatsByName :one
+SELECT repo_id, full_name, stars, forks, contributors, pushed_at, refreshed_at, checked_at FROM repo_stats
+WHERE LOWER(full_name) = LOWER($1) AND refreshed_at IS NOT NULL
+LIMIT 1
+`
+
+func (q *Queries) GetRepoStatsByName(ctx context.Context, fullName string) (RepoStat, error) {
+	row := q.db.QueryRow(ctx, getRepoStatsByName, fullName)
+	var i RepoStat
+	err := row.Scan(
+		&i.RepoID,
+		&i.FullName,
+		&i.Stars,
+		&i.Forks,
+		&i.Contributors,
+		&i.PushedAt,
+		&i.RefreshedAt,
+		&i.CheckedAt,
+	)
+	return i, err
+}
+
+const getRepoWebhookDeliveries = `-- name: GetRepoWebhookDeliveries :many
+SELECT id, delivery_id, event, action, repo_id, payload, status, error, attempts, received_at, last_attempt_at FROM webhook_deliveries
+WHERE repo_id = $1
+  AND (NOT $2::bool OR status = 'failed')
+ORDER BY last_attempt_at DESC
+LIMIT $3
+`
+
+type GetRepoWebhookDeliveriesParams struct {
+	RepoID     int64
+	FailedOnly bool
+	RowLimit   int32
+}
+
+func (q *Queries) GetRepoWebhookDeliveries(ctx context.Context, arg GetRepoWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, getRepoWebhookDeliveries, arg.RepoID, arg.FailedOnly, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDelivery
+	for rows.Next() {
+		var i WebhookDelivery
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.Event,
+			&i.Action,
+			&i.RepoID,
+			&i.Payload,
+			&i.Status,
+			&i.Error,
+			&i.Attempts,
+			&i.ReceivedAt,
+			&i.LastAttemptAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRepoWebhookStats = `-- name: GetRepoWebhookStats :one
+SELECT
+    COUNT(*)::int AS deliveries,
+    COUNT(*) FILTER (WHERE status = 'failed')::int AS failed,
+    COALESCE(SUM(attempts - 1), 0)::int AS retries,
+    MAX(last_attempt_at)::timestamptz AS last_delivery_at,
+    MAX(last_attempt_at) FILTER (WHERE status = 'failed')::timestamptz AS last_failure_at
+FROM webhook_deliveries
+WHERE repo_id = $1 AND last_attempt_at >= $2
+`
+
+type GetRepoWebhookStatsParams struct {
+	RepoID int64
+	Since  pgtype.Timestamptz
+}
+
+type GetRepoWebhookStatsRow struct {
+	Deliveries     int32
+	Failed         int32
+	Retries        int32
+	LastDeliveryAt pgtype.Timestamptz
+	LastFailureAt  pgtype.Timestamptz
+}
+
+func (q *Queries) GetRepoWebhookStats(ctx context.Context, arg GetRepoWebhookStatsParams) (GetRepoWebhookStatsRow, error) {
+	row := q.db.QueryRow(ctx, getRepoWebhookStats, arg.RepoID, arg.Since)
+	var i GetRepoWebhookStatsRow
+	err := row.Scan(
+		&i.Deliveries,
+		&i.Failed,
+		&i.Retries,
+		&i.LastDeliveryAt,
+		&i.LastFailureAt,
+	)
+	return i, err
+}
+
+const getRulesByProject = `-- name: GetRulesByProject :many
+SELECT id, project_id, criteria_type, threshold, created_at FROM rules
+WHERE project_id = $1
+ORDER BY created_at ASC
+`
+
+func (q *Queries) GetRulesByProject(ctx context.Context, projectID pgtype.UUID) ([]Rule, error) {
+	rows, err := q.db.Query(ctx, getRulesByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Rule
+	for rows.Next() {
+		var i Rule
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.CriteriaType,
+			&i.Threshold,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStandupByID = `-- name: GetStandupByID :one
+SELECT id, project_id, channel_id, name, questions, prompt_time, timezone, weekdays, collect_minutes, enabled, created_by, created_at, updated_at FROM standups WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetStandupByID(ctx context.Context, id pgtype.UUID) (Standup, error) {
+	row := q.db.QueryRow(ctx, getStandupByID, id)
+	var i Standup
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.Name,
+		&i.Questions,
+		&i.PromptTime,
+		&i.Timezone,
+		&i.Weekdays,
+		&i.CollectMinutes,
+		&i.Enabled,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getStandupParticipants = `-- name: GetStandupParticipants :many
+SELECT p.user_id, u.username, u.avatar_url
+FROM standup_participants p
+JOIN users u ON p.user_id = u.id
+WHERE p.standup_id = $1
+ORDER BY u.username ASC
+`
+
+type GetStandupParticipantsRow struct {
+	UserID    pgtype.UUID
+	Username  string
+	AvatarUrl pgtype.Text
+}
+
+func (q *Queries) GetStandupParticipants(ctx context.Context, standupID pgtype.UUID) ([]GetStandupParticipantsRow, error) {
+	rows, err := q.db.Query(ctx, getStandupParticipants, standupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStandupParticipantsRow
+	for rows.Next() {
+		var i GetStandupParticipantsRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.AvatarUrl,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStandupResponses = `-- name: GetStandupResponses :many
+SELECT r.user_id, u.username, r.answers, r.created_at
+FROM standup_responses r
+JOIN users u ON r.user_id = u.id
+WHERE r.run_id = $1
+ORDER BY r.created_at ASC
+`
+
+type GetStandupResponsesRow struct {
+	UserID    pgtype.UUID
+	Username  string
+	Answers   []string
+	CreatedAt pgtype.Timestamptz
+}
+
+func (q *Queries) GetStandupResponses(ctx context.Context, runID pgtype.UUID) ([]GetStandupResponsesRow, error) {
+	rows, err := q.db.Query(ctx, getStandupResponses, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetStandupResponsesRow
+	for rows.Next() {
+		var i GetStandupResponsesRow
+		if err := rows.Scan(
+			&i.UserID,
+			&i.Username,
+			&i.Answers,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getStandupRunByID = `-- name: GetStandupRunByID :one
+SELECT id, standup_id, status, started_at, closes_at, posted_at FROM standup_runs WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetStandupRunByID(ctx context.Context, id pgtype.UUID) (StandupRun, error) {
+	row := q.db.QueryRow(ctx, getStandupRunByID, id)
+	var i StandupRun
+	err := row.Scan(
+		&i.ID,
+		&i.StandupID,
+		&i.Status,
+		&i.StartedAt,
+		&i.ClosesAt,
+		&i.PostedAt,
+	)
+	return i, err
+}
+
+const getStandupsByProject = `-- name: GetStandupsByProject :many
+SELECT id, project_id, channel_id, name, questions, prompt_time, timezone, weekdays, collect_minutes, enabled, created_by, created_at, updated_at FROM standups WHERE project_id = $1 ORDER BY created_at ASC
+`
+
+func (q *Queries) GetStandupsByProject(ctx context.Context, projectID pgtype.UUID) ([]Standup, error) {
+	rows, err := q.db.Query(ctx, getStandupsByProject, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Standup
+	for rows.Next() {
+		var i Standup
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.ChannelID,
+			&i.Name,
+			&i.Questions,
+			&i.PromptTime,
+			&i.Timezone,
+			&i.Weekdays,
+			&i.CollectMinutes,
+			&i.Enabled,
+			&i.CreatedBy,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTaskByID = `-- name: GetTaskByID :one
+SELECT id, project_id, channel_id, message_id, title, assignee_id, due_at, status, github_issue_number, created_by, completed_at, created_at FROM tasks WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetTaskByID(ctx context.Context, id pgtype.UUID) (Task, error) {
+	row := q.db.QueryRow(ctx, getTaskByID, id)
+	var i Task
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.MessageID,
+		&i.Title,
+		&i.AssigneeID,
+		&i.DueAt,
+		&i.Status,
+		&i.GithubIssueNumber,
+		&i.CreatedBy,
+		&i.CompletedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getThreadReplies = `-- name: GetThreadReplies :many
+SELECT 
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.parent_id = $1 
+ORDER BY m.created_at ASC
+LIMIT $2 OFFSET $3
+`
+
+type GetThreadRepliesParams struct {
+	ParentID pgtype.Int8
+	Limit    int32
+	Offset   int32
+}
+
+type GetThreadRepliesRow struct {
+	ID             int64
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	SenderID       pgtype.UUID
+	ChannelID      pgtype.UUID
+	ParentID       pgtype.Int8
+	SenderUsername string
+	SenderAvatar   pgtype.Text
+	IsDeleted      bool
+}
+
+func (q *Queries) GetThreadReplies(ctx context.Context, arg GetThreadRepliesParams) ([]GetThreadRepliesRow, error) {
+	rows, err := q.db.Query(ctx, getThreadReplies, arg.ParentID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetThreadRepliesRow
+	for rows.Next() {
+		var i GetThreadRepliesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.SenderID,
+			&i.ChannelID,
+			&i.ParentID,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+			&i.IsDeleted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getThreadSummary = `-- name: GetThreadSummary :one
+
+SELECT message_id, summary, replies, last_reply_id, updated_at FROM thread_summaries WHERE message_id = $1
+`
+
+// ============================================================================
+// THREAD SUMMARIES
+// ============================================================================
+func (q *Queries) GetThreadSummary(ctx context.Context, messageID int64) (ThreadSummary, error) {
+	row := q.db.QueryRow(ctx, getThreadSummary, messageID)
+	var i ThreadSummary
+	err := row.Scan(
+		&i.MessageID,
+		&i.Summary,
+		&i.Replies,
+		&i.LastReplyID,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getTopReactedMessages = `-- name: GetTopReactedMessages :many
+
+SELECT m.id, m.channel_id, c.name AS channel_name, m.content, u.username AS sender_username, m.created_at,
+    COUNT(*)::int AS reactions
+FROM messages m
+JOIN message_reactions r ON r.message_id = m.id
+JOIN channels c ON c.id = m.channel_id
+JOIN users u ON u.id = m.sender_id
+WHERE m.project_id = $1
+  AND m.created_at >= $2
+  AND COALESCE(m.is_deleted, FALSE) = FALSE
+GROUP BY m.id, c.name, u.username
+ORDER BY reactions DESC, m.id
+LIMIT $3
+`
+
+type GetTopReactedMessagesParams struct {
+	ProjectID pgtype.UUID
+	Since     pgtype.Timestamptz
+	RowLimit  int32
+}
+
+type GetTopReactedMessagesRow struct {
+	ID             int64
+	ChannelID      pgtype.UUID
+	ChannelName    string
+	Content        string
+	SenderUsername string
+	CreatedAt      pgtype.Timestamptz
+	Reactions      int32
+}
+
+// TOP MESSAGES DIGEST
+// A loop's messages since a time with the most reactions
+func (q *Queries) GetTopReactedMessages(ctx context.Context, arg GetTopReactedMessagesParams) ([]GetTopReactedMessagesRow, error) {
+	rows, err := q.db.Query(ctx, getTopReactedMessages, arg.ProjectID, arg.Since, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopReactedMessagesRow
+	for rows.Next() {
+		var i GetTopReactedMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChannelID,
+			&i.ChannelName,
+			&i.Content,
+			&i.SenderUsername,
+			&i.CreatedAt,
+			&i.Reactions,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTopRepliedMessages = `-- name: GetTopRepliedMessages :many
+SELECT m.id, m.channel_id, c.name AS channel_name, m.content, u.username AS sender_username, m.created_at,
+    COALESCE(m.reply_count, 0)::int AS reply_count
+FROM messages m
+JOIN channels c ON c.id = m.channel_id
+JOIN users u ON u.id = m.sender_id
+WHERE m.project_id = $1
+  AND m.created_at >= $2
+  AND m.reply_count > 0
+  AND COALESCE(m.is_deleted, FALSE) = FALSE
+ORDER BY m.reply_count DESC, m.id
+LIMIT $3
+`
+
+type GetTopRepliedMessagesParams struct {
+	ProjectID pgtype.UUID
+	Since     pgtype.Timestamptz
+	RowLimit  int32
+}
+
+type GetTopRepliedMessagesRow struct {
+	ID             int64
+	ChannelID      pgtype.UUID
+	ChannelName    string
+	Content        string
+	SenderUsername string
+	CreatedAt      pgtype.Timestamptz
+	ReplyCount     int32
+}
+
+// A loop's messages since a time with the most thread replies
+func (q *Queries) GetTopRepliedMessages(ctx context.Context, arg GetTopRepliedMessagesParams) ([]GetTopRepliedMessagesRow, error) {
+	rows, err := q.db.Query(ctx, getTopRepliedMessages, arg.ProjectID, arg.Since, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTopRepliedMessagesRow
+	for rows.Next() {
+		var i GetTopRepliedMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChannelID,
+			&i.ChannelName,
+			&i.Content,
+			&i.SenderUsername,
+			&i.CreatedAt,
+			&i.ReplyCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUndoSendSeconds = `-- name: GetUndoSendSeconds :one
+
+SELECT undo_send_seconds FROM user_settings WHERE user_id = $1
+`
+
+// ============================================================================
+// UNDO SEND
+// ============================================================================
+func (q *Queries) GetUndoSendSeconds(ctx context.Context, userID pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, getUndoSendSeconds, userID)
+	var undo_send_seconds int32
+	err := row.Scan(&undo_send_seconds)
+	return undo_send_seconds, err
+}
+
+const getUnreadMentionCount = `-- name: GetUnreadMentionCount :one
+SELECT COUNT(*) FROM mentions
+WHERE user_id = $1 AND is_read = FALSE
+`
+
+func (q *Queries) GetUnreadMentionCount(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getUnreadMentionCount, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getUnreadNotificationCount = `-- name: GetUnreadNotificationCount :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND is_read = FALSE
+`
+
+func (q *Queries) GetUnreadNotificationCount(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, getUnreadNotificationCount, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getUserByGithubID = `-- name: GetUserByGithubID :one
+SELECT id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at FROM users WHERE github_id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByGithubID(ctx context.Context, githubID int64) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByGithubID, githubID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.GithubID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.DisplayName,
+		&i.AccessToken,
+		&i.ProfileCompleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
+	)
+	return i, err
+}
+
+const getUserByID = `-- name: GetUserByID :one
+SELECT id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at FROM users WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByID, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.GithubID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.DisplayName,
+		&i.AccessToken,
+		&i.ProfileCompleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
+	)
+	return i, err
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at FROM users WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (User, error) {
+	row := q.db.QueryRow(ctx, getUserByUsername, username)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.GithubID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.DisplayName,
+		&i.AccessToken,
+		&i.ProfileCompleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
+	)
+	return i, err
+}
+
+const getUserByUsername2 = `-- name: GetUserByUsername2 :one
+SELECT id FROM users WHERE username = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByUsername2(ctx context.Context, username string) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, getUserByUsername2, username)
+	var id pgtype.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
+const getUserEngagementStats = `-- name: GetUserEngagementStats :many
+WITH recent AS (
+    SELECT id, username, avatar_url, github_id, profile_completed, created_at
+    FROM users
+    ORDER BY created_at DESC
+    LIMIT $1
+),
+loop_counts AS (
+    SELECT user_id, COUNT(*) AS loop_count
+    FROM memberships
+    WHERE user_id IN (SELECT id FROM recent)
+    GROUP BY user_id
+),
+message_counts AS (
+    SELECT sender_id, COUNT(*) AS message_count
+    FROM messages
+    WHERE sender_id IN (SELECT id FROM recent)
+    GROUP BY sender_id
+)
+SELECT
+    r.id,
+    r.username,
+    r.avatar_url,
+    r.github_id,
+    r.profile_completed,
+    r.created_at,
+    COALESCE(lc.loop_count, 0)::bigint AS loop_count,
+    COALESCE(mc.message_count, 0)::bigint AS message_count
+FROM recent r
+LEFT JOIN loop_counts lc ON lc.user_id = r.id
+LEFT JOIN message_counts mc ON mc.sender_id = r.id
+ORDER BY r.created_at DESC
+`
+
+type GetUserEngagementStatsRow struct {
+	ID               pgtype.UUID
+	Username         string
+	AvatarUrl        pgtype.Text
+	GithubID         int64
+	ProfileCompleted pgtype.Bool
+	CreatedAt        pgtype.Timestamptz
+	LoopCount        int64
+	MessageCount     int64
+}
+
+func (q *Queries) GetUserEngagementStats(ctx context.Context, limit int32) ([]GetUserEngagementStatsRow, error) {
+	rows, err := q.db.Query(ctx, getUserEngagementStats, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserEngagementStatsRow
+	for rows.Next() {
+		var i GetUserEngagementStatsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.GithubID,
+			&i.ProfileCompleted,
+			&i.CreatedAt,
+			&i.LoopCount,
+			&i.MessageCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserGithubItemSubscriptions = `-- name: GetUserGithubItemSubscriptions :many
+SELECT project_id, user_id, github_number, kind, channel_id, created_at FROM github_item_subscriptions
+WHERE project_id = $1 AND user_id = $2
+ORDER BY github_number DESC
+`
+
+type GetUserGithubItemSubscriptionsParams struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+}
+
+func (q *Queries) GetUserGithubItemSubscriptions(ctx context.Context, arg GetUserGithubItemSubscriptionsParams) ([]GithubItemSubscription, error) {
+	rows, err := q.db.Query(ctx, getUserGithubItemSubscriptions, arg.ProjectID, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GithubItemSubscription
+	for rows.Next() {
+		var i GithubItemSubscription
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.UserID,
+			&i.GithubNumber,
+			&i.Kind,
+			&i.ChannelID,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserImpersonationSessions = `-- name: GetUserImpersonationSessions :many
+SELECT s.id, s.admin, s.reason, s.expires_at, s.created_at,
+    COUNT(r.id) AS request_count
+FROM impersonation_sessions s
+LEFT JOIN impersonation_requests r ON r.session_id = s.id
+WHERE s.user_id = $1
+GROUP BY s.id
+ORDER BY s.created_at DESC
+LIMIT 100
+`
+
+type GetUserImpersonationSessionsRow struct {
+	ID           pgtype.UUID
+	Admin        string
+	Reason       string
+	ExpiresAt    pgtype.Timestamptz
+	CreatedAt    pgtype.Timestamptz
+	RequestCount int64
+}
+
+func (q *Queries) GetUserImpersonationSessions(ctx context.Context, userID pgtype.UUID) ([]GetUserImpersonationSessionsRow, error) {
+	rows, err := q.db.Query(ctx, getUserImpersonationSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserImpersonationSessionsRow
+	for rows.Next() {
+		var i GetUserImpersonationSessionsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Admin,
+			&i.Reason,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+			&i.RequestCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserLocale = `-- name: GetUserLocale :one
+
+SELECT locale FROM user_settings WHERE user_id = $1
+`
+
+// LOCALE
+func (q *Queries) GetUserLocale(ctx context.Context, userID pgtype.UUID) (pgtype.Text, error) {
+	row := q.db.QueryRow(ctx, getUserLocale, userID)
+	var locale pgtype.Text
+	err := row.Scan(&locale)
+	return locale, err
+}
+
+const getUserMemberships = `-- name: GetUserMemberships :many
+SELECT 
+    p.id AS project_id,
+    p.name AS project_name,
     mem.role,
-    mem.joined_at
+    mem.joined_at,
+    mem.is_favorite,
+    mem.sort_order,
+    mem.sidebar_collapsed,
+    p.workspace_id
+FROM memberships mem
+JOIN projects p ON mem.project_id = p.id
+WHERE mem.user_id = $1
+ORDER BY mem.is_favorite DESC, mem.sort_order ASC NULLS LAST, mem.joined_at DESC
+`
+
+type GetUserMembershipsRow struct {
+	ProjectID        pgtype.UUID
+	ProjectName      string
+	Role             pgtype.Text
+	JoinedAt         pgtype.Timestamptz
+	IsFavorite       bool
+	SortOrder        pgtype.Int4
+	SidebarCollapsed bool
+	WorkspaceID      pgtype.UUID
+}
+
+func (q *Queries) GetUserMemberships(ctx context.Context, userID pgtype.UUID) ([]GetUserMembershipsRow, error) {
+	rows, err := q.db.Query(ctx, getUserMemberships, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserMembershipsRow
+	for rows.Next() {
+		var i GetUserMembershipsRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.ProjectName,
+			&i.Role,
+			&i.JoinedAt,
+			&i.IsFavorite,
+			&i.SortOrder,
+			&i.SidebarCollapsed,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserProfile = `-- name: GetUserProfile :one
+SELECT
+id,
+github_id,
+username,
+avatar_url,
+display_name,
+profile_completed,
+created_at
+FROM users WHERE id = $1 LIMIT 1
+`
+
+type GetUserProfileRow struct {
+	ID               pgtype.UUID
+	GithubID         int64
+	Username         string
+	AvatarUrl        pgtype.Text
+	DisplayName      pgtype.Text
+	ProfileCompleted pgtype.Bool
+	CreatedAt        pgtype.Timestamptz
+}
+
+func (q *Queries) GetUserProfile(ctx context.Context, id pgtype.UUID) (GetUserProfileRow, error) {
+	row := q.db.QueryRow(ctx, getUserProfile, id)
+	var i GetUserProfileRow
+	err := row.Scan(
+		&i.ID,
+		&i.GithubID,
+		&i.Username,
+		&i.AvatarUrl,
+		&i.DisplayName,
+		&i.ProfileCompleted,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getUserTimezone = `-- name: GetUserTimezone :one
+
+SELECT timezone FROM user_settings WHERE user_id = $1
+`
+
+// TIMEZONE
+func (q *Queries) GetUserTimezone(ctx context.Context, userID pgtype.UUID) (pgtype.Text, error) {
+	row := q.db.QueryRow(ctx, getUserTimezone, userID)
+	var timezone pgtype.Text
+	err := row.Scan(&timezone)
+	return timezone, err
+}
+
+const getUserWorkspaces = `-- name: GetUserWorkspaces :many
+SELECT w.id, w.slug, w.name, w.billing_owner_id, w.personal, w.default_role, w.created_at, wm.role
+FROM workspace_members wm
+JOIN workspaces w ON w.id = wm.workspace_id
+WHERE wm.user_id = $1
+ORDER BY w.personal DESC, w.name
+`
+
+type GetUserWorkspacesRow struct {
+	ID             pgtype.UUID
+	Slug           string
+	Name           string
+	BillingOwnerID pgtype.UUID
+	Personal       bool
+	DefaultRole    string
+	CreatedAt      pgtype.Timestamptz
+	Role           string
+}
+
+func (q *Queries) GetUserWorkspaces(ctx context.Context, userID pgtype.UUID) ([]GetUserWorkspacesRow, error) {
+	rows, err := q.db.Query(ctx, getUserWorkspaces, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUserWorkspacesRow
+	for rows.Next() {
+		var i GetUserWorkspacesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Slug,
+			&i.Name,
+			&i.BillingOwnerID,
+			&i.Personal,
+			&i.DefaultRole,
+			&i.CreatedAt,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUsernameRedirect = `-- name: GetUsernameRedirect :one
+SELECT user_id FROM username_history WHERE old_username = $1
+`
+
+func (q *Queries) GetUsernameRedirect(ctx context.Context, oldUsername string) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, getUsernameRedirect, oldUsername)
+	var user_id pgtype.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const getVerificationBaseline = `-- name: GetVerificationBaseline :one
+SELECT id, user_id, project_id, passed, results, source, checked_at FROM loop_verification_attempts
+WHERE user_id = $1 AND project_id = $2 AND checked_at < $3
+ORDER BY checked_at DESC
+LIMIT 1
+`
+
+type GetVerificationBaselineParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+	CheckedAt pgtype.Timestamptz
+}
+
+// The latest attempt checked before $3, to measure progress against
+func (q *Queries) GetVerificationBaseline(ctx context.Context, arg GetVerificationBaselineParams) (LoopVerificationAttempt, error) {
+	row := q.db.QueryRow(ctx, getVerificationBaseline, arg.UserID, arg.ProjectID, arg.CheckedAt)
+	var i LoopVerificationAttempt
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ProjectID,
+		&i.Passed,
+		&i.Results,
+		&i.Source,
+		&i.CheckedAt,
+	)
+	return i, err
+}
+
+const getWebhookDelivery = `-- name: GetWebhookDelivery :one
+SELECT id, delivery_id, event, action, repo_id, payload, status, error, attempts, received_at, last_attempt_at FROM webhook_deliveries WHERE id = $1
+`
+
+func (q *Queries) GetWebhookDelivery(ctx context.Context, id int64) (WebhookDelivery, error) {
+	row := q.db.QueryRow(ctx, getWebhookDelivery, id)
+	var i WebhookDelivery
+	err := row.Scan(
+		&i.ID,
+		&i.DeliveryID,
+		&i.Event,
+		&i.Action,
+		&i.RepoID,
+		&i.Payload,
+		&i.Status,
+		&i.Error,
+		&i.Attempts,
+		&i.ReceivedAt,
+		&i.LastAttemptAt,
+	)
+	return i, err
+}
+
+const getWorkspaceByID = `-- name: GetWorkspaceByID :one
+SELECT id, slug, name, billing_owner_id, personal, default_role, created_at FROM workspaces WHERE id = $1
+`
+
+func (q *Queries) GetWorkspaceByID(ctx context.Context, id pgtype.UUID) (Workspace, error) {
+	row := q.db.QueryRow(ctx, getWorkspaceByID, id)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.BillingOwnerID,
+		&i.Personal,
+		&i.DefaultRole,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWorkspaceBySlug = `-- name: GetWorkspaceBySlug :one
+SELECT id, slug, name, billing_owner_id, personal, default_role, created_at FROM workspaces WHERE slug = $1
+`
+
+func (q *Queries) GetWorkspaceBySlug(ctx context.Context, slug string) (Workspace, error) {
+	row := q.db.QueryRow(ctx, getWorkspaceBySlug, slug)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.BillingOwnerID,
+		&i.Personal,
+		&i.DefaultRole,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getWorkspaceMember = `-- name: GetWorkspaceMember :one
+SELECT workspace_id, user_id, role, joined_at FROM workspace_members WHERE workspace_id = $1 AND user_id = $2
+`
+
+type GetWorkspaceMemberParams struct {
+	WorkspaceID pgtype.UUID
+	UserID      pgtype.UUID
+}
+
+func (q *Queries) GetWorkspaceMember(ctx context.Context, arg GetWorkspaceMemberParams) (WorkspaceMember, error) {
+	row := q.db.QueryRow(ctx, getWorkspaceMember, arg.WorkspaceID, arg.UserID)
+	var i WorkspaceMember
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.UserID,
+		&i.Role,
+		&i.JoinedAt,
+	)
+	return i, err
+}
+
+const getWorkspaceMembers = `-- name: GetWorkspaceMembers :many
+SELECT u.id, u.username, u.avatar_url, wm.role, wm.joined_at
+FROM workspace_members wm
+JOIN users u ON u.id = wm.user_id
+WHERE wm.workspace_id = $1
+ORDER BY wm.joined_at
+`
+
+type GetWorkspaceMembersRow struct {
+	ID        pgtype.UUID
+	Username  string
+	AvatarUrl pgtype.Text
+	Role      string
+	JoinedAt  pgtype.Timestamptz
+}
+
+func (q *Queries) GetWorkspaceMembers(ctx context.Context, workspaceID pgtype.UUID) ([]GetWorkspaceMembersRow, error) {
+	rows, err := q.db.Query(ctx, getWorkspaceMembers, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWorkspaceMembersRow
+	for rows.Next() {
+		var i GetWorkspaceMembersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.Role,
+			&i.JoinedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceProjects = `-- name: GetWorkspaceProjects :many
+SELECT id, github_repo_id, name, owner_id, created_at, workspace_id FROM projects WHERE workspace_id = $1 ORDER BY name
+`
+
+func (q *Queries) GetWorkspaceProjects(ctx context.Context, workspaceID pgtype.UUID) ([]Project, error) {
+	rows, err := q.db.Query(ctx, getWorkspaceProjects, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Project
+	for rows.Next() {
+		var i Project
+		if err := rows.Scan(
+			&i.ID,
+			&i.GithubRepoID,
+			&i.Name,
+			&i.OwnerID,
+			&i.CreatedAt,
+			&i.WorkspaceID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const hardDeleteMessage = `-- name: HardDeleteMessage :exec
+DELETE FROM messages WHERE id = $1
+`
+
+func (q *Queries) HardDeleteMessage(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, hardDeleteMessage, id)
+	return err
+}
+
+const hasBlocked = `-- name: HasBlocked :one
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2
+)
+`
+
+type HasBlockedParams struct {
+	BlockerID pgtype.UUID
+	BlockedID pgtype.UUID
+}
+
+func (q *Queries) HasBlocked(ctx context.Context, arg HasBlockedParams) (bool, error) {
+	row := q.db.QueryRow(ctx, hasBlocked, arg.BlockerID, arg.BlockedID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const incrementReplyCount = `-- name: IncrementReplyCount :exec
+UPDATE messages SET reply_count = reply_count + 1 WHERE id = $1
+`
+
+func (q *Queries) IncrementReplyCount(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, incrementReplyCount, id)
+	return err
+}
+
+const isBannedFromLoop = `-- name: IsBannedFromLoop :one
+SELECT EXISTS (
+    SELECT 1 FROM loop_bans WHERE project_id = $1 AND user_id = $2
+)
+`
+
+type IsBannedFromLoopParams struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+}
+
+func (q *Queries) IsBannedFromLoop(ctx context.Context, arg IsBannedFromLoopParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isBannedFromLoop, arg.ProjectID, arg.UserID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isBlockedBetween = `-- name: IsBlockedBetween :one
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = $1 AND blocked_id = $2)
+       OR (blocker_id = $2 AND blocked_id = $1)
+)
+`
+
+type IsBlockedBetweenParams struct {
+	BlockerID pgtype.UUID
+	BlockedID pgtype.UUID
+}
+
+// Either direction counts: used to refuse DMs between the pair
+func (q *Queries) IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isBlockedBetween, arg.BlockerID, arg.BlockedID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isDMParticipant = `-- name: IsDMParticipant :one
+SELECT user_id FROM dm_participants
+WHERE conversation_id = $1 AND user_id = $2
+`
+
+type IsDMParticipantParams struct {
+	ConversationID pgtype.UUID
+	UserID         pgtype.UUID
+}
+
+func (q *Queries) IsDMParticipant(ctx context.Context, arg IsDMParticipantParams) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, isDMParticipant, arg.ConversationID, arg.UserID)
+	var user_id pgtype.UUID
+	err := row.Scan(&user_id)
+	return user_id, err
+}
+
+const isGithubCommentInChannel = `-- name: IsGithubCommentInChannel :one
+SELECT EXISTS(
+    SELECT 1 FROM message_github_comments g
+    JOIN messages m ON m.id = g.message_id
+    WHERE g.comment_id = $1 AND m.channel_id = $2
+)
+`
+
+type IsGithubCommentInChannelParams struct {
+	CommentID int64
+	ChannelID pgtype.UUID
+}
+
+func (q *Queries) IsGithubCommentInChannel(ctx context.Context, arg IsGithubCommentInChannelParams) (bool, error) {
+	row := q.db.QueryRow(ctx, isGithubCommentInChannel, arg.CommentID, arg.ChannelID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isMember = `-- name: IsMember :one
+SELECT 1 FROM memberships
+WHERE user_id = $1 AND project_id = $2 LIMIT 1
+`
+
+type IsMemberParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) IsMember(ctx context.Context, arg IsMemberParams) (int32, error) {
+	row := q.db.QueryRow(ctx, isMember, arg.UserID, arg.ProjectID)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const isMessageCrossPosted = `-- name: IsMessageCrossPosted :one
+SELECT EXISTS(SELECT 1 FROM message_github_comments WHERE message_id = $1)
+`
+
+func (q *Queries) IsMessageCrossPosted(ctx context.Context, messageID int64) (bool, error) {
+	row := q.db.QueryRow(ctx, isMessageCrossPosted, messageID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const isSessionActive = `-- name: IsSessionActive :one
+SELECT EXISTS (
+    SELECT 1 FROM sessions
+    WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+)
+`
+
+func (q *Queries) IsSessionActive(ctx context.Context, id pgtype.UUID) (bool, error) {
+	row := q.db.QueryRow(ctx, isSessionActive, id)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const linkMessageToGithubComment = `-- name: LinkMessageToGithubComment :execrows
+INSERT INTO message_github_comments (message_id, comment_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING
+`
+
+type LinkMessageToGithubCommentParams struct {
+	MessageID int64
+	CommentID int64
+}
+
+func (q *Queries) LinkMessageToGithubComment(ctx context.Context, arg LinkMessageToGithubCommentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, linkMessageToGithubComment, arg.MessageID, arg.CommentID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const listFeatureFlags = `-- name: ListFeatureFlags :many
+
+SELECT key, description, enabled, rollout_percent, allow_users, allow_loops, updated_at FROM feature_flags ORDER BY key
+`
+
+// ============================================================================
+// FEATURE FLAGS
+// ============================================================================
+func (q *Queries) ListFeatureFlags(ctx context.Context) ([]FeatureFlag, error) {
+	rows, err := q.db.Query(ctx, listFeatureFlags)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FeatureFlag
+	for rows.Next() {
+		var i FeatureFlag
+		if err := rows.Scan(
+			&i.Key,
+			&i.Description,
+			&i.Enabled,
+			&i.RolloutPercent,
+			&i.AllowUsers,
+			&i.AllowLoops,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listIncomingWebhooks = `-- name: ListIncomingWebhooks :many
+SELECT w.id, w.project_id, w.channel_id, w.bot_id, w.name, w.prefix, w.token_hash, w.created_by, w.last_used_at, w.created_at, w.revoked_at, c.name AS channel_name, u.username AS bot_username, u.avatar_url AS bot_avatar
+FROM incoming_webhooks w
+JOIN channels c ON c.id = w.channel_id
+JOIN users u ON u.id = w.bot_id
+WHERE w.project_id = $1
+ORDER BY w.revoked_at IS NOT NULL, w.created_at DESC
+`
+
+type ListIncomingWebhooksRow struct {
+	ID          pgtype.UUID
+	ProjectID   pgtype.UUID
+	ChannelID   pgtype.UUID
+	BotID       pgtype.UUID
+	Name        string
+	Prefix      string
+	TokenHash   string
+	CreatedBy   pgtype.UUID
+	LastUsedAt  pgtype.Timestamptz
+	CreatedAt   pgtype.Timestamptz
+	RevokedAt   pgtype.Timestamptz
+	ChannelName string
+	BotUsername string
+	BotAvatar   pgtype.Text
+}
+
+func (q *Queries) ListIncomingWebhooks(ctx context.Context, projectID pgtype.UUID) ([]ListIncomingWebhooksRow, error) {
+	rows, err := q.db.Query(ctx, listIncomingWebhooks, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListIncomingWebhooksRow
+	for rows.Next() {
+		var i ListIncomingWebhooksRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.ChannelID,
+			&i.BotID,
+			&i.Name,
+			&i.Prefix,
+			&i.TokenHash,
+			&i.CreatedBy,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+			&i.RevokedAt,
+			&i.ChannelName,
+			&i.BotUsername,
+			&i.BotAvatar,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listJobFailures = `-- name: ListJobFailures :many
+SELECT id, type, payload, run_at, status, attempts, last_error, created_at, updated_at FROM jobs
+WHERE status <> 'done' AND last_error IS NOT NULL
+  AND ($1::text IS NULL OR type = $1)
+ORDER BY updated_at DESC
+LIMIT $2
+`
+
+type ListJobFailuresParams struct {
+	Type pgtype.Text
+	N    int32
+}
+
+// Failed jobs and those waiting to retry, most recent failure first
+func (q *Queries) ListJobFailures(ctx context.Context, arg ListJobFailuresParams) ([]Job, error) {
+	rows, err := q.db.Query(ctx, listJobFailures, arg.Type, arg.N)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Job
+	for rows.Next() {
+		var i Job
+		if err := rows.Scan(
+			&i.ID,
+			&i.Type,
+			&i.Payload,
+			&i.RunAt,
+			&i.Status,
+			&i.Attempts,
+			&i.LastError,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLongPosts = `-- name: ListLongPosts :many
+SELECT lp.message_id, lp.chars, a.size_bytes
+FROM long_posts lp
+JOIN attachments a ON a.id = lp.attachment_id
+WHERE lp.message_id = ANY($1::bigint[])
+`
+
+type ListLongPostsRow struct {
+	MessageID int64
+	Chars     int32
+	SizeBytes int64
+}
+
+func (q *Queries) ListLongPosts(ctx context.Context, messageIds []int64) ([]ListLongPostsRow, error) {
+	rows, err := q.db.Query(ctx, listLongPosts, messageIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListLongPostsRow
+	for rows.Next() {
+		var i ListLongPostsRow
+		if err := rows.Scan(
+			&i.MessageID,
+			&i.Chars,
+			&i.SizeBytes,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLoopEmoji = `-- name: ListLoopEmoji :many
+SELECT id, project_id, name, image_path, created_by, created_at FROM loop_emoji WHERE project_id = $1 ORDER BY name
+`
+
+func (q *Queries) ListLoopEmoji(ctx context.Context, projectID pgtype.UUID) ([]LoopEmoji, error) {
+	rows, err := q.db.Query(ctx, listLoopEmoji, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoopEmoji
+	for rows.Next() {
+		var i LoopEmoji
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Name,
+			&i.ImagePath,
+			&i.CreatedBy,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLoopInvites = `-- name: ListLoopInvites :many
+SELECT code, project_id, role, created_by, max_uses, uses, expires_at, created_at FROM loop_invites
+WHERE project_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListLoopInvites(ctx context.Context, projectID pgtype.UUID) ([]LoopInvite, error) {
+	rows, err := q.db.Query(ctx, listLoopInvites, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoopInvite
+	for rows.Next() {
+		var i LoopInvite
+		if err := rows.Scan(
+			&i.Code,
+			&i.ProjectID,
+			&i.Role,
+			&i.CreatedBy,
+			&i.MaxUses,
+			&i.Uses,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOnboardingSteps = `-- name: ListOnboardingSteps :many
+
+SELECT id, project_id, title, description, kind, channel_id, position, created_at FROM onboarding_steps
+WHERE project_id = $1
+ORDER BY position, created_at
+`
+
+// ============================================================================
+// ONBOARDING
+// ============================================================================
+func (q *Queries) ListOnboardingSteps(ctx context.Context, projectID pgtype.UUID) ([]OnboardingStep, error) {
+	rows, err := q.db.Query(ctx, listOnboardingSteps, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OnboardingStep
+	for rows.Next() {
+		var i OnboardingStep
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProjectID,
+			&i.Title,
+			&i.Description,
+			&i.Kind,
+			&i.ChannelID,
+			&i.Position,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPRComments = `-- name: ListPRComments :many
+
+SELECT repo_id, pr_number, comment_type, comment_id, body, path, line, diff_hunk, in_reply_to_id, state, username, avatar_url, html_url, github_created_at, github_updated_at, synced_at FROM pr_comments
+WHERE repo_id = $1 AND pr_number = $2
+ORDER BY github_created_at, comment_id
+`
+
+type ListPRCommentsParams struct {
+	RepoID   int64
+	PrNumber int32
+}
+
+// ============================================================================
+// PR COMMENT STORE
+// ============================================================================
+func (q *Queries) ListPRComments(ctx context.Context, arg ListPRCommentsParams) ([]PrComment, error) {
+	rows, err := q.db.Query(ctx, listPRComments, arg.RepoID, arg.PrNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PrComment
+	for rows.Next() {
+		var i PrComment
+		if err := rows.Scan(
+			&i.RepoID,
+			&i.PrNumber,
+			&i.CommentType,
+			&i.CommentID,
+			&i.Body,
+			&i.Path,
+			&i.Line,
+			&i.DiffHunk,
+			&i.InReplyToID,
+			&i.State,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.HtmlUrl,
+			&i.GithubCreatedAt,
+			&i.GithubUpdatedAt,
+			&i.SyncedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPinEvents = `-- name: ListPinEvents :many
+SELECT
+    e.id,
+    e.channel_id,
+    e.message_id,
+    e.action,
+    e.created_at,
+    u.username AS actor_username,
+    ch.name AS channel_name
+FROM pin_events e
+LEFT JOIN users u ON e.actor_id = u.id
+LEFT JOIN channels ch ON e.channel_id = ch.id
+WHERE e.project_id = $1
+  AND ($2::bigint IS NULL OR e.id < $2)
+ORDER BY e.id DESC
+LIMIT $3
+`
+
+type ListPinEventsParams struct {
+	ProjectID pgtype.UUID
+	BeforeID  pgtype.Int8
+	RowLimit  int32
+}
+
+type ListPinEventsRow struct {
+	ID            int64
+	ChannelID     pgtype.UUID
+	MessageID     int64
+	Action        string
+	CreatedAt     pgtype.Timestamptz
+	ActorUsername pgtype.Text
+	ChannelName   pgtype.Text
+}
+
+// A loop's pin audit trail, newest first
+func (q *Queries) ListPinEvents(ctx context.Context, arg ListPinEventsParams) ([]ListPinEventsRow, error) {
+	rows, err := q.db.Query(ctx, listPinEvents, arg.ProjectID, arg.BeforeID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPinEventsRow
+	for rows.Next() {
+		var i ListPinEventsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChannelID,
+			&i.MessageID,
+			&i.Action,
+			&i.CreatedAt,
+			&i.ActorUsername,
+			&i.ChannelName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listReactionBadgeEarners = `-- name: ListReactionBadgeEarners :many
+SELECT s.project_id, s.author_id
+FROM loop_reaction_daily_stats s
+JOIN memberships mb ON mb.project_id = s.project_id AND mb.user_id = s.author_id
+WHERE NOT EXISTS (
+    SELECT 1 FROM member_badges b
+    WHERE b.project_id = s.project_id AND b.user_id = s.author_id AND b.badge = $1
+)
+GROUP BY s.project_id, s.author_id
+HAVING SUM(s.reactions) >= $2::int
+`
+
+type ListReactionBadgeEarnersParams struct {
+	Badge     string
+	Threshold int32
+}
+
+type ListReactionBadgeEarnersRow struct {
+	ProjectID pgtype.UUID
+	AuthorID  pgtype.UUID
+}
+
+// Members whose messages have drawn at least threshold reactions in a loop
+// and who don't hold the badge yet
+func (q *Queries) ListReactionBadgeEarners(ctx context.Context, arg ListReactionBadgeEarnersParams) ([]ListReactionBadgeEarnersRow, error) {
+	rows, err := q.db.Query(ctx, listReactionBadgeEarners, arg.Badge, arg.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReactionBadgeEarnersRow
+	for rows.Next() {
+		var i ListReactionBadgeEarnersRow
+		if err := rows.Scan(
+			&i.ProjectID,
+			&i.AuthorID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadRepliesSince = `-- name: ListThreadRepliesSince :many
+SELECT m.id, m.content, COALESCE(u.username, '')::text AS username
+FROM messages m
+LEFT JOIN users u ON u.id = m.sender_id
+WHERE m.parent_id = $1::bigint
+  AND m.id > $2
+  AND m.is_deleted IS NOT TRUE
+ORDER BY m.id
+LIMIT $3
+`
+
+type ListThreadRepliesSinceParams struct {
+	ParentID int64
+	After    int64
+	RowLimit int32
+}
+
+type ListThreadRepliesSinceRow struct {
+	ID       int64
+	Content  string
+	Username string
+}
+
+// Replies after the ones a summary already covers, oldest first
+func (q *Queries) ListThreadRepliesSince(ctx context.Context, arg ListThreadRepliesSinceParams) ([]ListThreadRepliesSinceRow, error) {
+	rows, err := q.db.Query(ctx, listThreadRepliesSince, arg.ParentID, arg.After, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListThreadRepliesSinceRow
+	for rows.Next() {
+		var i ListThreadRepliesSinceRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadSummaries = `-- name: ListThreadSummaries :many
+SELECT message_id, summary, replies, last_reply_id, updated_at FROM thread_summaries
+WHERE message_id = ANY($1::bigint[])
+`
+
+func (q *Queries) ListThreadSummaries(ctx context.Context, messageIds []int64) ([]ThreadSummary, error) {
+	rows, err := q.db.Query(ctx, listThreadSummaries, messageIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ThreadSummary
+	for rows.Next() {
+		var i ThreadSummary
+		if err := rows.Scan(
+			&i.MessageID,
+			&i.Summary,
+			&i.Replies,
+			&i.LastReplyID,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserAPIKeys = `-- name: ListUserAPIKeys :many
+SELECT id, user_id, name, prefix, key_hash, scopes, rate_limit, last_used_at, created_at, revoked_at FROM api_keys
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListUserAPIKeys(ctx context.Context, userID pgtype.UUID) ([]ApiKey, error) {
+	rows, err := q.db.Query(ctx, listUserAPIKeys, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ApiKey
+	for rows.Next() {
+		var i ApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			&i.KeyHash,
+			&i.Scopes,
+			&i.RateLimit,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserPersonalAccessTokens = `-- name: ListUserPersonalAccessTokens :many
+SELECT id, user_id, name, prefix, token_hash, expires_at, last_used_at, created_at, revoked_at FROM personal_access_tokens
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC
+`
+
+func (q *Queries) ListUserPersonalAccessTokens(ctx context.Context, userID pgtype.UUID) ([]PersonalAccessToken, error) {
+	rows, err := q.db.Query(ctx, listUserPersonalAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []PersonalAccessToken
+	for rows.Next() {
+		var i PersonalAccessToken
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Prefix,
+			&i.TokenHash,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.CreatedAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_seen_at DESC
+`
+
+func (q *Queries) ListUserSessions(ctx context.Context, userID pgtype.UUID) ([]Session, error) {
+	rows, err := q.db.Query(ctx, listUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Session
+	for rows.Next() {
+		var i Session
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.UserAgent,
+			&i.Ip,
+			&i.CreatedAt,
+			&i.LastSeenAt,
+			&i.ExpiresAt,
+			&i.RevokedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listVerificationAttempts = `-- name: ListVerificationAttempts :many
+SELECT id, user_id, project_id, passed, results, source, checked_at FROM loop_verification_attempts
+WHERE user_id = $1 AND project_id = $2
+ORDER BY checked_at DESC
+LIMIT $3
+`
+
+type ListVerificationAttemptsParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+	Limit     int32
+}
+
+func (q *Queries) ListVerificationAttempts(ctx context.Context, arg ListVerificationAttemptsParams) ([]LoopVerificationAttempt, error) {
+	rows, err := q.db.Query(ctx, listVerificationAttempts, arg.UserID, arg.ProjectID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []LoopVerificationAttempt
+	for rows.Next() {
+		var i LoopVerificationAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ProjectID,
+			&i.Passed,
+			&i.Results,
+			&i.Source,
+			&i.CheckedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockGithubRepo = `-- name: LockGithubRepo :exec
+SELECT pg_advisory_xact_lock($1)
+`
+
+// Serializes loop creation per repository until the transaction ends
+func (q *Queries) LockGithubRepo(ctx context.Context, repoID int64) error {
+	_, err := q.db.Exec(ctx, lockGithubRepo, repoID)
+	return err
+}
+
+const logImpersonatedRequest = `-- name: LogImpersonatedRequest :exec
+INSERT INTO impersonation_requests (session_id, method, path, status)
+VALUES ($1, $2, $3, $4)
+`
+
+type LogImpersonatedRequestParams struct {
+	SessionID pgtype.UUID
+	Method    string
+	Path      string
+	Status    int32
+}
+
+func (q *Queries) LogImpersonatedRequest(ctx context.Context, arg LogImpersonatedRequestParams) error {
+	_, err := q.db.Exec(ctx, logImpersonatedRequest,
+		arg.SessionID,
+		arg.Method,
+		arg.Path,
+		arg.Status,
+	)
+	return err
+}
+
+const logPersonalAccessTokenRequest = `-- name: LogPersonalAccessTokenRequest :exec
+INSERT INTO personal_access_token_requests (token_id, method, path, status)
+VALUES ($1, $2, $3, $4)
+`
+
+type LogPersonalAccessTokenRequestParams struct {
+	TokenID pgtype.UUID
+	Method  string
+	Path    string
+	Status  int32
+}
+
+func (q *Queries) LogPersonalAccessTokenRequest(ctx context.Context, arg LogPersonalAccessTokenRequestParams) error {
+	_, err := q.db.Exec(ctx, logPersonalAccessTokenRequest,
+		arg.TokenID,
+		arg.Method,
+		arg.Path,
+		arg.Status,
+	)
+	return err
+}
+
+const logPinEvent = `-- name: LogPinEvent :exec
+INSERT INTO pin_events (project_id, channel_id, message_id, actor_id, action)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type LogPinEventParams struct {
+	ProjectID pgtype.UUID
+	ChannelID pgtype.UUID
+	MessageID int64
+	ActorID   pgtype.UUID
+	Action    string
+}
+
+func (q *Queries) LogPinEvent(ctx context.Context, arg LogPinEventParams) error {
+	_, err := q.db.Exec(ctx, logPinEvent,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.MessageID,
+		arg.ActorID,
+		arg.Action,
+	)
+	return err
+}
+
+const markAllMentionsRead = `-- name: MarkAllMentionsRead :exec
+UPDATE mentions SET is_read = TRUE WHERE user_id = $1 AND is_read = FALSE
+`
+
+func (q *Queries) MarkAllMentionsRead(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markAllMentionsRead, userID)
+	return err
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :exec
+UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND is_read = FALSE
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markAllNotificationsRead, userID)
+	return err
+}
+
+const markChannelRead = `-- name: MarkChannelRead :one
+INSERT INTO channel_reads (user_id, channel_id, last_read_id)
+VALUES ($1, $2, COALESCE(
+    $3::bigint,
+    (SELECT MAX(id) FROM messages WHERE channel_id = $2),
+    0
+))
+ON CONFLICT (user_id, channel_id) DO UPDATE
+SET last_read_id = GREATEST(channel_reads.last_read_id, EXCLUDED.last_read_id),
+    updated_at = NOW()
+RETURNING last_read_id
+`
+
+type MarkChannelReadParams struct {
+	UserID    pgtype.UUID
+	ChannelID pgtype.UUID
+	MessageID pgtype.Int8
+}
+
+// Moves the user's read mark in a channel up to message_id, or to the
+// channel's latest message when it's null, and returns it. The mark never
+// moves back.
+func (q *Queries) MarkChannelRead(ctx context.Context, arg MarkChannelReadParams) (int64, error) {
+	row := q.db.QueryRow(ctx, markChannelRead, arg.UserID, arg.ChannelID, arg.MessageID)
+	var last_read_id int64
+	err := row.Scan(&last_read_id)
+	return last_read_id, err
+}
+
+const markDMRead = `-- name: MarkDMRead :exec
+UPDATE dm_participants SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2
+`
+
+type MarkDMReadParams struct {
+	ConversationID pgtype.UUID
+	UserID         pgtype.UUID
+}
+
+func (q *Queries) MarkDMRead(ctx context.Context, arg MarkDMReadParams) error {
+	_, err := q.db.Exec(ctx, markDMRead, arg.ConversationID, arg.UserID)
+	return err
+}
+
+const markLoopGithubDigestPosted = `-- name: MarkLoopGithubDigestPosted :exec
+UPDATE loop_github_digests SET last_posted_at = $2 WHERE project_id = $1
+`
+
+type MarkLoopGithubDigestPostedParams struct {
+	ProjectID    pgtype.UUID
+	LastPostedAt pgtype.Timestamptz
+}
+
+func (q *Queries) MarkLoopGithubDigestPosted(ctx context.Context, arg MarkLoopGithubDigestPostedParams) error {
+	_, err := q.db.Exec(ctx, markLoopGithubDigestPosted, arg.ProjectID, arg.LastPostedAt)
+	return err
+}
+
+const markMentionRead = `-- name: MarkMentionRead :exec
+UPDATE mentions SET is_read = TRUE WHERE id = $1 AND user_id = $2
+`
+
+type MarkMentionReadParams struct {
+	ID     int64
+	UserID pgtype.UUID
+}
+
+func (q *Queries) MarkMentionRead(ctx context.Context, arg MarkMentionReadParams) error {
+	_, err := q.db.Exec(ctx, markMentionRead, arg.ID, arg.UserID)
+	return err
+}
+
+const markNotificationRead = `-- name: MarkNotificationRead :exec
+UPDATE notifications SET is_read = TRUE WHERE id = $1 AND user_id = $2
+`
+
+type MarkNotificationReadParams struct {
+	ID     int64
+	UserID pgtype.UUID
+}
+
+func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) error {
+	_, err := q.db.Exec(ctx, markNotificationRead, arg.ID, arg.UserID)
+	return err
+}
+
+const markPRCommentsSynced = `-- name: MarkPRCommentsSynced :exec
+INSERT INTO pr_comment_syncs (repo_id, pr_number, synced_at) VALUES ($1, $2, NOW())
+ON CONFLICT (repo_id, pr_number) DO UPDATE SET synced_at = NOW()
+`
+
+type MarkPRCommentsSyncedParams struct {
+	RepoID   int64
+	PrNumber int32
+}
+
+func (q *Queries) MarkPRCommentsSynced(ctx context.Context, arg MarkPRCommentsSyncedParams) error {
+	_, err := q.db.Exec(ctx, markPRCommentsSynced, arg.RepoID, arg.PrNumber)
+	return err
+}
+
+const markRepoMirrored = `-- name: MarkRepoMirrored :many
+
+INSERT INTO loop_mirrors (project_id, reason)
+SELECT id, $2 FROM projects WHERE github_repo_id = $1
+ON CONFLICT (project_id) DO UPDATE SET reason = EXCLUDED.reason
+WHERE loop_mirrors.reason <> EXCLUDED.reason
+RETURNING project_id
+`
+
+type MarkRepoMirroredParams struct {
+	GithubRepoID int64
+	Reason       string
+}
+
+// ============================================================================
+// MIRROR MODE
+// ============================================================================
+// Returns the loops whose state changed
+func (q *Queries) MarkRepoMirrored(ctx context.Context, arg MarkRepoMirroredParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, markRepoMirrored, arg.GithubRepoID, arg.Reason)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var project_id pgtype.UUID
+		if err := rows.Scan(&project_id); err != nil {
+			return nil, err
+		}
+		items = append(items, project_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markStandupRunPosted = `-- name: MarkStandupRunPosted :exec
+UPDATE standup_runs SET status = 'posted', posted_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkStandupRunPosted(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, markStandupRunPosted, id)
+	return err
+}
+
+const mergeMembershipRoles = `-- name: MergeMembershipRoles :exec
+
+UPDATE memberships s SET role = m.role
+FROM memberships m
+WHERE s.user_id = $1 AND m.user_id = $2
+  AND s.project_id = m.project_id
+  AND (CASE m.role WHEN 'owner' THEN 3 WHEN 'moderator' THEN 2 WHEN 'guest' THEN 0 ELSE 1 END) >
+      (CASE s.role WHEN 'owner' THEN 3 WHEN 'moderator' THEN 2 WHEN 'guest' THEN 0 ELSE 1 END)
+`
+
+type MergeMembershipRolesParams struct {
+	SurvivorID pgtype.UUID
+	MergedID   pgtype.UUID
+}
+
+// ============================================================================
+// ACCOUNT MERGES
+// ============================================================================
+// In loops both accounts belong to, the survivor keeps the stronger role
+func (q *Queries) MergeMembershipRoles(ctx context.Context, arg MergeMembershipRolesParams) error {
+	_, err := q.db.Exec(ctx, mergeMembershipRoles, arg.SurvivorID, arg.MergedID)
+	return err
+}
+
+const nextChannelSeq = `-- name: NextChannelSeq :one
+
+INSERT INTO channel_seqs (channel_id, last_seq) VALUES ($1, 1)
+ON CONFLICT (channel_id) DO UPDATE SET last_seq = channel_seqs.last_seq + 1
+RETURNING last_seq
+`
+
+// ============================================================================
+// MESSAGE SEQUENCE NUMBERS
+// ============================================================================
+func (q *Queries) NextChannelSeq(ctx context.Context, channelID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, nextChannelSeq, channelID)
+	var last_seq int64
+	err := row.Scan(&last_seq)
+	return last_seq, err
+}
+
+const pinMessage = `-- name: PinMessage :exec
+
+UPDATE messages 
+SET is_pinned = TRUE, pinned_by = $2, pinned_at = NOW()
+WHERE id = $1
+`
+
+type PinMessageParams struct {
+	ID       int64
+	PinnedBy pgtype.UUID
+}
+
+// ============================================================================
+// PINNED MESSAGES
+// ============================================================================
+func (q *Queries) PinMessage(ctx context.Context, arg PinMessageParams) error {
+	_, err := q.db.Exec(ctx, pinMessage, arg.ID, arg.PinnedBy)
+	return err
+}
+
+const prunePRComments = `-- name: PrunePRComments :execrows
+DELETE FROM pr_comments
+WHERE repo_id = $1 AND pr_number = $2 AND comment_type = $3
+  AND NOT (comment_id = ANY($4::bigint[]))
+`
+
+type PrunePRCommentsParams struct {
+	RepoID      int64
+	PrNumber    int32
+	CommentType string
+	Keep        []int64
+}
+
+// Drops comments that no longer exist on GitHub
+func (q *Queries) PrunePRComments(ctx context.Context, arg PrunePRCommentsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, prunePRComments,
+		arg.RepoID,
+		arg.PrNumber,
+		arg.CommentType,
+		arg.Keep,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const pruneWebhookDeliveries = `-- name: PruneWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries WHERE received_at < $1
+`
+
+func (q *Queries) PruneWebhookDeliveries(ctx context.Context, before pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, pruneWebhookDeliveries, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeDeletedMessages = `-- name: PurgeDeletedMessages :execrows
+DELETE FROM messages
+WHERE is_deleted = TRUE
+  AND deleted_at < $1
+  AND NOT EXISTS (SELECT 1 FROM messages r WHERE r.parent_id = messages.id)
+`
+
+// Replies keep a deleted parent as their thread's tombstone
+func (q *Queries) PurgeDeletedMessages(ctx context.Context, deletedBefore pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeletedMessages, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const quickSwitchChannels = `-- name: QuickSwitchChannels :many
+SELECT c.id, c.name, c.project_id, p.name AS project_name,
+    COALESCE(posted.n, 0)::int AS uses,
+    GREATEST(posted.at, r.updated_at)::timestamptz AS last_used_at
+FROM memberships mem
+JOIN projects p ON p.id = mem.project_id
+JOIN channels c ON c.project_id = mem.project_id
+LEFT JOIN loop_settings ls ON ls.project_id = mem.project_id
+LEFT JOIN channel_reads r ON r.user_id = mem.user_id AND r.channel_id = c.id
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n, MAX(m.created_at) AS at
+    FROM messages m
+    WHERE m.channel_id = c.id AND m.sender_id = mem.user_id AND m.created_at > NOW() - INTERVAL '30 days'
+) posted ON TRUE
+WHERE mem.user_id = $1
+  AND c.name ILIKE $2
+  AND (mem.role IS DISTINCT FROM 'guest' OR c.id = ANY(COALESCE(ls.guest_channel_ids, '{}')))
+ORDER BY last_used_at DESC NULLS LAST, c.name
+LIMIT $3
+`
+
+type QuickSwitchChannelsParams struct {
+	UserID   pgtype.UUID
+	Pattern  string
+	RowLimit int32
+}
+
+type QuickSwitchChannelsRow struct {
+	ID          pgtype.UUID
+	Name        string
+	ProjectID   pgtype.UUID
+	ProjectName string
+	Uses        int32
+	LastUsedAt  pgtype.Timestamptz
+}
+
+// Guests only get their channels
+func (q *Queries) QuickSwitchChannels(ctx context.Context, arg QuickSwitchChannelsParams) ([]QuickSwitchChannelsRow, error) {
+	rows, err := q.db.Query(ctx, quickSwitchChannels, arg.UserID, arg.Pattern, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QuickSwitchChannelsRow
+	for rows.Next() {
+		var i QuickSwitchChannelsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.ProjectID,
+			&i.ProjectName,
+			&i.Uses,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const quickSwitchDMs = `-- name: QuickSwitchDMs :many
+SELECT dc.id, dc.is_bot, dc.is_group, dc.name, u.username AS other_username, u.avatar_url AS other_avatar,
+    COALESCE(posted.n, 0)::int AS uses,
+    GREATEST(posted.at, p.last_read_at)::timestamptz AS last_used_at
+FROM dm_participants p
+JOIN dm_conversations dc ON dc.id = p.conversation_id
+LEFT JOIN dm_participants op ON op.conversation_id = dc.id AND op.user_id <> p.user_id AND NOT dc.is_group
+LEFT JOIN users u ON u.id = op.user_id
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n, MAX(m.created_at) AS at
+    FROM dm_messages m
+    WHERE m.conversation_id = dc.id AND m.sender_id = p.user_id AND m.created_at > NOW() - INTERVAL '30 days'
+) posted ON TRUE
+WHERE p.user_id = $1
+  AND (dc.status = 'active' OR dc.requested_by = p.user_id)
+  AND (COALESCE(dc.name, '') ILIKE $2 OR COALESCE(u.username, '') ILIKE $2 OR dc.is_bot)
+ORDER BY last_used_at DESC NULLS LAST
+LIMIT $3
+`
+
+type QuickSwitchDMsParams struct {
+	UserID   pgtype.UUID
+	Pattern  string
+	RowLimit int32
+}
+
+type QuickSwitchDMsRow struct {
+	ID            pgtype.UUID
+	IsBot         bool
+	IsGroup       bool
+	Name          pgtype.Text
+	OtherUsername pgtype.Text
+	OtherAvatar   pgtype.Text
+	Uses          int32
+	LastUsedAt    pgtype.Timestamptz
+}
+
+// Matches a group's name or the other participant's username
+func (q *Queries) QuickSwitchDMs(ctx context.Context, arg QuickSwitchDMsParams) ([]QuickSwitchDMsRow, error) {
+	rows, err := q.db.Query(ctx, quickSwitchDMs, arg.UserID, arg.Pattern, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QuickSwitchDMsRow
+	for rows.Next() {
+		var i QuickSwitchDMsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.IsBot,
+			&i.IsGroup,
+			&i.Name,
+			&i.OtherUsername,
+			&i.OtherAvatar,
+			&i.Uses,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const quickSwitchLoops = `-- name: QuickSwitchLoops :many
+
+SELECT p.id, p.name,
+    COALESCE(posted.n, 0)::int AS uses,
+    GREATEST(posted.at, read.at)::timestamptz AS last_used_at
+FROM memberships mem
+JOIN projects p ON p.id = mem.project_id
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n, MAX(m.created_at) AS at
+    FROM messages m
+    WHERE m.project_id = p.id AND m.sender_id = mem.user_id AND m.created_at > NOW() - INTERVAL '30 days'
+) posted ON TRUE
+LEFT JOIN LATERAL (
+    SELECT MAX(r.updated_at) AS at
+    FROM channel_reads r
+    JOIN channels c ON c.id = r.channel_id
+    WHERE r.user_id = mem.user_id AND c.project_id = p.id
+) read ON TRUE
+WHERE mem.user_id = $1
+  AND p.name ILIKE $2
+ORDER BY last_used_at DESC NULLS LAST, p.name
+LIMIT $3
+`
+
+type QuickSwitchLoopsParams struct {
+	UserID   pgtype.UUID
+	Pattern  string
+	RowLimit int32
+}
+
+type QuickSwitchLoopsRow struct {
+	ID         pgtype.UUID
+	Name       string
+	Uses       int32
+	LastUsedAt pgtype.Timestamptz
+}
+
+// QUICK SWITCHER
+// The switcher's candidates are matched with pattern (an ILIKE pattern, '%'
+// for everything) and come with how often the user posted there in the last
+// 30 days (uses) and when they last posted or read there (last_used_at), for
+// frecency ranking.
+func (q *Queries) QuickSwitchLoops(ctx context.Context, arg QuickSwitchLoopsParams) ([]QuickSwitchLoopsRow, error) {
+	rows, err := q.db.Query(ctx, quickSwitchLoops, arg.UserID, arg.Pattern, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QuickSwitchLoopsRow
+	for rows.Next() {
+		var i QuickSwitchLoopsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Uses,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const quickSwitchThreads = `-- name: QuickSwitchThreads :many
+SELECT parent.id, parent.content, parent.channel_id, c.name AS channel_name, p.name AS project_name,
+    COALESCE(parent.reply_count, 0)::int AS reply_count,
+    COUNT(*)::int AS uses,
+    MAX(m.created_at)::timestamptz AS last_used_at
+FROM messages m
+JOIN messages parent ON parent.id = COALESCE(m.parent_id, m.id)
+JOIN channels c ON c.id = parent.channel_id
+JOIN projects p ON p.id = parent.project_id
+JOIN memberships mem ON mem.project_id = parent.project_id AND mem.user_id = m.sender_id
+LEFT JOIN loop_settings ls ON ls.project_id = parent.project_id
+WHERE m.sender_id = $1
+  AND (mem.role IS DISTINCT FROM 'guest' OR c.id = ANY(COALESCE(ls.guest_channel_ids, '{}')))
+  AND m.created_at > NOW() - INTERVAL '30 days'
+  AND (m.parent_id IS NOT NULL OR m.reply_count > 0)
+  AND (parent.is_deleted = FALSE OR parent.is_deleted IS NULL)
+  AND parent.content ILIKE $2
+GROUP BY parent.id, c.name, p.name
+ORDER BY last_used_at DESC
+LIMIT $3
+`
+
+type QuickSwitchThreadsParams struct {
+	UserID   pgtype.UUID
+	Pattern  string
+	RowLimit int32
+}
+
+type QuickSwitchThreadsRow struct {
+	ID          int64
+	Content     string
+	ChannelID   pgtype.UUID
+	ChannelName string
+	ProjectName string
+	ReplyCount  int32
+	Uses        int32
+	LastUsedAt  pgtype.Timestamptz
+}
+
+// Threads the user started or replied to in the last 30 days, in channels
+// they can still read, matched on the parent message's text
+func (q *Queries) QuickSwitchThreads(ctx context.Context, arg QuickSwitchThreadsParams) ([]QuickSwitchThreadsRow, error) {
+	rows, err := q.db.Query(ctx, quickSwitchThreads, arg.UserID, arg.Pattern, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []QuickSwitchThreadsRow
+	for rows.Next() {
+		var i QuickSwitchThreadsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.ChannelID,
+			&i.ChannelName,
+			&i.ProjectName,
+			&i.ReplyCount,
+			&i.Uses,
+			&i.LastUsedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reassignMemberships = `-- name: ReassignMemberships :execrows
+UPDATE memberships SET user_id = $1 WHERE user_id = $2
+`
+
+type ReassignMembershipsParams struct {
+	SurvivorID pgtype.UUID
+	MergedID   pgtype.UUID
+}
+
+func (q *Queries) ReassignMemberships(ctx context.Context, arg ReassignMembershipsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reassignMemberships, arg.SurvivorID, arg.MergedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reassignMessages = `-- name: ReassignMessages :execrows
+UPDATE messages SET sender_id = $1 WHERE sender_id = $2
+`
+
+type ReassignMessagesParams struct {
+	SurvivorID pgtype.UUID
+	MergedID   pgtype.UUID
+}
+
+func (q *Queries) ReassignMessages(ctx context.Context, arg ReassignMessagesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reassignMessages, arg.SurvivorID, arg.MergedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const reassignNotificationActors = `-- name: ReassignNotificationActors :exec
+UPDATE notifications SET actor_id = $1, actor_username = $2
+WHERE actor_id = $3
+`
+
+type ReassignNotificationActorsParams struct {
+	SurvivorID       pgtype.UUID
+	SurvivorUsername string
+	MergedID         pgtype.UUID
+}
+
+func (q *Queries) ReassignNotificationActors(ctx context.Context, arg ReassignNotificationActorsParams) error {
+	_, err := q.db.Exec(ctx, reassignNotificationActors, arg.SurvivorID, arg.SurvivorUsername, arg.MergedID)
+	return err
+}
+
+const reassignNotifications = `-- name: ReassignNotifications :execrows
+UPDATE notifications SET user_id = $1 WHERE user_id = $2
+`
+
+type ReassignNotificationsParams struct {
+	SurvivorID pgtype.UUID
+	MergedID   pgtype.UUID
+}
+
+func (q *Queries) ReassignNotifications(ctx context.Context, arg ReassignNotificationsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reassignNotifications, arg.SurvivorID, arg.MergedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordLoopWelcome = `-- name: RecordLoopWelcome :execrows
+
+INSERT INTO loop_welcomes (project_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (project_id, user_id) DO NOTHING
+`
+
+type RecordLoopWelcomeParams struct {
+	ProjectID pgtype.UUID
+	UserID    pgtype.UUID
+}
+
+// ============================================================================
+// WELCOMES AND BADGES
+// ============================================================================
+// 0 rows means the member was welcomed before
+func (q *Queries) RecordLoopWelcome(ctx context.Context, arg RecordLoopWelcomeParams) (int64, error) {
+	result, err := q.db.Exec(ctx, recordLoopWelcome, arg.ProjectID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordUsernameHistory = `-- name: RecordUsernameHistory :exec
+INSERT INTO username_history (old_username, user_id)
+VALUES ($1, $2)
+ON CONFLICT (old_username) DO UPDATE SET user_id = EXCLUDED.user_id, changed_at = NOW()
+`
+
+type RecordUsernameHistoryParams struct {
+	OldUsername string
+	UserID      pgtype.UUID
+}
+
+func (q *Queries) RecordUsernameHistory(ctx context.Context, arg RecordUsernameHistoryParams) error {
+	_, err := q.db.Exec(ctx, recordUsernameHistory, arg.OldUsername, arg.UserID)
+	return err
+}
+
+const recordWebhookDelivery = `-- name: RecordWebhookDelivery :exec
+
+INSERT INTO webhook_deliveries (delivery_id, event, action, repo_id, payload, status, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (delivery_id) DO UPDATE SET
+status = EXCLUDED.status,
+error = EXCLUDED.error,
+attempts = webhook_deliveries.attempts + 1,
+last_attempt_at = NOW()
+`
+
+type RecordWebhookDeliveryParams struct {
+	DeliveryID string
+	Event      string
+	Action     string
+	RepoID     int64
+	Payload    []byte
+	Status     string
+	Error      pgtype.Text
+}
+
+// WEBHOOK DELIVERIES
+// A repeated delivery id is a retry or redelivery and counts as another attempt
+func (q *Queries) RecordWebhookDelivery(ctx context.Context, arg RecordWebhookDeliveryParams) error {
+	_, err := q.db.Exec(ctx, recordWebhookDelivery,
+		arg.DeliveryID,
+		arg.Event,
+		arg.Action,
+		arg.RepoID,
+		arg.Payload,
+		arg.Status,
+		arg.Error,
+	)
+	return err
+}
+
+const redeemLoopInvite = `-- name: RedeemLoopInvite :one
+UPDATE loop_invites
+SET uses = uses + 1
+WHERE code = $1
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (max_uses IS NULL OR uses < max_uses)
+RETURNING code, project_id, role, created_by, max_uses, uses, expires_at, created_at
+`
+
+// Consumes one use; no row means the code is unknown, expired or used up
+func (q *Queries) RedeemLoopInvite(ctx context.Context, code string) (LoopInvite, error) {
+	row := q.db.QueryRow(ctx, redeemLoopInvite, code)
+	var i LoopInvite
+	err := row.Scan(
+		&i.Code,
+		&i.ProjectID,
+		&i.Role,
+		&i.CreatedBy,
+		&i.MaxUses,
+		&i.Uses,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const releaseUsernameHistory = `-- name: ReleaseUsernameHistory :exec
+DELETE FROM username_history WHERE old_username = $1
+`
+
+// A handle stops redirecting once someone claims it
+func (q *Queries) ReleaseUsernameHistory(ctx context.Context, oldUsername string) error {
+	_, err := q.db.Exec(ctx, releaseUsernameHistory, oldUsername)
+	return err
+}
+
+const removeDMParticipant = `-- name: RemoveDMParticipant :exec
+DELETE FROM dm_participants WHERE conversation_id = $1 AND user_id = $2
+`
+
+type RemoveDMParticipantParams struct {
+	ConversationID pgtype.UUID
+	UserID         pgtype.UUID
+}
+
+func (q *Queries) RemoveDMParticipant(ctx context.Context, arg RemoveDMParticipantParams) error {
+	_, err := q.db.Exec(ctx, removeDMParticipant, arg.ConversationID, arg.UserID)
+	return err
+}
+
+const removeMembership = `-- name: RemoveMembership :exec
+DELETE FROM memberships WHERE user_id = $1 AND project_id = $2
+`
+
+type RemoveMembershipParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) RemoveMembership(ctx context.Context, arg RemoveMembershipParams) error {
+	_, err := q.db.Exec(ctx, removeMembership, arg.UserID, arg.ProjectID)
+	return err
+}
+
+const removeMessageReaction = `-- name: RemoveMessageReaction :execrows
+DELETE FROM message_reactions
+WHERE message_id = $1 AND user_id = $2 AND emoji = $3
+`
+
+type RemoveMessageReactionParams struct {
+	MessageID int64
+	UserID    pgtype.UUID
+	Emoji     string
+}
+
+func (q *Queries) RemoveMessageReaction(ctx context.Context, arg RemoveMessageReactionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeMessageReaction, arg.MessageID, arg.UserID, arg.Emoji)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const removeStandupParticipant = `-- name: RemoveStandupParticipant :exec
+DELETE FROM standup_participants WHERE standup_id = $1 AND user_id = $2
+`
+
+type RemoveStandupParticipantParams struct {
+	StandupID pgtype.UUID
+	UserID    pgtype.UUID
+}
+
+func (q *Queries) RemoveStandupParticipant(ctx context.Context, arg RemoveStandupParticipantParams) error {
+	_, err := q.db.Exec(ctx, removeStandupParticipant, arg.StandupID, arg.UserID)
+	return err
+}
+
+const removeWorkspaceMember = `-- name: RemoveWorkspaceMember :execrows
+DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2
+`
+
+type RemoveWorkspaceMemberParams struct {
+	WorkspaceID pgtype.UUID
+	UserID      pgtype.UUID
+}
+
+func (q *Queries) RemoveWorkspaceMember(ctx context.Context, arg RemoveWorkspaceMemberParams) (int64, error) {
+	result, err := q.db.Exec(ctx, removeWorkspaceMember, arg.WorkspaceID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const removeWorkspaceMemberFromLoops = `-- name: RemoveWorkspaceMemberFromLoops :exec
+DELETE FROM memberships mem
+USING projects p
+WHERE mem.project_id = p.id
+  AND p.workspace_id = $1
+  AND mem.user_id = $2
+  AND p.owner_id IS DISTINCT FROM mem.user_id
+`
+
+type RemoveWorkspaceMemberFromLoopsParams struct {
+	WorkspaceID pgtype.UUID
+	UserID      pgtype.UUID
+}
+
+// Removes a departing member from the workspace's loops, except those they own
+func (q *Queries) RemoveWorkspaceMemberFromLoops(ctx context.Context, arg RemoveWorkspaceMemberFromLoopsParams) error {
+	_, err := q.db.Exec(ctx, removeWorkspaceMemberFromLoops, arg.WorkspaceID, arg.UserID)
+	return err
+}
+
+const renameBoardColumn = `-- name: RenameBoardColumn :one
+UPDATE board_columns
+SET name = $2
+WHERE id = $1
+RETURNING id, project_id, name, position, created_at
+`
+
+type RenameBoardColumnParams struct {
+	ID   pgtype.UUID
+	Name string
+}
+
+func (q *Queries) RenameBoardColumn(ctx context.Context, arg RenameBoardColumnParams) (BoardColumn, error) {
+	row := q.db.QueryRow(ctx, renameBoardColumn, arg.ID, arg.Name)
+	var i BoardColumn
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const requeueFailedJob = `-- name: RequeueFailedJob :execrows
+UPDATE jobs
+SET status = 'queued', attempts = 0, run_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'failed'
+`
+
+func (q *Queries) RequeueFailedJob(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.Exec(ctx, requeueFailedJob, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const requeueFailedJobsByType = `-- name: RequeueFailedJobsByType :execrows
+UPDATE jobs
+SET status = 'queued', attempts = 0, run_at = NOW(), updated_at = NOW()
+WHERE type = $1 AND status = 'failed'
+`
+
+func (q *Queries) RequeueFailedJobsByType(ctx context.Context, type_ string) (int64, error) {
+	result, err := q.db.Exec(ctx, requeueFailedJobsByType, type_)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const requeueStaleJobs = `-- name: RequeueStaleJobs :execrows
+UPDATE jobs
+SET status = 'queued', updated_at = NOW()
+WHERE status = 'running' AND updated_at < $1
+`
+
+// Recovers jobs whose worker died mid-run
+func (q *Queries) RequeueStaleJobs(ctx context.Context, updatedAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, requeueStaleJobs, updatedAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const resolveMessageReports = `-- name: ResolveMessageReports :many
+UPDATE message_reports
+SET status = $2, resolution = $3, resolved_by = $4, resolved_at = NOW()
+WHERE message_id = $1 AND status = 'open'
+RETURNING id, message_id, project_id, reporter_id, reason, details, status, resolution, resolved_by, resolved_at, created_at
+`
+
+type ResolveMessageReportsParams struct {
+	MessageID  int64
+	Status     string
+	Resolution pgtype.Text
+	ResolvedBy pgtype.UUID
+}
+
+// Resolves every open report on the message at once
+func (q *Queries) ResolveMessageReports(ctx context.Context, arg ResolveMessageReportsParams) ([]MessageReport, error) {
+	rows, err := q.db.Query(ctx, resolveMessageReports,
+		arg.MessageID,
+		arg.Status,
+		arg.Resolution,
+		arg.ResolvedBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MessageReport
+	for rows.Next() {
+		var i MessageReport
+		if err := rows.Scan(
+			&i.ID,
+			&i.MessageID,
+			&i.ProjectID,
+			&i.ReporterID,
+			&i.Reason,
+			&i.Details,
+			&i.Status,
+			&i.Resolution,
+			&i.ResolvedBy,
+			&i.ResolvedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const retryJob = `-- name: RetryJob :exec
+UPDATE jobs
+SET status = 'queued', run_at = $2, last_error = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type RetryJobParams struct {
+	ID        int64
+	RunAt     pgtype.Timestamptz
+	LastError pgtype.Text
+}
+
+func (q *Queries) RetryJob(ctx context.Context, arg RetryJobParams) error {
+	_, err := q.db.Exec(ctx, retryJob, arg.ID, arg.RunAt, arg.LastError)
+	return err
+}
+
+const reviewFilteredMessage = `-- name: ReviewFilteredMessage :execrows
+UPDATE filtered_messages
+SET status = $2, reviewed_by = $3, reviewed_at = NOW()
+WHERE id = $1 AND status = 'pending'
+`
+
+type ReviewFilteredMessageParams struct {
+	ID         int64
+	Status     string
+	ReviewedBy pgtype.UUID
+}
+
+// Zero rows means another moderator got there first
+func (q *Queries) ReviewFilteredMessage(ctx context.Context, arg ReviewFilteredMessageParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reviewFilteredMessage, arg.ID, arg.Status, arg.ReviewedBy)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeAPIKey = `-- name: RevokeAPIKey :one
+UPDATE api_keys SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING key_hash
+`
+
+type RevokeAPIKeyParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) RevokeAPIKey(ctx context.Context, arg RevokeAPIKeyParams) (string, error) {
+	row := q.db.QueryRow(ctx, revokeAPIKey, arg.ID, arg.UserID)
+	var key_hash string
+	err := row.Scan(&key_hash)
+	return key_hash, err
+}
+
+const revokeIncomingWebhook = `-- name: RevokeIncomingWebhook :execrows
+UPDATE incoming_webhooks SET revoked_at = NOW()
+WHERE id = $1 AND project_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeIncomingWebhookParams struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+func (q *Queries) RevokeIncomingWebhook(ctx context.Context, arg RevokeIncomingWebhookParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeIncomingWebhook, arg.ID, arg.ProjectID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokePersonalAccessToken = `-- name: RevokePersonalAccessToken :one
+UPDATE personal_access_tokens SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING token_hash
+`
+
+type RevokePersonalAccessTokenParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) RevokePersonalAccessToken(ctx context.Context, arg RevokePersonalAccessTokenParams) (string, error) {
+	row := q.db.QueryRow(ctx, revokePersonalAccessToken, arg.ID, arg.UserID)
+	var token_hash string
+	err := row.Scan(&token_hash)
+	return token_hash, err
+}
+
+const revokeSession = `-- name: RevokeSession :execrows
+UPDATE sessions SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+`
+
+type RevokeSessionParams struct {
+	ID     pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) RevokeSession(ctx context.Context, arg RevokeSessionParams) (int64, error) {
+	result, err := q.db.Exec(ctx, revokeSession, arg.ID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeUserAPIKeys = `-- name: RevokeUserAPIKeys :many
+UPDATE api_keys SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+RETURNING key_hash
+`
+
+func (q *Queries) RevokeUserAPIKeys(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, revokeUserAPIKeys, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var key_hash string
+		if err := rows.Scan(&key_hash); err != nil {
+			return nil, err
+		}
+		items = append(items, key_hash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeUserPersonalAccessTokens = `-- name: RevokeUserPersonalAccessTokens :many
+UPDATE personal_access_tokens SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+RETURNING token_hash
+`
+
+func (q *Queries) RevokeUserPersonalAccessTokens(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	rows, err := q.db.Query(ctx, revokeUserPersonalAccessTokens, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var token_hash string
+		if err := rows.Scan(&token_hash); err != nil {
+			return nil, err
+		}
+		items = append(items, token_hash)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :many
+UPDATE sessions SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+RETURNING id
+`
+
+func (q *Queries) RevokeUserSessions(ctx context.Context, userID pgtype.UUID) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, revokeUserSessions, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const rewrapLoopSecret = `-- name: RewrapLoopSecret :exec
+UPDATE loop_secrets SET wrapped_key = $1, key_id = $2
+WHERE id = $3 AND key_id = $4
+`
+
+type RewrapLoopSecretParams struct {
+	WrappedKey []byte
+	KeyID      string
+	ID         pgtype.UUID
+	OldKeyID   string
+}
+
+// Skips a secret rotated since it was read, whose new data key is already current
+func (q *Queries) RewrapLoopSecret(ctx context.Context, arg RewrapLoopSecretParams) error {
+	_, err := q.db.Exec(ctx, rewrapLoopSecret,
+		arg.WrappedKey,
+		arg.KeyID,
+		arg.ID,
+		arg.OldKeyID,
+	)
+	return err
+}
+
+const rollupLoopDay = `-- name: RollupLoopDay :exec
+INSERT INTO loop_daily_stats (project_id, day, members, new_members, messages, active_members)
+SELECT
+    p.id,
+    $1::date,
+    COUNT(mb.user_id) FILTER (WHERE mb.joined_at < ($1::date + 1)::timestamp AT TIME ZONE 'UTC')::int,
+    COUNT(mb.user_id) FILTER (WHERE mb.joined_at >= ($1::date)::timestamp AT TIME ZONE 'UTC' AND mb.joined_at < ($1::date + 1)::timestamp AT TIME ZONE 'UTC')::int,
+    COALESCE(act.messages, 0)::int,
+    COALESCE(act.active, 0)::int
+FROM projects p
+LEFT JOIN memberships mb ON mb.project_id = p.id
+LEFT JOIN (
+    SELECT project_id, SUM(messages) AS messages, COUNT(*) AS active
+    FROM loop_member_daily_stats
+    WHERE day = $1::date
+    GROUP BY project_id
+) act ON act.project_id = p.id
+WHERE p.created_at < ($1::date + 1)::timestamp AT TIME ZONE 'UTC'
+GROUP BY p.id, act.messages, act.active
+ON CONFLICT (project_id, day) DO UPDATE SET
+    members = EXCLUDED.members,
+    new_members = EXCLUDED.new_members,
+    messages = EXCLUDED.messages,
+    active_members = EXCLUDED.active_members
+`
+
+// Loop totals for one UTC day, from memberships and that day's member rollup
+func (q *Queries) RollupLoopDay(ctx context.Context, day pgtype.Date) error {
+	_, err := q.db.Exec(ctx, rollupLoopDay, day)
+	return err
+}
+
+const rollupLoopMemberDay = `-- name: RollupLoopMemberDay :exec
+INSERT INTO loop_member_daily_stats (project_id, user_id, day, messages)
+SELECT m.project_id, m.sender_id, $1::date, COUNT(*)::int
+FROM messages m
+WHERE m.created_at >= ($1::date)::timestamp AT TIME ZONE 'UTC'
+  AND m.created_at < ($1::date + 1)::timestamp AT TIME ZONE 'UTC'
+  AND m.project_id IS NOT NULL
+  AND m.sender_id IS NOT NULL
+  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+GROUP BY m.project_id, m.sender_id
+ON CONFLICT (project_id, day, user_id) DO UPDATE SET messages = EXCLUDED.messages
+`
+
+// Per-member message counts for one UTC day; run after DeleteLoopMemberDayStats
+func (q *Queries) RollupLoopMemberDay(ctx context.Context, day pgtype.Date) error {
+	_, err := q.db.Exec(ctx, rollupLoopMemberDay, day)
+	return err
+}
+
+const rollupLoopReactionDay = `-- name: RollupLoopReactionDay :exec
+INSERT INTO loop_reaction_daily_stats (project_id, day, emoji, author_id, reactions)
+SELECT m.project_id, $1::date, r.emoji, m.sender_id, COUNT(*)::int
+FROM message_reactions r
+JOIN messages m ON m.id = r.message_id
+WHERE r.created_at >= ($1::date)::timestamp AT TIME ZONE 'UTC'
+  AND r.created_at < ($1::date + 1)::timestamp AT TIME ZONE 'UTC'
+  AND m.project_id IS NOT NULL
+  AND m.sender_id IS NOT NULL
+  AND r.user_id <> m.sender_id
+  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+GROUP BY m.project_id, r.emoji, m.sender_id
+ON CONFLICT (project_id, day, emoji, author_id) DO UPDATE SET reactions = EXCLUDED.reactions
+`
+
+// Reactions given on one UTC day by loop, emoji and the author reacted to;
+// run after DeleteLoopReactionDayStats. Reacting to yourself doesn't count.
+func (q *Queries) RollupLoopReactionDay(ctx context.Context, day pgtype.Date) error {
+	_, err := q.db.Exec(ctx, rollupLoopReactionDay, day)
+	return err
+}
+
+const rotateLoopSecret = `-- name: RotateLoopSecret :one
+UPDATE loop_secrets
+SET ciphertext = $2, nonce = $3, wrapped_key = $4, key_id = $5, hint = $6, rotated_at = NOW()
+WHERE id = $1
+RETURNING id, project_id, name, ciphertext, nonce, wrapped_key, key_id, hint, created_by, created_at, rotated_at
+`
+
+type RotateLoopSecretParams struct {
+	ID         pgtype.UUID
+	Ciphertext []byte
+	Nonce      []byte
+	WrappedKey []byte
+	KeyID      string
+	Hint       string
+}
+
+func (q *Queries) RotateLoopSecret(ctx context.Context, arg RotateLoopSecretParams) (LoopSecret, error) {
+	row := q.db.QueryRow(ctx, rotateLoopSecret,
+		arg.ID,
+		arg.Ciphertext,
+		arg.Nonce,
+		arg.WrappedKey,
+		arg.KeyID,
+		arg.Hint,
+	)
+	var i LoopSecret
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Ciphertext,
+		&i.Nonce,
+		&i.WrappedKey,
+		&i.KeyID,
+		&i.Hint,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.RotatedAt,
+	)
+	return i, err
+}
+
+const searchMembersByUsername = `-- name: SearchMembersByUsername :many
+
+SELECT 
+    u.id,
+    u.username,
+    u.avatar_url,
+    u.display_name
 FROM memberships mem
-JOIN projects p ON mem.project_id = p.id
-WHERE mem.user_id = $1
-ORDER BY mem.joined_at DESC
+JOIN users u ON mem.user_id = u.id
+WHERE mem.project_id = $1
+  AND u.username ILIKE $2 || '%'
+ORDER BY u.username ASC
+LIMIT 10
+`
+
+type SearchMembersByUsernameParams struct {
+	ProjectID pgtype.UUID
+	Column2   pgtype.Text
+}
+
+type SearchMembersByUsernameRow struct {
+	ID          pgtype.UUID
+	Username    string
+	AvatarUrl   pgtype.Text
+	DisplayName pgtype.Text
+}
+
+// ============================================================================
+// MEMBER SEARCH (for @mentions autocomplete)
+// ============================================================================
+func (q *Queries) SearchMembersByUsername(ctx context.Context, arg SearchMembersByUsernameParams) ([]SearchMembersByUsernameRow, error) {
+	rows, err := q.db.Query(ctx, searchMembersByUsername, arg.ProjectID, arg.Column2)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchMembersByUsernameRow
+	for rows.Next() {
+		var i SearchMembersByUsernameRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.AvatarUrl,
+			&i.DisplayName,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchMessages = `-- name: SearchMessages :many
+
+SELECT m.id, m.content, m.created_at, m.project_id, p.name AS loop_name, m.channel_id, c.name AS channel_name,
+    m.sender_id, u.username AS sender_username, u.avatar_url AS sender_avatar
+FROM messages m
+JOIN memberships mem ON mem.project_id = m.project_id AND mem.user_id = $1
+JOIN projects p ON p.id = m.project_id
+JOIN channels c ON c.id = m.channel_id
+JOIN users u ON u.id = m.sender_id
+LEFT JOIN loop_settings ls ON ls.project_id = m.project_id
+WHERE m.content ILIKE '%' || $2 || '%'
+  AND COALESCE(m.is_deleted, FALSE) = FALSE
+  AND (mem.role IS DISTINCT FROM 'guest' OR m.channel_id = ANY(COALESCE(ls.guest_channel_ids, '{}')))
+  AND ($3::uuid IS NULL OR m.project_id = $3)
+  AND ($4::bigint IS NULL OR m.id < $4)
+ORDER BY m.id DESC
+LIMIT $5
+`
+
+type SearchMessagesParams struct {
+	UserID    pgtype.UUID
+	Pattern   pgtype.Text
+	ProjectID pgtype.UUID
+	BeforeID  pgtype.Int8
+	RowLimit  int32
+}
+
+type SearchMessagesRow struct {
+	ID             int64
+	Content        string
+	CreatedAt      pgtype.Timestamptz
+	ProjectID      pgtype.UUID
+	LoopName       string
+	ChannelID      pgtype.UUID
+	ChannelName    string
+	SenderID       pgtype.UUID
+	SenderUsername string
+	SenderAvatar   pgtype.Text
+}
+
+// MESSAGE SEARCH
+// Messages containing pattern (a LIKE pattern, wildcards escaped by the
+// caller) in the loops the user belongs to. Visibility is decided here, not
+// by the caller: guests only match their loop's guest channels, so counts
+// and keyset pages never include what the user can't read.
+func (q *Queries) SearchMessages(ctx context.Context, arg SearchMessagesParams) ([]SearchMessagesRow, error) {
+	rows, err := q.db.Query(ctx, searchMessages,
+		arg.UserID,
+		arg.Pattern,
+		arg.ProjectID,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchMessagesRow
+	for rows.Next() {
+		var i SearchMessagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Content,
+			&i.CreatedAt,
+			&i.ProjectID,
+			&i.LoopName,
+			&i.ChannelID,
+			&i.ChannelName,
+			&i.SenderID,
+			&i.SenderUsername,
+			&i.SenderAvatar,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchRepos = `-- name: SearchRepos :many
+SELECT id, name
+FROM projects
+WHERE name ILIKE $1 || '%'
+ORDER BY similarity(name, $1) DESC
+LIMIT $2
+`
+
+type SearchReposParams struct {
+	Q pgtype.Text
+	N int32
+}
+
+type SearchReposRow struct {
+	ID   pgtype.UUID
+	Name string
+}
+
+func (q *Queries) SearchRepos(ctx context.Context, arg SearchReposParams) ([]SearchReposRow, error) {
+	rows, err := q.db.Query(ctx, searchRepos, arg.Q, arg.N)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchReposRow
+	for rows.Next() {
+		var i SearchReposRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchReposFuzzy = `-- name: SearchReposFuzzy :many
+SELECT id, name
+FROM projects
+WHERE name % $1
+ORDER BY similarity(name, $1) DESC
+LIMIT $2
+`
+
+type SearchReposFuzzyParams struct {
+	Q string
+	N int32
+}
+
+type SearchReposFuzzyRow struct {
+	ID   pgtype.UUID
+	Name string
+}
+
+func (q *Queries) SearchReposFuzzy(ctx context.Context, arg SearchReposFuzzyParams) ([]SearchReposFuzzyRow, error) {
+	rows, err := q.db.Query(ctx, searchReposFuzzy, arg.Q, arg.N)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchReposFuzzyRow
+	for rows.Next() {
+		var i SearchReposFuzzyRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const seedRepoStats = `-- name: SeedRepoStats :exec
+
+INSERT INTO repo_stats (repo_id)
+SELECT DISTINCT github_repo_id FROM projects
+ON CONFLICT (repo_id) DO NOTHING
+`
+
+// ============================================================================
+// REPO STATS
+// ============================================================================
+// Adds an empty row for every linked repo that doesn't have one yet
+func (q *Queries) SeedRepoStats(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, seedRepoStats)
+	return err
+}
+
+const setAttachmentScanResult = `-- name: SetAttachmentScanResult :exec
+UPDATE attachments
+SET scan_status = $2, scan_engine = $3, scan_signature = $4, scanned_at = NOW()
+WHERE id = $1
+`
+
+type SetAttachmentScanResultParams struct {
+	ID            pgtype.UUID
+	ScanStatus    string
+	ScanEngine    pgtype.Text
+	ScanSignature pgtype.Text
+}
+
+func (q *Queries) SetAttachmentScanResult(ctx context.Context, arg SetAttachmentScanResultParams) error {
+	_, err := q.db.Exec(ctx, setAttachmentScanResult,
+		arg.ID,
+		arg.ScanStatus,
+		arg.ScanEngine,
+		arg.ScanSignature,
+	)
+	return err
+}
+
+const setBoardCardPosition = `-- name: SetBoardCardPosition :exec
+UPDATE board_cards
+SET column_id = $2, position = $3, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetBoardCardPositionParams struct {
+	ID       pgtype.UUID
+	ColumnID pgtype.UUID
+	Position int32
+}
+
+func (q *Queries) SetBoardCardPosition(ctx context.Context, arg SetBoardCardPositionParams) error {
+	_, err := q.db.Exec(ctx, setBoardCardPosition, arg.ID, arg.ColumnID, arg.Position)
+	return err
+}
+
+const setBoardColumnPosition = `-- name: SetBoardColumnPosition :exec
+UPDATE board_columns
+SET position = $3
+WHERE id = $1 AND project_id = $2
+`
+
+type SetBoardColumnPositionParams struct {
+	ID        pgtype.UUID
+	ProjectID pgtype.UUID
+	Position  int32
+}
+
+func (q *Queries) SetBoardColumnPosition(ctx context.Context, arg SetBoardColumnPositionParams) error {
+	_, err := q.db.Exec(ctx, setBoardColumnPosition, arg.ID, arg.ProjectID, arg.Position)
+	return err
+}
+
+const setChannelBanner = `-- name: SetChannelBanner :one
+INSERT INTO channel_topics (channel_id, project_id, banner, banner_set_by, banner_set_at, banner_expires_at)
+VALUES ($1, $2, $3, $4, NOW(), $5)
+ON CONFLICT (channel_id) DO UPDATE SET
+banner = EXCLUDED.banner,
+banner_set_by = EXCLUDED.banner_set_by,
+banner_set_at = NOW(),
+banner_expires_at = EXCLUDED.banner_expires_at
+RETURNING channel_id, project_id, topic, topic_set_by, topic_set_at, banner, banner_set_by, banner_set_at, banner_expires_at
 `
 
-type GetUserMembershipsRow struct {
+type SetChannelBannerParams struct {
+	ChannelID       pgtype.UUID
+	ProjectID       pgtype.UUID
+	Banner          string
+	BannerSetBy     pgtype.UUID
+	BannerExpiresAt pgtype.Timestamptz
+}
+
+// An empty banner clears it
+func (q *Queries) SetChannelBanner(ctx context.Context, arg SetChannelBannerParams) (ChannelTopic, error) {
+	row := q.db.QueryRow(ctx, setChannelBanner,
+		arg.ChannelID,
+		arg.ProjectID,
+		arg.Banner,
+		arg.BannerSetBy,
+		arg.BannerExpiresAt,
+	)
+	var i ChannelTopic
+	err := row.Scan(
+		&i.ChannelID,
+		&i.ProjectID,
+		&i.Topic,
+		&i.TopicSetBy,
+		&i.TopicSetAt,
+		&i.Banner,
+		&i.BannerSetBy,
+		&i.BannerSetAt,
+		&i.BannerExpiresAt,
+	)
+	return i, err
+}
+
+const setChannelDataPolicy = `-- name: SetChannelDataPolicy :one
+INSERT INTO channel_data_policies (channel_id, project_id, ai_summaries, exports, updated_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (channel_id) DO UPDATE SET
+ai_summaries = EXCLUDED.ai_summaries,
+exports = EXCLUDED.exports,
+updated_by = EXCLUDED.updated_by,
+updated_at = NOW()
+RETURNING channel_id, project_id, ai_summaries, exports, updated_by, updated_at
+`
+
+type SetChannelDataPolicyParams struct {
+	ChannelID   pgtype.UUID
 	ProjectID   pgtype.UUID
-	ProjectName string
-	Role        pgtype.Text
-	JoinedAt    pgtype.Timestamptz
+	AiSummaries bool
+	Exports     bool
+	UpdatedBy   pgtype.UUID
 }
 
-func (q *Queries) GetUserMemberships(ctx context.Context, userID pgtype.UUID) ([]GetUserMembershipsRow, error) {
-	rows, err := q.db.Query(ctx, getUserMemberships, userID)
+func (q *Queries) SetChannelDataPolicy(ctx context.Context, arg SetChannelDataPolicyParams) (ChannelDataPolicy, error) {
+	row := q.db.QueryRow(ctx, setChannelDataPolicy,
+		arg.ChannelID,
+		arg.ProjectID,
+		arg.AiSummaries,
+		arg.Exports,
+		arg.UpdatedBy,
+	)
+	var i ChannelDataPolicy
+	err := row.Scan(
+		&i.ChannelID,
+		&i.ProjectID,
+		&i.AiSummaries,
+		&i.Exports,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setChannelTopic = `-- name: SetChannelTopic :one
+INSERT INTO channel_topics (channel_id, project_id, topic, topic_set_by, topic_set_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (channel_id) DO UPDATE SET
+topic = EXCLUDED.topic,
+topic_set_by = EXCLUDED.topic_set_by,
+topic_set_at = NOW()
+RETURNING channel_id, project_id, topic, topic_set_by, topic_set_at, banner, banner_set_by, banner_set_at, banner_expires_at
+`
+
+type SetChannelTopicParams struct {
+	ChannelID  pgtype.UUID
+	ProjectID  pgtype.UUID
+	Topic      string
+	TopicSetBy pgtype.UUID
+}
+
+func (q *Queries) SetChannelTopic(ctx context.Context, arg SetChannelTopicParams) (ChannelTopic, error) {
+	row := q.db.QueryRow(ctx, setChannelTopic,
+		arg.ChannelID,
+		arg.ProjectID,
+		arg.Topic,
+		arg.TopicSetBy,
+	)
+	var i ChannelTopic
+	err := row.Scan(
+		&i.ChannelID,
+		&i.ProjectID,
+		&i.Topic,
+		&i.TopicSetBy,
+		&i.TopicSetAt,
+		&i.Banner,
+		&i.BannerSetBy,
+		&i.BannerSetAt,
+		&i.BannerExpiresAt,
+	)
+	return i, err
+}
+
+const setDMPrivacy = `-- name: SetDMPrivacy :exec
+INSERT INTO user_settings (user_id, dm_privacy)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET dm_privacy = EXCLUDED.dm_privacy, updated_at = NOW()
+`
+
+type SetDMPrivacyParams struct {
+	UserID    pgtype.UUID
+	DmPrivacy string
+}
+
+func (q *Queries) SetDMPrivacy(ctx context.Context, arg SetDMPrivacyParams) error {
+	_, err := q.db.Exec(ctx, setDMPrivacy, arg.UserID, arg.DmPrivacy)
+	return err
+}
+
+const setDefaultChannel = `-- name: SetDefaultChannel :exec
+UPDATE channels 
+SET is_default = (id = $2)
+WHERE project_id = $1
+`
+
+type SetDefaultChannelParams struct {
+	ProjectID pgtype.UUID
+	ID        pgtype.UUID
+}
+
+func (q *Queries) SetDefaultChannel(ctx context.Context, arg SetDefaultChannelParams) error {
+	_, err := q.db.Exec(ctx, setDefaultChannel, arg.ProjectID, arg.ID)
+	return err
+}
+
+const setLoopGithubToken = `-- name: SetLoopGithubToken :one
+INSERT INTO loop_github_tokens (project_id, source, installation_id, updated_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (project_id) DO UPDATE SET
+source = EXCLUDED.source,
+installation_id = EXCLUDED.installation_id,
+updated_by = EXCLUDED.updated_by,
+updated_at = NOW()
+RETURNING project_id, source, installation_id, updated_by, updated_at
+`
+
+type SetLoopGithubTokenParams struct {
+	ProjectID      pgtype.UUID
+	Source         string
+	InstallationID pgtype.Int8
+	UpdatedBy      pgtype.UUID
+}
+
+func (q *Queries) SetLoopGithubToken(ctx context.Context, arg SetLoopGithubTokenParams) (LoopGithubToken, error) {
+	row := q.db.QueryRow(ctx, setLoopGithubToken,
+		arg.ProjectID,
+		arg.Source,
+		arg.InstallationID,
+		arg.UpdatedBy,
+	)
+	var i LoopGithubToken
+	err := row.Scan(
+		&i.ProjectID,
+		&i.Source,
+		&i.InstallationID,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setMemberNotifyLevel = `-- name: SetMemberNotifyLevel :execrows
+
+UPDATE memberships SET notify_level = $3
+WHERE user_id = $1 AND project_id = $2
+`
+
+type SetMemberNotifyLevelParams struct {
+	UserID      pgtype.UUID
+	ProjectID   pgtype.UUID
+	NotifyLevel string
+}
+
+// ============================================================================
+// NOTIFICATION LEVELS
+// ============================================================================
+func (q *Queries) SetMemberNotifyLevel(ctx context.Context, arg SetMemberNotifyLevelParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setMemberNotifyLevel, arg.UserID, arg.ProjectID, arg.NotifyLevel)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
-	defer rows.Close()
-	var items []GetUserMembershipsRow
-	for rows.Next() {
-		var i GetUserMembershipsRow
-		if err := rows.Scan(
-			&i.ProjectID,
-			&i.ProjectName,
-			&i.Role,
-			&i.JoinedAt,
-		); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+	return result.RowsAffected(), nil
+}
+
+const setMembershipRole = `-- name: SetMembershipRole :execrows
+UPDATE memberships SET role = $3
+WHERE user_id = $1 AND project_id = $2
+`
+
+type SetMembershipRoleParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+	Role      pgtype.Text
+}
+
+func (q *Queries) SetMembershipRole(ctx context.Context, arg SetMembershipRoleParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setMembershipRole, arg.UserID, arg.ProjectID, arg.Role)
+	if err != nil {
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+	return result.RowsAffected(), nil
+}
+
+const setMembershipSortOrder = `-- name: SetMembershipSortOrder :exec
+UPDATE memberships
+SET sort_order = $3
+WHERE user_id = $1 AND project_id = $2
+`
+
+type SetMembershipSortOrderParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+	SortOrder pgtype.Int4
+}
+
+func (q *Queries) SetMembershipSortOrder(ctx context.Context, arg SetMembershipSortOrderParams) error {
+	_, err := q.db.Exec(ctx, setMembershipSortOrder, arg.UserID, arg.ProjectID, arg.SortOrder)
+	return err
+}
+
+const setNotificationDeliveryState = `-- name: SetNotificationDeliveryState :execrows
+UPDATE notification_deliveries
+SET state = $2, transport = $3, last_error = $4, attempts = attempts + 1, updated_at = NOW()
+WHERE notification_id = $1 AND state IN ('pending', 'retrying')
+`
+
+type SetNotificationDeliveryStateParams struct {
+	NotificationID int64
+	State          string
+	Transport      pgtype.Text
+	LastError      pgtype.Text
+}
+
+// Only while the notification is still waiting, so a racing ack wins
+func (q *Queries) SetNotificationDeliveryState(ctx context.Context, arg SetNotificationDeliveryStateParams) (int64, error) {
+	result, err := q.db.Exec(ctx, setNotificationDeliveryState,
+		arg.NotificationID,
+		arg.State,
+		arg.Transport,
+		arg.LastError,
+	)
+	if err != nil {
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const getUserProfile = `-- name: GetUserProfile :one
-SELECT
-id,
-github_id,
-username,
-avatar_url,
-display_name,
-profile_completed,
-created_at
-FROM users WHERE id = $1 LIMIT 1
+const setNotificationFallback = `-- name: SetNotificationFallback :exec
+INSERT INTO user_settings (user_id, notify_fallback)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET notify_fallback = EXCLUDED.notify_fallback, updated_at = NOW()
 `
 
-type GetUserProfileRow struct {
-	ID               pgtype.UUID
-	GithubID         int64
-	Username         string
-	AvatarUrl        pgtype.Text
-	DisplayName      pgtype.Text
-	ProfileCompleted pgtype.Bool
-	CreatedAt        pgtype.Timestamptz
+type SetNotificationFallbackParams struct {
+	UserID         pgtype.UUID
+	NotifyFallback []string
+}
+
+func (q *Queries) SetNotificationFallback(ctx context.Context, arg SetNotificationFallbackParams) error {
+	_, err := q.db.Exec(ctx, setNotificationFallback, arg.UserID, arg.NotifyFallback)
+	return err
+}
+
+const setPresencePrivacy = `-- name: SetPresencePrivacy :exec
+INSERT INTO user_settings (user_id, send_typing, show_presence)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET send_typing = EXCLUDED.send_typing, show_presence = EXCLUDED.show_presence, updated_at = NOW()
+`
+
+type SetPresencePrivacyParams struct {
+	UserID       pgtype.UUID
+	SendTyping   bool
+	ShowPresence bool
+}
+
+func (q *Queries) SetPresencePrivacy(ctx context.Context, arg SetPresencePrivacyParams) error {
+	_, err := q.db.Exec(ctx, setPresencePrivacy, arg.UserID, arg.SendTyping, arg.ShowPresence)
+	return err
+}
+
+const setTaskGithubIssue = `-- name: SetTaskGithubIssue :exec
+UPDATE tasks
+SET github_issue_number = $2
+WHERE id = $1
+`
+
+type SetTaskGithubIssueParams struct {
+	ID                pgtype.UUID
+	GithubIssueNumber pgtype.Int4
+}
+
+func (q *Queries) SetTaskGithubIssue(ctx context.Context, arg SetTaskGithubIssueParams) error {
+	_, err := q.db.Exec(ctx, setTaskGithubIssue, arg.ID, arg.GithubIssueNumber)
+	return err
+}
+
+const setUndoSendSeconds = `-- name: SetUndoSendSeconds :exec
+INSERT INTO user_settings (user_id, undo_send_seconds)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET undo_send_seconds = EXCLUDED.undo_send_seconds, updated_at = NOW()
+`
+
+type SetUndoSendSecondsParams struct {
+	UserID          pgtype.UUID
+	UndoSendSeconds int32
+}
+
+func (q *Queries) SetUndoSendSeconds(ctx context.Context, arg SetUndoSendSecondsParams) error {
+	_, err := q.db.Exec(ctx, setUndoSendSeconds, arg.UserID, arg.UndoSendSeconds)
+	return err
+}
+
+const setUserLocale = `-- name: SetUserLocale :exec
+INSERT INTO user_settings (user_id, locale)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET locale = EXCLUDED.locale, updated_at = NOW()
+`
+
+type SetUserLocaleParams struct {
+	UserID pgtype.UUID
+	Locale pgtype.Text
+}
+
+func (q *Queries) SetUserLocale(ctx context.Context, arg SetUserLocaleParams) error {
+	_, err := q.db.Exec(ctx, setUserLocale, arg.UserID, arg.Locale)
+	return err
+}
+
+const setUserTimezone = `-- name: SetUserTimezone :exec
+INSERT INTO user_settings (user_id, timezone)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone, updated_at = NOW()
+`
+
+type SetUserTimezoneParams struct {
+	UserID   pgtype.UUID
+	Timezone pgtype.Text
+}
+
+func (q *Queries) SetUserTimezone(ctx context.Context, arg SetUserTimezoneParams) error {
+	_, err := q.db.Exec(ctx, setUserTimezone, arg.UserID, arg.Timezone)
+	return err
+}
+
+const sharesLoop = `-- name: SharesLoop :one
+SELECT EXISTS (
+    SELECT 1 FROM memberships a
+    JOIN memberships b ON a.project_id = b.project_id
+    WHERE a.user_id = $1 AND b.user_id = $2
+)
+`
+
+type SharesLoopParams struct {
+	UserA pgtype.UUID
+	UserB pgtype.UUID
+}
+
+func (q *Queries) SharesLoop(ctx context.Context, arg SharesLoopParams) (bool, error) {
+	row := q.db.QueryRow(ctx, sharesLoop, arg.UserA, arg.UserB)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const softDeleteMessage = `-- name: SoftDeleteMessage :exec
+UPDATE messages 
+SET is_deleted = TRUE, deleted_at = NOW(), content = '[Message deleted]'
+WHERE id = $1
+`
+
+func (q *Queries) SoftDeleteMessage(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, softDeleteMessage, id)
+	return err
+}
+
+const startNotificationDelivery = `-- name: StartNotificationDelivery :exec
+
+INSERT INTO notification_deliveries (notification_id, user_id, state, pushed_at)
+VALUES ($1, $2, 'pending', NOW())
+ON CONFLICT (notification_id) DO UPDATE SET
+state = 'pending',
+pushed_at = NOW(),
+acked_at = NULL,
+transport = NULL,
+attempts = 0,
+last_error = NULL,
+updated_at = NOW()
+`
+
+type StartNotificationDeliveryParams struct {
+	NotificationID int64
+	UserID         pgtype.UUID
+}
+
+// NOTIFICATION DELIVERY
+// A batched notification pushed again waits for a new ack
+func (q *Queries) StartNotificationDelivery(ctx context.Context, arg StartNotificationDeliveryParams) error {
+	_, err := q.db.Exec(ctx, startNotificationDelivery, arg.NotificationID, arg.UserID)
+	return err
+}
+
+const subscribeGithubItem = `-- name: SubscribeGithubItem :one
+
+INSERT INTO github_item_subscriptions (project_id, user_id, github_number, kind, channel_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (project_id, github_number, user_id) DO UPDATE SET
+kind = EXCLUDED.kind,
+channel_id = EXCLUDED.channel_id
+RETURNING project_id, user_id, github_number, kind, channel_id, created_at
+`
+
+type SubscribeGithubItemParams struct {
+	ProjectID    pgtype.UUID
+	UserID       pgtype.UUID
+	GithubNumber int32
+	Kind         string
+	ChannelID    pgtype.UUID
+}
+
+// GITHUB ITEM SUBSCRIPTIONS
+func (q *Queries) SubscribeGithubItem(ctx context.Context, arg SubscribeGithubItemParams) (GithubItemSubscription, error) {
+	row := q.db.QueryRow(ctx, subscribeGithubItem,
+		arg.ProjectID,
+		arg.UserID,
+		arg.GithubNumber,
+		arg.Kind,
+		arg.ChannelID,
+	)
+	var i GithubItemSubscription
+	err := row.Scan(
+		&i.ProjectID,
+		&i.UserID,
+		&i.GithubNumber,
+		&i.Kind,
+		&i.ChannelID,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const syncBoardCardIssue = `-- name: SyncBoardCardIssue :exec
+UPDATE board_cards
+SET title = $3, github_state = $4, updated_at = NOW()
+WHERE project_id = $1 AND github_issue_number = $2
+`
+
+type SyncBoardCardIssueParams struct {
+	ProjectID         pgtype.UUID
+	GithubIssueNumber pgtype.Int4
+	Title             string
+	GithubState       pgtype.Text
+}
+
+func (q *Queries) SyncBoardCardIssue(ctx context.Context, arg SyncBoardCardIssueParams) error {
+	_, err := q.db.Exec(ctx, syncBoardCardIssue,
+		arg.ProjectID,
+		arg.GithubIssueNumber,
+		arg.Title,
+		arg.GithubState,
+	)
+	return err
+}
+
+const syncUserFromGitHub = `-- name: SyncUserFromGitHub :one
+UPDATE users SET
+username = CASE WHEN username_changed_at IS NULL THEN $1 ELSE username END,
+avatar_url = CASE
+    WHEN $2::boolean OR avatar_url IS NULL
+         OR (avatar_url NOT LIKE '/api/media/%' AND avatar_url NOT LIKE 'data:%')
+    THEN $3::text
+    ELSE avatar_url
+END,
+display_name = CASE
+    WHEN $2::boolean OR display_name IS NULL
+    THEN COALESCE($4, display_name)
+    ELSE display_name
+END,
+github_synced_at = NOW(),
+updated_at = NOW()
+WHERE id = $5
+RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at
+`
+
+type SyncUserFromGitHubParams struct {
+	Username      string
+	ReplaceCustom bool
+	AvatarUrl     string
+	DisplayName   pgtype.Text
+	ID            pgtype.UUID
 }
 
-func (q *Queries) GetUserProfile(ctx context.Context, id pgtype.UUID) (GetUserProfileRow, error) {
-	row := q.db.QueryRow(ctx, getUserProfile, id)
-	var i GetUserProfileRow
+// Uploaded avatars and custom display names are kept unless replace_custom is set;
+// a username chosen in Wireloop is never overwritten
+func (q *Queries) SyncUserFromGitHub(ctx context.Context, arg SyncUserFromGitHubParams) (User, error) {
+	row := q.db.QueryRow(ctx, syncUserFromGitHub,
+		arg.Username,
+		arg.ReplaceCustom,
+		arg.AvatarUrl,
+		arg.DisplayName,
+		arg.ID,
+	)
+	var i User
 	err := row.Scan(
 		&i.ID,
 		&i.GithubID,
 		&i.Username,
 		&i.AvatarUrl,
 		&i.DisplayName,
+		&i.AccessToken,
 		&i.ProfileCompleted,
 		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
 	)
 	return i, err
 }
 
-const hardDeleteMessage = `-- name: HardDeleteMessage :exec
-DELETE FROM messages WHERE id = $1
+const touchAPIKey = `-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = NOW() WHERE id = $1
 `
 
-func (q *Queries) HardDeleteMessage(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, hardDeleteMessage, id)
+func (q *Queries) TouchAPIKey(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchAPIKey, id)
 	return err
 }
 
-const incrementReplyCount = `-- name: IncrementReplyCount :exec
-UPDATE messages SET reply_count = reply_count + 1 WHERE id = $1
+const touchDMConversation = `-- name: TouchDMConversation :exec
+UPDATE dm_conversations SET last_message_at = NOW() WHERE id = $1
 `
 
-func (q *Queries) IncrementReplyCount(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, incrementReplyCount, id)
+func (q *Queries) TouchDMConversation(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchDMConversation, id)
 	return err
 }
 
-const isMember = `-- name: IsMember :one
-SELECT 1 FROM memberships
-WHERE user_id = $1 AND project_id = $2 LIMIT 1
+const touchIncomingWebhook = `-- name: TouchIncomingWebhook :exec
+UPDATE incoming_webhooks SET last_used_at = NOW() WHERE id = $1
 `
 
-type IsMemberParams struct {
-	UserID    pgtype.UUID
-	ProjectID pgtype.UUID
+func (q *Queries) TouchIncomingWebhook(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchIncomingWebhook, id)
+	return err
 }
 
-func (q *Queries) IsMember(ctx context.Context, arg IsMemberParams) (int32, error) {
-	row := q.db.QueryRow(ctx, isMember, arg.UserID, arg.ProjectID)
-	var column_1 int32
-	err := row.Scan(&column_1)
-	return column_1, err
-}
+const touchMemberActivity = `-- name: TouchMemberActivity :exec
 
-const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :exec
-UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND is_read = FALSE
+UPDATE memberships SET last_active_at = NOW()
+WHERE user_id = $1 AND project_id = $2
 `
 
-func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID pgtype.UUID) error {
-	_, err := q.db.Exec(ctx, markAllNotificationsRead, userID)
+type TouchMemberActivityParams struct {
+	UserID    pgtype.UUID
+	ProjectID pgtype.UUID
+}
+
+// MEMBER ACTIVITY
+func (q *Queries) TouchMemberActivity(ctx context.Context, arg TouchMemberActivityParams) error {
+	_, err := q.db.Exec(ctx, touchMemberActivity, arg.UserID, arg.ProjectID)
 	return err
 }
 
-const markNotificationRead = `-- name: MarkNotificationRead :exec
-UPDATE notifications SET is_read = TRUE WHERE id = $1 AND user_id = $2
+const touchPersonalAccessToken = `-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens SET last_used_at = NOW() WHERE id = $1
 `
 
-type MarkNotificationReadParams struct {
-	ID     int64
-	UserID pgtype.UUID
-}
-
-func (q *Queries) MarkNotificationRead(ctx context.Context, arg MarkNotificationReadParams) error {
-	_, err := q.db.Exec(ctx, markNotificationRead, arg.ID, arg.UserID)
+func (q *Queries) TouchPersonalAccessToken(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchPersonalAccessToken, id)
 	return err
 }
 
-const pinMessage = `-- name: PinMessage :exec
-
-UPDATE messages 
-SET is_pinned = TRUE, pinned_by = $2, pinned_at = NOW()
-WHERE id = $1
+const touchSession = `-- name: TouchSession :exec
+UPDATE sessions SET last_seen_at = NOW() WHERE id = $1
 `
 
-type PinMessageParams struct {
-	ID       int64
-	PinnedBy pgtype.UUID
-}
-
-// ============================================================================
-// PINNED MESSAGES
-// ============================================================================
-func (q *Queries) PinMessage(ctx context.Context, arg PinMessageParams) error {
-	_, err := q.db.Exec(ctx, pinMessage, arg.ID, arg.PinnedBy)
+func (q *Queries) TouchSession(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, touchSession, id)
 	return err
 }
 
-const searchMembersByUsername = `-- name: SearchMembersByUsername :many
-
-SELECT 
-    u.id,
-    u.username,
-    u.avatar_url,
-    u.display_name
-FROM memberships mem
-JOIN users u ON mem.user_id = u.id
-WHERE mem.project_id = $1
-  AND u.username ILIKE $2 || '%'
-ORDER BY u.username ASC
-LIMIT 10
+const transferOwnedLoops = `-- name: TransferOwnedLoops :many
+UPDATE projects SET owner_id = $1 WHERE owner_id = $2
+RETURNING id, name
 `
 
-type SearchMembersByUsernameParams struct {
-	ProjectID pgtype.UUID
-	Column2   pgtype.Text
+type TransferOwnedLoopsParams struct {
+	SurvivorID pgtype.UUID
+	MergedID   pgtype.UUID
 }
 
-type SearchMembersByUsernameRow struct {
-	ID          pgtype.UUID
-	Username    string
-	AvatarUrl   pgtype.Text
-	DisplayName pgtype.Text
+type TransferOwnedLoopsRow struct {
+	ID   pgtype.UUID
+	Name string
 }
 
-// ============================================================================
-// MEMBER SEARCH (for @mentions autocomplete)
-// ============================================================================
-func (q *Queries) SearchMembersByUsername(ctx context.Context, arg SearchMembersByUsernameParams) ([]SearchMembersByUsernameRow, error) {
-	rows, err := q.db.Query(ctx, searchMembersByUsername, arg.ProjectID, arg.Column2)
+func (q *Queries) TransferOwnedLoops(ctx context.Context, arg TransferOwnedLoopsParams) ([]TransferOwnedLoopsRow, error) {
+	rows, err := q.db.Query(ctx, transferOwnedLoops, arg.SurvivorID, arg.MergedID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []SearchMembersByUsernameRow
+	var items []TransferOwnedLoopsRow
 	for rows.Next() {
-		var i SearchMembersByUsernameRow
-		if err := rows.Scan(
-			&i.ID,
-			&i.Username,
-			&i.AvatarUrl,
-			&i.DisplayName,
-		); err != nil {
+		var i TransferOwnedLoopsRow
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -1255,152 +11506,384 @@ func (q *Queries) SearchMembersByUsername(ctx context.Context, arg SearchMembers
 	return items, nil
 }
 
-const searchRepos = `-- name: SearchRepos :many
-SELECT id, name
-FROM projects
-WHERE name ILIKE $1 || '%'
-ORDER BY similarity(name, $1) DESC
-LIMIT $2
+const unblockUser = `-- name: UnblockUser :exec
+DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2
 `
 
-type SearchReposParams struct {
-	Q pgtype.Text
-	N int32
+type UnblockUserParams struct {
+	BlockerID pgtype.UUID
+	BlockedID pgtype.UUID
 }
 
-type SearchReposRow struct {
-	ID   pgtype.UUID
-	Name string
+func (q *Queries) UnblockUser(ctx context.Context, arg UnblockUserParams) error {
+	_, err := q.db.Exec(ctx, unblockUser, arg.BlockerID, arg.BlockedID)
+	return err
 }
 
-func (q *Queries) SearchRepos(ctx context.Context, arg SearchReposParams) ([]SearchReposRow, error) {
-	rows, err := q.db.Query(ctx, searchRepos, arg.Q, arg.N)
+const uncompleteOnboardingStep = `-- name: UncompleteOnboardingStep :exec
+DELETE FROM onboarding_progress WHERE step_id = $1 AND user_id = $2
+`
+
+type UncompleteOnboardingStepParams struct {
+	StepID pgtype.UUID
+	UserID pgtype.UUID
+}
+
+func (q *Queries) UncompleteOnboardingStep(ctx context.Context, arg UncompleteOnboardingStepParams) error {
+	_, err := q.db.Exec(ctx, uncompleteOnboardingStep, arg.StepID, arg.UserID)
+	return err
+}
+
+const unpinMessage = `-- name: UnpinMessage :exec
+UPDATE messages 
+SET is_pinned = FALSE, pinned_by = NULL, pinned_at = NULL
+WHERE id = $1
+`
+
+func (q *Queries) UnpinMessage(ctx context.Context, id int64) error {
+	_, err := q.db.Exec(ctx, unpinMessage, id)
+	return err
+}
+
+const unsubscribeGithubItem = `-- name: UnsubscribeGithubItem :execrows
+DELETE FROM github_item_subscriptions
+WHERE project_id = $1 AND github_number = $2 AND user_id = $3
+`
+
+type UnsubscribeGithubItemParams struct {
+	ProjectID    pgtype.UUID
+	GithubNumber int32
+	UserID       pgtype.UUID
+}
+
+func (q *Queries) UnsubscribeGithubItem(ctx context.Context, arg UnsubscribeGithubItemParams) (int64, error) {
+	result, err := q.db.Exec(ctx, unsubscribeGithubItem, arg.ProjectID, arg.GithubNumber, arg.UserID)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []SearchReposRow
-	for rows.Next() {
-		var i SearchReposRow
-		if err := rows.Scan(&i.ID, &i.Name); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
-	}
-	if err := rows.Err(); err != nil {
-		return nil, err
+		return 0, err
 	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const searchReposFuzzy = `-- name: SearchReposFuzzy :many
-SELECT id, name
-FROM projects
-WHERE name % $1
-ORDER BY similarity(name, $1) DESC
-LIMIT $2
+const updateBoardCard = `-- name: UpdateBoardCard :one
+UPDATE board_cards
+SET title = $2, body = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING id, project_id, column_id, title, body, github_issue_number, github_state, position, created_by, created_at, updated_at
 `
 
-type SearchReposFuzzyParams struct {
-	Q string
-	N int32
+type UpdateBoardCardParams struct {
+	ID    pgtype.UUID
+	Title string
+	Body  pgtype.Text
 }
 
-type SearchReposFuzzyRow struct {
-	ID   pgtype.UUID
-	Name string
+func (q *Queries) UpdateBoardCard(ctx context.Context, arg UpdateBoardCardParams) (BoardCard, error) {
+	row := q.db.QueryRow(ctx, updateBoardCard, arg.ID, arg.Title, arg.Body)
+	var i BoardCard
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.ColumnID,
+		&i.Title,
+		&i.Body,
+		&i.GithubIssueNumber,
+		&i.GithubState,
+		&i.Position,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateChannel = `-- name: UpdateChannel :one
+UPDATE channels SET
+    name = COALESCE($2, name),
+    description = COALESCE($3, description),
+    position = COALESCE($4, position),
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, project_id, name, description, is_default, position, created_at, updated_at
+`
+
+type UpdateChannelParams struct {
+	ID          pgtype.UUID
+	Name        string
+	Description pgtype.Text
+	Position    pgtype.Int4
+}
+
+func (q *Queries) UpdateChannel(ctx context.Context, arg UpdateChannelParams) (Channel, error) {
+	row := q.db.QueryRow(ctx, updateChannel,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Position,
+	)
+	var i Channel
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Name,
+		&i.Description,
+		&i.IsDefault,
+		&i.Position,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateEvent = `-- name: UpdateEvent :one
+UPDATE events
+SET title = $2, description = $3, kind = $4, starts_at = $5, ends_at = $6,
+    recurrence = $7, recurrence_until = $8, remind_minutes = $9, updated_at = NOW()
+WHERE id = $1
+RETURNING id, project_id, title, description, kind, starts_at, ends_at, recurrence, recurrence_until, remind_minutes, created_by, created_at, updated_at
+`
+
+type UpdateEventParams struct {
+	ID              pgtype.UUID
+	Title           string
+	Description     pgtype.Text
+	Kind            string
+	StartsAt        pgtype.Timestamptz
+	EndsAt          pgtype.Timestamptz
+	Recurrence      string
+	RecurrenceUntil pgtype.Timestamptz
+	RemindMinutes   int32
+}
+
+func (q *Queries) UpdateEvent(ctx context.Context, arg UpdateEventParams) (Event, error) {
+	row := q.db.QueryRow(ctx, updateEvent,
+		arg.ID,
+		arg.Title,
+		arg.Description,
+		arg.Kind,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Recurrence,
+		arg.RecurrenceUntil,
+		arg.RemindMinutes,
+	)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Title,
+		&i.Description,
+		&i.Kind,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Recurrence,
+		&i.RecurrenceUntil,
+		&i.RemindMinutes,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateLoopIntegration = `-- name: UpdateLoopIntegration :one
+UPDATE loop_integrations
+SET name = $2, config = $3, topics = $4, enabled = $5, credentials_ref = $6, updated_at = NOW()
+WHERE id = $1
+RETURNING id, project_id, type, name, config, topics, enabled, credentials_ref, created_by, created_at, updated_at
+`
+
+type UpdateLoopIntegrationParams struct {
+	ID             pgtype.UUID
+	Name           string
+	Config         []byte
+	Topics         []string
+	Enabled        bool
+	CredentialsRef pgtype.Text
+}
+
+func (q *Queries) UpdateLoopIntegration(ctx context.Context, arg UpdateLoopIntegrationParams) (LoopIntegration, error) {
+	row := q.db.QueryRow(ctx, updateLoopIntegration,
+		arg.ID,
+		arg.Name,
+		arg.Config,
+		arg.Topics,
+		arg.Enabled,
+		arg.CredentialsRef,
+	)
+	var i LoopIntegration
+	err := row.Scan(
+		&i.ID,
+		&i.ProjectID,
+		&i.Type,
+		&i.Name,
+		&i.Config,
+		&i.Topics,
+		&i.Enabled,
+		&i.CredentialsRef,
+		&i.CreatedBy,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateMembershipPreferences = `-- name: UpdateMembershipPreferences :execrows
+UPDATE memberships
+SET is_favorite = $3, sidebar_collapsed = $4
+WHERE user_id = $1 AND project_id = $2
+`
+
+type UpdateMembershipPreferencesParams struct {
+	UserID           pgtype.UUID
+	ProjectID        pgtype.UUID
+	IsFavorite       bool
+	SidebarCollapsed bool
 }
 
-func (q *Queries) SearchReposFuzzy(ctx context.Context, arg SearchReposFuzzyParams) ([]SearchReposFuzzyRow, error) {
-	rows, err := q.db.Query(ctx, searchReposFuzzy, arg.Q, arg.N)
+func (q *Queries) UpdateMembershipPreferences(ctx context.Context, arg UpdateMembershipPreferencesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateMembershipPreferences,
+		arg.UserID,
+		arg.ProjectID,
+		arg.IsFavorite,
+		arg.SidebarCollapsed,
+	)
 	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	var items []SearchReposFuzzyRow
-	for rows.Next() {
-		var i SearchReposFuzzyRow
-		if err := rows.Scan(&i.ID, &i.Name); err != nil {
-			return nil, err
-		}
-		items = append(items, i)
+		return 0, err
 	}
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-	return items, nil
+	return result.RowsAffected(), nil
 }
 
-const setDefaultChannel = `-- name: SetDefaultChannel :exec
-UPDATE channels 
-SET is_default = (id = $2)
-WHERE project_id = $1
+const updateOnboardingStep = `-- name: UpdateOnboardingStep :execrows
+UPDATE onboarding_steps
+SET title = $3, description = $4, kind = $5, channel_id = $6, position = $7
+WHERE id = $1 AND project_id = $2
 `
 
-type SetDefaultChannelParams struct {
-	ProjectID pgtype.UUID
-	ID        pgtype.UUID
+type UpdateOnboardingStepParams struct {
+	ID          pgtype.UUID
+	ProjectID   pgtype.UUID
+	Title       string
+	Description string
+	Kind        string
+	ChannelID   pgtype.UUID
+	Position    int32
 }
 
-func (q *Queries) SetDefaultChannel(ctx context.Context, arg SetDefaultChannelParams) error {
-	_, err := q.db.Exec(ctx, setDefaultChannel, arg.ProjectID, arg.ID)
-	return err
+// project_id in the WHERE clause ignores steps from other loops
+func (q *Queries) UpdateOnboardingStep(ctx context.Context, arg UpdateOnboardingStepParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateOnboardingStep,
+		arg.ID,
+		arg.ProjectID,
+		arg.Title,
+		arg.Description,
+		arg.Kind,
+		arg.ChannelID,
+		arg.Position,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
-const softDeleteMessage = `-- name: SoftDeleteMessage :exec
-UPDATE messages 
-SET is_deleted = TRUE, deleted_at = NOW(), content = '[Message deleted]'
+const updateProjectRepo = `-- name: UpdateProjectRepo :one
+UPDATE projects
+SET github_repo_id = $2
 WHERE id = $1
+RETURNING id, github_repo_id, name, owner_id, created_at, workspace_id
 `
 
-func (q *Queries) SoftDeleteMessage(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, softDeleteMessage, id)
-	return err
+type UpdateProjectRepoParams struct {
+	ID           pgtype.UUID
+	GithubRepoID int64
 }
 
-const unpinMessage = `-- name: UnpinMessage :exec
-UPDATE messages 
-SET is_pinned = FALSE, pinned_by = NULL, pinned_at = NULL
-WHERE id = $1
+func (q *Queries) UpdateProjectRepo(ctx context.Context, arg UpdateProjectRepoParams) (Project, error) {
+	row := q.db.QueryRow(ctx, updateProjectRepo, arg.ID, arg.GithubRepoID)
+	var i Project
+	err := row.Scan(
+		&i.ID,
+		&i.GithubRepoID,
+		&i.Name,
+		&i.OwnerID,
+		&i.CreatedAt,
+		&i.WorkspaceID,
+	)
+	return i, err
+}
+
+const updateRepoStats = `-- name: UpdateRepoStats :exec
+UPDATE repo_stats
+SET full_name = $2, stars = $3, forks = $4, contributors = $5, pushed_at = $6, refreshed_at = NOW()
+WHERE repo_id = $1
 `
 
-func (q *Queries) UnpinMessage(ctx context.Context, id int64) error {
-	_, err := q.db.Exec(ctx, unpinMessage, id)
+type UpdateRepoStatsParams struct {
+	RepoID       int64
+	FullName     string
+	Stars        int32
+	Forks        int32
+	Contributors int32
+	PushedAt     pgtype.Timestamptz
+}
+
+func (q *Queries) UpdateRepoStats(ctx context.Context, arg UpdateRepoStatsParams) error {
+	_, err := q.db.Exec(ctx, updateRepoStats,
+		arg.RepoID,
+		arg.FullName,
+		arg.Stars,
+		arg.Forks,
+		arg.Contributors,
+		arg.PushedAt,
+	)
 	return err
 }
 
-const updateChannel = `-- name: UpdateChannel :one
-UPDATE channels SET
-    name = COALESCE($2, name),
-    description = COALESCE($3, description),
-    position = COALESCE($4, position),
-    updated_at = NOW()
+const updateStandup = `-- name: UpdateStandup :one
+UPDATE standups
+SET channel_id = $2, name = $3, questions = $4, prompt_time = $5, timezone = $6,
+    weekdays = $7, collect_minutes = $8, enabled = $9, updated_at = NOW()
 WHERE id = $1
-RETURNING id, project_id, name, description, is_default, position, created_at, updated_at
+RETURNING id, project_id, channel_id, name, questions, prompt_time, timezone, weekdays, collect_minutes, enabled, created_by, created_at, updated_at
 `
 
-type UpdateChannelParams struct {
-	ID          pgtype.UUID
-	Name        string
-	Description pgtype.Text
-	Position    pgtype.Int4
+type UpdateStandupParams struct {
+	ID             pgtype.UUID
+	ChannelID      pgtype.UUID
+	Name           string
+	Questions      []string
+	PromptTime     string
+	Timezone       string
+	Weekdays       int32
+	CollectMinutes int32
+	Enabled        bool
 }
 
-func (q *Queries) UpdateChannel(ctx context.Context, arg UpdateChannelParams) (Channel, error) {
-	row := q.db.QueryRow(ctx, updateChannel,
+func (q *Queries) UpdateStandup(ctx context.Context, arg UpdateStandupParams) (Standup, error) {
+	row := q.db.QueryRow(ctx, updateStandup,
 		arg.ID,
+		arg.ChannelID,
 		arg.Name,
-		arg.Description,
-		arg.Position,
+		arg.Questions,
+		arg.PromptTime,
+		arg.Timezone,
+		arg.Weekdays,
+		arg.CollectMinutes,
+		arg.Enabled,
 	)
-	var i Channel
+	var i Standup
 	err := row.Scan(
 		&i.ID,
 		&i.ProjectID,
+		&i.ChannelID,
 		&i.Name,
-		&i.Description,
-		&i.IsDefault,
-		&i.Position,
+		&i.Questions,
+		&i.PromptTime,
+		&i.Timezone,
+		&i.Weekdays,
+		&i.CollectMinutes,
+		&i.Enabled,
+		&i.CreatedBy,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 	)
@@ -1412,7 +11895,7 @@ UPDATE users SET
 avatar_url = $2,
 updated_at = NOW()
 WHERE id = $1
-RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at
+RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at
 `
 
 type UpdateUserAvatarParams struct {
@@ -1433,6 +11916,8 @@ func (q *Queries) UpdateUserAvatar(ctx context.Context, arg UpdateUserAvatarPara
 		&i.ProfileCompleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
 	)
 	return i, err
 }
@@ -1443,7 +11928,7 @@ display_name = COALESCE($2, display_name),
 profile_completed = TRUE,
 updated_at = NOW()
 WHERE id = $1
-RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at
+RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at
 `
 
 type UpdateUserProfileParams struct {
@@ -1464,6 +11949,559 @@ func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfilePa
 		&i.ProfileCompleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
+	)
+	return i, err
+}
+
+const updateWorkspace = `-- name: UpdateWorkspace :one
+UPDATE workspaces
+SET name = $2, default_role = $3, billing_owner_id = $4
+WHERE id = $1
+RETURNING id, slug, name, billing_owner_id, personal, default_role, created_at
+`
+
+type UpdateWorkspaceParams struct {
+	ID             pgtype.UUID
+	Name           string
+	DefaultRole    string
+	BillingOwnerID pgtype.UUID
+}
+
+func (q *Queries) UpdateWorkspace(ctx context.Context, arg UpdateWorkspaceParams) (Workspace, error) {
+	row := q.db.QueryRow(ctx, updateWorkspace,
+		arg.ID,
+		arg.Name,
+		arg.DefaultRole,
+		arg.BillingOwnerID,
+	)
+	var i Workspace
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.Name,
+		&i.BillingOwnerID,
+		&i.Personal,
+		&i.DefaultRole,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const upsertAccountQuota = `-- name: UpsertAccountQuota :one
+INSERT INTO account_quotas (user_id, max_loops, messages_per_day, storage_bytes, note, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW())
+ON CONFLICT (user_id) DO UPDATE SET
+    max_loops = EXCLUDED.max_loops,
+    messages_per_day = EXCLUDED.messages_per_day,
+    storage_bytes = EXCLUDED.storage_bytes,
+    note = EXCLUDED.note,
+    updated_at = NOW()
+RETURNING user_id, max_loops, messages_per_day, storage_bytes, note, updated_at
+`
+
+type UpsertAccountQuotaParams struct {
+	UserID         pgtype.UUID
+	MaxLoops       pgtype.Int8
+	MessagesPerDay pgtype.Int8
+	StorageBytes   pgtype.Int8
+	Note           pgtype.Text
+}
+
+func (q *Queries) UpsertAccountQuota(ctx context.Context, arg UpsertAccountQuotaParams) (AccountQuota, error) {
+	row := q.db.QueryRow(ctx, upsertAccountQuota,
+		arg.UserID,
+		arg.MaxLoops,
+		arg.MessagesPerDay,
+		arg.StorageBytes,
+		arg.Note,
+	)
+	var i AccountQuota
+	err := row.Scan(
+		&i.UserID,
+		&i.MaxLoops,
+		&i.MessagesPerDay,
+		&i.StorageBytes,
+		&i.Note,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertEventRSVP = `-- name: UpsertEventRSVP :exec
+INSERT INTO event_rsvps (event_id, user_id, status)
+VALUES ($1, $2, $3)
+ON CONFLICT (event_id, user_id) DO UPDATE SET status = EXCLUDED.status, updated_at = NOW()
+`
+
+type UpsertEventRSVPParams struct {
+	EventID pgtype.UUID
+	UserID  pgtype.UUID
+	Status  string
+}
+
+func (q *Queries) UpsertEventRSVP(ctx context.Context, arg UpsertEventRSVPParams) error {
+	_, err := q.db.Exec(ctx, upsertEventRSVP, arg.EventID, arg.UserID, arg.Status)
+	return err
+}
+
+const upsertFeatureFlag = `-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (key, description, enabled, rollout_percent, allow_users, allow_loops)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (key) DO UPDATE SET
+description = EXCLUDED.description,
+enabled = EXCLUDED.enabled,
+rollout_percent = EXCLUDED.rollout_percent,
+allow_users = EXCLUDED.allow_users,
+allow_loops = EXCLUDED.allow_loops,
+updated_at = NOW()
+RETURNING key, description, enabled, rollout_percent, allow_users, allow_loops, updated_at
+`
+
+type UpsertFeatureFlagParams struct {
+	Key            string
+	Description    pgtype.Text
+	Enabled        bool
+	RolloutPercent int32
+	AllowUsers     []pgtype.UUID
+	AllowLoops     []pgtype.UUID
+}
+
+func (q *Queries) UpsertFeatureFlag(ctx context.Context, arg UpsertFeatureFlagParams) (FeatureFlag, error) {
+	row := q.db.QueryRow(ctx, upsertFeatureFlag,
+		arg.Key,
+		arg.Description,
+		arg.Enabled,
+		arg.RolloutPercent,
+		arg.AllowUsers,
+		arg.AllowLoops,
+	)
+	var i FeatureFlag
+	err := row.Scan(
+		&i.Key,
+		&i.Description,
+		&i.Enabled,
+		&i.RolloutPercent,
+		&i.AllowUsers,
+		&i.AllowLoops,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertLoopFilterSettings = `-- name: UpsertLoopFilterSettings :one
+INSERT INTO loop_filter_settings (project_id, banned_words, banned_word_action, max_links, link_spam_action, repeat_limit, repeat_action)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (project_id) DO UPDATE SET
+banned_words = EXCLUDED.banned_words,
+banned_word_action = EXCLUDED.banned_word_action,
+max_links = EXCLUDED.max_links,
+link_spam_action = EXCLUDED.link_spam_action,
+repeat_limit = EXCLUDED.repeat_limit,
+repeat_action = EXCLUDED.repeat_action,
+updated_at = NOW()
+RETURNING project_id, banned_words, banned_word_action, max_links, link_spam_action, repeat_limit, repeat_action, updated_at
+`
+
+type UpsertLoopFilterSettingsParams struct {
+	ProjectID        pgtype.UUID
+	BannedWords      []string
+	BannedWordAction string
+	MaxLinks         int32
+	LinkSpamAction   string
+	RepeatLimit      int32
+	RepeatAction     string
+}
+
+func (q *Queries) UpsertLoopFilterSettings(ctx context.Context, arg UpsertLoopFilterSettingsParams) (LoopFilterSetting, error) {
+	row := q.db.QueryRow(ctx, upsertLoopFilterSettings,
+		arg.ProjectID,
+		arg.BannedWords,
+		arg.BannedWordAction,
+		arg.MaxLinks,
+		arg.LinkSpamAction,
+		arg.RepeatLimit,
+		arg.RepeatAction,
+	)
+	var i LoopFilterSetting
+	err := row.Scan(
+		&i.ProjectID,
+		&i.BannedWords,
+		&i.BannedWordAction,
+		&i.MaxLinks,
+		&i.LinkSpamAction,
+		&i.RepeatLimit,
+		&i.RepeatAction,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertLoopGithubDigest = `-- name: UpsertLoopGithubDigest :one
+INSERT INTO loop_github_digests (project_id, channel_id, frequency, send_time, timezone, weekday, sections, enabled, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (project_id) DO UPDATE SET
+channel_id = EXCLUDED.channel_id,
+frequency = EXCLUDED.frequency,
+send_time = EXCLUDED.send_time,
+timezone = EXCLUDED.timezone,
+weekday = EXCLUDED.weekday,
+sections = EXCLUDED.sections,
+enabled = EXCLUDED.enabled,
+updated_by = EXCLUDED.updated_by,
+updated_at = NOW()
+RETURNING project_id, channel_id, frequency, send_time, timezone, weekday, sections, enabled, last_posted_at, updated_by, updated_at
+`
+
+type UpsertLoopGithubDigestParams struct {
+	ProjectID pgtype.UUID
+	ChannelID pgtype.UUID
+	Frequency string
+	SendTime  string
+	Timezone  string
+	Weekday   int32
+	Sections  []string
+	Enabled   bool
+	UpdatedBy pgtype.UUID
+}
+
+func (q *Queries) UpsertLoopGithubDigest(ctx context.Context, arg UpsertLoopGithubDigestParams) (LoopGithubDigest, error) {
+	row := q.db.QueryRow(ctx, upsertLoopGithubDigest,
+		arg.ProjectID,
+		arg.ChannelID,
+		arg.Frequency,
+		arg.SendTime,
+		arg.Timezone,
+		arg.Weekday,
+		arg.Sections,
+		arg.Enabled,
+		arg.UpdatedBy,
+	)
+	var i LoopGithubDigest
+	err := row.Scan(
+		&i.ProjectID,
+		&i.ChannelID,
+		&i.Frequency,
+		&i.SendTime,
+		&i.Timezone,
+		&i.Weekday,
+		&i.Sections,
+		&i.Enabled,
+		&i.LastPostedAt,
+		&i.UpdatedBy,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertLoopGithubSettings = `-- name: UpsertLoopGithubSettings :one
+INSERT INTO loop_github_settings (project_id, announcements_channel_id, deploy_channel_id, production_environment, first_pr_channel_id, first_pr_template)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (project_id) DO UPDATE SET
+announcements_channel_id = EXCLUDED.announcements_channel_id,
+deploy_channel_id = EXCLUDED.deploy_channel_id,
+production_environment = EXCLUDED.production_environment,
+first_pr_channel_id = EXCLUDED.first_pr_channel_id,
+first_pr_template = EXCLUDED.first_pr_template,
+updated_at = NOW()
+RETURNING project_id, announcements_channel_id, updated_at, deploy_channel_id, production_environment, first_pr_channel_id, first_pr_template
+`
+
+type UpsertLoopGithubSettingsParams struct {
+	ProjectID              pgtype.UUID
+	AnnouncementsChannelID pgtype.UUID
+	DeployChannelID        pgtype.UUID
+	ProductionEnvironment  string
+	FirstPrChannelID       pgtype.UUID
+	FirstPrTemplate        string
+}
+
+func (q *Queries) UpsertLoopGithubSettings(ctx context.Context, arg UpsertLoopGithubSettingsParams) (LoopGithubSetting, error) {
+	row := q.db.QueryRow(ctx, upsertLoopGithubSettings,
+		arg.ProjectID,
+		arg.AnnouncementsChannelID,
+		arg.DeployChannelID,
+		arg.ProductionEnvironment,
+		arg.FirstPrChannelID,
+		arg.FirstPrTemplate,
+	)
+	var i LoopGithubSetting
+	err := row.Scan(
+		&i.ProjectID,
+		&i.AnnouncementsChannelID,
+		&i.UpdatedAt,
+		&i.DeployChannelID,
+		&i.ProductionEnvironment,
+		&i.FirstPrChannelID,
+		&i.FirstPrTemplate,
+	)
+	return i, err
+}
+
+const upsertLoopSettings = `-- name: UpsertLoopSettings :one
+INSERT INTO loop_settings (project_id, welcome_channel_id, welcome_template, welcome_dm, visibility, public_channel_ids, guest_channel_ids, pin_role, pin_limit, digest_channel_id, default_notify_level, thread_summaries, ai_disabled, ai_model, ai_max_tokens, ai_temperature, ai_safety, ai_content, access_revoked, ai_language, ai_tone)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19)
+ON CONFLICT (project_id) DO UPDATE SET
+welcome_channel_id = EXCLUDED.welcome_channel_id,
+welcome_template = EXCLUDED.welcome_template,
+welcome_dm = EXCLUDED.welcome_dm,
+visibility = EXCLUDED.visibility,
+public_channel_ids = EXCLUDED.public_channel_ids,
+guest_channel_ids = EXCLUDED.guest_channel_ids,
+pin_role = EXCLUDED.pin_role,
+pin_limit = EXCLUDED.pin_limit,
+digest_channel_id = EXCLUDED.digest_channel_id,
+default_notify_level = EXCLUDED.default_notify_level,
+thread_summaries = EXCLUDED.thread_summaries,
+ai_disabled = EXCLUDED.ai_disabled,
+ai_model = EXCLUDED.ai_model,
+ai_max_tokens = EXCLUDED.ai_max_tokens,
+ai_temperature = EXCLUDED.ai_temperature,
+ai_safety = EXCLUDED.ai_safety,
+ai_content = EXCLUDED.ai_content,
+access_revoked = EXCLUDED.access_revoked,
+ai_language = EXCLUDED.ai_language,
+ai_tone = EXCLUDED.ai_tone,
+updated_at = NOW()
+RETURNING project_id, welcome_channel_id, welcome_template, welcome_dm, updated_at, visibility, public_channel_ids, guest_channel_ids, pin_role, pin_limit, digest_channel_id, digest_posted_at, default_notify_level, thread_summaries, ai_disabled, ai_model, ai_max_tokens, ai_temperature, ai_safety, ai_content, access_revoked, ai_language, ai_tone
+`
+
+type UpsertLoopSettingsParams struct {
+	ProjectID          pgtype.UUID
+	WelcomeChannelID   pgtype.UUID
+	WelcomeTemplate    string
+	WelcomeDm          bool
+	Visibility         string
+	PublicChannelIds   []pgtype.UUID
+	GuestChannelIds    []pgtype.UUID
+	PinRole            string
+	PinLimit           int32
+	DigestChannelID    pgtype.UUID
+	DefaultNotifyLevel string
+	ThreadSummaries    string
+	AiDisabled         bool
+	AiModel            string
+	AiMaxTokens        int32
+	AiTemperature      pgtype.Float4
+	AiSafety           string
+	AiContent          []string
+	AccessRevoked      string
+	AiLanguage         string
+	AiTone             string
+}
+
+func (q *Queries) UpsertLoopSettings(ctx context.Context, arg UpsertLoopSettingsParams) (LoopSetting, error) {
+	row := q.db.QueryRow(ctx, upsertLoopSettings,
+		arg.ProjectID,
+		arg.WelcomeChannelID,
+		arg.WelcomeTemplate,
+		arg.WelcomeDm,
+		arg.Visibility,
+		arg.PublicChannelIds,
+		arg.GuestChannelIds,
+		arg.PinRole,
+		arg.PinLimit,
+		arg.DigestChannelID,
+		arg.DefaultNotifyLevel,
+		arg.ThreadSummaries,
+		arg.AiDisabled,
+		arg.AiModel,
+		arg.AiMaxTokens,
+		arg.AiTemperature,
+		arg.AiSafety,
+		arg.AiContent,
+		arg.AccessRevoked,
+		arg.AiLanguage,
+		arg.AiTone,
+	)
+	var i LoopSetting
+	err := row.Scan(
+		&i.ProjectID,
+		&i.WelcomeChannelID,
+		&i.WelcomeTemplate,
+		&i.WelcomeDm,
+		&i.UpdatedAt,
+		&i.Visibility,
+		&i.PublicChannelIds,
+		&i.GuestChannelIds,
+		&i.PinRole,
+		&i.PinLimit,
+		&i.DigestChannelID,
+		&i.DigestPostedAt,
+		&i.DefaultNotifyLevel,
+		&i.ThreadSummaries,
+		&i.AiDisabled,
+		&i.AiModel,
+		&i.AiMaxTokens,
+		&i.AiTemperature,
+		&i.AiSafety,
+		&i.AiContent,
+		&i.AccessRevoked,
+		&i.AiLanguage,
+		&i.AiTone,
+	)
+	return i, err
+}
+
+const upsertMessageGithubEntity = `-- name: UpsertMessageGithubEntity :exec
+
+INSERT INTO message_github_entities (message_id, text_offset, text_length, kind, number, sha, title, state, url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (message_id, text_offset) DO UPDATE SET
+text_length = EXCLUDED.text_length,
+kind = EXCLUDED.kind,
+number = EXCLUDED.number,
+sha = EXCLUDED.sha,
+title = EXCLUDED.title,
+state = EXCLUDED.state,
+url = EXCLUDED.url,
+resolved_at = NOW()
+`
+
+type UpsertMessageGithubEntityParams struct {
+	MessageID  int64
+	TextOffset int32
+	TextLength int32
+	Kind       string
+	Number     int32
+	Sha        string
+	Title      string
+	State      string
+	Url        string
+}
+
+// MESSAGE GITHUB ENTITIES
+func (q *Queries) UpsertMessageGithubEntity(ctx context.Context, arg UpsertMessageGithubEntityParams) error {
+	_, err := q.db.Exec(ctx, upsertMessageGithubEntity,
+		arg.MessageID,
+		arg.TextOffset,
+		arg.TextLength,
+		arg.Kind,
+		arg.Number,
+		arg.Sha,
+		arg.Title,
+		arg.State,
+		arg.Url,
+	)
+	return err
+}
+
+const upsertPRComment = `-- name: UpsertPRComment :execrows
+INSERT INTO pr_comments (
+    repo_id, pr_number, comment_type, comment_id, body, path, line, diff_hunk,
+    in_reply_to_id, state, username, avatar_url, html_url, github_created_at, github_updated_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+ON CONFLICT (repo_id, pr_number, comment_type, comment_id) DO UPDATE SET
+    body = EXCLUDED.body,
+    path = EXCLUDED.path,
+    line = EXCLUDED.line,
+    diff_hunk = EXCLUDED.diff_hunk,
+    in_reply_to_id = EXCLUDED.in_reply_to_id,
+    state = EXCLUDED.state,
+    username = EXCLUDED.username,
+    avatar_url = EXCLUDED.avatar_url,
+    html_url = EXCLUDED.html_url,
+    github_updated_at = EXCLUDED.github_updated_at,
+    synced_at = NOW()
+WHERE pr_comments.github_updated_at < EXCLUDED.github_updated_at
+   OR (pr_comments.github_updated_at = EXCLUDED.github_updated_at
+       AND (pr_comments.body, pr_comments.state, pr_comments.path, pr_comments.line)
+           IS DISTINCT FROM (EXCLUDED.body, EXCLUDED.state, EXCLUDED.path, EXCLUDED.line))
+`
+
+type UpsertPRCommentParams struct {
+	RepoID          int64
+	PrNumber        int32
+	CommentType     string
+	CommentID       int64
+	Body            string
+	Path            pgtype.Text
+	Line            pgtype.Int4
+	DiffHunk        pgtype.Text
+	InReplyToID     pgtype.Int8
+	State           pgtype.Text
+	Username        string
+	AvatarUrl       pgtype.Text
+	HtmlUrl         string
+	GithubCreatedAt pgtype.Timestamptz
+	GithubUpdatedAt pgtype.Timestamptz
+}
+
+// Older fetches never overwrite newer data; unchanged rows report 0 affected
+func (q *Queries) UpsertPRComment(ctx context.Context, arg UpsertPRCommentParams) (int64, error) {
+	result, err := q.db.Exec(ctx, upsertPRComment,
+		arg.RepoID,
+		arg.PrNumber,
+		arg.CommentType,
+		arg.CommentID,
+		arg.Body,
+		arg.Path,
+		arg.Line,
+		arg.DiffHunk,
+		arg.InReplyToID,
+		arg.State,
+		arg.Username,
+		arg.AvatarUrl,
+		arg.HtmlUrl,
+		arg.GithubCreatedAt,
+		arg.GithubUpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const upsertStandupResponse = `-- name: UpsertStandupResponse :exec
+INSERT INTO standup_responses (run_id, user_id, answers)
+VALUES ($1, $2, $3)
+ON CONFLICT (run_id, user_id) DO UPDATE SET answers = EXCLUDED.answers, created_at = NOW()
+`
+
+type UpsertStandupResponseParams struct {
+	RunID   pgtype.UUID
+	UserID  pgtype.UUID
+	Answers []string
+}
+
+func (q *Queries) UpsertStandupResponse(ctx context.Context, arg UpsertStandupResponseParams) error {
+	_, err := q.db.Exec(ctx, upsertStandupResponse, arg.RunID, arg.UserID, arg.Answers)
+	return err
+}
+
+const upsertThreadSummary = `-- name: UpsertThreadSummary :one
+INSERT INTO thread_summaries (message_id, summary, replies, last_reply_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (message_id) DO UPDATE SET
+summary = EXCLUDED.summary,
+replies = EXCLUDED.replies,
+last_reply_id = EXCLUDED.last_reply_id,
+updated_at = NOW()
+RETURNING message_id, summary, replies, last_reply_id, updated_at
+`
+
+type UpsertThreadSummaryParams struct {
+	MessageID   int64
+	Summary     string
+	Replies     int32
+	LastReplyID int64
+}
+
+func (q *Queries) UpsertThreadSummary(ctx context.Context, arg UpsertThreadSummaryParams) (ThreadSummary, error) {
+	row := q.db.QueryRow(ctx, upsertThreadSummary,
+		arg.MessageID,
+		arg.Summary,
+		arg.Replies,
+		arg.LastReplyID,
+	)
+	var i ThreadSummary
+	err := row.Scan(
+		&i.MessageID,
+		&i.Summary,
+		&i.Replies,
+		&i.LastReplyID,
+		&i.UpdatedAt,
 	)
 	return i, err
 }
@@ -1475,11 +12513,11 @@ INSERT INTO users (
 	$1, $2, $3, $4
 )
 ON CONFLICT (github_id) DO UPDATE SET
-username = EXCLUDED.username,
+username = CASE WHEN users.username_changed_at IS NULL THEN EXCLUDED.username ELSE users.username END,
 avatar_url = COALESCE(users.avatar_url, EXCLUDED.avatar_url),
 access_token = EXCLUDED.access_token,
 updated_at = NOW()
-RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at
+RETURNING id, github_id, username, avatar_url, display_name, access_token, profile_completed, created_at, updated_at, github_synced_at, username_changed_at
 `
 
 type UpsertUserParams struct {
@@ -1507,6 +12545,25 @@ func (q *Queries) UpsertUser(ctx context.Context, arg UpsertUserParams) (User, e
 		&i.ProfileCompleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.GithubSyncedAt,
+		&i.UsernameChangedAt,
 	)
 	return i, err
 }
+
+const upsertWorkspaceMember = `-- name: UpsertWorkspaceMember :exec
+INSERT INTO workspace_members (workspace_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role
+`
+
+type UpsertWorkspaceMemberParams struct {
+	WorkspaceID pgtype.UUID
+	UserID      pgtype.UUID
+	Role        string
+}
+
+func (q *Queries) UpsertWorkspaceMember(ctx context.Context, arg UpsertWorkspaceMemberParams) error {
+	_, err := q.db.Exec(ctx, upsertWorkspaceMember, arg.WorkspaceID, arg.UserID, arg.Role)
+	return err
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const maxSlowStatement = 2000 // bytes kept of each statement
+
+// SlowQuery is one query that took longer than the log's threshold. Arguments
+// are not kept; they can hold message content and tokens.
+type SlowQuery struct {
+	Name       string    `json:"name,omitempty"` // the sqlc query name, when there is one
+	Statement  string    `json:"statement"`
+	DurationMs float64   `json:"duration_ms"`
+	Caller     string    `json:"caller"` // first frame outside pgx and this package
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// SlowQueryLog is a pgx.QueryTracer that keeps the most recent slow queries
+// in a ring buffer. Batched queries (GetLoopOverview) are not traced.
+type SlowQueryLog struct {
+	threshold time.Duration
+	total     atomic.Int64
+
+	mu      sync.Mutex
+	entries []SlowQuery
+	next    int
+	full    bool
+}
+
+type slowQueryKey struct{}
+
+type slowQueryStart struct {
+	at  time.Time
+	sql string
+	pcs [24]uintptr
+	n   int
+}
+
+// NewSlowQueryLog records queries slower than threshold, keeping the last size
+func NewSlowQueryLog(threshold time.Duration, size int) *SlowQueryLog {
+	return &SlowQueryLog{threshold: threshold, entries: make([]SlowQuery, size)}
+}
+
+func (l *SlowQueryLog) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	start := &slowQueryStart{at: time.Now(), sql: data.SQL}
+	// Only the PCs now; they are resolved to a caller if the query turns out slow
+	start.n = runtime.Callers(2, start.pcs[:])
+	return context.WithValue(ctx, slowQueryKey{}, start)
+}
+
+func (l *SlowQueryLog) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(slowQueryKey{}).(*slowQueryStart)
+	if !ok {
+		return
+	}
+	d := time.Since(start.at)
+	if d < l.threshold {
+		return
+	}
+	q := SlowQuery{
+		Name:       queryName(start.sql),
+		Statement:  compactSQL(start.sql),
+		DurationMs: float64(d.Microseconds()) / 1000,
+		Caller:     callerOutsideDB(start.pcs[:start.n]),
+		At:         start.at,
+	}
+	if data.Err != nil {
+		q.Error = data.Err.Error()
+	}
+	l.total.Add(1)
+	l.mu.Lock()
+	l.entries[l.next] = q
+	l.next = (l.next + 1) % len(l.entries)
+	l.full = l.full || l.next == 0
+	l.mu.Unlock()
+}
+
+// Threshold is the duration from which a query is recorded
+func (l *SlowQueryLog) Threshold() time.Duration { return l.threshold }
+
+// Total counts the slow queries since start, including those that have
+// since left the buffer
+func (l *SlowQueryLog) Total() int64 { return l.total.Load() }
+
+// Recent returns the buffered slow queries, newest first
+func (l *SlowQueryLog) Recent() []SlowQuery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	n := l.next
+	if l.full {
+		n = len(l.entries)
+	}
+	out := make([]SlowQuery, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, l.entries[(l.next-i+len(l.entries))%len(l.entries)])
+	}
+	return out
+}
+
+// queryName reads the "-- name: X :kind" header sqlc puts on its queries
+func queryName(sql string) string {
+	rest, ok := strings.CutPrefix(sql, "-- name: ")
+	if !ok {
+		return ""
+	}
+	name, _, _ := strings.Cut(rest, " ")
+	return name
+}
+
+// compactSQL collapses whitespace so the statement reads on one line
+func compactSQL(sql string) string {
+	if _, body, ok := strings.Cut(sql, "\n"); ok && strings.HasPrefix(sql, "-- name: ") {
+		sql = body
+	}
+	sql = strings.Join(strings.Fields(sql), " ")
+	if len(sql) > maxSlowStatement {
+		sql = sql[:maxSlowStatement] + "…"
+	}
+	return sql
+}
+
+func callerOutsideDB(pcs []uintptr) string {
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "github.com/jackc/") && !strings.HasPrefix(f.Function, "wireloop/internal/db.") {
+			return f.Function + " (" + shortFile(f.File) + ":" + strconv.Itoa(f.Line) + ")"
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func shortFile(path string) string {
+	if i := strings.Index(path, "/internal/"); i >= 0 {
+		return path[i+1:]
+	}
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
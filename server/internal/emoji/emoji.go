@@ -0,0 +1,129 @@
+// Package emoji resolves :shortcode: sequences in message text to the emoji
+// they name: a standard Unicode emoji or one of a loop's custom emoji.
+package emoji
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf16"
+)
+
+// Entity is a resolved :shortcode: in a message. Offset and Length count
+// UTF-16 code units, the way the web client indexes strings.
+type Entity struct {
+	Type      string `json:"type"` // "emoji" or "custom_emoji"
+	Offset    int    `json:"offset"`
+	Length    int    `json:"length"`
+	Shortcode string `json:"shortcode"` // without the colons
+	Unicode   string `json:"unicode,omitempty"`
+	CustomID  string `json:"custom_id,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+// Custom looks up one of the loop's custom emoji by name
+type Custom func(name string) (Entity, bool)
+
+var (
+	// NamePattern is what a shortcode name (and a custom emoji's name) may contain
+	NamePattern = regexp.MustCompile(`^[a-z0-9_+\-]{1,32}$`)
+
+	shortcodeRegex = regexp.MustCompile(`:([a-z0-9_+\-]{1,32}):`)
+	// Inline code spans and fenced blocks, where colons are literal
+	codeRegex = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+)
+
+// Lookup returns the standard emoji a shortcode names
+func Lookup(name string) (string, bool) {
+	e, ok := standard[name]
+	return e, ok
+}
+
+// Search returns the standard shortcodes starting with prefix, by name
+func Search(prefix string) []Entity {
+	var out []Entity
+	for name, u := range standard {
+		if strings.HasPrefix(name, prefix) {
+			out = append(out, Entity{Type: "emoji", Shortcode: name, Unicode: u})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Shortcode < out[j].Shortcode })
+	return out
+}
+
+// Parse finds the shortcodes in content that name a standard emoji or,
+// through custom, one of the loop's. custom may be nil. Custom emoji take
+// precedence so a loop can't lose one to a later addition to the standard
+// set.
+func Parse(content string, custom Custom) []Entity {
+	if !strings.Contains(content, ":") {
+		return nil
+	}
+	code := codeRegex.FindAllStringIndex(content, -1)
+	var entities []Entity
+	units, last := 0, 0
+	for _, m := range shortcodeRegex.FindAllStringSubmatchIndex(content, -1) {
+		if inRanges(code, m[0]) {
+			continue
+		}
+		name := content[m[2]:m[3]]
+		ent, ok := Entity{}, false
+		if custom != nil {
+			ent, ok = custom(name)
+			ent.Type = "custom_emoji"
+		}
+		if !ok {
+			var u string
+			if u, ok = standard[name]; ok {
+				ent = Entity{Type: "emoji", Unicode: u}
+			}
+		}
+		if !ok {
+			continue
+		}
+		units += utf16Len(content[last:m[0]])
+		last = m[0]
+		ent.Offset = units
+		ent.Length = utf16Len(content[m[0]:m[1]])
+		ent.Shortcode = name
+		entities = append(entities, ent)
+	}
+	return entities
+}
+
+// ReplaceStandard swaps standard shortcodes in content for their Unicode
+// emoji, for places that show the raw text (GitHub comments, notifications).
+// Custom emoji and code spans are left alone.
+func ReplaceStandard(content string) string {
+	ents := Parse(content, nil)
+	if len(ents) == 0 {
+		return content
+	}
+	var b strings.Builder
+	units := utf16.Encode([]rune(content))
+	pos := 0
+	for _, e := range ents {
+		b.WriteString(string(utf16.Decode(units[pos:e.Offset])))
+		b.WriteString(e.Unicode)
+		pos = e.Offset + e.Length
+	}
+	b.WriteString(string(utf16.Decode(units[pos:])))
+	return b.String()
+}
+
+func inRanges(ranges [][]int, i int) bool {
+	for _, r := range ranges {
+		if i >= r[0] && i < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += utf16.RuneLen(r)
+	}
+	return n
+}
@@ -0,0 +1,294 @@
+package emoji
+
+// standard is the shortcode set the web client's picker offers, using the
+// GitHub/Slack names. Aliases point at the same emoji.
+var standard = map[string]string{
+	// Smileys
+	"smile":                        "😄",
+	"smiley":                       "😃",
+	"grinning":                     "😀",
+	"grin":                         "😁",
+	"laughing":                     "😆",
+	"satisfied":                    "😆",
+	"sweat_smile":                  "😅",
+	"joy":                          "😂",
+	"rofl":                         "🤣",
+	"slightly_smiling_face":        "🙂",
+	"upside_down_face":             "🙃",
+	"wink":                         "😉",
+	"blush":                        "😊",
+	"innocent":                     "😇",
+	"heart_eyes":                   "😍",
+	"star_struck":                  "🤩",
+	"kissing_heart":                "😘",
+	"yum":                          "😋",
+	"stuck_out_tongue":             "😛",
+	"stuck_out_tongue_winking_eye": "😜",
+	"thinking":                     "🤔",
+	"thinking_face":                "🤔",
+	"zipper_mouth_face":            "🤐",
+	"raised_eyebrow":               "🤨",
+	"neutral_face":                 "😐",
+	"expressionless":               "😑",
+	"no_mouth":                     "😶",
+	"smirk":                        "😏",
+	"unamused":                     "😒",
+	"roll_eyes":                    "🙄",
+	"grimacing":                    "😬",
+	"relieved":                     "😌",
+	"pensive":                      "😔",
+	"sleepy":                       "😪",
+	"sleeping":                     "😴",
+	"mask":                         "😷",
+	"nerd_face":                    "🤓",
+	"sunglasses":                   "😎",
+	"confused":                     "😕",
+	"worried":                      "😟",
+	"open_mouth":                   "😮",
+	"astonished":                   "😲",
+	"flushed":                      "😳",
+	"pleading_face":                "🥺",
+	"cry":                          "😢",
+	"sob":                          "😭",
+	"scream":                       "😱",
+	"sweat":                        "😓",
+	"weary":                        "😩",
+	"tired_face":                   "😫",
+	"yawning_face":                 "🥱",
+	"triumph":                      "😤",
+	"rage":                         "😡",
+	"angry":                        "😠",
+	"exploding_head":               "🤯",
+	"partying_face":                "🥳",
+	"skull":                        "💀",
+	"poop":                         "💩",
+	"hankey":                       "💩",
+	"clown_face":                   "🤡",
+	"ghost":                        "👻",
+	"alien":                        "👽",
+	"robot":                        "🤖",
+	"see_no_evil":                  "🙈",
+	"hear_no_evil":                 "🙉",
+	"speak_no_evil":                "🙊",
+	"melting_face":                 "🫠",
+	"saluting_face":                "🫡",
+
+	// Hands and people
+	"+1":              "👍",
+	"thumbsup":        "👍",
+	"-1":              "👎",
+	"thumbsdown":      "👎",
+	"ok_hand":         "👌",
+	"pinched_fingers": "🤌",
+	"v":               "✌️",
+	"crossed_fingers": "🤞",
+	"metal":           "🤘",
+	"call_me_hand":    "🤙",
+	"point_up":        "☝️",
+	"point_down":      "👇",
+	"point_left":      "👈",
+	"point_right":     "👉",
+	"wave":            "👋",
+	"raised_hand":     "✋",
+	"hand":            "✋",
+	"clap":            "👏",
+	"raised_hands":    "🙌",
+	"open_hands":      "👐",
+	"handshake":       "🤝",
+	"pray":            "🙏",
+	"muscle":          "💪",
+	"writing_hand":    "✍️",
+	"eyes":            "👀",
+	"eye":             "👁️",
+	"brain":           "🧠",
+	"facepalm":        "🤦",
+	"shrug":           "🤷",
+	"bow":             "🙇",
+	"ninja":           "🥷",
+
+	// Hearts and symbols
+	"heart":                       "❤️",
+	"orange_heart":                "🧡",
+	"yellow_heart":                "💛",
+	"green_heart":                 "💚",
+	"blue_heart":                  "💙",
+	"purple_heart":                "💜",
+	"black_heart":                 "🖤",
+	"white_heart":                 "🤍",
+	"broken_heart":                "💔",
+	"sparkling_heart":             "💖",
+	"100":                         "💯",
+	"fire":                        "🔥",
+	"sparkles":                    "✨",
+	"star":                        "⭐",
+	"star2":                       "🌟",
+	"zap":                         "⚡",
+	"boom":                        "💥",
+	"collision":                   "💥",
+	"tada":                        "🎉",
+	"confetti_ball":               "🎊",
+	"balloon":                     "🎈",
+	"gift":                        "🎁",
+	"trophy":                      "🏆",
+	"medal":                       "🏅",
+	"crown":                       "👑",
+	"gem":                         "💎",
+	"white_check_mark":            "✅",
+	"heavy_check_mark":            "✔️",
+	"ballot_box_with_check":       "☑️",
+	"x":                           "❌",
+	"negative_squared_cross_mark": "❎",
+	"heavy_plus_sign":             "➕",
+	"heavy_minus_sign":            "➖",
+	"question":                    "❓",
+	"grey_question":               "❔",
+	"exclamation":                 "❗",
+	"heavy_exclamation_mark":      "❗",
+	"bangbang":                    "‼️",
+	"warning":                     "⚠️",
+	"no_entry":                    "⛔",
+	"no_entry_sign":               "🚫",
+	"stop_sign":                   "🛑",
+	"recycle":                     "♻️",
+	"arrow_up":                    "⬆️",
+	"arrow_down":                  "⬇️",
+	"arrow_left":                  "⬅️",
+	"arrow_right":                 "➡️",
+	"arrows_counterclockwise":     "🔄",
+	"repeat":                      "🔁",
+	"hourglass":                   "⌛",
+	"hourglass_flowing_sand":      "⏳",
+	"stopwatch":                   "⏱️",
+	"alarm_clock":                 "⏰",
+	"red_circle":                  "🔴",
+	"large_orange_circle":         "🟠",
+	"large_yellow_circle":         "🟡",
+	"large_green_circle":          "🟢",
+	"large_blue_circle":           "🔵",
+	"large_purple_circle":         "🟣",
+	"white_circle":                "⚪",
+	"black_circle":                "⚫",
+	"speech_balloon":              "💬",
+	"thought_balloon":             "💭",
+	"zzz":                         "💤",
+	"link":                        "🔗",
+	"lock":                        "🔒",
+	"unlock":                      "🔓",
+	"key":                         "🔑",
+	"bell":                        "🔔",
+	"no_bell":                     "🔕",
+	"mega":                        "📣",
+	"loudspeaker":                 "📢",
+	"pushpin":                     "📌",
+	"round_pushpin":               "📍",
+	"paperclip":                   "📎",
+	"mag":                         "🔍",
+	"bulb":                        "💡",
+	"memo":                        "📝",
+	"pencil":                      "📝",
+	"pencil2":                     "✏️",
+	"books":                       "📚",
+	"bookmark":                    "🔖",
+	"calendar":                    "📆",
+	"date":                        "📅",
+	"chart_with_upwards_trend":    "📈",
+	"chart_with_downwards_trend":  "📉",
+	"bar_chart":                   "📊",
+	"clipboard":                   "📋",
+	"package":                     "📦",
+	"inbox_tray":                  "📥",
+	"outbox_tray":                 "📤",
+	"email":                       "📧",
+	"envelope":                    "✉️",
+
+	// Dev and objects
+	"bug":                  "🐛",
+	"beetle":               "🪲",
+	"rocket":               "🚀",
+	"ship":                 "🚢",
+	"construction":         "🚧",
+	"rotating_light":       "🚨",
+	"wrench":               "🔧",
+	"hammer":               "🔨",
+	"hammer_and_wrench":    "🛠️",
+	"gear":                 "⚙️",
+	"nut_and_bolt":         "🔩",
+	"toolbox":              "🧰",
+	"test_tube":            "🧪",
+	"microscope":           "🔬",
+	"computer":             "💻",
+	"desktop_computer":     "🖥️",
+	"keyboard":             "⌨️",
+	"floppy_disk":          "💾",
+	"cd":                   "💿",
+	"card_index_dividers":  "🗂️",
+	"file_folder":          "📁",
+	"open_file_folder":     "📂",
+	"wastebasket":          "🗑️",
+	"electric_plug":        "🔌",
+	"battery":              "🔋",
+	"satellite":            "📡",
+	"globe_with_meridians": "🌐",
+	"art":                  "🎨",
+	"lipstick":             "💄",
+	"rewind":               "⏪",
+	"fast_forward":         "⏩",
+	"heavy_dollar_sign":    "💲",
+	"moneybag":             "💰",
+	"coffee":               "☕",
+	"tea":                  "🍵",
+	"beer":                 "🍺",
+	"beers":                "🍻",
+	"clinking_glasses":     "🥂",
+	"pizza":                "🍕",
+	"cake":                 "🍰",
+	"birthday":             "🎂",
+	"cookie":               "🍪",
+	"popcorn":              "🍿",
+	"taco":                 "🌮",
+	"apple":                "🍎",
+	"lemon":                "🍋",
+	"avocado":              "🥑",
+	"hot_pepper":           "🌶️",
+
+	// Nature
+	"sunny":            "☀️",
+	"cloud":            "☁️",
+	"umbrella":         "☔",
+	"snowflake":        "❄️",
+	"rainbow":          "🌈",
+	"ocean":            "🌊",
+	"earth_americas":   "🌎",
+	"crescent_moon":    "🌙",
+	"seedling":         "🌱",
+	"evergreen_tree":   "🌲",
+	"herb":             "🌿",
+	"four_leaf_clover": "🍀",
+	"cactus":           "🌵",
+	"tulip":            "🌷",
+	"rose":             "🌹",
+	"sunflower":        "🌻",
+	"cherry_blossom":   "🌸",
+	"dog":              "🐶",
+	"cat":              "🐱",
+	"fox_face":         "🦊",
+	"bear":             "🐻",
+	"panda_face":       "🐼",
+	"koala":            "🐨",
+	"tiger":            "🐯",
+	"unicorn":          "🦄",
+	"bee":              "🐝",
+	"honeybee":         "🐝",
+	"snail":            "🐌",
+	"turtle":           "🐢",
+	"snake":            "🐍",
+	"octopus":          "🐙",
+	"crab":             "🦀",
+	"whale":            "🐳",
+	"dolphin":          "🐬",
+	"penguin":          "🐧",
+	"owl":              "🦉",
+	"parrot":           "🦜",
+	"sloth":            "🦥",
+	"hamster":          "🐹",
+}
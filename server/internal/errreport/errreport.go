@@ -0,0 +1,107 @@
+// Package errreport sends unexpected failures (panics, lost messages,
+// GitHub and AI errors) to an error tracker. The reporter is chosen once at
+// startup; until then, and without configuration, reports are dropped.
+package errreport
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Event is one failure. Stack holds program counters, innermost first.
+type Event struct {
+	Err     error
+	Panic   any // set instead of Err for recovered panics
+	Tags    map[string]string
+	Request *http.Request // the request being served, if any
+	Stack   []uintptr
+	At      time.Time
+}
+
+// Reporter delivers events. Report must not block the caller.
+type Reporter interface {
+	Report(ev Event)
+	// Flush waits up to timeout for queued events to be delivered
+	Flush(timeout time.Duration)
+}
+
+// FromEnv picks a reporter: Sentry when SENTRY_DSN is set (with optional
+// SENTRY_ENVIRONMENT and SENTRY_RELEASE), else Noop
+func FromEnv() Reporter {
+	dsn := os.Getenv("SENTRY_DSN")
+	if dsn == "" {
+		return Noop{}
+	}
+	s, err := NewSentry(dsn, os.Getenv("SENTRY_ENVIRONMENT"), os.Getenv("SENTRY_RELEASE"))
+	if err != nil {
+		log.Printf("[errreport] invalid SENTRY_DSN, errors won't be reported: %v", err)
+		return Noop{}
+	}
+	log.Printf("[errreport] reporting errors to Sentry")
+	return s
+}
+
+// Noop drops every event
+type Noop struct{}
+
+func (Noop) Report(Event)        {}
+func (Noop) Flush(time.Duration) {}
+
+var (
+	mu      sync.RWMutex
+	current Reporter = Noop{}
+)
+
+// Use makes r the reporter behind Capture and CapturePanic. Call once at
+// startup, before serving.
+func Use(r Reporter) {
+	mu.Lock()
+	current = r
+	mu.Unlock()
+}
+
+// Flush waits for the current reporter's queue, for use at shutdown
+func Flush(timeout time.Duration) {
+	mu.RLock()
+	r := current
+	mu.RUnlock()
+	r.Flush(timeout)
+}
+
+// Capture reports err. tags name where it happened, e.g. component=ws;
+// req may be nil.
+func Capture(req *http.Request, err error, tags map[string]string) {
+	if err == nil {
+		return
+	}
+	report(Event{Err: err, Tags: tags, Request: req})
+}
+
+// CapturePanic reports a recovered panic value. Call it from the deferred
+// function that recovered, so the stack still shows where it panicked.
+func CapturePanic(req *http.Request, v any, tags map[string]string) {
+	report(Event{Panic: v, Tags: tags, Request: req})
+}
+
+func report(ev Event) {
+	pcs := make([]uintptr, 48)
+	ev.Stack = pcs[:runtime.Callers(3, pcs)]
+	ev.At = time.Now()
+	mu.RLock()
+	r := current
+	mu.RUnlock()
+	r.Report(ev)
+}
+
+// message is the event's one-line description
+func (ev Event) message() string {
+	if ev.Panic != nil {
+		return fmt.Sprintf("panic: %v", ev.Panic)
+	}
+	return ev.Err.Error()
+}
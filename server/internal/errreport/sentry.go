@@ -0,0 +1,240 @@
+package errreport
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sentryQueueSize = 100 // events waiting to be sent; more are dropped
+	sentryTimeout   = 10 * time.Second
+)
+
+// Sentry posts events to a Sentry (or compatible, e.g. GlitchTip) project
+// through its envelope endpoint. Events are sent from one goroutine.
+type Sentry struct {
+	dsn         string
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+
+	queue   chan Event
+	pending sync.WaitGroup
+}
+
+// NewSentry parses a DSN of the form https://<key>@<host>/<project id>
+func NewSentry(dsn, environment, release string) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+	key := u.User.Username()
+	i := strings.LastIndex(u.Path, "/")
+	if key == "" || i < 0 || u.Path[i+1:] == "" {
+		return nil, errors.New("DSN needs a public key and a project id")
+	}
+	project := u.Path[i+1:]
+	host, _ := os.Hostname()
+	s := &Sentry{
+		dsn:         dsn,
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, u.Path[:i], project),
+		auth:        "Sentry sentry_version=7, sentry_client=wireloop/1.0, sentry_key=" + key,
+		environment: environment,
+		release:     release,
+		serverName:  host,
+		client:      &http.Client{Timeout: sentryTimeout},
+		queue:       make(chan Event, sentryQueueSize),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *Sentry) Report(ev Event) {
+	s.pending.Add(1)
+	select {
+	case s.queue <- ev:
+	default:
+		s.pending.Done()
+		log.Printf("[errreport] queue full, dropped: %s", ev.message())
+	}
+}
+
+func (s *Sentry) Flush(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
+func (s *Sentry) run() {
+	for ev := range s.queue {
+		if err := s.send(ev); err != nil {
+			log.Printf("[errreport] failed to send to Sentry: %v", err)
+		}
+		s.pending.Done()
+	}
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryException struct {
+	Type       string `json:"type"`
+	Value      string `json:"value"`
+	Stacktrace struct {
+		Frames []sentryFrame `json:"frames"`
+	} `json:"stacktrace"`
+}
+
+type sentryRequest struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Level       string            `json:"level"`
+	Platform    string            `json:"platform"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Request     *sentryRequest    `json:"request,omitempty"`
+	Exception   struct {
+		Values []sentryException `json:"values"`
+	} `json:"exception"`
+}
+
+func (s *Sentry) send(ev Event) error {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	out := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   ev.At.UTC().Format(time.RFC3339Nano),
+		Level:       "error",
+		Platform:    "go",
+		ServerName:  s.serverName,
+		Environment: s.environment,
+		Release:     s.release,
+		Tags:        ev.Tags,
+	}
+	if ev.Request != nil {
+		// The query string is left out; it can carry tokens
+		u := *ev.Request.URL
+		u.RawQuery = ""
+		out.Request = &sentryRequest{Method: ev.Request.Method, URL: u.String()}
+	}
+	exc := sentryException{Value: ev.message()}
+	if ev.Panic != nil {
+		exc.Type = "panic"
+	} else {
+		// Group by the root cause's type rather than the wrapping *fmt.wrapError
+		root := ev.Err
+		for errors.Unwrap(root) != nil {
+			root = errors.Unwrap(root)
+		}
+		exc.Type = fmt.Sprintf("%T", root)
+	}
+	exc.Stacktrace.Frames = sentryFrames(ev.Stack)
+	out.Exception.Values = []sentryException{exc}
+
+	payload, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+	header, _ := json.Marshal(map[string]string{
+		"event_id": out.EventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+		"dsn":      s.dsn,
+	})
+	var body bytes.Buffer
+	body.Write(header)
+	body.WriteString("\n{\"type\":\"event\"}\n")
+	body.Write(payload)
+	body.WriteString("\n")
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sentryFrames converts a stack to Sentry's order, outermost call first
+func sentryFrames(pcs []uintptr) []sentryFrame {
+	var frames []sentryFrame
+	it := runtime.CallersFrames(pcs)
+	for {
+		f, more := it.Next()
+		if f.Function != "" {
+			// The package path: up to the first dot after the last slash
+			slash := strings.LastIndex(f.Function, "/") + 1
+			module := f.Function
+			if dot := strings.Index(f.Function[slash:], "."); dot >= 0 {
+				module = f.Function[:slash+dot]
+			}
+			frames = append(frames, sentryFrame{
+				Function: f.Function,
+				Module:   module,
+				Filename: shortPath(f.File),
+				AbsPath:  f.File,
+				Lineno:   f.Line,
+				InApp:    strings.HasPrefix(f.Function, "wireloop/") || strings.HasPrefix(f.Function, "main."),
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	return frames
+}
+
+func shortPath(path string) string {
+	if i := strings.Index(path, "/internal/"); i >= 0 {
+		return path[i+1:]
+	}
+	if i := strings.Index(path, "/cmd/"); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
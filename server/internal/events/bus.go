@@ -0,0 +1,48 @@
+package events
+
+import (
+	"time"
+
+	"wireloop/internal/chat"
+)
+
+// Bus publishes events to the hub's rooms and users
+type Bus struct {
+	hub *chat.Hub
+}
+
+func NewBus(hub *chat.Hub) *Bus {
+	return &Bus{hub: hub}
+}
+
+// Publish sends ev to everyone in room, e.g. a loop or DM room
+func (b *Bus) Publish(room string, ev Event) {
+	b.count(ev)
+	b.hub.Broadcast(room, Wrap(ev, ""))
+}
+
+// PublishChannel sends ev to everyone following channelID
+func (b *Bus) PublishChannel(channelID string, ev Event) {
+	b.count(ev)
+	b.hub.Broadcast(channelID, Wrap(ev, channelID))
+}
+
+// PublishChannelFrom is PublishChannel for an event received at received,
+// which the hub's latency metrics count from
+func (b *Bus) PublishChannelFrom(channelID string, ev Event, received time.Time) {
+	b.count(ev)
+	b.hub.BroadcastFrom(channelID, Wrap(ev, channelID), received)
+}
+
+// PublishUser sends ev to every connection of userID
+func (b *Bus) PublishUser(userID string, ev Event) {
+	b.hub.NotifyUser(userID, Wrap(ev, ""))
+}
+
+// count feeds new chat and DM messages to the hub's message rate
+func (b *Bus) count(ev Event) {
+	switch ev.EventType() {
+	case Message, DMMessage:
+		b.hub.CountMessage()
+	}
+}
@@ -0,0 +1,117 @@
+// Package events defines the real-time events the server pushes to clients
+// over WebSocket and SSE: their wire names, their payloads and the envelope
+// they travel in. Producers publish through a Bus instead of building frames
+// by hand, so an event's name and payload shape live in one place and the
+// schema tests catch a change before a client does.
+package events
+
+// Type is an event's name on the wire
+type Type string
+
+// Event is something that can be published. Payload structs know their own
+// type; a bare Type is an event with no payload; Of pairs a type with an API
+// resource used as the payload as-is.
+type Event interface {
+	EventType() Type
+}
+
+// EventType makes a Type an event with no payload, e.g. pong or resync
+func (t Type) EventType() Type { return t }
+
+// Chat
+const (
+	Connected           Type = "connected"
+	ChannelSwitched     Type = "channel_switched"
+	Resync              Type = "resync"
+	Pong                Type = "pong"
+	Typing              Type = "typing"
+	Presence            Type = "presence"
+	Message             Type = "message"
+	MessageDeleted      Type = "message_deleted"
+	MessageRejected     Type = "message_rejected"
+	MessageHeld         Type = "message_held"
+	SendCancelled       Type = "send_cancelled"
+	MessagePinned       Type = "message_pinned"
+	MessageUnpinned     Type = "message_unpinned"
+	ReactionAdded       Type = "reaction_added"
+	ReactionRemoved     Type = "reaction_removed"
+	ReadState           Type = "read_state"
+	EntitiesResolved    Type = "entities_resolved"
+	AttachmentScanned   Type = "attachment_scanned"
+	ThreadExported      Type = "thread_exported"
+	ThreadSummarized    Type = "thread_summarized"
+	CommandError        Type = "command_error"
+	ChannelArchived     Type = "channel_archived"
+	ChannelTopicChanged Type = "channel_topic_changed"
+)
+
+// Direct messages
+const (
+	DMMessage         Type = "dm_message"
+	DMRequest         Type = "dm_request"
+	DMRequestAccepted Type = "dm_request_accepted"
+	DMActivity        Type = "dm_activity"
+	DMGroupCreated    Type = "dm_group_created"
+)
+
+// Loop features
+const (
+	Board              Type = "board"
+	TaskCreated        Type = "task_created"
+	TaskDone           Type = "task_done"
+	TaskEscalated      Type = "task_escalated"
+	EventCreated       Type = "event_created"
+	EventUpdated       Type = "event_updated"
+	EventDeleted       Type = "event_deleted"
+	EventRSVP          Type = "event_rsvp"
+	OnboardingUpdated  Type = "onboarding_updated"
+	OnboardingProgress Type = "onboarding_progress"
+	BadgeAwarded       Type = "badge_awarded"
+	LoopBanned         Type = "loop_banned"
+	Notification       Type = "notification"
+)
+
+// GitHub
+const (
+	IssueComment         Type = "issue_comment"
+	IssueCommentDeleted  Type = "issue_comment_deleted"
+	IssueCommentsUpdated Type = "issue_comments_updated"
+	PRComment            Type = "pr_comment"
+	PRCommentDeleted     Type = "pr_comment_deleted"
+	PRCommentsUpdated    Type = "pr_comments_updated"
+	PRReview             Type = "pr_review"
+)
+
+// Envelope is the frame every event is sent in
+type Envelope struct {
+	Type      Type   `json:"type"`
+	Payload   any    `json:"payload,omitempty"`
+	ChannelID string `json:"channel_id,omitempty"`
+}
+
+// resource is an API resource sent as an event's payload
+type resource struct {
+	t     Type
+	value any
+}
+
+func (r resource) EventType() Type { return r.t }
+
+// Of sends value, typically an API response type, as a t event
+func Of(t Type, value any) Event {
+	return resource{t, value}
+}
+
+// Wrap puts ev in its envelope; channelID is set for events that belong to
+// one channel and empty otherwise
+func Wrap(ev Event, channelID string) Envelope {
+	env := Envelope{Type: ev.EventType(), ChannelID: channelID}
+	switch ev := ev.(type) {
+	case Type:
+	case resource:
+		env.Payload = ev.value
+	default:
+		env.Payload = ev
+	}
+	return env
+}
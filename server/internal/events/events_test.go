@@ -0,0 +1,107 @@
+package events
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// keys marshals v and returns its top-level JSON keys, sorted
+func keys(t *testing.T, v any) []string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal %T: %v", v, err)
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatalf("%T is not a JSON object: %s", v, b)
+	}
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// The schema clients are written against: each payload's type and keys.
+// A change here is a change to the wire format.
+var schemas = []struct {
+	event Event
+	typ   Type
+	keys  string
+}{
+	{ConnectedInfo{Online: []string{"u"}}, Connected, "channel_id online project_id"},
+	{TypingUser{}, Typing, "user_id username"},
+	{PresenceChange{}, Presence, "project_id status user_id"},
+	{MessageRef{}, MessageDeleted, "message_id"},
+	{MessageRef{Unpinned: true}, MessageUnpinned, "message_id"},
+	{Rejection{}, MessageRejected, "reason"},
+	{Rejection{MessageID: "1", ChannelID: "c", Rule: "r", Quota: "q"}, MessageRejected, "channel_id message_id quota reason rule"},
+	{HeldMessage{}, MessageHeld, "message_id send_at"},
+	{CancelledSend{}, SendCancelled, "message_id"},
+	{Pin{}, MessagePinned, "message_id pinned_at pinned_by"},
+	{Reaction{}, ReactionAdded, "emoji message_id user_id"},
+	{Reaction{Removed: true}, ReactionRemoved, "emoji message_id user_id"},
+	{ResolvedEntities{}, EntitiesResolved, "github_entities message_id"},
+	{ThreadExport{}, ThreadExported, "exported_by html_url message_id number target"},
+	{ThreadSummary{}, ThreadSummarized, "message_id replies summary updated_at"},
+	{CommandFailure{}, CommandError, "command error"},
+	{ArchivedChannel{}, ChannelArchived, "channel_id number reason"},
+	{ConversationRef{}, DMRequestAccepted, "conversation_id"},
+	{ConversationActivity{}, DMActivity, "conversation_id message_id sender_id"},
+	{BoardChange{Action: "column_updated", ColumnID: "c", Name: "n"}, Board, "action column_id name"},
+	{BoardChange{Action: "card_moved", CardID: "c", FromColumnID: "a", ColumnID: "b", Position: new(int)}, Board, "action card_id column_id from_column_id position"},
+	{BoardChange{Action: "synced", Updated: new(int)}, Board, "action updated"},
+	{TaskRef{}, TaskDone, "task_id"},
+	{TaskEscalation{}, TaskEscalated, "html_url issue_number task_id"},
+	{EventRef{}, EventDeleted, "event_id"},
+	{RSVP{}, EventRSVP, "event_id status user_id"},
+	{LoopRef{}, OnboardingProgress, "project_id"},
+	{Badge{}, BadgeAwarded, "badge user_id"},
+	{Ban{}, LoopBanned, "loop_id loop_name"},
+	{IssueCommentChange{}, IssueComment, "action comment issue_number"},
+	{PRCommentChange{}, PRComment, "comment pr_number"},
+	{PRCommentChange{Action: "edited"}, PRComment, "action comment pr_number"},
+	{IssueCommentRemoval{}, IssueCommentDeleted, "id issue_number type"},
+	{PRCommentRemoval{}, PRCommentDeleted, "id pr_number type"},
+	{IssueCommentsSync{}, IssueCommentsUpdated, "issue_number"},
+	{PRCommentsSync{}, PRCommentsUpdated, "pr_number"},
+	{Review{}, PRReview, "pr_number review review_state"},
+}
+
+func TestPayloadSchemas(t *testing.T) {
+	for _, s := range schemas {
+		if got := s.event.EventType(); got != s.typ {
+			t.Errorf("%T: type %q, want %q", s.event, got, s.typ)
+		}
+		if got := strings.Join(keys(t, s.event), " "); got != s.keys {
+			t.Errorf("%s %T: keys %q, want %q", s.typ, s.event, got, s.keys)
+		}
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		name string
+		env  Envelope
+		want string
+	}{
+		{"bare", Wrap(Pong, ""), `{"type":"pong"}`},
+		{"bare in channel", Wrap(Resync, "c1"), `{"type":"resync","channel_id":"c1"}`},
+		{"payload", Wrap(TaskRef{TaskID: "t1"}, "c1"), `{"type":"task_done","payload":{"task_id":"t1"},"channel_id":"c1"}`},
+		{"resource", Wrap(Of(Message, map[string]string{"id": "1"}), "c1"), `{"type":"message","payload":{"id":"1"},"channel_id":"c1"}`},
+		{"deleted", Wrap(MessageRef{MessageID: "9"}, "c1"), `{"type":"message_deleted","payload":{"message_id":"9"},"channel_id":"c1"}`},
+	}
+	for _, tt := range tests {
+		b, err := json.Marshal(tt.env)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if string(b) != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, b, tt.want)
+		}
+	}
+}
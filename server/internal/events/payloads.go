@@ -0,0 +1,305 @@
+package events
+
+// Payloads of the events whose shape is defined here. Fields holding an API
+// resource (a comment, a board card) are typed any so this package doesn't
+// depend on the API's response types; the events sent with a resource as
+// the whole payload go through Of.
+
+// ConnectedInfo greets a new connection with the channel it follows and,
+// over WebSocket, who in the loop is online
+type ConnectedInfo struct {
+	ChannelID string   `json:"channel_id"`
+	ProjectID string   `json:"project_id"`
+	Online    []string `json:"online,omitempty"`
+}
+
+func (ConnectedInfo) EventType() Type { return Connected }
+
+// TypingUser says someone is typing in the channel
+type TypingUser struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"`
+}
+
+func (TypingUser) EventType() Type { return Typing }
+
+// PresenceChange announces a user coming online ("online") or going
+// offline ("offline") in a loop
+type PresenceChange struct {
+	ProjectID string `json:"project_id"`
+	UserID    string `json:"user_id"`
+	Status    string `json:"status"`
+}
+
+func (PresenceChange) EventType() Type { return Presence }
+
+// MessageRef names the message a deletion or unpin is about
+type MessageRef struct {
+	MessageID string `json:"message_id"`
+	Unpinned  bool   `json:"-"` // message_unpinned rather than message_deleted
+}
+
+func (m MessageRef) EventType() Type {
+	if m.Unpinned {
+		return MessageUnpinned
+	}
+	return MessageDeleted
+}
+
+// Rejection tells a sender their message was not posted. Rule and Quota
+// name the filter rule or plan limit responsible, when there is one.
+type Rejection struct {
+	MessageID string `json:"message_id,omitempty"`
+	ChannelID string `json:"channel_id,omitempty"`
+	Reason    string `json:"reason"`
+	Rule      string `json:"rule,omitempty"`
+	Quota     string `json:"quota,omitempty"`
+	Limit     int    `json:"limit,omitempty"` // the message size limit, when over it
+}
+
+func (Rejection) EventType() Type { return MessageRejected }
+
+// HeldMessage tells a sender their message waits out their undo window
+// and goes out at SendAt unless they cancel it
+type HeldMessage struct {
+	MessageID string `json:"message_id"`
+	SendAt    string `json:"send_at"`
+}
+
+func (HeldMessage) EventType() Type { return MessageHeld }
+
+// CancelledSend tells a sender the held message was withdrawn
+type CancelledSend struct {
+	MessageID string `json:"message_id"`
+}
+
+func (CancelledSend) EventType() Type { return SendCancelled }
+
+// Pin says a message was pinned
+type Pin struct {
+	MessageID string `json:"message_id"`
+	PinnedBy  string `json:"pinned_by"`
+	PinnedAt  string `json:"pinned_at"`
+}
+
+func (Pin) EventType() Type { return MessagePinned }
+
+// Reaction is an emoji added to or removed from a message
+type Reaction struct {
+	MessageID string `json:"message_id"`
+	Emoji     string `json:"emoji"`
+	UserID    string `json:"user_id"`
+	Removed   bool   `json:"-"`
+}
+
+func (r Reaction) EventType() Type {
+	if r.Removed {
+		return ReactionRemoved
+	}
+	return ReactionAdded
+}
+
+// ResolvedEntities are the GitHub references found in a message
+type ResolvedEntities struct {
+	MessageID      string `json:"message_id"`
+	GitHubEntities any    `json:"github_entities"`
+}
+
+func (ResolvedEntities) EventType() Type { return EntitiesResolved }
+
+// ThreadExport says a thread was posted to the loop's repo
+type ThreadExport struct {
+	MessageID  string `json:"message_id"`
+	Target     string `json:"target"` // issue or discussion
+	Number     int    `json:"number"`
+	HTMLURL    string `json:"html_url"`
+	ExportedBy string `json:"exported_by"`
+}
+
+func (ThreadExport) EventType() Type { return ThreadExported }
+
+// ThreadSummary is a new or extended summary of a thread
+type ThreadSummary struct {
+	MessageID string `json:"message_id"`
+	Summary   string `json:"summary"`
+	Replies   int    `json:"replies"` // how many replies it covers
+	UpdatedAt string `json:"updated_at"`
+}
+
+func (ThreadSummary) EventType() Type { return ThreadSummarized }
+
+// CommandFailure is a chat command that couldn't run, sent to its caller
+type CommandFailure struct {
+	Command string `json:"command"`
+	Error   string `json:"error"`
+}
+
+func (CommandFailure) EventType() Type { return CommandError }
+
+// ArchivedChannel is a channel archived because its GitHub item closed
+type ArchivedChannel struct {
+	ChannelID string `json:"channel_id"`
+	Number    int    `json:"number"`
+	Reason    string `json:"reason"`
+}
+
+func (ArchivedChannel) EventType() Type { return ChannelArchived }
+
+// ConversationRef names the conversation whose request was accepted
+type ConversationRef struct {
+	ConversationID string `json:"conversation_id"`
+}
+
+func (ConversationRef) EventType() Type { return DMRequestAccepted }
+
+// ConversationActivity pings a group DM's participants about a new message
+// without its content
+type ConversationActivity struct {
+	ConversationID string `json:"conversation_id"`
+	MessageID      string `json:"message_id"`
+	SenderID       string `json:"sender_id"`
+}
+
+func (ConversationActivity) EventType() Type { return DMActivity }
+
+// BoardChange is any change to a loop's board; Action says which, and only
+// the fields of that action are set:
+//
+//	column_created, card_created, card_updated: Column or Card
+//	column_updated: ColumnID, Name
+//	column_deleted: ColumnID
+//	columns_reordered: ColumnIDs
+//	card_moved: CardID, FromColumnID, ColumnID, Position
+//	card_deleted: CardID
+//	synced: Updated
+type BoardChange struct {
+	Action       string   `json:"action"`
+	Column       any      `json:"column,omitempty"`
+	Card         any      `json:"card,omitempty"`
+	ColumnID     string   `json:"column_id,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	ColumnIDs    []string `json:"column_ids,omitempty"`
+	CardID       string   `json:"card_id,omitempty"`
+	FromColumnID string   `json:"from_column_id,omitempty"`
+	Position     *int     `json:"position,omitempty"`
+	Updated      *int     `json:"updated,omitempty"`
+}
+
+func (BoardChange) EventType() Type { return Board }
+
+// TaskRef names a task marked done
+type TaskRef struct {
+	TaskID string `json:"task_id"`
+}
+
+func (TaskRef) EventType() Type { return TaskDone }
+
+// TaskEscalation is a task turned into a GitHub issue
+type TaskEscalation struct {
+	TaskID      string `json:"task_id"`
+	IssueNumber int    `json:"issue_number"`
+	HTMLURL     string `json:"html_url"`
+}
+
+func (TaskEscalation) EventType() Type { return TaskEscalated }
+
+// EventRef names a deleted calendar event
+type EventRef struct {
+	EventID string `json:"event_id"`
+}
+
+func (EventRef) EventType() Type { return EventDeleted }
+
+// RSVP is a member's answer to a calendar event
+type RSVP struct {
+	EventID string `json:"event_id"`
+	UserID  string `json:"user_id"`
+	Status  string `json:"status"`
+}
+
+func (RSVP) EventType() Type { return EventRSVP }
+
+// LoopRef names the loop whose onboarding checklist moved on
+type LoopRef struct {
+	ProjectID string `json:"project_id"`
+}
+
+func (LoopRef) EventType() Type { return OnboardingProgress }
+
+// Badge is a badge awarded to a member
+type Badge struct {
+	UserID string `json:"user_id"`
+	Badge  string `json:"badge"`
+}
+
+func (Badge) EventType() Type { return BadgeAwarded }
+
+// Ban tells a user they were removed from a loop
+type Ban struct {
+	LoopID   string `json:"loop_id"`
+	LoopName string `json:"loop_name"`
+}
+
+func (Ban) EventType() Type { return LoopBanned }
+
+// IssueCommentChange is a comment on an issue created or edited, from
+// Wireloop or GitHub
+type IssueCommentChange struct {
+	IssueNumber int    `json:"issue_number"`
+	Action      string `json:"action"`
+	Comment     any    `json:"comment"`
+}
+
+func (IssueCommentChange) EventType() Type { return IssueComment }
+
+// PRCommentChange is a comment on a pull request; Action is empty for
+// comments posted from Wireloop
+type PRCommentChange struct {
+	PRNumber int    `json:"pr_number"`
+	Action   string `json:"action,omitempty"`
+	Comment  any    `json:"comment"`
+}
+
+func (PRCommentChange) EventType() Type { return PRComment }
+
+// IssueCommentRemoval is a comment deleted on GitHub
+type IssueCommentRemoval struct {
+	IssueNumber int    `json:"issue_number"`
+	ID          int64  `json:"id"`
+	Type        string `json:"type"`
+}
+
+func (IssueCommentRemoval) EventType() Type { return IssueCommentDeleted }
+
+// PRCommentRemoval is a review or comment deleted on GitHub
+type PRCommentRemoval struct {
+	PRNumber int    `json:"pr_number"`
+	ID       int64  `json:"id"`
+	Type     string `json:"type"`
+}
+
+func (PRCommentRemoval) EventType() Type { return PRCommentDeleted }
+
+// IssueCommentsSync says an issue's comments changed in a sync
+type IssueCommentsSync struct {
+	IssueNumber int `json:"issue_number"`
+}
+
+func (IssueCommentsSync) EventType() Type { return IssueCommentsUpdated }
+
+// PRCommentsSync says a pull request's comments changed in a sync
+type PRCommentsSync struct {
+	PRNumber int `json:"pr_number"`
+}
+
+func (PRCommentsSync) EventType() Type { return PRCommentsUpdated }
+
+// Review is a review submitted from Wireloop, with the PR's review state
+// after it
+type Review struct {
+	PRNumber    int `json:"pr_number"`
+	Review      any `json:"review"`
+	ReviewState any `json:"review_state"`
+}
+
+func (Review) EventType() Type { return PRReview }
@@ -0,0 +1,187 @@
+// Package flags evaluates feature flags per user and loop. Flags are
+// declared in code with a default and can be overridden at runtime by rows
+// in the feature_flags table.
+package flags
+
+import (
+	"context"
+	"hash/fnv"
+	"log"
+	"sort"
+	"sync"
+	"time"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Key names a flag
+type Key string
+
+// Definition is a flag known to the code
+type Definition struct {
+	Key         Key
+	Description string
+	Default     bool // used when the flag has no row in feature_flags
+}
+
+var (
+	defsMu sync.RWMutex
+	defs   = make(map[Key]Definition)
+)
+
+// Define registers a flag and returns its key. Call from package-level vars.
+func Define(key, description string, def bool) Key {
+	defsMu.Lock()
+	defer defsMu.Unlock()
+	defs[Key(key)] = Definition{Key: Key(key), Description: description, Default: def}
+	return Key(key)
+}
+
+// Definitions returns every flag declared in code, sorted by key
+func Definitions() []Definition {
+	defsMu.RLock()
+	defer defsMu.RUnlock()
+	out := make([]Definition, 0, len(defs))
+	for _, d := range defs {
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// Flags rolled out through this package
+var (
+	AISummaries    = Define("ai_summaries", "AI summaries of GitHub issues, pull requests and long threads", true)
+	MessageFilters = Define("message_filters", "Banned-word and spam filtering of channel messages", true)
+	NewSearch      = Define("new_search", "Next-generation search experience", false)
+)
+
+// Subject is who a flag is evaluated for. Either ID may be unset.
+type Subject struct {
+	UserID pgtype.UUID
+	LoopID pgtype.UUID
+}
+
+// Store caches the feature_flags table and evaluates flags against it.
+// Safe for concurrent use.
+type Store struct {
+	queries *db.Queries
+	TTL     time.Duration // how long a loaded snapshot is used before reloading
+
+	mu       sync.RWMutex
+	rows     map[Key]db.FeatureFlag
+	loadedAt time.Time
+}
+
+// New creates a store that reloads flags every 30 seconds at most
+func New(queries *db.Queries) *Store {
+	return &Store{queries: queries, TTL: 30 * time.Second}
+}
+
+// Enabled reports whether the flag is on for subj
+func (s *Store) Enabled(ctx context.Context, key Key, subj Subject) bool {
+	rows := s.snapshot(ctx)
+	row, ok := rows[key]
+	if !ok {
+		defsMu.RLock()
+		def := defs[key].Default
+		defsMu.RUnlock()
+		return def
+	}
+	return evaluate(row, subj)
+}
+
+// Evaluate returns every known flag (declared or stored) for subj
+func (s *Store) Evaluate(ctx context.Context, subj Subject) map[Key]bool {
+	rows := s.snapshot(ctx)
+	out := make(map[Key]bool, len(rows))
+	for _, d := range Definitions() {
+		out[d.Key] = d.Default
+	}
+	for k, row := range rows {
+		out[k] = evaluate(row, subj)
+	}
+	return out
+}
+
+// Invalidate drops the cached snapshot so the next evaluation reloads it.
+// Other instances pick up changes within TTL.
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+func (s *Store) snapshot(ctx context.Context) map[Key]db.FeatureFlag {
+	s.mu.RLock()
+	rows, fresh := s.rows, time.Since(s.loadedAt) < s.TTL
+	s.mu.RUnlock()
+	if fresh {
+		return rows
+	}
+
+	list, err := s.queries.ListFeatureFlags(ctx)
+	if err != nil {
+		// Keep serving the last snapshot (or defaults) rather than flapping features
+		log.Printf("[flags] reload failed: %v", err)
+		s.mu.Lock()
+		s.loadedAt = time.Now()
+		s.mu.Unlock()
+		return rows
+	}
+	rows = make(map[Key]db.FeatureFlag, len(list))
+	for _, f := range list {
+		rows[Key(f.Key)] = f
+	}
+	s.mu.Lock()
+	s.rows, s.loadedAt = rows, time.Now()
+	s.mu.Unlock()
+	return rows
+}
+
+func evaluate(f db.FeatureFlag, subj Subject) bool {
+	if !f.Enabled {
+		return false
+	}
+	if contains(f.AllowUsers, subj.UserID) || contains(f.AllowLoops, subj.LoopID) {
+		return true
+	}
+	if f.RolloutPercent >= 100 {
+		return true
+	}
+	if f.RolloutPercent <= 0 {
+		return false
+	}
+	// Bucket by user so a person sees the same thing in every loop; fall back
+	// to the loop for requests without a user
+	id := subj.UserID
+	if !id.Valid {
+		id = subj.LoopID
+	}
+	if !id.Valid {
+		return false
+	}
+	return bucket(f.Key, id) < int(f.RolloutPercent)
+}
+
+// bucket maps (flag, id) to 0-99. Including the key keeps rollouts of
+// different flags independent of each other.
+func bucket(key string, id pgtype.UUID) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	h.Write(id.Bytes[:])
+	return int(h.Sum32() % 100)
+}
+
+func contains(ids []pgtype.UUID, id pgtype.UUID) bool {
+	if !id.Valid {
+		return false
+	}
+	for _, x := range ids {
+		if x == id {
+			return true
+		}
+	}
+	return false
+}
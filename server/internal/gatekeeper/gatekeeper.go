@@ -2,12 +2,13 @@ package gatekeeper
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
-	"time"
+	"wireloop/internal/github"
+	"wireloop/internal/i18n"
 )
 
 // CriteriaType defines the types of contribution criteria
@@ -21,6 +22,19 @@ const (
 	IssueCount  CriteriaType = "ISSUE_COUNT"
 )
 
+// CriteriaTypes lists every supported criteria type
+var CriteriaTypes = []CriteriaType{PRCount, PRMerged, CommitCount, StarCount, IssueCount}
+
+// Valid reports whether t is a supported criteria type
+func (t CriteriaType) Valid() bool {
+	for _, v := range CriteriaTypes {
+		if t == v {
+			return true
+		}
+	}
+	return false
+}
+
 // Rule represents a single access requirement
 type Rule struct {
 	CriteriaType CriteriaType `json:"criteria_type"`
@@ -34,20 +48,56 @@ type VerificationResult struct {
 	Required int    `json:"required"`
 	Actual   int    `json:"actual"`
 	Message  string `json:"message"`
+
+	unverified bool
 }
 
+// criteriaNouns name what each criteria type counts, in English
+var criteriaNouns = map[CriteriaType]string{
+	PRCount:     "pull requests",
+	PRMerged:    "merged pull requests",
+	CommitCount: "commits",
+	StarCount:   "stars",
+	IssueCount:  "issues",
+}
+
+// Localize rewrites Message in locale
+func (r *VerificationResult) Localize(locale string) {
+	noun := i18n.T(locale, criteriaNouns[CriteriaType(r.Criteria)])
+	switch {
+	case r.unverified:
+		r.Message = i18n.T(locale, "✗ Could not verify %s (repo may be private or inaccessible)", noun)
+	case r.Passed:
+		r.Message = i18n.T(locale, "✓ You have %d %s (required: %d)", r.Actual, noun, r.Required)
+	default:
+		r.Message = i18n.T(locale, "✗ You need %d more %s", r.Required-r.Actual, noun)
+	}
+}
+
+// StarSource returns a repo's star count from somewhere cheaper than GitHub,
+// such as a stats cache; ok is false when it has no recent enough count
+type StarSource func(ctx context.Context, owner, repo string) (stars int, ok bool)
+
 // Gatekeeper verifies user contributions against repository rules
 type Gatekeeper struct {
-	httpClient *http.Client
+	gh    *github.Client
+	stars StarSource
 }
 
-// New creates a new Gatekeeper instance
+// New creates a new Gatekeeper instance backed by the shared GitHub client
 func New() *Gatekeeper {
-	return &Gatekeeper{
-		httpClient: &http.Client{
-			Timeout: 10 * time.Second,
-		},
-	}
+	return NewWith(github.Default)
+}
+
+// NewWith creates a Gatekeeper that asks gh
+func NewWith(gh *github.Client) *Gatekeeper {
+	return &Gatekeeper{gh: gh}
+}
+
+// UseStarSource makes STAR_COUNT rules read from src, asking GitHub only
+// when src has no count for the repo
+func (g *Gatekeeper) UseStarSource(src StarSource) {
+	g.stars = src
 }
 
 // RepoInfo holds the resolved GitHub repo owner and name
@@ -59,41 +109,15 @@ type RepoInfo struct {
 // ResolveRepoByID fetches the actual owner/name of a GitHub repo by its numeric ID.
 // This is critical because the Wireloop loop name and owner may differ from the GitHub repo.
 func (g *Gatekeeper) ResolveRepoByID(ctx context.Context, accessToken string, repoID int64) (*RepoInfo, error) {
-	url := fmt.Sprintf("https://api.github.com/repositories/%d", repoID)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	fullName, err := g.gh.RepoFullName(ctx, accessToken, repoID)
 	if err != nil {
 		return nil, err
 	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := g.httpClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("GitHub API returned %d for repo ID %d", resp.StatusCode, repoID)
-	}
-
-	var repoData struct {
-		Owner struct {
-			Login string `json:"login"`
-		} `json:"owner"`
-		Name string `json:"name"`
+	owner, name, ok := github.SplitFullName(fullName)
+	if !ok {
+		return nil, fmt.Errorf("unexpected repo name %q for repo ID %d", fullName, repoID)
 	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&repoData); err != nil {
-		return nil, err
-	}
-
-	return &RepoInfo{
-		Owner: repoData.Owner.Login,
-		Name:  repoData.Name,
-	}, nil
+	return &RepoInfo{Owner: owner, Name: name}, nil
 }
 
 // VerifyAccess checks if a user meets all rules for a repository
@@ -142,61 +166,30 @@ func (g *Gatekeeper) checkRule(ctx context.Context, accessToken, repoOwner, repo
 		// Non-fatal: if we can't check a rule (e.g. private repo, API error), mark as failed with a message
 		result.Passed = false
 		result.Actual = 0
-		result.Message = fmt.Sprintf("✗ Could not verify %s (repo may be private or inaccessible)", strings.ToLower(string(rule.CriteriaType)))
+		result.unverified = true
+		result.Localize(i18n.Default)
 		return result, nil
 	}
 
 	result.Actual = actual
 	result.Passed = actual >= rule.Threshold
-
-	if result.Passed {
-		result.Message = fmt.Sprintf("✓ You have %d %s (required: %d)", actual, strings.ToLower(string(rule.CriteriaType)), rule.Threshold)
-	} else {
-		result.Message = fmt.Sprintf("✗ You need %d more %s", rule.Threshold-actual, strings.ToLower(string(rule.CriteriaType)))
-	}
+	result.Localize(i18n.Default)
 
 	return result, nil
 }
 
 // getPRCount fetches the number of PRs by a user on a repo
 func (g *Gatekeeper) getPRCount(ctx context.Context, accessToken, owner, repo, username string, mergedOnly bool) (int, error) {
-	// GitHub Search API: search for PRs by author in repo
 	state := "all"
 	if mergedOnly {
 		state = "closed"
 	}
 
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls?state=%s&per_page=100", owner, repo, state)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	pulls, err := g.gh.ListPulls(ctx, accessToken, owner+"/"+repo, github.ListOptions{State: state, PerPage: "100"})
 	if err != nil {
 		return 0, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := g.httpClient.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
-	}
-
-	var pulls []struct {
-		User struct {
-			Login string `json:"login"`
-		} `json:"user"`
-		MergedAt *string `json:"merged_at"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&pulls); err != nil {
-		return 0, err
-	}
-
 	count := 0
 	for _, pr := range pulls {
 		if strings.EqualFold(pr.User.Login, username) {
@@ -215,71 +208,22 @@ func (g *Gatekeeper) getPRCount(ctx context.Context, accessToken, owner, repo, u
 
 // getCommitCount fetches the number of commits by a user on a repo
 func (g *Gatekeeper) getCommitCount(ctx context.Context, accessToken, owner, repo, username string) (int, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/commits?author=%s&per_page=100", owner, repo, username)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return 0, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := g.httpClient.Do(req)
+	commits, err := g.gh.ListCommits(ctx, accessToken, owner+"/"+repo, github.ListOptions{PerPage: "100"},
+		url.Values{"author": {username}})
 	if err != nil {
 		return 0, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
-	}
-
-	// Check Link header for total count (if paginated)
-	linkHeader := resp.Header.Get("Link")
-	if linkHeader != "" && strings.Contains(linkHeader, "last") {
-		// Parse last page number from Link header for accurate count
-		// For now, just count what we get
-	}
-
-	var commits []interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&commits); err != nil {
-		return 0, err
-	}
-
 	return len(commits), nil
 }
 
 // getIssueCount fetches the number of issues created by a user on a repo
 func (g *Gatekeeper) getIssueCount(ctx context.Context, accessToken, owner, repo, username string) (int, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/issues?creator=%s&state=all&per_page=100", owner, repo, username)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	issues, err := g.gh.ListIssues(ctx, accessToken, owner+"/"+repo, github.ListOptions{State: "all", PerPage: "100"},
+		url.Values{"creator": {username}})
 	if err != nil {
 		return 0, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := g.httpClient.Do(req)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
-	}
-
-	var issues []struct {
-		PullRequest interface{} `json:"pull_request"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&issues); err != nil {
-		return 0, err
-	}
-
 	// Filter out PRs (GitHub returns PRs in issues endpoint)
 	count := 0
 	for _, issue := range issues {
@@ -291,37 +235,19 @@ func (g *Gatekeeper) getIssueCount(ctx context.Context, accessToken, owner, repo
 	return count, nil
 }
 
-// getStarCount fetches the star count for a repo
+// getStarCount fetches the star count for a repo, from the star source when
+// it has one
 func (g *Gatekeeper) getStarCount(ctx context.Context, accessToken, owner, repo string) (int, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return 0, err
+	if g.stars != nil {
+		if n, ok := g.stars(ctx, owner, repo); ok {
+			return n, nil
+		}
 	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := g.httpClient.Do(req)
+	r, err := g.gh.GetRepo(ctx, accessToken, owner, repo)
 	if err != nil {
 		return 0, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("GitHub API returned %d", resp.StatusCode)
-	}
-
-	var repoData struct {
-		StargazersCount int `json:"stargazers_count"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&repoData); err != nil {
-		return 0, err
-	}
-
-	return repoData.StargazersCount, nil
+	return r.StarCount, nil
 }
 
 // ParseThreshold converts a string threshold to int
@@ -333,40 +259,19 @@ func ParseThreshold(s string) (int, error) {
 // Uses GET /repos/{owner}/{repo} which returns the user's permissions on the repo.
 // This works with any token that has access to the repo — no admin required.
 func (g *Gatekeeper) CheckCollaborator(ctx context.Context, accessToken, owner, repo, username string) (bool, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
-
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return false, err
-	}
-
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-	req.Header.Set("Accept", "application/vnd.github.v3+json")
-
-	resp, err := g.httpClient.Do(req)
+	r, err := g.gh.GetRepo(ctx, accessToken, owner, repo)
 	if err != nil {
+		var apiErr *github.APIError
+		if errors.As(err, &apiErr) {
+			// No access to the repo at all — not a collaborator
+			return false, nil
+		}
 		return false, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
+	if r.Permissions == nil {
 		return false, nil
 	}
 
-	var repoData struct {
-		Permissions struct {
-			Admin    bool `json:"admin"`
-			Maintain bool `json:"maintain"`
-			Push     bool `json:"push"`
-			Triage   bool `json:"triage"`
-			Pull     bool `json:"pull"`
-		} `json:"permissions"`
-	}
-
-	if err := json.NewDecoder(resp.Body).Decode(&repoData); err != nil {
-		return false, err
-	}
-
 	// User has push (write) access or higher = collaborator
-	return repoData.Permissions.Push || repoData.Permissions.Admin || repoData.Permissions.Maintain, nil
+	return r.Permissions.Push || r.Permissions.Admin || r.Permissions.Maintain, nil
 }
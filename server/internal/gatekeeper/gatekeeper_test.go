@@ -0,0 +1,138 @@
+package gatekeeper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"wireloop/internal/testkit"
+)
+
+func TestVerifyAccess(t *testing.T) {
+	gh := testkit.NewGitHub(t)
+	gh.AddUser("maintainer", "maintainer-token")
+	gh.AddUser("ana", "ana-token")
+	repo := gh.AddRepo("maintainer", "app", false)
+	repo.StarCount = 42
+	repo.AddPull("ana", "Fix typo", true)
+	repo.AddPull("ana", "Add docs", true)
+	repo.AddPull("ana", "WIP", false)
+	repo.AddPull("bob", "Other", true)
+	repo.AddIssue("ana", "Crash on start", "closed")
+	repo.Commits["ana"] = 5
+	g := NewWith(gh.Client())
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		rule   Rule
+		actual int
+		passed bool
+	}{
+		{"pull requests", Rule{PRCount, 3}, 3, true},
+		{"merged pull requests short", Rule{PRMerged, 3}, 2, false},
+		{"merged pull requests", Rule{PRMerged, 2}, 2, true},
+		{"commits", Rule{CommitCount, 5}, 5, true},
+		// Pull requests show up in GitHub's issue list and must not count
+		{"issues", Rule{IssueCount, 2}, 1, false},
+		{"stars", Rule{StarCount, 10}, 42, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			results, passed, err := g.VerifyAccess(ctx, "ana-token", "maintainer", "app", "ana", []Rule{tc.rule})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if passed != tc.passed || results[0].Actual != tc.actual {
+				t.Errorf("got actual=%d passed=%v, want actual=%d passed=%v", results[0].Actual, passed, tc.actual, tc.passed)
+			}
+		})
+	}
+}
+
+func TestVerifyAccessUnverifiable(t *testing.T) {
+	gh := testkit.NewGitHub(t)
+	gh.AddUser("ana", "ana-token")
+	gh.AddRepo("maintainer", "secret", true)
+	g := NewWith(gh.Client())
+
+	results, passed, err := g.VerifyAccess(context.Background(), "ana-token", "maintainer", "secret", "ana", []Rule{{PRCount, 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if passed || !results[0].unverified {
+		t.Errorf("private repo: passed=%v unverified=%v, want a failed, unverified rule", passed, results[0].unverified)
+	}
+}
+
+func TestVerifyAccessRateLimited(t *testing.T) {
+	gh := testkit.NewGitHub(t)
+	gh.AddUser("ana", "ana-token")
+	repo := gh.AddRepo("maintainer", "app", false)
+	repo.AddPull("ana", "Fix", true)
+	gh.SetRateLimit(1, time.Now().Add(time.Hour))
+	g := NewWith(gh.Client())
+
+	rules := []Rule{{PRCount, 1}, {PRMerged, 1}}
+	results, passed, err := g.VerifyAccess(context.Background(), "ana-token", "maintainer", "app", "ana", rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Passed || results[1].Passed || !results[1].unverified || passed {
+		t.Errorf("results = %+v, want the second rule unverified once the limit ran out", results)
+	}
+	// The client backs off until the reset instead of asking again
+	if n := len(gh.Requests()); n != 1 {
+		t.Errorf("GitHub got %d requests, want 1", n)
+	}
+}
+
+func TestCheckCollaborator(t *testing.T) {
+	gh := testkit.NewGitHub(t)
+	for _, login := range []string{"maintainer", "writer", "triager", "ana"} {
+		gh.AddUser(login, login+"-token")
+	}
+	repo := gh.AddRepo("maintainer", "app", true)
+	repo.Collaborators["writer"] = "push"
+	repo.Collaborators["triager"] = "triage"
+	g := NewWith(gh.Client())
+
+	cases := []struct {
+		login string
+		want  bool
+	}{
+		{"maintainer", true},
+		{"writer", true},
+		{"triager", false},
+		{"ana", false}, // can't see the private repo at all
+	}
+	for _, tc := range cases {
+		t.Run(tc.login, func(t *testing.T) {
+			got, err := g.CheckCollaborator(context.Background(), tc.login+"-token", "maintainer", "app", tc.login)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("CheckCollaborator = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestResolveRepoByID(t *testing.T) {
+	gh := testkit.NewGitHub(t)
+	gh.AddUser("ana", "ana-token")
+	repo := gh.AddRepo("Maintainer", "App", false)
+	g := NewWith(gh.Client())
+
+	info, err := g.ResolveRepoByID(context.Background(), "ana-token", repo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Owner != "Maintainer" || info.Name != "App" {
+		t.Errorf("ResolveRepoByID = %+v", info)
+	}
+	if _, err := g.ResolveRepoByID(context.Background(), "bad-token", repo.ID+1); err == nil {
+		t.Error("unknown repo resolved")
+	}
+}
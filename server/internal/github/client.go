@@ -0,0 +1,251 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"wireloop/internal/cache"
+)
+
+const apiBase = "https://api.github.com"
+
+// Client is the single configured GitHub REST client for the server.
+// It shares one pooled http.Client, maps non-2xx responses to *APIError,
+// caches repo-ID → full-name lookups, and backs off per token once GitHub
+// reports the rate limit is exhausted.
+type Client struct {
+	http    *http.Client
+	baseURL string
+
+	repoNames *cache.TTL[int64, string]
+
+	rateMu    sync.Mutex
+	exhausted map[string]time.Time // token hash -> rate limit reset
+}
+
+// Default is shared by every package that talks to GitHub
+var Default = New()
+
+// New creates a client with pooled connections
+func New() *Client {
+	return NewAt(apiBase)
+}
+
+// NewAt creates a client for the REST API at baseURL instead of GitHub's,
+// e.g. a fake one in tests
+func NewAt(baseURL string) *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout: 15 * time.Second,
+			Transport: &http.Transport{
+				MaxIdleConns:        20,
+				MaxIdleConnsPerHost: 10,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		repoNames: cache.New[int64, string](time.Hour, 10000),
+		exhausted: make(map[string]time.Time),
+	}
+}
+
+// Response carries pagination info alongside the decoded body
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	NextURL    string // rel="next" from the Link header, empty on the last page
+}
+
+// Get performs an authenticated GET against a REST path and decodes JSON into out
+func (c *Client) Get(ctx context.Context, token, path string, out any) (*Response, error) {
+	return c.Do(ctx, token, http.MethodGet, path, nil, out)
+}
+
+// Post performs an authenticated POST with a JSON body and decodes JSON into out
+func (c *Client) Post(ctx context.Context, token, path string, body, out any) (*Response, error) {
+	return c.Do(ctx, token, http.MethodPost, path, body, out)
+}
+
+// Do is the one place requests are built, sent and error-mapped.
+// path may be relative to the API base or an absolute URL (e.g. from a Link header).
+func (c *Client) Do(ctx context.Context, token, method, path string, body, out any) (*Response, error) {
+	tokenKey := hashToken(token)
+	if reset, limited := c.rateLimited(tokenKey); limited {
+		return nil, &APIError{StatusCode: http.StatusForbidden, Method: method, Path: path, ResetAt: reset}
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(buf)
+	}
+
+	url := path
+	if len(path) > 0 && path[0] == '/' {
+		url = c.baseURL + path
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if strings.HasPrefix(url, c.baseURL) {
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	} else {
+		// github.com endpoints (OAuth) return form-encoded bodies unless asked for JSON
+		req.Header.Set("Accept", "application/json")
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to GitHub API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	c.trackRateLimit(tokenKey, resp)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{
+			StatusCode:     resp.StatusCode,
+			Method:         method,
+			Path:           path,
+			Scopes:         headerScopes(resp.Header, "X-OAuth-Scopes"),
+			AcceptedScopes: headerScopes(resp.Header, "X-Accepted-OAuth-Scopes"),
+		}
+		var ghErr struct {
+			Message string `json:"message"`
+		}
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		if json.Unmarshal(raw, &ghErr) == nil {
+			apiErr.Message = ghErr.Message
+		}
+		if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+			apiErr.ResetAt = parseReset(resp.Header)
+		}
+		log.Printf("[github] %s %s -> %d: %s", method, path, resp.StatusCode, apiErr.Message)
+		return nil, apiErr
+	}
+
+	// 202 means the result isn't ready yet (e.g. /stats still computing); its body is a placeholder
+	if out != nil && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusAccepted {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return nil, fmt.Errorf("failed to parse GitHub response: %w", err)
+		}
+	}
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, NextURL: nextLink(resp.Header)}, nil
+}
+
+// nextLink extracts the rel="next" URL from a Link header:
+// <https://api.github.com/user/repos?page=2>; rel="next", <...>; rel="last"
+func nextLink(h http.Header) string {
+	for _, part := range strings.Split(h.Get("Link"), ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok || !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		return strings.Trim(strings.TrimSpace(target), "<>")
+	}
+	return ""
+}
+
+// lastPage extracts the page number of the rel="last" URL from a Link
+// header, 0 when there is none (the response was the only page)
+func lastPage(h http.Header) int {
+	for _, part := range strings.Split(h.Get("Link"), ",") {
+		target, params, ok := strings.Cut(part, ";")
+		if !ok || !strings.Contains(params, `rel="last"`) {
+			continue
+		}
+		u, err := url.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return 0
+		}
+		n, _ := strconv.Atoi(u.Query().Get("page"))
+		return n
+	}
+	return 0
+}
+
+// getAllPages follows Link headers from path, stopping after maxPages.
+// truncated reports whether more pages were available.
+func getAllPages[T any](ctx context.Context, c *Client, token, path string, maxPages int) (items []T, truncated bool, err error) {
+	for page := 0; path != ""; page++ {
+		if page == maxPages {
+			return items, true, nil
+		}
+		var batch []T
+		resp, err := c.Get(ctx, token, path, &batch)
+		if err != nil {
+			return items, false, err
+		}
+		items = append(items, batch...)
+		path = resp.NextURL
+	}
+	return items, false, nil
+}
+
+// ============================================================================
+// Rate limit handling
+// ============================================================================
+
+func (c *Client) rateLimited(tokenKey string) (time.Time, bool) {
+	c.rateMu.Lock()
+	defer c.rateMu.Unlock()
+	reset, ok := c.exhausted[tokenKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	if time.Now().After(reset) {
+		delete(c.exhausted, tokenKey)
+		return time.Time{}, false
+	}
+	return reset, true
+}
+
+func (c *Client) trackRateLimit(tokenKey string, resp *http.Response) {
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return
+	}
+	reset := parseReset(resp.Header)
+	if reset.IsZero() {
+		return
+	}
+	c.rateMu.Lock()
+	c.exhausted[tokenKey] = reset
+	c.rateMu.Unlock()
+	log.Printf("[github] rate limit exhausted, backing off until %s", reset.Format(time.RFC3339))
+}
+
+func parseReset(h http.Header) time.Time {
+	secs, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(secs, 0)
+}
+
+// hashToken keeps raw tokens out of long-lived maps
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,460 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListOptions are the common list query parameters.
+// Empty fields are omitted so GitHub's defaults apply.
+type ListOptions struct {
+	State   string
+	Page    string
+	PerPage string
+	Sort    string
+	Dir     string
+}
+
+func (o ListOptions) encode(extra url.Values) string {
+	q := url.Values{}
+	for k, v := range extra {
+		q[k] = v
+	}
+	set := func(k, v string) {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	set("state", o.State)
+	set("page", o.Page)
+	set("per_page", o.PerPage)
+	set("sort", o.Sort)
+	set("direction", o.Dir)
+	if len(q) == 0 {
+		return ""
+	}
+	return "?" + q.Encode()
+}
+
+// ============================================================================
+// Users
+// ============================================================================
+
+// GetAuthenticatedUser returns the profile of the token owner
+func (c *Client) GetAuthenticatedUser(ctx context.Context, token string) (*User, error) {
+	var u User
+	if _, err := c.Get(ctx, token, "/user", &u); err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// ListUserRepos returns one page of repos the token owner can access.
+// filter takes /user/repos parameters such as affiliation and visibility.
+func (c *Client) ListUserRepos(ctx context.Context, token string, opts ListOptions, filter url.Values) ([]Repo, *Response, error) {
+	var repos []Repo
+	resp, err := c.Get(ctx, token, "/user/repos"+opts.encode(filter), &repos)
+	return repos, resp, err
+}
+
+// ListAllUserRepos follows pagination up to maxPages pages
+func (c *Client) ListAllUserRepos(ctx context.Context, token string, opts ListOptions, filter url.Values, maxPages int) ([]Repo, bool, error) {
+	return getAllPages[Repo](ctx, c, token, "/user/repos"+opts.encode(filter), maxPages)
+}
+
+// ============================================================================
+// Repositories
+// ============================================================================
+
+// GetRepoByID resolves a repo by its stable numeric ID
+func (c *Client) GetRepoByID(ctx context.Context, token string, repoID int64) (*Repo, error) {
+	var r Repo
+	if _, err := c.Get(ctx, token, fmt.Sprintf("/repositories/%d", repoID), &r); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			c.ForgetRepo(repoID)
+		}
+		return nil, err
+	}
+	c.repoNames.Set(repoID, r.FullName)
+	return &r, nil
+}
+
+// GetRepo fetches owner/name, including the caller's permissions
+func (c *Client) GetRepo(ctx context.Context, token, owner, name string) (*Repo, error) {
+	var r Repo
+	if _, err := c.Get(ctx, token, "/repos/"+owner+"/"+name, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// RepoFullName returns "owner/name" for a repo ID, served from cache when possible.
+// Loops store the numeric ID, which survives renames; the name is what the API needs.
+func (c *Client) RepoFullName(ctx context.Context, token string, repoID int64) (string, error) {
+	if repoID == 0 {
+		return "", fmt.Errorf("no GitHub repository linked to this loop (repo ID is 0)")
+	}
+	if name, ok := c.repoNames.Get(repoID); ok {
+		return name, nil
+	}
+	r, err := c.GetRepoByID(ctx, token, repoID)
+	if err != nil {
+		return "", err
+	}
+	return r.FullName, nil
+}
+
+// ForgetRepo drops a cached full name, e.g. after a 404 on a repo-scoped call
+func (c *Client) ForgetRepo(repoID int64) {
+	c.repoNames.Delete(repoID)
+}
+
+// TokenHealth is what a token can see of a repo and how much of its rate
+// limit is left
+type TokenHealth struct {
+	Repo        string           `json:"repo"`
+	Permissions *RepoPermissions `json:"permissions,omitempty"`
+	// Classic OAuth scopes; GitHub sends none for app and fine-grained tokens
+	Scopes         []string  `json:"scopes"`
+	RateLimit      int       `json:"rate_limit"`
+	RateRemaining  int       `json:"rate_remaining"`
+	RateLimitReset time.Time `json:"rate_limit_reset"`
+}
+
+// CheckRepoToken reads the repo with token, reporting what the token can do.
+// Errors are the usual *APIError, e.g. ErrUnauthorized for a revoked token.
+func (c *Client) CheckRepoToken(ctx context.Context, token string, repoID int64) (*TokenHealth, error) {
+	var r Repo
+	resp, err := c.Get(ctx, token, fmt.Sprintf("/repositories/%d", repoID), &r)
+	if err != nil {
+		return nil, err
+	}
+	h := &TokenHealth{Repo: r.FullName, Permissions: r.Permissions, Scopes: headerScopes(resp.Header, "X-OAuth-Scopes"), RateLimitReset: parseReset(resp.Header)}
+	if h.Scopes == nil {
+		h.Scopes = []string{}
+	}
+	h.RateLimit, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Limit"))
+	h.RateRemaining, _ = strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	return h, nil
+}
+
+// SplitFullName splits "owner/name"
+func SplitFullName(fullName string) (owner, name string, ok bool) {
+	return strings.Cut(fullName, "/")
+}
+
+// ============================================================================
+// Issues and pull requests
+// ============================================================================
+
+// ListIssues lists issues (GitHub includes PRs here; see Issue.PullRequest)
+func (c *Client) ListIssues(ctx context.Context, token, repo string, opts ListOptions, filter url.Values) ([]Issue, error) {
+	var issues []Issue
+	_, err := c.Get(ctx, token, "/repos/"+repo+"/issues"+opts.encode(filter), &issues)
+	return issues, err
+}
+
+func (c *Client) GetIssue(ctx context.Context, token, repo string, number int) (*Issue, error) {
+	var issue Issue
+	if _, err := c.Get(ctx, token, fmt.Sprintf("/repos/%s/issues/%d", repo, number), &issue); err != nil {
+		return nil, err
+	}
+	return &issue, nil
+}
+
+// CreateIssue opens an issue; the token needs push or triage access
+func (c *Client) CreateIssue(ctx context.Context, token, repo, title, body string) (*Issue, error) {
+	var created Issue
+	payload := map[string]string{"title": title, "body": body}
+	if _, err := c.Post(ctx, token, "/repos/"+repo+"/issues", payload, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (c *Client) ListPulls(ctx context.Context, token, repo string, opts ListOptions) ([]PullRequest, error) {
+	var prs []PullRequest
+	_, err := c.Get(ctx, token, "/repos/"+repo+"/pulls"+opts.encode(nil), &prs)
+	return prs, err
+}
+
+func (c *Client) GetPull(ctx context.Context, token, repo string, number int) (*PullRequest, error) {
+	var pr PullRequest
+	if _, err := c.Get(ctx, token, fmt.Sprintf("/repos/%s/pulls/%d", repo, number), &pr); err != nil {
+		return nil, err
+	}
+	return &pr, nil
+}
+
+// ListReleases lists a repo's releases, newest first
+func (c *Client) ListReleases(ctx context.Context, token, repo string, opts ListOptions) ([]Release, error) {
+	var releases []Release
+	_, err := c.Get(ctx, token, "/repos/"+repo+"/releases"+opts.encode(nil), &releases)
+	return releases, err
+}
+
+// ListDeployments lists deployments, newest first, optionally filtered (e.g. environment=production)
+func (c *Client) ListDeployments(ctx context.Context, token, repo string, opts ListOptions, filter url.Values) ([]Deployment, error) {
+	var deployments []Deployment
+	_, err := c.Get(ctx, token, "/repos/"+repo+"/deployments"+opts.encode(filter), &deployments)
+	return deployments, err
+}
+
+// ListDeploymentStatuses lists a deployment's statuses, newest first
+func (c *Client) ListDeploymentStatuses(ctx context.Context, token, repo string, deploymentID int64, opts ListOptions) ([]DeploymentStatus, error) {
+	var statuses []DeploymentStatus
+	path := fmt.Sprintf("/repos/%s/deployments/%d/statuses", repo, deploymentID)
+	_, err := c.Get(ctx, token, path+opts.encode(nil), &statuses)
+	return statuses, err
+}
+
+// DispatchWorkflow triggers a workflow_dispatch run on ref. workflow is the
+// numeric ID or the file name (deploy.yml). The run's ID and URL are returned
+// when GitHub supports return_run_details; otherwise the result is zero.
+func (c *Client) DispatchWorkflow(ctx context.Context, token, repo, workflow, ref string, inputs map[string]string) (*WorkflowDispatch, error) {
+	var run WorkflowDispatch
+	path := fmt.Sprintf("/repos/%s/actions/workflows/%s/dispatches", repo, url.PathEscape(workflow))
+	payload := map[string]any{"ref": ref, "return_run_details": true}
+	if len(inputs) > 0 {
+		payload["inputs"] = inputs
+	}
+	if _, err := c.Post(ctx, token, path, payload, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// GetCommit returns the commit a SHA or unambiguous SHA prefix names
+func (c *Client) GetCommit(ctx context.Context, token, repo, sha string) (*Commit, error) {
+	var commit Commit
+	if _, err := c.Get(ctx, token, "/repos/"+repo+"/commits/"+url.PathEscape(sha), &commit); err != nil {
+		return nil, err
+	}
+	return &commit, nil
+}
+
+// ListCommits lists commits, optionally filtered (e.g. author=login)
+func (c *Client) ListCommits(ctx context.Context, token, repo string, opts ListOptions, filter url.Values) ([]struct{}, error) {
+	var commits []struct{}
+	_, err := c.Get(ctx, token, "/repos/"+repo+"/commits"+opts.encode(filter), &commits)
+	return commits, err
+}
+
+// ============================================================================
+// Statistics
+// ============================================================================
+
+// getStats fetches a /stats endpoint, which answers 202 until GitHub has
+// computed the numbers for the repo
+func getStats[T any](ctx context.Context, c *Client, token, path string) (T, error) {
+	var out T
+	resp, err := c.Get(ctx, token, path, &out)
+	if err != nil {
+		return out, err
+	}
+	if resp.StatusCode == http.StatusAccepted {
+		return out, ErrStatsPending
+	}
+	return out, nil
+}
+
+// ContributorStats returns per-author commit totals with weekly breakdowns
+func (c *Client) ContributorStats(ctx context.Context, token, repo string) ([]ContributorStats, error) {
+	return getStats[[]ContributorStats](ctx, c, token, "/repos/"+repo+"/stats/contributors")
+}
+
+// ContributorCount returns how many people have committed to the repo,
+// anonymous contributors included. It asks for one contributor per page and
+// reads the count off the last page's number rather than listing them all.
+func (c *Client) ContributorCount(ctx context.Context, token, repo string) (int, error) {
+	var page []struct{}
+	resp, err := c.Get(ctx, token, "/repos/"+repo+"/contributors?per_page=1&anon=1", &page)
+	if err != nil {
+		return 0, err
+	}
+	if n := lastPage(resp.Header); n > 0 {
+		return n, nil
+	}
+	return len(page), nil
+}
+
+// CommitActivity returns commit counts for each of the last 52 weeks
+func (c *Client) CommitActivity(ctx context.Context, token, repo string) ([]WeeklyCommits, error) {
+	return getStats[[]WeeklyCommits](ctx, c, token, "/repos/"+repo+"/stats/commit_activity")
+}
+
+// ListLanguages returns bytes of code per language
+func (c *Client) ListLanguages(ctx context.Context, token, repo string) (map[string]int64, error) {
+	var langs map[string]int64
+	_, err := c.Get(ctx, token, "/repos/"+repo+"/languages", &langs)
+	return langs, err
+}
+
+// SearchIssueCount returns how many issues/PRs match a search query
+// (e.g. "repo:o/n is:issue is:open") without fetching them
+func (c *Client) SearchIssueCount(ctx context.Context, token, query string) (int, error) {
+	var result struct {
+		TotalCount int `json:"total_count"`
+	}
+	q := url.Values{"q": {query}, "per_page": {"1"}}
+	if _, err := c.Get(ctx, token, "/search/issues?"+q.Encode(), &result); err != nil {
+		return 0, err
+	}
+	return result.TotalCount, nil
+}
+
+// ============================================================================
+// Comments and reviews
+// ============================================================================
+
+// ListIssueComments lists top-level comments on an issue or PR
+func (c *Client) ListIssueComments(ctx context.Context, token, repo string, number int, opts ListOptions) ([]Comment, error) {
+	var comments []Comment
+	_, err := c.Get(ctx, token, fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number)+opts.encode(nil), &comments)
+	return comments, err
+}
+
+// ListReviewComments lists inline diff comments on a PR
+func (c *Client) ListReviewComments(ctx context.Context, token, repo string, number int, opts ListOptions) ([]ReviewComment, error) {
+	var comments []ReviewComment
+	_, err := c.Get(ctx, token, fmt.Sprintf("/repos/%s/pulls/%d/comments", repo, number)+opts.encode(nil), &comments)
+	return comments, err
+}
+
+func (c *Client) ListReviews(ctx context.Context, token, repo string, number int, opts ListOptions) ([]Review, error) {
+	var reviews []Review
+	_, err := c.Get(ctx, token, fmt.Sprintf("/repos/%s/pulls/%d/reviews", repo, number)+opts.encode(nil), &reviews)
+	return reviews, err
+}
+
+// CreateIssueComment posts a top-level comment on an issue or PR
+func (c *Client) CreateIssueComment(ctx context.Context, token, repo string, number int, body string) (*Comment, error) {
+	var created Comment
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", repo, number)
+	if _, err := c.Post(ctx, token, path, map[string]string{"body": body}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ReplyToReviewComment replies in the thread of an inline review comment
+func (c *Client) ReplyToReviewComment(ctx context.Context, token, repo string, number int, commentID int64, body string) (*ReviewComment, error) {
+	var created ReviewComment
+	path := fmt.Sprintf("/repos/%s/pulls/%d/comments/%d/replies", repo, number, commentID)
+	if _, err := c.Post(ctx, token, path, map[string]string{"body": body}, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreateReviewComment posts a new inline comment on a PR diff
+func (c *Client) CreateReviewComment(ctx context.Context, token, repo string, number int, comment NewReviewComment) (*ReviewComment, error) {
+	var created ReviewComment
+	path := fmt.Sprintf("/repos/%s/pulls/%d/comments", repo, number)
+	if _, err := c.Post(ctx, token, path, comment, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// CreateReview creates a review, pending unless review.Event is set
+func (c *Client) CreateReview(ctx context.Context, token, repo string, number int, review NewReview) (*Review, error) {
+	var created Review
+	path := fmt.Sprintf("/repos/%s/pulls/%d/reviews", repo, number)
+	if _, err := c.Post(ctx, token, path, review, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// SubmitReview publishes a pending review with the given event
+func (c *Client) SubmitReview(ctx context.Context, token, repo string, number int, reviewID int64, event, body string) (*Review, error) {
+	var submitted Review
+	path := fmt.Sprintf("/repos/%s/pulls/%d/reviews/%d/events", repo, number, reviewID)
+	payload := map[string]string{"event": event}
+	if body != "" {
+		payload["body"] = body
+	}
+	if _, err := c.Post(ctx, token, path, payload, &submitted); err != nil {
+		return nil, err
+	}
+	return &submitted, nil
+}
+
+// graphQLError is one entry of a GraphQL response's "errors"
+type graphQLError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// graphQL runs a query or mutation and decodes its "data" into out. GraphQL
+// reports most failures with a 200 and an "errors" list; those come back as
+// an APIError so callers can branch on them like REST errors.
+func (c *Client) graphQL(ctx context.Context, token, query string, vars map[string]any, out any) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	body := map[string]any{"query": query, "variables": vars}
+	if _, err := c.Post(ctx, token, "/graphql", body, &resp); err != nil {
+		return err
+	}
+	if len(resp.Errors) > 0 {
+		e := resp.Errors[0]
+		apiErr := &APIError{StatusCode: http.StatusUnprocessableEntity, Method: http.MethodPost, Path: "/graphql", Message: e.Message}
+		switch e.Type {
+		case "NOT_FOUND":
+			apiErr.StatusCode = http.StatusNotFound
+		case "FORBIDDEN":
+			apiErr.StatusCode = http.StatusForbidden
+		case "RATE_LIMITED":
+			apiErr.StatusCode = http.StatusTooManyRequests
+		}
+		return apiErr
+	}
+	return json.Unmarshal(resp.Data, out)
+}
+
+// CommentOnDiscussion adds a top-level comment to discussion #number. The
+// REST API can't write to discussions, so this goes through GraphQL.
+func (c *Client) CommentOnDiscussion(ctx context.Context, token, repo string, number int, body string) (*DiscussionComment, error) {
+	owner, name, ok := SplitFullName(repo)
+	if !ok {
+		return nil, fmt.Errorf("invalid repository name %q", repo)
+	}
+
+	var found struct {
+		Repository struct {
+			Discussion *struct {
+				ID string `json:"id"`
+			} `json:"discussion"`
+		} `json:"repository"`
+	}
+	if err := c.graphQL(ctx, token, `query($owner: String!, $name: String!, $number: Int!) {
+  repository(owner: $owner, name: $name) { discussion(number: $number) { id } }
+}`, map[string]any{"owner": owner, "name": name, "number": number}, &found); err != nil {
+		return nil, err
+	}
+	if found.Repository.Discussion == nil {
+		return nil, &APIError{StatusCode: http.StatusNotFound, Method: http.MethodPost, Path: "/graphql"}
+	}
+
+	var added struct {
+		AddDiscussionComment struct {
+			Comment DiscussionComment `json:"comment"`
+		} `json:"addDiscussionComment"`
+	}
+	if err := c.graphQL(ctx, token, `mutation($id: ID!, $body: String!) {
+  addDiscussionComment(input: {discussionId: $id, body: $body}) { comment { id url } }
+}`, map[string]any{"id": found.Repository.Discussion.ID, "body": body}, &added); err != nil {
+		return nil, err
+	}
+	return &added.AddDiscussionComment.Comment, nil
+}
@@ -0,0 +1,141 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnauthorized = errors.New("GitHub token expired or invalid — try signing out and back in")
+	ErrForbidden    = errors.New("GitHub token lacks permission for this resource")
+	ErrNotFound     = errors.New("not found on GitHub — it may have been deleted or made private")
+	ErrRateLimited  = errors.New("GitHub rate limit exceeded")
+	// ErrStatsPending means GitHub is still computing a /stats endpoint (202); retry later
+	ErrStatsPending = errors.New("GitHub is still computing repository statistics — try again shortly")
+)
+
+// APIError is returned for any non-2xx GitHub response.
+// Use errors.Is with the Err* sentinels to branch on the common cases.
+type APIError struct {
+	StatusCode int
+	Method     string
+	Path       string
+	Message    string    // GitHub's "message" field, if any
+	ResetAt    time.Time // set when rate limited
+	// OAuth scopes the token has and the ones the endpoint accepts, from
+	// X-OAuth-Scopes and X-Accepted-OAuth-Scopes. Scopes is nil for app and
+	// fine-grained tokens, which have none.
+	Scopes         []string
+	AcceptedScopes []string
+}
+
+func (e *APIError) Error() string {
+	if base := e.sentinel(); base != nil {
+		if base == ErrRateLimited && !e.ResetAt.IsZero() {
+			return fmt.Sprintf("%s (resets at %s)", base, e.ResetAt.Format(time.Kitchen))
+		}
+		return base.Error()
+	}
+	if e.Message != "" {
+		return fmt.Sprintf("GitHub API error %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("GitHub API error %d", e.StatusCode)
+}
+
+func (e *APIError) Is(target error) bool {
+	return target != nil && e.sentinel() == target
+}
+
+func (e *APIError) sentinel() error {
+	switch {
+	case !e.ResetAt.IsZero() || e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	}
+	return nil
+}
+
+// StatusCode maps an error from this package to the HTTP status a handler
+// should return: GitHub's own status for API errors, 502 for transport failures.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.sentinel() == ErrRateLimited {
+			return http.StatusTooManyRequests
+		}
+		return apiErr.StatusCode
+	}
+	return http.StatusBadGateway
+}
+
+// scopeIncludes lists the narrower OAuth scopes each scope grants
+var scopeIncludes = map[string][]string{
+	"repo":      {"public_repo", "repo:status", "repo_deployment", "repo:invite", "security_events"},
+	"admin:org": {"write:org", "read:org"},
+	"write:org": {"read:org"},
+	"user":      {"read:user", "user:email", "user:follow"},
+}
+
+// HasScope reports whether granted includes want, directly or through a
+// broader scope
+func HasScope(granted []string, want string) bool {
+	for _, g := range granted {
+		if g == want || slices.Contains(scopeIncludes[g], want) {
+			return true
+		}
+	}
+	return false
+}
+
+// MissingScope returns the OAuth scope the token behind a failed call lacks,
+// or "" when the failure isn't down to scopes. GitHub answers 404 rather
+// than 403 for a private repo the token may not read, so a repo call that
+// 404s without the repo scope counts too.
+func MissingScope(err error) string {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Scopes == nil {
+		return ""
+	}
+	if apiErr.StatusCode != http.StatusForbidden && apiErr.StatusCode != http.StatusNotFound {
+		return ""
+	}
+	accepted := apiErr.AcceptedScopes
+	if len(accepted) == 0 && (strings.HasPrefix(apiErr.Path, "/repos/") || strings.HasPrefix(apiErr.Path, "/repositories/")) {
+		accepted = []string{"repo"}
+	}
+	for _, s := range accepted {
+		if HasScope(apiErr.Scopes, s) {
+			return ""
+		}
+	}
+	if slices.Contains(accepted, "repo") {
+		return "repo"
+	}
+	if len(accepted) > 0 {
+		return accepted[0]
+	}
+	return ""
+}
+
+// headerScopes parses a comma-separated scopes header; nil when it is absent
+func headerScopes(h http.Header, key string) []string {
+	if len(h.Values(key)) == 0 {
+		return nil
+	}
+	scopes := []string{}
+	for _, s := range strings.Split(h.Get(key), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
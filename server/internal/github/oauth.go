@@ -0,0 +1,41 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+const oauthTokenURL = "https://github.com/login/oauth/access_token"
+
+// ExchangeCode trades an OAuth callback code for an access token.
+// Returns the token and the scopes actually granted.
+func (c *Client) ExchangeCode(ctx context.Context, clientID, clientSecret, code string) (token, scope string, err error) {
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		TokenType   string `json:"token_type"`
+		Scope       string `json:"scope"`
+		Error       string `json:"error"`
+		ErrorDesc   string `json:"error_description"`
+	}
+
+	// The token endpoint reports failures as 200 + error fields
+	_, err = c.Do(ctx, "", http.MethodPost, oauthTokenURL, map[string]string{
+		"client_id":     clientID,
+		"client_secret": clientSecret,
+		"code":          code,
+	}, &tokenResp)
+	if err != nil {
+		return "", "", err
+	}
+
+	if tokenResp.Error != "" {
+		log.Printf("[github] OAuth token exchange error: %s - %s", tokenResp.Error, tokenResp.ErrorDesc)
+		return "", "", fmt.Errorf("GitHub OAuth error: %s", tokenResp.ErrorDesc)
+	}
+	if tokenResp.AccessToken == "" {
+		return "", "", fmt.Errorf("GitHub returned empty access token")
+	}
+	return tokenResp.AccessToken, tokenResp.Scope, nil
+}
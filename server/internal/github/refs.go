@@ -0,0 +1,92 @@
+package github
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Ref is an issue, PR or commit reference found in message text. Offset and
+// Length count UTF-16 code units, like emoji entities.
+type Ref struct {
+	Offset int
+	Length int
+	Number int    // #123; 0 for a commit
+	SHA    string // a commit SHA or prefix; "" for #123
+}
+
+// Key identifies what the ref points at within a repo
+func (r Ref) Key() string {
+	if r.SHA != "" {
+		return r.SHA
+	}
+	return "#" + strconv.Itoa(r.Number)
+}
+
+// MaxRefs caps the references taken from one message
+const MaxRefs = 10
+
+var (
+	// #123 not glued to a word (so not a URL fragment or "abc#1") or an entity like &#39;
+	numberRefRegex = regexp.MustCompile(`(?:^|[^\w&/#])(#([1-9]\d{0,6}))\b`)
+	// 7-40 hex characters standing alone
+	shaRefRegex = regexp.MustCompile(`(?:^|[^\w/#.-])([0-9a-f]{7,40})\b`)
+	// Fenced blocks are code, not references
+	fencedRegex = regexp.MustCompile("(?s)```.*?```")
+)
+
+// FindRefs returns the references in content in order of appearance, at
+// most MaxRefs. A hex run only counts as a SHA when it mixes digits and
+// letters, so plain numbers and words like "defaced" don't.
+func FindRefs(content string) []Ref {
+	if !strings.ContainsAny(content, "#0123456789") {
+		return nil
+	}
+	fenced := fencedRegex.FindAllStringIndex(content, -1)
+	type match struct {
+		start, end int
+		ref        Ref
+	}
+	var found []match
+	for _, m := range numberRefRegex.FindAllStringSubmatchIndex(content, -1) {
+		n, _ := strconv.Atoi(content[m[4]:m[5]])
+		found = append(found, match{m[2], m[3], Ref{Number: n}})
+	}
+	for _, m := range shaRefRegex.FindAllStringSubmatchIndex(content, -1) {
+		sha := content[m[2]:m[3]]
+		if strings.IndexAny(sha, "abcdef") < 0 || strings.IndexAny(sha, "0123456789") < 0 {
+			continue
+		}
+		found = append(found, match{m[2], m[3], Ref{SHA: sha}})
+	}
+
+	// Merge the two passes back into text order
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+	var refs []Ref
+	units, last := 0, 0
+	for _, m := range found {
+		if inFence(fenced, m.start) {
+			continue
+		}
+		units += len(utf16.Encode([]rune(content[last:m.start])))
+		last = m.start
+		m.ref.Offset = units
+		m.ref.Length = len(utf16.Encode([]rune(content[m.start:m.end])))
+		refs = append(refs, m.ref)
+		if len(refs) == MaxRefs {
+			break
+		}
+	}
+	return refs
+}
+
+func inFence(ranges [][]int, i int) bool {
+	for _, r := range ranges {
+		if i >= r[0] && i < r[1] {
+			return true
+		}
+	}
+	return false
+}
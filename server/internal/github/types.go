@@ -0,0 +1,238 @@
+package github
+
+// JSON tags mirror the GitHub REST API, so these types can be passed
+// straight through to the frontend.
+
+type User struct {
+	ID        int64  `json:"id,omitempty"`
+	Login     string `json:"login"`
+	AvatarURL string `json:"avatar_url"`
+	// Display name; only returned for full user objects such as /user
+	Name string `json:"name,omitempty"`
+}
+
+type Label struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+type Repo struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	Private     bool   `json:"private"`
+	HTMLURL     string `json:"html_url"`
+	Language    string `json:"language"`
+	StarCount   int    `json:"stargazers_count"`
+	ForksCount  int    `json:"forks_count"`
+	PushedAt    string `json:"pushed_at"` // empty for a repo never pushed to
+	// Archived repos can still be read but refuse every write
+	Archived bool `json:"archived"`
+	// DefaultBranch is what workflow dispatches run on when no ref is given
+	DefaultBranch string `json:"default_branch"`
+	Owner         struct {
+		Login     string `json:"login"`
+		AvatarURL string `json:"avatar_url"`
+	} `json:"owner"`
+	// Permissions of the token owner; only present on authenticated requests
+	Permissions *RepoPermissions `json:"permissions,omitempty"`
+}
+
+type RepoPermissions struct {
+	Admin    bool `json:"admin"`
+	Maintain bool `json:"maintain"`
+	Push     bool `json:"push"`
+	Triage   bool `json:"triage"`
+	Pull     bool `json:"pull"`
+}
+
+type Issue struct {
+	Number      int     `json:"number"`
+	Title       string  `json:"title"`
+	Body        string  `json:"body"`
+	State       string  `json:"state"`
+	Labels      []Label `json:"labels"`
+	User        User    `json:"user"`
+	Comments    int     `json:"comments"`
+	CreatedAt   string  `json:"created_at"`
+	UpdatedAt   string  `json:"updated_at"`
+	HTMLURL     string  `json:"html_url"`
+	PullRequest *struct {
+		URL string `json:"url"`
+	} `json:"pull_request,omitempty"`
+}
+
+type PullRequest struct {
+	Number    int     `json:"number"`
+	Title     string  `json:"title"`
+	Body      string  `json:"body"`
+	State     string  `json:"state"`
+	Draft     bool    `json:"draft"`
+	Labels    []Label `json:"labels"`
+	User      User    `json:"user"`
+	Comments  int     `json:"comments"`
+	Additions int     `json:"additions"`
+	Deletions int     `json:"deletions"`
+	CreatedAt string  `json:"created_at"`
+	UpdatedAt string  `json:"updated_at"`
+	MergedAt  *string `json:"merged_at"`
+	MergedBy  *User   `json:"merged_by"`
+	HTMLURL   string  `json:"html_url"`
+	Head      struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// Commit is a repo commit; Commit.Message is the full message
+type Commit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Author  *User  `json:"author"` // nil when the email matches no account
+	Commit  struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+			Date string `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+}
+
+type Release struct {
+	ID          int64  `json:"id"`
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Body        string `json:"body"`
+	Draft       bool   `json:"draft"`
+	Prerelease  bool   `json:"prerelease"`
+	Author      User   `json:"author"`
+	HTMLURL     string `json:"html_url"`
+	PublishedAt string `json:"published_at"`
+}
+
+type Deployment struct {
+	ID          int64  `json:"id"`
+	SHA         string `json:"sha"`
+	Ref         string `json:"ref"`
+	Environment string `json:"environment"`
+	Description string `json:"description"`
+	Creator     User   `json:"creator"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type DeploymentStatus struct {
+	ID             int64  `json:"id"`
+	State          string `json:"state"` // queued, pending, in_progress, success, failure, error, inactive
+	Description    string `json:"description"`
+	Environment    string `json:"environment"`
+	LogURL         string `json:"log_url"`
+	EnvironmentURL string `json:"environment_url"`
+	Creator        User   `json:"creator"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type WorkflowRun struct {
+	ID         int64  `json:"id"`
+	Name       string `json:"name"`
+	HTMLURL    string `json:"html_url"`
+	Status     string `json:"status"`     // queued, in_progress, completed
+	Conclusion string `json:"conclusion"` // success, failure, cancelled, ... once completed
+	HeadBranch string `json:"head_branch"`
+	HeadSHA    string `json:"head_sha"`
+}
+
+// WorkflowDispatch identifies the run a workflow_dispatch created
+type WorkflowDispatch struct {
+	RunID   int64  `json:"workflow_run_id"`
+	HTMLURL string `json:"html_url"`
+}
+
+// Comment is a top-level issue/PR comment
+type Comment struct {
+	ID        int64  `json:"id"`
+	Body      string `json:"body"`
+	User      User   `json:"user"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	HTMLURL   string `json:"html_url"`
+}
+
+// DiscussionComment is a comment on a repository discussion
+type DiscussionComment struct {
+	ID  string `json:"id"` // GraphQL node ID; discussions have no REST IDs
+	URL string `json:"url"`
+}
+
+// ReviewComment is an inline comment on a PR diff
+type ReviewComment struct {
+	Comment
+	Path        string `json:"path,omitempty"`
+	Line        *int   `json:"line,omitempty"`
+	Side        string `json:"side,omitempty"`
+	DiffHunk    string `json:"diff_hunk,omitempty"`
+	InReplyToID *int64 `json:"in_reply_to_id,omitempty"`
+}
+
+type Review struct {
+	ID          int64  `json:"id"`
+	Body        string `json:"body"`
+	State       string `json:"state"` // APPROVED, CHANGES_REQUESTED, COMMENTED, DISMISSED, PENDING
+	User        User   `json:"user"`
+	CommitID    string `json:"commit_id"`
+	SubmittedAt string `json:"submitted_at"`
+	HTMLURL     string `json:"html_url"`
+}
+
+// NewReviewComment is the payload for a standalone inline comment on a PR diff.
+// Line is the (last) line of the range; StartLine is set for multi-line comments.
+type NewReviewComment struct {
+	Body      string `json:"body"`
+	CommitID  string `json:"commit_id"`
+	Path      string `json:"path"`
+	Line      int    `json:"line"`
+	Side      string `json:"side,omitempty"` // LEFT (base) or RIGHT (head)
+	StartLine int    `json:"start_line,omitempty"`
+	StartSide string `json:"start_side,omitempty"`
+}
+
+// DraftReviewComment is an inline comment attached to a review as it is created
+type DraftReviewComment struct {
+	Path      string `json:"path"`
+	Body      string `json:"body"`
+	Line      int    `json:"line"`
+	Side      string `json:"side,omitempty"`
+	StartLine int    `json:"start_line,omitempty"`
+	StartSide string `json:"start_side,omitempty"`
+}
+
+// NewReview is the payload for creating a review. Leaving Event empty creates
+// a PENDING review that can be submitted later.
+type NewReview struct {
+	CommitID string               `json:"commit_id,omitempty"`
+	Body     string               `json:"body,omitempty"`
+	Event    string               `json:"event,omitempty"` // APPROVE, REQUEST_CHANGES, COMMENT
+	Comments []DraftReviewComment `json:"comments,omitempty"`
+}
+
+// ContributorStats is one author's entry from /stats/contributors
+type ContributorStats struct {
+	Author User `json:"author"`
+	Total  int  `json:"total"`
+	Weeks  []struct {
+		Week      int64 `json:"w"`
+		Additions int   `json:"a"`
+		Deletions int   `json:"d"`
+		Commits   int   `json:"c"`
+	} `json:"weeks"`
+}
+
+// WeeklyCommits is one week of /stats/commit_activity
+type WeeklyCommits struct {
+	Week  int64 `json:"week"` // unix start of the week (Sunday)
+	Total int   `json:"total"`
+	Days  []int `json:"days"`
+}
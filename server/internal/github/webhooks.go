@@ -0,0 +1,73 @@
+package github
+
+// WebhookRepo is the repository object sent with every webhook event
+type WebhookRepo struct {
+	ID       int64  `json:"id"`
+	FullName string `json:"full_name"`
+}
+
+// CommentEvent is the payload of issue_comment and pull_request_review_comment
+// events. Issue is set for issue_comment (Issue.PullRequest is non-nil when
+// the issue is a PR); PullRequest is set for pull_request_review_comment.
+type CommentEvent struct {
+	Action      string        `json:"action"` // created, edited, deleted
+	Comment     ReviewComment `json:"comment"`
+	Issue       *Issue        `json:"issue"`
+	PullRequest *PullRequest  `json:"pull_request"`
+	Repository  WebhookRepo   `json:"repository"`
+}
+
+// ItemEvent is the payload of issues and pull_request events
+type ItemEvent struct {
+	Action      string       `json:"action"` // opened, closed, reopened, labeled, ...
+	Issue       *Issue       `json:"issue"`
+	PullRequest *PullRequest `json:"pull_request"`
+	Repository  WebhookRepo  `json:"repository"`
+	// The label added or removed, for labeled and unlabeled
+	Label *Label `json:"label,omitempty"`
+	// Who triggered the event
+	Sender User `json:"sender"`
+}
+
+// ReleaseEvent is the payload of release events
+type ReleaseEvent struct {
+	Action     string      `json:"action"` // published, created, edited, ...
+	Release    Release     `json:"release"`
+	Repository WebhookRepo `json:"repository"`
+}
+
+// DeploymentStatusEvent is the payload of deployment_status events
+type DeploymentStatusEvent struct {
+	Action           string           `json:"action"`
+	DeploymentStatus DeploymentStatus `json:"deployment_status"`
+	Deployment       Deployment       `json:"deployment"`
+	Repository       WebhookRepo      `json:"repository"`
+}
+
+// WorkflowRunEvent is the payload of workflow_run events
+type WorkflowRunEvent struct {
+	Action      string      `json:"action"` // requested, in_progress, completed
+	WorkflowRun WorkflowRun `json:"workflow_run"`
+	Repository  WebhookRepo `json:"repository"`
+}
+
+// MemberEvent is the payload of member events: a collaborator added to or
+// removed from the repository, or their permission changed
+type MemberEvent struct {
+	Action  string `json:"action"` // added, edited, removed
+	Member  User   `json:"member"`
+	Changes struct {
+		// Set on edited when the permission changed
+		Permission *struct {
+			From string `json:"from"`
+			To   string `json:"to"`
+		} `json:"permission"`
+	} `json:"changes"`
+	Repository WebhookRepo `json:"repository"`
+}
+
+// RepositoryEvent is the payload of repository events
+type RepositoryEvent struct {
+	Action     string      `json:"action"` // archived, unarchived, deleted, renamed, ...
+	Repository WebhookRepo `json:"repository"`
+}
@@ -0,0 +1,106 @@
+// Package i18n translates server-generated text. Strings are written in
+// English at the call site and double as catalog keys; each locale's catalog
+// (locales/<locale>.json) maps them to a translation. Strings missing from a
+// catalog fall back to English, so new text can ship before it is translated.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the language strings are written in
+const Default = "en"
+
+//go:embed locales/*.json
+var files embed.FS
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	out := map[string]map[string]string{Default: nil}
+	for _, e := range entries {
+		data, err := files.ReadFile("locales/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		var catalog map[string]string
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", e.Name(), err))
+		}
+		out[strings.TrimSuffix(e.Name(), path.Ext(e.Name()))] = catalog
+	}
+	return out
+}
+
+// Supported lists the locales with a catalog, English included, sorted
+func Supported() []string {
+	out := make([]string, 0, len(catalogs))
+	for l := range catalogs {
+		out = append(out, l)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Normalize maps a language tag such as "es-MX" to a supported locale, or ""
+func Normalize(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	base, _, _ = strings.Cut(base, "_")
+	if _, ok := catalogs[base]; ok {
+		return base
+	}
+	return ""
+}
+
+// FromAcceptLanguage picks the supported locale an Accept-Language header
+// prefers most, or "" when it names none
+func FromAcceptLanguage(header string) string {
+	type choice struct {
+		tag string
+		q   float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{tag, q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	for _, c := range choices {
+		if l := Normalize(c.tag); l != "" {
+			return l
+		}
+	}
+	return ""
+}
+
+// T translates msg into locale and, given args, formats it like fmt.Sprintf
+func T(locale, msg string, args ...any) string {
+	if tr, ok := catalogs[locale][msg]; ok && tr != "" {
+		msg = tr
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
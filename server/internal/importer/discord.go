@@ -0,0 +1,117 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// discordExport is a channel export in DiscordChatExporter's JSON format
+type discordExport struct {
+	Channel struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Topic string `json:"topic"`
+	} `json:"channel"`
+	Messages []discordMessage `json:"messages"`
+}
+
+type discordMessage struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Content   string    `json:"content"`
+	Author    struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"author"`
+	Attachments []struct {
+		URL      string `json:"url"`
+		FileName string `json:"fileName"`
+	} `json:"attachments"`
+	Reference *struct {
+		MessageID string `json:"messageId"`
+	} `json:"reference"`
+}
+
+// ImportDiscord loads DiscordChatExporter JSON exports into opts.Loop. file
+// is one export or a directory of them, one per channel; replies become
+// thread replies.
+func ImportDiscord(ctx context.Context, pool *pgxpool.Pool, file string, opts Options) (Result, error) {
+	files := []string{file}
+	if st, err := os.Stat(file); err != nil {
+		return Result{}, fmt.Errorf("importer: %w", err)
+	} else if st.IsDir() {
+		files, err = filepath.Glob(filepath.Join(file, "*.json"))
+		if err != nil || len(files) == 0 {
+			return Result{}, fmt.Errorf("importer: no .json exports in %s", file)
+		}
+		sort.Strings(files)
+	}
+
+	r, err := newRun(ctx, pool, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	fi := 0
+	var export discordExport
+	var pending []discordMessage
+	return r.load(ctx, func() (message, error) {
+		for len(pending) == 0 {
+			if fi == len(files) {
+				return message{}, io.EOF
+			}
+			export = discordExport{}
+			if err := readJSONFile(files[fi], &export); err != nil {
+				return message{}, fmt.Errorf("%s: %w", files[fi], err)
+			}
+			fi++
+			if export.Channel.Name == "" {
+				continue
+			}
+			if _, err := r.channel(ctx, export.Channel.Name, export.Channel.Topic); err != nil {
+				return message{}, err
+			}
+			pending = export.Messages
+		}
+		dm := pending[0]
+		pending = pending[1:]
+		return discordToMessage(export.Channel.Name, dm), nil
+	})
+}
+
+func discordToMessage(channel string, dm discordMessage) message {
+	m := message{channel: channel, at: dm.Timestamp.UTC()}
+	// Joins, pins, boosts and calls are system messages
+	if dm.Type != "Default" && dm.Type != "Reply" {
+		return m
+	}
+	m.authors = []string{dm.Author.ID, dm.Author.Name}
+	m.author = dm.Author.Name
+	m.content = dm.Content
+	for _, a := range dm.Attachments {
+		m.content = strings.TrimSpace(m.content + "\n" + a.FileName + ": " + a.URL)
+	}
+	m.sourceID = dm.ID
+	if dm.Reference != nil {
+		m.parentID = dm.Reference.MessageID
+	}
+	return m
+}
+
+func readJSONFile(name string, v any) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewDecoder(f).Decode(v)
+}
@@ -0,0 +1,206 @@
+// Package importer moves chat history from other services into a loop.
+// Exports are read as they come from Slack (the workspace export ZIP) and
+// Discord (DiscordChatExporter JSON), their authors are matched to Wireloop
+// users, missing channels are created, and messages are bulk-loaded with
+// their original timestamps through backup.ImportMessageSource.
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/backup"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const maxChannelName = 100
+
+// Options control an import
+type Options struct {
+	// Loop is the name of the target loop
+	Loop string
+	// UserMap maps a source user (ID, username or email; case-insensitive)
+	// to a Wireloop username. Users not in it are matched by username.
+	UserMap map[string]string
+	// Progress, if set, is called after each committed chunk
+	Progress func(backup.ImportProgress)
+}
+
+// Result summarizes an import
+type Result struct {
+	backup.ImportProgress
+	ChannelsCreated []string       `json:"channels_created"`
+	Skipped         int            `json:"skipped"`  // system messages and empty ones
+	Unmapped        map[string]int `json:"unmapped"` // messages dropped per unmatched author
+}
+
+// LoadUserMap reads a JSON object of source user -> Wireloop username
+func LoadUserMap(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("importer: user map: %w", err)
+	}
+	m := make(map[string]string, len(raw))
+	for k, v := range raw {
+		m[strings.ToLower(strings.TrimSpace(k))] = strings.TrimPrefix(strings.TrimSpace(v), "@")
+	}
+	return m, nil
+}
+
+// message is a source message before it's tied to Wireloop rows
+type message struct {
+	channel  string   // source channel name
+	authors  []string // keys to match the author by, most specific first
+	author   string   // for reporting when unmatched
+	content  string
+	at       time.Time
+	sourceID string // the source's message ID, for replies
+	parentID string // the source ID of the message this replies to
+}
+
+type run struct {
+	q        *db.Queries
+	pool     *pgxpool.Pool
+	opts     Options
+	project  db.Project
+	channels map[string]pgtype.UUID
+	users    map[string]pgtype.UUID // by Wireloop username; invalid when not found
+	ids      backup.IDGenerator
+	imported map[string]int64 // source message ID -> Wireloop ID of its thread root
+	result   Result
+}
+
+func newRun(ctx context.Context, pool *pgxpool.Pool, opts Options) (*run, error) {
+	q := db.New(pool)
+	project, err := q.GetProjectByName(ctx, opts.Loop)
+	if err != nil {
+		return nil, fmt.Errorf("importer: loop %q: %w", opts.Loop, err)
+	}
+	existing, err := q.GetChannelsByProject(ctx, project.ID)
+	if err != nil {
+		return nil, err
+	}
+	r := &run{
+		q:        q,
+		pool:     pool,
+		opts:     opts,
+		project:  project,
+		channels: make(map[string]pgtype.UUID, len(existing)),
+		users:    make(map[string]pgtype.UUID),
+		imported: make(map[string]int64),
+		result:   Result{Unmapped: make(map[string]int)},
+	}
+	for _, ch := range existing {
+		r.channels[ch.Name] = ch.ID
+	}
+	return r, nil
+}
+
+// channel returns the loop's channel called name, creating it if needed
+func (r *run) channel(ctx context.Context, name, description string) (pgtype.UUID, error) {
+	name = strings.TrimSpace(name)
+	if len([]rune(name)) > maxChannelName {
+		name = string([]rune(name)[:maxChannelName])
+	}
+	if id, ok := r.channels[name]; ok {
+		return id, nil
+	}
+	ch, err := r.q.CreateChannel(ctx, db.CreateChannelParams{
+		ProjectID:   r.project.ID,
+		Name:        name,
+		Description: pgtype.Text{String: description, Valid: description != ""},
+		IsDefault:   pgtype.Bool{Bool: false, Valid: true},
+		Position:    pgtype.Int4{Int32: int32(len(r.channels)), Valid: true},
+	})
+	if err != nil {
+		return pgtype.UUID{}, fmt.Errorf("importer: creating channel %q: %w", name, err)
+	}
+	r.channels[name] = ch.ID
+	r.result.ChannelsCreated = append(r.result.ChannelsCreated, name)
+	return ch.ID, nil
+}
+
+// user matches a source author by the user map, then by username
+func (r *run) user(ctx context.Context, keys []string) (pgtype.UUID, bool) {
+	for _, k := range keys {
+		if name, ok := r.opts.UserMap[strings.ToLower(k)]; ok {
+			return r.lookup(ctx, name)
+		}
+	}
+	for _, k := range keys {
+		if id, ok := r.lookup(ctx, k); ok {
+			return id, true
+		}
+	}
+	return pgtype.UUID{}, false
+}
+
+func (r *run) lookup(ctx context.Context, username string) (pgtype.UUID, bool) {
+	if username == "" {
+		return pgtype.UUID{}, false
+	}
+	id, ok := r.users[username]
+	if !ok {
+		id, _ = r.q.GetUserByUsername2(ctx, username)
+		r.users[username] = id
+	}
+	return id, id.Valid
+}
+
+// load imports the messages next yields, oldest first within each channel,
+// until next returns io.EOF
+func (r *run) load(ctx context.Context, next func() (message, error)) (Result, error) {
+	src := func() (backup.ArchiveMessage, error) {
+		for {
+			m, err := next()
+			if err != nil {
+				return backup.ArchiveMessage{}, err
+			}
+			if strings.TrimSpace(m.content) == "" {
+				r.result.Skipped++
+				continue
+			}
+			sender, ok := r.user(ctx, m.authors)
+			if !ok {
+				r.result.Unmapped[m.author]++
+				continue
+			}
+			channel, err := r.channel(ctx, m.channel, "")
+			if err != nil {
+				return backup.ArchiveMessage{}, err
+			}
+			id := r.ids.Next(m.at)
+			// A reply to a message that wasn't imported becomes a top-level
+			// message; replies to replies join the root's thread
+			parent := r.imported[m.parentID]
+			if m.sourceID != "" {
+				r.imported[m.sourceID] = id
+				if parent != 0 {
+					r.imported[m.sourceID] = parent
+				}
+			}
+			return backup.ArchiveMessage{
+				ID:        id,
+				ChannelID: utils.UUIDToStr(channel),
+				SenderID:  utils.UUIDToStr(sender),
+				Content:   m.content,
+				ParentID:  parent,
+				CreatedAt: m.at,
+			}, nil
+		}
+	}
+	done, err := backup.ImportMessageSource(ctx, r.pool, src, r.opts.Progress)
+	r.result.ImportProgress = done
+	return r.result, err
+}
@@ -0,0 +1,223 @@
+package importer
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Slack messages with these subtypes are channel events, not conversation
+var slackSkipSubtypes = map[string]bool{
+	"channel_join": true, "channel_leave": true, "channel_topic": true,
+	"channel_purpose": true, "channel_name": true, "channel_archive": true,
+	"channel_unarchive": true, "group_join": true, "group_leave": true,
+	"group_topic": true, "group_purpose": true, "group_name": true,
+	"pinned_item": true, "unpinned_item": true, "bot_add": true, "bot_remove": true,
+}
+
+// <@U123>, <#C123|general>, <!here>, <https://x|label>
+var slackMarkup = regexp.MustCompile(`<([^<>|]+)(?:\|([^<>]*))?>`)
+
+type slackUser struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Profile struct {
+		Email       string `json:"email"`
+		DisplayName string `json:"display_name"`
+	} `json:"profile"`
+}
+
+type slackChannel struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Purpose struct {
+		Value string `json:"value"`
+	} `json:"purpose"`
+}
+
+type slackMessage struct {
+	Type     string `json:"type"`
+	Subtype  string `json:"subtype"`
+	User     string `json:"user"`
+	Username string `json:"username"` // bots
+	Text     string `json:"text"`
+	Ts       string `json:"ts"`
+	ThreadTs string `json:"thread_ts"`
+	Files    []struct {
+		Name       string `json:"name"`
+		URLPrivate string `json:"url_private"`
+	} `json:"files"`
+}
+
+// ImportSlack loads a Slack workspace export ZIP into opts.Loop: public and
+// private channels become channels of the same name, threads become replies.
+// Direct messages aren't imported.
+func ImportSlack(ctx context.Context, pool *pgxpool.Pool, file string, opts Options) (Result, error) {
+	zr, err := zip.OpenReader(file)
+	if err != nil {
+		return Result{}, fmt.Errorf("importer: opening Slack export: %w", err)
+	}
+	defer zr.Close()
+
+	byName := make(map[string]*zip.File, len(zr.File))
+	days := make(map[string][]*zip.File) // channel directory -> daily files
+	for _, f := range zr.File {
+		byName[f.Name] = f
+		if dir, name := path.Split(f.Name); dir != "" && strings.HasSuffix(name, ".json") {
+			dir = strings.TrimSuffix(dir, "/")
+			days[dir] = append(days[dir], f)
+		}
+	}
+	// Daily files are named YYYY-MM-DD.json
+	for _, files := range days {
+		sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	}
+	var users []slackUser
+	if err := readZipJSON(byName["users.json"], &users); err != nil {
+		return Result{}, fmt.Errorf("importer: users.json: %w", err)
+	}
+	var channels []slackChannel
+	for _, name := range []string{"channels.json", "groups.json"} {
+		var list []slackChannel
+		if err := readZipJSON(byName[name], &list); err != nil {
+			return Result{}, fmt.Errorf("importer: %s: %w", name, err)
+		}
+		channels = append(channels, list...)
+	}
+	if len(channels) == 0 {
+		return Result{}, fmt.Errorf("importer: %s has no channels.json; is it a Slack export?", file)
+	}
+
+	r, err := newRun(ctx, pool, opts)
+	if err != nil {
+		return Result{}, err
+	}
+	usersByID := make(map[string]slackUser, len(users))
+	for _, u := range users {
+		usersByID[u.ID] = u
+	}
+	for _, ch := range channels {
+		if len(days[ch.Name]) == 0 {
+			continue
+		}
+		if _, err := r.channel(ctx, ch.Name, ch.Purpose.Value); err != nil {
+			return r.result, err
+		}
+	}
+
+	ci, di := 0, 0
+	var pending []slackMessage
+	return r.load(ctx, func() (message, error) {
+		for len(pending) == 0 {
+			if ci == len(channels) {
+				return message{}, io.EOF
+			}
+			files := days[channels[ci].Name]
+			if di == len(files) {
+				ci, di = ci+1, 0
+				continue
+			}
+			if err := readZipJSON(files[di], &pending); err != nil {
+				return message{}, fmt.Errorf("%s: %w", files[di].Name, err)
+			}
+			di++
+		}
+		sm := pending[0]
+		pending = pending[1:]
+		return r.slackMessage(channels[ci].Name, sm, usersByID), nil
+	})
+}
+
+func (r *run) slackMessage(channel string, sm slackMessage, users map[string]slackUser) message {
+	m := message{channel: channel, at: slackTime(sm.Ts)}
+	if sm.Type != "message" || slackSkipSubtypes[sm.Subtype] || m.at.IsZero() {
+		return m // no content: counted as skipped
+	}
+	if u, ok := users[sm.User]; ok {
+		m.authors = []string{u.ID, u.Profile.Email, u.Name}
+		m.author = u.Name
+	} else {
+		m.authors = []string{sm.User, sm.Username}
+		m.author = strings.TrimSpace(sm.User + " " + sm.Username)
+	}
+	m.content = r.slackText(sm.Text, users)
+	for _, f := range sm.Files {
+		m.content = strings.TrimSpace(m.content + "\n" + f.Name + ": " + f.URLPrivate)
+	}
+	m.sourceID = channel + "/" + sm.Ts
+	if sm.ThreadTs != "" && sm.ThreadTs != sm.Ts {
+		m.parentID = channel + "/" + sm.ThreadTs
+	}
+	return m
+}
+
+// slackText turns Slack's markup into plain text with @username mentions
+func (r *run) slackText(text string, users map[string]slackUser) string {
+	text = slackMarkup.ReplaceAllStringFunc(text, func(s string) string {
+		parts := slackMarkup.FindStringSubmatch(s)
+		target, label := parts[1], parts[2]
+		switch {
+		case strings.HasPrefix(target, "@"):
+			u := users[target[1:]]
+			for _, k := range []string{u.ID, u.Profile.Email, u.Name} {
+				if name, ok := r.opts.UserMap[strings.ToLower(k)]; ok && k != "" {
+					return "@" + name
+				}
+			}
+			if u.Name != "" {
+				return "@" + u.Name
+			}
+			return "@" + target[1:]
+		case strings.HasPrefix(target, "#"):
+			if label != "" {
+				return "#" + label
+			}
+			return target
+		case strings.HasPrefix(target, "!"):
+			// <!here>, <!channel>, <!subteam^ID|@team>
+			if label != "" {
+				return label
+			}
+			return "@" + target[1:]
+		case label != "" && label != target:
+			return label + " (" + target + ")"
+		}
+		return target
+	})
+	return html.UnescapeString(text)
+}
+
+// slackTime parses a message ts such as "1512085950.000216"
+func slackTime(ts string) time.Time {
+	sec, frac, _ := strings.Cut(ts, ".")
+	s, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	us, _ := strconv.ParseInt((frac + "000000")[:6], 10, 64)
+	return time.Unix(s, us*1000).UTC()
+}
+
+// readZipJSON decodes f into v; a missing file leaves v untouched
+func readZipJSON(f *zip.File, v any) error {
+	if f == nil {
+		return nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return json.NewDecoder(rc).Decode(v)
+}
@@ -0,0 +1,336 @@
+// Package integrations connects loops to outside services. A loop sets up
+// integrations of the kinds registered here, each with its own config, the
+// topics it listens to and a reference to the credential it needs. Dispatch
+// hands an event to every enabled integration of the loop that takes it, one
+// job per integration, so a slow or failing service is retried without
+// holding up the caller. Configuration changes and failed deliveries go to
+// the loop's integration audit trail.
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+	"wireloop/internal/jobs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Event topics. An integration listening to "github" gets every github.* topic.
+const (
+	TopicMessage        = "message.created"
+	TopicStandupSummary = "standup.summary"
+	TopicGitHub         = "github" // github.<webhook event>, e.g. github.issues
+)
+
+// Audit actions
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+	ActionFailed  = "failed"
+)
+
+// JobDelivery is the job type that delivers one event to one integration
+const JobDelivery = "integration_delivery"
+
+// Event is something that happened in a loop
+type Event struct {
+	Topic     string         `json:"topic"`
+	ProjectID string         `json:"project_id"`
+	ChannelID string         `json:"channel_id,omitempty"`
+	Text      string         `json:"text"` // a line a person can read
+	Data      map[string]any `json:"data,omitempty"`
+	At        time.Time      `json:"at"`
+}
+
+// Delivery is an event on its way to one integration
+type Delivery struct {
+	Integration db.LoopIntegration
+	Event       Event
+	// The resolved credentials_ref; "" when the integration has none
+	Credential string
+}
+
+// Kind is a type of integration
+type Kind interface {
+	Type() string
+	// Topics the kind can act on
+	Topics() []string
+	// NeedsCredential is true when an integration can't work without a
+	// credentials_ref
+	NeedsCredential() bool
+	// Validate checks an integration's config before it is saved
+	Validate(config json.RawMessage) error
+	Deliver(ctx context.Context, d Delivery) error
+}
+
+// Credentials resolves a credentials_ref to the secret it names
+type Credentials func(ctx context.Context, projectID pgtype.UUID, ref string) (string, error)
+
+// EnvRefPrefix marks a credentials_ref read from the server's environment,
+// the only kind there was before loop secrets. Such refs keep working for
+// the variables the operator allows, whether or not a secrets store is
+// configured, but owners are asked to move them to a secret.
+const EnvRefPrefix = "env:"
+
+// EnvCredentials resolves refs of the form env:NAME from the environment,
+// but only for the variables the operator granted to the loop in
+// INTEGRATION_CREDENTIAL_VARS: comma-separated <loop id>:<NAME> pairs, a
+// variable shared by loops being listed once for each. Any other name would
+// let a loop owner read the server's own secrets, or another loop's, so it
+// fails exactly like a granted variable that isn't set.
+func EnvCredentials(_ context.Context, projectID pgtype.UUID, ref string) (string, error) {
+	name, ok := strings.CutPrefix(ref, EnvRefPrefix)
+	if !ok || name == "" {
+		return "", fmt.Errorf("unknown credential %q", ref)
+	}
+	var v string
+	if slices.Contains(credentialVars()[utils.UUIDToStr(projectID)], name) {
+		v = os.Getenv(name)
+	}
+	if v == "" {
+		return "", fmt.Errorf("credential %q is not available; ask the server operator to provide it or move it to a loop secret", ref)
+	}
+	return v, nil
+}
+
+// credentialVars lists the variables EnvCredentials may read, by loop ID
+func credentialVars() map[string][]string {
+	vars := make(map[string][]string)
+	for _, entry := range strings.Split(os.Getenv("INTEGRATION_CREDENTIAL_VARS"), ",") {
+		loop, name, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || loop == "" || name == "" {
+			continue
+		}
+		vars[loop] = append(vars[loop], name)
+	}
+	return vars
+}
+
+// noCredentials is the resolver until UseCredentials installs a store
+func noCredentials(_ context.Context, _ pgtype.UUID, ref string) (string, error) {
+	return "", fmt.Errorf("credential %q can't be resolved: no secrets store is configured", ref)
+}
+
+// commonConfig holds the config fields every kind understands
+type commonConfig struct {
+	// Only events from this channel; loop-wide events still go through
+	ChannelID string `json:"channel_id"`
+}
+
+// Service is the registry of kinds and the way events reach integrations
+type Service struct {
+	queries     *db.Queries
+	jobs        *jobs.Queue
+	kinds       map[string]Kind
+	credentials Credentials
+	enabled     *cache.TTL[string, []db.LoopIntegration] // by project ID
+}
+
+// New creates a service with the built-in kinds that queues deliveries on
+// queue and registers their job handler there
+func New(queries *db.Queries, queue *jobs.Queue) *Service {
+	s := &Service{
+		queries:     queries,
+		jobs:        queue,
+		kinds:       make(map[string]Kind),
+		credentials: noCredentials,
+		enabled:     cache.New[string, []db.LoopIntegration](time.Minute, 5000),
+	}
+	s.Register(Slack{})
+	s.Register(Webhook{})
+	queue.Register(JobDelivery, s.runDelivery)
+	return s
+}
+
+// Register adds a kind, replacing one of the same type
+func (s *Service) Register(k Kind) {
+	s.kinds[k.Type()] = k
+}
+
+// UseCredentials resolves credentials_refs with c from now on; env: refs
+// still go through EnvCredentials
+func (s *Service) UseCredentials(c Credentials) {
+	s.credentials = c
+}
+
+// resolve returns the secret ref names
+func (s *Service) resolve(ctx context.Context, projectID pgtype.UUID, ref string) (string, error) {
+	if strings.HasPrefix(ref, EnvRefPrefix) {
+		return EnvCredentials(ctx, projectID, ref)
+	}
+	return s.credentials(ctx, projectID, ref)
+}
+
+// Kind returns the registered kind named typ
+func (s *Service) Kind(typ string) (Kind, bool) {
+	k, ok := s.kinds[typ]
+	return k, ok
+}
+
+// Types lists the registered kinds, sorted
+func (s *Service) Types() []string {
+	types := make([]string, 0, len(s.kinds))
+	for t := range s.kinds {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Validate checks an integration before it is saved. Its errors are meant
+// for the person configuring it.
+func (s *Service) Validate(ctx context.Context, in db.LoopIntegration) error {
+	k, ok := s.kinds[in.Type]
+	if !ok {
+		return fmt.Errorf("unknown integration type %q; available: %s", in.Type, strings.Join(s.Types(), ", "))
+	}
+	for _, t := range in.Topics {
+		if !slices.ContainsFunc(k.Topics(), func(kt string) bool { return covers(t, kt) || covers(kt, t) }) {
+			return fmt.Errorf("%s integrations don't handle %q; they handle %s", in.Type, t, strings.Join(k.Topics(), ", "))
+		}
+	}
+	var common commonConfig
+	if err := json.Unmarshal(in.Config, &common); err != nil {
+		return errors.New("config must be a JSON object")
+	}
+	if common.ChannelID != "" {
+		if _, err := utils.StrToUUID(common.ChannelID); err != nil {
+			return errors.New("config.channel_id is not a channel id")
+		}
+	}
+	if err := k.Validate(in.Config); err != nil {
+		return err
+	}
+	if !in.CredentialsRef.Valid {
+		if k.NeedsCredential() {
+			return fmt.Errorf("%s integrations need a credentials_ref", in.Type)
+		}
+		return nil
+	}
+	if _, err := s.resolve(ctx, in.ProjectID, in.CredentialsRef.String); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Forget drops the cached integrations of a loop; call it after they change
+func (s *Service) Forget(projectID string) {
+	s.enabled.Delete(projectID)
+}
+
+// Dispatch queues ev for every enabled integration of its loop that takes it
+func (s *Service) Dispatch(ctx context.Context, ev Event) error {
+	projectID, err := utils.StrToUUID(ev.ProjectID)
+	if err != nil {
+		return fmt.Errorf("bad project id: %w", err)
+	}
+	all, err := s.enabled.GetOrLoad(ev.ProjectID, func() ([]db.LoopIntegration, error) {
+		return s.queries.GetEnabledLoopIntegrations(ctx, projectID)
+	})
+	if err != nil {
+		return err
+	}
+	if ev.At.IsZero() {
+		ev.At = time.Now()
+	}
+	for _, in := range all {
+		if !s.takes(in, ev) {
+			continue
+		}
+		payload := deliveryPayload{IntegrationID: utils.UUIDToStr(in.ID), Event: ev}
+		if _, err := s.jobs.Enqueue(ctx, JobDelivery, payload, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// takes reports whether in wants ev
+func (s *Service) takes(in db.LoopIntegration, ev Event) bool {
+	k, ok := s.kinds[in.Type]
+	if !ok {
+		return false
+	}
+	topics := in.Topics
+	if len(topics) == 0 {
+		topics = k.Topics()
+	}
+	if !slices.ContainsFunc(topics, func(t string) bool { return covers(t, ev.Topic) }) {
+		return false
+	}
+	var common commonConfig
+	_ = json.Unmarshal(in.Config, &common)
+	return common.ChannelID == "" || ev.ChannelID == "" || common.ChannelID == ev.ChannelID
+}
+
+// covers reports whether listening to t includes topic
+func covers(t, topic string) bool {
+	return t == topic || strings.HasPrefix(topic, t+".")
+}
+
+type deliveryPayload struct {
+	IntegrationID string `json:"integration_id"`
+	Event         Event  `json:"event"`
+}
+
+func (s *Service) runDelivery(ctx context.Context, raw json.RawMessage) error {
+	var p deliveryPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	id, err := utils.StrToUUID(p.IntegrationID)
+	if err != nil {
+		return fmt.Errorf("bad integration id: %w", err)
+	}
+
+	in, err := s.queries.GetLoopIntegration(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // deleted since
+	}
+	if err != nil {
+		return err
+	}
+	k, ok := s.kinds[in.Type]
+	if !in.Enabled || !ok {
+		return nil
+	}
+
+	d := Delivery{Integration: in, Event: p.Event}
+	if in.CredentialsRef.Valid {
+		d.Credential, err = s.resolve(ctx, in.ProjectID, in.CredentialsRef.String)
+	}
+	if err == nil {
+		err = k.Deliver(ctx, d)
+	}
+	if err != nil {
+		s.Audit(ctx, in, pgtype.UUID{}, ActionFailed, p.Event.Topic+": "+err.Error())
+	}
+	return err
+}
+
+// Audit records action on in; actor is unset for deliveries
+func (s *Service) Audit(ctx context.Context, in db.LoopIntegration, actor pgtype.UUID, action, detail string) {
+	if err := s.queries.AddIntegrationAudit(ctx, db.AddIntegrationAuditParams{
+		ProjectID:       in.ProjectID,
+		IntegrationID:   in.ID,
+		IntegrationName: in.Name,
+		ActorID:         actor,
+		Action:          action,
+		Detail:          detail,
+	}); err != nil {
+		log.Printf("[integrations] failed to record %s of %s: %v", action, in.Name, err)
+	}
+}
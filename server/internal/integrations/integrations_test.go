@@ -0,0 +1,45 @@
+package integrations
+
+import (
+	"context"
+	"testing"
+
+	utils "wireloop/internal"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+func TestEnvCredentialsScopedToLoop(t *testing.T) {
+	mine := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	theirs := pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+	t.Setenv("INTEGRATION_CREDENTIAL_VARS", utils.UUIDToStr(mine)+":MY_SLACK_TOKEN, "+utils.UUIDToStr(theirs)+":THEIR_SLACK_TOKEN")
+	t.Setenv("MY_SLACK_TOKEN", "mine")
+	t.Setenv("THEIR_SLACK_TOKEN", "theirs")
+	t.Setenv("JWT_SECRET", "server")
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		ref  string
+		want string // "" when it must be refused
+	}{
+		{"granted to the loop", "env:MY_SLACK_TOKEN", "mine"},
+		{"granted to another loop", "env:THEIR_SLACK_TOKEN", ""},
+		{"not granted at all", "env:JWT_SECRET", ""},
+		{"no name", "env:", ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := EnvCredentials(ctx, mine, tc.ref)
+			if tc.want == "" {
+				if err == nil {
+					t.Errorf("EnvCredentials(%q) = %q, want an error", tc.ref, got)
+				}
+				return
+			}
+			if err != nil || got != tc.want {
+				t.Errorf("EnvCredentials(%q) = %q, %v; want %q", tc.ref, got, err, tc.want)
+			}
+		})
+	}
+}
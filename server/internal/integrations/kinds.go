@@ -0,0 +1,106 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// allTopics is what the built-in kinds forward
+var allTopics = []string{TopicMessage, TopicStandupSummary, TopicGitHub}
+
+// Slack forwards events as text to a Slack incoming webhook. The webhook URL
+// is the credential; config may set channel_id.
+type Slack struct{}
+
+func (Slack) Type() string          { return "slack" }
+func (Slack) Topics() []string      { return allTopics }
+func (Slack) NeedsCredential() bool { return true }
+
+func (Slack) Validate(json.RawMessage) error { return nil }
+
+func (Slack) Deliver(ctx context.Context, d Delivery) error {
+	body, err := json.Marshal(map[string]string{"text": d.Event.Text})
+	if err != nil {
+		return err
+	}
+	return post(ctx, d.Credential, body, nil)
+}
+
+// Webhook posts each event as JSON to config.url. With a credential, the
+// body is signed with it in X-Wireloop-Signature (sha256=<hex HMAC>), the
+// same scheme GitHub uses.
+type Webhook struct{}
+
+type webhookConfig struct {
+	URL string `json:"url"`
+}
+
+func (Webhook) Type() string          { return "webhook" }
+func (Webhook) Topics() []string      { return allTopics }
+func (Webhook) NeedsCredential() bool { return false }
+
+func (Webhook) Validate(config json.RawMessage) error {
+	var cfg webhookConfig
+	if err := json.Unmarshal(config, &cfg); err != nil {
+		return errors.New("config must be a JSON object")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return errors.New("config.url must be an http(s) URL")
+	}
+	return nil
+}
+
+func (Webhook) Deliver(ctx context.Context, d Delivery) error {
+	var cfg webhookConfig
+	if err := json.Unmarshal(d.Integration.Config, &cfg); err != nil {
+		return err
+	}
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return err
+	}
+	headers := map[string]string{"X-Wireloop-Topic": d.Event.Topic}
+	if d.Credential != "" {
+		mac := hmac.New(sha256.New, []byte(d.Credential))
+		mac.Write(body)
+		headers["X-Wireloop-Signature"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return post(ctx, cfg.URL, body, headers)
+}
+
+func post(ctx context.Context, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// The URL can be the secret itself (Slack), so only the host goes in the error
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("%s: %w", req.URL.Host, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d", req.URL.Host, resp.StatusCode)
+	}
+	return nil
+}
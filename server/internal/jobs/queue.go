@@ -0,0 +1,174 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Queue is a small Postgres-backed job queue.
+// Jobs survive restarts and are claimed with SKIP LOCKED, so any number of
+// server instances can run Start against the same database.
+type Queue struct {
+	queries *db.Queries
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+
+	PollInterval time.Duration
+	BatchSize    int32
+	MaxAttempts  int32
+	StaleAfter   time.Duration // running jobs older than this are requeued
+}
+
+// HandlerFunc runs one job. Returning an error schedules a retry with backoff.
+type HandlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// New creates a queue with default polling settings
+func New(queries *db.Queries) *Queue {
+	return &Queue{
+		queries:      queries,
+		handlers:     make(map[string]HandlerFunc),
+		PollInterval: 5 * time.Second,
+		BatchSize:    20,
+		MaxAttempts:  5,
+		StaleAfter:   10 * time.Minute,
+	}
+}
+
+// Register sets the handler for a job type
+func (q *Queue) Register(jobType string, fn HandlerFunc) {
+	q.mu.Lock()
+	q.handlers[jobType] = fn
+	q.mu.Unlock()
+}
+
+// Types returns the registered job types, sorted
+func (q *Queue) Types() []string {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	types := make([]string, 0, len(q.handlers))
+	for t := range q.handlers {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Enqueue schedules a job to run at runAt (or as soon as possible if in the past)
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload any, runAt time.Time) (int64, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("encode %s payload: %w", jobType, err)
+	}
+	return q.queries.EnqueueJob(ctx, db.EnqueueJobParams{
+		Type:    jobType,
+		Payload: raw,
+		RunAt:   pgtype.Timestamptz{Time: runAt, Valid: true},
+	})
+}
+
+// Start polls for due jobs until ctx is cancelled
+func (q *Queue) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(q.PollInterval)
+		defer ticker.Stop()
+		lastSweep := time.Time{}
+		for {
+			if time.Since(lastSweep) > q.StaleAfter {
+				q.requeueStale(ctx)
+				lastSweep = time.Now()
+			}
+			q.runDue(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (q *Queue) runDue(ctx context.Context) {
+	claimed, err := q.queries.ClaimDueJobs(ctx, q.BatchSize)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[jobs] claim failed: %v", err)
+		}
+		return
+	}
+	for _, job := range claimed {
+		q.run(ctx, job)
+	}
+}
+
+func (q *Queue) run(ctx context.Context, job db.Job) {
+	q.mu.RLock()
+	fn := q.handlers[job.Type]
+	q.mu.RUnlock()
+
+	var err error
+	if fn == nil {
+		err = fmt.Errorf("no handler registered for job type %q", job.Type)
+	} else {
+		err = safeRun(ctx, fn, job.Payload)
+	}
+
+	if err == nil {
+		if err := q.queries.CompleteJob(ctx, job.ID); err != nil {
+			log.Printf("[jobs] failed to mark job %d done: %v", job.ID, err)
+		}
+		return
+	}
+
+	lastErr := pgtype.Text{String: err.Error(), Valid: true}
+	if fn == nil || job.Attempts >= q.MaxAttempts {
+		log.Printf("[jobs] %s job %d failed permanently after %d attempts: %v", job.Type, job.ID, job.Attempts, err)
+		if err := q.queries.FailJob(ctx, db.FailJobParams{ID: job.ID, LastError: lastErr}); err != nil {
+			log.Printf("[jobs] failed to mark job %d failed: %v", job.ID, err)
+		}
+		return
+	}
+
+	// Quadratic backoff: 30s, 2m, 4.5m, 8m, ...
+	backoff := time.Duration(job.Attempts*job.Attempts) * 30 * time.Second
+	log.Printf("[jobs] %s job %d attempt %d failed, retrying in %s: %v", job.Type, job.ID, job.Attempts, backoff, err)
+	if err := q.queries.RetryJob(ctx, db.RetryJobParams{
+		ID:        job.ID,
+		RunAt:     pgtype.Timestamptz{Time: time.Now().Add(backoff), Valid: true},
+		LastError: lastErr,
+	}); err != nil {
+		log.Printf("[jobs] failed to reschedule job %d: %v", job.ID, err)
+	}
+}
+
+// safeRun turns a handler panic into a retryable error
+func safeRun(ctx context.Context, fn HandlerFunc, payload []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, payload)
+}
+
+func (q *Queue) requeueStale(ctx context.Context) {
+	cutoff := pgtype.Timestamptz{Time: time.Now().Add(-q.StaleAfter), Valid: true}
+	n, err := q.queries.RequeueStaleJobs(ctx, cutoff)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("[jobs] stale sweep failed: %v", err)
+		}
+		return
+	}
+	if n > 0 {
+		log.Printf("[jobs] requeued %d stale jobs", n)
+	}
+}
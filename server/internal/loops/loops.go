@@ -0,0 +1,62 @@
+// Package loops looks up loops (projects) by name and ID. The lookups run
+// on nearly every request, often several times per request, so they read
+// through a short-lived cache; whoever writes a project row must Forget it.
+package loops
+
+import (
+	"context"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const lookupTTL = 30 * time.Second
+
+// Store is what a Service reads; *db.Queries implements it
+type Store interface {
+	GetProjectByName(ctx context.Context, name string) (db.Project, error)
+	GetProjectByID(ctx context.Context, id pgtype.UUID) (db.Project, error)
+}
+
+// Service finds loops
+type Service struct {
+	store  Store
+	byName *cache.TTL[string, db.Project]
+	byID   *cache.TTL[string, db.Project]
+}
+
+func New(store Store) *Service {
+	return &Service{
+		store:  store,
+		byName: cache.New[string, db.Project](lookupTTL, 2000),
+		byID:   cache.New[string, db.Project](lookupTTL, 2000),
+	}
+}
+
+// ByName finds a loop by its name
+func (s *Service) ByName(ctx context.Context, name string) (db.Project, error) {
+	return s.byName.GetOrLoad(name, func() (db.Project, error) {
+		return s.store.GetProjectByName(ctx, name)
+	})
+}
+
+// ByID finds a loop by its ID
+func (s *Service) ByID(ctx context.Context, id pgtype.UUID) (db.Project, error) {
+	return s.byID.GetOrLoad(utils.UUIDToStr(id), func() (db.Project, error) {
+		return s.store.GetProjectByID(ctx, id)
+	})
+}
+
+// ForgetName drops a cached lookup by name, on this instance only
+func (s *Service) ForgetName(name string) {
+	s.byName.Delete(name)
+}
+
+// ForgetID drops a cached lookup by ID, on this instance only
+func (s *Service) ForgetID(id string) {
+	s.byID.Delete(id)
+}
@@ -0,0 +1,145 @@
+// Package members holds the rules of loop membership: who belongs to a loop,
+// with which role, and what each role may do there. Handlers ask a Service
+// instead of reading memberships themselves, so the rules live in one place.
+package members
+
+import (
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"time"
+
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Membership roles
+const (
+	Owner       = "owner"
+	Contributor = "contributor" // passed the gatekeeper
+	Moderator   = "moderator"
+	// Joined through an invite: reads and posts only in the loop's guest
+	// channels, can't broadcast-mention and can't reach GitHub through the app
+	Guest = "guest"
+)
+
+// Broadcast mentions guests may not use. The names are reserved, so they
+// never collide with a real user.
+var massMentionRegex = regexp.MustCompile(`(?i)@(here|everyone|all|channel)\b`)
+
+// Store is what a Service reads; *db.Queries implements it
+type Store interface {
+	IsMember(ctx context.Context, arg db.IsMemberParams) (int32, error)
+	GetMembership(ctx context.Context, arg db.GetMembershipParams) (db.Membership, error)
+	GetLoopSettings(ctx context.Context, projectID pgtype.UUID) (db.LoopSetting, error)
+	GetChannelByID(ctx context.Context, id pgtype.UUID) (db.Channel, error)
+}
+
+// Service answers membership questions
+type Service struct {
+	store Store
+	// The loop each channel belongs to; channels never move between loops
+	channelLoops *cache.TTL[pgtype.UUID, pgtype.UUID]
+}
+
+func New(store Store) *Service {
+	return &Service{store: store, channelLoops: cache.New[pgtype.UUID, pgtype.UUID](time.Hour, 10000)}
+}
+
+// IsMember reports whether uid belongs to the loop, in any role
+func (s *Service) IsMember(ctx context.Context, uid, projectID pgtype.UUID) bool {
+	if !uid.Valid {
+		return false
+	}
+	_, err := s.store.IsMember(ctx, db.IsMemberParams{UserID: uid, ProjectID: projectID})
+	return err == nil
+}
+
+// Role returns uid's role in the loop, or "" for non-members and anonymous
+// visitors
+func (s *Service) Role(ctx context.Context, uid, projectID pgtype.UUID) string {
+	if !uid.Valid {
+		return ""
+	}
+	m, err := s.store.GetMembership(ctx, db.GetMembershipParams{UserID: uid, ProjectID: projectID})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("[roles] failed to load membership: %v", err)
+		}
+		return ""
+	}
+	if m.Role.String == "" {
+		return Contributor
+	}
+	return m.Role.String
+}
+
+// GuestChannels returns the channels guests of the loop may use
+func (s *Service) GuestChannels(ctx context.Context, projectID pgtype.UUID) map[pgtype.UUID]bool {
+	settings, err := s.store.GetLoopSettings(ctx, projectID)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("[roles] failed to load loop settings: %v", err)
+		}
+		return nil
+	}
+	set := make(map[pgtype.UUID]bool, len(settings.GuestChannelIds))
+	for _, id := range settings.GuestChannelIds {
+		set[id] = true
+	}
+	return set
+}
+
+// CanUseChannel reports whether a member holding role may read and post in
+// channelID. Non-members ("") never can, and nobody can through a loop the
+// channel isn't in.
+func (s *Service) CanUseChannel(ctx context.Context, role string, projectID, channelID pgtype.UUID) bool {
+	if role == "" || !s.channelInLoop(ctx, projectID, channelID) {
+		return false
+	}
+	if role == Guest {
+		return s.GuestChannels(ctx, projectID)[channelID]
+	}
+	return true
+}
+
+// channelInLoop reports whether channelID is one of the loop's channels
+func (s *Service) channelInLoop(ctx context.Context, projectID, channelID pgtype.UUID) bool {
+	// Messages from before channels existed carry none, only their loop
+	if !channelID.Valid {
+		return true
+	}
+	loop, err := s.channelLoops.GetOrLoad(channelID, func() (pgtype.UUID, error) {
+		ch, err := s.store.GetChannelByID(ctx, channelID)
+		return ch.ProjectID, err
+	})
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			log.Printf("[roles] failed to load channel: %v", err)
+		}
+		return false
+	}
+	return loop == projectID
+}
+
+// CanModerate reports whether uid may moderate the loop: its owner or a
+// moderator
+func (s *Service) CanModerate(ctx context.Context, uid pgtype.UUID, project db.Project) bool {
+	if project.OwnerID == uid {
+		return true
+	}
+	return s.Role(ctx, uid, project.ID) == Moderator
+}
+
+// GuestPostAllowed checks a guest's message for broadcast mentions and
+// returns the refusal to show them, or "" when the message may be posted
+func GuestPostAllowed(role, content string) string {
+	if role == Guest && massMentionRegex.MatchString(content) {
+		return "guests can't use @here, @everyone or @channel"
+	}
+	return ""
+}
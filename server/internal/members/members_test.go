@@ -0,0 +1,119 @@
+package members
+
+import (
+	"context"
+	"testing"
+
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// fakeStore keeps memberships keyed by user, one set of guest channels and
+// the loop of each channel
+type fakeStore struct {
+	roles         map[pgtype.UUID]string
+	guestChannels []pgtype.UUID
+	channelLoops  map[pgtype.UUID]pgtype.UUID
+}
+
+func (f fakeStore) IsMember(_ context.Context, arg db.IsMemberParams) (int32, error) {
+	if _, ok := f.roles[arg.UserID]; !ok {
+		return 0, pgx.ErrNoRows
+	}
+	return 1, nil
+}
+
+func (f fakeStore) GetMembership(_ context.Context, arg db.GetMembershipParams) (db.Membership, error) {
+	role, ok := f.roles[arg.UserID]
+	if !ok {
+		return db.Membership{}, pgx.ErrNoRows
+	}
+	return db.Membership{UserID: arg.UserID, ProjectID: arg.ProjectID, Role: pgtype.Text{String: role, Valid: role != ""}}, nil
+}
+
+func (f fakeStore) GetLoopSettings(_ context.Context, projectID pgtype.UUID) (db.LoopSetting, error) {
+	return db.LoopSetting{ProjectID: projectID, GuestChannelIds: f.guestChannels}, nil
+}
+
+func (f fakeStore) GetChannelByID(_ context.Context, id pgtype.UUID) (db.Channel, error) {
+	loop, ok := f.channelLoops[id]
+	if !ok {
+		return db.Channel{}, pgx.ErrNoRows
+	}
+	return db.Channel{ID: id, ProjectID: loop}, nil
+}
+
+func uuid(b byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{b}, Valid: true}
+}
+
+func TestRoles(t *testing.T) {
+	loop := uuid(1)
+	owner, legacy, mod, guest, stranger := uuid(2), uuid(3), uuid(4), uuid(5), uuid(6)
+	open, guestRoom, elsewhere := uuid(10), uuid(11), uuid(12)
+	s := New(fakeStore{
+		roles: map[pgtype.UUID]string{
+			owner:  Owner,
+			legacy: "", // joined before roles existed
+			mod:    Moderator,
+			guest:  Guest,
+		},
+		guestChannels: []pgtype.UUID{guestRoom},
+		channelLoops:  map[pgtype.UUID]pgtype.UUID{open: loop, guestRoom: loop, elsewhere: uuid(99)},
+	})
+	ctx := context.Background()
+	project := db.Project{ID: loop, OwnerID: owner}
+
+	cases := []struct {
+		name        string
+		uid         pgtype.UUID
+		role        string
+		moderates   bool
+		usesOpen    bool
+		usesGuestCh bool
+	}{
+		{"owner", owner, Owner, true, true, true},
+		{"member without a role", legacy, Contributor, false, true, true},
+		{"moderator", mod, Moderator, true, true, true},
+		{"guest", guest, Guest, false, false, true},
+		{"non-member", stranger, "", false, false, false},
+		{"anonymous", pgtype.UUID{}, "", false, false, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			role := s.Role(ctx, tc.uid, loop)
+			if role != tc.role {
+				t.Errorf("Role = %q, want %q", role, tc.role)
+			}
+			if got := s.IsMember(ctx, tc.uid, loop); got != (tc.role != "") {
+				t.Errorf("IsMember = %v", got)
+			}
+			if got := s.CanModerate(ctx, tc.uid, project); got != tc.moderates {
+				t.Errorf("CanModerate = %v, want %v", got, tc.moderates)
+			}
+			if got := s.CanUseChannel(ctx, role, loop, open); got != tc.usesOpen {
+				t.Errorf("CanUseChannel(open) = %v, want %v", got, tc.usesOpen)
+			}
+			if got := s.CanUseChannel(ctx, role, loop, guestRoom); got != tc.usesGuestCh {
+				t.Errorf("CanUseChannel(guest channel) = %v, want %v", got, tc.usesGuestCh)
+			}
+			if s.CanUseChannel(ctx, role, loop, elsewhere) {
+				t.Error("CanUseChannel(channel of another loop) = true")
+			}
+		})
+	}
+}
+
+func TestGuestPostAllowed(t *testing.T) {
+	if GuestPostAllowed(Guest, "hello @everyone") == "" {
+		t.Error("guest broadcast mention allowed")
+	}
+	if GuestPostAllowed(Guest, "hello @ana") != "" {
+		t.Error("guest plain mention refused")
+	}
+	if GuestPostAllowed(Contributor, "hello @here") != "" {
+		t.Error("contributor broadcast mention refused")
+	}
+}
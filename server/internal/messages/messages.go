@@ -0,0 +1,75 @@
+// Package messages holds the rules for chat messages that more than one
+// route applies: what history shows for a deleted message, who may delete
+// one, and what deleting it touches.
+package messages
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// DeletedText replaces the content of a deleted message wherever it still
+// shows up in history, so threads keep their shape
+const DeletedText = "[Message deleted]"
+
+// ErrNotFound is returned for messages that don't exist or were deleted
+var ErrNotFound = errors.New("message not found")
+
+// Content is what history shows for a message's content
+func Content(content string, deleted bool) string {
+	if deleted {
+		return DeletedText
+	}
+	return content
+}
+
+// Store is what a Service reads and writes; *db.Queries implements it
+type Store interface {
+	GetMessageByID(ctx context.Context, id int64) (db.Message, error)
+	SoftDeleteMessage(ctx context.Context, id int64) error
+	DecrementReplyCount(ctx context.Context, id int64) error
+}
+
+// Service applies the message rules
+type Service struct {
+	store Store
+}
+
+func New(store Store) *Service {
+	return &Service{store: store}
+}
+
+// Get returns a message that hasn't been deleted
+func (s *Service) Get(ctx context.Context, id int64) (db.Message, error) {
+	msg, err := s.store.GetMessageByID(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && msg.IsDeleted.Bool) {
+		return db.Message{}, ErrNotFound
+	}
+	return msg, err
+}
+
+// CanDelete reports whether uid may delete msg: its sender or the owner of
+// its loop
+func (s *Service) CanDelete(uid pgtype.UUID, msg db.Message, project db.Project) bool {
+	return uid.Valid && (msg.SenderID == uid || project.OwnerID == uid)
+}
+
+// Delete soft-deletes msg and takes it off its thread's reply count
+func (s *Service) Delete(ctx context.Context, msg db.Message) error {
+	if err := s.store.SoftDeleteMessage(ctx, msg.ID); err != nil {
+		return err
+	}
+	if msg.ParentID.Valid {
+		// The message is gone either way; a stale count is only cosmetic
+		if err := s.store.DecrementReplyCount(ctx, msg.ParentID.Int64); err != nil {
+			log.Printf("[messages] failed to update reply count of %d: %v", msg.ParentID.Int64, err)
+		}
+	}
+	return nil
+}
@@ -1,9 +1,12 @@
 package middleware
 
 import (
+	"context"
+	"errors"
 	"net/http"
-	"os"
-	"strings"
+
+	"wireloop/internal/auth"
+	"wireloop/internal/problem"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -16,54 +19,87 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// SessionChecker reports whether a login session is still live. The API sets
+// it at startup; until then every signed session is accepted.
+var SessionChecker func(ctx context.Context, sessionID string) bool
+
+// ErrSessionRevoked is returned for a valid token whose session was revoked
+var ErrSessionRevoked = errors.New("session has been revoked")
+
+const (
+	sessionKey = "session_id"
+	apiKeyKey  = "api_key_id"
+	patKey     = "personal_access_token_id"
+	ticketKey  = "ws_ticket"
+)
+
+// parseToken verifies a session token: signed with one of our keys using a
+// pinned algorithm (see auth.ValidMethods), with exp and iat present, our
+// issuer and audience, and a session that hasn't been revoked
+func parseToken(ctx context.Context, tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, auth.Keyfunc,
+		jwt.WithValidMethods(auth.ValidMethods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithIssuer(auth.Issuer),
+		jwt.WithAudience(auth.Audience),
+	)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	// WithIssuedAt only checks iat when it's there
+	if _, ok := claims["iat"]; !ok {
+		return nil, jwt.ErrTokenRequiredClaimMissing
+	}
+	sid, _ := claims["sid"].(string)
+	if sid == "" {
+		// Login tokens from before sessions were tracked can't be revoked,
+		// so they have to sign in again. Impersonation tokens carry their
+		// support session in "imp" instead.
+		if imp, _ := claims["imp"].(string); imp == "" {
+			return nil, ErrSessionRevoked
+		}
+	} else if SessionChecker != nil && !SessionChecker(ctx, sid) {
+		return nil, ErrSessionRevoked
+	}
+	return claims, nil
+}
+
 // AuthMiddleware validates JWT tokens and sets user context
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var tokenString string
-
-		// First try Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" {
-			// Extract token from "Bearer <token>"
-			parts := strings.Split(authHeader, " ")
-			if len(parts) == 2 && parts[0] == "Bearer" {
-				tokenString = parts[1]
-			}
+		// Already authenticated by an API key or personal access token
+		if preAuthenticated(c) {
+			c.Next()
+			return
 		}
 
-		// Fallback to query param (for WebSocket connections)
-		if tokenString == "" {
-			tokenString = c.Query("token")
+		// A one-time ticket opening a WebSocket or SSE connection, redeemed
+		// by TicketAuth on the stream routes
+		if c.GetBool(ticketKey) {
+			c.Next()
+			return
 		}
 
+		tokenString := requestToken(c)
 		if tokenString == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
+			problem.Respond(c, http.StatusUnauthorized, "Authorization required")
 			c.Abort()
 			return
 		}
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "your-secret-key"
-		}
-
 		// Parse and validate token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		claims, err := parseToken(c, tokenString)
+		if errors.Is(err, ErrSessionRevoked) {
+			problem.Respond(c, http.StatusUnauthorized, "Session has been revoked")
 			c.Abort()
 			return
 		}
-
-		// Extract claims
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token claims"})
+		if err != nil {
+			problem.Respond(c, http.StatusUnauthorized, "Invalid or expired token")
 			c.Abort()
 			return
 		}
@@ -71,7 +107,7 @@ func AuthMiddleware() gin.HandlerFunc {
 		// Set user ID in context
 		userIDBytes, ok := claims["user_id"].([]interface{})
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID in token"})
+			problem.Respond(c, http.StatusUnauthorized, "Invalid user ID in token")
 			c.Abort()
 			return
 		}
@@ -87,11 +123,74 @@ func AuthMiddleware() gin.HandlerFunc {
 			}
 		}
 
+		// Impersonation tokens can look but not touch
+		if sessionID, ok := claims["imp"].(string); ok && sessionID != "" {
+			if !isReadOnlyMethod(c.Request.Method) {
+				problem.Respond(c, http.StatusForbidden, "impersonation sessions are read-only")
+				c.Abort()
+				return
+			}
+			c.Set(impersonationKey, sessionID)
+		}
+		if sid, ok := claims["sid"].(string); ok {
+			c.Set(sessionKey, sid)
+		}
+
 		c.Set("user_id", pgtype.UUID{Bytes: userIDBytes16, Valid: true})
 		c.Next()
 	}
 }
 
+const impersonationKey = "impersonation_session"
+
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// ImpersonationSession returns the support session ID when the request was
+// made with an impersonation token
+func ImpersonationSession(c *gin.Context) (string, bool) {
+	id := c.GetString(impersonationKey)
+	return id, id != ""
+}
+
+// SessionID returns the login session the request's token belongs to
+func SessionID(c *gin.Context) (string, bool) {
+	id := c.GetString(sessionKey)
+	return id, id != ""
+}
+
+// SetAPIKeyUser authenticates the request as uid through an API key. The JWT
+// middlewares further down the chain then let it through.
+func SetAPIKeyUser(c *gin.Context, uid pgtype.UUID, keyID string) {
+	c.Set("user_id", uid)
+	c.Set(apiKeyKey, keyID)
+}
+
+// APIKeyID returns the API key the request was authenticated with
+func APIKeyID(c *gin.Context) (string, bool) {
+	id := c.GetString(apiKeyKey)
+	return id, id != ""
+}
+
+// SetTokenUser authenticates the request as uid through a personal access
+// token, which the JWT middlewares then let through like an API key
+func SetTokenUser(c *gin.Context, uid pgtype.UUID, tokenID string) {
+	c.Set("user_id", uid)
+	c.Set(patKey, tokenID)
+}
+
+// PersonalTokenID returns the personal access token the request was
+// authenticated with
+func PersonalTokenID(c *gin.Context) (string, bool) {
+	id := c.GetString(patKey)
+	return id, id != ""
+}
+
+func preAuthenticated(c *gin.Context) bool {
+	return c.GetString(apiKeyKey) != "" || c.GetString(patKey) != ""
+}
+
 // GetUserID extracts the user ID from context as pgtype.UUID
 func GetUserID(c *gin.Context) (pgtype.UUID, bool) {
 	userID, exists := c.Get("user_id")
@@ -106,21 +205,12 @@ func GetUserID(c *gin.Context) (pgtype.UUID, bool) {
 // Use this for endpoints that work for both logged-in and anonymous users
 func OptionalAuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		var tokenString string
-
-		// Try Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" {
-			parts := strings.Split(authHeader, " ")
-			if len(parts) == 2 && parts[0] == "Bearer" {
-				tokenString = parts[1]
-			}
+		if preAuthenticated(c) {
+			c.Next()
+			return
 		}
 
-		// Fallback to query param
-		if tokenString == "" {
-			tokenString = c.Query("token")
-		}
+		tokenString := requestToken(c)
 
 		// No token? That's fine, just continue
 		if tokenString == "" {
@@ -128,27 +218,9 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "your-secret-key"
-		}
-
-		// Try to parse token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
-
 		// Invalid token? Just continue without user context
-		if err != nil || !token.Valid {
-			c.Next()
-			return
-		}
-
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
+		claims, err := parseToken(c, tokenString)
+		if err != nil {
 			c.Next()
 			return
 		}
@@ -169,6 +241,10 @@ func OptionalAuthMiddleware() gin.HandlerFunc {
 			}
 		}
 
+		if sessionID, ok := claims["imp"].(string); ok && sessionID != "" {
+			c.Set(impersonationKey, sessionID)
+		}
+
 		// Set user ID in context (available for handlers that need it)
 		c.Set("user_id", pgtype.UUID{Bytes: userIDBytes16, Valid: true})
 		c.Next()
@@ -181,24 +257,8 @@ func ExtractUserFromToken(tokenString string) (pgtype.UUID, bool) {
 		return pgtype.UUID{}, false
 	}
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key"
-	}
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(secret), nil
-	})
-
-	if err != nil || !token.Valid {
-		return pgtype.UUID{}, false
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
+	claims, err := parseToken(context.Background(), tokenString)
+	if err != nil {
 		return pgtype.UUID{}, false
 	}
 
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMaxJSONBody   = 1 << 20  // 1MB
+	defaultMaxUploadBody = 26 << 20 // 25MB attachments plus multipart overhead
+)
+
+// BodyLimits caps request bodies. Multipart uploads and UploadRoutes get the
+// Upload limit; everything else gets the JSON limit.
+type BodyLimits struct {
+	JSON         int64
+	Upload       int64
+	UploadRoutes []string // gin route patterns, e.g. "/api/github/webhook"
+}
+
+// BodyLimitsFromEnv reads MAX_JSON_BODY and MAX_UPLOAD_BODY (bytes)
+func BodyLimitsFromEnv() BodyLimits {
+	return BodyLimits{
+		JSON:   envBytes("MAX_JSON_BODY", defaultMaxJSONBody),
+		Upload: envBytes("MAX_UPLOAD_BODY", defaultMaxUploadBody),
+	}
+}
+
+func envBytes(key string, def int64) int64 {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || n <= 0 {
+		log.Printf("Invalid %s value: %s. Using default %d", key, raw, def)
+		return def
+	}
+	return n
+}
+
+// BodyLimitMiddleware rejects requests whose declared length is over the
+// limit and caps the rest with http.MaxBytesReader, so a handler reading an
+// oversized body gets *http.MaxBytesError instead of the whole payload
+func BodyLimitMiddleware(limits BodyLimits) gin.HandlerFunc {
+	uploadRoutes := make(map[string]bool, len(limits.UploadRoutes))
+	for _, r := range limits.UploadRoutes {
+		uploadRoutes[r] = true
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Body == nil || c.Request.Body == http.NoBody {
+			c.Next()
+			return
+		}
+
+		limit := limits.JSON
+		mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if mediaType == "multipart/form-data" || uploadRoutes[c.FullPath()] {
+			limit = limits.Upload
+		}
+
+		if c.Request.ContentLength > limit {
+			BodyTooLarge(c, limit)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
+
+// BodyTooLarge writes the 413 response shared by the middleware and handlers
+// that hit the limit while reading
+func BodyTooLarge(c *gin.Context, limit int64) {
+	problem.AbortCode(c, http.StatusRequestEntityTooLarge, "request_too_large",
+		"Request body must be at most "+strconv.FormatInt(limit, 10)+" bytes", gin.H{"limit": limit})
+}
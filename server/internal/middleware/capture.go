@@ -0,0 +1,333 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	utils "wireloop/internal"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	captureBodyLimit = 16 << 10 // bytes kept of each request and response body
+	captureStoreSize = 500
+)
+
+var (
+	// Header values replaced with "[redacted]"
+	captureSecretHeaders = map[string]bool{
+		"Authorization": true,
+		"Cookie":        true,
+		"Set-Cookie":    true,
+		"X-Api-Key":     true,
+		"X-Csrf-Token":  true,
+	}
+	// JSON fields replaced with "[redacted]", at any depth
+	captureSecretField = regexp.MustCompile(`(?i)token|secret|password|passphrase|api_?key|authorization|cookie|code_verifier`)
+)
+
+// CaptureRule selects the requests to record: those of one user, those on
+// one route pattern, or both when both are set
+type CaptureRule struct {
+	ID        int64     `json:"id"`
+	UserID    string    `json:"user_id,omitempty"`
+	Route     string    `json:"route,omitempty"` // gin pattern, e.g. /api/channels/:id/messages
+	Note      string    `json:"note,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// CapturedExchange is one sanitized request/response pair
+type CapturedExchange struct {
+	ID          int64               `json:"id"`
+	RuleID      int64               `json:"rule_id"`
+	At          time.Time           `json:"at"`
+	DurationMs  float64             `json:"duration_ms"`
+	RequestID   string              `json:"request_id"`
+	UserID      string              `json:"user_id,omitempty"`
+	Method      string              `json:"method"`
+	Path        string              `json:"path"`
+	Route       string              `json:"route"`
+	Query       map[string][]string `json:"query,omitempty"`
+	ReqHeaders  map[string]string   `json:"request_headers"`
+	ReqBody     string              `json:"request_body,omitempty"`
+	Status      int                 `json:"status"`
+	RespHeaders map[string]string   `json:"response_headers"`
+	RespBody    string              `json:"response_body,omitempty"`
+	Truncated   bool                `json:"truncated,omitempty"` // a body went over the capture limit
+}
+
+// CaptureStore holds the active capture rules and a bounded buffer of what
+// they recorded, on this instance only. Nothing is buffered while no rule is
+// active.
+type CaptureStore struct {
+	active atomic.Bool // any rule, expired or not; checked on every request
+	nextID atomic.Int64
+
+	mu        sync.Mutex
+	rules     []CaptureRule
+	exchanges []CapturedExchange // ring of captureStoreSize
+	next      int
+	full      bool
+}
+
+func NewCaptureStore() *CaptureStore {
+	return &CaptureStore{exchanges: make([]CapturedExchange, captureStoreSize)}
+}
+
+// AddRule starts capturing; the rule's ID is assigned here
+func (s *CaptureStore) AddRule(r CaptureRule) CaptureRule {
+	r.ID = s.nextID.Add(1)
+	s.mu.Lock()
+	s.rules = append(s.rules, r)
+	s.active.Store(true)
+	s.mu.Unlock()
+	return r
+}
+
+// RemoveRule stops a rule; what it captured is kept
+func (s *CaptureStore) RemoveRule(id int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range s.rules {
+		if r.ID == id {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			s.active.Store(len(s.rules) > 0)
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the rules that haven't expired, dropping the rest
+func (s *CaptureStore) Rules() []CaptureRule {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]CaptureRule{}, s.liveRules(time.Now())...)
+}
+
+// liveRules prunes expired rules; s.mu must be held
+func (s *CaptureStore) liveRules(now time.Time) []CaptureRule {
+	live := s.rules[:0]
+	for _, r := range s.rules {
+		if now.Before(r.ExpiresAt) {
+			live = append(live, r)
+		}
+	}
+	s.rules = live
+	s.active.Store(len(live) > 0)
+	return live
+}
+
+// Exchanges returns the captured pairs newest first, optionally only those
+// of one rule (ruleID > 0)
+func (s *CaptureStore) Exchanges(ruleID int64) []CapturedExchange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.next
+	if s.full {
+		n = len(s.exchanges)
+	}
+	out := make([]CapturedExchange, 0, n)
+	for i := 1; i <= n; i++ {
+		e := s.exchanges[(s.next-i+len(s.exchanges))%len(s.exchanges)]
+		if ruleID == 0 || e.RuleID == ruleID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Clear drops every captured pair
+func (s *CaptureStore) Clear() {
+	s.mu.Lock()
+	clear(s.exchanges)
+	s.next, s.full = 0, false
+	s.mu.Unlock()
+}
+
+// match returns the first live rule covering the request, or 0
+func (s *CaptureStore) match(userID, route string, now time.Time) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range s.liveRules(now) {
+		if (r.UserID == "" || r.UserID == userID) && (r.Route == "" || r.Route == route) {
+			return r.ID
+		}
+	}
+	return 0
+}
+
+func (s *CaptureStore) add(e CapturedExchange) {
+	e.ID = s.nextID.Add(1)
+	s.mu.Lock()
+	s.exchanges[s.next] = e
+	s.next = (s.next + 1) % len(s.exchanges)
+	s.full = s.full || s.next == 0
+	s.mu.Unlock()
+}
+
+// captureWriter keeps the first captureBodyLimit bytes of the response
+type captureWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (w *captureWriter) Write(b []byte) (int, error) {
+	w.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *captureWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *captureWriter) keep(b []byte) {
+	room := captureBodyLimit - w.body.Len()
+	if len(b) > room {
+		b, w.truncated = b[:max(room, 0)], true
+	}
+	w.body.Write(b)
+}
+
+// Middleware records the requests matching a capture rule. Install it
+// inside compression so bodies are recorded as the handler wrote them. The
+// user is known only once auth has run, so while any rule is active every
+// request is buffered and matched afterwards.
+func (s *CaptureStore) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !s.active.Load() || strings.Contains(strings.ToLower(c.GetHeader("Connection")), "upgrade") {
+			c.Next()
+			return
+		}
+		start := time.Now()
+		var reqBody []byte
+		reqTruncated := false
+		if c.Request.Body != nil && c.Request.Body != http.NoBody && textual(c.GetHeader("Content-Type")) {
+			reqBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, captureBodyLimit+1))
+			reqTruncated = len(reqBody) > captureBodyLimit
+			// The handler still gets the whole body
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(reqBody), c.Request.Body), c.Request.Body}
+			reqBody = reqBody[:min(len(reqBody), captureBodyLimit)]
+		}
+		cw := &captureWriter{ResponseWriter: c.Writer}
+		c.Writer = cw
+		c.Next()
+
+		var userID string
+		if id, ok := utils.GetUserIdFromContext(c); ok && id.Valid {
+			userID = utils.UUIDToStr(id)
+		}
+		ruleID := s.match(userID, c.FullPath(), start)
+		if ruleID == 0 {
+			return
+		}
+		e := CapturedExchange{
+			RuleID:      ruleID,
+			At:          start,
+			DurationMs:  float64(time.Since(start).Microseconds()) / 1000,
+			RequestID:   c.Writer.Header().Get(problem.RequestIDHeader),
+			UserID:      userID,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			Route:       c.FullPath(),
+			Query:       sanitizeQuery(c.Request.URL.Query()),
+			ReqHeaders:  sanitizeHeaders(c.Request.Header),
+			ReqBody:     sanitizeBody(c.GetHeader("Content-Type"), reqBody),
+			Status:      c.Writer.Status(),
+			RespHeaders: sanitizeHeaders(c.Writer.Header()),
+			Truncated:   reqTruncated || cw.truncated,
+		}
+		if textual(c.Writer.Header().Get("Content-Type")) {
+			e.RespBody = sanitizeBody(c.Writer.Header().Get("Content-Type"), cw.body.Bytes())
+		} else if cw.body.Len() > 0 {
+			e.RespBody = "[" + strconv.Itoa(c.Writer.Size()) + " bytes of " + c.Writer.Header().Get("Content-Type") + "]"
+		}
+		s.add(e)
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// textual reports whether a body of this content type is worth keeping
+func textual(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+func sanitizeHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if captureSecretHeaders[k] {
+			out[k] = "[redacted]"
+		} else {
+			out[k] = strings.Join(v, ", ")
+		}
+	}
+	return out
+}
+
+func sanitizeQuery(q map[string][]string) map[string][]string {
+	for k := range q {
+		if captureSecretField.MatchString(k) {
+			q[k] = []string{"[redacted]"}
+		}
+	}
+	return q
+}
+
+// sanitizeBody redacts secret-looking fields of JSON bodies. Anything that
+// doesn't parse (including truncated JSON) is kept only as a size.
+func sanitizeBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !strings.HasSuffix(mediaType, "json") {
+		if mediaType == "application/x-www-form-urlencoded" {
+			return "[" + strconv.Itoa(len(body)) + " bytes of form data]"
+		}
+		return string(body)
+	}
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "[" + strconv.Itoa(len(body)) + " bytes of unparseable JSON]"
+	}
+	out, _ := json.Marshal(redactJSON(v))
+	return string(out)
+}
+
+func redactJSON(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, inner := range t {
+			if captureSecretField.MatchString(k) {
+				t[k] = "[redacted]"
+			} else {
+				t[k] = redactJSON(inner)
+			}
+		}
+	case []any:
+		for i := range t {
+			t[i] = redactJSON(t[i])
+		}
+	}
+	return v
+}
@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// OriginPolicy decides which browser origins may call the API and open
+// WebSockets. It is built from:
+//
+//	FRONTEND_URL, OBS_FRONTEND_URL  exact origins (as before)
+//	CORS_ORIGINS                    comma-separated origins; a leading "*." in
+//	                                the host matches any subdomain, e.g.
+//	                                https://*.wireloop.dev for preview deploys
+//	APP_ENV                         "production" drops the localhost defaults
+//	                                and only lets wildcards match https
+type OriginPolicy struct {
+	strict   bool
+	exact    map[string]bool
+	patterns []originPattern
+}
+
+type originPattern struct {
+	scheme string // "" matches http or https
+	suffix string // ".wireloop.dev"
+	port   string
+}
+
+var devOrigins = []string{"http://localhost:3000", "https://localhost:3000"}
+
+// Origins is the policy loaded from the environment on first use, after
+// main has read .env
+var Origins = sync.OnceValue(OriginPolicyFromEnv)
+
+// OriginPolicyFromEnv builds the policy described on OriginPolicy
+func OriginPolicyFromEnv() *OriginPolicy {
+	p := &OriginPolicy{
+		strict: strings.EqualFold(os.Getenv("APP_ENV"), "production"),
+		exact:  map[string]bool{},
+	}
+	if !p.strict {
+		for _, o := range devOrigins {
+			p.exact[o] = true
+		}
+	}
+	entries := []string{os.Getenv("FRONTEND_URL"), os.Getenv("OBS_FRONTEND_URL")}
+	entries = append(entries, strings.Split(os.Getenv("CORS_ORIGINS"), ",")...)
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if err := p.add(e); err != "" {
+			log.Printf("Ignoring CORS origin %q: %s", e, err)
+		}
+	}
+	return p
+}
+
+func (p *OriginPolicy) add(entry string) string {
+	entry = strings.TrimSuffix(entry, "/")
+	if entry == "" {
+		return ""
+	}
+	if entry == "*" {
+		return "use explicit origins or *.domain patterns"
+	}
+
+	scheme, host, hasScheme := strings.Cut(entry, "://")
+	if !hasScheme {
+		scheme, host = "", entry
+	}
+	if scheme != "" && scheme != "http" && scheme != "https" {
+		return "scheme must be http or https"
+	}
+
+	if rest, ok := strings.CutPrefix(host, "*."); ok {
+		domain, port, _ := strings.Cut(rest, ":")
+		if !strings.Contains(domain, ".") {
+			return "wildcard must cover a registrable domain"
+		}
+		if p.strict {
+			if scheme == "http" {
+				return "http wildcards are not allowed in production"
+			}
+			scheme = "https"
+		}
+		p.patterns = append(p.patterns, originPattern{scheme: scheme, suffix: "." + strings.ToLower(domain), port: port})
+		return ""
+	}
+
+	if !hasScheme {
+		return "exact origins need a scheme"
+	}
+	if strings.Contains(host, "/") {
+		return "origins have no path"
+	}
+	p.exact[scheme+"://"+strings.ToLower(host)] = true
+	return ""
+}
+
+// Allowed reports whether a browser Origin header is permitted
+func (p *OriginPolicy) Allowed(origin string) bool {
+	if p.exact[strings.ToLower(origin)] {
+		return true
+	}
+	if len(p.patterns) == 0 {
+		return false
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, pat := range p.patterns {
+		if pat.scheme != "" && u.Scheme != pat.scheme {
+			continue
+		}
+		if pat.scheme == "" && u.Scheme != "http" && u.Scheme != "https" {
+			continue
+		}
+		if u.Port() != pat.port {
+			continue
+		}
+		// Needs at least one label in front of the suffix
+		if strings.HasSuffix(host, pat.suffix) && len(host) > len(pat.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// CORSMiddleware applies Origins to cross-origin requests. The open route
+// patterns (as in c.FullPath) are public embeds: any origin may read them,
+// without credentials.
+func CORSMiddleware(open ...string) gin.HandlerFunc {
+	origins := Origins()
+	restricted := cors.New(cors.Config{
+		AllowOriginFunc:  origins.Allowed,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Request-ID", IdempotencyHeader},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "X-Request-ID", ReplayedHeader},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	})
+	openRoutes := make(map[string]bool, len(open))
+	for _, route := range open {
+		openRoutes[route] = true
+	}
+	return func(c *gin.Context) {
+		if openRoutes[c.FullPath()] {
+			c.Header("Access-Control-Allow-Origin", "*")
+			c.Header("Access-Control-Expose-Headers", "ETag")
+			c.Next()
+			return
+		}
+		restricted(c)
+	}
+}
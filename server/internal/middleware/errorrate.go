@@ -0,0 +1,214 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	errorWindow       = 5 * time.Minute
+	errorBuckets      = 60 // of 5s each
+	defaultAlertRate  = 0.05
+	defaultAlertMin   = 10
+	defaultAlertQuiet = 15 * time.Minute
+	alertTimeout      = 10 * time.Second
+)
+
+// ErrorAlert posts to a Slack or Discord incoming webhook when a route's 5xx
+// rate over the window reaches Rate with at least MinErrors errors. A route
+// alerts at most once per Cooldown.
+type ErrorAlert struct {
+	Webhook   string // empty disables alerts
+	Rate      float64
+	MinErrors int
+	Cooldown  time.Duration
+}
+
+// ErrorAlertFromEnv reads ERROR_ALERT_WEBHOOK, ERROR_ALERT_RATE (percent),
+// ERROR_ALERT_MIN and ERROR_ALERT_COOLDOWN (a duration)
+func ErrorAlertFromEnv() ErrorAlert {
+	a := ErrorAlert{
+		Webhook:   os.Getenv("ERROR_ALERT_WEBHOOK"),
+		Rate:      defaultAlertRate,
+		MinErrors: defaultAlertMin,
+		Cooldown:  defaultAlertQuiet,
+	}
+	if raw := os.Getenv("ERROR_ALERT_RATE"); raw != "" {
+		if pct, err := strconv.ParseFloat(raw, 64); err == nil && pct > 0 && pct <= 100 {
+			a.Rate = pct / 100
+		} else {
+			log.Printf("Invalid ERROR_ALERT_RATE value: %s. Using default %g%%", raw, defaultAlertRate*100)
+		}
+	}
+	a.MinErrors = int(envBytes("ERROR_ALERT_MIN", defaultAlertMin))
+	if raw := os.Getenv("ERROR_ALERT_COOLDOWN"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			a.Cooldown = d
+		} else {
+			log.Printf("Invalid ERROR_ALERT_COOLDOWN value: %s. Using default %s", raw, defaultAlertQuiet)
+		}
+	}
+	return a
+}
+
+// ErrorRates counts requests and 5xx responses per route over a sliding
+// five-minute window on this instance
+type ErrorRates struct {
+	alert  ErrorAlert
+	client *http.Client
+
+	mu      sync.Mutex
+	routes  map[string]*routeWindow
+	alerted map[string]time.Time
+}
+
+type routeWindow struct {
+	buckets [errorBuckets]errorBucket
+}
+
+type errorBucket struct {
+	slot     int64
+	requests int
+	errors   int
+}
+
+// RouteErrorRate is one route's figures over the window
+type RouteErrorRate struct {
+	Route    string  `json:"route"` // method and gin route pattern
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	Rate     float64 `json:"rate"`
+}
+
+type ErrorRateSnapshot struct {
+	WindowSeconds int              `json:"window_seconds"`
+	Requests      int              `json:"requests"`
+	Errors        int              `json:"errors"`
+	AlertRate     float64          `json:"alert_rate"`
+	AlertsEnabled bool             `json:"alerts_enabled"`
+	Routes        []RouteErrorRate `json:"routes"` // routes with 5xx, most errors first
+}
+
+func NewErrorRates(alert ErrorAlert) *ErrorRates {
+	return &ErrorRates{
+		alert:   alert,
+		client:  &http.Client{Timeout: alertTimeout},
+		routes:  make(map[string]*routeWindow),
+		alerted: make(map[string]time.Time),
+	}
+}
+
+// Middleware records every response. Install it outside Recovery so
+// recovered panics count as the 500s they become.
+func (e *ErrorRates) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = "(unmatched)"
+		}
+		e.record(c.Request.Method+" "+route, c.Writer.Status() >= 500, time.Now())
+	}
+}
+
+func (e *ErrorRates) record(route string, failed bool, now time.Time) {
+	slot := now.UnixNano() / int64(errorWindow/errorBuckets)
+	e.mu.Lock()
+	w := e.routes[route]
+	if w == nil {
+		w = &routeWindow{}
+		e.routes[route] = w
+	}
+	b := &w.buckets[slot%errorBuckets]
+	if b.slot != slot {
+		*b = errorBucket{slot: slot}
+	}
+	b.requests++
+	if !failed {
+		e.mu.Unlock()
+		return
+	}
+	b.errors++
+	requests, errors := w.sum(slot)
+	fire := e.alert.Webhook != "" && errors >= e.alert.MinErrors &&
+		float64(errors)/float64(requests) >= e.alert.Rate &&
+		now.Sub(e.alerted[route]) >= e.alert.Cooldown
+	if fire {
+		e.alerted[route] = now
+	}
+	e.mu.Unlock()
+
+	if fire {
+		go e.sendAlert(route, requests, errors)
+	}
+}
+
+// sum totals the buckets still inside the window ending at slot
+func (w *routeWindow) sum(slot int64) (requests, errors int) {
+	for _, b := range w.buckets {
+		if b.slot > slot-errorBuckets {
+			requests += b.requests
+			errors += b.errors
+		}
+	}
+	return requests, errors
+}
+
+// Snapshot returns the current window's figures
+func (e *ErrorRates) Snapshot() ErrorRateSnapshot {
+	slot := time.Now().UnixNano() / int64(errorWindow/errorBuckets)
+	out := ErrorRateSnapshot{
+		WindowSeconds: int(errorWindow.Seconds()),
+		AlertRate:     e.alert.Rate,
+		AlertsEnabled: e.alert.Webhook != "",
+		Routes:        []RouteErrorRate{},
+	}
+	e.mu.Lock()
+	for route, w := range e.routes {
+		requests, errors := w.sum(slot)
+		out.Requests += requests
+		out.Errors += errors
+		if errors > 0 {
+			out.Routes = append(out.Routes, RouteErrorRate{
+				Route:    route,
+				Requests: requests,
+				Errors:   errors,
+				Rate:     float64(errors) / float64(requests),
+			})
+		}
+	}
+	e.mu.Unlock()
+	sort.Slice(out.Routes, func(i, j int) bool {
+		if out.Routes[i].Errors != out.Routes[j].Errors {
+			return out.Routes[i].Errors > out.Routes[j].Errors
+		}
+		return out.Routes[i].Route < out.Routes[j].Route
+	})
+	return out
+}
+
+func (e *ErrorRates) sendAlert(route string, requests, errors int) {
+	text := fmt.Sprintf("Wireloop: %s returned %d 5xx responses out of %d (%.1f%%) in the last %s",
+		route, errors, requests, float64(errors)*100/float64(requests), errorWindow)
+	// Slack reads text, Discord reads content
+	body, _ := json.Marshal(map[string]string{"text": text, "content": text})
+	resp, err := e.client.Post(e.alert.Webhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("[errors] alert webhook failed: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("[errors] alert webhook returned %d", resp.StatusCode)
+	}
+}
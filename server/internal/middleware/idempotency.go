@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// IdempotencyHeader is the client-chosen key for a retryable POST
+	IdempotencyHeader = "Idempotency-Key"
+	// ReplayedHeader marks a response served from the idempotency cache
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+	// Completed responses are replayed this long
+	idempotencyTTL = 24 * time.Hour
+	// An in-flight claim expires on its own if the instance dies mid-request
+	idempotencyPendingTTL = 2 * time.Minute
+	maxIdempotentBody     = 1 << 20
+)
+
+// IdempotentResponse is what a key remembers: a fingerprint of the request
+// that claimed it and, once the handler finished, the response to replay
+type IdempotentResponse struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// IdempotencyStore keeps idempotency keys and their responses
+type IdempotencyStore interface {
+	// Claim records entry under key unless the key exists, in which case
+	// the stored entry is returned and claimed is false
+	Claim(ctx context.Context, key string, entry IdempotentResponse, ttl time.Duration) (existing IdempotentResponse, claimed bool, err error)
+	// Save replaces the claim with the finished response
+	Save(ctx context.Context, key string, entry IdempotentResponse, ttl time.Duration) error
+	// Release forgets key so the next attempt runs the handler again
+	Release(ctx context.Context, key string)
+}
+
+var idempotencyStore IdempotencyStore = &memoryIdempotency{entries: map[string]memoryIdempotencyEntry{}}
+
+// UseRedisIdempotency shares idempotency keys across instances. Call once at
+// startup, before serving; without it a retry that lands on another
+// instance runs again.
+func UseRedisIdempotency(rdb *redis.Client) {
+	if rdb != nil {
+		idempotencyStore = redisIdempotency{rdb}
+	}
+}
+
+type memoryIdempotencyEntry struct {
+	resp    IdempotentResponse
+	expires time.Time
+}
+
+type memoryIdempotency struct {
+	mu      sync.Mutex
+	entries map[string]memoryIdempotencyEntry
+}
+
+func (m *memoryIdempotency) Claim(_ context.Context, key string, entry IdempotentResponse, ttl time.Duration) (IdempotentResponse, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if e, ok := m.entries[key]; ok && now.Before(e.expires) {
+		return e.resp, false, nil
+	}
+	if len(m.entries) >= 10000 {
+		for k, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[key] = memoryIdempotencyEntry{resp: entry, expires: now.Add(ttl)}
+	return IdempotentResponse{}, true, nil
+}
+
+func (m *memoryIdempotency) Save(_ context.Context, key string, entry IdempotentResponse, ttl time.Duration) error {
+	m.mu.Lock()
+	m.entries[key] = memoryIdempotencyEntry{resp: entry, expires: time.Now().Add(ttl)}
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memoryIdempotency) Release(_ context.Context, key string) {
+	m.mu.Lock()
+	delete(m.entries, key)
+	m.mu.Unlock()
+}
+
+type redisIdempotency struct{ rdb *redis.Client }
+
+func (r redisIdempotency) Claim(ctx context.Context, key string, entry IdempotentResponse, ttl time.Duration) (IdempotentResponse, bool, error) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return IdempotentResponse{}, false, err
+	}
+	ok, err := r.rdb.SetNX(ctx, "idempotency:"+key, raw, ttl).Result()
+	if err != nil || ok {
+		return IdempotentResponse{}, ok, err
+	}
+	stored, err := r.rdb.Get(ctx, "idempotency:"+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Expired between the two calls; the client's next retry claims it
+		return IdempotentResponse{Fingerprint: entry.Fingerprint}, false, nil
+	}
+	if err != nil {
+		return IdempotentResponse{}, false, err
+	}
+	var existing IdempotentResponse
+	err = json.Unmarshal(stored, &existing)
+	return existing, false, err
+}
+
+func (r redisIdempotency) Save(ctx context.Context, key string, entry IdempotentResponse, ttl time.Duration) error {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return r.rdb.Set(ctx, "idempotency:"+key, raw, ttl).Err()
+}
+
+func (r redisIdempotency) Release(ctx context.Context, key string) {
+	r.rdb.Del(ctx, "idempotency:"+key)
+}
+
+// Idempotency makes a POST safe to retry. A request carrying an
+// Idempotency-Key runs once per user and key; repeats get the first
+// response back with Idempotent-Replayed: true instead of creating the
+// resource again. Reusing a key for a different request is a 422, and a
+// repeat that arrives while the first is still running is a 409. Server
+// errors aren't remembered, so a retry after a 5xx runs the handler again.
+// Attach it after authentication; requests without the header pass through.
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			problem.Abort(c, http.StatusBadRequest, "Idempotency-Key must be 1-255 printable ASCII characters")
+			return
+		}
+		uid, ok := GetUserID(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			BodyTooLarge(c, maxErr.Limit)
+			return
+		}
+		if err != nil {
+			problem.Abort(c, http.StatusBadRequest, "failed to read body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(append([]byte(c.Request.Method+" "+c.Request.URL.Path+"\n"), body...))
+		fingerprint := hex.EncodeToString(sum[:])
+		storeKey := hex.EncodeToString(uid.Bytes[:]) + ":" + key
+
+		existing, claimed, err := idempotencyStore.Claim(c, storeKey, IdempotentResponse{Fingerprint: fingerprint}, idempotencyPendingTTL)
+		if err != nil {
+			// Best effort, like webhook dedupe: without the store the request still runs
+			log.Printf("[idempotency] store unavailable: %v", err)
+			c.Next()
+			return
+		}
+		if !claimed {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				problem.AbortCode(c, http.StatusUnprocessableEntity, "idempotency_key_reused",
+					"this Idempotency-Key was already used for a different request", nil)
+			case !existing.Done:
+				problem.AbortCode(c, http.StatusConflict, "idempotency_in_progress",
+					"a request with this Idempotency-Key is still being processed", nil)
+			default:
+				c.Header(ReplayedHeader, "true")
+				c.Data(existing.Status, existing.ContentType, existing.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+		c.Writer = rec.ResponseWriter
+
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := rec.Status()
+		if status >= 500 || status == http.StatusTooManyRequests || rec.overflow {
+			idempotencyStore.Release(ctx, storeKey)
+			return
+		}
+		err = idempotencyStore.Save(ctx, storeKey, IdempotentResponse{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      status,
+			ContentType: rec.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+		}, idempotencyTTL)
+		if err != nil {
+			log.Printf("[idempotency] failed to save response: %v", err)
+			idempotencyStore.Release(ctx, storeKey)
+		}
+	}
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLen {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingWriter passes the response through and keeps a copy to replay
+type recordingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool // too big to remember; the key is released instead
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.record(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.record([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordingWriter) record(b []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(b) > maxIdempotentBody {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(b)
+}
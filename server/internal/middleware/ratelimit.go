@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"os"
 
+	"wireloop/internal/problem"
+
 	"github.com/gin-gonic/gin"
 	"github.com/ulule/limiter/v3"
 	mgin "github.com/ulule/limiter/v3/drivers/middleware/gin"
@@ -31,12 +33,7 @@ func RateLimitMiddleware() gin.HandlerFunc {
 	instance := limiter.New(store, rate)
 
 	return mgin.NewMiddleware(instance, mgin.WithLimitReachedHandler(func(c *gin.Context) {
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error":       "rate_limit_exceeded",
-			"message":     "Too many requests, please slow down",
-			"retry_after": "60s",
-		})
-		c.Abort()
+		problem.AbortCode(c, http.StatusTooManyRequests, "rate_limit_exceeded", "Too many requests, please slow down", gin.H{"retry_after": "60s"})
 	}))
 }
 
@@ -52,12 +49,24 @@ func StrictRateLimitMiddleware() gin.HandlerFunc {
 	instance := limiter.New(store, rate)
 
 	return mgin.NewMiddleware(instance, mgin.WithLimitReachedHandler(func(c *gin.Context) {
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error":       "rate_limit_exceeded",
-			"message":     "Too many requests to this endpoint",
-			"retry_after": "60s",
-		})
-		c.Abort()
+		problem.AbortCode(c, http.StatusTooManyRequests, "rate_limit_exceeded", "Too many requests to this endpoint", gin.H{"retry_after": "60s"})
+	}))
+}
+
+// EmbedRateLimitMiddleware for unauthenticated embeds (badges, widgets)
+// Default: 30 requests per minute per IP; their responses are cached anyway
+func EmbedRateLimitMiddleware() gin.HandlerFunc {
+	rate := limiter.Rate{
+		Period: 60,
+		Limit:  30,
+	}
+
+	store := memory.NewStore()
+	instance := limiter.New(store, rate)
+
+	return mgin.NewMiddleware(instance, mgin.WithLimitReachedHandler(func(c *gin.Context) {
+		c.Header("Retry-After", "60")
+		problem.AbortCode(c, http.StatusTooManyRequests, "rate_limit_exceeded", "Too many requests for embeds, please cache them", gin.H{"retry_after": "60s"})
 	}))
 }
 
@@ -73,10 +82,6 @@ func WebSocketRateLimitMiddleware() gin.HandlerFunc {
 	instance := limiter.New(store, rate)
 
 	return mgin.NewMiddleware(instance, mgin.WithLimitReachedHandler(func(c *gin.Context) {
-		c.JSON(http.StatusTooManyRequests, gin.H{
-			"error":   "connection_limit_exceeded",
-			"message": "Too many WebSocket connection attempts",
-		})
-		c.Abort()
+		problem.AbortCode(c, http.StatusTooManyRequests, "connection_limit_exceeded", "Too many WebSocket connection attempts", nil)
 	}))
 }
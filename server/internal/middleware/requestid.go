@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"wireloop/internal/errreport"
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxRequestIDLen = 64
+
+// RequestIDMiddleware tags every response with X-Request-ID. A well-formed
+// id from the caller (or a proxy in front of us) is kept so logs line up
+// across hops; otherwise a random one is generated.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(problem.RequestIDHeader)
+		if !validRequestID(id) {
+			b := make([]byte, 12)
+			_, _ = rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		c.Header(problem.RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// Recovery turns a panic into a logged 500 problem response and reports it
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, v any) {
+		errreport.CapturePanic(c.Request, v, map[string]string{
+			"route":      c.FullPath(),
+			"request_id": c.Writer.Header().Get(problem.RequestIDHeader),
+		})
+		problem.Abort(c, http.StatusInternalServerError, "internal server error")
+	})
+}
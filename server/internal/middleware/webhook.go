@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	webhookBodyKey = "webhook_body"
+
+	// Deliveries are remembered this long; providers stop retrying well before
+	deliveryTTL = 24 * time.Hour
+	// Signed timestamps older (or further in the future) than this are replays
+	defaultWebhookMaxAge = 5 * time.Minute
+	defaultWebhookBody   = 5 << 20
+)
+
+// WebhookSignature describes how a provider signs its deliveries. The
+// signature is an HMAC-SHA256 of the raw body, or of "<timestamp>.<body>"
+// when TimestampHeader is set.
+type WebhookSignature struct {
+	Provider        string        // used in logs and as the dedupe namespace
+	Secret          func() []byte // empty disables the endpoint (503)
+	Header          string        // header carrying the signature
+	Prefix          string        // stripped before decoding, e.g. "sha256="
+	Base64          bool          // signature is base64 rather than hex
+	DeliveryHeader  string        // unique delivery id; empty skips dedupe
+	TimestampHeader string        // unix seconds, signed along with the body
+	MaxAge          time.Duration // timestamp tolerance, default 5 minutes
+	MaxBody         int64         // default 5MB
+}
+
+// GitHubWebhook is the X-Hub-Signature-256 scheme, deduped on X-GitHub-Delivery
+func GitHubWebhook(secret func() []byte) WebhookSignature {
+	return WebhookSignature{
+		Provider:       "github",
+		Secret:         secret,
+		Header:         "X-Hub-Signature-256",
+		Prefix:         "sha256=",
+		DeliveryHeader: "X-GitHub-Delivery",
+	}
+}
+
+// DeliveryStore remembers which webhook deliveries were already accepted
+type DeliveryStore interface {
+	// Claim records key and reports false if it was already recorded
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Release forgets key so the provider's retry is accepted
+	Release(ctx context.Context, key string)
+}
+
+var webhookDeliveries DeliveryStore = &memoryDeliveries{seen: map[string]time.Time{}}
+
+// UseRedisDeliveries shares delivery dedupe across instances. Call once at
+// startup, before serving; without it each instance dedupes on its own.
+func UseRedisDeliveries(rdb *redis.Client) {
+	if rdb != nil {
+		webhookDeliveries = redisDeliveries{rdb}
+	}
+}
+
+type memoryDeliveries struct {
+	mu   sync.Mutex
+	seen map[string]time.Time // key -> expiry
+}
+
+func (m *memoryDeliveries) Claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if exp, ok := m.seen[key]; ok && now.Before(exp) {
+		return false, nil
+	}
+	if len(m.seen) >= 50000 {
+		for k, exp := range m.seen {
+			if now.After(exp) {
+				delete(m.seen, k)
+			}
+		}
+	}
+	m.seen[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (m *memoryDeliveries) Release(_ context.Context, key string) {
+	m.mu.Lock()
+	delete(m.seen, key)
+	m.mu.Unlock()
+}
+
+type redisDeliveries struct{ rdb *redis.Client }
+
+func (r redisDeliveries) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return r.rdb.SetNX(ctx, "webhook:delivery:"+key, 1, ttl).Result()
+}
+
+func (r redisDeliveries) Release(ctx context.Context, key string) {
+	r.rdb.Del(ctx, "webhook:delivery:"+key)
+}
+
+// VerifyWebhook authenticates a webhook before the handler parses anything.
+// It reads the body (up to MaxBody), checks the HMAC in constant time,
+// rejects stale timestamps and answers a repeated delivery id with 200
+// without calling the handler. A delivery whose handler fails with a 5xx is
+// forgotten so the provider's retry goes through. Handlers read the verified
+// body with WebhookBody.
+func VerifyWebhook(cfg WebhookSignature) gin.HandlerFunc {
+	if cfg.MaxAge == 0 {
+		cfg.MaxAge = defaultWebhookMaxAge
+	}
+	if cfg.MaxBody == 0 {
+		cfg.MaxBody = defaultWebhookBody
+	}
+
+	return func(c *gin.Context) {
+		secret := cfg.Secret()
+		if len(secret) == 0 {
+			problem.Abort(c, http.StatusServiceUnavailable, "webhooks not configured")
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, cfg.MaxBody+1))
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			BodyTooLarge(c, maxErr.Limit)
+			return
+		}
+		if int64(len(body)) > cfg.MaxBody {
+			BodyTooLarge(c, cfg.MaxBody)
+			return
+		}
+		if err != nil {
+			problem.Abort(c, http.StatusBadRequest, "failed to read body")
+			return
+		}
+
+		signed := body
+		if cfg.TimestampHeader != "" {
+			ts := c.GetHeader(cfg.TimestampHeader)
+			sec, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				problem.Abort(c, http.StatusUnauthorized, "missing or invalid timestamp")
+				return
+			}
+			if age := time.Since(time.Unix(sec, 0)); age > cfg.MaxAge || age < -cfg.MaxAge {
+				problem.Abort(c, http.StatusUnauthorized, "stale delivery")
+				return
+			}
+			signed = append([]byte(ts+"."), body...)
+		}
+		if !validSignature(secret, signed, c.GetHeader(cfg.Header), cfg.Prefix, cfg.Base64) {
+			problem.Abort(c, http.StatusUnauthorized, "invalid signature")
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Set(webhookBodyKey, body)
+
+		id := c.GetHeader(cfg.DeliveryHeader)
+		if cfg.DeliveryHeader == "" || id == "" {
+			c.Next()
+			return
+		}
+		key := cfg.Provider + ":" + id
+		fresh, err := webhookDeliveries.Claim(c, key, deliveryTTL)
+		if err != nil {
+			// Dedupe is best effort; the signature has already been checked
+			log.Printf("[webhook] %s dedupe unavailable for %s: %v", cfg.Provider, id, err)
+			c.Next()
+			return
+		}
+		if !fresh {
+			c.AbortWithStatusJSON(http.StatusOK, gin.H{"ok": true, "duplicate": true})
+			return
+		}
+		c.Next()
+		if c.Writer.Status() >= 500 {
+			webhookDeliveries.Release(context.WithoutCancel(c.Request.Context()), key)
+		}
+	}
+}
+
+// validSignature compares the expected HMAC with the header in constant time
+func validSignature(secret, body []byte, header, prefix string, b64 bool) bool {
+	sig, ok := strings.CutPrefix(header, prefix)
+	if !ok || sig == "" {
+		return false
+	}
+	var got []byte
+	var err error
+	if b64 {
+		got, err = base64.StdEncoding.DecodeString(sig)
+	} else {
+		got, err = hex.DecodeString(sig)
+	}
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hmac.Equal(mac.Sum(nil), got)
+}
+
+// WebhookBody returns the body VerifyWebhook authenticated
+func WebhookBody(c *gin.Context) []byte {
+	b, _ := c.Get(webhookBodyKey)
+	body, _ := b.([]byte)
+	return body
+}
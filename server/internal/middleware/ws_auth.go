@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"wireloop/internal/problem"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+)
+
+// ============================================================================
+// STREAM AUTHENTICATION
+// Browsers can't set an Authorization header on a WebSocket or EventSource,
+// so those connections authenticate with one of:
+//   - a ticket from POST /api/ws-ticket in ?ticket=, good for one connection
+//     within WSTicketTTL, so a copy in a proxy log is worthless
+//   - the session token in Sec-WebSocket-Protocol as "bearer.<token>",
+//     offered alongside WSProtocol, which is the protocol the server picks
+//
+// The session token in ?token= still works but is deprecated: it ends up in
+// access logs along the way.
+// ============================================================================
+
+const (
+	// WSProtocol is the WebSocket subprotocol the server answers with. Clients
+	// sending their token in Sec-WebSocket-Protocol must offer it too, or the
+	// browser fails the handshake for want of a selected protocol.
+	WSProtocol = "wireloop"
+
+	// WSTicketTTL is how long a ticket can wait to be used
+	WSTicketTTL = 30 * time.Second
+
+	wsBearerPrefix = "bearer."
+)
+
+// ErrTicketNotAllowed is returned when asked for a ticket on a request
+// authenticated by an API key or personal access token, which can send
+// their credential in the Authorization header on any connection
+var ErrTicketNotAllowed = errors.New("tickets are only issued to browser sessions")
+
+// WSGrant is what a ticket stands for: the session that asked for it
+type WSGrant struct {
+	UserID        pgtype.UUID `json:"user_id"`
+	SessionID     string      `json:"session_id,omitempty"`
+	Impersonation string      `json:"impersonation,omitempty"`
+}
+
+// WSTicketStore keeps tickets until they are used or expire
+type WSTicketStore interface {
+	// Put stores grant under ticket for ttl
+	Put(ctx context.Context, ticket string, grant WSGrant, ttl time.Duration) error
+	// Take returns ticket's grant and forgets the ticket; ok is false when
+	// it doesn't exist, was already used or expired
+	Take(ctx context.Context, ticket string) (grant WSGrant, ok bool, err error)
+}
+
+var wsTickets WSTicketStore = &memoryTickets{entries: map[string]memoryTicket{}}
+
+// UseRedisWSTickets shares tickets across instances. Call once at startup,
+// before serving; without it a ticket only works on the instance that
+// issued it.
+func UseRedisWSTickets(rdb *redis.Client) {
+	if rdb != nil {
+		wsTickets = redisTickets{rdb}
+	}
+}
+
+type memoryTicket struct {
+	grant   WSGrant
+	expires time.Time
+}
+
+type memoryTickets struct {
+	mu      sync.Mutex
+	entries map[string]memoryTicket
+}
+
+func (m *memoryTickets) Put(_ context.Context, ticket string, grant WSGrant, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	if len(m.entries) >= 10000 {
+		for k, e := range m.entries {
+			if now.After(e.expires) {
+				delete(m.entries, k)
+			}
+		}
+	}
+	m.entries[ticket] = memoryTicket{grant: grant, expires: now.Add(ttl)}
+	return nil
+}
+
+func (m *memoryTickets) Take(_ context.Context, ticket string) (WSGrant, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[ticket]
+	delete(m.entries, ticket)
+	if !ok || time.Now().After(e.expires) {
+		return WSGrant{}, false, nil
+	}
+	return e.grant, true, nil
+}
+
+type redisTickets struct{ rdb *redis.Client }
+
+func (r redisTickets) Put(ctx context.Context, ticket string, grant WSGrant, ttl time.Duration) error {
+	raw, err := json.Marshal(grant)
+	if err != nil {
+		return err
+	}
+	return r.rdb.Set(ctx, "ws:ticket:"+ticket, raw, ttl).Err()
+}
+
+func (r redisTickets) Take(ctx context.Context, ticket string) (WSGrant, bool, error) {
+	raw, err := r.rdb.GetDel(ctx, "ws:ticket:"+ticket).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return WSGrant{}, false, nil
+	}
+	if err != nil {
+		return WSGrant{}, false, err
+	}
+	var grant WSGrant
+	err = json.Unmarshal(raw, &grant)
+	return grant, err == nil, err
+}
+
+// IssueWSTicket returns a ticket that opens one WebSocket or SSE connection
+// as the request's session within WSTicketTTL
+func IssueWSTicket(c *gin.Context) (string, time.Time, error) {
+	if preAuthenticated(c) {
+		return "", time.Time{}, ErrTicketNotAllowed
+	}
+	uid, ok := GetUserID(c)
+	if !ok {
+		return "", time.Time{}, errors.New("unauthenticated")
+	}
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	ticket := hex.EncodeToString(b)
+	grant := WSGrant{UserID: uid, SessionID: c.GetString(sessionKey), Impersonation: c.GetString(impersonationKey)}
+	if err := wsTickets.Put(c, ticket, grant, WSTicketTTL); err != nil {
+		return "", time.Time{}, err
+	}
+	return ticket, time.Now().Add(WSTicketTTL), nil
+}
+
+// TicketAuth authenticates a stream request by its one-time ?ticket=. It is
+// only mounted on the WebSocket and SSE routes, ahead of AuthMiddleware, so a
+// ticket can't stand in for the session token anywhere else.
+func TicketAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if handled, ok := redeemTicket(c); handled {
+			if !ok {
+				problem.Respond(c, http.StatusUnauthorized, "Invalid or expired ticket")
+				c.Abort()
+				return
+			}
+			c.Set(ticketKey, true)
+		}
+		c.Next()
+	}
+}
+
+// redeemTicket authenticates a stream request by its ?ticket=. Tickets only
+// open connections, so they are only taken on GETs.
+func redeemTicket(c *gin.Context) (handled, ok bool) {
+	ticket := c.Query("ticket")
+	if ticket == "" || c.Request.Method != http.MethodGet {
+		return false, false
+	}
+	grant, ok, err := wsTickets.Take(c, ticket)
+	if err != nil || !ok {
+		return true, false
+	}
+	// The session may have been revoked since the ticket was issued
+	if grant.SessionID != "" && SessionChecker != nil && !SessionChecker(c, grant.SessionID) {
+		return true, false
+	}
+	if grant.SessionID != "" {
+		c.Set(sessionKey, grant.SessionID)
+	}
+	if grant.Impersonation != "" {
+		c.Set(impersonationKey, grant.Impersonation)
+	}
+	c.Set("user_id", grant.UserID)
+	return true, true
+}
+
+// requestToken finds the session token of a request: the Authorization
+// header, then a "bearer." entry in Sec-WebSocket-Protocol, then the
+// deprecated ?token= query parameter
+func requestToken(c *gin.Context) string {
+	if parts := strings.Split(c.GetHeader("Authorization"), " "); len(parts) == 2 && parts[0] == "Bearer" {
+		return parts[1]
+	}
+	for _, p := range strings.Split(c.GetHeader("Sec-WebSocket-Protocol"), ",") {
+		if token, ok := strings.CutPrefix(strings.TrimSpace(p), wsBearerPrefix); ok {
+			return token
+		}
+	}
+	if token := c.Query("token"); token != "" {
+		c.Header("Deprecation", "true")
+		return token
+	}
+	return ""
+}
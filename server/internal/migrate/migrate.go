@@ -0,0 +1,199 @@
+// Package migrate applies the goose-format migrations in the migrations
+// directory. It records versions in goose's own goose_db_version table, so
+// databases migrated with the goose CLI and with this package stay
+// interchangeable. Each migration runs in a transaction.
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const versionTable = "goose_db_version"
+
+// Migration is one NNN_name.sql file
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration and when it was applied; AppliedAt is zero when
+// it is pending
+type Status struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// Load reads every .sql migration in fsys, ordered by version
+func Load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Migration, 0, len(files))
+	for _, file := range files {
+		prefix, _, ok := strings.Cut(file, "_")
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil {
+			return nil, fmt.Errorf("%s: file name must start with a version number", file)
+		}
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		up, down, err := split(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		out = append(out, Migration{Version: version, Name: strings.TrimSuffix(file, path.Ext(file)), Up: up, Down: down})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	for i := 1; i < len(out); i++ {
+		if out[i].Version == out[i-1].Version {
+			return nil, fmt.Errorf("%s and %s share version %d", out[i-1].Name, out[i].Name, out[i].Version)
+		}
+	}
+	return out, nil
+}
+
+// split returns the Up and Down sections. goose's other annotations
+// (StatementBegin/End) are SQL comments and are left in: each section runs
+// as one multi-statement exec.
+func split(src string) (up, down string, err error) {
+	upAt := strings.Index(src, "-- +goose Up")
+	if upAt < 0 {
+		return "", "", fmt.Errorf("missing -- +goose Up")
+	}
+	rest := src[upAt:]
+	if downAt := strings.Index(rest, "-- +goose Down"); downAt >= 0 {
+		return rest[:downAt], rest[downAt:], nil
+	}
+	return rest, "", nil
+}
+
+func ensureVersionTable(ctx context.Context, pool *pgxpool.Pool) error {
+	_, err := pool.Exec(ctx, `CREATE TABLE IF NOT EXISTS `+versionTable+` (
+    id SERIAL PRIMARY KEY,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP DEFAULT NOW()
+)`)
+	return err
+}
+
+// applied maps each applied version to when it was applied
+func applied(ctx context.Context, pool *pgxpool.Pool) (map[int64]time.Time, error) {
+	if err := ensureVersionTable(ctx, pool); err != nil {
+		return nil, err
+	}
+	rows, err := pool.Query(ctx, `SELECT version_id, is_applied, tstamp FROM `+versionTable+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var ok bool
+		var at time.Time
+		if err := rows.Scan(&version, &ok, &at); err != nil {
+			return nil, err
+		}
+		// Older goose versions record a rollback as a row with is_applied false
+		if ok {
+			out[version] = at
+		} else {
+			delete(out, version)
+		}
+	}
+	return out, rows.Err()
+}
+
+// StatusOf lists every migration in fsys with when it was applied
+func StatusOf(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS) ([]Status, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	done, err := applied(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, len(migrations))
+	for i, m := range migrations {
+		out[i] = Status{Migration: m, AppliedAt: done[m.Version]}
+	}
+	return out, nil
+}
+
+// Up applies every pending migration in order and returns those it applied.
+// It stops at the first failure, leaving that migration unapplied.
+func Up(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS) ([]Migration, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	done, err := applied(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	var ran []Migration
+	for _, m := range migrations {
+		if _, ok := done[m.Version]; ok {
+			continue
+		}
+		if err := run(ctx, pool, m.Up, `INSERT INTO `+versionTable+` (version_id, is_applied) VALUES ($1, TRUE)`, m.Version); err != nil {
+			return ran, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		ran = append(ran, m)
+	}
+	return ran, nil
+}
+
+// Down rolls back the most recently applied migration and returns it, or
+// nil when nothing is applied
+func Down(ctx context.Context, pool *pgxpool.Pool, fsys fs.FS) (*Migration, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	done, err := applied(ctx, pool)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := done[m.Version]; !ok {
+			continue
+		}
+		if err := run(ctx, pool, m.Down, `DELETE FROM `+versionTable+` WHERE version_id = $1`, m.Version); err != nil {
+			return nil, fmt.Errorf("%s: %w", m.Name, err)
+		}
+		return &m, nil
+	}
+	return nil, nil
+}
+
+// run executes sql and records the version change in one transaction
+func run(ctx context.Context, pool *pgxpool.Pool, sql, record string, version int64) error {
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		if strings.TrimSpace(sql) != "" {
+			if _, err := tx.Exec(ctx, sql); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec(ctx, record, version)
+		return err
+	})
+}
@@ -0,0 +1,217 @@
+// Package msgfilter screens chat messages for banned words and spam before
+// they are stored or broadcast.
+package msgfilter
+
+import (
+	"crypto/sha256"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Action is what happens to a message that trips a rule
+type Action string
+
+const (
+	ActionNone  Action = ""
+	ActionFlag  Action = "flag"  // posted normally, queued for moderator review
+	ActionHold  Action = "hold"  // shown only to the sender until a moderator approves it
+	ActionBlock Action = "block" // rejected outright
+	ActionOff   Action = "off"   // rule disabled
+)
+
+// severity orders actions so the strongest verdict wins
+var severity = map[Action]int{ActionNone: 0, ActionOff: 0, ActionFlag: 1, ActionHold: 2, ActionBlock: 3}
+
+// ValidAction reports whether a is a configurable action
+func ValidAction(a Action) bool {
+	_, ok := severity[a]
+	return ok && a != ActionNone
+}
+
+// Rule names the check that produced a verdict
+type Rule string
+
+const (
+	RuleBannedWord Rule = "banned_word"
+	RuleLinkSpam   Rule = "link_spam"
+	RuleRepeat     Rule = "repeat"
+)
+
+// Config is one loop's filter configuration
+type Config struct {
+	BannedWords      []string // lowercased words or phrases
+	BannedWordAction Action
+	MaxLinks         int // more links than this trips the rule; 0 disables
+	LinkSpamAction   Action
+	RepeatLimit      int // identical messages allowed per RepeatWindow; 0 disables
+	RepeatAction     Action
+}
+
+// DefaultConfig applies to loops that haven't configured their filters
+func DefaultConfig() Config {
+	return Config{
+		BannedWordAction: ActionBlock,
+		MaxLinks:         5,
+		LinkSpamAction:   ActionHold,
+		RepeatLimit:      3,
+		RepeatAction:     ActionBlock,
+	}
+}
+
+// Verdict is the outcome of checking one message. A zero Verdict means allow.
+type Verdict struct {
+	Action Action
+	Rule   Rule
+	Detail string
+}
+
+// RepeatWindow is how far back identical messages are counted
+const RepeatWindow = time.Minute
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://|\bwww\.`)
+
+// Filter checks messages and keeps the short-lived state the repeat rule
+// needs. Safe for concurrent use.
+type Filter struct {
+	mu        sync.Mutex
+	recent    map[string][]sent
+	lastSweep time.Time
+
+	checked  atomic.Int64
+	counters sync.Map // "rule:action" -> *atomic.Int64
+}
+
+type sent struct {
+	hash [sha256.Size]byte
+	at   time.Time
+}
+
+func New() *Filter {
+	return &Filter{recent: make(map[string][]sent)}
+}
+
+// Check runs every rule in cfg over content sent by sender and returns the
+// strongest verdict. Every call is remembered for the repeat rule.
+func (f *Filter) Check(cfg Config, sender, content string) Verdict {
+	f.checked.Add(1)
+
+	var v Verdict
+	consider := func(action Action, rule Rule, detail string) {
+		if severity[action] > severity[v.Action] {
+			v = Verdict{Action: action, Rule: rule, Detail: detail}
+		}
+	}
+
+	if cfg.BannedWordAction != ActionOff {
+		if w := matchWord(content, cfg.BannedWords); w != "" {
+			consider(cfg.BannedWordAction, RuleBannedWord, w)
+		}
+	}
+	if cfg.MaxLinks > 0 && cfg.LinkSpamAction != ActionOff {
+		if n := len(linkPattern.FindAllStringIndex(content, -1)); n > cfg.MaxLinks {
+			consider(cfg.LinkSpamAction, RuleLinkSpam, strconv.Itoa(n)+" links")
+		}
+	}
+	if n := f.remember(sender, content); cfg.RepeatLimit > 0 && cfg.RepeatAction != ActionOff && n > cfg.RepeatLimit {
+		consider(cfg.RepeatAction, RuleRepeat, strconv.Itoa(n)+" identical messages")
+	}
+
+	if v.Action != ActionNone {
+		f.counter(string(v.Rule) + ":" + string(v.Action)).Add(1)
+	}
+	return v
+}
+
+// remember records the message and returns how many identical messages
+// (including this one) the sender has sent within RepeatWindow
+func (f *Filter) remember(sender, content string) int {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(content))))
+	now := time.Now()
+	cutoff := now.Add(-RepeatWindow)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now.Sub(f.lastSweep) > RepeatWindow {
+		for k, list := range f.recent {
+			if len(list) == 0 || list[len(list)-1].at.Before(cutoff) {
+				delete(f.recent, k)
+			}
+		}
+		f.lastSweep = now
+	}
+
+	kept := f.recent[sender][:0]
+	count := 1
+	for _, s := range f.recent[sender] {
+		if s.at.Before(cutoff) {
+			continue
+		}
+		kept = append(kept, s)
+		if s.hash == hash {
+			count++
+		}
+	}
+	f.recent[sender] = append(kept, sent{hash: hash, at: now})
+	return count
+}
+
+func (f *Filter) counter(key string) *atomic.Int64 {
+	c, _ := f.counters.LoadOrStore(key, new(atomic.Int64))
+	return c.(*atomic.Int64)
+}
+
+// Stats returns how many messages were checked and how many tripped each
+// rule, keyed "rule:action", since the process started
+func (f *Filter) Stats() map[string]int64 {
+	stats := map[string]int64{"checked": f.checked.Load()}
+	f.counters.Range(func(k, v any) bool {
+		stats[k.(string)] = v.(*atomic.Int64).Load()
+		return true
+	})
+	return stats
+}
+
+// matchWord returns the first of words found in content as a whole word or
+// phrase (case-insensitive), or "". words must already be lowercased.
+func matchWord(content string, words []string) string {
+	if len(words) == 0 {
+		return ""
+	}
+	lower := strings.ToLower(content)
+	for _, w := range words {
+		for from := 0; from < len(lower); {
+			i := strings.Index(lower[from:], w)
+			if i < 0 {
+				break
+			}
+			start, end := from+i, from+i+len(w)
+			if !wordRuneAt(lower, start-1, true) && !wordRuneAt(lower, end, false) {
+				return w
+			}
+			from = start + 1
+		}
+	}
+	return ""
+}
+
+// wordRuneAt reports whether the rune ending (before) or starting (!before)
+// at byte offset i is a letter or digit
+func wordRuneAt(s string, i int, before bool) bool {
+	if i < 0 || i >= len(s) {
+		return false
+	}
+	var r rune
+	if before {
+		r, _ = utf8.DecodeLastRuneInString(s[:i+1])
+	} else {
+		r, _ = utf8.DecodeRuneInString(s[i:])
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
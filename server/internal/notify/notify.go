@@ -0,0 +1,340 @@
+// Package notify delivers notifications. Notify drops events the recipient
+// filters out, stores the rest for the inbox (folding repeats into a batch
+// when asked) and pushes them to the recipient's open connections. A client
+// acks what it receives; a notification nobody acked within AckWindow goes
+// out through the other transports the recipient allows, such as push or
+// email, and each notification records how it was delivered.
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+	utils "wireloop/internal"
+	"wireloop/internal/chat"
+	"wireloop/internal/db"
+	"wireloop/internal/events"
+	"wireloop/internal/jobs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// PreviewLen is how much of a message a preview keeps
+const PreviewLen = 100
+
+// AckWindow is how long a client has to ack a notification pushed over
+// WebSocket before the fallback transports are tried
+const AckWindow = 30 * time.Second
+
+// JobFallback sends a notification nobody acked through a fallback transport
+const JobFallback = "notification_fallback"
+
+// Delivery states, as recorded and returned by the notifications API
+const (
+	StatePending   = "pending"   // pushed, waiting for an ack
+	StateDelivered = "delivered" // acked by a client, or read in the app
+	StateFallback  = "fallback"  // sent through a fallback transport
+	StateRetrying  = "retrying"  // every fallback transport failed; retried by the job
+	StatePersisted = "persisted" // in the inbox only: no fallback was allowed or registered
+)
+
+// DefaultFallback is the transport order for users who haven't chosen one
+var DefaultFallback = []string{"push", "email"}
+
+// Event is something a user should hear about
+type Event struct {
+	Type          string
+	UserID        pgtype.UUID // the recipient
+	ActorID       pgtype.UUID
+	ActorUsername string
+	ProjectID     pgtype.UUID
+	ChannelID     pgtype.UUID
+	MessageID     pgtype.Int8
+	Preview       string
+	// Extra fields for the real-time payload, e.g. what the client needs to
+	// act on the notification without a fetch
+	Extra map[string]any
+	// Batch, when set, folds the event into an unread notification of the
+	// same type from the same actor in the same channel
+	Batch *Batch
+}
+
+// Batch says how repeats of an event fold together
+type Batch struct {
+	Window time.Duration // how recent the notification folded into must be
+	// Summary is the preview of a batch of count events
+	Summary func(count int32) string
+}
+
+// Notification is a delivered event
+type Notification struct {
+	Event
+	ID         int64
+	BatchCount int32
+	CreatedAt  time.Time
+}
+
+// Filter reports whether the recipient wants ev; any filter returning false
+// drops it before it is stored
+type Filter func(ctx context.Context, ev Event) bool
+
+// Transport delivers notifications outside the app when the app didn't.
+// Name is what users list in their fallback preferences.
+type Transport interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// Service is the one path notifications take
+type Service struct {
+	queries    *db.Queries
+	hub        *chat.Hub
+	jobs       *jobs.Queue
+	filters    []Filter
+	transports []Transport
+}
+
+// New creates a service storing with queries, delivering through hub and
+// scheduling fallbacks on queue. Register filters and transports before it
+// is used.
+func New(queries *db.Queries, hub *chat.Hub, queue *jobs.Queue) *Service {
+	s := &Service{queries: queries, hub: hub, jobs: queue}
+	queue.Register(JobFallback, s.runFallback)
+	return s
+}
+
+// AddFilter drops events f rejects
+func (s *Service) AddFilter(f Filter) {
+	s.filters = append(s.filters, f)
+}
+
+// AddTransport makes t available as a fallback, for the users who list it
+func (s *Service) AddTransport(t Transport) {
+	s.transports = append(s.transports, t)
+}
+
+// Preview shortens content to PreviewLen bytes
+func Preview(content string) string {
+	if len(content) > PreviewLen {
+		return content[:PreviewLen] + "..."
+	}
+	return content
+}
+
+// Notify delivers ev. It reports false, with no error, when a filter
+// dropped it. Failing to record or schedule the delivery is logged, not
+// returned: the notification is already in the inbox.
+func (s *Service) Notify(ctx context.Context, ev Event) (Notification, bool, error) {
+	for _, f := range s.filters {
+		if !f(ctx, ev) {
+			return Notification{}, false, nil
+		}
+	}
+	n, err := s.store(ctx, ev)
+	if err != nil {
+		return Notification{}, false, err
+	}
+
+	payload := map[string]any{
+		"id":              strconv.FormatInt(n.ID, 10),
+		"type":            n.Type,
+		"actor_username":  n.ActorUsername,
+		"content_preview": n.Preview,
+		"batch_count":     n.BatchCount,
+	}
+	if n.ProjectID.Valid {
+		payload["project_id"] = utils.UUIDToStr(n.ProjectID)
+	}
+	if n.ChannelID.Valid {
+		payload["channel_id"] = utils.UUIDToStr(n.ChannelID)
+	}
+	if n.MessageID.Valid {
+		payload["message_id"] = strconv.FormatInt(n.MessageID.Int64, 10)
+	}
+	for k, v := range ev.Extra {
+		payload[k] = v
+	}
+	s.hub.NotifyUser(utils.UUIDToStr(n.UserID), events.Wrap(events.Of(events.Notification, payload), ""))
+
+	s.awaitAck(ctx, n)
+	return n, true, nil
+}
+
+type fallbackPayload struct {
+	NotificationID int64 `json:"notification_id"`
+	// The push this job follows up; a later push of the same (batched)
+	// notification schedules its own
+	PushedAt int64 `json:"pushed_at"`
+}
+
+// awaitAck records n as pushed and schedules its fallback
+func (s *Service) awaitAck(ctx context.Context, n Notification) {
+	if err := s.queries.StartNotificationDelivery(ctx, db.StartNotificationDeliveryParams{
+		NotificationID: n.ID,
+		UserID:         n.UserID,
+	}); err != nil {
+		log.Printf("[notify] failed to record delivery of %d: %v", n.ID, err)
+		return
+	}
+	d, err := s.queries.GetNotificationDelivery(ctx, n.ID)
+	if err != nil {
+		log.Printf("[notify] failed to record delivery of %d: %v", n.ID, err)
+		return
+	}
+	if _, err := s.jobs.Enqueue(ctx, JobFallback, fallbackPayload{
+		NotificationID: n.ID,
+		PushedAt:       d.PushedAt.Time.UnixMicro(),
+	}, time.Now().Add(AckWindow)); err != nil {
+		log.Printf("[notify] failed to schedule fallback for %d: %v", n.ID, err)
+	}
+}
+
+// Ack records that one of userID's clients received notification id. It
+// reports false when the notification isn't theirs or has no delivery.
+func (s *Service) Ack(ctx context.Context, userID pgtype.UUID, id int64) (bool, error) {
+	n, err := s.queries.AckNotificationDelivery(ctx, db.AckNotificationDeliveryParams{
+		NotificationID: id,
+		UserID:         userID,
+	})
+	return n > 0, err
+}
+
+// FallbackOrder returns the transports userID allows, in the order they
+// are tried
+func (s *Service) FallbackOrder(ctx context.Context, userID pgtype.UUID) ([]string, error) {
+	order, err := s.queries.GetNotificationFallback(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return DefaultFallback, nil
+	}
+	return order, err
+}
+
+// runFallback sends an unacked notification through the first transport
+// the recipient allows that takes it. When all of them fail the job is
+// retried; when none is allowed or registered it stays in the inbox.
+func (s *Service) runFallback(ctx context.Context, raw json.RawMessage) error {
+	var p fallbackPayload
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return fmt.Errorf("bad payload: %w", err)
+	}
+	d, err := s.queries.GetNotificationDelivery(ctx, p.NotificationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil // deleted
+	}
+	if err != nil {
+		return err
+	}
+	if d.PushedAt.Time.UnixMicro() != p.PushedAt || (d.State != StatePending && d.State != StateRetrying) {
+		return nil
+	}
+	row, err := s.queries.GetNotificationByID(ctx, p.NotificationID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if row.IsRead.Bool {
+		return s.setState(ctx, d.NotificationID, StateDelivered, "", nil)
+	}
+
+	order, err := s.FallbackOrder(ctx, d.UserID)
+	if err != nil {
+		return err
+	}
+	n := Notification{
+		Event: Event{
+			Type:          row.Type,
+			UserID:        row.UserID,
+			ActorID:       row.ActorID,
+			ActorUsername: row.ActorUsername,
+			ProjectID:     row.ProjectID,
+			ChannelID:     row.ChannelID,
+			MessageID:     row.MessageID,
+			Preview:       row.ContentPreview.String,
+		},
+		ID:         row.ID,
+		BatchCount: row.BatchCount,
+		CreatedAt:  row.CreatedAt.Time,
+	}
+	var lastErr error
+	for _, t := range s.transports {
+		if !slices.Contains(order, t.Name()) {
+			continue
+		}
+		if err := t.Send(ctx, n); err != nil {
+			log.Printf("[notify] %s delivery of %s to %s failed: %v", t.Name(), n.Type, utils.UUIDToStr(n.UserID), err)
+			lastErr = fmt.Errorf("%s: %w", t.Name(), err)
+			continue
+		}
+		return s.setState(ctx, n.ID, StateFallback, t.Name(), nil)
+	}
+	if lastErr == nil {
+		return s.setState(ctx, n.ID, StatePersisted, "", nil)
+	}
+	if err := s.setState(ctx, n.ID, StateRetrying, "", lastErr); err != nil {
+		return err
+	}
+	return lastErr
+}
+
+func (s *Service) setState(ctx context.Context, id int64, state, transport string, cause error) error {
+	params := db.SetNotificationDeliveryStateParams{
+		NotificationID: id,
+		State:          state,
+		Transport:      pgtype.Text{String: transport, Valid: transport != ""},
+	}
+	if cause != nil {
+		params.LastError = pgtype.Text{String: cause.Error(), Valid: true}
+	}
+	_, err := s.queries.SetNotificationDeliveryState(ctx, params)
+	return err
+}
+
+// store folds ev into a recent batch or creates its notification
+func (s *Service) store(ctx context.Context, ev Event) (Notification, error) {
+	n := Notification{Event: ev, BatchCount: 1, CreatedAt: time.Now()}
+	if ev.Batch != nil {
+		recent, err := s.queries.GetRecentUnreadNotification(ctx, db.GetRecentUnreadNotificationParams{
+			UserID:    ev.UserID,
+			ActorID:   ev.ActorID,
+			ChannelID: ev.ChannelID,
+			Type:      ev.Type,
+			CreatedAt: pgtype.Timestamptz{Time: n.CreatedAt.Add(-ev.Batch.Window), Valid: true},
+		})
+		if err == nil {
+			n.ID = recent.ID
+			n.BatchCount = recent.BatchCount + 1
+			n.Preview = ev.Batch.Summary(n.BatchCount)
+			err = s.queries.BumpNotificationBatch(ctx, db.BumpNotificationBatchParams{
+				ID:             recent.ID,
+				MessageID:      ev.MessageID,
+				ContentPreview: pgtype.Text{String: n.Preview, Valid: true},
+			})
+			return n, err
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return n, err
+		}
+	}
+
+	n.ID = utils.GetMessageId()
+	err := s.queries.CreateNotification(ctx, db.CreateNotificationParams{
+		ID:             n.ID,
+		UserID:         ev.UserID,
+		Type:           ev.Type,
+		MessageID:      ev.MessageID,
+		ProjectID:      ev.ProjectID,
+		ChannelID:      ev.ChannelID,
+		ActorID:        ev.ActorID,
+		ActorUsername:  ev.ActorUsername,
+		ContentPreview: pgtype.Text{String: ev.Preview, Valid: ev.Preview != ""},
+	})
+	return n, err
+}
@@ -0,0 +1,138 @@
+// Package problem writes error responses as RFC 7807 problem details.
+//
+// Every API error has the same shape:
+//
+//	{"type": "about:blank", "title": "Not Found", "status": 404,
+//	 "detail": "loop not found", "code": "not_found",
+//	 "instance": "/api/loops/foo", "request_id": "…", "details": …}
+//
+// Clients branch on code, show detail to people, and quote request_id when
+// reporting a problem. details is optional and code-specific.
+package problem
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+)
+
+// ContentType is the media type of every error body
+const ContentType = "application/problem+json"
+
+// RequestIDHeader carries the id that ties a response to the server logs
+const RequestIDHeader = "X-Request-ID"
+
+// Problem is an RFC 7807 body plus Wireloop's code, request_id and details
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail"`
+	Code      string `json:"code"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Details   any    `json:"details,omitempty"`
+}
+
+// Code is the default machine-readable code for a status
+func Code(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusMethodNotAllowed:
+		return "method_not_allowed"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusUnprocessableEntity:
+		return "unprocessable"
+	case http.StatusLocked:
+		return "locked"
+	case http.StatusTooManyRequests:
+		return "rate_limit_exceeded"
+	case http.StatusBadGateway:
+		return "upstream_error"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	case http.StatusGatewayTimeout:
+		return "timeout"
+	}
+	if status >= 500 {
+		return "internal_error"
+	}
+	return "error"
+}
+
+// Localize, when set, translates detail into the language of the request.
+// code and details stay as they are for clients to branch on.
+var Localize func(c *gin.Context, detail string) string
+
+func build(c *gin.Context, status int, code, detail string, details any) Problem {
+	if Localize != nil {
+		detail = Localize(c, detail)
+	}
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Code:      code,
+		Instance:  c.Request.URL.Path,
+		RequestID: c.Writer.Header().Get(RequestIDHeader),
+		Details:   details,
+	}
+}
+
+// Respond writes an error with the status's default code
+func Respond(c *gin.Context, status int, detail string) {
+	RespondCode(c, status, Code(status), detail, nil)
+}
+
+// RespondCode writes an error with a specific code and optional details
+func RespondCode(c *gin.Context, status int, code, detail string, details any) {
+	c.Header("Content-Type", ContentType)
+	c.JSON(status, build(c, status, code, detail, details))
+}
+
+// Abort is Respond for middleware: later handlers don't run
+func Abort(c *gin.Context, status int, detail string) {
+	c.Abort()
+	Respond(c, status, detail)
+}
+
+// AbortCode is RespondCode for middleware
+func AbortCode(c *gin.Context, status int, code, detail string, details any) {
+	c.Abort()
+	RespondCode(c, status, code, detail, details)
+}
+
+// Error maps a failed operation to a response. Missing rows become 404,
+// oversized bodies 413 and timeouts 504; anything else is logged and
+// answered with a 500 carrying detail, so internals never reach the client.
+func Error(c *gin.Context, err error, detail string) {
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		Respond(c, http.StatusNotFound, detail)
+	case errors.As(err, &maxErr):
+		RespondCode(c, http.StatusRequestEntityTooLarge, "request_too_large",
+			"request body is too large", gin.H{"limit": maxErr.Limit})
+	case errors.Is(err, context.DeadlineExceeded):
+		Respond(c, http.StatusGatewayTimeout, detail)
+	default:
+		log.Printf("[error] %s %s (request %s): %v", c.Request.Method, c.FullPath(),
+			c.Writer.Header().Get(RequestIDHeader), err)
+		Respond(c, http.StatusInternalServerError, detail)
+	}
+}
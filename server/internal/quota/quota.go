@@ -0,0 +1,230 @@
+// Package quota enforces usage limits per account: how many loops may be
+// billed to it, and how many messages a day and bytes of attachments each of
+// those loops may take. A loop is billed to its workspace's billing owner,
+// or to its owner outside a workspace. Limits default to the QUOTA_* environment variables
+// and can be overridden per account by rows in the account_quotas table.
+// A limit of 0 means unlimited, which is the default when no variable is set.
+package quota
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+	"wireloop/internal/cache"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Kind names a quota
+type Kind string
+
+const (
+	Loops          Kind = "loops"
+	MessagesPerDay Kind = "messages_per_day"
+	StorageBytes   Kind = "storage_bytes"
+)
+
+// Limits are an account's quotas; 0 means unlimited
+type Limits struct {
+	MaxLoops       int64 `json:"max_loops"`
+	MessagesPerDay int64 `json:"messages_per_day"`
+	StorageBytes   int64 `json:"storage_bytes"`
+}
+
+// Exceeded is returned when an action would go over a quota
+type Exceeded struct {
+	Kind  Kind
+	Limit int64
+	Used  int64
+	// ResetAt is when a rolling quota frees up again; zero for quotas that
+	// only free up when something is deleted
+	ResetAt time.Time
+}
+
+func (e *Exceeded) Error() string {
+	switch e.Kind {
+	case Loops:
+		return fmt.Sprintf("your plan allows %d loops", e.Limit)
+	case MessagesPerDay:
+		return fmt.Sprintf("this loop has reached its limit of %d messages a day", e.Limit)
+	case StorageBytes:
+		return fmt.Sprintf("this loop has used its %d MB of attachment storage", e.Limit>>20)
+	}
+	return "quota exceeded"
+}
+
+// DefaultLimits reads QUOTA_MAX_LOOPS, QUOTA_MESSAGES_PER_DAY and
+// QUOTA_STORAGE_BYTES
+func DefaultLimits() Limits {
+	env := func(name string) int64 {
+		v := os.Getenv(name)
+		if v == "" {
+			return 0
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 0 {
+			log.Printf("[quota] invalid %s value: %s, leaving it unlimited", name, v)
+			return 0
+		}
+		return n
+	}
+	return Limits{
+		MaxLoops:       env("QUOTA_MAX_LOOPS"),
+		MessagesPerDay: env("QUOTA_MESSAGES_PER_DAY"),
+		StorageBytes:   env("QUOTA_STORAGE_BYTES"),
+	}
+}
+
+// Apply overlays an account's overrides on the defaults
+func (l Limits) Apply(row db.AccountQuota) Limits {
+	if row.MaxLoops.Valid {
+		l.MaxLoops = row.MaxLoops.Int64
+	}
+	if row.MessagesPerDay.Valid {
+		l.MessagesPerDay = row.MessagesPerDay.Int64
+	}
+	if row.StorageBytes.Valid {
+		l.StorageBytes = row.StorageBytes.Int64
+	}
+	return l
+}
+
+// dayCount is a loop's message count for one UTC day: loaded from the
+// database, then counted up locally until the entry expires. Other instances'
+// messages show up on the next load.
+type dayCount struct {
+	mu      sync.Mutex
+	account pgtype.UUID
+	day     time.Time
+	count   int64
+}
+
+// Store caches limits and message counts. A nil *Store allows everything.
+// Checks fail open: if usage can't be read, the action goes ahead.
+type Store struct {
+	queries  *db.Queries
+	defaults Limits
+	limits   *cache.TTL[pgtype.UUID, Limits]
+	daily    *cache.TTL[pgtype.UUID, *dayCount]
+}
+
+// New creates a store using DefaultLimits. Limit changes reach other
+// instances within a minute.
+func New(queries *db.Queries) *Store {
+	return &Store{
+		queries:  queries,
+		defaults: DefaultLimits(),
+		limits:   cache.New[pgtype.UUID, Limits](time.Minute, 10000),
+		daily:    cache.New[pgtype.UUID, *dayCount](time.Minute, 50000),
+	}
+}
+
+// Defaults are the limits of accounts without overrides
+func (s *Store) Defaults() Limits {
+	if s == nil {
+		return Limits{}
+	}
+	return s.defaults
+}
+
+// Limits returns the account's effective limits
+func (s *Store) Limits(ctx context.Context, account pgtype.UUID) Limits {
+	if s == nil {
+		return Limits{}
+	}
+	l, err := s.limits.GetOrLoad(account, func() (Limits, error) {
+		row, err := s.queries.GetAccountQuota(ctx, account)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return s.defaults, nil
+		}
+		if err != nil {
+			return Limits{}, err
+		}
+		return s.defaults.Apply(row), nil
+	})
+	if err != nil {
+		log.Printf("[quota] failed to load limits: %v", err)
+		return s.defaults
+	}
+	return l
+}
+
+// Invalidate drops the account's cached limits after its overrides change
+func (s *Store) Invalidate(account pgtype.UUID) {
+	if s != nil {
+		s.limits.Delete(account)
+	}
+}
+
+// CheckLoops reports whether another loop may be billed to account
+func (s *Store) CheckLoops(ctx context.Context, account pgtype.UUID) error {
+	limit := s.Limits(ctx, account).MaxLoops
+	if limit == 0 {
+		return nil
+	}
+	n, err := s.queries.CountLoopsBilledTo(ctx, account)
+	if err != nil {
+		log.Printf("[quota] failed to count loops: %v", err)
+		return nil
+	}
+	if n >= limit {
+		return &Exceeded{Kind: Loops, Limit: limit, Used: n}
+	}
+	return nil
+}
+
+// UseMessage counts one message against the loop's daily quota, or reports
+// that the quota is used up
+func (s *Store) UseMessage(ctx context.Context, projectID pgtype.UUID) error {
+	if s == nil {
+		return nil
+	}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	dc, ok := s.daily.Get(projectID)
+	if !ok || !dc.day.Equal(today) {
+		usage, err := s.queries.GetLoopMessageUsage(ctx, db.GetLoopMessageUsageParams{
+			Since:     pgtype.Timestamptz{Time: today, Valid: true},
+			ProjectID: projectID,
+		})
+		if err != nil {
+			log.Printf("[quota] failed to count messages: %v", err)
+			return nil
+		}
+		dc = &dayCount{account: usage.OwnerID, day: today, count: usage.Messages}
+		s.daily.Set(projectID, dc)
+	}
+
+	limit := s.Limits(ctx, dc.account).MessagesPerDay
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if limit > 0 && dc.count >= limit {
+		return &Exceeded{Kind: MessagesPerDay, Limit: limit, Used: dc.count, ResetAt: today.Add(24 * time.Hour)}
+	}
+	dc.count++
+	return nil
+}
+
+// CheckStorage reports whether the loop may store size more bytes of
+// attachments
+func (s *Store) CheckStorage(ctx context.Context, projectID pgtype.UUID, size int64) error {
+	if s == nil {
+		return nil
+	}
+	usage, err := s.queries.GetLoopStorageUsage(ctx, projectID)
+	if err != nil {
+		log.Printf("[quota] failed to read storage usage: %v", err)
+		return nil
+	}
+	limit := s.Limits(ctx, usage.OwnerID).StorageBytes
+	if limit > 0 && usage.Bytes+size > limit {
+		return &Exceeded{Kind: StorageBytes, Limit: limit, Used: usage.Bytes}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+// Package retention enforces a data-retention cutoff for self-hosted
+// deployments: rows older than the cutoff are either anonymized, keeping
+// them for counts and threads but dropping their content and authors, or
+// purged outright.
+//
+// Work is done in batches of BatchSize rows, each in its own statement, so a
+// large backlog doesn't hold locks for the whole run and an interrupted run
+// can simply be started again. A dry run only counts what would change.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Kind is a group of tables the cutoff can be applied to
+type Kind string
+
+const (
+	Messages      Kind = "messages"      // loop and DM messages, held messages
+	Notifications Kind = "notifications" // in-app notifications
+	Audit         Kind = "audit"         // integration audit and request logs
+)
+
+// Kinds lists every kind, in the order they are processed
+var Kinds = []Kind{Messages, Notifications, Audit}
+
+// Mode is what happens to rows past the cutoff
+type Mode string
+
+const (
+	Anonymize Mode = "anonymize"
+	Purge     Mode = "purge"
+)
+
+// BatchSize is how many rows one statement changes
+const BatchSize = 5000
+
+// Options say what to apply the cutoff to and how
+type Options struct {
+	Cutoff time.Time // rows created before this are affected
+	Mode   Mode
+	Kinds  []Kind // empty means all
+	DryRun bool
+	// Progress, when set, is called after each batch
+	Progress func(table string, done int64)
+}
+
+// Report counts the rows changed (or, in a dry run, that would be) per table
+type Report map[string]int64
+
+func (r Report) String() string {
+	tables := make([]string, 0, len(r))
+	for t := range r {
+		tables = append(tables, t)
+	}
+	slices.Sort(tables)
+	parts := make([]string, len(tables))
+	for i, t := range tables {
+		parts[i] = fmt.Sprintf("%s=%d", t, r[t])
+	}
+	return strings.Join(parts, " ")
+}
+
+// step is the cutoff applied to one table
+type step struct {
+	kind  Kind
+	table string
+	// scrub is the SET clause that anonymizes a row and scrubbed is true of
+	// rows it already ran on; tables without one are purged either way
+	scrub    string
+	scrubbed string
+}
+
+var steps = []step{
+	{Messages, "messages", "content = '', sender_id = NULL", "content = '' AND sender_id IS NULL"},
+	{Messages, "dm_messages", "content = '', sender_id = NULL", "content = '' AND sender_id IS NULL"},
+	{Messages, "filtered_messages", "content = '', detail = NULL", "content = '' AND detail IS NULL"},
+	{Notifications, "notifications", "actor_username = '', content_preview = NULL", "actor_username = '' AND content_preview IS NULL"},
+	{Audit, "integration_audit", "actor_id = NULL, detail = ''", "actor_id IS NULL AND detail = ''"},
+	{Audit, "personal_access_token_requests", "", ""},
+	{Audit, "impersonation_requests", "", ""},
+}
+
+// Run applies opts to the database
+func Run(ctx context.Context, pool *pgxpool.Pool, opts Options) (Report, error) {
+	if opts.Mode != Anonymize && opts.Mode != Purge {
+		return nil, fmt.Errorf("retention: unknown mode %q", opts.Mode)
+	}
+	if opts.Cutoff.IsZero() || opts.Cutoff.After(time.Now()) {
+		return nil, fmt.Errorf("retention: cutoff must be in the past")
+	}
+	for _, k := range opts.Kinds {
+		if !slices.Contains(Kinds, k) {
+			return nil, fmt.Errorf("retention: unknown kind %q", k)
+		}
+	}
+
+	report := Report{}
+	for _, s := range steps {
+		if len(opts.Kinds) > 0 && !slices.Contains(opts.Kinds, s.kind) {
+			continue
+		}
+		n, err := s.apply(ctx, pool, opts)
+		report[s.table] = n
+		if err != nil {
+			return report, fmt.Errorf("retention: %s: %w", s.table, err)
+		}
+	}
+	return report, nil
+}
+
+func (s step) apply(ctx context.Context, pool *pgxpool.Pool, opts Options) (int64, error) {
+	where := "created_at < $1"
+	anonymize := opts.Mode == Anonymize && s.scrub != ""
+	if anonymize {
+		where += " AND NOT (" + s.scrubbed + ")"
+	}
+	if opts.DryRun {
+		var n int64
+		err := pool.QueryRow(ctx, "SELECT COUNT(*) FROM "+s.table+" WHERE "+where, opts.Cutoff).Scan(&n)
+		return n, err
+	}
+
+	batch := fmt.Sprintf("ctid IN (SELECT ctid FROM %s WHERE %s LIMIT %d)", s.table, where, BatchSize)
+	query := "DELETE FROM " + s.table + " WHERE " + batch
+	if anonymize {
+		query = "UPDATE " + s.table + " SET " + s.scrub + " WHERE " + batch
+	}
+	var done int64
+	for {
+		tag, err := pool.Exec(ctx, query, opts.Cutoff)
+		if err != nil {
+			return done, err
+		}
+		done += tag.RowsAffected()
+		if opts.Progress != nil && tag.RowsAffected() > 0 {
+			opts.Progress(s.table, done)
+		}
+		if tag.RowsAffected() < BatchSize {
+			return done, nil
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package scan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Result is the outcome of scanning one file
+type Result struct {
+	Clean     bool
+	Signature string // what was found, empty when clean
+	Engine    string // which scanner produced the result
+}
+
+// Scanner checks uploaded files for malware.
+// An error means the scan couldn't run and should be retried; it is not a verdict.
+type Scanner interface {
+	Scan(ctx context.Context, filename string, data []byte) (Result, error)
+}
+
+// FromEnv picks a scanner from the environment:
+// CLAMAV_ADDR (clamd sidecar, host:port), else SCAN_API_URL (+ SCAN_API_TOKEN),
+// else Noop which passes everything.
+func FromEnv() Scanner {
+	if addr := os.Getenv("CLAMAV_ADDR"); addr != "" {
+		log.Printf("[scan] using clamd at %s", addr)
+		return &ClamAV{Addr: addr, Timeout: 30 * time.Second}
+	}
+	if u := os.Getenv("SCAN_API_URL"); u != "" {
+		log.Printf("[scan] using external scan API")
+		return &HTTP{URL: u, Token: os.Getenv("SCAN_API_TOKEN"), Client: &http.Client{Timeout: 60 * time.Second}}
+	}
+	log.Println("[scan] no scanner configured, attachments are released without scanning")
+	return Noop{}
+}
+
+// Noop marks every file clean
+type Noop struct{}
+
+func (Noop) Scan(context.Context, string, []byte) (Result, error) {
+	return Result{Clean: true, Engine: "none"}, nil
+}
+
+// ClamAV talks to a clamd daemon with the INSTREAM command
+type ClamAV struct {
+	Addr    string
+	Timeout time.Duration
+}
+
+const clamChunkSize = 64 * 1024
+
+func (s *ClamAV) Scan(ctx context.Context, _ string, data []byte) (Result, error) {
+	d := net.Dialer{Timeout: s.Timeout}
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return Result{}, fmt.Errorf("clamd dial: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(s.Timeout))
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return Result{}, fmt.Errorf("clamd write: %w", err)
+	}
+	var size [4]byte
+	for off := 0; off < len(data); off += clamChunkSize {
+		chunk := data[off:min(off+clamChunkSize, len(data))]
+		binary.BigEndian.PutUint32(size[:], uint32(len(chunk)))
+		if _, err := conn.Write(size[:]); err != nil {
+			return Result{}, fmt.Errorf("clamd write: %w", err)
+		}
+		if _, err := conn.Write(chunk); err != nil {
+			return Result{}, fmt.Errorf("clamd write: %w", err)
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := conn.Write(size[:]); err != nil {
+		return Result{}, fmt.Errorf("clamd write: %w", err)
+	}
+
+	// Reply looks like "stream: OK" or "stream: Eicar-Test-Signature FOUND"
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return Result{}, fmt.Errorf("clamd read: %w", err)
+	}
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return Result{Clean: true, Engine: "clamav"}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Result{Signature: strings.TrimSuffix(reply, " FOUND"), Engine: "clamav"}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
+
+// HTTP posts the file to an external scanning API.
+// The API answers 200 with {"clean": bool, "signature": "..."}.
+type HTTP struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func (s *HTTP) Scan(ctx context.Context, filename string, data []byte) (Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(data))
+	if err != nil {
+		return Result{}, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Filename", filename)
+	if s.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.Token)
+	}
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return Result{}, fmt.Errorf("scan api: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, fmt.Errorf("scan api: status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Clean     bool   `json:"clean"`
+		Signature string `json:"signature"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return Result{}, fmt.Errorf("scan api: decode: %w", err)
+	}
+	return Result{Clean: body.Clean, Signature: body.Signature, Engine: "api"}, nil
+}
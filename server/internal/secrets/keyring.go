@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvKeyring holds master keys given in the environment.
+// SECRETS_MASTER_KEY (or _FILE) is a base64 32-byte key that wraps new data
+// keys. After rotating it, list the old key in SECRETS_PREVIOUS_KEYS
+// (comma-separated) until Store.Rewrap has moved every data key over.
+type EnvKeyring struct {
+	current string
+	keys    map[string][]byte // by key ID
+}
+
+// KeyringFromEnv reads the master keys; it returns nil without error when no
+// master key is set, leaving the secrets store off
+func KeyringFromEnv() (*EnvKeyring, error) {
+	raw := os.Getenv("SECRETS_MASTER_KEY")
+	if path := os.Getenv("SECRETS_MASTER_KEY_FILE"); raw == "" && path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("SECRETS_MASTER_KEY_FILE: %w", err)
+		}
+		raw = string(b)
+	}
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	master, err := decodeKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("SECRETS_MASTER_KEY: %w", err)
+	}
+	k := NewEnvKeyring(master)
+	for i, p := range strings.Split(os.Getenv("SECRETS_PREVIOUS_KEYS"), ",") {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		key, err := decodeKey(p)
+		if err != nil {
+			return nil, fmt.Errorf("SECRETS_PREVIOUS_KEYS[%d]: %w", i, err)
+		}
+		k.keys[keyID(key)] = key
+	}
+	return k, nil
+}
+
+// NewEnvKeyring wraps with master, a 32-byte key
+func NewEnvKeyring(master []byte) *EnvKeyring {
+	id := keyID(master)
+	return &EnvKeyring{current: id, keys: map[string][]byte{id: master}}
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, errors.New("not base64")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("want 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// keyID names a master key without giving it away
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return "env:" + hex.EncodeToString(sum[:8])
+}
+
+func (k *EnvKeyring) KeyID() string {
+	return k.current
+}
+
+// Wrap seals dataKey under the current master key; the nonce leads the result
+func (k *EnvKeyring) Wrap(_ context.Context, dataKey []byte) ([]byte, error) {
+	gcm, err := newGCM(k.keys[k.current])
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, dataKey, nil), nil
+}
+
+func (k *EnvKeyring) Unwrap(_ context.Context, id string, wrapped []byte) ([]byte, error) {
+	master, ok := k.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("master key %s is not loaded", id)
+	}
+	gcm, err := newGCM(master)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < gcm.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	nonce, sealed := wrapped[:gcm.NonceSize()], wrapped[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, nil)
+}
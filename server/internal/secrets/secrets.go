@@ -0,0 +1,197 @@
+// Package secrets keeps loop credentials (webhook URLs, signing secrets, bot
+// tokens) encrypted at rest with envelope encryption. Every secret is sealed
+// with its own random data key using AES-256-GCM, and the data key is stored
+// wrapped by a master key that never touches the database. A Keyring holds
+// the master key: EnvKeyring reads it from the environment, and a KMS can sit
+// behind the same interface. Values go in through Create and Rotate and only
+// come out through Reveal, for the server's own use.
+package secrets
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	utils "wireloop/internal"
+	"wireloop/internal/db"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ErrNotFound is returned for a secret the loop doesn't have
+var ErrNotFound = errors.New("secrets: not found")
+
+// Keyring wraps and unwraps data keys with a master key
+type Keyring interface {
+	// KeyID names the master key new data keys are wrapped with
+	KeyID() string
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	// Unwrap opens a data key wrapped by the master key keyID, which may be
+	// a previous one still held for rotation
+	Unwrap(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Store reads and writes a loop's secrets
+type Store struct {
+	queries *db.Queries
+	keys    Keyring
+}
+
+// New creates a store sealing secrets under keys
+func New(queries *db.Queries, keys Keyring) *Store {
+	return &Store{queries: queries, keys: keys}
+}
+
+// Create seals value as the loop's secret called name
+func (s *Store) Create(ctx context.Context, projectID pgtype.UUID, name, value string, createdBy pgtype.UUID) (db.LoopSecret, error) {
+	env, err := s.seal(ctx, projectID, name, value)
+	if err != nil {
+		return db.LoopSecret{}, err
+	}
+	return s.queries.CreateLoopSecret(ctx, db.CreateLoopSecretParams{
+		ProjectID:  projectID,
+		Name:       name,
+		Ciphertext: env.ciphertext,
+		Nonce:      env.nonce,
+		WrappedKey: env.wrappedKey,
+		KeyID:      env.keyID,
+		Hint:       Hint(value),
+		CreatedBy:  createdBy,
+	})
+}
+
+// Rotate replaces the value of sec, under a fresh data key
+func (s *Store) Rotate(ctx context.Context, sec db.LoopSecret, value string) (db.LoopSecret, error) {
+	env, err := s.seal(ctx, sec.ProjectID, sec.Name, value)
+	if err != nil {
+		return db.LoopSecret{}, err
+	}
+	sec, err = s.queries.RotateLoopSecret(ctx, db.RotateLoopSecretParams{
+		ID:         sec.ID,
+		Ciphertext: env.ciphertext,
+		Nonce:      env.nonce,
+		WrappedKey: env.wrappedKey,
+		KeyID:      env.keyID,
+		Hint:       Hint(value),
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return sec, ErrNotFound
+	}
+	return sec, err
+}
+
+// Reveal returns the value of the loop's secret called name. It is for the
+// server's own use; nothing should send the result to a client.
+func (s *Store) Reveal(ctx context.Context, projectID pgtype.UUID, name string) (string, error) {
+	sec, err := s.queries.GetLoopSecretByName(ctx, db.GetLoopSecretByNameParams{ProjectID: projectID, Name: name})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	dataKey, err := s.keys.Unwrap(ctx, sec.KeyID, sec.WrappedKey)
+	if err != nil {
+		return "", fmt.Errorf("secrets: unwrap %s: %w", name, err)
+	}
+	plain, err := open(dataKey, sec.Nonce, sec.Ciphertext, boundTo(projectID, name))
+	if err != nil {
+		return "", fmt.Errorf("secrets: open %s: %w", name, err)
+	}
+	return string(plain), nil
+}
+
+// Rewrap moves every data key still wrapped by an older master key under the
+// current one, for after the master key is rotated. Values are untouched.
+func (s *Store) Rewrap(ctx context.Context) (int, error) {
+	current := s.keys.KeyID()
+	stale, err := s.queries.GetLoopSecretsNotUnderKey(ctx, current)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, sec := range stale {
+		dataKey, err := s.keys.Unwrap(ctx, sec.KeyID, sec.WrappedKey)
+		if err != nil {
+			log.Printf("[secrets] can't unwrap %s (key %s): %v", utils.UUIDToStr(sec.ID), sec.KeyID, err)
+			continue
+		}
+		wrapped, err := s.keys.Wrap(ctx, dataKey)
+		if err != nil {
+			return n, err
+		}
+		if err := s.queries.RewrapLoopSecret(ctx, db.RewrapLoopSecretParams{
+			ID:         sec.ID,
+			WrappedKey: wrapped,
+			KeyID:      current,
+			OldKeyID:   sec.KeyID,
+		}); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Hint is the part of a value safe to show to tell secrets apart: its last
+// four characters, when the value is long enough that they give little away
+func Hint(value string) string {
+	if len(value) < 16 {
+		return ""
+	}
+	return "…" + value[len(value)-4:]
+}
+
+type envelope struct {
+	ciphertext, nonce, wrappedKey []byte
+	keyID                         string
+}
+
+// boundTo is the associated data a value is sealed with, so a ciphertext
+// copied to another loop's or name's row fails to open
+func boundTo(projectID pgtype.UUID, name string) []byte {
+	return append(projectID.Bytes[:], name...)
+}
+
+// seal encrypts value under a new data key and wraps that key
+func (s *Store) seal(ctx context.Context, projectID pgtype.UUID, name, value string) (envelope, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return envelope{}, err
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return envelope{}, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return envelope{}, err
+	}
+	env := envelope{
+		ciphertext: gcm.Seal(nil, nonce, []byte(value), boundTo(projectID, name)),
+		nonce:      nonce,
+		keyID:      s.keys.KeyID(),
+	}
+	env.wrappedKey, err = s.keys.Wrap(ctx, dataKey)
+	return env, err
+}
+
+func open(dataKey, nonce, ciphertext, ad []byte) ([]byte, error) {
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, nonce, ciphertext, ad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
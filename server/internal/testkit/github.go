@@ -0,0 +1,484 @@
+package testkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wireloop/internal/github"
+)
+
+// GitHub is an in-memory GitHub REST API. Seed it with users and repos,
+// point a client at it with Client, and it answers the endpoints the server
+// uses the way GitHub does: 401 for unknown tokens, 404 for private repos
+// the caller can't see, Link pagination and rate limit headers.
+type GitHub struct {
+	Server *httptest.Server
+
+	mu     sync.Mutex
+	tokens map[string]string // token -> login
+	repos  map[string]*Repo  // by lowercased full name
+	nextID int64
+	// Requests left before GitHub answers 403 rate limited; -1 is unlimited
+	remaining int
+	resetAt   time.Time
+	requests  []string
+}
+
+// Repo is a repository of the fake and everything in it
+type Repo struct {
+	github.Repo
+	// Access of each login: "admin", "maintain", "push", "triage" or
+	// "read". Anyone else can read public repos and gets 404 on private ones.
+	Collaborators map[string]string
+	Issues        []github.Issue // pull requests show up here too, as GitHub does
+	Pulls         []github.PullRequest
+	Comments      map[int][]github.Comment // by issue or PR number
+	Commits       map[string]int           // commits per author login
+}
+
+// NewGitHub starts a fake GitHub for the length of t
+func NewGitHub(t testing.TB) *GitHub {
+	t.Helper()
+	g := &GitHub{
+		tokens:    make(map[string]string),
+		repos:     make(map[string]*Repo),
+		nextID:    1000,
+		remaining: -1,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /user", g.handleUser)
+	mux.HandleFunc("GET /repositories/{id}", g.handleRepoByID)
+	mux.HandleFunc("GET /repos/{owner}/{repo}", g.handleRepo)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/issues", g.handleListIssues)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/issues", g.handleCreateIssue)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/issues/{number}", g.handleGetIssue)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/issues/{number}/comments", g.handleListComments)
+	mux.HandleFunc("POST /repos/{owner}/{repo}/issues/{number}/comments", g.handleCreateComment)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls", g.handleListPulls)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/pulls/{number}", g.handleGetPull)
+	mux.HandleFunc("GET /repos/{owner}/{repo}/commits", g.handleListCommits)
+	g.Server = httptest.NewServer(g.limit(mux))
+	t.Cleanup(g.Server.Close)
+	return g
+}
+
+// Client returns a GitHub client that talks to the fake
+func (g *GitHub) Client() *github.Client {
+	return github.NewAt(g.Server.URL)
+}
+
+// AddUser makes token authenticate as login
+func (g *GitHub) AddUser(login, token string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tokens[token] = login
+}
+
+// AddRepo creates owner/name and returns it for seeding. Its ID is what
+// loops store as github_repo_id.
+func (g *GitHub) AddRepo(owner, name string, private bool) *Repo {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nextID++
+	r := &Repo{
+		Collaborators: map[string]string{owner: "admin"},
+		Comments:      make(map[int][]github.Comment),
+		Commits:       make(map[string]int),
+	}
+	r.ID = g.nextID
+	r.Name = name
+	r.FullName = owner + "/" + name
+	r.Private = private
+	r.HTMLURL = "https://github.com/" + r.FullName
+	r.DefaultBranch = "main"
+	r.Owner.Login = owner
+	g.repos[strings.ToLower(r.FullName)] = r
+	return r
+}
+
+// AddIssue opens an issue by author on r and returns its number
+func (r *Repo) AddIssue(author, title, state string) int {
+	n := r.nextNumber()
+	r.Issues = append(r.Issues, github.Issue{
+		Number:    n,
+		Title:     title,
+		State:     state,
+		User:      github.User{Login: author},
+		CreatedAt: "2024-01-01T00:00:00Z",
+		UpdatedAt: "2024-01-01T00:00:00Z",
+		HTMLURL:   fmt.Sprintf("%s/issues/%d", r.HTMLURL, n),
+	})
+	return n
+}
+
+// AddPull opens a pull request by author on r and returns its number.
+// Merged pulls are closed with a merge time.
+func (r *Repo) AddPull(author, title string, merged bool) int {
+	n := r.nextNumber()
+	pr := github.PullRequest{
+		Number:    n,
+		Title:     title,
+		State:     "open",
+		User:      github.User{Login: author},
+		CreatedAt: "2024-01-01T00:00:00Z",
+		UpdatedAt: "2024-01-01T00:00:00Z",
+		HTMLURL:   fmt.Sprintf("%s/pull/%d", r.HTMLURL, n),
+	}
+	if merged {
+		at := "2024-01-02T00:00:00Z"
+		pr.State, pr.MergedAt = "closed", &at
+	}
+	r.Pulls = append(r.Pulls, pr)
+	issue := github.Issue{Number: n, Title: title, State: pr.State, User: pr.User, CreatedAt: pr.CreatedAt, UpdatedAt: pr.UpdatedAt, HTMLURL: pr.HTMLURL}
+	issue.PullRequest = &struct {
+		URL string `json:"url"`
+	}{URL: pr.HTMLURL}
+	r.Issues = append(r.Issues, issue)
+	return n
+}
+
+func (r *Repo) nextNumber() int {
+	return len(r.Issues) + 1
+}
+
+// SetRateLimit lets the next n requests through and answers the rest as
+// rate limited until reset
+func (g *GitHub) SetRateLimit(n int, reset time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.remaining, g.resetAt = n, reset
+}
+
+// Comments returns the comments on issue or PR number of r as they stand
+func (g *GitHub) Comments(r *Repo, number int) []github.Comment {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]github.Comment(nil), r.Comments[number]...)
+}
+
+// Requests returns the "METHOD /path" of every request so far
+func (g *GitHub) Requests() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string(nil), g.requests...)
+}
+
+// limit records requests, checks the token and applies the rate limit
+func (g *GitHub) limit(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.mu.Lock()
+		g.requests = append(g.requests, r.Method+" "+r.URL.Path)
+		_, known := g.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		limited := g.remaining == 0
+		if g.remaining > 0 {
+			g.remaining--
+		}
+		remaining, reset := g.remaining, g.resetAt
+		g.mu.Unlock()
+
+		if !known {
+			writeError(w, http.StatusUnauthorized, "Bad credentials")
+			return
+		}
+		w.Header().Set("X-RateLimit-Limit", "5000")
+		if remaining < 0 {
+			w.Header().Set("X-RateLimit-Remaining", "5000")
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		if limited {
+			writeError(w, http.StatusForbidden, "API rate limit exceeded")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (g *GitHub) login(r *http.Request) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+}
+
+// visibleRepo finds the request's repo, writing the 404 GitHub gives when
+// it doesn't exist or the caller can't see it
+func (g *GitHub) visibleRepo(w http.ResponseWriter, r *http.Request) (*Repo, string, bool) {
+	login := g.login(r)
+	g.mu.Lock()
+	repo := g.repos[strings.ToLower(r.PathValue("owner")+"/"+r.PathValue("repo"))]
+	g.mu.Unlock()
+	if repo == nil || (repo.Private && repo.Collaborators[login] == "") {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return nil, login, false
+	}
+	return repo, login, true
+}
+
+// withPermissions is r as login sees it
+func (r *Repo) withPermissions(login string) github.Repo {
+	out := r.Repo
+	access := r.Collaborators[login]
+	out.Permissions = &github.RepoPermissions{
+		Admin:    access == "admin",
+		Maintain: access == "admin" || access == "maintain",
+		Push:     access == "admin" || access == "maintain" || access == "push",
+		Triage:   access != "" && access != "read",
+		Pull:     true,
+	}
+	return out
+}
+
+func (g *GitHub) handleUser(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, github.User{Login: g.login(r)})
+}
+
+func (g *GitHub) handleRepoByID(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	login := g.login(r)
+	g.mu.Lock()
+	var repo *Repo
+	for _, candidate := range g.repos {
+		if candidate.ID == id {
+			repo = candidate
+		}
+	}
+	g.mu.Unlock()
+	if repo == nil || (repo.Private && repo.Collaborators[login] == "") {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, repo.withPermissions(login))
+}
+
+func (g *GitHub) handleRepo(w http.ResponseWriter, r *http.Request) {
+	repo, login, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, repo.withPermissions(login))
+}
+
+func (g *GitHub) handleListIssues(w http.ResponseWriter, r *http.Request) {
+	repo, _, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	var out []github.Issue
+	g.mu.Lock()
+	for _, issue := range repo.Issues {
+		if matchesState(issue.State, q.Get("state")) &&
+			(q.Get("creator") == "" || strings.EqualFold(issue.User.Login, q.Get("creator"))) {
+			out = append(out, issue)
+		}
+	}
+	g.mu.Unlock()
+	writePage(w, r, out)
+}
+
+func (g *GitHub) handleGetIssue(w http.ResponseWriter, r *http.Request) {
+	repo, _, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	n, _ := strconv.Atoi(r.PathValue("number"))
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, issue := range repo.Issues {
+		if issue.Number == n {
+			issue.Comments = len(repo.Comments[n])
+			writeJSON(w, http.StatusOK, issue)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (g *GitHub) handleCreateIssue(w http.ResponseWriter, r *http.Request) {
+	repo, login, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	if !repo.withPermissions(login).Permissions.Triage {
+		writeError(w, http.StatusForbidden, "Resource not accessible by integration")
+		return
+	}
+	var body struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Title == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	g.mu.Lock()
+	repo.AddIssue(login, body.Title, "open")
+	issue := &repo.Issues[len(repo.Issues)-1]
+	issue.Body = body.Body
+	created := *issue
+	g.mu.Unlock()
+	writeJSON(w, http.StatusCreated, created)
+}
+
+func (g *GitHub) handleListComments(w http.ResponseWriter, r *http.Request) {
+	repo, _, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	n, _ := strconv.Atoi(r.PathValue("number"))
+	g.mu.Lock()
+	comments := append([]github.Comment{}, repo.Comments[n]...)
+	g.mu.Unlock()
+	writePage(w, r, comments)
+}
+
+func (g *GitHub) handleCreateComment(w http.ResponseWriter, r *http.Request) {
+	repo, login, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	n, _ := strconv.Atoi(r.PathValue("number"))
+	var body struct {
+		Body string `json:"body"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Body == "" {
+		writeError(w, http.StatusUnprocessableEntity, "Validation Failed")
+		return
+	}
+	g.mu.Lock()
+	if n < 1 || n > len(repo.Issues) {
+		g.mu.Unlock()
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	g.nextID++
+	now := time.Now().UTC().Format(time.RFC3339)
+	comment := github.Comment{
+		ID:        g.nextID,
+		Body:      body.Body,
+		User:      github.User{Login: login},
+		CreatedAt: now,
+		UpdatedAt: now,
+		HTMLURL:   fmt.Sprintf("%s/issues/%d#issuecomment-%d", repo.HTMLURL, n, g.nextID),
+	}
+	repo.Comments[n] = append(repo.Comments[n], comment)
+	g.mu.Unlock()
+	writeJSON(w, http.StatusCreated, comment)
+}
+
+func (g *GitHub) handleListPulls(w http.ResponseWriter, r *http.Request) {
+	repo, _, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	var out []github.PullRequest
+	g.mu.Lock()
+	for _, pr := range repo.Pulls {
+		if matchesState(pr.State, r.URL.Query().Get("state")) {
+			out = append(out, pr)
+		}
+	}
+	g.mu.Unlock()
+	writePage(w, r, out)
+}
+
+func (g *GitHub) handleGetPull(w http.ResponseWriter, r *http.Request) {
+	repo, _, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	n, _ := strconv.Atoi(r.PathValue("number"))
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, pr := range repo.Pulls {
+		if pr.Number == n {
+			writeJSON(w, http.StatusOK, pr)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "Not Found")
+}
+
+func (g *GitHub) handleListCommits(w http.ResponseWriter, r *http.Request) {
+	repo, _, ok := g.visibleRepo(w, r)
+	if !ok {
+		return
+	}
+	g.mu.Lock()
+	var authors []string
+	for login := range repo.Commits {
+		if a := r.URL.Query().Get("author"); a == "" || strings.EqualFold(a, login) {
+			authors = append(authors, login)
+		}
+	}
+	sort.Strings(authors)
+	var out []map[string]any
+	for _, login := range authors {
+		for i := 0; i < repo.Commits[login]; i++ {
+			out = append(out, map[string]any{"sha": fmt.Sprintf("%s%d", login, i), "author": github.User{Login: login}})
+		}
+	}
+	g.mu.Unlock()
+	writePage(w, r, out)
+}
+
+// matchesState applies GitHub's state filter, which defaults to open
+func matchesState(state, filter string) bool {
+	switch filter {
+	case "all":
+		return true
+	case "":
+		filter = "open"
+	}
+	return state == filter
+}
+
+// writePage writes the page and per_page slice of items (GitHub defaults:
+// page 1, 30 per page) with a Link header to the next and last pages
+func writePage[T any](w http.ResponseWriter, r *http.Request, items []T) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 30
+	}
+	last := (len(items) + perPage - 1) / perPage
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	link := func(p int, rel string) string {
+		u := url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path}
+		v := r.URL.Query()
+		v.Set("page", strconv.Itoa(p))
+		u.RawQuery = v.Encode()
+		return fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel)
+	}
+	if page < last {
+		w.Header().Set("Link", link(page+1, "next")+", "+link(last, "last"))
+	}
+	writeJSON(w, http.StatusOK, append([]T{}, items[start:end]...))
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
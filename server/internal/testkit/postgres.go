@@ -0,0 +1,173 @@
+// Package testkit holds what the integration tests share: a migrated
+// Postgres database per test and a fake GitHub API.
+package testkit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"wireloop/internal/db"
+	"wireloop/internal/migrate"
+	"wireloop/migrations"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// DatabaseEnv names the Postgres URL the database tests run against. The
+// role needs CREATEDB: each test gets its own database.
+const DatabaseEnv = "WIRELOOP_TEST_DATABASE_URL"
+
+// Held while the template database is built, so test binaries running in
+// parallel build it once
+const templateLock = 0x77697265
+
+var (
+	templateOnce sync.Once
+	templateName string
+	templateErr  error
+
+	databaseSeq atomic.Int64
+)
+
+// DB returns a freshly migrated database for t, dropped when t ends, and
+// skips t when DatabaseEnv isn't set. Databases are copied from a template
+// migrated once per set of migrations, so a test pays for a copy, not for
+// every migration.
+func DB(t testing.TB) (*db.Queries, *pgxpool.Pool) {
+	t.Helper()
+	base := os.Getenv(DatabaseEnv)
+	if base == "" {
+		t.Skipf("%s not set", DatabaseEnv)
+	}
+	ctx := context.Background()
+	templateOnce.Do(func() {
+		templateName, templateErr = migrateTemplate(ctx, base)
+	})
+	if templateErr != nil {
+		t.Fatalf("test database template: %v", templateErr)
+	}
+
+	name := fmt.Sprintf("wireloop_test_%d_%d", os.Getpid(), databaseSeq.Add(1))
+	if err := adminExec(ctx, base, "CREATE DATABASE "+ident(name)+" TEMPLATE "+ident(templateName)); err != nil {
+		t.Fatalf("create test database: %v", err)
+	}
+	cfg, err := configFor(base, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		pool.Close()
+		if err := adminExec(context.Background(), base, "DROP DATABASE IF EXISTS "+ident(name)+" WITH (FORCE)"); err != nil {
+			t.Logf("drop test database %s: %v", name, err)
+		}
+	})
+	return db.New(pool), pool
+}
+
+// migrateTemplate makes sure a database migrated to the current migrations
+// exists and returns its name. It is built under another name and renamed
+// when done, so a failed run never leaves a half-migrated template behind.
+func migrateTemplate(ctx context.Context, base string) (string, error) {
+	digest, err := migrationsDigest()
+	if err != nil {
+		return "", err
+	}
+	name := "wireloop_test_template_" + digest
+	building := name + "_building"
+
+	admin, err := pgx.Connect(ctx, base)
+	if err != nil {
+		return "", err
+	}
+	defer admin.Close(ctx)
+	if _, err := admin.Exec(ctx, "SELECT pg_advisory_lock($1)", templateLock); err != nil {
+		return "", err
+	}
+	defer admin.Exec(ctx, "SELECT pg_advisory_unlock($1)", templateLock)
+
+	var exists bool
+	if err := admin.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", name).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists {
+		return name, nil
+	}
+	if _, err := admin.Exec(ctx, "DROP DATABASE IF EXISTS "+ident(building)+" WITH (FORCE)"); err != nil {
+		return "", err
+	}
+	if _, err := admin.Exec(ctx, "CREATE DATABASE "+ident(building)); err != nil {
+		return "", err
+	}
+
+	cfg, err := configFor(base, building)
+	if err != nil {
+		return "", err
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return "", err
+	}
+	_, err = migrate.Up(ctx, pool, migrations.FS)
+	pool.Close()
+	if err != nil {
+		return "", fmt.Errorf("migrate: %w", err)
+	}
+	if _, err := admin.Exec(ctx, "ALTER DATABASE "+ident(building)+" RENAME TO "+ident(name)); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// migrationsDigest identifies the current set of migrations, so editing one
+// builds a new template
+func migrationsDigest() (string, error) {
+	files, err := fs.Glob(migrations.FS, "*.sql")
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, f := range files {
+		data, err := fs.ReadFile(migrations.FS, f)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", f, len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+func configFor(base, database string) (*pgxpool.Config, error) {
+	cfg, err := pgxpool.ParseConfig(base)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConnConfig.Database = database
+	return cfg, nil
+}
+
+func adminExec(ctx context.Context, base, sql string) error {
+	conn, err := pgx.Connect(ctx, base)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, sql)
+	return err
+}
+
+func ident(name string) string {
+	return pgx.Identifier{name}.Sanitize()
+}
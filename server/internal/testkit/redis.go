@@ -0,0 +1,34 @@
+package testkit
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisEnv names the Redis URL the tests that span server instances run
+// against
+const RedisEnv = "WIRELOOP_TEST_REDIS_URL"
+
+// Redis returns a client for RedisEnv, closed when t ends, and skips t when
+// RedisEnv isn't set. Each call connects anew, as another server instance
+// would.
+func Redis(t testing.TB) *redis.Client {
+	t.Helper()
+	url := os.Getenv(RedisEnv)
+	if url == "" {
+		t.Skipf("%s not set", RedisEnv)
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatalf("%s: %v", RedisEnv, err)
+	}
+	rdb := redis.NewClient(opts)
+	if err := rdb.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("test redis: %v", err)
+	}
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
@@ -1,6 +1,6 @@
 package types
 
 type Rule struct {
-	CriteriaType string `json:"criteria_type"`
-	Threshold    int    `json:"threshold"`
+	CriteriaType string `json:"criteria_type" binding:"required,criteria"`
+	Threshold    int    `json:"threshold" binding:"min=0"`
 }
@@ -3,6 +3,7 @@ package utils
 import (
 	"encoding/hex"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -47,3 +48,21 @@ func GetMessageId() int64 {
 func FormatMessageID(id int64) string {
 	return strconv.FormatInt(id, 10)
 }
+
+// FormatTime is how responses carry a point in time: RFC3339 in UTC, or ""
+// for the zero time
+func FormatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// EpochMillis is t as milliseconds since the Unix epoch, 0 for the zero time.
+// Responses pair it with the FormatTime field so clients needn't parse dates.
+func EpochMillis(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,12 @@
+-- +goose Up
+-- Indexes backing the grouped aggregates (dashboard, observability)
+
+-- Member counts grouped by project (memberships PK leads with user_id)
+CREATE INDEX IF NOT EXISTS idx_memberships_project_id ON memberships (project_id);
+
+-- Message counts grouped by sender (observability users)
+CREATE INDEX IF NOT EXISTS idx_messages_sender_id ON messages (sender_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_sender_id;
+DROP INDEX IF EXISTS idx_memberships_project_id;
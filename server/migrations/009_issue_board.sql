@@ -0,0 +1,40 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Issue board (kanban columns + cards per loop)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS board_columns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Cards are either local tasks or references to a GitHub issue
+CREATE TABLE IF NOT EXISTS board_cards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    column_id UUID NOT NULL REFERENCES board_columns(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    body TEXT,
+    github_issue_number INTEGER,             -- NULL for local cards
+    github_state TEXT,                       -- 'open' / 'closed', synced from GitHub
+    position INTEGER NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(project_id, github_issue_number)  -- One card per issue per loop
+);
+
+CREATE INDEX IF NOT EXISTS idx_board_columns_project
+ON board_columns (project_id, position);
+
+CREATE INDEX IF NOT EXISTS idx_board_cards_column
+ON board_cards (column_id, position);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_board_cards_column;
+DROP INDEX IF EXISTS idx_board_columns_project;
+DROP TABLE IF EXISTS board_cards;
+DROP TABLE IF EXISTS board_columns;
@@ -0,0 +1,26 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Tasks (chat messages turned into assignable to-dos)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS tasks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    message_id BIGINT REFERENCES messages(id) ON DELETE SET NULL,  -- Source message
+    title TEXT NOT NULL,
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    due_at TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'open',                            -- 'open' / 'done'
+    github_issue_number INTEGER,                                    -- Set once escalated
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tasks_channel_open
+ON tasks (channel_id, due_at) WHERE status = 'open';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_tasks_channel_open;
+DROP TABLE IF EXISTS tasks;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- ============================================================================
+-- Infrastructure: Postgres-backed job queue (reminders, scheduled work)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status TEXT NOT NULL DEFAULT 'queued',   -- 'queued' / 'running' / 'done' / 'failed'
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Workers only ever scan queued jobs by due time
+CREATE INDEX IF NOT EXISTS idx_jobs_due
+ON jobs (run_at) WHERE status = 'queued';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_jobs_due;
+DROP TABLE IF EXISTS jobs;
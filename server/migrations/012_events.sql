@@ -0,0 +1,53 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Loop events (standups, release cutoffs, community calls) + activity
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    kind TEXT NOT NULL DEFAULT 'other',       -- 'standup' / 'release' / 'call' / 'other'
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    recurrence TEXT NOT NULL DEFAULT 'none',  -- 'none' / 'daily' / 'weekly' / 'monthly'
+    recurrence_until TIMESTAMPTZ,             -- NULL = repeats forever
+    remind_minutes INTEGER NOT NULL DEFAULT 15,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- RSVPs apply to the whole series for recurring events
+CREATE TABLE IF NOT EXISTS event_rsvps (
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,                     -- 'going' / 'maybe' / 'declined'
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (event_id, user_id)
+);
+
+-- Loop activity feed: one row per noteworthy thing that happened in a loop
+CREATE TABLE IF NOT EXISTS loop_activity (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL,                       -- e.g. 'event_created'
+    ref_id TEXT,                              -- ID of the referenced object
+    summary TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_project_start
+ON events (project_id, starts_at);
+
+CREATE INDEX IF NOT EXISTS idx_loop_activity_project_time
+ON loop_activity (project_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_loop_activity_project_time;
+DROP INDEX IF EXISTS idx_events_project_start;
+DROP TABLE IF EXISTS loop_activity;
+DROP TABLE IF EXISTS event_rsvps;
+DROP TABLE IF EXISTS events;
@@ -0,0 +1,41 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Direct messages (1:1 conversations + per-user bot conversation)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS dm_conversations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dm_key TEXT UNIQUE,                       -- 'userA:userB' (sorted) or 'bot:user'
+    is_bot BOOLEAN NOT NULL DEFAULT FALSE,    -- Wireloop bot talking to one user
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_message_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS dm_participants (
+    conversation_id UUID NOT NULL REFERENCES dm_conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_read_at TIMESTAMPTZ,
+    joined_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS dm_messages (
+    id BIGINT PRIMARY KEY,                    -- Snowflake ID, like messages
+    conversation_id UUID NOT NULL REFERENCES dm_conversations(id) ON DELETE CASCADE,
+    sender_id UUID REFERENCES users(id) ON DELETE SET NULL,  -- NULL = Wireloop bot
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_dm_participants_user
+ON dm_participants (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_dm_messages_conversation_time
+ON dm_messages (conversation_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_dm_messages_conversation_time;
+DROP INDEX IF EXISTS idx_dm_participants_user;
+DROP TABLE IF EXISTS dm_messages;
+DROP TABLE IF EXISTS dm_participants;
+DROP TABLE IF EXISTS dm_conversations;
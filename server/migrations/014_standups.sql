@@ -0,0 +1,58 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Standup bot (scheduled DM prompts, compiled channel summary)
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS standups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,  -- Summary target
+    name TEXT NOT NULL,
+    questions TEXT[] NOT NULL,
+    prompt_time TEXT NOT NULL,                -- 'HH:MM' in timezone
+    timezone TEXT NOT NULL DEFAULT 'UTC',     -- IANA name
+    weekdays INTEGER NOT NULL DEFAULT 62,     -- Bitmask, bit 0 = Sunday (62 = Mon-Fri)
+    collect_minutes INTEGER NOT NULL DEFAULT 120,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS standup_participants (
+    standup_id UUID NOT NULL REFERENCES standups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (standup_id, user_id)
+);
+
+-- One run per prompt; responses are collected until closes_at
+CREATE TABLE IF NOT EXISTS standup_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    standup_id UUID NOT NULL REFERENCES standups(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'collecting',  -- 'collecting' / 'posted'
+    started_at TIMESTAMPTZ DEFAULT NOW(),
+    closes_at TIMESTAMPTZ NOT NULL,
+    posted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS standup_responses (
+    run_id UUID NOT NULL REFERENCES standup_runs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    answers TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (run_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_standups_project
+ON standups (project_id);
+
+CREATE INDEX IF NOT EXISTS idx_standup_runs_open
+ON standup_runs (standup_id, started_at DESC) WHERE status = 'collecting';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_standup_runs_open;
+DROP INDEX IF EXISTS idx_standups_project;
+DROP TABLE IF EXISTS standup_responses;
+DROP TABLE IF EXISTS standup_runs;
+DROP TABLE IF EXISTS standup_participants;
+DROP TABLE IF EXISTS standups;
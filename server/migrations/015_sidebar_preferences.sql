@@ -0,0 +1,16 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Per-user sidebar layout (favorites, manual order, collapse state)
+-- Stored on the membership row since it only exists while the user is a member.
+-- ============================================================================
+
+ALTER TABLE memberships
+ADD COLUMN IF NOT EXISTS is_favorite BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS sort_order INTEGER,                        -- NULL = not manually placed
+ADD COLUMN IF NOT EXISTS sidebar_collapsed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE memberships
+DROP COLUMN IF EXISTS sidebar_collapsed,
+DROP COLUMN IF EXISTS sort_order,
+DROP COLUMN IF EXISTS is_favorite;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Mention inbox
+-- Notifications can be cleared; mentions are kept so the inbox stays complete.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS mentions (
+    id BIGINT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,      -- who was mentioned
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (message_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_user_time
+ON mentions (user_id, id DESC);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_user_unread
+ON mentions (user_id, id DESC) WHERE is_read = FALSE;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_mentions_user_unread;
+DROP INDEX IF EXISTS idx_mentions_user_time;
+DROP TABLE IF EXISTS mentions;
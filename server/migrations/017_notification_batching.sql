@@ -0,0 +1,17 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Notification batching
+-- Bursts of mentions from one sender in one channel collapse into a single
+-- notification; the individual mentions point back at it for expansion.
+-- ============================================================================
+
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS batch_count INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE mentions ADD COLUMN IF NOT EXISTS notification_id BIGINT REFERENCES notifications(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_mentions_notification
+ON mentions (notification_id) WHERE notification_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_mentions_notification;
+ALTER TABLE mentions DROP COLUMN IF EXISTS notification_id;
+ALTER TABLE notifications DROP COLUMN IF EXISTS batch_count;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Per-user muted keywords
+-- Messages containing a muted word don't notify and are flagged in history.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS user_muted_words (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    word TEXT NOT NULL,                 -- stored lowercased
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_id, word)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS user_muted_words;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: DM privacy + message requests
+-- dm_privacy: 'anyone' | 'shared_loops' | 'nobody'
+-- A conversation opened by someone who shares no loop with the recipient starts
+-- as 'pending' and only shows up in the recipient's requests until accepted.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    dm_privacy TEXT NOT NULL DEFAULT 'anyone',
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE dm_conversations
+ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active',         -- 'active' | 'pending'
+ADD COLUMN IF NOT EXISTS requested_by UUID REFERENCES users(id) ON DELETE CASCADE;
+
+-- +goose Down
+ALTER TABLE dm_conversations
+DROP COLUMN IF EXISTS requested_by,
+DROP COLUMN IF EXISTS status;
+DROP TABLE IF EXISTS user_settings;
@@ -0,0 +1,17 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Group direct messages
+-- Group conversations have no dm_key (any number can exist for the same people)
+-- and are capped in size by the API.
+-- ============================================================================
+
+ALTER TABLE dm_conversations
+ADD COLUMN IF NOT EXISTS is_group BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS name TEXT,
+ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE dm_conversations
+DROP COLUMN IF EXISTS created_by,
+DROP COLUMN IF EXISTS name,
+DROP COLUMN IF EXISTS is_group;
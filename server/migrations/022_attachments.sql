@@ -0,0 +1,29 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Attachments with malware scanning
+-- Blobs live in the storage backend under storage_key. New uploads are
+-- quarantined (scan_status 'pending') until a scanner marks them 'clean'.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    uploader_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL,
+    scan_status TEXT NOT NULL DEFAULT 'pending',   -- 'pending' | 'clean' | 'infected'
+    scan_engine TEXT,
+    scan_signature TEXT,
+    scanned_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_channel_time
+ON attachments (channel_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_attachments_channel_time;
+DROP TABLE IF EXISTS attachments;
@@ -0,0 +1,15 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: GitHub profile re-sync
+-- github_synced_at records the last refresh of username/avatar/name from
+-- GitHub; the background refresher picks the stalest rows first.
+-- ============================================================================
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS github_synced_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_github_synced_at
+ON users (github_synced_at NULLS FIRST);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_github_synced_at;
+ALTER TABLE users DROP COLUMN IF EXISTS github_synced_at;
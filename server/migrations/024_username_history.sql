@@ -0,0 +1,22 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Username changes with old-handle redirects
+-- username_history maps retired handles to their owner so profile links and
+-- @mentions keep resolving. A handle leaves the table once someone claims it.
+-- ============================================================================
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username_changed_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS username_history (
+    old_username TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    changed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_username_history_user
+ON username_history (user_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_username_history_user;
+DROP TABLE IF EXISTS username_history;
+ALTER TABLE users DROP COLUMN IF EXISTS username_changed_at;
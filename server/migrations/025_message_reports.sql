@@ -0,0 +1,39 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Content reporting + moderation queue
+-- Members report messages; loop owners and moderators resolve reports by
+-- dismissing them, deleting the message or banning its author.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS message_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,                     -- 'spam' | 'harassment' | 'inappropriate' | 'other'
+    details TEXT,
+    status TEXT NOT NULL DEFAULT 'open',      -- 'open' | 'dismissed' | 'actioned'
+    resolution TEXT,                          -- 'dismissed' | 'message_deleted' | 'author_banned'
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (message_id, reporter_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_reports_queue
+ON message_reports (project_id, status, created_at);
+
+-- Banned users are removed from the loop and can't rejoin
+CREATE TABLE IF NOT EXISTS loop_bans (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    banned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_bans;
+DROP INDEX IF EXISTS idx_message_reports_queue;
+DROP TABLE IF EXISTS message_reports;
@@ -0,0 +1,43 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Profanity + spam filtering
+-- loop_filter_settings holds each loop's filter configuration (loops without
+-- a row use the defaults). filtered_messages records messages caught by a
+-- filter: 'hold' rows are not in messages until a moderator approves them,
+-- 'flag' rows point at a message that was posted normally.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_filter_settings (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    banned_words TEXT[] NOT NULL DEFAULT '{}',
+    banned_word_action TEXT NOT NULL DEFAULT 'block',   -- 'block' | 'hold' | 'flag' | 'off'
+    max_links INTEGER NOT NULL DEFAULT 5,               -- 0 disables the link check
+    link_spam_action TEXT NOT NULL DEFAULT 'hold',
+    repeat_limit INTEGER NOT NULL DEFAULT 3,            -- identical messages per minute; 0 disables
+    repeat_action TEXT NOT NULL DEFAULT 'block',
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS filtered_messages (
+    id BIGINT PRIMARY KEY,                    -- the message's snowflake ID
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id BIGINT,
+    content TEXT NOT NULL,
+    rule TEXT NOT NULL,                       -- 'banned_word' | 'link_spam' | 'repeat'
+    action TEXT NOT NULL,                     -- 'hold' | 'flag'
+    detail TEXT,
+    status TEXT NOT NULL DEFAULT 'pending',   -- 'pending' | 'approved' | 'rejected'
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_filtered_messages_queue
+ON filtered_messages (project_id, status, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_filtered_messages_queue;
+DROP TABLE IF EXISTS filtered_messages;
+DROP TABLE IF EXISTS loop_filter_settings;
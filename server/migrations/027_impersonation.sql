@@ -0,0 +1,37 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Admin support-mode impersonation
+-- A platform admin can mint a short-lived, read-only token for a user.
+-- Each session and every request made with it is recorded, and the user can
+-- list both afterwards.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    admin TEXT NOT NULL,                      -- admin login that started the session
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_user
+ON impersonation_sessions (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS impersonation_requests (
+    id BIGSERIAL PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES impersonation_sessions(id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_requests_session
+ON impersonation_requests (session_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_impersonation_requests_session;
+DROP TABLE IF EXISTS impersonation_requests;
+DROP INDEX IF EXISTS idx_impersonation_sessions_user;
+DROP TABLE IF EXISTS impersonation_sessions;
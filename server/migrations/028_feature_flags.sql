@@ -0,0 +1,20 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Feature flags
+-- A row overrides the flag's built-in default. enabled is the master switch;
+-- allowlisted users/loops always get the feature, everyone else is bucketed
+-- by rollout_percent.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key TEXT PRIMARY KEY,
+    description TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    rollout_percent INTEGER NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+    allow_users UUID[] NOT NULL DEFAULT '{}',
+    allow_loops UUID[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS feature_flags;
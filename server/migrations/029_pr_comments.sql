@@ -0,0 +1,40 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Local PR comment store
+-- Review comments, issue comments and reviews fetched from GitHub, keyed by
+-- repo/PR/comment so views are served from the DB. github_updated_at guards
+-- against an older fetch overwriting a newer one.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS pr_comments (
+    repo_id BIGINT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    comment_type TEXT NOT NULL, -- review_comment | issue_comment | review
+    comment_id BIGINT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    path TEXT,
+    line INTEGER,
+    diff_hunk TEXT,
+    in_reply_to_id BIGINT,
+    state TEXT,
+    username TEXT NOT NULL,
+    avatar_url TEXT,
+    html_url TEXT NOT NULL DEFAULT '',
+    github_created_at TIMESTAMPTZ NOT NULL,
+    github_updated_at TIMESTAMPTZ NOT NULL,
+    synced_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (repo_id, pr_number, comment_type, comment_id)
+);
+
+-- One row per PR that has been fetched at least once
+CREATE TABLE IF NOT EXISTS pr_comment_syncs (
+    repo_id BIGINT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    synced_at TIMESTAMPTZ,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (repo_id, pr_number)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS pr_comment_syncs;
+DROP TABLE IF EXISTS pr_comments;
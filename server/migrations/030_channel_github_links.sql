@@ -0,0 +1,34 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Channels bound to a GitHub issue or PR
+-- A bound channel receives the item's comments, can cross-post its own
+-- messages back, and is archived when the item is closed or merged.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS channel_github_links (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    github_number INTEGER NOT NULL,
+    kind TEXT NOT NULL, -- issue | pr
+    cross_post BOOLEAN NOT NULL DEFAULT FALSE,
+    linked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    archived_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_github_links_number
+ON channel_github_links(project_id, github_number);
+
+-- Which GitHub comment a message was cross-posted as (or mirrored from),
+-- so webhook deliveries of our own comments aren't echoed back
+CREATE TABLE IF NOT EXISTS message_github_comments (
+    message_id BIGINT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    comment_id BIGINT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_github_comments_comment
+ON message_github_comments(comment_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_github_comments;
+DROP TABLE IF EXISTS channel_github_links;
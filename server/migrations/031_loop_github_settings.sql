@@ -0,0 +1,14 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Per-loop GitHub integration settings
+-- Where automated GitHub posts (release announcements, ...) go.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_github_settings (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    announcements_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_github_settings;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Deployment notifications
+-- Deploys to production_environment are announced in deploy_channel_id.
+-- ============================================================================
+
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS deploy_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL;
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS production_environment TEXT NOT NULL DEFAULT 'production';
+
+-- +goose Down
+ALTER TABLE loop_github_settings DROP COLUMN IF EXISTS production_environment;
+ALTER TABLE loop_github_settings DROP COLUMN IF EXISTS deploy_channel_id;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Workflow dispatch from chat
+-- Runs started from a channel, so the workflow_run webhook can report back.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS workflow_dispatches (
+    run_id BIGINT PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workflow TEXT NOT NULL,
+    ref TEXT NOT NULL,
+    html_url TEXT NOT NULL,
+    conclusion TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS workflow_dispatches;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: First-time contributor welcome
+-- Welcome posts for gatekeeper joins, congrats for a first merged PR, and
+-- the badges members earn along the way.
+-- ============================================================================
+
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS welcome_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL;
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS welcome_template TEXT NOT NULL DEFAULT '';
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS first_pr_template TEXT NOT NULL DEFAULT '';
+
+-- Members already welcomed, so leaving and rejoining doesn't repeat it
+CREATE TABLE IF NOT EXISTS loop_welcomes (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    welcomed_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS member_badges (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    badge TEXT NOT NULL,
+    awarded_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id, badge)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS member_badges;
+DROP TABLE IF EXISTS loop_welcomes;
+ALTER TABLE loop_github_settings DROP COLUMN IF EXISTS first_pr_template;
+ALTER TABLE loop_github_settings DROP COLUMN IF EXISTS welcome_template;
+ALTER TABLE loop_github_settings DROP COLUMN IF EXISTS welcome_channel_id;
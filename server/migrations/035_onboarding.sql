@@ -0,0 +1,31 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Member onboarding checklist
+-- Owner-defined steps per loop and each member's progress through them.
+-- kind: manual (member ticks it off), post_in_channel (first post in channel_id),
+-- first_pr (a PR of theirs merges).
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS onboarding_steps (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL DEFAULT 'manual',
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_onboarding_steps_project ON onboarding_steps (project_id, position);
+
+CREATE TABLE IF NOT EXISTS onboarding_progress (
+    step_id UUID NOT NULL REFERENCES onboarding_steps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    completed_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (step_id, user_id)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS onboarding_progress;
+DROP TABLE IF EXISTS onboarding_steps;
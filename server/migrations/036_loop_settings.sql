@@ -0,0 +1,29 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Loop settings and configurable welcome messages
+-- The join welcome moves here from loop_github_settings and can also be sent
+-- as a DM. The GitHub settings keep their channel for first-PR congrats.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_settings (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    welcome_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    welcome_template TEXT NOT NULL DEFAULT '',
+    welcome_dm BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+INSERT INTO loop_settings (project_id, welcome_channel_id, welcome_template)
+SELECT project_id, welcome_channel_id, welcome_template FROM loop_github_settings
+WHERE welcome_channel_id IS NOT NULL OR welcome_template <> ''
+ON CONFLICT (project_id) DO NOTHING;
+
+ALTER TABLE loop_github_settings DROP COLUMN IF EXISTS welcome_template;
+ALTER TABLE loop_github_settings RENAME COLUMN welcome_channel_id TO first_pr_channel_id;
+
+-- +goose Down
+ALTER TABLE loop_github_settings RENAME COLUMN first_pr_channel_id TO welcome_channel_id;
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS welcome_template TEXT NOT NULL DEFAULT '';
+UPDATE loop_github_settings g SET welcome_template = s.welcome_template
+FROM loop_settings s WHERE s.project_id = g.project_id;
+DROP TABLE IF EXISTS loop_settings;
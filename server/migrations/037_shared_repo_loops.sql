@@ -0,0 +1,13 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Intentional duplicate loops
+-- One loop per repository is now enforced by the API, which lets owners
+-- explicitly opt into a second loop for the same repo.
+-- ============================================================================
+
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS projects_github_repo_id_key;
+CREATE INDEX IF NOT EXISTS idx_projects_github_repo_id ON projects (github_repo_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_projects_github_repo_id;
+ALTER TABLE projects ADD CONSTRAINT projects_github_repo_id_key UNIQUE (github_repo_id);
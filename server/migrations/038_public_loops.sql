@@ -0,0 +1,13 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Public read-only loops
+-- A public loop lets anyone, signed in or not, read the channels listed in
+-- public_channel_ids. Posting still requires joining.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'members';
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS public_channel_ids UUID[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS public_channel_ids;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Guest role
+-- Guests join through an invite code instead of the gatekeeper and can only
+-- read and post in the loop's guest channels.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS guest_channel_ids UUID[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS loop_invites (
+    code TEXT PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'guest',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    max_uses INTEGER,                 -- NULL = unlimited
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,           -- NULL = never
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_loop_invites_project ON loop_invites (project_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_invites;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS guest_channel_ids;
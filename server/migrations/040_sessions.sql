@@ -0,0 +1,22 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Session tracking
+-- One row per login. Session tokens carry the row's id ("sid"), so a user can
+-- see where they're signed in and revoke a session to kill its token.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ            -- NULL = live until expires_at
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions (user_id, last_seen_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS sessions;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Scoped API keys
+-- Users create keys for scripts and bots. Only a SHA-256 of the key is kept;
+-- the key itself is shown once at creation.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,             -- first characters, to tell keys apart
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    rate_limit INTEGER NOT NULL DEFAULT 60, -- requests per minute
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys (user_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
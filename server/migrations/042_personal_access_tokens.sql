@@ -0,0 +1,37 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Personal access tokens
+-- Long-lived Bearer tokens for the CLI and scripts. Only a SHA-256 of the
+-- token is kept, every token expires, and each request made with one is
+-- logged by route so its owner can see what it has been used for.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user ON personal_access_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS personal_access_token_requests (
+    id BIGSERIAL PRIMARY KEY,
+    token_id UUID NOT NULL REFERENCES personal_access_tokens(id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,               -- route pattern, e.g. /api/loops/:name/messages
+    status INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_token_requests_token
+ON personal_access_token_requests (token_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS personal_access_token_requests;
+DROP TABLE IF EXISTS personal_access_tokens;
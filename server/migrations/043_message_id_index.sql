@@ -0,0 +1,9 @@
+-- +goose Up
+-- Cursor pagination walks a channel's top-level messages by snowflake ID in
+-- both directions (before=, after=), so index them by ID as well as by time.
+CREATE INDEX IF NOT EXISTS idx_messages_channel_top_level_id
+ON messages (channel_id, id)
+WHERE parent_id IS NULL AND (is_deleted = FALSE OR is_deleted IS NULL);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_channel_top_level_id;
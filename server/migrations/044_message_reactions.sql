@@ -0,0 +1,20 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Message reactions
+-- One row per user, message and emoji. Message lists carry the counts per
+-- emoji, so there's no per-message lookup when history loads.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS message_reactions (
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id, emoji)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_reactions_message
+ON message_reactions (message_id, emoji);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_reactions;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Custom emoji
+-- Loop owners and moderators upload images under a :name: that messages
+-- and reactions in the loop can use alongside the standard set.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_emoji (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    image_path TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_emoji;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Pin permissions, limits and audit
+-- Loops choose who may pin (any member or moderators only) and how many
+-- pins a channel holds. Every pin, unpin and eviction is recorded.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS pin_role TEXT NOT NULL DEFAULT 'members';
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS pin_limit INT NOT NULL DEFAULT 50;
+
+-- No foreign key on message_id: the trail outlives deleted messages
+CREATE TABLE IF NOT EXISTS pin_events (
+    id BIGSERIAL PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    message_id BIGINT NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL, -- pin, unpin, evict
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pin_events_project
+ON pin_events (project_id, id DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS pin_events;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS pin_limit;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS pin_role;
@@ -0,0 +1,33 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Loop analytics rollups
+-- A background job rolls messages and memberships up per loop and day (and
+-- per loop, member and day for activity and top contributors), so owner
+-- analytics read a few hundred small rows instead of counting messages.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_daily_stats (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    day DATE NOT NULL,                      -- UTC
+    members INT NOT NULL DEFAULT 0,         -- current members who had joined by the end of the day
+    new_members INT NOT NULL DEFAULT 0,
+    messages INT NOT NULL DEFAULT 0,
+    active_members INT NOT NULL DEFAULT 0,  -- distinct senders
+    PRIMARY KEY (project_id, day)
+);
+
+CREATE TABLE IF NOT EXISTS loop_member_daily_stats (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    messages INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, day, user_id)
+);
+
+-- The rollup reads one day of messages at a time
+CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages (created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_created_at;
+DROP TABLE IF EXISTS loop_member_daily_stats;
+DROP TABLE IF EXISTS loop_daily_stats;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Usage quotas
+-- Limits default to the QUOTA_* environment variables; a row here overrides
+-- them for one account. NULL keeps the default, 0 means unlimited. A loop's
+-- limits are those of its owner's account.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS account_quotas (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_loops BIGINT,
+    messages_per_day BIGINT,
+    storage_bytes BIGINT,
+    note TEXT,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS account_quotas;
@@ -0,0 +1,57 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Workspaces
+-- A workspace groups loops under one billing owner. Its members are members
+-- of every loop in it, joining them with the workspace's default role.
+-- Existing loops move into their owner's personal workspace.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    billing_owner_id UUID NOT NULL REFERENCES users(id),
+    personal BOOLEAN NOT NULL DEFAULT FALSE,
+    default_role TEXT NOT NULL DEFAULT 'contributor', -- contributor, moderator, guest
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- One personal workspace per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_personal
+ON workspaces (billing_owner_id) WHERE personal;
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member', -- owner, admin, member
+    joined_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user
+ON workspace_members (user_id);
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_projects_workspace
+ON projects (workspace_id);
+
+INSERT INTO workspaces (slug, name, billing_owner_id, personal)
+SELECT u.username, u.username, u.id, TRUE
+FROM users u
+WHERE EXISTS (SELECT 1 FROM projects p WHERE p.owner_id = u.id)
+ON CONFLICT DO NOTHING;
+
+INSERT INTO workspace_members (workspace_id, user_id, role)
+SELECT id, billing_owner_id, 'owner' FROM workspaces WHERE personal
+ON CONFLICT DO NOTHING;
+
+UPDATE projects p SET workspace_id = w.id
+FROM workspaces w
+WHERE w.personal AND w.billing_owner_id = p.owner_id AND p.workspace_id IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_projects_workspace;
+ALTER TABLE projects DROP COLUMN IF EXISTS workspace_id;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
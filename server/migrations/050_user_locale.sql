@@ -0,0 +1,11 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Localized server text
+-- The language a user wants server-generated text in. NULL follows the
+-- request's Accept-Language header.
+-- ============================================================================
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS locale TEXT;
+
+-- +goose Down
+ALTER TABLE user_settings DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: User time zones
+-- An IANA zone name used to read and schedule local times for the user.
+-- NULL means UTC.
+-- ============================================================================
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS timezone TEXT;
+
+-- +goose Down
+ALTER TABLE user_settings DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Member last activity
+-- When each member last sent a message or connected to the loop, written at
+-- most every few minutes. Backfilled from message history; members who never
+-- posted start out NULL and count from when they joined.
+-- ============================================================================
+
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ;
+
+UPDATE memberships m
+SET last_active_at = a.last_message_at
+FROM (
+    SELECT sender_id, project_id, MAX(created_at) AS last_message_at
+    FROM messages
+    GROUP BY sender_id, project_id
+) a
+WHERE a.sender_id = m.user_id AND a.project_id = m.project_id;
+
+CREATE INDEX IF NOT EXISTS idx_memberships_last_active
+ON memberships (project_id, (COALESCE(last_active_at, joined_at)));
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_memberships_last_active;
+ALTER TABLE memberships DROP COLUMN IF EXISTS last_active_at;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Loop GitHub service tokens
+-- Which token reads the loop's repo: the requesting member's ('requester'),
+-- the loop owner's ('owner'), or a GitHub App installation's ('app').
+-- Writes attributed to a member always use that member's own token.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_github_tokens (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    source TEXT NOT NULL DEFAULT 'requester' CHECK (source IN ('requester', 'owner', 'app')),
+    installation_id BIGINT,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (source <> 'app' OR installation_id IS NOT NULL)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_github_tokens;
@@ -0,0 +1,14 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Weekly top messages digest
+-- Loops with a digest channel get a weekly post of their most-reacted and
+-- most-replied messages. digest_posted_at records the last post, so only one
+-- instance posts each week.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS digest_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL;
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS digest_posted_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS digest_posted_at;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS digest_channel_id;
@@ -0,0 +1,23 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: GitHub issue / PR subscriptions
+-- Members follow items of the loop's repo and are notified when one closes,
+-- merges, reopens or is labeled; a subscription can also post those changes
+-- to a channel.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS github_item_subscriptions (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    github_number INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('issue', 'pr')),
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (project_id, github_number, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_github_item_subscriptions_user
+ON github_item_subscriptions (user_id, project_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS github_item_subscriptions;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Webhook delivery log
+-- Every GitHub webhook delivery with its outcome, so loop owners can see why
+-- an integration went quiet and replay a delivery. Rows are pruned after two
+-- weeks.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id TEXT NOT NULL UNIQUE, -- X-GitHub-Delivery; GitHub reuses it on redelivery
+    event TEXT NOT NULL,
+    action TEXT NOT NULL DEFAULT '',
+    repo_id BIGINT NOT NULL DEFAULT 0,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('ok', 'failed', 'ignored')),
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 1,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_repo
+ON webhook_deliveries (repo_id, last_attempt_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_received
+ON webhook_deliveries (received_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS webhook_deliveries;
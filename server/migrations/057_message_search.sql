@@ -0,0 +1,12 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Message search
+-- Trigram index so substring searches over message content don't scan every
+-- message of the user's loops.
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_messages_content_trgm
+ON messages USING gin (content gin_trgm_ops);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_content_trgm;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Channel read state
+-- How far each member has read each channel, by message id, so the sidebar
+-- can show unread and mention counts and sort channels by activity.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS channel_reads (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    last_read_id BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, channel_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_user_channel
+ON mentions (user_id, channel_id, message_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_mentions_user_channel;
+DROP TABLE IF EXISTS channel_reads;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Typing and presence privacy
+-- send_typing: whether the user's typing indicators reach other members
+-- show_presence: whether other members see the user as online
+-- Both are enforced by the hub, whatever the client sends.
+-- ============================================================================
+
+ALTER TABLE user_settings
+ADD COLUMN IF NOT EXISTS send_typing BOOLEAN NOT NULL DEFAULT TRUE,
+ADD COLUMN IF NOT EXISTS show_presence BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- +goose Down
+ALTER TABLE user_settings
+DROP COLUMN IF EXISTS show_presence,
+DROP COLUMN IF EXISTS send_typing;
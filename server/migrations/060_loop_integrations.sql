@@ -0,0 +1,43 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Loop integrations
+-- One model for every outside service a loop talks to. type names a kind the
+-- server registers (slack, webhook, ...), config holds its kind-specific
+-- settings and credentials_ref points at the secret it needs, so the secret
+-- itself never sits in config. topics narrows the events an integration
+-- receives; empty takes everything its kind handles.
+-- integration_audit records configuration changes and deliveries.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_integrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    name TEXT NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    topics TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    credentials_ref TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS integration_audit (
+    id BIGSERIAL PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    integration_id UUID REFERENCES loop_integrations(id) ON DELETE SET NULL,
+    integration_name TEXT NOT NULL, -- kept for entries about deleted integrations
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for deliveries
+    action TEXT NOT NULL, -- created, updated, deleted, delivered, failed
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_integration_audit_project
+ON integration_audit (project_id, created_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS integration_audit;
+DROP TABLE IF EXISTS loop_integrations;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Encrypted secrets store
+-- Credentials integrations refer to as secret:<name>. Each value is sealed
+-- with its own data key (AES-256-GCM); the data key is stored wrapped by the
+-- master key named key_id, which lives outside the database. hint is the
+-- value's last few characters, shown so owners can tell secrets apart.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_secrets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    ciphertext BYTEA NOT NULL,
+    nonce BYTEA NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    key_id TEXT NOT NULL,
+    hint TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rotated_at TIMESTAMPTZ,
+    UNIQUE (project_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loop_secrets_key ON loop_secrets (key_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_secrets;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Scheduled GitHub activity digest
+-- A loop can have its linked repo's activity (new issues, merged PRs,
+-- releases, busiest threads) written up daily or weekly and posted to a
+-- channel. updated_at versions the scheduled job, as for standups.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_github_digests (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    frequency TEXT NOT NULL DEFAULT 'weekly',  -- 'daily' / 'weekly'
+    send_time TEXT NOT NULL DEFAULT '09:00',   -- 'HH:MM' in timezone
+    timezone TEXT NOT NULL DEFAULT 'UTC',      -- IANA name
+    weekday INTEGER NOT NULL DEFAULT 1,        -- weekly only, 0 = Sunday
+    sections TEXT[] NOT NULL DEFAULT '{issues,pulls,releases,discussions}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_posted_at TIMESTAMPTZ,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_github_digests;
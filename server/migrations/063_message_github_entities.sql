@@ -0,0 +1,24 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Issue, PR and commit references in chat
+-- #123 and pasted SHAs in a message are looked up in the loop's repo after
+-- it is sent; one row per reference holds what was found, at the reference's
+-- UTF-16 offset in the text.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS message_github_entities (
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    text_offset INTEGER NOT NULL,
+    text_length INTEGER NOT NULL,
+    kind TEXT NOT NULL,                 -- 'issue' / 'pull' / 'commit'
+    number INTEGER NOT NULL DEFAULT 0,  -- issues and PRs
+    sha TEXT NOT NULL DEFAULT '',       -- commits, the full SHA
+    title TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT '',     -- open / closed / merged / draft
+    url TEXT NOT NULL,
+    resolved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, text_offset)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS message_github_entities;
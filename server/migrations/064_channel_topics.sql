@@ -0,0 +1,23 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Channel topics and announcement banners
+-- Moderators set a short topic shown in the channel header and can pin an
+-- announcement banner above the messages, optionally until a given time.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS channel_topics (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    topic TEXT NOT NULL DEFAULT '',
+    topic_set_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    topic_set_at TIMESTAMPTZ,
+    banner TEXT NOT NULL DEFAULT '',
+    banner_set_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    banner_set_at TIMESTAMPTZ,
+    banner_expires_at TIMESTAMPTZ  -- NULL keeps the banner until it is cleared
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_topics_project ON channel_topics (project_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS channel_topics;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Quick switcher
+-- The switcher ranks loops, channels, DMs and threads by how often and how
+-- recently the user posted in them, which reads each user's own messages.
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_messages_sender_created
+ON messages (sender_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_dm_messages_sender_created
+ON dm_messages (sender_id, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_dm_messages_sender_created;
+DROP INDEX IF EXISTS idx_messages_sender_created;
@@ -0,0 +1,28 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Notification delivery receipts
+-- A notification pushed over WebSocket waits for the client's ack. Without
+-- one it goes out through the fallback transports the user allows (push,
+-- email), and each notification records how it was delivered.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    notification_id BIGINT PRIMARY KEY REFERENCES notifications(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    -- pending (pushed, awaiting an ack), delivered (acked), fallback (sent
+    -- through transport), retrying (the fallback failed) or persisted (inbox only)
+    state TEXT NOT NULL DEFAULT 'pending',
+    pushed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    acked_at TIMESTAMPTZ,
+    transport TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Fallback transports in the order they are tried; empty keeps notifications in-app
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS notify_fallback TEXT[] NOT NULL DEFAULT '{push,email}';
+
+-- +goose Down
+ALTER TABLE user_settings DROP COLUMN IF EXISTS notify_fallback;
+DROP TABLE IF EXISTS notification_deliveries;
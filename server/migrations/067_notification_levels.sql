@@ -0,0 +1,19 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Per-loop default notification level
+-- A loop's owner picks what new members are notified about: every message
+-- (all), only mentions of them (mentions) or nothing (none). Each member can
+-- change their own level afterwards; members from before keep mentions.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS default_notify_level TEXT NOT NULL DEFAULT 'mentions';
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS notify_level TEXT NOT NULL DEFAULT 'mentions';
+
+-- Members following every message of a loop, for the fan-out of each message
+CREATE INDEX IF NOT EXISTS idx_memberships_notify_all
+ON memberships (project_id) WHERE notify_level = 'all';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_memberships_notify_all;
+ALTER TABLE memberships DROP COLUMN IF EXISTS notify_level;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS default_notify_level;
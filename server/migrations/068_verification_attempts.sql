@@ -0,0 +1,25 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Join verification history
+-- Every check of a user against a loop's contribution requirements is kept,
+-- so the join page can show progress between attempts, and failed attempts
+-- are re-checked in the background until the user qualifies.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_verification_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    passed BOOLEAN NOT NULL,
+    -- [{criteria, required, actual, passed}], empty for collaborators and open loops
+    results JSONB NOT NULL DEFAULT '[]',
+    -- manual (the join page) or recheck (the background job)
+    source TEXT NOT NULL DEFAULT 'manual',
+    checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_verification_attempts_user_loop
+ON loop_verification_attempts (user_id, project_id, checked_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_verification_attempts;
@@ -0,0 +1,25 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Repo statistics cache
+-- Stars, forks, contributors and last push of each linked repo, refreshed in
+-- the background so the gatekeeper's STAR_COUNT rule and the explore page
+-- don't ask GitHub for the same numbers on every request.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS repo_stats (
+    repo_id BIGINT PRIMARY KEY,
+    full_name TEXT NOT NULL DEFAULT '',
+    stars INTEGER NOT NULL DEFAULT 0,
+    forks INTEGER NOT NULL DEFAULT 0,
+    contributors INTEGER NOT NULL DEFAULT 0,
+    pushed_at TIMESTAMPTZ,
+    -- When the numbers were last fetched; NULL until the first refresh
+    refreshed_at TIMESTAMPTZ,
+    -- When a refresher last claimed the row, successful or not
+    checked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_repo_stats_full_name ON repo_stats (LOWER(full_name));
+
+-- +goose Down
+DROP TABLE IF EXISTS repo_stats;
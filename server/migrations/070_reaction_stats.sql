@@ -0,0 +1,28 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Reaction analytics
+-- Reactions rolled up per loop, UTC day, emoji and the author of the message
+-- reacted to, alongside the message rollups. Owners get top emoji, the most
+-- reacted-to members and the daily trend; the badge engine reads the
+-- per-author totals.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_reaction_daily_stats (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    emoji TEXT NOT NULL,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reactions INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, day, emoji, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loop_reaction_daily_stats_author
+ON loop_reaction_daily_stats (project_id, author_id);
+
+-- The rollup reads one day of reactions at a time
+CREATE INDEX IF NOT EXISTS idx_message_reactions_created
+ON message_reactions (created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_message_reactions_created;
+DROP TABLE IF EXISTS loop_reaction_daily_stats;
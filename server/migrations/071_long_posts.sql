@@ -0,0 +1,16 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Long posts
+-- A message over the size limit can be sent as a long post: the markdown is
+-- stored as an attachment and the message itself carries a preview.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS long_posts (
+    message_id BIGINT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    attachment_id UUID NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    chars INT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS long_posts;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Thread summaries
+-- AI summaries of long threads, stored on the parent message and extended as
+-- replies arrive. Loops choose whether members ask for them (offer), they
+-- are kept up to date automatically (auto), or neither (off).
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS thread_summaries TEXT NOT NULL DEFAULT 'offer';
+
+CREATE TABLE IF NOT EXISTS thread_summaries (
+    message_id BIGINT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    replies INT NOT NULL,          -- replies the summary covers
+    last_reply_id BIGINT NOT NULL, -- the newest of them; later ones are added next time
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS thread_summaries;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS thread_summaries;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Per-loop AI settings
+-- Loops can turn the AI features off entirely, so nothing of theirs is sent
+-- to the model, or tune them: model, output token cap, temperature and the
+-- safety threshold. Empty/zero/NULL keeps the server's defaults.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_disabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_model TEXT NOT NULL DEFAULT '';
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_max_tokens INT NOT NULL DEFAULT 0;
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_temperature REAL;
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_safety TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS ai_safety;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS ai_temperature;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS ai_max_tokens;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS ai_model;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS ai_disabled;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: AI content allowlist
+-- The kinds of fetched content a loop lets the AI features send to the model
+-- (bodies, comments, chat, releases). NULL allows them all.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_content TEXT[];
+
+-- +goose Down
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS ai_content;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Job queue observability
+-- The admin jobs view counts and lists unfinished jobs by type; done jobs,
+-- the bulk of the table, stay out of the index.
+-- ============================================================================
+
+CREATE INDEX IF NOT EXISTS idx_jobs_unfinished
+ON jobs (type, status) WHERE status <> 'done';
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_jobs_unfinished;
@@ -0,0 +1,30 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Incoming webhooks
+-- A secret URL that posts into one channel as the hook's own bot user, for CI
+-- scripts and cron jobs. Only the token's hash is stored. Bot users have no
+-- GitHub account; negative github_ids from bot_user_seq keep them apart.
+-- ============================================================================
+
+CREATE SEQUENCE IF NOT EXISTS bot_user_seq;
+
+CREATE TABLE IF NOT EXISTS incoming_webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    bot_id UUID NOT NULL REFERENCES users(id),
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_incoming_webhooks_project ON incoming_webhooks (project_id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_incoming_webhooks_project;
+DROP TABLE IF EXISTS incoming_webhooks;
+DROP SEQUENCE IF EXISTS bot_user_seq;
@@ -0,0 +1,19 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Mirror mode
+-- A loop whose repo was archived or deleted on GitHub becomes a read-only
+-- mirror: GitHub writes are refused, and for a deleted repo reads too. The
+-- row goes away when the repo comes back or the owner relinks; until then
+-- the owner can also archive the loop, which stops new messages.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS loop_mirrors (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('archived', 'deleted')),
+    since TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    archived_at TIMESTAMPTZ,
+    archived_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS loop_mirrors;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Per-channel message sequence numbers
+-- Top-level messages are numbered 1, 2, 3... within their channel when they
+-- are sent, before the broadcast, so clients can order live messages against
+-- history. A message that fails to store leaves its number unused. Replies
+-- are ordered within their thread and have no number.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS channel_seqs (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL
+);
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+-- Existing messages are numbered in ID (send) order
+UPDATE messages m SET seq = n.seq
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY channel_id ORDER BY id) AS seq
+    FROM messages
+    WHERE parent_id IS NULL AND channel_id IS NOT NULL
+) n
+WHERE m.id = n.id;
+
+INSERT INTO channel_seqs (channel_id, last_seq)
+SELECT channel_id, MAX(seq) FROM messages WHERE seq IS NOT NULL GROUP BY channel_id
+ON CONFLICT (channel_id) DO NOTHING;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_channel_seq ON messages (channel_id, seq);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_messages_channel_seq;
+ALTER TABLE messages DROP COLUMN IF EXISTS seq;
+DROP TABLE IF EXISTS channel_seqs;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Channel data policies
+-- Moderators can keep a channel with sensitive discussions out of AI thread
+-- summaries and out of exports (thread export and cross-posting to GitHub).
+-- Channels without a row allow both.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS channel_data_policies (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    ai_summaries BOOLEAN NOT NULL DEFAULT TRUE,
+    exports BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_data_policies_project ON channel_data_policies (project_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS channel_data_policies;
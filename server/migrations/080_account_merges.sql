@@ -0,0 +1,24 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Account merges
+-- An admin folds a duplicate account into the one the user keeps. Each merge
+-- is recorded with who ran it, why, and how much moved. The merged account
+-- stays (other records still point at it) but is left with nothing.
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS account_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    survivor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    merged_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    merged_username TEXT NOT NULL,
+    admin TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    memberships INT NOT NULL,
+    messages INT NOT NULL,
+    notifications INT NOT NULL,
+    loops INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS account_merges;
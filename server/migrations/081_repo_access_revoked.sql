@@ -0,0 +1,12 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Lost repo access
+-- What happens when GitHub reports that a loop member lost access to the
+-- loop's repo: 'notify' tells the owner, 'demote' also drops a moderator back
+-- to contributor.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS access_revoked TEXT NOT NULL DEFAULT 'notify';
+
+-- +goose Down
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS access_revoked;
@@ -0,0 +1,11 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Undo send
+-- How many seconds the server holds a user's chat messages before sending
+-- them, so they can still be cancelled. 0 sends at once.
+-- ============================================================================
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS undo_send_seconds INT NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE user_settings DROP COLUMN IF EXISTS undo_send_seconds;
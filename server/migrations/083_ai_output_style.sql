@@ -0,0 +1,14 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: AI output language and tone
+-- The language and tone preset (formal, casual, bullets) of everything the
+-- AI features write for a loop. '' keeps the defaults: English, in the
+-- feature's own style.
+-- ============================================================================
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_language TEXT NOT NULL DEFAULT '';
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_tone TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS ai_tone;
+ALTER TABLE loop_settings DROP COLUMN IF EXISTS ai_language;
@@ -0,0 +1,16 @@
+-- +goose Up
+-- ============================================================================
+-- Feature: Issue board defaults
+-- Loops now get their default columns when they are created rather than on
+-- the first board load. Loops that never opened their board get them here.
+-- ============================================================================
+
+INSERT INTO board_columns (project_id, name, position)
+SELECT p.id, d.name, d.position
+FROM projects p
+CROSS JOIN (VALUES ('Backlog', 0), ('In Progress', 1), ('Done', 2)) AS d(name, position)
+WHERE NOT EXISTS (SELECT 1 FROM board_columns b WHERE b.project_id = p.id);
+
+-- +goose Down
+-- The columns may hold cards by now, so they stay
+SELECT 1;
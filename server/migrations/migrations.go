@@ -0,0 +1,8 @@
+// Package migrations embeds the goose migration files so the server binary
+// can apply them itself (wireloop migrate).
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -5,7 +5,7 @@ INSERT INTO users (
 	$1, $2, $3, $4
 )
 ON CONFLICT (github_id) DO UPDATE SET
-username = EXCLUDED.username,
+username = CASE WHEN users.username_changed_at IS NULL THEN EXCLUDED.username ELSE users.username END,
 avatar_url = COALESCE(users.avatar_url, EXCLUDED.avatar_url),
 access_token = EXCLUDED.access_token,
 updated_at = NOW()
@@ -67,8 +67,14 @@ WHERE owner_id = $1
 ORDER BY created_at DESC;
 
 -- name: CreateProject :one
-INSERT INTO projects (github_repo_id, name, owner_id)
-VALUES ($1, $2, $3)
+INSERT INTO projects (github_repo_id, name, owner_id, workspace_id)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: UpdateProjectRepo :one
+UPDATE projects
+SET github_repo_id = $2
+WHERE id = $1
 RETURNING *;
 
 -- name: CreateRule :one
@@ -77,8 +83,9 @@ VALUES ($1, $2, $3)
 RETURNING *;
 
 -- name: AddMembership :exec
-INSERT INTO memberships (user_id, project_id, role)
-VALUES ($1, $2, $3);
+-- New members start at the loop's default notification level
+INSERT INTO memberships (user_id, project_id, role, notify_level)
+VALUES ($1, $2, $3, COALESCE((SELECT default_notify_level FROM loop_settings WHERE project_id = $2), 'mentions'));
 
 -- name: SearchRepos :many
 SELECT id, name
@@ -95,8 +102,8 @@ ORDER BY similarity(name, sqlc.arg(q)) DESC
 LIMIT sqlc.arg(n);
 
 -- name: AddMessage :exec
-INSERT INTO messages (id, project_id, channel_id, sender_id, content, parent_id)
-VALUES ($1, $2, $3, $4, $5, $6);
+INSERT INTO messages (id, project_id, channel_id, sender_id, content, parent_id, seq)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
 
 -- name: AddReply :exec
 INSERT INTO messages (id, project_id, channel_id, sender_id, content, parent_id)
@@ -122,13 +129,14 @@ SELECT
     m.channel_id,
     m.parent_id,
     m.reply_count,
+    m.seq,
     u.username AS sender_username,
-    u.avatar_url AS sender_avatar
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
 FROM messages m
 JOIN users u ON m.sender_id = u.id
 WHERE m.channel_id = $1 
   AND m.parent_id IS NULL 
-  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
 ORDER BY m.created_at DESC
 LIMIT $2 OFFSET $3;
 
@@ -141,11 +149,11 @@ SELECT
     m.channel_id,
     m.parent_id,
     u.username AS sender_username,
-    u.avatar_url AS sender_avatar
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
 FROM messages m
 JOIN users u ON m.sender_id = u.id
 WHERE m.parent_id = $1 
-  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
 ORDER BY m.created_at ASC
 LIMIT $2 OFFSET $3;
 
@@ -160,6 +168,32 @@ WHERE id = $1;
 -- name: HardDeleteMessage :exec
 DELETE FROM messages WHERE id = $1;
 
+-- name: GetEntryChannelMessages :many
+-- Messages for the channel a loop opens on (default channel, else first by position)
+SELECT 
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.seq,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.channel_id = (
+    SELECT ch.id FROM channels ch
+    WHERE ch.project_id = $1
+    ORDER BY ch.is_default DESC NULLS LAST, ch.position ASC, ch.created_at ASC
+    LIMIT 1
+)
+  AND m.parent_id IS NULL 
+ORDER BY m.created_at DESC
+LIMIT $2;
+
 -- name: GetMessagesByProject :many
 SELECT 
     m.id,
@@ -192,7 +226,12 @@ SELECT
     u.avatar_url,
     u.display_name,
     mem.role,
-    mem.joined_at
+    mem.joined_at,
+    ARRAY(
+        SELECT b.badge FROM member_badges b
+        WHERE b.project_id = mem.project_id AND b.user_id = u.id
+        ORDER BY b.awarded_at
+    )::TEXT[] AS badges
 FROM memberships mem
 JOIN users u ON mem.user_id = u.id
 WHERE mem.project_id = $1
@@ -211,9 +250,16 @@ SELECT
     p.created_at,
     u.username AS owner_username,
     u.avatar_url AS owner_avatar,
-    (SELECT COUNT(*) FROM memberships m WHERE m.project_id = p.id) AS member_count
+    COUNT(m.user_id) AS member_count,
+    rs.stars,
+    rs.forks,
+    rs.contributors,
+    rs.pushed_at
 FROM projects p
 JOIN users u ON p.owner_id = u.id
+LEFT JOIN memberships m ON m.project_id = p.id
+LEFT JOIN repo_stats rs ON rs.repo_id = p.github_repo_id AND rs.refreshed_at IS NOT NULL
+GROUP BY p.id, u.id, rs.repo_id
 ORDER BY p.created_at DESC
 LIMIT $1 OFFSET $2;
 
@@ -222,11 +268,15 @@ SELECT
     p.id AS project_id,
     p.name AS project_name,
     mem.role,
-    mem.joined_at
+    mem.joined_at,
+    mem.is_favorite,
+    mem.sort_order,
+    mem.sidebar_collapsed,
+    p.workspace_id
 FROM memberships mem
 JOIN projects p ON mem.project_id = p.id
 WHERE mem.user_id = $1
-ORDER BY mem.joined_at DESC;
+ORDER BY mem.is_favorite DESC, mem.sort_order ASC NULLS LAST, mem.joined_at DESC;
 
 -- ============================================================================
 -- CHANNEL QUERIES
@@ -327,9 +377,31 @@ INSERT INTO notifications (id, user_id, type, message_id, project_id, channel_id
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
 
 -- name: GetNotifications :many
-SELECT * FROM notifications
-WHERE user_id = $1
-ORDER BY created_at DESC
+SELECT
+    n.id,
+    n.user_id,
+    n.type,
+    n.message_id,
+    n.project_id,
+    n.channel_id,
+    n.actor_id,
+    n.actor_username,
+    n.content_preview,
+    n.is_read,
+    n.created_at,
+    n.batch_count,
+    p.name AS project_name,
+    ch.name AS channel_name,
+    m.parent_id AS thread_parent_id,
+    COALESCE(d.state, 'persisted')::text AS delivery_state,
+    d.transport AS delivery_transport
+FROM notifications n
+LEFT JOIN projects p ON n.project_id = p.id
+LEFT JOIN channels ch ON n.channel_id = ch.id
+LEFT JOIN messages m ON n.message_id = m.id
+LEFT JOIN notification_deliveries d ON d.notification_id = n.id
+WHERE n.user_id = $1
+ORDER BY n.created_at DESC
 LIMIT $2 OFFSET $3;
 
 -- name: GetUnreadNotificationCount :one
@@ -342,6 +414,26 @@ UPDATE notifications SET is_read = TRUE WHERE id = $1 AND user_id = $2;
 -- name: MarkAllNotificationsRead :exec
 UPDATE notifications SET is_read = TRUE WHERE user_id = $1 AND is_read = FALSE;
 
+-- name: GetRecentUnreadNotification :one
+-- Finds an open batch to fold a new notification into
+SELECT id, batch_count FROM notifications
+WHERE user_id = $1 AND actor_id = $2 AND channel_id = $3 AND type = $4
+  AND is_read = FALSE AND created_at > $5
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: BumpNotificationBatch :exec
+UPDATE notifications
+SET batch_count = batch_count + 1, message_id = $2, content_preview = $3
+WHERE id = $1;
+
+-- name: GetNotificationMentions :many
+SELECT mn.id, mn.message_id, m.content, m.parent_id, mn.created_at
+FROM mentions mn
+JOIN messages m ON mn.message_id = m.id
+WHERE mn.notification_id = $1 AND mn.user_id = $2
+ORDER BY mn.id ASC;
+
 -- ============================================================================
 -- MEMBER SEARCH (for @mentions autocomplete)
 -- ============================================================================
@@ -361,3 +453,2643 @@ LIMIT 10;
 
 -- name: GetUserByUsername2 :one
 SELECT id FROM users WHERE username = $1 LIMIT 1;
+
+-- ============================================================================
+-- AGGREGATES (single-pass GROUP BY instead of per-row subqueries)
+-- ============================================================================
+
+-- name: GetActiveLoopStats :many
+SELECT
+    p.name,
+    COALESCE(mem.member_count, 0)::bigint AS member_count,
+    COALESCE(ch.channel_count, 0)::bigint AS channel_count,
+    COALESCE(msg.total_messages, 0)::bigint AS total_messages,
+    COALESCE(msg.messages_today, 0)::bigint AS messages_today,
+    p.created_at
+FROM projects p
+LEFT JOIN (
+    SELECT project_id, COUNT(*) AS member_count
+    FROM memberships
+    GROUP BY project_id
+) mem ON mem.project_id = p.id
+LEFT JOIN (
+    SELECT project_id, COUNT(*) AS channel_count
+    FROM channels
+    GROUP BY project_id
+) ch ON ch.project_id = p.id
+LEFT JOIN (
+    SELECT c.project_id,
+           COUNT(*) AS total_messages,
+           COUNT(*) FILTER (WHERE m.created_at > NOW() - INTERVAL '24 hours') AS messages_today
+    FROM messages m
+    JOIN channels c ON m.channel_id = c.id
+    GROUP BY c.project_id
+) msg ON msg.project_id = p.id
+ORDER BY messages_today DESC, total_messages DESC
+LIMIT $1;
+
+-- name: GetUserEngagementStats :many
+WITH recent AS (
+    SELECT id, username, avatar_url, github_id, profile_completed, created_at
+    FROM users
+    ORDER BY created_at DESC
+    LIMIT $1
+),
+loop_counts AS (
+    SELECT user_id, COUNT(*) AS loop_count
+    FROM memberships
+    WHERE user_id IN (SELECT id FROM recent)
+    GROUP BY user_id
+),
+message_counts AS (
+    SELECT sender_id, COUNT(*) AS message_count
+    FROM messages
+    WHERE sender_id IN (SELECT id FROM recent)
+    GROUP BY sender_id
+)
+SELECT
+    r.id,
+    r.username,
+    r.avatar_url,
+    r.github_id,
+    r.profile_completed,
+    r.created_at,
+    COALESCE(lc.loop_count, 0)::bigint AS loop_count,
+    COALESCE(mc.message_count, 0)::bigint AS message_count
+FROM recent r
+LEFT JOIN loop_counts lc ON lc.user_id = r.id
+LEFT JOIN message_counts mc ON mc.sender_id = r.id
+ORDER BY r.created_at DESC;
+
+-- ============================================================================
+-- ISSUE BOARD
+-- ============================================================================
+
+-- name: CreateBoardColumn :one
+INSERT INTO board_columns (project_id, name, position)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetBoardColumns :many
+SELECT * FROM board_columns
+WHERE project_id = $1
+ORDER BY position ASC, created_at ASC;
+
+-- name: GetBoardColumnByID :one
+SELECT * FROM board_columns WHERE id = $1 LIMIT 1;
+
+-- name: RenameBoardColumn :one
+UPDATE board_columns
+SET name = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: SetBoardColumnPosition :exec
+UPDATE board_columns
+SET position = $3
+WHERE id = $1 AND project_id = $2;
+
+-- name: DeleteBoardColumn :exec
+DELETE FROM board_columns WHERE id = $1;
+
+-- name: CreateBoardCard :one
+INSERT INTO board_cards (project_id, column_id, title, body, github_issue_number, github_state, position, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetBoardCards :many
+SELECT * FROM board_cards
+WHERE project_id = $1
+ORDER BY position ASC, created_at ASC;
+
+-- name: GetBoardCardsByColumn :many
+SELECT * FROM board_cards
+WHERE column_id = $1
+ORDER BY position ASC, created_at ASC;
+
+-- name: GetBoardCardByID :one
+SELECT * FROM board_cards WHERE id = $1 LIMIT 1;
+
+-- name: GetLinkedBoardCards :many
+SELECT * FROM board_cards
+WHERE project_id = $1 AND github_issue_number IS NOT NULL;
+
+-- name: GetBoardCardCount :one
+SELECT COUNT(*) FROM board_cards WHERE column_id = $1;
+
+-- name: UpdateBoardCard :one
+UPDATE board_cards
+SET title = $2, body = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetBoardCardPosition :exec
+UPDATE board_cards
+SET column_id = $2, position = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SyncBoardCardIssue :exec
+UPDATE board_cards
+SET title = $3, github_state = $4, updated_at = NOW()
+WHERE project_id = $1 AND github_issue_number = $2;
+
+-- name: DeleteBoardCard :exec
+DELETE FROM board_cards WHERE id = $1;
+
+-- ============================================================================
+-- TASKS
+-- ============================================================================
+
+-- name: CreateTask :one
+INSERT INTO tasks (project_id, channel_id, message_id, title, assignee_id, due_at, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetTaskByID :one
+SELECT * FROM tasks WHERE id = $1 LIMIT 1;
+
+-- name: GetOpenTasksByChannel :many
+SELECT
+    t.id,
+    t.message_id,
+    t.title,
+    t.assignee_id,
+    t.due_at,
+    t.github_issue_number,
+    t.created_at,
+    a.username AS assignee_username,
+    a.avatar_url AS assignee_avatar
+FROM tasks t
+LEFT JOIN users a ON t.assignee_id = a.id
+WHERE t.channel_id = $1 AND t.status = 'open'
+ORDER BY t.due_at ASC NULLS LAST, t.created_at ASC;
+
+-- name: CompleteTask :one
+UPDATE tasks
+SET status = 'done', completed_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetTaskGithubIssue :exec
+UPDATE tasks
+SET github_issue_number = $2
+WHERE id = $1;
+
+-- ============================================================================
+-- JOB QUEUE
+-- ============================================================================
+
+-- name: EnqueueJob :one
+INSERT INTO jobs (type, payload, run_at)
+VALUES ($1, $2, $3)
+RETURNING id;
+
+-- name: ClaimDueJobs :many
+-- SKIP LOCKED lets several server instances poll without double-running jobs
+UPDATE jobs
+SET status = 'running', attempts = attempts + 1, updated_at = NOW()
+WHERE id IN (
+    SELECT id FROM jobs
+    WHERE status = 'queued' AND run_at <= NOW()
+    ORDER BY run_at
+    LIMIT $1
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: CompleteJob :exec
+UPDATE jobs SET status = 'done', updated_at = NOW() WHERE id = $1;
+
+-- name: RetryJob :exec
+UPDATE jobs
+SET status = 'queued', run_at = $2, last_error = $3, updated_at = NOW()
+WHERE id = $1;
+
+-- name: FailJob :exec
+UPDATE jobs
+SET status = 'failed', last_error = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: RequeueStaleJobs :execrows
+-- Recovers jobs whose worker died mid-run
+UPDATE jobs
+SET status = 'queued', updated_at = NOW()
+WHERE status = 'running' AND updated_at < $1;
+
+-- name: GetJobStats :many
+-- Unfinished jobs per type; retrying ones are queued after a failed attempt
+SELECT type,
+    COUNT(*) FILTER (WHERE status = 'queued')::int AS queued,
+    COUNT(*) FILTER (WHERE status = 'queued' AND attempts > 0)::int AS retrying,
+    COUNT(*) FILTER (WHERE status = 'running')::int AS running,
+    COUNT(*) FILTER (WHERE status = 'failed')::int AS failed,
+    MIN(run_at) FILTER (WHERE status = 'queued' AND run_at <= NOW())::timestamptz AS oldest_due
+FROM jobs
+WHERE status <> 'done'
+GROUP BY type
+ORDER BY type;
+
+-- name: ListJobFailures :many
+-- Failed jobs and those waiting to retry, most recent failure first
+SELECT * FROM jobs
+WHERE status <> 'done' AND last_error IS NOT NULL
+  AND (sqlc.narg(type)::text IS NULL OR type = sqlc.narg(type))
+ORDER BY updated_at DESC
+LIMIT sqlc.arg(n);
+
+-- name: RequeueFailedJob :execrows
+UPDATE jobs
+SET status = 'queued', attempts = 0, run_at = NOW(), updated_at = NOW()
+WHERE id = $1 AND status = 'failed';
+
+-- name: RequeueFailedJobsByType :execrows
+UPDATE jobs
+SET status = 'queued', attempts = 0, run_at = NOW(), updated_at = NOW()
+WHERE type = $1 AND status = 'failed';
+
+-- ============================================================================
+-- EVENTS + ACTIVITY
+-- ============================================================================
+
+-- name: CreateEvent :one
+INSERT INTO events (project_id, title, description, kind, starts_at, ends_at, recurrence, recurrence_until, remind_minutes, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING *;
+
+-- name: GetEventByID :one
+SELECT * FROM events WHERE id = $1 LIMIT 1;
+
+-- name: GetEventsInRange :many
+-- Recurring series are returned whole; occurrences are expanded in Go
+SELECT * FROM events
+WHERE project_id = sqlc.arg(project_id)
+  AND starts_at < sqlc.arg(range_end)
+  AND (ends_at >= sqlc.arg(range_start) OR recurrence <> 'none')
+ORDER BY starts_at ASC;
+
+-- name: UpdateEvent :one
+UPDATE events
+SET title = $2, description = $3, kind = $4, starts_at = $5, ends_at = $6,
+    recurrence = $7, recurrence_until = $8, remind_minutes = $9, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteEvent :exec
+DELETE FROM events WHERE id = $1;
+
+-- name: UpsertEventRSVP :exec
+INSERT INTO event_rsvps (event_id, user_id, status)
+VALUES ($1, $2, $3)
+ON CONFLICT (event_id, user_id) DO UPDATE SET status = EXCLUDED.status, updated_at = NOW();
+
+-- name: GetEventRSVPs :many
+SELECT r.user_id, r.status, u.username, u.avatar_url
+FROM event_rsvps r
+JOIN users u ON r.user_id = u.id
+WHERE r.event_id = $1
+ORDER BY r.updated_at ASC;
+
+-- name: GetEventRemindees :many
+SELECT user_id FROM event_rsvps
+WHERE event_id = $1 AND status IN ('going', 'maybe');
+
+-- name: CreateActivity :exec
+INSERT INTO loop_activity (project_id, actor_id, kind, ref_id, summary)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: GetLoopActivity :many
+SELECT a.id, a.kind, a.ref_id, a.summary, a.created_at, u.username AS actor_username, u.avatar_url AS actor_avatar
+FROM loop_activity a
+LEFT JOIN users u ON a.actor_id = u.id
+WHERE a.project_id = $1
+ORDER BY a.created_at DESC
+LIMIT $2;
+
+-- ============================================================================
+-- DIRECT MESSAGES
+-- ============================================================================
+
+-- name: GetDMConversationByKey :one
+SELECT * FROM dm_conversations WHERE dm_key = $1 LIMIT 1;
+
+-- name: GetDMConversationByID :one
+SELECT * FROM dm_conversations WHERE id = $1 LIMIT 1;
+
+-- name: CreateDMConversation :one
+-- Upsert so two concurrent opens of the same pair share one conversation
+INSERT INTO dm_conversations (dm_key, is_bot, status, requested_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (dm_key) DO UPDATE SET dm_key = EXCLUDED.dm_key
+RETURNING *;
+
+-- name: AddDMParticipant :exec
+INSERT INTO dm_participants (conversation_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: IsDMParticipant :one
+SELECT user_id FROM dm_participants
+WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: GetDMParticipantIDs :many
+SELECT user_id FROM dm_participants WHERE conversation_id = $1;
+
+-- name: GetDMConversations :many
+SELECT
+    c.id,
+    c.is_bot,
+    c.is_group,
+    c.name,
+    c.status,
+    c.last_message_at,
+    u.id AS other_user_id,
+    u.username AS other_username,
+    u.avatar_url AS other_avatar,
+    (SELECT COUNT(*) FROM dm_messages m
+     WHERE m.conversation_id = c.id
+       AND m.created_at > COALESCE(p.last_read_at, 'epoch')
+       AND m.sender_id IS DISTINCT FROM p.user_id) AS unread_count,
+    (SELECT COUNT(*) FROM dm_participants mp WHERE mp.conversation_id = c.id) AS member_count
+FROM dm_participants p
+JOIN dm_conversations c ON c.id = p.conversation_id
+LEFT JOIN dm_participants op ON op.conversation_id = c.id AND op.user_id <> p.user_id AND NOT c.is_group
+LEFT JOIN users u ON u.id = op.user_id
+WHERE p.user_id = $1
+  AND (c.status = 'active' OR c.requested_by = p.user_id)
+ORDER BY c.last_message_at DESC;
+
+-- name: AddDMMessage :exec
+INSERT INTO dm_messages (id, conversation_id, sender_id, content)
+VALUES ($1, $2, $3, $4);
+
+-- name: TouchDMConversation :exec
+UPDATE dm_conversations SET last_message_at = NOW() WHERE id = $1;
+
+-- name: GetDMMessages :many
+SELECT m.id, m.sender_id, m.content, m.created_at, u.username AS sender_username, u.avatar_url AS sender_avatar
+FROM dm_messages m
+LEFT JOIN users u ON m.sender_id = u.id
+WHERE m.conversation_id = sqlc.arg(conversation_id)
+  AND (sqlc.arg(before)::bigint = 0 OR m.id < sqlc.arg(before)::bigint)
+ORDER BY m.id DESC
+LIMIT sqlc.arg(n);
+
+-- name: MarkDMRead :exec
+UPDATE dm_participants SET last_read_at = NOW()
+WHERE conversation_id = $1 AND user_id = $2;
+
+-- ============================================================================
+-- STANDUPS
+-- ============================================================================
+
+-- name: CreateStandup :one
+INSERT INTO standups (project_id, channel_id, name, questions, prompt_time, timezone, weekdays, collect_minutes, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: GetStandupByID :one
+SELECT * FROM standups WHERE id = $1 LIMIT 1;
+
+-- name: GetStandupsByProject :many
+SELECT * FROM standups WHERE project_id = $1 ORDER BY created_at ASC;
+
+-- name: UpdateStandup :one
+UPDATE standups
+SET channel_id = $2, name = $3, questions = $4, prompt_time = $5, timezone = $6,
+    weekdays = $7, collect_minutes = $8, enabled = $9, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteStandup :exec
+DELETE FROM standups WHERE id = $1;
+
+-- name: AddStandupParticipant :exec
+INSERT INTO standup_participants (standup_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveStandupParticipant :exec
+DELETE FROM standup_participants WHERE standup_id = $1 AND user_id = $2;
+
+-- name: GetStandupParticipants :many
+SELECT p.user_id, u.username, u.avatar_url
+FROM standup_participants p
+JOIN users u ON p.user_id = u.id
+WHERE p.standup_id = $1
+ORDER BY u.username ASC;
+
+-- name: CreateStandupRun :one
+INSERT INTO standup_runs (standup_id, closes_at)
+VALUES ($1, $2)
+RETURNING *;
+
+-- name: GetStandupRunByID :one
+SELECT * FROM standup_runs WHERE id = $1 LIMIT 1;
+
+-- name: GetOpenStandupRun :one
+SELECT * FROM standup_runs
+WHERE standup_id = $1 AND status = 'collecting'
+ORDER BY started_at DESC
+LIMIT 1;
+
+-- name: GetOpenStandupRunsForUser :many
+SELECT r.id, r.standup_id, s.name, s.questions, r.closes_at
+FROM standup_runs r
+JOIN standups s ON r.standup_id = s.id
+JOIN standup_participants p ON p.standup_id = s.id AND p.user_id = $1
+WHERE r.status = 'collecting'
+ORDER BY r.started_at DESC;
+
+-- name: MarkStandupRunPosted :exec
+UPDATE standup_runs SET status = 'posted', posted_at = NOW() WHERE id = $1;
+
+-- name: UpsertStandupResponse :exec
+INSERT INTO standup_responses (run_id, user_id, answers)
+VALUES ($1, $2, $3)
+ON CONFLICT (run_id, user_id) DO UPDATE SET answers = EXCLUDED.answers, created_at = NOW();
+
+-- name: GetStandupResponses :many
+SELECT r.user_id, u.username, r.answers, r.created_at
+FROM standup_responses r
+JOIN users u ON r.user_id = u.id
+WHERE r.run_id = $1
+ORDER BY r.created_at ASC;
+
+-- ============================================================================
+-- SIDEBAR PREFERENCES
+-- ============================================================================
+
+-- name: GetMembership :one
+SELECT * FROM memberships
+WHERE user_id = $1 AND project_id = $2 LIMIT 1;
+
+-- name: UpdateMembershipPreferences :execrows
+UPDATE memberships
+SET is_favorite = $3, sidebar_collapsed = $4
+WHERE user_id = $1 AND project_id = $2;
+
+-- name: SetMembershipSortOrder :exec
+UPDATE memberships
+SET sort_order = $3
+WHERE user_id = $1 AND project_id = $2;
+
+-- ============================================================================
+-- MENTIONS
+-- ============================================================================
+
+-- name: CreateMention :exec
+INSERT INTO mentions (id, user_id, message_id, project_id, channel_id, actor_id, notification_id)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (message_id, user_id) DO NOTHING;
+
+-- name: GetMentions :many
+SELECT
+    mn.id,
+    mn.message_id,
+    mn.project_id,
+    mn.channel_id,
+    mn.is_read,
+    mn.created_at,
+    m.content,
+    m.parent_id,
+    u.username AS actor_username,
+    u.avatar_url AS actor_avatar,
+    p.name AS project_name,
+    ch.name AS channel_name
+FROM mentions mn
+JOIN messages m ON mn.message_id = m.id
+JOIN users u ON mn.actor_id = u.id
+LEFT JOIN projects p ON mn.project_id = p.id
+LEFT JOIN channels ch ON mn.channel_id = ch.id
+WHERE mn.user_id = sqlc.arg(user_id)
+  AND (m.is_deleted IS NULL OR m.is_deleted = FALSE)
+  AND (NOT sqlc.arg(unread_only)::bool OR mn.is_read = FALSE)
+  AND (sqlc.arg(before)::bigint = 0 OR mn.id < sqlc.arg(before)::bigint)
+ORDER BY mn.id DESC
+LIMIT sqlc.arg(n);
+
+-- name: GetUnreadMentionCount :one
+SELECT COUNT(*) FROM mentions
+WHERE user_id = $1 AND is_read = FALSE;
+
+-- name: MarkMentionRead :exec
+UPDATE mentions SET is_read = TRUE WHERE id = $1 AND user_id = $2;
+
+-- name: MarkAllMentionsRead :exec
+UPDATE mentions SET is_read = TRUE WHERE user_id = $1 AND is_read = FALSE;
+
+-- ============================================================================
+-- MUTED WORDS
+-- ============================================================================
+
+-- name: GetMutedWords :many
+SELECT word FROM user_muted_words
+WHERE user_id = $1
+ORDER BY word ASC;
+
+-- name: ClearMutedWords :exec
+DELETE FROM user_muted_words WHERE user_id = $1;
+
+-- name: AddMutedWord :exec
+INSERT INTO user_muted_words (user_id, word)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- ============================================================================
+-- USER BLOCKS
+-- ============================================================================
+
+-- name: BlockUser :exec
+INSERT INTO user_blocks (blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: UnblockUser :exec
+DELETE FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: GetBlockedUsers :many
+SELECT b.blocked_id, u.username, u.avatar_url, b.created_at
+FROM user_blocks b
+JOIN users u ON b.blocked_id = u.id
+WHERE b.blocker_id = $1
+ORDER BY b.created_at DESC;
+
+-- name: GetBlockedUserIDs :many
+SELECT blocked_id FROM user_blocks WHERE blocker_id = $1;
+
+-- name: HasBlocked :one
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks WHERE blocker_id = $1 AND blocked_id = $2
+);
+
+-- name: IsBlockedBetween :one
+-- Either direction counts: used to refuse DMs between the pair
+SELECT EXISTS (
+    SELECT 1 FROM user_blocks
+    WHERE (blocker_id = $1 AND blocked_id = $2)
+       OR (blocker_id = $2 AND blocked_id = $1)
+);
+
+-- ============================================================================
+-- DM PRIVACY + REQUESTS
+-- ============================================================================
+
+-- name: GetDMRequests :many
+SELECT
+    c.id,
+    c.created_at,
+    c.last_message_at,
+    u.id AS requester_id,
+    u.username AS requester_username,
+    u.avatar_url AS requester_avatar,
+    (SELECT m.content FROM dm_messages m
+     WHERE m.conversation_id = c.id
+     ORDER BY m.id DESC
+     LIMIT 1) AS last_message
+FROM dm_participants p
+JOIN dm_conversations c ON c.id = p.conversation_id
+JOIN users u ON u.id = c.requested_by
+WHERE p.user_id = $1 AND c.status = 'pending' AND c.requested_by <> $1
+ORDER BY c.last_message_at DESC;
+
+-- name: AcceptDMRequest :execrows
+UPDATE dm_conversations SET status = 'active'
+WHERE id = $1 AND status = 'pending' AND requested_by <> $2;
+
+-- name: DeclineDMRequest :execrows
+DELETE FROM dm_conversations
+WHERE id = $1 AND status = 'pending' AND requested_by <> $2;
+
+-- name: GetDMPrivacy :one
+SELECT dm_privacy FROM user_settings WHERE user_id = $1;
+
+-- name: SetDMPrivacy :exec
+INSERT INTO user_settings (user_id, dm_privacy)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET dm_privacy = EXCLUDED.dm_privacy, updated_at = NOW();
+
+-- name: SharesLoop :one
+SELECT EXISTS (
+    SELECT 1 FROM memberships a
+    JOIN memberships b ON a.project_id = b.project_id
+    WHERE a.user_id = sqlc.arg(user_a) AND b.user_id = sqlc.arg(user_b)
+);
+
+-- ============================================================================
+-- GROUP DMS
+-- ============================================================================
+
+-- name: CreateGroupDM :one
+INSERT INTO dm_conversations (is_group, name, created_by)
+VALUES (TRUE, $1, $2)
+RETURNING *;
+
+-- name: GetDMParticipants :many
+SELECT p.user_id, u.username, u.avatar_url, p.joined_at
+FROM dm_participants p
+JOIN users u ON p.user_id = u.id
+WHERE p.conversation_id = $1
+ORDER BY p.joined_at ASC;
+
+-- name: CountDMParticipants :one
+SELECT COUNT(*) FROM dm_participants WHERE conversation_id = $1;
+
+-- name: RemoveDMParticipant :exec
+DELETE FROM dm_participants WHERE conversation_id = $1 AND user_id = $2;
+
+-- name: DeleteDMConversation :exec
+DELETE FROM dm_conversations WHERE id = $1;
+
+-- ============================================================================
+-- ATTACHMENTS
+-- ============================================================================
+
+-- name: CreateAttachment :one
+INSERT INTO attachments (project_id, channel_id, uploader_id, filename, content_type, size_bytes, storage_key)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: GetAttachmentByID :one
+SELECT * FROM attachments WHERE id = $1 LIMIT 1;
+
+-- name: GetChannelAttachments :many
+SELECT * FROM attachments
+WHERE channel_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
+
+-- name: SetAttachmentScanResult :exec
+UPDATE attachments
+SET scan_status = $2, scan_engine = $3, scan_signature = $4, scanned_at = NOW()
+WHERE id = $1;
+
+-- ============================================================================
+-- GITHUB PROFILE SYNC
+-- ============================================================================
+
+-- name: ClaimStaleGitHubProfiles :many
+-- Stamps the rows up front so concurrent refreshers pick different users
+UPDATE users
+SET github_synced_at = NOW()
+WHERE id IN (
+    SELECT id FROM users
+    WHERE github_id > 0 AND (github_synced_at IS NULL OR github_synced_at < $1)
+    ORDER BY github_synced_at NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: SyncUserFromGitHub :one
+-- Uploaded avatars and custom display names are kept unless replace_custom is set;
+-- a username chosen in Wireloop is never overwritten
+UPDATE users SET
+username = CASE WHEN username_changed_at IS NULL THEN sqlc.arg(username) ELSE username END,
+avatar_url = CASE
+    WHEN sqlc.arg(replace_custom)::boolean OR avatar_url IS NULL
+         OR (avatar_url NOT LIKE '/api/media/%' AND avatar_url NOT LIKE 'data:%')
+    THEN sqlc.arg(avatar_url)::text
+    ELSE avatar_url
+END,
+display_name = CASE
+    WHEN sqlc.arg(replace_custom)::boolean OR display_name IS NULL
+    THEN COALESCE(sqlc.narg(display_name), display_name)
+    ELSE display_name
+END,
+github_synced_at = NOW(),
+updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
+-- ============================================================================
+-- USERNAME CHANGES
+-- ============================================================================
+
+-- name: ChangeUsername :one
+UPDATE users
+SET username = $2, username_changed_at = NOW(), updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: RecordUsernameHistory :exec
+INSERT INTO username_history (old_username, user_id)
+VALUES ($1, $2)
+ON CONFLICT (old_username) DO UPDATE SET user_id = EXCLUDED.user_id, changed_at = NOW();
+
+-- name: ReleaseUsernameHistory :exec
+-- A handle stops redirecting once someone claims it
+DELETE FROM username_history WHERE old_username = $1;
+
+-- name: GetUsernameRedirect :one
+SELECT user_id FROM username_history WHERE old_username = $1;
+
+-- ============================================================================
+-- CONTENT REPORTS
+-- ============================================================================
+
+-- name: CreateMessageReport :one
+-- Re-reporting an open report updates its reason; resolved reports are left alone
+INSERT INTO message_reports (message_id, project_id, reporter_id, reason, details)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (message_id, reporter_id) DO UPDATE
+SET reason = EXCLUDED.reason, details = EXCLUDED.details
+WHERE message_reports.status = 'open'
+RETURNING *;
+
+-- name: GetMessageReportByID :one
+SELECT * FROM message_reports WHERE id = $1 LIMIT 1;
+
+-- name: GetLoopReports :many
+SELECT
+    r.id, r.message_id, r.reporter_id, r.reason, r.details, r.status, r.resolution, r.resolved_at, r.created_at,
+    ru.username AS reporter_username,
+    m.content AS message_content,
+    m.sender_id AS author_id,
+    m.channel_id,
+    m.is_deleted AS message_deleted,
+    au.username AS author_username
+FROM message_reports r
+JOIN users ru ON r.reporter_id = ru.id
+JOIN messages m ON r.message_id = m.id
+LEFT JOIN users au ON m.sender_id = au.id
+WHERE r.project_id = $1 AND r.status = $2
+ORDER BY r.created_at ASC
+LIMIT $3;
+
+-- name: ResolveMessageReports :many
+-- Resolves every open report on the message at once
+UPDATE message_reports
+SET status = $2, resolution = $3, resolved_by = $4, resolved_at = NOW()
+WHERE message_id = $1 AND status = 'open'
+RETURNING *;
+
+-- name: BanFromLoop :exec
+INSERT INTO loop_bans (project_id, user_id, banned_by, reason)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (project_id, user_id) DO NOTHING;
+
+-- name: IsBannedFromLoop :one
+SELECT EXISTS (
+    SELECT 1 FROM loop_bans WHERE project_id = $1 AND user_id = $2
+);
+
+-- name: RemoveMembership :exec
+DELETE FROM memberships WHERE user_id = $1 AND project_id = $2;
+
+-- ============================================================================
+-- MESSAGE FILTERS
+-- ============================================================================
+
+-- name: GetLoopFilterSettings :one
+SELECT * FROM loop_filter_settings WHERE project_id = $1 LIMIT 1;
+
+-- name: UpsertLoopFilterSettings :one
+INSERT INTO loop_filter_settings (project_id, banned_words, banned_word_action, max_links, link_spam_action, repeat_limit, repeat_action)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (project_id) DO UPDATE SET
+banned_words = EXCLUDED.banned_words,
+banned_word_action = EXCLUDED.banned_word_action,
+max_links = EXCLUDED.max_links,
+link_spam_action = EXCLUDED.link_spam_action,
+repeat_limit = EXCLUDED.repeat_limit,
+repeat_action = EXCLUDED.repeat_action,
+updated_at = NOW()
+RETURNING *;
+
+-- name: CreateFilteredMessage :exec
+INSERT INTO filtered_messages (id, project_id, channel_id, sender_id, parent_id, content, rule, action, detail)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+
+-- name: GetFilteredMessageByID :one
+SELECT * FROM filtered_messages WHERE id = $1 LIMIT 1;
+
+-- name: GetLoopFilteredMessages :many
+SELECT f.id, f.channel_id, f.sender_id, f.parent_id, f.content, f.rule, f.action, f.detail, f.status, f.created_at,
+    u.username AS sender_username
+FROM filtered_messages f
+JOIN users u ON f.sender_id = u.id
+WHERE f.project_id = $1 AND f.status = $2
+ORDER BY f.created_at ASC
+LIMIT $3;
+
+-- name: ReviewFilteredMessage :execrows
+-- Zero rows means another moderator got there first
+UPDATE filtered_messages
+SET status = $2, reviewed_by = $3, reviewed_at = NOW()
+WHERE id = $1 AND status = 'pending';
+
+-- ============================================================================
+-- ADMIN IMPERSONATION
+-- ============================================================================
+
+-- name: CreateImpersonationSession :one
+INSERT INTO impersonation_sessions (user_id, admin, reason, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetImpersonationSession :one
+SELECT * FROM impersonation_sessions WHERE id = $1 LIMIT 1;
+
+-- name: LogImpersonatedRequest :exec
+INSERT INTO impersonation_requests (session_id, method, path, status)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetUserImpersonationSessions :many
+SELECT s.id, s.admin, s.reason, s.expires_at, s.created_at,
+    COUNT(r.id) AS request_count
+FROM impersonation_sessions s
+LEFT JOIN impersonation_requests r ON r.session_id = s.id
+WHERE s.user_id = $1
+GROUP BY s.id
+ORDER BY s.created_at DESC
+LIMIT 100;
+
+-- name: GetImpersonationRequests :many
+SELECT * FROM impersonation_requests
+WHERE session_id = $1
+ORDER BY created_at ASC
+LIMIT 1000;
+
+-- ============================================================================
+-- FEATURE FLAGS
+-- ============================================================================
+
+-- name: ListFeatureFlags :many
+SELECT * FROM feature_flags ORDER BY key;
+
+-- name: UpsertFeatureFlag :one
+INSERT INTO feature_flags (key, description, enabled, rollout_percent, allow_users, allow_loops)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (key) DO UPDATE SET
+description = EXCLUDED.description,
+enabled = EXCLUDED.enabled,
+rollout_percent = EXCLUDED.rollout_percent,
+allow_users = EXCLUDED.allow_users,
+allow_loops = EXCLUDED.allow_loops,
+updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteFeatureFlag :execrows
+DELETE FROM feature_flags WHERE key = $1;
+
+-- ============================================================================
+-- PR COMMENT STORE
+-- ============================================================================
+
+-- name: ListPRComments :many
+SELECT * FROM pr_comments
+WHERE repo_id = $1 AND pr_number = $2
+ORDER BY github_created_at, comment_id;
+
+-- name: UpsertPRComment :execrows
+-- Older fetches never overwrite newer data; unchanged rows report 0 affected
+INSERT INTO pr_comments (
+    repo_id, pr_number, comment_type, comment_id, body, path, line, diff_hunk,
+    in_reply_to_id, state, username, avatar_url, html_url, github_created_at, github_updated_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+ON CONFLICT (repo_id, pr_number, comment_type, comment_id) DO UPDATE SET
+    body = EXCLUDED.body,
+    path = EXCLUDED.path,
+    line = EXCLUDED.line,
+    diff_hunk = EXCLUDED.diff_hunk,
+    in_reply_to_id = EXCLUDED.in_reply_to_id,
+    state = EXCLUDED.state,
+    username = EXCLUDED.username,
+    avatar_url = EXCLUDED.avatar_url,
+    html_url = EXCLUDED.html_url,
+    github_updated_at = EXCLUDED.github_updated_at,
+    synced_at = NOW()
+WHERE pr_comments.github_updated_at < EXCLUDED.github_updated_at
+   OR (pr_comments.github_updated_at = EXCLUDED.github_updated_at
+       AND (pr_comments.body, pr_comments.state, pr_comments.path, pr_comments.line)
+           IS DISTINCT FROM (EXCLUDED.body, EXCLUDED.state, EXCLUDED.path, EXCLUDED.line));
+
+-- name: PrunePRComments :execrows
+-- Drops comments that no longer exist on GitHub
+DELETE FROM pr_comments
+WHERE repo_id = $1 AND pr_number = $2 AND comment_type = $3
+  AND NOT (comment_id = ANY($4::bigint[]));
+
+-- name: GetPRCommentSync :one
+SELECT * FROM pr_comment_syncs WHERE repo_id = $1 AND pr_number = $2;
+
+-- name: ClaimPRCommentRefresh :execrows
+-- Returns 1 when the caller won the right to refresh this PR
+INSERT INTO pr_comment_syncs (repo_id, pr_number) VALUES ($1, $2)
+ON CONFLICT (repo_id, pr_number) DO UPDATE SET claimed_at = NOW()
+WHERE pr_comment_syncs.claimed_at < $3;
+
+-- name: MarkPRCommentsSynced :exec
+INSERT INTO pr_comment_syncs (repo_id, pr_number, synced_at) VALUES ($1, $2, NOW())
+ON CONFLICT (repo_id, pr_number) DO UPDATE SET synced_at = NOW();
+
+-- ============================================================================
+-- GITHUB WEBHOOKS
+-- ============================================================================
+
+-- name: GetProjectsByGithubRepoID :many
+SELECT * FROM projects WHERE github_repo_id = $1;
+
+-- name: DeletePRComment :exec
+DELETE FROM pr_comments
+WHERE repo_id = $1 AND pr_number = $2 AND comment_type = $3 AND comment_id = $4;
+
+-- ============================================================================
+-- CHANNEL GITHUB LINKS
+-- ============================================================================
+
+-- name: CreateChannelGithubLink :one
+INSERT INTO channel_github_links (channel_id, project_id, github_number, kind, cross_post, linked_by)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: GetChannelGithubLink :one
+SELECT * FROM channel_github_links WHERE channel_id = $1;
+
+-- name: GetProjectChannelGithubLinks :many
+SELECT * FROM channel_github_links WHERE project_id = $1;
+
+-- name: GetActiveChannelGithubLinks :many
+SELECT * FROM channel_github_links
+WHERE project_id = $1 AND github_number = $2 AND archived_at IS NULL;
+
+-- name: ArchiveChannelGithubLinks :many
+UPDATE channel_github_links SET archived_at = NOW()
+WHERE project_id = $1 AND github_number = $2 AND archived_at IS NULL
+RETURNING *;
+
+-- name: LinkMessageToGithubComment :execrows
+INSERT INTO message_github_comments (message_id, comment_id)
+VALUES ($1, $2)
+ON CONFLICT DO NOTHING;
+
+-- name: IsGithubCommentInChannel :one
+SELECT EXISTS(
+    SELECT 1 FROM message_github_comments g
+    JOIN messages m ON m.id = g.message_id
+    WHERE g.comment_id = $1 AND m.channel_id = $2
+);
+
+-- name: IsMessageCrossPosted :one
+SELECT EXISTS(SELECT 1 FROM message_github_comments WHERE message_id = $1);
+
+-- ============================================================================
+-- LOOP GITHUB SETTINGS
+-- ============================================================================
+
+-- name: GetLoopGithubSettings :one
+SELECT * FROM loop_github_settings WHERE project_id = $1;
+
+-- name: UpsertLoopGithubSettings :one
+INSERT INTO loop_github_settings (project_id, announcements_channel_id, deploy_channel_id, production_environment, first_pr_channel_id, first_pr_template)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (project_id) DO UPDATE SET
+announcements_channel_id = EXCLUDED.announcements_channel_id,
+deploy_channel_id = EXCLUDED.deploy_channel_id,
+production_environment = EXCLUDED.production_environment,
+first_pr_channel_id = EXCLUDED.first_pr_channel_id,
+first_pr_template = EXCLUDED.first_pr_template,
+updated_at = NOW()
+RETURNING *;
+
+-- ============================================================================
+-- WORKFLOW DISPATCHES
+-- ============================================================================
+
+-- name: CreateWorkflowDispatch :exec
+INSERT INTO workflow_dispatches (run_id, project_id, channel_id, user_id, workflow, ref, html_url)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (run_id) DO NOTHING;
+
+-- name: CompleteWorkflowDispatch :one
+-- Only the first completion wins, so redelivered webhooks don't post twice
+UPDATE workflow_dispatches
+SET conclusion = $2, completed_at = NOW()
+WHERE run_id = $1 AND completed_at IS NULL
+RETURNING *;
+
+-- ============================================================================
+-- WELCOMES AND BADGES
+-- ============================================================================
+
+-- name: RecordLoopWelcome :execrows
+-- 0 rows means the member was welcomed before
+INSERT INTO loop_welcomes (project_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (project_id, user_id) DO NOTHING;
+
+-- name: AwardMemberBadge :execrows
+-- 0 rows means the badge was already held
+INSERT INTO member_badges (project_id, user_id, badge)
+VALUES ($1, $2, $3)
+ON CONFLICT (project_id, user_id, badge) DO NOTHING;
+
+-- ============================================================================
+-- ONBOARDING
+-- ============================================================================
+
+-- name: ListOnboardingSteps :many
+SELECT * FROM onboarding_steps
+WHERE project_id = $1
+ORDER BY position, created_at;
+
+-- name: GetOnboardingStep :one
+SELECT * FROM onboarding_steps WHERE id = $1;
+
+-- name: CreateOnboardingStep :one
+INSERT INTO onboarding_steps (project_id, title, description, kind, channel_id, position)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: UpdateOnboardingStep :execrows
+-- project_id in the WHERE clause ignores steps from other loops
+UPDATE onboarding_steps
+SET title = $3, description = $4, kind = $5, channel_id = $6, position = $7
+WHERE id = $1 AND project_id = $2;
+
+-- name: DeleteOnboardingStepsExcept :exec
+DELETE FROM onboarding_steps
+WHERE project_id = $1 AND NOT (id = ANY($2::uuid[]));
+
+-- name: CompleteOnboardingStep :execrows
+INSERT INTO onboarding_progress (step_id, user_id)
+VALUES ($1, $2)
+ON CONFLICT (step_id, user_id) DO NOTHING;
+
+-- name: UncompleteOnboardingStep :exec
+DELETE FROM onboarding_progress WHERE step_id = $1 AND user_id = $2;
+
+-- name: CompleteOnboardingStepsByKind :execrows
+-- Ticks off automatic steps; channel_id narrows post_in_channel steps to the channel posted in
+INSERT INTO onboarding_progress (step_id, user_id)
+SELECT s.id, sqlc.arg(user_id)::uuid FROM onboarding_steps s
+WHERE s.project_id = sqlc.arg(project_id) AND s.kind = sqlc.arg(kind)
+  AND (sqlc.narg(channel_id)::uuid IS NULL OR s.channel_id = sqlc.narg(channel_id))
+ON CONFLICT (step_id, user_id) DO NOTHING;
+
+-- name: GetMemberOnboarding :many
+SELECT s.id, s.title, s.description, s.kind, s.channel_id, s.position, p.completed_at
+FROM onboarding_steps s
+LEFT JOIN onboarding_progress p ON p.step_id = s.id AND p.user_id = $2
+WHERE s.project_id = $1
+ORDER BY s.position, s.created_at;
+
+-- name: GetLoopOnboardingProgress :many
+SELECT u.id, u.username, u.avatar_url, mem.joined_at,
+    (SELECT COUNT(*) FROM onboarding_progress p
+     JOIN onboarding_steps s ON s.id = p.step_id
+     WHERE s.project_id = mem.project_id AND p.user_id = u.id) AS completed
+FROM memberships mem
+JOIN users u ON u.id = mem.user_id
+WHERE mem.project_id = $1
+ORDER BY mem.joined_at DESC;
+
+-- ============================================================================
+-- LOOP SETTINGS
+-- ============================================================================
+
+-- name: GetLoopSettings :one
+SELECT * FROM loop_settings WHERE project_id = $1;
+
+-- name: UpsertLoopSettings :one
+INSERT INTO loop_settings (project_id, welcome_channel_id, welcome_template, welcome_dm, visibility, public_channel_ids, guest_channel_ids, pin_role, pin_limit, digest_channel_id, default_notify_level, thread_summaries, ai_disabled, ai_model, ai_max_tokens, ai_temperature, ai_safety, ai_content, access_revoked, ai_language, ai_tone)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21)
+ON CONFLICT (project_id) DO UPDATE SET
+welcome_channel_id = EXCLUDED.welcome_channel_id,
+welcome_template = EXCLUDED.welcome_template,
+welcome_dm = EXCLUDED.welcome_dm,
+visibility = EXCLUDED.visibility,
+public_channel_ids = EXCLUDED.public_channel_ids,
+guest_channel_ids = EXCLUDED.guest_channel_ids,
+pin_role = EXCLUDED.pin_role,
+pin_limit = EXCLUDED.pin_limit,
+digest_channel_id = EXCLUDED.digest_channel_id,
+default_notify_level = EXCLUDED.default_notify_level,
+thread_summaries = EXCLUDED.thread_summaries,
+ai_disabled = EXCLUDED.ai_disabled,
+ai_model = EXCLUDED.ai_model,
+ai_max_tokens = EXCLUDED.ai_max_tokens,
+ai_temperature = EXCLUDED.ai_temperature,
+ai_safety = EXCLUDED.ai_safety,
+ai_content = EXCLUDED.ai_content,
+access_revoked = EXCLUDED.access_revoked,
+ai_language = EXCLUDED.ai_language,
+ai_tone = EXCLUDED.ai_tone,
+updated_at = NOW()
+RETURNING *;
+
+-- name: LockGithubRepo :exec
+-- Serializes loop creation per repository until the transaction ends
+SELECT pg_advisory_xact_lock($1);
+
+-- ============================================================================
+-- LOOP INVITES
+-- ============================================================================
+
+-- name: CreateLoopInvite :one
+INSERT INTO loop_invites (code, project_id, role, created_by, max_uses, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListLoopInvites :many
+SELECT * FROM loop_invites
+WHERE project_id = $1
+ORDER BY created_at DESC;
+
+-- name: DeleteLoopInvite :execrows
+DELETE FROM loop_invites WHERE code = $1 AND project_id = $2;
+
+-- name: RedeemLoopInvite :one
+-- Consumes one use; no row means the code is unknown, expired or used up
+UPDATE loop_invites
+SET uses = uses + 1
+WHERE code = $1
+  AND (expires_at IS NULL OR expires_at > NOW())
+  AND (max_uses IS NULL OR uses < max_uses)
+RETURNING *;
+
+-- name: SetMembershipRole :execrows
+UPDATE memberships SET role = $3
+WHERE user_id = $1 AND project_id = $2;
+
+-- name: DeleteRulesByProject :exec
+DELETE FROM rules WHERE project_id = $1;
+
+-- ============================================================================
+-- SESSIONS
+-- ============================================================================
+
+-- name: CreateSession :one
+INSERT INTO sessions (user_id, user_agent, ip, expires_at)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListUserSessions :many
+SELECT * FROM sessions
+WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+ORDER BY last_seen_at DESC;
+
+-- name: RevokeSession :execrows
+UPDATE sessions SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
+
+-- name: IsSessionActive :one
+SELECT EXISTS (
+    SELECT 1 FROM sessions
+    WHERE id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+);
+
+-- name: TouchSession :exec
+UPDATE sessions SET last_seen_at = NOW() WHERE id = $1;
+
+-- ============================================================================
+-- API KEYS
+-- ============================================================================
+
+-- name: CreateAPIKey :one
+INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, rate_limit)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING *;
+
+-- name: ListUserAPIKeys :many
+SELECT * FROM api_keys
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: GetActiveAPIKeyByHash :one
+SELECT * FROM api_keys
+WHERE key_hash = $1 AND revoked_at IS NULL;
+
+-- name: RevokeAPIKey :one
+UPDATE api_keys SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING key_hash;
+
+-- name: TouchAPIKey :exec
+UPDATE api_keys SET last_used_at = NOW() WHERE id = $1;
+
+-- ============================================================================
+-- PERSONAL ACCESS TOKENS
+-- ============================================================================
+
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (user_id, name, prefix, token_hash, expires_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListUserPersonalAccessTokens :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: GetPersonalAccessToken :one
+SELECT * FROM personal_access_tokens WHERE id = $1;
+
+-- name: GetActivePersonalAccessTokenByHash :one
+SELECT * FROM personal_access_tokens
+WHERE token_hash = $1 AND revoked_at IS NULL AND expires_at > NOW();
+
+-- name: RevokePersonalAccessToken :one
+UPDATE personal_access_tokens SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+RETURNING token_hash;
+
+-- name: TouchPersonalAccessToken :exec
+UPDATE personal_access_tokens SET last_used_at = NOW() WHERE id = $1;
+
+-- name: LogPersonalAccessTokenRequest :exec
+INSERT INTO personal_access_token_requests (token_id, method, path, status)
+VALUES ($1, $2, $3, $4);
+
+-- name: GetPersonalAccessTokenUsage :many
+-- Requests per route, for the token's audit view
+SELECT method, path, COUNT(*)::bigint AS hits, MAX(created_at)::timestamptz AS last_hit_at
+FROM personal_access_token_requests
+WHERE token_id = $1
+GROUP BY method, path
+ORDER BY hits DESC;
+
+-- name: GetMessagesBefore :many
+-- Newest top-level messages older than before (and newer than after, when set)
+SELECT
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.seq,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.channel_id = sqlc.arg(channel_id)
+  AND m.parent_id IS NULL
+  AND m.id < sqlc.arg(before)
+  AND (sqlc.narg(after)::bigint IS NULL OR m.id > sqlc.narg(after))
+ORDER BY m.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetMessagesAfter :many
+-- Oldest top-level messages newer than after (and older than before, when set)
+SELECT
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.seq,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    COALESCE(m.is_deleted, FALSE)::bool AS is_deleted
+FROM messages m
+JOIN users u ON m.sender_id = u.id
+WHERE m.channel_id = sqlc.arg(channel_id)
+  AND m.parent_id IS NULL
+  AND m.id > sqlc.arg(after)
+  AND (sqlc.narg(before)::bigint IS NULL OR m.id < sqlc.narg(before))
+ORDER BY m.id ASC
+LIMIT sqlc.arg(row_limit);
+
+-- ============================================================================
+-- MESSAGE REACTIONS
+-- ============================================================================
+
+-- name: AddMessageReaction :execrows
+INSERT INTO message_reactions (message_id, user_id, emoji)
+VALUES ($1, $2, $3)
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveMessageReaction :execrows
+DELETE FROM message_reactions
+WHERE message_id = $1 AND user_id = $2 AND emoji = $3;
+
+-- name: CountOtherReactionEmoji :one
+-- Distinct emoji on the message besides the given one
+SELECT COUNT(DISTINCT emoji)::int FROM message_reactions
+WHERE message_id = $1 AND emoji <> $2;
+
+-- name: GetReactionSummaries :many
+-- Per-emoji counts for a page of messages, in the order each emoji was
+-- first used, and whether the viewer is among the reactors
+SELECT
+    message_id,
+    emoji,
+    COUNT(*)::int AS count,
+    BOOL_OR(user_id = sqlc.narg(viewer_id)) AS reacted
+FROM message_reactions
+WHERE message_id = ANY(sqlc.arg(message_ids)::bigint[])
+GROUP BY message_id, emoji
+ORDER BY message_id, MIN(created_at);
+
+-- ============================================================================
+-- CUSTOM EMOJI
+-- ============================================================================
+
+-- name: CreateLoopEmoji :one
+INSERT INTO loop_emoji (project_id, name, image_path, created_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (project_id, name) DO NOTHING
+RETURNING *;
+
+-- name: ListLoopEmoji :many
+SELECT * FROM loop_emoji WHERE project_id = $1 ORDER BY name;
+
+-- name: CountLoopEmoji :one
+SELECT COUNT(*)::int FROM loop_emoji WHERE project_id = $1;
+
+-- name: DeleteLoopEmoji :one
+DELETE FROM loop_emoji WHERE project_id = $1 AND name = $2
+RETURNING image_path;
+
+-- name: GetLoopPinnedMessages :many
+-- Pins across a loop's channels (all of them when channel_ids is NULL),
+-- newest pin first, paged by the (pinned_at, id) of the last one seen
+SELECT
+    m.id,
+    m.content,
+    m.created_at,
+    m.sender_id,
+    m.channel_id,
+    m.parent_id,
+    m.reply_count,
+    m.pinned_at,
+    u.username AS sender_username,
+    u.avatar_url AS sender_avatar,
+    pinner.username AS pinned_by_username,
+    ch.name AS channel_name
+FROM messages m
+JOIN channels ch ON m.channel_id = ch.id
+JOIN users u ON m.sender_id = u.id
+LEFT JOIN users pinner ON m.pinned_by = pinner.id
+WHERE ch.project_id = sqlc.arg(project_id)
+  AND m.is_pinned = TRUE
+  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+  AND (sqlc.narg(channel_ids)::uuid[] IS NULL OR m.channel_id = ANY(sqlc.narg(channel_ids)::uuid[]))
+  AND (sqlc.narg(before_pinned_at)::timestamptz IS NULL
+       OR (m.pinned_at, m.id) < (sqlc.narg(before_pinned_at)::timestamptz, sqlc.narg(before_id)::bigint))
+ORDER BY m.pinned_at DESC, m.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: CountChannelPins :one
+SELECT COUNT(*)::int FROM messages
+WHERE channel_id = $1 AND is_pinned = TRUE AND (is_deleted = FALSE OR is_deleted IS NULL);
+
+-- name: GetOldestChannelPin :one
+SELECT id FROM messages
+WHERE channel_id = $1 AND is_pinned = TRUE AND (is_deleted = FALSE OR is_deleted IS NULL)
+ORDER BY pinned_at ASC, id ASC
+LIMIT 1;
+
+-- name: LogPinEvent :exec
+INSERT INTO pin_events (project_id, channel_id, message_id, actor_id, action)
+VALUES ($1, $2, $3, $4, $5);
+
+-- name: ListPinEvents :many
+-- A loop's pin audit trail, newest first
+SELECT
+    e.id,
+    e.channel_id,
+    e.message_id,
+    e.action,
+    e.created_at,
+    u.username AS actor_username,
+    ch.name AS channel_name
+FROM pin_events e
+LEFT JOIN users u ON e.actor_id = u.id
+LEFT JOIN channels ch ON e.channel_id = ch.id
+WHERE e.project_id = sqlc.arg(project_id)
+  AND (sqlc.narg(before_id)::bigint IS NULL OR e.id < sqlc.narg(before_id))
+ORDER BY e.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetLoopWidgetStats :one
+-- Member count and last-7-days message volume for the embeddable widget
+SELECT
+    (SELECT COUNT(*) FROM memberships WHERE project_id = $1)::bigint AS member_count,
+    (SELECT COUNT(*) FROM messages m
+     JOIN channels c ON m.channel_id = c.id
+     WHERE c.project_id = $1
+       AND m.created_at > NOW() - INTERVAL '7 days'
+       AND (m.is_deleted = FALSE OR m.is_deleted IS NULL))::bigint AS messages_this_week;
+
+-- ============================================================================
+-- LOOP ANALYTICS ROLLUPS
+-- ============================================================================
+
+-- name: DeleteLoopMemberDayStats :exec
+DELETE FROM loop_member_daily_stats WHERE day = $1;
+
+-- name: RollupLoopMemberDay :exec
+-- Per-member message counts for one UTC day; run after DeleteLoopMemberDayStats
+INSERT INTO loop_member_daily_stats (project_id, user_id, day, messages)
+SELECT m.project_id, m.sender_id, sqlc.arg(day)::date, COUNT(*)::int
+FROM messages m
+WHERE m.created_at >= (sqlc.arg(day)::date)::timestamp AT TIME ZONE 'UTC'
+  AND m.created_at < (sqlc.arg(day)::date + 1)::timestamp AT TIME ZONE 'UTC'
+  AND m.project_id IS NOT NULL
+  AND m.sender_id IS NOT NULL
+  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+GROUP BY m.project_id, m.sender_id
+ON CONFLICT (project_id, day, user_id) DO UPDATE SET messages = EXCLUDED.messages;
+
+-- name: RollupLoopDay :exec
+-- Loop totals for one UTC day, from memberships and that day's member rollup
+INSERT INTO loop_daily_stats (project_id, day, members, new_members, messages, active_members)
+SELECT
+    p.id,
+    sqlc.arg(day)::date,
+    COUNT(mb.user_id) FILTER (WHERE mb.joined_at < (sqlc.arg(day)::date + 1)::timestamp AT TIME ZONE 'UTC')::int,
+    COUNT(mb.user_id) FILTER (WHERE mb.joined_at >= (sqlc.arg(day)::date)::timestamp AT TIME ZONE 'UTC' AND mb.joined_at < (sqlc.arg(day)::date + 1)::timestamp AT TIME ZONE 'UTC')::int,
+    COALESCE(act.messages, 0)::int,
+    COALESCE(act.active, 0)::int
+FROM projects p
+LEFT JOIN memberships mb ON mb.project_id = p.id
+LEFT JOIN (
+    SELECT project_id, SUM(messages) AS messages, COUNT(*) AS active
+    FROM loop_member_daily_stats
+    WHERE day = sqlc.arg(day)::date
+    GROUP BY project_id
+) act ON act.project_id = p.id
+WHERE p.created_at < (sqlc.arg(day)::date + 1)::timestamp AT TIME ZONE 'UTC'
+GROUP BY p.id, act.messages, act.active
+ON CONFLICT (project_id, day) DO UPDATE SET
+    members = EXCLUDED.members,
+    new_members = EXCLUDED.new_members,
+    messages = EXCLUDED.messages,
+    active_members = EXCLUDED.active_members;
+
+-- name: GetLatestLoopStatsDay :one
+SELECT MAX(day)::date FROM loop_daily_stats;
+
+-- name: GetOwnerLoopDailyStats :many
+SELECT s.project_id, s.day, s.members, s.new_members, s.messages, s.active_members
+FROM loop_daily_stats s
+JOIN projects p ON p.id = s.project_id
+WHERE p.owner_id = $1 AND s.day >= $2
+ORDER BY s.project_id, s.day;
+
+-- name: GetOwnerLoopActiveMembers :many
+-- Distinct senders per owned loop over the last 7 and 30 days
+SELECT
+    s.project_id,
+    COUNT(DISTINCT s.user_id) FILTER (WHERE s.day > CURRENT_DATE - 7)::int AS active_7d,
+    COUNT(DISTINCT s.user_id)::int AS active_30d
+FROM loop_member_daily_stats s
+JOIN projects p ON p.id = s.project_id
+WHERE p.owner_id = $1 AND s.day > CURRENT_DATE - 30
+GROUP BY s.project_id;
+
+-- name: GetOwnerTopContributors :many
+-- The most active senders of each owned loop since a day
+SELECT t.project_id, t.user_id, u.username, u.avatar_url, t.messages
+FROM (
+    SELECT
+        s.project_id,
+        s.user_id,
+        SUM(s.messages)::int AS messages,
+        ROW_NUMBER() OVER (PARTITION BY s.project_id ORDER BY SUM(s.messages) DESC, s.user_id) AS rank
+    FROM loop_member_daily_stats s
+    JOIN projects p ON p.id = s.project_id
+    WHERE p.owner_id = sqlc.arg(owner_id) AND s.day >= sqlc.arg(since)
+    GROUP BY s.project_id, s.user_id
+) t
+JOIN users u ON u.id = t.user_id
+WHERE t.rank <= sqlc.arg(per_loop)
+ORDER BY t.project_id, t.messages DESC;
+
+-- ============================================================================
+-- USAGE QUOTAS
+-- ============================================================================
+
+-- name: GetAccountQuota :one
+SELECT * FROM account_quotas WHERE user_id = $1;
+
+-- name: UpsertAccountQuota :one
+INSERT INTO account_quotas (user_id, max_loops, messages_per_day, storage_bytes, note, updated_at)
+VALUES ($1, $2, $3, $4, $5, NOW())
+ON CONFLICT (user_id) DO UPDATE SET
+    max_loops = EXCLUDED.max_loops,
+    messages_per_day = EXCLUDED.messages_per_day,
+    storage_bytes = EXCLUDED.storage_bytes,
+    note = EXCLUDED.note,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteAccountQuota :execrows
+DELETE FROM account_quotas WHERE user_id = $1;
+
+-- name: CountLoopsBilledTo :one
+SELECT COUNT(*) FROM projects p
+LEFT JOIN workspaces w ON w.id = p.workspace_id
+WHERE COALESCE(w.billing_owner_id, p.owner_id) = $1;
+
+-- name: GetLoopMessageUsage :one
+-- The account the loop is billed to and how many messages it has received since a time
+SELECT
+    COALESCE(w.billing_owner_id, p.owner_id)::uuid AS owner_id,
+    (SELECT COUNT(*) FROM messages m
+     WHERE m.project_id = p.id AND m.created_at >= sqlc.arg(since))::bigint AS messages
+FROM projects p
+LEFT JOIN workspaces w ON w.id = p.workspace_id
+WHERE p.id = sqlc.arg(project_id);
+
+-- name: GetLoopStorageUsage :one
+-- The account the loop is billed to and the bytes its attachments take up
+SELECT
+    COALESCE(w.billing_owner_id, p.owner_id)::uuid AS owner_id,
+    COALESCE((SELECT SUM(a.size_bytes) FROM attachments a WHERE a.project_id = p.id), 0)::bigint AS bytes
+FROM projects p
+LEFT JOIN workspaces w ON w.id = p.workspace_id
+WHERE p.id = $1;
+
+-- ============================================================================
+-- WORKSPACES
+-- ============================================================================
+
+-- name: CreateWorkspace :one
+INSERT INTO workspaces (slug, name, billing_owner_id, personal, default_role)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: GetWorkspaceByID :one
+SELECT * FROM workspaces WHERE id = $1;
+
+-- name: GetWorkspaceBySlug :one
+SELECT * FROM workspaces WHERE slug = $1;
+
+-- name: GetPersonalWorkspace :one
+SELECT * FROM workspaces WHERE billing_owner_id = $1 AND personal;
+
+-- name: UpdateWorkspace :one
+UPDATE workspaces
+SET name = $2, default_role = $3, billing_owner_id = $4
+WHERE id = $1
+RETURNING *;
+
+-- name: GetUserWorkspaces :many
+SELECT w.id, w.slug, w.name, w.billing_owner_id, w.personal, w.default_role, w.created_at, wm.role
+FROM workspace_members wm
+JOIN workspaces w ON w.id = wm.workspace_id
+WHERE wm.user_id = $1
+ORDER BY w.personal DESC, w.name;
+
+-- name: GetWorkspaceMember :one
+SELECT * FROM workspace_members WHERE workspace_id = $1 AND user_id = $2;
+
+-- name: GetWorkspaceMembers :many
+SELECT u.id, u.username, u.avatar_url, wm.role, wm.joined_at
+FROM workspace_members wm
+JOIN users u ON u.id = wm.user_id
+WHERE wm.workspace_id = $1
+ORDER BY wm.joined_at;
+
+-- name: UpsertWorkspaceMember :exec
+INSERT INTO workspace_members (workspace_id, user_id, role)
+VALUES ($1, $2, $3)
+ON CONFLICT (workspace_id, user_id) DO UPDATE SET role = EXCLUDED.role;
+
+-- name: RemoveWorkspaceMember :execrows
+DELETE FROM workspace_members WHERE workspace_id = $1 AND user_id = $2;
+
+-- name: GetWorkspaceProjects :many
+SELECT * FROM projects WHERE workspace_id = $1 ORDER BY name;
+
+-- name: AddWorkspaceMemberToLoops :exec
+-- Makes a workspace member a member of each of its loops they aren't in yet
+INSERT INTO memberships (user_id, project_id, role, notify_level)
+SELECT wm.user_id, p.id, CASE WHEN wm.role IN ('owner', 'admin') THEN 'moderator' ELSE w.default_role END,
+    COALESCE(ls.default_notify_level, 'mentions')
+FROM workspace_members wm
+JOIN workspaces w ON w.id = wm.workspace_id
+JOIN projects p ON p.workspace_id = w.id
+LEFT JOIN loop_settings ls ON ls.project_id = p.id
+WHERE wm.workspace_id = $1 AND wm.user_id = $2
+ON CONFLICT (user_id, project_id) DO NOTHING;
+
+-- name: AddWorkspaceMembersToLoop :exec
+-- Makes every member of the loop's workspace a member of the loop
+INSERT INTO memberships (user_id, project_id, role, notify_level)
+SELECT wm.user_id, p.id, CASE WHEN wm.role IN ('owner', 'admin') THEN 'moderator' ELSE w.default_role END,
+    COALESCE(ls.default_notify_level, 'mentions')
+FROM projects p
+JOIN workspaces w ON w.id = p.workspace_id
+JOIN workspace_members wm ON wm.workspace_id = w.id
+LEFT JOIN loop_settings ls ON ls.project_id = p.id
+WHERE p.id = $1
+ON CONFLICT (user_id, project_id) DO NOTHING;
+
+-- name: RemoveWorkspaceMemberFromLoops :exec
+-- Removes a departing member from the workspace's loops, except those they own
+DELETE FROM memberships mem
+USING projects p
+WHERE mem.project_id = p.id
+  AND p.workspace_id = $1
+  AND mem.user_id = $2
+  AND p.owner_id IS DISTINCT FROM mem.user_id;
+
+-- ============================================================================
+-- LOCALE
+-- ============================================================================
+
+-- name: GetUserLocale :one
+SELECT locale FROM user_settings WHERE user_id = $1;
+
+-- name: SetUserLocale :exec
+INSERT INTO user_settings (user_id, locale)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET locale = EXCLUDED.locale, updated_at = NOW();
+
+-- ============================================================================
+-- TIMEZONE
+-- ============================================================================
+
+-- name: GetUserTimezone :one
+SELECT timezone FROM user_settings WHERE user_id = $1;
+
+-- name: SetUserTimezone :exec
+INSERT INTO user_settings (user_id, timezone)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE SET timezone = EXCLUDED.timezone, updated_at = NOW();
+
+-- ============================================================================
+-- MEMBER ACTIVITY
+-- ============================================================================
+
+-- name: TouchMemberActivity :exec
+UPDATE memberships SET last_active_at = NOW()
+WHERE user_id = $1 AND project_id = $2;
+
+-- name: GetLoopInactivity :one
+-- Members not active for 30 and 90 days; members who never were count from joining
+SELECT
+    COUNT(*)::int AS members,
+    COUNT(*) FILTER (WHERE COALESCE(last_active_at, joined_at) < NOW() - INTERVAL '30 days')::int AS inactive_30d,
+    COUNT(*) FILTER (WHERE COALESCE(last_active_at, joined_at) < NOW() - INTERVAL '90 days')::int AS inactive_90d
+FROM memberships
+WHERE project_id = $1;
+
+-- name: GetInactiveMembers :many
+-- Members last active before a time, longest idle first
+SELECT u.id, u.username, u.avatar_url, m.role, m.joined_at, m.last_active_at
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+WHERE m.project_id = sqlc.arg(project_id) AND COALESCE(m.last_active_at, m.joined_at) < sqlc.arg(before)
+ORDER BY COALESCE(m.last_active_at, m.joined_at), u.username
+LIMIT sqlc.arg(row_limit) OFFSET sqlc.arg(row_offset);
+
+-- name: GetOwnerLoopInactiveMembers :many
+-- Inactive member counts per owned loop
+SELECT
+    m.project_id,
+    COUNT(*) FILTER (WHERE COALESCE(m.last_active_at, m.joined_at) < NOW() - INTERVAL '30 days')::int AS inactive_30d,
+    COUNT(*) FILTER (WHERE COALESCE(m.last_active_at, m.joined_at) < NOW() - INTERVAL '90 days')::int AS inactive_90d
+FROM memberships m
+JOIN projects p ON p.id = m.project_id
+WHERE p.owner_id = $1
+GROUP BY m.project_id;
+
+-- ============================================================================
+-- LOOP GITHUB TOKENS
+-- ============================================================================
+
+-- name: GetLoopGithubToken :one
+SELECT * FROM loop_github_tokens WHERE project_id = $1;
+
+-- name: SetLoopGithubToken :one
+INSERT INTO loop_github_tokens (project_id, source, installation_id, updated_by)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (project_id) DO UPDATE SET
+source = EXCLUDED.source,
+installation_id = EXCLUDED.installation_id,
+updated_by = EXCLUDED.updated_by,
+updated_at = NOW()
+RETURNING *;
+
+-- name: PurgeDeletedMessages :execrows
+-- Replies keep a deleted parent as their thread's tombstone
+DELETE FROM messages
+WHERE is_deleted = TRUE
+  AND deleted_at < sqlc.arg(deleted_before)
+  AND NOT EXISTS (SELECT 1 FROM messages r WHERE r.parent_id = messages.id);
+
+-- ============================================================================
+-- TOP MESSAGES DIGEST
+-- ============================================================================
+
+-- name: GetTopReactedMessages :many
+-- A loop's messages since a time with the most reactions
+SELECT m.id, m.channel_id, c.name AS channel_name, m.content, u.username AS sender_username, m.created_at,
+    COUNT(*)::int AS reactions
+FROM messages m
+JOIN message_reactions r ON r.message_id = m.id
+JOIN channels c ON c.id = m.channel_id
+JOIN users u ON u.id = m.sender_id
+WHERE m.project_id = sqlc.arg(project_id)
+  AND m.created_at >= sqlc.arg(since)
+  AND COALESCE(m.is_deleted, FALSE) = FALSE
+GROUP BY m.id, c.name, u.username
+ORDER BY reactions DESC, m.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetTopRepliedMessages :many
+-- A loop's messages since a time with the most thread replies
+SELECT m.id, m.channel_id, c.name AS channel_name, m.content, u.username AS sender_username, m.created_at,
+    COALESCE(m.reply_count, 0)::int AS reply_count
+FROM messages m
+JOIN channels c ON c.id = m.channel_id
+JOIN users u ON u.id = m.sender_id
+WHERE m.project_id = sqlc.arg(project_id)
+  AND m.created_at >= sqlc.arg(since)
+  AND m.reply_count > 0
+  AND COALESCE(m.is_deleted, FALSE) = FALSE
+ORDER BY m.reply_count DESC, m.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: ClaimDueLoopDigests :many
+-- Marks every loop whose digest hasn't been posted since week_start as
+-- posted and returns them, so concurrent instances each get different loops
+UPDATE loop_settings SET digest_posted_at = NOW()
+WHERE digest_channel_id IS NOT NULL
+  AND (digest_posted_at IS NULL OR digest_posted_at < sqlc.arg(week_start))
+RETURNING project_id, digest_channel_id;
+
+-- ============================================================================
+-- GITHUB ITEM SUBSCRIPTIONS
+-- ============================================================================
+
+-- name: SubscribeGithubItem :one
+INSERT INTO github_item_subscriptions (project_id, user_id, github_number, kind, channel_id)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (project_id, github_number, user_id) DO UPDATE SET
+kind = EXCLUDED.kind,
+channel_id = EXCLUDED.channel_id
+RETURNING *;
+
+-- name: UnsubscribeGithubItem :execrows
+DELETE FROM github_item_subscriptions
+WHERE project_id = $1 AND github_number = $2 AND user_id = $3;
+
+-- name: GetUserGithubItemSubscriptions :many
+SELECT * FROM github_item_subscriptions
+WHERE project_id = $1 AND user_id = $2
+ORDER BY github_number DESC;
+
+-- name: GetGithubItemSubscribers :many
+SELECT s.user_id, s.channel_id, u.username, u.github_id
+FROM github_item_subscriptions s
+JOIN users u ON u.id = s.user_id
+WHERE s.project_id = $1 AND s.github_number = $2;
+
+-- ============================================================================
+-- WEBHOOK DELIVERIES
+-- ============================================================================
+
+-- name: RecordWebhookDelivery :exec
+-- A repeated delivery id is a retry or redelivery and counts as another attempt
+INSERT INTO webhook_deliveries (delivery_id, event, action, repo_id, payload, status, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+ON CONFLICT (delivery_id) DO UPDATE SET
+status = EXCLUDED.status,
+error = EXCLUDED.error,
+attempts = webhook_deliveries.attempts + 1,
+last_attempt_at = NOW();
+
+-- name: GetRepoWebhookDeliveries :many
+SELECT * FROM webhook_deliveries
+WHERE repo_id = sqlc.arg(repo_id)
+  AND (NOT sqlc.arg(failed_only)::bool OR status = 'failed')
+ORDER BY last_attempt_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetRepoWebhookStats :one
+SELECT
+    COUNT(*)::int AS deliveries,
+    COUNT(*) FILTER (WHERE status = 'failed')::int AS failed,
+    COALESCE(SUM(attempts - 1), 0)::int AS retries,
+    MAX(last_attempt_at)::timestamptz AS last_delivery_at,
+    MAX(last_attempt_at) FILTER (WHERE status = 'failed')::timestamptz AS last_failure_at
+FROM webhook_deliveries
+WHERE repo_id = $1 AND last_attempt_at >= $2;
+
+-- name: GetWebhookDelivery :one
+SELECT * FROM webhook_deliveries WHERE id = $1;
+
+-- name: PruneWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries WHERE received_at < $1;
+
+-- ============================================================================
+-- MESSAGE SEARCH
+-- ============================================================================
+
+-- name: SearchMessages :many
+-- Messages containing pattern (a LIKE pattern, wildcards escaped by the
+-- caller) in the loops the user belongs to. Visibility is decided here, not
+-- by the caller: guests only match their loop's guest channels, so counts
+-- and keyset pages never include what the user can't read.
+SELECT m.id, m.content, m.created_at, m.project_id, p.name AS loop_name, m.channel_id, c.name AS channel_name,
+    m.sender_id, u.username AS sender_username, u.avatar_url AS sender_avatar
+FROM messages m
+JOIN memberships mem ON mem.project_id = m.project_id AND mem.user_id = sqlc.arg(user_id)
+JOIN projects p ON p.id = m.project_id
+JOIN channels c ON c.id = m.channel_id
+JOIN users u ON u.id = m.sender_id
+LEFT JOIN loop_settings ls ON ls.project_id = m.project_id
+WHERE m.content ILIKE '%' || sqlc.arg(pattern) || '%'
+  AND COALESCE(m.is_deleted, FALSE) = FALSE
+  AND (mem.role IS DISTINCT FROM 'guest' OR m.channel_id = ANY(COALESCE(ls.guest_channel_ids, '{}')))
+  AND (sqlc.narg(project_id)::uuid IS NULL OR m.project_id = sqlc.narg(project_id))
+  AND (sqlc.narg(before_id)::bigint IS NULL OR m.id < sqlc.narg(before_id))
+ORDER BY m.id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- ============================================================================
+-- AUTOCOMPLETE
+-- ============================================================================
+
+-- name: AutocompleteMembers :many
+-- Members whose username or display name starts with prefix: those the
+-- viewer last notified (mentions, replies) first, then the recently active
+SELECT u.id, u.username, u.avatar_url, u.display_name
+FROM memberships mem
+JOIN users u ON u.id = mem.user_id
+LEFT JOIN LATERAL (
+    SELECT MAX(n.created_at) AS at
+    FROM notifications n
+    WHERE n.user_id = u.id AND n.actor_id = sqlc.arg(viewer_id) AND n.project_id = mem.project_id
+) mentioned ON TRUE
+WHERE mem.project_id = sqlc.arg(project_id)
+  AND (u.username ILIKE sqlc.arg(prefix) || '%' OR u.display_name ILIKE sqlc.arg(prefix) || '%')
+ORDER BY mentioned.at DESC NULLS LAST, COALESCE(mem.last_active_at, mem.joined_at) DESC, u.username
+LIMIT sqlc.arg(row_limit);
+
+-- name: AutocompleteChannels :many
+-- Channels whose name starts with prefix, those the viewer posted in most
+-- recently first. With restricted, only allowed_ids (a guest's channels).
+SELECT c.id, c.name, c.description
+FROM channels c
+LEFT JOIN LATERAL (
+    SELECT MAX(m.created_at) AS at
+    FROM messages m
+    WHERE m.channel_id = c.id AND m.sender_id = sqlc.arg(viewer_id)
+) posted ON TRUE
+WHERE c.project_id = sqlc.arg(project_id)
+  AND c.name ILIKE sqlc.arg(prefix) || '%'
+  AND (NOT sqlc.arg(restricted)::bool OR c.id = ANY(sqlc.arg(allowed_ids)::uuid[]))
+ORDER BY posted.at DESC NULLS LAST, c.position, c.name
+LIMIT sqlc.arg(row_limit);
+
+-- name: GetRecentReactionEmoji :many
+-- The emoji a user reacted with in a loop over the last 30 days, most used first
+SELECT r.emoji, COUNT(*)::int AS uses
+FROM message_reactions r
+JOIN messages m ON m.id = r.message_id
+WHERE r.user_id = $1 AND m.project_id = $2 AND r.created_at > NOW() - INTERVAL '30 days'
+GROUP BY r.emoji
+ORDER BY uses DESC, MAX(r.created_at) DESC
+LIMIT 50;
+
+-- ============================================================================
+-- CHANNEL READ STATE
+-- ============================================================================
+
+-- name: GetChannelActivity :many
+-- Per-channel activity for a member's sidebar in one pass: the latest
+-- top-level message and how many top-level messages from others and unread
+-- mentions came after the member's read mark (or their join, before they
+-- first read the channel). Unread counts stop at unread_cap. Covers every
+-- loop of the user unless project_id is set; guests only get their channels.
+SELECT c.project_id, c.id AS channel_id,
+    last.id AS last_message_id, last.created_at AS last_message_at,
+    last.content AS last_message_content, last.sender_username AS last_message_sender,
+    COALESCE(unread.n, 0)::int AS unread_count, COALESCE(mentioned.n, 0)::int AS mention_count
+FROM memberships mem
+JOIN channels c ON c.project_id = mem.project_id
+LEFT JOIN loop_settings ls ON ls.project_id = mem.project_id
+LEFT JOIN channel_reads r ON r.user_id = mem.user_id AND r.channel_id = c.id
+LEFT JOIN LATERAL (
+    SELECT m.id, m.created_at, m.content, u.username AS sender_username
+    FROM messages m
+    LEFT JOIN users u ON u.id = m.sender_id
+    WHERE m.channel_id = c.id AND m.parent_id IS NULL AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+    ORDER BY m.id DESC
+    LIMIT 1
+) last ON TRUE
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n FROM (
+        SELECT 1 FROM messages m
+        WHERE m.channel_id = c.id AND m.parent_id IS NULL AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+          AND m.sender_id IS DISTINCT FROM mem.user_id
+          AND CASE WHEN r.last_read_id IS NULL THEN m.created_at > mem.joined_at ELSE m.id > r.last_read_id END
+        LIMIT sqlc.arg(unread_cap)
+    ) capped
+) unread ON TRUE
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n FROM mentions mn
+    WHERE mn.user_id = mem.user_id AND mn.channel_id = c.id AND mn.is_read = FALSE
+      AND CASE WHEN r.last_read_id IS NULL THEN mn.created_at > mem.joined_at ELSE mn.message_id > r.last_read_id END
+) mentioned ON TRUE
+WHERE mem.user_id = sqlc.arg(user_id)
+  AND (sqlc.narg(project_id)::uuid IS NULL OR mem.project_id = sqlc.narg(project_id))
+  AND (mem.role IS DISTINCT FROM 'guest' OR c.id = ANY(COALESCE(ls.guest_channel_ids, '{}')))
+ORDER BY c.project_id, c.position, c.name;
+
+-- name: MarkChannelRead :one
+-- Moves the user's read mark in a channel up to message_id, or to the
+-- channel's latest message when it's null, and returns it. The mark never
+-- moves back.
+INSERT INTO channel_reads (user_id, channel_id, last_read_id)
+VALUES (sqlc.arg(user_id), sqlc.arg(channel_id), COALESCE(
+    sqlc.narg(message_id)::bigint,
+    (SELECT MAX(id) FROM messages WHERE channel_id = sqlc.arg(channel_id)),
+    0
+))
+ON CONFLICT (user_id, channel_id) DO UPDATE
+SET last_read_id = GREATEST(channel_reads.last_read_id, EXCLUDED.last_read_id),
+    updated_at = NOW()
+RETURNING last_read_id;
+
+-- ============================================================================
+-- TYPING & PRESENCE PRIVACY
+-- ============================================================================
+
+-- name: GetPresencePrivacy :one
+SELECT send_typing, show_presence FROM user_settings WHERE user_id = $1;
+
+-- name: SetPresencePrivacy :exec
+INSERT INTO user_settings (user_id, send_typing, show_presence)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id) DO UPDATE
+SET send_typing = EXCLUDED.send_typing, show_presence = EXCLUDED.show_presence, updated_at = NOW();
+
+-- ============================================================================
+-- LOOP INTEGRATIONS
+-- ============================================================================
+
+-- name: CreateLoopIntegration :one
+INSERT INTO loop_integrations (project_id, type, name, config, topics, enabled, credentials_ref, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetLoopIntegration :one
+SELECT * FROM loop_integrations WHERE id = $1;
+
+-- name: GetLoopIntegrations :many
+SELECT * FROM loop_integrations WHERE project_id = $1 ORDER BY created_at;
+
+-- name: GetEnabledLoopIntegrations :many
+SELECT * FROM loop_integrations WHERE project_id = $1 AND enabled = TRUE;
+
+-- name: UpdateLoopIntegration :one
+UPDATE loop_integrations
+SET name = $2, config = $3, topics = $4, enabled = $5, credentials_ref = $6, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteLoopIntegration :execrows
+DELETE FROM loop_integrations WHERE id = $1;
+
+-- name: AddIntegrationAudit :exec
+INSERT INTO integration_audit (project_id, integration_id, integration_name, actor_id, action, detail)
+VALUES ($1, $2, $3, $4, $5, $6);
+
+-- name: GetIntegrationAudit :many
+-- A loop's integration audit trail, newest first, optionally for one integration
+SELECT a.id, a.integration_id, a.integration_name, a.action, a.detail, a.created_at,
+       u.username AS actor_username
+FROM integration_audit a
+LEFT JOIN users u ON u.id = a.actor_id
+WHERE a.project_id = sqlc.arg(project_id)
+  AND (sqlc.narg(integration_id)::uuid IS NULL OR a.integration_id = sqlc.narg(integration_id))
+ORDER BY a.created_at DESC, a.id DESC
+LIMIT sqlc.arg(max_rows);
+
+-- ============================================================================
+-- LOOP SECRETS
+-- ============================================================================
+
+-- name: CreateLoopSecret :one
+INSERT INTO loop_secrets (project_id, name, ciphertext, nonce, wrapped_key, key_id, hint, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING *;
+
+-- name: GetLoopSecret :one
+SELECT * FROM loop_secrets WHERE id = $1;
+
+-- name: GetLoopSecretByName :one
+SELECT * FROM loop_secrets WHERE project_id = $1 AND name = $2;
+
+-- name: GetLoopSecrets :many
+SELECT * FROM loop_secrets WHERE project_id = $1 ORDER BY name;
+
+-- name: RotateLoopSecret :one
+UPDATE loop_secrets
+SET ciphertext = $2, nonce = $3, wrapped_key = $4, key_id = $5, hint = $6, rotated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: GetLoopSecretsNotUnderKey :many
+SELECT * FROM loop_secrets WHERE key_id <> $1;
+
+-- name: RewrapLoopSecret :exec
+-- Skips a secret rotated since it was read, whose new data key is already current
+UPDATE loop_secrets SET wrapped_key = sqlc.arg(wrapped_key), key_id = sqlc.arg(key_id)
+WHERE id = sqlc.arg(id) AND key_id = sqlc.arg(old_key_id);
+
+-- name: DeleteLoopSecret :execrows
+DELETE FROM loop_secrets WHERE id = $1;
+
+-- name: CountIntegrationsUsingCredential :one
+SELECT COUNT(*) FROM loop_integrations WHERE project_id = $1 AND credentials_ref = $2;
+
+-- ============================================================================
+-- GITHUB ACTIVITY DIGESTS
+-- ============================================================================
+
+-- name: GetLoopGithubDigest :one
+SELECT * FROM loop_github_digests WHERE project_id = $1;
+
+-- name: UpsertLoopGithubDigest :one
+INSERT INTO loop_github_digests (project_id, channel_id, frequency, send_time, timezone, weekday, sections, enabled, updated_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (project_id) DO UPDATE SET
+channel_id = EXCLUDED.channel_id,
+frequency = EXCLUDED.frequency,
+send_time = EXCLUDED.send_time,
+timezone = EXCLUDED.timezone,
+weekday = EXCLUDED.weekday,
+sections = EXCLUDED.sections,
+enabled = EXCLUDED.enabled,
+updated_by = EXCLUDED.updated_by,
+updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteLoopGithubDigest :execrows
+DELETE FROM loop_github_digests WHERE project_id = $1;
+
+-- name: MarkLoopGithubDigestPosted :exec
+UPDATE loop_github_digests SET last_posted_at = $2 WHERE project_id = $1;
+
+-- ============================================================================
+-- MESSAGE GITHUB ENTITIES
+-- ============================================================================
+
+-- name: UpsertMessageGithubEntity :exec
+INSERT INTO message_github_entities (message_id, text_offset, text_length, kind, number, sha, title, state, url)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (message_id, text_offset) DO UPDATE SET
+text_length = EXCLUDED.text_length,
+kind = EXCLUDED.kind,
+number = EXCLUDED.number,
+sha = EXCLUDED.sha,
+title = EXCLUDED.title,
+state = EXCLUDED.state,
+url = EXCLUDED.url,
+resolved_at = NOW();
+
+-- name: GetMessageGithubEntities :many
+SELECT * FROM message_github_entities
+WHERE message_id = ANY(sqlc.arg(message_ids)::bigint[])
+ORDER BY message_id, text_offset;
+
+-- ============================================================================
+-- CHANNEL TOPICS AND BANNERS
+-- ============================================================================
+
+-- name: GetChannelTopic :one
+SELECT * FROM channel_topics WHERE channel_id = $1;
+
+-- name: GetProjectChannelTopics :many
+SELECT * FROM channel_topics WHERE project_id = $1;
+
+-- name: SetChannelTopic :one
+INSERT INTO channel_topics (channel_id, project_id, topic, topic_set_by, topic_set_at)
+VALUES ($1, $2, $3, $4, NOW())
+ON CONFLICT (channel_id) DO UPDATE SET
+topic = EXCLUDED.topic,
+topic_set_by = EXCLUDED.topic_set_by,
+topic_set_at = NOW()
+RETURNING *;
+
+-- name: SetChannelBanner :one
+-- An empty banner clears it
+INSERT INTO channel_topics (channel_id, project_id, banner, banner_set_by, banner_set_at, banner_expires_at)
+VALUES ($1, $2, $3, $4, NOW(), $5)
+ON CONFLICT (channel_id) DO UPDATE SET
+banner = EXCLUDED.banner,
+banner_set_by = EXCLUDED.banner_set_by,
+banner_set_at = NOW(),
+banner_expires_at = EXCLUDED.banner_expires_at
+RETURNING *;
+
+-- ============================================================================
+-- QUICK SWITCHER
+-- ============================================================================
+
+-- name: QuickSwitchLoops :many
+-- The switcher's candidates are matched with pattern (an ILIKE pattern, '%'
+-- for everything) and come with how often the user posted there in the last
+-- 30 days (uses) and when they last posted or read there (last_used_at), for
+-- frecency ranking.
+SELECT p.id, p.name,
+    COALESCE(posted.n, 0)::int AS uses,
+    GREATEST(posted.at, read.at)::timestamptz AS last_used_at
+FROM memberships mem
+JOIN projects p ON p.id = mem.project_id
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n, MAX(m.created_at) AS at
+    FROM messages m
+    WHERE m.project_id = p.id AND m.sender_id = mem.user_id AND m.created_at > NOW() - INTERVAL '30 days'
+) posted ON TRUE
+LEFT JOIN LATERAL (
+    SELECT MAX(r.updated_at) AS at
+    FROM channel_reads r
+    JOIN channels c ON c.id = r.channel_id
+    WHERE r.user_id = mem.user_id AND c.project_id = p.id
+) read ON TRUE
+WHERE mem.user_id = sqlc.arg(user_id)
+  AND p.name ILIKE sqlc.arg(pattern)
+ORDER BY last_used_at DESC NULLS LAST, p.name
+LIMIT sqlc.arg(row_limit);
+
+-- name: QuickSwitchChannels :many
+-- Guests only get their channels
+SELECT c.id, c.name, c.project_id, p.name AS project_name,
+    COALESCE(posted.n, 0)::int AS uses,
+    GREATEST(posted.at, r.updated_at)::timestamptz AS last_used_at
+FROM memberships mem
+JOIN projects p ON p.id = mem.project_id
+JOIN channels c ON c.project_id = mem.project_id
+LEFT JOIN loop_settings ls ON ls.project_id = mem.project_id
+LEFT JOIN channel_reads r ON r.user_id = mem.user_id AND r.channel_id = c.id
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n, MAX(m.created_at) AS at
+    FROM messages m
+    WHERE m.channel_id = c.id AND m.sender_id = mem.user_id AND m.created_at > NOW() - INTERVAL '30 days'
+) posted ON TRUE
+WHERE mem.user_id = sqlc.arg(user_id)
+  AND c.name ILIKE sqlc.arg(pattern)
+  AND (mem.role IS DISTINCT FROM 'guest' OR c.id = ANY(COALESCE(ls.guest_channel_ids, '{}')))
+ORDER BY last_used_at DESC NULLS LAST, c.name
+LIMIT sqlc.arg(row_limit);
+
+-- name: QuickSwitchDMs :many
+-- Matches a group's name or the other participant's username
+SELECT dc.id, dc.is_bot, dc.is_group, dc.name, u.username AS other_username, u.avatar_url AS other_avatar,
+    COALESCE(posted.n, 0)::int AS uses,
+    GREATEST(posted.at, p.last_read_at)::timestamptz AS last_used_at
+FROM dm_participants p
+JOIN dm_conversations dc ON dc.id = p.conversation_id
+LEFT JOIN dm_participants op ON op.conversation_id = dc.id AND op.user_id <> p.user_id AND NOT dc.is_group
+LEFT JOIN users u ON u.id = op.user_id
+LEFT JOIN LATERAL (
+    SELECT COUNT(*) AS n, MAX(m.created_at) AS at
+    FROM dm_messages m
+    WHERE m.conversation_id = dc.id AND m.sender_id = p.user_id AND m.created_at > NOW() - INTERVAL '30 days'
+) posted ON TRUE
+WHERE p.user_id = sqlc.arg(user_id)
+  AND (dc.status = 'active' OR dc.requested_by = p.user_id)
+  AND (COALESCE(dc.name, '') ILIKE sqlc.arg(pattern) OR COALESCE(u.username, '') ILIKE sqlc.arg(pattern) OR dc.is_bot)
+ORDER BY last_used_at DESC NULLS LAST
+LIMIT sqlc.arg(row_limit);
+
+-- name: QuickSwitchThreads :many
+-- Threads the user started or replied to in the last 30 days, in channels
+-- they can still read, matched on the parent message's text
+SELECT parent.id, parent.content, parent.channel_id, c.name AS channel_name, p.name AS project_name,
+    COALESCE(parent.reply_count, 0)::int AS reply_count,
+    COUNT(*)::int AS uses,
+    MAX(m.created_at)::timestamptz AS last_used_at
+FROM messages m
+JOIN messages parent ON parent.id = COALESCE(m.parent_id, m.id)
+JOIN channels c ON c.id = parent.channel_id
+JOIN projects p ON p.id = parent.project_id
+JOIN memberships mem ON mem.project_id = parent.project_id AND mem.user_id = m.sender_id
+LEFT JOIN loop_settings ls ON ls.project_id = parent.project_id
+WHERE m.sender_id = sqlc.arg(user_id)
+  AND (mem.role IS DISTINCT FROM 'guest' OR c.id = ANY(COALESCE(ls.guest_channel_ids, '{}')))
+  AND m.created_at > NOW() - INTERVAL '30 days'
+  AND (m.parent_id IS NOT NULL OR m.reply_count > 0)
+  AND (parent.is_deleted = FALSE OR parent.is_deleted IS NULL)
+  AND parent.content ILIKE sqlc.arg(pattern)
+GROUP BY parent.id, c.name, p.name
+ORDER BY last_used_at DESC
+LIMIT sqlc.arg(row_limit);
+
+-- ============================================================================
+-- NOTIFICATION DELIVERY
+-- ============================================================================
+
+-- name: StartNotificationDelivery :exec
+-- A batched notification pushed again waits for a new ack
+INSERT INTO notification_deliveries (notification_id, user_id, state, pushed_at)
+VALUES ($1, $2, 'pending', NOW())
+ON CONFLICT (notification_id) DO UPDATE SET
+state = 'pending',
+pushed_at = NOW(),
+acked_at = NULL,
+transport = NULL,
+attempts = 0,
+last_error = NULL,
+updated_at = NOW();
+
+-- name: AckNotificationDelivery :execrows
+-- A late ack is recorded but doesn't undo a fallback already sent
+UPDATE notification_deliveries
+SET acked_at = COALESCE(acked_at, NOW()),
+    state = CASE WHEN state IN ('pending', 'retrying') THEN 'delivered' ELSE state END,
+    updated_at = NOW()
+WHERE notification_id = $1 AND user_id = $2;
+
+-- name: GetNotificationDelivery :one
+SELECT * FROM notification_deliveries WHERE notification_id = $1;
+
+-- name: SetNotificationDeliveryState :execrows
+-- Only while the notification is still waiting, so a racing ack wins
+UPDATE notification_deliveries
+SET state = $2, transport = $3, last_error = $4, attempts = attempts + 1, updated_at = NOW()
+WHERE notification_id = $1 AND state IN ('pending', 'retrying');
+
+-- name: GetNotificationByID :one
+SELECT * FROM notifications WHERE id = $1;
+
+-- name: GetNotificationFallback :one
+SELECT notify_fallback FROM user_settings WHERE user_id = $1;
+
+-- name: SetNotificationFallback :exec
+INSERT INTO user_settings (user_id, notify_fallback)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET notify_fallback = EXCLUDED.notify_fallback, updated_at = NOW();
+
+-- ============================================================================
+-- NOTIFICATION LEVELS
+-- ============================================================================
+
+-- name: SetMemberNotifyLevel :execrows
+UPDATE memberships SET notify_level = $3
+WHERE user_id = $1 AND project_id = $2;
+
+-- name: GetLoopMessageFollowers :many
+-- Members notified of every message in the loop, but the sender
+SELECT user_id FROM memberships
+WHERE project_id = $1 AND notify_level = 'all' AND user_id <> $2;
+
+-- ============================================================================
+-- JOIN VERIFICATION HISTORY
+-- ============================================================================
+
+-- name: CreateVerificationAttempt :one
+INSERT INTO loop_verification_attempts (user_id, project_id, passed, results, source)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING *;
+
+-- name: ListVerificationAttempts :many
+SELECT * FROM loop_verification_attempts
+WHERE user_id = $1 AND project_id = $2
+ORDER BY checked_at DESC
+LIMIT $3;
+
+-- name: GetVerificationBaseline :one
+-- The latest attempt checked before $3, to measure progress against
+SELECT * FROM loop_verification_attempts
+WHERE user_id = $1 AND project_id = $2 AND checked_at < $3
+ORDER BY checked_at DESC
+LIMIT 1;
+
+-- ============================================================================
+-- REPO STATS
+-- ============================================================================
+
+-- name: SeedRepoStats :exec
+-- Adds an empty row for every linked repo that doesn't have one yet
+INSERT INTO repo_stats (repo_id)
+SELECT DISTINCT github_repo_id FROM projects
+ON CONFLICT (repo_id) DO NOTHING;
+
+-- name: ClaimStaleRepoStats :many
+-- Stamps the rows up front so concurrent refreshers pick different repos;
+-- repos no loop links to any more are left alone
+UPDATE repo_stats
+SET checked_at = NOW()
+WHERE repo_id IN (
+    SELECT s.repo_id FROM repo_stats s
+    WHERE (s.checked_at IS NULL OR s.checked_at < $1)
+      AND EXISTS (SELECT 1 FROM projects p WHERE p.github_repo_id = s.repo_id)
+    ORDER BY s.checked_at NULLS FIRST
+    LIMIT $2
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: UpdateRepoStats :exec
+UPDATE repo_stats
+SET full_name = $2, stars = $3, forks = $4, contributors = $5, pushed_at = $6, refreshed_at = NOW()
+WHERE repo_id = $1;
+
+-- name: GetRepoStatsByName :one
+SELECT * FROM repo_stats
+WHERE LOWER(full_name) = LOWER(sqlc.arg(full_name)) AND refreshed_at IS NOT NULL
+LIMIT 1;
+
+-- ============================================================================
+-- REACTION ANALYTICS
+-- ============================================================================
+
+-- name: DeleteLoopReactionDayStats :exec
+DELETE FROM loop_reaction_daily_stats WHERE day = $1;
+
+-- name: RollupLoopReactionDay :exec
+-- Reactions given on one UTC day by loop, emoji and the author reacted to;
+-- run after DeleteLoopReactionDayStats. Reacting to yourself doesn't count.
+INSERT INTO loop_reaction_daily_stats (project_id, day, emoji, author_id, reactions)
+SELECT m.project_id, sqlc.arg(day)::date, r.emoji, m.sender_id, COUNT(*)::int
+FROM message_reactions r
+JOIN messages m ON m.id = r.message_id
+WHERE r.created_at >= (sqlc.arg(day)::date)::timestamp AT TIME ZONE 'UTC'
+  AND r.created_at < (sqlc.arg(day)::date + 1)::timestamp AT TIME ZONE 'UTC'
+  AND m.project_id IS NOT NULL
+  AND m.sender_id IS NOT NULL
+  AND r.user_id <> m.sender_id
+  AND (m.is_deleted = FALSE OR m.is_deleted IS NULL)
+GROUP BY m.project_id, r.emoji, m.sender_id
+ON CONFLICT (project_id, day, emoji, author_id) DO UPDATE SET reactions = EXCLUDED.reactions;
+
+-- name: GetLatestLoopReactionStatsDay :one
+SELECT MAX(day)::date FROM loop_reaction_daily_stats;
+
+-- name: GetLoopTopEmoji :many
+SELECT emoji, SUM(reactions)::int AS reactions
+FROM loop_reaction_daily_stats
+WHERE project_id = $1 AND day >= $2
+GROUP BY emoji
+ORDER BY reactions DESC, emoji
+LIMIT $3;
+
+-- name: GetLoopMostReactedAuthors :many
+SELECT s.author_id, u.username, u.avatar_url, SUM(s.reactions)::int AS reactions
+FROM loop_reaction_daily_stats s
+JOIN users u ON u.id = s.author_id
+WHERE s.project_id = $1 AND s.day >= $2
+GROUP BY s.author_id, u.username, u.avatar_url
+ORDER BY reactions DESC, u.username
+LIMIT $3;
+
+-- name: GetLoopReactionTrend :many
+SELECT day, SUM(reactions)::int AS reactions
+FROM loop_reaction_daily_stats
+WHERE project_id = $1 AND day >= $2
+GROUP BY day
+ORDER BY day;
+
+-- name: ListReactionBadgeEarners :many
+-- Members whose messages have drawn at least threshold reactions in a loop
+-- and who don't hold the badge yet
+SELECT s.project_id, s.author_id
+FROM loop_reaction_daily_stats s
+JOIN memberships mb ON mb.project_id = s.project_id AND mb.user_id = s.author_id
+WHERE NOT EXISTS (
+    SELECT 1 FROM member_badges b
+    WHERE b.project_id = s.project_id AND b.user_id = s.author_id AND b.badge = sqlc.arg(badge)
+)
+GROUP BY s.project_id, s.author_id
+HAVING SUM(s.reactions) >= sqlc.arg(threshold)::int;
+
+-- ============================================================================
+-- LONG POSTS
+-- ============================================================================
+
+-- name: CreateLongPost :exec
+INSERT INTO long_posts (message_id, attachment_id, chars)
+VALUES ($1, $2, $3);
+
+-- name: GetLongPost :one
+-- The stored markdown of a long post, unless its message was deleted
+SELECT lp.message_id, lp.chars, m.project_id, m.channel_id, a.storage_key
+FROM long_posts lp
+JOIN messages m ON m.id = lp.message_id
+JOIN attachments a ON a.id = lp.attachment_id
+WHERE lp.message_id = $1 AND m.is_deleted IS NOT TRUE;
+
+-- name: ListLongPosts :many
+SELECT lp.message_id, lp.chars, a.size_bytes
+FROM long_posts lp
+JOIN attachments a ON a.id = lp.attachment_id
+WHERE lp.message_id = ANY(sqlc.arg(message_ids)::bigint[]);
+
+-- ============================================================================
+-- THREAD SUMMARIES
+-- ============================================================================
+
+-- name: GetThreadSummary :one
+SELECT * FROM thread_summaries WHERE message_id = $1;
+
+-- name: ListThreadSummaries :many
+SELECT * FROM thread_summaries
+WHERE message_id = ANY(sqlc.arg(message_ids)::bigint[]);
+
+-- name: ListThreadRepliesSince :many
+-- Replies after the ones a summary already covers, oldest first
+SELECT m.id, m.content, COALESCE(u.username, '')::text AS username
+FROM messages m
+LEFT JOIN users u ON u.id = m.sender_id
+WHERE m.parent_id = sqlc.arg(parent_id)::bigint
+  AND m.id > sqlc.arg(after)
+  AND m.is_deleted IS NOT TRUE
+ORDER BY m.id
+LIMIT sqlc.arg(row_limit);
+
+-- name: UpsertThreadSummary :one
+INSERT INTO thread_summaries (message_id, summary, replies, last_reply_id)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (message_id) DO UPDATE SET
+summary = EXCLUDED.summary,
+replies = EXCLUDED.replies,
+last_reply_id = EXCLUDED.last_reply_id,
+updated_at = NOW()
+RETURNING *;
+
+-- ============================================================================
+-- INCOMING WEBHOOKS
+-- ============================================================================
+
+-- name: CreateBotUser :one
+-- Bots have no GitHub account or token; negative github_ids keep them apart
+INSERT INTO users (github_id, username, avatar_url, access_token, profile_completed)
+VALUES (-nextval('bot_user_seq'), $1, $2, '', TRUE)
+RETURNING *;
+
+-- name: CreateIncomingWebhook :one
+INSERT INTO incoming_webhooks (project_id, channel_id, bot_id, name, prefix, token_hash, created_by)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ListIncomingWebhooks :many
+SELECT w.*, c.name AS channel_name, u.username AS bot_username, u.avatar_url AS bot_avatar
+FROM incoming_webhooks w
+JOIN channels c ON c.id = w.channel_id
+JOIN users u ON u.id = w.bot_id
+WHERE w.project_id = $1
+ORDER BY w.revoked_at IS NOT NULL, w.created_at DESC;
+
+-- name: GetActiveIncomingWebhookByHash :one
+SELECT * FROM incoming_webhooks WHERE token_hash = $1 AND revoked_at IS NULL;
+
+-- name: TouchIncomingWebhook :exec
+UPDATE incoming_webhooks SET last_used_at = NOW() WHERE id = $1;
+
+-- name: RevokeIncomingWebhook :execrows
+UPDATE incoming_webhooks SET revoked_at = NOW()
+WHERE id = $1 AND project_id = $2 AND revoked_at IS NULL;
+
+-- ============================================================================
+-- MIRROR MODE
+-- ============================================================================
+
+-- name: MarkRepoMirrored :many
+-- Returns the loops whose state changed
+INSERT INTO loop_mirrors (project_id, reason)
+SELECT id, $2 FROM projects WHERE github_repo_id = $1
+ON CONFLICT (project_id) DO UPDATE SET reason = EXCLUDED.reason
+WHERE loop_mirrors.reason <> EXCLUDED.reason
+RETURNING project_id;
+
+-- name: ClearRepoMirrors :many
+-- Loops the owner archived stay as they are until relinked
+DELETE FROM loop_mirrors m
+USING projects p
+WHERE p.id = m.project_id AND p.github_repo_id = $1 AND m.archived_at IS NULL
+RETURNING m.project_id;
+
+-- name: GetLoopMirror :one
+SELECT * FROM loop_mirrors WHERE project_id = $1;
+
+-- name: DeleteLoopMirror :exec
+DELETE FROM loop_mirrors WHERE project_id = $1;
+
+-- name: ArchiveMirroredLoop :one
+UPDATE loop_mirrors SET archived_at = NOW(), archived_by = $2
+WHERE project_id = $1 AND archived_at IS NULL
+RETURNING *;
+
+-- ============================================================================
+-- MESSAGE SEQUENCE NUMBERS
+-- ============================================================================
+
+-- name: NextChannelSeq :one
+INSERT INTO channel_seqs (channel_id, last_seq) VALUES ($1, 1)
+ON CONFLICT (channel_id) DO UPDATE SET last_seq = channel_seqs.last_seq + 1
+RETURNING last_seq;
+
+-- ============================================================================
+-- CHANNEL DATA POLICIES
+-- ============================================================================
+
+-- name: GetChannelDataPolicy :one
+SELECT * FROM channel_data_policies WHERE channel_id = $1;
+
+-- name: GetProjectChannelDataPolicies :many
+SELECT * FROM channel_data_policies WHERE project_id = $1;
+
+-- name: SetChannelDataPolicy :one
+INSERT INTO channel_data_policies (channel_id, project_id, ai_summaries, exports, updated_by)
+VALUES ($1, $2, $3, $4, $5)
+ON CONFLICT (channel_id) DO UPDATE SET
+ai_summaries = EXCLUDED.ai_summaries,
+exports = EXCLUDED.exports,
+updated_by = EXCLUDED.updated_by,
+updated_at = NOW()
+RETURNING *;
+
+-- name: DeleteChannelThreadSummaries :exec
+DELETE FROM thread_summaries
+WHERE message_id IN (SELECT id FROM messages WHERE channel_id = $1);
+
+-- ============================================================================
+-- ACCOUNT MERGES
+-- ============================================================================
+
+-- name: MergeMembershipRoles :exec
+-- In loops both accounts belong to, the survivor keeps the stronger role
+UPDATE memberships s SET role = m.role
+FROM memberships m
+WHERE s.user_id = sqlc.arg(survivor_id) AND m.user_id = sqlc.arg(merged_id)
+  AND s.project_id = m.project_id
+  AND (CASE m.role WHEN 'owner' THEN 3 WHEN 'moderator' THEN 2 WHEN 'guest' THEN 0 ELSE 1 END) >
+      (CASE s.role WHEN 'owner' THEN 3 WHEN 'moderator' THEN 2 WHEN 'guest' THEN 0 ELSE 1 END);
+
+-- name: DeleteOverlappingMemberships :exec
+DELETE FROM memberships m
+WHERE m.user_id = sqlc.arg(merged_id)
+  AND EXISTS (
+    SELECT 1 FROM memberships s
+    WHERE s.user_id = sqlc.arg(survivor_id) AND s.project_id = m.project_id
+  );
+
+-- name: ReassignMemberships :execrows
+UPDATE memberships SET user_id = sqlc.arg(survivor_id) WHERE user_id = sqlc.arg(merged_id);
+
+-- name: ReassignMessages :execrows
+UPDATE messages SET sender_id = sqlc.arg(survivor_id) WHERE sender_id = sqlc.arg(merged_id);
+
+-- name: ReassignNotifications :execrows
+UPDATE notifications SET user_id = sqlc.arg(survivor_id) WHERE user_id = sqlc.arg(merged_id);
+
+-- name: ReassignNotificationActors :exec
+UPDATE notifications SET actor_id = sqlc.arg(survivor_id), actor_username = sqlc.arg(survivor_username)
+WHERE actor_id = sqlc.arg(merged_id);
+
+-- name: TransferOwnedLoops :many
+UPDATE projects SET owner_id = sqlc.arg(survivor_id) WHERE owner_id = sqlc.arg(merged_id)
+RETURNING id, name;
+
+-- name: RevokeUserSessions :many
+UPDATE sessions SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+RETURNING id;
+
+-- name: RevokeUserAPIKeys :many
+UPDATE api_keys SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+RETURNING key_hash;
+
+-- name: RevokeUserPersonalAccessTokens :many
+UPDATE personal_access_tokens SET revoked_at = NOW()
+WHERE user_id = $1 AND revoked_at IS NULL
+RETURNING token_hash;
+
+-- name: CreateAccountMerge :one
+INSERT INTO account_merges (survivor_id, merged_id, merged_username, admin, reason, memberships, messages, notifications, loops)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- ============================================================================
+-- LOST REPO ACCESS
+-- ============================================================================
+
+-- name: GetRepoMembershipsByGithubID :many
+-- The memberships of a GitHub user in the loops linked to a repo
+SELECT m.user_id, m.project_id, m.role, u.username, p.name AS project_name, p.owner_id,
+       COALESCE(ls.access_revoked, 'notify')::text AS access_revoked
+FROM memberships m
+JOIN users u ON u.id = m.user_id
+JOIN projects p ON p.id = m.project_id
+LEFT JOIN loop_settings ls ON ls.project_id = p.id
+WHERE p.github_repo_id = $1 AND u.github_id = $2;
+
+-- ============================================================================
+-- UNDO SEND
+-- ============================================================================
+
+-- name: GetUndoSendSeconds :one
+SELECT undo_send_seconds FROM user_settings WHERE user_id = $1;
+
+-- name: SetUndoSendSeconds :exec
+INSERT INTO user_settings (user_id, undo_send_seconds)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET undo_send_seconds = EXCLUDED.undo_send_seconds, updated_at = NOW();
@@ -110,3 +110,1012 @@ ON notifications (user_id, is_read, created_at DESC);
 
 CREATE INDEX IF NOT EXISTS idx_messages_pinned
 ON messages (channel_id, is_pinned) WHERE is_pinned = TRUE;
+
+-- ============================================================================
+-- Issue Board
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS board_columns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS board_cards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    column_id UUID NOT NULL REFERENCES board_columns(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    body TEXT,
+    github_issue_number INTEGER,
+    github_state TEXT,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE(project_id, github_issue_number)
+);
+
+-- ============================================================================
+-- Tasks
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tasks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    message_id BIGINT REFERENCES messages(id) ON DELETE SET NULL,
+    title TEXT NOT NULL,
+    assignee_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    due_at TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'open',
+    github_issue_number INTEGER,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Job Queue
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS jobs (
+    id BIGSERIAL PRIMARY KEY,
+    type TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    run_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    status TEXT NOT NULL DEFAULT 'queued',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Events + Activity
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT,
+    kind TEXT NOT NULL DEFAULT 'other',
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    recurrence TEXT NOT NULL DEFAULT 'none',
+    recurrence_until TIMESTAMPTZ,
+    remind_minutes INTEGER NOT NULL DEFAULT 15,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS event_rsvps (
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (event_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS loop_activity (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL,
+    ref_id TEXT,
+    summary TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Direct Messages
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS dm_conversations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    dm_key TEXT UNIQUE,
+    is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_message_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS dm_participants (
+    conversation_id UUID NOT NULL REFERENCES dm_conversations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    last_read_at TIMESTAMPTZ,
+    joined_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS dm_messages (
+    id BIGINT PRIMARY KEY,
+    conversation_id UUID NOT NULL REFERENCES dm_conversations(id) ON DELETE CASCADE,
+    sender_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    content TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Standups
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS standups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    questions TEXT[] NOT NULL,
+    prompt_time TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    weekdays INTEGER NOT NULL DEFAULT 62,
+    collect_minutes INTEGER NOT NULL DEFAULT 120,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS standup_participants (
+    standup_id UUID NOT NULL REFERENCES standups(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    PRIMARY KEY (standup_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS standup_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    standup_id UUID NOT NULL REFERENCES standups(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'collecting',
+    started_at TIMESTAMPTZ DEFAULT NOW(),
+    closes_at TIMESTAMPTZ NOT NULL,
+    posted_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS standup_responses (
+    run_id UUID NOT NULL REFERENCES standup_runs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    answers TEXT[] NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (run_id, user_id)
+);
+
+-- ============================================================================
+-- Sidebar Preferences
+-- ============================================================================
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS is_favorite BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS sort_order INTEGER;
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS sidebar_collapsed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- ============================================================================
+-- Mentions (durable inbox, independent of notifications)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS mentions (
+    id BIGINT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    project_id UUID REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    actor_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    is_read BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (message_id, user_id)
+);
+
+-- ============================================================================
+-- Notification Batching
+-- ============================================================================
+ALTER TABLE notifications ADD COLUMN IF NOT EXISTS batch_count INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE mentions ADD COLUMN IF NOT EXISTS notification_id BIGINT REFERENCES notifications(id) ON DELETE SET NULL;
+
+-- ============================================================================
+-- Muted Words
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS user_muted_words (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    word TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_id, word)
+);
+
+-- ============================================================================
+-- User Blocks
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS user_blocks (
+    blocker_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    blocked_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (blocker_id, blocked_id),
+    CHECK (blocker_id <> blocked_id)
+);
+
+-- ============================================================================
+-- DM Privacy + Requests
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS user_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    dm_privacy TEXT NOT NULL DEFAULT 'anyone',
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE dm_conversations ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'active';
+ALTER TABLE dm_conversations ADD COLUMN IF NOT EXISTS requested_by UUID REFERENCES users(id) ON DELETE CASCADE;
+
+-- ============================================================================
+-- Group DMs
+-- ============================================================================
+ALTER TABLE dm_conversations ADD COLUMN IF NOT EXISTS is_group BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE dm_conversations ADD COLUMN IF NOT EXISTS name TEXT;
+ALTER TABLE dm_conversations ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- ============================================================================
+-- Attachments
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    uploader_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    filename TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL,
+    storage_key TEXT NOT NULL,
+    scan_status TEXT NOT NULL DEFAULT 'pending',
+    scan_engine TEXT,
+    scan_signature TEXT,
+    scanned_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- GitHub profile re-sync
+-- ============================================================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS github_synced_at TIMESTAMPTZ;
+
+-- ============================================================================
+-- Username changes
+-- ============================================================================
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username_changed_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS username_history (
+    old_username TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    changed_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Content reporting
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS message_reports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    reporter_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL,
+    details TEXT,
+    status TEXT NOT NULL DEFAULT 'open',
+    resolution TEXT,
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (message_id, reporter_id)
+);
+
+CREATE TABLE IF NOT EXISTS loop_bans (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    banned_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reason TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id)
+);
+
+-- ============================================================================
+-- Message filtering
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS loop_filter_settings (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    banned_words TEXT[] NOT NULL DEFAULT '{}',
+    banned_word_action TEXT NOT NULL DEFAULT 'block',
+    max_links INTEGER NOT NULL DEFAULT 5,
+    link_spam_action TEXT NOT NULL DEFAULT 'hold',
+    repeat_limit INTEGER NOT NULL DEFAULT 3,
+    repeat_action TEXT NOT NULL DEFAULT 'block',
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS filtered_messages (
+    id BIGINT PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE CASCADE,
+    sender_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id BIGINT,
+    content TEXT NOT NULL,
+    rule TEXT NOT NULL,
+    action TEXT NOT NULL,
+    detail TEXT,
+    status TEXT NOT NULL DEFAULT 'pending',
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Admin impersonation
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    admin TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS impersonation_requests (
+    id BIGSERIAL PRIMARY KEY,
+    session_id UUID NOT NULL REFERENCES impersonation_sessions(id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Feature flags
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS feature_flags (
+    key TEXT PRIMARY KEY,
+    description TEXT,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    rollout_percent INTEGER NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+    allow_users UUID[] NOT NULL DEFAULT '{}',
+    allow_loops UUID[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- PR comment store
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS pr_comments (
+    repo_id BIGINT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    comment_type TEXT NOT NULL,
+    comment_id BIGINT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    path TEXT,
+    line INTEGER,
+    diff_hunk TEXT,
+    in_reply_to_id BIGINT,
+    state TEXT,
+    username TEXT NOT NULL,
+    avatar_url TEXT,
+    html_url TEXT NOT NULL DEFAULT '',
+    github_created_at TIMESTAMPTZ NOT NULL,
+    github_updated_at TIMESTAMPTZ NOT NULL,
+    synced_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (repo_id, pr_number, comment_type, comment_id)
+);
+
+CREATE TABLE IF NOT EXISTS pr_comment_syncs (
+    repo_id BIGINT NOT NULL,
+    pr_number INTEGER NOT NULL,
+    synced_at TIMESTAMPTZ,
+    claimed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (repo_id, pr_number)
+);
+
+-- ============================================================================
+-- Channels bound to GitHub issues / PRs
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS channel_github_links (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    github_number INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    cross_post BOOLEAN NOT NULL DEFAULT FALSE,
+    linked_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    archived_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS message_github_comments (
+    message_id BIGINT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    comment_id BIGINT NOT NULL
+);
+
+-- ============================================================================
+-- Loop GitHub integration settings
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS loop_github_settings (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    announcements_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- ============================================================================
+-- Deployment notifications
+-- ============================================================================
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS deploy_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL;
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS production_environment TEXT NOT NULL DEFAULT 'production';
+
+-- ============================================================================
+-- Workflow dispatches started from chat
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS workflow_dispatches (
+    run_id BIGINT PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    workflow TEXT NOT NULL,
+    ref TEXT NOT NULL,
+    html_url TEXT NOT NULL,
+    conclusion TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    completed_at TIMESTAMPTZ
+);
+
+-- ============================================================================
+-- First-time contributor welcome and member badges
+-- ============================================================================
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS welcome_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL;
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS welcome_template TEXT NOT NULL DEFAULT '';
+ALTER TABLE loop_github_settings ADD COLUMN IF NOT EXISTS first_pr_template TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS loop_welcomes (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    welcomed_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS member_badges (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    badge TEXT NOT NULL,
+    awarded_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (project_id, user_id, badge)
+);
+
+-- ============================================================================
+-- Member onboarding checklist
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS onboarding_steps (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    kind TEXT NOT NULL DEFAULT 'manual',
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS onboarding_progress (
+    step_id UUID NOT NULL REFERENCES onboarding_steps(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    completed_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (step_id, user_id)
+);
+
+-- ============================================================================
+-- Loop settings (welcome messages)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS loop_settings (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    welcome_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    welcome_template TEXT NOT NULL DEFAULT '',
+    welcome_dm BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE loop_github_settings DROP COLUMN IF EXISTS welcome_template;
+ALTER TABLE loop_github_settings RENAME COLUMN welcome_channel_id TO first_pr_channel_id;
+
+-- ============================================================================
+-- Duplicate loops per repository (checked by the API, see 037)
+-- ============================================================================
+ALTER TABLE projects DROP CONSTRAINT IF EXISTS projects_github_repo_id_key;
+
+-- ============================================================================
+-- Public read-only loops
+-- ============================================================================
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS visibility TEXT NOT NULL DEFAULT 'members';
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS public_channel_ids UUID[] NOT NULL DEFAULT '{}';
+
+-- ============================================================================
+-- Guest role (invite codes, guest channels)
+-- ============================================================================
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS guest_channel_ids UUID[] NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS loop_invites (
+    code TEXT PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'guest',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    max_uses INTEGER,
+    uses INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_loop_invites_project ON loop_invites (project_id);
+
+-- ============================================================================
+-- Sessions (one per login, revocable)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    last_seen_at TIMESTAMPTZ DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions (user_id, last_seen_at DESC);
+
+-- ============================================================================
+-- Scoped API keys
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    rate_limit INTEGER NOT NULL DEFAULT 60,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user ON api_keys (user_id);
+
+-- ============================================================================
+-- Personal access tokens (+ per-request audit)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user ON personal_access_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS personal_access_token_requests (
+    id BIGSERIAL PRIMARY KEY,
+    token_id UUID NOT NULL REFERENCES personal_access_tokens(id) ON DELETE CASCADE,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    status INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_personal_access_token_requests_token
+ON personal_access_token_requests (token_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_messages_channel_top_level_id
+ON messages (channel_id, id)
+WHERE parent_id IS NULL AND (is_deleted = FALSE OR is_deleted IS NULL);
+
+CREATE TABLE IF NOT EXISTS message_reactions (
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    emoji TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (message_id, user_id, emoji)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_reactions_message
+ON message_reactions (message_id, emoji);
+
+CREATE TABLE IF NOT EXISTS loop_emoji (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    image_path TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS pin_role TEXT NOT NULL DEFAULT 'members';
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS pin_limit INT NOT NULL DEFAULT 50;
+
+CREATE TABLE IF NOT EXISTS pin_events (
+    id BIGSERIAL PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    message_id BIGINT NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pin_events_project
+ON pin_events (project_id, id DESC);
+
+CREATE TABLE IF NOT EXISTS loop_daily_stats (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    members INT NOT NULL DEFAULT 0,
+    new_members INT NOT NULL DEFAULT 0,
+    messages INT NOT NULL DEFAULT 0,
+    active_members INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, day)
+);
+
+CREATE TABLE IF NOT EXISTS loop_member_daily_stats (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    messages INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, day, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_messages_created_at ON messages (created_at);
+
+CREATE TABLE IF NOT EXISTS account_quotas (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    max_loops BIGINT,
+    messages_per_day BIGINT,
+    storage_bytes BIGINT,
+    note TEXT,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS workspaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug TEXT UNIQUE NOT NULL,
+    name TEXT NOT NULL,
+    billing_owner_id UUID NOT NULL REFERENCES users(id),
+    personal BOOLEAN NOT NULL DEFAULT FALSE,
+    default_role TEXT NOT NULL DEFAULT 'contributor',
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_personal
+ON workspaces (billing_owner_id) WHERE personal;
+
+CREATE TABLE IF NOT EXISTS workspace_members (
+    workspace_id UUID NOT NULL REFERENCES workspaces(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member',
+    joined_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (workspace_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user
+ON workspace_members (user_id);
+
+ALTER TABLE projects ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_projects_workspace
+ON projects (workspace_id);
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS locale TEXT;
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS timezone TEXT;
+
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS last_active_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_memberships_last_active
+ON memberships (project_id, (COALESCE(last_active_at, joined_at)));
+
+CREATE TABLE IF NOT EXISTS loop_github_tokens (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    source TEXT NOT NULL DEFAULT 'requester' CHECK (source IN ('requester', 'owner', 'app')),
+    installation_id BIGINT,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (source <> 'app' OR installation_id IS NOT NULL)
+);
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS digest_channel_id UUID REFERENCES channels(id) ON DELETE SET NULL;
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS digest_posted_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS github_item_subscriptions (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    github_number INTEGER NOT NULL,
+    kind TEXT NOT NULL CHECK (kind IN ('issue', 'pr')),
+    channel_id UUID REFERENCES channels(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (project_id, github_number, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_github_item_subscriptions_user
+ON github_item_subscriptions (user_id, project_id);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id TEXT NOT NULL UNIQUE, -- X-GitHub-Delivery; GitHub reuses it on redelivery
+    event TEXT NOT NULL,
+    action TEXT NOT NULL DEFAULT '',
+    repo_id BIGINT NOT NULL DEFAULT 0,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('ok', 'failed', 'ignored')),
+    error TEXT,
+    attempts INT NOT NULL DEFAULT 1,
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_repo
+ON webhook_deliveries (repo_id, last_attempt_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_received
+ON webhook_deliveries (received_at);
+
+CREATE INDEX IF NOT EXISTS idx_messages_content_trgm
+ON messages USING gin (content gin_trgm_ops);
+
+CREATE TABLE IF NOT EXISTS channel_reads (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    last_read_id BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, channel_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_mentions_user_channel
+ON mentions (user_id, channel_id, message_id);
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS send_typing BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS show_presence BOOLEAN NOT NULL DEFAULT TRUE;
+
+CREATE TABLE IF NOT EXISTS loop_integrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    type TEXT NOT NULL,
+    name TEXT NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    topics TEXT[] NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    credentials_ref TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (project_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS integration_audit (
+    id BIGSERIAL PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    integration_id UUID REFERENCES loop_integrations(id) ON DELETE SET NULL,
+    integration_name TEXT NOT NULL,
+    actor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_integration_audit_project
+ON integration_audit (project_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS loop_secrets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    ciphertext BYTEA NOT NULL,
+    nonce BYTEA NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    key_id TEXT NOT NULL,
+    hint TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    rotated_at TIMESTAMPTZ,
+    UNIQUE (project_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loop_secrets_key ON loop_secrets (key_id);
+
+CREATE TABLE IF NOT EXISTS loop_github_digests (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    frequency TEXT NOT NULL DEFAULT 'weekly',
+    send_time TEXT NOT NULL DEFAULT '09:00',
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    weekday INTEGER NOT NULL DEFAULT 1,
+    sections TEXT[] NOT NULL DEFAULT '{issues,pulls,releases,discussions}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    last_posted_at TIMESTAMPTZ,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS message_github_entities (
+    message_id BIGINT NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    text_offset INTEGER NOT NULL,
+    text_length INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    number INTEGER NOT NULL DEFAULT 0,
+    sha TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT '',
+    url TEXT NOT NULL,
+    resolved_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (message_id, text_offset)
+);
+
+CREATE TABLE IF NOT EXISTS channel_topics (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    topic TEXT NOT NULL DEFAULT '',
+    topic_set_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    topic_set_at TIMESTAMPTZ,
+    banner TEXT NOT NULL DEFAULT '',
+    banner_set_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    banner_set_at TIMESTAMPTZ,
+    banner_expires_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_topics_project ON channel_topics (project_id);
+
+CREATE INDEX IF NOT EXISTS idx_messages_sender_created
+ON messages (sender_id, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_dm_messages_sender_created
+ON dm_messages (sender_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    notification_id BIGINT PRIMARY KEY REFERENCES notifications(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    state TEXT NOT NULL DEFAULT 'pending',
+    pushed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    acked_at TIMESTAMPTZ,
+    transport TEXT,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS notify_fallback TEXT[] NOT NULL DEFAULT '{push,email}';
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS default_notify_level TEXT NOT NULL DEFAULT 'mentions';
+ALTER TABLE memberships ADD COLUMN IF NOT EXISTS notify_level TEXT NOT NULL DEFAULT 'mentions';
+
+CREATE INDEX IF NOT EXISTS idx_memberships_notify_all
+ON memberships (project_id) WHERE notify_level = 'all';
+
+CREATE TABLE IF NOT EXISTS loop_verification_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    passed BOOLEAN NOT NULL,
+    results JSONB NOT NULL DEFAULT '[]',
+    source TEXT NOT NULL DEFAULT 'manual',
+    checked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_verification_attempts_user_loop
+ON loop_verification_attempts (user_id, project_id, checked_at DESC);
+
+CREATE TABLE IF NOT EXISTS repo_stats (
+    repo_id BIGINT PRIMARY KEY,
+    full_name TEXT NOT NULL DEFAULT '',
+    stars INTEGER NOT NULL DEFAULT 0,
+    forks INTEGER NOT NULL DEFAULT 0,
+    contributors INTEGER NOT NULL DEFAULT 0,
+    pushed_at TIMESTAMPTZ,
+    refreshed_at TIMESTAMPTZ,
+    checked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_repo_stats_full_name ON repo_stats (LOWER(full_name));
+
+CREATE TABLE IF NOT EXISTS loop_reaction_daily_stats (
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    emoji TEXT NOT NULL,
+    author_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    reactions INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, day, emoji, author_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_loop_reaction_daily_stats_author
+ON loop_reaction_daily_stats (project_id, author_id);
+
+CREATE INDEX IF NOT EXISTS idx_message_reactions_created
+ON message_reactions (created_at);
+
+CREATE TABLE IF NOT EXISTS long_posts (
+    message_id BIGINT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    attachment_id UUID NOT NULL REFERENCES attachments(id) ON DELETE CASCADE,
+    chars INT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS thread_summaries TEXT NOT NULL DEFAULT 'offer';
+
+CREATE TABLE IF NOT EXISTS thread_summaries (
+    message_id BIGINT PRIMARY KEY REFERENCES messages(id) ON DELETE CASCADE,
+    summary TEXT NOT NULL,
+    replies INT NOT NULL,
+    last_reply_id BIGINT NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_disabled BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_model TEXT NOT NULL DEFAULT '';
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_max_tokens INT NOT NULL DEFAULT 0;
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_temperature REAL;
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_safety TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_content TEXT[];
+
+CREATE INDEX IF NOT EXISTS idx_jobs_unfinished
+ON jobs (type, status) WHERE status <> 'done';
+
+CREATE SEQUENCE IF NOT EXISTS bot_user_seq;
+
+CREATE TABLE IF NOT EXISTS incoming_webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    channel_id UUID NOT NULL REFERENCES channels(id) ON DELETE CASCADE,
+    bot_id UUID NOT NULL REFERENCES users(id),
+    name TEXT NOT NULL,
+    prefix TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_incoming_webhooks_project ON incoming_webhooks (project_id);
+
+CREATE TABLE IF NOT EXISTS loop_mirrors (
+    project_id UUID PRIMARY KEY REFERENCES projects(id) ON DELETE CASCADE,
+    reason TEXT NOT NULL CHECK (reason IN ('archived', 'deleted')),
+    since TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    archived_at TIMESTAMPTZ,
+    archived_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS channel_seqs (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    last_seq BIGINT NOT NULL
+);
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS seq BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_channel_seq ON messages (channel_id, seq);
+
+CREATE TABLE IF NOT EXISTS channel_data_policies (
+    channel_id UUID PRIMARY KEY REFERENCES channels(id) ON DELETE CASCADE,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    ai_summaries BOOLEAN NOT NULL DEFAULT TRUE,
+    exports BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_channel_data_policies_project ON channel_data_policies (project_id);
+
+CREATE TABLE IF NOT EXISTS account_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    survivor_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    merged_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    merged_username TEXT NOT NULL,
+    admin TEXT NOT NULL,
+    reason TEXT NOT NULL,
+    memberships INT NOT NULL,
+    messages INT NOT NULL,
+    notifications INT NOT NULL,
+    loops INT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS access_revoked TEXT NOT NULL DEFAULT 'notify';
+
+ALTER TABLE user_settings ADD COLUMN IF NOT EXISTS undo_send_seconds INT NOT NULL DEFAULT 0;
+
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_language TEXT NOT NULL DEFAULT '';
+ALTER TABLE loop_settings ADD COLUMN IF NOT EXISTS ai_tone TEXT NOT NULL DEFAULT '';